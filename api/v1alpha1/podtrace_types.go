@@ -47,9 +47,10 @@ type PodTraceSpec struct {
 }
 
 // NodeStatusReason is the stable enum the agent stamps onto
-// status.nodeStatus[].reason when a node reports unready or a CR rule
-// fails.
-// +kubebuilder:validation:Enum=AgentUnready;BackendUnavailable;BundleLoadFailed;ExporterBuildFailed;ProgramAttachFailed;PolicyParseError;PodMatchFailed;CgroupResolutionFailed;Unknown
+// status.nodeStatus[].reason when a node reports unready, a CR rule
+// fails, the agent backs off under node pressure, or its self watchdog
+// sees it leak.
+// +kubebuilder:validation:Enum=AgentUnready;BackendUnavailable;BundleLoadFailed;ExporterBuildFailed;ProgramAttachFailed;PolicyParseError;PodMatchFailed;CgroupResolutionFailed;NodePressure;SelfDegraded;Unknown
 type NodeStatusReason string
 
 var (
@@ -61,6 +62,8 @@ var (
 	NodeStatusReasonPolicyParseError       = NodeStatusReason("PolicyParseError")
 	NodeStatusReasonPodMatchFailed         = NodeStatusReason("PodMatchFailed")
 	NodeStatusReasonCgroupResolutionFailed = NodeStatusReason("CgroupResolutionFailed")
+	NodeStatusReasonNodePressure           = NodeStatusReason("NodePressure")
+	NodeStatusReasonSelfDegraded           = NodeStatusReason("SelfDegraded")
 	NodeStatusReasonUnknown                = NodeStatusReason("Unknown")
 )
 
@@ -54,6 +54,13 @@ type PodTraceSessionSpec struct {
 	// +optional
 	Filters []EventFilter `json:"filters,omitempty"`
 
+	// ProbeGroups limits the BPF probes the session attaches to those the
+	// listed categories need; empty attaches all of them. Unlike Filters,
+	// which only drops events from the report, it keeps the other probes
+	// from running on the node.
+	// +optional
+	ProbeGroups []EventFilter `json:"probeGroups,omitempty"`
+
 	// +kubebuilder:validation:Required
 	ExporterRef LocalObjectReference `json:"exporterRef"`
 
@@ -71,6 +78,13 @@ type PodTraceSessionSpec struct {
 	// +kubebuilder:validation:Minimum=0
 	// +optional
 	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// RetainAfterFinished keeps a finished session, and the results in its
+	// status, until it is deleted, overriding TTLSecondsAfterFinished. Set
+	// it on sessions applied by a GitOps controller, which would otherwise
+	// re-create the deleted session and run the trace again.
+	// +optional
+	RetainAfterFinished bool `json:"retainAfterFinished,omitempty"`
 }
 
 // SessionJobRef describes a child Job created by the session reconciler.
@@ -161,6 +175,7 @@ type PodTraceSessionStatus struct {
 // +kubebuilder:printcolumn:name="Duration",type=string,JSONPath=`.spec.duration`
 // +kubebuilder:printcolumn:name="Exporter",type=string,JSONPath=`.spec.exporterRef.name`
 // +kubebuilder:printcolumn:name="Events",type=integer,JSONPath=`.status.summary.totalEvents`
+// +kubebuilder:printcolumn:name="Report",type=string,JSONPath=`.status.reportLocation`,priority=1
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // PodTraceSession is a bounded, diagnose-mode trace. Running a session
@@ -810,6 +810,11 @@ func (in *PodTraceSessionSpec) DeepCopyInto(out *PodTraceSessionSpec) {
 		*out = make([]EventFilter, len(*in))
 		copy(*out, *in)
 	}
+	if in.ProbeGroups != nil {
+		in, out := &in.ProbeGroups, &out.ProbeGroups
+		*out = make([]EventFilter, len(*in))
+		copy(*out, *in)
+	}
 	out.ExporterRef = in.ExporterRef
 	if in.Thresholds != nil {
 		in, out := &in.Thresholds, &out.Thresholds
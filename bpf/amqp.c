@@ -0,0 +1,255 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+#include "protocols.h"
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+
+// AMQP 0-9-1 (RabbitMQ) basic-class methods, read from plaintext port-5672
+// sockets and from OpenSSL plaintext (AMQPS on 5671). Each interesting
+// method frame becomes one EVENT_AMQP; pairing deliveries with acks and
+// publishes with confirms is left to userspace, which sees the whole
+// channel.
+//
+// Event layout: tcp_state = class << 16 | method, bytes = delivery tag
+// (consume flags for basic.consume), correlation_id = channel << 48 |
+// connection id, error = 1 for nack and reject.
+
+static __always_inline u64 amqp_be64(const u8 *b)
+{
+	return ((u64)read_be32(b) << 32) | (u64)read_be32(b + 4);
+}
+
+static __always_inline u64 amqp_conn_id(u64 conn)
+{
+	u64 *id = bpf_map_lookup_elem(&amqp_conns, &conn);
+	if (id)
+		return *id;
+	u64 now = bpf_ktime_get_ns() & 0xffffffffffffULL;
+	bpf_map_update_elem(&amqp_conns, &conn, &now, BPF_ANY);
+	return now;
+}
+
+// amqp_read_shortstr copies the short string at p into dst and returns the
+// number of bytes it occupies on the wire, or -1.
+static __always_inline s32 amqp_read_shortstr(u8 *p, char *dst)
+{
+	u8 n = 0;
+	if (bpf_probe_read_user(&n, 1, p) != 0)
+		return -1;
+	u32 c = n;
+	if (c > MAX_STRING_LEN - 1)
+		c = MAX_STRING_LEN - 1;
+	if (c > 0 && bpf_probe_read_user(dst, c & (MAX_STRING_LEN - 1), p + 1) != 0)
+		return -1;
+	dst[c & (MAX_STRING_LEN - 1)] = '\0';
+	return 1 + (s32)n;
+}
+
+static __noinline void amqp_emit_method(void *ctx, u8 *args, u16 channel, u16 class_id,
+					 u16 method_id, u64 conn, u8 dir)
+{
+	u32 pid = bpf_get_current_pid_tgid() >> 32;
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	struct event *e = get_event_buf();
+	if (!e)
+		return;
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = pid;
+	e->type = EVENT_AMQP;
+	e->latency_ns = 0;
+	e->error = 0;
+	e->bytes = 0;
+	e->tcp_state = ((u32)class_id << 16) | method_id;
+	e->correlation_id = ((u64)channel << 48) | amqp_conn_id(conn);
+	e->target[0] = '\0';
+	e->details[0] = '\0';
+
+	u8 tag[9] = {};
+	s32 n;
+	if (class_id == AMQP_CLASS_CONFIRM) {
+		// confirm.select: no arguments worth keeping
+	} else if (method_id == AMQP_BASIC_CONSUME) {
+		// reserved short, queue, consumer-tag, then the no-local/no-ack/
+		// exclusive/no-wait bits, kept in bytes.
+		n = amqp_read_shortstr(args + 2, e->target);
+		if (n < 0)
+			return;
+		s32 m = amqp_read_shortstr(args + 2 + n, e->details);
+		u8 bits = 0;
+		if (m > 0 && bpf_probe_read_user(&bits, 1, args + 2 + n + m) == 0)
+			e->bytes = bits;
+	} else if (method_id == AMQP_BASIC_CONSUME_OK) {
+		amqp_read_shortstr(args, e->target);
+	} else if (method_id == AMQP_BASIC_PUBLISH) {
+		// reserved short, exchange, routing-key
+		n = amqp_read_shortstr(args + 2, e->details);
+		if (n < 0)
+			return;
+		amqp_read_shortstr(args + 2 + n, e->target);
+	} else if (method_id == AMQP_BASIC_DELIVER) {
+		// consumer-tag, delivery-tag, redelivered, exchange, routing-key
+		n = amqp_read_shortstr(args, e->target);
+		if (n < 0 || bpf_probe_read_user(tag, 8, args + n) != 0)
+			return;
+		e->bytes = amqp_be64(tag);
+		// details ends up holding the routing key; the exchange read
+		// into it first only serves to find where the key starts.
+		s32 m = amqp_read_shortstr(args + n + 9, e->details);
+		if (m > 0)
+			amqp_read_shortstr(args + n + 9 + m, e->details);
+	} else {
+		// ack, nack, reject: delivery-tag, then a bit field
+		// (multiple for ack/nack, requeue for reject).
+		if (bpf_probe_read_user(tag, sizeof(tag), args) != 0)
+			return;
+		e->bytes = amqp_be64(tag);
+		e->error = method_id == AMQP_BASIC_ACK ? 0 : 1;
+		u32 pos = 2;
+		if (dir == AMQP_DIR_IN) {
+			__builtin_memcpy(e->details, "in", 3);
+		} else {
+			__builtin_memcpy(e->details, "out", 4);
+			pos = 3;
+		}
+		if (method_id != AMQP_BASIC_REJECT && (tag[8] & 1))
+			__builtin_memcpy(e->details + pos, " multiple", 10);
+	}
+	fill_event_peer(e);
+	capture_user_stack(ctx, pid, tid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+}
+
+static __always_inline int amqp_wanted(u16 class_id, u16 method_id, u8 dir)
+{
+	if (class_id == AMQP_CLASS_CONFIRM)
+		return method_id == AMQP_CONFIRM_SELECT && dir == AMQP_DIR_OUT;
+	if (class_id != AMQP_CLASS_BASIC)
+		return 0;
+	switch (method_id) {
+	case AMQP_BASIC_CONSUME:
+	case AMQP_BASIC_PUBLISH:
+	case AMQP_BASIC_REJECT:
+		return dir == AMQP_DIR_OUT;
+	case AMQP_BASIC_CONSUME_OK:
+	case AMQP_BASIC_DELIVER:
+		return dir == AMQP_DIR_IN;
+	case AMQP_BASIC_ACK:
+	case AMQP_BASIC_NACK:
+		return 1;
+	}
+	return 0;
+}
+
+// amqp_emit_frames walks the frames at the start of a buffer. A publish is
+// followed by content header and body frames, and a delivery read usually
+// carries several messages, so a few frames are walked rather than one.
+static __noinline void amqp_emit_frames(void *ctx, u8 *base, u64 len, u64 conn, u8 dir)
+{
+	u64 off = 0;
+	u32 i;
+	for (i = 0; i < AMQP_FRAME_WALK_MAX; i++) {
+		if (off + AMQP_FRAME_HDR_LEN + 4 > len)
+			return;
+		u8 h[AMQP_FRAME_HDR_LEN + 4] = {};
+		if (bpf_probe_read_user(h, sizeof(h), base + off) != 0)
+			return;
+		u32 size = read_be32(&h[3]);
+		if (h[0] < AMQP_FRAME_METHOD || h[0] > 8 || size >= AMQP_MAX_FRAME_LEN)
+			return;
+		u64 end = off + AMQP_FRAME_HDR_LEN + size;
+		if (end < len) {
+			u8 fe = 0;
+			if (bpf_probe_read_user(&fe, 1, base + end) != 0 || fe != AMQP_FRAME_END)
+				return;
+		}
+		if (h[0] == AMQP_FRAME_METHOD) {
+			u16 class_id = ((u16)h[7] << 8) | h[8];
+			u16 method_id = ((u16)h[9] << 8) | h[10];
+			if (amqp_wanted(class_id, method_id, dir))
+				amqp_emit_method(ctx, base + off + AMQP_FRAME_HDR_LEN + 4,
+						 ((u16)h[1] << 8) | h[2], class_id, method_id, conn, dir);
+		}
+		off = end + 1;
+	}
+}
+
+// amqp_is_method_frame recognises a basic or confirm method frame at the
+// start of a TLS record, for the OpenSSL hooks in http.c.
+static __always_inline int amqp_is_method_frame(const u8 *b)
+{
+	return b[0] == AMQP_FRAME_METHOD && b[3] == 0 && b[7] == 0 &&
+	       (b[8] == AMQP_CLASS_BASIC || b[8] == AMQP_CLASS_CONFIRM);
+}
+
+static __always_inline int amqp_sock_on_port(struct sock *sk)
+{
+	if (!sk)
+		return 0;
+	u16 dport = __builtin_bswap16(BPF_CORE_READ(sk, __sk_common.skc_dport));
+	return dport == AMQP_DEFAULT_PORT;
+}
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_amqp_tcp_sendmsg(struct pt_regs *ctx)
+{
+	struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+	if (!http_should_trace() || !amqp_sock_on_port(sk))
+		return 0;
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	u64 avail = 0;
+	u8 *base = msghdr_user_base(msg, &avail);
+	if (base)
+		amqp_emit_frames(ctx, base, avail, (u64)sk, AMQP_DIR_OUT);
+	return 0;
+}
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_amqp_tcp_recvmsg(struct pt_regs *ctx)
+{
+	struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+	if (!http_should_trace() || !amqp_sock_on_port(sk))
+		return 0;
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	u64 avail = 0;
+	void *base = msghdr_user_base(msg, &avail);
+	if (!base)
+		return 0;
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state st = { .buf = (u64)base, .conn = (u64)sk };
+	bpf_map_update_elem(&amqp_recv_base, &key, &st, BPF_ANY);
+	return 0;
+}
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_amqp_tcp_recvmsg(struct pt_regs *ctx)
+{
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state *st = bpf_map_lookup_elem(&amqp_recv_base, &key);
+	if (!st)
+		return 0;
+	u8 *base = (u8 *)st->buf;
+	u64 sk = st->conn;
+	bpf_map_delete_elem(&amqp_recv_base, &key);
+
+	s32 ret = (s32)PT_REGS_RC(ctx);
+	if (ret <= 0)
+		return 0;
+	amqp_emit_frames(ctx, base, (u64)ret, sk, AMQP_DIR_IN);
+	return 0;
+}
+
+#else
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_amqp_tcp_sendmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_amqp_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_amqp_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+#endif
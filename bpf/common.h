@@ -30,6 +30,7 @@ typedef __s32 s32;
 typedef __u32 u32;
 typedef __s64 s64;
 typedef __u64 u64;
+typedef _Bool bool;
 #endif
 
 #include <bpf/bpf_helpers.h>
@@ -119,6 +120,12 @@ struct trace_event_raw_sys_enter {
 	unsigned long args[6];
 	char __data[0];
 };
+struct trace_event_raw_sys_exit {
+	struct trace_entry ent;
+	long id;
+	long ret;
+	char __data[0];
+};
 
 struct __sk_buff {
 	__u32 len;
@@ -156,6 +163,7 @@ struct __sk_buff {
 #define PAGE_SIZE 4096
 #define MAX_BYTES_THRESHOLD (10ULL * 1024ULL * 1024ULL)
 #define MIN_LATENCY_NS (1ULL * NS_PER_MS)
+#define EVENT_LOOP_LAG_MIN_NS (10ULL * NS_PER_MS)
 
 #define AF_INET 2
 #define AF_INET6 10
@@ -170,6 +178,9 @@ struct podtrace_sockaddr_alg {
 };
 #define IPPROTO_TCP 6
 #define EAGAIN 11
+#define ENOSPC 28
+#define EROFS 30
+#define EDQUOT 122
 #define HEX_ADDR_LEN 16
 #define COMM_LEN 16
 
@@ -196,5 +207,17 @@ struct podtrace_sockaddr_alg {
 #ifndef BPF_F_USER_STACK
 #define BPF_F_USER_STACK 8
 #endif
+#ifndef BPF_MAP_TYPE_PERCPU_HASH
+#define BPF_MAP_TYPE_PERCPU_HASH 5
+#endif
+#ifndef BPF_MAP_TYPE_LPM_TRIE
+#define BPF_MAP_TYPE_LPM_TRIE 11
+#endif
+#ifndef BPF_NOEXIST
+#define BPF_NOEXIST 1
+#endif
+#ifndef BPF_F_NO_PREALLOC
+#define BPF_F_NO_PREALLOC 1
+#endif
 
 #endif
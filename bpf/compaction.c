@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+
+/* Direct compaction runs compact_zone() in the task whose allocation failed,
+ * once per zone it tries, so the begin/end pair is timed per thread and
+ * charged to the allocating cgroup. kcompactd and proactive compaction run
+ * in kernel threads outside any pod and are dropped by the cgroup filter. */
+
+struct compaction_try_hdr {
+	unsigned char common[8];
+	int order;
+};
+_Static_assert(__builtin_offsetof(struct compaction_try_hdr, order) == 8, "try_to_compact_pages: order must be at offset 8");
+
+struct compaction_end_hdr {
+	unsigned char common[8];
+	unsigned long zone_start;
+	unsigned long migrate_pfn;
+	unsigned long free_pfn;
+	unsigned long zone_end;
+	bool sync;
+	int status;
+};
+_Static_assert(__builtin_offsetof(struct compaction_end_hdr, status) == 44, "mm_compaction_end: status must be at offset 44");
+
+#define COMPACTION_PAGE_SHIFT 12
+
+SEC("tp/compaction/mm_compaction_try_to_compact_pages")
+int tracepoint_mm_compaction_try_to_compact_pages(void *ctx) {
+	u64 order = 0;
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+	struct trace_event_raw_mm_compaction_try_to_compact_pages *tp = ctx;
+	order = (u64)BPF_CORE_READ(tp, order);
+#else
+	struct compaction_try_hdr hdr = {};
+	if (bpf_probe_read_kernel(&hdr, sizeof(hdr), ctx) == 0) {
+		order = (u64)hdr.order;
+	}
+#endif
+	/* start_times holds u64 values; the order of the allocation being
+	 * compacted for rides along under its own pair. */
+	struct pair_key key = make_pair_key(PAIR_COMPACTION_ORDER);
+	bpf_map_update_elem(&start_times, &key, &order, BPF_ANY);
+	return 0;
+}
+
+SEC("tp/compaction/mm_compaction_begin")
+int tracepoint_mm_compaction_begin(void *ctx) {
+	struct pair_key key = make_pair_key(PAIR_COMPACTION);
+	u64 ts = bpf_ktime_get_ns();
+	bpf_map_update_elem(&start_times, &key, &ts, BPF_ANY);
+	return 0;
+}
+
+SEC("tp/compaction/mm_compaction_end")
+int tracepoint_mm_compaction_end(void *ctx) {
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct pair_key key = make_pair_key(PAIR_COMPACTION);
+	u64 *start_ts = bpf_map_lookup_elem(&start_times, &key);
+	if (!start_ts) {
+		return 0;
+	}
+	u64 latency = calc_latency(*start_ts);
+	bpf_map_delete_elem(&start_times, &key);
+
+	struct pair_key order_key = make_pair_key(PAIR_COMPACTION_ORDER);
+	u64 *order = bpf_map_lookup_elem(&start_times, &order_key);
+	u64 bytes = 0;
+	if (order && *order < 20) {
+		bytes = 1ULL << (*order + COMPACTION_PAGE_SHIFT);
+	}
+
+	struct event *e = get_event_buf();
+	if (!e) {
+		return 0;
+	}
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = pid_tgid >> 32;
+	e->type = EVENT_COMPACTION;
+	e->latency_ns = latency;
+	e->error = 0;
+	e->bytes = bytes;
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+	struct trace_event_raw_mm_compaction_end *tp = ctx;
+	e->tcp_state = (u32)BPF_CORE_READ(tp, status);
+#else
+	struct compaction_end_hdr hdr = {};
+	if (bpf_probe_read_kernel(&hdr, sizeof(hdr), ctx) == 0) {
+		e->tcp_state = (u32)hdr.status;
+	}
+#endif
+
+	capture_user_stack(ctx, e->pid, (u32)pid_tgid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+/* khugepaged collapses small pages into a huge page from its own thread,
+ * holding the target mm's mmap lock for writing while it copies; the pod
+ * sees it as page faults that stall. The mm's owner task names the cgroup
+ * the memory is charged to, which needs BTF to follow. */
+#define SCAN_SUCCEED 1
+#define HPAGE_PMD_BYTES (2ULL * 1024 * 1024)
+
+SEC("tp/huge_memory/mm_collapse_huge_page")
+int tracepoint_mm_collapse_huge_page(void *ctx) {
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+	struct trace_event_raw_mm_collapse_huge_page *tp = ctx;
+	struct mm_struct *mm = BPF_CORE_READ(tp, mm);
+	if (!mm || !bpf_core_field_exists(mm->owner)) {
+		return 0;
+	}
+	struct task_struct *owner = BPF_CORE_READ(mm, owner);
+	if (!owner) {
+		return 0;
+	}
+	u64 cgid = BPF_CORE_READ(owner, cgroups, dfl_cgrp, kn, id);
+
+	if (!cgroup_allowed(cgid)) {
+		return 0;
+	}
+
+	struct event *e = get_event_buf_unfiltered();
+	if (!e) {
+		return 0;
+	}
+	int status = BPF_CORE_READ(tp, status);
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = BPF_CORE_READ(owner, tgid);
+	e->cgroup_id = cgid;
+	e->net_ns_id = 0;
+	e->type = EVENT_THP_COLLAPSE;
+	e->latency_ns = 0;
+	e->error = status == SCAN_SUCCEED ? 0 : status;
+	e->bytes = status == SCAN_SUCCEED ? HPAGE_PMD_BYTES : 0;
+	e->tcp_state = (u32)status;
+	BPF_CORE_READ_STR_INTO(&e->comm, owner, comm);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+#endif
+	return 0;
+}
@@ -7,13 +7,32 @@
 
 #define CPU_SAMPLE_WINDOW_NS (1000ULL * 1000ULL * 1000ULL)
 
-static __always_inline void cpu_sample_accumulate(void *ctx, u64 on_cpu_ns, u64 now)
+/* thread_cpu_accumulate adds a slice of on-CPU time to the thread that ran
+ * it. Only that thread's CPU updates its entry, so no atomics are needed. */
+static __always_inline void thread_cpu_accumulate(u64 cgid, u32 tid, u64 on_cpu_ns)
+{
+	struct thread_cpu_value *v = bpf_map_lookup_elem(&thread_cpu_time, &tid);
+	if (v) {
+		v->on_cpu_ns += on_cpu_ns;
+		return;
+	}
+	struct thread_cpu_value init = {
+		.cgroup_id = cgid,
+		.pid = bpf_get_current_pid_tgid() >> 32,
+		.on_cpu_ns = on_cpu_ns,
+	};
+	bpf_map_update_elem(&thread_cpu_time, &tid, &init, BPF_ANY);
+}
+
+static __always_inline void cpu_sample_accumulate(void *ctx, u32 tid, u64 on_cpu_ns, u64 now)
 {
 	u64 cgid = bpf_get_current_cgroup_id();
 
 	if (!bpf_map_lookup_elem(&target_cgroup_ids, &cgid))
 		return;
 
+	thread_cpu_accumulate(cgid, tid, on_cpu_ns);
+
 	struct cpu_window *w = bpf_map_lookup_elem(&cgroup_cpu_window, &cgid);
 	if (!w) {
 		struct cpu_window init = {.window_start_ns = now, .runtime_ns = on_cpu_ns};
@@ -97,7 +116,8 @@ int tracepoint_sched_switch(void *ctx) {
 				e->latency_ns = blocked;
 				e->error = 0;
 				e->bytes = 0;
-				e->tcp_state = 0;
+				/* The blocked thread; userspace names it. */
+				e->tcp_state = prev_pid;
 				e->target[0] = '\0';
 				e->details[0] = '\0';
 
@@ -111,7 +131,7 @@ int tracepoint_sched_switch(void *ctx) {
 			u64 on_cpu = now > *in_ts ? now - *in_ts : 0;
 			bpf_map_delete_elem(&sched_in_ts, &prev_pid);
 			if (on_cpu > 0) {
-				cpu_sample_accumulate(ctx, on_cpu, now);
+				cpu_sample_accumulate(ctx, prev_pid, on_cpu, now);
 			}
 		}
 
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+
+static __always_inline int custom_uprobe_emit(struct pt_regs *ctx, struct custom_uprobe *p, u64 latency_ns)
+{
+	struct event *e = get_event_buf();
+	if (!e)
+		return 0;
+
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = bpf_get_current_pid_tgid() >> 32;
+	e->type = EVENT_UPROBE;
+	e->latency_ns = latency_ns;
+
+	bpf_probe_read_kernel_str(e->target, sizeof(e->target), p->label);
+	bpf_probe_read_kernel_str(e->details, sizeof(e->details), p->symbol);
+
+	u32 pid = e->pid;
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	capture_user_stack(ctx, pid, tid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+SEC("uprobe/custom")
+int uprobe_custom(struct pt_regs *ctx)
+{
+	u64 cookie = bpf_get_attach_cookie(ctx);
+	struct custom_uprobe *p = bpf_map_lookup_elem(&custom_uprobes, &cookie);
+	if (!p)
+		return 0;
+	if (!p->paired)
+		return custom_uprobe_emit(ctx, p, 0);
+
+	struct custom_uprobe_key key = {
+		.pid_tgid = bpf_get_current_pid_tgid(),
+		.cookie = cookie,
+	};
+	u64 now = bpf_ktime_get_ns();
+	bpf_map_update_elem(&custom_uprobe_starts, &key, &now, BPF_ANY);
+	return 0;
+}
+
+SEC("uretprobe/custom")
+int uretprobe_custom(struct pt_regs *ctx)
+{
+	u64 cookie = bpf_get_attach_cookie(ctx);
+	struct custom_uprobe *p = bpf_map_lookup_elem(&custom_uprobes, &cookie);
+	if (!p)
+		return 0;
+
+	struct custom_uprobe_key key = {
+		.pid_tgid = bpf_get_current_pid_tgid(),
+		.cookie = cookie,
+	};
+	u64 *start = bpf_map_lookup_elem(&custom_uprobe_starts, &key);
+	if (!start)
+		return 0;
+	u64 latency = bpf_ktime_get_ns() - *start;
+	bpf_map_delete_elem(&custom_uprobe_starts, &key);
+	return custom_uprobe_emit(ctx, p, latency);
+}
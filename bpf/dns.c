@@ -207,6 +207,8 @@ static __always_inline void dns_drop_inc(void) {
 }
 
 static __always_inline void emit_encrypted_dns(struct __sk_buff *skb, u8 is_v6, __u16 port, int is_doh) {
+	if (emission_paused())
+		return;
 	struct event *e = bpf_ringbuf_reserve(&events, sizeof(*e), 0);
 	if (!e) {
 		dns_drop_inc();
@@ -321,6 +323,10 @@ int dns_egress(struct __sk_buff *skb) {
 	if (bpf_skb_load_bytes(skb, qend, &qtype, sizeof(qtype)) == 0)
 		q.qtype = bpf_ntohs(qtype);
 
+	/* Paused: no query is tracked, so its response is skipped too. */
+	if (emission_paused())
+		return 1;
+
 	struct dns_flow_key key = {};
 	key.cgroup_id = bpf_skb_cgroup_id(skb);
 	key.txid = bpf_ntohs(txid);
@@ -423,6 +429,10 @@ int dns_ingress(struct __sk_buff *skb) {
 	struct dns_query_state *q = bpf_map_lookup_elem(&dns_inflight, &key);
 	if (!q)
 		return 1;
+	if (emission_paused()) {
+		bpf_map_delete_elem(&dns_inflight, &key);
+		return 1;
+	}
 
 	struct event *e = bpf_ringbuf_reserve(&events, sizeof(*e), 0);
 	if (!e) {
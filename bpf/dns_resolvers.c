@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+
+// Resolvers that never call getaddrinfo. Which of these are attached is
+// decided per container from the runtimes mapped into it; see
+// internal/ebpf/probes/dns_resolvers.go.
+
+// c-ares resolves asynchronously and reports through a callback, so there
+// is no return to time: ares_query/ares_search/ares_getaddrinfo are recorded
+// as the intent to resolve a name, like the packet path's query events.
+static __always_inline int ares_emit_query(struct pt_regs *ctx, u32 qtype)
+{
+	void *name = (void *)PT_REGS_PARM2(ctx);
+	if (!name)
+		return 0;
+	struct event *e = get_event_buf();
+	if (!e)
+		return 0;
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = pid_tgid >> 32;
+	e->type = EVENT_DNS_QUERY;
+	e->tcp_state = qtype;
+	bpf_probe_read_user_str(e->target, sizeof(e->target), name);
+	capture_user_stack(ctx, e->pid, (u32)pid_tgid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+// ares_query(channel, name, dnsclass, type, callback, arg); ares_search has
+// the same arguments.
+SEC("uprobe/ares_query")
+int uprobe_ares_query(struct pt_regs *ctx)
+{
+	return ares_emit_query(ctx, (u32)PT_REGS_PARM4(ctx));
+}
+
+// ares_getaddrinfo(channel, name, service, hints, callback, arg) asks for A
+// and AAAA at once, so no single query type applies.
+SEC("uprobe/ares_getaddrinfo")
+int uprobe_ares_getaddrinfo(struct pt_regs *ctx)
+{
+	return ares_emit_query(ctx, 0);
+}
+
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+
+#if defined(__TARGET_ARCH_x86) || defined(__x86_64__)
+#define GO_DNS_HOST_PTR(ctx)  ((void *)(ctx)->r8)
+#define GO_DNS_HOST_LEN(ctx)  ((u64)(ctx)->r9)
+#define GO_DNS_RET_ERR(ctx)   ((u64)(ctx)->di)
+#define GO_DNS_GOROUTINE(ctx) ((u64)(ctx)->r14)
+#define GO_DNS_SUPPORTED 1
+#elif defined(__TARGET_ARCH_arm64) || defined(__aarch64__)
+#define GO_DNS_HOST_PTR(ctx)  ((void *)(ctx)->regs[5])
+#define GO_DNS_HOST_LEN(ctx)  ((u64)(ctx)->regs[6])
+#define GO_DNS_RET_ERR(ctx)   ((u64)(ctx)->regs[3])
+#define GO_DNS_GOROUTINE(ctx) ((u64)(ctx)->regs[28])
+#define GO_DNS_SUPPORTED 1
+#endif
+
+#endif
+
+#ifdef GO_DNS_SUPPORTED
+
+// net.(*Resolver).lookupIPAddr(ctx, network, host string) ([]IPAddr, error)
+// is where both the pure-Go and the cgo resolver start. Under the register
+// ABI the receiver, ctx and network come first, so host is the fourth and
+// fifth integer register. The return is caught with uprobes on the
+// function's RET instructions: a uretprobe would corrupt a growing
+// goroutine stack.
+SEC("uprobe/go_lookup_ip")
+int uprobe_go_lookup_ip(struct pt_regs *ctx)
+{
+	void *host = GO_DNS_HOST_PTR(ctx);
+	u64 len = GO_DNS_HOST_LEN(ctx);
+	if (!host || len == 0)
+		return 0;
+	if (len > MAX_STRING_LEN - 1)
+		len = MAX_STRING_LEN - 1;
+
+	u64 key = GO_DNS_GOROUTINE(ctx);
+	struct go_dns_lookup st = {};
+	st.start_ns = bpf_ktime_get_ns();
+	bpf_probe_read_user(st.name, len & (MAX_STRING_LEN - 1), host);
+	bpf_map_update_elem(&go_dns_lookups, &key, &st, BPF_ANY);
+	return 0;
+}
+
+SEC("uprobe/go_lookup_ip_ret")
+int uprobe_go_lookup_ip_ret(struct pt_regs *ctx)
+{
+	u64 key = GO_DNS_GOROUTINE(ctx);
+	struct go_dns_lookup *st = bpf_map_lookup_elem(&go_dns_lookups, &key);
+	if (!st)
+		return 0;
+
+	struct event *e = get_event_buf();
+	if (!e) {
+		bpf_map_delete_elem(&go_dns_lookups, &key);
+		return 0;
+	}
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = pid_tgid >> 32;
+	e->type = EVENT_DNS;
+	e->latency_ns = calc_latency(st->start_ns);
+	// The returned error is an interface; a non-nil type word means the
+	// lookup failed. Go does not expose an errno, so report -1.
+	e->error = GO_DNS_RET_ERR(ctx) ? -1 : 0;
+	__builtin_memcpy(e->target, st->name, MAX_STRING_LEN);
+	bpf_map_delete_elem(&go_dns_lookups, &key);
+	capture_user_stack(ctx, e->pid, (u32)pid_tgid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+#else
+
+SEC("uprobe/go_lookup_ip")
+int uprobe_go_lookup_ip(struct pt_regs *ctx) { return 0; }
+
+SEC("uprobe/go_lookup_ip_ret")
+int uprobe_go_lookup_ip_ret(struct pt_regs *ctx) { return 0; }
+
+#endif
+
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+
+// Socket-level fallback for resolvers none of the probes above cover (musl's
+// res_send, statically linked or stripped binaries): a UDP datagram sent to
+// port 53 starts a lookup and the next datagram read from the same socket
+// ends it. The name is not decoded here; the packet path does that when it
+// can attach.
+static __always_inline u16 dns_sock_dport(struct sock *sk, struct msghdr *msg)
+{
+	u16 dport = __builtin_bswap16(BPF_CORE_READ(sk, __sk_common.skc_dport));
+	if (dport)
+		return dport;
+	// Unconnected sockets name the server in each sendmsg; sin_port and
+	// sin6_port share the same offset.
+	struct sockaddr_in *sin = (struct sockaddr_in *)BPF_CORE_READ(msg, msg_name);
+	if (!sin)
+		return 0;
+	u16 port = 0;
+	bpf_probe_read_kernel(&port, sizeof(port), &sin->sin_port);
+	return __builtin_bswap16(port);
+}
+
+SEC("kprobe/udp_sendmsg")
+int kprobe_dns_udp_sendmsg(struct pt_regs *ctx)
+{
+	struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	if (!sk || !msg || dns_sock_dport(sk, msg) != DNS_PORT)
+		return 0;
+
+	struct dns_sock_query q = {};
+	q.start_ns = bpf_ktime_get_ns();
+	q.server_ip = BPF_CORE_READ(sk, __sk_common.skc_daddr);
+	struct sockaddr_in *sin = (struct sockaddr_in *)BPF_CORE_READ(msg, msg_name);
+	if (!sin) {
+		if (!q.server_ip && BPF_CORE_READ(sk, __sk_common.skc_family) == AF_INET6)
+			BPF_CORE_READ_INTO(&q.server_ip6, sk, __sk_common.skc_v6_daddr);
+	} else if (!q.server_ip) {
+		u16 family = 0;
+		bpf_probe_read_kernel(&family, sizeof(family), &sin->sin_family);
+		if (family == AF_INET)
+			bpf_probe_read_kernel(&q.server_ip, sizeof(q.server_ip), &sin->sin_addr);
+		else if (family == AF_INET6)
+			bpf_probe_read_kernel(q.server_ip6, sizeof(q.server_ip6),
+					      &((struct sockaddr_in6 *)sin)->sin6_addr);
+	}
+	u64 key = (u64)sk;
+	// A resolver sends A and AAAA back to back; time from the first.
+	bpf_map_update_elem(&dns_sock_queries, &key, &q, BPF_NOEXIST);
+	return 0;
+}
+
+SEC("kprobe/udp_recvmsg")
+int kprobe_dns_udp_recvmsg(struct pt_regs *ctx)
+{
+	u64 sk = (u64)PT_REGS_PARM1(ctx);
+	if (!bpf_map_lookup_elem(&dns_sock_queries, &sk))
+		return 0;
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	bpf_map_update_elem(&dns_sock_recv, &pid_tgid, &sk, BPF_ANY);
+	return 0;
+}
+
+SEC("kretprobe/udp_recvmsg")
+int kretprobe_dns_udp_recvmsg(struct pt_regs *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u64 *skp = bpf_map_lookup_elem(&dns_sock_recv, &pid_tgid);
+	if (!skp)
+		return 0;
+	u64 sk = *skp;
+	bpf_map_delete_elem(&dns_sock_recv, &pid_tgid);
+	// EAGAIN from a non-blocking poll loop is not an answer yet.
+	if ((s64)PT_REGS_RC(ctx) <= 0)
+		return 0;
+	struct dns_sock_query *q = bpf_map_lookup_elem(&dns_sock_queries, &sk);
+	if (!q)
+		return 0;
+
+	struct event *e = get_event_buf();
+	if (!e) {
+		bpf_map_delete_elem(&dns_sock_queries, &sk);
+		return 0;
+	}
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = pid_tgid >> 32;
+	e->type = EVENT_DNS;
+	e->latency_ns = calc_latency(q->start_ns);
+	e->dns_server_ip = q->server_ip;
+	__builtin_memcpy(e->dns_server_ip6, q->server_ip6, 16);
+	bpf_map_delete_elem(&dns_sock_queries, &sk);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+#endif
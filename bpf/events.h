@@ -48,6 +48,21 @@ enum event_type {
 	EVENT_AF_ALG,
 	EVENT_HTTP3,
 	EVENT_USDT,
+	EVENT_UPROBE,
+	EVENT_PY_CALL,
+	EVENT_PY_GC,
+	EVENT_LOOP_LAG,
+	EVENT_KAFKA_OP,
+	EVENT_AMQP,
+	EVENT_ANNOTATION, // userspace only: operator markers from the annotation socket
+	EVENT_CRASH,
+	EVENT_POD_THROUGHPUT, // userspace only: veth counters polled from pod_throughput
+	EVENT_CPU_PLACEMENT,  // userspace only: /proc CPU and NUMA placement samples
+	EVENT_COMPACTION,
+	EVENT_THP_COLLAPSE,
+	// The Go enum (internal/events) continues with userspace-only types;
+	// kernel types added after them take their Go value explicitly.
+	EVENT_TCP_ZERO_WINDOW = 62,
 };
 
 struct event {
@@ -64,7 +79,7 @@ struct event {
 	char target[MAX_STRING_LEN];
 	char details[MAX_STRING_LEN];
 	u32 net_ns_id;
-	u32 _pad2;
+	u32 mnt_id; // FS events: mount ID of the file, 0 elsewhere
 	u32 dns_server_ip;
 	u8  dns_transport;
 	u8  _pad3[3];
@@ -78,6 +93,7 @@ struct event {
 	u8  peer_saddr6[16];
 	u8  peer_daddr6[16];
 	u64 correlation_id;
+	u64 sock_id; // kernel address of the socket, 0 when unknown
 };
 
 #define H2_HDR_FRAG_MAX 1024
@@ -6,6 +6,56 @@
 #include "helpers.h"
 #include "filesystem.h"
 
+/* count_read_cache adds one regular-file read of the current cgroup to
+ * read_cache_stats. The map is per-CPU, so plain increments are safe. */
+static __always_inline void count_read_cache(u64 misses) {
+	u64 cgid = bpf_get_current_cgroup_id();
+	if (!cgroup_allowed(cgid)) {
+		return;
+	}
+	struct read_cache_value *v = bpf_map_lookup_elem(&read_cache_stats, &cgid);
+	if (v) {
+		v->reads++;
+		if (misses) {
+			v->miss_reads++;
+			v->miss_folios += misses;
+		}
+		return;
+	}
+	struct read_cache_value init = {
+		.reads = 1,
+		.miss_reads = misses ? 1 : 0,
+		.miss_folios = misses,
+	};
+	bpf_map_update_elem(&read_cache_stats, &cgid, &init, BPF_NOEXIST);
+}
+
+/* record_mnt_id keeps the mount ID of the file an in-flight vfs call works
+ * on until its kretprobe, which no longer sees the file. VFS calls do not
+ * nest on a thread, so one slot per thread is enough. */
+static __always_inline void record_mnt_id(struct file *file) {
+	struct pair_key mnt_key = make_pair_key(PAIR_VFS_MNT);
+	u64 mnt_id = file_mnt_id(file);
+	bpf_map_update_elem(&start_times, &mnt_key, &mnt_id, BPF_ANY);
+}
+
+/* take_mnt_id moves the recorded mount ID into e. */
+static __always_inline void take_mnt_id(struct event *e) {
+	struct pair_key mnt_key = make_pair_key(PAIR_VFS_MNT);
+	u64 *mnt_id = bpf_map_lookup_elem(&start_times, &mnt_key);
+	if (mnt_id) {
+		e->mnt_id = (u32)*mnt_id;
+		bpf_map_delete_elem(&start_times, &mnt_key);
+	}
+}
+
+/* is_write_refused reports whether ret is a write the filesystem refused for
+ * being read-only or full. Those fail fast, under MIN_LATENCY_NS, and are
+ * kept anyway: a burst of them is a finding of its own. */
+static __always_inline int is_write_refused(s64 ret) {
+	return ret == -EROFS || ret == -ENOSPC || ret == -EDQUOT;
+}
+
 SEC("kprobe/vfs_write")
 int kprobe_vfs_write(struct pt_regs *ctx) {
 	u32 pid = bpf_get_current_pid_tgid() >> 32;
@@ -21,6 +71,7 @@ int kprobe_vfs_write(struct pt_regs *ctx) {
 			bpf_map_update_elem(&syscall_paths, &key, path_buf, BPF_ANY);
 		}
 	}
+	record_mnt_id(file);
 	
 	return 0;
 }
@@ -33,13 +84,23 @@ int kprobe_vfs_read(struct pt_regs *ctx) {
 	u64 ts = bpf_ktime_get_ns();
 	bpf_map_update_elem(&start_times, &key, &ts, BPF_ANY);
 	
+	/* The miss counter exists only while a regular-file read is in flight;
+	 * kprobe_filemap_add_folio counts into it. */
+	struct pair_key miss_key = make_pair_key(PAIR_VFS_READ_MISS);
 	struct file *file = (struct file *)PT_REGS_PARM1(ctx);
+	if (file && file_is_regular(file)) {
+		u64 zero = 0;
+		bpf_map_update_elem(&start_times, &miss_key, &zero, BPF_ANY);
+	} else {
+		bpf_map_delete_elem(&start_times, &miss_key);
+	}
 	if (file) {
 		char path_buf[MAX_STRING_LEN] = {};
 		if (get_path_str_from_file(file, path_buf, MAX_STRING_LEN)) {
 			bpf_map_update_elem(&syscall_paths, &key, path_buf, BPF_ANY);
 		}
 	}
+	record_mnt_id(file);
 	
 	return 0;
 }
@@ -55,6 +116,15 @@ int kretprobe_vfs_read(struct pt_regs *ctx) {
 		return 0;
 	}
 	
+	u64 misses = 0;
+	struct pair_key miss_key = make_pair_key(PAIR_VFS_READ_MISS);
+	u64 *miss_count = bpf_map_lookup_elem(&start_times, &miss_key);
+	if (miss_count) {
+		misses = *miss_count;
+		bpf_map_delete_elem(&start_times, &miss_key);
+		count_read_cache(misses);
+	}
+	
 	u64 latency = calc_latency(*start_ts);
 	if (latency < MIN_LATENCY_NS) {
 		bpf_map_delete_elem(&start_times, &key);
@@ -78,7 +148,7 @@ int kretprobe_vfs_read(struct pt_regs *ctx) {
 	e->latency_ns = latency;
 	e->error = ret < 0 ? ret : 0;
 	e->bytes = bytes;
-	e->tcp_state = 0;
+	e->tcp_state = (u32)misses;
 	
 	char *path = bpf_map_lookup_elem(&syscall_paths, &key);
 	if (path) {
@@ -87,6 +157,7 @@ int kretprobe_vfs_read(struct pt_regs *ctx) {
 	} else {
 		e->target[0] = '\0';
 	}
+	take_mnt_id(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -105,12 +176,12 @@ int kretprobe_vfs_write(struct pt_regs *ctx) {
 	}
 	
 	u64 latency = calc_latency(*start_ts);
-	if (latency < MIN_LATENCY_NS) {
+	s64 ret = PT_REGS_RC(ctx);
+	if (latency < MIN_LATENCY_NS && !is_write_refused(ret)) {
 		bpf_map_delete_elem(&start_times, &key);
 		return 0;
 	}
 	
-	s64 ret = PT_REGS_RC(ctx);
 	u64 bytes = 0;
 	if (ret > 0 && (u64)ret < MAX_BYTES_THRESHOLD) {
 		bytes = (u64)ret;
@@ -136,6 +207,7 @@ int kretprobe_vfs_write(struct pt_regs *ctx) {
 	} else {
 		e->target[0] = '\0';
 	}
+	take_mnt_id(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -157,6 +229,7 @@ int kprobe_vfs_fsync(struct pt_regs *ctx) {
 			bpf_map_update_elem(&syscall_paths, &key, path_buf, BPF_ANY);
 		}
 	}
+	record_mnt_id(file);
 	
 	return 0;
 }
@@ -173,7 +246,8 @@ int kretprobe_vfs_fsync(struct pt_regs *ctx) {
 	}
 	
 	u64 latency = calc_latency(*start_ts);
-	if (latency < MIN_LATENCY_NS) {
+	s64 ret = PT_REGS_RC(ctx);
+	if (latency < MIN_LATENCY_NS && !is_write_refused(ret)) {
 		bpf_map_delete_elem(&start_times, &key);
 		return 0;
 	}
@@ -187,7 +261,7 @@ int kretprobe_vfs_fsync(struct pt_regs *ctx) {
 	e->pid = pid;
 	e->type = EVENT_FSYNC;
 	e->latency_ns = latency;
-	e->error = PT_REGS_RC(ctx);
+	e->error = ret;
 	e->bytes = 0;
 	e->tcp_state = 0;
 	
@@ -198,8 +272,21 @@ int kretprobe_vfs_fsync(struct pt_regs *ctx) {
 	} else {
 		e->target[0] = '\0';
 	}
+	take_mnt_id(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
 	return 0;
-}
\ No newline at end of file
+}
+/* filemap_add_folio inserts a folio the read is about to fill from the
+ * device: buffered reads and readahead both come through it, so each call
+ * inside a regular-file vfs_read is one page-cache miss of that read. */
+SEC("kprobe/filemap_add_folio")
+int kprobe_filemap_add_folio(struct pt_regs *ctx) {
+	struct pair_key miss_key = make_pair_key(PAIR_VFS_READ_MISS);
+	u64 *misses = bpf_map_lookup_elem(&start_times, &miss_key);
+	if (misses) {
+		__sync_fetch_and_add(misses, 1);
+	}
+	return 0;
+}
@@ -27,5 +27,38 @@ static inline int get_path_str_from_file(struct file *file, char *out_buf, u32 b
 #endif
 }
 
+/* file_is_regular reports whether file is a regular file, the only kind
+ * whose reads go through the page cache. Without BTF every file counts. */
+static inline int file_is_regular(struct file *file)
+{
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+    umode_t mode = BPF_CORE_READ(file, f_inode, i_mode);
+    return (mode & 00170000) == 0100000;
+#else
+    return 1;
+#endif
+}
+
+/* file_mnt_id returns the mount ID of the mount file was opened through,
+ * the first field of /proc/<pid>/mountinfo, so userspace can tell which
+ * pod volume the file lives on. Zero without BTF. */
+static inline u32 file_mnt_id(struct file *file)
+{
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+    if (!file) {
+        return 0;
+    }
+    struct vfsmount *vfsmnt = BPF_CORE_READ(file, f_path.mnt);
+    if (!vfsmnt) {
+        return 0;
+    }
+    /* struct mount embeds the vfsmount as its mnt member. */
+    struct mount *mnt = (struct mount *)((char *)vfsmnt - bpf_core_field_offset(struct mount, mnt));
+    return (u32)BPF_CORE_READ(mnt, mnt_id);
+#else
+    return 0;
+#endif
+}
+
 #endif
 
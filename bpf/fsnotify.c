@@ -0,0 +1,98 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+
+/* Config reloaders and file watchers register inotify watches and fanotify
+ * marks per file; a storm of them, or a reader too slow to drain its queue,
+ * costs the node kernel memory and CPU long before the application notices.
+ * Nothing here is latency: every call is counted into fsnotify_stats and
+ * userspace turns the counters into events. */
+
+enum fsnotify_counter {
+	FSNOTIFY_WATCH,
+	FSNOTIFY_MARK,
+	FSNOTIFY_LIMIT,
+	FSNOTIFY_OVERFLOW,
+};
+
+static __always_inline void count_fsnotify(u64 cgid, enum fsnotify_counter counter) {
+	if (!cgroup_allowed(cgid)) {
+		return;
+	}
+	struct fsnotify_value *v = bpf_map_lookup_elem(&fsnotify_stats, &cgid);
+	if (!v) {
+		struct fsnotify_value init = {};
+		bpf_map_update_elem(&fsnotify_stats, &cgid, &init, BPF_NOEXIST);
+		v = bpf_map_lookup_elem(&fsnotify_stats, &cgid);
+		if (!v) {
+			return;
+		}
+	}
+	switch (counter) {
+	case FSNOTIFY_WATCH:
+		v->watches++;
+		break;
+	case FSNOTIFY_MARK:
+		v->marks++;
+		break;
+	case FSNOTIFY_LIMIT:
+		v->limit_hits++;
+		break;
+	case FSNOTIFY_OVERFLOW:
+		v->overflows++;
+		break;
+	}
+}
+
+/* A registration past fs.inotify.max_user_watches or fanotify's
+ * max_user_marks fails with ENOSPC; the limit is per user, so one pod can
+ * starve every other pod running as the same uid on the node. */
+static __always_inline void count_registration(long ret, enum fsnotify_counter counter) {
+	u64 cgid = bpf_get_current_cgroup_id();
+	if (ret == -ENOSPC) {
+		count_fsnotify(cgid, FSNOTIFY_LIMIT);
+	} else if (ret >= 0) {
+		count_fsnotify(cgid, counter);
+	}
+}
+
+SEC("tp/syscalls/sys_exit_inotify_add_watch")
+int tracepoint_sys_exit_inotify_add_watch(struct trace_event_raw_sys_exit *ctx) {
+	count_registration(ctx->ret, FSNOTIFY_WATCH);
+	return 0;
+}
+
+SEC("tp/syscalls/sys_exit_fanotify_mark")
+int tracepoint_sys_exit_fanotify_mark(struct trace_event_raw_sys_exit *ctx) {
+	count_registration(ctx->ret, FSNOTIFY_MARK);
+	return 0;
+}
+
+/* fsnotify_insert_event() runs in whichever task touched the watched file,
+ * not in the watcher, so the overflow is charged to the group's memory
+ * cgroup: inotify and fanotify groups record the memcg of the task that
+ * created them. Once the queue holds max_events, the overflow event is
+ * queued (once) and every further event is dropped; each is counted. */
+SEC("kprobe/fsnotify_insert_event")
+int kprobe_fsnotify_insert_event(struct pt_regs *ctx) {
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+	struct fsnotify_group *group = (struct fsnotify_group *)PT_REGS_PARM1(ctx);
+	struct fsnotify_event *event = (struct fsnotify_event *)PT_REGS_PARM2(ctx);
+	if (!group || !bpf_core_field_exists(group->memcg)) {
+		return 0;
+	}
+	if (event != BPF_CORE_READ(group, overflow_event) &&
+	    BPF_CORE_READ(group, q_len) < BPF_CORE_READ(group, max_events)) {
+		return 0;
+	}
+	struct mem_cgroup *memcg = BPF_CORE_READ(group, memcg);
+	if (!memcg) {
+		return 0;
+	}
+	count_fsnotify(BPF_CORE_READ(memcg, css.cgroup, kn, id), FSNOTIFY_OVERFLOW);
+#endif
+	return 0;
+}
@@ -121,8 +121,9 @@ static long h2_frames_cb(u32 idx, void *vctx)
 					((completes && (fs->flags & HTTP2_FLAG_END_HEADERS)) ? H2_HDR_FLAG_END_HEADERS : 0) |
 					((type == HTTP2_CONTINUATION) ? H2_HDR_FLAG_CONTINUATION : 0);
 				fill_h2_record_peer(&s->rec, c->dir);
-				bpf_ringbuf_output(&h2_hdr_events, &s->rec,
-						   sizeof(s->rec) + frag, 0);
+				if (!emission_paused())
+					bpf_ringbuf_output(&h2_hdr_events, &s->rec,
+							   sizeof(s->rec) + frag, 0);
 			}
 			off += frag;
 			fs->remaining -= frag;
@@ -215,7 +216,8 @@ static __always_inline void h2_emit_frames(void *base, u64 avail, u64 conn,
 		s->rec.conn_id = conn;
 		s->rec.timestamp = bpf_ktime_get_ns();
 		s->rec.flags = H2_HDR_FLAG_CLOSE;
-		bpf_ringbuf_output(&h2_hdr_events, &s->rec, sizeof(s->rec), 0);
+		if (!emission_paused())
+			bpf_ringbuf_output(&h2_hdr_events, &s->rec, sizeof(s->rec), 0);
 
 		struct h2_frame_state fresh = {};
 		bpf_map_update_elem(&h2_frame_state, &fk, &fresh, BPF_ANY);
@@ -330,7 +332,8 @@ int kprobe_h2_tcp_close(struct pt_regs *ctx)
 	s->rec.conn_id = conn;
 	s->rec.timestamp = bpf_ktime_get_ns();
 	s->rec.flags = H2_HDR_FLAG_CLOSE;
-	bpf_ringbuf_output(&h2_hdr_events, &s->rec, sizeof(s->rec), 0);
+	if (!emission_paused())
+		bpf_ringbuf_output(&h2_hdr_events, &s->rec, sizeof(s->rec), 0);
 	return 0;
 }
 
@@ -39,6 +39,7 @@ static inline void fill_event_peer(struct event *e) {
 	e->peer_daddr = p->daddr;
 	__builtin_memcpy(e->peer_saddr6, p->saddr6, 16);
 	__builtin_memcpy(e->peer_daddr6, p->daddr6, 16);
+	e->sock_id = p->sock_id;
 }
 
 static inline void fill_h2_record_peer(struct h2_hdr_record *rec, u32 dir) {
@@ -65,6 +66,12 @@ static inline u64 calc_latency(u64 start) {
 	return now > start ? now - start : 0;
 }
 
+/* read_be32 decodes the big-endian u32 the wire protocols put lengths
+ * and ids in. */
+static __always_inline u32 read_be32(const u8 *b) {
+	return ((u32)b[0] << 24) | ((u32)b[1] << 16) | ((u32)b[2] << 8) | (u32)b[3];
+}
+
 static __attribute__((noinline)) u32 append_dec(char *buf, u32 idx, u32 max_idx, u32 val) {
 	u32 divisor = 10000;
 	int started = 0;
@@ -141,8 +148,28 @@ static inline u64 build_stack_key(u32 pid, u32 tid, u64 timestamp) {
 	return h;
 }
 
+/* emission_paused reports whether an armed session has paused event
+ * emission. get_event_buf checks it; every path that writes a ring
+ * buffer without going through it must check it too. */
+static inline int emission_paused(void) {
+	u32 zero = 0;
+	u32 *paused = bpf_map_lookup_elem(&emit_paused, &zero);
+	return paused && *paused;
+}
+
+/* cgroup_allowed reports whether events of cgroup cgid pass the cgroup
+ * filter: always when no filter is set, else only for a target cgroup. */
+static __always_inline bool cgroup_allowed(u64 cgid) {
+	u32 zero = 0;
+	u32 *enabled = bpf_map_lookup_elem(&cgroup_filter_enabled, &zero);
+	return !(enabled && *enabled) || bpf_map_lookup_elem(&target_cgroup_ids, &cgid) != NULL;
+}
+
 static inline struct event *get_event_buf_unfiltered(void) {
 	u32 zero = 0;
+	if (emission_paused()) {
+		return NULL;
+	}
 	struct event *e = bpf_map_lookup_elem(&event_buf, &zero);
 	if (e) {
 		__builtin_memset(e, 0, sizeof(*e));
@@ -165,14 +192,8 @@ static inline struct event *get_event_buf(void) {
 		return NULL;
 	}
 
-	u32 zero = 0;
-	u32 *enabled = bpf_map_lookup_elem(&cgroup_filter_enabled, &zero);
-	if (enabled && *enabled) {
-		u64 cgid = e->cgroup_id;
-		u8 *allowed = bpf_map_lookup_elem(&target_cgroup_ids, &cgid);
-		if (!allowed) {
-			return NULL;
-		}
+	if (!cgroup_allowed(e->cgroup_id)) {
+		return NULL;
 	}
 	return e;
 }
@@ -31,14 +31,7 @@ static __always_inline int http_method_len(const u8 *b)
 
 static __always_inline int http_should_trace(void)
 {
-	u32 zero = 0;
-	u32 *enabled = bpf_map_lookup_elem(&cgroup_filter_enabled, &zero);
-	if (enabled && *enabled) {
-		u64 cgid = bpf_get_current_cgroup_id();
-		if (!bpf_map_lookup_elem(&target_cgroup_ids, &cgid))
-			return 0;
-	}
-	return 1;
+	return cgroup_allowed(bpf_get_current_cgroup_id());
 }
 
 struct tp_scan_ctx {
@@ -103,6 +96,94 @@ static __noinline void http_capture_traceparent(void *base, u64 avail, char *out
 	out[13 + W3C_TRACEPARENT_LEN] = '\0';
 }
 
+struct sigv4_scan_ctx {
+	u32 rlen;
+	int amz;
+	int host;
+};
+
+// sigv4_scan_cb looks for the start of an x-amz-* header (x-amz-date and
+// x-amz-content-sha256 accompany every SigV4-signed S3 request) and of the
+// Host header.
+static long sigv4_scan_cb(u32 i, void *vctx)
+{
+	struct sigv4_scan_ctx *c = (struct sigv4_scan_ctx *)vctx;
+	if (c->amz >= 0 && c->host >= 0)
+		return 1;
+	if (i + 8 >= HTTP_SCAN_BUF_SIZE || i + 6 > c->rlen)
+		return 1;
+	if (i == 0)
+		return 0;
+	u32 zero = 0;
+	char *buf = bpf_map_lookup_elem(&http_scan_buf, &zero);
+	if (!buf)
+		return 1;
+	if (buf[(i - 1) & (HTTP_SCAN_BUF_SIZE - 1)] != '\n')
+		return 0;
+	const char amz[] = "x-amz-";
+	const char host[] = "host:";
+	u32 da = 0, dh = 0;
+	u32 j;
+#pragma unroll
+	for (j = 0; j < 6; j++)
+		da |= (u32)(((u8)buf[(i + j) & (HTTP_SCAN_BUF_SIZE - 1)] | 0x20) ^ (u8)amz[j]);
+#pragma unroll
+	for (j = 0; j < 5; j++)
+		dh |= (u32)(((u8)buf[(i + j) & (HTTP_SCAN_BUF_SIZE - 1)] | 0x20) ^ (u8)host[j]);
+	if (da == 0 && c->amz < 0)
+		c->amz = (int)i;
+	if (dh == 0 && c->host < 0)
+		c->host = (int)i;
+	return 0;
+}
+
+// http_capture_sigv4 appends a "sigv4: <host>" line to the request details
+// when the request carries AWS SigV4 headers, so userspace can tell S3-style
+// object storage calls (and the bucket of virtual-hosted URLs) from other
+// HTTP traffic. It runs after http_capture_traceparent, whose output has a
+// fixed length.
+static __noinline void http_capture_sigv4(void *base, u64 avail, char *out)
+{
+	if (!base)
+		return;
+	u32 zero = 0;
+	char *buf = bpf_map_lookup_elem(&http_scan_buf, &zero);
+	if (!buf)
+		return;
+	u32 rlen = (avail < HTTP_SCAN_BUF_SIZE) ? (u32)avail : HTTP_SCAN_BUF_SIZE;
+	if (rlen < 32)
+		return;
+	if (bpf_probe_read_user(buf, rlen, base) != 0)
+		return;
+
+	struct sigv4_scan_ctx sc = {.rlen = rlen, .amz = -1, .host = -1};
+	bpf_loop(HTTP_SCAN_BUF_SIZE, sigv4_scan_cb, &sc, 0);
+	if (sc.amz < 0)
+		return;
+
+	u32 p = 0;
+	if (out[0] != '\0') {
+		p = 13 + W3C_TRACEPARENT_LEN;
+		out[p++] = '\n';
+	}
+	__builtin_memcpy(out + p, "sigv4: ", 7);
+	p += 7;
+	if (sc.host >= 0) {
+		u32 v = (u32)sc.host + 5;
+		if (v < rlen && buf[v & (HTTP_SCAN_BUF_SIZE - 1)] == ' ')
+			v++;
+		u32 i;
+		for (i = 0; i < MAX_STRING_LEN && p < MAX_STRING_LEN - 1 && v + i < rlen; i++) {
+			char c = buf[(v + i) & (HTTP_SCAN_BUF_SIZE - 1)];
+			if (c == '\r' || c == '\n')
+				break;
+			out[p & (MAX_STRING_LEN - 1)] = c;
+			p++;
+		}
+	}
+	out[p & (MAX_STRING_LEN - 1)] = '\0';
+}
+
 static __noinline void http_emit_request(void *ctx, void *base, u64 avail,
 					 u8 transport, u64 conn)
 {
@@ -158,6 +239,7 @@ static __noinline void http_emit_request(void *ctx, void *base, u64 avail,
 		e->correlation_id = now;
 		bpf_probe_read_kernel_str(e->target, sizeof(e->target), req.endpoint);
 		http_capture_traceparent(base, avail, e->details);
+		http_capture_sigv4(base, avail, e->details);
 		fill_event_peer(e);
 		capture_user_stack(ctx, pid, tid, e);
 		bpf_ringbuf_output(&events, e, sizeof(*e), 0);
@@ -293,6 +375,10 @@ int kretprobe_http_tcp_recvmsg(struct pt_regs *ctx)
 static __always_inline void h2_emit_frames(void *base, u64 avail, u64 conn,
 					   u32 dir, u8 transport);
 
+/* AMQP over OpenSSL (AMQPS) is recognised here; see amqp.c. */
+static __noinline void amqp_emit_frames(void *ctx, u8 *base, u64 len, u64 conn, u8 dir);
+static __always_inline int amqp_is_method_frame(const u8 *b);
+
 #define SSL_TLS_PEEK 16
 
 SEC("uprobe/SSL_write")
@@ -316,6 +402,8 @@ int uprobe_SSL_write(struct pt_regs *ctx)
 	else if (peek[0] == 'H' && peek[1] == 'T' && peek[2] == 'T' && peek[3] == 'P' &&
 		 peek[4] == '/' && peek[5] == '1' && peek[6] == '.')
 		http_emit_response(ctx, base, num, HTTP_TRANSPORT_TLS, (u64)ssl);
+	else if (amqp_is_method_frame(peek))
+		amqp_emit_frames(ctx, base, num, (u64)ssl, AMQP_DIR_OUT);
 	else
 		h2_emit_frames(base, num, (u64)ssl, H2_DIR_EGRESS,
 			       HTTP_TRANSPORT_H2_TLS);
@@ -364,6 +452,8 @@ int uretprobe_SSL_read(struct pt_regs *ctx)
 		http_emit_response(ctx, base, (u64)ret, HTTP_TRANSPORT_TLS, conn);
 	else if (http_method_len(peek) > 0)
 		http_emit_request(ctx, base, (u64)ret, HTTP_TRANSPORT_TLS, conn);
+	else if (amqp_is_method_frame(peek))
+		amqp_emit_frames(ctx, base, (u64)ret, conn, AMQP_DIR_IN);
 	else
 		h2_emit_frames(base, (u64)ret, conn, H2_DIR_INGRESS,
 			       HTTP_TRANSPORT_H2_TLS);
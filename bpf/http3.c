@@ -29,6 +29,8 @@ static __always_inline int quic_is_initial(struct __sk_buff *skb, int l4) {
 static __always_inline void quic_ship(struct __sk_buff *skb, u8 is_v6,
 				      u32 addr_off, u32 port_l4_off, int l4) {
 	struct quic_flow_key k = {};
+	if (emission_paused())
+		return;
 	k.cgroup_id = bpf_skb_cgroup_id(skb);
 	u16 port_be = 0;
 	if (bpf_skb_load_bytes(skb, l4 + port_l4_off, &port_be, sizeof(port_be)) < 0)
@@ -335,7 +335,8 @@ static __always_inline void h3_emit_txn(u64 goroutine, u16 status, u8 is_client,
 	}
 
 	bpf_map_delete_elem(&h3_req_stash, &key);
-	bpf_ringbuf_output(&h3_txn_events, rec, sizeof(*rec), 0);
+	if (!emission_paused())
+		bpf_ringbuf_output(&h3_txn_events, rec, sizeof(*rec), 0);
 }
 
 SEC("uprobe/h3_roundtrip")
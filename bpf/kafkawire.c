@@ -0,0 +1,332 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+#include "protocols.h"
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+
+// Kafka client protocol on plaintext broker connections (port 9092). Produce,
+// Fetch and Metadata requests are matched to their responses by correlation
+// id; the topic comes from the request and the error code from the first
+// partition (or, for Fetch v7+, the top-level error) of the response. This
+// covers clients that never load librdkafka (the Java client, sarama,
+// franz-go, kafka-python).
+
+static __always_inline u16 kafka_be16(const u8 *b)
+{
+	return ((u16)b[0] << 8) | (u16)b[1];
+}
+
+static __always_inline int kafka_sock_on_port(struct sock *sk)
+{
+	if (!sk)
+		return 0;
+	u16 dport = __builtin_bswap16(BPF_CORE_READ(sk, __sk_common.skc_dport));
+	return dport == KAFKA_DEFAULT_PORT;
+}
+
+static __always_inline int kafka_is_flexible(u16 api_key, u16 version)
+{
+	switch (api_key) {
+	case KAFKA_API_PRODUCE:
+		return version >= KAFKA_PRODUCE_FLEX_VER;
+	case KAFKA_API_FETCH:
+		return version >= KAFKA_FETCH_FLEX_VER;
+	default:
+		return version >= KAFKA_METADATA_FLEX_VER;
+	}
+}
+
+// kafka_send_base finds the request in a send. The 4-byte size prefix is
+// either the first bytes of the buffer or, for gathering writes such as the
+// Java client's, an iovec of its own.
+static __always_inline void *kafka_send_base(struct msghdr *msg, u32 *size)
+{
+	u64 avail = 0;
+	u8 *base = msghdr_user_base(msg, &avail);
+	if (!base)
+		return NULL;
+	u8 sz[4] = {};
+	if (bpf_probe_read_user(sz, sizeof(sz), base) != 0)
+		return NULL;
+	*size = read_be32(sz);
+	if (avail > 4)
+		return base + 4;
+	if (avail != 4 || BPF_CORE_READ(msg, msg_iter.iter_type) != ITER_IOVEC)
+		return NULL;
+	const struct iovec *iov = BPF_CORE_READ(msg, msg_iter.__iov);
+	struct iovec next = {};
+	if (!iov || bpf_probe_read_kernel(&next, sizeof(next), iov + 1) != 0)
+		return NULL;
+	return next.iov_base;
+}
+
+// kafka_read_string copies a (compact) string at p into q->topic.
+static __always_inline void kafka_read_string(u8 *p, struct kafka_req *q)
+{
+	u8 lb[2] = {};
+	if (bpf_probe_read_user(lb, sizeof(lb), p) != 0)
+		return;
+	u32 n;
+	if (q->flexible) {
+		if (lb[0] == 0 || lb[0] >= 0x80)
+			return;
+		n = lb[0] - 1;
+		p += 1;
+	} else {
+		n = kafka_be16(lb);
+		if (n == 0xffff)
+			return;
+		p += 2;
+	}
+	if (n == 0)
+		return;
+	if (n > MAX_STRING_LEN - 1)
+		n = MAX_STRING_LEN - 1;
+	if (bpf_probe_read_user(q->topic, n & (MAX_STRING_LEN - 1), p) != 0)
+		return;
+	q->topic[n & (MAX_STRING_LEN - 1)] = '\0';
+	q->topic_len = (u16)n;
+}
+
+// kafka_read_topic locates the first topic name in a request body. Varints
+// are assumed to fit one byte, which holds for any realistic topic count
+// and name length.
+static __noinline void kafka_read_topic(u8 *body, struct kafka_req *q)
+{
+	u8 b[2] = {};
+	u32 off = 0;
+	switch (q->api_key) {
+	case KAFKA_API_PRODUCE:
+		// [transactional_id (v3+)], acks int16, timeout int32, topics
+		if (q->api_version >= KAFKA_PRODUCE_FLEX_VER) {
+			if (bpf_probe_read_user(b, 1, body) != 0 || b[0] >= 0x80)
+				return;
+			off = 1 + (b[0] ? b[0] - 1 : 0);
+		} else if (q->api_version >= 3) {
+			if (bpf_probe_read_user(b, 2, body) != 0)
+				return;
+			u16 tl = kafka_be16(b);
+			off = 2 + (tl == 0xffff ? 0 : tl);
+		}
+		off += 6;
+		break;
+	case KAFKA_API_FETCH:
+		// replica_id, max_wait, min_bytes, [max_bytes v3+],
+		// [isolation_level v4+], [session_id, session_epoch v7+], topics
+		if (q->api_version >= KAFKA_FETCH_TOPIC_ID_VER)
+			return;
+		if (q->api_version >= 7)
+			off = 25;
+		else if (q->api_version >= 4)
+			off = 17;
+		else if (q->api_version >= 3)
+			off = 16;
+		else
+			off = 12;
+		break;
+	case KAFKA_API_METADATA:
+		// topics is nullable; null or empty asks for every topic.
+		if (q->flexible) {
+			if (bpf_probe_read_user(b, 1, body) != 0 || b[0] <= 1)
+				return;
+			// v10+ entries start with the topic id.
+			kafka_read_string(body + 1 + (q->api_version >= 10 ? 16 : 0), q);
+		} else {
+			u8 cnt[4] = {};
+			if (bpf_probe_read_user(cnt, sizeof(cnt), body) != 0)
+				return;
+			if ((s32)read_be32(cnt) <= 0)
+				return;
+			kafka_read_string(body + 4, q);
+		}
+		return;
+	default:
+		return;
+	}
+	// topics array length (int32, or a one-byte compact length), then the name
+	kafka_read_string(body + off + (q->flexible ? 1 : 4), q);
+}
+
+static __noinline void kafka_track_request(struct msghdr *msg, u64 sk)
+{
+	u32 size = 0;
+	u8 *hdr = kafka_send_base(msg, &size);
+	if (!hdr || size < KAFKA_REQ_HDR_LEN || size >= KAFKA_MAX_MSG_LEN)
+		return;
+	u8 h[KAFKA_REQ_HDR_LEN] = {};
+	if (bpf_probe_read_user(h, sizeof(h), hdr) != 0)
+		return;
+	u16 api_key = kafka_be16(&h[0]);
+	u16 version = kafka_be16(&h[2]);
+	if (api_key != KAFKA_API_PRODUCE && api_key != KAFKA_API_FETCH && api_key != KAFKA_API_METADATA)
+		return;
+	if (version > 20)
+		return;
+
+	u32 zero = 0;
+	struct kafka_req *q = bpf_map_lookup_elem(&kafka_req_scratch, &zero);
+	if (!q)
+		return;
+	q->start_ns = bpf_ktime_get_ns();
+	q->size = size;
+	q->api_key = api_key;
+	q->api_version = version;
+	q->flexible = kafka_is_flexible(api_key, version);
+	q->topic_len = 0;
+	q->topic[0] = '\0';
+
+	// client_id stays a nullable int16 string even in flexible headers,
+	// which then add a tagged-field section (empty in practice).
+	u16 client_len = kafka_be16(&h[8]);
+	u32 body_off = KAFKA_REQ_HDR_LEN + (client_len == 0xffff ? 0 : client_len);
+	if (q->flexible)
+		body_off += 1;
+	if (body_off < size)
+		kafka_read_topic(hdr + body_off, q);
+
+	struct kafka_req_key key = { .sk = sk, .correlation_id = (s32)read_be32(&h[4]) };
+	bpf_map_update_elem(&kafka_requests, &key, q, BPF_ANY);
+}
+
+// kafka_error_offset returns where the response's error code sits, relative
+// to the end of the response header, or -1 when it is not at a fixed place.
+static __always_inline s32 kafka_error_offset(const struct kafka_req *q)
+{
+	u32 tl = q->topic_len;
+	switch (q->api_key) {
+	case KAFKA_API_PRODUCE:
+		// responses[0]: name, partitions[0]: index int32, error_code
+		if (q->flexible)
+			return 1 + 1 + tl + 1 + 4;
+		return 4 + 2 + tl + 4 + 4;
+	case KAFKA_API_FETCH:
+		// v7+: throttle_time_ms, error_code; before that, per partition
+		if (q->api_version >= 7)
+			return 4;
+		return (q->api_version >= 1 ? 4 : 0) + 4 + 2 + tl + 4 + 4;
+	default:
+		return -1;
+	}
+}
+
+static __noinline void kafka_emit_response(void *ctx, u8 *base, u64 len, u64 sk)
+{
+	if (!base)
+		return;
+	// A read of exactly the size prefix means the next one starts at the
+	// correlation id.
+	if (len == 4) {
+		u8 one = 1;
+		bpf_map_update_elem(&kafka_size_read, &sk, &one, BPF_ANY);
+		return;
+	}
+	u32 skip = 4;
+	if (bpf_map_lookup_elem(&kafka_size_read, &sk)) {
+		skip = 0;
+		bpf_map_delete_elem(&kafka_size_read, &sk);
+	}
+	if (len < skip + 4)
+		return;
+	u8 c[4] = {};
+	if (bpf_probe_read_user(c, sizeof(c), base + skip) != 0)
+		return;
+	struct kafka_req_key key = { .sk = sk, .correlation_id = (s32)read_be32(c) };
+	struct kafka_req *q = bpf_map_lookup_elem(&kafka_requests, &key);
+	if (!q)
+		return;
+
+	s16 err = 0;
+	s32 eoff = kafka_error_offset(q);
+	if (eoff >= 0) {
+		u64 at = skip + 4 + (q->flexible ? 1 : 0) + (u32)eoff;
+		u8 eb[2] = {};
+		if (at + 2 <= len && bpf_probe_read_user(eb, sizeof(eb), base + at) == 0)
+			err = (s16)kafka_be16(eb);
+	}
+
+	u32 pid = bpf_get_current_pid_tgid() >> 32;
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	struct event *e = get_event_buf();
+	if (e) {
+		e->timestamp = bpf_ktime_get_ns();
+		e->pid = pid;
+		e->type = EVENT_KAFKA_OP;
+		e->latency_ns = calc_latency(q->start_ns);
+		e->error = err;
+		e->bytes = q->size;
+		e->tcp_state = q->api_version;
+		e->correlation_id = (u32)key.correlation_id;
+		if (q->api_key == KAFKA_API_PRODUCE)
+			__builtin_memcpy(e->target, "Produce", 8);
+		else if (q->api_key == KAFKA_API_FETCH)
+			__builtin_memcpy(e->target, "Fetch", 6);
+		else
+			__builtin_memcpy(e->target, "Metadata", 9);
+		bpf_probe_read_kernel_str(e->details, sizeof(e->details), q->topic);
+		fill_event_peer(e);
+		capture_user_stack(ctx, pid, tid, e);
+		bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	}
+	bpf_map_delete_elem(&kafka_requests, &key);
+}
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_kafka_tcp_sendmsg(struct pt_regs *ctx)
+{
+	struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+	if (!http_should_trace() || !kafka_sock_on_port(sk))
+		return 0;
+	kafka_track_request((struct msghdr *)PT_REGS_PARM2(ctx), (u64)sk);
+	return 0;
+}
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_kafka_tcp_recvmsg(struct pt_regs *ctx)
+{
+	struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+	if (!kafka_sock_on_port(sk))
+		return 0;
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	u64 avail = 0;
+	void *base = msghdr_user_base(msg, &avail);
+	if (!base)
+		return 0;
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state st = { .buf = (u64)base, .conn = (u64)sk };
+	bpf_map_update_elem(&kafka_recv_base, &key, &st, BPF_ANY);
+	return 0;
+}
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_kafka_tcp_recvmsg(struct pt_regs *ctx)
+{
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state *st = bpf_map_lookup_elem(&kafka_recv_base, &key);
+	if (!st)
+		return 0;
+	u8 *base = (u8 *)st->buf;
+	u64 sk = st->conn;
+	bpf_map_delete_elem(&kafka_recv_base, &key);
+
+	s32 ret = (s32)PT_REGS_RC(ctx);
+	if (ret <= 0)
+		return 0;
+	kafka_emit_response(ctx, base, (u64)ret, sk);
+	return 0;
+}
+
+#else
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_kafka_tcp_sendmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_kafka_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_kafka_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+#endif
@@ -47,6 +47,10 @@ enum probe_pair {
 	PAIR_KAFKA_TOPIC_NEW,
 	PAIR_KAFKA_PRODUCE,
 	PAIR_KAFKA_POLL,
+	PAIR_COMPACTION,
+	PAIR_COMPACTION_ORDER,
+	PAIR_VFS_READ_MISS,
+	PAIR_VFS_MNT,
 };
 
 struct pair_key {
@@ -100,6 +104,122 @@ struct {
 	__type(value, struct usdt_probe);
 } usdt_probes SEC(".maps");
 
+#define CUSTOM_UPROBE_LABEL_LEN 64
+#define CUSTOM_UPROBE_SYMBOL_LEN 64
+
+struct custom_uprobe {
+	char label[CUSTOM_UPROBE_LABEL_LEN];
+	char symbol[CUSTOM_UPROBE_SYMBOL_LEN];
+	u8 paired;
+	u8 _pad[7];
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_HASH);
+	__uint(max_entries, 1024);
+	__type(key, u64);
+	__type(value, struct custom_uprobe);
+} custom_uprobes SEC(".maps");
+
+struct custom_uprobe_key {
+	u64 pid_tgid;
+	u64 cookie;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 8192);
+	__type(key, struct custom_uprobe_key);
+	__type(value, u64);
+} custom_uprobe_starts SEC(".maps");
+
+#define PY_CALL_MAX_DEPTH 32
+
+struct py_call_key {
+	u64 pid_tgid;
+	u32 depth;
+	u32 _pad;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 8192);
+	__type(key, u64);
+	__type(value, u32);
+} py_call_depth SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 65536);
+	__type(key, struct py_call_key);
+	__type(value, u64);
+} py_call_starts SEC(".maps");
+
+struct py_gc_start {
+	u64 ts;
+	u64 generation;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, struct py_gc_start);
+} py_gc_starts SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, u64);
+} uv_poll_exit SEC(".maps");
+
+struct go_dns_lookup {
+	u64 start_ns;
+	char name[MAX_STRING_LEN];
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, struct go_dns_lookup);
+} go_dns_lookups SEC(".maps");
+
+struct dns_sock_query {
+	u64 start_ns;
+	u32 server_ip;
+	u8  server_ip6[16];
+	u32 _pad;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, struct dns_sock_query);
+} dns_sock_queries SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, u64);
+} dns_sock_recv SEC(".maps");
+
+// Start of the zero-window episodes of one socket, keyed by sock_cookie.
+struct tcp_zero_window {
+	u64 peer_start_ns;
+	u64 local_start_ns;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 16384);
+	__type(key, u64);
+	__type(value, struct tcp_zero_window);
+} tcp_zero_windows SEC(".maps");
+
 struct dns_flow_key {
 	u64 cgroup_id;
 	u32 txid;
@@ -236,6 +356,7 @@ struct tcp_peer {
 	u16 _pad;
 	u8  saddr6[16];
 	u8  daddr6[16];
+	u64 sock_id;
 };
 struct {
 	__uint(type, BPF_MAP_TYPE_LRU_HASH);
@@ -259,6 +380,79 @@ struct {
 	__type(value, u8);
 } quic_seen SEC(".maps");
 
+/* Per-pod veth throughput, counted by the tc programs in throughput.c. dir
+ * and class are from the pod's point of view; keep in sync with
+ * internal/ebpf/tracer/throughput.go. */
+#define TP_DIR_INGRESS 0
+#define TP_DIR_EGRESS 1
+#define TP_CLASS_EXTERNAL 0
+#define TP_CLASS_CLUSTER 1
+#define TP_CLASS_NODE 2
+
+struct throughput_key {
+	u32 ifindex;
+	u8  dir;
+	u8  class;
+	u16 _pad;
+};
+struct throughput_value {
+	u64 bytes;
+	u64 packets;
+};
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_HASH);
+	__uint(max_entries, 1024);
+	__type(key, struct throughput_key);
+	__type(value, struct throughput_value);
+} pod_throughput SEC(".maps");
+
+/* Regular-file reads per traced cgroup, for the page-cache hit ratio: every
+ * vfs_read is counted here, not only those slow enough to be emitted. A miss
+ * is a read that added folios to the page cache. Keep in sync with
+ * internal/ebpf/tracer/pagecache.go. */
+struct read_cache_value {
+	u64 reads;
+	u64 miss_reads;
+	u64 miss_folios;
+};
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_HASH);
+	__uint(max_entries, 1024);
+	__type(key, u64);
+	__type(value, struct read_cache_value);
+} read_cache_stats SEC(".maps");
+
+/* inotify/fanotify activity per traced cgroup: watch and mark registrations,
+ * those refused by the per-user limits, and events the watching group's full
+ * queue dropped. Keep in sync with internal/ebpf/tracer/fsnotify.go. */
+struct fsnotify_value {
+	u64 watches;
+	u64 marks;
+	u64 limit_hits;
+	u64 overflows;
+};
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_HASH);
+	__uint(max_entries, 1024);
+	__type(key, u64);
+	__type(value, struct fsnotify_value);
+} fsnotify_stats SEC(".maps");
+
+/* Remote address classes, filled from PODTRACE_CLUSTER_CIDRS, the node's own
+ * addresses and PODTRACE_NODE_CIDRS. IPv4 is stored IPv4-mapped so one trie
+ * serves both families; unmatched addresses are external. */
+struct cidr_class_key {
+	u32 prefixlen;
+	u8  addr[16];
+};
+struct {
+	__uint(type, BPF_MAP_TYPE_LPM_TRIE);
+	__uint(max_entries, 256);
+	__uint(map_flags, BPF_F_NO_PREALLOC);
+	__type(key, struct cidr_class_key);
+	__type(value, u8);
+} cidr_classes SEC(".maps");
+
 #define QUIC_PKT_CAP 1500
 struct quic_initial_record {
 	u64 timestamp;
@@ -330,6 +524,15 @@ struct {
 	__type(value, u32);
 } cgroup_filter_enabled SEC(".maps");
 
+/* Set to 1 by an armed podtrace (--armed): every probe stays attached but
+ * no event is emitted until `podtrace arm --fire` clears it. */
+struct {
+	__uint(type, BPF_MAP_TYPE_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, u32);
+} emit_paused SEC(".maps");
+
 struct cpu_quota {
 	u64 quota_us;
 	u64 period_us;
@@ -366,6 +569,7 @@ struct connect_addr {
 	u16 port_be;
 	u32 addr_be;
 	u8 addr6[16];
+	u64 sock_id;
 };
 
 struct {
@@ -389,6 +593,22 @@ struct {
 	__type(value, u64);
 } sched_pending_blocked SEC(".maps");
 
+/* On-CPU time per thread of the traced cgroups, for the per-thread CPU
+ * report. Keep in sync with internal/ebpf/tracer/threadcpu.go. */
+struct thread_cpu_value {
+	u64 cgroup_id;
+	u32 pid;
+	u32 _pad;
+	u64 on_cpu_ns;
+};
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 8192);
+	__type(key, u32);
+	__type(value, struct thread_cpu_value);
+} thread_cpu_time SEC(".maps");
+
 struct {
 	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
 	__uint(max_entries, 1);
@@ -518,6 +738,163 @@ struct {
 	__type(value, char[HTTP_SCAN_BUF_SIZE]);
 } http_scan_buf SEC(".maps");
 
+#define PG_KIND_QUERY 'Q'
+#define PG_KIND_PARSE 'P'
+#define PG_KIND_BIND  'B'
+#define PG_STMT_NAME_LEN 64
+#define PG_SQLSTATE_LEN 5
+
+struct pg_query {
+	u64 start_ns;
+	u8 kind;
+	u8 seen_response;
+	u8 failed;
+	char sqlstate[PG_SQLSTATE_LEN];
+	char stmt[PG_STMT_NAME_LEN];
+	char query[MAX_STRING_LEN];
+};
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, struct pg_query);
+} pg_queries SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct pg_query);
+} pg_query_scratch SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 1024);
+	__type(key, u64);
+	__type(value, struct ssl_read_state);
+} pg_recv_base SEC(".maps");
+
+#define MYSQL_KIND_QUERY   'Q'
+#define MYSQL_KIND_PREPARE 'P'
+#define MYSQL_KIND_EXECUTE 'E'
+#define MYSQL_SQLSTATE_LEN 5
+#define MYSQL_VERSION_LEN  32
+
+struct mysql_query {
+	u64 start_ns;
+	u8 kind;
+	u8 failed;
+	u16 err_code;
+	u32 stmt_id;
+	char sqlstate[MYSQL_SQLSTATE_LEN];
+	char query[MAX_STRING_LEN];
+};
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, struct mysql_query);
+} mysql_queries SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct mysql_query);
+} mysql_query_scratch SEC(".maps");
+
+/* Prepared statement ids are per connection: key is the socket plus id. */
+struct mysql_stmt_key {
+	u64 sk;
+	u32 stmt_id;
+	u32 _pad;
+};
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, struct mysql_stmt_key);
+	__type(value, char[MAX_STRING_LEN]);
+} mysql_stmts SEC(".maps");
+
+/* Server version from the initial handshake, keyed by socket. */
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, char[MYSQL_VERSION_LEN]);
+} mysql_conns SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 1024);
+	__type(key, u64);
+	__type(value, struct ssl_read_state);
+} mysql_recv_base SEC(".maps");
+
+struct kafka_req_key {
+	u64 sk;
+	s32 correlation_id;
+	u32 _pad;
+};
+
+struct kafka_req {
+	u64 start_ns;
+	u32 size;
+	u16 api_key;
+	u16 api_version;
+	u16 topic_len;
+	u8 flexible;
+	u8 _pad;
+	char topic[MAX_STRING_LEN];
+};
+/* Kafka clients pipeline requests, so pending ones are keyed by socket and
+ * correlation id rather than by socket alone. */
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, struct kafka_req_key);
+	__type(value, struct kafka_req);
+} kafka_requests SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_PERCPU_ARRAY);
+	__uint(max_entries, 1);
+	__type(key, u32);
+	__type(value, struct kafka_req);
+} kafka_req_scratch SEC(".maps");
+
+/* Sockets whose last read was a bare 4-byte size prefix (Java, franz-go):
+ * the next read starts at the correlation id. */
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, u8);
+} kafka_size_read SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 1024);
+	__type(key, u64);
+	__type(value, struct ssl_read_state);
+} kafka_recv_base SEC(".maps");
+
+/* Opaque AMQP connection ids (first-seen timestamp), keyed by socket or SSL
+ * pointer so the pointer itself never reaches userspace. */
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 4096);
+	__type(key, u64);
+	__type(value, u64);
+} amqp_conns SEC(".maps");
+
+struct {
+	__uint(type, BPF_MAP_TYPE_LRU_HASH);
+	__uint(max_entries, 1024);
+	__type(key, u64);
+	__type(value, struct ssl_read_state);
+} amqp_recv_base SEC(".maps");
+
 struct {
 	__uint(type, BPF_MAP_TYPE_LRU_HASH);
 	__uint(max_entries, 1024);
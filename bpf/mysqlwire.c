@@ -0,0 +1,275 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+#include "protocols.h"
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+
+// MySQL/MariaDB client/server protocol on plaintext port-3306 sockets, for
+// clients that do not link libmysqlclient (JDBC, go-sql-driver). A
+// COM_QUERY, COM_STMT_PREPARE or COM_STMT_EXECUTE packet starts the timer;
+// the OK or ERR packet that answers it, or the EOF that ends its result
+// set, stops it.
+
+static __always_inline u32 mysql_le24(const u8 *b)
+{
+	return (u32)b[0] | ((u32)b[1] << 8) | ((u32)b[2] << 16);
+}
+
+static __always_inline u32 mysql_le32(const u8 *b)
+{
+	return (u32)b[0] | ((u32)b[1] << 8) | ((u32)b[2] << 16) | ((u32)b[3] << 24);
+}
+
+static __always_inline int mysql_sock_on_port(struct sock *sk)
+{
+	if (!sk)
+		return 0;
+	u16 dport = __builtin_bswap16(BPF_CORE_READ(sk, __sk_common.skc_dport));
+	return dport == MYSQL_DEFAULT_PORT;
+}
+
+static __noinline void mysql_track_command(void *base, u64 avail, u64 sk)
+{
+	if (!base || avail <= MYSQL_PKT_HDR_LEN + 1)
+		return;
+	u8 hdr[MYSQL_PKT_HDR_LEN + 1] = {};
+	if (bpf_probe_read_user(hdr, sizeof(hdr), base) != 0)
+		return;
+	// Every command starts a new exchange, so its sequence id is 0.
+	u32 len = mysql_le24(hdr);
+	if (hdr[3] != 0 || len == 0 || len >= MYSQL_MAX_PKT_LEN)
+		return;
+	u8 cmd = hdr[4];
+	char *body = (char *)base + MYSQL_PKT_HDR_LEN + 1;
+
+	if (cmd == MYSQL_COM_STMT_CLOSE) {
+		u8 id[4] = {};
+		if (bpf_probe_read_user(id, sizeof(id), body) != 0)
+			return;
+		struct mysql_stmt_key k = { .sk = sk, .stmt_id = mysql_le32(id) };
+		bpf_map_delete_elem(&mysql_stmts, &k);
+		return;
+	}
+	if (cmd != MYSQL_COM_QUERY && cmd != MYSQL_COM_STMT_PREPARE && cmd != MYSQL_COM_STMT_EXECUTE)
+		return;
+
+	u32 zero = 0;
+	struct mysql_query *q = bpf_map_lookup_elem(&mysql_query_scratch, &zero);
+	if (!q)
+		return;
+	q->start_ns = bpf_ktime_get_ns();
+	q->failed = 0;
+	q->err_code = 0;
+	q->stmt_id = 0;
+	q->sqlstate[0] = '\0';
+	q->query[0] = '\0';
+
+	if (cmd == MYSQL_COM_STMT_EXECUTE) {
+		// The statement text was sent by an earlier prepare on this
+		// connection; an unknown id is passed on for userspace to label.
+		q->kind = MYSQL_KIND_EXECUTE;
+		u8 id[4] = {};
+		if (bpf_probe_read_user(id, sizeof(id), body) != 0)
+			return;
+		q->stmt_id = mysql_le32(id);
+		struct mysql_stmt_key k = { .sk = sk, .stmt_id = q->stmt_id };
+		char *text = bpf_map_lookup_elem(&mysql_stmts, &k);
+		if (text)
+			bpf_probe_read_kernel_str(q->query, sizeof(q->query), text);
+	} else {
+		q->kind = cmd == MYSQL_COM_QUERY ? MYSQL_KIND_QUERY : MYSQL_KIND_PREPARE;
+		// The query text is not NUL-terminated on the wire; read at most
+		// the payload and terminate it here.
+		u32 n = len - 1;
+		if (n > sizeof(q->query) - 1)
+			n = sizeof(q->query) - 1;
+		if (n == 0 || bpf_probe_read_user(q->query, n & (MAX_STRING_LEN - 1), body) != 0)
+			return;
+		q->query[n & (MAX_STRING_LEN - 1)] = '\0';
+		char c = q->query[0];
+		if (!((c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || c == '(' || c == ' ' || c == '/'))
+			return;
+	}
+	bpf_map_update_elem(&mysql_queries, &sk, q, BPF_ANY);
+}
+
+// mysql_record_greeting stores the server version from a protocol-10
+// handshake so query events on the connection can name the server.
+static __noinline void mysql_record_greeting(void *base, u64 len, u64 sk)
+{
+	if (len <= MYSQL_PKT_HDR_LEN + 1)
+		return;
+	u8 hdr[MYSQL_PKT_HDR_LEN + 1] = {};
+	if (bpf_probe_read_user(hdr, sizeof(hdr), base) != 0)
+		return;
+	if (hdr[3] != 0 || hdr[4] != MYSQL_HANDSHAKE_V10)
+		return;
+	char ver[MYSQL_VERSION_LEN] = {};
+	if (bpf_probe_read_user_str(ver, sizeof(ver), (char *)base + MYSQL_PKT_HDR_LEN + 1) <= 1)
+		return;
+	bpf_map_update_elem(&mysql_conns, &sk, ver, BPF_ANY);
+}
+
+// mysql_reply_done reports whether the bytes just received finish the reply:
+// a first packet that is OK or ERR, or a result set whose last packet is an
+// EOF (or, with CLIENT_DEPRECATE_EOF, an OK packet carrying the EOF header).
+static __always_inline int mysql_reply_done(void *base, u64 len, struct mysql_query *q, u8 first)
+{
+	if (first == MYSQL_ERR_PACKET) {
+		q->failed = 1;
+		u8 err[2 + 1 + MYSQL_SQLSTATE_LEN] = {};
+		if (bpf_probe_read_user(err, sizeof(err), (char *)base + MYSQL_PKT_HDR_LEN + 1) == 0) {
+			q->err_code = (u16)err[0] | ((u16)err[1] << 8);
+			if (err[2] == '#')
+				__builtin_memcpy(q->sqlstate, &err[3], MYSQL_SQLSTATE_LEN);
+		}
+		return 1;
+	}
+	if (first == MYSQL_OK_PACKET) {
+		if (q->kind == MYSQL_KIND_PREPARE) {
+			// COM_STMT_PREPARE_OK: status byte, then the statement id.
+			u8 id[4] = {};
+			if (bpf_probe_read_user(id, sizeof(id), (char *)base + MYSQL_PKT_HDR_LEN + 1) == 0)
+				q->stmt_id = mysql_le32(id);
+		}
+		return 1;
+	}
+	// Result set: look for the terminating EOF (9 bytes on the wire) or
+	// OK-as-EOF (at least 11 bytes) at the end of this read.
+	if (len >= 9) {
+		u8 eof[9] = {};
+		if (bpf_probe_read_user(eof, sizeof(eof), (char *)base + len - 9) == 0 &&
+		    eof[0] == 5 && eof[1] == 0 && eof[2] == 0 && eof[4] == MYSQL_EOF_PACKET)
+			return 1;
+	}
+	if (len >= 11) {
+		u8 ok[11] = {};
+		if (bpf_probe_read_user(ok, sizeof(ok), (char *)base + len - 11) == 0 &&
+		    ok[0] == 7 && ok[1] == 0 && ok[2] == 0 && ok[4] == MYSQL_EOF_PACKET)
+			return 1;
+	}
+	return 0;
+}
+
+static __noinline void mysql_emit_response(void *ctx, void *base, u64 len, u64 sk)
+{
+	if (!base || len < MYSQL_PKT_HDR_LEN + 1)
+		return;
+	struct mysql_query *q = bpf_map_lookup_elem(&mysql_queries, &sk);
+	if (!q) {
+		mysql_record_greeting(base, len, sk);
+		return;
+	}
+	u8 hdr[MYSQL_PKT_HDR_LEN + 1] = {};
+	if (bpf_probe_read_user(hdr, sizeof(hdr), base) != 0)
+		return;
+	// Only the first packet of the reply (sequence id 1) can be OK or ERR;
+	// later reads are rows of a result set.
+	u8 first = hdr[3] == 1 ? hdr[4] : 0x01;
+	if (!mysql_reply_done(base, len, q, first))
+		return;
+
+	if (q->kind == MYSQL_KIND_PREPARE && !q->failed) {
+		struct mysql_stmt_key k = { .sk = sk, .stmt_id = q->stmt_id };
+		bpf_map_update_elem(&mysql_stmts, &k, q->query, BPF_ANY);
+	}
+
+	u32 pid = bpf_get_current_pid_tgid() >> 32;
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	struct event *e = get_event_buf();
+	if (e) {
+		e->timestamp = bpf_ktime_get_ns();
+		e->pid = pid;
+		e->type = EVENT_DB_QUERY;
+		e->latency_ns = calc_latency(q->start_ns);
+		e->error = q->failed ? (q->err_code ? (s32)q->err_code : -1) : 0;
+		e->bytes = q->stmt_id;
+		e->tcp_state = 0;
+		bpf_probe_read_kernel_str(e->target, sizeof(e->target), q->query);
+
+		// details: "mysql:<kind>[ sqlstate=XXXXX][ server=<version>]"
+		__builtin_memcpy(e->details, "mysql:", 6);
+		e->details[6] = q->kind;
+		u32 pos = 7;
+		if (q->failed && q->sqlstate[0]) {
+			__builtin_memcpy(&e->details[7], " sqlstate=", 10);
+			__builtin_memcpy(&e->details[17], q->sqlstate, MYSQL_SQLSTATE_LEN);
+			pos = 22;
+		}
+		char *ver = bpf_map_lookup_elem(&mysql_conns, &sk);
+		if (ver && ver[0]) {
+			__builtin_memcpy(&e->details[pos], " server=", 8);
+			bpf_probe_read_kernel_str(&e->details[pos + 8], MYSQL_VERSION_LEN, ver);
+		} else {
+			e->details[pos] = '\0';
+		}
+		fill_event_peer(e);
+		capture_user_stack(ctx, pid, tid, e);
+		bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	}
+	bpf_map_delete_elem(&mysql_queries, &sk);
+}
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_mysql_tcp_sendmsg(struct pt_regs *ctx)
+{
+	struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+	if (!http_should_trace() || !mysql_sock_on_port(sk))
+		return 0;
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	u64 avail = 0;
+	void *base = msghdr_user_base(msg, &avail);
+	mysql_track_command(base, avail, (u64)sk);
+	return 0;
+}
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_mysql_tcp_recvmsg(struct pt_regs *ctx)
+{
+	struct sock *sk = (struct sock *)PT_REGS_PARM1(ctx);
+	if (!mysql_sock_on_port(sk))
+		return 0;
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	u64 avail = 0;
+	void *base = msghdr_user_base(msg, &avail);
+	if (!base)
+		return 0;
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state st = { .buf = (u64)base, .conn = (u64)sk };
+	bpf_map_update_elem(&mysql_recv_base, &key, &st, BPF_ANY);
+	return 0;
+}
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_mysql_tcp_recvmsg(struct pt_regs *ctx)
+{
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state *st = bpf_map_lookup_elem(&mysql_recv_base, &key);
+	if (!st)
+		return 0;
+	void *base = (void *)st->buf;
+	u64 sk = st->conn;
+	bpf_map_delete_elem(&mysql_recv_base, &key);
+
+	s32 ret = (s32)PT_REGS_RC(ctx);
+	if (ret <= 0)
+		return 0;
+	mysql_emit_response(ctx, base, (u64)ret, sk);
+	return 0;
+}
+
+#else
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_mysql_tcp_sendmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_mysql_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_mysql_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+#endif
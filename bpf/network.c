@@ -18,6 +18,7 @@ static __noinline void stash_tcp_peer(struct pt_regs *ctx, u32 pair)
 	char buf[MAX_STRING_LEN] = {};
 	struct tcp_peer peer = {};
 	peer.family = family;
+	peer.sock_id = (u64)sk;
 	peer.dport = __builtin_bswap16(dport_be);
 	peer.sport = BPF_CORE_READ(sk, __sk_common.skc_num); /* host order already */
 	if (family == AF_INET) {
@@ -60,6 +61,7 @@ int kprobe_tcp_connect(struct pt_regs *ctx) {
 			ca.family = AF_INET;
 			ca.port_be = sa.sin_port;
 			ca.addr_be = sa.sin_addr.s_addr;
+			ca.sock_id = (u64)PT_REGS_PARM1(ctx);
 			bpf_map_update_elem(&connect_addrs, &key, &ca, BPF_ANY);
 		}
 	}
@@ -86,6 +88,7 @@ int kprobe_tcp_v6_connect(struct pt_regs *ctx) {
 			ca.family = AF_INET6;
 			ca.port_be = sa6.sin6_port;
 			__builtin_memcpy(ca.addr6, sa6.sin6_addr, 16);
+			ca.sock_id = (u64)PT_REGS_PARM1(ctx);
 			bpf_map_update_elem(&connect_addrs, &key, &ca, BPF_ANY);
 		}
 	}
@@ -123,6 +126,7 @@ int kretprobe_tcp_v6_connect(struct pt_regs *ctx) {
 	if (ca && ca->family == AF_INET6) {
 		u16 port = __builtin_bswap16(ca->port_be);
 		format_ipv6_port(ca->addr6, port, e->target);
+		e->sock_id = ca->sock_id;
 		struct dns_v6key k6 = {};
 		__builtin_memcpy(k6.addr, ca->addr6, 16);
 		char *resolved = bpf_map_lookup_elem(&dns_resolved6, &k6);
@@ -169,6 +173,7 @@ int kretprobe_tcp_connect(struct pt_regs *ctx) {
 		u16 port = __builtin_bswap16(ca->port_be);
 		u32 ip = __builtin_bswap32(ca->addr_be);
 		format_ip_port(ip, port, e->target);
+		e->sock_id = ca->sock_id;
 		u32 ip_be = ca->addr_be;
 		char *resolved = bpf_map_lookup_elem(&dns_resolved, &ip_be);
 		if (resolved) {
@@ -505,7 +510,8 @@ int tracepoint_tcp_retransmit_skb(void *ctx) {
 	e->latency_ns = 0;
 	e->error = 0;
 	e->bytes = 0;
-	e->tcp_state = 0;
+	// The socket's state tells a SYN (SYN_SENT) from a data retransmit.
+	e->tcp_state = args_local.state;
 	e->target[0] = '\0';
 
 	if (args_local.family == AF_INET6) {
@@ -524,6 +530,177 @@ int tracepoint_tcp_retransmit_skb(void *ctx) {
 	return 0;
 }
 
+struct tcp_probe_args {
+	unsigned short common_type;
+	unsigned char common_flags;
+	unsigned char common_preempt_count;
+	int common_pid;
+	__u8 saddr[28];
+	__u8 daddr[28];
+	__u16 sport;
+	__u16 dport;
+	__u16 family;
+	__u32 mark;
+	__u16 data_len;
+	__u32 snd_nxt;
+	__u32 snd_una;
+	__u32 snd_cwnd;
+	__u32 ssthresh;
+	__u32 snd_wnd;
+	__u32 srtt;
+	__u32 rcv_wnd;
+	__u64 sock_cookie;
+};
+_Static_assert(__builtin_offsetof(struct tcp_probe_args, daddr) == 36, "tcp_probe: daddr must be at offset 36");
+_Static_assert(__builtin_offsetof(struct tcp_probe_args, dport) == 66, "tcp_probe: dport must be at offset 66");
+_Static_assert(__builtin_offsetof(struct tcp_probe_args, family) == 68, "tcp_probe: family must be at offset 68");
+_Static_assert(__builtin_offsetof(struct tcp_probe_args, snd_wnd) == 96, "tcp_probe: snd_wnd must be at offset 96");
+_Static_assert(__builtin_offsetof(struct tcp_probe_args, rcv_wnd) == 104, "tcp_probe: rcv_wnd must be at offset 104");
+_Static_assert(__builtin_offsetof(struct tcp_probe_args, sock_cookie) == 112, "tcp_probe: sock_cookie must be at offset 112");
+
+#define TCP_ZERO_WINDOW_PEER  1
+#define TCP_ZERO_WINDOW_LOCAL 2
+
+static __always_inline void emit_tcp_zero_window(struct tcp_probe_args *a, u32 side, u64 start_ns)
+{
+	struct event *e = get_event_buf_unfiltered();
+	if (!e) {
+		return;
+	}
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = bpf_get_current_pid_tgid() >> 32;
+	e->type = EVENT_TCP_ZERO_WINDOW;
+	e->latency_ns = calc_latency(start_ns);
+	e->error = 0;
+	e->bytes = 0;
+	e->tcp_state = side;
+	e->target[0] = '\0';
+	// daddr is a sockaddr_in or sockaddr_in6 of the remote end.
+	if (a->family == AF_INET6) {
+		format_ipv6_port(&a->daddr[8], a->dport, e->target);
+	} else {
+		u32 daddr = ((u32)a->daddr[4] << 24) |
+		            ((u32)a->daddr[5] << 16) |
+		            ((u32)a->daddr[6] << 8) |
+		            (u32)a->daddr[7];
+		if (daddr != 0) {
+			format_ip_port(daddr, a->dport, e->target);
+		}
+	}
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+}
+
+// tcp_probe fires for every segment received on an established connection
+// and carries both windows: snd_wnd is what the peer advertised, rcv_wnd
+// what this end last advertised. A window that closes and reopens is one
+// stall, emitted when it reopens with its duration: a closed snd_wnd means
+// the remote receiver stopped reading, a closed rcv_wnd that this pod did.
+SEC("tp/tcp/tcp_probe")
+int tracepoint_tcp_probe(void *ctx) {
+	struct tcp_probe_args a;
+	if (bpf_probe_read_kernel(&a, sizeof(a), ctx) != 0) {
+		return 0;
+	}
+	u64 key = a.sock_cookie;
+	struct tcp_zero_window *zw = bpf_map_lookup_elem(&tcp_zero_windows, &key);
+	if (!zw) {
+		if (a.snd_wnd != 0 && a.rcv_wnd != 0) {
+			return 0;
+		}
+		struct tcp_zero_window init = {};
+		u64 now = bpf_ktime_get_ns();
+		if (a.snd_wnd == 0) {
+			init.peer_start_ns = now;
+		}
+		if (a.rcv_wnd == 0) {
+			init.local_start_ns = now;
+		}
+		bpf_map_update_elem(&tcp_zero_windows, &key, &init, BPF_NOEXIST);
+		return 0;
+	}
+
+	u64 now = bpf_ktime_get_ns();
+	if (a.snd_wnd == 0) {
+		if (!zw->peer_start_ns) {
+			zw->peer_start_ns = now;
+		}
+	} else if (zw->peer_start_ns) {
+		emit_tcp_zero_window(&a, TCP_ZERO_WINDOW_PEER, zw->peer_start_ns);
+		zw->peer_start_ns = 0;
+	}
+	if (a.rcv_wnd == 0) {
+		if (!zw->local_start_ns) {
+			zw->local_start_ns = now;
+		}
+	} else if (zw->local_start_ns) {
+		emit_tcp_zero_window(&a, TCP_ZERO_WINDOW_LOCAL, zw->local_start_ns);
+		zw->local_start_ns = 0;
+	}
+	if (!zw->peer_start_ns && !zw->local_start_ns) {
+		bpf_map_delete_elem(&tcp_zero_windows, &key);
+	}
+	return 0;
+}
+
+struct tcp_retransmit_synack_args {
+	unsigned short common_type;
+	unsigned char common_flags;
+	unsigned char common_preempt_count;
+	int common_pid;
+	const void *skaddr;
+	const void *req;
+	__u16 sport;
+	__u16 dport;
+	__u16 family;
+	__u8 saddr[4];
+	__u8 daddr[4];
+	__u8 saddr_v6[16];
+	__u8 daddr_v6[16];
+};
+_Static_assert(__builtin_offsetof(struct tcp_retransmit_synack_args, dport) == 26, "tcp_retransmit_synack: dport must be at offset 26");
+_Static_assert(__builtin_offsetof(struct tcp_retransmit_synack_args, family) == 28, "tcp_retransmit_synack: family must be at offset 28");
+_Static_assert(__builtin_offsetof(struct tcp_retransmit_synack_args, daddr) == 34, "tcp_retransmit_synack: daddr must be at offset 34");
+_Static_assert(__builtin_offsetof(struct tcp_retransmit_synack_args, daddr_v6) == 54, "tcp_retransmit_synack: daddr_v6 must be at offset 54");
+
+#define TCP_SYN_RECV_STATE 3
+
+// A listener retransmitting its SYN-ACK: the client's handshake is not
+// completing. Reported as a retransmit in SYN_RECV so it is counted with
+// the SYN retransmits of outgoing connects.
+SEC("tp/tcp/tcp_retransmit_synack")
+int tracepoint_tcp_retransmit_synack(void *ctx) {
+	struct tcp_retransmit_synack_args args_local;
+	if (bpf_probe_read_kernel(&args_local, sizeof(args_local), ctx) != 0) {
+		return 0;
+	}
+	struct event *e = get_event_buf_unfiltered();
+	if (!e) {
+		return 0;
+	}
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = bpf_get_current_pid_tgid() >> 32;
+	e->type = EVENT_TCP_RETRANS;
+	e->latency_ns = 0;
+	e->error = 0;
+	e->bytes = 0;
+	e->tcp_state = TCP_SYN_RECV_STATE;
+	e->target[0] = '\0';
+
+	if (args_local.family == AF_INET6) {
+		format_ipv6_port(args_local.daddr_v6, args_local.dport, e->target);
+	} else {
+		u32 daddr = ((u32)args_local.daddr[0] << 24) |
+		            ((u32)args_local.daddr[1] << 16) |
+		            ((u32)args_local.daddr[2] << 8) |
+		            (u32)args_local.daddr[3];
+		if (daddr != 0) {
+			format_ip_port(daddr, args_local.dport, e->target);
+		}
+	}
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
 struct net_dev_xmit_args {
 	unsigned short common_type;
 	unsigned char common_flags;
@@ -708,6 +885,7 @@ int uretprobe_http_request(struct pt_regs *ctx) {
 	} else {
 		e->target[0] = '\0';
 	}
+	fill_event_peer(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -911,6 +1089,10 @@ int uretprobe_SSL_connect(struct pt_regs *ctx) {
 	e->bytes = 0;
 	e->tcp_state = 0;
 	e->target[0] = '\0';
+	// The peer of the handshake's connection, from the ClientHello or
+	// ServerHello just sent on it, lets userspace tie the handshake to
+	// its connect and the requests that follow.
+	fill_event_peer(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -950,6 +1132,7 @@ int uretprobe_SSL_accept(struct pt_regs *ctx) {
 	e->bytes = 0;
 	e->tcp_state = 0;
 	e->target[0] = '\0';
+	fill_event_peer(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -989,6 +1172,7 @@ int uretprobe_SSL_do_handshake(struct pt_regs *ctx) {
 	e->bytes = 0;
 	e->tcp_state = 0;
 	e->target[0] = '\0';
+	fill_event_peer(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -1028,6 +1212,7 @@ int uretprobe_gnutls_handshake(struct pt_regs *ctx) {
 	e->bytes = 0;
 	e->tcp_state = 0;
 	e->target[0] = '\0';
+	fill_event_peer(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -1067,6 +1252,7 @@ int uretprobe_mbedtls_ssl_handshake(struct pt_regs *ctx) {
 	e->bytes = 0;
 	e->tcp_state = 0;
 	e->target[0] = '\0';
+	fill_event_peer(e);
 	capture_user_stack(ctx, pid, tid, e);
 	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
 	bpf_map_delete_elem(&start_times, &key);
@@ -165,7 +165,8 @@ static __always_inline void h3_adapter_emit(struct h3_txn_record *rec,
 	rec->pid = bpf_get_current_pid_tgid() >> 32;
 	rec->is_client = is_client;
 	rec->flags = flags;
-	bpf_ringbuf_output(&h3_txn_events, rec, sizeof(*rec), 0);
+	if (!emission_paused())
+		bpf_ringbuf_output(&h3_txn_events, rec, sizeof(*rec), 0);
 }
 
 static __always_inline void h3_adapter_first_inbound(u64 conn, u64 stream_id)
@@ -252,7 +253,7 @@ int uprobe_nghttp3_submit_response(struct pt_regs *ctx)
 static __always_inline void h3_capture_stream_bytes(u64 conn, u64 stream_id,
 						    u64 src, u64 srclen)
 {
-	if (!src || srclen == 0)
+	if (!src || srclen == 0 || emission_paused())
 		return;
 	struct h3_adapter_stream_key k = h3_adapter_key(conn, stream_id);
 	u32 captured = 0;
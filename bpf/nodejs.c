@@ -0,0 +1,55 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+
+// libuv spends idle time inside uv__io_poll. The gap between one poll
+// returning and the next starting is the time the loop ran callbacks and
+// could not service I/O; a long gap is a blocked event loop.
+SEC("uprobe/uv__io_poll")
+int uprobe_uv_io_poll(struct pt_regs *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u64 *last = bpf_map_lookup_elem(&uv_poll_exit, &pid_tgid);
+	if (!last)
+		return 0;
+	u64 lag = calc_latency(*last);
+	if (lag < EVENT_LOOP_LAG_MIN_NS)
+		return 0;
+
+	struct event *e = get_event_buf();
+	if (!e)
+		return 0;
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = pid_tgid >> 32;
+	e->type = EVENT_LOOP_LAG;
+	e->latency_ns = lag;
+	e->error = 0;
+	e->bytes = 0;
+	e->tcp_state = (u32)pid_tgid;
+	e->details[0] = 0;
+	bpf_get_current_comm(e->target, sizeof(e->target));
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+SEC("uretprobe/uv__io_poll")
+int uretprobe_uv_io_poll(struct pt_regs *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u64 now = bpf_ktime_get_ns();
+	bpf_map_update_elem(&uv_poll_exit, &pid_tgid, &now, BPF_ANY);
+	return 0;
+}
+
+// A loop that leaves uv_run is stopped, not blocked; forget its last poll so
+// the time until the next uv_run is not reported as lag.
+SEC("uretprobe/uv_run")
+int uretprobe_uv_run(struct pt_regs *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	bpf_map_delete_elem(&uv_poll_exit, &pid_tgid);
+	return 0;
+}
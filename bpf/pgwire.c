@@ -0,0 +1,227 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+#include "protocols.h"
+#ifdef PODTRACE_VMLINUX_FROM_BTF
+
+// PostgreSQL frontend/backend protocol v3 on plaintext sockets, for clients
+// that do not go through libpq (pgx, tokio-postgres, JDBC). A Query, Parse or
+// Bind message sent on a socket starts the timer; the ReadyForQuery that
+// ends the server's reply stops it.
+
+static __always_inline int pg_plausible_sql(char c)
+{
+	return (c >= 'A' && c <= 'Z') || (c >= 'a' && c <= 'z') || c == '(' ||
+	       c == ' ' || c == '\t' || c == '\n' || c == '/' || c == '-';
+}
+
+static __noinline void pg_track_request(void *base, u64 avail, u64 sk)
+{
+	if (!base || avail <= PG_MSG_HDR_LEN)
+		return;
+	u8 hdr[PG_MSG_HDR_LEN] = {};
+	if (bpf_probe_read_user(hdr, sizeof(hdr), base) != 0)
+		return;
+	u8 kind = hdr[0];
+	if (kind != PG_KIND_QUERY && kind != PG_KIND_PARSE && kind != PG_KIND_BIND)
+		return;
+	u32 len = read_be32(&hdr[1]);
+	if (len < 4 || len >= PG_MAX_MSG_LEN)
+		return;
+
+	u32 zero = 0;
+	struct pg_query *q = bpf_map_lookup_elem(&pg_query_scratch, &zero);
+	if (!q)
+		return;
+	q->start_ns = bpf_ktime_get_ns();
+	q->kind = kind;
+	q->seen_response = 0;
+	q->failed = 0;
+	q->sqlstate[0] = '\0';
+	q->stmt[0] = '\0';
+	q->query[0] = '\0';
+
+	char *body = (char *)base + PG_MSG_HDR_LEN;
+	long n;
+	if (kind == PG_KIND_QUERY) {
+		n = bpf_probe_read_user_str(q->query, sizeof(q->query), body);
+		if (n <= 1 || !pg_plausible_sql(q->query[0]))
+			return;
+	} else if (kind == PG_KIND_PARSE) {
+		// Parse: statement name, then the query text.
+		n = bpf_probe_read_user_str(q->stmt, sizeof(q->stmt), body);
+		if (n <= 0 || n > PG_STMT_NAME_LEN)
+			return;
+		long m = bpf_probe_read_user_str(q->query, sizeof(q->query), body + n);
+		if (m <= 1 || !pg_plausible_sql(q->query[0]))
+			return;
+	} else {
+		// Bind: portal name, then the prepared statement it executes. The
+		// statement text was sent earlier; userspace maps the name back.
+		n = bpf_probe_read_user_str(q->stmt, sizeof(q->stmt), body);
+		if (n <= 0 || n > PG_STMT_NAME_LEN)
+			return;
+		if (bpf_probe_read_user_str(q->stmt, sizeof(q->stmt), body + n) <= 0)
+			return;
+	}
+	bpf_map_update_elem(&pg_queries, &sk, q, BPF_ANY);
+}
+
+// pg_scan_errors walks the first backend messages of a reply and records an
+// ErrorResponse together with its SQLSTATE code.
+static __noinline void pg_scan_errors(void *base, u64 len, struct pg_query *q)
+{
+	u64 off = 0;
+	u32 i;
+	for (i = 0; i < PG_RESPONSE_WALK_MAX; i++) {
+		if (off + PG_MSG_HDR_LEN > len)
+			return;
+		u8 hdr[PG_MSG_HDR_LEN] = {};
+		if (bpf_probe_read_user(hdr, sizeof(hdr), (char *)base + off) != 0)
+			return;
+		if (hdr[0] == 'Z')
+			return;
+		if (hdr[0] == 'E') {
+			q->failed = 1;
+			u8 fields[PG_ERROR_SCAN_LEN] = {};
+			if (bpf_probe_read_user(fields, sizeof(fields), (char *)base + off + PG_MSG_HDR_LEN) != 0)
+				return;
+			u32 j;
+#pragma unroll
+			for (j = 0; j < PG_ERROR_SCAN_LEN - PG_SQLSTATE_LEN - 1; j++) {
+				if (fields[j] == 'C' && (j == 0 || fields[j - 1] == 0)) {
+					__builtin_memcpy(q->sqlstate, &fields[j + 1], PG_SQLSTATE_LEN);
+					break;
+				}
+			}
+			return;
+		}
+		u32 mlen = read_be32(&hdr[1]);
+		if (mlen < 4)
+			return;
+		off += 1 + (u64)mlen;
+	}
+}
+
+static __noinline void pg_emit_response(void *ctx, void *base, u64 len, u64 sk)
+{
+	struct pg_query *q = bpf_map_lookup_elem(&pg_queries, &sk);
+	if (!q || !base || len == 0)
+		return;
+	if (!q->seen_response) {
+		q->seen_response = 1;
+		pg_scan_errors(base, len, q);
+	}
+
+	// ReadyForQuery: 'Z', int32 5, transaction status (I, T or E).
+	if (len < 6)
+		return;
+	u8 tail[6] = {};
+	if (bpf_probe_read_user(tail, sizeof(tail), (char *)base + len - 6) != 0)
+		return;
+	if (tail[0] != 'Z' || tail[1] != 0 || tail[2] != 0 || tail[3] != 0 || tail[4] != 5)
+		return;
+	if (tail[5] != 'I' && tail[5] != 'T' && tail[5] != 'E')
+		return;
+
+	u32 pid = bpf_get_current_pid_tgid() >> 32;
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	struct event *e = get_event_buf();
+	if (e) {
+		e->timestamp = bpf_ktime_get_ns();
+		e->pid = pid;
+		e->type = EVENT_DB_QUERY;
+		e->latency_ns = calc_latency(q->start_ns);
+		e->error = q->failed ? -1 : 0;
+		e->bytes = 0;
+		e->tcp_state = 0;
+		if (q->kind == PG_KIND_BIND)
+			bpf_probe_read_kernel_str(e->target, sizeof(e->target), q->stmt);
+		else
+			bpf_probe_read_kernel_str(e->target, sizeof(e->target), q->query);
+
+		// details: "pg:<kind>[ sqlstate=XXXXX][ stmt=<name>]"
+		__builtin_memcpy(e->details, "pg:", 3);
+		e->details[3] = q->kind;
+		u32 pos = 4;
+		if (q->failed && q->sqlstate[0]) {
+			__builtin_memcpy(&e->details[4], " sqlstate=", 10);
+			__builtin_memcpy(&e->details[14], q->sqlstate, PG_SQLSTATE_LEN);
+			pos = 19;
+		}
+		if (q->kind != PG_KIND_QUERY && q->stmt[0]) {
+			__builtin_memcpy(&e->details[pos], " stmt=", 6);
+			bpf_probe_read_kernel_str(&e->details[pos + 6], PG_STMT_NAME_LEN, q->stmt);
+		} else {
+			e->details[pos] = '\0';
+		}
+		fill_event_peer(e);
+		capture_user_stack(ctx, pid, tid, e);
+		bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	}
+	bpf_map_delete_elem(&pg_queries, &sk);
+}
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_pg_tcp_sendmsg(struct pt_regs *ctx)
+{
+	if (!http_should_trace())
+		return 0;
+	u64 sk = (u64)PT_REGS_PARM1(ctx);
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	u64 avail = 0;
+	void *base = msghdr_user_base(msg, &avail);
+	pg_track_request(base, avail, sk);
+	return 0;
+}
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_pg_tcp_recvmsg(struct pt_regs *ctx)
+{
+	u64 sk = (u64)PT_REGS_PARM1(ctx);
+	if (!bpf_map_lookup_elem(&pg_queries, &sk))
+		return 0;
+	struct msghdr *msg = (struct msghdr *)PT_REGS_PARM2(ctx);
+	u64 avail = 0;
+	void *base = msghdr_user_base(msg, &avail);
+	if (!base)
+		return 0;
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state st = { .buf = (u64)base, .conn = sk };
+	bpf_map_update_elem(&pg_recv_base, &key, &st, BPF_ANY);
+	return 0;
+}
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_pg_tcp_recvmsg(struct pt_regs *ctx)
+{
+	u64 key = bpf_get_current_pid_tgid();
+	struct ssl_read_state *st = bpf_map_lookup_elem(&pg_recv_base, &key);
+	if (!st)
+		return 0;
+	void *base = (void *)st->buf;
+	u64 sk = st->conn;
+	bpf_map_delete_elem(&pg_recv_base, &key);
+
+	s32 ret = (s32)PT_REGS_RC(ctx);
+	if (ret <= 0)
+		return 0;
+	pg_emit_response(ctx, base, (u64)ret, sk);
+	return 0;
+}
+
+#else
+
+SEC("kprobe/tcp_sendmsg")
+int kprobe_pg_tcp_sendmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kprobe/tcp_recvmsg")
+int kprobe_pg_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+SEC("kretprobe/tcp_recvmsg")
+int kretprobe_pg_tcp_recvmsg(struct pt_regs *ctx) { return 0; }
+
+#endif
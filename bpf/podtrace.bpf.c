@@ -8,10 +8,14 @@
 #include "resources.c"
 #include "network.c"
 #include "dns.c"
+#include "dns_resolvers.c"
 #include "http3.c"
+#include "throughput.c"
 #include "filesystem.c"
+#include "fsnotify.c"
 #include "cpu.c"
 #include "memory.c"
+#include "compaction.c"
 #include "syscalls.c"
 #include "database.c"
 #include "redis.c"
@@ -20,6 +24,10 @@
 #include "fastcgi.c"
 #include "grpc.c"
 #include "http.c"
+#include "pgwire.c"
+#include "mysqlwire.c"
+#include "kafkawire.c"
+#include "amqp.c"
 #include "h2.c"
 #include "gotls.c"
 #include "grpcgo.c"
@@ -29,5 +37,8 @@
 #include "quiche.c"
 #include "crypto.c"
 #include "usdt.c"
+#include "custom_uprobe.c"
+#include "python.c"
+#include "nodejs.c"
 
 char LICENSE[] SEC("license") = "GPL";
@@ -8,6 +8,8 @@
 #define MEMCACHED_DEFAULT_PORT  11211
 #define KAFKA_DEFAULT_PORT      9092
 #define GRPC_DEFAULT_PORT       50051
+#define MYSQL_DEFAULT_PORT      3306
+#define AMQP_DEFAULT_PORT       5672
 
 /* === FastCGI Record Types === */
 #define FCGI_VERSION_1       1
@@ -57,6 +59,54 @@
 #define HTTP_TRANSPORT_H2_TLS    3  /* HTTP/2 over TLS (Go crypto/tls) */
 #define HTTP_TRANSPORT_H3        5  /* HTTP/3 over QUIC (always encrypted): H3 bit | TLS bit */
 
+/* === PostgreSQL wire protocol (bpf/pgwire.c) === */
+#define PG_MSG_HDR_LEN        5         /* type byte + int32 length */
+#define PG_MAX_MSG_LEN        (1 << 24) /* larger frontend messages are not Postgres */
+#define PG_RESPONSE_WALK_MAX  16
+#define PG_ERROR_SCAN_LEN     64
+
+/* === MySQL client/server protocol (bpf/mysqlwire.c) === */
+#define MYSQL_PKT_HDR_LEN        4     /* int<3> payload length + sequence id */
+#define MYSQL_MAX_PKT_LEN        0xffffff
+#define MYSQL_COM_QUERY          0x03
+#define MYSQL_COM_STMT_PREPARE   0x16
+#define MYSQL_COM_STMT_EXECUTE   0x17
+#define MYSQL_COM_STMT_CLOSE     0x19
+#define MYSQL_HANDSHAKE_V10      0x0a
+#define MYSQL_OK_PACKET          0x00
+#define MYSQL_EOF_PACKET         0xfe
+#define MYSQL_ERR_PACKET         0xff
+
+/* === Kafka wire protocol (bpf/kafkawire.c) === */
+#define KAFKA_API_PRODUCE        0
+#define KAFKA_API_FETCH          1
+#define KAFKA_API_METADATA       3
+#define KAFKA_REQ_HDR_LEN        10    /* api_key, api_version, correlation_id, client_id length */
+#define KAFKA_MAX_MSG_LEN        (100 << 20)
+#define KAFKA_PRODUCE_FLEX_VER   9     /* first flexible (compact-encoded) versions */
+#define KAFKA_FETCH_FLEX_VER     12
+#define KAFKA_METADATA_FLEX_VER  9
+#define KAFKA_FETCH_TOPIC_ID_VER 13    /* Fetch names topics by UUID from here on */
+
+/* === AMQP 0-9-1 (bpf/amqp.c) === */
+#define AMQP_FRAME_METHOD        1
+#define AMQP_FRAME_HDR_LEN       7     /* type, channel int16, size int32 */
+#define AMQP_FRAME_END           0xce
+#define AMQP_FRAME_WALK_MAX      8
+#define AMQP_MAX_FRAME_LEN       (128 << 20)
+#define AMQP_CLASS_BASIC         60
+#define AMQP_CLASS_CONFIRM       85
+#define AMQP_BASIC_CONSUME       20
+#define AMQP_BASIC_CONSUME_OK    21
+#define AMQP_BASIC_PUBLISH       40
+#define AMQP_BASIC_DELIVER       60
+#define AMQP_BASIC_ACK           80
+#define AMQP_BASIC_REJECT        90
+#define AMQP_BASIC_NACK          120
+#define AMQP_CONFIRM_SELECT      10
+#define AMQP_DIR_OUT             0
+#define AMQP_DIR_IN              1
+
 /* === FastCGI NV Pair Helpers === */
 #define FCGI_NV_LEN_4BYTE    0x80
 
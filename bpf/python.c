@@ -0,0 +1,170 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+#include "events.h"
+#include "helpers.h"
+
+static __always_inline void py_call_push(void)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u32 zero = 0;
+	u32 *depth = bpf_map_lookup_elem(&py_call_depth, &pid_tgid);
+	if (!depth) {
+		bpf_map_update_elem(&py_call_depth, &pid_tgid, &zero, BPF_ANY);
+		depth = bpf_map_lookup_elem(&py_call_depth, &pid_tgid);
+		if (!depth)
+			return;
+	}
+	u32 d = *depth;
+	if (d < PY_CALL_MAX_DEPTH) {
+		struct py_call_key key = {.pid_tgid = pid_tgid, .depth = d};
+		u64 now = bpf_ktime_get_ns();
+		bpf_map_update_elem(&py_call_starts, &key, &now, BPF_ANY);
+	}
+	*depth = d + 1;
+}
+
+// py_call_pop returns the latency of the innermost open call, or 0 when
+// its entry was not recorded (deeper than PY_CALL_MAX_DEPTH or attached
+// mid-call).
+static __always_inline u64 py_call_pop(void)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	u32 *depth = bpf_map_lookup_elem(&py_call_depth, &pid_tgid);
+	if (!depth || *depth == 0)
+		return 0;
+	u32 d = *depth - 1;
+	*depth = d;
+	if (d >= PY_CALL_MAX_DEPTH)
+		return 0;
+	struct py_call_key key = {.pid_tgid = pid_tgid, .depth = d};
+	u64 *start = bpf_map_lookup_elem(&py_call_starts, &key);
+	if (!start)
+		return 0;
+	u64 latency = calc_latency(*start);
+	bpf_map_delete_elem(&py_call_starts, &key);
+	return latency;
+}
+
+static __always_inline struct event *py_event(u32 type, u64 latency)
+{
+	struct event *e = get_event_buf();
+	if (!e)
+		return NULL;
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = bpf_get_current_pid_tgid() >> 32;
+	e->type = type;
+	e->latency_ns = latency;
+	e->error = 0;
+	e->bytes = 0;
+	e->tcp_state = 0;
+	e->target[0] = 0;
+	e->details[0] = 0;
+	return e;
+}
+
+SEC("uprobe/py_function_entry")
+int uprobe_py_function_entry(struct pt_regs *ctx)
+{
+	py_call_push();
+	return 0;
+}
+
+// function__return(filename, funcname, lineno): only calls slower than
+// MIN_LATENCY_NS are emitted, which keeps hot interpreters cheap to trace.
+SEC("uprobe/py_function_return")
+int uprobe_py_function_return(struct pt_regs *ctx)
+{
+	u64 latency = py_call_pop();
+	if (latency < MIN_LATENCY_NS)
+		return 0;
+
+	u64 cookie = bpf_get_attach_cookie(ctx);
+	struct usdt_probe *p = bpf_map_lookup_elem(&usdt_probes, &cookie);
+	if (!p || p->nargs < 3)
+		return 0;
+
+	struct event *e = py_event(EVENT_PY_CALL, latency);
+	if (!e)
+		return 0;
+	u64 filename = usdt_read_arg(ctx, &p->args[0]);
+	u64 funcname = usdt_read_arg(ctx, &p->args[1]);
+	e->tcp_state = (u32)usdt_read_arg(ctx, &p->args[2]);
+	bpf_probe_read_user_str(e->target, sizeof(e->target), (void *)funcname);
+	bpf_probe_read_user_str(e->details, sizeof(e->details), (void *)filename);
+
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	capture_user_stack(ctx, e->pid, tid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+// Fallback for interpreters built without --with-dtrace: frame evaluation
+// timing only, without function names.
+SEC("uprobe/PyEval_EvalFrameDefault")
+int uprobe_py_eval_frame(struct pt_regs *ctx)
+{
+	py_call_push();
+	return 0;
+}
+
+SEC("uretprobe/PyEval_EvalFrameDefault")
+int uretprobe_py_eval_frame(struct pt_regs *ctx)
+{
+	u64 latency = py_call_pop();
+	if (latency < MIN_LATENCY_NS)
+		return 0;
+
+	struct event *e = py_event(EVENT_PY_CALL, latency);
+	if (!e)
+		return 0;
+	u32 tid = (u32)bpf_get_current_pid_tgid();
+	capture_user_stack(ctx, e->pid, tid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
+// gc__start(generation)
+SEC("uprobe/py_gc_start")
+int uprobe_py_gc_start(struct pt_regs *ctx)
+{
+	u64 cookie = bpf_get_attach_cookie(ctx);
+	struct usdt_probe *p = bpf_map_lookup_elem(&usdt_probes, &cookie);
+	if (!p)
+		return 0;
+
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct py_gc_start st = {.ts = bpf_ktime_get_ns()};
+	if (p->nargs >= 1)
+		st.generation = usdt_read_arg(ctx, &p->args[0]) & 0xffffffff;
+	bpf_map_update_elem(&py_gc_starts, &pid_tgid, &st, BPF_ANY);
+	return 0;
+}
+
+// gc__done(collected)
+SEC("uprobe/py_gc_done")
+int uprobe_py_gc_done(struct pt_regs *ctx)
+{
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	struct py_gc_start *st = bpf_map_lookup_elem(&py_gc_starts, &pid_tgid);
+	if (!st)
+		return 0;
+	u64 latency = calc_latency(st->ts);
+	u32 generation = (u32)st->generation;
+	bpf_map_delete_elem(&py_gc_starts, &pid_tgid);
+
+	u64 cookie = bpf_get_attach_cookie(ctx);
+	struct usdt_probe *p = bpf_map_lookup_elem(&usdt_probes, &cookie);
+
+	struct event *e = py_event(EVENT_PY_GC, latency);
+	if (!e)
+		return 0;
+	e->tcp_state = generation;
+	if (p && p->nargs >= 1) {
+		s64 collected = (s64)usdt_read_arg(ctx, &p->args[0]);
+		e->bytes = collected > 0 ? (u64)collected : 0;
+	}
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
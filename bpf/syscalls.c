@@ -85,6 +85,46 @@ int tracepoint_sched_process_fork(void *ctx) {
 	return 0;
 }
 
+/* Leading fields of kernel_siginfo_t. Its layout is the userspace siginfo
+ * ABI, so no CO-RE is needed: si_addr is the first member of the _sigfault
+ * union, which starts at offset 16 on 64-bit. */
+struct coredump_siginfo {
+	int si_signo;
+	int si_errno;
+	int si_code;
+	int _pad;
+	u64 si_addr;
+};
+_Static_assert(__builtin_offsetof(struct coredump_siginfo, si_addr) == 16, "siginfo: si_addr must be at offset 16");
+
+/* do_coredump runs in the crashing thread before the process exits, so the
+ * user stack and /proc/<pid> still describe the fault. */
+SEC("kprobe/do_coredump")
+int kprobe_do_coredump(struct pt_regs *ctx) {
+	struct coredump_siginfo info = {};
+	if (bpf_probe_read_kernel(&info, sizeof(info), (void *)PT_REGS_PARM1(ctx)) != 0) {
+		return 0;
+	}
+
+	struct event *e = get_event_buf();
+	if (!e) {
+		return 0;
+	}
+	u64 pid_tgid = bpf_get_current_pid_tgid();
+	e->timestamp = bpf_ktime_get_ns();
+	e->pid = pid_tgid >> 32;
+	e->type = EVENT_CRASH;
+	e->latency_ns = 0;
+	e->error = info.si_signo;
+	e->bytes = info.si_addr;
+	e->tcp_state = (u32)info.si_code;
+	__builtin_memcpy(e->target, e->comm, COMM_LEN);
+
+	capture_user_stack(ctx, e->pid, (u32)pid_tgid, e);
+	bpf_ringbuf_output(&events, e, sizeof(*e), 0);
+	return 0;
+}
+
 SEC("kprobe/do_sys_openat2")
 int kprobe_do_sys_openat2(struct pt_regs *ctx) {
 	u32 pid = bpf_get_current_pid_tgid() >> 32;
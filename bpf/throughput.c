@@ -0,0 +1,61 @@
+// SPDX-License-Identifier: GPL-2.0
+
+#include "common.h"
+#include "maps.h"
+
+#define ETH_HLEN 14
+#define ETH_P_IP 0x0800
+#define ETH_P_IPV6 0x86DD
+#define TCX_NEXT -1
+
+/* Counts one packet seen on the host side of a pod's veth. The packet's
+ * remote address sits at v4_off/v6_off into the IP header: the destination
+ * for traffic leaving the pod, the source for traffic entering it. */
+static __always_inline void throughput_count(struct __sk_buff *skb, u8 dir,
+					     u32 v4_off, u32 v6_off) {
+	struct cidr_class_key ck = {};
+	ck.prefixlen = 128;
+	u16 proto = bpf_ntohs((u16)skb->protocol);
+	if (proto == ETH_P_IP) {
+		ck.addr[10] = 0xff;
+		ck.addr[11] = 0xff;
+		if (bpf_skb_load_bytes(skb, ETH_HLEN + v4_off, &ck.addr[12], 4) < 0)
+			return;
+	} else if (proto == ETH_P_IPV6) {
+		if (bpf_skb_load_bytes(skb, ETH_HLEN + v6_off, ck.addr, 16) < 0)
+			return;
+	} else {
+		return;
+	}
+
+	struct throughput_key k = {};
+	k.ifindex = skb->ifindex;
+	k.dir = dir;
+	k.class = TP_CLASS_EXTERNAL;
+	u8 *cls = bpf_map_lookup_elem(&cidr_classes, &ck);
+	if (cls)
+		k.class = *cls;
+
+	struct throughput_value *v = bpf_map_lookup_elem(&pod_throughput, &k);
+	if (v) {
+		v->bytes += skb->len;
+		v->packets += 1;
+		return;
+	}
+	struct throughput_value init = {.bytes = skb->len, .packets = 1};
+	bpf_map_update_elem(&pod_throughput, &k, &init, BPF_NOEXIST);
+}
+
+/* Ingress of the host-side veth is traffic the pod sends. */
+SEC("tc")
+int pod_veth_ingress(struct __sk_buff *skb) {
+	throughput_count(skb, TP_DIR_EGRESS, 16, 24);
+	return TCX_NEXT;
+}
+
+/* Egress of the host-side veth is traffic delivered to the pod. */
+SEC("tc")
+int pod_veth_egress(struct __sk_buff *skb) {
+	throughput_count(skb, TP_DIR_INGRESS, 12, 8);
+	return TCX_NEXT;
+}
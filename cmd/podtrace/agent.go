@@ -10,8 +10,10 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 
 	"github.com/podtrace/podtrace/internal/agent"
+	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/ebpf"
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/system"
 	"github.com/podtrace/podtrace/pkg/tracer"
 )
 
@@ -31,6 +33,10 @@ type agentOptions struct {
 	healthAddr           string
 	statusReportInterval time.Duration
 	backendMode          string
+	artifactMirror       string
+	artifactChecksums    string
+	artifactCache        string
+	debugAddr            string
 }
 
 func newAgentCmd() *cobra.Command {
@@ -46,10 +52,19 @@ single tracer instance (one set of cgroups, union of filters, per-CR
 exporter routing).`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx := ctrl.SetupSignalHandler()
+			// The backend factory reads these when it loads the eBPF program.
+			config.ArtifactMirror = opts.artifactMirror
+			config.ArtifactChecksums = opts.artifactChecksums
+			config.ArtifactCacheDir = opts.artifactCache
 			resolved, err := toAgentOptions(opts)
 			if err != nil {
 				return err
 			}
+			stopDebugServer, err := startDebugServer(opts.debugAddr)
+			if err != nil {
+				return err
+			}
+			defer stopDebugServer()
 			return agent.Run(ctx, resolved)
 		},
 	}
@@ -68,6 +83,14 @@ exporter routing).`,
 		"How often to patch PodTrace.status.nodeStatus (default: 30s)")
 	cmd.Flags().StringVar(&opts.backendMode, "backend", backendModeReal,
 		"Tracer backend mode: 'real' loads the eBPF program (production); 'noop' skips kernel attachment and exercises only the control plane (dev/kind smoke tests)")
+	cmd.Flags().StringVar(&opts.debugAddr, "debug-addr", config.DebugAddr,
+		"Serve pprof and runtime stats for the agent process on this loopback address, e.g. 127.0.0.1:6061 (env PODTRACE_DEBUG_ADDR)")
+	cmd.Flags().StringVar(&opts.artifactMirror, "artifact-mirror", config.ArtifactMirror,
+		"HTTP(S) base URL to fetch this kernel's BTF and this version's BPF object from at startup (env PODTRACE_ARTIFACT_MIRROR)")
+	cmd.Flags().StringVar(&opts.artifactChecksums, "artifact-checksums", config.ArtifactChecksums,
+		"SHA256SUMS file pinning every artifact fetched from --artifact-mirror; required to fetch (env PODTRACE_ARTIFACT_CHECKSUMS)")
+	cmd.Flags().StringVar(&opts.artifactCache, "artifact-cache", config.ArtifactCacheDir,
+		"Directory verified artifacts are cached in, used when the mirror is unreachable (env PODTRACE_ARTIFACT_CACHE)")
 
 	return cmd
 }
@@ -86,6 +109,11 @@ func toAgentOptions(c *agentOptions) (agent.Options, error) {
 	if err != nil {
 		return agent.Options{}, err
 	}
+	// A noop backend attaches nothing, so it needs no claim on the node.
+	var lockDir string
+	if c.backendMode != backendModeNoop {
+		lockDir = agent.NodeLockDir(config.PinDir)
+	}
 	return agent.Options{
 		NodeName:             node,
 		SystemNamespace:      c.systemNamespace,
@@ -93,6 +121,7 @@ func toAgentOptions(c *agentOptions) (agent.Options, error) {
 		MetricsAddr:          c.metricsAddr,
 		HealthAddr:           c.healthAddr,
 		StatusReportInterval: c.statusReportInterval,
+		NodeLockDir:          lockDir,
 		BackendFactory:       factory,
 	}, nil
 }
@@ -111,9 +140,10 @@ func selectBackendFactory(mode string) (func() (tracer.TracerBackend, error), er
 }
 
 func agentBackendFactory() (tracer.TracerBackend, error) {
+	prepareKernelArtifacts()
 	tr, err := ebpf.NewTracer()
 	if err != nil {
-		return nil, err
+		return nil, system.ExplainLSMDenial(err)
 	}
 	if deny, ok := tr.(interface{ SetDenyWhenNoTargets(bool) }); ok {
 		deny.SetDenyWhenNoTargets(true)
@@ -184,6 +214,16 @@ func (a *ebpfBackendAdapter) SetEnabledCategories(categories []string) error {
 	return g.SetEnabledCategories(categories)
 }
 
+// MapFill implements the optional pkg/tracer.MapFillReporter interface
+// for the agent's self watchdog.
+func (a *ebpfBackendAdapter) MapFill() map[string]float64 {
+	r, ok := a.tr.(tracer.MapFillReporter)
+	if !ok {
+		return nil
+	}
+	return r.MapFill()
+}
+
 func noopBackendFactory() (tracer.TracerBackend, error) {
 	return agent.NewNoopBackend(), nil
 }
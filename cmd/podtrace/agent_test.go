@@ -4,6 +4,9 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/podtrace/podtrace/internal/agent"
+	"github.com/podtrace/podtrace/internal/config"
 )
 
 func TestNewAgentCmd_Metadata(t *testing.T) {
@@ -163,6 +166,16 @@ func TestToAgentOptions_PropagatesBackendChoice(t *testing.T) {
 	if reflect.ValueOf(opts.BackendFactory).Pointer() != reflect.ValueOf(noopBackendFactory).Pointer() {
 		t.Error("backendMode=noop must inject noopBackendFactory")
 	}
+	if opts.NodeLockDir != "" {
+		t.Errorf("noop backend takes the node lock %q; it attaches nothing", opts.NodeLockDir)
+	}
+	opts, err = toAgentOptions(&agentOptions{systemNamespace: "ns"})
+	if err != nil {
+		t.Fatalf("toAgentOptions: %v", err)
+	}
+	if want := agent.NodeLockDir(config.PinDir); opts.NodeLockDir != want {
+		t.Errorf("real backend NodeLockDir = %q, want %q", opts.NodeLockDir, want)
+	}
 }
 
 func TestToAgentOptions_RejectsInvalidBackend(t *testing.T) {
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/annotation"
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+var annotationSocket string
+
+func newAnnotateCmd() *cobra.Command {
+	var socket string
+	cmd := &cobra.Command{
+		Use:   "annotate <text>",
+		Short: "Mark a point in a running trace (e.g. \"deployed v2\")",
+		Long: `Sends a marker to a podtrace started with --annotation-socket. The marker is
+recorded in the event timeline and shows up in the report (with before/after
+comparison), in exports and as a span when tracing is enabled.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if socket == "" {
+				return errors.New("--socket (or PODTRACE_ANNOTATION_SOCKET) is required")
+			}
+			if err := annotation.Send(socket, strings.Join(args, " ")); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "annotation recorded")
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&socket, "socket", config.AnnotationSocket, "Annotation socket of the running podtrace (env PODTRACE_ANNOTATION_SOCKET)")
+	return cmd
+}
+
+// startAnnotationServer opens --annotation-socket, if set, and injects every
+// marker it receives into eventChan until ctx is done.
+func startAnnotationServer(ctx context.Context, eventChan chan<- *events.Event) error {
+	if annotationSocket == "" {
+		return nil
+	}
+	srv, err := annotation.Listen(annotationSocket)
+	if err != nil {
+		return err
+	}
+	logger.Info("Accepting annotations", zap.String("socket", annotationSocket))
+	go srv.Serve(ctx, eventChan)
+	return nil
+}
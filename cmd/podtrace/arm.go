@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/annotation"
+	"github.com/podtrace/podtrace/internal/arm"
+	"github.com/podtrace/podtrace/internal/config"
+	tracerpkg "github.com/podtrace/podtrace/internal/ebpf/tracer"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/metricsexporter"
+)
+
+var (
+	armed     bool
+	armSocket string
+)
+
+// validateArmed checks --armed against the rest of the run.
+func validateArmed(trigger bool) error {
+	if !armed {
+		return nil
+	}
+	if trigger {
+		return errors.New("--armed cannot be combined with --trigger: a trigger condition is measured on events, which an armed podtrace does not emit; have the alert run `podtrace arm --fire` instead")
+	}
+	if config.LowPrivilege {
+		return errors.New("--armed needs the BPF probes; it cannot run with --low-privilege")
+	}
+	if armSocket == "" {
+		return errors.New("--armed requires --arm-socket (or PODTRACE_ARM_SOCKET)")
+	}
+	return nil
+}
+
+// armSwitch turns on the emission of an armed tracer, once.
+type armSwitch struct {
+	gate tracerpkg.EmissionGate
+	// annotations receives a marker when it fires, for the timeline.
+	annotations chan<- *events.Event
+	mu          sync.Mutex
+	fired       chan struct{}
+	armedAt     time.Time
+}
+
+func newArmSwitch(gate tracerpkg.EmissionGate, annotations chan<- *events.Event) *armSwitch {
+	return &armSwitch{gate: gate, annotations: annotations, fired: make(chan struct{}), armedAt: time.Now()}
+}
+
+// Fire starts emission; firing again does nothing.
+func (s *armSwitch) Fire() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Fired() {
+		return nil
+	}
+	if err := s.gate.SetEmission(true); err != nil {
+		return fmt.Errorf("enable event emission: %w", err)
+	}
+	close(s.fired)
+	logger.Info("Armed capture fired; events are being emitted", zap.Duration("armed_for", time.Since(s.armedAt)))
+	fmt.Println("=== Armed capture fired — full capture started ===")
+	select {
+	case s.annotations <- annotation.NewEvent("armed capture fired", time.Now()):
+	default:
+	}
+	return nil
+}
+
+// Fired reports whether Fire has started emission.
+func (s *armSwitch) Fired() bool {
+	select {
+	case <-s.fired:
+		return true
+	default:
+		return false
+	}
+}
+
+// wait blocks until the switch fires or ctx is done.
+func (s *armSwitch) wait(ctx context.Context) error {
+	select {
+	case <-s.fired:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startArmServer opens --arm-socket for `podtrace arm` until ctx is done.
+func startArmServer(ctx context.Context, sw *armSwitch) error {
+	srv, err := arm.Listen(armSocket)
+	if err != nil {
+		return err
+	}
+	logger.Info("Armed: probes attached, emission off; run `podtrace arm --fire` to start capturing",
+		zap.String("socket", armSocket))
+	go srv.Serve(ctx, sw)
+	return nil
+}
+
+// gateOnArm drops what reaches in before sw fires: the BPF programs emit
+// nothing until then, but userspace sources such as the resource monitor do.
+func gateOnArm(ctx context.Context, in <-chan *events.Event, out chan<- *events.Event, sw *armSwitch) {
+	defer close(out)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-in:
+			if !ok {
+				return
+			}
+			if event == nil || !sw.Fired() {
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- event:
+			default:
+				logger.Warn("Armed event channel full, dropping event",
+					zap.String("event_type", event.TypeString()),
+					zap.Uint32("pid", event.PID))
+				metricsexporter.RecordFilteredEventDrop()
+			}
+		}
+	}
+}
+
+func newArmCmd() *cobra.Command {
+	var socket string
+	var fire bool
+	cmd := &cobra.Command{
+		Use:   "arm",
+		Short: "Fire a podtrace started with --armed, or show whether it has fired",
+		Long: `A podtrace started with --armed has every probe loaded and attached but its
+BPF programs emit nothing. ` + "`podtrace arm --fire`" + ` switches emission on at once,
+with no attach delay, e.g. from an alert webhook; without --fire it prints
+whether the podtrace is still armed or has fired.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if socket == "" {
+				return errors.New("--socket (or PODTRACE_ARM_SOCKET) is required")
+			}
+			command := arm.CmdStatus
+			if fire {
+				command = arm.CmdFire
+			}
+			state, err := arm.Send(socket, command)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(cmd.OutOrStdout(), state)
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&fire, "fire", false, "Start emitting events")
+	cmd.Flags().StringVar(&socket, "socket", config.ArmSocket, "Arm socket of the running podtrace (env PODTRACE_ARM_SOCKET)")
+	return cmd
+}
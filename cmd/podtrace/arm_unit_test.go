@@ -0,0 +1,169 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+type fakeEmissionGate struct {
+	calls []bool
+	err   error
+}
+
+func (g *fakeEmissionGate) SetEmission(enabled bool) error {
+	if g.err != nil {
+		return g.err
+	}
+	g.calls = append(g.calls, enabled)
+	return nil
+}
+
+func saveArmGlobals(t *testing.T) {
+	t.Helper()
+	a, s, low := armed, armSocket, config.LowPrivilege
+	t.Cleanup(func() { armed, armSocket, config.LowPrivilege = a, s, low })
+}
+
+func TestValidateArmed(t *testing.T) {
+	saveArmGlobals(t)
+	tests := []struct {
+		name    string
+		armed   bool
+		trigger bool
+		low     bool
+		socket  string
+		wantErr string
+	}{
+		{name: "not armed", trigger: true},
+		{name: "armed", armed: true, socket: "/tmp/a.sock"},
+		{name: "with trigger", armed: true, trigger: true, socket: "/tmp/a.sock", wantErr: "--trigger"},
+		{name: "low privilege", armed: true, low: true, socket: "/tmp/a.sock", wantErr: "--low-privilege"},
+		{name: "no socket", armed: true, wantErr: "--arm-socket"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			armed, armSocket, config.LowPrivilege = tt.armed, tt.socket, tt.low
+			err := validateArmed(tt.trigger)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("error = %v, want it to mention %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestArmSwitchFiresOnce(t *testing.T) {
+	gate := &fakeEmissionGate{}
+	annotations := make(chan *events.Event, 2)
+	sw := newArmSwitch(gate, annotations)
+	if sw.Fired() {
+		t.Fatal("switch fired before Fire")
+	}
+	for i := 0; i < 2; i++ {
+		if err := sw.Fire(); err != nil {
+			t.Fatalf("Fire #%d: %v", i+1, err)
+		}
+	}
+	if !sw.Fired() || len(gate.calls) != 1 || !gate.calls[0] {
+		t.Errorf("fired=%v gate calls=%v, want one SetEmission(true)", sw.Fired(), gate.calls)
+	}
+	if len(annotations) != 1 {
+		t.Fatalf("annotations = %d, want 1", len(annotations))
+	}
+	if e := <-annotations; e.Type != events.EventAnnotation || !strings.Contains(e.Target, "fired") {
+		t.Errorf("unexpected annotation %+v", e)
+	}
+	if err := sw.wait(context.Background()); err != nil {
+		t.Errorf("wait after fire: %v", err)
+	}
+}
+
+func TestArmSwitchFireFailureStaysArmed(t *testing.T) {
+	sw := newArmSwitch(&fakeEmissionGate{err: errors.New("map update failed")}, nil)
+	if err := sw.Fire(); err == nil {
+		t.Fatal("expected the gate error")
+	}
+	if sw.Fired() {
+		t.Error("switch fired although emission could not be enabled")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sw.wait(ctx); err == nil {
+		t.Error("wait returned nil for a switch that never fired")
+	}
+}
+
+func TestGateOnArmDropsUntilFired(t *testing.T) {
+	sw := newArmSwitch(&fakeEmissionGate{}, nil)
+	in := make(chan *events.Event)
+	out := make(chan *events.Event, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go gateOnArm(ctx, in, out, sw)
+
+	in <- &events.Event{Type: events.EventDNS, PID: 1}
+	if err := sw.Fire(); err != nil {
+		t.Fatal(err)
+	}
+	in <- &events.Event{Type: events.EventDNS, PID: 2}
+	close(in)
+
+	var got []uint32
+	for e := range out {
+		got = append(got, e.PID)
+	}
+	if len(got) != 1 || got[0] != 2 {
+		t.Errorf("passed PIDs %v, want only the event after the fire", got)
+	}
+}
+
+func TestArmCommandFiresRunningPodtrace(t *testing.T) {
+	saveArmGlobals(t)
+	armSocket = filepath.Join(t.TempDir(), "arm.sock")
+	gate := &fakeEmissionGate{}
+	sw := newArmSwitch(gate, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := startArmServer(ctx, sw); err != nil {
+		t.Fatalf("startArmServer: %v", err)
+	}
+
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := newArmCmd()
+		var out bytes.Buffer
+		cmd.SetOut(&out)
+		cmd.SetArgs(append([]string{"--socket", armSocket}, args...))
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("podtrace arm %v: %v", args, err)
+		}
+		return strings.TrimSpace(out.String())
+	}
+	if got := run(); got != "armed" {
+		t.Errorf("status = %q, want armed", got)
+	}
+	if got := run("--fire"); got != "fired" {
+		t.Errorf("fire = %q, want fired", got)
+	}
+	select {
+	case <-sw.fired:
+	case <-time.After(5 * time.Second):
+		t.Fatal("switch did not fire")
+	}
+	if len(gate.calls) != 1 {
+		t.Errorf("gate calls = %v, want one", gate.calls)
+	}
+}
@@ -0,0 +1,19 @@
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/ebpf/artifacts"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// prepareKernelArtifacts fetches the pinned BTF and BPF object for this host
+// (PODTRACE_ARTIFACT_*) before the tracer loads. A failure is not fatal: the
+// tracer falls back to the kernel's BTF and the embedded object.
+func prepareKernelArtifacts() {
+	if err := artifacts.Prepare(context.Background()); err != nil {
+		logger.Warn("Fetching kernel artifacts failed; using built-in defaults", zap.Error(err))
+	}
+}
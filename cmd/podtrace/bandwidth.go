@@ -0,0 +1,54 @@
+package main
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/system"
+)
+
+// setNodeLinkSpeed gives d the node's link speed, the capacity of pods
+// without bandwidth annotations, when --pod-throughput is on.
+func setNodeLinkSpeed(d *diagnose.Diagnostician) {
+	if !config.PodThroughput {
+		return
+	}
+	bps, iface := system.NodeLinkSpeed()
+	if bps == 0 {
+		logger.Debug("Node link speed unknown; only annotated pods are checked for bandwidth saturation")
+		return
+	}
+	logger.Debug("Node link speed", zap.String("interface", iface), zap.Uint64("bits_per_sec", bps))
+	d.SetNodeLinkSpeed(bps)
+}
+
+// noteBandwidthLimit records the bandwidth annotations of the pod a
+// throughput event came from, so saturation is judged against the limit the
+// pod had while it was traced.
+func noteBandwidthLimit(d *diagnose.Diagnostician, e *events.Event, resolve func(*events.Event) *kubernetes.PodInfo) {
+	if e == nil || e.Type != events.EventPodThroughput || resolve == nil {
+		return
+	}
+	src := resolve(e)
+	if src == nil || src.PodName == "" {
+		return
+	}
+	d.SetBandwidthLimit(src.Namespace+"/"+src.PodName, analyzer.BandwidthLimit{
+		IngressBitsPerS: src.Bandwidth.IngressBitsPerS,
+		EgressBitsPerS:  src.Bandwidth.EgressBitsPerS,
+	})
+}
+
+// copyBandwidthLimits hands the limits d learned to a per-pod child.
+func copyBandwidthLimits(dst, src *diagnose.Diagnostician) {
+	limits, nic := src.BandwidthLimits()
+	for pod, l := range limits {
+		dst.SetBandwidthLimit(pod, l)
+	}
+	dst.SetNodeLinkSpeed(nic)
+}
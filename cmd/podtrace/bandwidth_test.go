@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+func TestNoteBandwidthLimit(t *testing.T) {
+	web := &kubernetes.PodInfo{PodName: "web", Namespace: "ns",
+		Bandwidth: kubernetes.PodBandwidth{IngressBitsPerS: 10_000_000}}
+	resolve := func(*events.Event) *kubernetes.PodInfo { return web }
+
+	d := diagnose.NewDiagnostician()
+	noteBandwidthLimit(d, &events.Event{Type: events.EventTCPSend}, resolve)
+	if limits, _ := d.BandwidthLimits(); len(limits) != 0 {
+		t.Fatalf("non-throughput event recorded limits: %v", limits)
+	}
+	noteBandwidthLimit(d, &events.Event{Type: events.EventPodThroughput}, resolve)
+	noteBandwidthLimit(d, &events.Event{Type: events.EventPodThroughput}, nil)
+	d.SetNodeLinkSpeed(1_000_000_000)
+
+	child := diagnose.NewDiagnostician()
+	copyBandwidthLimits(child, d)
+	limits, nic := child.BandwidthLimits()
+	if want := (analyzer.BandwidthLimit{IngressBitsPerS: 10_000_000}); limits["ns/web"] != want || len(limits) != 1 {
+		t.Errorf("limits = %v", limits)
+	}
+	if nic != 1_000_000_000 {
+		t.Errorf("nic = %d", nic)
+	}
+}
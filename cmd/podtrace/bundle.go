@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	"github.com/podtrace/podtrace/internal/bundle"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/export"
+	pkgkube "github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/kubernetes/nodespawn"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// Upper bounds on what --bundle keeps in memory during the run.
+const (
+	maxBundleLogBytes    = 16 << 20
+	maxBundleOutputBytes = 64 << 20
+)
+
+// spawnBundleOmitted explains the node-side parts a workstation-built bundle
+// lacks: the spawn pod collects them, and is gone when the bundle is written.
+const spawnBundleOmitted = "collected on the node by the spawn pod; rerun with --local on the node to include it"
+
+var (
+	// bundleLogs holds the tail of this run's logs while --bundle is set.
+	bundleLogs *bundle.TailBuffer
+	// bundleClientset and bundlePods are what the Kubernetes part of the
+	// bundle (pod specs, events) is collected from.
+	bundleClientset kubernetes.Interface
+	bundlePods      []nodespawn.PodRef
+)
+
+// startBundle validates --bundle and starts capturing logs for it.
+func startBundle() error {
+	if bundlePath == "" {
+		return nil
+	}
+	if diagnoseDuration == "" {
+		return fmt.Errorf("--bundle requires --diagnose")
+	}
+	bundleLogs = bundle.NewTailBuffer(maxBundleLogBytes)
+	logger.AddSink(bundleLogs)
+	return nil
+}
+
+// setBundleTargets records the traced pods, and the clientset to fetch their
+// spec and events with, for the bundle.
+func setBundleTargets(resolver pkgkube.PodResolverInterface, infos []*pkgkube.PodInfo) {
+	if bundlePath == "" {
+		return
+	}
+	if cp, ok := resolver.(pkgkube.ClientsetProvider); ok {
+		bundleClientset = cp.GetClientset()
+	}
+	bundlePods = bundlePods[:0]
+	for _, info := range infos {
+		bundlePods = append(bundlePods, nodespawn.PodRef{Namespace: info.Namespace, Name: info.PodName})
+	}
+}
+
+// writeDiagnoseBundle writes the --bundle archive for a diagnose run traced
+// by this process.
+func writeDiagnoseBundle(ctx context.Context, report string, d *diagnose.Diagnostician) {
+	if bundlePath == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalizeGracePeriod)
+	defer cancel()
+
+	b := bundle.New(time.Now())
+	addBundleReport(b, report)
+	if err := b.AddJSON(bundle.ReportJSON, d.ExportJSON()); err != nil {
+		b.Omit(bundle.ReportJSON, err.Error())
+	}
+	var capture bytes.Buffer
+	enc := json.NewEncoder(&capture)
+	for _, e := range d.GetEvents() {
+		if e != nil {
+			_ = enc.Encode(export.SchemaEvent(e))
+		}
+	}
+	b.Add(bundle.Capture, capture.Bytes())
+	if err := b.AddJSON(bundle.Capabilities, collectEnvReport()); err != nil {
+		b.Omit(bundle.Capabilities, err.Error())
+	}
+	finishBundle(ctx, b)
+}
+
+// newSpawnBundleOutput returns the writer a spawned run's output is copied
+// to for the bundle, or nil without --bundle.
+func newSpawnBundleOutput() *bundle.TailBuffer {
+	if bundlePath == "" {
+		return nil
+	}
+	return bundle.NewTailBuffer(maxBundleOutputBytes)
+}
+
+// writeSpawnBundle writes the --bundle archive on the workstation after a
+// spawned run, from the output the spawn pods streamed back.
+func writeSpawnBundle(ctx context.Context, output *bundle.TailBuffer, clientset kubernetes.Interface, pods []nodespawn.PodRef) {
+	if bundlePath == "" || output == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalizeGracePeriod)
+	defer cancel()
+	bundleClientset, bundlePods = clientset, pods
+
+	b := bundle.New(time.Now())
+	if exportFormat != "" {
+		b.Add("report."+exportFormat, output.Bytes())
+	} else {
+		addBundleReport(b, string(output.Bytes()))
+		b.Omit(bundle.ReportJSON, spawnBundleOmitted)
+	}
+	b.Omit(bundle.Capture, spawnBundleOmitted)
+	b.Omit(bundle.Capabilities, spawnBundleOmitted)
+	finishBundle(ctx, b)
+}
+
+func addBundleReport(b *bundle.Bundle, report string) {
+	b.Add(bundle.ReportText, []byte(report))
+	page, err := bundle.HTMLReport("podtrace diagnostic report", report, time.Now())
+	if err != nil {
+		b.Omit(bundle.ReportHTML, err.Error())
+		return
+	}
+	b.Add(bundle.ReportHTML, page)
+}
+
+// finishBundle adds the Kubernetes objects and logs every bundle carries and
+// writes the archive. Failures are logged: the trace itself succeeded.
+func finishBundle(ctx context.Context, b *bundle.Bundle) {
+	addBundleKubernetes(ctx, b, bundleClientset, bundlePods)
+	if bundleLogs != nil {
+		b.Add(bundle.Logs, bundleLogs.Bytes())
+	}
+	if err := b.WriteFile(bundlePath); err != nil {
+		logger.Warn("Failed to write incident bundle", zap.String("path", bundlePath), zap.Error(err))
+		return
+	}
+	logger.Info("Wrote incident bundle", zap.String("path", bundlePath))
+}
+
+// bundleEvent is one Kubernetes event of a traced pod in k8s-events.json.
+type bundleEvent struct {
+	Time      string `json:"time"`
+	Pod       string `json:"pod"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	Message   string `json:"message"`
+	Count     int32  `json:"count,omitempty"`
+	Component string `json:"component,omitempty"`
+}
+
+// addBundleKubernetes snapshots each traced pod's spec and status and its
+// Kubernetes events.
+func addBundleKubernetes(ctx context.Context, b *bundle.Bundle, clientset kubernetes.Interface, pods []nodespawn.PodRef) {
+	if clientset == nil || len(pods) == 0 {
+		reason := "no Kubernetes API access in this run"
+		b.Omit(bundle.PodsDir, reason)
+		b.Omit(bundle.K8sEvents, reason)
+		return
+	}
+	evs := []bundleEvent{}
+	var failures []string
+	seen := make(map[string]bool, len(pods))
+	for _, p := range pods {
+		if seen[p.String()] {
+			continue
+		}
+		seen[p.String()] = true
+		pod, err := clientset.CoreV1().Pods(p.Namespace).Get(ctx, p.Name, metav1.GetOptions{})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("pod %s: %v", p, err))
+		} else {
+			pod.ManagedFields = nil
+			if data, err := sigsyaml.Marshal(pod); err == nil {
+				b.Add(path.Join(bundle.PodsDir, p.Namespace+"_"+p.Name+".yaml"), data)
+			}
+		}
+
+		list, err := clientset.CoreV1().Events(p.Namespace).List(ctx, metav1.ListOptions{
+			FieldSelector: fields.AndSelectors(
+				fields.OneTermEqualSelector("involvedObject.name", p.Name),
+				fields.OneTermEqualSelector("involvedObject.kind", "Pod"),
+			).String(),
+		})
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("events %s: %v", p, err))
+			continue
+		}
+		for i := range list.Items {
+			evs = append(evs, newBundleEvent(p.String(), &list.Items[i]))
+		}
+	}
+	sort.SliceStable(evs, func(i, j int) bool { return evs[i].Time < evs[j].Time })
+	if err := b.AddJSON(bundle.K8sEvents, evs); err != nil {
+		failures = append(failures, err.Error())
+	}
+	if len(failures) > 0 {
+		logger.Warn("Incident bundle is missing Kubernetes objects", zap.Strings("errors", failures))
+	}
+}
+
+func newBundleEvent(pod string, e *corev1.Event) bundleEvent {
+	ts := e.LastTimestamp.Time
+	if ts.IsZero() {
+		ts = e.EventTime.Time
+	}
+	if ts.IsZero() {
+		ts = e.CreationTimestamp.Time
+	}
+	return bundleEvent{
+		Time:      ts.UTC().Format(time.RFC3339),
+		Pod:       pod,
+		Type:      e.Type,
+		Reason:    e.Reason,
+		Message:   e.Message,
+		Count:     e.Count,
+		Component: e.Source.Component,
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/podtrace/podtrace/internal/bundle"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes/nodespawn"
+)
+
+func withBundleGlobals(t *testing.T, path string) {
+	t.Helper()
+	origPath, origLogs, origClient, origPods := bundlePath, bundleLogs, bundleClientset, bundlePods
+	origDiagnose, origExport := diagnoseDuration, exportFormat
+	t.Cleanup(func() {
+		bundlePath, bundleLogs, bundleClientset, bundlePods = origPath, origLogs, origClient, origPods
+		diagnoseDuration, exportFormat = origDiagnose, origExport
+	})
+	bundlePath = path
+}
+
+func readBundle(t *testing.T, file string) map[string]string {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	out := make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out[hdr.Name] = string(data)
+	}
+}
+
+func bundleFakeClient() *fake.Clientset {
+	return fake.NewSimpleClientset(
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod"}, Spec: corev1.PodSpec{NodeName: "node-1"}},
+		&corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "api.1", Namespace: "prod"},
+			InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "api", Namespace: "prod"},
+			Type:           "Warning",
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+		},
+	)
+}
+
+func TestStartBundleRequiresDiagnose(t *testing.T) {
+	withBundleGlobals(t, filepath.Join(t.TempDir(), "out.tar.gz"))
+	diagnoseDuration = ""
+	if err := startBundle(); err == nil || !strings.Contains(err.Error(), "--diagnose") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestWriteDiagnoseBundle(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.tar.gz")
+	withBundleGlobals(t, file)
+	bundleLogs = bundle.NewTailBuffer(1 << 10)
+	_, _ = bundleLogs.Write([]byte(`{"msg":"Running diagnose mode"}` + "\n"))
+	bundleClientset = bundleFakeClient()
+	bundlePods = []nodespawn.PodRef{{Namespace: "prod", Name: "api"}, {Namespace: "prod", Name: "api"}}
+
+	d := diagnose.NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventDNS, PID: 7, Target: "db.prod.svc", LatencyNS: 2_000_000})
+	d.Finish()
+	writeDiagnoseBundle(context.Background(), "=== Diagnostic Report ===\n", d)
+
+	files := readBundle(t, file)
+	for name, want := range map[string]string{
+		bundle.ReportText:    "=== Diagnostic Report ===",
+		bundle.ReportHTML:    "<pre>=== Diagnostic Report ===",
+		bundle.ReportJSON:    `"summary"`,
+		bundle.Capture:       `"target":"db.prod.svc"`,
+		bundle.Capabilities:  `"goVersion"`,
+		"pods/prod_api.yaml": "nodeName: node-1",
+		bundle.K8sEvents:     `"reason": "BackOff"`,
+		bundle.Logs:          "Running diagnose mode",
+		bundle.Manifest:      `"name": "report.txt"`,
+	} {
+		if !strings.Contains(files[name], want) {
+			t.Errorf("%s missing %q:\n%s", name, want, files[name])
+		}
+	}
+	if strings.Count(files[bundle.K8sEvents], "BackOff") != 1 {
+		t.Errorf("duplicate pod refs collected twice:\n%s", files[bundle.K8sEvents])
+	}
+}
+
+func TestWriteSpawnBundle(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "out.tar.gz")
+	withBundleGlobals(t, file)
+	exportFormat = ""
+
+	output := newSpawnBundleOutput()
+	_, _ = output.Write([]byte("=== Diagnostic Report ===\n"))
+	writeSpawnBundle(context.Background(), output, bundleFakeClient(), []nodespawn.PodRef{{Namespace: "prod", Name: "api"}})
+
+	files := readBundle(t, file)
+	if !strings.Contains(files[bundle.ReportText], "Diagnostic Report") || files["pods/prod_api.yaml"] == "" {
+		t.Errorf("files = %v", files)
+	}
+	if _, ok := files[bundle.Capture]; ok {
+		t.Error("spawned run has no local capture")
+	}
+	if !strings.Contains(files[bundle.Manifest], spawnBundleOmitted) {
+		t.Errorf("manifest does not explain omissions:\n%s", files[bundle.Manifest])
+	}
+}
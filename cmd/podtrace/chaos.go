@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+
+	"github.com/podtrace/podtrace/internal/chaos"
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+// startChaosWatch injects the netem qdiscs and iptables DROP rules found on
+// the target pods' network into eventChan as EventChaos until ctx is done,
+// so the report marks the intervals a chaos experiment was running.
+func startChaosWatch(ctx context.Context, eventChan chan<- *events.Event, targets []*kubernetes.PodInfo) {
+	if !config.ChaosDetect || len(targets) == 0 {
+		return
+	}
+	watched := make([]chaos.Target, 0, len(targets))
+	for _, t := range targets {
+		w := chaos.Target{Pod: t.Namespace + "/" + t.PodName}
+		for _, c := range t.Containers {
+			w.CgroupPaths = append(w.CgroupPaths, c.CgroupPath)
+		}
+		w.CgroupPaths = append(w.CgroupPaths, t.CgroupPath)
+		watched = append(watched, w)
+	}
+	chaos.NewWatcher(watched, eventChan).Start(ctx)
+}
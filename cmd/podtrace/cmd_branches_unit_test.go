@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"os"
@@ -22,7 +23,7 @@ import (
 
 func saveDiagnoseGlobals(t *testing.T) {
 	t.Helper()
-	origExport := exportFormat
+	origExport, origOutput := exportFormat, exportOutput
 	origSummary := summaryFile
 	origTermination := terminationMessagePath
 	origReportTo := reportTo
@@ -30,7 +31,7 @@ func saveDiagnoseGlobals(t *testing.T) {
 	origRTT := rttSpikeThreshold
 	origFS := fsSlowThreshold
 	t.Cleanup(func() {
-		exportFormat = origExport
+		exportFormat, exportOutput = origExport, origOutput
 		summaryFile = origSummary
 		terminationMessagePath = origTermination
 		reportTo = origReportTo
@@ -136,6 +137,35 @@ func TestRunDiagnoseModeWithSource_ExportJSONOnTimeout(t *testing.T) {
 	}
 }
 
+func TestRunDiagnoseModeWithSource_ExportSQLiteToOutput(t *testing.T) {
+	saveDiagnoseGlobals(t)
+	exportFormat = "sqlite"
+	exportOutput = filepath.Join(t.TempDir(), "trace.db")
+	summaryFile = ""
+	terminationMessagePath = ""
+	reportTo = ""
+
+	ch := make(chan *events.Event, 1)
+	ch <- &events.Event{Type: events.EventDNS, ProcessName: "curl", Target: "example.com"}
+
+	out := captureStdout(t, func() {
+		if err := runDiagnoseModeWithSource(context.Background(), ch, "100ms",
+			nil, nil, nil, nil, false, nil, nil); err != nil {
+			t.Fatalf("unexpected error exporting SQLite: %v", err)
+		}
+	})
+	if strings.Contains(out, "SQLite format 3") {
+		t.Error("the database went to stdout as well as --output")
+	}
+	data, err := os.ReadFile(exportOutput)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) {
+		t.Errorf("--output holds %d bytes that are not a SQLite database", len(data))
+	}
+}
+
 func TestStartWorkstationEventCorrelation_NilClientset(t *testing.T) {
 	finish := startWorkstationEventCorrelation(context.Background(), nil,
 		[]nodespawn.PodRef{{Namespace: "default", Name: "web-0"}}, io.Discard)
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/ebpf"
+	tracerpkg "github.com/podtrace/podtrace/internal/ebpf/tracer"
+	"github.com/podtrace/podtrace/internal/hostfs"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/resource"
+	"github.com/podtrace/podtrace/internal/validation"
+)
+
+// controlFilePoll is how often the control file is checked for changes;
+// SIGHUP rereads it at once.
+const controlFilePoll = 2 * time.Second
+
+var controlFile string
+
+// liveConfigUpdates carries reloaded settings to the report loop, which owns
+// the diagnostician. It stays nil, and never fires, without --control-file.
+var liveConfigUpdates chan liveConfig
+
+// liveConfig is the part of the configuration a running trace can change.
+type liveConfig struct {
+	ErrorThreshold float64
+	RTTThreshold   float64
+	FSThreshold    float64
+	Filter         string
+	SamplingRate   int
+	AlertWarnPct   int
+	AlertCritPct   int
+	AlertEmergPct  int
+}
+
+// controlSettings is the control file. A key that is left out keeps the
+// value podtrace was started with:
+//
+//	error_threshold: 5
+//	rtt_threshold: 50
+//	filter: dns,net
+//	sampling_rate: 10
+//	alert_warn_pct: 70
+type controlSettings struct {
+	ErrorThreshold *float64 `yaml:"error_threshold"`
+	RTTThreshold   *float64 `yaml:"rtt_threshold"`
+	FSThreshold    *float64 `yaml:"fs_threshold"`
+	Filter         *string  `yaml:"filter"`
+	SamplingRate   *int     `yaml:"sampling_rate"`
+	AlertWarnPct   *int     `yaml:"alert_warn_pct"`
+	AlertCritPct   *int     `yaml:"alert_crit_pct"`
+	AlertEmergPct  *int     `yaml:"alert_emerg_pct"`
+}
+
+// startupLiveConfig is the configuration podtrace was started with.
+func startupLiveConfig() liveConfig {
+	return liveConfig{
+		ErrorThreshold: errorRateThreshold,
+		RTTThreshold:   rttSpikeThreshold,
+		FSThreshold:    fsSlowThreshold,
+		Filter:         eventFilter,
+		SamplingRate:   config.EventSamplingRate,
+		AlertWarnPct:   config.AlertWarnPct,
+		AlertCritPct:   config.AlertCritPct,
+		AlertEmergPct:  config.AlertEmergPct,
+	}
+}
+
+// parseControlFile overlays the settings in data on base and validates the
+// result.
+func parseControlFile(data []byte, base liveConfig) (liveConfig, error) {
+	var s controlSettings
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&s); err != nil && !errors.Is(err, io.EOF) {
+		return liveConfig{}, fmt.Errorf("parse control file: %w", err)
+	}
+	c := base
+	if s.ErrorThreshold != nil {
+		c.ErrorThreshold = *s.ErrorThreshold
+	}
+	if s.RTTThreshold != nil {
+		c.RTTThreshold = *s.RTTThreshold
+	}
+	if s.FSThreshold != nil {
+		c.FSThreshold = *s.FSThreshold
+	}
+	if s.Filter != nil {
+		c.Filter = *s.Filter
+	}
+	if s.SamplingRate != nil {
+		c.SamplingRate = *s.SamplingRate
+	}
+	if s.AlertWarnPct != nil {
+		c.AlertWarnPct = *s.AlertWarnPct
+	}
+	if s.AlertCritPct != nil {
+		c.AlertCritPct = *s.AlertCritPct
+	}
+	if s.AlertEmergPct != nil {
+		c.AlertEmergPct = *s.AlertEmergPct
+	}
+	return c, c.validate()
+}
+
+func (c liveConfig) validate() error {
+	if err := validation.ValidateErrorRateThreshold(c.ErrorThreshold); err != nil {
+		return err
+	}
+	if err := validation.ValidateRTTThreshold(c.RTTThreshold); err != nil {
+		return err
+	}
+	if err := validation.ValidateFSThreshold(c.FSThreshold); err != nil {
+		return err
+	}
+	if err := validation.ValidateEventFilter(c.Filter); err != nil {
+		return err
+	}
+	if c.SamplingRate < 1 {
+		return errors.New("sampling_rate must be at least 1")
+	}
+	if c.AlertWarnPct < 0 || c.AlertEmergPct > 100 || c.AlertWarnPct > c.AlertCritPct || c.AlertCritPct > c.AlertEmergPct {
+		return errors.New("alert percentages must satisfy 0 <= warn <= crit <= emerg <= 100")
+	}
+	return nil
+}
+
+// watchControlFile applies the control file at path when podtrace starts,
+// on SIGHUP and whenever the file changes, until ctx is done. The event
+// filter and the resource alert levels, in BPF and userspace, are changed
+// here; the rest is handed to the report loop on updates. A file that does
+// not parse or validate is logged and the previous settings stay.
+func watchControlFile(ctx context.Context, path string, tracer ebpf.TracerInterface, filter *liveEventFilter, updates chan liveConfig) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+	ticker := time.NewTicker(controlFilePoll)
+	defer ticker.Stop()
+
+	base := startupLiveConfig()
+	current := base
+	var lastMod time.Time
+	reload := func(force bool) {
+		fi, err := hostfs.Stat(path)
+		if err != nil {
+			if force {
+				logger.Warn("Control file not readable", zap.String("path", path), zap.Error(err))
+			}
+			return
+		}
+		if !force && fi.ModTime().Equal(lastMod) {
+			return
+		}
+		lastMod = fi.ModTime()
+		data, err := hostfs.ReadFile(path)
+		if err != nil {
+			logger.Warn("Control file not readable", zap.String("path", path), zap.Error(err))
+			return
+		}
+		next, err := parseControlFile(data, base)
+		if err != nil {
+			logger.Error("Control file rejected, keeping the current settings", zap.String("path", path), zap.Error(err))
+			return
+		}
+		if next == current {
+			return
+		}
+		applyControlSettings(tracer, filter, current, next)
+		current = next
+		// Replace a pending update the loop has not taken yet; this is
+		// the only sender, so there is room afterwards.
+		select {
+		case <-updates:
+		default:
+		}
+		updates <- next
+	}
+
+	reload(true)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			reload(true)
+		case <-ticker.C:
+			reload(false)
+		}
+	}
+}
+
+// applyControlSettings changes what is read outside the report loop.
+func applyControlSettings(tracer ebpf.TracerInterface, filter *liveEventFilter, prev, next liveConfig) {
+	if next.Filter != prev.Filter {
+		filter.Store(next.Filter)
+	}
+	if next.AlertWarnPct != prev.AlertWarnPct || next.AlertCritPct != prev.AlertCritPct || next.AlertEmergPct != prev.AlertEmergPct {
+		resource.SetAlertThresholds(next.AlertWarnPct, next.AlertCritPct, next.AlertEmergPct)
+		if setter, ok := tracer.(tracerpkg.AlertThresholdSetter); ok {
+			if err := setter.SetAlertThresholds(next.AlertWarnPct, next.AlertCritPct, next.AlertEmergPct); err != nil {
+				logger.Warn("Failed to update the BPF alert thresholds", zap.Error(err))
+			}
+		}
+	}
+	logger.Info("Control file applied",
+		zap.Float64("error_threshold", next.ErrorThreshold),
+		zap.Float64("rtt_threshold", next.RTTThreshold),
+		zap.Float64("fs_threshold", next.FSThreshold),
+		zap.String("filter", next.Filter),
+		zap.Int("sampling_rate", next.SamplingRate),
+		zap.Ints("alert_pct", []int{next.AlertWarnPct, next.AlertCritPct, next.AlertEmergPct}))
+}
+
+// applyLiveConfig changes what the report loop reads. It runs on the loop's
+// goroutine, which also adds the events, so nothing else races with it.
+func applyLiveConfig(d *diagnose.Diagnostician, printer *verbosityPrinter, c liveConfig) {
+	d.SetThresholds(c.ErrorThreshold, c.RTTThreshold, c.FSThreshold)
+	printer.rttMS, printer.fsMS = c.RTTThreshold, c.FSThreshold
+	config.EventSamplingRate = c.SamplingRate
+	config.AlertWarnPct, config.AlertCritPct, config.AlertEmergPct = c.AlertWarnPct, c.AlertCritPct, c.AlertEmergPct
+}
+
+// liveEventFilter is the --filter of a running trace; the control file can
+// swap it. An empty filter passes every event unless emptyDropsAll is set,
+// as it is for a static --filter.
+type liveEventFilter struct {
+	set           atomic.Pointer[map[string]bool]
+	emptyDropsAll bool
+}
+
+func newLiveEventFilter(filter string) *liveEventFilter {
+	f := &liveEventFilter{}
+	f.Store(filter)
+	return f
+}
+
+// Store replaces the filter with the comma-separated categories in filter.
+func (f *liveEventFilter) Store(filter string) {
+	set := make(map[string]bool)
+	for _, c := range strings.Split(strings.ToLower(filter), ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			set[c] = true
+		}
+	}
+	f.set.Store(&set)
+}
+
+func (f *liveEventFilter) load() map[string]bool {
+	return *f.set.Load()
+}
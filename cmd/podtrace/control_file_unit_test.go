@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func testLiveConfig() liveConfig {
+	return liveConfig{
+		ErrorThreshold: 10, RTTThreshold: 100, FSThreshold: 10,
+		Filter: "dns", SamplingRate: 100,
+		AlertWarnPct: 80, AlertCritPct: 90, AlertEmergPct: 95,
+	}
+}
+
+func TestParseControlFile_OverlaysStartupValues(t *testing.T) {
+	base := testLiveConfig()
+	c, err := parseControlFile([]byte("error_threshold: 2.5\nfilter: net,fs\nalert_warn_pct: 60\n"), base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := base
+	want.ErrorThreshold, want.Filter, want.AlertWarnPct = 2.5, "net,fs", 60
+	if c != want {
+		t.Errorf("parseControlFile = %+v, want %+v", c, want)
+	}
+
+	c, err = parseControlFile(nil, base)
+	if err != nil || c != base {
+		t.Errorf("empty control file = %+v, %v; want the startup values", c, err)
+	}
+}
+
+func TestParseControlFile_Rejects(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown key":    "error_treshold: 5\n",
+		"bad filter":     "filter: disk\n",
+		"zero sampling":  "sampling_rate: 0\n",
+		"alert order":    "alert_warn_pct: 95\nalert_crit_pct: 90\n",
+		"error range":    "error_threshold: 120\n",
+		"negative rtt":   "rtt_threshold: -1\n",
+		"malformed yaml": "filter: [dns\n",
+	} {
+		if _, err := parseControlFile([]byte(data), testLiveConfig()); err == nil {
+			t.Errorf("%s: accepted %q", name, data)
+		}
+	}
+}
+
+func TestLiveEventFilter_SwapsWhileRunning(t *testing.T) {
+	filter := newLiveEventFilter("dns")
+	in := make(chan *events.Event, 4)
+	out := make(chan *events.Event, 4)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go runEventFilter(ctx, in, out, filter)
+
+	in <- &events.Event{Type: events.EventConnect}
+	in <- &events.Event{Type: events.EventDNS}
+	if e := <-out; e.Type != events.EventDNS {
+		t.Fatalf("passed %v with filter dns", e.Type)
+	}
+
+	filter.Store("")
+	in <- &events.Event{Type: events.EventConnect}
+	select {
+	case e := <-out:
+		if e.Type != events.EventConnect {
+			t.Errorf("got %v, want the connect event", e.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("an empty filter did not pass the event")
+	}
+}
+
+func TestApplyLiveConfig(t *testing.T) {
+	origRate := config.EventSamplingRate
+	origWarn, origCrit, origEmerg := config.AlertWarnPct, config.AlertCritPct, config.AlertEmergPct
+	t.Cleanup(func() {
+		config.EventSamplingRate = origRate
+		config.AlertWarnPct, config.AlertCritPct, config.AlertEmergPct = origWarn, origCrit, origEmerg
+	})
+
+	d := diagnose.NewDiagnostician()
+	printer := newVerbosityPrinter(os.Stdout, "", 100, 10)
+	c := testLiveConfig()
+	c.ErrorThreshold, c.RTTThreshold, c.SamplingRate, c.AlertWarnPct = 3, 25, 7, 70
+	applyLiveConfig(d, printer, c)
+
+	if d.ErrorRateThreshold() != 3 || d.RTTSpikeThreshold() != 25 || printer.rttMS != 25 {
+		t.Errorf("thresholds not applied: error=%v rtt=%v printer=%v", d.ErrorRateThreshold(), d.RTTSpikeThreshold(), printer.rttMS)
+	}
+	if config.EventSamplingRate != 7 || config.AlertWarnPct != 70 {
+		t.Errorf("sampling=%d warn=%d", config.EventSamplingRate, config.AlertWarnPct)
+	}
+}
+
+func TestWatchControlFile_AppliesAtStart(t *testing.T) {
+	origFilter, origErr := eventFilter, errorRateThreshold
+	t.Cleanup(func() { eventFilter, errorRateThreshold = origFilter, origErr })
+	eventFilter, errorRateThreshold = "dns", 10
+
+	path := filepath.Join(t.TempDir(), "control.yaml")
+	if err := os.WriteFile(path, []byte("error_threshold: 4\nfilter: net\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	filter := newLiveEventFilter(eventFilter)
+	updates := make(chan liveConfig, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go watchControlFile(ctx, path, &mockTracer{}, filter, updates)
+
+	select {
+	case c := <-updates:
+		if c.ErrorThreshold != 4 || c.Filter != "net" {
+			t.Errorf("update = %+v", c)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no update from the control file")
+	}
+	if f := filter.load(); !f["net"] || f["dns"] {
+		t.Errorf("filter = %v, want net only", f)
+	}
+}
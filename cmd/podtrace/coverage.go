@@ -0,0 +1,46 @@
+package main
+
+import (
+	"slices"
+	"sync"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/coverage"
+	"github.com/podtrace/podtrace/internal/metricsexporter"
+)
+
+// probeFailures records the BPF programs that did not attach, for grading
+// the report sections they feed.
+type probeFailures struct {
+	mu       sync.Mutex
+	programs []string
+}
+
+var failedProbes = &probeFailures{}
+
+func (p *probeFailures) OnAttachFailure(program, _ string, _ bool, _ error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !slices.Contains(p.programs, program) {
+		p.programs = append(p.programs, program)
+	}
+}
+
+func (p *probeFailures) list() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.programs...)
+}
+
+// setTelemetry hands d what the session knows about its own data loss so
+// far, for the report's per-section data confidence.
+func setTelemetry(d *diagnose.Diagnostician) {
+	d.SetTelemetry(coverage.Telemetry{
+		LowPrivilege:     config.LowPrivilege,
+		FailedProbes:     failedProbes.list(),
+		RingBufferErrors: metricsexporter.RingBufferDrops(),
+		FilteredDrops:    metricsexporter.FilteredEventDrops(),
+		DNSDrops:         metricsexporter.DNSDrops(),
+	})
+}
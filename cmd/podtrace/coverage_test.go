@@ -0,0 +1,24 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+)
+
+func TestSetTelemetryRecordsFailedProbes(t *testing.T) {
+	saved := failedProbes
+	defer func() { failedProbes = saved }()
+	failedProbes = &probeFailures{}
+	failedProbes.OnAttachFailure("tracepoint_tcp_probe", "tcp:tcp_probe", false, errors.New("not found"))
+	failedProbes.OnAttachFailure("tracepoint_tcp_probe", "tcp:tcp_probe", false, errors.New("not found"))
+	failedProbes.OnAttachFailure("kprobe_vfs_fsync", "vfs_fsync", false, errors.New("not found"))
+
+	d := diagnose.NewDiagnostician()
+	setTelemetry(d)
+	if got, want := d.Telemetry().FailedProbes, []string{"tracepoint_tcp_probe", "kprobe_vfs_fsync"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FailedProbes = %v, want %v", got, want)
+	}
+}
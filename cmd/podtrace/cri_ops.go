@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/cri"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// startCRIOperations polls the container runtime for the target pods'
+// container starts and the runtime's responsiveness, injecting them into
+// eventChan as EventCRIOp until ctx is done. Without a reachable CRI socket
+// it logs and returns: the rest of the trace does not depend on it.
+func startCRIOperations(ctx context.Context, eventChan chan<- *events.Event, targets []*kubernetes.PodInfo) {
+	if !config.CRIOps || os.Getenv("PODTRACE_CRI_RESOLVE") == "false" {
+		return
+	}
+	r, err := cri.NewResolver()
+	if err != nil {
+		logger.Info("Container runtime operations not traced", zap.Error(err))
+		return
+	}
+	since := time.Now().Add(-config.CRIOpsLookback)
+	watchers := make([]*cri.OperationWatcher, 0, len(targets))
+	for _, p := range targets {
+		watchers = append(watchers, cri.NewOperationWatcher(r, p.Namespace, p.PodName, since))
+	}
+	logger.Info("Tracing container runtime operations", zap.String("endpoint", r.Endpoint()))
+
+	go func() {
+		defer func() { _ = r.Close() }()
+		ticker := time.NewTicker(config.CRIOpsInterval)
+		defer ticker.Stop()
+		for {
+			for _, w := range watchers {
+				pollCtx, cancel := context.WithTimeout(ctx, config.CRIOpsInterval)
+				ops, err := w.Poll(pollCtx)
+				cancel()
+				if err != nil && ctx.Err() == nil {
+					logger.Debug("CRI operation poll failed", zap.Error(err))
+				}
+				for _, op := range ops {
+					select {
+					case <-ctx.Done():
+						return
+					case eventChan <- op.Event():
+					default:
+					}
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,20 @@
+package main
+
+import "github.com/podtrace/podtrace/internal/debugserver"
+
+// debugAddr is where --debug-addr serves pprof and runtime stats for this
+// process.
+var debugAddr string
+
+// startDebugServer starts the --debug-addr endpoint when addr is set. The
+// returned stop function is never nil.
+func startDebugServer(addr string) (stop func(), err error) {
+	if addr == "" {
+		return func() {}, nil
+	}
+	srv, err := debugserver.Start(addr)
+	if err != nil {
+		return nil, err
+	}
+	return srv.Shutdown, nil
+}
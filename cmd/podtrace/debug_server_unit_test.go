@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestStartDebugServer(t *testing.T) {
+	stop, err := startDebugServer("")
+	if err != nil || stop == nil {
+		t.Fatalf("disabled: err = %v, nil stop = %t", err, stop == nil)
+	}
+	stop()
+
+	if _, err := startDebugServer("0.0.0.0:6061"); err == nil {
+		t.Error("expected a non-loopback address to be refused")
+	}
+	stop, err = startDebugServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stop()
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -15,28 +16,33 @@ import (
 	"github.com/podtrace/podtrace/internal/cri"
 	"github.com/podtrace/podtrace/internal/ebpf/embedded"
 	"github.com/podtrace/podtrace/internal/ebpf/loader"
+	"github.com/podtrace/podtrace/internal/system"
 )
 
 type envReport struct {
-	Time           string   `json:"time"`
-	GoVersion      string   `json:"goVersion"`
-	GOOS           string   `json:"goos"`
-	GOARCH         string   `json:"goarch"`
-	KernelRelease  string   `json:"kernelRelease"`
-	CgroupBase     string   `json:"cgroupBase"`
-	ProcBase       string   `json:"procBase"`
-	CgroupV2       bool     `json:"cgroupV2"`
-	BTFVmlinux     bool     `json:"btfVmlinuxPresent"`
-	BTFFile        string   `json:"btfFile"`
-	CRIEndpointEnv string   `json:"criEndpointEnv"`
-	CRICandidates  []string `json:"criCandidates"`
-	CRIDetected    string   `json:"criDetected"`
-	BPFObjectPath  string   `json:"bpfObjectPath"`
-	BPFEmbedded    bool     `json:"bpfEmbeddedAvailable"`
-	BPFPrograms    []string `json:"bpfPrograms"`
-	BPFMaps        []string `json:"bpfMaps"`
-	HasCgroupIDMap bool     `json:"hasTargetCgroupIdMap"`
-	Warnings       []string `json:"warnings"`
+	Time           string                      `json:"time"`
+	GoVersion      string                      `json:"goVersion"`
+	GOOS           string                      `json:"goos"`
+	GOARCH         string                      `json:"goarch"`
+	KernelRelease  string                      `json:"kernelRelease"`
+	HostRoot       string                      `json:"hostRoot,omitempty"`
+	CgroupBase     string                      `json:"cgroupBase"`
+	ProcBase       string                      `json:"procBase"`
+	CgroupV2       bool                        `json:"cgroupV2"`
+	BTFVmlinux     bool                        `json:"btfVmlinuxPresent"`
+	BTFFile        string                      `json:"btfFile"`
+	CRIEndpointEnv string                      `json:"criEndpointEnv"`
+	CRICandidates  []string                    `json:"criCandidates"`
+	CRIDetected    string                      `json:"criDetected"`
+	BPFObjectPath  string                      `json:"bpfObjectPath"`
+	BPFEmbedded    bool                        `json:"bpfEmbeddedAvailable"`
+	BPFPrograms    []string                    `json:"bpfPrograms"`
+	BPFMaps        []string                    `json:"bpfMaps"`
+	HasCgroupIDMap bool                        `json:"hasTargetCgroupIdMap"`
+	LSMDenials     []string                    `json:"lsmDenials,omitempty"`
+	BPFCoexistence *system.BPFCoexistence      `json:"bpfCoexistence,omitempty"`
+	KernelParams   []system.KernelParamFinding `json:"kernelParams,omitempty"`
+	Warnings       []string                    `json:"warnings"`
 }
 
 func newDiagnoseEnvCmd() *cobra.Command {
@@ -54,11 +60,13 @@ func newDiagnoseEnvCmd() *cobra.Command {
 }
 
 func collectEnvReport() envReport {
+	prepareKernelArtifacts()
 	rep := envReport{
 		Time:           time.Now().Format(time.RFC3339),
 		GoVersion:      runtime.Version(),
 		GOOS:           runtime.GOOS,
 		GOARCH:         runtime.GOARCH,
+		HostRoot:       config.HostRoot,
 		CgroupBase:     config.CgroupBasePath,
 		ProcBase:       config.ProcBasePath,
 		BTFFile:        config.BTFFilePath,
@@ -100,7 +108,25 @@ func collectEnvReport() envReport {
 	}
 
 	if !rep.BTFVmlinux && rep.BTFFile == "" {
-		rep.Warnings = append(rep.Warnings, "kernel BTF (/sys/kernel/btf/vmlinux) not found and PODTRACE_BTF_FILE not set; CO-RE relocations may fail")
+		rep.Warnings = append(rep.Warnings, "kernel BTF (/sys/kernel/btf/vmlinux) not found and neither PODTRACE_BTF_FILE nor PODTRACE_ARTIFACT_MIRROR provided one; CO-RE relocations may fail")
+	}
+	for _, d := range system.RecentLSMDenials(time.Time{}) {
+		rep.LSMDenials = append(rep.LSMDenials, d.String())
+	}
+	if len(rep.LSMDenials) > 0 {
+		rep.Warnings = append(rep.Warnings, fmt.Sprintf("%d recent SELinux/AppArmor denial(s) related to podtrace/BPF found in the audit log; see lsmDenials", len(rep.LSMDenials)))
+	}
+	rep.KernelParams = system.KernelParamFindings()
+	for _, f := range rep.KernelParams {
+		rep.Warnings = append(rep.Warnings, fmt.Sprintf("%s=%s: %s; fix: %s", f.Param, f.Value, f.Limitation, f.Fix))
+	}
+	if co, err := system.DetectBPFCoexistence(); err == nil {
+		rep.BPFCoexistence = &co
+		for _, c := range co.Conflicts {
+			rep.Warnings = append(rep.Warnings, fmt.Sprintf("%s programs from %s share podtrace's hooks (%s): %s", c.Hook, c.Owner, strings.Join(c.Programs, ", "), c.Note))
+		}
+	} else {
+		rep.Warnings = append(rep.Warnings, fmt.Sprintf("could not enumerate loaded BPF programs to check for conflicting datapaths: %v", err))
 	}
 	if rep.CgroupV2 && !rep.HasCgroupIDMap {
 		rep.Warnings = append(rep.Warnings, "cgroup v2 detected but BPF map target_cgroup_ids missing; kernel-side cgroup filtering will be unavailable")
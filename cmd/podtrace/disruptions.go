@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// startDisruptionWatch injects the evictions and preemptions of pods on the
+// traced node, and its pressure condition changes, into eventChan as
+// EventDisruption until ctx is done. The node is the first target pod's, or
+// $NODE_NAME. Without a clientset, or permission to watch events and nodes
+// cluster-wide, disruptions are left out of the trace.
+func startDisruptionWatch(ctx context.Context, eventChan chan<- *events.Event, resolver kubernetes.PodResolverInterface, targets []*kubernetes.PodInfo) {
+	if !config.Disruptions || len(targets) == 0 {
+		return
+	}
+	provider, ok := resolver.(kubernetes.ClientsetProvider)
+	if !ok || provider.GetClientset() == nil {
+		return
+	}
+	clientset := provider.GetClientset()
+	node := targetNode(ctx, clientset, targets)
+	if node == "" {
+		logger.Info("Pod disruptions not traced: the target's node is unknown")
+		return
+	}
+	emit := func(d kubernetes.Disruption) {
+		select {
+		case <-ctx.Done():
+		case eventChan <- d.Event():
+		default:
+		}
+	}
+	if err := kubernetes.WatchDisruptions(ctx, clientset, node, time.Now().Add(-config.DisruptionWindow), emit); err != nil {
+		logger.Info("Pod disruptions partly traced", zap.String("node", node), zap.Error(err))
+	}
+}
+
+// targetNode returns the node of the first target pod, or $NODE_NAME when
+// the pod cannot be read.
+func targetNode(ctx context.Context, clientset k8s.Interface, targets []*kubernetes.PodInfo) string {
+	if pod, err := clientset.CoreV1().Pods(targets[0].Namespace).Get(ctx, targets[0].PodName, metav1.GetOptions{}); err == nil && pod.Spec.NodeName != "" {
+		return pod.Spec.NodeName
+	}
+	return os.Getenv("NODE_NAME")
+}
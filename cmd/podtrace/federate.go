@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/spf13/cobra"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/federation"
+)
+
+// federatePollInterval is how often --wait polls the remote session.
+var federatePollInterval = 5 * time.Second
+
+type federateOptions struct {
+	gateway     string
+	namespace   string
+	selector    string
+	duration    time.Duration
+	exporter    string
+	filter      string
+	requestedBy string
+	wait        bool
+}
+
+func newFederateCmd() *cobra.Command {
+	opts := &federateOptions{}
+	cmd := &cobra.Command{
+		Use:   "federate",
+		Short: "Request a bounded trace from the agents of another cluster",
+		Long: `Asks the federation gateway of another cluster's operator (started with
+--federation-addr) to trace the pods matching --label there for --duration.
+The remote operator runs it as a PodTraceSession, and the events go to the
+remote ExporterConfig named by --exporter: point both clusters at the same
+tracing backend to see both sides of a cross-cluster call together.
+
+The gateway token is read from PODTRACE_FEDERATION_TOKEN.`,
+		Example: `  # Trace the callee in the west cluster while reproducing from here:
+  PODTRACE_FEDERATION_TOKEN=... podtrace federate --gateway https://podtrace.west.example:8443 \
+      -n payments --label app=ledger --duration 2m --exporter otlp-default --wait`,
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, cancel := context.WithTimeout(ctrl.SetupSignalHandler(), opts.duration+10*time.Minute)
+			defer cancel()
+			return runFederate(ctx, cmd.OutOrStdout(), opts, config.FederationToken)
+		},
+	}
+	cmd.Flags().StringVar(&opts.gateway, "gateway", config.FederationGateway, "URL of the remote cluster's federation gateway (env PODTRACE_FEDERATION_GATEWAY)")
+	cmd.Flags().StringVarP(&opts.namespace, "namespace", "n", "", "Namespace of the pods in the remote cluster (required)")
+	cmd.Flags().StringVar(&opts.selector, "label", "", "Label selector of the pods in the remote cluster, e.g. app=api (required)")
+	cmd.Flags().DurationVar(&opts.duration, "duration", time.Minute, "How long the remote cluster traces (at most 1h)")
+	cmd.Flags().StringVar(&opts.exporter, "exporter", "default", "ExporterConfig in the remote namespace the events are sent to")
+	cmd.Flags().StringVar(&opts.filter, "filter", "", "Filter events by type (dns,net,fs,cpu,proc,crypto,usdt)")
+	cmd.Flags().StringVar(&opts.requestedBy, "requested-by", "", "Name of this cluster, recorded on the remote session")
+	cmd.Flags().BoolVar(&opts.wait, "wait", false, "Wait for the remote trace to finish and print its outcome")
+	_ = cmd.MarkFlagRequired("namespace")
+	_ = cmd.MarkFlagRequired("label")
+	return cmd
+}
+
+func runFederate(ctx context.Context, out io.Writer, opts *federateOptions, token string) error {
+	if opts.gateway == "" {
+		return errors.New("--gateway (or PODTRACE_FEDERATION_GATEWAY) is required")
+	}
+	client, err := federation.NewClient(opts.gateway, token)
+	if err != nil {
+		return err
+	}
+	session, err := client.RequestTrace(ctx, federation.TraceRequest{
+		Namespace:   opts.namespace,
+		Selector:    opts.selector,
+		Duration:    opts.duration.String(),
+		Exporter:    opts.exporter,
+		Filters:     parseCSV(opts.filter),
+		RequestedBy: opts.requestedBy,
+	})
+	if err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(out, "requested trace %s/%s%s\n", session.Namespace, session.Name, clusterSuffix(session.Cluster))
+	if !opts.wait {
+		return nil
+	}
+	ticker := time.NewTicker(federatePollInterval)
+	defer ticker.Stop()
+	for !session.Done() {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for %s/%s: %w", session.Namespace, session.Name, ctx.Err())
+		case <-ticker.C:
+		}
+		if session, err = client.Status(ctx, session.Namespace, session.Name); err != nil {
+			return err
+		}
+	}
+	_, _ = fmt.Fprintf(out, "%s/%s: %s\n", session.Namespace, session.Name, session.State)
+	if session.ReportLocation != "" {
+		_, _ = fmt.Fprintf(out, "report: %s\n", session.ReportLocation)
+	}
+	if session.State == "Failed" {
+		if session.Message != "" {
+			return fmt.Errorf("remote trace failed: %s", session.Message)
+		}
+		return errors.New("remote trace failed")
+	}
+	return nil
+}
+
+func clusterSuffix(cluster string) string {
+	if cluster == "" {
+		return ""
+	}
+	return " in cluster " + cluster
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunFederate_WaitsForOutcome(t *testing.T) {
+	var polls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"cluster":"west","namespace":"payments","name":"federated-x1"}`))
+		case polls.Add(1) < 2:
+			_, _ = w.Write([]byte(`{"namespace":"payments","name":"federated-x1","state":"Running"}`))
+		default:
+			_, _ = w.Write([]byte(`{"namespace":"payments","name":"federated-x1","state":"Completed","reportLocation":"configmap/payments/r"}`))
+		}
+	}))
+	defer srv.Close()
+	old := federatePollInterval
+	federatePollInterval = time.Millisecond
+	defer func() { federatePollInterval = old }()
+
+	var out bytes.Buffer
+	err := runFederate(context.Background(), &out, &federateOptions{
+		gateway: srv.URL, namespace: "payments", selector: "app=ledger",
+		duration: time.Minute, exporter: "otlp", wait: true,
+	}, "tok")
+	if err != nil {
+		t.Fatalf("runFederate: %v", err)
+	}
+	for _, want := range []string{"requested trace payments/federated-x1 in cluster west", "payments/federated-x1: Completed", "report: configmap/payments/r"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output %q lacks %q", out.String(), want)
+		}
+	}
+}
+
+func TestRunFederate_RequiresGatewayAndToken(t *testing.T) {
+	opts := &federateOptions{namespace: "ns", selector: "app=x", duration: time.Minute, exporter: "e"}
+	if err := runFederate(context.Background(), &bytes.Buffer{}, opts, "tok"); err == nil {
+		t.Error("runFederate without --gateway = nil error")
+	}
+	opts.gateway = "https://gateway:8443"
+	if err := runFederate(context.Background(), &bytes.Buffer{}, opts, ""); err == nil {
+		t.Error("runFederate without a token = nil error")
+	}
+}
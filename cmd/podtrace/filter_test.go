@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -247,3 +248,27 @@ func TestFilterEvents_MultipleFilters(t *testing.T) {
 		t.Errorf("Expected 5 events, got %d", count)
 	}
 }
+
+type gatedTracer struct {
+	mockTracer
+	categories []string
+}
+
+func (g *gatedTracer) SetEnabledCategories(categories []string) error {
+	g.categories = categories
+	return nil
+}
+
+func TestGateProbeGroups(t *testing.T) {
+	g := &gatedTracer{}
+	gateProbeGroups(g, "")
+	if g.categories != nil {
+		t.Fatalf("empty --probe-groups gated %v", g.categories)
+	}
+	gateProbeGroups(g, "NET, fs")
+	if strings.Join(g.categories, ",") != "net,fs" {
+		t.Errorf("categories = %v, want [net fs]", g.categories)
+	}
+	// A tracer without probe groups is left alone.
+	gateProbeGroups(&mockTracer{}, "net")
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// focusFlag is the --focus window; reportFocus is its parsed form, nil
+// without the flag.
+var (
+	focusFlag   string
+	reportFocus *focusWindow
+)
+
+// focusBound resolves one end of a --focus window against the session's
+// start and end.
+type focusBound func(start, end time.Time) time.Time
+
+// focusWindow narrows the final report to part of a --diagnose run. A nil
+// bound leaves that end of the session as it is.
+type focusWindow struct {
+	spec     string
+	from, to focusBound
+}
+
+// validateFocus parses --focus, which only a --diagnose report uses.
+func validateFocus(diagnose bool) error {
+	reportFocus = nil
+	if focusFlag == "" {
+		return nil
+	}
+	if !diagnose {
+		return errors.New("--focus requires --diagnose")
+	}
+	w, err := parseFocus(focusFlag)
+	if err != nil {
+		return err
+	}
+	reportFocus = w
+	return nil
+}
+
+// parseFocus parses FROM..TO, where each bound is a clock time (12:04:30),
+// an RFC 3339 time, an offset from the start of the session (+5m, or 5m) or
+// one back from its end (-2m); either may be left out.
+func parseFocus(spec string) (*focusWindow, error) {
+	fromSpec, toSpec, ok := strings.Cut(strings.TrimSpace(spec), "..")
+	if !ok {
+		return nil, fmt.Errorf("--focus %q: want FROM..TO, e.g. 12:04:30..12:05:10 or +5m..+7m", spec)
+	}
+	from, err := parseFocusBound(fromSpec)
+	if err != nil {
+		return nil, fmt.Errorf("--focus %q: %w", spec, err)
+	}
+	to, err := parseFocusBound(toSpec)
+	if err != nil {
+		return nil, fmt.Errorf("--focus %q: %w", spec, err)
+	}
+	if from == nil && to == nil {
+		return nil, fmt.Errorf("--focus %q: give at least one bound", spec)
+	}
+	return &focusWindow{spec: spec, from: from, to: to}, nil
+}
+
+func parseFocusBound(s string) (focusBound, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		if clock.BeforeBoot(t) {
+			return nil, fmt.Errorf("bound %q is before this node booted, so no event can fall there", s)
+		}
+		return func(_, _ time.Time) time.Time { return t }, nil
+	}
+	for _, layout := range []string{"15:04:05", "15:04"} {
+		if c, err := time.Parse(layout, s); err == nil {
+			sinceMidnight := c.Sub(time.Date(0, 1, 1, 0, 0, 0, 0, time.UTC))
+			return clockBound(sinceMidnight), nil
+		}
+	}
+	if rest, fromEnd := strings.CutPrefix(s, "-"); fromEnd {
+		d, err := time.ParseDuration(rest)
+		if err != nil {
+			return nil, fmt.Errorf("bad bound %q", s)
+		}
+		return func(_, end time.Time) time.Time { return end.Add(-d) }, nil
+	}
+	d, err := time.ParseDuration(strings.TrimPrefix(s, "+"))
+	if err != nil {
+		return nil, fmt.Errorf("bad bound %q: want a clock time, an RFC 3339 time or an offset like +5m", s)
+	}
+	return func(start, _ time.Time) time.Time { return start.Add(d) }, nil
+}
+
+// clockBound places a clock time on the local date the session started, or
+// the day after when the session ran past midnight and the time is only
+// reached then.
+func clockBound(sinceMidnight time.Duration) focusBound {
+	return func(start, end time.Time) time.Time {
+		local := start.In(time.Local)
+		t := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local).Add(sinceMidnight)
+		if next := t.AddDate(0, 0, 1); t.Before(start) && !next.After(end) {
+			return next
+		}
+		return t
+	}
+}
+
+// bounds resolves the window against a session from start to end; a zero
+// time is a bound left to the session.
+func (w *focusWindow) bounds(start, end time.Time) (time.Time, time.Time) {
+	var from, to time.Time
+	if w.from != nil {
+		from = w.from(start, end)
+	}
+	if w.to != nil {
+		to = w.to(start, end)
+	}
+	return from, to
+}
+
+// applyTo narrows d to the window, recomputing every section over it.
+func (w *focusWindow) applyTo(d *diagnose.Diagnostician) {
+	if w == nil {
+		return
+	}
+	from, to := w.bounds(d.StartTime(), d.EndTime())
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		logger.Warn("--focus window is empty; reporting the whole session", zap.String("focus", w.spec))
+		return
+	}
+	if (!from.IsZero() && from.After(d.EndTime())) || (!to.IsZero() && to.Before(d.StartTime())) {
+		logger.Warn("--focus window is outside the session; reporting the whole session", zap.String("focus", w.spec))
+		return
+	}
+	d.Focus(from, to)
+	logger.Info("Report focused on a window of the session",
+		zap.Time("from", d.StartTime()), zap.Time("to", d.EndTime()), zap.Int("events", len(d.GetEvents())))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestValidateFocus(t *testing.T) {
+	orig, origFocus := focusFlag, reportFocus
+	t.Cleanup(func() { focusFlag, reportFocus = orig, origFocus })
+
+	tests := []struct {
+		name, spec string
+		diagnose   bool
+		wantErr    string
+	}{
+		{name: "none"},
+		{name: "clock times", spec: "12:04:30..12:05:10", diagnose: true},
+		{name: "offsets", spec: "+5m..-30s", diagnose: true},
+		{name: "open end", spec: time.Now().UTC().Format(time.RFC3339) + "..", diagnose: true},
+		{name: "before boot", spec: "1970-01-01T00:00:10Z..", diagnose: true, wantErr: "before this node booted"},
+		{name: "without diagnose", spec: "+5m..", wantErr: "requires --diagnose"},
+		{name: "no separator", spec: "12:04:30", diagnose: true, wantErr: "want FROM..TO"},
+		{name: "no bounds", spec: "..", diagnose: true, wantErr: "at least one bound"},
+		{name: "bad bound", spec: "noon..", diagnose: true, wantErr: "bad bound"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			focusFlag = tt.spec
+			err := validateFocus(tt.diagnose)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if (tt.spec == "") != (reportFocus == nil) {
+					t.Fatalf("reportFocus = %v for %q", reportFocus, tt.spec)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestFocusWindowBounds(t *testing.T) {
+	start := time.Date(2026, 10, 16, 23, 50, 0, 0, time.Local)
+	end := start.Add(time.Hour)
+	tests := []struct {
+		spec     string
+		from, to time.Time
+	}{
+		{"+5m..+7m", start.Add(5 * time.Minute), start.Add(7 * time.Minute)},
+		{"-10m..", end.Add(-10 * time.Minute), time.Time{}},
+		{"23:55..00:10:30", start.Add(5 * time.Minute), start.Add(20*time.Minute + 30*time.Second)},
+	}
+	for _, tt := range tests {
+		w, err := parseFocus(tt.spec)
+		if err != nil {
+			t.Fatalf("parseFocus(%q): %v", tt.spec, err)
+		}
+		from, to := w.bounds(start, end)
+		if !from.Equal(tt.from) || !to.Equal(tt.to) {
+			t.Errorf("%q bounds = %v..%v, want %v..%v", tt.spec, from, to, tt.from, tt.to)
+		}
+	}
+}
+
+func TestFocusWindowApplyTo(t *testing.T) {
+	start := time.Now().Truncate(time.Second)
+	newDiag := func() *diagnose.Diagnostician {
+		d := diagnose.NewDiagnostician()
+		for _, offset := range []time.Duration{time.Minute, 30 * time.Minute, 45 * time.Minute} {
+			d.AddEvent(&events.Event{Type: events.EventDNS, Timestamp: clock.WallToBPFTimestamp(start.Add(offset))})
+		}
+		d.SetTimeWindow(start, start.Add(time.Hour))
+		return d
+	}
+
+	d := newDiag()
+	w, _ := parseFocus("+25m..+35m")
+	w.applyTo(d)
+	if n := len(d.GetEvents()); n != 1 {
+		t.Errorf("focused report kept %d events, want 1", n)
+	}
+
+	d = newDiag()
+	w, _ = parseFocus("+35m..+25m")
+	w.applyTo(d)
+	if n := len(d.GetEvents()); n != 3 {
+		t.Errorf("an empty window kept %d events, want the whole session's 3", n)
+	}
+
+	var none *focusWindow
+	none.applyTo(d)
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/geoip"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// geoIPDBs are the --geoip-db MaxMind DB files.
+var geoIPDBs []string
+
+// startGeoIP loads the --geoip-db files and installs them for the report's
+// external networks section. The returned stop function is never nil.
+func startGeoIP() (stop func(), err error) {
+	if len(geoIPDBs) == 0 {
+		return func() {}, nil
+	}
+	db, err := geoip.Open(geoIPDBs)
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Loaded GeoIP databases", zap.Strings("types", db.DatabaseTypes()))
+	geoip.SetGlobal(db)
+	return func() { geoip.SetGlobal(nil) }, nil
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"k8s.io/client-go/rest"
+
+	"github.com/podtrace/podtrace/internal/bundle"
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/history"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/kubernetes/nodespawn"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// maxHistoryReportBytes caps the report of a spawned run kept in the
+// history; the start of a longer one is dropped.
+const maxHistoryReportBytes = 16 << 20
+
+// historyTarget names what this run traces, for the history.
+var historyTarget string
+
+// describeTarget names sel the way it was asked for on the command line.
+func describeTarget(sel kubernetes.TargetSelection) string {
+	var parts []string
+	for _, p := range sel.Pods {
+		ns, name := parsePodRef(p, sel.DefaultNamespace)
+		parts = append(parts, ns+"/"+name)
+	}
+	scope := sel.DefaultNamespace
+	if len(sel.Namespaces) > 0 {
+		scope = strings.Join(sel.Namespaces, ",")
+	}
+	if scope == "" {
+		scope = "*"
+	}
+	if sel.PodSelector != "" {
+		parts = append(parts, scope+"/"+sel.PodSelector)
+	}
+	if sel.AllInNamespace {
+		parts = append(parts, scope+"/*")
+	}
+	target := strings.Join(parts, " ")
+	if sel.ContainerName != "" {
+		target += " (" + sel.ContainerName + ")"
+	}
+	return target
+}
+
+// historyWanted reports whether this run goes into the history: a --diagnose
+// run on a workstation, not the pod it spawns nor an in-cluster job.
+func historyWanted() bool {
+	if !config.History || diagnoseDuration == "" {
+		return false
+	}
+	if os.Getenv(nodespawn.EnvNodeLocalSentinel) == "1" {
+		return false
+	}
+	_, err := rest.InClusterConfig()
+	return err != nil
+}
+
+func openHistory() (*history.Store, error) {
+	dir := config.HistoryDir
+	if dir == "" {
+		var err error
+		if dir, err = history.DefaultDir(); err != nil {
+			return nil, err
+		}
+	}
+	return history.Open(dir, config.HistoryMaxRuns), nil
+}
+
+// runExports lists where else the results of this run went.
+func runExports() []string {
+	var out []string
+	addFile := func(kind, path string) {
+		if path == "" {
+			return
+		}
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		out = append(out, kind+" "+path)
+	}
+	addFile("bundle", bundlePath)
+	addFile("summary", summaryFile)
+	if reportTo != "" {
+		out = append(out, "report-to "+reportTo)
+	}
+	return out
+}
+
+// recordHistory adds the run to the history. A failure is logged: the trace
+// itself succeeded.
+func recordHistory(run history.Run, report []byte) {
+	store, err := openHistory()
+	if err == nil {
+		run.Target = historyTarget
+		run.Exports = runExports()
+		run, err = store.Record(run, report)
+	}
+	if err != nil {
+		logger.Warn("Failed to record the run in the history", zap.Error(err))
+		return
+	}
+	logger.Info("Recorded run; re-open it with `podtrace show "+run.ID+"`", zap.String("id", run.ID))
+}
+
+// recordLocalRun records a --diagnose run traced by this process.
+func recordLocalRun(report string, d *diagnose.Diagnostician) {
+	if !historyWanted() {
+		return
+	}
+	var codes []string
+	for _, issue := range detector.ScoreIssues(d.GetEvents(), d.ErrorRateThreshold(), d.RTTSpikeThreshold()) {
+		codes = append(codes, issue.Code)
+	}
+	recordHistory(history.Run{
+		Started:    d.StartTime(),
+		DurationMS: d.EndTime().Sub(d.StartTime()).Milliseconds(),
+		Issues:     codes,
+		Metrics:    runMetrics(d, d.EndTime().Sub(d.StartTime())),
+	}, []byte(report))
+}
+
+// historyMetrics are the headline figures a run is compared on, in report
+// order.
+var historyMetrics = []struct{ key, label, unit string }{
+	{"dns_p99_ms", "p99 DNS", "ms"},
+	{"dns_errors_pct", "DNS errors", "%"},
+	{"connect_p99_ms", "p99 connect", "ms"},
+	{"connect_errors_pct", "connect errors", "%"},
+	{"tcp_p99_ms", "p99 TCP send/recv", "ms"},
+	{"tcp_retransmits_per_min", "TCP retransmits", "/min"},
+	{"fs_p99_ms", "p99 file I/O", "ms"},
+	{"http_p99_ms", "p99 HTTP", "ms"},
+	{"events_per_sec", "events", "/s"},
+}
+
+// runMetrics computes the headline figures of a run from d's session totals,
+// so they cover every event, kept or not. A figure without a sample is left
+// out, so it is not compared with a run that had
+// one.
+func runMetrics(d *diagnose.Diagnostician, duration time.Duration) map[string]float64 {
+	out := make(map[string]float64, len(historyMetrics))
+	for key, types := range historyMetricTypes {
+		g, _ := d.SessionGroup(types...)
+		if g.Events == 0 {
+			continue
+		}
+		out[key+"_p99_ms"] = g.P99MS
+		if key == "dns" || key == "connect" {
+			out[key+"_errors_pct"] = float64(g.Errors) / float64(g.Events) * 100
+		}
+	}
+	if duration > 0 {
+		retrans, _ := d.SessionGroup(events.EventTCPRetrans)
+		out["tcp_retransmits_per_min"] = float64(retrans.Events) / duration.Minutes()
+		if s := d.SessionTotals(); s != nil {
+			out["events_per_sec"] = float64(s.Events) / duration.Seconds()
+		}
+	}
+	return out
+}
+
+// historyMetricTypes are the event types behind each latency figure.
+var historyMetricTypes = map[string][]events.EventType{
+	"dns":     {events.EventDNS},
+	"connect": {events.EventConnect},
+	"tcp":     {events.EventTCPSend, events.EventTCPRecv},
+	"fs":      {events.EventWrite, events.EventRead, events.EventFsync},
+	"http":    {events.EventHTTPResp},
+}
+
+// historyComparison returns the report section comparing this run with the
+// last recorded run of the same target, or "" when there is none or
+// nothing moved.
+func historyComparison(d *diagnose.Diagnostician) string {
+	if !historyWanted() || historyTarget == "" {
+		return ""
+	}
+	store, err := openHistory()
+	if err != nil {
+		return ""
+	}
+	prev, err := store.Previous(historyTarget, d.StartTime())
+	if err != nil {
+		if !errors.Is(err, history.ErrNotFound) {
+			logger.Debug("Failed to read the history for a comparison", zap.Error(err))
+		}
+		return ""
+	}
+	current := runMetrics(d, d.EndTime().Sub(d.StartTime()))
+	return formatHistoryComparison(prev, history.Compare(prev.Metrics, current, config.HistoryCompareMinChange))
+}
+
+func formatHistoryComparison(prev history.Run, deltas []history.Delta) string {
+	if len(deltas) == 0 {
+		return ""
+	}
+	moved := make(map[string]history.Delta, len(deltas))
+	for _, d := range deltas {
+		moved[d.Metric] = d
+	}
+	on := prev.Started.Local().Format("2006-01-02")
+	var b strings.Builder
+	fmt.Fprintf(&b, "\nChanges since the last run (%s, `podtrace show %s`):\n", prev.Started.Local().Format("2006-01-02 15:04"), prev.ID)
+	for _, m := range historyMetrics {
+		d, ok := moved[m.key]
+		if !ok {
+			continue
+		}
+		values := formatHistoryValue(d.Previous, m.unit) + " → " + formatHistoryValue(d.Current, m.unit)
+		if math.IsInf(d.Change(), 1) {
+			fmt.Fprintf(&b, "  %s up from none vs last run on %s (%s)\n", m.label, on, values)
+			continue
+		}
+		fmt.Fprintf(&b, "  %s %+.0f%% vs last run on %s (%s)\n", m.label, d.Change()*100, on, values)
+	}
+	return b.String()
+}
+
+func formatHistoryValue(v float64, unit string) string {
+	switch unit {
+	case "%":
+		return fmt.Sprintf("%.1f%%", v)
+	case "ms":
+		return fmt.Sprintf("%.2fms", v)
+	}
+	return fmt.Sprintf("%.1f%s", v, unit)
+}
+
+// newSpawnHistoryOutput returns the writer a spawned run's output is copied
+// to for the history, or nil when the run is not recorded.
+func newSpawnHistoryOutput() *bundle.TailBuffer {
+	if !historyWanted() {
+		return nil
+	}
+	return bundle.NewTailBuffer(maxHistoryReportBytes)
+}
+
+// recordSpawnRun records a --diagnose run traced by spawned pods, with the
+// output they streamed back as its report.
+func recordSpawnRun(output *bundle.TailBuffer, started time.Time) {
+	if output == nil || len(output.Bytes()) == 0 {
+		return
+	}
+	format := exportFormat
+	if format == "" {
+		format = "text"
+	}
+	recordHistory(history.Run{
+		Started:    started,
+		DurationMS: time.Since(started).Milliseconds(),
+		Remote:     true,
+		Format:     format,
+	}, output.Bytes())
+}
+
+func newHistoryCmd() *cobra.Command {
+	var limit int
+	var target string
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "List past --diagnose runs made from this workstation",
+		Long: `Lists the --diagnose runs recorded in PODTRACE_HISTORY_DIR (default
+~/.podtrace), newest first: when they ran, what they traced, the issues they
+found and where their results were exported. Re-open a report with
+` + "`podtrace show <id>`" + `. Set PODTRACE_HISTORY=false to stop recording.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openHistory()
+			if err != nil {
+				return err
+			}
+			runs, err := store.List()
+			if err != nil {
+				return err
+			}
+			var shown []history.Run
+			for _, r := range runs {
+				if target != "" && !strings.Contains(r.Target, target) {
+					continue
+				}
+				if limit > 0 && len(shown) == limit {
+					break
+				}
+				shown = append(shown, r)
+			}
+			return writeHistory(cmd.OutOrStdout(), shown)
+		},
+	}
+	cmd.Flags().IntVar(&limit, "limit", 20, "Show at most this many runs (0 = all)")
+	cmd.Flags().StringVar(&target, "target", "", "Only runs whose target contains this text, e.g. a pod or namespace name")
+	return cmd
+}
+
+func writeHistory(out io.Writer, runs []history.Run) error {
+	if len(runs) == 0 {
+		_, err := fmt.Fprintln(out, "No runs recorded.")
+		return err
+	}
+	tw := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tSTARTED\tDURATION\tTARGET\tISSUES\tEXPORTS")
+	for _, r := range runs {
+		exports := strings.Join(r.Exports, ", ")
+		if exports == "" {
+			exports = "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.ID, r.Started.Local().Format("2006-01-02 15:04"), r.Duration().Round(time.Second),
+			r.Target, historyIssues(r), exports)
+	}
+	return tw.Flush()
+}
+
+// historyIssues summarizes the issues of r, e.g. "2 (PODTRACE-NET-001)".
+func historyIssues(r history.Run) string {
+	if r.Remote {
+		return "?"
+	}
+	if len(r.Issues) == 0 {
+		return "0"
+	}
+	codes := append([]string(nil), r.Issues...)
+	sort.Strings(codes)
+	codes = slices.Compact(codes)
+	return strconv.Itoa(len(r.Issues)) + " (" + strings.Join(codes, ",") + ")"
+}
+
+func newShowCmd() *cobra.Command {
+	var meta bool
+	cmd := &cobra.Command{
+		Use:   "show [id]",
+		Short: "Print the report of a past --diagnose run",
+		Long: `Prints the stored report of the run with this ID, or any unique prefix of
+it, from ` + "`podtrace history`" + `; without an ID, that of the latest run.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			store, err := openHistory()
+			if err != nil {
+				return err
+			}
+			ref := ""
+			if len(args) == 1 {
+				ref = args[0]
+			}
+			run, err := store.Find(ref)
+			if errors.Is(err, history.ErrNotFound) && ref == "" {
+				return errors.New("no runs recorded yet")
+			}
+			if err != nil {
+				return err
+			}
+			if meta {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(run)
+			}
+			report, err := store.Report(run)
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(report)
+			return err
+		},
+	}
+	cmd.Flags().BoolVar(&meta, "meta", false, "Print the run's record (target, time, issues, exports) as JSON instead of its report")
+	return cmd
+}
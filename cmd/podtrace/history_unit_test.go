@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/history"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+func TestDescribeTarget(t *testing.T) {
+	tests := []struct {
+		sel  kubernetes.TargetSelection
+		want string
+	}{
+		{kubernetes.TargetSelection{DefaultNamespace: "prod", Pods: []string{"api-0", "db/pg-0"}}, "prod/api-0 db/pg-0"},
+		{kubernetes.TargetSelection{DefaultNamespace: "prod", PodSelector: "app=api", ContainerName: "app"}, "prod/app=api (app)"},
+		{kubernetes.TargetSelection{Namespaces: []string{"a", "b"}, AllInNamespace: true}, "a,b/*"},
+		{kubernetes.TargetSelection{PodSelector: "app=api"}, "*/app=api"},
+	}
+	for _, tt := range tests {
+		if got := describeTarget(tt.sel); got != tt.want {
+			t.Errorf("describeTarget(%+v) = %q, want %q", tt.sel, got, tt.want)
+		}
+	}
+}
+
+func TestHistoryIssues(t *testing.T) {
+	if got := historyIssues(history.Run{Remote: true}); got != "?" {
+		t.Errorf("remote run: %q", got)
+	}
+	if got := historyIssues(history.Run{}); got != "0" {
+		t.Errorf("clean run: %q", got)
+	}
+	r := history.Run{Issues: []string{"PODTRACE-NET-001", "PODTRACE-DNS-001", "PODTRACE-NET-001"}}
+	if got := historyIssues(r); got != "3 (PODTRACE-DNS-001,PODTRACE-NET-001)" {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestHistoryAndShowCommands(t *testing.T) {
+	origDir, origEnabled, origTarget := config.HistoryDir, config.History, historyTarget
+	origDiagnose, origBundle := diagnoseDuration, bundlePath
+	t.Cleanup(func() {
+		config.HistoryDir, config.History, historyTarget = origDir, origEnabled, origTarget
+		diagnoseDuration, bundlePath = origDiagnose, origBundle
+	})
+	config.HistoryDir = t.TempDir()
+	config.History = true
+	diagnoseDuration = "1m"
+	bundlePath = "/tmp/incident.tar.gz"
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+
+	var out bytes.Buffer
+	show := newShowCmd()
+	show.SetOut(&out)
+	show.SetArgs(nil)
+	if err := show.Execute(); err == nil || !strings.Contains(err.Error(), "no runs recorded") {
+		t.Fatalf("expected an empty-history error, got %v", err)
+	}
+
+	if !historyWanted() {
+		t.Fatal("a workstation --diagnose run should be recorded")
+	}
+	historyTarget = "prod/api-0"
+	recordHistory(history.Run{Started: time.Now(), DurationMS: 90000, Issues: []string{"PODTRACE-NET-001"}}, []byte("the report\n"))
+
+	list := newHistoryCmd()
+	list.SetOut(&out)
+	list.SetArgs([]string{"--target", "api-0"})
+	if err := list.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"prod/api-0", "1m30s", "1 (PODTRACE-NET-001)", "bundle /tmp/incident.tar.gz"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("history output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	list.SetArgs([]string{"--target", "nomatch"})
+	if err := list.Execute(); err != nil || !strings.Contains(out.String(), "No runs recorded") {
+		t.Errorf("filtered history = %q, %v", out.String(), err)
+	}
+
+	out.Reset()
+	show.SetArgs(nil)
+	if err := show.Execute(); err != nil || out.String() != "the report\n" {
+		t.Errorf("show = %q, %v", out.String(), err)
+	}
+	out.Reset()
+	show.SetArgs([]string{"--meta", time.Now().UTC().Format("2006")})
+	if err := show.Execute(); err != nil || !strings.Contains(out.String(), `"target": "prod/api-0"`) {
+		t.Errorf("show --meta = %q, %v", out.String(), err)
+	}
+
+	config.History = false
+	if historyWanted() {
+		t.Error("PODTRACE_HISTORY=false should stop recording")
+	}
+}
+
+func TestRunMetricsComparison(t *testing.T) {
+	d := diagnose.NewDiagnostician()
+	d.SetEventBudget(1)
+	for _, e := range []*events.Event{
+		{Type: events.EventDNS, LatencyNS: 40_000_000},
+		{Type: events.EventDNS, LatencyNS: 40_000_000, Error: 3},
+		{Type: events.EventConnect, LatencyNS: 2_000_000},
+		{Type: events.EventTCPRetrans},
+	} {
+		d.AddEvent(e)
+	}
+	got := runMetrics(d, 2*time.Minute)
+	if got["dns_p99_ms"] != 40 || got["dns_errors_pct"] != 50 || got["connect_errors_pct"] != 0 || got["tcp_retransmits_per_min"] != 0.5 {
+		t.Errorf("metrics = %v", got)
+	}
+	if _, ok := got["fs_p99_ms"]; ok {
+		t.Error("fs p99 recorded without file events")
+	}
+
+	prev := history.Run{
+		ID:      "20261015-142233-9f1c",
+		Started: time.Date(2026, 10, 15, 14, 22, 33, 0, time.Local),
+		Metrics: map[string]float64{"dns_p99_ms": 10, "dns_errors_pct": 0, "connect_p99_ms": 2, "connect_errors_pct": 0},
+	}
+	section := formatHistoryComparison(prev, history.Compare(prev.Metrics, got, 0.2))
+	for _, want := range []string{
+		"Changes since the last run (2026-10-15 14:22, `podtrace show 20261015-142233-9f1c`):",
+		"  p99 DNS +300% vs last run on 2026-10-15 (10.00ms → 40.00ms)",
+		"  DNS errors up from none vs last run on 2026-10-15 (0.0% → 50.0%)",
+	} {
+		if !strings.Contains(section, want) {
+			t.Errorf("section lacks %q:\n%s", want, section)
+		}
+	}
+	if strings.Contains(section, "connect") {
+		t.Errorf("unchanged connect metrics reported:\n%s", section)
+	}
+	if formatHistoryComparison(prev, nil) != "" {
+		t.Error("section without changes")
+	}
+}
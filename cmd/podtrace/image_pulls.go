@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// startImagePullWatch injects the image pulls the kubelet reports for the
+// target pods into eventChan as EventImagePull until ctx is done, each with
+// the registry's connect latency when PODTRACE_REGISTRY_PROBE is on.
+// Resolvers without a clientset, or a missing permission to watch events,
+// leave pulls out of the trace.
+func startImagePullWatch(ctx context.Context, eventChan chan<- *events.Event, resolver kubernetes.PodResolverInterface, targets []*kubernetes.PodInfo) {
+	if !config.ImagePulls {
+		return
+	}
+	provider, ok := resolver.(kubernetes.ClientsetProvider)
+	if !ok || provider.GetClientset() == nil {
+		return
+	}
+	since := time.Now().Add(-config.ImagePullLookback)
+	emit := func(p kubernetes.ImagePull) {
+		// Probing blocks for up to the timeout; the watch must not.
+		go func() {
+			if config.RegistryProbe && !p.Cached {
+				if rtt, err := kubernetes.ProbeRegistry(ctx, p.Registry, config.DefaultRegistryProbeTimeout); err == nil {
+					p.RegistryRTT = rtt
+				} else {
+					logger.Debug("Registry probe failed", zap.String("registry", p.Registry), zap.Error(err))
+				}
+			}
+			select {
+			case <-ctx.Done():
+			case eventChan <- p.Event():
+			default:
+			}
+		}()
+	}
+	for _, p := range targets {
+		if err := kubernetes.WatchImagePulls(ctx, provider.GetClientset(), p.Namespace, p.PodName, since, emit); err != nil {
+			logger.Info("Image pulls not traced",
+				zap.String("namespace", p.Namespace), zap.String("pod", p.PodName), zap.Error(err))
+		}
+	}
+}
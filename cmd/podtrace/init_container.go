@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/validation"
+)
+
+// initContainerName is the --init-container to trace.
+var initContainerName string
+
+// validateInitContainer checks --init-container against the rest of the
+// selection: it names one container of one pod, like --container.
+func validateInitContainer(pods []string) error {
+	if initContainerName == "" {
+		return nil
+	}
+	if err := validation.ValidateContainerName(initContainerName); err != nil {
+		return fmt.Errorf("invalid init container name: %w", err)
+	}
+	if containerName != "" {
+		return fmt.Errorf("--init-container and --container are mutually exclusive")
+	}
+	if len(pods) != 1 || podSelector != "" || allInNamespace {
+		return fmt.Errorf("--init-container requires exactly one target pod")
+	}
+	return nil
+}
+
+// waitForInitContainer blocks until --init-container is running in the
+// target pod, for up to PODTRACE_INIT_CONTAINER_WAIT_TIMEOUT, so that a pod
+// still pulling images or running earlier init steps can be traced from the
+// moment the named step starts. Resolvers without a clientset cannot be
+// polled and resolve the container as-is.
+func waitForInitContainer(ctx context.Context, resolver kubernetes.PodResolverInterface, podRef string) error {
+	provider, ok := resolver.(kubernetes.ClientsetProvider)
+	if !ok {
+		return nil
+	}
+	podNs, podName := parsePodRef(podRef, namespace)
+	waitCtx, cancel := context.WithTimeout(ctx, config.InitContainerWaitTimeout)
+	defer cancel()
+	if err := kubernetes.WaitForInitContainer(waitCtx, provider.GetClientset(), podNs, podName,
+		initContainerName, config.DefaultInitContainerPollInterval); err != nil {
+		return fmt.Errorf("failed to resolve pod %s/%s: %w", podNs, podName, err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+func TestValidateInitContainer(t *testing.T) {
+	saveRunPodtraceGlobals(t)
+	resetRunPodtraceGlobals()
+
+	if err := validateInitContainer([]string{"api-0"}); err != nil {
+		t.Fatalf("unset --init-container should validate, got %v", err)
+	}
+
+	initContainerName = "migrate"
+	if err := validateInitContainer([]string{"api-0"}); err != nil {
+		t.Fatalf("single pod should validate, got %v", err)
+	}
+	if err := validateInitContainer([]string{"api-0", "api-1"}); err == nil {
+		t.Fatal("expected an error for more than one pod")
+	}
+
+	containerName = "app"
+	if err := validateInitContainer([]string{"api-0"}); err == nil || !strings.Contains(err.Error(), "mutually exclusive") {
+		t.Fatalf("expected --container conflict, got %v", err)
+	}
+	containerName = ""
+
+	podSelector = "app=api"
+	if err := validateInitContainer(nil); err == nil {
+		t.Fatal("expected an error with --pod-selector")
+	}
+	podSelector = ""
+
+	initContainerName = "bad name!"
+	if err := validateInitContainer([]string{"api-0"}); err == nil {
+		t.Fatal("expected an invalid-name error")
+	}
+}
+
+func TestRunPodtrace_InitContainerResolvesNamedInitContainer(t *testing.T) {
+	saveRunPodtraceGlobals(t)
+	resetRunPodtraceGlobals()
+	initContainerName = "migrate"
+
+	var got string
+	resolverFactory = func() (kubernetes.PodResolverInterface, error) {
+		return &mockPodResolver{resolvePodFunc: func(_ context.Context, _, _, container string) (*kubernetes.PodInfo, error) {
+			got = container
+			return nil, errors.New("stop after resolve")
+		}}, nil
+	}
+
+	err := runPodtrace(cmdWithNamespaceChanged(), []string{"api-0"})
+	if err == nil || !strings.Contains(err.Error(), "stop after resolve") {
+		t.Fatalf("expected resolver error, got %v", err)
+	}
+	if got != "migrate" {
+		t.Fatalf("ResolvePod container: got %q want %q", got, "migrate")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.uber.org/zap"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/hooks"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// onIssueScripts are the --on-issue scripts run for each detected issue.
+var onIssueScripts []string
+
+// issueEvents is --issue-events.
+var issueEvents bool
+
+// startIssueHooks installs the global hooks dispatcher with one exec
+// callback per --on-issue script; the Kubernetes Events callback is added
+// by registerIssueEvents once there is a clientset. The returned stop
+// function waits for running callbacks; it is a no-op without --on-issue,
+// --issue-events and --syslog.
+func startIssueHooks() (stop func(), err error) {
+	if len(onIssueScripts) == 0 && !config.IssueEvents && syslogOut == nil {
+		return func() {}, nil
+	}
+	d := hooks.NewDispatcher(config.IssueHookCooldown, config.IssueHookTimeout)
+	for _, script := range onIssueScripts {
+		info, err := os.Stat(script)
+		if err != nil {
+			return nil, fmt.Errorf("--on-issue: %w", err)
+		}
+		if info.IsDir() || info.Mode().Perm()&0o111 == 0 {
+			return nil, fmt.Errorf("--on-issue: %s is not an executable file", script)
+		}
+		d.Register(&hooks.ExecCallback{Path: script})
+	}
+	if syslogOut != nil {
+		d.Register(syslogOut)
+	}
+	hooks.SetGlobal(d)
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), config.IssueHookTimeout)
+		defer cancel()
+		if err := d.Wait(ctx); err != nil {
+			logger.Warn("Issue callbacks still running at exit", zap.Error(err))
+		}
+		hooks.SetGlobal(nil)
+	}, nil
+}
+
+// registerIssueEvents adds the callback that records findings as Events on
+// the traced pod when --issue-events is set and resolver reaches the API
+// server.
+func registerIssueEvents(resolver kubernetes.PodResolverInterface) {
+	d := hooks.Global()
+	if !config.IssueEvents || d == nil {
+		return
+	}
+	var clientset k8s.Interface
+	if p, ok := resolver.(kubernetes.ClientsetProvider); ok {
+		clientset = p.GetClientset()
+	}
+	if clientset == nil {
+		logger.Warn("--issue-events ignored: no Kubernetes API access to record Events with")
+		return
+	}
+	d.Register(kubernetes.NewIssueEventRecorder(clientset))
+}
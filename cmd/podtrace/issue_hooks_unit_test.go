@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/hooks"
+)
+
+func TestStartIssueHooks(t *testing.T) {
+	orig := onIssueScripts
+	t.Cleanup(func() { onIssueScripts = orig })
+
+	onIssueScripts = nil
+	stop, err := startIssueHooks()
+	if err != nil || hooks.Global() != nil {
+		t.Fatalf("no scripts: err = %v, global = %v", err, hooks.Global())
+	}
+	stop()
+
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "notes.txt")
+	if err := os.WriteFile(plain, []byte("x"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	onIssueScripts = []string{plain}
+	if _, err := startIssueHooks(); err == nil || !strings.Contains(err.Error(), "not an executable") {
+		t.Errorf("err = %v", err)
+	}
+	onIssueScripts = []string{filepath.Join(dir, "missing.sh")}
+	if _, err := startIssueHooks(); err == nil {
+		t.Error("expected error for a missing script")
+	}
+
+	script := filepath.Join(dir, "hook.sh")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	onIssueScripts = []string{script}
+	stop, err = startIssueHooks()
+	if err != nil || hooks.Global() == nil {
+		t.Fatalf("err = %v, global = %v", err, hooks.Global())
+	}
+	stop()
+	if hooks.Global() != nil {
+		t.Error("stop must uninstall the dispatcher")
+	}
+}
+
+func TestStartIssueHooks_IssueEvents(t *testing.T) {
+	origScripts, origEvents := onIssueScripts, config.IssueEvents
+	t.Cleanup(func() { onIssueScripts, config.IssueEvents = origScripts, origEvents })
+
+	onIssueScripts, config.IssueEvents = nil, true
+	stop, err := startIssueHooks()
+	if err != nil || hooks.Global() == nil {
+		t.Fatalf("--issue-events alone: err = %v, global = %v", err, hooks.Global())
+	}
+	// Without API access the callback is skipped rather than failing.
+	registerIssueEvents(&mockPodResolver{})
+	stop()
+}
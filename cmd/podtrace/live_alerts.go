@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/sanitize"
+)
+
+// liveAlertRecent is how many alert lines are kept to be re-printed above
+// the real-time report, which clears the screen on every refresh.
+const liveAlertRecent = 5
+
+// liveAlerter prints an [ALERT] line as soon as a detection rule fires,
+// between the periodic report refreshes. An issue that keeps firing is
+// alerted again at most once per debounce window.
+type liveAlerter struct {
+	w         io.Writer
+	debounce  time.Duration
+	highlight bool
+	now       func() time.Time
+
+	mu     sync.Mutex
+	last   map[string]time.Time
+	recent []string
+}
+
+func newLiveAlerter(w io.Writer, debounce time.Duration) *liveAlerter {
+	return &liveAlerter{
+		w:         w,
+		debounce:  debounce,
+		highlight: isTerminal(w),
+		now:       time.Now,
+		last:      make(map[string]time.Time),
+	}
+}
+
+// Check scores the issues seen so far and prints those that have not been
+// alerted within the debounce window. Issues of a per-subject rule, such as
+// 5xx bursts on two endpoints, are alerted separately.
+func (a *liveAlerter) Check(d *diagnose.Diagnostician) {
+	scored := detector.ScoreIssues(d.GetEvents(), d.ErrorRateThreshold(), d.RTTSpikeThreshold())
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := a.now()
+	for _, issue := range scored {
+		key := issue.Key()
+		if at, ok := a.last[key]; ok && now.Sub(at) < a.debounce {
+			continue
+		}
+		a.last[key] = now
+		line := fmt.Sprintf("[ALERT] %s [%s] %s", now.Format("15:04:05"), issue.Code, sanitize.Terminal(issue.Message))
+		a.recent = append(a.recent, line)
+		if len(a.recent) > liveAlertRecent {
+			a.recent = a.recent[len(a.recent)-liveAlertRecent:]
+		}
+		_, _ = fmt.Fprintln(a.w, a.render(line))
+	}
+}
+
+// Recent renders the latest alerts for the top of the real-time report, or
+// "" when none has fired yet.
+func (a *liveAlerter) Recent() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.recent) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range a.recent {
+		b.WriteString(a.render(line))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func (a *liveAlerter) render(line string) string {
+	if !a.highlight {
+		return line
+	}
+	return "\033[1;31m" + line + "\033[0m"
+}
+
+// isTerminal reports whether w is a character device, so that escape codes
+// are not written into redirected output.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// startLiveAlerts checks for issues every PODTRACE_LIVE_ALERT_INTERVAL in
+// its own goroutine, so that scoring the session's events never holds up
+// the event loop: the [ISSUE] lines for the issues tier, [ALERT] lines
+// otherwise. With live alerts disabled the issues tier is still checked at
+// the report interval. The returned stop waits for a running check.
+func startLiveAlerts(ctx context.Context, d *diagnose.Diagnostician, printer *verbosityPrinter, alerter *liveAlerter) (stop func()) {
+	interval := config.LiveAlertInterval
+	if interval <= 0 && printer.issuesOnly() {
+		interval = config.DefaultRealtimeUpdateInterval
+	}
+	if interval <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-t.C:
+				if printer.issuesOnly() {
+					printer.PrintNewIssues(d)
+				} else {
+					alerter.Check(d)
+				}
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/annotation"
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/debugserver"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/metricsexporter"
+)
+
+var (
+	markStartCmd string
+	markEndCmd   string
+	markAddr     string
+)
+
+// loadWindow brackets a --diagnose run to the load-generation period; nil
+// without --mark-start-cmd and --mark-addr.
+var loadWindow *measureWindow
+
+func loadMarksRequested() bool {
+	return markStartCmd != "" || markAddr != ""
+}
+
+// validateLoadMarks checks the --mark-* flags against the rest of the run.
+func validateLoadMarks(diagnose bool) error {
+	if markEndCmd != "" && markStartCmd == "" {
+		return errors.New("--mark-end-cmd requires --mark-start-cmd")
+	}
+	if !loadMarksRequested() {
+		return nil
+	}
+	if !diagnose {
+		return errors.New("--mark-start-cmd and --mark-addr require --diagnose")
+	}
+	if markAddr != "" && !debugserver.IsLoopback(markAddr) {
+		return fmt.Errorf("--mark-addr %q is not a loopback address", markAddr)
+	}
+	return nil
+}
+
+// measureWindow is the period the load ran. It opens and closes once; the
+// bounds are wall-clock times compared against event timestamps, so events
+// that are still in flight when it closes are kept.
+type measureWindow struct {
+	mu         sync.Mutex
+	start, end time.Time
+	closed     chan struct{}
+	// annotations receives a marker at each bound, for the timeline.
+	annotations chan<- *events.Event
+}
+
+func newMeasureWindow(annotations chan<- *events.Event) *measureWindow {
+	return &measureWindow{closed: make(chan struct{}), annotations: annotations}
+}
+
+// Open starts the window at at; it reports false if it was already open.
+func (w *measureWindow) Open(at time.Time, why string) bool {
+	w.mu.Lock()
+	if !w.start.IsZero() {
+		w.mu.Unlock()
+		return false
+	}
+	w.start = at
+	w.mu.Unlock()
+	logger.Info("Load window opened", zap.String("by", why))
+	w.annotate("load start: "+why, at)
+	return true
+}
+
+// Close ends the window at at; it reports false if the window is not open
+// or already closed.
+func (w *measureWindow) Close(at time.Time, why string) bool {
+	w.mu.Lock()
+	if w.start.IsZero() || !w.end.IsZero() {
+		w.mu.Unlock()
+		return false
+	}
+	w.end = at
+	w.mu.Unlock()
+	logger.Info("Load window closed", zap.String("by", why), zap.Duration("length", at.Sub(w.start)))
+	w.annotate("load end: "+why, at)
+	close(w.closed)
+	return true
+}
+
+// Closed fires once the window closes. It is nil, and never fires, for a
+// nil window.
+func (w *measureWindow) Closed() <-chan struct{} {
+	if w == nil {
+		return nil
+	}
+	return w.closed
+}
+
+func (w *measureWindow) bounds() (start, end time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.start, w.end
+}
+
+// admits reports whether an event at at falls inside the window.
+func (w *measureWindow) admits(at time.Time) bool {
+	start, end := w.bounds()
+	if start.IsZero() || at.Before(start) {
+		return false
+	}
+	return end.IsZero() || !at.After(end)
+}
+
+func (w *measureWindow) annotate(text string, at time.Time) {
+	if w.annotations == nil {
+		return
+	}
+	select {
+	case w.annotations <- annotation.NewEvent(text, at):
+	default:
+	}
+}
+
+// diagnoseDeadline fires after d or, earlier, when w closes: what follows
+// the load is teardown noise.
+func diagnoseDeadline(d time.Duration, w *measureWindow) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-w.Closed():
+		}
+		close(done)
+	}()
+	return done
+}
+
+// applyTo makes d's rates cover the window instead of the whole run.
+func (w *measureWindow) applyTo(d *diagnose.Diagnostician) {
+	if w == nil {
+		return
+	}
+	start, end := w.bounds()
+	if start.IsZero() {
+		logger.Warn("The load window never opened; the report covers no events")
+		return
+	}
+	if end.IsZero() {
+		end = d.EndTime()
+	}
+	d.SetTimeWindow(start, end)
+}
+
+// gateOnWindow passes the events of in that fall inside w to out and counts
+// the rest, the setup and teardown noise around the load.
+func gateOnWindow(ctx context.Context, in <-chan *events.Event, out chan<- *events.Event, w *measureWindow) {
+	defer close(out)
+	excluded := 0
+	defer func() {
+		if excluded > 0 {
+			logger.Info("Excluded events outside the load window", zap.Int("events", excluded))
+		}
+	}()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-in:
+			if !ok {
+				return
+			}
+			if event == nil {
+				continue
+			}
+			at := time.Now()
+			if event.Timestamp != 0 {
+				at = event.TimestampTime()
+			}
+			if !w.admits(at) {
+				excluded++
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case out <- event:
+			default:
+				logger.Warn("Load window event channel full, dropping event",
+					zap.String("event_type", event.TypeString()),
+					zap.Uint32("pid", event.PID))
+				metricsexporter.RecordFilteredEventDrop()
+			}
+		}
+	}
+}
+
+// startLoadMarks runs --mark-start-cmd and serves --mark-addr. The window
+// opens when the command starts and closes when it exits, unless a
+// --mark-end-cmd will stop the load: then it closes when the returned stop
+// function runs that command at the end of the run.
+func startLoadMarks(ctx context.Context, w *measureWindow) (stop func(), err error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	var srv *http.Server
+	if markAddr != "" {
+		ln, err := net.Listen("tcp", markAddr)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("--mark-addr: %w", err)
+		}
+		srv = &http.Server{Handler: markHandler(w), ReadHeaderTimeout: 10 * time.Second}
+		go func() { _ = srv.Serve(ln) }()
+		logger.Info("Accepting load window marks", zap.String("addr", ln.Addr().String()))
+	}
+	if markStartCmd != "" {
+		cmd := markCommand(ctx, markStartCmd)
+		if err := cmd.Start(); err != nil {
+			cancel()
+			if srv != nil {
+				_ = srv.Close()
+			}
+			return nil, fmt.Errorf("--mark-start-cmd: %w", err)
+		}
+		w.Open(time.Now(), "--mark-start-cmd")
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := cmd.Wait()
+			if err != nil && ctx.Err() == nil {
+				logger.Warn("--mark-start-cmd failed", zap.Error(err))
+			}
+			if markEndCmd == "" {
+				w.Close(time.Now(), "--mark-start-cmd exited")
+			}
+		}()
+	}
+	return func() {
+		if markEndCmd != "" {
+			w.Close(time.Now(), "--mark-end-cmd")
+			endCtx, endCancel := context.WithTimeout(context.Background(), config.MarkCommandTimeout)
+			if err := markCommand(endCtx, markEndCmd).Run(); err != nil {
+				logger.Warn("--mark-end-cmd failed", zap.Error(err))
+			}
+			endCancel()
+		}
+		cancel()
+		wg.Wait()
+		if srv != nil {
+			_ = srv.Close()
+		}
+	}, nil
+}
+
+// markCommand runs line through the shell, with its output on stderr so it
+// stays apart from the report. It gets its own process group, killed as a
+// whole when ctx is done, so that a load tool the shell started does not
+// outlive podtrace.
+func markCommand(ctx context.Context, line string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", line) // #nosec G204 -- operator-supplied --mark-*-cmd.
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
+
+// markHandler serves POST /start and POST /end for load tools that mark the
+// window themselves, e.g. from a k6 setup() and teardown().
+func markHandler(w *measureWindow) http.Handler {
+	mux := http.NewServeMux()
+	mark := func(apply func(time.Time, string) bool) http.HandlerFunc {
+		return func(rw http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost {
+				rw.Header().Set("Allow", http.MethodPost)
+				http.Error(rw, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			if !apply(time.Now(), "--mark-addr") {
+				http.Error(rw, "load window is not in a state to take this mark", http.StatusConflict)
+				return
+			}
+			rw.WriteHeader(http.StatusNoContent)
+		}
+	}
+	mux.HandleFunc("/start", mark(w.Open))
+	mux.HandleFunc("/end", mark(w.Close))
+	return mux
+}
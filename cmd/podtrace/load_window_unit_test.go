@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func saveLoadMarkGlobals(t *testing.T) {
+	t.Helper()
+	start, end, addr, window := markStartCmd, markEndCmd, markAddr, loadWindow
+	t.Cleanup(func() {
+		markStartCmd, markEndCmd, markAddr, loadWindow = start, end, addr, window
+	})
+}
+
+func TestValidateLoadMarks(t *testing.T) {
+	saveLoadMarkGlobals(t)
+	tests := []struct {
+		name             string
+		start, end, addr string
+		diagnose         bool
+		wantErr          string
+	}{
+		{name: "none", diagnose: false},
+		{name: "start cmd", start: "k6 run load.js", diagnose: true},
+		{name: "end without start", end: "pkill k6", diagnose: true, wantErr: "requires --mark-start-cmd"},
+		{name: "without diagnose", start: "k6 run load.js", wantErr: "require --diagnose"},
+		{name: "loopback addr", addr: "127.0.0.1:7070", diagnose: true},
+		{name: "public addr", addr: "0.0.0.0:7070", diagnose: true, wantErr: "not a loopback"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			markStartCmd, markEndCmd, markAddr = tt.start, tt.end, tt.addr
+			err := validateLoadMarks(tt.diagnose)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestMeasureWindow_Bounds(t *testing.T) {
+	annotations := make(chan *events.Event, 4)
+	w := newMeasureWindow(annotations)
+	t0 := time.Now()
+
+	if w.admits(t0) {
+		t.Error("a window that never opened admitted an event")
+	}
+	if w.Close(t0, "test") {
+		t.Error("closed a window that was not open")
+	}
+	if !w.Open(t0, "test") || w.Open(t0.Add(time.Second), "test") {
+		t.Fatal("expected the window to open exactly once")
+	}
+	if w.admits(t0.Add(-time.Millisecond)) || !w.admits(t0.Add(time.Hour)) {
+		t.Error("an open window must admit what follows its start only")
+	}
+	end := t0.Add(time.Second)
+	if !w.Close(end, "test") || w.Close(end.Add(time.Second), "test") {
+		t.Fatal("expected the window to close exactly once")
+	}
+	select {
+	case <-w.Closed():
+	default:
+		t.Error("Closed did not fire")
+	}
+	if !w.admits(end) || w.admits(end.Add(time.Millisecond)) {
+		t.Error("a closed window must admit up to its end only")
+	}
+	if len(annotations) != 2 {
+		t.Errorf("expected a timeline marker at each bound, got %d", len(annotations))
+	}
+
+	var nilWindow *measureWindow
+	if nilWindow.Closed() != nil {
+		t.Error("a nil window must never close")
+	}
+	nilWindow.applyTo(nil)
+}
+
+func TestGateOnWindow(t *testing.T) {
+	w := newMeasureWindow(nil)
+	t0 := time.Now()
+	w.Open(t0, "test")
+	w.Close(t0.Add(time.Second), "test")
+
+	at := func(d time.Duration) *events.Event {
+		return &events.Event{Type: events.EventDNS, Timestamp: uint64(t0.Add(d).UnixNano()), Target: d.String()}
+	}
+	inside := at(500 * time.Millisecond)
+	// Event timestamps anchor to the wall clock, so compare through them.
+	w.mu.Lock()
+	w.start, w.end = at(0).TimestampTime(), at(time.Second).TimestampTime()
+	w.mu.Unlock()
+
+	in := make(chan *events.Event, 4)
+	out := make(chan *events.Event, 4)
+	in <- at(-time.Second)
+	in <- inside
+	in <- nil
+	in <- at(2 * time.Second)
+	close(in)
+	gateOnWindow(context.Background(), in, out, w)
+
+	var got []*events.Event
+	for e := range out {
+		got = append(got, e)
+	}
+	if len(got) != 1 || got[0] != inside {
+		t.Fatalf("expected only the event inside the window, got %v", got)
+	}
+}
+
+func TestMarkHandler(t *testing.T) {
+	w := newMeasureWindow(nil)
+	srv := httptest.NewServer(markHandler(w))
+	defer srv.Close()
+
+	post := func(path string) int {
+		resp, err := http.Post(srv.URL+path, "", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+	resp, err := http.Get(srv.URL + "/start")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET /start: expected 405, got %d", resp.StatusCode)
+	}
+	if code := post("/end"); code != http.StatusConflict {
+		t.Errorf("/end before /start: expected 409, got %d", code)
+	}
+	if code := post("/start"); code != http.StatusNoContent {
+		t.Errorf("/start: expected 204, got %d", code)
+	}
+	if code := post("/start"); code != http.StatusConflict {
+		t.Errorf("second /start: expected 409, got %d", code)
+	}
+	if code := post("/end"); code != http.StatusNoContent {
+		t.Errorf("/end: expected 204, got %d", code)
+	}
+	select {
+	case <-w.Closed():
+	default:
+		t.Error("/end did not close the window")
+	}
+}
+
+func TestStartLoadMarks_StartCmdExitClosesWindow(t *testing.T) {
+	saveLoadMarkGlobals(t)
+	markStartCmd, markEndCmd, markAddr = "true", "", ""
+	w := newMeasureWindow(nil)
+	stop, err := startLoadMarks(context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stop()
+	select {
+	case <-diagnoseDeadline(time.Minute, w):
+	case <-time.After(5 * time.Second):
+		t.Fatal("the window did not close when --mark-start-cmd exited")
+	}
+	if start, end := w.bounds(); start.IsZero() || end.Before(start) {
+		t.Errorf("unexpected bounds %v..%v", start, end)
+	}
+}
+
+func TestStartLoadMarks_EndCmdClosesWindowOnStop(t *testing.T) {
+	saveLoadMarkGlobals(t)
+	markStartCmd, markEndCmd, markAddr = "sleep 30", "true", ""
+	w := newMeasureWindow(nil)
+	stop, err := startLoadMarks(context.Background(), w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-w.Closed():
+		t.Fatal("the window closed before the end command ran")
+	case <-time.After(50 * time.Millisecond):
+	}
+	stop()
+	select {
+	case <-w.Closed():
+	default:
+		t.Error("stop did not close the window")
+	}
+}
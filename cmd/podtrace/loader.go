@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf"
+	"github.com/podtrace/podtrace/internal/ebpf/pin"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+type loaderOptions struct {
+	session     string
+	cgroups     []string
+	containerID string
+	readerGroup string
+	cleanup     bool
+	pinDir      string
+	stateDir    string
+}
+
+func newLoaderCmd() *cobra.Command {
+	var opts loaderOptions
+	cmd := &cobra.Command{
+		Use:   "loader",
+		Short: "Load and pin the BPF programs for an unprivileged podtrace --pinned-session",
+		Long: `Runs the privileged half of a split deployment: loads the BPF programs,
+attaches them to the given cgroups (and the uprobes to --container-id), pins
+the event maps under PODTRACE_PIN_DIR/<session> and waits. A podtrace started
+with --pinned-session <session> by a member of --reader-group then reads the
+events without any capability. The session is unpinned when the loader stops;
+sessions of loaders that died are removed the next time one starts.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runLoader(ctx, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.session, "session", "default", "Name of the pinned session")
+	cmd.Flags().StringSliceVar(&opts.cgroups, "cgroup", nil, "Cgroup path to trace (repeatable)")
+	cmd.Flags().StringVar(&opts.containerID, "container-id", "", "Container whose binaries get the application uprobes")
+	cmd.Flags().StringVar(&opts.readerGroup, "reader-group", "", "Group (name or gid) allowed to open the pinned maps; empty leaves them to root")
+	cmd.Flags().BoolVar(&opts.cleanup, "cleanup", false, "Unpin the session and exit")
+	cmd.Flags().StringVar(&opts.pinDir, "pin-dir", config.PinDir, "bpffs directory the sessions are pinned under (env PODTRACE_PIN_DIR)")
+	cmd.Flags().StringVar(&opts.stateDir, "state-dir", config.PinStateDir, "Directory of the session manifests (env PODTRACE_PIN_STATE_DIR)")
+	return cmd
+}
+
+func runLoader(ctx context.Context, opts loaderOptions) error {
+	s, err := pin.NewSession(opts.pinDir, opts.stateDir, opts.session)
+	if err != nil {
+		return err
+	}
+	if opts.cleanup {
+		return pin.Remove(s)
+	}
+	if len(opts.cgroups) == 0 {
+		return errors.New("at least one --cgroup is required")
+	}
+	if config.LowPrivilege || config.PinnedSession != "" {
+		return errors.New("the loader must load the BPF programs itself: unset PODTRACE_LOW_PRIVILEGE and PODTRACE_PINNED_SESSION")
+	}
+	gid, err := lookupGroup(opts.readerGroup)
+	if err != nil {
+		return err
+	}
+	if reaped := pin.Reap(opts.pinDir, opts.stateDir); len(reaped) > 0 {
+		logger.Info("Removed sessions of stopped loaders", zap.Strings("sessions", reaped))
+	}
+
+	tracer, err := newSessionTracer()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracer.Stop() }()
+	if err := tracer.SetCgroups(opts.cgroups); err != nil {
+		return fmt.Errorf("attach to cgroups: %w", err)
+	}
+	if opts.containerID != "" {
+		if err := tracer.SetContainerID(opts.containerID); err != nil {
+			logger.Warn("Failed to attach uprobes to the container", zap.String("container_id", opts.containerID), zap.Error(err))
+		}
+	}
+	m, err := ebpf.Publish(tracer, s, gid)
+	if err != nil {
+		return fmt.Errorf("pin session %q: %w", s.Name, err)
+	}
+	defer func() {
+		if err := pin.Remove(s); err != nil {
+			logger.Warn("Failed to unpin session", zap.String("session", s.Name), zap.Error(err))
+		}
+	}()
+	logger.Info("Session pinned; start the reader with --pinned-session",
+		zap.String("session", s.Name),
+		zap.String("pin_dir", m.PinDir),
+		zap.Strings("maps", m.Maps),
+		zap.Int("reader_gid", gid))
+
+	<-ctx.Done()
+	return nil
+}
+
+// lookupGroup resolves --reader-group to a gid, or -1 when it is empty.
+func lookupGroup(group string) (int, error) {
+	if group == "" {
+		return -1, nil
+	}
+	if gid, err := strconv.Atoi(group); err == nil && gid >= 0 {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(group)
+	if err != nil {
+		return 0, fmt.Errorf("--reader-group: %w", err)
+	}
+	return strconv.Atoi(g.Gid)
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf"
+)
+
+func testLoaderOptions(t *testing.T) loaderOptions {
+	t.Helper()
+	dir := t.TempDir()
+	return loaderOptions{
+		session:  "s1",
+		cgroups:  []string{"/sys/fs/cgroup/kubepods/pod1"},
+		pinDir:   filepath.Join(dir, "bpf"),
+		stateDir: filepath.Join(dir, "run"),
+	}
+}
+
+func TestRunLoader_Validation(t *testing.T) {
+	opts := testLoaderOptions(t)
+	opts.session = "../etc"
+	if err := runLoader(context.Background(), opts); err == nil {
+		t.Error("expected an invalid session name to be rejected")
+	}
+
+	opts = testLoaderOptions(t)
+	opts.cgroups = nil
+	if err := runLoader(context.Background(), opts); err == nil || !strings.Contains(err.Error(), "--cgroup") {
+		t.Errorf("err = %v, want a missing --cgroup error", err)
+	}
+
+	orig := config.LowPrivilege
+	config.LowPrivilege = true
+	t.Cleanup(func() { config.LowPrivilege = orig })
+	if err := runLoader(context.Background(), testLoaderOptions(t)); err == nil {
+		t.Error("expected the loader to refuse low-privilege mode")
+	}
+}
+
+func TestRunLoader_CleanupRemovesSession(t *testing.T) {
+	opts := testLoaderOptions(t)
+	opts.cleanup = true
+	pinned := filepath.Join(opts.pinDir, opts.session)
+	if err := os.MkdirAll(pinned, 0o750); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(opts.stateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	manifest := filepath.Join(opts.stateDir, opts.session+".json")
+	if err := os.WriteFile(manifest, []byte(`{"version":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runLoader(context.Background(), opts); err != nil {
+		t.Fatalf("runLoader --cleanup: %v", err)
+	}
+	for _, p := range []string{pinned, manifest} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s survived --cleanup", p)
+		}
+	}
+}
+
+func TestRunLoader_TracerWithoutMaps(t *testing.T) {
+	origFactory := tracerFactory
+	t.Cleanup(func() { tracerFactory = origFactory })
+	tracerFactory = func() (ebpf.TracerInterface, error) { return &mockTracer{}, nil }
+
+	opts := testLoaderOptions(t)
+	err := runLoader(context.Background(), opts)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, statErr := os.Stat(filepath.Join(opts.stateDir, opts.session+".json")); !os.IsNotExist(statErr) {
+		t.Error("a manifest was written for a session that was not pinned")
+	}
+}
+
+func TestLookupGroup(t *testing.T) {
+	if gid, err := lookupGroup(""); err != nil || gid != -1 {
+		t.Errorf("lookupGroup(\"\") = %d, %v", gid, err)
+	}
+	if gid, err := lookupGroup("1234"); err != nil || gid != 1234 {
+		t.Errorf("lookupGroup(\"1234\") = %d, %v", gid, err)
+	}
+	if _, err := lookupGroup("no-such-group-podtrace"); err == nil {
+		t.Error("expected an unknown group to fail")
+	}
+}
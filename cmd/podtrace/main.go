@@ -1,6 +1,7 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,6 +10,7 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime/debug"
 	"sort"
 	"strings"
@@ -25,11 +27,16 @@ import (
 	"strconv"
 
 	"github.com/podtrace/podtrace/internal/alerting"
+	"github.com/podtrace/podtrace/internal/clock"
 	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/report"
 	"github.com/podtrace/podtrace/internal/ebpf"
+	"github.com/podtrace/podtrace/internal/ebpf/probes"
 	tracerpkg "github.com/podtrace/podtrace/internal/ebpf/tracer"
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/fips"
+	"github.com/podtrace/podtrace/internal/hostfs"
 	"github.com/podtrace/podtrace/internal/kubernetes"
 	"github.com/podtrace/podtrace/internal/kubernetes/nodespawn"
 	"github.com/podtrace/podtrace/internal/logger"
@@ -37,10 +44,13 @@ import (
 	"github.com/podtrace/podtrace/internal/profiling"
 	"github.com/podtrace/podtrace/internal/system"
 	"github.com/podtrace/podtrace/internal/tracing"
+	"github.com/podtrace/podtrace/internal/trigger"
 	"github.com/podtrace/podtrace/internal/validation"
 )
 
 var (
+	kubeContext           string
+	fipsMode              bool
 	namespace             string
 	namespacesCSV         string
 	podsCSV               string
@@ -48,22 +58,45 @@ var (
 	allInNamespace        bool
 	diagnoseDuration      string
 	enableMetrics         bool
+	metricsAddr           string
+	metricsTokenFile      string
+	metricsTLSCert        string
+	metricsTLSKey         string
+	metricsClientCA       string
+	metricsNamespaces     string
+	metricsLinger         time.Duration
 	enableTracing         bool
 	enableSynthesizeSpans bool
 	exportFormat          string
+	exportOutput          string
 	eventFilter           string
+	probeGroups           string
+	verbosity             string
+	triggerExpr           string
+	triggerRecord         string
+	maxEventsBudget       int
+	uprobesFile           string
 	containerName         string
+	strictContainer       bool
 	errorRateThreshold    float64
 	rttSpikeThreshold     float64
 	fsSlowThreshold       float64
 	logLevel              string
+	logFilePath           string
+	logFormat             string
 	tracingOTLPEndpoint   string
 	tracingJaegerEndpoint string
 	tracingSplunkEndpoint string
 	tracingSplunkToken    string
+	tracingZipkinEndpoint string
 	tracingSampleRate     float64
 	showVersion           bool
 	enableProfiling       bool
+	procRootOnly          bool
+	lowPrivilege          bool
+	pinnedSession         string
+	podThroughput         bool
+	cpuPlacement          bool
 
 	localMode             bool
 	spawnImage            string
@@ -75,6 +108,7 @@ var (
 
 	exporterFromFile       string
 	summaryFile            string
+	bundlePath             string
 	terminationMessagePath string
 	reportTo               string
 
@@ -88,6 +122,7 @@ func init() {
 		return kubernetes.NewPodResolver()
 	}
 	tracerFactory = func() (ebpf.TracerInterface, error) {
+		prepareKernelArtifacts()
 		return ebpf.NewTracer()
 	}
 	exitFunc = os.Exit
@@ -128,6 +163,15 @@ func main() {
 	rootCmd.AddCommand(newReportUploaderCmd())
 	rootCmd.AddCommand(newScheduleCmd())
 	rootCmd.AddCommand(newWatchCmd())
+	rootCmd.AddCommand(newAnnotateCmd())
+	rootCmd.AddCommand(newArmCmd())
+	rootCmd.AddCommand(newFederateCmd())
+	rootCmd.AddCommand(newLoaderCmd())
+	rootCmd.AddCommand(newHistoryCmd())
+	rootCmd.AddCommand(newShowCmd())
+	rootCmd.AddCommand(newSchemaCmd())
+	rootCmd.AddCommand(newQueryCmd())
+	rootCmd.AddCommand(newSelftestCmd())
 
 	rootCmd.Flags().StringVarP(&namespace, "namespace", "n", config.DefaultNamespace, "Kubernetes namespace (defaults to the current kubeconfig context's namespace)")
 	rootCmd.Flags().StringVar(&namespacesCSV, "namespaces", "", "Comma-separated namespaces for multi-pod tracing (e.g., default,prod)")
@@ -136,42 +180,110 @@ func main() {
 	rootCmd.Flags().BoolVar(&allInNamespace, "all-in-namespace", false, "Trace all pods in --namespace (or all --namespaces)")
 	rootCmd.Flags().StringVar(&diagnoseDuration, "diagnose", "", "Run in diagnose mode for the specified duration (e.g., 10s, 5m)")
 	rootCmd.Flags().BoolVar(&enableMetrics, "metrics", false, "Enable Prometheus metrics server")
-	rootCmd.Flags().StringVar(&exportFormat, "export", "", "Export format for diagnose report (json, csv)")
+	rootCmd.Flags().StringVar(&metricsAddr, "metrics-addr", "", "Address the metrics server binds (default PODTRACE_METRICS_ADDR or 127.0.0.1:3000); beyond loopback it needs --metrics-bearer-token-file or --metrics-client-ca")
+	rootCmd.Flags().StringVar(&metricsTokenFile, "metrics-bearer-token-file", "", "Require a bearer token from this file to scrape metrics: one token per line, optionally followed by the comma-separated namespaces it may see")
+	rootCmd.Flags().StringVar(&metricsTLSCert, "metrics-tls-cert", "", "Serve metrics over HTTPS with this certificate (needs --metrics-tls-key)")
+	rootCmd.Flags().StringVar(&metricsTLSKey, "metrics-tls-key", "", "Private key of --metrics-tls-cert")
+	rootCmd.Flags().StringVar(&metricsClientCA, "metrics-client-ca", "", "Require scrapers to present a client certificate signed by this CA (mTLS; needs --metrics-tls-cert)")
+	rootCmd.Flags().StringVar(&metricsNamespaces, "metrics-namespaces", "", "Comma-separated namespaces whose series the metrics server exposes (default all)")
+	rootCmd.Flags().DurationVar(&metricsLinger, "metrics-linger", 0, "With --metrics and --diagnose, keep serving the final metrics this long after the run so Prometheus can scrape them (e.g. 10m; Ctrl+C stops early)")
+	rootCmd.Flags().StringVar(&exportFormat, "export", "", "Export format for diagnose report (json, csv, proto, sqlite)")
+	rootCmd.Flags().StringVar(&exportOutput, "output", "", "Write the --export output to this file instead of stdout (required for sqlite)")
 	rootCmd.Flags().StringVar(&eventFilter, "filter", "", "Filter events by type (dns,net,fs,cpu,proc,crypto,usdt)")
+	rootCmd.Flags().StringVar(&probeGroups, "probe-groups", "", "Attach only the probes these event categories need (dns,net,fs,cpu,proc,crypto,usdt); empty attaches all")
+	rootCmd.Flags().StringVar(&verbosity, "verbosity", "", "Output tier: events (print every event), anomalies (only threshold-exceeding events), issues (only detected issues). Default prints the periodic report")
+	rootCmd.Flags().StringVar(&triggerExpr, "trigger", "", "Stay in aggregation-only mode until a condition holds, then start full capture (e.g. \"error_rate>5% for 30s\"; metrics: error_rate, errors, event_rate, latency_avg)")
+	rootCmd.Flags().StringVar(&markStartCmd, "mark-start-cmd", "", "With --diagnose, run this shell command (e.g. a k6 run) once tracing is up and measure only while it runs")
+	rootCmd.Flags().StringVar(&focusFlag, "focus", "", "With --diagnose, compute the report and export over this window only: FROM..TO as clock times (12:04:30..12:05:10), RFC 3339 times or offsets (+5m..+7m, -2m..)")
+	rootCmd.Flags().StringVar(&markEndCmd, "mark-end-cmd", "", "With --mark-start-cmd, keep measuring past the start command's exit and run this shell command to stop the load at the end of the run")
+	rootCmd.Flags().StringVar(&markAddr, "mark-addr", "", "With --diagnose, accept POST /start and /end on this loopback address to bracket the measured window")
+	rootCmd.Flags().StringVar(&triggerRecord, "trigger-record", "", "With --trigger, also record every event captured after the trigger fires to this file as JSON lines, or in the binary format for a .pb path")
+	rootCmd.Flags().StringVar(&debugAddr, "debug-addr", config.DebugAddr, "Serve pprof and runtime stats for podtrace itself on this loopback address, e.g. 127.0.0.1:6061 (env PODTRACE_DEBUG_ADDR)")
+	rootCmd.Flags().BoolVar(&issueEvents, "issue-events", config.IssueEvents, "Record each detected issue as a Warning Event (reason PodtraceIssueDetected) on the traced pod (env PODTRACE_ISSUE_EVENTS)")
+	rootCmd.Flags().StringVar(&syslogURL, "syslog", config.Syslog, "Send detected issues and the events --verbosity prints to this syslog receiver as RFC 5424: udp://, tcp:// or tls://host:port (env PODTRACE_SYSLOG)")
+	rootCmd.Flags().StringArrayVar(&onIssueScripts, "on-issue", nil, "Run this executable for each detected issue, with the finding as JSON on stdin (repeatable; not run by spawned pods)")
+	rootCmd.Flags().BoolVar(&resolveNames, "resolve-names", config.ResolveTargetNames, "Reverse-DNS external connection targets in the background so the report names them (env PODTRACE_RESOLVE_NAMES; cloud ranges from PODTRACE_IP_RANGES)")
+	rootCmd.Flags().StringSliceVar(&geoIPDBs, "geoip-db", parseCSV(config.GeoIPDBFiles), "MaxMind DB file (e.g. GeoLite2-ASN.mmdb) to tag internet-bound traffic with its ASN and organization in the report (repeatable; env PODTRACE_GEOIP_DB)")
+	rootCmd.Flags().IntVar(&maxEventsBudget, "max-events", 0, "Keep at most N events for the session; later events are only counted per type (aggregation-only mode) and the report notes the switchover (0 = no cap)")
+	rootCmd.Flags().StringVar(&uprobesFile, "uprobes", "", "YAML file of custom uprobes (binary pattern, symbol, label, optional latency pairing) to attach in the target containers")
+	rootCmd.Flags().StringVar(&sloFile, "slo", "", "YAML file of SLOs (target pattern, p99 latency, max error rate) evaluated over the --diagnose window and reported as pass/fail")
+	rootCmd.Flags().StringVar(&sloInline, "slo-inline", "", "internal: SLO definitions forwarded verbatim to the spawn pod")
+	_ = rootCmd.Flags().MarkHidden("slo-inline")
+	rootCmd.Flags().StringVar(&suppressFile, "suppress", "", "YAML file of known-benign event patterns (type, process, target, errno) counted but kept out of issue detection and the report tables, on top of the built-in ones")
+	rootCmd.Flags().StringVar(&suppressInline, "suppress-inline", "", "internal: suppression rules forwarded verbatim to the spawn pod")
+	_ = rootCmd.Flags().MarkHidden("suppress-inline")
+	rootCmd.Flags().BoolVar(&noSuppress, "no-suppress", false, "Analyze the events the built-in suppression rules would set aside (EAGAIN on non-blocking sockets, kubelet health checks, pause containers)")
+	rootCmd.Flags().StringVar(&reportTemplateDir, "report-template", "", "Directory of Go templates (*.tmpl, messages.yaml, runbooks.yaml) that customize the diagnose report")
+	rootCmd.Flags().StringVar(&reportTemplateInline, "report-template-inline", "", "internal: report template files forwarded to the spawn pod")
+	_ = rootCmd.Flags().MarkHidden("report-template-inline")
+	rootCmd.Flags().StringSliceVar(&failOn, "fail-on", nil, "Exit non-zero at the end of --diagnose when the condition holds (slo: any --slo objective failed)")
+	rootCmd.Flags().StringVar(&annotationSocket, "annotation-socket", config.AnnotationSocket, "Listen on this unix socket for 'podtrace annotate' markers and record them in the event timeline (env PODTRACE_ANNOTATION_SOCKET)")
+	rootCmd.Flags().BoolVar(&armed, "armed", false, "Load and attach every probe but emit no events until 'podtrace arm --fire'; a --diagnose run starts counting when it fires")
+	rootCmd.Flags().StringVar(&armSocket, "arm-socket", config.ArmSocket, "With --armed, listen on this unix socket for 'podtrace arm' (env PODTRACE_ARM_SOCKET)")
 	rootCmd.Flags().StringVar(&containerName, "container", "", "Container name to trace (default: all containers of the pod)")
+	rootCmd.Flags().BoolVar(&strictContainer, "strict", false, "Without --container, fail on multi-container pods whose main container would otherwise be guessed")
+	rootCmd.Flags().StringVar(&initContainerName, "init-container", "", "Init container name to trace; waits for it to start running (env PODTRACE_INIT_CONTAINER_WAIT_TIMEOUT bounds the wait)")
 	rootCmd.Flags().Float64Var(&errorRateThreshold, "error-threshold", config.DefaultErrorRateThreshold, "Error rate threshold percentage for issue detection")
 	rootCmd.Flags().Float64Var(&rttSpikeThreshold, "rtt-threshold", config.DefaultRTTThreshold, "RTT spike threshold in milliseconds")
 	rootCmd.Flags().Float64Var(&fsSlowThreshold, "fs-threshold", config.DefaultFSSlowThreshold, "File system slow operation threshold in milliseconds")
 	rootCmd.Flags().StringVar(&logLevel, "log-level", "", "Set log level (debug, info, warn, error, fatal). Overrides PODTRACE_LOG_LEVEL environment variable")
+	rootCmd.Flags().StringVar(&logFilePath, "log-file", "", "Write logs to this file (size-rotated, see PODTRACE_LOG_MAX_SIZE_MB/PODTRACE_LOG_MAX_BACKUPS) instead of stderr, keeping the terminal for the report")
+	rootCmd.Flags().StringVar(&logFormat, "log-format", "json", "Log encoding (json, console)")
 	rootCmd.Flags().BoolVar(&enableTracing, "tracing", config.DefaultTracingEnabled, "Enable distributed tracing")
 	rootCmd.Flags().StringVar(&tracingOTLPEndpoint, "tracing-otlp-endpoint", config.DefaultOTLPEndpoint, "OpenTelemetry OTLP endpoint")
 	rootCmd.Flags().StringVar(&tracingJaegerEndpoint, "tracing-jaeger-endpoint", "", "Jaeger endpoint (opt-in; OTLP is the default exporter)")
 	rootCmd.Flags().StringVar(&tracingSplunkEndpoint, "tracing-splunk-endpoint", "", "Splunk HEC endpoint (opt-in; requires --tracing-splunk-token)")
 	rootCmd.Flags().StringVar(&tracingSplunkToken, "tracing-splunk-token", "", "Splunk HEC token")
+	rootCmd.Flags().StringVar(&tracingZipkinEndpoint, "tracing-zipkin-endpoint", "", "Zipkin v2 spans endpoint, e.g. http://zipkin:9411/api/v2/spans (opt-in)")
 	rootCmd.Flags().Float64Var(&tracingSampleRate, "tracing-sample-rate", config.DefaultTracingSampleRate, "Tracing sample rate (0.0-1.0)")
 	rootCmd.Flags().BoolVar(&enableSynthesizeSpans, "tracing-synthesize-spans", config.DefaultSynthesizeSpans, "Mint spans for correlated L7 traffic (HTTP/gRPC) that carries no inbound W3C/B3 trace context (per-pod root spans)")
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Print version information")
 	rootCmd.Flags().BoolVar(&enableProfiling, "profiling", false, "Enable performance profiling: pprof endpoint discovery on the target pod, auto-trigger on latency spikes, and CPU/memory correlation in reports")
+	rootCmd.Flags().BoolVar(&podThroughput, "pod-throughput", config.PodThroughput, "Count bytes and packets on each target pod's veth with tc programs (Linux 6.6+), split by cluster, node and external peers (env PODTRACE_POD_THROUGHPUT)")
+	rootCmd.Flags().BoolVar(&cpuPlacement, "cpu-placement", config.CPUPlacement, "Sample each target process's allowed CPUs, NUMA memory placement and run queue wait to spot pinning and NUMA effects (env PODTRACE_CPU_PLACEMENT)")
+	rootCmd.Flags().BoolVar(&lowPrivilege, "low-privilege", config.LowPrivilege, "Load no BPF program and collect only cgroup resource usage and per-thread CPU time from /proc, for clusters that forbid CAP_BPF and CAP_SYS_ADMIN; the report lists what is not observed (env PODTRACE_LOW_PRIVILEGE)")
+	rootCmd.Flags().StringVar(&controlFile, "control-file", config.ControlFile, "YAML file of thresholds, --filter, sampling rate and alert levels reread on SIGHUP and whenever it changes, to tune a running trace (env PODTRACE_CONTROL_FILE)")
+	rootCmd.Flags().StringVar(&pinnedSession, "pinned-session", config.PinnedSession, "Read the events of a session pinned by a privileged 'podtrace loader' instead of loading BPF, so this process needs no capability (env PODTRACE_PINNED_SESSION)")
+	rootCmd.Flags().BoolVar(&procRootOnly, "proc-root-only", config.ProcRootOnly, "Discover container binaries and libraries only through /proc/<pid>/root with RESOLVE_IN_ROOT semantics, never reading /var/lib/docker or containerd state directly (for hardened AppArmor/SELinux profiles; env PODTRACE_PROC_ROOT_ONLY)")
 	rootCmd.Flags().BoolVar(&localMode, "local", false, "Run eBPF on this workstation instead of spawning a privileged pod on the target node. Use for kind/minikube/docker-desktop where the workstation IS the kubelet host.")
 	rootCmd.Flags().StringVar(&spawnImage, "image", "", "Container image used when spawning on the target node (overrides PODTRACE_IMAGE and the linker default)")
 	rootCmd.Flags().StringVar(&spawnNamespace, "spawn-namespace", "", "Namespace for the ephemeral spawn pod (defaults to the target pod's namespace; overridable via PODTRACE_SPAWN_NAMESPACE)")
 	rootCmd.Flags().BoolVar(&dynamicSpawn, "dynamic-spawn", false, "Continuously poll target selection and spawn additional pods on newly-matched nodes (incompatible with --diagnose; covers new nodes only, not new pods on already-covered nodes)")
 	rootCmd.Flags().BoolVar(&keepSpawnPodOnFailure, "keep-spawn-pod", false, "On failure, leave the spawn pod in place so its logs and state can be inspected (the reaper still cleans it up on the next podtrace invocation)")
 	rootCmd.Flags().StringVar(&spawnServiceAccount, "service-account", "", "ServiceAccount the spawn pod runs as. Only required when --dynamic-spawn watches selector changes from inside the pod; otherwise the workstation pre-resolves everything and the spawn pod needs no RBAC.")
-	rootCmd.Flags().StringSliceVar(&preresolvedPods, "preresolved-pod", nil, "internal: workstation pre-resolved target as ns/name/containerID/containerName, lets the spawn pod skip its own K8s lookup")
+	rootCmd.Flags().StringSliceVar(&preresolvedPods, "preresolved-pod", nil, "internal: workstation pre-resolved target as ns/name/containerID/containerName/podUID, lets the spawn pod skip its own K8s lookup")
 	_ = rootCmd.Flags().MarkHidden("preresolved-pod")
 	rootCmd.Flags().StringVar(&exporterFromFile, "exporter-from-file", "", "Load exporter config from a YAML file (set by the operator for session Jobs)")
 	_ = rootCmd.Flags().MarkHidden("exporter-from-file")
+	rootCmd.Flags().StringVar(&bundlePath, "bundle", "", "With --diagnose, write an incident bundle (report as text, HTML and JSON, raw capture, diagnose-env output, pod specs, Kubernetes events and podtrace logs) to this .tar.gz path")
 	rootCmd.Flags().StringVar(&summaryFile, "summary-file", "", "Write a JSON summary of diagnose results to this path when diagnose completes")
 	rootCmd.Flags().StringVar(&terminationMessagePath, "termination-message-path", "", "Write a compact summary JSON to this path so Kubernetes surfaces it in pod status")
 	rootCmd.Flags().StringVar(&reportTo, "report-to", "", "Upload the full diagnose report to a sink: kind/namespace/name (kind is configmap|secret)")
 
 	registerTargetFlags(rootCmd.Flags())
 
-	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+	rootCmd.PersistentFlags().BoolVar(&fipsMode, "fips", config.FIPS, "Refuse to run outside Go's FIPS 140-3 mode and refuse connections without FIPS-approved TLS (env PODTRACE_FIPS)")
+	rootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "Kubeconfig context to use instead of the current one, e.g. to trace in another cluster")
+
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		kubernetes.SetKubeContext(kubeContext)
 		if logLevel != "" {
 			logger.SetLevel(logLevel)
 		}
+		if logFilePath != "" || cmd.Flags().Changed("log-format") {
+			if err := logger.Configure(logFormat, logFilePath); err != nil {
+				return fmt.Errorf("configure logging: %w", err)
+			}
+		}
+		config.FIPS = fipsMode
+		if err := fips.Check(); err != nil {
+			return err
+		}
+		if root := system.DetectHostRoot(); root != "" && root != "/" {
+			config.ApplyHostRoot(root)
+			logger.Debug("Reading node paths under the host root", zap.String("root", root))
+		}
+		return nil
 	}
 
 	err := rootCmd.Execute()
@@ -206,6 +318,9 @@ func applyTracingFlags(cmd *cobra.Command) error {
 	if tracingSplunkToken != "" {
 		config.SplunkToken = tracingSplunkToken
 	}
+	if flags.Changed("tracing-zipkin-endpoint") && tracingZipkinEndpoint != "" {
+		config.ZipkinEndpoint = tracingZipkinEndpoint
+	}
 	if flags.Changed("tracing-sample-rate") {
 		if tracingSampleRate < 0.0 || tracingSampleRate > 1.0 {
 			return fmt.Errorf("--tracing-sample-rate must be between 0.0 and 1.0, got %v", tracingSampleRate)
@@ -218,6 +333,31 @@ func applyTracingFlags(cmd *cobra.Command) error {
 	return nil
 }
 
+// metricsServerOptions returns the metrics server options from the
+// PODTRACE_METRICS_* settings and the --metrics-* flags over them.
+func metricsServerOptions(cmd *cobra.Command) (metricsexporter.ServerOptions, error) {
+	flags := cmd.Flags()
+	for name, dst := range map[string]*string{
+		"metrics-bearer-token-file": &config.MetricsBearerTokenFile,
+		"metrics-tls-cert":          &config.MetricsTLSCert,
+		"metrics-tls-key":           &config.MetricsTLSKey,
+		"metrics-client-ca":         &config.MetricsClientCA,
+		"metrics-namespaces":        &config.MetricsNamespaces,
+	} {
+		if flags.Changed(name) {
+			*dst, _ = flags.GetString(name)
+		}
+	}
+	opts, err := metricsexporter.OptionsFromConfig()
+	if err != nil {
+		return metricsexporter.ServerOptions{}, fmt.Errorf("metrics server: %w", err)
+	}
+	if flags.Changed("metrics-addr") {
+		opts.Addr = metricsAddr
+	}
+	return opts, nil
+}
+
 func runPodtrace(cmd *cobra.Command, args []string) error {
 	if showVersion {
 		fmt.Println(config.GetVersion())
@@ -256,6 +396,21 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 	if err := applyTracingFlags(cmd); err != nil {
 		return err
 	}
+	if procRootOnly {
+		config.ProcRootOnly = true
+	}
+	if lowPrivilege {
+		config.LowPrivilege = true
+	}
+	if pinnedSession != "" {
+		config.PinnedSession = pinnedSession
+	}
+	if podThroughput {
+		config.PodThroughput = true
+	}
+	if cpuPlacement {
+		config.CPUPlacement = true
+	}
 
 	alertManager, err := alerting.NewManager()
 	if err != nil {
@@ -269,11 +424,46 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 		}()
 	}
 
+	if issueEvents {
+		config.IssueEvents = true
+	}
+	stopSyslog, err := startSyslog()
+	if err != nil {
+		return err
+	}
+	defer stopSyslog()
+	stopIssueHooks, err := startIssueHooks()
+	if err != nil {
+		return err
+	}
+	defer stopIssueHooks()
+
+	stopTargetNames, err := startTargetNames()
+	if err != nil {
+		return err
+	}
+	defer stopTargetNames()
+
+	stopGeoIP, err := startGeoIP()
+	if err != nil {
+		return err
+	}
+	defer stopGeoIP()
+
 	var metricsServer *metricsexporter.Server
 	if enableMetrics {
-		metricsServer = metricsexporter.StartServer()
+		opts, err := metricsServerOptions(cmd)
+		if err != nil {
+			return err
+		}
+		metricsServer = metricsexporter.NewServer(opts)
 		defer metricsServer.Shutdown()
 	}
+	stopDebugServer, err := startDebugServer(debugAddr)
+	if err != nil {
+		return err
+	}
+	defer stopDebugServer()
 
 	tracingManager, err := tracing.NewManager()
 	if err != nil {
@@ -337,20 +527,89 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 	if err := validation.ValidateContainerName(containerName); err != nil {
 		return fmt.Errorf("invalid container name: %w", err)
 	}
+	if err := validateInitContainer(pods); err != nil {
+		return err
+	}
 
 	if err := validation.ValidateExportFormat(exportFormat); err != nil {
 		return fmt.Errorf("invalid export format: %w", err)
 	}
+	if strings.EqualFold(exportFormat, "sqlite") && exportOutput == "" {
+		return fmt.Errorf("--export sqlite needs --output <file>")
+	}
+	if exportOutput != "" && exportFormat == "" {
+		return fmt.Errorf("--output needs --export")
+	}
 
 	if diagnoseDuration != "" {
 		if _, err := time.ParseDuration(diagnoseDuration); err != nil {
 			return fmt.Errorf("invalid --diagnose duration %q: %w", diagnoseDuration, err)
 		}
 	}
+	if metricsLinger < 0 {
+		return fmt.Errorf("--metrics-linger must be >= 0, got %s", metricsLinger)
+	}
+	if metricsLinger > 0 && (!enableMetrics || diagnoseDuration == "") {
+		return fmt.Errorf("--metrics-linger requires --metrics and --diagnose")
+	}
 
 	if err := validation.ValidateEventFilter(eventFilter); err != nil {
 		return fmt.Errorf("invalid event filter: %w", err)
 	}
+	if err := validation.ValidateEventFilter(probeGroups); err != nil {
+		return fmt.Errorf("invalid --probe-groups: %w", err)
+	}
+	if err := validation.ValidateVerbosity(verbosity); err != nil {
+		return err
+	}
+	if maxEventsBudget < 0 {
+		return fmt.Errorf("--max-events must be >= 0, got %d", maxEventsBudget)
+	}
+	var triggerCond *trigger.Condition
+	if triggerExpr != "" {
+		if diagnoseDuration != "" {
+			return fmt.Errorf("--trigger cannot be combined with --diagnose")
+		}
+		c, err := trigger.Parse(triggerExpr)
+		if err != nil {
+			return err
+		}
+		triggerCond = c
+	} else if triggerRecord != "" {
+		return fmt.Errorf("--trigger-record requires --trigger")
+	}
+	if err := validateLoadMarks(diagnoseDuration != ""); err != nil {
+		return err
+	}
+	if err := validateArmed(triggerCond != nil); err != nil {
+		return err
+	}
+	if err := validateFocus(diagnoseDuration != ""); err != nil {
+		return err
+	}
+	if uprobesFile != "" {
+		abs, err := filepath.Abs(uprobesFile)
+		if err != nil {
+			return fmt.Errorf("invalid --uprobes path: %w", err)
+		}
+		if _, err := probes.LoadCustomUprobes(abs); err != nil {
+			return err
+		}
+		config.CustomUprobesFile = abs
+	}
+
+	if err := loadSLOFlags(); err != nil {
+		return err
+	}
+	if err := loadSuppressFlags(); err != nil {
+		return err
+	}
+	if err := loadReportTemplateFlags(); err != nil {
+		return err
+	}
+	if err := startBundle(); err != nil {
+		return err
+	}
 
 	if err := validation.ValidateErrorRateThreshold(errorRateThreshold); err != nil {
 		return fmt.Errorf("invalid error threshold: %w", err)
@@ -391,6 +650,14 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("failed to create pod resolver: %w", err)
 	}
 
+	targetContainer := containerName
+	if initContainerName != "" {
+		if err := waitForInitContainer(ctx, resolver, pods[0]); err != nil {
+			return err
+		}
+		targetContainer = initContainerName
+	}
+
 	resolveCtx, resolveCancel := context.WithTimeout(ctx, config.DefaultPodResolveTimeout)
 	defer resolveCancel()
 	selectionDefaultNamespace := namespace
@@ -405,8 +672,10 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 		PodSelector:      podSelector,
 		AllInNamespace:   allInNamespace,
 		Pods:             pods,
-		ContainerName:    containerName,
+		ContainerName:    targetContainer,
+		StrictContainer:  strictContainer,
 	}
+	historyTarget = describeTarget(selection)
 
 	if handled, err := maybeSpawnOnNode(ctx, cmd, resolver, selection); handled {
 		return err
@@ -454,10 +723,13 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 	} else {
 		for _, podRef := range selection.Pods {
 			podNs, podName := parsePodRef(podRef, namespace)
-			info, err := resolver.ResolvePod(resolveCtx, podName, podNs, containerName)
+			info, err := resolver.ResolvePod(resolveCtx, podName, podNs, targetContainer)
 			if err != nil {
 				return fmt.Errorf("failed to resolve pod %s/%s: %w", podNs, podName, err)
 			}
+			if strictContainer && targetContainer == "" && info.MainContainer.Guessed {
+				return kubernetes.NewAmbiguousContainerError(podNs, podName, info.MainContainer)
+			}
 			targetInfos = append(targetInfos, info)
 		}
 	}
@@ -466,6 +738,12 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 	}
 	podInfo := targetInfos[0]
 	sourceIndex := newSourcePodIndex(targetInfos)
+	if enableMetrics {
+		metricsexporter.SetTargetPods(podMetadata(targetInfos))
+	}
+	setBundleTargets(resolver, targetInfos)
+	recordPodStatuses(resolveCtx, resolver, targetInfos)
+	recordVolumeMounts(resolveCtx, resolver, targetInfos)
 
 	if len(targetInfos) > 1 {
 		logger.Info("Tracing multiple target pods on this node",
@@ -479,6 +757,21 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 			zap.Int("containers", len(podContainerTargets(p))),
 			zap.String("container_id", p.ContainerID),
 			zap.String("cgroup_path", p.CgroupPath))
+		if targetContainer == "" && len(p.Containers) > 1 && p.MainContainer.Name != "" {
+			logger.Info("Picked main container; pass --container to choose another",
+				zap.String("pod", p.Namespace+"/"+p.PodName),
+				zap.String("container", p.MainContainer.Name),
+				zap.String("reason", p.MainContainer.Reason))
+		}
+		if targetContainer == "" {
+			for _, c := range p.Containers {
+				if c.Kind == kubernetes.ContainerKindEphemeral {
+					logger.Info("Also tracing ephemeral debug container; pass --container to leave it out",
+						zap.String("pod", p.Namespace+"/"+p.PodName),
+						zap.String("container", c.Name))
+				}
+			}
+		}
 		if os.Getenv("PODTRACE_ALLOW_BROAD_CGROUP") == "1" {
 			continue
 		}
@@ -505,23 +798,19 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 		}
 	}
 
-	if err := system.CheckRequirements(); err != nil {
-		return err
-	}
-	if err := system.CheckKernelLockdown(); err != nil {
-		return err
+	if config.PinState && !config.LowPrivilege && config.PinnedSession == "" {
+		config.StatePinDir = statePinDir(targetInfos)
 	}
-	system.CheckSELinux()
-
-	tracer, err := tracerFactory()
+	config.StartArmed = armed
+	tracer, err := newSessionTracer()
 	if err != nil {
-		return fmt.Errorf("failed to create tracer: %w", err)
+		return err
 	}
 	defer func() { _ = tracer.Stop() }()
 
 	cgroupPaths, containerIDs := targetAttachSets(targetInfos)
 	if err := attachTracerToCgroups(tracer, cgroupPaths); err != nil {
-		return fmt.Errorf("failed to attach to cgroups: %w", err)
+		return fmt.Errorf("failed to attach to cgroups: %w", system.ExplainLSMDenial(err))
 	}
 	if err := setTracerContainerIDs(tracer, containerIDs); err != nil {
 		return fmt.Errorf("failed to set container IDs: %w", err)
@@ -546,6 +835,9 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 						logger.Warn("Failed to apply dynamic container uprobe target update", zap.Error(err))
 					}
 					sourceIndex.Replace(snapshot)
+					if enableMetrics {
+						metricsexporter.SetTargetPods(podMetadata(snapshot))
+					}
 					logger.Info("Updated dynamic target set",
 						zap.Int("pods", len(snapshot)),
 						zap.Int("containers", len(nextContainerIDs)))
@@ -554,6 +846,8 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 		}()
 	}
 
+	registerIssueEvents(resolver)
+
 	var enricher *kubernetes.ContextEnricher
 	enrichmentEnabled := os.Getenv("PODTRACE_K8S_ENRICHMENT_ENABLED") != "false"
 	if enrichmentEnabled {
@@ -578,8 +872,12 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 	}
 	profilingActive := (enableProfiling || config.ProfilingEnabled) &&
 		len(profilingPodIPs) > 0
+	// With tracing on, the tracing consumer records the metrics once it has
+	// joined each event to its trace, so latency histograms carry the trace
+	// as an exemplar without racing the manager for the event.
+	metricsConsumer := enableMetrics && !tracingActive
 	auxiliaryConsumers := 0
-	for _, active := range []bool{enableMetrics, tracingActive, profilingActive} {
+	for _, active := range []bool{metricsConsumer, tracingActive, profilingActive} {
 		if active {
 			auxiliaryConsumers++
 		}
@@ -596,7 +894,7 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 		return c
 	}
 
-	if enableMetrics {
+	if metricsConsumer {
 		metricsChan := takeAuxiliary()
 		go func() {
 			defer func() {
@@ -649,13 +947,18 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 						return
 					}
 					var k8sCtx interface{}
+					var metricsCtx map[string]interface{}
 					if enricher != nil {
 						enriched := enricher.EnrichEvent(ctx, event)
 						if enriched != nil && enriched.KubernetesContext != nil {
-							k8sCtx = buildK8sContextMap(enriched, sourceIndex.Resolve(event))
+							metricsCtx = buildK8sContextMap(enriched, sourceIndex.Resolve(event))
+							k8sCtx = metricsCtx
 						}
 					}
 					tracingManager.ProcessEvent(event, k8sCtx)
+					if enableMetrics {
+						metricsexporter.HandleEventWithContext(event, metricsCtx)
+					}
 				}
 			}
 		}()
@@ -689,11 +992,50 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 	enrichedChan := reportChan
 
 	filteredChan := enrichedChan
-	if eventFilter != "" {
+	if controlFile != "" {
+		filter := newLiveEventFilter(eventFilter)
+		filteredChan = make(chan *events.Event, config.EventChannelBufferSize)
+		go runEventFilter(ctx, enrichedChan, filteredChan, filter)
+		liveConfigUpdates = make(chan liveConfig, 1)
+		go watchControlFile(ctx, controlFile, tracer, filter, liveConfigUpdates)
+	} else if eventFilter != "" {
 		filteredChan = make(chan *events.Event, config.EventChannelBufferSize)
 		go filterEvents(ctx, enrichedChan, filteredChan, eventFilter)
 	}
 
+	var armSw *armSwitch
+	if armed {
+		gate, ok := tracer.(tracerpkg.EmissionGate)
+		if !ok {
+			return fmt.Errorf("--armed is not supported by this tracer")
+		}
+		armSw = newArmSwitch(gate, eventChan)
+		armedChan := make(chan *events.Event, config.EventChannelBufferSize)
+		go gateOnArm(ctx, filteredChan, armedChan, armSw)
+		filteredChan = armedChan
+	}
+	if triggerCond != nil {
+		f, err := openTriggerRecord(triggerRecord)
+		if err != nil {
+			return err
+		}
+		var record *eventRecorder
+		if f != nil {
+			defer func() { _ = f.Close() }()
+			record = newEventRecorder(f, binaryRecordPath(triggerRecord))
+		}
+		gatedChan := make(chan *events.Event, config.EventChannelBufferSize)
+		go gateOnTrigger(ctx, filteredChan, gatedChan, trigger.NewEvaluator(triggerCond), record, time.Now)
+		filteredChan = gatedChan
+	}
+	loadWindow = nil
+	if loadMarksRequested() {
+		loadWindow = newMeasureWindow(eventChan)
+		windowChan := make(chan *events.Event, config.EventChannelBufferSize)
+		go gateOnWindow(ctx, filteredChan, windowChan, loadWindow)
+		filteredChan = windowChan
+	}
+
 	if enableMetrics {
 		go func() {
 			ticker := time.NewTicker(5 * time.Second)
@@ -710,16 +1052,85 @@ func runPodtrace(cmd *cobra.Command, args []string) error {
 	}
 
 	if err := tracer.Start(ctx, eventChan); err != nil {
-		return fmt.Errorf("failed to start tracer: %w", err)
+		return fmt.Errorf("failed to start tracer: %w", system.ExplainLSMDenial(err))
+	}
+	gateProbeGroups(tracer, probeGroups)
+	clock.StartCalibration(ctx, config.ClockRecalibrateInterval)
+	if err := startAnnotationServer(ctx, eventChan); err != nil {
+		return err
 	}
+	if armSw != nil {
+		if err := startArmServer(ctx, armSw); err != nil {
+			return err
+		}
+	}
+	if loadWindow != nil {
+		stopLoadMarks, err := startLoadMarks(ctx, loadWindow)
+		if err != nil {
+			return err
+		}
+		defer stopLoadMarks()
+	}
+	startCRIOperations(ctx, eventChan, targetInfos)
+	startImagePullWatch(ctx, eventChan, resolver, targetInfos)
+	startDisruptionWatch(ctx, eventChan, resolver, targetInfos)
+	if targetRegistry == nil {
+		startTerminationWatch(ctx, cancel, resolver, targetInfos)
+	}
+	startNeighborMonitor(ctx, eventChan, resolver, targetInfos)
+	startChaosWatch(ctx, eventChan, targetInfos)
+	startPauseWatch(ctx, eventChan, targetInfos)
 
 	if diagnoseDuration != "" {
-		return runDiagnoseModeWithSource(ctx, filteredChan, diagnoseDuration, podInfo, enricher, nil, tracingManager, enableTracing, sourceIndex.Resolve, profilingReporter)
+		if armSw != nil {
+			if err := armSw.wait(ctx); err != nil {
+				logger.Info("Stopped before the armed capture fired; no report")
+				return nil
+			}
+		}
+		err := runDiagnoseModeWithSource(ctx, filteredChan, diagnoseDuration, podInfo, enricher, nil, tracingManager, enableTracing, sourceIndex.Resolve, profilingReporter)
+		lingerMetrics(ctx, metricsLinger)
+		return err
 	}
 
 	return runNormalModeWithSource(ctx, filteredChan, podInfo, enricher, nil, tracingManager, enableTracing, sourceIndex.Resolve, profilingReporter)
 }
 
+// newSessionTracer checks the node can load the BPF programs and creates
+// the tracer, or in low-privilege mode the tracer that needs neither, or
+// the reader of a session pinned by `podtrace loader`.
+func newSessionTracer() (ebpf.TracerInterface, error) {
+	if config.LowPrivilege {
+		return ebpf.NewLowPrivilegeTracer(), nil
+	}
+	if config.PinnedSession != "" {
+		return ebpf.NewPinnedReader(config.PinnedSession)
+	}
+	if err := system.CheckRequirements(); err != nil {
+		return nil, err
+	}
+	if err := system.CheckKernelLockdown(); err != nil {
+		return nil, err
+	}
+	system.CheckSELinux()
+	system.CheckKernelParams()
+	system.CheckBPFCoexistence()
+
+	probes.SetAttachObserver(failedProbes)
+	tracer, err := tracerFactory()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracer: %w", system.ExplainLSMDenial(err))
+	}
+	return tracer, nil
+}
+
+// setLowPrivilege notes in d's report what low-privilege mode leaves out.
+func setLowPrivilege(d *diagnose.Diagnostician) {
+	if config.LowPrivilege {
+		d.SetLowPrivilege(tracerpkg.LowPrivilegeDisabled)
+	}
+}
+
 func runNormalMode(ctx context.Context, eventChan <-chan *events.Event, podInfo *kubernetes.PodInfo, enricher *kubernetes.ContextEnricher, eventsCorrelator *kubernetes.EventsCorrelator, tracingManager *tracing.Manager, enableTracing bool) error {
 	return runNormalModeWithSource(ctx, eventChan, podInfo, enricher, eventsCorrelator, tracingManager, enableTracing, nil, nil)
 }
@@ -734,15 +1145,28 @@ func runNormalModeWithSource(ctx context.Context, eventChan <-chan *events.Event
 	} else {
 		diagnostician = diagnose.NewDiagnosticianWithThresholds(errorRateThreshold, rttSpikeThreshold, fsSlowThreshold)
 	}
+	diagnostician.SetReportTemplate(loadedReportTemplate)
+	diagnostician.SetEventBudget(maxEventsBudget)
+	setNodeLinkSpeed(diagnostician)
+	setLowPrivilege(diagnostician)
+	setSuppression(diagnostician)
+	setVolumeMounts(diagnostician)
 	ticker := time.NewTicker(config.DefaultRealtimeUpdateInterval)
 	defer ticker.Stop()
 
+	printer := newVerbosityPrinter(os.Stdout, verbosity, rttSpikeThreshold, fsSlowThreshold)
+	alerter := newLiveAlerter(os.Stdout, config.LiveAlertDebounce)
+	stopAlerts := startLiveAlerts(ctx, diagnostician, printer, alerter)
+	defer stopAlerts()
+	snapshotSig, stopSnapshotSig := snapshotSignal()
+	defer stopSnapshotSig()
 	hasPrintedReport := false
 
 	for {
 		select {
 		case event := <-eventChan:
 			attachSourcePod(event, resolveSource)
+			noteBandwidthLimit(diagnostician, event, resolveSource)
 			var k8sCtx map[string]interface{}
 			if enricher != nil {
 				enriched := enricher.EnrichEvent(ctx, event)
@@ -762,33 +1186,54 @@ func runNormalModeWithSource(ctx context.Context, eventChan <-chan *events.Event
 				}
 				tracingManager.ProcessEvent(event, k8sCtxInterface)
 			}
+			printer.PrintEvent(event)
+
+		case <-snapshotSig:
+			handleSnapshotSignal(diagnostician)
+
+		case c := <-liveConfigUpdates:
+			applyLiveConfig(diagnostician, printer, c)
 
 		case <-ticker.C:
 			diagnostician.Finish()
 
+			if printer.streaming() || printer.issuesOnly() {
+				continue
+			}
+
 			if hasPrintedReport {
 				fmt.Print("\033[2J\033[H")
 			}
 
+			setTelemetry(diagnostician)
 			report := diagnostician.GenerateReport()
 			fmt.Println("=== Real-time Diagnostic Report (updating every 5s) ===")
 			fmt.Println("Press Ctrl+C to stop and see final report.")
 			fmt.Println()
+			if recent := alerter.Recent(); recent != "" {
+				fmt.Print(recent)
+				fmt.Println()
+			}
 			fmt.Println(report)
 			hasPrintedReport = true
 
 		case <-ctx.Done():
+			stopAlerts()
 			diagnostician.Finish()
+			crossCheckStorage(ctx, diagnostician)
 			if hasPrintedReport {
 				fmt.Print("\033[2J\033[H")
 			}
 			fmt.Println("\n=== Final Diagnostic Report ===")
 			fmt.Println()
 			finalDuration := diagnostician.EndTime().Sub(diagnostician.StartTime())
-			report := generateDiagnoseReport(diagnostician)
-			if profilingReporter != nil {
-				report += profilingReporter.GenerateSection(diagnostician.GetEvents(), finalDuration)
-			}
+			report := printer.finalReport(diagnostician, func() string {
+				r := generateDiagnoseReport(diagnostician)
+				if profilingReporter != nil {
+					r += profilingReporter.GenerateSection(diagnostician.GetEvents(), finalDuration)
+				}
+				return r
+			})
 			fmt.Println(report)
 			return nil
 		}
@@ -817,9 +1262,20 @@ func runDiagnoseModeWithSource(ctx context.Context, eventChan <-chan *events.Eve
 	} else {
 		diagnostician = diagnose.NewDiagnosticianWithThresholds(errorRateThreshold, rttSpikeThreshold, fsSlowThreshold)
 	}
-	timeout := time.After(duration)
+	diagnostician.SetReportTemplate(loadedReportTemplate)
+	diagnostician.SetEventBudget(maxEventsBudget)
+	setNodeLinkSpeed(diagnostician)
+	setLowPrivilege(diagnostician)
+	setSuppression(diagnostician)
+	setVolumeMounts(diagnostician)
+	setPodStatuses(diagnostician)
+	diagnostician.SetSLOs(loadedSLOs)
+	timeout := diagnoseDeadline(duration, loadWindow)
+	printer := newVerbosityPrinter(os.Stdout, verbosity, rttSpikeThreshold, fsSlowThreshold)
 	batchTicker := time.NewTicker(config.BatchProcessingInterval)
 	defer batchTicker.Stop()
+	snapshotSig, stopSnapshotSig := snapshotSignal()
+	defer stopSnapshotSig()
 	eventBatch := make([]*events.Event, 0, config.EventBatchSize)
 	// flushBatch feeds every pending event to the diagnostician (and the
 	// tracing manager). The terminal paths MUST flush too: finishing the
@@ -857,55 +1313,99 @@ func runDiagnoseModeWithSource(ctx context.Context, eventChan <-chan *events.Eve
 		select {
 		case event := <-eventChan:
 			attachSourcePod(event, resolveSource)
+			noteBandwidthLimit(diagnostician, event, resolveSource)
+			if exportFormat == "" {
+				printer.PrintEvent(event)
+			}
 			eventBatch = append(eventBatch, event)
 			if len(eventBatch) >= config.EventBatchSize {
 				flushBatch()
 			}
 		case <-batchTicker.C:
 			flushBatch()
+		case <-snapshotSig:
+			flushBatch()
+			handleSnapshotSignal(diagnostician)
+		case c := <-liveConfigUpdates:
+			flushBatch()
+			applyLiveConfig(diagnostician, printer, c)
 		case <-timeout:
 			flushBatch()
 			diagnostician.Finish()
+			loadWindow.applyTo(diagnostician)
+			reportFocus.applyTo(diagnostician)
+			crossCheckStorage(ctx, diagnostician)
 			report := generateDiagnoseReport(diagnostician)
 			if profilingReporter != nil {
 				report += profilingReporter.GenerateSection(diagnostician.GetEvents(), duration)
 			}
+			report += historyComparison(diagnostician)
 			finalizeDiagnoseOutputs(ctx, report, diagnostician)
+			writeDiagnoseBundle(ctx, report, diagnostician)
+			recordLocalRun(report, diagnostician)
 			if exportFormat != "" {
-				return exportReport(report, exportFormat, diagnostician)
+				if err := exportReport(report, exportFormat, diagnostician); err != nil {
+					return err
+				}
+				return sloFailure(diagnostician.EvaluateSLOs())
 			}
-			fmt.Println(report)
-			return nil
+			fmt.Println(printer.finalReport(diagnostician, func() string { return report }))
+			return sloFailure(diagnostician.EvaluateSLOs())
 		case <-ctx.Done():
 			flushBatch()
 			diagnostician.Finish()
+			loadWindow.applyTo(diagnostician)
+			reportFocus.applyTo(diagnostician)
+			crossCheckStorage(ctx, diagnostician)
 			report := generateDiagnoseReport(diagnostician)
 			if profilingReporter != nil {
 				report += profilingReporter.GenerateSection(diagnostician.GetEvents(), duration)
 			}
+			report += historyComparison(diagnostician)
 			finalizeDiagnoseOutputs(ctx, report, diagnostician)
+			writeDiagnoseBundle(ctx, report, diagnostician)
+			recordLocalRun(report, diagnostician)
 			if exportFormat != "" {
-				return exportReport(report, exportFormat, diagnostician)
+				if err := exportReport(report, exportFormat, diagnostician); err != nil {
+					return err
+				}
+				return sloFailure(diagnostician.EvaluateSLOs())
 			}
 			fmt.Println("\n=== Final Diagnostic Report ===")
 			fmt.Println()
-			fmt.Println(report)
-			return nil
+			fmt.Println(printer.finalReport(diagnostician, func() string { return report }))
+			return sloFailure(diagnostician.EvaluateSLOs())
 		}
 	}
 }
 
 func filterEvents(ctx context.Context, in <-chan *events.Event, out chan<- *events.Event, filter string) {
-	defer close(out)
-	filters := strings.Split(strings.ToLower(filter), ",")
-	filterMap := make(map[string]bool, len(filters))
-	for _, f := range filters {
-		f = strings.TrimSpace(f)
-		if f != "" {
-			filterMap[f] = true
-		}
+	f := newLiveEventFilter(filter)
+	f.emptyDropsAll = true
+	runEventFilter(ctx, in, out, f)
+}
+
+// gateProbeGroups detaches the probe groups that none of the --probe-groups
+// categories needs, so their programs stop running rather than having their
+// events dropped in userspace.
+func gateProbeGroups(tracer ebpf.TracerInterface, categories string) {
+	if categories == "" {
+		return
+	}
+	gate, ok := tracer.(tracerpkg.ProbeCategoryGate)
+	if !ok {
+		logger.Warn("--probe-groups ignored: this tracer attaches no probe groups")
+		return
+	}
+	if err := gate.SetEnabledCategories(parseCSV(strings.ToLower(categories))); err != nil {
+		logger.Warn("Failed to detach unneeded probe groups", zap.Error(err))
 	}
+}
 
+// runEventFilter forwards the events of in that pass filter, reading the
+// filter anew for every event so that a control-file reload applies at once.
+func runEventFilter(ctx context.Context, in <-chan *events.Event, out chan<- *events.Event, filter *liveEventFilter) {
+	defer close(out)
 	for {
 		select {
 		case <-ctx.Done():
@@ -917,20 +1417,29 @@ func filterEvents(ctx context.Context, in <-chan *events.Event, out chan<- *even
 			if event == nil {
 				continue
 			}
-			shouldInclude := false
+			filterMap := filter.load()
+			shouldInclude := len(filterMap) == 0 && !filter.emptyDropsAll
 			switch {
+			case event.Type == events.EventAnnotation, event.Type == events.EventCrash, event.Type == events.EventPodThroughput,
+				event.Type == events.EventCPUPlacement, event.Type == events.EventCRIOp,
+				event.Type == events.EventImagePull, event.Type == events.EventDisruption, event.Type == events.EventNeighbor,
+				event.Type == events.EventChaos, event.Type == events.EventPause:
+				shouldInclude = true
 			case filterMap["dns"] && event.Type == events.EventDNS:
 				shouldInclude = true
 			case filterMap["net"] && (event.Type == events.EventConnect || event.Type == events.EventTCPSend || event.Type == events.EventTCPRecv ||
 				event.Type == events.EventFastCGIReq || event.Type == events.EventFastCGIResp ||
 				event.Type == events.EventHTTPReq || event.Type == events.EventHTTPResp ||
-				event.Type == events.EventGRPCMethod || event.Type == events.EventHTTP3):
+				event.Type == events.EventGRPCMethod || event.Type == events.EventHTTP3 ||
+				event.Type == events.EventTCPZeroWindow):
 				shouldInclude = true
-			case filterMap["fs"] && (event.Type == events.EventRead || event.Type == events.EventWrite || event.Type == events.EventFsync):
+			case filterMap["fs"] && (event.Type == events.EventRead || event.Type == events.EventWrite || event.Type == events.EventFsync ||
+				event.Type == events.EventPageCache || event.Type == events.EventFsNotify):
 				shouldInclude = true
-			case filterMap["cpu"] && event.Type == events.EventSchedSwitch:
+			case filterMap["cpu"] && (event.Type == events.EventSchedSwitch || event.Type == events.EventThreadCPU):
 				shouldInclude = true
-			case filterMap["proc"] && (event.Type == events.EventExec || event.Type == events.EventFork || event.Type == events.EventOpen || event.Type == events.EventClose):
+			case filterMap["proc"] && (event.Type == events.EventExec || event.Type == events.EventFork || event.Type == events.EventOpen || event.Type == events.EventClose ||
+				event.Type == events.EventCompaction || event.Type == events.EventTHPCollapse || event.Type == events.EventSwap || event.Type == events.EventTmpfs):
 				shouldInclude = true
 			case filterMap["crypto"] && event.Type == events.EventAFALG:
 				shouldInclude = true
@@ -952,15 +1461,37 @@ func filterEvents(ctx context.Context, in <-chan *events.Event, out chan<- *even
 }
 
 func exportReport(_ string, format string, d *diagnose.Diagnostician) error {
+	if exportOutput == "" {
+		return writeExport(os.Stdout, format, d)
+	}
+	var buf bytes.Buffer
+	if err := writeExport(&buf, format, d); err != nil {
+		return err
+	}
+	path, err := filepath.Abs(exportOutput)
+	if err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	if err := hostfs.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write export: %w", err)
+	}
+	return nil
+}
+
+func writeExport(w io.Writer, format string, d *diagnose.Diagnostician) error {
 	format = strings.ToLower(strings.TrimSpace(format))
 	switch format {
 	case "json":
 		data := d.ExportJSON()
-		enc := json.NewEncoder(os.Stdout)
+		enc := json.NewEncoder(w)
 		enc.SetIndent("", "  ")
 		return enc.Encode(data)
 	case "csv":
-		return d.ExportCSV(os.Stdout)
+		return d.ExportCSV(w)
+	case "proto":
+		return d.ExportProto(w)
+	case "sqlite":
+		return d.ExportSQLite(w)
 	default:
 		return fmt.Errorf("unsupported export format: %s", format)
 	}
@@ -978,6 +1509,27 @@ func podContainerTargets(p *kubernetes.PodInfo) []kubernetes.ContainerTarget {
 	return []kubernetes.ContainerTarget{{Name: p.ContainerName, ID: p.ContainerID, CgroupPath: p.CgroupPath}}
 }
 
+// podMetadata lists the traced pods for podtrace_pod_info.
+func podMetadata(infos []*kubernetes.PodInfo) []metricsexporter.PodMetadata {
+	out := make([]metricsexporter.PodMetadata, 0, len(infos))
+	for _, p := range infos {
+		if p == nil {
+			continue
+		}
+		out = append(out, metricsexporter.PodMetadata{
+			Namespace: p.Namespace,
+			Pod:       p.PodName,
+			UID:       p.PodUID,
+			Node:      p.NodeName,
+			Container: p.ContainerName,
+			OwnerKind: p.OwnerKind,
+			OwnerName: p.OwnerName,
+			Runtime:   p.Runtime.Name,
+		})
+	}
+	return out
+}
+
 // targetAttachSets flattens every traced container of every target pod into
 // the cgroup-path and container-ID attach lists.
 func targetAttachSets(infos []*kubernetes.PodInfo) (cgroupPaths, containerIDs []string) {
@@ -1102,15 +1654,21 @@ func attachSourcePod(e *events.Event, resolve func(*events.Event) *kubernetes.Po
 	}
 	if src := resolve(e); src != nil && src.PodName != "" {
 		e.K8s = &events.K8sMetadata{
-			Namespace:     src.Namespace,
-			PodName:       src.PodName,
-			ContainerName: src.ContainerName,
+			Namespace:        src.Namespace,
+			PodName:          src.PodName,
+			PodUID:           src.PodUID,
+			NodeName:         src.NodeName,
+			ContainerName:    src.ContainerName,
+			ContainerRuntime: src.Runtime.Name,
+			RuntimeVersion:   src.Runtime.Version,
 		}
 	}
 }
 
 // generateDiagnoseReport renders the diagnostic report.
 func generateDiagnoseReport(agg *diagnose.Diagnostician) string {
+	setTelemetry(agg)
+	setPodTerminations(agg)
 	allEvents := agg.GetEvents()
 	contexts := agg.EventContexts()
 
@@ -1148,11 +1706,27 @@ func generateDiagnoseReport(agg *diagnose.Diagnostician) string {
 
 	sort.Strings(order)
 	var sb strings.Builder
+	// The budget is spent across every traced pod, and events are
+	// suppressed before they are split per pod, so both are noted once,
+	// as is the list of pods that went away.
+	sb.WriteString(report.GeneratePartialSection(agg.PodTerminations(), agg.StartTime()))
+	sb.WriteString(report.GenerateBudgetSection(agg.BudgetOverflow(), agg.StartTime()))
+	sb.WriteString(report.GenerateSuppressedSection(agg.Suppressed()))
 	for _, key := range order {
 		b := buckets[key]
 		child := diagnose.NewDiagnosticianWithK8sAndThresholds(
 			b.podName, b.namespace, errorRateThreshold, rttSpikeThreshold, fsSlowThreshold)
 		child.SetTimeWindow(agg.StartTime(), agg.EndTime())
+		child.SetReportTemplate(agg.ReportTemplate())
+		copyBandwidthLimits(child, agg)
+		if status, ok := agg.PodStatus(b.namespace, b.podName); ok {
+			child.SetPodStatus(status)
+		}
+		child.SetVolumeMounts(agg.VolumeMounts())
+		child.SetTelemetry(agg.Telemetry())
+		for claim, ev := range agg.StorageEvidence() {
+			child.SetStorageEvidence(claim, ev)
+		}
 		for i, e := range b.events {
 			child.AddEventWithContext(e, b.contexts[i])
 		}
@@ -1163,6 +1737,11 @@ func generateDiagnoseReport(agg *diagnose.Diagnostician) string {
 		fmt.Fprintf(&sb, "\n================ Diagnosis: %s ================\n\n", label)
 		sb.WriteString(child.GenerateReport())
 	}
+	// SLOs are evaluated across every traced pod, matching --fail-on.
+	if slos := report.GenerateSLOSection(agg.EvaluateSLOs()); slos != "" {
+		sb.WriteString("\n================ Diagnosis: all pods ================\n\n")
+		sb.WriteString(slos)
+	}
 	return sb.String()
 }
 
@@ -1265,6 +1844,23 @@ func parsePodRef(podRef, defaultNamespace string) (namespace, podName string) {
 	return defaultNamespace, podRef
 }
 
+// lingerMetrics keeps the process, and so the metrics server, up for d after
+// a diagnose run so a scrape after it still sees the final values. An
+// interrupt (ctx done) ends it early.
+func lingerMetrics(ctx context.Context, d time.Duration) {
+	if !enableMetrics || d <= 0 || ctx.Err() != nil {
+		return
+	}
+	logger.Info("Diagnose finished; serving final metrics until the linger ends",
+		zap.Duration("linger", d))
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
 func interruptChan() <-chan os.Signal {
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
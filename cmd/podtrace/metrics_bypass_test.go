@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
@@ -105,3 +106,44 @@ func TestRunPodtrace_EnableMetrics_WithEvent(t *testing.T) {
 		t.Error("runPodtrace with enableMetrics+event did not complete in time")
 	}
 }
+
+func TestRunPodtrace_MetricsLingerRequiresMetricsAndDiagnose(t *testing.T) {
+	saveRunPodtraceGlobals(t)
+	resetRunPodtraceGlobals()
+	metricsLinger = time.Minute
+	diagnoseDuration = "1s"
+
+	err := runPodtrace(cmdWithNamespaceChanged(), []string{"test-pod"})
+	if err == nil || !strings.Contains(err.Error(), "--metrics-linger requires --metrics and --diagnose") {
+		t.Fatalf("expected --metrics-linger error, got %v", err)
+	}
+}
+
+func TestLingerMetrics(t *testing.T) {
+	saveRunPodtraceGlobals(t)
+	enableMetrics = true
+
+	start := time.Now()
+	lingerMetrics(context.Background(), 30*time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("lingerMetrics returned after %s, want >= 30ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	start = time.Now()
+	lingerMetrics(ctx, time.Hour)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("lingerMetrics ignored the interrupt for %s", elapsed)
+	}
+
+	enableMetrics = false
+	start = time.Now()
+	lingerMetrics(context.Background(), time.Hour)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("lingerMetrics without --metrics waited %s", elapsed)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+// startNeighborMonitor injects the CPU and block I/O of the busiest other
+// pods on the node into eventChan as EventNeighbor until ctx is done. Pods
+// are named from the API when a clientset is at hand, by UID otherwise.
+func startNeighborMonitor(ctx context.Context, eventChan chan<- *events.Event, resolver kubernetes.PodResolverInterface, targets []*kubernetes.PodInfo) {
+	if !config.NoisyNeighbors || len(targets) == 0 {
+		return
+	}
+	var traced []string
+	for _, t := range targets {
+		traced = append(traced, t.CgroupPath)
+		for _, c := range t.Containers {
+			traced = append(traced, c.CgroupPath)
+		}
+	}
+	var name func(uid string) string
+	if provider, ok := resolver.(kubernetes.ClientsetProvider); ok && provider.GetClientset() != nil {
+		clientset := provider.GetClientset()
+		name = podNamesOnNode(ctx, clientset, targetNode(ctx, clientset, targets))
+	}
+	resource.NewNeighborMonitor(traced, name, eventChan).Start(ctx)
+}
+
+// podNamesOnNode returns a lookup of the namespace/name of the pods on node
+// by UID. It lists them again on a miss, at most once per neighbor interval.
+func podNamesOnNode(ctx context.Context, clientset k8s.Interface, node string) func(uid string) string {
+	var (
+		mu     sync.Mutex
+		names  map[string]string
+		listed time.Time
+	)
+	return func(uid string) string {
+		mu.Lock()
+		defer mu.Unlock()
+		if n, ok := names[uid]; ok || time.Since(listed) < config.NeighborInterval {
+			return n
+		}
+		listed = time.Now()
+		opts := metav1.ListOptions{}
+		if node != "" {
+			opts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", node).String()
+		}
+		pods, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, opts)
+		if err != nil {
+			return ""
+		}
+		names = make(map[string]string, len(pods.Items))
+		for _, p := range pods.Items {
+			names[string(p.UID)] = p.Namespace + "/" + p.Name
+		}
+		return names[uid]
+	}
+}
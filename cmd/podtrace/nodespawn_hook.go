@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
@@ -30,6 +32,13 @@ var spawnControlFlags = map[string]struct{}{
 	"service-account":      {},
 	"dynamic-spawn":        {},
 	"keep-spawn-pod":       {},
+	"bundle":               {},
+	"on-issue":             {},
+	"mark-start-cmd":       {},
+	"mark-end-cmd":         {},
+	"mark-addr":            {},
+	"init-container":       {},
+	"geoip-db":             {},
 	"namespace":            {},
 	"namespaces":           {},
 	"pods":                 {},
@@ -38,6 +47,7 @@ var spawnControlFlags = map[string]struct{}{
 	"app":                  {},
 	"label":                {},
 	"all-namespaces":       {},
+	"context":              {},
 }
 
 // maybeSpawnOnNode runs the spawn flow when appropriate.
@@ -88,14 +98,35 @@ func maybeSpawnOnNode(ctx context.Context, cmd *cobra.Command, resolver pkgkube.
 		return true, fmt.Errorf("nodespawn: no scheduled target pods")
 	}
 	multiNode := len(preResolved.NodeNames) > 1
+	if multiNode && strings.EqualFold(strings.TrimSpace(exportFormat), "proto") {
+		return true, fmt.Errorf("--export proto needs a single node: output from several spawned pods is line-prefixed, which corrupts the binary stream. Pass --local or narrow the selection to one node")
+	}
+	if exportOutput != "" {
+		return true, fmt.Errorf("--output would write inside the spawned pod, where the file is lost. Pass --local")
+	}
 
 	metricsPassThrough := enableMetrics && !multiNode
 	if enableMetrics && multiNode {
 		logger.Warn("--metrics ignored: spawn covers multiple nodes; auto-port-forward only single-node spawns. Pass --local for workstation metrics.")
 	}
 
+	if len(onIssueScripts) > 0 {
+		logger.Warn("--on-issue ignored: issues are detected by the spawned pod on the node, where the scripts are not available. Pass --local to run them.")
+	}
+	if loadMarksRequested() {
+		logger.Warn("--mark-start-cmd and --mark-addr ignored: the spawned pod measures the whole --diagnose window. Pass --local to bracket it to the load.")
+	}
+
 	build := newChildArgsBuilder(cmd, metricsPassThrough)
 	streams := genericiooptions.IOStreams{In: os.Stdin, Out: os.Stdout, ErrOut: os.Stderr}
+	bundleOutput := newSpawnBundleOutput()
+	if bundleOutput != nil {
+		streams.Out = io.MultiWriter(streams.Out, bundleOutput)
+	}
+	historyOutput := newSpawnHistoryOutput()
+	if historyOutput != nil {
+		streams.Out = io.MultiWriter(streams.Out, historyOutput)
+	}
 
 	var onRunning func(context.Context, *corev1.Pod) error
 	if metricsPassThrough {
@@ -133,9 +164,13 @@ func maybeSpawnOnNode(ctx context.Context, cmd *cobra.Command, resolver pkgkube.
 	if exportFormat != "" {
 		eventsOut = streams.ErrOut
 	}
+	// Deferred first so that it runs last and the bundle captures the
+	// Kubernetes events section too.
+	defer writeSpawnBundle(ctx, bundleOutput, clientset, allTargetPods)
 	finishEventCorrelation := startWorkstationEventCorrelation(ctx, clientset, allTargetPods, eventsOut)
 	defer finishEventCorrelation()
 
+	started := time.Now()
 	err = nodespawn.Run(ctx, nodespawn.RunOptions{
 		Clientset:             clientset,
 		RestConfig:            restCfg,
@@ -151,6 +186,7 @@ func maybeSpawnOnNode(ctx context.Context, cmd *cobra.Command, resolver pkgkube.
 		DynamicReSpawn:        dynamic,
 		ServiceAccountName:    sa,
 		KeepSpawnPodOnFailure: keepSpawnPodOnFailure,
+		LowPrivilege:          config.LowPrivilege,
 	})
 	if err != nil {
 		var exitErr *nodespawn.ExitError
@@ -159,6 +195,7 @@ func maybeSpawnOnNode(ctx context.Context, cmd *cobra.Command, resolver pkgkube.
 		}
 		return true, err
 	}
+	recordSpawnRun(historyOutput, started)
 	return true, nil
 }
 
@@ -195,7 +232,21 @@ func newChildArgsBuilder(cmd *cobra.Command, passMetrics bool) func(string, []no
 			if _, drop := spawnControlFlags[f.Name]; drop {
 				return
 			}
-			if f.Name == "metrics" && !passMetrics {
+			if (f.Name == "metrics" || f.Name == "metrics-linger") && !passMetrics {
+				return
+			}
+			if f.Name == "slo" {
+				args = append(args, "--slo-inline="+sloDefinitions)
+				return
+			}
+			if f.Name == "suppress" {
+				args = append(args, "--suppress-inline="+suppressDefinitions)
+				return
+			}
+			if f.Name == "report-template" {
+				if arg := reportTemplateInlineArg(); arg != "" {
+					args = append(args, arg)
+				}
 				return
 			}
 			args = append(args, "--"+f.Name+"="+f.Value.String())
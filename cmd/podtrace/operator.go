@@ -24,6 +24,8 @@ type operatorOptions struct {
 	leaderElectNamespace string
 	webhookPort          int
 	webhookCertDir       string
+	federationAddr       string
+	clusterName          string
 }
 
 func newOperatorCmd() *cobra.Command {
@@ -67,6 +69,10 @@ namespace.`,
 		"Port the validating webhook server listens on")
 	cmd.Flags().StringVar(&opts.webhookCertDir, "webhook-cert-dir", "",
 		"Directory containing tls.crt and tls.key for the webhook server (empty disables the webhook)")
+	cmd.Flags().StringVar(&opts.federationAddr, "federation-addr", "",
+		"Address for the federation gateway serving trace requests from other clusters (empty disables it; the token is read from PODTRACE_FEDERATION_TOKEN)")
+	cmd.Flags().StringVar(&opts.clusterName, "cluster-name", "",
+		"Name of this cluster in federation responses")
 
 	return cmd
 }
@@ -100,6 +106,9 @@ func toOperatorOptions(c *operatorOptions, leaderNSExplicit bool) operator.Optio
 		WebhookCertDir:            c.webhookCertDir,
 		BootstrapFallbackImage:    bootstrapFallbackImage(),
 		BootstrapTracerConfigName: os.Getenv("PODTRACE_BOOTSTRAP_TC_NAME"),
+		FederationBindAddress:     c.federationAddr,
+		FederationToken:           config.FederationToken,
+		ClusterName:               c.clusterName,
 	}
 }
 
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+// startPauseWatch injects into eventChan, as EventPause until ctx is done,
+// the intervals in which a target container was frozen or its main process
+// stopped, so the timeline shows them rather than an unexplained gap.
+func startPauseWatch(ctx context.Context, eventChan chan<- *events.Event, targets []*kubernetes.PodInfo) {
+	if !config.PauseDetect || len(targets) == 0 {
+		return
+	}
+	var watched []resource.PauseTarget
+	for _, t := range targets {
+		pod := t.Namespace + "/" + t.PodName
+		if len(t.Containers) == 0 {
+			if t.CgroupPath != "" {
+				watched = append(watched, resource.PauseTarget{Name: pod + "/" + t.ContainerName, CgroupPath: t.CgroupPath})
+			}
+			continue
+		}
+		for _, c := range t.Containers {
+			if c.CgroupPath != "" {
+				watched = append(watched, resource.PauseTarget{Name: pod + "/" + c.Name, CgroupPath: c.CgroupPath})
+			}
+		}
+	}
+	if len(watched) == 0 {
+		return
+	}
+	resource.NewPauseMonitor(watched, eventChan).Start(ctx)
+}
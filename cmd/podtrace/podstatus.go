@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/report"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// reportPodStatuses are the traced pods' Kubernetes statuses, recorded when
+// the targets are resolved, for the top of the diagnose report.
+var reportPodStatuses []report.PodStatus
+
+// recordPodStatuses keeps the statuses of the resolved targets. Pre-resolved
+// targets carry none, so theirs are fetched when the resolver can reach the
+// API; a failure only leaves the pod out of the report header.
+func recordPodStatuses(ctx context.Context, resolver kubernetes.PodResolverInterface, infos []*kubernetes.PodInfo) {
+	reportPodStatuses = reportPodStatuses[:0]
+	cp, hasClientset := resolver.(kubernetes.ClientsetProvider)
+	for _, info := range infos {
+		status := info.Status
+		if status.Empty() && hasClientset {
+			fetched, err := kubernetes.FetchPodStatus(ctx, cp.GetClientset(), info.Namespace, info.PodName, podContainerTargets(info))
+			if err != nil {
+				logger.Debug("Pod status unavailable for the report header",
+					zap.String("pod", info.Namespace+"/"+info.PodName), zap.Error(err))
+				continue
+			}
+			status = fetched
+		}
+		if status.Empty() {
+			continue
+		}
+		reportPodStatuses = append(reportPodStatuses, newReportPodStatus(info.Namespace, info.PodName, status))
+	}
+}
+
+func newReportPodStatus(namespace, pod string, s kubernetes.PodStatus) report.PodStatus {
+	out := report.PodStatus{Namespace: namespace, Pod: pod, QOSClass: s.QOSClass}
+	for _, c := range s.Containers {
+		rc := report.ContainerStatus{
+			Name:         c.Name,
+			RestartCount: c.RestartCount,
+			Requests:     c.Requests,
+			Limits:       c.Limits,
+		}
+		if t := c.LastTermination; t != nil {
+			rc.LastReason, rc.LastExitCode, rc.LastFinished = t.Reason, t.ExitCode, t.FinishedAt
+		}
+		out.Containers = append(out.Containers, rc)
+	}
+	return out
+}
+
+// setPodStatuses hands d the statuses recordPodStatuses kept.
+func setPodStatuses(d *diagnose.Diagnostician) {
+	for _, s := range reportPodStatuses {
+		d.SetPodStatus(s)
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8s "k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+type podStatusResolver struct{ clientset k8s.Interface }
+
+func (r *podStatusResolver) ResolvePod(context.Context, string, string, string) (*kubernetes.PodInfo, error) {
+	return nil, nil
+}
+
+func (r *podStatusResolver) GetClientset() k8s.Interface { return r.clientset }
+
+func TestRecordPodStatuses(t *testing.T) {
+	orig := reportPodStatuses
+	t.Cleanup(func() { reportPodStatuses = orig })
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker-1", Namespace: "jobs"},
+		Status: corev1.PodStatus{
+			QOSClass: corev1.PodQOSBestEffort,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "worker", RestartCount: 4, LastTerminationState: corev1.ContainerState{
+				Terminated: &corev1.ContainerStateTerminated{Reason: "Error", ExitCode: 1, FinishedAt: metav1.NewTime(time.Now())},
+			}}},
+		},
+	}
+	resolved := &kubernetes.PodInfo{PodName: "api-0", Namespace: "shop", Status: kubernetes.PodStatus{
+		QOSClass: "Guaranteed", Containers: []kubernetes.ContainerStatus{{Name: "api"}},
+	}}
+	preResolved := &kubernetes.PodInfo{PodName: "worker-1", Namespace: "jobs", ContainerName: "worker",
+		Containers: []kubernetes.ContainerTarget{{Name: "worker"}}}
+	gone := &kubernetes.PodInfo{PodName: "gone", Namespace: "jobs", Containers: []kubernetes.ContainerTarget{{Name: "x"}}}
+
+	recordPodStatuses(context.Background(), &podStatusResolver{clientset: fake.NewSimpleClientset(pod)},
+		[]*kubernetes.PodInfo{resolved, preResolved, gone})
+	if len(reportPodStatuses) != 2 {
+		t.Fatalf("recorded %+v; want the resolved and the fetched pod", reportPodStatuses)
+	}
+	fetched := reportPodStatuses[1]
+	if fetched.Pod != "worker-1" || fetched.QOSClass != "BestEffort" || len(fetched.Containers) != 1 ||
+		fetched.Containers[0].RestartCount != 4 || fetched.Containers[0].LastReason != "Error" || fetched.Containers[0].LastExitCode != 1 {
+		t.Errorf("fetched status = %+v", fetched)
+	}
+
+	d := diagnose.NewDiagnostician()
+	setPodStatuses(d)
+	if got := d.PodStatuses(); len(got) != 2 || got[0].Namespace != "jobs" {
+		t.Errorf("diagnostician statuses = %+v", got)
+	}
+}
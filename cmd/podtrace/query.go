@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/podtrace/podtrace/internal/hostfs"
+	"github.com/podtrace/podtrace/internal/query"
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+const queryHelp = `Filters (all must hold):  <field><op><value> ...
+  text fields:    type, process, target, details  (=, !=, ~ regexp, !~)
+  number fields:  latency (ms, or 250ms, 1.5s), bytes, error, pid  (=, !=, <, <=, >, >=)
+Stages:  | count [by <field>]
+         | sum|avg|min|max|p50|p90|p95|p99 <number field> [by <field>]
+         | limit <rows>
+Example: type=dns target~"payments" | p99 latency by target
+Type quit or exit to leave.
+`
+
+func newQueryCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "query <capture> [query]",
+		Short: "Filter and aggregate the events of a capture file",
+		Long: `Loads a capture file (--trigger-record output in JSON lines or the binary
+format, or a bundle's capture.jsonl) and runs a query over its events, or, without a
+query, opens a prompt to run one after another:
+
+  podtrace query capture.pb 'type=dns target~"payments" | p99 latency by target'
+
+` + queryHelp,
+		Args: cobra.RangeArgs(1, 2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			evts, err := readCapture(args[0])
+			if err != nil {
+				return err
+			}
+			if len(args) == 2 {
+				return runQuery(cmd.OutOrStdout(), args[1], evts)
+			}
+			return queryREPL(cmd.InOrStdin(), cmd.OutOrStdout(), args[0], evts)
+		},
+	}
+}
+
+// readCapture reads every event of a capture file: the binary format of
+// pkg/schema for a .pb path, and otherwise JSON lines unless the content is
+// not JSON, as with a recording renamed to capture.bin.
+func readCapture(path string) ([]schema.Event, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("read capture: %w", err)
+	}
+	data, err := hostfs.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("read capture: %w", err)
+	}
+	if binaryRecordPath(path) {
+		return readProtoCapture(path, data)
+	}
+	evts, err := readJSONCapture(path, data)
+	if err != nil && !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if binEvts, binErr := readProtoCapture(path, data); binErr == nil {
+			return binEvts, nil
+		}
+	}
+	return evts, err
+}
+
+func readJSONCapture(path string, data []byte) ([]schema.Event, error) {
+	var evts []schema.Event
+	r := schema.NewEventReader(bytes.NewReader(data))
+	for {
+		e, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return evts, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read capture %s: %w", path, err)
+		}
+		evts = append(evts, e)
+	}
+}
+
+func readProtoCapture(path string, data []byte) ([]schema.Event, error) {
+	var evts []schema.Event
+	r := schema.NewProtoReader(bytes.NewReader(data))
+	for {
+		rec, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			return evts, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read capture %s: %w", path, err)
+		}
+		if rec.Event != nil {
+			evts = append(evts, *rec.Event)
+		}
+	}
+}
+
+func runQuery(w io.Writer, q string, evts []schema.Event) error {
+	parsed, err := query.Parse(q)
+	if err != nil {
+		return err
+	}
+	return parsed.Run(evts).Write(w)
+}
+
+// queryREPL runs one query per line read from in until it ends or the
+// user quits. A bad query is reported and the prompt comes back.
+func queryREPL(in io.Reader, out io.Writer, path string, evts []schema.Event) error {
+	fmt.Fprintf(out, "%d events loaded from %s. Type a query, help or quit.\n", len(evts), path)
+	sc := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(out, "> ")
+		if !sc.Scan() {
+			fmt.Fprintln(out)
+			return sc.Err()
+		}
+		line := strings.TrimSpace(sc.Text())
+		switch line {
+		case "":
+			continue
+		case "quit", "exit":
+			return nil
+		case "help":
+			fmt.Fprint(out, queryHelp)
+			continue
+		}
+		if err := runQuery(out, line, evts); err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+		}
+	}
+}
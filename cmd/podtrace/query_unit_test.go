@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+func writeTestCapture(t *testing.T, name string) string {
+	t.Helper()
+	evts := []schema.Event{
+		{SchemaVersion: schema.CurrentVersion, Time: "2026-01-02T10:00:00Z", Type: "DNS", Target: "payments.shop.svc", LatencyNS: 4_000_000},
+		{SchemaVersion: schema.CurrentVersion, Time: "2026-01-02T10:00:01Z", Type: "DNS", Target: "payments.shop.svc", LatencyNS: 6_000_000},
+		{SchemaVersion: schema.CurrentVersion, Time: "2026-01-02T10:00:02Z", Type: "CONNECT", Target: "10.0.0.7:443", Error: 111},
+	}
+	var buf bytes.Buffer
+	if ext := filepath.Ext(name); ext == ".pb" || ext == ".bin" {
+		w := schema.NewProtoWriter(&buf)
+		for _, e := range evts {
+			if err := w.WriteEvent(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	} else {
+		enc := json.NewEncoder(&buf)
+		for _, e := range evts {
+			if err := enc.Encode(e); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestQueryCmdOneLiner(t *testing.T) {
+	for _, name := range []string{"capture.jsonl", "capture.pb", "capture.bin"} {
+		path := writeTestCapture(t, name)
+		var out bytes.Buffer
+		cmd := newQueryCmd()
+		cmd.SetOut(&out)
+		cmd.SetArgs([]string{path, "type=dns | avg latency by target"})
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		for _, want := range []string{"payments.shop.svc  5.00ms", "2 of 3 events matched"} {
+			if !strings.Contains(out.String(), want) {
+				t.Errorf("%s: output missing %q:\n%s", name, want, out.String())
+			}
+		}
+	}
+}
+
+func TestQueryCmdREPL(t *testing.T) {
+	path := writeTestCapture(t, "capture.jsonl")
+	var out bytes.Buffer
+	cmd := newQueryCmd()
+	cmd.SetIn(strings.NewReader("help\ntype=\n| color\nerror!=0 | count by target\nquit\ntype=dns\n"))
+	cmd.SetOut(&out)
+	cmd.SetArgs([]string{path})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"3 events loaded from " + path,
+		"Example: type=dns",
+		`error: unknown aggregation "color"`,
+		"10.0.0.7:443  1",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "2 of 3 events matched") {
+		t.Errorf("query after quit ran:\n%s", got)
+	}
+}
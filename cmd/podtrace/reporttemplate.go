@@ -0,0 +1,61 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/podtrace/podtrace/internal/diagnose/reporttmpl"
+)
+
+var (
+	reportTemplateDir    string
+	reportTemplateInline string
+
+	// loadedReportTemplate is nil unless --report-template was given, which
+	// leaves diagnosticians on the built-in report.
+	loadedReportTemplate *reporttmpl.Template
+)
+
+// loadReportTemplateFlags loads --report-template (or the files forwarded to
+// a spawned pod with --report-template-inline) and checks that it renders.
+func loadReportTemplateFlags() error {
+	var files map[string]string
+	switch {
+	case reportTemplateDir != "" && reportTemplateInline != "":
+		return fmt.Errorf("--report-template and --report-template-inline are mutually exclusive")
+	case reportTemplateDir != "":
+		abs, err := filepath.Abs(reportTemplateDir)
+		if err != nil {
+			return fmt.Errorf("invalid --report-template path: %w", err)
+		}
+		if files, err = reporttmpl.ReadDir(abs); err != nil {
+			return err
+		}
+	case reportTemplateInline != "":
+		if err := json.Unmarshal([]byte(reportTemplateInline), &files); err != nil {
+			return fmt.Errorf("invalid --report-template-inline: %w", err)
+		}
+	default:
+		return nil
+	}
+	tmpl, err := reporttmpl.New(files)
+	if err != nil {
+		return err
+	}
+	loadedReportTemplate = tmpl
+	return nil
+}
+
+// reportTemplateInlineArg encodes the loaded template directory for a spawned
+// pod, where the workstation path does not exist.
+func reportTemplateInlineArg() string {
+	if loadedReportTemplate == nil {
+		return ""
+	}
+	data, err := json.Marshal(loadedReportTemplate.Files())
+	if err != nil {
+		return ""
+	}
+	return "--report-template-inline=" + string(data)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/podtrace/podtrace/internal/kubernetes/nodespawn"
+)
+
+func restoreReportTemplateGlobals(t *testing.T) {
+	t.Helper()
+	origDir, origInline, origLoaded := reportTemplateDir, reportTemplateInline, loadedReportTemplate
+	t.Cleanup(func() {
+		reportTemplateDir, reportTemplateInline, loadedReportTemplate = origDir, origInline, origLoaded
+	})
+}
+
+const testRunbooks = "\"High connection failure rate\": https://runbooks.example.com/connect\n"
+
+func writeReportTemplateDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "runbooks.yaml"), []byte(testRunbooks), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadReportTemplateFlags(t *testing.T) {
+	restoreReportTemplateGlobals(t)
+	dir := writeReportTemplateDir(t)
+
+	reportTemplateDir, reportTemplateInline, loadedReportTemplate = dir, "", nil
+	if err := loadReportTemplateFlags(); err != nil {
+		t.Fatalf("loadReportTemplateFlags: %v", err)
+	}
+	if loadedReportTemplate == nil || loadedReportTemplate.Files()["runbooks.yaml"] != testRunbooks {
+		t.Fatalf("template not loaded: %+v", loadedReportTemplate)
+	}
+
+	inline := strings.TrimPrefix(reportTemplateInlineArg(), "--report-template-inline=")
+	reportTemplateDir, reportTemplateInline, loadedReportTemplate = "", inline, nil
+	if err := loadReportTemplateFlags(); err != nil || loadedReportTemplate == nil {
+		t.Errorf("--report-template-inline: err = %v", err)
+	}
+
+	for name, set := range map[string]func(){
+		"both sources":    func() { reportTemplateDir, reportTemplateInline = dir, inline },
+		"missing dir":     func() { reportTemplateDir, reportTemplateInline = dir+"/missing", "" },
+		"bad inline":      func() { reportTemplateDir, reportTemplateInline = "", "{" },
+		"broken template": func() { reportTemplateDir, reportTemplateInline = "", `{"x.tmpl":"{{.Nope}"}` },
+	} {
+		set()
+		if err := loadReportTemplateFlags(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestChildArgsForwardReportTemplate(t *testing.T) {
+	restoreReportTemplateGlobals(t)
+	dir := writeReportTemplateDir(t)
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&reportTemplateDir, "report-template", "", "")
+	if err := cmd.Flags().Set("report-template", dir); err != nil {
+		t.Fatal(err)
+	}
+	reportTemplateInline = ""
+	if err := loadReportTemplateFlags(); err != nil {
+		t.Fatal(err)
+	}
+
+	args := strings.Join(newChildArgsBuilder(cmd, false)("node-a", []nodespawn.PodRef{}), " ")
+	if !strings.Contains(args, "--report-template-inline=") || !strings.Contains(args, "runbooks.example.com") {
+		t.Errorf("expected template files forwarded inline, got %v", args)
+	}
+	if strings.Contains(args, "--report-template="+dir) {
+		t.Errorf("workstation --report-template path must not reach the spawn pod, got %v", args)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -17,7 +18,14 @@ func saveRunPodtraceGlobals(t *testing.T) {
 	orig := struct {
 		namespace          string
 		containerName      string
+		initContainerName  string
+		strictContainer    bool
 		eventFilter        string
+		verbosity          string
+		triggerExpr        string
+		triggerRecord      string
+		maxEventsBudget    int
+		uprobesFile        string
 		exportFormat       string
 		errorRateThreshold float64
 		rttSpikeThreshold  float64
@@ -33,21 +41,29 @@ func saveRunPodtraceGlobals(t *testing.T) {
 		preresolvedPods    []string
 		diagnoseDuration   string
 		enableMetrics      bool
+		metricsLinger      time.Duration
 		enableTracing      bool
 		enableProfiling    bool
 		resolverFactory    func() (kubernetes.PodResolverInterface, error)
 		tracerFactory      func() (ebpf.TracerInterface, error)
 	}{
-		namespace, containerName, eventFilter, exportFormat,
+		namespace, containerName, initContainerName, strictContainer, eventFilter, verbosity, triggerExpr, triggerRecord, maxEventsBudget, uprobesFile, exportFormat,
 		errorRateThreshold, rttSpikeThreshold, fsSlowThreshold, showVersion,
 		watchAppName, watchLabels, podSelector, podsCSV, namespacesCSV,
 		allInNamespace, exporterFromFile, preresolvedPods, diagnoseDuration,
-		enableMetrics, enableTracing, enableProfiling, resolverFactory, tracerFactory,
+		enableMetrics, metricsLinger, enableTracing, enableProfiling, resolverFactory, tracerFactory,
 	}
 	t.Cleanup(func() {
 		namespace = orig.namespace
 		containerName = orig.containerName
+		initContainerName = orig.initContainerName
+		strictContainer = orig.strictContainer
 		eventFilter = orig.eventFilter
+		verbosity = orig.verbosity
+		triggerExpr = orig.triggerExpr
+		triggerRecord = orig.triggerRecord
+		maxEventsBudget = orig.maxEventsBudget
+		uprobesFile = orig.uprobesFile
 		exportFormat = orig.exportFormat
 		errorRateThreshold = orig.errorRateThreshold
 		rttSpikeThreshold = orig.rttSpikeThreshold
@@ -63,6 +79,7 @@ func saveRunPodtraceGlobals(t *testing.T) {
 		preresolvedPods = orig.preresolvedPods
 		diagnoseDuration = orig.diagnoseDuration
 		enableMetrics = orig.enableMetrics
+		metricsLinger = orig.metricsLinger
 		enableTracing = orig.enableTracing
 		enableProfiling = orig.enableProfiling
 		resolverFactory = orig.resolverFactory
@@ -73,7 +90,14 @@ func saveRunPodtraceGlobals(t *testing.T) {
 func resetRunPodtraceGlobals() {
 	namespace = "default"
 	containerName = ""
+	initContainerName = ""
+	strictContainer = false
 	eventFilter = ""
+	verbosity = ""
+	triggerExpr = ""
+	triggerRecord = ""
+	maxEventsBudget = 0
+	uprobesFile = ""
 	exportFormat = ""
 	errorRateThreshold = 10.0
 	rttSpikeThreshold = 100.0
@@ -89,6 +113,7 @@ func resetRunPodtraceGlobals() {
 	preresolvedPods = nil
 	diagnoseDuration = ""
 	enableMetrics = false
+	metricsLinger = 0
 	enableTracing = false
 	enableProfiling = false
 }
@@ -16,21 +16,67 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	podtracev1alpha1 "github.com/podtrace/podtrace/api/v1alpha1"
+	"github.com/podtrace/podtrace/internal/kubernetes"
 	"github.com/podtrace/podtrace/internal/operator"
 )
 
-// newScheduleCmd produces the `podtrace schedule` command tree. The
-// only sub-verb today is `trigger`, which materialises a one-off
-// PodTraceSession from the named PodTraceSchedule's template.
+// newScheduleCmd produces the `podtrace schedule` command tree. On its own
+// with --every it runs short diagnoses locally on an interval and stores the
+// JSON exports; `trend` renders those over days. The `trigger` sub-verb
+// materialises a one-off PodTraceSession from the named PodTraceSchedule's
+// template.
 func newScheduleCmd() *cobra.Command {
+	var opts scheduleLoopOptions
 	cmd := &cobra.Command{
-		Use:   "schedule",
-		Short: "Manage PodTraceSchedule resources",
-		Long: `Operate on PodTraceSchedule resources. The schedule controller fires
-a new PodTraceSession on each cron tick; this command exposes
-operations that complement that loop (e.g. one-off manual triggers).`,
+		Use:   "schedule [--every D --for D --store DIR -- <podtrace args>]",
+		Short: "Run periodic diagnoses locally, or manage PodTraceSchedule resources",
+		Long: `With --every, run a short diagnose (--for) on a fixed interval and
+store each JSON export in --store, so slow regressions become visible
+with "podtrace schedule trend" without anyone babysitting the tool.
+Everything after -- is passed to each diagnose run.
+
+The trigger sub-command operates on PodTraceSchedule resources, whose
+controller fires a new PodTraceSession on each cron tick.
+
+Examples:
+  podtrace schedule --every 1h --for 2m --store ./history -- -n prod my-pod
+  podtrace schedule trend --store ./history --window 168h`,
+		Args:         cobra.ArbitraryArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.Every == 0 {
+				return cmd.Help()
+			}
+			opts.Args = args
+			return runScheduleLoop(ctrl.SetupSignalHandler(), opts, time.Now)
+		},
 	}
+	cmd.Flags().DurationVar(&opts.Every, "every", 0, "Interval between diagnose runs (e.g. 1h)")
+	cmd.Flags().DurationVar(&opts.For, "for", 2*time.Minute, "Duration of each diagnose run")
+	cmd.Flags().StringVar(&opts.Store, "store", "", "Directory the JSON export of every run is written to")
+	cmd.Flags().IntVar(&opts.Runs, "runs", 0, "Stop after this many runs (0 = until interrupted)")
 	cmd.AddCommand(newScheduleTriggerCmd())
+	cmd.AddCommand(newScheduleTrendCmd())
+	return cmd
+}
+
+func newScheduleTrendCmd() *cobra.Command {
+	var (
+		store  string
+		window time.Duration
+	)
+	cmd := &cobra.Command{
+		Use:          "trend",
+		Short:        "Render a per-day trend report from stored scheduled diagnoses",
+		Args:         cobra.NoArgs,
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runScheduleTrend(cmd.OutOrStdout(), store, window, time.Now())
+		},
+	}
+	cmd.Flags().StringVar(&store, "store", "", "Directory written by `podtrace schedule --store`")
+	cmd.Flags().DurationVar(&window, "window", 0, "Only include runs from this far back (e.g. 168h; 0 = all)")
+	_ = cmd.MarkFlagRequired("store")
 	return cmd
 }
 
@@ -105,7 +151,7 @@ func runScheduleTrigger(ctx context.Context, opts scheduleTriggerOptions) error
 	if opts.Kubeconfig != "" {
 		loader.ExplicitPath = opts.Kubeconfig
 	}
-	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &clientcmd.ConfigOverrides{}).ClientConfig()
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, kubernetes.ConfigOverrides()).ClientConfig()
 	if err != nil {
 		return fmt.Errorf("load kubeconfig: %w", err)
 	}
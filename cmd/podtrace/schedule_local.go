@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/hostfs"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// scheduleLoopOptions configures a local `podtrace schedule --every` loop.
+type scheduleLoopOptions struct {
+	Every time.Duration
+	For   time.Duration
+	Store string
+	// Runs stops the loop after this many diagnoses; 0 runs until
+	// interrupted.
+	Runs int
+	// Args are the root-command arguments (target and flags) passed to
+	// every diagnose run.
+	Args []string
+}
+
+// scheduleReservedFlags are set by the loop itself on each child run.
+var scheduleReservedFlags = []string{"--diagnose", "--export", "--trigger", "--dynamic-spawn"}
+
+func (o scheduleLoopOptions) validate() error {
+	if o.Every <= 0 || o.For <= 0 {
+		return errors.New("--every and --for must both be positive")
+	}
+	if o.For >= o.Every {
+		return fmt.Errorf("--for (%s) must be shorter than --every (%s)", o.For, o.Every)
+	}
+	if o.Store == "" {
+		return errors.New("--store is required with --every")
+	}
+	if len(o.Args) == 0 {
+		return errors.New("no target given; pass the pod and its flags after --, e.g. `podtrace schedule --every 1h --for 2m --store ./history -- -n prod my-pod`")
+	}
+	for _, a := range o.Args {
+		for _, r := range scheduleReservedFlags {
+			if a == r || strings.HasPrefix(a, r+"=") {
+				return fmt.Errorf("%s is managed by the schedule loop and cannot be passed through", r)
+			}
+		}
+	}
+	return nil
+}
+
+// diagnoseRunner runs one diagnose with the given root-command arguments and
+// returns its stdout. A var so tests can substitute a fake.
+var diagnoseRunner = func(ctx context.Context, args []string) ([]byte, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locate podtrace binary: %w", err)
+	}
+	var stdout bytes.Buffer
+	c := exec.CommandContext(ctx, exe, args...) // #nosec G204 -- re-executes this binary with operator-supplied arguments.
+	c.Stdout = &stdout
+	c.Stderr = os.Stderr
+	if err := c.Run(); err != nil {
+		return stdout.Bytes(), err
+	}
+	return stdout.Bytes(), nil
+}
+
+// runScheduleLoop runs a short diagnose every opts.Every and stores each JSON
+// export in opts.Store, named by its start time so `schedule trend` can
+// order them. A failed run is logged and skipped; the loop keeps going.
+func runScheduleLoop(ctx context.Context, opts scheduleLoopOptions, now func() time.Time) error {
+	if err := opts.validate(); err != nil {
+		return err
+	}
+	store, err := filepath.Abs(opts.Store)
+	if err != nil {
+		return fmt.Errorf("resolve --store: %w", err)
+	}
+	if err := os.MkdirAll(store, 0o750); err != nil {
+		return fmt.Errorf("create --store directory: %w", err)
+	}
+	args := append(append([]string(nil), opts.Args...), "--diagnose", opts.For.String(), "--export", "json")
+
+	logger.Info("Scheduled diagnose loop started",
+		zap.Duration("every", opts.Every), zap.Duration("for", opts.For), zap.String("store", store))
+	for run := 1; ; run++ {
+		started := now()
+		out, err := diagnoseRunner(ctx, args)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.Warn("Scheduled diagnose run failed; will retry next interval", zap.Int("run", run), zap.Error(err))
+		} else if path, err := storeDiagnoseExport(store, started, out); err != nil {
+			logger.Warn("Could not store scheduled diagnose result", zap.Int("run", run), zap.Error(err))
+		} else {
+			logger.Info("Stored scheduled diagnose result", zap.Int("run", run), zap.String("path", path))
+		}
+		if opts.Runs > 0 && run >= opts.Runs {
+			return nil
+		}
+		wait := opts.Every - now().Sub(started)
+		if wait < 0 {
+			wait = 0
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(wait):
+		}
+	}
+}
+
+// storeDiagnoseExport validates that out carries a JSON export and writes it
+// to <store>/<UTC start time>.json.
+func storeDiagnoseExport(store string, started time.Time, out []byte) (string, error) {
+	data, err := extractExportJSON(out)
+	if err != nil {
+		return "", err
+	}
+	name := started.UTC().Format("20060102T150405Z") + ".json"
+	path := filepath.Join(store, name)
+	return path, hostfs.WriteFileWithin(store, path, data, 0o600)
+}
+
+// extractExportJSON returns the JSON export object from a diagnose run's
+// stdout. When the run was spawned on a node, pod output can precede the
+// export, so the object is located from the first line that opens one.
+func extractExportJSON(out []byte) ([]byte, error) {
+	start := bytes.Index(out, []byte("\n{"))
+	switch {
+	case bytes.HasPrefix(out, []byte("{")):
+		start = 0
+	case start >= 0:
+		start++
+	default:
+		return nil, errors.New("diagnose run produced no JSON export")
+	}
+	var raw json.RawMessage
+	if err := json.NewDecoder(bytes.NewReader(out[start:])).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("decode diagnose export: %w", err)
+	}
+	return raw, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func fakeExport(start time.Time, dnsP95, connFail float64, issues int) string {
+	var iss []string
+	for i := 0; i < issues; i++ {
+		iss = append(iss, fmt.Sprintf("%q", "issue"))
+	}
+	return fmt.Sprintf(`{"summary":{"start_time":%q,"events_per_second":10},"dns":{"p95_ms":%g},"connections":{"p95_ms":3,"failure_rate":%g},"potential_issues":[%s]}`,
+		start.Format(time.RFC3339), dnsP95, connFail, strings.Join(iss, ","))
+}
+
+func TestScheduleLoopOptions_Validate(t *testing.T) {
+	base := scheduleLoopOptions{Every: time.Hour, For: 2 * time.Minute, Store: "h", Args: []string{"pod"}}
+	if err := base.validate(); err != nil {
+		t.Fatalf("valid options rejected: %v", err)
+	}
+	cases := map[string]func(o *scheduleLoopOptions){
+		"for >= every":   func(o *scheduleLoopOptions) { o.For = time.Hour },
+		"missing store":  func(o *scheduleLoopOptions) { o.Store = "" },
+		"no target":      func(o *scheduleLoopOptions) { o.Args = nil },
+		"reserved flag":  func(o *scheduleLoopOptions) { o.Args = []string{"pod", "--diagnose=1m"} },
+		"reserved trig.": func(o *scheduleLoopOptions) { o.Args = []string{"pod", "--trigger", "errors>1"} },
+	}
+	for name, mut := range cases {
+		o := base
+		mut(&o)
+		if err := o.validate(); err == nil {
+			t.Errorf("%s: expected validation error", name)
+		}
+	}
+}
+
+func TestRunScheduleLoop_StoresEachRun(t *testing.T) {
+	store := t.TempDir()
+	orig := diagnoseRunner
+	t.Cleanup(func() { diagnoseRunner = orig })
+
+	clock := time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC)
+	var gotArgs []string
+	calls := 0
+	diagnoseRunner = func(_ context.Context, args []string) ([]byte, error) {
+		calls++
+		gotArgs = args
+		if calls == 2 {
+			return nil, errors.New("pod gone")
+		}
+		return []byte("spawn pod ready\n" + fakeExport(clock, 5, 0, 0) + "\n"), nil
+	}
+	now := func() time.Time {
+		clock = clock.Add(time.Hour)
+		return clock
+	}
+
+	err := runScheduleLoop(context.Background(), scheduleLoopOptions{
+		Every: time.Millisecond * 2, For: time.Millisecond, Store: store, Runs: 3, Args: []string{"-n", "prod", "api"},
+	}, now)
+	if err != nil {
+		t.Fatalf("runScheduleLoop: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("runs = %d, want 3", calls)
+	}
+	if want := "-n prod api --diagnose 1ms --export json"; strings.Join(gotArgs, " ") != want {
+		t.Errorf("child args = %q, want %q", strings.Join(gotArgs, " "), want)
+	}
+	files, _ := filepath.Glob(filepath.Join(store, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("stored %d results, want 2 (the failed run is skipped): %v", len(files), files)
+	}
+	data, _ := os.ReadFile(files[0])
+	if !bytes.HasPrefix(data, []byte(`{"summary"`)) {
+		t.Errorf("stored file should hold only the export JSON, got %q", data)
+	}
+}
+
+func TestExtractExportJSON_NoJSON(t *testing.T) {
+	if _, err := extractExportJSON([]byte("error: pod not found\n")); err == nil {
+		t.Fatal("expected error for output without an export")
+	}
+}
+
+func TestScheduleTrend_FlagsRegression(t *testing.T) {
+	store := t.TempDir()
+	day1 := time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC)
+	runs := []struct {
+		at       time.Time
+		dns      float64
+		connFail float64
+		issues   int
+	}{
+		{day1, 10, 0, 0},
+		{day1.Add(time.Hour), 12, 0, 0},
+		{day1.Add(24 * time.Hour), 11, 0, 0},
+		{day1.Add(48 * time.Hour), 25, 4, 1},
+	}
+	for _, r := range runs {
+		name := filepath.Join(store, r.at.Format("20060102T150405Z")+".json")
+		if err := os.WriteFile(name, []byte(fakeExport(r.at, r.dns, r.connFail, r.issues)), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = os.WriteFile(filepath.Join(store, "garbage.json"), []byte("not json"), 0o600)
+
+	var out bytes.Buffer
+	if err := runScheduleTrend(&out, store, 0, day1.Add(72*time.Hour)); err != nil {
+		t.Fatalf("runScheduleTrend: %v", err)
+	}
+	report := out.String()
+	for _, want := range []string{"2026-03-01", "2026-03-03", "Regressions:", "DNS p95: 11.0ms → 25.0ms (+127%", "connect fail: 0.0% → 4.0% (new"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("trend report missing %q:\n%s", want, report)
+		}
+	}
+	if strings.Contains(report, "events/s:") {
+		t.Errorf("load changes must not be reported as regressions:\n%s", report)
+	}
+
+	out.Reset()
+	if err := runScheduleTrend(&out, store, 12*time.Hour, day1.Add(49*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "2026-03-01") || !strings.Contains(out.String(), "No regressions") {
+		t.Errorf("--window should drop older days:\n%s", out.String())
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+// trendRegressionRatio is how much worse the last day must be than the first
+// before the trend report calls it out.
+const trendRegressionRatio = 1.2
+
+// trendSample is the handful of figures a stored diagnose export contributes
+// to the trend report.
+type trendSample struct {
+	Start        time.Time
+	EventsPerSec float64
+	DNSP95       float64
+	TCPP95       float64
+	ConnectP95   float64
+	ConnectFail  float64
+	FSP95        float64
+	Issues       int
+}
+
+// trendMetric is one column of the trend table.
+type trendMetric struct {
+	Name  string
+	Unit  string
+	Value func(trendSample) float64
+}
+
+var trendMetrics = []trendMetric{
+	{"events/s", "", func(s trendSample) float64 { return s.EventsPerSec }},
+	{"DNS p95", "ms", func(s trendSample) float64 { return s.DNSP95 }},
+	{"TCP p95", "ms", func(s trendSample) float64 { return s.TCPP95 }},
+	{"connect p95", "ms", func(s trendSample) float64 { return s.ConnectP95 }},
+	{"connect fail", "%", func(s trendSample) float64 { return s.ConnectFail }},
+	{"FS p95", "ms", func(s trendSample) float64 { return s.FSP95 }},
+	{"issues", "", func(s trendSample) float64 { return float64(s.Issues) }},
+}
+
+// trendDay aggregates the samples of one UTC day.
+type trendDay struct {
+	Day   string
+	Runs  int
+	Means []float64 // indexed like trendMetrics
+}
+
+// loadTrendSamples reads every stored export in store that started at or
+// after since, oldest first. Unreadable files are skipped.
+func loadTrendSamples(store string, since time.Time) ([]trendSample, error) {
+	paths, err := filepath.Glob(filepath.Join(store, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var samples []trendSample
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			continue
+		}
+		data, err := hostfs.ReadFile(abs)
+		if err != nil {
+			continue
+		}
+		s, ok := parseTrendSample(data)
+		if !ok || s.Start.Before(since) {
+			continue
+		}
+		samples = append(samples, s)
+	}
+	sort.Slice(samples, func(i, j int) bool { return samples[i].Start.Before(samples[j].Start) })
+	return samples, nil
+}
+
+func parseTrendSample(data []byte) (trendSample, bool) {
+	var exp struct {
+		Summary         map[string]interface{} `json:"summary"`
+		DNS             map[string]interface{} `json:"dns"`
+		TCP             map[string]interface{} `json:"tcp"`
+		Connections     map[string]interface{} `json:"connections"`
+		FileSystem      map[string]interface{} `json:"filesystem"`
+		PotentialIssues []string               `json:"potential_issues"`
+	}
+	if err := json.Unmarshal(data, &exp); err != nil || exp.Summary == nil {
+		return trendSample{}, false
+	}
+	startStr, _ := exp.Summary["start_time"].(string)
+	start, err := time.Parse(time.RFC3339, startStr)
+	if err != nil {
+		return trendSample{}, false
+	}
+	num := func(m map[string]interface{}, k string) float64 {
+		v, _ := m[k].(float64)
+		return v
+	}
+	return trendSample{
+		Start:        start,
+		EventsPerSec: num(exp.Summary, "events_per_second"),
+		DNSP95:       num(exp.DNS, "p95_ms"),
+		TCPP95:       num(exp.TCP, "p95_ms"),
+		ConnectP95:   num(exp.Connections, "p95_ms"),
+		ConnectFail:  num(exp.Connections, "failure_rate"),
+		FSP95:        num(exp.FileSystem, "p95_ms"),
+		Issues:       len(exp.PotentialIssues),
+	}, true
+}
+
+// buildTrend groups samples by UTC day and averages each metric.
+func buildTrend(samples []trendSample) []trendDay {
+	var days []trendDay
+	for _, s := range samples {
+		day := s.Start.UTC().Format("2006-01-02")
+		if len(days) == 0 || days[len(days)-1].Day != day {
+			days = append(days, trendDay{Day: day, Means: make([]float64, len(trendMetrics))})
+		}
+		d := &days[len(days)-1]
+		d.Runs++
+		for i, m := range trendMetrics {
+			// Incremental mean keeps a single pass over the samples.
+			d.Means[i] += (m.Value(s) - d.Means[i]) / float64(d.Runs)
+		}
+	}
+	return days
+}
+
+// renderTrend writes the per-day table followed by any metric that got
+// markedly worse between the first and last day.
+func renderTrend(w io.Writer, days []trendDay) {
+	if len(days) == 0 {
+		_, _ = fmt.Fprintln(w, "No stored diagnose runs found.")
+		return
+	}
+	var b strings.Builder
+	b.WriteString("=== Diagnose Trend ===\n\n")
+	fmt.Fprintf(&b, "%-10s %5s", "day", "runs")
+	for _, m := range trendMetrics {
+		fmt.Fprintf(&b, " %12s", m.Name)
+	}
+	b.WriteString("\n")
+	for _, d := range days {
+		fmt.Fprintf(&b, "%-10s %5d", d.Day, d.Runs)
+		for i, m := range trendMetrics {
+			fmt.Fprintf(&b, " %12s", fmt.Sprintf("%.1f%s", d.Means[i], m.Unit))
+		}
+		b.WriteString("\n")
+	}
+
+	first, last := days[0], days[len(days)-1]
+	var regressions []string
+	if len(days) > 1 {
+		for i, m := range trendMetrics {
+			// events/s is load, not health; a change there is not a regression.
+			if i == 0 {
+				continue
+			}
+			from, to := first.Means[i], last.Means[i]
+			if to > 0 && to > from*trendRegressionRatio && to-from >= 1 {
+				change := "new"
+				if from > 0 {
+					change = fmt.Sprintf("+%.0f%%", (to-from)/from*100)
+				}
+				regressions = append(regressions, fmt.Sprintf("  • %s: %.1f%s → %.1f%s (%s, %s → %s)",
+					m.Name, from, m.Unit, to, m.Unit, change, first.Day, last.Day))
+			}
+		}
+	}
+	b.WriteString("\n")
+	if len(regressions) == 0 {
+		b.WriteString("No regressions between the first and last day.\n")
+	} else {
+		b.WriteString("Regressions:\n")
+		b.WriteString(strings.Join(regressions, "\n"))
+		b.WriteString("\n")
+	}
+	_, _ = io.WriteString(w, b.String())
+}
+
+// runScheduleTrend loads the store and prints the trend report to w.
+func runScheduleTrend(w io.Writer, store string, window time.Duration, now time.Time) error {
+	abs, err := filepath.Abs(store)
+	if err != nil {
+		return fmt.Errorf("resolve --store: %w", err)
+	}
+	if _, err := os.Stat(abs); err != nil {
+		return fmt.Errorf("read --store: %w", err)
+	}
+	var since time.Time
+	if window > 0 {
+		since = now.Add(-window)
+	}
+	samples, err := loadTrendSamples(abs, since)
+	if err != nil {
+		return err
+	}
+	renderTrend(w, buildTrend(samples))
+	return nil
+}
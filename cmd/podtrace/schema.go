@@ -0,0 +1,36 @@
+package main
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+// schemaProto is the schema command's argument for the binary format.
+const schemaProto = "proto"
+
+func newSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema <event|report|proto>",
+		Short: "Print the schema of exported events or reports",
+		Long: `Prints the JSON Schema (draft 2020-12) of the current export format: "event"
+for the lines of capture files (--trigger-record, a bundle's capture.jsonl),
+"report" for --export json. "proto" prints podtrace.proto, the definition of
+--export proto and of .pb trigger recordings. Every export carries its schema
+version; see docs/export-schema.md for what may change between versions.`,
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: []string{schema.KindEvent, schema.KindReport, schemaProto},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if args[0] == schemaProto {
+				_, err := cmd.OutOrStdout().Write(schema.ProtoDefinition())
+				return err
+			}
+			data, err := schema.JSONSchema(args[0])
+			if err != nil {
+				return err
+			}
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+}
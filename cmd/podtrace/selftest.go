@@ -0,0 +1,436 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/diagnose/report"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// The selftest workload resolves selftestName through a responder on
+// selftestDNSAddr, which answers every A query with 127.0.0.1: loopback
+// port 53 is what the DNS probe matches, on an address no local resolver
+// listens on.
+const (
+	selftestName    = "selftest.podtrace.test"
+	selftestDNSAddr = "127.0.0.42:53"
+	// selftestRefused is how many connects the workload makes to a closed
+	// port, enough for the connect_failures rule to fire.
+	selftestRefused = 10
+	selftestFileMiB = 16
+)
+
+type selftestOptions struct {
+	timeout time.Duration
+	report  bool
+
+	// The workload's side, set by the parent when it re-executes itself.
+	workload    bool
+	tcpAddr     string
+	refusedAddr string
+	dir         string
+}
+
+func newSelftestCmd() *cobra.Command {
+	var opts selftestOptions
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Trace a local workload with known DNS, TCP and file patterns and check podtrace sees them",
+		Long: `Validates an installation on this node and kernel before it is trusted in an
+incident. selftest creates a test cgroup, attaches the BPF programs to it and
+runs a small workload in it that resolves a name, connects to a local
+listener and exchanges data, connects to a closed port, and writes, fsyncs
+and reads back a file. The events go through the same diagnose pipeline as
+a trace, and selftest checks that the expected events and the
+connect-failure issue (PODTRACE-NET-001) came out of it. It exits non-zero
+when a check fails. Needs root and cgroup v2.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.workload {
+				return runSelftestWorkload(cmd.Context(), opts)
+			}
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGTERM)
+			defer stop()
+			return runSelftest(ctx, cmd.OutOrStdout(), opts)
+		},
+	}
+	cmd.Flags().DurationVar(&opts.timeout, "timeout", 30*time.Second, "Give up on the workload after this long")
+	cmd.Flags().BoolVar(&opts.report, "report", false, "Also print the diagnose report of the workload")
+	cmd.Flags().BoolVar(&opts.workload, "workload", false, "internal: run as the selftest workload")
+	cmd.Flags().StringVar(&opts.tcpAddr, "workload-tcp", "", "internal: listener the workload connects to")
+	cmd.Flags().StringVar(&opts.refusedAddr, "workload-refused", "", "internal: closed port the workload connects to")
+	cmd.Flags().StringVar(&opts.dir, "workload-dir", "", "internal: directory the workload writes its file in")
+	for _, name := range []string{"workload", "workload-tcp", "workload-refused", "workload-dir"} {
+		_ = cmd.Flags().MarkHidden(name)
+	}
+	return cmd
+}
+
+// selftestExpect is what the workload was told to do, for the checks.
+type selftestExpect struct {
+	tcpAddr     string
+	refusedAddr string
+	dir         string
+}
+
+// selftestCheck is the outcome of one check.
+type selftestCheck struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+func runSelftest(ctx context.Context, out io.Writer, opts selftestOptions) error {
+	if _, err := os.Stat(filepath.Join(config.CgroupBasePath, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("selftest needs cgroup v2 at %s: %w", config.CgroupBasePath, err)
+	}
+	cgroupPath := filepath.Join(config.CgroupBasePath, fmt.Sprintf("podtrace-selftest-%d", os.Getpid()))
+	if err := os.Mkdir(cgroupPath, 0o755); err != nil {
+		return fmt.Errorf("create test cgroup: %w", err)
+	}
+	defer func() {
+		if err := os.Remove(cgroupPath); err != nil {
+			logger.Warn("Failed to remove the test cgroup", zap.String("cgroup", cgroupPath), zap.Error(err))
+		}
+	}()
+	dir, err := os.MkdirTemp("", "podtrace-selftest-")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	listener, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		return fmt.Errorf("selftest listener: %w", err)
+	}
+	defer func() { _ = listener.Close() }()
+	go serveSelftestEcho(listener)
+	refused, err := closedPort()
+	if err != nil {
+		return err
+	}
+	dns, err := net.ListenPacket("udp4", selftestDNSAddr)
+	if err != nil {
+		return fmt.Errorf("selftest DNS responder on %s: %w", selftestDNSAddr, err)
+	}
+	defer func() { _ = dns.Close() }()
+	go serveSelftestDNS(dns)
+
+	tracer, err := newSessionTracer()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tracer.Stop() }()
+	if err := tracer.SetCgroups([]string{cgroupPath}); err != nil {
+		return fmt.Errorf("attach to the test cgroup: %w", err)
+	}
+	traceCtx, stopTrace := context.WithCancel(ctx)
+	defer stopTrace()
+	eventChan := make(chan *events.Event, config.EventChannelBufferSize)
+	if err := tracer.Start(traceCtx, eventChan); err != nil {
+		return fmt.Errorf("start tracer: %w", err)
+	}
+	d := diagnose.NewDiagnosticianWithThresholds(errorRateThreshold, rttSpikeThreshold, fsSlowThreshold)
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for {
+			select {
+			case <-traceCtx.Done():
+				return
+			case e := <-eventChan:
+				if e != nil {
+					d.AddEvent(e)
+				}
+			}
+		}
+	}()
+
+	expect := selftestExpect{tcpAddr: listener.Addr().String(), refusedAddr: refused, dir: dir}
+	if err := runSelftestChild(ctx, cgroupPath, expect, opts.timeout); err != nil {
+		return err
+	}
+	// Give the ring buffer time to drain before the checks.
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(2 * time.Second):
+	}
+	stopTrace()
+	<-collected
+	d.Finish()
+
+	checks := selftestChecks(d.GetEvents(), report.DetectIssues(d), expect)
+	_, _ = fmt.Fprintf(out, "podtrace selftest in %s:\n", cgroupPath)
+	failed := 0
+	for _, c := range checks {
+		status := "PASS"
+		if !c.OK {
+			status = "FAIL"
+			failed++
+		}
+		_, _ = fmt.Fprintf(out, "  %s  %s: %s\n", status, c.Name, c.Detail)
+	}
+	if opts.report {
+		_, _ = fmt.Fprintf(out, "\n%s", d.GenerateReport())
+	}
+	if failed > 0 {
+		return fmt.Errorf("selftest failed %d of %d checks", failed, len(checks))
+	}
+	_, _ = fmt.Fprintf(out, "All %d checks passed.\n", len(checks))
+	return nil
+}
+
+// runSelftestChild re-executes podtrace as the workload, moves it into the
+// test cgroup before letting it start, and waits for it.
+func runSelftestChild(ctx context.Context, cgroupPath string, expect selftestExpect, timeout time.Duration) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	child := exec.CommandContext(ctx, self, "selftest", "--workload", // #nosec G204 -- re-executes this binary
+		"--workload-tcp", expect.tcpAddr, "--workload-refused", expect.refusedAddr, "--workload-dir", expect.dir)
+	child.Stdout, child.Stderr = os.Stderr, os.Stderr
+	start, err := child.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("start selftest workload: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(cgroupPath, "cgroup.procs"), []byte(strconv.Itoa(child.Process.Pid)), 0o644); err != nil { // #nosec G306 -- cgroupfs
+		_ = child.Process.Kill()
+		_ = child.Wait()
+		return fmt.Errorf("move the workload into the test cgroup: %w", err)
+	}
+	_, _ = io.WriteString(start, "go\n")
+	_ = start.Close()
+	if err := child.Wait(); err != nil {
+		return fmt.Errorf("selftest workload: %w", err)
+	}
+	return nil
+}
+
+// runSelftestWorkload waits for the parent's go-ahead on stdin, then makes
+// the DNS, TCP and file operations the checks look for.
+func runSelftestWorkload(ctx context.Context, opts selftestOptions) error {
+	if _, err := bufio.NewReader(os.Stdin).ReadString('\n'); err != nil {
+		return fmt.Errorf("wait for the test cgroup: %w", err)
+	}
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "udp4", selftestDNSAddr)
+		},
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := resolver.LookupIP(ctx, "ip4", selftestName); err != nil {
+			return fmt.Errorf("resolve %s: %w", selftestName, err)
+		}
+	}
+
+	conn, err := net.DialTimeout("tcp4", opts.tcpAddr, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", opts.tcpAddr, err)
+	}
+	payload := make([]byte, 256<<10)
+	if _, err := conn.Write(payload); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	_ = conn.(*net.TCPConn).CloseWrite()
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	_ = conn.Close()
+	for i := 0; i < selftestRefused; i++ {
+		if c, err := net.DialTimeout("tcp4", opts.refusedAddr, time.Second); err == nil {
+			_ = c.Close()
+		}
+	}
+
+	path := filepath.Join(opts.dir, "data")
+	f, err := os.Create(path) // #nosec G304 -- the parent's temporary directory
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(make([]byte, selftestFileMiB<<20)); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path) // #nosec G304 -- the file written above
+	if err != nil {
+		return err
+	}
+	if len(data) != selftestFileMiB<<20 {
+		return fmt.Errorf("read back %d bytes of %s", len(data), path)
+	}
+	return nil
+}
+
+// selftestChecks compares what the pipeline produced with what the
+// workload did.
+func selftestChecks(evs []*events.Event, issues []detector.Issue, expect selftestExpect) []selftestCheck {
+	var lookups, connects, refused, tcpData, fsOps int
+	// Paths are recorded from the dentry and may miss the leading
+	// components, and peers may be named, so both match loosely.
+	dirName := filepath.Base(expect.dir)
+	for _, e := range evs {
+		if e == nil {
+			continue
+		}
+		switch e.Type {
+		case events.EventDNS:
+			if strings.Contains(e.Target, selftestName) {
+				lookups++
+			}
+		case events.EventConnect:
+			switch {
+			case samePort(e.Target, expect.tcpAddr) && e.Error == 0:
+				connects++
+			case samePort(e.Target, expect.refusedAddr) && e.Error != 0:
+				refused++
+			}
+		case events.EventTCPSend, events.EventTCPRecv:
+			tcpData++
+		case events.EventWrite, events.EventRead, events.EventFsync, events.EventOpen:
+			if strings.Contains(e.Target, dirName) {
+				fsOps++
+			}
+		}
+	}
+	code := detector.RuleCodes["connect_failures"]
+	var issue *detector.Issue
+	for i := range issues {
+		if issues[i].Code == code {
+			issue = &issues[i]
+			break
+		}
+	}
+	checks := []selftestCheck{
+		{Name: "dns", OK: lookups > 0, Detail: fmt.Sprintf("%d lookups of %s seen", lookups, selftestName)},
+		{Name: "tcp connect", OK: connects > 0, Detail: fmt.Sprintf("%d connects to %s seen", connects, expect.tcpAddr)},
+		{Name: "tcp errors", OK: refused > 0, Detail: fmt.Sprintf("%d of %d refused connects to %s seen", refused, selftestRefused, expect.refusedAddr)},
+		{Name: "tcp data", OK: tcpData > 0, Detail: fmt.Sprintf("%d sends and receives seen", tcpData)},
+		{Name: "fs", OK: fsOps > 0, Detail: fmt.Sprintf("%d opens, writes, fsyncs and reads under %s seen", fsOps, expect.dir)},
+	}
+	if issue != nil {
+		checks = append(checks, selftestCheck{Name: "issues", OK: true, Detail: fmt.Sprintf("%s raised: %s", code, issue.Message)})
+	} else {
+		checks = append(checks, selftestCheck{Name: "issues", Detail: fmt.Sprintf("%s not raised", code)})
+	}
+	return checks
+}
+
+// samePort reports whether target is a connect to the port of addr.
+func samePort(target, addr string) bool {
+	i, j := strings.LastIndexByte(target, ':'), strings.LastIndexByte(addr, ':')
+	return i >= 0 && j >= 0 && target[i:] == addr[j:]
+}
+
+// serveSelftestEcho sends back what each connection sends until it is
+// closed.
+func serveSelftestEcho(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer func() { _ = conn.Close() }()
+			_, _ = io.Copy(conn, conn)
+		}()
+	}
+}
+
+// closedPort returns a loopback address nothing listens on, by listening
+// on a free port and closing it.
+func closedPort() (string, error) {
+	l, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+	addr := l.Addr().String()
+	return addr, l.Close()
+}
+
+// serveSelftestDNS answers every query on pc until it is closed.
+func serveSelftestDNS(pc net.PacketConn) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := pc.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if resp, err := selftestDNSAnswer(buf[:n]); err == nil {
+			_, _ = pc.WriteTo(resp, addr)
+		}
+	}
+}
+
+// selftestDNSAnswer builds the response to a single-question query: an A
+// record of 127.0.0.1 for an A question, no answer for any other type.
+func selftestDNSAnswer(query []byte) ([]byte, error) {
+	if len(query) < 12 || binary.BigEndian.Uint16(query[4:6]) != 1 {
+		return nil, errors.New("not a single-question query")
+	}
+	end := 12
+	for end < len(query) && query[end] != 0 {
+		end += int(query[end]) + 1
+	}
+	end += 5 // root label, type, class
+	if end > len(query) {
+		return nil, errors.New("truncated question")
+	}
+	qtype := binary.BigEndian.Uint16(query[end-4 : end-2])
+
+	resp := append([]byte(nil), query[:end]...)
+	resp[2] = 0x80 | query[2]&0x01 // QR, keep RD
+	resp[3] = 0x80                 // RA, NOERROR
+	binary.BigEndian.PutUint16(resp[8:10], 0)
+	binary.BigEndian.PutUint16(resp[10:12], 0)
+	if qtype != 1 {
+		binary.BigEndian.PutUint16(resp[6:8], 0)
+		return resp, nil
+	}
+	binary.BigEndian.PutUint16(resp[6:8], 1)
+	resp = append(resp,
+		0xc0, 0x0c, // name: pointer to the question
+		0x00, 0x01, // type A
+		0x00, 0x01, // class IN
+		0x00, 0x00, 0x00, 0x3c, // TTL 60s
+		0x00, 0x04, 127, 0, 0, 1)
+	return resp, nil
+}
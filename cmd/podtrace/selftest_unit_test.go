@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestSelftestDNSResponder(t *testing.T) {
+	pc, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = pc.Close() }()
+	go serveSelftestDNS(pc)
+
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "udp4", pc.LocalAddr().String())
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ips, err := resolver.LookupIP(ctx, "ip4", selftestName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ips) != 1 || !ips[0].Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("ips = %v", ips)
+	}
+	if _, err := selftestDNSAnswer([]byte{0, 1, 1, 0, 0, 1, 0, 0, 0, 0, 0, 0, 8, 's'}); err == nil {
+		t.Error("expected an error for a truncated question")
+	}
+}
+
+func TestSelftestChecks(t *testing.T) {
+	expect := selftestExpect{tcpAddr: "127.0.0.1:4000", refusedAddr: "127.0.0.1:4001", dir: "/tmp/podtrace-selftest-123"}
+	evs := []*events.Event{
+		{Type: events.EventDNS, Target: selftestName},
+		{Type: events.EventConnect, Target: "127.0.0.1:4000"},
+		{Type: events.EventTCPSend, Target: "127.0.0.1:4000"},
+		{Type: events.EventFsync, Target: "podtrace-selftest-123/data"},
+	}
+	for i := 0; i < selftestRefused; i++ {
+		evs = append(evs, &events.Event{Type: events.EventConnect, Target: "127.0.0.1:4001", Error: -111})
+	}
+	checks := selftestChecks(evs, detector.ScoreIssues(evs, 10, 100), expect)
+	if len(checks) != 6 {
+		t.Fatalf("checks = %+v", checks)
+	}
+	for _, c := range checks {
+		if !c.OK {
+			t.Errorf("%s failed: %s", c.Name, c.Detail)
+		}
+	}
+
+	// Nothing traced: every check fails.
+	for _, c := range selftestChecks(nil, nil, expect) {
+		if c.OK {
+			t.Errorf("%s passed without events: %s", c.Name, c.Detail)
+		}
+		if c.Name == "issues" && !strings.Contains(c.Detail, "PODTRACE-NET-001 not raised") {
+			t.Errorf("issues detail = %q", c.Detail)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/diagnose/slo"
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+var (
+	sloFile   string
+	sloInline string
+	failOn    []string
+
+	// sloDefinitions is the raw --slo file, forwarded to spawned pods as
+	// --slo-inline since the workstation path does not exist there.
+	sloDefinitions string
+	loadedSLOs     []slo.SLO
+)
+
+// failOnSLO is the --fail-on value that turns a violated SLO into a non-zero
+// exit.
+const failOnSLO = "slo"
+
+// loadSLOFlags validates --slo/--slo-inline and --fail-on, and loads the
+// objectives the diagnose report evaluates.
+func loadSLOFlags() error {
+	for _, v := range failOn {
+		if strings.TrimSpace(strings.ToLower(v)) != failOnSLO {
+			return fmt.Errorf("invalid --fail-on value %q (supported: %s)", v, failOnSLO)
+		}
+	}
+	var data []byte
+	switch {
+	case sloFile != "" && sloInline != "":
+		return fmt.Errorf("--slo and --slo-inline are mutually exclusive")
+	case sloFile != "":
+		abs, err := filepath.Abs(sloFile)
+		if err != nil {
+			return fmt.Errorf("invalid --slo path: %w", err)
+		}
+		if data, err = hostfs.ReadFile(abs); err != nil {
+			return fmt.Errorf("read SLO definitions: %w", err)
+		}
+	case sloInline != "":
+		data = []byte(sloInline)
+	default:
+		if len(failOn) > 0 {
+			return fmt.Errorf("--fail-on %s requires --slo", failOnSLO)
+		}
+		return nil
+	}
+	slos, err := slo.Parse(data)
+	if err != nil {
+		return err
+	}
+	if len(failOn) > 0 && diagnoseDuration == "" {
+		return fmt.Errorf("--fail-on requires --diagnose")
+	}
+	sloDefinitions = string(data)
+	loadedSLOs = slos
+	return nil
+}
+
+// sloFailure returns the error that makes podtrace exit non-zero when
+// --fail-on slo is set and an objective was violated.
+func sloFailure(results []slo.Result) error {
+	if len(failOn) == 0 {
+		return nil
+	}
+	if n := slo.Failed(results); n > 0 {
+		return fmt.Errorf("%d of %d SLOs failed", n, len(results))
+	}
+	return nil
+}
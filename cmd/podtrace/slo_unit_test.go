@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/podtrace/podtrace/internal/diagnose/slo"
+	"github.com/podtrace/podtrace/internal/kubernetes/nodespawn"
+)
+
+func restoreSLOFlagGlobals(t *testing.T) {
+	t.Helper()
+	origFile, origInline, origFailOn, origDuration := sloFile, sloInline, failOn, diagnoseDuration
+	origDefs, origLoaded := sloDefinitions, loadedSLOs
+	t.Cleanup(func() {
+		sloFile, sloInline, failOn, diagnoseDuration = origFile, origInline, origFailOn, origDuration
+		sloDefinitions, loadedSLOs = origDefs, origLoaded
+	})
+}
+
+const testSLOs = "slos:\n  - name: orders\n    target: \"GET /orders*\"\n    p99: 100ms\n"
+
+func TestLoadSLOFlags(t *testing.T) {
+	restoreSLOFlagGlobals(t)
+	path := filepath.Join(t.TempDir(), "slo.yaml")
+	if err := os.WriteFile(path, []byte(testSLOs), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	sloFile, sloInline, failOn, diagnoseDuration = path, "", []string{"slo"}, "30s"
+	if err := loadSLOFlags(); err != nil {
+		t.Fatalf("loadSLOFlags: %v", err)
+	}
+	if len(loadedSLOs) != 1 || loadedSLOs[0].Name != "orders" || sloDefinitions != testSLOs {
+		t.Errorf("loaded = %+v, definitions = %q", loadedSLOs, sloDefinitions)
+	}
+
+	sloFile, sloInline = "", testSLOs
+	if err := loadSLOFlags(); err != nil || len(loadedSLOs) != 1 {
+		t.Errorf("--slo-inline: err = %v, loaded = %+v", err, loadedSLOs)
+	}
+
+	for name, set := range map[string]func(){
+		"bad fail-on":         func() { sloFile, sloInline, failOn = path, "", []string{"issues"} },
+		"fail-on without slo": func() { sloFile, sloInline, failOn = "", "", []string{"slo"} },
+		"fail-on without diagnose": func() {
+			sloFile, sloInline, failOn, diagnoseDuration = path, "", []string{"slo"}, ""
+		},
+		"both sources":  func() { sloFile, sloInline, failOn = path, testSLOs, nil },
+		"missing file":  func() { sloFile, sloInline, failOn = path+".missing", "", nil },
+		"invalid input": func() { sloFile, sloInline, failOn = "", "slos: []", nil },
+	} {
+		diagnoseDuration = "30s"
+		set()
+		if err := loadSLOFlags(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestSLOFailure(t *testing.T) {
+	restoreSLOFlagGlobals(t)
+	results := []slo.Result{{Status: slo.StatusPass}, {Status: slo.StatusFail}, {Status: slo.StatusNoData}}
+
+	failOn = nil
+	if err := sloFailure(results); err != nil {
+		t.Errorf("without --fail-on: %v", err)
+	}
+	failOn = []string{"slo"}
+	if err := sloFailure(results); err == nil || !strings.Contains(err.Error(), "1 of 3 SLOs failed") {
+		t.Errorf("with --fail-on slo: %v", err)
+	}
+	if err := sloFailure(results[:1]); err != nil {
+		t.Errorf("all passing: %v", err)
+	}
+}
+
+func TestChildArgsForwardSLODefinitions(t *testing.T) {
+	restoreSLOFlagGlobals(t)
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&sloFile, "slo", "", "")
+	if err := cmd.Flags().Set("slo", "/home/me/slo.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	sloDefinitions = testSLOs
+
+	args := newChildArgsBuilder(cmd, false)("node-a", []nodespawn.PodRef{})
+	if !contains(args, "--slo-inline="+testSLOs) {
+		t.Errorf("expected SLO definitions forwarded inline, got %v", args)
+	}
+	if strings.Contains(strings.Join(args, " "), "--slo=") {
+		t.Errorf("workstation --slo path must not reach the spawn pod, got %v", args)
+	}
+}
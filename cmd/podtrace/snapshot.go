@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// snapshotSignal returns the channel SIGUSR2 is delivered on, which asks a
+// running trace to write a snapshot, and the function that stops delivery.
+func snapshotSignal() (<-chan os.Signal, func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGUSR2)
+	return ch, func() { signal.Stop(ch) }
+}
+
+// snapshotPath names the snapshot taken at now, in PODTRACE_SNAPSHOT_DIR or
+// the working directory.
+func snapshotPath(now time.Time) string {
+	return filepath.Join(config.SnapshotDir, "podtrace-snapshot-"+now.UTC().Format("20060102T150405.000Z")+".json")
+}
+
+// writeSnapshot writes the JSON export of what d has seen so far without
+// stopping the trace, so the state of a long capture can be shared while it
+// runs.
+func writeSnapshot(d *diagnose.Diagnostician, now time.Time) (string, error) {
+	d.Finish()
+	data, err := json.MarshalIndent(d.ExportJSON(), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := snapshotPath(now)
+	if err := writeArtifactFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// handleSnapshotSignal writes a snapshot and logs where it went. Failures
+// are logged: the trace goes on either way.
+func handleSnapshotSignal(d *diagnose.Diagnostician) {
+	path, err := writeSnapshot(d, time.Now())
+	if err != nil {
+		logger.Warn("Failed to write session snapshot", zap.Error(err))
+		return
+	}
+	logger.Info("Wrote session snapshot", zap.String("path", path), zap.Int("events", len(d.GetEvents())))
+}
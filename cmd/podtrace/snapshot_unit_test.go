@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestWriteSnapshot_WritesExportAndKeepsTracing(t *testing.T) {
+	dir := t.TempDir()
+	orig := config.SnapshotDir
+	config.SnapshotDir = dir
+	t.Cleanup(func() { config.SnapshotDir = orig })
+
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	d.AddEvent(&events.Event{Type: events.EventDNS, Target: "example.com", LatencyNS: 1_000_000})
+	now := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	path, err := writeSnapshot(d, now)
+	if err != nil {
+		t.Fatalf("writeSnapshot: %v", err)
+	}
+	if want := filepath.Join(dir, "podtrace-snapshot-20260304T050607.000Z.json"); path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data diagnose.ExportData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("snapshot is not an export: %v", err)
+	}
+	if data.Summary["total_events"] != float64(1) {
+		t.Errorf("unexpected summary: %v", data.Summary)
+	}
+
+	d.AddEvent(&events.Event{Type: events.EventDNS, Target: "example.com"})
+	if got := len(d.GetEvents()); got != 2 {
+		t.Errorf("diagnostician stopped collecting after the snapshot: %d events", got)
+	}
+}
+
+func TestSnapshotSignal_DeliversSIGUSR2(t *testing.T) {
+	ch, stop := snapshotSignal()
+	defer stop()
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-ch:
+	case <-time.After(2 * time.Second):
+		t.Fatal("SIGUSR2 was not delivered")
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/pin"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+// statePinDir returns where PODTRACE_PIN_STATE pins the BPF state of a
+// trace: under the UID of the first target pod, read from its cgroup path,
+// or "" when no target has one. The state of pods that left the node is
+// removed on the way.
+func statePinDir(targets []*kubernetes.PodInfo) string {
+	if reaped := pin.ReapState(config.PinDir, resource.NodePodUIDs()); len(reaped) > 0 {
+		logger.Info("Removed pinned BPF state of deleted pods", zap.Strings("pod_uids", reaped))
+	}
+	for _, p := range targets {
+		uid := resource.PodUIDFromCgroup(p.CgroupPath)
+		if uid == "" {
+			continue
+		}
+		if dir, err := pin.StateDir(config.PinDir, uid); err == nil {
+			return dir
+		}
+	}
+	logger.Warn("PODTRACE_PIN_STATE is set but no target pod UID was found in its cgroup path; BPF state is not pinned")
+	return ""
+}
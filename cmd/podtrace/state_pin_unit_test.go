@@ -0,0 +1,28 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+func TestStatePinDir(t *testing.T) {
+	origPin, origCgroup := config.PinDir, config.CgroupBasePath
+	t.Cleanup(func() { config.PinDir, config.CgroupBasePath = origPin, origCgroup })
+	config.PinDir = t.TempDir()
+	config.CgroupBasePath = t.TempDir()
+
+	targets := []*kubernetes.PodInfo{
+		{PodName: "host", CgroupPath: "/sys/fs/cgroup/system.slice/foo.service"},
+		{PodName: "api", CgroupPath: "/sys/fs/cgroup/kubepods.slice/kubepods-pod11111111_2222_3333_4444_555555555555.slice/cri-containerd-abc.scope"},
+	}
+	want := filepath.Join(config.PinDir, "11111111-2222-3333-4444-555555555555")
+	if got := statePinDir(targets); got != want {
+		t.Errorf("statePinDir = %q, want %q", got, want)
+	}
+	if got := statePinDir(targets[:1]); got != "" {
+		t.Errorf("statePinDir without a pod cgroup = %q, want \"\"", got)
+	}
+}
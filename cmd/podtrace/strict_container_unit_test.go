@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+func TestRunPodtrace_StrictRejectsGuessedContainer(t *testing.T) {
+	saveRunPodtraceGlobals(t)
+	resetRunPodtraceGlobals()
+	strictContainer = true
+
+	resolverFactory = func() (kubernetes.PodResolverInterface, error) {
+		return &mockPodResolver{resolvePodFunc: func(_ context.Context, podName, ns, _ string) (*kubernetes.PodInfo, error) {
+			return &kubernetes.PodInfo{
+				PodName:   podName,
+				Namespace: ns,
+				MainContainer: kubernetes.ContainerChoice{
+					Name:       "api",
+					Reason:     "only container that is not a known sidecar",
+					Candidates: []string{"istio-proxy", "api"},
+					Guessed:    true,
+				},
+			}, nil
+		}}, nil
+	}
+
+	err := runPodtrace(cmdWithNamespaceChanged(), []string{"web-0"})
+	if err == nil || !strings.Contains(err.Error(), "pass --container") {
+		t.Fatalf("expected --strict to reject a guessed container, got %v", err)
+	}
+}
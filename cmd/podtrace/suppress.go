@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/suppress"
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+var (
+	suppressFile   string
+	suppressInline string
+	noSuppress     bool
+
+	// suppressDefinitions is the raw --suppress file, forwarded to spawned
+	// pods as --suppress-inline since the workstation path does not exist
+	// there.
+	suppressDefinitions string
+	loadedSuppressRules []suppress.Rule
+)
+
+// loadSuppressFlags builds the suppression rules: the built-in ones unless
+// --no-suppress, then those of --suppress/--suppress-inline.
+func loadSuppressFlags() error {
+	var rules []suppress.Rule
+	if !noSuppress {
+		rules = suppress.Builtin()
+	}
+	var data []byte
+	switch {
+	case suppressFile != "" && suppressInline != "":
+		return fmt.Errorf("--suppress and --suppress-inline are mutually exclusive")
+	case suppressFile != "":
+		abs, err := filepath.Abs(suppressFile)
+		if err != nil {
+			return fmt.Errorf("invalid --suppress path: %w", err)
+		}
+		if data, err = hostfs.ReadFile(abs); err != nil {
+			return fmt.Errorf("read suppression rules: %w", err)
+		}
+	case suppressInline != "":
+		data = []byte(suppressInline)
+	}
+	if data != nil {
+		user, err := suppress.Parse(data)
+		if err != nil {
+			return err
+		}
+		rules = append(rules, user...)
+		suppressDefinitions = string(data)
+	}
+	loadedSuppressRules = rules
+	return nil
+}
+
+// setSuppression hands d the rules loadSuppressFlags built.
+func setSuppression(d *diagnose.Diagnostician) {
+	d.SetSuppression(loadedSuppressRules)
+}
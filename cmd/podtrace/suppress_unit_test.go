@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/podtrace/podtrace/internal/kubernetes/nodespawn"
+)
+
+func restoreSuppressFlagGlobals(t *testing.T) {
+	t.Helper()
+	origFile, origInline, origNo := suppressFile, suppressInline, noSuppress
+	origDefs, origLoaded := suppressDefinitions, loadedSuppressRules
+	t.Cleanup(func() {
+		suppressFile, suppressInline, noSuppress = origFile, origInline, origNo
+		suppressDefinitions, loadedSuppressRules = origDefs, origLoaded
+	})
+}
+
+const testSuppress = "suppress:\n  - name: readiness\n    type: HTTP\n    target: \"GET /healthz*\"\n"
+
+func TestLoadSuppressFlags(t *testing.T) {
+	restoreSuppressFlagGlobals(t)
+	path := filepath.Join(t.TempDir(), "suppress.yaml")
+	if err := os.WriteFile(path, []byte(testSuppress), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	suppressFile, suppressInline, noSuppress = "", "", false
+	if err := loadSuppressFlags(); err != nil || len(loadedSuppressRules) == 0 {
+		t.Fatalf("defaults: err = %v, rules = %+v; want the built-in rules", err, loadedSuppressRules)
+	}
+	builtin := len(loadedSuppressRules)
+
+	suppressFile = path
+	if err := loadSuppressFlags(); err != nil {
+		t.Fatalf("loadSuppressFlags: %v", err)
+	}
+	if len(loadedSuppressRules) != builtin+1 || loadedSuppressRules[builtin].Name != "readiness" || suppressDefinitions != testSuppress {
+		t.Errorf("rules = %+v, definitions = %q", loadedSuppressRules, suppressDefinitions)
+	}
+
+	suppressFile, suppressInline, noSuppress = "", testSuppress, true
+	if err := loadSuppressFlags(); err != nil || len(loadedSuppressRules) != 1 {
+		t.Errorf("--no-suppress with --suppress-inline: err = %v, rules = %+v; want only the user rule", err, loadedSuppressRules)
+	}
+
+	for name, set := range map[string]func(){
+		"both sources":  func() { suppressFile, suppressInline = path, testSuppress },
+		"missing file":  func() { suppressFile, suppressInline = path+".missing", "" },
+		"invalid input": func() { suppressFile, suppressInline = "", "suppress: [{name: empty}]" },
+	} {
+		set()
+		if err := loadSuppressFlags(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestChildArgsForwardSuppressionRules(t *testing.T) {
+	restoreSuppressFlagGlobals(t)
+	cmd := &cobra.Command{}
+	cmd.Flags().StringVar(&suppressFile, "suppress", "", "")
+	if err := cmd.Flags().Set("suppress", "/home/me/suppress.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	suppressDefinitions = testSuppress
+
+	args := newChildArgsBuilder(cmd, false)("node-a", []nodespawn.PodRef{})
+	if !contains(args, "--suppress-inline="+testSuppress) {
+		t.Errorf("expected suppression rules forwarded inline, got %v", args)
+	}
+	if strings.Contains(strings.Join(args, " "), "--suppress=") {
+		t.Errorf("workstation --suppress path must not reach the spawn pod, got %v", args)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/syslogsink"
+)
+
+// syslogURL is --syslog.
+var syslogURL string
+
+// syslogOut is the open --syslog sink, nil without the flag.
+var syslogOut *syslogsink.Sink
+
+// startSyslog connects to the --syslog receiver before the issue hooks
+// start, so they register it. The returned stop function sends what is
+// still queued; it is a no-op without --syslog.
+func startSyslog() (stop func(), err error) {
+	if syslogURL == "" {
+		return func() {}, nil
+	}
+	sink, err := syslogsink.Open(syslogURL)
+	if err != nil {
+		return nil, fmt.Errorf("--syslog: %w", err)
+	}
+	syslogOut = sink
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+		defer cancel()
+		if err := sink.Close(ctx); err != nil {
+			logger.Warn("Syslog messages still queued at exit", zap.Error(err))
+		}
+		syslogOut = nil
+	}, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/targetnames"
+)
+
+// resolveNames enables reverse-DNS lookups of external connection targets.
+var resolveNames bool
+
+// startTargetNames installs the global target name resolver the report
+// labels connection targets with. Names the traced pod resolved itself are
+// always used; --resolve-names adds background PTR lookups and
+// PODTRACE_IP_RANGES adds cloud provider ranges. The returned stop function
+// is never nil.
+func startTargetNames() (stop func(), err error) {
+	var ranges *targetnames.Ranges
+	if config.IPRangesFiles != "" {
+		ranges, err = targetnames.LoadRanges(parseCSV(config.IPRangesFiles))
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("Loaded cloud provider IP ranges", zap.Int("ranges", ranges.Len()))
+	}
+	r := targetnames.New(targetnames.Options{
+		ReverseDNS: resolveNames,
+		Timeout:    config.ReverseDNSTimeout,
+		TTL:        config.DefaultTargetNameTTL,
+		Ranges:     ranges,
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Start(ctx)
+	targetnames.SetGlobal(r)
+	return func() {
+		cancel()
+		r.Wait()
+		targetnames.SetGlobal(nil)
+	}, nil
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// podTerminations are the traced pods that went away mid-trace, for the
+// report.
+var (
+	podTerminationsMu sync.Mutex
+	podTerminations   []analyzer.PodTermination
+)
+
+// startTerminationWatch ends the trace through stop, as an interrupt would,
+// once every target pod is deleted, evicted or finished, so the report and
+// exports still cover what was collected. Only a fixed set of targets is
+// watched: a selector's registry picks up the pods that replace them.
+// Without a clientset, or permission to watch the pods, the trace runs its
+// full duration.
+func startTerminationWatch(ctx context.Context, stop context.CancelFunc, resolver kubernetes.PodResolverInterface, targets []*kubernetes.PodInfo) {
+	provider, ok := resolver.(kubernetes.ClientsetProvider)
+	if !ok || provider.GetClientset() == nil || len(targets) == 0 {
+		return
+	}
+	podTerminationsMu.Lock()
+	podTerminations = nil
+	podTerminationsMu.Unlock()
+	remaining := len(targets)
+	gone := func(t kubernetes.PodTermination) {
+		podTerminationsMu.Lock()
+		podTerminations = append(podTerminations, analyzer.PodTermination(t))
+		remaining--
+		last := remaining == 0
+		podTerminationsMu.Unlock()
+		logger.Warn("Traced pod went away",
+			zap.String("pod", t.Namespace+"/"+t.Pod), zap.String("reason", t.Reason), zap.String("message", t.Message))
+		if last {
+			logger.Warn("Every traced pod is gone; stopping the probes and finishing with a partial report")
+			stop()
+		}
+	}
+	for _, target := range targets {
+		// An unwatched pod never counts down, so the trace then runs on.
+		if err := kubernetes.WatchPodTermination(ctx, provider.GetClientset(), target.Namespace, target.PodName, gone); err != nil {
+			logger.Info("Pod termination not watched; the trace runs its full duration",
+				zap.String("pod", target.Namespace+"/"+target.PodName), zap.Error(err))
+		}
+	}
+}
+
+// setPodTerminations hands d the pods startTerminationWatch saw go.
+func setPodTerminations(d *diagnose.Diagnostician) {
+	podTerminationsMu.Lock()
+	defer podTerminationsMu.Unlock()
+	d.SetPodTerminations(podTerminations)
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+)
+
+func TestStartTerminationWatchStopsOnceEveryPodIsGone(t *testing.T) {
+	orig := podTerminations
+	t.Cleanup(func() { podTerminations = orig })
+
+	pod := func(name string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: name}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	}
+	clientset := fake.NewSimpleClientset(pod("api-0"), pod("api-1"))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stopped := make(chan struct{})
+	stop := func() { close(stopped) }
+
+	targets := []*kubernetes.PodInfo{{Namespace: "shop", PodName: "api-0"}, {Namespace: "shop", PodName: "api-1"}}
+	startTerminationWatch(ctx, stop, &podStatusResolver{clientset: clientset}, targets)
+
+	if err := clientset.CoreV1().Pods("shop").Delete(ctx, "api-0", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+		t.Fatal("stopped with api-1 still running")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	evicted := pod("api-1")
+	evicted.Status = corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: memory."}
+	if _, err := clientset.CoreV1().Pods("shop").UpdateStatus(ctx, evicted, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("not stopped after every traced pod went away")
+	}
+
+	d := diagnose.NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventDNS, Target: "db", LatencyNS: 1000000})
+	d.Finish()
+	setPodTerminations(d)
+	out := d.GenerateReport()
+	for _, want := range []string{"Partial Report", "shop/api-0: Deleted", "shop/api-1: Evicted", "(The node was low on resource: memory.)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+	if data := d.ExportJSON(); !data.Partial || len(data.TerminatedPods) != 2 {
+		t.Errorf("export partial=%v terminated=%v", data.Partial, data.TerminatedPods)
+	}
+}
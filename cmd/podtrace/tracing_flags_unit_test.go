@@ -14,6 +14,7 @@ func newTracingFlagsCommand() *cobra.Command {
 	cmd.Flags().StringVar(&tracingJaegerEndpoint, "tracing-jaeger-endpoint", config.DefaultJaegerEndpoint, "")
 	cmd.Flags().StringVar(&tracingSplunkEndpoint, "tracing-splunk-endpoint", config.DefaultSplunkEndpoint, "")
 	cmd.Flags().StringVar(&tracingSplunkToken, "tracing-splunk-token", "", "")
+	cmd.Flags().StringVar(&tracingZipkinEndpoint, "tracing-zipkin-endpoint", "", "")
 	cmd.Flags().Float64Var(&tracingSampleRate, "tracing-sample-rate", config.DefaultTracingSampleRate, "")
 	return cmd
 }
@@ -25,6 +26,7 @@ func restoreTracingFlagGlobals(t *testing.T) {
 	origJaeger := tracingJaegerEndpoint
 	origSplunk := tracingSplunkEndpoint
 	origToken := tracingSplunkToken
+	origZipkin := tracingZipkinEndpoint
 	origRate := tracingSampleRate
 	t.Cleanup(func() {
 		enableTracing = origEnable
@@ -32,6 +34,7 @@ func restoreTracingFlagGlobals(t *testing.T) {
 		tracingJaegerEndpoint = origJaeger
 		tracingSplunkEndpoint = origSplunk
 		tracingSplunkToken = origToken
+		tracingZipkinEndpoint = origZipkin
 		tracingSampleRate = origRate
 	})
 }
@@ -121,3 +124,30 @@ func TestApplyTracingFlags_DisabledIsNoOp(t *testing.T) {
 		t.Error("TracingEnabled set without --tracing")
 	}
 }
+
+func TestApplyTracingFlags_ZipkinEndpoint(t *testing.T) {
+	restoreTracingFlagGlobals(t)
+	defer resetTracingConfig()
+
+	cmd := newTracingFlagsCommand()
+	if err := cmd.Flags().Set("tracing", "true"); err != nil {
+		t.Fatal(err)
+	}
+	config.ZipkinEndpoint = ""
+	if err := applyTracingFlags(cmd); err != nil {
+		t.Fatalf("applyTracingFlags: %v", err)
+	}
+	if config.ZipkinEndpoint != "" {
+		t.Errorf("Zipkin enabled without --tracing-zipkin-endpoint: %q", config.ZipkinEndpoint)
+	}
+
+	if err := cmd.Flags().Set("tracing-zipkin-endpoint", "http://zipkin:9411/api/v2/spans"); err != nil {
+		t.Fatal(err)
+	}
+	if err := applyTracingFlags(cmd); err != nil {
+		t.Fatalf("applyTracingFlags: %v", err)
+	}
+	if config.ZipkinEndpoint != "http://zipkin:9411/api/v2/spans" {
+		t.Errorf("explicit --tracing-zipkin-endpoint not applied: %q", config.ZipkinEndpoint)
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/diagnose/export"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/metricsexporter"
+	"github.com/podtrace/podtrace/internal/trigger"
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+// triggerCheckInterval is how often the armed trigger samples its metric.
+const triggerCheckInterval = time.Second
+
+// eventRecorder appends captured events to a --trigger-record file: JSON
+// lines, or the binary format of pkg/schema for a .pb path. A nil recorder
+// records nothing.
+type eventRecorder struct {
+	buf   *bufio.Writer
+	write func(schema.Event) error
+}
+
+func newEventRecorder(w io.Writer, binary bool) *eventRecorder {
+	buf := bufio.NewWriter(w)
+	rec := &eventRecorder{buf: buf}
+	if binary {
+		rec.write = schema.NewProtoWriter(buf).WriteEvent
+	} else {
+		enc := json.NewEncoder(buf)
+		rec.write = func(e schema.Event) error { return enc.Encode(e) }
+	}
+	return rec
+}
+
+func (r *eventRecorder) record(event *events.Event) {
+	if r != nil {
+		_ = r.write(export.SchemaEvent(event))
+	}
+}
+
+func (r *eventRecorder) flush() {
+	if r != nil {
+		_ = r.buf.Flush()
+	}
+}
+
+// gateOnTrigger holds back every event from in until ev's condition fires,
+// feeding them only into its rolling counters. From then on events pass
+// through to out and are recorded by rec. out is closed when in closes or
+// ctx ends.
+func gateOnTrigger(ctx context.Context, in <-chan *events.Event, out chan<- *events.Event, ev *trigger.Evaluator, rec *eventRecorder, now func() time.Time) {
+	defer close(out)
+	defer rec.flush()
+	logger.Info("Capture armed; waiting for trigger", zap.String("trigger", ev.Condition().String()))
+
+	ticker := time.NewTicker(triggerCheckInterval)
+	defer ticker.Stop()
+	fired := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if fired {
+				rec.flush()
+				continue
+			}
+			if v, ok := ev.Check(now()); ok {
+				fired = true
+				logger.Info("Trigger fired; starting full capture",
+					zap.String("trigger", ev.Condition().String()),
+					zap.Float64("observed", v))
+				fmt.Printf("=== Trigger fired: %s (observed %.2f) — full capture started ===\n", ev.Condition(), v)
+			}
+		case event, ok := <-in:
+			if !ok {
+				return
+			}
+			if event == nil {
+				continue
+			}
+			if !fired {
+				ev.Observe(event, now())
+				continue
+			}
+			rec.record(event)
+			select {
+			case <-ctx.Done():
+				return
+			case out <- event:
+			default:
+				logger.Warn("Triggered event channel full, dropping event",
+					zap.String("event_type", event.TypeString()),
+					zap.Uint32("pid", event.PID))
+				metricsexporter.RecordFilteredEventDrop()
+			}
+		}
+	}
+}
+
+// openTriggerRecord creates the --trigger-record file, or returns nil when no
+// path was given.
+func openTriggerRecord(path string) (*os.File, error) {
+	if path == "" {
+		return nil, nil
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600) // #nosec G304 -- operator-supplied output path.
+	if err != nil {
+		return nil, fmt.Errorf("open --trigger-record file: %w", err)
+	}
+	return f, nil
+}
+
+// binaryRecordPath reports whether a --trigger-record path asks for the
+// binary format.
+func binaryRecordPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".pb")
+}
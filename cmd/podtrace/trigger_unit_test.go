@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/trigger"
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestGateOnTrigger_HoldsEventsUntilFired(t *testing.T) {
+	cond, err := trigger.Parse("errors>0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in := make(chan *events.Event, 4)
+	out := make(chan *events.Event, 4)
+	rec := &lockedBuffer{}
+	done := make(chan struct{})
+	go func() {
+		gateOnTrigger(ctx, in, out, trigger.NewEvaluator(cond), newEventRecorder(rec, false), time.Now)
+		close(done)
+	}()
+
+	in <- &events.Event{Type: events.EventConnect, Error: 111, Target: "10.0.0.1:80"}
+	select {
+	case e := <-out:
+		t.Fatalf("event forwarded before the trigger fired: %+v", e)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// The armed ticker samples once per second; afterwards events flow.
+	deadline := time.After(5 * time.Second)
+	for {
+		in <- &events.Event{Type: events.EventDNS, Target: "example.com"}
+		select {
+		case e := <-out:
+			if e.Target != "example.com" {
+				t.Fatalf("unexpected event %+v", e)
+			}
+			close(in)
+			<-done
+			if !strings.Contains(rec.String(), `"target":"example.com"`) {
+				t.Errorf("recording missing forwarded event: %q", rec.String())
+			}
+			recorded, err := schema.NewEventReader(strings.NewReader(rec.String())).Next()
+			if err != nil || recorded.SchemaVersion != schema.CurrentVersion {
+				t.Errorf("recording is not a current-version capture line: %+v, %v", recorded, err)
+			}
+			return
+		case <-time.After(200 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("trigger never fired")
+		}
+	}
+}
+
+func TestRunPodtrace_TriggerValidation(t *testing.T) {
+	saveRunPodtraceGlobals(t)
+	resetRunPodtraceGlobals()
+
+	triggerExpr = "cpu>5%"
+	if err := runPodtrace(cmdWithNamespaceChanged(), []string{"pod"}); err == nil || !strings.Contains(err.Error(), "unknown metric") {
+		t.Errorf("expected unknown metric error, got %v", err)
+	}
+
+	triggerExpr = "error_rate>5%"
+	diagnoseDuration = "10s"
+	if err := runPodtrace(cmdWithNamespaceChanged(), []string{"pod"}); err == nil || !strings.Contains(err.Error(), "--diagnose") {
+		t.Errorf("expected --diagnose conflict, got %v", err)
+	}
+
+	triggerExpr, diagnoseDuration = "", ""
+	triggerRecord = "/tmp/x.jsonl"
+	if err := runPodtrace(cmdWithNamespaceChanged(), []string{"pod"}); err == nil || !strings.Contains(err.Error(), "requires --trigger") {
+		t.Errorf("expected --trigger-record error, got %v", err)
+	}
+}
+
+func TestRunPodtrace_MaxEventsValidation(t *testing.T) {
+	saveRunPodtraceGlobals(t)
+	resetRunPodtraceGlobals()
+
+	maxEventsBudget = -1
+	if err := runPodtrace(cmdWithNamespaceChanged(), []string{"pod"}); err == nil || !strings.Contains(err.Error(), "--max-events") {
+		t.Errorf("expected --max-events error, got %v", err)
+	}
+}
+
+func TestEventRecorder_Binary(t *testing.T) {
+	var buf bytes.Buffer
+	rec := newEventRecorder(&buf, binaryRecordPath("/tmp/incident.PB"))
+	rec.record(&events.Event{Type: events.EventDNS, Target: "example.com", PID: 7, LatencyNS: 1500})
+	rec.flush()
+
+	r, err := schema.NewProtoReader(&buf).Next()
+	if err != nil || r.Event == nil {
+		t.Fatalf("Next = %+v, %v; want a recorded event", r, err)
+	}
+	if r.Event.Target != "example.com" || r.Event.PID != 7 || r.Event.LatencyNS != 1500 {
+		t.Errorf("recorded event = %+v", *r.Event)
+	}
+
+	var nilRec *eventRecorder
+	nilRec.record(&events.Event{})
+	nilRec.flush()
+	if binaryRecordPath("/tmp/incident.jsonl") {
+		t.Error(".jsonl path treated as binary")
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/diagnose/report"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/sanitize"
+	"github.com/podtrace/podtrace/internal/syslogsink"
+)
+
+// verbosityPrinter renders the --verbosity output tiers: every event, only
+// threshold-exceeding events, or only newly detected issues. The zero tier
+// ("") prints nothing here and leaves output to the periodic report.
+type verbosityPrinter struct {
+	w          io.Writer
+	tier       string
+	rttMS      float64
+	fsMS       float64
+	seenIssues map[string]bool
+	// syslog also receives every event line printed, when --syslog is set.
+	syslog *syslogsink.Sink
+}
+
+func newVerbosityPrinter(w io.Writer, tier string, rttMS, fsMS float64) *verbosityPrinter {
+	return &verbosityPrinter{
+		w:          w,
+		tier:       strings.ToLower(strings.TrimSpace(tier)),
+		rttMS:      rttMS,
+		fsMS:       fsMS,
+		seenIssues: make(map[string]bool),
+		syslog:     syslogOut,
+	}
+}
+
+// streaming reports whether the tier prints per-event lines instead of the
+// periodic real-time report.
+func (p *verbosityPrinter) streaming() bool {
+	return p.tier == config.VerbosityEvents || p.tier == config.VerbosityAnomalies
+}
+
+// issuesOnly reports whether the tier suppresses everything but issues.
+func (p *verbosityPrinter) issuesOnly() bool {
+	return p.tier == config.VerbosityIssues
+}
+
+// PrintEvent writes one formatted line for e when the tier selects it.
+func (p *verbosityPrinter) PrintEvent(e *events.Event) {
+	if e == nil || !p.streaming() {
+		return
+	}
+	if p.tier == config.VerbosityAnomalies && !isAnomalousEvent(e, p.rttMS, p.fsMS) {
+		return
+	}
+	line := formatEventLine(e)
+	_, _ = fmt.Fprintln(p.w, line)
+	p.syslog.Event(e, line)
+}
+
+// PrintNewIssues writes issues detected since the previous call, so the
+// issues tier surfaces each problem once instead of re-rendering the list.
+func (p *verbosityPrinter) PrintNewIssues(d *diagnose.Diagnostician) {
+	if !p.issuesOnly() {
+		return
+	}
+	scored := detector.ScoreIssues(d.GetEvents(), d.ErrorRateThreshold(), d.RTTSpikeThreshold())
+	var fresh []detector.Issue
+	for _, issue := range scored {
+		key := issue.Key()
+		if p.seenIssues[key] {
+			continue
+		}
+		p.seenIssues[key] = true
+		fresh = append(fresh, issue)
+		_, _ = fmt.Fprintf(p.w, "[ISSUE] [%s] %s\n", issue.Code, sanitize.Terminal(issue.Message))
+	}
+	if len(fresh) > 0 {
+		d.NotifyIssues(fresh)
+	}
+}
+
+// finalReport renders the end-of-run output for the tier: the issues section
+// alone for the issues tier, the full report otherwise.
+func (p *verbosityPrinter) finalReport(d *diagnose.Diagnostician, full func() string) string {
+	if !p.issuesOnly() {
+		return full()
+	}
+	slos := report.GenerateSLOSection(d.EvaluateSLOs())
+	if section := report.GenerateIssuesSection(d); section != "" {
+		return slos + section
+	}
+	return slos + "No issues detected.\n"
+}
+
+// formatEventLine renders a single event as one human-readable line.
+func formatEventLine(e *events.Event) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %-8s pid=%d", e.TimestampTime().Format("15:04:05.000"), e.TypeString(), e.PID)
+	if e.ProcessName != "" {
+		fmt.Fprintf(&b, " comm=%s", sanitize.Terminal(e.ProcessName))
+	}
+	if e.Target != "" {
+		fmt.Fprintf(&b, " target=%s", sanitize.Terminal(e.Target))
+	}
+	if e.LatencyNS > 0 {
+		fmt.Fprintf(&b, " latency=%.2fms", float64(e.LatencyNS)/float64(config.NSPerMS))
+	}
+	if e.Bytes > 0 && e.Type != events.EventResourceLimit {
+		fmt.Fprintf(&b, " bytes=%d", e.Bytes)
+	}
+	if e.IsError() {
+		fmt.Fprintf(&b, " error=%d", e.Error)
+	}
+	if e.K8s != nil && e.K8s.PodName != "" {
+		fmt.Fprintf(&b, " pod=%s/%s", e.K8s.Namespace, e.K8s.PodName)
+	}
+	return b.String()
+}
+
+// isAnomalousEvent reports whether e crosses one of the configured
+// thresholds: a failure, network latency above rttMS, filesystem latency
+// above fsMS, or an event type that is noteworthy on its own.
+func isAnomalousEvent(e *events.Event, rttMS, fsMS float64) bool {
+	if e.IsError() {
+		return true
+	}
+	latencyMS := float64(e.LatencyNS) / float64(config.NSPerMS)
+	switch e.Type {
+	case events.EventOOMKill, events.EventPoolExhausted, events.EventTCPRetrans, events.EventTCPZeroWindow,
+		events.EventNetDevError, events.EventTLSError, events.EventAnnotation:
+		return true
+	case events.EventResourceLimit:
+		return e.Error >= int32(config.AlertWarnPct)
+	case events.EventConnect, events.EventTCPSend, events.EventTCPRecv,
+		events.EventUDPSend, events.EventUDPRecv, events.EventDNS, events.EventDNSQuery,
+		events.EventHTTPReq, events.EventHTTPResp, events.EventHTTP3, events.EventGRPCMethod,
+		events.EventDBQuery, events.EventRedisCmd, events.EventMemcachedCmd,
+		events.EventKafkaProduce, events.EventKafkaFetch, events.EventKafkaOp,
+		events.EventFastCGIReq, events.EventFastCGIResp, events.EventTLSHandshake:
+		return latencyMS > rttMS
+	case events.EventRead, events.EventWrite, events.EventFsync, events.EventOpen,
+		events.EventClose, events.EventUnlink, events.EventRename:
+		return latencyMS > fsMS
+	default:
+		return false
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestVerbosityPrinter_EventsTierPrintsEveryEvent(t *testing.T) {
+	var buf bytes.Buffer
+	p := newVerbosityPrinter(&buf, "events", 100, 10)
+	p.PrintEvent(&events.Event{Type: events.EventDNS, PID: 7, Target: "example.com", LatencyNS: 1000})
+	p.PrintEvent(&events.Event{Type: events.EventRead, PID: 7})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %q", len(lines), buf.String())
+	}
+	if !strings.Contains(lines[0], "DNS") || !strings.Contains(lines[0], "target=example.com") {
+		t.Errorf("unexpected DNS line: %q", lines[0])
+	}
+}
+
+func TestVerbosityPrinter_AnomaliesTierFiltersBelowThreshold(t *testing.T) {
+	var buf bytes.Buffer
+	p := newVerbosityPrinter(&buf, "anomalies", 100, 10)
+	p.PrintEvent(&events.Event{Type: events.EventTCPSend, LatencyNS: 5_000_000})
+	p.PrintEvent(&events.Event{Type: events.EventTCPSend, LatencyNS: 150_000_000, Target: "slow"})
+	p.PrintEvent(&events.Event{Type: events.EventWrite, LatencyNS: 20_000_000, Target: "/data/f"})
+	p.PrintEvent(&events.Event{Type: events.EventConnect, Error: -111, Target: "refused"})
+
+	out := buf.String()
+	if strings.Count(out, "\n") != 3 {
+		t.Fatalf("expected 3 anomalous lines, got %q", out)
+	}
+	for _, want := range []string{"target=slow", "target=/data/f", "target=refused"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in %q", want, out)
+		}
+	}
+}
+
+func TestVerbosityPrinter_DefaultAndIssuesTiersPrintNoEvents(t *testing.T) {
+	for _, tier := range []string{"", "issues"} {
+		var buf bytes.Buffer
+		p := newVerbosityPrinter(&buf, tier, 100, 10)
+		p.PrintEvent(&events.Event{Type: events.EventConnect, Error: -111})
+		if buf.Len() != 0 {
+			t.Errorf("tier %q printed events: %q", tier, buf.String())
+		}
+	}
+}
+
+func TestVerbosityPrinter_IssuesTierPrintsEachIssueOnce(t *testing.T) {
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	for i := 0; i < 4; i++ {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Error: -111})
+	}
+
+	var buf bytes.Buffer
+	p := newVerbosityPrinter(&buf, "issues", 100, 10)
+	p.PrintNewIssues(d)
+	// More failures change the message's counts, not the issue.
+	for i := 0; i < 3; i++ {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Error: -111})
+	}
+	p.PrintNewIssues(d)
+
+	if got := strings.Count(buf.String(), "[ISSUE]"); got != 1 {
+		t.Fatalf("expected the issue once, got %d: %q", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "High connection failure rate") {
+		t.Errorf("unexpected issue output: %q", buf.String())
+	}
+}
+
+func TestVerbosityPrinter_IssuesTierPrintsEachEndpoint(t *testing.T) {
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	burst := func(endpoint string) {
+		for i := 0; i < 6; i++ {
+			d.AddEvent(&events.Event{Type: events.EventHTTPResp, Timestamp: uint64(time.Hour + time.Duration(i)*time.Second),
+				Target: endpoint, Details: "503"})
+		}
+	}
+
+	var buf bytes.Buffer
+	p := newVerbosityPrinter(&buf, "issues", 100, 10)
+	burst("GET /orders")
+	p.PrintNewIssues(d)
+	burst("GET /cart\x1b[2J")
+	p.PrintNewIssues(d)
+	p.PrintNewIssues(d)
+
+	out := buf.String()
+	if got := strings.Count(out, "[ISSUE] [PODTRACE-HTTP-001]"); got != 2 {
+		t.Fatalf("expected one 5xx burst per endpoint, got %d: %q", got, out)
+	}
+	if !strings.Contains(out, "GET /orders") || !strings.Contains(out, "GET /cart") {
+		t.Errorf("endpoints missing: %q", out)
+	}
+	if strings.Contains(out, "\x1b") {
+		t.Errorf("escape sequence from the traced endpoint reached the terminal: %q", out)
+	}
+}
+
+func TestVerbosityPrinter_FinalReportIssuesOnly(t *testing.T) {
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	d.AddEvent(&events.Event{Type: events.EventDNS})
+	d.Finish()
+
+	full := func() string { return "FULL REPORT" }
+	if got := newVerbosityPrinter(&bytes.Buffer{}, "", 100, 10).finalReport(d, full); got != "FULL REPORT" {
+		t.Errorf("default tier should render the full report, got %q", got)
+	}
+	if got := newVerbosityPrinter(&bytes.Buffer{}, "issues", 100, 10).finalReport(d, full); got != "No issues detected.\n" {
+		t.Errorf("issues tier without issues: got %q", got)
+	}
+}
+
+func TestLiveAlerter_DebouncesRepeatedRule(t *testing.T) {
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	for i := 0; i < 4; i++ {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Error: -111})
+	}
+
+	var buf bytes.Buffer
+	a := newLiveAlerter(&buf, 30*time.Second)
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	a.Check(d)
+	now = now.Add(10 * time.Second)
+	a.Check(d)
+	if got := strings.Count(buf.String(), "[ALERT]"); got != 1 {
+		t.Fatalf("expected one alert within the debounce window, got %d: %q", got, buf.String())
+	}
+	if !strings.Contains(buf.String(), "[ALERT] 10:00:00 [") || strings.Contains(buf.String(), "\033[") {
+		t.Errorf("unexpected alert line: %q", buf.String())
+	}
+
+	now = now.Add(30 * time.Second)
+	a.Check(d)
+	if got := strings.Count(buf.String(), "[ALERT]"); got != 2 {
+		t.Fatalf("expected the rule to alert again after the window, got %d: %q", got, buf.String())
+	}
+	if got := strings.Count(a.Recent(), "[ALERT]"); got != 2 {
+		t.Errorf("expected both alerts kept for the report, got %q", a.Recent())
+	}
+}
+
+func TestLiveAlerter_NoIssuesNoAlerts(t *testing.T) {
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	d.AddEvent(&events.Event{Type: events.EventDNS})
+
+	var buf bytes.Buffer
+	a := newLiveAlerter(&buf, time.Second)
+	a.Check(d)
+	if buf.Len() != 0 || a.Recent() != "" {
+		t.Errorf("expected no alerts, got %q", buf.String())
+	}
+}
+
+func TestStartLiveAlerts_ChecksOutsideTheCaller(t *testing.T) {
+	old := config.LiveAlertInterval
+	config.LiveAlertInterval = time.Millisecond
+	defer func() { config.LiveAlertInterval = old }()
+
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	for i := 0; i < 4; i++ {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Error: -111})
+	}
+	a := newLiveAlerter(io.Discard, time.Hour)
+	stop := startLiveAlerts(context.Background(), d, newVerbosityPrinter(io.Discard, "", 100, 10), a)
+	deadline := time.Now().Add(5 * time.Second)
+	for a.Recent() == "" && time.Now().Before(deadline) {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Error: -111})
+		time.Sleep(time.Millisecond)
+	}
+	stop()
+	if got := strings.Count(a.Recent(), "[ALERT]"); got != 1 {
+		t.Errorf("expected one alert from the background check, got %q", a.Recent())
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+	k8s "k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// reportVolumeMounts are the pod volumes behind the mount IDs of the traced
+// containers, read when the targets are resolved, for putting FS events on
+// their volume.
+var reportVolumeMounts map[uint32]analyzer.VolumeMount
+
+// volumeClientset is the API client the mounts were read with, kept for
+// crossCheckStorage.
+var volumeClientset k8s.Interface
+
+// recordVolumeMounts reads the volume mounts of the resolved targets from
+// their pod specs and matches them against each container's mount table.
+// It needs the API; a pod or container whose mounts cannot be read only
+// leaves its FS events off the per-volume table.
+func recordVolumeMounts(ctx context.Context, resolver kubernetes.PodResolverInterface, infos []*kubernetes.PodInfo) {
+	reportVolumeMounts = nil
+	volumeClientset = nil
+	cp, ok := resolver.(kubernetes.ClientsetProvider)
+	if !ok {
+		return
+	}
+	volumeClientset = cp.GetClientset()
+	for _, info := range infos {
+		pod := info.Namespace + "/" + info.PodName
+		targets := podContainerTargets(info)
+		vols, err := kubernetes.FetchPodVolumes(ctx, volumeClientset, info.Namespace, info.PodName, targets)
+		if err != nil {
+			logger.Debug("Pod volumes unavailable", zap.String("pod", pod), zap.Error(err))
+			continue
+		}
+		for _, c := range targets {
+			if c.CgroupPath == "" {
+				continue
+			}
+			mounted, err := kubernetes.MountedVolumes(c.Name, c.CgroupPath, vols)
+			if err != nil {
+				logger.Debug("Container mount table unavailable",
+					zap.String("pod", pod), zap.String("container", c.Name), zap.Error(err))
+				continue
+			}
+			for id, v := range mounted {
+				if reportVolumeMounts == nil {
+					reportVolumeMounts = make(map[uint32]analyzer.VolumeMount)
+				}
+				reportVolumeMounts[id] = analyzer.VolumeMount{
+					Pod:          pod,
+					Container:    v.Container,
+					Volume:       v.Name,
+					Kind:         v.Kind,
+					Source:       v.Source,
+					StorageClass: v.StorageClass,
+					MountPath:    v.MountPath,
+					ReadOnly:     v.ReadOnly,
+					SizeLimit:    v.SizeLimit,
+				}
+			}
+		}
+	}
+}
+
+// setVolumeMounts hands d the mounts recordVolumeMounts read.
+func setVolumeMounts(d *diagnose.Diagnostician) {
+	if len(reportVolumeMounts) > 0 {
+		d.SetVolumeMounts(reportVolumeMounts)
+	}
+}
+
+// crossCheckStorage reads the claim, PersistentVolume, StorageClass and
+// recent Events behind every slow PVC volume once the trace is over, so the
+// slow-volume finding carries what the storage team needs. A claim that
+// cannot be read keeps its finding without the evidence.
+func crossCheckStorage(ctx context.Context, d *diagnose.Diagnostician) {
+	if volumeClientset == nil {
+		return
+	}
+	slow := detector.SlowVolumes(d.Volumes(), d.FSSlowThreshold())
+	if len(slow) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalizeGracePeriod)
+	defer cancel()
+	checked := make(map[string]bool)
+	for _, v := range slow {
+		if v.Kind != "persistentVolumeClaim" || v.Source == "" {
+			continue
+		}
+		namespace, _, _ := strings.Cut(v.Pod, "/")
+		claim := namespace + "/" + v.Source
+		if checked[claim] {
+			continue
+		}
+		checked[claim] = true
+		h, err := kubernetes.FetchStorageHealth(ctx, volumeClientset, namespace, v.Source)
+		if err != nil {
+			logger.Debug("Storage cross-check failed", zap.String("claim", claim), zap.Error(err))
+			continue
+		}
+		d.SetStorageEvidence(claim, newStorageEvidence(h))
+	}
+}
+
+func newStorageEvidence(h kubernetes.StorageHealth) analyzer.StorageEvidence {
+	ev := analyzer.StorageEvidence{
+		Phase:            h.Phase,
+		Capacity:         h.Capacity,
+		AccessModes:      h.AccessModes,
+		PersistentVolume: h.PersistentVolume,
+		Provisioner:      h.Provisioner,
+		CSIDriver:        h.CSIDriver,
+		VolumeHandle:     h.VolumeHandle,
+	}
+	for _, e := range h.Events {
+		ev.Events = append(ev.Events, analyzer.StorageEvent{
+			Object:   e.Object,
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Message:  e.Message,
+			Count:    e.Count,
+			LastSeen: e.LastSeen,
+		})
+	}
+	return ev
+}
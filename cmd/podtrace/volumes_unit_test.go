@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/diagnose/report"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/kubernetes"
+	"github.com/podtrace/podtrace/internal/procfs"
+	"github.com/podtrace/podtrace/internal/sysfs"
+)
+
+func TestRecordVolumeMounts(t *testing.T) {
+	orig := reportVolumeMounts
+	t.Cleanup(func() { reportVolumeMounts = orig })
+	cgroupBase, procBase := t.TempDir(), t.TempDir()
+	origCgroup, origProc := config.CgroupBasePath, config.ProcBasePath
+	config.SetCgroupBasePath(cgroupBase)
+	config.SetProcBasePath(procBase)
+	sysfs.ResetForTesting()
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.SetCgroupBasePath(origCgroup)
+		config.SetProcBasePath(origProc)
+		sysfs.ResetForTesting()
+		procfs.ResetForTesting()
+	})
+
+	cgroup := filepath.Join(cgroupBase, "pod", "app")
+	for path, data := range map[string]string{
+		filepath.Join(cgroup, "cgroup.procs"):       "700\n",
+		filepath.Join(procBase, "700", "mountinfo"): "1 0 0:40 / / rw - overlay overlay rw\n2 1 8:16 / /data rw - ext4 /dev/sdb rw\n",
+	} {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "app-0", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{{Name: "data", VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-app-0"},
+			}}},
+			Containers: []corev1.Container{{Name: "app", VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: "/data"}}}},
+		},
+	}
+	info := &kubernetes.PodInfo{PodName: "app-0", Namespace: "shop",
+		Containers: []kubernetes.ContainerTarget{{Name: "app", CgroupPath: cgroup}}}
+	gone := &kubernetes.PodInfo{PodName: "gone", Namespace: "shop", Containers: []kubernetes.ContainerTarget{{Name: "x"}}}
+
+	recordVolumeMounts(context.Background(), &podStatusResolver{clientset: fake.NewSimpleClientset(pod)},
+		[]*kubernetes.PodInfo{info, gone})
+	if len(reportVolumeMounts) != 2 {
+		t.Fatalf("recorded %+v; want the rootfs and the data mount", reportVolumeMounts)
+	}
+	if m := reportVolumeMounts[2]; m.Pod != "shop/app-0" || m.Volume != "data" || m.Source != "data-app-0" || m.MountPath != "/data" {
+		t.Errorf("data mount = %+v", m)
+	}
+	if m := reportVolumeMounts[1]; m.Kind != kubernetes.VolumeRootfs {
+		t.Errorf("root mount = %+v", m)
+	}
+
+	d := diagnose.NewDiagnostician()
+	setVolumeMounts(d)
+	if got := d.VolumeMounts(); len(got) != 2 {
+		t.Errorf("diagnostician mounts = %+v", got)
+	}
+
+	recordVolumeMounts(context.Background(), plainResolver{}, []*kubernetes.PodInfo{info})
+	if reportVolumeMounts != nil {
+		t.Errorf("without the API: recorded %+v", reportVolumeMounts)
+	}
+}
+
+func TestCrossCheckStorage(t *testing.T) {
+	orig := volumeClientset
+	t.Cleanup(func() { volumeClientset = orig })
+	class := "gp3"
+	volumeClientset = fake.NewSimpleClientset(&corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-app-0", Namespace: "shop"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &class, VolumeName: "pvc-9"},
+		Status:     corev1.PersistentVolumeClaimStatus{Phase: corev1.ClaimBound},
+	})
+
+	d := diagnose.NewDiagnosticianWithThresholds(10, 100, 10)
+	d.SetVolumeMounts(map[uint32]analyzer.VolumeMount{7: {
+		Pod: "shop/app-0", Container: "app", Volume: "data", Kind: "persistentVolumeClaim", Source: "data-app-0", MountPath: "/data",
+	}})
+	for i := 0; i < 10; i++ {
+		d.AddEvent(&events.Event{Type: events.EventFsync, MntID: 7, LatencyNS: uint64(50 * time.Millisecond)})
+	}
+
+	crossCheckStorage(context.Background(), d)
+	vols := d.Volumes()
+	if len(vols) != 1 || vols[0].Storage == nil || vols[0].Storage.Phase != "Bound" || vols[0].Storage.PersistentVolume != "pvc-9" {
+		t.Fatalf("volumes = %+v", vols)
+	}
+	issues := report.DetectIssues(d)
+	if len(issues) == 0 || issues[0].Rule != "slow_volume" {
+		t.Fatalf("issues = %+v", issues)
+	}
+
+	volumeClientset = nil
+	d2 := diagnose.NewDiagnostician()
+	crossCheckStorage(context.Background(), d2)
+	if len(d2.StorageEvidence()) != 0 {
+		t.Error("without the API: evidence recorded")
+	}
+}
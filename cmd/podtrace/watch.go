@@ -179,7 +179,7 @@ func runWatch(ctx context.Context, opts watchOptions) error {
 	if opts.Kubeconfig != "" {
 		loader.ExplicitPath = opts.Kubeconfig
 	}
-	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, &clientcmd.ConfigOverrides{}).ClientConfig()
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loader, kubernetes.ConfigOverrides()).ClientConfig()
 	if err != nil {
 		return fmt.Errorf("load kubeconfig: %w", err)
 	}
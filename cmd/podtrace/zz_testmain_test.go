@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/ebpf"
 	"github.com/podtrace/podtrace/internal/kubernetes"
 )
@@ -22,5 +23,7 @@ func TestMain(m *testing.M) {
 	tracerFactory = func() (ebpf.TracerInterface, error) {
 		return nil, fmt.Errorf("test: tracerFactory not stubbed")
 	}
+	// Diagnose runs must not land in the developer's ~/.podtrace.
+	config.History = false
 	os.Exit(m.Run())
 }
@@ -8,6 +8,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
+	"github.com/podtrace/podtrace/internal/cri"
 	"github.com/podtrace/podtrace/internal/events"
 )
 
@@ -174,6 +175,9 @@ func buildK8sMetadata(entry PodCgroupEntry) events.K8sMetadata {
 	kind, name := resolveWorkload(pod)
 	meta.WorkloadKind = kind
 	meta.WorkloadName = name
+	rt := cri.PodRuntime(pod)
+	meta.ContainerRuntime = rt.Name
+	meta.RuntimeVersion = rt.Version
 	return meta
 }
 
@@ -48,17 +48,22 @@ func TestAppendK8sAttributes_FullBundle(t *testing.T) {
 		ContainerName: "app",
 		WorkloadKind:  "Deployment",
 		WorkloadName:  "web",
+
+		ContainerRuntime: "cri-o",
+		RuntimeVersion:   "1.29.1",
 	}
 
 	m := attrMap(appendK8sAttributes(nil, meta))
 
 	want := map[string]string{
-		"k8s.namespace.name":  "team-a",
-		"k8s.pod.name":        "web-abc",
-		"k8s.pod.uid":         "uid-123",
-		"k8s.node.name":       "node-1",
-		"k8s.container.name":  "app",
-		"k8s.deployment.name": "web",
+		"k8s.namespace.name":        "team-a",
+		"k8s.pod.name":              "web-abc",
+		"k8s.pod.uid":               "uid-123",
+		"k8s.node.name":             "node-1",
+		"k8s.container.name":        "app",
+		"k8s.deployment.name":       "web",
+		"container.runtime":         "cri-o",
+		"container.runtime.version": "1.29.1",
 	}
 	for key, val := range want {
 		got, ok := m[key]
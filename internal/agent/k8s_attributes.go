@@ -7,6 +7,10 @@ import (
 	"github.com/podtrace/podtrace/internal/events"
 )
 
+// containerRuntimeVersionKey has no semantic convention yet; it follows
+// container.runtime.
+const containerRuntimeVersionKey = attribute.Key("container.runtime.version")
+
 // appendK8sAttributes appends the frozen v1 enrichment attribute set
 // to attrs and returns the extended slice.
 func appendK8sAttributes(attrs []attribute.KeyValue, meta *events.K8sMetadata) []attribute.KeyValue {
@@ -28,6 +32,12 @@ func appendK8sAttributes(attrs []attribute.KeyValue, meta *events.K8sMetadata) [
 	if meta.ContainerName != "" {
 		attrs = append(attrs, semconv.K8SContainerName(meta.ContainerName))
 	}
+	if meta.ContainerRuntime != "" {
+		attrs = append(attrs, semconv.ContainerRuntime(meta.ContainerRuntime))
+	}
+	if meta.RuntimeVersion != "" {
+		attrs = append(attrs, containerRuntimeVersionKey.String(meta.RuntimeVersion))
+	}
 	attrs = appendWorkloadAttributes(attrs, meta.WorkloadKind, meta.WorkloadName)
 	return attrs
 }
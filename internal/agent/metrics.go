@@ -37,6 +37,15 @@ type Metrics struct {
 	SpansBatched          *prometheus.CounterVec
 	SpansDelivered        *prometheus.CounterVec
 
+	PressureDegraded   prometheus.Gauge
+	PressureBackoffs   *prometheus.CounterVec
+	PressureShedEvents prometheus.Counter
+
+	SelfGoroutines prometheus.Gauge
+	SelfHeapBytes  prometheus.Gauge
+	BPFMapFill     *prometheus.GaugeVec
+	SelfDegraded   prometheus.Gauge
+
 	detectorsMu sync.Mutex
 	detectors   map[CRKey]*errorRateDetector
 
@@ -170,6 +179,41 @@ func NewMetrics() *Metrics {
 			Name:      "spans_delivered_total",
 			Help:      "Spans successfully delivered to the backend by an exporter ExportSpans call.",
 		}, []string{"cr_namespace", "cr_name"}),
+		PressureDegraded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "podtrace_agent",
+			Name:      "pressure_degraded",
+			Help:      "1 while the agent has narrowed its probes and samples its events because the node is under CPU, memory or I/O pressure (PSI).",
+		}),
+		PressureBackoffs: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "podtrace_agent",
+			Name:      "pressure_backoffs_total",
+			Help:      "Times the agent backed off under node pressure, labeled by the resource (cpu|memory|io) whose stall share crossed PODTRACE_AGENT_PRESSURE_HIGH.",
+		}, []string{"resource"}),
+		PressureShedEvents: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "podtrace_agent",
+			Name:      "pressure_shed_events_total",
+			Help:      "Events the agent sampled out during node-pressure backoffs instead of exporting them.",
+		}),
+		SelfGoroutines: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "podtrace_agent",
+			Name:      "self_goroutines",
+			Help:      "Goroutines of the agent process at the last self-watchdog sample.",
+		}),
+		SelfHeapBytes: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "podtrace_agent",
+			Name:      "self_heap_bytes",
+			Help:      "Heap in use by the agent process at the last self-watchdog sample.",
+		}),
+		BPFMapFill: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "podtrace_agent",
+			Name:      "bpf_map_fill_ratio",
+			Help:      "Share (0.0–1.0) of max_entries in use of each BPF map that grows with the traced workload, at the last self-watchdog sample.",
+		}, []string{"map"}),
+		SelfDegraded: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: "podtrace_agent",
+			Name:      "self_degraded",
+			Help:      "1 while the self watchdog sees the agent's goroutines, heap or BPF map fill past their limit or with a floor that keeps rising.",
+		}),
 		detectors:          map[CRKey]*errorRateDetector{},
 		lastEvents:         map[CRKey]int64{},
 		lastDropped:        map[CRKey]int64{},
@@ -184,6 +228,8 @@ func NewMetrics() *Metrics {
 		m.ErrorRateBreached,
 		m.ProgramAttachFailures, m.ExporterInitFailures, m.ExportDeliveryDropped,
 		m.SpansBatched, m.SpansDelivered,
+		m.PressureDegraded, m.PressureBackoffs, m.PressureShedEvents,
+		m.SelfGoroutines, m.SelfHeapBytes, m.BPFMapFill, m.SelfDegraded,
 	)
 	return m
 }
@@ -0,0 +1,157 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+// A second agent on a node, from a duplicate DaemonSet or a surge
+// rollout, would attach every probe again and report each event twice.
+// The node lock prevents it: a flock on a directory on bpffs, which every
+// agent on the node shares through its /sys/fs/bpf host mount and which
+// the kernel releases when its holder exits, however it exits.
+
+// nodeLockDirName starts with a dot so no pinned session, whose names
+// start with a letter or digit, can take it.
+const nodeLockDirName = ".agent-lock"
+
+var (
+	errNodeLockHeld = errors.New("another podtrace agent holds the node lock")
+
+	nodeLockPoll = time.Second
+)
+
+// NodeLockDir is the node lock under pinDir, normally config.PinDir.
+func NodeLockDir(pinDir string) string {
+	return filepath.Join(pinDir, nodeLockDirName)
+}
+
+type nodeLock struct {
+	f *os.File
+}
+
+func tryNodeLock(dir string) (*nodeLock, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("create node lock %s: %w", dir, err)
+	}
+	f, err := os.Open(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open node lock %s: %w", dir, err)
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		_ = f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, errNodeLockHeld
+		}
+		return nil, fmt.Errorf("lock %s: %w", dir, err)
+	}
+	return &nodeLock{f: f}, nil
+}
+
+func (l *nodeLock) release() {
+	if l != nil {
+		_ = l.f.Close()
+	}
+}
+
+// acquireNodeLock takes the node lock, waiting up to wait for an agent on
+// its way out to release it. A lock that cannot be set up at all, as on a
+// node without bpffs, is logged and skipped: the agent then relies on
+// otherAgents alone.
+func acquireNodeLock(ctx context.Context, dir string, wait time.Duration, logger logr.Logger) (*nodeLock, error) {
+	deadline := time.Now().Add(wait)
+	for logged := false; ; logged = true {
+		l, err := tryNodeLock(dir)
+		switch {
+		case err == nil:
+			return l, nil
+		case !errors.Is(err, errNodeLockHeld):
+			logger.Error(err, "node lock unavailable; only running agents that hold BPF programs are detected")
+			return nil, nil
+		case !time.Now().Before(deadline):
+			return nil, fmt.Errorf("%w (%s) after %s: refusing to attach the probes a second time; remove the duplicate agent, such as a second DaemonSet or TracerConfig covering this node", errNodeLockHeld, dir, wait)
+		}
+		if !logged {
+			logger.Info("another podtrace agent holds the node lock; waiting for it to exit", "lock", dir, "wait", wait)
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(nodeLockPoll):
+		}
+	}
+}
+
+// otherAgents returns the pids of podtrace agents on the node that hold
+// BPF programs, under config.ProcBasePath. It finds agents from before
+// the node lock, which never take it. It runs before this agent loads
+// its programs, so it never finds this agent itself.
+func otherAgents() []int {
+	entries, err := os.ReadDir(config.ProcBasePath)
+	if err != nil {
+		return nil
+	}
+	var pids []int
+	for _, e := range entries {
+		pid, err := strconv.Atoi(e.Name())
+		if err != nil || !e.IsDir() {
+			continue
+		}
+		cmdline, err := procfs.ReadFile(e.Name() + "/cmdline")
+		if err != nil || !isAgentCmdline(strings.Split(strings.TrimRight(string(cmdline), "\x00"), "\x00")) {
+			continue
+		}
+		if holdsBPFProgram(e.Name()) {
+			pids = append(pids, pid)
+		}
+	}
+	return pids
+}
+
+// isAgentCmdline reports whether args run `podtrace agent`.
+func isAgentCmdline(args []string) bool {
+	return len(args) > 1 && filepath.Base(args[0]) == "podtrace" && args[1] == "agent"
+}
+
+func holdsBPFProgram(pid string) bool {
+	fds, err := procfs.ReadDir(pid + "/fd")
+	if err != nil {
+		return false
+	}
+	for _, fd := range fds {
+		if target, err := procfs.Readlink(pid + "/fd/" + fd.Name()); err == nil && target == "anon_inode:bpf-prog" {
+			return true
+		}
+	}
+	return false
+}
+
+// claimNode takes the node lock and checks for agents that do not take
+// it. The caller releases the returned lock when the agent stops.
+func claimNode(ctx context.Context, dir string, logger logr.Logger) (*nodeLock, error) {
+	lock, err := acquireNodeLock(ctx, dir, config.AgentLockWait, logger)
+	if err != nil {
+		return nil, err
+	}
+	if pids := otherAgents(); len(pids) > 0 {
+		lock.release()
+		ids := make([]string, len(pids))
+		for i, pid := range pids {
+			ids[i] = strconv.Itoa(pid)
+		}
+		return nil, fmt.Errorf("a podtrace agent on this node already holds BPF programs (pid %s): refusing to attach the probes a second time; remove the duplicate agent", strings.Join(ids, ", "))
+	}
+	return lock, nil
+}
@@ -0,0 +1,92 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+func TestNodeLockExcludesSecondAgent(t *testing.T) {
+	dir := NodeLockDir(t.TempDir())
+	first, err := tryNodeLock(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tryNodeLock(dir); !errors.Is(err, errNodeLockHeld) {
+		t.Fatalf("second lock: %v, want errNodeLockHeld", err)
+	}
+
+	origPoll := nodeLockPoll
+	nodeLockPoll = 5 * time.Millisecond
+	t.Cleanup(func() { nodeLockPoll = origPoll })
+	if _, err := acquireNodeLock(context.Background(), dir, 20*time.Millisecond, logr.Discard()); !errors.Is(err, errNodeLockHeld) {
+		t.Fatalf("acquire past the wait: %v, want errNodeLockHeld", err)
+	}
+
+	// An agent on its way out releases the lock to the one waiting.
+	time.AfterFunc(20*time.Millisecond, first.release)
+	second, err := acquireNodeLock(context.Background(), dir, 5*time.Second, logr.Discard())
+	if err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+	second.release()
+}
+
+func TestAcquireNodeLockSkipsUnusableDir(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "not-a-dir")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := acquireNodeLock(context.Background(), filepath.Join(file, "lock"), time.Second, logr.Discard())
+	if err != nil || l != nil {
+		t.Errorf("acquireNodeLock = %v, %v; want no lock and no error", l, err)
+	}
+	l.release()
+}
+
+func TestOtherAgents(t *testing.T) {
+	proc := t.TempDir()
+	process := func(pid, cmdline string, fds ...string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(proc, pid, "fd"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(proc, pid, "cmdline"), []byte(cmdline), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		for i, target := range fds {
+			if err := os.Symlink(target, filepath.Join(proc, pid, "fd", string(rune('3'+i)))); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	process("10", "/podtrace\x00agent\x00--tracer-config\x00default\x00", "socket:[1]", "anon_inode:bpf-prog")
+	process("11", "/podtrace\x00agent\x00", "anon_inode:bpf-map")
+	process("12", "/usr/bin/podtrace\x00-n\x00shop\x00api\x00", "anon_inode:bpf-prog")
+	process("13", "/bin/sleep\x00agent\x00", "anon_inode:bpf-prog")
+
+	orig := config.ProcBasePath
+	config.ProcBasePath = proc
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.ProcBasePath = orig
+		procfs.ResetForTesting()
+	})
+
+	if got := otherAgents(); len(got) != 1 || got[0] != 10 {
+		t.Errorf("otherAgents = %v, want [10]", got)
+	}
+	_, err := claimNode(context.Background(), NodeLockDir(t.TempDir()), logr.Discard())
+	if err == nil || !strings.Contains(err.Error(), "pid 10") {
+		t.Errorf("claimNode = %v, want a refusal naming pid 10", err)
+	}
+}
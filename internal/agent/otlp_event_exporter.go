@@ -11,6 +11,7 @@ import (
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/fips"
 	"github.com/podtrace/podtrace/pkg/tracer"
 )
 
@@ -47,6 +48,9 @@ func newOTLPSpanExporter(b *BundlePayload) (*otlptrace.Exporter, error) {
 		insecure = *endpoint.insecure
 	}
 	if insecure {
+		if err := fips.CheckCleartext("OTLP exporter", endpoint.host); err != nil {
+			return nil, err
+		}
 		opts = append(opts, otlptracehttp.WithInsecure())
 	}
 	if len(b.Headers) > 0 || len(b.SecretHeaders) > 0 || b.HeaderName != "" {
@@ -135,6 +139,7 @@ var eventTypeNames = map[events.EventType]string{
 	events.EventUDPRecv:        "net.udp.recv",
 	events.EventTCPState:       "net.tcp.state",
 	events.EventTCPRetrans:     "net.tcp.retransmit",
+	events.EventTCPZeroWindow:  "net.tcp.zero_window",
 	events.EventNetDevError:    "net.dev.error",
 	events.EventWrite:          "fs.write",
 	events.EventRead:           "fs.read",
@@ -143,14 +148,30 @@ var eventTypeNames = map[events.EventType]string{
 	events.EventFsync:          "fs.fsync",
 	events.EventUnlink:         "fs.unlink",
 	events.EventRename:         "fs.rename",
+	events.EventPageCache:      "fs.page_cache",
+	events.EventFsNotify:       "fs.fsnotify",
 	events.EventSchedSwitch:    "cpu.sched",
+	events.EventThreadCPU:      "cpu.thread",
 	events.EventLockContention: "cpu.lock",
 	events.EventPageFault:      "mem.pagefault",
 	events.EventOOMKill:        "mem.oomkill",
 	events.EventExec:           "proc.exec",
 	events.EventFork:           "proc.fork",
+	events.EventCrash:          "proc.crash",
+	events.EventCRIOp:          "runtime.cri",
+	events.EventImagePull:      "runtime.image_pull",
+	events.EventDisruption:     "k8s.disruption",
+	events.EventNeighbor:       "k8s.neighbor",
+	events.EventChaos:          "net.chaos",
+	events.EventPause:          "proc.pause",
 	events.EventHTTPReq:        "http.req",
 	events.EventHTTPResp:       "http.resp",
 	events.EventHTTP3:          "http3.conn",
+	events.EventPodThroughput:  "net.throughput",
+	events.EventCPUPlacement:   "cpu.placement",
+	events.EventCompaction:     "mem.compaction",
+	events.EventTHPCollapse:    "mem.thp_collapse",
+	events.EventSwap:           "mem.swap",
+	events.EventTmpfs:          "mem.tmpfs",
 	events.EventDBQuery:        "db.query",
 }
@@ -0,0 +1,279 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/pkg/tracer"
+)
+
+// pressureDir holds the node-wide pressure stall information, whatever
+// cgroup the agent runs in.
+const pressureDir = "/proc/pressure"
+
+// pressureResources are the files under /proc/pressure the backoff reads.
+var pressureResources = []string{"cpu", "memory", "io"}
+
+// readPressure returns the "some avg10" of each resource under dir: the
+// share of the last 10 seconds in which at least one task stalled on it,
+// in percent. Resources the kernel does not report are left out.
+func readPressure(dir string) (map[string]float64, error) {
+	out := make(map[string]float64, len(pressureResources))
+	var firstErr error
+	for _, res := range pressureResources {
+		avg, err := readPressureFile(filepath.Join(dir, res))
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		out[res] = avg
+	}
+	if len(out) == 0 {
+		return nil, firstErr
+	}
+	return out, nil
+}
+
+func readPressureFile(path string) (float64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = f.Close() }()
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) < 2 || fields[0] != "some" {
+			continue
+		}
+		for _, kv := range fields[1:] {
+			if v, ok := strings.CutPrefix(kv, "avg10="); ok {
+				return strconv.ParseFloat(v, 64)
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("%s: no \"some avg10\" line", path)
+}
+
+// PressureBackoff sheds the agent's own load while the node is under
+// pressure, so the observer does not add to a node that is already
+// stalling. Between the High and Low watermarks it keeps its current
+// state, so a node hovering at one threshold does not flap the probes.
+type PressureBackoff struct {
+	// Dir holds the node's cpu, memory and io PSI files.
+	Dir      string
+	Interval time.Duration
+	// High and Low are the "some avg10" percentages that start and end
+	// the backoff.
+	High, Low float64
+	// Categories are the CRD filter categories kept attached during the
+	// backoff, and KeepOneIn the share of events still exported.
+	Categories []string
+	KeepOneIn  int
+	// Gate applies the probe categories; nil leaves the probes alone.
+	Gate    func(categories []string) error
+	Metrics *Metrics
+	Logger  logr.Logger
+
+	mu       sync.Mutex
+	wanted   []string
+	degraded bool
+	reason   string
+	since    time.Time
+
+	active atomic.Bool
+	seq    atomic.Uint64
+}
+
+// SetCategories is the category gate the reconciler applies: it records
+// the categories the CRs want and applies them, narrowed while backed off.
+func (p *PressureBackoff) SetCategories(categories []string) error {
+	p.mu.Lock()
+	p.wanted = nil
+	if categories != nil {
+		p.wanted = append(make([]string, 0, len(categories)), categories...)
+	}
+	effective := p.effectiveLocked()
+	p.mu.Unlock()
+	if p.Gate == nil {
+		return nil
+	}
+	return p.Gate(effective)
+}
+
+// effectiveLocked returns the categories to attach: those wanted, or their
+// overlap with Categories during a backoff. A CR that wants none of the
+// kept categories keeps its own, thinned by sampling alone.
+func (p *PressureBackoff) effectiveLocked() []string {
+	wanted := p.wanted
+	if wanted == nil {
+		if !p.degraded {
+			return nil
+		}
+		wanted = knownFilterCategories()
+	}
+	if !p.degraded {
+		return wanted
+	}
+	keep := make(map[string]struct{}, len(p.Categories))
+	for _, c := range p.Categories {
+		keep[c] = struct{}{}
+	}
+	var out []string
+	for _, c := range wanted {
+		if _, ok := keep[c]; ok {
+			out = append(out, c)
+		}
+	}
+	if len(out) == 0 {
+		return wanted
+	}
+	return out
+}
+
+// Degradation describes the backoff in force, or returns "" when there is
+// none.
+func (p *PressureBackoff) Degradation() string {
+	if p == nil {
+		return ""
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.degraded {
+		return ""
+	}
+	return fmt.Sprintf("backed off under node pressure (%s) since %s: probes narrowed to %s, exporting 1 in %d events",
+		p.reason, p.since.UTC().Format(time.RFC3339), strings.Join(p.effectiveLocked(), ","), p.KeepOneIn)
+}
+
+// Run reads the node's pressure every Interval until ctx is done. It
+// returns at once when the interval is zero or the node has no PSI.
+func (p *PressureBackoff) Run(ctx context.Context) error {
+	if p.Interval <= 0 {
+		return nil
+	}
+	if _, err := readPressure(p.Dir); err != nil {
+		p.Logger.Info("node pressure backoff disabled: pressure stall information unavailable", "dir", p.Dir, "error", err.Error())
+		return nil
+	}
+	t := time.NewTicker(p.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			avgs, err := readPressure(p.Dir)
+			if err != nil {
+				p.Logger.V(1).Info("read node pressure failed", "error", err.Error())
+				continue
+			}
+			p.observe(avgs, time.Now())
+		}
+	}
+}
+
+// observe moves the backoff on one reading of the node's pressure.
+func (p *PressureBackoff) observe(avgs map[string]float64, now time.Time) {
+	worst, worstAvg := "", 0.0
+	for _, res := range pressureResources {
+		if avg, ok := avgs[res]; ok && (worst == "" || avg > worstAvg) {
+			worst, worstAvg = res, avg
+		}
+	}
+
+	p.mu.Lock()
+	switch {
+	case !p.degraded && worstAvg >= p.High:
+		p.degraded = true
+		p.reason = fmt.Sprintf("%s some avg10 %.1f%%", worst, worstAvg)
+		p.since = now
+		if p.Metrics != nil {
+			p.Metrics.PressureBackoffs.WithLabelValues(worst).Inc()
+			p.Metrics.PressureDegraded.Set(1)
+		}
+		p.Logger.Info("node under pressure: narrowing probes and sampling events",
+			"resource", worst, "avg10", worstAvg, "categories", p.effectiveLocked(), "keepOneIn", p.KeepOneIn)
+	case p.degraded && worstAvg < p.Low:
+		p.degraded = false
+		if p.Metrics != nil {
+			p.Metrics.PressureDegraded.Set(0)
+		}
+		p.Logger.Info("node pressure cleared: restoring probes and sampling",
+			"after", now.Sub(p.since).Round(time.Second).String(), "categories", p.wanted)
+	default:
+		p.mu.Unlock()
+		return
+	}
+	p.active.Store(p.degraded && p.KeepOneIn > 1)
+	effective := p.effectiveLocked()
+	if effective == nil {
+		// No CR has asked for categories yet; undo the narrowing.
+		effective = knownFilterCategories()
+	}
+	p.mu.Unlock()
+
+	if p.Gate != nil {
+		if err := p.Gate(effective); err != nil {
+			p.Logger.V(1).Info("category gate apply failed", "error", err, "categories", effective)
+		}
+	}
+}
+
+// keep reports whether an event is exported: every one, or one in
+// KeepOneIn during a backoff.
+func (p *PressureBackoff) keep() bool {
+	if !p.active.Load() {
+		return true
+	}
+	return p.seq.Add(1)%uint64(p.KeepOneIn) == 0
+}
+
+// Wrap returns an exporter that hands next the events the backoff keeps.
+func (p *PressureBackoff) Wrap(next tracer.Exporter) tracer.Exporter {
+	return &pressureSampler{backoff: p, next: next}
+}
+
+type pressureSampler struct {
+	backoff *PressureBackoff
+	next    tracer.Exporter
+}
+
+func (s *pressureSampler) Name() string { return s.next.Name() }
+
+func (s *pressureSampler) Export(ctx context.Context, batch []*events.Event) error {
+	if !s.backoff.active.Load() {
+		return s.next.Export(ctx, batch)
+	}
+	kept := make([]*events.Event, 0, len(batch)/max(s.backoff.KeepOneIn, 1)+1)
+	for _, ev := range batch {
+		if s.backoff.keep() {
+			kept = append(kept, ev)
+		}
+	}
+	if shed := len(batch) - len(kept); shed > 0 && s.backoff.Metrics != nil {
+		s.backoff.Metrics.PressureShedEvents.Add(float64(shed))
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return s.next.Export(ctx, kept)
+}
+
+func (s *pressureSampler) Close(ctx context.Context) error { return s.next.Close(ctx) }
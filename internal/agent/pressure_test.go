@@ -0,0 +1,117 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestReadPressure(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cpu", "some avg10=63.20 avg60=40.00 avg300=12.50 total=123456\nfull avg10=0.00 avg60=0.00 avg300=0.00 total=0\n")
+	write("memory", "some avg10=1.50 avg60=0.80 avg300=0.10 total=999\nfull avg10=0.40 avg60=0.10 avg300=0.00 total=100\n")
+
+	got, err := readPressure(dir)
+	if err != nil {
+		t.Fatalf("readPressure: %v", err)
+	}
+	if want := map[string]float64{"cpu": 63.2, "memory": 1.5}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pressure = %v, want %v", got, want)
+	}
+	if _, err := readPressure(t.TempDir()); err == nil {
+		t.Error("expected an error without any PSI file")
+	}
+}
+
+func TestPressureBackoff(t *testing.T) {
+	var applied [][]string
+	p := &PressureBackoff{
+		High: 40, Low: 20,
+		Categories: []string{"dns", "net", "proc"},
+		KeepOneIn:  4,
+		Gate: func(categories []string) error {
+			applied = append(applied, categories)
+			return nil
+		},
+		Metrics: NewMetrics(),
+		Logger:  logr.Discard(),
+	}
+	if err := p.SetCategories([]string{"cpu", "fs", "net"}); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	p.observe(map[string]float64{"cpu": 55, "memory": 3, "io": 10}, now)
+	if d := p.Degradation(); !strings.Contains(d, "cpu some avg10 55.0%") || !strings.Contains(d, "probes narrowed to net") {
+		t.Errorf("degradation = %q", d)
+	}
+	// Between the watermarks the backoff holds, and a reconcile during it
+	// stays narrowed.
+	p.observe(map[string]float64{"cpu": 30}, now.Add(10*time.Second))
+	if err := p.SetCategories([]string{"fs", "net", "usdt"}); err != nil {
+		t.Fatal(err)
+	}
+	p.observe(map[string]float64{"cpu": 10, "io": 5}, now.Add(time.Minute))
+	if p.Degradation() != "" {
+		t.Errorf("still degraded after pressure cleared: %q", p.Degradation())
+	}
+	want := [][]string{{"cpu", "fs", "net"}, {"net"}, {"net"}, {"fs", "net", "usdt"}}
+	if !reflect.DeepEqual(applied, want) {
+		t.Errorf("applied categories = %v, want %v", applied, want)
+	}
+
+	// A CR that wants none of the kept categories is only sampled.
+	_ = p.SetCategories([]string{"fs"})
+	p.observe(map[string]float64{"io": 80}, now.Add(2*time.Minute))
+	if got := applied[len(applied)-1]; !reflect.DeepEqual(got, []string{"fs"}) {
+		t.Errorf("fs-only CR narrowed to %v", got)
+	}
+}
+
+// batchRecorder records the size of every batch it is handed.
+type batchRecorder struct {
+	fakeExporter
+	sizes []int
+}
+
+func (e *batchRecorder) Export(_ context.Context, batch []*events.Event) error {
+	e.sizes = append(e.sizes, len(batch))
+	return nil
+}
+
+func TestPressureSampler(t *testing.T) {
+	p := &PressureBackoff{High: 40, Low: 20, KeepOneIn: 4, Metrics: NewMetrics(), Logger: logr.Discard()}
+	next := &batchRecorder{fakeExporter: fakeExporter{name: "cr-router"}}
+	exp := p.Wrap(next)
+	if exp.Name() != "cr-router" {
+		t.Errorf("name = %q", exp.Name())
+	}
+	batch := make([]*events.Event, 20)
+	for i := range batch {
+		batch[i] = &events.Event{Type: events.EventTCPSend}
+	}
+
+	_ = exp.Export(context.Background(), batch)
+	p.observe(map[string]float64{"memory": 70}, time.Now())
+	_ = exp.Export(context.Background(), batch)
+	_ = exp.Export(context.Background(), batch[:3])
+	p.observe(map[string]float64{"memory": 5}, time.Now())
+	_ = exp.Export(context.Background(), batch)
+
+	if want := []int{20, 5, 20}; !reflect.DeepEqual(next.sizes, want) {
+		t.Errorf("exported batch sizes = %v, want %v", next.sizes, want)
+	}
+}
@@ -775,21 +775,24 @@ func filterToEventTypes(f podtracev1alpha1.EventFilter) []events.EventType {
 		return []events.EventType{
 			events.EventConnect, events.EventTCPSend, events.EventTCPRecv,
 			events.EventUDPSend, events.EventUDPRecv, events.EventTCPState,
-			events.EventTCPRetrans, events.EventNetDevError,
+			events.EventTCPRetrans, events.EventTCPZeroWindow, events.EventNetDevError,
 			events.EventFastCGIReq, events.EventFastCGIResp,
 			events.EventHTTPReq, events.EventHTTPResp,
 			events.EventGRPCMethod, events.EventHTTP3,
+			events.EventPodThroughput,
 		}
 	case podtracev1alpha1.FilterFS:
 		return []events.EventType{
 			events.EventOpen, events.EventClose, events.EventRead,
 			events.EventWrite, events.EventFsync,
-			events.EventUnlink, events.EventRename,
+			events.EventUnlink, events.EventRename, events.EventPageCache,
+			events.EventFsNotify,
 		}
 	case podtracev1alpha1.FilterCPU:
-		return []events.EventType{events.EventSchedSwitch, events.EventLockContention}
+		return []events.EventType{events.EventSchedSwitch, events.EventLockContention, events.EventCPUPlacement, events.EventThreadCPU}
 	case podtracev1alpha1.FilterProc:
-		return []events.EventType{events.EventExec, events.EventFork, events.EventOOMKill}
+		return []events.EventType{events.EventExec, events.EventFork, events.EventOOMKill, events.EventCrash,
+			events.EventCompaction, events.EventTHPCollapse, events.EventSwap, events.EventTmpfs}
 	case podtracev1alpha1.FilterCrypto:
 		return []events.EventType{events.EventAFALG}
 	case podtracev1alpha1.FilterUSDT:
@@ -101,7 +101,8 @@ func TestFiltersToSet_DedupesAcrossCategories(t *testing.T) {
 		events.EventDNS, events.EventDNSQuery,
 		events.EventOpen, events.EventClose,
 		events.EventRead, events.EventWrite, events.EventFsync,
-		events.EventUnlink, events.EventRename,
+		events.EventUnlink, events.EventRename, events.EventPageCache,
+		events.EventFsNotify,
 	}
 	if len(got) != len(want) {
 		t.Fatalf("got %d entries, want %d (%v)", len(got), len(want), got)
@@ -27,6 +27,7 @@ import (
 
 	podtracev1alpha1 "github.com/podtrace/podtrace/api/v1alpha1"
 	"github.com/podtrace/podtrace/internal/alerting"
+	"github.com/podtrace/podtrace/internal/clock"
 	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/ebpf/probes"
 	"github.com/podtrace/podtrace/internal/events"
@@ -60,6 +61,10 @@ type Options struct {
 
 	StatusReportInterval time.Duration
 
+	// NodeLockDir, when set, is the node lock the agent holds while it
+	// runs, so that no second agent attaches the probes on the same node.
+	NodeLockDir string
+
 	BackendFactory func() (tracer.TracerBackend, error)
 }
 
@@ -127,6 +132,16 @@ func Run(ctx context.Context, opts Options) error {
 
 	probes.SetAttachObserver(&attachMetricsObserver{metrics: metrics})
 
+	if opts.NodeLockDir != "" {
+		lock, err := claimNode(ctx, opts.NodeLockDir, logger)
+		if err != nil {
+			return err
+		}
+		defer lock.release()
+	}
+
+	clock.StartCalibration(ctx, config.ClockRecalibrateInterval)
+
 	backend, backendErr := buildBackend(opts, logger)
 	if backendErr != nil {
 		reason := tracer.ClassifyBackendError(backendErr)
@@ -135,7 +150,33 @@ func Run(ctx context.Context, opts Options) error {
 		metrics.BackendDegraded.WithLabelValues(reason).Set(1)
 	}
 
-	exporters := []tracer.Exporter{router}
+	backoff := &PressureBackoff{
+		Dir:        pressureDir,
+		Interval:   config.AgentPressureInterval,
+		High:       config.AgentPressureHigh,
+		Low:        config.AgentPressureLow,
+		Categories: strings.Split(config.AgentPressureCategories, ","),
+		KeepOneIn:  config.AgentPressureKeepOneIn,
+		Gate:       makeCategoryGate(backend),
+		Metrics:    metrics,
+		Logger:     logger.WithName("pressure"),
+	}
+
+	watchdog := &SelfWatchdog{
+		Interval:      config.AgentWatchdogInterval,
+		Window:        config.AgentWatchdogWindow,
+		MaxGoroutines: config.AgentWatchdogMaxGoroutines,
+		MaxHeapBytes:  uint64(max(config.AgentWatchdogMaxHeapMB, 0)) << 20,
+		MaxMapFill:    config.AgentWatchdogMaxMapFill,
+		Recycle:       config.AgentWatchdogRecycle,
+		Metrics:       metrics,
+		Logger:        logger.WithName("watchdog"),
+	}
+	if r, ok := backend.(tracer.MapFillReporter); ok {
+		watchdog.MapFill = r.MapFill
+	}
+
+	exporters := []tracer.Exporter{backoff.Wrap(router)}
 	engine, err := tracer.NewEngine(backend, exporters, tracer.Config{
 		Observer: metrics.EngineObserver(),
 	})
@@ -153,20 +194,23 @@ func Run(ctx context.Context, opts Options) error {
 		TargetsCh:       targetsCh,
 		Metrics:         metrics,
 		Enricher:        enricher,
-		CategoryGate:    makeCategoryGate(backend),
+		CategoryGate:    backoff.SetCategories,
 	}
 	if err := reconciler.SetupWithManager(mgr); err != nil {
 		return fmt.Errorf("setup reconciler: %w", err)
 	}
 
 	writer := &StatusWriter{
-		Client:     mgr.GetClient(),
-		NodeName:   opts.NodeName,
-		Interval:   opts.StatusReportInterval,
-		Router:     router,
-		Ready:      probeSrv.IsReady,
-		Heartbeat:  probeSrv.Heartbeat,
-		BackendErr: backendErr,
+		Client:      mgr.GetClient(),
+		NodeName:    opts.NodeName,
+		Interval:    opts.StatusReportInterval,
+		Router:      router,
+		Ready:       probeSrv.IsReady,
+		Heartbeat:   probeSrv.Heartbeat,
+		BackendErr:  backendErr,
+		Degradation: backoff.Degradation,
+
+		SelfDegradation: watchdog.Degradation,
 	}
 
 	g, gctx := errgroup.WithContext(ctx)
@@ -175,6 +219,8 @@ func Run(ctx context.Context, opts Options) error {
 	g.Go(func() error { return engine.Run(gctx, targetsCh) })
 	g.Go(func() error { return writer.Run(gctx) })
 	g.Go(func() error { return probeSrv.Run(gctx) })
+	g.Go(func() error { return backoff.Run(gctx) })
+	g.Go(func() error { return watchdog.Run(gctx) })
 	g.Go(func() error { return serveMetrics(gctx, opts.MetricsAddr, metrics, logger) })
 
 	g.Go(func() error {
@@ -28,6 +28,11 @@ type StatusWriter struct {
 
 	BackendErr error
 
+	// Degradation describes a node-pressure backoff in force, if any.
+	Degradation func() string
+	// SelfDegradation describes the agent's own leak, if any.
+	SelfDegradation func() string
+
 	reportedKeys map[CRKey]struct{}
 }
 
@@ -69,6 +74,18 @@ func (w *StatusWriter) emitOnce(ctx context.Context) error {
 	current := make(map[CRKey]struct{}, len(rules))
 	for _, rule := range rules {
 		entry := buildNodeStatusEntry(w.NodeName, &rule, stats[rule.Key], agentReady, w.BackendErr, time.Now())
+		if w.Degradation != nil && entry.Reason == "" {
+			if msg := w.Degradation(); msg != "" {
+				entry.Message = msg
+				entry.Reason = podtracev1alpha1.NodeStatusReasonNodePressure
+			}
+		}
+		if w.SelfDegradation != nil && entry.Reason == "" {
+			if msg := w.SelfDegradation(); msg != "" {
+				entry.Message = msg
+				entry.Reason = podtracev1alpha1.NodeStatusReasonSelfDegraded
+			}
+		}
 		if err := w.patchCRStatus(ctx, rule.Key, entry); err != nil && firstErr == nil {
 			firstErr = err
 		}
@@ -0,0 +1,231 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// ErrSelfRecycle ends SelfWatchdog.Run when the agent leaks and is set to
+// recycle itself: the agent exits and the DaemonSet restarts it with a
+// fresh tracing session.
+var ErrSelfRecycle = errors.New("agent: self watchdog recycling a leaking agent")
+
+// Watchdog resources other than the per-map fill, which is keyed
+// "map/<name>".
+const (
+	watchGoroutines = "goroutines"
+	watchHeap       = "heap"
+	watchMapPrefix  = "map/"
+)
+
+// A floor has to rise by half and by at least this much before it counts
+// as a leak, so a small agent settling in does not trip it.
+var watchMinRise = map[string]float64{
+	watchGoroutines: 100,
+	watchHeap:       64 << 20,
+	watchMapPrefix:  0.1,
+}
+
+// SelfWatchdog guards a long-running agent against its own leaks. Every
+// Interval it samples the agent's goroutines, heap in use and BPF map
+// fill. A resource is leaking when it is past its limit, or when the
+// lowest value of the newer half of the last Window samples is half as
+// high again as the lowest of the older half: garbage collection and
+// bursts move the peaks, a leak lifts the floor.
+type SelfWatchdog struct {
+	Interval time.Duration
+	Window   int
+
+	MaxGoroutines int
+	MaxHeapBytes  uint64
+	MaxMapFill    float64
+
+	// Recycle makes Run return ErrSelfRecycle once the agent leaks;
+	// otherwise the agent only reports itself degraded.
+	Recycle bool
+	// MapFill reports the BPF map fill; nil leaves the maps out.
+	MapFill func() map[string]float64
+	Metrics *Metrics
+	Logger  logr.Logger
+
+	// read samples the process; nil is the Go runtime.
+	read func() map[string]float64
+
+	mu      sync.Mutex
+	series  map[string][]float64
+	reasons []string
+	since   time.Time
+}
+
+// Run samples the agent every Interval until ctx is done. It returns at
+// once when the interval is zero.
+func (w *SelfWatchdog) Run(ctx context.Context) error {
+	if w.Interval <= 0 {
+		return nil
+	}
+	t := time.NewTicker(w.Interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+			if w.observe(w.sample(), time.Now()) && w.Recycle {
+				msg := w.Degradation()
+				w.Logger.Info("agent is leaking: exiting so it is restarted with a fresh session", "degradation", msg)
+				return fmt.Errorf("%w: %s", ErrSelfRecycle, msg)
+			}
+		}
+	}
+}
+
+func (w *SelfWatchdog) sample() map[string]float64 {
+	if w.read != nil {
+		return w.read()
+	}
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	out := map[string]float64{
+		watchGoroutines: float64(runtime.NumGoroutine()),
+		watchHeap:       float64(ms.HeapInuse),
+	}
+	if w.MapFill != nil {
+		for name, fill := range w.MapFill() {
+			out[watchMapPrefix+name] = fill
+		}
+	}
+	return out
+}
+
+// observe adds one sample and reports whether the agent is degraded.
+func (w *SelfWatchdog) observe(sample map[string]float64, now time.Time) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.series == nil {
+		w.series = make(map[string][]float64, len(sample))
+	}
+	window := max(w.Window, 2)
+	var reasons []string
+	for key, v := range sample {
+		s := append(w.series[key], v)
+		if len(s) > window {
+			s = s[len(s)-window:]
+		}
+		w.series[key] = s
+		w.record(key, v)
+		if limit := w.limit(key); limit > 0 && v > limit {
+			reasons = append(reasons, fmt.Sprintf("%s %s over the %s limit", key, formatWatch(key, v), formatWatch(key, limit)))
+			continue
+		}
+		if len(s) == window {
+			if older, newer, ok := risingFloor(s, riseFor(key)); ok {
+				reasons = append(reasons, fmt.Sprintf("%s floor rose from %s to %s over %d samples",
+					key, formatWatch(key, older), formatWatch(key, newer), len(s)))
+			}
+		}
+	}
+	for key := range w.series {
+		if _, ok := sample[key]; !ok {
+			delete(w.series, key)
+		}
+	}
+	sort.Strings(reasons)
+
+	switch {
+	case len(reasons) > 0 && len(w.reasons) == 0:
+		w.since = now
+		w.Logger.Info("agent self-degraded: its own resource use keeps growing", "reasons", reasons, "recycle", w.Recycle)
+	case len(reasons) == 0 && len(w.reasons) > 0:
+		w.Logger.Info("agent self-degradation cleared", "after", now.Sub(w.since).Round(time.Second).String())
+	}
+	w.reasons = reasons
+	if w.Metrics != nil {
+		degraded := 0.0
+		if len(reasons) > 0 {
+			degraded = 1
+		}
+		w.Metrics.SelfDegraded.Set(degraded)
+	}
+	return len(reasons) > 0
+}
+
+func (w *SelfWatchdog) record(key string, v float64) {
+	if w.Metrics == nil {
+		return
+	}
+	switch {
+	case key == watchGoroutines:
+		w.Metrics.SelfGoroutines.Set(v)
+	case key == watchHeap:
+		w.Metrics.SelfHeapBytes.Set(v)
+	case strings.HasPrefix(key, watchMapPrefix):
+		w.Metrics.BPFMapFill.WithLabelValues(strings.TrimPrefix(key, watchMapPrefix)).Set(v)
+	}
+}
+
+func (w *SelfWatchdog) limit(key string) float64 {
+	switch {
+	case key == watchGoroutines:
+		return float64(w.MaxGoroutines)
+	case key == watchHeap:
+		return float64(w.MaxHeapBytes)
+	case strings.HasPrefix(key, watchMapPrefix):
+		return w.MaxMapFill
+	}
+	return 0
+}
+
+func riseFor(key string) float64 {
+	if strings.HasPrefix(key, watchMapPrefix) {
+		return watchMinRise[watchMapPrefix]
+	}
+	return watchMinRise[key]
+}
+
+// risingFloor compares the lowest value of the older and the newer half
+// of s.
+func risingFloor(s []float64, minRise float64) (older, newer float64, ok bool) {
+	half := len(s) / 2
+	older, newer = minOf(s[:half]), minOf(s[half:])
+	return older, newer, newer >= older*1.5 && newer-older >= minRise
+}
+
+func minOf(s []float64) float64 {
+	m := s[0]
+	for _, v := range s[1:] {
+		m = min(m, v)
+	}
+	return m
+}
+
+func formatWatch(key string, v float64) string {
+	switch {
+	case key == watchHeap:
+		return fmt.Sprintf("%.0fMiB", v/(1<<20))
+	case strings.HasPrefix(key, watchMapPrefix):
+		return fmt.Sprintf("%.0f%%", v*100)
+	}
+	return fmt.Sprintf("%.0f", v)
+}
+
+// Degradation describes the agent's self-degradation, or returns "" when
+// there is none.
+func (w *SelfWatchdog) Degradation() string {
+	if w == nil {
+		return ""
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.reasons) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("agent self-degraded since %s: %s", w.since.UTC().Format(time.RFC3339), strings.Join(w.reasons, "; "))
+}
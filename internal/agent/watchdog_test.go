@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+func TestSelfWatchdog(t *testing.T) {
+	w := &SelfWatchdog{
+		Window:        6,
+		MaxGoroutines: 1000,
+		MaxHeapBytes:  1 << 30,
+		MaxMapFill:    0.9,
+		Metrics:       NewMetrics(),
+		Logger:        logr.Discard(),
+	}
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	// Goroutines swing with bursts but fall back to the same floor.
+	for i, g := range []float64{200, 600, 210, 500, 205, 700} {
+		if w.observe(map[string]float64{watchGoroutines: g, watchHeap: 80 << 20}, now.Add(time.Duration(i)*time.Minute)) {
+			t.Fatalf("degraded on a steady floor: %q", w.Degradation())
+		}
+	}
+	// The heap floor climbs: a leak.
+	for i, mib := range []float64{100, 120, 110, 240, 260, 250} {
+		w.observe(map[string]float64{watchGoroutines: 200, watchHeap: mib * (1 << 20)}, now.Add(time.Duration(6+i)*time.Minute))
+	}
+	if d := w.Degradation(); !strings.Contains(d, "heap floor rose from 100MiB to 240MiB over 6 samples") {
+		t.Errorf("degradation = %q", d)
+	}
+
+	// A map past its limit degrades at once; a full map that empties clears.
+	w = &SelfWatchdog{Window: 6, MaxMapFill: 0.9, Logger: logr.Discard()}
+	if !w.observe(map[string]float64{watchMapPrefix + "socket_conns": 0.95}, now) {
+		t.Fatal("95% full map not reported")
+	}
+	if d := w.Degradation(); !strings.Contains(d, "map/socket_conns 95% over the 90% limit") {
+		t.Errorf("degradation = %q", d)
+	}
+	if w.observe(map[string]float64{watchMapPrefix + "socket_conns": 0.2}, now.Add(time.Minute)) || w.Degradation() != "" {
+		t.Errorf("still degraded: %q", w.Degradation())
+	}
+}
+
+func TestSelfWatchdogRecycle(t *testing.T) {
+	w := &SelfWatchdog{
+		Interval:      time.Millisecond,
+		MaxGoroutines: 10,
+		Recycle:       true,
+		Logger:        logr.Discard(),
+		read:          func() map[string]float64 { return map[string]float64{watchGoroutines: 50} },
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := w.Run(ctx); !errors.Is(err, ErrSelfRecycle) {
+		t.Errorf("Run = %v, want ErrSelfRecycle", err)
+	}
+
+	w.Recycle = false
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := w.Run(ctx); err != nil {
+		t.Errorf("Run without recycling = %v", err)
+	}
+	if w.Degradation() == "" {
+		t.Error("leak not reported without recycling")
+	}
+}
@@ -0,0 +1,149 @@
+// Package annotation lets an operator drop markers ("deployed v2", "enabled
+// feature flag") into a running trace through a local unix socket. Each
+// marker becomes an EventAnnotation in the event stream, so reports, exports
+// and tracing spans can split the window into before and after.
+package annotation
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/sanitize"
+)
+
+// MaxTextLen is the longest annotation kept; longer text is truncated to fit
+// the event target, like every other event string.
+const MaxTextLen = 127
+
+// Server accepts annotation lines on a unix socket. The protocol is one
+// annotation per line; each accepted line is answered with "ok".
+type Server struct {
+	ln   net.Listener
+	path string
+	now  func() time.Time
+	wg   sync.WaitGroup
+}
+
+// Listen creates the control socket at path, replacing a stale socket left by
+// a previous run. The socket is only accessible to the owner.
+func Listen(path string) (*Server, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("annotation socket %s exists and is not a socket", path)
+		}
+		_ = os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on annotation socket: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("restrict annotation socket: %w", err)
+	}
+	return &Server{ln: ln, path: path, now: time.Now}, nil
+}
+
+// Serve emits an EventAnnotation on out for every line received until ctx is
+// done, then closes the socket.
+func (s *Server) Serve(ctx context.Context, out chan<- *events.Event) {
+	go func() {
+		<-ctx.Done()
+		_ = s.ln.Close()
+	}()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				logger.Warn("Annotation socket accept failed", zap.Error(err))
+			}
+			break
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(ctx, conn, out)
+		}()
+	}
+	s.wg.Wait()
+	_ = os.Remove(s.path)
+}
+
+func (s *Server) handle(ctx context.Context, conn net.Conn, out chan<- *events.Event) {
+	defer func() { _ = conn.Close() }()
+	sc := bufio.NewScanner(conn)
+	for sc.Scan() {
+		text := Normalize(sc.Text())
+		if text == "" {
+			_, _ = fmt.Fprintln(conn, "error: empty annotation")
+			continue
+		}
+		select {
+		case out <- NewEvent(text, s.now()):
+			logger.Info("Annotation recorded", zap.String("text", text))
+			_, _ = fmt.Fprintln(conn, "ok")
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// NewEvent builds the annotation event for text at wall-clock time at. A
+// time before boot cannot be placed on the BPF clock and lands at boot.
+func NewEvent(text string, at time.Time) *events.Event {
+	if clock.BeforeBoot(at) {
+		logger.Warn("Annotation time is before boot; placing it at boot", zap.String("text", text), zap.Time("at", at))
+	}
+	return &events.Event{
+		Type:      events.EventAnnotation,
+		Timestamp: clock.WallToBPFTimestamp(at),
+		Target:    text,
+	}
+}
+
+// Normalize trims text, neutralizes terminal control characters and
+// truncates it to MaxTextLen bytes.
+func Normalize(text string) string {
+	text = sanitize.Terminal(strings.TrimSpace(text))
+	if len(text) > MaxTextLen {
+		text = strings.ToValidUTF8(text[:MaxTextLen], "")
+	}
+	return text
+}
+
+// Send delivers one annotation to the podtrace listening on path.
+func Send(path, text string) error {
+	text = Normalize(text)
+	if text == "" {
+		return errors.New("annotation text is empty")
+	}
+	conn, err := net.DialTimeout("unix", path, 5*time.Second)
+	if err != nil {
+		return fmt.Errorf("connect to annotation socket: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := fmt.Fprintln(conn, text); err != nil {
+		return fmt.Errorf("send annotation: %w", err)
+	}
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("read annotation reply: %w", err)
+	}
+	if reply = strings.TrimSpace(reply); reply != "ok" {
+		return fmt.Errorf("annotation rejected: %s", reply)
+	}
+	return nil
+}
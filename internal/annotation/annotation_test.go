@@ -0,0 +1,70 @@
+package annotation
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestSendRecordsAnnotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "annotate.sock")
+	srv, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	out := make(chan *events.Event, 1)
+	done := make(chan struct{})
+	go func() {
+		srv.Serve(ctx, out)
+		close(done)
+	}()
+
+	if err := Send(path, "  enabled feature flag\n"); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	select {
+	case e := <-out:
+		if e.Type != events.EventAnnotation || e.Target != "enabled feature flag" {
+			t.Errorf("unexpected event %+v", e)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no annotation event emitted")
+	}
+
+	cancel()
+	<-done
+	if err := Send(path, "after shutdown"); err == nil {
+		t.Error("expected Send to fail once the server stopped")
+	}
+}
+
+func TestSendRejectsEmptyText(t *testing.T) {
+	if err := Send(filepath.Join(t.TempDir(), "missing.sock"), " \t "); err == nil {
+		t.Error("expected error for empty annotation")
+	}
+}
+
+func TestListenRefusesNonSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regular")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(path); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("expected not-a-socket error, got %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	if got := Normalize("\x1b[31mred\n"); strings.ContainsRune(got, '\x1b') || !strings.HasSuffix(got, "red") {
+		t.Errorf("Normalize left control characters in %q", got)
+	}
+	if got := Normalize(strings.Repeat("é", 100)); len(got) > MaxTextLen || !strings.HasPrefix(got, "éé") {
+		t.Errorf("Normalize truncated to %d bytes: %q", len(got), got)
+	}
+}
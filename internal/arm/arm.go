@@ -0,0 +1,146 @@
+// Package arm is the control socket of a podtrace started with --armed: its
+// probes are loaded and attached but emit nothing until `podtrace arm --fire`
+// asks it, through this socket, to start emitting.
+package arm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// Commands and the states a status request answers with. The protocol is
+// one command per line, each answered with one line; a failure is answered
+// with "error: <reason>".
+const (
+	CmdFire   = "fire"
+	CmdStatus = "status"
+
+	StateArmed = "armed"
+	StateFired = "fired"
+)
+
+const dialTimeout = 5 * time.Second
+
+// Switch is what the socket drives.
+type Switch interface {
+	// Fire starts emission; firing again does nothing.
+	Fire() error
+	Fired() bool
+}
+
+// Server accepts arm commands on a unix socket.
+type Server struct {
+	ln   net.Listener
+	path string
+	wg   sync.WaitGroup
+}
+
+// Listen creates the socket at path, replacing a stale socket left by a
+// previous run but not one another armed podtrace still listens on. The
+// socket is only accessible to the owner.
+func Listen(path string) (*Server, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("arm socket %s exists and is not a socket", path)
+		}
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("arm socket %s is in use by another podtrace; pass a different --arm-socket", path)
+		}
+		_ = os.Remove(path)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("listen on arm socket: %w", err)
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = ln.Close()
+		return nil, fmt.Errorf("restrict arm socket: %w", err)
+	}
+	return &Server{ln: ln, path: path}, nil
+}
+
+// Serve answers commands with sw until ctx is done, then removes the socket.
+func (s *Server) Serve(ctx context.Context, sw Switch) {
+	go func() {
+		<-ctx.Done()
+		_ = s.ln.Close()
+	}()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, net.ErrClosed) {
+				logger.Warn("Arm socket accept failed", zap.Error(err))
+			}
+			break
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn, sw)
+		}()
+	}
+	s.wg.Wait()
+	_ = os.Remove(s.path)
+}
+
+func (s *Server) handle(conn net.Conn, sw Switch) {
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	sc := bufio.NewScanner(conn)
+	if !sc.Scan() {
+		return
+	}
+	_, _ = fmt.Fprintln(conn, reply(strings.TrimSpace(sc.Text()), sw))
+}
+
+func reply(cmd string, sw Switch) string {
+	switch cmd {
+	case CmdStatus:
+		if sw.Fired() {
+			return StateFired
+		}
+		return StateArmed
+	case CmdFire:
+		if err := sw.Fire(); err != nil {
+			return "error: " + err.Error()
+		}
+		return StateFired
+	default:
+		return fmt.Sprintf("error: unknown command %q", cmd)
+	}
+}
+
+// Send delivers cmd to the armed podtrace listening on path and returns its
+// reply.
+func Send(path, cmd string) (string, error) {
+	conn, err := net.DialTimeout("unix", path, dialTimeout)
+	if err != nil {
+		return "", fmt.Errorf("connect to arm socket (is a podtrace running with --armed?): %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_ = conn.SetDeadline(time.Now().Add(dialTimeout))
+	if _, err := fmt.Fprintln(conn, cmd); err != nil {
+		return "", fmt.Errorf("send %s: %w", cmd, err)
+	}
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("read %s reply: %w", cmd, err)
+	}
+	line = strings.TrimSpace(line)
+	if reason, ok := strings.CutPrefix(line, "error: "); ok {
+		return "", errors.New(reason)
+	}
+	return line, nil
+}
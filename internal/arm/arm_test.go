@@ -0,0 +1,96 @@
+package arm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type fakeSwitch struct {
+	fires atomic.Int32
+	err   error
+}
+
+func (f *fakeSwitch) Fire() error {
+	if f.err != nil {
+		return f.err
+	}
+	f.fires.Add(1)
+	return nil
+}
+
+func (f *fakeSwitch) Fired() bool { return f.fires.Load() > 0 }
+
+func serve(t *testing.T, sw Switch) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "arm.sock")
+	srv, err := Listen(path)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.Serve(ctx, sw)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	return path
+}
+
+func TestFireAndStatus(t *testing.T) {
+	sw := &fakeSwitch{}
+	path := serve(t, sw)
+
+	if got, err := Send(path, CmdStatus); err != nil || got != StateArmed {
+		t.Fatalf("status before fire = %q, %v; want %q", got, err, StateArmed)
+	}
+	for i := 0; i < 2; i++ {
+		if got, err := Send(path, CmdFire); err != nil || got != StateFired {
+			t.Fatalf("fire #%d = %q, %v; want %q", i+1, got, err, StateFired)
+		}
+	}
+	if got, err := Send(path, CmdStatus); err != nil || got != StateFired {
+		t.Errorf("status after fire = %q, %v; want %q", got, err, StateFired)
+	}
+}
+
+func TestSendReportsErrors(t *testing.T) {
+	path := serve(t, &fakeSwitch{err: errors.New("map update failed")})
+	if _, err := Send(path, CmdFire); err == nil || err.Error() != "map update failed" {
+		t.Errorf("fire error = %v, want the switch's error", err)
+	}
+	if _, err := Send(path, "disarm"); err == nil || !strings.Contains(err.Error(), "unknown command") {
+		t.Errorf("unknown command error = %v", err)
+	}
+}
+
+func TestSendWithoutServer(t *testing.T) {
+	if _, err := Send(filepath.Join(t.TempDir(), "missing.sock"), CmdStatus); err == nil {
+		t.Error("expected Send to fail without a listening podtrace")
+	}
+}
+
+func TestListenRefusesSocketInUse(t *testing.T) {
+	path := serve(t, &fakeSwitch{})
+	if _, err := Listen(path); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected in-use error, got %v", err)
+	}
+}
+
+func TestListenRefusesNonSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "regular")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen(path); err == nil || !strings.Contains(err.Error(), "not a socket") {
+		t.Errorf("expected not-a-socket error, got %v", err)
+	}
+}
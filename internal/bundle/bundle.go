@@ -0,0 +1,206 @@
+// Package bundle packages the artifacts of one podtrace run (report,
+// capture, environment, pod specs, Kubernetes events and logs) into a single
+// tar.gz, so every incident ticket gets the same attachment.
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Entry names inside the archive.
+const (
+	ReportText   = "report.txt"
+	ReportHTML   = "report.html"
+	ReportJSON   = "report.json"
+	Capture      = "capture.jsonl"
+	Capabilities = "capabilities.json"
+	PodsDir      = "pods"
+	K8sEvents    = "k8s-events.json"
+	Logs         = "podtrace.log"
+	Manifest     = "manifest.json"
+)
+
+// Bundle collects files in memory until WriteFile.
+type Bundle struct {
+	created time.Time
+	files   map[string][]byte
+	omitted map[string]string
+}
+
+// New returns an empty bundle stamped with created.
+func New(created time.Time) *Bundle {
+	return &Bundle{created: created, files: make(map[string][]byte), omitted: make(map[string]string)}
+}
+
+// Add stores data under name, replacing an earlier entry of that name.
+func (b *Bundle) Add(name string, data []byte) {
+	name = path.Clean(name)
+	b.files[name] = data
+	delete(b.omitted, name)
+}
+
+// AddJSON stores v as indented JSON.
+func (b *Bundle) AddJSON(name string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("bundle %s: %w", name, err)
+	}
+	b.Add(name, append(data, '\n'))
+	return nil
+}
+
+// Omit records in the manifest why name is missing, so a reader can tell a
+// failed collection from one that was never attempted.
+func (b *Bundle) Omit(name, reason string) {
+	name = path.Clean(name)
+	if _, ok := b.files[name]; !ok {
+		b.omitted[name] = reason
+	}
+}
+
+type manifestEntry struct {
+	Name   string `json:"name"`
+	Size   int    `json:"size,omitempty"`
+	Reason string `json:"reason,omitempty"`
+}
+
+type manifest struct {
+	Created time.Time       `json:"created"`
+	Files   []manifestEntry `json:"files"`
+	Omitted []manifestEntry `json:"omitted,omitempty"`
+}
+
+// Bytes renders the archive: manifest.json first, then the files in name
+// order.
+func (b *Bundle) Bytes() ([]byte, error) {
+	m := manifest{Created: b.created.UTC()}
+	names := make([]string, 0, len(b.files))
+	for name := range b.files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		m.Files = append(m.Files, manifestEntry{Name: name, Size: len(b.files[name])})
+	}
+	for name, reason := range b.omitted {
+		m.Omitted = append(m.Omitted, manifestEntry{Name: name, Reason: reason})
+	}
+	sort.Slice(m.Omitted, func(i, j int) bool { return m.Omitted[i].Name < m.Omitted[j].Name })
+	manifestData, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	add := func(name string, data []byte) error {
+		hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: b.created, Typeflag: tar.TypeReg}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		_, err := tw.Write(data)
+		return err
+	}
+	if err := add(Manifest, append(manifestData, '\n')); err != nil {
+		return nil, err
+	}
+	for _, name := range names {
+		if err := add(name, b.files[name]); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteFile writes the archive to file, readable by the owner only: the
+// capture and logs may hold request targets and payload details.
+func (b *Bundle) WriteFile(file string) error {
+	data, err := b.Bytes()
+	if err != nil {
+		return fmt.Errorf("build bundle: %w", err)
+	}
+	if err := os.WriteFile(file, data, 0o600); err != nil { // #nosec G306 G304 -- operator-supplied output path.
+		return fmt.Errorf("write bundle: %w", err)
+	}
+	return nil
+}
+
+var reportPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>body{font-family:sans-serif;margin:2em}pre{background:#f6f8fa;padding:1em;overflow-x:auto}</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Generated {{.Created}}</p>
+<pre>{{.Report}}</pre>
+</body>
+</html>
+`))
+
+// HTMLReport wraps the text report in a standalone HTML page, for ticket
+// systems that preview HTML attachments.
+func HTMLReport(title, report string, created time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	err := reportPage.Execute(&buf, struct {
+		Title, Report, Created string
+	}{title, report, created.UTC().Format(time.RFC3339)})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// TailBuffer is an io.Writer that keeps roughly the last max bytes written,
+// so logs of a long run can be bundled without unbounded memory.
+type TailBuffer struct {
+	mu  sync.Mutex
+	max int
+	buf []byte
+}
+
+// NewTailBuffer returns a TailBuffer holding up to max bytes.
+func NewTailBuffer(max int) *TailBuffer {
+	return &TailBuffer{max: max}
+}
+
+func (t *TailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if over := len(t.buf) - t.max; over > 0 {
+		// Drop whole lines where possible so the bundle starts cleanly.
+		cut := over
+		if i := bytes.IndexByte(t.buf[over:], '\n'); i >= 0 {
+			cut += i + 1
+		}
+		t.buf = append(t.buf[:0], t.buf[cut:]...)
+	}
+	return len(p), nil
+}
+
+// Bytes returns a copy of the retained tail.
+func (t *TailBuffer) Bytes() []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]byte(nil), t.buf...)
+}
@@ -0,0 +1,102 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readArchive(t *testing.T, file string) (names []string, contents map[string]string) {
+	t.Helper()
+	f, err := os.Open(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	contents = make(map[string]string)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		contents[hdr.Name] = string(data)
+	}
+	return names, contents
+}
+
+func TestBundleWriteFile(t *testing.T) {
+	b := New(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+	b.Add(ReportText, []byte("report\n"))
+	b.Add("pods/default_api.yaml", []byte("kind: Pod\n"))
+	if err := b.AddJSON(ReportJSON, map[string]int{"events": 3}); err != nil {
+		t.Fatal(err)
+	}
+	b.Omit(Capture, "not collected")
+	b.Omit(ReportText, "ignored: already added")
+
+	file := filepath.Join(t.TempDir(), "out.tar.gz")
+	if err := b.WriteFile(file); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(file); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("stat = %v, %v", info, err)
+	}
+
+	names, contents := readArchive(t, file)
+	want := []string{Manifest, "pods/default_api.yaml", ReportJSON, ReportText}
+	if strings.Join(names, ",") != strings.Join(want, ",") {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+	if contents[ReportText] != "report\n" || !strings.Contains(contents[ReportJSON], `"events": 3`) {
+		t.Errorf("contents = %v", contents)
+	}
+
+	var m manifest
+	if err := json.Unmarshal([]byte(contents[Manifest]), &m); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Files) != 3 || len(m.Omitted) != 1 || m.Omitted[0].Name != Capture || m.Omitted[0].Reason != "not collected" {
+		t.Errorf("manifest = %+v", m)
+	}
+}
+
+func TestHTMLReportEscapes(t *testing.T) {
+	page, err := HTMLReport("podtrace report", "GET /a?x=<script>\n", time.Unix(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(page), "<script>") || !strings.Contains(string(page), "GET /a?x=&lt;script&gt;") {
+		t.Errorf("page = %s", page)
+	}
+}
+
+func TestTailBuffer(t *testing.T) {
+	tb := NewTailBuffer(16)
+	for _, line := range []string{"first line\n", "second line\n", "third\n"} {
+		if _, err := tb.Write([]byte(line)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := string(tb.Bytes()); got != "third\n" {
+		t.Errorf("tail = %q", got)
+	}
+}
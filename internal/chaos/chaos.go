@@ -0,0 +1,256 @@
+// Package chaos spots the fault injections chaos tools leave on a traced
+// pod's network, netem qdiscs and iptables DROP rules, so a report taken
+// during a chaos experiment says which of its intervals were injected.
+package chaos
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/sysfs"
+)
+
+// netlinkTimeout bounds one qdisc dump or iptables-save run.
+const netlinkTimeout = 5 * time.Second
+
+// Target is a traced pod whose network is watched.
+type Target struct {
+	// Pod is the pod as namespace/name.
+	Pod string
+	// CgroupPaths are the pod's container and pod cgroups; the network
+	// namespace is entered through the oldest process in the first one
+	// that has any.
+	CgroupPaths []string
+}
+
+// podState is what one look at a pod's network found. A nil netem or
+// drops could not be read, and leaves the previous state in place.
+type podState struct {
+	// netem maps interface names to their netem impairment.
+	netem map[string]string
+	drops []string
+}
+
+// Watcher looks at the traced pods' network every interval and emits an
+// EventChaos when a netem impairment or a DROP rule appears or goes away.
+// Impairments in place at the first look are reported as preexisting;
+// DROP rules are only reported when added after it, since pods may drop
+// packets on purpose.
+type Watcher struct {
+	eventChan chan<- *events.Event
+	interval  time.Duration
+	targets   []Target
+	// read looks at the network of the pod pid lives in.
+	read func(ctx context.Context, pid uint32) podState
+
+	netem    map[string]map[string]string
+	baseline map[string]map[string]bool
+	drops    map[string]map[string]bool
+}
+
+// NewWatcher returns a watcher of the targets' network.
+func NewWatcher(targets []Target, eventChan chan<- *events.Event) *Watcher {
+	return &Watcher{
+		eventChan: eventChan,
+		interval:  config.ChaosInterval,
+		targets:   targets,
+		read:      readPodState,
+		netem:     make(map[string]map[string]string),
+		baseline:  make(map[string]map[string]bool),
+		drops:     make(map[string]map[string]bool),
+	}
+}
+
+// Start looks every interval until ctx is done.
+func (w *Watcher) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+		now := time.Now()
+		for {
+			for _, e := range w.sample(ctx, now) {
+				select {
+				case w.eventChan <- e:
+				default:
+					logger.Warn("Failed to send chaos event, channel full", zap.String("target", e.Target))
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case now = <-ticker.C:
+			}
+		}
+	}()
+}
+
+// sample looks at every target once and returns the changes since the
+// previous look. A pod without a process to enter keeps its state.
+func (w *Watcher) sample(ctx context.Context, now time.Time) []*events.Event {
+	var out []*events.Event
+	for _, t := range w.targets {
+		pid := oldestPID(t.CgroupPaths)
+		if pid == 0 {
+			continue
+		}
+		out = append(out, w.observe(t.Pod, w.read(ctx, pid), now)...)
+	}
+	return out
+}
+
+// observe compares one look at pod's network with the previous one.
+func (w *Watcher) observe(pod string, st podState, now time.Time) []*events.Event {
+	var out []*events.Event
+	emit := func(kind, state, target, spec string, preexisting bool) {
+		out = append(out, &events.Event{
+			Type:      events.EventChaos,
+			Timestamp: clock.WallToBPFTimestamp(now),
+			Target:    target,
+			Details:   events.ChaosDetails{Kind: kind, State: state, Pod: pod, Spec: spec, Preexisting: preexisting}.String(),
+		})
+	}
+
+	if st.netem != nil {
+		prev, seen := w.netem[pod]
+		for _, iface := range sortedKeys(prev) {
+			if spec, ok := st.netem[iface]; !ok || spec != prev[iface] {
+				emit(events.ChaosNetem, events.ChaosEnd, iface, prev[iface], false)
+			}
+		}
+		for _, iface := range sortedKeys(st.netem) {
+			if spec, ok := prev[iface]; !ok || spec != st.netem[iface] {
+				emit(events.ChaosNetem, events.ChaosStart, iface, st.netem[iface], !seen)
+			}
+		}
+		w.netem[pod] = st.netem
+	}
+
+	if st.drops == nil {
+		return out
+	}
+	current := make(map[string]bool, len(st.drops))
+	for _, r := range st.drops {
+		current[r] = true
+	}
+	if _, ok := w.baseline[pod]; !ok {
+		w.baseline[pod] = current
+		w.drops[pod] = make(map[string]bool)
+		return out
+	}
+	// A rule from the baseline that goes away and comes back is an
+	// injection like any other.
+	for r := range w.baseline[pod] {
+		if !current[r] {
+			delete(w.baseline[pod], r)
+		}
+	}
+	active := w.drops[pod]
+	for _, r := range sortedKeys(active) {
+		if !current[r] {
+			delete(active, r)
+			emit(events.ChaosIPTablesDrop, events.ChaosEnd, r, "", false)
+		}
+	}
+	for _, r := range st.drops {
+		if !w.baseline[pod][r] && !active[r] {
+			active[r] = true
+			emit(events.ChaosIPTablesDrop, events.ChaosStart, r, "", false)
+		}
+	}
+	return out
+}
+
+// readPodState reads the netem qdiscs in the pod's network namespace and
+// on the host end of its veth, and the DROP rules of the namespace. The
+// qdiscs count as read when the pod's own could be; a pod on the host
+// network has no veth.
+func readPodState(ctx context.Context, pid uint32) podState {
+	var st podState
+	netem, err := podNetem(pid)
+	if err != nil {
+		logger.Debug("Cannot read the pod's qdiscs", zap.Uint32("pid", pid), zap.Error(err))
+	} else {
+		st.netem = netem
+		if host, err := hostNetem(pid); err == nil {
+			for iface, spec := range host {
+				st.netem[iface] = spec
+			}
+		}
+	}
+	drops, err := podDropRules(ctx, pid)
+	if err != nil {
+		logger.Debug("Cannot read iptables rules", zap.Uint32("pid", pid), zap.Error(err))
+	}
+	st.drops = drops
+	return st
+}
+
+// netemByInterface returns the impairments of the netem qdiscs in qs by
+// interface name, keeping only interfaces keep accepts when it is set.
+// Several netem qdiscs on one interface are joined with ";".
+func netemByInterface(qs []qdisc, names map[int]string, keep func(ifindex int) bool) map[string]string {
+	out := make(map[string]string)
+	for _, q := range qs {
+		if q.kind != "netem" || q.spec == "" || (keep != nil && !keep(q.ifindex)) {
+			continue
+		}
+		name := names[q.ifindex]
+		if name == "" {
+			name = "if" + strconv.Itoa(q.ifindex)
+		}
+		if prev, ok := out[name]; ok {
+			out[name] = prev + ";" + q.spec
+		} else {
+			out[name] = q.spec
+		}
+	}
+	return out
+}
+
+// oldestPID returns the lowest PID in the first of the cgroups that has
+// one, or 0.
+func oldestPID(cgroupPaths []string) uint32 {
+	for _, p := range cgroupPaths {
+		rel, ok := sysfs.CgroupRelative(p)
+		if !ok {
+			continue
+		}
+		data, err := sysfs.CgroupReadFile(filepath.Join(rel, "cgroup.procs"))
+		if err != nil {
+			continue
+		}
+		var oldest uint32
+		for _, f := range strings.Fields(string(data)) {
+			pid, err := strconv.ParseUint(f, 10, 32)
+			if err != nil || pid == 0 {
+				continue
+			}
+			if oldest == 0 || uint32(pid) < oldest {
+				oldest = uint32(pid)
+			}
+		}
+		if oldest != 0 {
+			return oldest
+		}
+	}
+	return 0
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	out := make([]string, 0, len(m))
+	for k := range m {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
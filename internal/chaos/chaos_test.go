@@ -0,0 +1,88 @@
+package chaos
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestDropRules(t *testing.T) {
+	save := `# Generated by iptables-save v1.8.9
+*nat
+:PREROUTING ACCEPT [0:0]
+-A PREROUTING -p tcp -j REDIRECT --to-ports 15001
+COMMIT
+*filter
+:INPUT ACCEPT [0:0]
+-A INPUT -s 10.0.0.5/32 -j DROP
+-A OUTPUT -p tcp --dport 5432 -j REJECT --reject-with icmp-port-unreachable
+-A OUTPUT -p tcp --dport 80 -j ACCEPT
+COMMIT
+`
+	want := []string{
+		"iptables -t filter -A INPUT -s 10.0.0.5/32 -j DROP",
+		"iptables -t filter -A OUTPUT -p tcp --dport 5432 -j REJECT --reject-with icmp-port-unreachable",
+	}
+	if got := dropRules("iptables", save); !reflect.DeepEqual(got, want) {
+		t.Errorf("dropRules = %q, want %q", got, want)
+	}
+}
+
+// chaosEvents decodes the events as "state kind target spec" lines.
+func chaosEvents(t *testing.T, evs []*events.Event) []string {
+	t.Helper()
+	var out []string
+	for _, e := range evs {
+		if e.Type != events.EventChaos {
+			t.Fatalf("event type %v", e.Type)
+		}
+		d := events.ParseChaosDetails(e.Details)
+		line := d.State + " " + d.Kind + " " + e.Target
+		if d.Spec != "" {
+			line += " " + d.Spec
+		}
+		if d.Preexisting {
+			line += " (preexisting)"
+		}
+		if d.Pod != "shop/cart-0" {
+			t.Errorf("pod = %q", d.Pod)
+		}
+		out = append(out, line)
+	}
+	return out
+}
+
+func TestWatcherObserve(t *testing.T) {
+	w := NewWatcher(nil, nil)
+	pod := "shop/cart-0"
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	steps := []struct {
+		st   podState
+		want []string
+	}{
+		// A netem already in place is reported; the DROP rules at the first
+		// look are the pod's own.
+		{podState{netem: map[string]string{"eth0": "delay=50ms"}, drops: []string{"iptables -t filter -A INPUT -p udp -j DROP"}},
+			[]string{"start netem eth0 delay=50ms (preexisting)"}},
+		// The qdiscs and rules could not be read: nothing changes.
+		{podState{}, nil},
+		{podState{netem: map[string]string{"eth0": "delay=200ms", "veth1a2b": "loss=10%"}, drops: []string{
+			"iptables -t filter -A INPUT -p udp -j DROP", "iptables -t filter -A OUTPUT -d 10.0.0.9/32 -j DROP"}},
+			[]string{"end netem eth0 delay=50ms", "start netem eth0 delay=200ms", "start netem veth1a2b loss=10%",
+				"start iptables_drop iptables -t filter -A OUTPUT -d 10.0.0.9/32 -j DROP"}},
+		{podState{netem: map[string]string{}, drops: []string{}},
+			[]string{"end netem eth0 delay=200ms", "end netem veth1a2b loss=10%",
+				"end iptables_drop iptables -t filter -A OUTPUT -d 10.0.0.9/32 -j DROP"}},
+		// The pod's own rule, once gone, counts when it comes back.
+		{podState{netem: map[string]string{}, drops: []string{"iptables -t filter -A INPUT -p udp -j DROP"}},
+			[]string{"start iptables_drop iptables -t filter -A INPUT -p udp -j DROP"}},
+	}
+	for i, s := range steps {
+		evs := w.observe(pod, s.st, now.Add(time.Duration(i)*5*time.Second))
+		if got := chaosEvents(t, evs); !reflect.DeepEqual(got, s.want) {
+			t.Errorf("step %d: events = %q, want %q", i, got, s.want)
+		}
+	}
+}
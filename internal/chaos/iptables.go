@@ -0,0 +1,35 @@
+package chaos
+
+import (
+	"strings"
+)
+
+// saveTools are the iptables dumps read from a pod's network namespace,
+// by the command the rules are reported as.
+var saveTools = []struct{ cmd, bin string }{
+	{"iptables", "iptables-save"},
+	{"ip6tables", "ip6tables-save"},
+}
+
+// dropRules returns the rules of an iptables-save dump that drop or reject
+// packets, as the iptables command that adds them.
+func dropRules(cmd, save string) []string {
+	var out []string
+	table := "filter"
+	for _, line := range strings.Split(save, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "*"):
+			table = line[1:]
+		case strings.HasPrefix(line, "-A "):
+			fields := strings.Fields(line)
+			for i := 0; i+1 < len(fields); i++ {
+				if (fields[i] == "-j" || fields[i] == "--jump") && (fields[i+1] == "DROP" || fields[i+1] == "REJECT") {
+					out = append(out, cmd+" -t "+table+" "+line)
+					break
+				}
+			}
+		}
+	}
+	return out
+}
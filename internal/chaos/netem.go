@@ -0,0 +1,166 @@
+package chaos
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Netlink and traffic control constants, from linux/netlink.h,
+// linux/rtnetlink.h and linux/pkt_sched.h.
+const (
+	nlmsgHdrLen = 16
+	tcmsgLen    = 20
+	rtattrLen   = 4
+
+	nlmsgError  = 2
+	nlmsgDone   = 3
+	rtmNewQdisc = 36
+	rtmGetQdisc = 38
+	nlmRequest  = 0x1
+	nlmDump     = 0x300
+
+	tcaKind    = 1
+	tcaOptions = 2
+
+	// tc_netem_qopt: latency, limit, loss, gap, duplicate and jitter.
+	netemQoptLen      = 24
+	tcaNetemReorder   = 3
+	tcaNetemCorrupt   = 4
+	tcaNetemRate      = 6
+	tcaNetemRate64    = 8
+	tcaNetemLatency64 = 10
+	tcaNetemJitter64  = 11
+)
+
+// qdisc is one queueing discipline of a netlink dump, with the impairment
+// it applies when it is a netem.
+type qdisc struct {
+	ifindex int
+	kind    string
+	spec    string
+}
+
+// qdiscDumpRequest returns an RTM_GETQDISC dump request for every
+// interface of the network namespace.
+func qdiscDumpRequest(seq uint32) []byte {
+	b := make([]byte, nlmsgHdrLen+tcmsgLen)
+	binary.NativeEndian.PutUint32(b[0:], uint32(len(b)))
+	binary.NativeEndian.PutUint16(b[4:], rtmGetQdisc)
+	binary.NativeEndian.PutUint16(b[6:], nlmRequest|nlmDump)
+	binary.NativeEndian.PutUint32(b[8:], seq)
+	return b
+}
+
+// parseQdiscMessages reads the qdiscs from one netlink read of a dump, and
+// reports whether it held the end of the dump.
+func parseQdiscMessages(b []byte) ([]qdisc, bool, error) {
+	var out []qdisc
+	for len(b) >= nlmsgHdrLen {
+		n := int(binary.NativeEndian.Uint32(b[0:]))
+		if n < nlmsgHdrLen || n > len(b) {
+			return out, false, errors.New("truncated netlink message")
+		}
+		typ := binary.NativeEndian.Uint16(b[4:])
+		body := b[nlmsgHdrLen:n]
+		b = b[min(nlmAlign(n), len(b)):]
+		switch typ {
+		case nlmsgDone:
+			return out, true, nil
+		case nlmsgError:
+			if len(body) >= 4 {
+				if errno := int32(binary.NativeEndian.Uint32(body)); errno != 0 {
+					return out, true, fmt.Errorf("netlink qdisc dump: errno %d", -errno)
+				}
+			}
+			return out, true, nil
+		case rtmNewQdisc:
+			if len(body) < tcmsgLen {
+				continue
+			}
+			q := qdisc{ifindex: int(int32(binary.NativeEndian.Uint32(body[4:])))}
+			attrs := parseAttrs(body[tcmsgLen:])
+			q.kind = strings.TrimRight(string(attrs[tcaKind]), "\x00")
+			if q.kind == "netem" {
+				q.spec = netemSpec(attrs[tcaOptions])
+			}
+			out = append(out, q)
+		}
+	}
+	return out, false, nil
+}
+
+func nlmAlign(n int) int { return (n + 3) &^ 3 }
+
+// parseAttrs returns the payload of each route attribute in b by type; a
+// nested flag is masked off.
+func parseAttrs(b []byte) map[uint16][]byte {
+	out := make(map[uint16][]byte)
+	for len(b) >= rtattrLen {
+		n := int(binary.NativeEndian.Uint16(b[0:]))
+		if n < rtattrLen || n > len(b) {
+			break
+		}
+		out[binary.NativeEndian.Uint16(b[2:])&0x3fff] = b[rtattrLen:n]
+		b = b[min(nlmAlign(n), len(b)):]
+	}
+	return out
+}
+
+// netemSpec describes the impairment of a netem qdisc from its
+// TCA_OPTIONS, such as "delay=100ms,jitter=10ms,loss=5%", or returns ""
+// when it leaves the traffic alone.
+func netemSpec(opts []byte) string {
+	if len(opts) < netemQoptLen {
+		return ""
+	}
+	u32 := func(b []byte, off int) uint32 { return binary.NativeEndian.Uint32(b[off:]) }
+	attrs := parseAttrs(opts[nlmAlign(netemQoptLen):])
+
+	var parts []string
+	add := func(k, v string) { parts = append(parts, k+"="+v) }
+	if v := attrs[tcaNetemLatency64]; len(v) >= 8 {
+		if d := time.Duration(int64(binary.NativeEndian.Uint64(v))); d > 0 {
+			add("delay", d.String())
+		}
+	}
+	if v := attrs[tcaNetemJitter64]; len(v) >= 8 {
+		if d := time.Duration(int64(binary.NativeEndian.Uint64(v))); d > 0 {
+			add("jitter", d.String())
+		}
+	}
+	if p := u32(opts, 8); p > 0 {
+		add("loss", probability(p))
+	}
+	if p := u32(opts, 16); p > 0 {
+		add("duplicate", probability(p))
+	}
+	if v := attrs[tcaNetemCorrupt]; len(v) >= 4 && u32(v, 0) > 0 {
+		add("corrupt", probability(u32(v, 0)))
+	}
+	if v := attrs[tcaNetemReorder]; len(v) >= 4 && u32(v, 0) > 0 {
+		add("reorder", probability(u32(v, 0)))
+	}
+	var rate uint64
+	if v := attrs[tcaNetemRate]; len(v) >= 4 {
+		rate = uint64(u32(v, 0))
+	}
+	if v := attrs[tcaNetemRate64]; len(v) >= 8 {
+		rate = binary.NativeEndian.Uint64(v)
+	}
+	if rate > 0 {
+		add("rate", strconv.FormatUint(rate*8, 10)+"bit")
+	}
+	return strings.Join(parts, ",")
+}
+
+// probability renders a netem probability, scaled to the full uint32
+// range, as a percentage.
+func probability(p uint32) string {
+	pct := float64(p) / math.MaxUint32 * 100
+	return strconv.FormatFloat(math.Round(pct*100)/100, 'f', -1, 64) + "%"
+}
@@ -0,0 +1,105 @@
+package chaos
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+// attr encodes one route attribute, padded to 4 bytes.
+func attr(typ uint16, payload []byte) []byte {
+	b := make([]byte, nlmAlign(rtattrLen+len(payload)))
+	binary.NativeEndian.PutUint16(b[0:], uint16(rtattrLen+len(payload)))
+	binary.NativeEndian.PutUint16(b[2:], typ)
+	copy(b[rtattrLen:], payload)
+	return b
+}
+
+func u64(v uint64) []byte {
+	return binary.NativeEndian.AppendUint64(nil, v)
+}
+
+// qdiscMessage encodes an RTM_NEWQDISC message for ifindex.
+func qdiscMessage(ifindex int32, kind string, opts []byte) []byte {
+	body := make([]byte, tcmsgLen)
+	binary.NativeEndian.PutUint32(body[4:], uint32(ifindex))
+	body = append(body, attr(tcaKind, append([]byte(kind), 0))...)
+	if opts != nil {
+		body = append(body, attr(tcaOptions, opts)...)
+	}
+	msg := make([]byte, nlmsgHdrLen, nlmsgHdrLen+len(body))
+	binary.NativeEndian.PutUint32(msg[0:], uint32(nlmsgHdrLen+len(body)))
+	binary.NativeEndian.PutUint16(msg[4:], rtmNewQdisc)
+	return append(msg, body...)
+}
+
+func doneMessage() []byte {
+	msg := make([]byte, nlmsgHdrLen+4)
+	binary.NativeEndian.PutUint32(msg[0:], uint32(len(msg)))
+	binary.NativeEndian.PutUint16(msg[4:], nlmsgDone)
+	return msg
+}
+
+// netemOptions encodes a tc_netem_qopt with loss p (as a fraction) and the
+// 64-bit latency and jitter attributes the kernel dumps.
+func netemOptions(delay, jitter time.Duration, loss float64) []byte {
+	qopt := make([]byte, netemQoptLen)
+	binary.NativeEndian.PutUint32(qopt[4:], 1000)
+	binary.NativeEndian.PutUint32(qopt[8:], uint32(loss*math.MaxUint32))
+	qopt = append(qopt, attr(tcaNetemLatency64, u64(uint64(delay)))...)
+	return append(qopt, attr(tcaNetemJitter64, u64(uint64(jitter)))...)
+}
+
+func TestParseQdiscMessages(t *testing.T) {
+	var dump []byte
+	dump = append(dump, qdiscMessage(1, "noqueue", nil)...)
+	dump = append(dump, qdiscMessage(3, "netem", netemOptions(100*time.Millisecond, 10*time.Millisecond, 0.05))...)
+	dump = append(dump, qdiscMessage(4, "netem", netemOptions(0, 0, 0))...)
+
+	qs, done, err := parseQdiscMessages(dump)
+	if err != nil || done {
+		t.Fatalf("parseQdiscMessages: done=%v err=%v", done, err)
+	}
+	if len(qs) != 3 {
+		t.Fatalf("got %d qdiscs, want 3: %+v", len(qs), qs)
+	}
+	if qs[0].kind != "noqueue" || qs[0].spec != "" {
+		t.Errorf("qdisc 0 = %+v", qs[0])
+	}
+	if want := "delay=100ms,jitter=10ms,loss=5%"; qs[1].ifindex != 3 || qs[1].spec != want {
+		t.Errorf("qdisc 1 = %+v, want spec %q on ifindex 3", qs[1], want)
+	}
+	if qs[2].spec != "" {
+		t.Errorf("a netem without impairment has spec %q", qs[2].spec)
+	}
+
+	got := netemByInterface(qs, map[int]string{3: "eth0"}, nil)
+	if len(got) != 1 || got["eth0"] != qs[1].spec {
+		t.Errorf("netemByInterface = %v", got)
+	}
+	if got := netemByInterface(qs, nil, func(i int) bool { return i == 3 }); got["if3"] != qs[1].spec {
+		t.Errorf("unnamed interface = %v", got)
+	}
+
+	if _, done, err := parseQdiscMessages(doneMessage()); !done || err != nil {
+		t.Errorf("NLMSG_DONE: done=%v err=%v", done, err)
+	}
+	if _, _, err := parseQdiscMessages(dump[:30]); err == nil {
+		t.Error("expected an error for a truncated message")
+	}
+}
+
+func TestNetemSpecRate(t *testing.T) {
+	opts := make([]byte, netemQoptLen)
+	binary.NativeEndian.PutUint32(opts[16:], math.MaxUint32/2)
+	rate := make([]byte, 16)
+	binary.NativeEndian.PutUint32(rate, 125000)
+	opts = append(opts, attr(tcaNetemRate, rate)...)
+	if got, want := netemSpec(opts), "duplicate=50%,rate=1000000bit"; got != want {
+		t.Errorf("netemSpec = %q, want %q", got, want)
+	}
+	if netemSpec(opts[:10]) != "" {
+		t.Error("short options should have no spec")
+	}
+}
@@ -0,0 +1,169 @@
+//go:build linux
+
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/probes"
+)
+
+// inNetNS runs fn on a thread moved into the network namespace pid lives
+// in, and moves the thread back.
+func inNetNS(pid uint32, fn func() error) error {
+	target, err := os.Open(filepath.Join(config.ProcBasePath, strconv.FormatUint(uint64(pid), 10), "ns", "net"))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = target.Close() }()
+
+	runtime.LockOSThread()
+	self, err := os.Open("/proc/thread-self/ns/net")
+	if err != nil {
+		runtime.UnlockOSThread()
+		return err
+	}
+	defer func() { _ = self.Close() }()
+	if err := unix.Setns(int(target.Fd()), unix.CLONE_NEWNET); err != nil {
+		runtime.UnlockOSThread()
+		return fmt.Errorf("enter network namespace of pid %d: %w", pid, err)
+	}
+	ferr := fn()
+	if err := unix.Setns(int(self.Fd()), unix.CLONE_NEWNET); err != nil {
+		// The thread stays locked, so the runtime discards it rather than
+		// run other goroutines in the pod's namespace.
+		return errors.Join(ferr, fmt.Errorf("leave network namespace of pid %d: %w", pid, err))
+	}
+	runtime.UnlockOSThread()
+	return ferr
+}
+
+// dumpQdiscs returns the qdiscs of every interface in the calling thread's
+// network namespace.
+func dumpQdiscs() ([]qdisc, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = unix.Close(fd) }()
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &unix.Timeval{Sec: int64(netlinkTimeout.Seconds())}); err != nil {
+		return nil, err
+	}
+	if err := unix.Bind(fd, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+	if err := unix.Sendto(fd, qdiscDumpRequest(1), 0, &unix.SockaddrNetlink{Family: unix.AF_NETLINK}); err != nil {
+		return nil, err
+	}
+	var out []qdisc
+	buf := make([]byte, 1<<16)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, err
+		}
+		qs, done, err := parseQdiscMessages(buf[:n])
+		out = append(out, qs...)
+		if err != nil || done {
+			return out, err
+		}
+	}
+}
+
+// interfaceNames maps the ifindex of every interface in the calling
+// thread's network namespace to its name.
+func interfaceNames() map[int]string {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	out := make(map[int]string, len(ifaces))
+	for _, ifc := range ifaces {
+		out[ifc.Index] = ifc.Name
+	}
+	return out
+}
+
+// podNetem returns the netem impairments on the interfaces of the network
+// namespace pid lives in, by interface name.
+func podNetem(pid uint32) (map[string]string, error) {
+	var qs []qdisc
+	var names map[int]string
+	err := inNetNS(pid, func() error {
+		var err error
+		qs, err = dumpQdiscs()
+		names = interfaceNames()
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return netemByInterface(qs, names, nil), nil
+}
+
+// hostNetem returns the netem impairment on the host side of the veth
+// serving pid's network namespace, by interface name.
+func hostNetem(pid uint32) (map[string]string, error) {
+	veth, err := probes.VethIfindex(pid)
+	if err != nil {
+		return nil, err
+	}
+	qs, err := dumpQdiscs()
+	if err != nil {
+		return nil, err
+	}
+	return netemByInterface(qs, interfaceNames(), func(ifindex int) bool { return ifindex == veth }), nil
+}
+
+// errNoSaveTool is returned when neither iptables-save nor ip6tables-save
+// is installed.
+var errNoSaveTool = errors.New("no iptables-save or ip6tables-save in PATH")
+
+// podDropRules returns the iptables and ip6tables rules dropping or
+// rejecting packets in the network namespace pid lives in. Dumps whose
+// tool is not installed are skipped; the rules are nil when no dump could
+// be read.
+func podDropRules(ctx context.Context, pid uint32) ([]string, error) {
+	var out []string
+	var errs []error
+	found, read := false, false
+	for _, t := range saveTools {
+		bin, err := exec.LookPath(t.bin)
+		if err != nil {
+			continue
+		}
+		found = true
+		var save []byte
+		err = inNetNS(pid, func() error {
+			runCtx, cancel := context.WithTimeout(ctx, netlinkTimeout)
+			defer cancel()
+			var err error
+			save, err = exec.CommandContext(runCtx, bin).Output() // #nosec G204 -- LookPath-resolved iptables-save without arguments
+			return err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.bin, err))
+			continue
+		}
+		read = true
+		out = append(out, dropRules(t.cmd, string(save))...)
+	}
+	if !found {
+		return nil, errNoSaveTool
+	}
+	if read && out == nil {
+		out = []string{}
+	}
+	return out, errors.Join(errs...)
+}
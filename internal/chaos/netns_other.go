@@ -0,0 +1,14 @@
+//go:build !linux
+
+package chaos
+
+import (
+	"context"
+	"errors"
+)
+
+func podNetem(uint32) (map[string]string, error) { return nil, errors.ErrUnsupported }
+
+func hostNetem(uint32) (map[string]string, error) { return nil, errors.ErrUnsupported }
+
+func podDropRules(context.Context, uint32) ([]string, error) { return nil, errors.ErrUnsupported }
@@ -1,11 +1,18 @@
 // Package clock anchors BPF timestamps to wall-clock time.
 //
 // bpf_ktime_get_ns() returns nanoseconds since boot on CLOCK_MONOTONIC, not
-// nanoseconds since the Unix epoch.
+// nanoseconds since the Unix epoch. The offset between the two clocks is not
+// fixed: NTP slews and steps the wall clock, so one offset taken at start
+// drifts from the truth over a long session. Recalibrate samples the offset
+// again, and each timestamp is converted with the offset sampled last
+// before it, so an export written at the end of a session lines up with
+// application logs at every point of it.
 package clock
 
 import (
+	"context"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -13,24 +20,103 @@ import (
 	"golang.org/x/sys/unix"
 )
 
+// maxSamples bounds the calibration history; at one sample every 30s it
+// covers more than a day. Older samples are dropped, and timestamps before
+// the oldest one kept use its offset.
+const maxSamples = 4096
+
+// sample is the wall-minus-monotonic offset measured at monotonic time mono.
+type sample struct {
+	mono   int64
+	offset int64
+}
+
 var (
-	offsetOnce sync.Once
-	offset     int64
+	mu      sync.RWMutex
+	samples []sample
 )
 
+// measure reads CLOCK_MONOTONIC between two wall-clock reads and takes
+// their midpoint, which halves the error a preemption between the reads
+// would add.
+func measure() (sample, bool) {
+	var ts unix.Timespec
+	before := time.Now().UnixNano()
+	if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err != nil {
+		return sample{}, false
+	}
+	after := time.Now().UnixNano()
+	mono := ts.Sec*int64(time.Second) + ts.Nsec
+	return sample{mono: mono, offset: before + (after-before)/2 - mono}, true
+}
+
+func calibrated() []sample {
+	mu.RLock()
+	s := samples
+	mu.RUnlock()
+	if s != nil {
+		return s
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if samples == nil {
+		if m, ok := measure(); ok {
+			samples = []sample{m}
+		} else {
+			samples = []sample{{}}
+		}
+	}
+	return samples
+}
+
+// Recalibrate samples the offset between the clocks again. Timestamps from
+// now on convert with it; earlier ones keep the offset of their time.
+func Recalibrate() {
+	calibrated()
+	m, ok := measure()
+	if !ok {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	next := make([]sample, 0, min(len(samples)+1, maxSamples))
+	next = append(next, samples[max(0, len(samples)+1-maxSamples):]...)
+	samples = append(next, m)
+}
+
+// StartCalibration calls Recalibrate every interval until ctx is done. An
+// interval of zero or less keeps the offset taken at start.
+func StartCalibration(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	calibrated()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				Recalibrate()
+			}
+		}
+	}()
+}
+
 // MonotonicToWallOffset returns the offset in nanoseconds between wall-clock
 // time (time.Now().UnixNano()) and CLOCK_MONOTONIC (the clock behind
-// bpf_ktime_get_ns()).
+// bpf_ktime_get_ns()), as last calibrated.
 func MonotonicToWallOffset() int64 {
-	offsetOnce.Do(func() {
-		var ts unix.Timespec
-		if err := unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts); err == nil {
-			monotonicNS := ts.Sec*int64(time.Second) + ts.Nsec
-			wallNS := time.Now().UnixNano()
-			offset = wallNS - monotonicNS
-		}
-	})
-	return offset
+	s := calibrated()
+	return s[len(s)-1].offset
+}
+
+// offsetAt returns the offset sampled last at or before monotonic time mono.
+func offsetAt(s []sample, mono int64) int64 {
+	i := sort.Search(len(s), func(i int) bool { return s[i].mono > mono })
+	return s[max(i-1, 0)].offset
 }
 
 // BPFTimestampToWall converts a bpf_ktime_get_ns() timestamp (nanoseconds
@@ -39,15 +125,32 @@ func BPFTimestampToWall(bpfNS uint64) time.Time {
 	if bpfNS > math.MaxInt64 {
 		bpfNS = math.MaxInt64
 	}
-	return time.Unix(0, safeconv.AddInt64(int64(bpfNS), MonotonicToWallOffset()))
+	return time.Unix(0, safeconv.AddInt64(int64(bpfNS), offsetAt(calibrated(), int64(bpfNS))))
 }
 
 // WallToBPFTimestamp converts a wall-clock time.Time to the bpf_ktime_get_ns()
-// timestamp that BPFTimestampToWall would map back to it.
+// timestamp that BPFTimestampToWall would map back to it. A time before
+// boot has none and is placed at boot; check times from outside the run
+// with BeforeBoot first.
 func WallToBPFTimestamp(t time.Time) uint64 {
-	bpfNS := t.UnixNano() - MonotonicToWallOffset()
+	bpfNS := wallToMono(t)
 	if bpfNS < 0 {
 		return 0
 	}
 	return uint64(bpfNS)
 }
+
+// BeforeBoot reports whether t predates the boot bpf_ktime_get_ns() counts
+// from, so that no event can carry it.
+func BeforeBoot(t time.Time) bool {
+	return wallToMono(t) < 0
+}
+
+func wallToMono(t time.Time) int64 {
+	s := calibrated()
+	wall := t.UnixNano()
+	// The sample in force at t is the last one taken at or before it on
+	// the wall clock.
+	i := sort.Search(len(s), func(i int) bool { return s[i].mono+s[i].offset > wall })
+	return wall - s[max(i-1, 0)].offset
+}
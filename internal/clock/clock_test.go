@@ -78,3 +78,74 @@ func TestWallToBPFTimestamp_ClampsBeforeBoot(t *testing.T) {
 		t.Errorf("WallToBPFTimestamp(epoch) = %d, want 0 (clamped)", got)
 	}
 }
+
+func TestBeforeBoot(t *testing.T) {
+	if !BeforeBoot(time.Unix(0, 0)) {
+		t.Error("BeforeBoot(epoch) = false, want true")
+	}
+	if BeforeBoot(time.Now()) {
+		t.Error("BeforeBoot(now) = true, want false")
+	}
+}
+
+// withSamples swaps in a calibration history for the length of a test.
+func withSamples(t *testing.T, s ...sample) {
+	t.Helper()
+	calibrated()
+	mu.Lock()
+	saved := samples
+	samples = s
+	mu.Unlock()
+	t.Cleanup(func() {
+		mu.Lock()
+		samples = saved
+		mu.Unlock()
+	})
+}
+
+func TestBPFTimestampToWall_UsesOffsetInForce(t *testing.T) {
+	// The wall clock was stepped 5ms ahead at monotonic 100s.
+	withSamples(t, sample{mono: 10e9, offset: 1_000e9}, sample{mono: 100e9, offset: 1_000e9 + 5e6})
+
+	for _, tc := range []struct {
+		bpfNS uint64
+		want  int64
+	}{
+		{5e9, 1_005e9},
+		{50e9, 1_050e9},
+		{100e9, 1_100e9 + 5e6},
+		{200e9, 1_200e9 + 5e6},
+	} {
+		if got := BPFTimestampToWall(tc.bpfNS).UnixNano(); got != tc.want {
+			t.Errorf("BPFTimestampToWall(%d) = %d, want %d", tc.bpfNS, got, tc.want)
+		}
+		if back := WallToBPFTimestamp(time.Unix(0, tc.want)); back != tc.bpfNS {
+			t.Errorf("WallToBPFTimestamp(%d) = %d, want %d", tc.want, back, tc.bpfNS)
+		}
+	}
+	if got := MonotonicToWallOffset(); got != 1_000e9+5e6 {
+		t.Errorf("MonotonicToWallOffset() = %d, want the latest offset", got)
+	}
+}
+
+func TestRecalibrate_AppendsAndBoundsHistory(t *testing.T) {
+	full := make([]sample, maxSamples)
+	for i := range full {
+		full[i] = sample{mono: int64(i), offset: 1}
+	}
+	withSamples(t, full...)
+
+	Recalibrate()
+	mu.RLock()
+	got := samples
+	mu.RUnlock()
+	if len(got) != maxSamples {
+		t.Fatalf("history has %d samples, want %d", len(got), maxSamples)
+	}
+	if got[0].mono != 1 {
+		t.Errorf("oldest sample mono = %d, want 1 (the first dropped)", got[0].mono)
+	}
+	if last := got[len(got)-1]; last.offset == 1 {
+		t.Errorf("latest sample %+v was not measured", last)
+	}
+}
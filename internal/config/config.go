@@ -21,6 +21,8 @@ const (
 	DefaultMetricsHost           = "127.0.0.1"
 	DefaultRingBufferSizeKB      = 2048
 	DefaultLogLevel              = "info"
+	DefaultLogMaxSizeMB          = 100
+	DefaultLogMaxBackups         = 3
 	DefaultTracingEnabled        = false
 	DefaultSynthesizeSpans       = false
 	DefaultTracingSampleRate     = 1.0
@@ -37,6 +39,14 @@ const (
 	DefaultAlertMaxPayloadSize   = 1024 * 1024
 )
 
+// Output tiers for --verbosity. The empty tier keeps the default real-time
+// report.
+const (
+	VerbosityEvents    = "events"
+	VerbosityAnomalies = "anomalies"
+	VerbosityIssues    = "issues"
+)
+
 const (
 	MaxProcessCacheSize              = 10000
 	DefaultProcessCacheEvictionRatio = 0.9
@@ -65,7 +75,7 @@ var (
 	SplunkToken               = getEnvOrDefault("PODTRACE_SPLUNK_TOKEN", "")
 	DataDogEndpoint           = getEnvOrDefault("PODTRACE_DATADOG_ENDPOINT", DefaultDataDogEndpoint)
 	DataDogAPIKey             = getEnvOrDefault("PODTRACE_DATADOG_API_KEY", "")
-	ZipkinEndpoint            = getEnvOrDefault("PODTRACE_ZIPKIN_ENDPOINT", DefaultZipkinEndpoint)
+	ZipkinEndpoint            = os.Getenv("PODTRACE_ZIPKIN_ENDPOINT")
 	MaxTraceIDLength          = 32
 	MaxSpanIDLength           = 16
 	MaxTraceStateLength       = 512
@@ -97,6 +107,7 @@ var (
 	TopURLsLimit              = getIntEnvOrDefault("PODTRACE_TOP_URLS_LIMIT", DefaultTopURLsLimit)
 	TopProcessesLimit         = getIntEnvOrDefault("PODTRACE_TOP_PROCESSES_LIMIT", DefaultTopProcessesLimit)
 	TopStatesLimit            = getIntEnvOrDefault("PODTRACE_TOP_STATES_LIMIT", DefaultTopStatesLimit)
+	TopThreadsLimit           = getIntEnvOrDefault("PODTRACE_TOP_THREADS_LIMIT", DefaultTopThreadsLimit)
 	MaxStackTracesLimit       = getIntEnvOrDefault("PODTRACE_MAX_STACK_TRACES_LIMIT", DefaultMaxStackTracesLimit)
 	MaxStackFramesLimit       = getIntEnvOrDefault("PODTRACE_MAX_STACK_FRAMES_LIMIT", DefaultMaxStackFramesLimit)
 	MaxOOMKillsDisplay        = getIntEnvOrDefault("PODTRACE_MAX_OOM_KILLS_DISPLAY", DefaultMaxOOMKillsDisplay)
@@ -106,10 +117,32 @@ var (
 	HighErrorCountThreshold   = getIntEnvOrDefault("PODTRACE_HIGH_ERROR_COUNT_THRESHOLD", DefaultHighErrorCountThreshold)
 	SpikeRateThreshold        = getFloatEnvOrDefault("PODTRACE_SPIKE_RATE_THRESHOLD", DefaultSpikeRateThreshold)
 	MaxEventsForStacks        = getIntEnvOrDefault("PODTRACE_MAX_EVENTS_FOR_STACKS", DefaultMaxEventsForStacks)
+	MaxEvents                 = getIntEnvOrDefault("PODTRACE_MAX_RETAINED_EVENTS", DefaultMaxEvents)
 	MinLatencyForStackNS      = getInt64EnvOrDefault("PODTRACE_MIN_LATENCY_FOR_STACK_NS", DefaultMinLatencyForStackNS)
 	MaxBytesForBandwidth      = getInt64EnvOrDefault("PODTRACE_MAX_BYTES_FOR_BANDWIDTH", DefaultMaxBytesForBandwidth)
 	EventSamplingRate         = getIntEnvOrDefault("PODTRACE_EVENT_SAMPLING_RATE", DefaultEventSamplingRate)
 	ContainerPID              = getIntEnvOrDefault("PODTRACE_CONTAINER_PID", DefaultContainerPID)
+	LogMaxSizeMB              = getIntEnvOrDefault("PODTRACE_LOG_MAX_SIZE_MB", DefaultLogMaxSizeMB)
+	LogMaxBackups             = getIntEnvOrDefault("PODTRACE_LOG_MAX_BACKUPS", DefaultLogMaxBackups)
+	ProcRootOnly              = getBoolEnvOrDefault("PODTRACE_PROC_ROOT_ONLY", false)
+	// LowPrivilege loads no BPF program and collects only what cgroupfs and
+	// /proc show, for clusters that forbid CAP_BPF and CAP_SYS_ADMIN.
+	LowPrivilege = getBoolEnvOrDefault("PODTRACE_LOW_PRIVILEGE", false)
+
+	// The metrics server asks for one of the bearer tokens in
+	// MetricsBearerTokenFile, serves HTTPS with MetricsTLSCert and
+	// MetricsTLSKey, and with MetricsClientCA only to clients holding a
+	// certificate it signed. MetricsNamespaces, comma-separated, limits the
+	// series served to those namespaces.
+	MetricsBearerTokenFile = getEnvOrDefault("PODTRACE_METRICS_BEARER_TOKEN_FILE", "")
+	MetricsTLSCert         = getEnvOrDefault("PODTRACE_METRICS_TLS_CERT", "")
+	MetricsTLSKey          = getEnvOrDefault("PODTRACE_METRICS_TLS_KEY", "")
+	MetricsClientCA        = getEnvOrDefault("PODTRACE_METRICS_CLIENT_CA", "")
+	MetricsNamespaces      = getEnvOrDefault("PODTRACE_METRICS_NAMESPACES", "")
+
+	// MetricsLegacyNames also exports the series renamed for OpenMetrics
+	// under their old names, for one release.
+	MetricsLegacyNames = getBoolEnvOrDefault("PODTRACE_METRICS_LEGACY_NAMES", true)
 
 	RingBufferSizeKB = getIntEnvOrDefault("PODTRACE_RING_BUFFER_SIZE_KB", DefaultRingBufferSizeKB)
 	BPFHashMapSize   = getIntEnvOrDefault("PODTRACE_BPF_HASH_MAP_SIZE", DefaultBPFHashMapSize)
@@ -122,7 +155,15 @@ var (
 
 	GRPCPort             = getIntEnvOrDefault("PODTRACE_GRPC_PORT", 50051)
 	USDTEnabled          = getBoolEnvOrDefault("PODTRACE_USDT_ENABLED", true)
+	CustomUprobesFile    = getEnvOrDefault("PODTRACE_CUSTOM_UPROBES", "")
+	PythonEnabled        = getBoolEnvOrDefault("PODTRACE_PYTHON_ENABLED", true)
+	NodeEnabled          = getBoolEnvOrDefault("PODTRACE_NODE_ENABLED", true)
+	AMQPUnackedWarn      = getIntEnvOrDefault("PODTRACE_AMQP_UNACKED_WARN", 100)
+	AnnotationSocket     = getEnvOrDefault("PODTRACE_ANNOTATION_SOCKET", "")
+	FederationGateway    = getEnvOrDefault("PODTRACE_FEDERATION_GATEWAY", "")
+	FederationToken      = getEnvOrDefault("PODTRACE_FEDERATION_TOKEN", "")
 	DNSPayloadEnabled    = getBoolEnvOrDefault("PODTRACE_DNS_PAYLOAD_ENABLED", true)
+	DNSProbes            = getEnvOrDefault("PODTRACE_DNS_PROBES", "libc,cares,go,socket")
 	RedactPII            = getBoolEnvOrDefault("PODTRACE_REDACT_PII", false)
 	RedactCustomRules    = getEnvOrDefault("PODTRACE_REDACT_CUSTOM_RULES", "")
 	CaptureHeaders       = getEnvOrDefault("PODTRACE_CAPTURE_HEADERS", "")
@@ -167,6 +208,7 @@ const (
 	DefaultTopURLsLimit         = 5
 	DefaultTopProcessesLimit    = 10
 	DefaultTopStatesLimit       = 10
+	DefaultTopThreadsLimit      = 5
 	DefaultMaxStackTracesLimit  = 5
 	DefaultMaxStackFramesLimit  = 5
 	DefaultMaxOOMKillsDisplay   = 5
@@ -182,7 +224,7 @@ const (
 	DefaultMinLatencyForStackNS    = 1000000
 	DefaultMaxBytesForBandwidth    = 10 * 1024 * 1024
 	EAGAIN                         = 11
-	MaxEvents                      = 1000000
+	DefaultMaxEvents               = 1000000
 	DefaultEventSamplingRate       = 100
 
 	DefaultBPFHashMapSize = 4096
@@ -254,6 +296,363 @@ var (
 	DockerBasePath     = getEnvOrDefault("PODTRACE_DOCKER_BASE", DockerContainersPath)
 	ContainerdBasePath = getEnvOrDefault("PODTRACE_CONTAINERD_BASE", "/var/lib/containerd")
 	LdSoConfBasePath   = getEnvOrDefault("PODTRACE_LDSOCONF_BASE", "/etc")
+
+	// HostRoot is where the node's root filesystem is mounted when podtrace
+	// runs in a container that does not share it, such as the /host of a
+	// `kubectl debug node/...` container. Set, it prefixes the host paths
+	// below that were not set by their own variable; "/" turns off the
+	// detection of a debug container.
+	HostRoot = getEnvOrDefault("PODTRACE_HOST_ROOT", "")
+
+	// ArtifactMirror is an HTTP(S) base URL kernel-specific BTF and BPF
+	// objects are fetched from at startup; ArtifactChecksums is the
+	// SHA256SUMS file pinning them and ArtifactCacheDir keeps verified
+	// copies for offline restarts.
+	ArtifactMirror       = getEnvOrDefault("PODTRACE_ARTIFACT_MIRROR", "")
+	ArtifactChecksums    = getEnvOrDefault("PODTRACE_ARTIFACT_CHECKSUMS", "")
+	ArtifactCacheDir     = getEnvOrDefault("PODTRACE_ARTIFACT_CACHE", DefaultArtifactCacheDir)
+	ArtifactFetchTimeout = getDurationEnvOrDefault("PODTRACE_ARTIFACT_FETCH_TIMEOUT", DefaultArtifactFetchTimeout)
+
+	// ProbeAttachConcurrency bounds how many probes are attached at once;
+	// 1 attaches them one after another.
+	ProbeAttachConcurrency = getIntEnvOrDefault("PODTRACE_PROBE_ATTACH_CONCURRENCY", DefaultProbeAttachConcurrency)
+
+	// IssueHookCooldown suppresses re-running --on-issue callbacks for a
+	// rule that fired for the same pod less than this long ago;
+	// IssueHookTimeout bounds each callback run.
+	IssueHookCooldown = getDurationEnvOrDefault("PODTRACE_ISSUE_HOOK_COOLDOWN", DefaultIssueHookCooldown)
+	IssueHookTimeout  = getDurationEnvOrDefault("PODTRACE_ISSUE_HOOK_TIMEOUT", DefaultIssueHookTimeout)
+	// MarkCommandTimeout bounds the --mark-end-cmd run at the end of a
+	// load window.
+	MarkCommandTimeout = getDurationEnvOrDefault("PODTRACE_MARK_CMD_TIMEOUT", DefaultMarkCommandTimeout)
+
+	// IssueEvents records each detected issue as a Warning Event on the
+	// traced pod; the cooldown above applies to it too.
+	IssueEvents = getBoolEnvOrDefault("PODTRACE_ISSUE_EVENTS", false)
+
+	// Syslog is the udp://, tcp:// or tls:// receiver that detected issues
+	// and the events printed by --verbosity are sent to as RFC 5424
+	// messages; empty sends nothing.
+	Syslog = getEnvOrDefault("PODTRACE_SYSLOG", "")
+
+	// FIPS refuses to run outside Go's FIPS 140-3 mode and refuses network
+	// integrations that would connect without TLS allowed by that mode.
+	FIPS = getBoolEnvOrDefault("PODTRACE_FIPS", false)
+
+	// History records each workstation --diagnose run and its report under
+	// HistoryDir (empty: ~/.podtrace) for `podtrace history`, keeping the
+	// latest HistoryMaxRuns.
+	History        = getBoolEnvOrDefault("PODTRACE_HISTORY", true)
+	HistoryDir     = getEnvOrDefault("PODTRACE_HISTORY_DIR", "")
+	HistoryMaxRuns = getIntEnvOrDefault("PODTRACE_HISTORY_MAX_RUNS", DefaultHistoryMaxRuns)
+	// The report of a recorded run notes each headline metric that moved by
+	// HistoryCompareMinChange (0.2 is 20%) or more since the last run of
+	// the same target.
+	HistoryCompareMinChange = getFloatEnvOrDefault("PODTRACE_HISTORY_COMPARE_MIN_CHANGE", DefaultHistoryCompareMinChange)
+
+	// InitContainerWaitTimeout bounds how long --init-container waits for
+	// the named init container to start running.
+	InitContainerWaitTimeout = getDurationEnvOrDefault("PODTRACE_INIT_CONTAINER_WAIT_TIMEOUT", DefaultInitContainerWaitTimeout)
+
+	// ResolveTargetNames names external connection targets in the report
+	// from observed DNS answers, reverse DNS and the PODTRACE_IP_RANGES cloud
+	// range files (comma-separated AWS, Google Cloud or Azure JSON).
+	ResolveTargetNames = getBoolEnvOrDefault("PODTRACE_RESOLVE_NAMES", true)
+	IPRangesFiles      = getEnvOrDefault("PODTRACE_IP_RANGES", "")
+	ReverseDNSTimeout  = getDurationEnvOrDefault("PODTRACE_REVERSE_DNS_TIMEOUT", DefaultReverseDNSTimeout)
+
+	// GeoIPDBFiles are comma-separated MaxMind DB files (e.g. GeoLite2-ASN)
+	// the report tags internet-bound traffic with.
+	GeoIPDBFiles = getEnvOrDefault("PODTRACE_GEOIP_DB", "")
+
+	// PodThroughput counts bytes and packets on each target pod's veth with
+	// tc programs, classifying the remote address as cluster
+	// (PODTRACE_CLUSTER_CIDRS), node (the node's own addresses and
+	// PODTRACE_NODE_CIDRS) or external.
+	PodThroughput         = getBoolEnvOrDefault("PODTRACE_POD_THROUGHPUT", false)
+	PodThroughputInterval = getDurationEnvOrDefault("PODTRACE_POD_THROUGHPUT_INTERVAL", DefaultPodThroughputInterval)
+	ClusterCIDRs          = getEnvOrDefault("PODTRACE_CLUSTER_CIDRS", DefaultClusterCIDRs)
+	NodeCIDRs             = getEnvOrDefault("PODTRACE_NODE_CIDRS", "")
+	// BandwidthSaturation is the share of a pod's bandwidth annotation, or
+	// of the node's link speed, at which an interval counts as saturated.
+	BandwidthSaturation = getFloatEnvOrDefault("PODTRACE_BANDWIDTH_SATURATION", DefaultBandwidthSaturation)
+
+	// CPUPlacement samples each target process's allowed CPUs, the NUMA
+	// nodes of its memory and its run queue wait. An interval is flagged
+	// when NUMARemoteMemoryWarn of the resident memory sits on nodes the
+	// process cannot run on, or it waited RunQueueWaitWarn of the time for
+	// a CPU.
+	CPUPlacement         = getBoolEnvOrDefault("PODTRACE_CPU_PLACEMENT", false)
+	CPUPlacementInterval = getDurationEnvOrDefault("PODTRACE_CPU_PLACEMENT_INTERVAL", DefaultCPUPlacementInterval)
+	NUMARemoteMemoryWarn = getFloatEnvOrDefault("PODTRACE_NUMA_REMOTE_MEMORY_WARN", DefaultNUMARemoteMemoryWarn)
+	RunQueueWaitWarn     = getFloatEnvOrDefault("PODTRACE_RUNQUEUE_WAIT_WARN", DefaultRunQueueWaitWarn)
+
+	// PageCacheInterval is how often the per-cgroup read and page-cache
+	// miss counters are turned into an EventPageCache.
+	PageCacheInterval = getDurationEnvOrDefault("PODTRACE_PAGE_CACHE_INTERVAL", DefaultPageCacheInterval)
+
+	// FsNotifyInterval is how often the per-cgroup inotify and fanotify
+	// counters are turned into an EventFsNotify.
+	FsNotifyInterval = getDurationEnvOrDefault("PODTRACE_FSNOTIFY_INTERVAL", DefaultFsNotifyInterval)
+	// FsNotifyWatchRateWarn is the rate of inotify watch and fanotify mark
+	// registrations, per second over an interval, that flags a cgroup.
+	FsNotifyWatchRateWarn = getFloatEnvOrDefault("PODTRACE_FSNOTIFY_WATCH_RATE_WARN", DefaultFsNotifyWatchRateWarn)
+
+	// TmpfsMemoryWarn is the share of a cgroup's memory limit its tmpfs
+	// files (emptyDirs with medium Memory, /dev/shm) may hold before the
+	// cgroup is flagged.
+	TmpfsMemoryWarn = getFloatEnvOrDefault("PODTRACE_TMPFS_MEMORY_WARN", DefaultTmpfsMemoryWarn)
+
+	// ThreadCPUInterval is how often the per-thread on-CPU time is turned
+	// into EventThreadCPU events.
+	ThreadCPUInterval = getDurationEnvOrDefault("PODTRACE_THREAD_CPU_INTERVAL", DefaultThreadCPUInterval)
+
+	// CRIOps polls the container runtime for the target pods' container
+	// starts and times its answers, every CRIOpsInterval. Starts older than
+	// CRIOpsLookback before the trace are left out.
+	CRIOps         = getBoolEnvOrDefault("PODTRACE_CRI_OPS", true)
+	CRIOpsInterval = getDurationEnvOrDefault("PODTRACE_CRI_OPS_INTERVAL", DefaultCRIOpsInterval)
+	CRIOpsLookback = getDurationEnvOrDefault("PODTRACE_CRI_OPS_LOOKBACK", DefaultCRIOpsLookback)
+
+	// ImagePulls watches the kubelet's image pull events for the target
+	// pods, back to ImagePullLookback before the trace. RegistryProbe times
+	// a TCP connect to the registry of every pull reported. A pull slower
+	// than ImagePullSlowWarn, or a failed one, is an issue.
+	ImagePulls        = getBoolEnvOrDefault("PODTRACE_IMAGE_PULLS", true)
+	ImagePullLookback = getDurationEnvOrDefault("PODTRACE_IMAGE_PULL_LOOKBACK", DefaultImagePullLookback)
+	RegistryProbe     = getBoolEnvOrDefault("PODTRACE_REGISTRY_PROBE", true)
+	ImagePullSlowWarn = getDurationEnvOrDefault("PODTRACE_IMAGE_PULL_SLOW", DefaultImagePullSlowWarn)
+
+	// Disruptions watches the evictions and preemptions of pods on the
+	// traced node, and its pressure conditions. The traffic DisruptionWindow
+	// before and after each one is compared.
+	Disruptions      = getBoolEnvOrDefault("PODTRACE_DISRUPTIONS", true)
+	DisruptionWindow = getDurationEnvOrDefault("PODTRACE_DISRUPTION_WINDOW", DefaultDisruptionWindow)
+
+	// ConnectRaceWindow is the longest gap between two connect attempts
+	// of one process to the same port and name that are still taken as
+	// legs of one raced connect (happy eyeballs, parallel endpoints).
+	ConnectRaceWindow = getDurationEnvOrDefault("PODTRACE_CONNECT_RACE_WINDOW", DefaultConnectRaceWindow)
+
+	// A target with at least ConnectionChurnMin connects and as many as
+	// ConnectionChurnWarn connects per send is flagged for churn.
+	ConnectionChurnMin  = getIntEnvOrDefault("PODTRACE_CONNECTION_CHURN_MIN", DefaultConnectionChurnMin)
+	ConnectionChurnWarn = getFloatEnvOrDefault("PODTRACE_CONNECTION_CHURN_WARN", DefaultConnectionChurnWarn)
+
+	// An endpoint is flagged for a 5xx burst when HTTP5xxBurstMin of its
+	// responses within HTTP5xxBurstWindow were 5xx, and for throttling when
+	// HTTPThrottleMin of its responses were 429.
+	HTTP5xxBurstWindow = getDurationEnvOrDefault("PODTRACE_HTTP_5XX_BURST_WINDOW", DefaultHTTP5xxBurstWindow)
+	HTTP5xxBurstMin    = getIntEnvOrDefault("PODTRACE_HTTP_5XX_BURST_MIN", DefaultHTTP5xxBurstMin)
+	HTTPThrottleMin    = getIntEnvOrDefault("PODTRACE_HTTP_THROTTLE_MIN", DefaultHTTPThrottleMin)
+
+	// A client timeout is inferred for a target when ClientTimeoutMin of the
+	// requests to it were abandoned after durations within
+	// ClientTimeoutTolerance of each other.
+	ClientTimeoutMin       = getIntEnvOrDefault("PODTRACE_CLIENT_TIMEOUT_MIN", DefaultClientTimeoutMin)
+	ClientTimeoutTolerance = getFloatEnvOrDefault("PODTRACE_CLIENT_TIMEOUT_TOLERANCE", DefaultClientTimeoutTolerance)
+
+	// ServiceTranslation reads the node's load-balancer tables, IPVS
+	// connections and Cilium's pinned service maps under CiliumBPFDir, to
+	// attribute traffic to backend pods to the Service that was dialed. The
+	// tables are re-read at most every ServiceTranslationRefresh.
+	ServiceTranslation        = getBoolEnvOrDefault("PODTRACE_SERVICE_TRANSLATION", true)
+	CiliumBPFDir              = getEnvOrDefault("PODTRACE_CILIUM_BPF_DIR", DefaultCiliumBPFDir)
+	ServiceTranslationRefresh = getDurationEnvOrDefault("PODTRACE_SERVICE_TRANSLATION_REFRESH", DefaultServiceTranslationRefresh)
+
+	// In live mode the detection rules are evaluated every LiveAlertInterval
+	// (0 disables it) so an alert is printed without waiting for the next
+	// report refresh; a rule that keeps firing is alerted again at most once
+	// per LiveAlertDebounce.
+	LiveAlertInterval = getDurationEnvOrDefault("PODTRACE_LIVE_ALERT_INTERVAL", DefaultLiveAlertInterval)
+	LiveAlertDebounce = getDurationEnvOrDefault("PODTRACE_LIVE_ALERT_DEBOUNCE", DefaultLiveAlertDebounce)
+
+	// SnapshotDir is where SIGUSR2 writes a snapshot of a running trace;
+	// empty is the working directory.
+	SnapshotDir = getEnvOrDefault("PODTRACE_SNAPSHOT_DIR", "")
+
+	// A privileged `podtrace loader` pins the maps of a session under
+	// PinDir/<session> on bpffs and describes it in PinStateDir, where an
+	// unprivileged podtrace started with PinnedSession picks them up.
+	PinDir        = getEnvOrDefault("PODTRACE_PIN_DIR", DefaultPinDir)
+	PinStateDir   = getEnvOrDefault("PODTRACE_PIN_STATE_DIR", DefaultPinStateDir)
+	PinnedSession = getEnvOrDefault("PODTRACE_PINNED_SESSION", "")
+
+	// AgentLockWait is how long an agent waits for another agent on the
+	// same node to release the node lock, as in a surge rollout, before it
+	// refuses to start rather than attach every probe a second time.
+	AgentLockWait = getDurationEnvOrDefault("PODTRACE_AGENT_LOCK_WAIT", DefaultAgentLockWait)
+
+	// The agent reads the node's pressure stall information every
+	// AgentPressureInterval (zero turns the backoff off). When some task
+	// stalled on CPU, memory or I/O for AgentPressureHigh percent of the
+	// last 10 seconds, it narrows its probes to AgentPressureCategories and
+	// keeps one event in AgentPressureKeepOneIn, until every resource is
+	// below AgentPressureLow again.
+	AgentPressureInterval   = getDurationEnvOrDefault("PODTRACE_AGENT_PRESSURE_INTERVAL", DefaultAgentPressureInterval)
+	AgentPressureHigh       = getFloatEnvOrDefault("PODTRACE_AGENT_PRESSURE_HIGH", DefaultAgentPressureHigh)
+	AgentPressureLow        = getFloatEnvOrDefault("PODTRACE_AGENT_PRESSURE_LOW", DefaultAgentPressureLow)
+	AgentPressureCategories = getEnvOrDefault("PODTRACE_AGENT_PRESSURE_CATEGORIES", DefaultAgentPressureCategories)
+	AgentPressureKeepOneIn  = getIntEnvOrDefault("PODTRACE_AGENT_PRESSURE_KEEP_ONE_IN", DefaultAgentPressureKeepOneIn)
+
+	// The agent samples its own goroutines, heap and BPF map fill every
+	// AgentWatchdogInterval (zero turns the watchdog off) and keeps the
+	// last AgentWatchdogWindow samples. A resource past its limit, or whose
+	// floor kept rising across the window, marks the agent self-degraded;
+	// with AgentWatchdogRecycle the agent then exits so the DaemonSet
+	// restarts it with a fresh session.
+	AgentWatchdogInterval      = getDurationEnvOrDefault("PODTRACE_AGENT_WATCHDOG_INTERVAL", DefaultAgentWatchdogInterval)
+	AgentWatchdogWindow        = getIntEnvOrDefault("PODTRACE_AGENT_WATCHDOG_WINDOW", DefaultAgentWatchdogWindow)
+	AgentWatchdogMaxGoroutines = getIntEnvOrDefault("PODTRACE_AGENT_WATCHDOG_MAX_GOROUTINES", DefaultAgentWatchdogMaxGoroutines)
+	AgentWatchdogMaxHeapMB     = getIntEnvOrDefault("PODTRACE_AGENT_WATCHDOG_MAX_HEAP_MB", DefaultAgentWatchdogMaxHeapMB)
+	AgentWatchdogMaxMapFill    = getFloatEnvOrDefault("PODTRACE_AGENT_WATCHDOG_MAX_MAP_FILL", DefaultAgentWatchdogMaxMapFill)
+	AgentWatchdogRecycle       = getBoolEnvOrDefault("PODTRACE_AGENT_WATCHDOG_RECYCLE", false)
+
+	// ClockRecalibrateInterval is how often the offset between
+	// bpf_ktime_get_ns() and wall-clock time is sampled again, so NTP
+	// adjustments during a long trace do not skew exported timestamps.
+	// Zero keeps the offset taken at start.
+	ClockRecalibrateInterval = getDurationEnvOrDefault("PODTRACE_CLOCK_RECALIBRATE_INTERVAL", DefaultClockRecalibrateInterval)
+
+	// ExportBucketInterval is the width of the time-series buckets of the
+	// JSON export; zero leaves them out. Long sessions get wider buckets so
+	// the series stay at most MaxExportBuckets long.
+	ExportBucketInterval = getDurationEnvOrDefault("PODTRACE_EXPORT_BUCKET_INTERVAL", DefaultExportBucketInterval)
+
+	// ControlFile is reread on SIGHUP and when it changes, to retune the
+	// thresholds, filter, sampling rate and alert levels of a running trace.
+	ControlFile = getEnvOrDefault("PODTRACE_CONTROL_FILE", "")
+
+	// StartArmed loads and attaches every probe with event emission off,
+	// for `podtrace arm --fire` to turn on; set by --armed. ArmSocket is
+	// where an armed podtrace listens for it.
+	StartArmed bool
+	ArmSocket  = getEnvOrDefault("PODTRACE_ARM_SOCKET", DefaultArmSocket)
+
+	// PinState pins the BPF maps and probe links of a trace under
+	// PinDir/<pod-uid>, so a podtrace restarted after a crash or an upgrade
+	// picks up the in-flight state instead of starting from empty maps.
+	// StatePinDir is that directory, set by the CLI once the target pod is
+	// resolved.
+	PinState    = getBoolEnvOrDefault("PODTRACE_PIN_STATE", false)
+	StatePinDir string
+
+	// NoisyNeighbors samples the CPU and block I/O of the other pods on the
+	// traced node every NeighborInterval and records the NeighborTop busiest,
+	// to name suspects when the traced pods wait for a CPU or on I/O.
+	NoisyNeighbors   = getBoolEnvOrDefault("PODTRACE_NOISY_NEIGHBORS", true)
+	NeighborInterval = getDurationEnvOrDefault("PODTRACE_NEIGHBOR_INTERVAL", DefaultNeighborInterval)
+	NeighborTop      = getIntEnvOrDefault("PODTRACE_NEIGHBOR_TOP", DefaultNeighborTop)
+
+	// ChaosDetect looks at the traced pods' network every ChaosInterval for
+	// the netem qdiscs and iptables DROP rules chaos tools inject, so the
+	// report marks the intervals they were in place.
+	ChaosDetect   = getBoolEnvOrDefault("PODTRACE_CHAOS_DETECT", true)
+	ChaosInterval = getDurationEnvOrDefault("PODTRACE_CHAOS_INTERVAL", DefaultChaosInterval)
+
+	// PauseDetect looks at the traced containers every PauseInterval for a
+	// frozen cgroup or a stopped main process, so the gaps they leave in
+	// the trace are not put down to the network or the disk.
+	PauseDetect   = getBoolEnvOrDefault("PODTRACE_PAUSE_DETECT", true)
+	PauseInterval = getDurationEnvOrDefault("PODTRACE_PAUSE_INTERVAL", DefaultPauseInterval)
+
+	// DebugAddr is where pprof and runtime stats for podtrace itself are
+	// served; empty disables the endpoint.
+	DebugAddr = getEnvOrDefault("PODTRACE_DEBUG_ADDR", "")
+
+	// Every tracing exporter gets its own queue of TracingQueueSize traces,
+	// so a slow collector only backs up its own queue; traces that do not
+	// fit are dropped and counted. A worker sends up to TracingBatchSize
+	// traces at a time, or what it has after TracingBatchTimeout, and
+	// retries a failed batch up to TracingRetryMaxAttempts times, waiting
+	// from TracingRetryInitialBackoff, doubling, up to
+	// TracingRetryMaxBackoff.
+	TracingQueueSize           = getIntEnvOrDefault("PODTRACE_TRACING_QUEUE_SIZE", DefaultTracingQueueSize)
+	TracingBatchSize           = getIntEnvOrDefault("PODTRACE_TRACING_BATCH_SIZE", DefaultTracingBatchSize)
+	TracingBatchTimeout        = getDurationEnvOrDefault("PODTRACE_TRACING_BATCH_TIMEOUT", DefaultTracingBatchTimeout)
+	TracingRetryMaxAttempts    = getIntEnvOrDefault("PODTRACE_TRACING_RETRY_MAX_ATTEMPTS", DefaultTracingRetryMaxAttempts)
+	TracingRetryInitialBackoff = getDurationEnvOrDefault("PODTRACE_TRACING_RETRY_INITIAL_BACKOFF", DefaultTracingRetryInitialBackoff)
+	TracingRetryMaxBackoff     = getDurationEnvOrDefault("PODTRACE_TRACING_RETRY_MAX_BACKOFF", DefaultTracingRetryMaxBackoff)
+)
+
+const (
+	DefaultArtifactCacheDir          = "/var/cache/podtrace"
+	DefaultArtifactFetchTimeout      = 30 * time.Second
+	DefaultIssueHookCooldown         = 5 * time.Minute
+	DefaultIssueHookTimeout          = 30 * time.Second
+	DefaultMarkCommandTimeout        = 2 * time.Minute
+	DefaultHistoryMaxRuns            = 200
+	DefaultHistoryCompareMinChange   = 0.2
+	DefaultProbeAttachConcurrency    = 8
+	DefaultArmSocket                 = "/tmp/podtrace-arm.sock"
+	DefaultInitContainerWaitTimeout  = 10 * time.Minute
+	DefaultInitContainerPollInterval = time.Second
+	DefaultReverseDNSTimeout         = 2 * time.Second
+	DefaultTargetNameTTL             = 10 * time.Minute
+	DefaultPodThroughputInterval     = 5 * time.Second
+	DefaultBandwidthSaturation       = 0.9
+	DefaultCPUPlacementInterval      = 10 * time.Second
+	DefaultNUMARemoteMemoryWarn      = 0.25
+	DefaultRunQueueWaitWarn          = 0.1
+	DefaultPageCacheInterval         = 10 * time.Second
+	DefaultFsNotifyInterval          = 10 * time.Second
+	DefaultFsNotifyWatchRateWarn     = 100.0
+	DefaultTmpfsMemoryWarn           = 0.2
+	DefaultThreadCPUInterval         = 10 * time.Second
+	DefaultCRIOpsInterval            = 10 * time.Second
+	DefaultCRIOpsLookback            = 15 * time.Minute
+	DefaultImagePullLookback         = time.Hour
+	DefaultImagePullSlowWarn         = 30 * time.Second
+	DefaultRegistryProbeTimeout      = 5 * time.Second
+	DefaultDisruptionWindow          = 30 * time.Second
+	DefaultNeighborInterval          = 5 * time.Second
+	DefaultChaosInterval             = 5 * time.Second
+	DefaultPauseInterval             = time.Second
+	DefaultConnectRaceWindow         = 2 * time.Second
+	DefaultConnectionChurnMin        = 20
+	DefaultConnectionChurnWarn       = 0.2
+	DefaultHTTP5xxBurstWindow        = 10 * time.Second
+	DefaultHTTP5xxBurstMin           = 5
+	DefaultHTTPThrottleMin           = 3
+	DefaultClientTimeoutMin          = 5
+	DefaultClientTimeoutTolerance    = 0.05
+	DefaultNeighborTop               = 5
+	DefaultCiliumBPFDir              = "/sys/fs/bpf/tc/globals"
+	DefaultServiceTranslationRefresh = 10 * time.Second
+	DefaultLiveAlertInterval         = time.Second
+	DefaultLiveAlertDebounce         = 30 * time.Second
+	DefaultPinDir                    = "/sys/fs/bpf/podtrace"
+	DefaultPinStateDir               = "/run/podtrace"
+	DefaultDebugHostRoot             = "/host"
+	DefaultAgentLockWait             = 30 * time.Second
+	DefaultAgentPressureInterval     = 10 * time.Second
+	DefaultAgentPressureHigh         = 40.0
+	DefaultAgentPressureLow          = 20.0
+	DefaultAgentPressureCategories   = "dns,net,proc"
+	DefaultAgentPressureKeepOneIn    = 10
+	DefaultClockRecalibrateInterval  = 30 * time.Second
+	DefaultExportBucketInterval      = 10 * time.Second
+	MaxExportBuckets                 = 720
+	// DefaultClusterCIDRs are the private and CGNAT ranges pod and service
+	// networks are carved from.
+	DefaultClusterCIDRs = "10.0.0.0/8,172.16.0.0/12,192.168.0.0/16,100.64.0.0/10,fc00::/7"
+)
+
+const (
+	DefaultAgentWatchdogInterval      = time.Minute
+	DefaultAgentWatchdogWindow        = 30
+	DefaultAgentWatchdogMaxGoroutines = 10000
+	DefaultAgentWatchdogMaxHeapMB     = 1024
+	DefaultAgentWatchdogMaxMapFill    = 0.9
+)
+
+const (
+	DefaultTracingQueueSize           = 2048
+	DefaultTracingBatchSize           = 256
+	DefaultTracingBatchTimeout        = 2 * time.Second
+	DefaultTracingRetryMaxAttempts    = 5
+	DefaultTracingRetryInitialBackoff = 500 * time.Millisecond
+	DefaultTracingRetryMaxBackoff     = 30 * time.Second
 )
 
 func SetCgroupBasePath(path string) {
@@ -268,6 +667,48 @@ func SetProcBasePath(path string) {
 	ProcBasePath = path
 }
 
+// appliedHostRoot is the root ApplyHostRoot moved the paths under, so a
+// second call does not prefix them twice.
+var appliedHostRoot string
+
+// hostPaths are the paths ApplyHostRoot moves under the host root, with the
+// variable that sets each one explicitly.
+var hostPaths = []struct {
+	path *string
+	env  string
+}{
+	{&CgroupBasePath, "PODTRACE_CGROUP_BASE"},
+	{&ProcBasePath, "PODTRACE_PROC_BASE"},
+	{&DockerBasePath, "PODTRACE_DOCKER_BASE"},
+	{&ContainerdBasePath, "PODTRACE_CONTAINERD_BASE"},
+	{&LdSoConfBasePath, "PODTRACE_LDSOCONF_BASE"},
+	{&CiliumBPFDir, "PODTRACE_CILIUM_BPF_DIR"},
+	{&PinDir, "PODTRACE_PIN_DIR"},
+	{&PinStateDir, "PODTRACE_PIN_STATE_DIR"},
+}
+
+// ApplyHostRoot makes root the host root: the proc, cgroup, bpffs and
+// container runtime paths not set by their own variable are read under it.
+func ApplyHostRoot(root string) {
+	if root == "" || root == "/" || appliedHostRoot != "" {
+		return
+	}
+	HostRoot, appliedHostRoot = root, root
+	for _, p := range hostPaths {
+		if os.Getenv(p.env) == "" {
+			*p.path = filepath.Join(root, *p.path)
+		}
+	}
+}
+
+// HostPath returns the path under the host root of a path on the node.
+func HostPath(path string) string {
+	if HostRoot == "" || HostRoot == "/" {
+		return path
+	}
+	return filepath.Join(HostRoot, path)
+}
+
 func GetDefaultLibSearchPaths() []string {
 	return []string{"/lib", "/usr/lib", "/lib64", "/usr/lib64", "/usr/local/lib"}
 }
@@ -305,12 +746,12 @@ func GetDockerContainerRootfs(containerID string) (string, error) {
 }
 
 func GetContainerdOverlayPattern() string {
-	pattern := getEnvOrDefault("PODTRACE_CONTAINERD_OVERLAY_PATTERN", ContainerdOverlayPath)
+	pattern := getEnvOrDefault("PODTRACE_CONTAINERD_OVERLAY_PATTERN", HostPath(ContainerdOverlayPath))
 	return pattern
 }
 
 func GetContainerdNativePattern() string {
-	pattern := getEnvOrDefault("PODTRACE_CONTAINERD_NATIVE_PATTERN", ContainerdNativePath)
+	pattern := getEnvOrDefault("PODTRACE_CONTAINERD_NATIVE_PATTERN", HostPath(ContainerdNativePath))
 	return pattern
 }
 
@@ -458,6 +899,12 @@ func AllowNonLoopbackMetrics() bool {
 	return getBoolEnvOrDefault("PODTRACE_METRICS_INSECURE_ALLOW_ANY_ADDR", false)
 }
 
+// AllowNonLoopbackDebug permits the --debug-addr endpoint to listen beyond
+// the loopback interface.
+func AllowNonLoopbackDebug() bool {
+	return getBoolEnvOrDefault("PODTRACE_DEBUG_INSECURE_ALLOW_ANY_ADDR", false)
+}
+
 func GetAlertMinSeverity() string {
 	return getEnvOrDefault("PODTRACE_ALERT_MIN_SEVERITY", "warning")
 }
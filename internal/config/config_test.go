@@ -118,6 +118,49 @@ func TestSetProcBasePath(t *testing.T) {
 	}
 }
 
+func TestApplyHostRoot(t *testing.T) {
+	saved := make([]string, len(hostPaths))
+	for i, p := range hostPaths {
+		saved[i] = *p.path
+	}
+	origRoot, origApplied := HostRoot, appliedHostRoot
+	defer func() {
+		for i, p := range hostPaths {
+			*p.path = saved[i]
+		}
+		HostRoot, appliedHostRoot = origRoot, origApplied
+	}()
+	CgroupBasePath, ProcBasePath, PinDir, HostRoot = "/sys/fs/cgroup", "/custom/proc", DefaultPinDir, ""
+	t.Setenv("PODTRACE_PROC_BASE", "/custom/proc")
+
+	ApplyHostRoot("/")
+	if HostRoot != "" || CgroupBasePath != "/sys/fs/cgroup" {
+		t.Fatalf("ApplyHostRoot(/) changed paths: root %q, cgroup %q", HostRoot, CgroupBasePath)
+	}
+
+	ApplyHostRoot("/host")
+	if CgroupBasePath != "/host/sys/fs/cgroup" {
+		t.Errorf("CgroupBasePath = %q, want /host/sys/fs/cgroup", CgroupBasePath)
+	}
+	if PinDir != "/host/sys/fs/bpf/podtrace" {
+		t.Errorf("PinDir = %q, want /host/sys/fs/bpf/podtrace", PinDir)
+	}
+	if ProcBasePath != "/custom/proc" {
+		t.Errorf("ProcBasePath = %q, want the PODTRACE_PROC_BASE value kept", ProcBasePath)
+	}
+	if got := HostPath("/run/containerd/containerd.sock"); got != "/host/run/containerd/containerd.sock" {
+		t.Errorf("HostPath = %q", got)
+	}
+	if got := GetContainerdOverlayPattern(); got != "/host"+ContainerdOverlayPath {
+		t.Errorf("GetContainerdOverlayPattern() = %q", got)
+	}
+
+	ApplyHostRoot("/host")
+	if CgroupBasePath != "/host/sys/fs/cgroup" {
+		t.Errorf("second ApplyHostRoot prefixed again: %q", CgroupBasePath)
+	}
+}
+
 func TestGetMetricsAddress(t *testing.T) {
 	key := "PODTRACE_METRICS_ADDR"
 	originalValue := os.Getenv(key)
@@ -0,0 +1,114 @@
+// Package coredump describes where the kernel sent the core of a traced
+// process that crashed, so the report can point at the dump to open next to
+// the signal and faulting frames.
+package coredump
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+// Details line prefixes of an EventCrash, written when the event is received
+// (while the crashing process still exists) and read back by the report.
+const (
+	corePrefix  = "core: "
+	framePrefix = "frame: "
+)
+
+// Pattern returns the node's kernel.core_pattern, or "" when it cannot be
+// read.
+func Pattern() string {
+	data, err := procfs.ReadFile("sys/kernel/core_pattern")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// Destination describes where a core written under pattern ends up for the
+// process pid (named comm) that received sig at time at. Piped patterns name
+// their handler, with the coredumpctl command for systemd-coredump; file
+// patterns are expanded as far as the host-side view allows.
+func Destination(pattern string, pid uint32, comm string, sig int32, at time.Time) string {
+	if pattern == "" {
+		return ""
+	}
+	if handler, ok := strings.CutPrefix(pattern, "|"); ok {
+		fields := strings.Fields(handler)
+		if len(fields) == 0 {
+			return "piped to an empty handler"
+		}
+		name := filepath.Base(fields[0])
+		switch {
+		case strings.Contains(name, "systemd-coredump"):
+			return "systemd-coredump (coredumpctl info " + strconv.FormatUint(uint64(pid), 10) + ")"
+		case name == "apport":
+			return "apport (/var/crash)"
+		}
+		return "piped to " + name
+	}
+	path := expand(pattern, pid, comm, sig, at)
+	if !strings.HasPrefix(path, "/") {
+		path += " (relative to the process working directory)"
+	}
+	return path
+}
+
+// expand substitutes the core_pattern specifiers that can be known from the
+// host. %p, %u, %h and the rest depend on the crashed process's namespaces
+// and are left for the reader.
+func expand(pattern string, pid uint32, comm string, sig int32, at time.Time) string {
+	var b strings.Builder
+	for i := 0; i < len(pattern); i++ {
+		if pattern[i] != '%' || i+1 == len(pattern) {
+			b.WriteByte(pattern[i])
+			continue
+		}
+		i++
+		switch pattern[i] {
+		case '%':
+			b.WriteByte('%')
+		case 'P':
+			b.WriteString(strconv.FormatUint(uint64(pid), 10))
+		case 'e':
+			b.WriteString(comm)
+		case 's':
+			b.WriteString(strconv.Itoa(int(sig)))
+		case 't':
+			b.WriteString(strconv.FormatInt(at.Unix(), 10))
+		default:
+			b.WriteByte('%')
+			b.WriteByte(pattern[i])
+		}
+	}
+	return b.String()
+}
+
+// FormatDetails encodes a crash's core destination and resolved frames as
+// EventCrash Details.
+func FormatDetails(destination string, frames []string) string {
+	lines := make([]string, 0, len(frames)+1)
+	if destination != "" {
+		lines = append(lines, corePrefix+destination)
+	}
+	for _, f := range frames {
+		lines = append(lines, framePrefix+f)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseDetails is the inverse of FormatDetails.
+func ParseDetails(details string) (destination string, frames []string) {
+	for _, line := range strings.Split(details, "\n") {
+		if v, ok := strings.CutPrefix(line, corePrefix); ok {
+			destination = v
+		} else if v, ok := strings.CutPrefix(line, framePrefix); ok {
+			frames = append(frames, v)
+		}
+	}
+	return destination, frames
+}
@@ -0,0 +1,65 @@
+package coredump
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+func TestDestination(t *testing.T) {
+	at := time.Unix(1700000000, 0)
+	cases := []struct {
+		pattern string
+		want    string
+	}{
+		{"|/usr/lib/systemd/systemd-coredump %P %u %g %s %t %c %h", "systemd-coredump (coredumpctl info 4321)"},
+		{"|/usr/share/apport/apport -p%p -s%s -c%c", "apport (/var/crash)"},
+		{"|/usr/local/bin/dumper %p", "piped to dumper"},
+		{"/var/cores/core.%e.%P.%s.%t", "/var/cores/core.api.4321.11.1700000000"},
+		{"/cores/%h-%p-100%%", "/cores/%h-%p-100%"},
+		{"core", "core (relative to the process working directory)"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		if got := Destination(c.pattern, 4321, "api", 11, at); got != c.want {
+			t.Errorf("Destination(%q) = %q, want %q", c.pattern, got, c.want)
+		}
+	}
+}
+
+func TestPattern(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sys", "kernel"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sys", "kernel", "core_pattern"), []byte("|/lib/systemd/systemd-coredump %P\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orig := config.ProcBasePath
+	config.ProcBasePath = dir
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.ProcBasePath = orig
+		procfs.ResetForTesting()
+	})
+
+	if got := Pattern(); got != "|/lib/systemd/systemd-coredump %P" {
+		t.Errorf("Pattern() = %q", got)
+	}
+}
+
+func TestDetailsRoundTrip(t *testing.T) {
+	frames := []string{"api:main.c:42", "libc.so.6@0x7f00"}
+	dest, got := ParseDetails(FormatDetails("/var/cores/core.1", frames))
+	if dest != "/var/cores/core.1" || !reflect.DeepEqual(got, frames) {
+		t.Errorf("round trip = %q %v", dest, got)
+	}
+	if dest, got := ParseDetails(""); dest != "" || got != nil {
+		t.Errorf("empty details = %q %v", dest, got)
+	}
+}
@@ -0,0 +1,155 @@
+package cri
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"google.golang.org/grpc/status"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/safeconv"
+)
+
+// Labels the kubelet puts on every container it creates.
+const (
+	labelPodName      = "io.kubernetes.pod.name"
+	labelPodNamespace = "io.kubernetes.pod.namespace"
+)
+
+// Operation is one container runtime operation on a traced pod.
+type Operation struct {
+	// Name is the CRI call, one of the events.CRIOp names.
+	Name        string
+	ContainerID string
+	Container   string
+	Image       string
+	// At is when the operation finished.
+	At      time.Time
+	Latency time.Duration
+	Failed  bool
+	// Reason is the runtime's reason for a container that exited without
+	// starting, or the gRPC code of a failed call.
+	Reason string
+}
+
+// Event returns o as an EventCRIOp.
+func (o Operation) Event() *events.Event {
+	e := &events.Event{
+		Type:      events.EventCRIOp,
+		Timestamp: clock.WallToBPFTimestamp(o.At),
+		LatencyNS: safeconv.Int64ToUint64(o.Latency.Nanoseconds()),
+		Target:    o.Name,
+		Details:   events.CRIOpDetails{Container: o.Container, Image: o.Image, Reason: o.Reason}.String(),
+	}
+	if o.Failed {
+		e.Error = 1
+	}
+	return e
+}
+
+// OperationWatcher reports the runtime operations on one pod, each container
+// start once.
+type OperationWatcher struct {
+	r         *Resolver
+	namespace string
+	pod       string
+	since     time.Time
+	reported  map[string]bool
+	now       func() time.Time
+}
+
+// NewOperationWatcher watches namespace/pod through r; container starts
+// before since are not reported.
+func NewOperationWatcher(r *Resolver, namespace, pod string, since time.Time) *OperationWatcher {
+	return &OperationWatcher{
+		r:         r,
+		namespace: namespace,
+		pod:       pod,
+		since:     since,
+		reported:  make(map[string]bool),
+		now:       time.Now,
+	}
+}
+
+// Poll lists the pod's containers and returns the timed calls it made and
+// every container start not reported yet. The error is that of the listing;
+// the returned operations include it.
+func (w *OperationWatcher) Poll(ctx context.Context) ([]Operation, error) {
+	if w.r == nil || w.r.client == nil {
+		return nil, errors.New("podtrace: CRI resolver not initialized")
+	}
+	begin := w.now()
+	resp, err := w.r.client.ListContainers(ctx, &runtimeapi.ListContainersRequest{
+		Filter: &runtimeapi.ContainerFilter{LabelSelector: map[string]string{
+			labelPodName:      w.pod,
+			labelPodNamespace: w.namespace,
+		}},
+	})
+	ops := []Operation{w.timed(events.CRIOpListContainers, begin, err)}
+	if err != nil {
+		return ops, err
+	}
+	for _, c := range resp.GetContainers() {
+		begin = w.now()
+		st, err := w.r.client.ContainerStatus(ctx, &runtimeapi.ContainerStatusRequest{ContainerId: c.GetId()})
+		op := w.timed(events.CRIOpContainerStatus, begin, err)
+		op.ContainerID, op.Container, op.Image = c.GetId(), c.GetMetadata().GetName(), c.GetImage().GetImage()
+		ops = append(ops, op)
+		if err != nil || w.reported[c.GetId()] {
+			continue
+		}
+		start, done := startOperation(st.GetStatus())
+		if !done {
+			continue
+		}
+		w.reported[c.GetId()] = true
+		if start.At.Before(w.since) {
+			continue
+		}
+		start.ContainerID, start.Container, start.Image = op.ContainerID, op.Container, op.Image
+		ops = append(ops, start)
+	}
+	return ops, nil
+}
+
+// timed records a call of podtrace's own that began at begin.
+func (w *OperationWatcher) timed(name string, begin time.Time, err error) Operation {
+	end := w.now()
+	op := Operation{Name: name, At: end, Latency: end.Sub(begin)}
+	if err != nil {
+		op.Failed, op.Reason = true, status.Code(err).String()
+	}
+	return op
+}
+
+// startOperation returns the start of the container s describes, and false
+// while it is neither running nor exited.
+func startOperation(s *runtimeapi.ContainerStatus) (Operation, bool) {
+	if s == nil || s.GetCreatedAt() == 0 {
+		return Operation{}, false
+	}
+	created := time.Unix(0, s.GetCreatedAt())
+	op := Operation{Name: events.CRIOpStartContainer}
+	switch {
+	case s.GetStartedAt() > 0:
+		op.At = time.Unix(0, s.GetStartedAt())
+	case s.GetState() == runtimeapi.ContainerState_CONTAINER_EXITED:
+		// The container exited without running: a failed start.
+		op.At, op.Failed, op.Reason = created, true, s.GetReason()
+		if s.GetFinishedAt() > 0 {
+			op.At = time.Unix(0, s.GetFinishedAt())
+		}
+		if op.Reason == "" {
+			op.Reason = "Exited"
+		}
+	default:
+		return Operation{}, false
+	}
+	if op.At.After(created) {
+		op.Latency = op.At.Sub(created)
+	}
+	return op, true
+}
@@ -0,0 +1,137 @@
+package cri
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// fakeOperationsServer lists containers and answers their status from
+// statuses, keyed by container ID.
+type fakeOperationsServer struct {
+	runtimeapi.UnimplementedRuntimeServiceServer
+	statuses map[string]*runtimeapi.ContainerStatus
+	selector map[string]string
+}
+
+func (f *fakeOperationsServer) ListContainers(_ context.Context, req *runtimeapi.ListContainersRequest) (*runtimeapi.ListContainersResponse, error) {
+	f.selector = req.GetFilter().GetLabelSelector()
+	resp := &runtimeapi.ListContainersResponse{}
+	for id, s := range f.statuses {
+		resp.Containers = append(resp.Containers, &runtimeapi.Container{Id: id, Metadata: s.Metadata, Image: s.Image})
+	}
+	return resp, nil
+}
+
+func (f *fakeOperationsServer) ContainerStatus(_ context.Context, req *runtimeapi.ContainerStatusRequest) (*runtimeapi.ContainerStatusResponse, error) {
+	return &runtimeapi.ContainerStatusResponse{Status: f.statuses[req.GetContainerId()]}, nil
+}
+
+func newOperationsResolver(t *testing.T, srv *fakeOperationsServer) *Resolver {
+	t.Helper()
+	lis := bufconn.Listen(bufSize)
+	gs := grpc.NewServer()
+	runtimeapi.RegisterRuntimeServiceServer(gs, srv)
+	go gs.Serve(lis) //nolint:errcheck
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient(
+		"passthrough://bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return &Resolver{endpoint: "bufnet", conn: conn, client: runtimeapi.NewRuntimeServiceClient(conn)}
+}
+
+func containerStatus(name string, created, started time.Time) *runtimeapi.ContainerStatus {
+	s := &runtimeapi.ContainerStatus{
+		Metadata:  &runtimeapi.ContainerMetadata{Name: name},
+		Image:     &runtimeapi.ImageSpec{Image: name + ":1.0"},
+		State:     runtimeapi.ContainerState_CONTAINER_RUNNING,
+		CreatedAt: created.UnixNano(),
+	}
+	if !started.IsZero() {
+		s.StartedAt = started.UnixNano()
+	}
+	return s
+}
+
+func TestOperationWatcherPoll(t *testing.T) {
+	base := time.Now().Add(-time.Minute)
+	failed := containerStatus("init", base, time.Time{})
+	failed.State, failed.Reason, failed.FinishedAt = runtimeapi.ContainerState_CONTAINER_EXITED, "StartError", base.Add(time.Second).UnixNano()
+	pending := containerStatus("sidecar", base, time.Time{})
+	pending.State = runtimeapi.ContainerState_CONTAINER_CREATED
+	srv := &fakeOperationsServer{statuses: map[string]*runtimeapi.ContainerStatus{
+		"c1":  containerStatus("app", base, base.Add(2500*time.Millisecond)),
+		"c2":  failed,
+		"c3":  pending,
+		"old": containerStatus("old", base.Add(-time.Hour), base.Add(-time.Hour+time.Second)),
+	}}
+	w := NewOperationWatcher(newOperationsResolver(t, srv), "shop", "web-0", base.Add(-time.Minute))
+
+	ops, err := w.Poll(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if srv.selector[labelPodName] != "web-0" || srv.selector[labelPodNamespace] != "shop" {
+		t.Errorf("label selector = %v", srv.selector)
+	}
+	starts := make(map[string]Operation)
+	calls := make(map[string]int)
+	for _, op := range ops {
+		if op.Name == events.CRIOpStartContainer {
+			starts[op.Container] = op
+		} else {
+			calls[op.Name]++
+		}
+	}
+	if calls[events.CRIOpListContainers] != 1 || calls[events.CRIOpContainerStatus] != 4 {
+		t.Errorf("calls = %v", calls)
+	}
+	if len(starts) != 2 {
+		t.Fatalf("starts = %+v", starts)
+	}
+	if app := starts["app"]; app.Failed || app.Latency != 2500*time.Millisecond || app.Image != "app:1.0" {
+		t.Errorf("app start = %+v", app)
+	}
+	if init := starts["init"]; !init.Failed || init.Reason != "StartError" || init.Latency != time.Second {
+		t.Errorf("init start = %+v", init)
+	}
+
+	// Starts are reported once; the pending container once it has started.
+	srv.statuses["c3"] = containerStatus("sidecar", base, base.Add(time.Second))
+	ops, _ = w.Poll(context.Background())
+	var again []string
+	for _, op := range ops {
+		if op.Name == events.CRIOpStartContainer {
+			again = append(again, op.Container)
+		}
+	}
+	if len(again) != 1 || again[0] != "sidecar" {
+		t.Errorf("second poll starts = %v", again)
+	}
+}
+
+func TestOperationEvent(t *testing.T) {
+	e := Operation{Name: events.CRIOpStartContainer, Container: "app", Latency: time.Second, Failed: true, Reason: "StartError", At: time.Now()}.Event()
+	if e.Type != events.EventCRIOp || e.Target != events.CRIOpStartContainer || e.LatencyNS != uint64(time.Second) || e.Error != 1 {
+		t.Errorf("event = %+v", e)
+	}
+	if d := events.ParseCRIOpDetails(e.Details); d.Container != "app" || d.Reason != "StartError" {
+		t.Errorf("details = %+v", d)
+	}
+}
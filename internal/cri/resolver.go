@@ -16,6 +16,7 @@ import (
 
 	runtimeapi "k8s.io/cri-api/pkg/apis/runtime/v1"
 
+	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/safeconv"
 )
 
@@ -63,6 +64,10 @@ func DefaultCandidateEndpoints() []string {
 			"unix:///var/run/podman/podman.sock",
 		)
 	}
+	// In a debug container the node's sockets are under the host root.
+	for i, ep := range endpoints {
+		endpoints[i] = "unix://" + config.HostPath(strings.TrimPrefix(ep, "unix://"))
+	}
 	return endpoints
 }
 
@@ -153,6 +158,19 @@ func (r *Resolver) Close() error {
 	return r.conn.Close()
 }
 
+// RuntimeVersion returns the name and version the runtime reports, e.g.
+// "containerd" and "v1.7.13".
+func (r *Resolver) RuntimeVersion(ctx context.Context) (name, version string, err error) {
+	if r == nil || r.client == nil {
+		return "", "", errors.New("podtrace: CRI resolver not initialized")
+	}
+	resp, err := r.client.Version(ctx, &runtimeapi.VersionRequest{})
+	if err != nil {
+		return "", "", fmt.Errorf("podtrace: CRI Version failed: %w", err)
+	}
+	return resp.GetRuntimeName(), resp.GetRuntimeVersion(), nil
+}
+
 func (r *Resolver) ResolveContainer(ctx context.Context, containerID string) (*ContainerInfo, error) {
 	if r == nil || r.client == nil {
 		return nil, errors.New("podtrace: CRI resolver not initialized")
@@ -26,6 +26,10 @@ func (f *fakeRuntimeServer) ContainerStatus(_ context.Context, req *runtimeapi.C
 	}, nil
 }
 
+func (f *fakeRuntimeServer) Version(context.Context, *runtimeapi.VersionRequest) (*runtimeapi.VersionResponse, error) {
+	return &runtimeapi.VersionResponse{RuntimeName: "containerd", RuntimeVersion: "v1.7.13"}, nil
+}
+
 // startFakeCRIServer starts an in-process gRPC CRI server and returns a conn to it.
 func startFakeCRIServer(t *testing.T, info map[string]string) *grpc.ClientConn {
 	t.Helper()
@@ -102,6 +106,22 @@ func TestResolveContainer_Success(t *testing.T) {
 	}
 }
 
+func TestRuntimeVersion(t *testing.T) {
+	r := newFakeResolver(t, nil)
+	name, version, err := r.RuntimeVersion(context.Background())
+	if err != nil || name != "containerd" || version != "v1.7.13" {
+		t.Errorf("RuntimeVersion = %q, %q, %v", name, version, err)
+	}
+	if _, _, err := (*Resolver)(nil).RuntimeVersion(context.Background()); err == nil {
+		t.Error("nil resolver: expected an error")
+	}
+	for id, want := range map[string]string{"containerd://0a1b2c": "containerd", "cri-o://0a1b2c": "cri-o", "0a1b2c": ""} {
+		if got := RuntimeFromContainerID(id); got != want {
+			t.Errorf("RuntimeFromContainerID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
+
 // TestResolveContainer_EmptyInfo covers the path where Info map has no JSON data.
 func TestResolveContainer_EmptyInfo(t *testing.T) {
 	r := newFakeResolver(t, map[string]string{})
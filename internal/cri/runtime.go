@@ -0,0 +1,73 @@
+package cri
+
+import (
+	"context"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Runtime is the container runtime of the node podtrace runs on, e.g.
+// containerd v1.7.13 or cri-o 1.29.1.
+type Runtime struct {
+	Name    string
+	Version string
+}
+
+// runtimeQueryTimeout bounds the one Version call LocalRuntime makes.
+const runtimeQueryTimeout = 2 * time.Second
+
+var local struct {
+	once    sync.Once
+	runtime Runtime
+}
+
+// LocalRuntime asks the node's CRI endpoint for its name and version on the
+// first call and returns the same answer after; the zero Runtime when there
+// is no reachable endpoint or PODTRACE_CRI_RESOLVE=false.
+func LocalRuntime() Runtime {
+	local.once.Do(func() {
+		if os.Getenv("PODTRACE_CRI_RESOLVE") == "false" {
+			return
+		}
+		r, err := NewResolver()
+		if err != nil {
+			return
+		}
+		defer func() { _ = r.Close() }()
+		ctx, cancel := context.WithTimeout(context.Background(), runtimeQueryTimeout)
+		defer cancel()
+		if name, version, err := r.RuntimeVersion(ctx); err == nil {
+			local.runtime = Runtime{Name: name, Version: version}
+		}
+	})
+	return local.runtime
+}
+
+// PodRuntime returns the node's runtime for pod, or only its name from the
+// pod's container IDs when the CRI cannot be asked.
+func PodRuntime(pod *corev1.Pod) Runtime {
+	if rt := LocalRuntime(); rt.Name != "" {
+		return rt
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if name := RuntimeFromContainerID(cs.ContainerID); name != "" {
+			return Runtime{Name: name}
+		}
+	}
+	return Runtime{}
+}
+
+// RuntimeFromContainerID returns the runtime named by the scheme of a
+// container status ID: "containerd" for "containerd://0a1b...", "" without
+// a scheme.
+func RuntimeFromContainerID(id string) string {
+	name, _, ok := strings.Cut(id, "://")
+	if !ok {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,170 @@
+// Package debugserver serves pprof and runtime statistics about the podtrace
+// process itself, on a port of its own, so its overhead can be profiled in
+// place. It is separate from the metrics server, which may be scraped from
+// outside the node.
+package debugserver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	pprofhttp "net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// requestsPerSecond bounds how often the endpoints can be hit: a CPU profile
+// or execution trace costs the traced node real CPU.
+const requestsPerSecond = 2
+
+var processStart = time.Now()
+
+// RuntimeStats is the body of /debug/runtime.
+type RuntimeStats struct {
+	Version       string  `json:"version"`
+	GoVersion     string  `json:"goVersion"`
+	UptimeSeconds float64 `json:"uptimeSeconds"`
+	Goroutines    int     `json:"goroutines"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+	NumCPU        int     `json:"numCPU"`
+	CgoCalls      int64   `json:"cgoCalls"`
+	HeapAlloc     uint64  `json:"heapAllocBytes"`
+	HeapInuse     uint64  `json:"heapInuseBytes"`
+	HeapObjects   uint64  `json:"heapObjects"`
+	StackInuse    uint64  `json:"stackInuseBytes"`
+	Sys           uint64  `json:"sysBytes"`
+	TotalAlloc    uint64  `json:"totalAllocBytes"`
+	NumGC         uint32  `json:"numGC"`
+	GCPauseTotal  float64 `json:"gcPauseTotalMs"`
+	LastGCPause   float64 `json:"lastGCPauseMs"`
+	GCCPUFraction float64 `json:"gcCPUFraction"`
+}
+
+// ReadRuntimeStats samples the process's runtime statistics.
+func ReadRuntimeStats() RuntimeStats {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	stats := RuntimeStats{
+		Version:       config.GetVersion(),
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: time.Since(processStart).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumCPU:        runtime.NumCPU(),
+		CgoCalls:      runtime.NumCgoCall(),
+		HeapAlloc:     ms.HeapAlloc,
+		HeapInuse:     ms.HeapInuse,
+		HeapObjects:   ms.HeapObjects,
+		StackInuse:    ms.StackInuse,
+		Sys:           ms.Sys,
+		TotalAlloc:    ms.TotalAlloc,
+		NumGC:         ms.NumGC,
+		GCPauseTotal:  float64(ms.PauseTotalNs) / 1e6,
+		GCCPUFraction: ms.GCCPUFraction,
+	}
+	if ms.NumGC > 0 {
+		stats.LastGCPause = float64(ms.PauseNs[(ms.NumGC+255)%256]) / 1e6
+	}
+	return stats
+}
+
+// Handler returns the debug endpoints:
+//
+//	/debug/pprof/   index, heap, goroutine, allocs, block, mutex, ...
+//	/debug/pprof/profile, /trace, /cmdline, /symbol
+//	/debug/runtime  RuntimeStats as JSON
+//	/debug/vars     expvar
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprofhttp.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprofhttp.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprofhttp.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprofhttp.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprofhttp.Trace)
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/runtime", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(ReadRuntimeStats())
+	})
+
+	limiter := rate.NewLimiter(requestsPerSecond, requestsPerSecond)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !limiter.Allow() {
+			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// Server is a running debug endpoint.
+type Server struct {
+	server   *http.Server
+	listener net.Listener
+}
+
+// Start listens on addr and serves Handler. addr must be a loopback address
+// unless PODTRACE_DEBUG_INSECURE_ALLOW_ANY_ADDR is set: the endpoints expose
+// the command line and memory of a privileged process.
+func Start(addr string) (*Server, error) {
+	if !IsLoopback(addr) && !config.AllowNonLoopbackDebug() {
+		return nil, fmt.Errorf("debug address %q is not a loopback address; set PODTRACE_DEBUG_INSECURE_ALLOW_ANY_ADDR=1 to expose it", addr)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("debug endpoint: %w", err)
+	}
+	s := &Server{
+		listener: ln,
+		server: &http.Server{
+			Handler:           Handler(),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logger.Error("Debug endpoint error", zap.Error(err))
+		}
+	}()
+	logger.Info("Serving pprof and runtime stats", zap.String("addr", ln.Addr().String()))
+	return s, nil
+}
+
+// Addr is the address the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Shutdown stops the server, waiting up to the metrics shutdown timeout for
+// in-flight requests.
+func (s *Server) Shutdown() {
+	ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsShutdownTimeout)
+	defer cancel()
+	_ = s.server.Shutdown(ctx)
+}
+
+// IsLoopback reports whether addr, a host:port, is on a loopback address.
+func IsLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return false
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback()
+	}
+	return strings.EqualFold(host, "localhost")
+}
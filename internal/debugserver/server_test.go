@@ -0,0 +1,59 @@
+package debugserver
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	h := Handler()
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/runtime", nil))
+	var stats RuntimeStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("runtime stats: %v\n%s", err, rec.Body)
+	}
+	if stats.Goroutines == 0 || stats.HeapAlloc == 0 || stats.GoVersion == "" {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "goroutine") {
+		t.Errorf("pprof index = %d\n%s", rec.Code, rec.Body)
+	}
+
+	// The limiter allows a burst of requestsPerSecond; the endpoints were
+	// just hit twice.
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+	if rec.Code != http.StatusTooManyRequests {
+		t.Errorf("third request = %d, want rate limited", rec.Code)
+	}
+}
+
+func TestStart(t *testing.T) {
+	if _, err := Start(":6061"); err == nil || !strings.Contains(err.Error(), "loopback") {
+		t.Errorf("err = %v, want the wildcard address refused", err)
+	}
+
+	srv, err := Start("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Shutdown()
+	resp, err := http.Get("http://" + srv.Addr() + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || !strings.Contains(string(body), "goroutine profile") {
+		t.Errorf("goroutine profile = %d\n%.200s", resp.StatusCode, body)
+	}
+}
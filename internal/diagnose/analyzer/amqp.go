@@ -0,0 +1,250 @@
+package analyzer
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// AMQPQueueStats is the consumer side of one queue: deliveries received and
+// how long the application took to settle (ack, nack or reject) them.
+type AMQPQueueStats struct {
+	Queue       string
+	Deliveries  int
+	Acks        int
+	Rejects     int
+	Unacked     int
+	PeakUnacked int
+	P50AckMS    float64
+	P95AckMS    float64
+	MaxAckMS    float64
+}
+
+// AMQPPublishStats is the publisher side of one exchange/routing key: how
+// long the broker took to confirm publishes on channels in confirm mode.
+type AMQPPublishStats struct {
+	Target       string
+	Publishes    int
+	Confirms     int
+	Nacks        int
+	P50ConfirmMS float64
+	P95ConfirmMS float64
+	MaxConfirmMS float64
+}
+
+type AMQPStats struct {
+	Queues    []AMQPQueueStats
+	Publishes []AMQPPublishStats
+}
+
+type amqpPending struct {
+	ts  uint64
+	key string
+}
+
+// amqpConsume is a basic.consume that left the consumer tag to the broker
+// and waits for basic.consume-ok to learn it.
+type amqpConsume struct {
+	queue string
+	noAck bool
+}
+
+type amqpChannel struct {
+	ctagQueue      map[string]string
+	ctagNoAck      map[string]bool
+	pendingConsume []amqpConsume
+	unacked        map[uint64]amqpPending
+	confirmMode    bool
+	publishSeq     uint64
+	unconfirmed    map[uint64]amqpPending
+}
+
+// amqpNoAck is the no-ack bit of basic.consume, carried in Bytes.
+const amqpNoAck = 2
+
+// AnalyzeAMQP pairs deliveries with the acks that settle them and publishes
+// with broker confirms. Delivery tags and publish sequence numbers are per
+// channel, which is what CorrelationID identifies for EventAMQP.
+func AnalyzeAMQP(amqpEvents []*events.Event) AMQPStats {
+	sorted := make([]*events.Event, 0, len(amqpEvents))
+	for _, e := range amqpEvents {
+		if e != nil {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	channels := make(map[uint64]*amqpChannel)
+	queues := make(map[string]*AMQPQueueStats)
+	queueLat := make(map[string][]float64)
+	pubs := make(map[string]*AMQPPublishStats)
+	pubLat := make(map[string][]float64)
+
+	queue := func(name string) *AMQPQueueStats {
+		q, ok := queues[name]
+		if !ok {
+			q = &AMQPQueueStats{Queue: name}
+			queues[name] = q
+		}
+		return q
+	}
+	pub := func(name string) *AMQPPublishStats {
+		p, ok := pubs[name]
+		if !ok {
+			p = &AMQPPublishStats{Target: name}
+			pubs[name] = p
+		}
+		return p
+	}
+	// settle resolves tag (and, with multiple, every lower tag) in pending.
+	settle := func(pending map[uint64]amqpPending, tag uint64, multiple bool, fn func(amqpPending)) {
+		if !multiple {
+			if p, ok := pending[tag]; ok {
+				delete(pending, tag)
+				fn(p)
+			}
+			return
+		}
+		for t, p := range pending {
+			if tag == 0 || t <= tag {
+				delete(pending, t)
+				fn(p)
+			}
+		}
+	}
+	elapsedMS := func(from, to uint64) float64 {
+		if to < from {
+			return 0
+		}
+		return float64(to-from) / float64(config.NSPerMS)
+	}
+
+	for _, e := range sorted {
+		ch, ok := channels[e.CorrelationID]
+		if !ok {
+			ch = &amqpChannel{
+				ctagQueue:   make(map[string]string),
+				ctagNoAck:   make(map[string]bool),
+				unacked:     make(map[uint64]amqpPending),
+				unconfirmed: make(map[uint64]amqpPending),
+			}
+			channels[e.CorrelationID] = ch
+		}
+		dir, multiple := amqpSettleFlags(e.Details)
+
+		switch e.TCPState {
+		case events.AMQPBasicConsume:
+			if e.Details == "" {
+				ch.pendingConsume = append(ch.pendingConsume, amqpConsume{queue: e.Target, noAck: e.Bytes&amqpNoAck != 0})
+				continue
+			}
+			ch.ctagQueue[e.Details] = e.Target
+			ch.ctagNoAck[e.Details] = e.Bytes&amqpNoAck != 0
+		case events.AMQPBasicConsumeOK:
+			if len(ch.pendingConsume) == 0 {
+				continue
+			}
+			c := ch.pendingConsume[0]
+			ch.pendingConsume = ch.pendingConsume[1:]
+			ch.ctagQueue[e.Target] = c.queue
+			ch.ctagNoAck[e.Target] = c.noAck
+		case events.AMQPBasicDeliver:
+			name := ch.ctagQueue[e.Target]
+			if name == "" {
+				// Default-exchange deliveries are routed by queue name.
+				name = e.Details
+			}
+			if name == "" {
+				name = "unknown"
+			}
+			q := queue(name)
+			q.Deliveries++
+			if ch.ctagNoAck[e.Target] {
+				continue
+			}
+			ch.unacked[e.Bytes] = amqpPending{ts: e.Timestamp, key: name}
+			q.Unacked++
+			q.PeakUnacked = max(q.PeakUnacked, q.Unacked)
+		case events.AMQPConfirmSelect:
+			ch.confirmMode = true
+			ch.publishSeq = 0
+		case events.AMQPBasicPublish:
+			name := e.Target
+			if e.Details != "" {
+				name = e.Details + "/" + e.Target
+			}
+			p := pub(name)
+			p.Publishes++
+			if ch.confirmMode {
+				ch.publishSeq++
+				ch.unconfirmed[ch.publishSeq] = amqpPending{ts: e.Timestamp, key: name}
+			}
+		case events.AMQPBasicAck, events.AMQPBasicNack, events.AMQPBasicReject:
+			ack := e.TCPState == events.AMQPBasicAck
+			if dir == "in" {
+				settle(ch.unconfirmed, e.Bytes, multiple, func(p amqpPending) {
+					ps := pub(p.key)
+					if ack {
+						ps.Confirms++
+					} else {
+						ps.Nacks++
+					}
+					pubLat[p.key] = append(pubLat[p.key], elapsedMS(p.ts, e.Timestamp))
+				})
+				continue
+			}
+			settle(ch.unacked, e.Bytes, multiple, func(p amqpPending) {
+				q := queue(p.key)
+				q.Unacked--
+				if ack {
+					q.Acks++
+				} else {
+					q.Rejects++
+				}
+				queueLat[p.key] = append(queueLat[p.key], elapsedMS(p.ts, e.Timestamp))
+			})
+		}
+	}
+
+	var stats AMQPStats
+	for name, q := range queues {
+		if lat := queueLat[name]; len(lat) > 0 {
+			sort.Float64s(lat)
+			q.P50AckMS = Percentile(lat, 50)
+			q.P95AckMS = Percentile(lat, 95)
+			q.MaxAckMS = lat[len(lat)-1]
+		}
+		stats.Queues = append(stats.Queues, *q)
+	}
+	for name, p := range pubs {
+		if lat := pubLat[name]; len(lat) > 0 {
+			sort.Float64s(lat)
+			p.P50ConfirmMS = Percentile(lat, 50)
+			p.P95ConfirmMS = Percentile(lat, 95)
+			p.MaxConfirmMS = lat[len(lat)-1]
+		}
+		stats.Publishes = append(stats.Publishes, *p)
+	}
+	sort.Slice(stats.Queues, func(i, j int) bool {
+		if stats.Queues[i].Unacked != stats.Queues[j].Unacked {
+			return stats.Queues[i].Unacked > stats.Queues[j].Unacked
+		}
+		return stats.Queues[i].Queue < stats.Queues[j].Queue
+	})
+	sort.Slice(stats.Publishes, func(i, j int) bool {
+		if stats.Publishes[i].Publishes != stats.Publishes[j].Publishes {
+			return stats.Publishes[i].Publishes > stats.Publishes[j].Publishes
+		}
+		return stats.Publishes[i].Target < stats.Publishes[j].Target
+	})
+	return stats
+}
+
+// amqpSettleFlags splits the "in|out[ multiple]" details of ack, nack and
+// reject events.
+func amqpSettleFlags(details string) (dir string, multiple bool) {
+	dir, rest, _ := strings.Cut(details, " ")
+	return dir, rest == "multiple"
+}
@@ -0,0 +1,61 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeAMQP(t *testing.T) {
+	const ms = uint64(1_000_000)
+	const consumer, publisher = uint64(1<<48 | 100), uint64(1<<48 | 200)
+	amqp := func(ts uint64, ch uint64, method uint32, target, details string, tag uint64) *events.Event {
+		return &events.Event{Type: events.EventAMQP, Timestamp: ts, CorrelationID: ch, TCPState: method, Target: target, Details: details, Bytes: tag}
+	}
+	stats := AnalyzeAMQP([]*events.Event{
+		// Broker-assigned consumer tag, learned from consume-ok.
+		amqp(1*ms, consumer, events.AMQPBasicConsume, "orders", "", 0),
+		amqp(2*ms, consumer, events.AMQPBasicConsumeOK, "amq.ctag-1", "", 0),
+		amqp(10*ms, consumer, events.AMQPBasicDeliver, "amq.ctag-1", "orders.created", 1),
+		amqp(11*ms, consumer, events.AMQPBasicDeliver, "amq.ctag-1", "orders.created", 2),
+		amqp(12*ms, consumer, events.AMQPBasicDeliver, "amq.ctag-1", "orders.created", 3),
+		amqp(30*ms, consumer, events.AMQPBasicAck, "", "out multiple", 2),
+		amqp(40*ms, consumer, events.AMQPBasicDeliver, "amq.ctag-1", "orders.created", 4),
+
+		amqp(1*ms, publisher, events.AMQPConfirmSelect, "", "", 0),
+		amqp(5*ms, publisher, events.AMQPBasicPublish, "orders.created", "events", 0),
+		amqp(6*ms, publisher, events.AMQPBasicPublish, "orders.created", "events", 0),
+		amqp(9*ms, publisher, events.AMQPBasicAck, "", "in", 1),
+		amqp(15*ms, publisher, events.AMQPBasicNack, "", "in", 2),
+		nil,
+	})
+
+	if len(stats.Queues) != 1 {
+		t.Fatalf("queues = %+v", stats.Queues)
+	}
+	q := stats.Queues[0]
+	if q.Queue != "orders" || q.Deliveries != 4 || q.Acks != 2 || q.Unacked != 2 || q.PeakUnacked != 3 {
+		t.Errorf("queue stats = %+v", q)
+	}
+	if q.MaxAckMS != 20 {
+		t.Errorf("max ack latency = %v, want 20ms", q.MaxAckMS)
+	}
+
+	if len(stats.Publishes) != 1 {
+		t.Fatalf("publishes = %+v", stats.Publishes)
+	}
+	p := stats.Publishes[0]
+	if p.Target != "events/orders.created" || p.Publishes != 2 || p.Confirms != 1 || p.Nacks != 1 || p.MaxConfirmMS != 9 {
+		t.Errorf("publish stats = %+v", p)
+	}
+}
+
+func TestAnalyzeAMQP_NoAckConsumerHasNoBacklog(t *testing.T) {
+	stats := AnalyzeAMQP([]*events.Event{
+		{Type: events.EventAMQP, Timestamp: 1, CorrelationID: 7, TCPState: events.AMQPBasicConsume, Target: "logs", Details: "c1", Bytes: 2},
+		{Type: events.EventAMQP, Timestamp: 2, CorrelationID: 7, TCPState: events.AMQPBasicDeliver, Target: "c1", Bytes: 1},
+	})
+	if len(stats.Queues) != 1 || stats.Queues[0].Deliveries != 1 || stats.Queues[0].Unacked != 0 {
+		t.Errorf("no-ack deliveries must not count as unacked: %+v", stats.Queues)
+	}
+}
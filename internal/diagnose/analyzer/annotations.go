@@ -0,0 +1,108 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// AnnotationPhase summarizes the traffic between two consecutive markers (or
+// a marker and the edge of the trace window).
+type AnnotationPhase struct {
+	Events       int
+	Errors       int
+	Seconds      float64
+	AvgLatencyMS float64
+	P95LatencyMS float64
+}
+
+// Rate is the phase's event rate per second.
+func (p AnnotationPhase) Rate() float64 {
+	if p.Seconds <= 0 {
+		return 0
+	}
+	return float64(p.Events) / p.Seconds
+}
+
+// ErrorRate is the share of the phase's events that failed, in percent.
+func (p AnnotationPhase) ErrorRate() float64 {
+	if p.Events == 0 {
+		return 0
+	}
+	return float64(p.Errors) / float64(p.Events) * 100
+}
+
+// AnnotationStats is one operator marker with the phases on either side.
+type AnnotationStats struct {
+	Text   string
+	At     time.Time
+	Before AnnotationPhase
+	After  AnnotationPhase
+}
+
+// AnalyzeAnnotations splits the window at each EventAnnotation and compares
+// the traffic before and after it. Before runs back to the previous marker
+// (or the window start) and After forward to the next marker (or the window
+// end), so consecutive markers never share events.
+func AnalyzeAnnotations(evs []*events.Event, start, end time.Time) []AnnotationStats {
+	var marks []*events.Event
+	for _, e := range evs {
+		if e != nil && e.Type == events.EventAnnotation {
+			marks = append(marks, e)
+		}
+	}
+	if len(marks) == 0 {
+		return nil
+	}
+	sort.SliceStable(marks, func(i, j int) bool { return marks[i].Timestamp < marks[j].Timestamp })
+
+	// Segment k holds the events after k markers.
+	segments := make([]AnnotationPhase, len(marks)+1)
+	latencies := make([][]float64, len(marks)+1)
+	for _, e := range evs {
+		if e == nil || e.Type == events.EventAnnotation {
+			continue
+		}
+		k := sort.Search(len(marks), func(i int) bool { return marks[i].Timestamp > e.Timestamp })
+		segments[k].Events++
+		if e.IsError() {
+			segments[k].Errors++
+		}
+		if e.LatencyNS > 0 {
+			latencies[k] = append(latencies[k], float64(e.LatencyNS)/float64(config.NSPerMS))
+		}
+	}
+	bounds := make([]time.Time, 0, len(marks)+2)
+	bounds = append(bounds, start)
+	for _, m := range marks {
+		bounds = append(bounds, m.TimestampTime())
+	}
+	bounds = append(bounds, end)
+	for k := range segments {
+		if secs := bounds[k+1].Sub(bounds[k]).Seconds(); secs > 0 {
+			segments[k].Seconds = secs
+		}
+		if lat := latencies[k]; len(lat) > 0 {
+			sort.Float64s(lat)
+			var sum float64
+			for _, l := range lat {
+				sum += l
+			}
+			segments[k].AvgLatencyMS = sum / float64(len(lat))
+			segments[k].P95LatencyMS = Percentile(lat, 95)
+		}
+	}
+
+	stats := make([]AnnotationStats, len(marks))
+	for i, m := range marks {
+		stats[i] = AnnotationStats{
+			Text:   m.Target,
+			At:     m.TimestampTime(),
+			Before: segments[i],
+			After:  segments[i+1],
+		}
+	}
+	return stats
+}
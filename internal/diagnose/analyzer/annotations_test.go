@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeAnnotations(t *testing.T) {
+	const s = uint64(time.Second)
+	base := 100 * s
+	ev := func(ts uint64, latMS uint64, errCode int32) *events.Event {
+		return &events.Event{Type: events.EventHTTPResp, Timestamp: ts, LatencyNS: latMS * uint64(time.Millisecond), Error: errCode}
+	}
+	evs := []*events.Event{
+		ev(base+1*s, 10, 0),
+		ev(base+2*s, 20, 0),
+		{Type: events.EventAnnotation, Timestamp: base + 5*s, Target: "deployed v2"},
+		ev(base+6*s, 100, 500),
+		ev(base+7*s, 300, 0),
+		nil,
+	}
+	start := (&events.Event{Timestamp: base}).TimestampTime()
+	end := (&events.Event{Timestamp: base + 10*s}).TimestampTime()
+
+	stats := AnalyzeAnnotations(evs, start, end)
+	if len(stats) != 1 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	a := stats[0]
+	if a.Text != "deployed v2" {
+		t.Errorf("text = %q", a.Text)
+	}
+	if a.Before.Events != 2 || a.Before.Errors != 0 || a.Before.AvgLatencyMS != 15 {
+		t.Errorf("before = %+v", a.Before)
+	}
+	if a.After.Events != 2 || a.After.ErrorRate() != 50 || a.After.AvgLatencyMS != 200 {
+		t.Errorf("after = %+v", a.After)
+	}
+	if r := a.Before.Rate(); r < 0.39 || r > 0.41 {
+		t.Errorf("before rate = %v, want 2 events over 5s", r)
+	}
+	if AnalyzeAnnotations(evs[:2], start, end) != nil {
+		t.Error("expected nil without annotations")
+	}
+}
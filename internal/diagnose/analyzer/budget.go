@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// OverflowTotals counts the events of one type that arrived after the event
+// budget was spent.
+type OverflowTotals struct {
+	Type         string  `json:"type"`
+	Events       int     `json:"events"`
+	Errors       int     `json:"errors"`
+	AvgLatencyMS float64 `json:"avg_latency_ms"`
+	MaxLatencyMS float64 `json:"max_latency_ms"`
+	latencySumMS float64
+	latencyN     int
+}
+
+// BudgetOverflow summarizes the events a session received once its event
+// budget (--max-events) was spent. They are tallied per type instead of
+// being kept, so memory stays bounded however chatty the traced pod is.
+type BudgetOverflow struct {
+	Limit     int               `json:"limit"`
+	ReachedAt time.Time         `json:"reached_at"`
+	Events    int               `json:"summarized_events"`
+	ByType    []*OverflowTotals `json:"by_type"`
+	index     map[events.EventType]*OverflowTotals
+}
+
+// NewBudgetOverflow starts the tally for a budget of limit events that was
+// spent at reachedAt.
+func NewBudgetOverflow(limit int, reachedAt time.Time) *BudgetOverflow {
+	return &BudgetOverflow{
+		Limit:     limit,
+		ReachedAt: reachedAt,
+		index:     make(map[events.EventType]*OverflowTotals),
+	}
+}
+
+// Add counts one event past the budget.
+func (o *BudgetOverflow) Add(e *events.Event) {
+	if e == nil {
+		return
+	}
+	t := o.index[e.Type]
+	if t == nil {
+		t = &OverflowTotals{Type: e.TypeString()}
+		o.index[e.Type] = t
+		o.ByType = append(o.ByType, t)
+	}
+	o.Events++
+	t.Events++
+	if e.IsError() {
+		t.Errors++
+	}
+	if e.LatencyNS > 0 {
+		ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+		t.latencySumMS += ms
+		t.latencyN++
+		t.AvgLatencyMS = t.latencySumMS / float64(t.latencyN)
+		if ms > t.MaxLatencyMS {
+			t.MaxLatencyMS = ms
+		}
+	}
+}
+
+// Snapshot returns a copy safe to read while Add continues, with the types
+// ordered by event count.
+func (o *BudgetOverflow) Snapshot() *BudgetOverflow {
+	out := &BudgetOverflow{Limit: o.Limit, ReachedAt: o.ReachedAt, Events: o.Events}
+	out.ByType = make([]*OverflowTotals, 0, len(o.ByType))
+	for _, t := range o.ByType {
+		c := *t
+		out.ByType = append(out.ByType, &c)
+	}
+	sort.SliceStable(out.ByType, func(i, j int) bool { return out.ByType[i].Events > out.ByType[j].Events })
+	return out
+}
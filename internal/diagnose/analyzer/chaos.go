@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ChaosInterval is one fault injection found on a traced pod's network,
+// with the network traffic while it was in place and outside it, so a
+// latency spike during a chaos experiment can be told from a real one.
+type ChaosInterval struct {
+	Kind   string `json:"kind"`
+	Pod    string `json:"pod,omitempty"`
+	Target string `json:"target"`
+	Spec   string `json:"spec,omitempty"`
+	// Start and End bound the interval within the trace window; an
+	// injection is found at most PODTRACE_CHAOS_INTERVAL after it starts
+	// or ends.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Preexisting marks an injection already in place when the trace
+	// started, and Ongoing one still in place when it ended.
+	Preexisting bool            `json:"preexisting,omitempty"`
+	Ongoing     bool            `json:"ongoing,omitempty"`
+	During      AnnotationPhase `json:"during"`
+	Outside     AnnotationPhase `json:"outside"`
+}
+
+// LatencyRise is how many times the p95 latency during the injection is
+// that outside it, or 0 when either has none.
+func (c ChaosInterval) LatencyRise() float64 {
+	if c.Outside.P95LatencyMS <= 0 || c.During.P95LatencyMS <= 0 {
+		return 0
+	}
+	return c.During.P95LatencyMS / c.Outside.P95LatencyMS
+}
+
+// isChaosTraffic reports whether an event type is network traffic a netem
+// qdisc or a DROP rule slows down or fails.
+func isChaosTraffic(t events.EventType) bool {
+	switch t {
+	case events.EventDNS, events.EventHTTPReq, events.EventHTTPResp, events.EventHTTP3, events.EventGRPCMethod,
+		events.EventDBQuery, events.EventRedisCmd, events.EventMemcachedCmd:
+		return true
+	}
+	return isNetworkEvent(t)
+}
+
+// AnalyzeChaos pairs the start and end EventChaos of each injection in evs
+// into intervals, clipped to the trace window, and compares the network
+// traffic inside each with the rest of the trace. Intervals are returned
+// in start order.
+func AnalyzeChaos(evs []*events.Event, start, end time.Time) []ChaosInterval {
+	type sample struct {
+		at      time.Time
+		err     bool
+		latency float64
+	}
+	var marks []*events.Event
+	var traffic []sample
+	for _, e := range evs {
+		switch {
+		case e == nil:
+		case e.Type == events.EventChaos:
+			marks = append(marks, e)
+		case isChaosTraffic(e.Type):
+			traffic = append(traffic, sample{at: e.TimestampTime(), err: e.IsError(), latency: float64(e.LatencyNS) / float64(config.NSPerMS)})
+		}
+	}
+	if len(marks) == 0 {
+		return nil
+	}
+	sort.SliceStable(marks, func(i, j int) bool { return marks[i].Timestamp < marks[j].Timestamp })
+	sort.Slice(traffic, func(i, j int) bool { return traffic[i].at.Before(traffic[j].at) })
+
+	// phase sums the traffic in the half-open ranges [from, to).
+	phase := func(ranges ...[2]time.Time) AnnotationPhase {
+		var p AnnotationPhase
+		var lat []float64
+		for _, r := range ranges {
+			if !r[1].After(r[0]) {
+				continue
+			}
+			p.Seconds += r[1].Sub(r[0]).Seconds()
+			i := sort.Search(len(traffic), func(i int) bool { return !traffic[i].at.Before(r[0]) })
+			for ; i < len(traffic) && traffic[i].at.Before(r[1]); i++ {
+				p.Events++
+				if traffic[i].err {
+					p.Errors++
+				}
+				if traffic[i].latency > 0 {
+					lat = append(lat, traffic[i].latency)
+				}
+			}
+		}
+		if len(lat) > 0 {
+			sort.Float64s(lat)
+			var sum float64
+			for _, l := range lat {
+				sum += l
+			}
+			p.AvgLatencyMS = sum / float64(len(lat))
+			p.P95LatencyMS = Percentile(lat, 95)
+		}
+		return p
+	}
+
+	type key struct{ kind, pod, target string }
+	open := make(map[key]*ChaosInterval)
+	var out []ChaosInterval
+	closeAt := func(c *ChaosInterval, at time.Time) {
+		c.End = at
+		if c.End.After(end) {
+			c.End = end
+		}
+		out = append(out, *c)
+	}
+	for _, m := range marks {
+		d := events.ParseChaosDetails(m.Details)
+		k := key{d.Kind, d.Pod, m.Target}
+		at := m.TimestampTime()
+		switch d.State {
+		case events.ChaosStart:
+			if c := open[k]; c != nil {
+				closeAt(c, at)
+			}
+			c := &ChaosInterval{Kind: d.Kind, Pod: d.Pod, Target: m.Target, Spec: d.Spec, Start: at, Preexisting: d.Preexisting}
+			if c.Preexisting || c.Start.Before(start) {
+				c.Start = start
+			}
+			open[k] = c
+		case events.ChaosEnd:
+			if c := open[k]; c != nil {
+				closeAt(c, at)
+				delete(open, k)
+			}
+		}
+	}
+	for _, c := range open {
+		c.Ongoing = true
+		closeAt(c, end)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		return out[i].Target < out[j].Target
+	})
+	for i := range out {
+		c := &out[i]
+		c.During = phase([2]time.Time{c.Start, c.End})
+		c.Outside = phase([2]time.Time{start, c.Start}, [2]time.Time{c.End, end})
+	}
+	return out
+}
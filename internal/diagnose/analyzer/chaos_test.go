@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeChaos(t *testing.T) {
+	const base = uint64(1_000_000_000_000)
+	ts := func(d time.Duration) uint64 { return base + uint64(d) }
+	at := func(d time.Duration) time.Time { return (&events.Event{Timestamp: ts(d)}).TimestampTime() }
+	mark := func(d time.Duration, kind, state, target, spec string, preexisting bool) *events.Event {
+		return &events.Event{
+			Type:      events.EventChaos,
+			Timestamp: ts(d),
+			Target:    target,
+			Details:   events.ChaosDetails{Kind: kind, State: state, Pod: "shop/cart-0", Spec: spec, Preexisting: preexisting}.String(),
+		}
+	}
+	const rule = "iptables -t filter -A OUTPUT -d 10.0.0.9/32 -j DROP"
+	evs := []*events.Event{
+		mark(2*time.Second, events.ChaosNetem, events.ChaosStart, "eth0", "delay=100ms", true),
+		mark(20*time.Second, events.ChaosNetem, events.ChaosEnd, "eth0", "delay=100ms", false),
+		mark(40*time.Second, events.ChaosIPTablesDrop, events.ChaosStart, rule, "", false),
+		{Type: events.EventTCPSend, Timestamp: ts(5 * time.Second), LatencyNS: uint64(100 * time.Millisecond)},
+		{Type: events.EventDNS, Timestamp: ts(10 * time.Second), LatencyNS: uint64(110 * time.Millisecond)},
+		{Type: events.EventTCPSend, Timestamp: ts(25 * time.Second), LatencyNS: uint64(2 * time.Millisecond)},
+		{Type: events.EventTCPSend, Timestamp: ts(30 * time.Second), LatencyNS: uint64(3 * time.Millisecond)},
+		{Type: events.EventConnect, Timestamp: ts(45 * time.Second), LatencyNS: uint64(3 * time.Millisecond), Error: -111},
+		// Not network traffic: its interval-long latency must not count.
+		{Type: events.EventNeighbor, Timestamp: ts(12 * time.Second), LatencyNS: uint64(5 * time.Second)},
+	}
+	got := AnalyzeChaos(evs, at(0), at(60*time.Second))
+	if len(got) != 2 {
+		t.Fatalf("intervals = %+v", got)
+	}
+
+	// The netem in place at the first look is taken from the trace start.
+	n := got[0]
+	if n.Kind != events.ChaosNetem || n.Target != "eth0" || n.Spec != "delay=100ms" || n.Pod != "shop/cart-0" ||
+		!n.Preexisting || n.Ongoing || !n.Start.Equal(at(0)) || !n.End.Equal(at(20*time.Second)) {
+		t.Errorf("netem interval = %+v", n)
+	}
+	if n.During.Events != 2 || n.During.Seconds != 20 || n.Outside.Events != 3 || n.Outside.Seconds != 40 {
+		t.Errorf("netem phases: during %+v, outside %+v", n.During, n.Outside)
+	}
+	if n.LatencyRise() < 2 {
+		t.Errorf("latency rise = %v", n.LatencyRise())
+	}
+
+	// The rule still in place runs to the end of the trace.
+	d := got[1]
+	if d.Kind != events.ChaosIPTablesDrop || d.Target != rule || !d.Ongoing || !d.End.Equal(at(60*time.Second)) {
+		t.Errorf("drop interval = %+v", d)
+	}
+	if d.During.Events != 1 || d.During.Errors != 1 {
+		t.Errorf("drop during = %+v", d.During)
+	}
+
+	if AnalyzeChaos(evs[3:], at(0), at(time.Minute)) != nil {
+		t.Error("expected nil without chaos marks")
+	}
+}
@@ -0,0 +1,103 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// hugePageBytes is the allocation size at and above which a compaction stall
+// was for a transparent huge page rather than an ordinary high-order
+// allocation.
+const hugePageBytes = 2 << 20
+
+// CompactionProcess is the compaction stall time of one process.
+type CompactionProcess struct {
+	PID     uint32  `json:"pid"`
+	Process string  `json:"process,omitempty"`
+	Stalls  int     `json:"stalls"`
+	StallMS float64 `json:"stall_ms"`
+}
+
+// MemoryCompaction summarizes the direct-compaction stalls and khugepaged
+// collapses of the traced cgroups.
+type MemoryCompaction struct {
+	Stalls     int     `json:"stalls"`
+	StallMS    float64 `json:"stall_ms"`
+	P95StallMS float64 `json:"p95_stall_ms"`
+	MaxStallMS float64 `json:"max_stall_ms"`
+	// HugePageStalls and HugePageStallMS are the stalls of allocations of a
+	// huge page or more, typically THP faults.
+	HugePageStalls  int     `json:"huge_page_stalls"`
+	HugePageStallMS float64 `json:"huge_page_stall_ms"`
+	// Results counts the stalls by how compaction ended; anything but
+	// "success" left the allocation to fall back or reclaim.
+	Results   map[string]int      `json:"results"`
+	Processes []CompactionProcess `json:"processes"`
+	// Collapses and FailedCollapses count khugepaged collapse attempts on
+	// the traced processes' memory.
+	Collapses       int    `json:"collapses"`
+	FailedCollapses int    `json:"failed_collapses"`
+	CollapsedBytes  uint64 `json:"collapsed_bytes"`
+}
+
+// AnalyzeCompaction sums the EventCompaction and EventTHPCollapse events in
+// evts, processes with the most stall time first. It returns nil when there
+// are none.
+func AnalyzeCompaction(evts []*events.Event) *MemoryCompaction {
+	out := &MemoryCompaction{Results: make(map[string]int)}
+	procs := make(map[uint32]*CompactionProcess)
+	var stalls []float64
+	for _, e := range evts {
+		if e == nil {
+			continue
+		}
+		switch e.Type {
+		case events.EventCompaction:
+			ms := float64(e.LatencyNS) / 1e6
+			stalls = append(stalls, ms)
+			out.StallMS += ms
+			if e.Bytes >= hugePageBytes {
+				out.HugePageStalls++
+				out.HugePageStallMS += ms
+			}
+			out.Results[e.CompactionResult()]++
+			p := procs[e.PID]
+			if p == nil {
+				p = &CompactionProcess{PID: e.PID}
+				procs[e.PID] = p
+			}
+			if e.ProcessName != "" {
+				p.Process = e.ProcessName
+			}
+			p.Stalls++
+			p.StallMS += ms
+		case events.EventTHPCollapse:
+			out.Collapses++
+			if e.Error != 0 {
+				out.FailedCollapses++
+			}
+			out.CollapsedBytes += e.Bytes
+		}
+	}
+	if len(stalls) == 0 && out.Collapses == 0 {
+		return nil
+	}
+	out.Stalls = len(stalls)
+	if len(stalls) > 0 {
+		sort.Float64s(stalls)
+		out.P95StallMS = Percentile(stalls, 95)
+		out.MaxStallMS = stalls[len(stalls)-1]
+	}
+	for _, p := range procs {
+		out.Processes = append(out.Processes, *p)
+	}
+	sort.Slice(out.Processes, func(i, j int) bool {
+		a, b := out.Processes[i], out.Processes[j]
+		if a.StallMS != b.StallMS {
+			return a.StallMS > b.StallMS
+		}
+		return a.PID < b.PID
+	})
+	return out
+}
@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeCompaction(t *testing.T) {
+	stall := func(pid uint32, d time.Duration, bytes uint64, result uint32) *events.Event {
+		return &events.Event{Type: events.EventCompaction, PID: pid, ProcessName: "app",
+			LatencyNS: uint64(d), Bytes: bytes, TCPState: result}
+	}
+	evts := []*events.Event{
+		stall(42, 30*time.Millisecond, 2<<20, 8),
+		stall(42, 10*time.Millisecond, 2<<20, 2),
+		stall(7, 5*time.Millisecond, 16<<10, 8),
+		{Type: events.EventTHPCollapse, PID: 42, Bytes: 2 << 20},
+		{Type: events.EventTHPCollapse, PID: 42, Error: 3},
+		{Type: events.EventPageFault, PID: 42},
+	}
+	got := AnalyzeCompaction(evts)
+	if got == nil {
+		t.Fatal("AnalyzeCompaction returned nil")
+	}
+	if got.Stalls != 3 || got.StallMS != 45 || got.MaxStallMS != 30 {
+		t.Errorf("stalls = %d, %.1fms, max %.1fms", got.Stalls, got.StallMS, got.MaxStallMS)
+	}
+	if got.HugePageStalls != 2 || got.HugePageStallMS != 40 {
+		t.Errorf("huge page stalls = %d, %.1fms", got.HugePageStalls, got.HugePageStallMS)
+	}
+	if got.Results["success"] != 2 || got.Results["deferred"] != 1 {
+		t.Errorf("results = %v", got.Results)
+	}
+	if len(got.Processes) != 2 || got.Processes[0].PID != 42 || got.Processes[0].StallMS != 40 {
+		t.Errorf("processes = %+v", got.Processes)
+	}
+	if got.Collapses != 2 || got.FailedCollapses != 1 || got.CollapsedBytes != 2<<20 {
+		t.Errorf("collapses = %d (%d failed), %d bytes", got.Collapses, got.FailedCollapses, got.CollapsedBytes)
+	}
+
+	if AnalyzeCompaction([]*events.Event{{Type: events.EventPageFault}}) != nil {
+		t.Error("expected nil without compaction events")
+	}
+}
@@ -0,0 +1,197 @@
+package analyzer
+
+import (
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ConnectRace is one logical connect that a client raced over several
+// addresses: the IPv4 and IPv6 legs of happy eyeballs, or the endpoints of
+// a name tried in parallel.
+type ConnectRace struct {
+	// Host is the name the addresses resolved from, or empty when the
+	// legs were only recognised by their address families.
+	Host     string `json:"host,omitempty"`
+	Port     string `json:"port"`
+	PID      uint32 `json:"pid"`
+	Attempts int    `json:"attempts"`
+	// Failed counts the legs whose connect returned an error.
+	Failed int `json:"failed"`
+	// Winner is the leg the client went on to use, or empty when the trace
+	// does not show which one.
+	Winner       string `json:"winner,omitempty"`
+	WinnerFamily string `json:"winner_family,omitempty"`
+}
+
+// ConnectRaces summarises the connection-attempt fan-out of a trace.
+type ConnectRaces struct {
+	Races    int `json:"races"`
+	Attempts int `json:"attempts"`
+	// Wasted counts the legs beyond the one each race needed.
+	Wasted int `json:"wasted_attempts"`
+	// LosingLegErrors counts the failed legs of races another leg won:
+	// connection errors the application never saw.
+	LosingLegErrors int            `json:"losing_leg_errors"`
+	Undecided       int            `json:"undecided"`
+	WinsByFamily    map[string]int `json:"wins_by_family,omitempty"`
+	// Top lists the races with the most attempts first.
+	Top []ConnectRace `json:"top,omitempty"`
+}
+
+type connectLeg struct {
+	e      *events.Event
+	start  uint64
+	addr   netip.Addr
+	target string
+}
+
+// AnalyzeConnectRaces finds the connects in evs that belong to one
+// logical connect: attempts by the same process to the same port and name
+// that start at most PODTRACE_CONNECT_RACE_WINDOW after the previous one,
+// over at least two addresses. Without a resolved name the legs must also
+// differ in address family, so unrelated connects to one port are not
+// mistaken for a race. The leg that later carries the process's TCP
+// traffic is taken as the winner. It returns nil when there is no race.
+func AnalyzeConnectRaces(evs []*events.Event) *ConnectRaces {
+	type groupKey struct {
+		pid        uint32
+		host, port string
+	}
+	groups := make(map[groupKey][]connectLeg)
+	used := make(map[uint32]map[string]uint64)
+	for _, e := range evs {
+		if e == nil {
+			continue
+		}
+		switch e.Type {
+		case events.EventConnect:
+			addr, port, ok := splitConnectTarget(e.Target)
+			if !ok {
+				continue
+			}
+			start := e.Timestamp
+			if e.LatencyNS < start {
+				start -= e.LatencyNS
+			}
+			k := groupKey{pid: e.PID, host: e.Details, port: port}
+			groups[k] = append(groups[k], connectLeg{e: e, start: start, addr: addr, target: e.Target})
+		case events.EventTCPSend, events.EventTCPRecv:
+			if e.Target == "" {
+				continue
+			}
+			if used[e.PID] == nil {
+				used[e.PID] = make(map[string]uint64)
+			}
+			if last, ok := used[e.PID][e.Target]; !ok || e.Timestamp > last {
+				used[e.PID][e.Target] = e.Timestamp
+			}
+		}
+	}
+
+	out := &ConnectRaces{WinsByFamily: map[string]int{}}
+	window := uint64(config.ConnectRaceWindow.Nanoseconds())
+	for k, legs := range groups {
+		sort.Slice(legs, func(i, j int) bool { return legs[i].start < legs[j].start })
+		for i := 0; i < len(legs); {
+			j := i + 1
+			for j < len(legs) && legs[j].start-legs[j-1].start <= window {
+				j++
+			}
+			if race, ok := connectRace(legs[i:j], k.host != "", used[k.pid]); ok {
+				race.Host, race.Port, race.PID = k.host, k.port, k.pid
+				out.add(race)
+			}
+			i = j
+		}
+	}
+	if out.Races == 0 {
+		return nil
+	}
+	sort.Slice(out.Top, func(i, j int) bool {
+		if out.Top[i].Attempts != out.Top[j].Attempts {
+			return out.Top[i].Attempts > out.Top[j].Attempts
+		}
+		return out.Top[i].Host+out.Top[i].Winner < out.Top[j].Host+out.Top[j].Winner
+	})
+	if len(out.Top) > config.TopTargetsLimit {
+		out.Top = out.Top[:config.TopTargetsLimit]
+	}
+	return out
+}
+
+// connectRace decides whether legs raced and which of them won.
+func connectRace(legs []connectLeg, named bool, used map[string]uint64) (ConnectRace, bool) {
+	addrs := make(map[netip.Addr]struct{}, len(legs))
+	families := make(map[bool]struct{}, 2)
+	for _, l := range legs {
+		addrs[l.addr] = struct{}{}
+		families[l.addr.Is4()] = struct{}{}
+	}
+	if len(legs) < 2 || len(addrs) < 2 || (!named && len(families) < 2) {
+		return ConnectRace{}, false
+	}
+	race := ConnectRace{Attempts: len(legs)}
+	var succeeded []connectLeg
+	for _, l := range legs {
+		if l.e.Error != 0 {
+			race.Failed++
+			continue
+		}
+		succeeded = append(succeeded, l)
+	}
+	for _, l := range succeeded {
+		if last, ok := used[l.target]; ok && last >= l.start {
+			race.Winner = l.target
+			race.WinnerFamily = addrFamily(l.addr)
+			break
+		}
+	}
+	if race.Winner == "" && len(succeeded) == 1 {
+		race.Winner = succeeded[0].target
+		race.WinnerFamily = addrFamily(succeeded[0].addr)
+	}
+	return race, true
+}
+
+func (r *ConnectRaces) add(race ConnectRace) {
+	r.Races++
+	r.Attempts += race.Attempts
+	r.Wasted += race.Attempts - 1
+	if race.Winner == "" {
+		r.Undecided++
+	} else {
+		r.WinsByFamily[race.WinnerFamily]++
+		r.LosingLegErrors += race.Failed
+	}
+	r.Top = append(r.Top, race)
+}
+
+// splitConnectTarget parses a connect target, "ip:port" for IPv4 and, as
+// the BPF side formats it, the bare address followed by ":port" for IPv6.
+func splitConnectTarget(target string) (netip.Addr, string, bool) {
+	host, port, err := net.SplitHostPort(target)
+	if err != nil {
+		i := strings.LastIndexByte(target, ':')
+		if i <= 0 {
+			return netip.Addr{}, "", false
+		}
+		host, port = target[:i], target[i+1:]
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil || port == "" {
+		return netip.Addr{}, "", false
+	}
+	return addr.Unmap(), port, true
+}
+
+func addrFamily(a netip.Addr) string {
+	if a.Is4() {
+		return "IPv4"
+	}
+	return "IPv6"
+}
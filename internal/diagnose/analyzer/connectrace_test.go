@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeConnectRaces(t *testing.T) {
+	const ms = uint64(time.Millisecond)
+	connect := func(pid uint32, ts uint64, target, host string, errno int32) *events.Event {
+		return &events.Event{Type: events.EventConnect, PID: pid, Timestamp: ts, LatencyNS: ms / 10, Target: target, Details: host, Error: errno}
+	}
+	evts := []*events.Event{
+		// Happy eyeballs to api.example.com: IPv6 fails at once, IPv4 wins
+		// 250ms later and carries the traffic.
+		connect(1, 1000*ms, "2001:db8:0:0:0:0:0:1:443", "api.example.com", -101),
+		connect(1, 1250*ms, "93.184.216.34:443", "api.example.com", 0),
+		{Type: events.EventTCPSend, PID: 1, Timestamp: 1300 * ms, Target: "93.184.216.34:443"},
+		// Three endpoints of a name tried in parallel; the second is used.
+		connect(2, 5000*ms, "10.0.0.1:5432", "db.internal", 0),
+		connect(2, 5100*ms, "10.0.0.2:5432", "db.internal", 0),
+		connect(2, 5200*ms, "10.0.0.3:5432", "db.internal", 0),
+		{Type: events.EventTCPRecv, PID: 2, Timestamp: 5400 * ms, Target: "10.0.0.2:5432"},
+		// Unnamed connects to one port over one family are not a race.
+		connect(3, 9000*ms, "10.1.0.1:80", "", 0),
+		connect(3, 9010*ms, "10.1.0.2:80", "", -111),
+		// A retry of one address is not a race either, nor is a later
+		// connect outside the window.
+		connect(1, 20000*ms, "93.184.216.34:443", "api.example.com", -111),
+		connect(1, 20100*ms, "93.184.216.34:443", "api.example.com", 0),
+		connect(1, 30000*ms, "93.184.216.35:443", "api.example.com", 0),
+	}
+	got := AnalyzeConnectRaces(evts)
+	if got == nil {
+		t.Fatal("expected connect races")
+	}
+	if got.Races != 2 || got.Attempts != 5 || got.Wasted != 3 || got.LosingLegErrors != 1 || got.Undecided != 0 {
+		t.Errorf("summary = %+v", got)
+	}
+	if got.WinsByFamily["IPv4"] != 2 || got.WinsByFamily["IPv6"] != 0 {
+		t.Errorf("wins = %v", got.WinsByFamily)
+	}
+	if top := got.Top[0]; top.Host != "db.internal" || top.Attempts != 3 || top.Winner != "10.0.0.2:5432" || top.PID != 2 {
+		t.Errorf("top race = %+v", top)
+	}
+	if he := got.Top[1]; he.Winner != "93.184.216.34:443" || he.WinnerFamily != "IPv4" || he.Failed != 1 || he.Port != "443" {
+		t.Errorf("happy eyeballs race = %+v", he)
+	}
+
+	if AnalyzeConnectRaces(evts[7:9]) != nil {
+		t.Error("unnamed same-family connects were taken for a race")
+	}
+}
+
+func TestSplitConnectTarget(t *testing.T) {
+	for target, want := range map[string]string{
+		"10.0.0.1:80":                           "10.0.0.1",
+		"[2001:db8::1]:443":                     "2001:db8::1",
+		"2001:db8:0:0:0:0:0:1:53":               "2001:db8::1",
+		"fe80:000:000:000:000:000:000:001:8080": "fe80::1",
+	} {
+		addr, _, ok := splitConnectTarget(target)
+		if !ok || addr.String() != want {
+			t.Errorf("splitConnectTarget(%q) = %v, %v; want %s", target, addr, ok, want)
+		}
+	}
+	for _, bad := range []string{"", "example.com:80", "10.0.0.1"} {
+		if _, _, ok := splitConnectTarget(bad); ok {
+			t.Errorf("splitConnectTarget(%q) accepted", bad)
+		}
+	}
+}
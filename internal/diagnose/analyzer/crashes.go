@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/coredump"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// CrashLookback is how far before a crash the crashed process's activity is
+// summarized.
+const CrashLookback = 10 * time.Second
+
+// CrashActivity is one event type of the crashed process: its volume and p95
+// latency in the lookback before the crash, against its p95 over the whole
+// trace.
+type CrashActivity struct {
+	Type       string
+	Count      int
+	Errors     int
+	P95MS      float64
+	TraceP95MS float64
+}
+
+// CrashInfo is one process that dumped core during the trace.
+type CrashInfo struct {
+	PID     uint32
+	Process string
+	Signal  string
+	At      time.Time
+	// FaultAddr is the faulting address; HasFaultAddr is false when the
+	// signal was sent rather than raised by a fault, and carries no address.
+	FaultAddr    uint64
+	HasFaultAddr bool
+	Core         string
+	Frames       []string
+	Before       []CrashActivity
+}
+
+// AnalyzeCrashes describes every EventCrash in evs with the activity of the
+// same process leading up to it, so a latency spike that ends abruptly can be
+// tied to the crash that ended it.
+func AnalyzeCrashes(evs []*events.Event) []CrashInfo {
+	var crashes []*events.Event
+	for _, e := range evs {
+		if e != nil && e.Type == events.EventCrash {
+			crashes = append(crashes, e)
+		}
+	}
+	if len(crashes) == 0 {
+		return nil
+	}
+	sort.SliceStable(crashes, func(i, j int) bool { return crashes[i].Timestamp < crashes[j].Timestamp })
+
+	lookback := uint64(CrashLookback)
+	infos := make([]CrashInfo, 0, len(crashes))
+	for _, c := range crashes {
+		info := CrashInfo{
+			PID:     c.PID,
+			Process: c.ProcessName,
+			Signal:  c.CrashSignal(),
+			At:      c.TimestampTime(),
+		}
+		if info.Process == "" {
+			info.Process = c.Target
+		}
+		if hasFaultAddress(c) {
+			info.FaultAddr, info.HasFaultAddr = c.Bytes, true
+		}
+		info.Core, info.Frames = coredump.ParseDetails(c.Details)
+
+		type typeStats struct {
+			count, errors int
+			recent, all   []float64
+		}
+		byType := make(map[string]*typeStats)
+		from := uint64(0)
+		if c.Timestamp > lookback {
+			from = c.Timestamp - lookback
+		}
+		for _, e := range evs {
+			if e == nil || e.PID != c.PID || e.Type == events.EventCrash || e.Type == events.EventAnnotation {
+				continue
+			}
+			name := e.TypeString()
+			ts, ok := byType[name]
+			if !ok {
+				ts = &typeStats{}
+				byType[name] = ts
+			}
+			ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+			if e.LatencyNS > 0 {
+				ts.all = append(ts.all, ms)
+			}
+			if e.Timestamp < from || e.Timestamp > c.Timestamp {
+				continue
+			}
+			ts.count++
+			if e.IsError() {
+				ts.errors++
+			}
+			if e.LatencyNS > 0 {
+				ts.recent = append(ts.recent, ms)
+			}
+		}
+		for name, ts := range byType {
+			if ts.count == 0 {
+				continue
+			}
+			a := CrashActivity{Type: name, Count: ts.count, Errors: ts.errors}
+			if len(ts.recent) > 0 {
+				sort.Float64s(ts.recent)
+				sort.Float64s(ts.all)
+				a.P95MS = Percentile(ts.recent, 95)
+				a.TraceP95MS = Percentile(ts.all, 95)
+			}
+			info.Before = append(info.Before, a)
+		}
+		sort.Slice(info.Before, func(i, j int) bool {
+			if info.Before[i].Count != info.Before[j].Count {
+				return info.Before[i].Count > info.Before[j].Count
+			}
+			return info.Before[i].Type < info.Before[j].Type
+		})
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// hasFaultAddress reports whether a crash's siginfo carries a faulting
+// address: only synchronous faults raised by the kernel (si_code > 0) do.
+func hasFaultAddress(e *events.Event) bool {
+	if int32(e.TCPState) <= 0 {
+		return false
+	}
+	switch e.CrashSignal() {
+	case "SIGSEGV", "SIGBUS", "SIGILL", "SIGFPE", "SIGTRAP":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/coredump"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeCrashes(t *testing.T) {
+	const s = uint64(time.Second)
+	const ms = uint64(time.Millisecond)
+	base := 100 * s
+	fs := func(ts, lat uint64) *events.Event {
+		return &events.Event{Type: events.EventWrite, PID: 7, Timestamp: ts, LatencyNS: lat}
+	}
+	evs := []*events.Event{
+		fs(base+1*s, 1*ms),
+		fs(base+2*s, 1*ms),
+		fs(base+25*s, 80*ms),
+		fs(base+26*s, 90*ms),
+		{Type: events.EventWrite, PID: 8, Timestamp: base + 26*s, LatencyNS: 500 * ms},
+		{
+			Type: events.EventCrash, PID: 7, Timestamp: base + 30*s, ProcessName: "api",
+			Error: 11, TCPState: 1, Bytes: 0x10,
+			Details: coredump.FormatDetails("/cores/core.7", []string{"api:main.c:42"}),
+		},
+		nil,
+	}
+
+	crashes := AnalyzeCrashes(evs)
+	if len(crashes) != 1 {
+		t.Fatalf("crashes = %+v", crashes)
+	}
+	c := crashes[0]
+	if c.Process != "api" || c.Signal != "SIGSEGV" || !c.HasFaultAddr || c.FaultAddr != 0x10 {
+		t.Errorf("crash = %+v", c)
+	}
+	if c.Core != "/cores/core.7" || len(c.Frames) != 1 || c.Frames[0] != "api:main.c:42" {
+		t.Errorf("core/frames = %q %v", c.Core, c.Frames)
+	}
+	if len(c.Before) != 1 {
+		t.Fatalf("before = %+v", c.Before)
+	}
+	fsBefore := c.Before[0]
+	if fsBefore.Type != "FS" || fsBefore.Count != 2 || fsBefore.P95MS < 80 || fsBefore.TraceP95MS < fsBefore.P95MS/2 {
+		t.Errorf("FS activity = %+v", fsBefore)
+	}
+
+	if AnalyzeCrashes([]*events.Event{fs(base, ms)}) != nil {
+		t.Error("expected no crashes")
+	}
+}
+
+func TestCrashFaultAddressOnlyForFaults(t *testing.T) {
+	sent := &events.Event{Type: events.EventCrash, Error: 11, TCPState: 0, Bytes: 1234}
+	if hasFaultAddress(sent) {
+		t.Error("kill(SIGSEGV) carries a sender pid, not a fault address")
+	}
+	abort := &events.Event{Type: events.EventCrash, Error: 6, TCPState: 1}
+	if hasFaultAddress(abort) {
+		t.Error("SIGABRT has no fault address")
+	}
+}
@@ -0,0 +1,124 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// DisruptionStats is one pod disruption on the traced node with the traffic
+// on either side of it.
+type DisruptionStats struct {
+	Kind     string    `json:"kind"`
+	Subject  string    `json:"subject"`
+	Resource string    `json:"resource,omitempty"`
+	Node     string    `json:"node,omitempty"`
+	At       time.Time `json:"at"`
+	// Before and After cover PODTRACE_DISRUPTION_WINDOW on each side,
+	// clipped to the trace window.
+	Before AnnotationPhase `json:"before"`
+	After  AnnotationPhase `json:"after"`
+}
+
+// LatencyRise is how many times the p95 latency after the disruption is
+// that before, or 0 when either side has none.
+func (s DisruptionStats) LatencyRise() float64 {
+	if s.Before.P95LatencyMS <= 0 || s.After.P95LatencyMS <= 0 {
+		return 0
+	}
+	return s.After.P95LatencyMS / s.Before.P95LatencyMS
+}
+
+// PodTermination is a traced pod that went away before the trace ended,
+// with the reason the API gave, so the report reads as partial.
+type PodTermination struct {
+	Namespace string    `json:"namespace"`
+	Pod       string    `json:"pod"`
+	Reason    string    `json:"reason"`
+	Message   string    `json:"message,omitempty"`
+	At        time.Time `json:"at"`
+}
+
+// AnalyzeDisruptions compares, for each EventDisruption in evs, the traffic
+// in the PODTRACE_DISRUPTION_WINDOW before and after it, in time order.
+// Annotations, disruptions, chaos and pause marks and the runtime's own
+// container and image pull records are not traffic.
+func AnalyzeDisruptions(evs []*events.Event, start, end time.Time) []DisruptionStats {
+	type sample struct {
+		at      time.Time
+		err     bool
+		latency float64
+	}
+	var marks []*events.Event
+	var traffic []sample
+	for _, e := range evs {
+		switch {
+		case e == nil:
+		case e.Type == events.EventAnnotation, e.Type == events.EventCRIOp, e.Type == events.EventImagePull, e.Type == events.EventChaos,
+			e.Type == events.EventPause:
+		case e.Type == events.EventDisruption:
+			marks = append(marks, e)
+		default:
+			traffic = append(traffic, sample{at: e.TimestampTime(), err: e.IsError(), latency: float64(e.LatencyNS) / float64(config.NSPerMS)})
+		}
+	}
+	if len(marks) == 0 {
+		return nil
+	}
+	sort.SliceStable(marks, func(i, j int) bool { return marks[i].Timestamp < marks[j].Timestamp })
+	sort.Slice(traffic, func(i, j int) bool { return traffic[i].at.Before(traffic[j].at) })
+
+	phase := func(from, to time.Time) AnnotationPhase {
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		var p AnnotationPhase
+		if !to.After(from) {
+			return p
+		}
+		p.Seconds = to.Sub(from).Seconds()
+		i := sort.Search(len(traffic), func(i int) bool { return !traffic[i].at.Before(from) })
+		var lat []float64
+		for ; i < len(traffic) && traffic[i].at.Before(to); i++ {
+			p.Events++
+			if traffic[i].err {
+				p.Errors++
+			}
+			if traffic[i].latency > 0 {
+				lat = append(lat, traffic[i].latency)
+			}
+		}
+		if len(lat) > 0 {
+			sort.Float64s(lat)
+			var sum float64
+			for _, l := range lat {
+				sum += l
+			}
+			p.AvgLatencyMS = sum / float64(len(lat))
+			p.P95LatencyMS = Percentile(lat, 95)
+		}
+		return p
+	}
+
+	window := config.DisruptionWindow
+	out := make([]DisruptionStats, len(marks))
+	for i, m := range marks {
+		d := events.ParseDisruptionDetails(m.Details)
+		at := m.TimestampTime()
+		out[i] = DisruptionStats{
+			Kind:     d.Kind,
+			Subject:  m.Target,
+			Resource: d.Resource,
+			Node:     d.Node,
+			At:       at,
+			Before:   phase(at.Add(-window), at),
+			After:    phase(at, at.Add(window)),
+		}
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeDisruptions(t *testing.T) {
+	const base = uint64(1_000_000_000_000)
+	ts := func(d time.Duration) uint64 { return base + uint64(d) }
+	at := func(d time.Duration) time.Time { return (&events.Event{Timestamp: ts(d)}).TimestampTime() }
+	evict := &events.Event{
+		Type:      events.EventDisruption,
+		Timestamp: ts(60 * time.Second),
+		Target:    "batch/job-1",
+		Details:   events.DisruptionDetails{Kind: events.DisruptionEviction, Resource: "memory", Node: "node-1"}.String(),
+	}
+	evs := []*events.Event{
+		evict,
+		{Type: events.EventTCPSend, Timestamp: ts(10 * time.Second), LatencyNS: uint64(time.Millisecond)},
+		{Type: events.EventTCPSend, Timestamp: ts(40 * time.Second), LatencyNS: uint64(time.Millisecond)},
+		{Type: events.EventTCPSend, Timestamp: ts(50 * time.Second), LatencyNS: uint64(2 * time.Millisecond)},
+		{Type: events.EventTCPSend, Timestamp: ts(70 * time.Second), LatencyNS: uint64(20 * time.Millisecond), Error: -110},
+		{Type: events.EventTCPSend, Timestamp: ts(80 * time.Second), LatencyNS: uint64(30 * time.Millisecond)},
+		{Type: events.EventImagePull, Timestamp: ts(75 * time.Second), LatencyNS: uint64(time.Minute)},
+		{Type: events.EventAnnotation, Timestamp: ts(65 * time.Second), Target: "deploy"},
+	}
+	got := AnalyzeDisruptions(evs, at(0), at(75*time.Second))
+	if len(got) != 1 {
+		t.Fatalf("disruptions = %+v", got)
+	}
+	d := got[0]
+	if d.Kind != events.DisruptionEviction || d.Subject != "batch/job-1" || d.Resource != "memory" || d.Node != "node-1" {
+		t.Errorf("disruption = %+v", d)
+	}
+	// The 30s window before holds two sends; the one after is clipped to the
+	// 15s left in the trace, and the image pull and annotation in it are not
+	// traffic.
+	if d.Before.Events != 2 || d.Before.Seconds != 30 || d.Before.Errors != 0 {
+		t.Errorf("before = %+v", d.Before)
+	}
+	if d.After.Events != 1 || d.After.Seconds != 15 || d.After.Errors != 1 {
+		t.Errorf("after = %+v", d.After)
+	}
+	if d.LatencyRise() < 5 {
+		t.Errorf("latency rise = %v", d.LatencyRise())
+	}
+	if AnalyzeDisruptions(evs[1:], at(0), at(time.Minute)) != nil {
+		t.Error("expected nil without disruptions")
+	}
+}
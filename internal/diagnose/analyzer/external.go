@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"net"
+	"net/netip"
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/geoip"
+)
+
+// NetworkStats aggregates the internet-bound traffic to one autonomous
+// system.
+type NetworkStats struct {
+	Network           string `json:"network"`
+	ASN               uint32 `json:"asn,omitempty"`
+	Org               string `json:"org,omitempty"`
+	Country           string `json:"country,omitempty"`
+	Events            int    `json:"events"`
+	Connections       int    `json:"connections"`
+	FailedConnections int    `json:"failed_connections"`
+	Retransmits       int    `json:"retransmits"`
+	Bytes             uint64 `json:"bytes"`
+}
+
+// ExternalNetworks is internet-bound traffic broken down by the network that
+// owns the remote address, busiest first.
+type ExternalNetworks struct {
+	Events            int            `json:"events"`
+	Connections       int            `json:"connections"`
+	FailedConnections int            `json:"failed_connections"`
+	Retransmits       int            `json:"retransmits"`
+	Untagged          int            `json:"untagged_events"`
+	Networks          []NetworkStats `json:"networks"`
+}
+
+// AnalyzeExternalNetworks groups network events whose remote address is
+// public by the network lookup places it in. Events to addresses lookup
+// does not know are counted as untagged. It returns nil when no event went
+// to the internet.
+func AnalyzeExternalNetworks(evts []*events.Event, lookup func(netip.Addr) (geoip.Info, bool)) *ExternalNetworks {
+	if lookup == nil {
+		return nil
+	}
+	out := &ExternalNetworks{}
+	byNetwork := make(map[string]*NetworkStats)
+	for _, e := range evts {
+		if e == nil || !isNetworkEvent(e.Type) {
+			continue
+		}
+		addr, ok := remoteAddr(e.Target)
+		if !ok || !geoip.IsExternal(addr) {
+			continue
+		}
+		out.Events++
+		failed := e.Type == events.EventConnect && e.Error != 0
+		switch e.Type {
+		case events.EventConnect:
+			out.Connections++
+			if failed {
+				out.FailedConnections++
+			}
+		case events.EventTCPRetrans:
+			out.Retransmits++
+		}
+		info, ok := lookup(addr)
+		if !ok || info.Network() == "" {
+			out.Untagged++
+			continue
+		}
+		key := info.Network()
+		s := byNetwork[key]
+		if s == nil {
+			s = &NetworkStats{Network: key, ASN: info.ASN, Org: info.Org, Country: info.Country}
+			byNetwork[key] = s
+		}
+		s.Events++
+		switch e.Type {
+		case events.EventConnect:
+			s.Connections++
+			if failed {
+				s.FailedConnections++
+			}
+		case events.EventTCPRetrans:
+			s.Retransmits++
+		case events.EventTCPSend, events.EventTCPRecv, events.EventUDPSend, events.EventUDPRecv:
+			s.Bytes += e.Bytes
+		}
+	}
+	if out.Events == 0 {
+		return nil
+	}
+	for _, s := range byNetwork {
+		out.Networks = append(out.Networks, *s)
+	}
+	sort.Slice(out.Networks, func(i, j int) bool {
+		a, b := out.Networks[i], out.Networks[j]
+		if a.Events != b.Events {
+			return a.Events > b.Events
+		}
+		return a.Network < b.Network
+	})
+	return out
+}
+
+func isNetworkEvent(t events.EventType) bool {
+	switch t {
+	case events.EventConnect, events.EventTCPSend, events.EventTCPRecv, events.EventTCPRetrans,
+		events.EventUDPSend, events.EventUDPRecv:
+		return true
+	}
+	return false
+}
+
+// remoteAddr extracts the IP of an "ip:port" or "[ipv6]:port" target.
+func remoteAddr(target string) (netip.Addr, bool) {
+	host := target
+	if h, _, err := net.SplitHostPort(target); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/geoip"
+)
+
+func TestAnalyzeExternalNetworks(t *testing.T) {
+	amazon := netip.MustParsePrefix("52.94.0.0/16")
+	lookup := func(addr netip.Addr) (geoip.Info, bool) {
+		if amazon.Contains(addr) {
+			return geoip.Info{ASN: 16509, Org: "Amazon.com, Inc.", Country: "US"}, true
+		}
+		return geoip.Info{}, false
+	}
+	evts := []*events.Event{
+		{Type: events.EventConnect, Target: "52.94.1.1:443"},
+		{Type: events.EventConnect, Target: "52.94.1.2:443", Error: 111},
+		{Type: events.EventTCPRetrans, Target: "52.94.1.1:443"},
+		{Type: events.EventTCPRetrans, Target: "52.94.1.1:443"},
+		{Type: events.EventTCPRetrans, Target: "1.1.1.1:443"},
+		{Type: events.EventTCPSend, Target: "52.94.1.1:443", Bytes: 1024},
+		{Type: events.EventTCPRetrans, Target: "10.0.0.5:5432"},
+		{Type: events.EventDNS, Target: "example.com"},
+		nil,
+	}
+	got := AnalyzeExternalNetworks(evts, lookup)
+	if got == nil {
+		t.Fatal("expected external networks")
+	}
+	if got.Events != 6 || got.Retransmits != 3 || got.Connections != 2 || got.FailedConnections != 1 || got.Untagged != 1 {
+		t.Errorf("totals = %+v", got)
+	}
+	if len(got.Networks) != 1 {
+		t.Fatalf("networks = %+v", got.Networks)
+	}
+	want := NetworkStats{Network: "AS16509/Amazon.com, Inc.", ASN: 16509, Org: "Amazon.com, Inc.", Country: "US",
+		Events: 5, Connections: 2, FailedConnections: 1, Retransmits: 2, Bytes: 1024}
+	if got.Networks[0] != want {
+		t.Errorf("network = %+v, want %+v", got.Networks[0], want)
+	}
+
+	if AnalyzeExternalNetworks(evts[6:], lookup) != nil {
+		t.Error("expected nil without internet-bound events")
+	}
+	if AnalyzeExternalNetworks(evts, nil) != nil {
+		t.Error("expected nil without a lookup")
+	}
+}
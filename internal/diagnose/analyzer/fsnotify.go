@@ -0,0 +1,78 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// CgroupFsNotify summarizes the EventFsNotify samples of one cgroup.
+type CgroupFsNotify struct {
+	Cgroup  string `json:"cgroup"`
+	Samples int    `json:"samples"`
+	// Watches and Marks are the inotify watches and fanotify marks
+	// registered; LimitHits the registrations refused by
+	// fs.inotify.max_user_watches or fanotify's mark limit.
+	Watches   uint64 `json:"watches"`
+	Marks     uint64 `json:"marks"`
+	LimitHits uint64 `json:"limit_hits"`
+	// Overflows are the events dropped because a group of the cgroup did
+	// not drain its queue.
+	Overflows uint64 `json:"overflows"`
+	// PeakRegistrationRate is the most watches and marks registered per
+	// second over one interval.
+	PeakRegistrationRate float64 `json:"peak_registration_rate"`
+	// StormIntervals counts the samples past PODTRACE_FSNOTIFY_WATCH_RATE_WARN
+	// or with refused registrations or dropped events.
+	StormIntervals int `json:"storm_intervals"`
+}
+
+// AnalyzeFsNotify summarizes the EventFsNotify samples in evts per cgroup,
+// most dropped events first, then most registrations. It returns nil when
+// there are none.
+func AnalyzeFsNotify(evts []*events.Event) []CgroupFsNotify {
+	byCgroup := make(map[string]*CgroupFsNotify)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventFsNotify {
+			continue
+		}
+		n := events.ParseFsNotifyCounts(e.Details)
+		c := byCgroup[e.Target]
+		if c == nil {
+			c = &CgroupFsNotify{Cgroup: e.Target}
+			byCgroup[e.Target] = c
+		}
+		c.Samples++
+		c.Watches += n.Watches
+		c.Marks += n.Marks
+		c.LimitHits += n.LimitHits
+		c.Overflows += n.Overflows
+		var rate float64
+		if e.LatencyNS > 0 {
+			rate = float64(n.Watches+n.Marks) / (float64(e.LatencyNS) / 1e9)
+			c.PeakRegistrationRate = max(c.PeakRegistrationRate, rate)
+		}
+		if rate >= config.FsNotifyWatchRateWarn || n.LimitHits > 0 || n.Overflows > 0 {
+			c.StormIntervals++
+		}
+	}
+	if len(byCgroup) == 0 {
+		return nil
+	}
+	out := make([]CgroupFsNotify, 0, len(byCgroup))
+	for _, c := range byCgroup {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Overflows+a.LimitHits != b.Overflows+b.LimitHits {
+			return a.Overflows+a.LimitHits > b.Overflows+b.LimitHits
+		}
+		if a.Watches+a.Marks != b.Watches+b.Marks {
+			return a.Watches+a.Marks > b.Watches+b.Marks
+		}
+		return a.Cgroup < b.Cgroup
+	})
+	return out
+}
@@ -0,0 +1,39 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func fsnotifyEvent(cgroup string, c events.FsNotifyCounts) *events.Event {
+	return &events.Event{Type: events.EventFsNotify, Target: cgroup, LatencyNS: uint64(10 * time.Second), Details: c.String()}
+}
+
+func TestAnalyzeFsNotify(t *testing.T) {
+	evts := []*events.Event{
+		fsnotifyEvent("/reloader", events.FsNotifyCounts{Watches: 20000}),
+		fsnotifyEvent("/reloader", events.FsNotifyCounts{Watches: 50}),
+		fsnotifyEvent("/reloader", events.FsNotifyCounts{Watches: 10, LimitHits: 4}),
+		fsnotifyEvent("/app", events.FsNotifyCounts{Watches: 3, Marks: 1}),
+		{Type: events.EventRead, Target: "/reloader"},
+	}
+	got := AnalyzeFsNotify(evts)
+	if len(got) != 2 {
+		t.Fatalf("fsnotify = %+v", got)
+	}
+	r := got[0]
+	if r.Cgroup != "/reloader" || r.Samples != 3 || r.Watches != 20060 || r.LimitHits != 4 {
+		t.Errorf("cgroup = %+v", r)
+	}
+	if r.PeakRegistrationRate != 2000 || r.StormIntervals != 2 {
+		t.Errorf("peak %v, storm intervals %d", r.PeakRegistrationRate, r.StormIntervals)
+	}
+	if got[1].StormIntervals != 0 || got[1].Marks != 1 {
+		t.Errorf("quiet cgroup = %+v", got[1])
+	}
+	if AnalyzeFsNotify(nil) != nil {
+		t.Error("expected nil without fsnotify samples")
+	}
+}
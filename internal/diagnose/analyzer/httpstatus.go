@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// httpTrendSlices is how many equal slices of the session the 5xx rate of an
+// endpoint is given for, so a rate that climbs shows as a climb.
+const httpTrendSlices = 6
+
+// HTTPEndpointStatus is the status code distribution of one endpoint's
+// responses.
+type HTTPEndpointStatus struct {
+	Endpoint  string `json:"endpoint"`
+	Responses int    `json:"responses"`
+	Status2xx int    `json:"status_2xx"`
+	Status3xx int    `json:"status_3xx"`
+	Status4xx int    `json:"status_4xx"`
+	Status5xx int    `json:"status_5xx"`
+	// Throttled is the 429 Too Many Requests among the 4xx, when there
+	// were any.
+	Throttled *HTTPThrottled `json:"throttled,omitempty"`
+	// Trend splits the responses into equal slices of the session.
+	Trend []HTTPStatusSlice `json:"trend"`
+	// Burst is the PODTRACE_HTTP_5XX_BURST_WINDOW with the most 5xx
+	// responses, when it holds any.
+	Burst *HTTP5xxBurst `json:"burst,omitempty"`
+}
+
+// HTTPStatusSlice is the responses of one slice of the session.
+type HTTPStatusSlice struct {
+	Responses int `json:"responses"`
+	Status5xx int `json:"status_5xx"`
+}
+
+// HTTPThrottled is an endpoint's 429 responses and when they came.
+type HTTPThrottled struct {
+	Responses int       `json:"responses"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+}
+
+// HTTP5xxBurst is a window of an endpoint's responses.
+type HTTP5xxBurst struct {
+	Start     time.Time `json:"start"`
+	Responses int       `json:"responses"`
+	Status5xx int       `json:"status_5xx"`
+}
+
+// ErrorRate is the share of responses that were 5xx, in percent.
+func (s HTTPEndpointStatus) ErrorRate() float64 {
+	if s.Responses == 0 {
+		return 0
+	}
+	return float64(s.Status5xx) / float64(s.Responses) * 100
+}
+
+// ErrorRate is the share of the slice's responses that were 5xx, in
+// percent.
+func (s HTTPStatusSlice) ErrorRate() float64 {
+	if s.Responses == 0 {
+		return 0
+	}
+	return float64(s.Status5xx) / float64(s.Responses) * 100
+}
+
+// HTTPStatusCode returns the status code of an HTTP response event: the
+// number on the first line of Details, or the code carried in Error. It
+// returns 0 when the event has none.
+func HTTPStatusCode(e *events.Event) int {
+	first := e.Details
+	if i := strings.IndexByte(first, '\n'); i >= 0 {
+		first = first[:i]
+	}
+	if n, err := strconv.Atoi(strings.TrimSpace(first)); err == nil && n >= 100 && n <= 599 {
+		return n
+	}
+	if e.Error >= 100 && e.Error <= 599 {
+		return int(e.Error)
+	}
+	return 0
+}
+
+// AnalyzeHTTPStatus tallies the status codes of each endpoint's responses,
+// busiest endpoint first. start and end bound the trend slices; zero bounds
+// take the first and last response. Responses without a target or a status
+// code are left out.
+func AnalyzeHTTPStatus(responses []*events.Event, start, end time.Time) []HTTPEndpointStatus {
+	type response struct {
+		at   time.Time
+		code int
+	}
+	byEndpoint := make(map[string][]response)
+	for _, e := range responses {
+		if e == nil || e.Type != events.EventHTTPResp || e.Target == "" {
+			continue
+		}
+		code := HTTPStatusCode(e)
+		if code == 0 {
+			continue
+		}
+		at := e.TimestampTime()
+		if start.IsZero() || at.Before(start) {
+			start = at
+		}
+		if at.After(end) {
+			end = at
+		}
+		byEndpoint[e.Target] = append(byEndpoint[e.Target], response{at, code})
+	}
+	if len(byEndpoint) == 0 {
+		return nil
+	}
+	span := end.Sub(start)
+	window := config.HTTP5xxBurstWindow
+
+	out := make([]HTTPEndpointStatus, 0, len(byEndpoint))
+	for endpoint, rs := range byEndpoint {
+		sort.Slice(rs, func(i, j int) bool { return rs[i].at.Before(rs[j].at) })
+		s := HTTPEndpointStatus{Endpoint: endpoint, Responses: len(rs), Trend: make([]HTTPStatusSlice, httpTrendSlices)}
+		lo, win5xx := 0, 0
+		for i, r := range rs {
+			slice := 0
+			if span > 0 {
+				slice = min(int(r.at.Sub(start)*httpTrendSlices/span), httpTrendSlices-1)
+			}
+			s.Trend[slice].Responses++
+			switch r.code / 100 {
+			case 2:
+				s.Status2xx++
+			case 3:
+				s.Status3xx++
+			case 4:
+				s.Status4xx++
+			case 5:
+				s.Status5xx++
+				s.Trend[slice].Status5xx++
+				win5xx++
+			}
+			if r.code == 429 {
+				if s.Throttled == nil {
+					s.Throttled = &HTTPThrottled{First: r.at}
+				}
+				s.Throttled.Responses++
+				s.Throttled.Last = r.at
+			}
+			// Slide the burst window to end at this response.
+			for r.at.Sub(rs[lo].at) > window {
+				if rs[lo].code/100 == 5 {
+					win5xx--
+				}
+				lo++
+			}
+			if win5xx > 0 && (s.Burst == nil || win5xx > s.Burst.Status5xx) {
+				s.Burst = &HTTP5xxBurst{Start: rs[lo].at, Responses: i - lo + 1, Status5xx: win5xx}
+			}
+		}
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Responses != out[j].Responses {
+			return out[i].Responses > out[j].Responses
+		}
+		return out[i].Endpoint < out[j].Endpoint
+	})
+	return out
+}
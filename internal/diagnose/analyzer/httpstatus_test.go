@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func httpResponse(target string, at time.Duration, status string) *events.Event {
+	return &events.Event{Type: events.EventHTTPResp, Timestamp: uint64(time.Hour + at), Target: target, Details: status}
+}
+
+func TestHTTPStatusCode(t *testing.T) {
+	for _, tc := range []struct {
+		e    *events.Event
+		want int
+	}{
+		{&events.Event{Details: "503\nbody"}, 503},
+		{&events.Event{Details: " 200 "}, 200},
+		{&events.Event{Error: 429}, 429},
+		{&events.Event{Details: "OK", Error: -104}, 0},
+	} {
+		if got := HTTPStatusCode(tc.e); got != tc.want {
+			t.Errorf("HTTPStatusCode(%+v) = %d, want %d", tc.e, got, tc.want)
+		}
+	}
+}
+
+func TestAnalyzeHTTPStatus(t *testing.T) {
+	var evts []*events.Event
+	// /orders is healthy for a minute, then fails five times in 4s.
+	for i := 0; i < 10; i++ {
+		evts = append(evts, httpResponse("GET /orders", time.Duration(i)*5*time.Second, "200"))
+	}
+	for i := 0; i < 5; i++ {
+		evts = append(evts, httpResponse("GET /orders", time.Minute+time.Duration(i)*time.Second, "503"))
+	}
+	evts = append(evts,
+		httpResponse("POST /pay", 10*time.Second, "429"),
+		httpResponse("POST /pay", 20*time.Second, "429"),
+		httpResponse("POST /pay", 30*time.Second, "201"),
+		httpResponse("", 30*time.Second, "500"),
+		httpResponse("GET /none", 30*time.Second, ""),
+		&events.Event{Type: events.EventHTTPReq, Target: "GET /orders", Details: "500"},
+	)
+
+	got := AnalyzeHTTPStatus(evts, time.Time{}, time.Time{})
+	if len(got) != 2 {
+		t.Fatalf("endpoints = %+v", got)
+	}
+	orders, pay := got[0], got[1]
+	if orders.Endpoint != "GET /orders" || orders.Responses != 15 || orders.Status2xx != 10 || orders.Status5xx != 5 {
+		t.Errorf("orders = %+v", orders)
+	}
+	if b := orders.Burst; b == nil || b.Status5xx != 5 || b.Responses != 5 {
+		t.Errorf("orders burst = %+v, want all five 5xx in one window", b)
+	}
+	if first, last := orders.Trend[0], orders.Trend[len(orders.Trend)-1]; first.Status5xx != 0 || last.ErrorRate() != 100 {
+		t.Errorf("orders trend = %+v, want the 5xx at the end", orders.Trend)
+	}
+	if pay.Status4xx != 2 || pay.Throttled == nil || pay.Throttled.Responses != 2 || pay.Burst != nil {
+		t.Errorf("pay = %+v", pay)
+	}
+	if d := pay.Throttled.Last.Sub(pay.Throttled.First); d != 10*time.Second {
+		t.Errorf("throttled for %v, want 10s", d)
+	}
+}
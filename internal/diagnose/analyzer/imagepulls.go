@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ImagePull is one image pull of a traced pod.
+type ImagePull struct {
+	Image    string    `json:"image"`
+	Registry string    `json:"registry"`
+	At       time.Time `json:"at"`
+	// DurationMS is the pull itself, WaitingMS the time it queued behind
+	// other pulls first.
+	DurationMS float64 `json:"duration_ms"`
+	WaitingMS  float64 `json:"waiting_ms"`
+	SizeBytes  uint64  `json:"size_bytes"`
+	// ThroughputBps is the image size over the pull time, when the kubelet
+	// reported the size.
+	ThroughputBps float64 `json:"throughput_bps"`
+	Cached        bool    `json:"cached"`
+	Failed        bool    `json:"failed"`
+	Reason        string  `json:"reason,omitempty"`
+	// Slow is set past PODTRACE_IMAGE_PULL_SLOW.
+	Slow bool `json:"slow"`
+}
+
+// RegistryPulls summarizes the pulls from one registry.
+type RegistryPulls struct {
+	Registry string `json:"registry"`
+	Pulls    int    `json:"pulls"`
+	Cached   int    `json:"cached"`
+	Failed   int    `json:"failed"`
+	Slow     int    `json:"slow"`
+	Bytes    uint64 `json:"bytes"`
+	// ThroughputBps is the bytes pulled over the time the pulls with a known
+	// size took.
+	ThroughputBps float64 `json:"throughput_bps"`
+	MaxPullMS     float64 `json:"max_pull_ms"`
+	// ConnectMS is the median TCP connect latency to the registry over the
+	// probes, 0 when it was not probed.
+	ConnectMS float64 `json:"connect_ms"`
+	// Failures counts the failed pulls by reason.
+	Failures map[string]int `json:"failures,omitempty"`
+}
+
+// ImagePulls summarizes the image pulls of the traced pods.
+type ImagePulls struct {
+	Pulls      []ImagePull     `json:"pulls"`
+	Registries []RegistryPulls `json:"registries"`
+}
+
+// AnalyzeImagePulls summarizes the EventImagePull events in evts: each pull
+// in time order, and per registry, the slowest first. It returns nil when
+// there are none.
+func AnalyzeImagePulls(evts []*events.Event) *ImagePulls {
+	out := &ImagePulls{}
+	type acc struct {
+		RegistryPulls
+		sizedNS  uint64
+		connects []float64
+	}
+	registries := make(map[string]*acc)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventImagePull {
+			continue
+		}
+		d := events.ParseImagePullDetails(e.Details)
+		p := ImagePull{
+			Image:      e.Target,
+			Registry:   d.Registry,
+			At:         e.TimestampTime(),
+			DurationMS: float64(e.LatencyNS) / float64(time.Millisecond),
+			WaitingMS:  float64(d.WaitNS) / float64(time.Millisecond),
+			SizeBytes:  e.Bytes,
+			Cached:     d.Cached,
+			Failed:     e.Error != 0,
+			Reason:     d.Reason,
+			Slow:       e.LatencyNS > uint64(config.ImagePullSlowWarn),
+		}
+		if e.Bytes > 0 && e.LatencyNS > 0 {
+			p.ThroughputBps = float64(e.Bytes) / (float64(e.LatencyNS) / 1e9)
+		}
+		out.Pulls = append(out.Pulls, p)
+
+		r := registries[p.Registry]
+		if r == nil {
+			r = &acc{RegistryPulls: RegistryPulls{Registry: p.Registry}}
+			registries[p.Registry] = r
+		}
+		r.Pulls++
+		switch {
+		case p.Cached:
+			r.Cached++
+		case p.Failed:
+			r.Failed++
+			if r.Failures == nil {
+				r.Failures = make(map[string]int)
+			}
+			r.Failures[p.Reason]++
+		}
+		if p.Slow {
+			r.Slow++
+		}
+		if p.ThroughputBps > 0 {
+			r.Bytes += e.Bytes
+			r.sizedNS += e.LatencyNS
+		}
+		r.MaxPullMS = max(r.MaxPullMS, p.DurationMS)
+		if d.RegistryRTTNS > 0 {
+			r.connects = append(r.connects, float64(d.RegistryRTTNS)/float64(time.Millisecond))
+		}
+	}
+	if len(out.Pulls) == 0 {
+		return nil
+	}
+	sort.SliceStable(out.Pulls, func(i, j int) bool { return out.Pulls[i].At.Before(out.Pulls[j].At) })
+	for _, r := range registries {
+		if r.sizedNS > 0 {
+			r.ThroughputBps = float64(r.Bytes) / (float64(r.sizedNS) / 1e9)
+		}
+		if len(r.connects) > 0 {
+			sort.Float64s(r.connects)
+			r.ConnectMS = r.connects[len(r.connects)/2]
+		}
+		out.Registries = append(out.Registries, r.RegistryPulls)
+	}
+	sort.Slice(out.Registries, func(i, j int) bool {
+		a, b := out.Registries[i], out.Registries[j]
+		if a.MaxPullMS != b.MaxPullMS {
+			return a.MaxPullMS > b.MaxPullMS
+		}
+		return a.Registry < b.Registry
+	})
+	return out
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func imagePullEvent(image string, ts uint64, pull time.Duration, size uint64, failed bool, d events.ImagePullDetails) *events.Event {
+	e := &events.Event{Type: events.EventImagePull, Timestamp: ts, Target: image, LatencyNS: uint64(pull), Bytes: size, Details: d.String()}
+	if failed {
+		e.Error = 1
+	}
+	return e
+}
+
+func TestAnalyzeImagePulls(t *testing.T) {
+	evts := []*events.Event{
+		imagePullEvent("ghcr.io/acme/app:1", 300, 40*time.Second, 200<<20, false,
+			events.ImagePullDetails{Registry: "ghcr.io", WaitNS: uint64(5 * time.Second), RegistryRTTNS: uint64(80 * time.Millisecond)}),
+		imagePullEvent("ghcr.io/acme/sidecar:1", 100, 10*time.Second, 50<<20, false,
+			events.ImagePullDetails{Registry: "ghcr.io", RegistryRTTNS: uint64(20 * time.Millisecond)}),
+		imagePullEvent("ghcr.io/acme/init:1", 200, 0, 0, true, events.ImagePullDetails{Registry: "ghcr.io", Reason: "Unauthorized"}),
+		imagePullEvent("nginx", 50, 0, 0, false, events.ImagePullDetails{Registry: "docker.io", Cached: true}),
+		{Type: events.EventCRIOp},
+	}
+	got := AnalyzeImagePulls(evts)
+	if got == nil || len(got.Pulls) != 4 || len(got.Registries) != 2 {
+		t.Fatalf("image pulls = %+v", got)
+	}
+	if got.Pulls[0].Image != "nginx" || got.Pulls[3].Image != "ghcr.io/acme/app:1" {
+		t.Errorf("pulls not in time order: %+v", got.Pulls)
+	}
+	app := got.Pulls[3]
+	if !app.Slow || app.WaitingMS != 5000 || app.ThroughputBps != float64(200<<20)/40 {
+		t.Errorf("app pull = %+v", app)
+	}
+	r := got.Registries[0]
+	if r.Registry != "ghcr.io" || r.Pulls != 3 || r.Failed != 1 || r.Slow != 1 || r.Failures["Unauthorized"] != 1 {
+		t.Errorf("ghcr.io = %+v", r)
+	}
+	if r.Bytes != 250<<20 || r.ThroughputBps != float64(250<<20)/50 || r.MaxPullMS != 40000 || r.ConnectMS != 80 {
+		t.Errorf("ghcr.io throughput = %+v", r)
+	}
+	if hub := got.Registries[1]; hub.Registry != "docker.io" || hub.Cached != 1 {
+		t.Errorf("docker.io = %+v", hub)
+	}
+	if AnalyzeImagePulls(nil) != nil {
+		t.Error("expected nil without image pulls")
+	}
+}
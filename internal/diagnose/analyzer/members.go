@@ -0,0 +1,137 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/targetnames"
+)
+
+// outlierLatencyFactor is how many times the median member's connect
+// latency a member must take to stand out from its siblings.
+const outlierLatencyFactor = 2
+
+// StatefulSetMember is the traffic of the traced pods to one member of a
+// headless Service, addressed by its pod DNS name.
+type StatefulSetMember struct {
+	Name           string  `json:"name"`
+	Lookups        int     `json:"lookups"`
+	FailedLookups  int     `json:"failed_lookups"`
+	Connects       int     `json:"connects"`
+	FailedConnects int     `json:"failed_connects"`
+	AvgConnectMS   float64 `json:"avg_connect_ms"`
+	// Outlier marks the member that fails while its siblings do not, or
+	// whose connects are much slower than theirs.
+	Outlier bool `json:"outlier,omitempty"`
+}
+
+// HeadlessService groups the members of one headless Service the traced
+// pods addressed individually.
+type HeadlessService struct {
+	Service   string              `json:"service"`
+	Namespace string              `json:"namespace"`
+	Members   []StatefulSetMember `json:"members"`
+}
+
+// AnalyzeStatefulSetMembers finds the DNS answers and connects in evts that
+// name a headless Service pod (<member>.<service>.<namespace>.svc...) and
+// sums them per member, so a single misbehaving replica is not averaged
+// away in the Service's totals.
+func AnalyzeStatefulSetMembers(evts []*events.Event) []HeadlessService {
+	type svcKey struct{ service, namespace string }
+	services := make(map[svcKey]map[string]*StatefulSetMember)
+	connectNS := make(map[*StatefulSetMember]uint64)
+	for _, e := range evts {
+		if e == nil {
+			continue
+		}
+		var name string
+		switch e.Type {
+		case events.EventDNS:
+			name = e.Target
+		case events.EventConnect:
+			name = e.Details
+		default:
+			continue
+		}
+		p, ok := targetnames.ParsePodDNSName(name)
+		if !ok {
+			continue
+		}
+		k := svcKey{p.Service, p.Namespace}
+		if services[k] == nil {
+			services[k] = make(map[string]*StatefulSetMember)
+		}
+		m := services[k][p.Host]
+		if m == nil {
+			m = &StatefulSetMember{Name: p.Host}
+			services[k][p.Host] = m
+		}
+		if e.Type == events.EventDNS {
+			m.Lookups++
+			if e.Error != 0 {
+				m.FailedLookups++
+			}
+			continue
+		}
+		m.Connects++
+		if e.Error != 0 {
+			m.FailedConnects++
+		}
+		connectNS[m] += e.LatencyNS
+	}
+
+	var out []HeadlessService
+	for k, members := range services {
+		hs := HeadlessService{Service: k.service, Namespace: k.namespace}
+		for _, m := range members {
+			if m.Connects > 0 {
+				m.AvgConnectMS = float64(connectNS[m]) / float64(m.Connects) / 1e6
+			}
+			hs.Members = append(hs.Members, *m)
+		}
+		sort.Slice(hs.Members, func(i, j int) bool { return hs.Members[i].Name < hs.Members[j].Name })
+		markMemberOutliers(hs.Members)
+		out = append(out, hs)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		return out[i].Service < out[j].Service
+	})
+	return out
+}
+
+func markMemberOutliers(members []StatefulSetMember) {
+	if len(members) < 2 {
+		return
+	}
+	failing := 0
+	var latencies []float64
+	for _, m := range members {
+		if m.FailedLookups+m.FailedConnects > 0 {
+			failing++
+		}
+		if m.Connects > 0 {
+			latencies = append(latencies, m.AvgConnectMS)
+		}
+	}
+	var median float64
+	if len(latencies) >= 2 {
+		sort.Float64s(latencies)
+		median = latencies[len(latencies)/2]
+		if len(latencies)%2 == 0 {
+			median = (latencies[len(latencies)/2-1] + median) / 2
+		}
+	}
+	for i := range members {
+		m := &members[i]
+		if failing < len(members) && m.FailedLookups+m.FailedConnects > 0 {
+			m.Outlier = true
+		}
+		if median > 0 && m.Connects > 0 && m.AvgConnectMS >= outlierLatencyFactor*median {
+			m.Outlier = true
+		}
+	}
+}
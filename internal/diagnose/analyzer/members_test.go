@@ -0,0 +1,48 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeStatefulSetMembers(t *testing.T) {
+	connect := func(member string, d time.Duration, errno int32) *events.Event {
+		return &events.Event{Type: events.EventConnect, Target: "10.0.3.1:5432",
+			Details: member + ".pg.db.svc.cluster.local", LatencyNS: uint64(d), Error: errno}
+	}
+	evts := []*events.Event{
+		{Type: events.EventDNS, Target: "pg-0.pg.db.svc.cluster.local"},
+		{Type: events.EventDNS, Target: "pg-1.pg.db.svc.cluster.local"},
+		{Type: events.EventDNS, Target: "pg-2.pg.db.svc.cluster.local", Error: 3},
+		{Type: events.EventDNS, Target: "pg.db.svc.cluster.local"},
+		{Type: events.EventDNSQuery, Target: "pg-0.pg.db.svc.cluster.local"},
+		connect("pg-0", time.Millisecond, 0),
+		connect("pg-1", time.Millisecond, 0),
+		connect("pg-1", 3*time.Millisecond, 0),
+		connect("pg-2", 40*time.Millisecond, -111),
+		{Type: events.EventConnect, Target: "10.0.9.9:443", Details: "api.example.com"},
+	}
+	got := AnalyzeStatefulSetMembers(evts)
+	if len(got) != 1 || got[0].Service != "pg" || got[0].Namespace != "db" {
+		t.Fatalf("services = %+v", got)
+	}
+	m := got[0].Members
+	if len(m) != 3 || m[0].Name != "pg-0" || m[2].Name != "pg-2" {
+		t.Fatalf("members = %+v", m)
+	}
+	if m[0].Lookups != 1 || m[0].Connects != 1 || m[0].Outlier {
+		t.Errorf("pg-0 = %+v", m[0])
+	}
+	if m[1].Connects != 2 || m[1].AvgConnectMS != 2 || m[1].Outlier {
+		t.Errorf("pg-1 = %+v", m[1])
+	}
+	if m[2].FailedLookups != 1 || m[2].FailedConnects != 1 || !m[2].Outlier {
+		t.Errorf("pg-2 = %+v, want an outlier", m[2])
+	}
+
+	if s := AnalyzeStatefulSetMembers([]*events.Event{{Type: events.EventDNS, Target: "example.com"}}); s != nil {
+		t.Errorf("no pod DNS names = %+v, want nil", s)
+	}
+}
@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/numa"
+)
+
+// Neighbor is another pod on the traced node, as busy as it was while the
+// traced pods suffered.
+type Neighbor struct {
+	Pod string `json:"pod"`
+	// CPUCores is its CPU time over the sampled time, IOBps its block I/O.
+	CPUCores float64 `json:"cpu_cores"`
+	IOBps    float64 `json:"io_bps"`
+	// Intervals counts its samples that overlapped the suffering.
+	Intervals int `json:"intervals"`
+}
+
+// NoisyNeighbors names the pods on the node that were busiest while the
+// traced pods waited for a CPU or on file I/O.
+type NoisyNeighbors struct {
+	// RunQueueIntervals are the placement samples past
+	// PODTRACE_RUNQUEUE_WAIT_WARN, PeakRunQueueShare the worst of them.
+	RunQueueIntervals int     `json:"run_queue_intervals"`
+	PeakRunQueueShare float64 `json:"peak_run_queue_share"`
+	// SlowIOOps are the reads, writes and fsyncs past the slow threshold.
+	SlowIOOps int `json:"slow_io_ops"`
+	// CPU are the suspects by CPU time when the traced pods waited for a
+	// CPU, IO by block I/O when their file operations were slow.
+	CPU []Neighbor `json:"cpu,omitempty"`
+	IO  []Neighbor `json:"io,omitempty"`
+}
+
+// span is a [from, to] range of event timestamps.
+type span struct{ from, to uint64 }
+
+// mergeSpans sorts spans and merges the overlapping ones.
+func mergeSpans(spans []span) []span {
+	sort.Slice(spans, func(i, j int) bool { return spans[i].from < spans[j].from })
+	var out []span
+	for _, s := range spans {
+		if n := len(out); n > 0 && s.from <= out[n-1].to {
+			out[n-1].to = max(out[n-1].to, s.to)
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// overlaps reports whether s overlaps any of the merged spans.
+func overlaps(merged []span, s span) bool {
+	i := sort.Search(len(merged), func(i int) bool { return merged[i].to >= s.from })
+	return i < len(merged) && merged[i].from <= s.to
+}
+
+// spanBefore is the span of d ending at ts.
+func spanBefore(ts, d uint64) span {
+	if d > ts {
+		return span{0, ts}
+	}
+	return span{ts - d, ts}
+}
+
+// AnalyzeNoisyNeighbors names, from the EventNeighbor samples overlapping
+// them, the busiest pods by CPU while the traced processes waited for a CPU
+// (EventCPUPlacement) and by block I/O while their file operations took
+// fsSlowMS or longer. It returns nil when the traced pods did not suffer
+// either way, or no neighbor was sampled meanwhile.
+func AnalyzeNoisyNeighbors(evts []*events.Event, fsSlowMS float64) *NoisyNeighbors {
+	out := &NoisyNeighbors{}
+	var waits, slowIO []span
+	var samples []*events.Event
+	slowNS := uint64(fsSlowMS * float64(config.NSPerMS))
+	for _, e := range evts {
+		if e == nil {
+			continue
+		}
+		switch e.Type {
+		case events.EventCPUPlacement:
+			s := numa.ParseDetails(e.Details)
+			if s.IntervalNS == 0 {
+				continue
+			}
+			share := float64(s.RunQueueNS) / float64(s.IntervalNS)
+			if share >= config.RunQueueWaitWarn {
+				out.RunQueueIntervals++
+				out.PeakRunQueueShare = max(out.PeakRunQueueShare, share)
+				waits = append(waits, spanBefore(e.Timestamp, s.IntervalNS))
+			}
+		case events.EventRead, events.EventWrite, events.EventFsync:
+			if slowNS > 0 && e.LatencyNS >= slowNS {
+				out.SlowIOOps++
+				slowIO = append(slowIO, spanBefore(e.Timestamp, e.LatencyNS))
+			}
+		case events.EventNeighbor:
+			samples = append(samples, e)
+		}
+	}
+
+	suspects := func(suffering []span, metric func(cpuNS, ioBytes, sampledNS uint64) float64) []Neighbor {
+		if len(suffering) == 0 {
+			return nil
+		}
+		merged := mergeSpans(suffering)
+		type acc struct {
+			Neighbor
+			cpuNS, ioBytes, sampledNS uint64
+		}
+		pods := make(map[string]*acc)
+		for _, e := range samples {
+			if e.LatencyNS == 0 || !overlaps(merged, spanBefore(e.Timestamp, e.LatencyNS)) {
+				continue
+			}
+			p := pods[e.Target]
+			if p == nil {
+				p = &acc{Neighbor: Neighbor{Pod: e.Target}}
+				pods[e.Target] = p
+			}
+			u := events.ParseNeighborUsage(e.Details)
+			p.cpuNS += u.CPUNS
+			p.ioBytes += u.IOBytes
+			p.sampledNS += e.LatencyNS
+			p.Intervals++
+		}
+		type ranked struct {
+			Neighbor
+			score float64
+		}
+		var list []ranked
+		for _, p := range pods {
+			p.CPUCores = float64(p.cpuNS) / float64(p.sampledNS)
+			p.IOBps = float64(p.ioBytes) / (float64(p.sampledNS) / 1e9)
+			if score := metric(p.cpuNS, p.ioBytes, p.sampledNS); score > 0 {
+				list = append(list, ranked{p.Neighbor, score})
+			}
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].score != list[j].score {
+				return list[i].score > list[j].score
+			}
+			return list[i].Pod < list[j].Pod
+		})
+		var top []Neighbor
+		for i := 0; i < len(list) && i < config.NeighborTop; i++ {
+			top = append(top, list[i].Neighbor)
+		}
+		return top
+	}
+	out.CPU = suspects(waits, func(cpuNS, _, sampledNS uint64) float64 { return float64(cpuNS) / float64(sampledNS) })
+	out.IO = suspects(slowIO, func(_, ioBytes, sampledNS uint64) float64 { return float64(ioBytes) / float64(sampledNS) })
+	if len(out.CPU) == 0 && len(out.IO) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/numa"
+)
+
+func TestAnalyzeNoisyNeighbors(t *testing.T) {
+	const s = uint64(time.Second)
+	neighbor := func(pod string, ts uint64, cpu time.Duration, io uint64) *events.Event {
+		u := events.NeighborUsage{CPUNS: uint64(cpu), IOBytes: io}
+		return &events.Event{Type: events.EventNeighbor, Timestamp: ts, Target: pod, LatencyNS: 5 * s, Bytes: io, Details: u.String()}
+	}
+	placement := func(ts uint64, wait time.Duration) *events.Event {
+		return &events.Event{Type: events.EventCPUPlacement, Timestamp: ts, PID: 1,
+			Details: numa.FormatDetails(numa.Sample{IntervalNS: 10 * s, RunQueueNS: uint64(wait)})}
+	}
+	evts := []*events.Event{
+		// The traced process waited 40% of 90..100s for a CPU, and a read
+		// took 50ms at 200s.
+		placement(100*s, 4*time.Second),
+		placement(50*s, 0),
+		{Type: events.EventRead, Timestamp: 200 * s, LatencyNS: uint64(50 * time.Millisecond)},
+		{Type: events.EventRead, Timestamp: 300 * s, LatencyNS: uint64(time.Millisecond)},
+		neighbor("batch/cruncher", 95*s, 15*time.Second, 0),
+		neighbor("batch/cruncher", 100*s, 10*time.Second, 0),
+		neighbor("web/api", 95*s, 1*time.Second, 0),
+		// Busy, but not while the traced pods waited.
+		neighbor("batch/other", 50*s, 40*time.Second, 0),
+		neighbor("logs/shipper", 200*s, 0, 500<<20),
+		neighbor("web/api", 200*s, time.Second, 10<<20),
+	}
+	got := AnalyzeNoisyNeighbors(evts, 10)
+	if got == nil {
+		t.Fatal("expected noisy neighbors")
+	}
+	if got.RunQueueIntervals != 1 || got.PeakRunQueueShare != 0.4 || got.SlowIOOps != 1 {
+		t.Errorf("symptoms = %+v", got)
+	}
+	if len(got.CPU) != 2 || got.CPU[0].Pod != "batch/cruncher" || got.CPU[0].CPUCores != 2.5 || got.CPU[0].Intervals != 2 {
+		t.Errorf("cpu suspects = %+v", got.CPU)
+	}
+	if len(got.IO) != 2 || got.IO[0].Pod != "logs/shipper" || got.IO[0].IOBps != float64(100<<20) {
+		t.Errorf("io suspects = %+v", got.IO)
+	}
+	if AnalyzeNoisyNeighbors(evts[4:], 10) != nil {
+		t.Error("expected nil when the traced pods did not suffer")
+	}
+}
@@ -0,0 +1,187 @@
+package analyzer
+
+import (
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ObjectStorageStats aggregates the S3-style calls made to one bucket with
+// one operation (GET, PUT, LIST, HEAD or DELETE).
+type ObjectStorageStats struct {
+	Bucket    string
+	Operation string
+	Count     int
+	Errors    int
+	P50MS     float64
+	P95MS     float64
+	P99MS     float64
+	MaxMS     float64
+}
+
+// sigv4Prefix is the Details line the HTTP probes and the HTTP/2 decoder add
+// to requests carrying AWS SigV4 headers; its value is the request host.
+const sigv4Prefix = "sigv4: "
+
+type httpRequestKey struct {
+	pid uint32
+	id  uint64
+}
+
+// AnalyzeObjectStorage picks S3-style calls out of the HTTP stream and
+// aggregates their responses by bucket and operation. A call counts as object
+// storage when its request was SigV4-signed, or when its URL carries a
+// presigned signature or a ListObjectsV2 query. Requests and responses are
+// paired on PID and CorrelationID, which both hold the request start time.
+func AnalyzeObjectStorage(reqEvents, respEvents []*events.Event) []ObjectStorageStats {
+	hosts := make(map[httpRequestKey]string)
+	for _, e := range reqEvents {
+		if e == nil {
+			continue
+		}
+		if host, ok := sigv4Host(e.Details); ok {
+			hosts[httpRequestKey{e.PID, e.CorrelationID}] = host
+		}
+	}
+
+	type bucketOp struct{ bucket, op string }
+	groups := make(map[bucketOp]*ObjectStorageStats)
+	lat := make(map[bucketOp][]float64)
+	for _, e := range respEvents {
+		if e == nil {
+			continue
+		}
+		method, target, ok := strings.Cut(e.Target, " ")
+		if !ok {
+			continue
+		}
+		host, signed := hosts[httpRequestKey{e.PID, e.CorrelationID}]
+		if !signed && !IsObjectStorageURL(target) {
+			continue
+		}
+		bucket, op := ClassifyObjectStorageCall(host, method, target)
+		k := bucketOp{bucket, op}
+		g, ok := groups[k]
+		if !ok {
+			g = &ObjectStorageStats{Bucket: bucket, Operation: op}
+			groups[k] = g
+		}
+		g.Count++
+		if e.Error != 0 {
+			g.Errors++
+		}
+		lat[k] = append(lat[k], float64(e.LatencyNS)/float64(config.NSPerMS))
+	}
+
+	stats := make([]ObjectStorageStats, 0, len(groups))
+	for k, g := range groups {
+		l := lat[k]
+		sort.Float64s(l)
+		g.P50MS = Percentile(l, 50)
+		g.P95MS = Percentile(l, 95)
+		g.P99MS = Percentile(l, 99)
+		g.MaxMS = l[len(l)-1]
+		stats = append(stats, *g)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Count != stats[j].Count {
+			return stats[i].Count > stats[j].Count
+		}
+		if stats[i].Bucket != stats[j].Bucket {
+			return stats[i].Bucket < stats[j].Bucket
+		}
+		return stats[i].Operation < stats[j].Operation
+	})
+	return stats
+}
+
+func sigv4Host(details string) (string, bool) {
+	for _, line := range strings.Split(details, "\n") {
+		if host, ok := strings.CutPrefix(line, sigv4Prefix); ok {
+			return strings.TrimSpace(host), true
+		}
+	}
+	return "", false
+}
+
+// IsObjectStorageURL reports whether a request path identifies an S3-style
+// call on its own: a presigned URL or a ListObjectsV2 query.
+func IsObjectStorageURL(target string) bool {
+	_, query, ok := strings.Cut(target, "?")
+	if !ok {
+		return false
+	}
+	for _, p := range strings.Split(query, "&") {
+		name, _, _ := strings.Cut(p, "=")
+		switch name {
+		case "X-Amz-Signature", "X-Amz-Credential", "X-Amz-Algorithm", "list-type":
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyObjectStorageCall derives the bucket and operation of an S3-style
+// call. The bucket comes from the host for virtual-hosted AWS and GCS
+// endpoints and from the first path segment otherwise (path-style URLs, as
+// used by MinIO and most S3-compatible stores).
+func ClassifyObjectStorageCall(host, method, target string) (bucket, op string) {
+	path, query, _ := strings.Cut(target, "?")
+	path = strings.TrimPrefix(path, "/")
+	key := path
+	bucket = virtualHostedBucket(host)
+	if bucket == "" {
+		bucket, key, _ = strings.Cut(path, "/")
+	}
+	if bucket == "" {
+		bucket = "(no bucket)"
+	}
+
+	hasParam := func(name string) bool {
+		for _, p := range strings.Split(query, "&") {
+			if n, _, _ := strings.Cut(p, "="); n == name {
+				return true
+			}
+		}
+		return false
+	}
+	switch strings.ToUpper(method) {
+	case "GET":
+		if key == "" || hasParam("list-type") || hasParam("uploads") {
+			return bucket, "LIST"
+		}
+		return bucket, "GET"
+	case "POST":
+		if hasParam("delete") {
+			return bucket, "DELETE"
+		}
+		// Multipart upload initiation and completion.
+		return bucket, "PUT"
+	}
+	return bucket, strings.ToUpper(method)
+}
+
+// virtualHostedBucket returns the bucket named by a virtual-hosted-style
+// host such as "logs.s3.us-east-1.amazonaws.com", or "" for path-style and
+// non-AWS/GCS hosts.
+func virtualHostedBucket(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if b, ok := strings.CutSuffix(host, ".storage.googleapis.com"); ok {
+		return b
+	}
+	if !strings.HasSuffix(host, ".amazonaws.com") {
+		return ""
+	}
+	for _, sep := range []string{".s3.", ".s3-"} {
+		if i := strings.Index(host, sep); i > 0 {
+			return host[:i]
+		}
+	}
+	return ""
+}
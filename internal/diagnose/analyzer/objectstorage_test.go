@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeObjectStorage(t *testing.T) {
+	const ms = uint64(1_000_000)
+	req := func(id uint64, target, details string) *events.Event {
+		return &events.Event{Type: events.EventHTTPReq, PID: 7, CorrelationID: id, Target: target, Details: details}
+	}
+	resp := func(id uint64, target string, latMS uint64, errCode int32) *events.Event {
+		return &events.Event{Type: events.EventHTTPResp, PID: 7, CorrelationID: id, Target: target, LatencyNS: latMS * ms, Error: errCode}
+	}
+	reqs := []*events.Event{
+		req(1, "GET /?list-type=2&prefix=a", "sigv4: logs.s3.us-east-1.amazonaws.com"),
+		req(2, "GET /?list-type=2&prefix=b", "traceparent: 00-0af7651916cd43dd8448eb211c80319c-b7ad6b7169203331-01\nsigv4: logs.s3.us-east-1.amazonaws.com"),
+		req(3, "PUT /data/obj.bin", "sigv4: minio:9000"),
+		req(4, "GET /api/orders", ""),
+		nil,
+	}
+	resps := []*events.Event{
+		resp(1, "GET /?list-type=2&prefix=a", 10, 0),
+		resp(2, "GET /?list-type=2&prefix=b", 30, 503),
+		resp(3, "PUT /data/obj.bin", 5, 0),
+		resp(4, "GET /api/orders", 2, 0),
+		// Presigned download with no SigV4 headers on the request.
+		resp(5, "GET /media/cat.jpg?X-Amz-Algorithm=AWS4-HMAC-SHA256", 8, 0),
+		nil,
+	}
+
+	stats := AnalyzeObjectStorage(reqs, resps)
+	if len(stats) != 3 {
+		t.Fatalf("stats = %+v", stats)
+	}
+	list := stats[0]
+	if list.Bucket != "logs" || list.Operation != "LIST" || list.Count != 2 || list.Errors != 1 || list.MaxMS != 30 || list.P50MS != 20 {
+		t.Errorf("list stats = %+v", list)
+	}
+	if stats[1].Bucket != "data" || stats[1].Operation != "PUT" {
+		t.Errorf("put stats = %+v", stats[1])
+	}
+	if stats[2].Bucket != "media" || stats[2].Operation != "GET" {
+		t.Errorf("presigned stats = %+v", stats[2])
+	}
+}
+
+func TestClassifyObjectStorageCall(t *testing.T) {
+	tests := []struct {
+		host, method, target string
+		bucket, op           string
+	}{
+		{"logs.s3.amazonaws.com", "GET", "/2026/01/app.log", "logs", "GET"},
+		{"my.bucket.s3-eu-west-1.amazonaws.com:443", "HEAD", "/k", "my.bucket", "HEAD"},
+		{"assets.storage.googleapis.com", "GET", "/", "assets", "LIST"},
+		{"s3.us-east-1.amazonaws.com", "GET", "/logs?prefix=x", "logs", "LIST"},
+		{"", "DELETE", "/logs/old", "logs", "DELETE"},
+		{"", "POST", "/logs?delete", "logs", "DELETE"},
+		{"", "POST", "/logs/big?uploads", "logs", "PUT"},
+		{"", "GET", "/logs?uploads", "logs", "LIST"},
+		{"", "GET", "/", "(no bucket)", "LIST"},
+	}
+	for _, tt := range tests {
+		bucket, op := ClassifyObjectStorageCall(tt.host, tt.method, tt.target)
+		if bucket != tt.bucket || op != tt.op {
+			t.Errorf("ClassifyObjectStorageCall(%q, %q, %q) = %q, %q; want %q, %q",
+				tt.host, tt.method, tt.target, bucket, op, tt.bucket, tt.op)
+		}
+	}
+}
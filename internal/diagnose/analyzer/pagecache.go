@@ -0,0 +1,173 @@
+package analyzer
+
+import (
+	"math"
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// How slow reads were told apart into page-cache hits and cold reads.
+const (
+	// ColdReadsByMisses uses the folios each read added to the page cache.
+	ColdReadsByMisses = "folio_misses"
+	// ColdReadsByLatency splits the read latencies at the gap between their
+	// two modes, for kernels without the filemap_add_folio probe.
+	ColdReadsByLatency = "latency_bimodality"
+)
+
+// bimodalGap is how many times slower the slow mode of read latencies must
+// be than the fast one to be told apart.
+const bimodalGap = 4.0
+
+// FileCache is the page-cache behaviour of the slow reads of one file.
+type FileCache struct {
+	File      string  `json:"file"`
+	Reads     int     `json:"reads"`
+	ColdReads int     `json:"cold_reads"`
+	HitRatio  float64 `json:"hit_ratio"`
+	// ColdLatencyMS is the read time spent in the cold reads.
+	ColdLatencyMS float64 `json:"cold_latency_ms"`
+	LatencyMS     float64 `json:"latency_ms"`
+}
+
+// PageCache estimates how well the page cache served the traced reads.
+type PageCache struct {
+	// Reads, MissReads and MissFolios count every regular-file read, not
+	// only the slow ones; HitRatio is derived from them when HasCounters.
+	Reads       uint64  `json:"reads"`
+	MissReads   uint64  `json:"miss_reads"`
+	MissFolios  uint64  `json:"miss_folios"`
+	HitRatio    float64 `json:"hit_ratio"`
+	HasCounters bool    `json:"has_counters"`
+	// Method is ColdReadsByMisses or ColdReadsByLatency, empty when no slow
+	// read could be called cold. ColdThresholdMS is the latency split of
+	// ColdReadsByLatency.
+	Method          string  `json:"method,omitempty"`
+	ColdThresholdMS float64 `json:"cold_threshold_ms,omitempty"`
+	// SlowReads are the reads slow enough to be traced one by one;
+	// ColdSlowReads those among them that went to the device.
+	SlowReads     int `json:"slow_reads"`
+	ColdSlowReads int `json:"cold_slow_reads"`
+	// Files are the files with cold reads, most cold read time first.
+	Files []FileCache `json:"files,omitempty"`
+}
+
+// AnalyzePageCache estimates the page-cache hit ratio from the
+// EventPageCache counters and classifies the slow EventRead reads as hits or
+// cold reads. It returns nil when there is neither.
+func AnalyzePageCache(evts []*events.Event) *PageCache {
+	out := &PageCache{}
+	var reads []*events.Event
+	haveMisses := false
+	for _, e := range evts {
+		if e == nil {
+			continue
+		}
+		switch e.Type {
+		case events.EventPageCache:
+			c := events.ParsePageCacheCounts(e.Details)
+			out.Reads += c.Reads
+			out.MissReads += c.MissReads
+			out.MissFolios += c.MissFolios
+			out.HasCounters = true
+		case events.EventRead:
+			if e.Error != 0 {
+				continue
+			}
+			reads = append(reads, e)
+			if e.ReadCacheMisses() > 0 {
+				haveMisses = true
+			}
+		}
+	}
+	if !out.HasCounters && len(reads) == 0 {
+		return nil
+	}
+	if out.Reads > 0 {
+		out.HitRatio = 1 - float64(out.MissReads)/float64(out.Reads)
+	}
+	out.SlowReads = len(reads)
+
+	cold := func(e *events.Event) bool { return false }
+	if haveMisses || out.MissReads > 0 {
+		out.Method = ColdReadsByMisses
+		cold = func(e *events.Event) bool { return e.ReadCacheMisses() > 0 }
+	} else {
+		latencies := make([]float64, len(reads))
+		for i, e := range reads {
+			latencies[i] = float64(e.LatencyNS) / float64(config.NSPerMS)
+		}
+		if threshold, ok := bimodalSplit(latencies); ok {
+			out.Method, out.ColdThresholdMS = ColdReadsByLatency, threshold
+			cold = func(e *events.Event) bool { return float64(e.LatencyNS)/float64(config.NSPerMS) > threshold }
+		}
+	}
+
+	byFile := make(map[string]*FileCache)
+	for _, e := range reads {
+		ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+		isCold := cold(e)
+		if isCold {
+			out.ColdSlowReads++
+		}
+		if e.Target == "" || e.Target == "?" || e.Target == "unknown" || e.Target == "file" {
+			continue
+		}
+		f := byFile[e.Target]
+		if f == nil {
+			f = &FileCache{File: e.Target}
+			byFile[e.Target] = f
+		}
+		f.Reads++
+		f.LatencyMS += ms
+		if isCold {
+			f.ColdReads++
+			f.ColdLatencyMS += ms
+		}
+	}
+	for _, f := range byFile {
+		if f.ColdReads == 0 {
+			continue
+		}
+		f.HitRatio = 1 - float64(f.ColdReads)/float64(f.Reads)
+		out.Files = append(out.Files, *f)
+	}
+	sort.Slice(out.Files, func(i, j int) bool {
+		if out.Files[i].ColdLatencyMS != out.Files[j].ColdLatencyMS {
+			return out.Files[i].ColdLatencyMS > out.Files[j].ColdLatencyMS
+		}
+		return out.Files[i].File < out.Files[j].File
+	})
+	if len(out.Files) > config.TopFilesLimit {
+		out.Files = out.Files[:config.TopFilesLimit]
+	}
+	return out
+}
+
+// bimodalSplit returns the latency between the fast and slow modes of
+// latencies: the midpoint, on a log scale, of the widest gap between
+// neighbouring values with at least 5% of the samples on either side. It
+// fails when there are too few samples or no gap of bimodalGap.
+func bimodalSplit(latencies []float64) (float64, bool) {
+	if len(latencies) < 10 {
+		return 0, false
+	}
+	sorted := append([]float64(nil), latencies...)
+	sort.Float64s(sorted)
+	minSide := max(2, len(sorted)/20)
+	best, at := 0.0, -1
+	for i := minSide - 1; i+1 <= len(sorted)-minSide; i++ {
+		if sorted[i] <= 0 {
+			continue
+		}
+		if gap := sorted[i+1] / sorted[i]; gap > best {
+			best, at = gap, i
+		}
+	}
+	if at < 0 || best < bimodalGap {
+		return 0, false
+	}
+	return math.Sqrt(sorted[at] * sorted[at+1]), true
+}
@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func readEvent(file string, d time.Duration, misses uint32) *events.Event {
+	return &events.Event{Type: events.EventRead, Target: file, LatencyNS: uint64(d), TCPState: misses}
+}
+
+func TestAnalyzePageCache_Misses(t *testing.T) {
+	evts := []*events.Event{
+		{Type: events.EventPageCache, Details: events.PageCacheCounts{Reads: 900, MissReads: 40, MissFolios: 160}.String()},
+		{Type: events.EventPageCache, Details: events.PageCacheCounts{Reads: 100, MissReads: 10, MissFolios: 40}.String()},
+		readEvent("data.db", 12*time.Millisecond, 4),
+		readEvent("data.db", 8*time.Millisecond, 2),
+		readEvent("data.db", 2*time.Millisecond, 0),
+		readEvent("index.db", 3*time.Millisecond, 1),
+		readEvent("config.yaml", 1*time.Millisecond, 0),
+	}
+	got := AnalyzePageCache(evts)
+	if got == nil {
+		t.Fatal("AnalyzePageCache returned nil")
+	}
+	if !got.HasCounters || got.Reads != 1000 || got.MissReads != 50 || got.MissFolios != 200 || got.HitRatio != 0.95 {
+		t.Errorf("counters = %+v", got)
+	}
+	if got.Method != ColdReadsByMisses || got.SlowReads != 5 || got.ColdSlowReads != 3 {
+		t.Errorf("classification = %s, %d of %d cold", got.Method, got.ColdSlowReads, got.SlowReads)
+	}
+	if len(got.Files) != 2 || got.Files[0].File != "data.db" || got.Files[0].ColdReads != 2 || got.Files[0].ColdLatencyMS != 20 {
+		t.Fatalf("files = %+v", got.Files)
+	}
+	if hr := got.Files[0].HitRatio; hr < 0.33 || hr > 0.34 {
+		t.Errorf("data.db hit ratio = %v", hr)
+	}
+}
+
+func TestAnalyzePageCache_LatencyBimodality(t *testing.T) {
+	var evts []*events.Event
+	for i := 0; i < 16; i++ {
+		evts = append(evts, readEvent("hot.log", time.Duration(1000+i*50)*time.Microsecond, 0))
+	}
+	for i := 0; i < 4; i++ {
+		evts = append(evts, readEvent("cold.bin", time.Duration(20+i)*time.Millisecond, 0))
+	}
+	got := AnalyzePageCache(evts)
+	if got == nil || got.Method != ColdReadsByLatency {
+		t.Fatalf("page cache = %+v", got)
+	}
+	if got.ColdThresholdMS < 1.75 || got.ColdThresholdMS > 20 || got.ColdSlowReads != 4 {
+		t.Errorf("threshold %.2fms, %d cold reads", got.ColdThresholdMS, got.ColdSlowReads)
+	}
+	if len(got.Files) != 1 || got.Files[0].File != "cold.bin" || got.Files[0].HitRatio != 0 {
+		t.Errorf("files = %+v", got.Files)
+	}
+	if got.HasCounters {
+		t.Error("no EventPageCache, yet HasCounters")
+	}
+}
+
+func TestAnalyzePageCache_Unimodal(t *testing.T) {
+	var evts []*events.Event
+	for i := 0; i < 20; i++ {
+		evts = append(evts, readEvent("a", time.Duration(1+i%3)*time.Millisecond, 0))
+	}
+	got := AnalyzePageCache(evts)
+	if got == nil || got.Method != "" || got.ColdSlowReads != 0 || len(got.Files) != 0 {
+		t.Errorf("page cache = %+v", got)
+	}
+	if AnalyzePageCache([]*events.Event{{Type: events.EventWrite}}) != nil {
+		t.Error("expected nil without reads")
+	}
+}
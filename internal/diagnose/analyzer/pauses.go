@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// Pause is one interval in which a traced container was frozen or its
+// main process stopped: the container ran nothing, so the gap it left in
+// the trace is neither the network nor the disk.
+type Pause struct {
+	Container string `json:"container"`
+	Kind      string `json:"kind"`
+	PID       uint32 `json:"pid,omitempty"`
+	// Start and End bound the pause within the trace window; it is found
+	// at most PODTRACE_PAUSE_INTERVAL after it starts or ends.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Ongoing marks a pause still in effect when the trace ended.
+	Ongoing bool `json:"ongoing,omitempty"`
+}
+
+// Duration is how long the container was paused.
+func (p Pause) Duration() time.Duration { return p.End.Sub(p.Start) }
+
+// Overlaps reports whether the pause covers part of [from, to).
+func (p Pause) Overlaps(from, to time.Time) bool {
+	return p.Start.Before(to) && p.End.After(from)
+}
+
+// AnalyzePauses pairs the start and end EventPause of each container into
+// pauses, clipped to the trace window, in start order.
+func AnalyzePauses(evs []*events.Event, start, end time.Time) []Pause {
+	var marks []*events.Event
+	for _, e := range evs {
+		if e != nil && e.Type == events.EventPause {
+			marks = append(marks, e)
+		}
+	}
+	if len(marks) == 0 {
+		return nil
+	}
+	sort.SliceStable(marks, func(i, j int) bool { return marks[i].Timestamp < marks[j].Timestamp })
+
+	open := make(map[string]*Pause)
+	var out []Pause
+	closeAt := func(p *Pause, at time.Time) {
+		if at.After(end) {
+			at = end
+		}
+		p.End = at
+		if p.End.After(p.Start) {
+			out = append(out, *p)
+		}
+	}
+	for _, m := range marks {
+		d := events.ParsePauseDetails(m.Details)
+		at := m.TimestampTime()
+		switch d.State {
+		case events.PauseStart:
+			if p := open[m.Target]; p != nil {
+				closeAt(p, at)
+			}
+			p := &Pause{Container: m.Target, Kind: d.Kind, PID: m.PID, Start: at}
+			if p.Start.Before(start) {
+				p.Start = start
+			}
+			open[m.Target] = p
+		case events.PauseEnd:
+			if p := open[m.Target]; p != nil {
+				closeAt(p, at)
+				delete(open, m.Target)
+			}
+		}
+	}
+	for _, p := range open {
+		p.Ongoing = true
+		closeAt(p, end)
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].Start.Equal(out[j].Start) {
+			return out[i].Start.Before(out[j].Start)
+		}
+		return out[i].Container < out[j].Container
+	})
+	return out
+}
@@ -0,0 +1,44 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzePauses(t *testing.T) {
+	const base = uint64(1_000_000_000_000)
+	ts := func(d time.Duration) uint64 { return base + uint64(d) }
+	at := func(d time.Duration) time.Time { return (&events.Event{Timestamp: ts(d)}).TimestampTime() }
+	mark := func(d time.Duration, container, kind, state string) *events.Event {
+		return &events.Event{Type: events.EventPause, Timestamp: ts(d), PID: 7, Target: container,
+			Details: events.PauseDetails{Kind: kind, State: state}.String()}
+	}
+	evs := []*events.Event{
+		mark(30*time.Second, "shop/cart-0/app", events.PauseStop, events.PauseStart),
+		mark(10*time.Second, "shop/cart-0/app", events.PauseFreeze, events.PauseStart),
+		mark(15*time.Second, "shop/cart-0/app", events.PauseFreeze, events.PauseEnd),
+		mark(12*time.Second, "shop/cart-0/sidecar", events.PauseFreeze, events.PauseEnd),
+		{Type: events.EventTCPSend, Timestamp: ts(20 * time.Second)},
+	}
+	got := AnalyzePauses(evs, at(0), at(40*time.Second))
+	if len(got) != 2 {
+		t.Fatalf("pauses = %+v", got)
+	}
+	freeze := got[0]
+	if freeze.Kind != events.PauseFreeze || freeze.Container != "shop/cart-0/app" || freeze.PID != 7 ||
+		freeze.Duration() != 5*time.Second || freeze.Ongoing {
+		t.Errorf("freeze = %+v", freeze)
+	}
+	if !freeze.Overlaps(at(14*time.Second), at(20*time.Second)) || freeze.Overlaps(at(15*time.Second), at(20*time.Second)) {
+		t.Error("freeze overlap is off")
+	}
+	stop := got[1]
+	if stop.Kind != events.PauseStop || !stop.Ongoing || stop.Duration() != 10*time.Second {
+		t.Errorf("stop = %+v", stop)
+	}
+	if AnalyzePauses(evs[4:], at(0), at(time.Minute)) != nil {
+		t.Error("expected nil without pause marks")
+	}
+}
@@ -0,0 +1,128 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/numa"
+)
+
+// ProcessPlacement summarizes the EventCPUPlacement samples of one process.
+type ProcessPlacement struct {
+	PID      uint32 `json:"pid"`
+	Process  string `json:"process,omitempty"`
+	CPUs     string `json:"cpus"`
+	CPUNodes string `json:"cpu_nodes"`
+	Mems     string `json:"mems"`
+	Pinned   bool   `json:"pinned"`
+	// SharedCores are pinned CPUs whose core has a hardware thread other
+	// workloads may run on.
+	SharedCores string `json:"shared_cores,omitempty"`
+	Samples     int    `json:"samples"`
+	// MemoryBytes and RemoteMemoryBytes are from the sample with the
+	// largest RemoteMemoryShare: the resident memory, and the part of it on
+	// NUMA nodes none of the process's CPUs belong to.
+	MemoryBytes       uint64  `json:"memory_bytes"`
+	RemoteMemoryBytes uint64  `json:"remote_memory_bytes"`
+	RemoteMemoryShare float64 `json:"remote_memory_share"`
+	// RunQueueShare is the share of the sampled time the process waited
+	// for a CPU; PeakRunQueueShare is its worst interval.
+	RunQueueShare     float64 `json:"run_queue_share"`
+	PeakRunQueueShare float64 `json:"peak_run_queue_share"`
+	// RemoteMemoryIntervals and RunQueueIntervals count the samples past
+	// PODTRACE_NUMA_REMOTE_MEMORY_WARN and PODTRACE_RUNQUEUE_WAIT_WARN.
+	RemoteMemoryIntervals int `json:"remote_memory_intervals"`
+	RunQueueIntervals     int `json:"run_queue_intervals"`
+}
+
+// CPUPlacement summarizes where the traced processes ran and where their
+// memory lived.
+type CPUPlacement struct {
+	Processes []ProcessPlacement `json:"processes"`
+	// NodeRemoteAllocShare is the node-wide share of page allocations
+	// served from another NUMA node than the allocating task's; only set
+	// when HasNodeAllocations.
+	NodeRemoteAllocShare float64 `json:"node_remote_alloc_share"`
+	HasNodeAllocations   bool    `json:"has_node_allocations"`
+}
+
+// AnalyzeCPUPlacement summarizes the EventCPUPlacement samples per process,
+// most remote memory first. It returns nil when there are none.
+func AnalyzeCPUPlacement(evts []*events.Event) *CPUPlacement {
+	type acc struct {
+		ProcessPlacement
+		waited, sampled uint64
+	}
+	procs := make(map[uint32]*acc)
+	var local, remote uint64
+	out := &CPUPlacement{}
+	seenTS := make(map[uint64]bool)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventCPUPlacement {
+			continue
+		}
+		s := numa.ParseDetails(e.Details)
+		p := procs[e.PID]
+		if p == nil {
+			p = &acc{ProcessPlacement: ProcessPlacement{PID: e.PID}}
+			procs[e.PID] = p
+		}
+		// The latest sample describes the current placement.
+		p.Process = e.ProcessName
+		p.CPUs, p.CPUNodes, p.Mems = s.CPUs.String(), s.CPUNodes.String(), s.Mems.String()
+		p.Pinned, p.SharedCores = s.Pinned, s.SharedCores.String()
+		p.Samples++
+
+		if total := s.TotalMemory(); total > 0 {
+			share := float64(s.RemoteMemory()) / float64(total)
+			if share >= p.RemoteMemoryShare {
+				p.RemoteMemoryShare, p.RemoteMemoryBytes, p.MemoryBytes = share, s.RemoteMemory(), total
+			}
+			if share >= config.NUMARemoteMemoryWarn {
+				p.RemoteMemoryIntervals++
+			}
+		}
+		if s.IntervalNS > 0 {
+			p.waited += s.RunQueueNS
+			p.sampled += s.IntervalNS
+			share := float64(s.RunQueueNS) / float64(s.IntervalNS)
+			if share > p.PeakRunQueueShare {
+				p.PeakRunQueueShare = share
+			}
+			if share >= config.RunQueueWaitWarn {
+				p.RunQueueIntervals++
+			}
+		}
+		// Node counters are the same for every process sampled together.
+		if s.HasAllocations && !seenTS[e.Timestamp] {
+			seenTS[e.Timestamp] = true
+			local += s.Allocations.Local
+			remote += s.Allocations.Remote
+			out.HasNodeAllocations = true
+		}
+	}
+	if len(procs) == 0 {
+		return nil
+	}
+	if local+remote > 0 {
+		out.NodeRemoteAllocShare = float64(remote) / float64(local+remote)
+	}
+	for _, p := range procs {
+		if p.sampled > 0 {
+			p.RunQueueShare = float64(p.waited) / float64(p.sampled)
+		}
+		out.Processes = append(out.Processes, p.ProcessPlacement)
+	}
+	sort.Slice(out.Processes, func(i, j int) bool {
+		a, b := out.Processes[i], out.Processes[j]
+		if a.RemoteMemoryShare != b.RemoteMemoryShare {
+			return a.RemoteMemoryShare > b.RemoteMemoryShare
+		}
+		if a.RunQueueShare != b.RunQueueShare {
+			return a.RunQueueShare > b.RunQueueShare
+		}
+		return a.PID < b.PID
+	})
+	return out
+}
@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/numa"
+)
+
+func placementEvent(ts uint64, pid uint32, remote, local uint64, runq time.Duration) *events.Event {
+	s := numa.Sample{
+		Placement: numa.Placement{
+			CPUs: numa.CPUSet{2, 3}, CPUNodes: numa.CPUSet{1}, Mems: numa.CPUSet{0, 1}, Pinned: true,
+			SharedCores: numa.CPUSet{2, 3}, MemoryByNode: map[int]uint64{0: remote, 1: local}, LastCPU: 2,
+		},
+		IntervalNS:     uint64(10 * time.Second),
+		RunQueueNS:     uint64(runq),
+		Allocations:    numa.NodeAllocations{Local: 75, Remote: 25},
+		HasAllocations: true,
+	}
+	return &events.Event{Type: events.EventCPUPlacement, Timestamp: ts, PID: pid, ProcessName: "app",
+		LatencyNS: uint64(runq), Target: "2-3", Details: numa.FormatDetails(s)}
+}
+
+func TestAnalyzeCPUPlacement(t *testing.T) {
+	evts := []*events.Event{
+		placementEvent(1, 42, 600, 400, 3*time.Second),
+		placementEvent(1, 7, 0, 1000, 0),
+		placementEvent(2, 42, 100, 900, time.Second),
+		{Type: events.EventSchedSwitch},
+	}
+	got := AnalyzeCPUPlacement(evts)
+	if got == nil || len(got.Processes) != 2 {
+		t.Fatalf("placement = %+v", got)
+	}
+	p := got.Processes[0]
+	if p.PID != 42 || p.CPUs != "2-3" || p.CPUNodes != "1" || !p.Pinned || p.SharedCores != "2-3" || p.Samples != 2 {
+		t.Errorf("process = %+v", p)
+	}
+	if p.RemoteMemoryShare != 0.6 || p.RemoteMemoryBytes != 600 || p.RemoteMemoryIntervals != 1 {
+		t.Errorf("remote memory = %v (%d bytes, %d intervals)", p.RemoteMemoryShare, p.RemoteMemoryBytes, p.RemoteMemoryIntervals)
+	}
+	if p.RunQueueShare != 0.2 || p.PeakRunQueueShare != 0.3 || p.RunQueueIntervals != 2 {
+		t.Errorf("run queue = %v peak %v (%d intervals)", p.RunQueueShare, p.PeakRunQueueShare, p.RunQueueIntervals)
+	}
+	// The node counters of timestamp 1 are counted once for both processes.
+	if !got.HasNodeAllocations || got.NodeRemoteAllocShare != 0.25 {
+		t.Errorf("node allocations = %v, %v", got.NodeRemoteAllocShare, got.HasNodeAllocations)
+	}
+	if AnalyzeCPUPlacement(evts[3:]) != nil {
+		t.Error("expected nil without placement events")
+	}
+}
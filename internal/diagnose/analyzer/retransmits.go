@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// TargetRetransmits are the retransmits to one remote endpoint.
+type TargetRetransmits struct {
+	Target string `json:"target"`
+	SYN    int    `json:"syn"`
+	Data   int    `json:"data"`
+}
+
+// Retransmits splits the TCP retransmits of a trace into SYN retransmits,
+// connections that are not getting set up, and data retransmits, loss on
+// established connections. The two call for different fixes: the first
+// for a peer that is down, unreachable or dropping SYNs (firewall, full
+// accept queue), the second for the path.
+type Retransmits struct {
+	SYN  int `json:"syn"`
+	Data int `json:"data"`
+	// Targets lists the endpoints with the most retransmits first.
+	Targets []TargetRetransmits `json:"targets"`
+}
+
+// AnalyzeRetransmits counts the EventTCPRetrans events in evts by kind and
+// remote endpoint. It returns nil when there are none.
+func AnalyzeRetransmits(evts []*events.Event) *Retransmits {
+	out := &Retransmits{}
+	byTarget := make(map[string]*TargetRetransmits)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventTCPRetrans {
+			continue
+		}
+		t := byTarget[e.Target]
+		if t == nil {
+			t = &TargetRetransmits{Target: e.Target}
+			byTarget[e.Target] = t
+		}
+		if e.IsSYNRetrans() {
+			out.SYN++
+			t.SYN++
+		} else {
+			out.Data++
+			t.Data++
+		}
+	}
+	if out.SYN+out.Data == 0 {
+		return nil
+	}
+	for _, t := range byTarget {
+		if t.Target == "" {
+			continue
+		}
+		out.Targets = append(out.Targets, *t)
+	}
+	sort.Slice(out.Targets, func(i, j int) bool {
+		a, b := out.Targets[i], out.Targets[j]
+		if a.SYN+a.Data != b.SYN+b.Data {
+			return a.SYN+a.Data > b.SYN+b.Data
+		}
+		return a.Target < b.Target
+	})
+	if len(out.Targets) > config.TopTargetsLimit {
+		out.Targets = out.Targets[:config.TopTargetsLimit]
+	}
+	return out
+}
@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeRetransmits(t *testing.T) {
+	retrans := func(target string, state uint32) *events.Event {
+		return &events.Event{Type: events.EventTCPRetrans, Target: target, TCPState: state}
+	}
+	evts := []*events.Event{
+		retrans("10.0.0.5:5432", 2),
+		retrans("10.0.0.5:5432", 2),
+		retrans("10.0.0.5:5432", 2),
+		retrans("10.0.0.9:443", 1),
+		retrans("10.0.0.9:443", 8),
+		retrans("10.0.0.3:41000", 3),
+		retrans("", 0),
+		{Type: events.EventTCPSend, Target: "10.0.0.9:443"},
+	}
+	got := AnalyzeRetransmits(evts)
+	if got == nil {
+		t.Fatal("AnalyzeRetransmits returned nil")
+	}
+	if got.SYN != 4 || got.Data != 3 {
+		t.Errorf("SYN = %d, data = %d, want 4 and 3", got.SYN, got.Data)
+	}
+	want := []TargetRetransmits{
+		{Target: "10.0.0.5:5432", SYN: 3},
+		{Target: "10.0.0.9:443", Data: 2},
+		{Target: "10.0.0.3:41000", SYN: 1},
+	}
+	if len(got.Targets) != len(want) {
+		t.Fatalf("targets = %+v", got.Targets)
+	}
+	for i := range want {
+		if got.Targets[i] != want[i] {
+			t.Errorf("targets[%d] = %+v, want %+v", i, got.Targets[i], want[i])
+		}
+	}
+
+	if r := AnalyzeRetransmits([]*events.Event{{Type: events.EventTCPSend}}); r != nil {
+		t.Errorf("AnalyzeRetransmits without retransmits = %+v, want nil", r)
+	}
+}
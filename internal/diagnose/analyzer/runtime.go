@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ContainerStart is one container start recorded by the runtime.
+type ContainerStart struct {
+	Container string    `json:"container"`
+	Image     string    `json:"image,omitempty"`
+	At        time.Time `json:"at"`
+	// StartMS is the time from the container's creation to its start, or to
+	// its exit when it failed to start.
+	StartMS float64 `json:"start_ms"`
+	Failed  bool    `json:"failed"`
+	Reason  string  `json:"reason,omitempty"`
+}
+
+// RuntimeCall summarizes podtrace's own calls of one CRI method.
+type RuntimeCall struct {
+	Name     string  `json:"name"`
+	Calls    int     `json:"calls"`
+	Failures int     `json:"failures"`
+	AvgMS    float64 `json:"avg_ms"`
+	MaxMS    float64 `json:"max_ms"`
+}
+
+// RuntimeOperations summarizes the container runtime operations on the
+// traced pods.
+type RuntimeOperations struct {
+	Starts []ContainerStart `json:"starts,omitempty"`
+	Calls  []RuntimeCall    `json:"calls,omitempty"`
+}
+
+// AnalyzeRuntimeOperations summarizes the EventCRIOp events in evts: the
+// container starts in time order and the timed calls by name. It returns nil
+// when there are none.
+func AnalyzeRuntimeOperations(evts []*events.Event) *RuntimeOperations {
+	out := &RuntimeOperations{}
+	calls := make(map[string]*RuntimeCall)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventCRIOp {
+			continue
+		}
+		ms := float64(e.LatencyNS) / float64(time.Millisecond)
+		d := events.ParseCRIOpDetails(e.Details)
+		if e.Target == events.CRIOpStartContainer {
+			out.Starts = append(out.Starts, ContainerStart{
+				Container: d.Container,
+				Image:     d.Image,
+				At:        e.TimestampTime(),
+				StartMS:   ms,
+				Failed:    e.Error != 0,
+				Reason:    d.Reason,
+			})
+			continue
+		}
+		c := calls[e.Target]
+		if c == nil {
+			c = &RuntimeCall{Name: e.Target}
+			calls[e.Target] = c
+		}
+		c.Calls++
+		if e.Error != 0 {
+			c.Failures++
+		}
+		c.AvgMS += ms
+		c.MaxMS = max(c.MaxMS, ms)
+	}
+	if len(out.Starts) == 0 && len(calls) == 0 {
+		return nil
+	}
+	sort.SliceStable(out.Starts, func(i, j int) bool { return out.Starts[i].At.Before(out.Starts[j].At) })
+	for _, c := range calls {
+		c.AvgMS /= float64(c.Calls)
+		out.Calls = append(out.Calls, *c)
+	}
+	sort.Slice(out.Calls, func(i, j int) bool { return out.Calls[i].Name < out.Calls[j].Name })
+	return out
+}
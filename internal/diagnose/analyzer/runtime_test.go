@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func criEvent(name string, ts uint64, latency time.Duration, failed bool, d events.CRIOpDetails) *events.Event {
+	e := &events.Event{Type: events.EventCRIOp, Timestamp: ts, Target: name, LatencyNS: uint64(latency), Details: d.String()}
+	if failed {
+		e.Error = 1
+	}
+	return e
+}
+
+func TestAnalyzeRuntimeOperations(t *testing.T) {
+	evts := []*events.Event{
+		criEvent(events.CRIOpStartContainer, 200, 3*time.Second, false, events.CRIOpDetails{Container: "app", Image: "app:1.0"}),
+		criEvent(events.CRIOpStartContainer, 100, time.Second, true, events.CRIOpDetails{Container: "init", Reason: "StartError"}),
+		criEvent(events.CRIOpContainerStatus, 300, 2*time.Millisecond, false, events.CRIOpDetails{Container: "app"}),
+		criEvent(events.CRIOpContainerStatus, 400, 6*time.Millisecond, false, events.CRIOpDetails{Container: "app"}),
+		criEvent(events.CRIOpListContainers, 300, 4*time.Millisecond, true, events.CRIOpDetails{Reason: "Unavailable"}),
+		{Type: events.EventExec},
+	}
+	got := AnalyzeRuntimeOperations(evts)
+	if got == nil || len(got.Starts) != 2 || len(got.Calls) != 2 {
+		t.Fatalf("runtime = %+v", got)
+	}
+	if s := got.Starts[0]; s.Container != "init" || !s.Failed || s.Reason != "StartError" || s.StartMS != 1000 {
+		t.Errorf("first start = %+v", s)
+	}
+	if s := got.Starts[1]; s.Container != "app" || s.Image != "app:1.0" || s.StartMS != 3000 {
+		t.Errorf("second start = %+v", s)
+	}
+	if c := got.Calls[0]; c.Name != events.CRIOpContainerStatus || c.Calls != 2 || c.AvgMS != 4 || c.MaxMS != 6 {
+		t.Errorf("status calls = %+v", c)
+	}
+	if c := got.Calls[1]; c.Name != events.CRIOpListContainers || c.Failures != 1 {
+		t.Errorf("list calls = %+v", c)
+	}
+	if AnalyzeRuntimeOperations(nil) != nil {
+		t.Error("expected nil without runtime operations")
+	}
+}
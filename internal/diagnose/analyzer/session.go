@@ -0,0 +1,211 @@
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/stream"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// Sizes of the session-wide summaries. Together they bound SessionStream to
+// about 32 KiB for target counts plus, per event type, a latency digest of
+// at most ~2x sessionLatencyCompression centroids (16 bytes each) and
+// sessionRecentErrors retained events.
+const (
+	sessionSketchWidth        = 2048
+	sessionSketchDepth        = 4
+	sessionTopTargets         = 20
+	sessionLatencyCompression = 100
+	sessionRecentErrors       = 8
+)
+
+// TypeTotals are the session-wide totals of one event type.
+type TypeTotals struct {
+	Type   string  `json:"type"`
+	Events int     `json:"events"`
+	Errors int     `json:"errors"`
+	AvgMS  float64 `json:"avg_ms"`
+	P50MS  float64 `json:"p50_ms"`
+	P95MS  float64 `json:"p95_ms"`
+	P99MS  float64 `json:"p99_ms"`
+	MaxMS  float64 `json:"max_ms"`
+}
+
+// RecentError is one of the last failed events of the session.
+type RecentError struct {
+	Type   string `json:"type"`
+	Target string `json:"target,omitempty"`
+	Error  int32  `json:"error"`
+}
+
+// SessionTotals summarize every event of a session, including those the
+// diagnostician no longer keeps.
+type SessionTotals struct {
+	Events       int              `json:"events"`
+	ByType       []TypeTotals     `json:"by_type"`
+	TopTargets   []stream.Counted `json:"top_targets,omitempty"`
+	RecentErrors []RecentError    `json:"recent_errors,omitempty"`
+}
+
+type typeStream struct {
+	label   string
+	events  int
+	errors  int
+	sumMS   float64
+	latency *stream.TDigest
+}
+
+func (ts *typeStream) totals() TypeTotals {
+	out := TypeTotals{
+		Type:   ts.label,
+		Events: ts.events,
+		Errors: ts.errors,
+		P50MS:  ts.latency.Quantile(0.50),
+		P95MS:  ts.latency.Quantile(0.95),
+		P99MS:  ts.latency.Quantile(0.99),
+		MaxMS:  ts.latency.Max(),
+	}
+	if n := ts.latency.Count(); n > 0 {
+		out.AvgMS = ts.sumMS / float64(n)
+	}
+	return out
+}
+
+// merge adds the counts and latencies of o.
+func (ts *typeStream) merge(o *typeStream) {
+	if o == nil {
+		return
+	}
+	if ts.label == "" {
+		ts.label = o.label
+	}
+	ts.events += o.events
+	ts.errors += o.errors
+	ts.sumMS += o.sumMS
+	ts.latency.Merge(o.latency)
+}
+
+func newTypeStream(label string) *typeStream {
+	return &typeStream{label: label, latency: stream.NewTDigest(sessionLatencyCompression)}
+}
+
+// SessionStream folds every event of a session into fixed-size summaries:
+// a t-digest of latencies per event type, a count-min sketch of the busiest
+// targets and a ring of the last errors. Its memory does not grow with the
+// number of events. Suppressed events count toward the totals but not
+// toward Group, which stands in for the kept events in report sections.
+type SessionStream struct {
+	events     int
+	byType     map[events.EventType]*typeStream
+	suppressed map[events.EventType]*typeStream
+	targets    *stream.TopK
+	errors     *stream.Ring[RecentError]
+}
+
+// NewSessionStream returns an empty session summary.
+func NewSessionStream() *SessionStream {
+	return &SessionStream{
+		byType:     make(map[events.EventType]*typeStream),
+		suppressed: make(map[events.EventType]*typeStream),
+		targets:    stream.NewTopK(sessionTopTargets, sessionSketchWidth, sessionSketchDepth),
+		errors:     stream.NewRing[RecentError](sessionRecentErrors),
+	}
+}
+
+// Add folds one event into the summaries.
+func (s *SessionStream) Add(e *events.Event) {
+	s.add(s.byType, e)
+}
+
+// AddSuppressed folds one suppressed event into the summaries.
+func (s *SessionStream) AddSuppressed(e *events.Event) {
+	s.add(s.suppressed, e)
+}
+
+func (s *SessionStream) add(byType map[events.EventType]*typeStream, e *events.Event) {
+	if e == nil {
+		return
+	}
+	s.events++
+	ts := byType[e.Type]
+	if ts == nil {
+		ts = newTypeStream(e.TypeString())
+		byType[e.Type] = ts
+	}
+	ts.events++
+	ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+	ts.sumMS += ms
+	ts.latency.Add(ms)
+	if e.Target != "" {
+		s.targets.Add(e.Target)
+	}
+	if e.IsError() {
+		ts.errors++
+		s.errors.Push(RecentError{Type: ts.label, Target: e.Target, Error: e.Error})
+	}
+}
+
+// Events is the number of events added.
+func (s *SessionStream) Events() int {
+	return s.events
+}
+
+// Totals reads the summaries, with types ordered by event count.
+func (s *SessionStream) Totals() *SessionTotals {
+	out := &SessionTotals{
+		Events:       s.events,
+		TopTargets:   s.targets.Top(),
+		RecentErrors: s.errors.Items(),
+	}
+	all := make(map[events.EventType]*typeStream, len(s.byType))
+	for t, ts := range s.byType {
+		all[t] = ts
+	}
+	for t, ts := range s.suppressed {
+		if kept := s.byType[t]; kept != nil {
+			both := newTypeStream(kept.label)
+			both.merge(kept)
+			both.merge(ts)
+			ts = both
+		}
+		all[t] = ts
+	}
+	for _, ts := range all {
+		out.ByType = append(out.ByType, ts.totals())
+	}
+	sort.Slice(out.ByType, func(i, j int) bool {
+		if out.ByType[i].Events != out.ByType[j].Events {
+			return out.ByType[i].Events > out.ByType[j].Events
+		}
+		return out.ByType[i].Type < out.ByType[j].Type
+	})
+	return out
+}
+
+// Group combines the totals of the given event types, as one report section
+// sees them (e.g. TCP send and receive). Type lists the labels seen.
+func (s *SessionStream) Group(types ...events.EventType) TypeTotals {
+	group := newTypeStream("")
+	var labels []string
+	for _, t := range types {
+		ts := s.byType[t]
+		if ts == nil {
+			continue
+		}
+		labels = append(labels, ts.label)
+		group.merge(ts)
+	}
+	group.label = strings.Join(labels, "+")
+	return group.totals()
+}
+
+// String renders a recent error for the report.
+func (r RecentError) String() string {
+	if r.Target == "" {
+		return fmt.Sprintf("%s error %d", r.Type, r.Error)
+	}
+	return fmt.Sprintf("%s %s error %d", r.Type, r.Target, r.Error)
+}
@@ -0,0 +1,63 @@
+package analyzer
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestSessionStream(t *testing.T) {
+	s := NewSessionStream()
+	for i := 1; i <= 1000; i++ {
+		s.Add(&events.Event{Type: events.EventDNS, Target: fmt.Sprintf("svc-%d.local", i%3), LatencyNS: uint64(i) * 100_000})
+	}
+	for i := 0; i < 20; i++ {
+		s.Add(&events.Event{Type: events.EventConnect, Target: "10.0.0.9:443", Error: 111})
+	}
+	s.Add(nil)
+
+	got := s.Totals()
+	if got.Events != 1020 || s.Events() != 1020 {
+		t.Fatalf("events = %d", got.Events)
+	}
+	dns := got.ByType[0]
+	if dns.Type != "DNS" || dns.Events != 1000 || dns.MaxMS != 100 {
+		t.Errorf("dns = %+v", dns)
+	}
+	if dns.P50MS < 48 || dns.P50MS > 52 || dns.P99MS < 98 || dns.P99MS > 100 {
+		t.Errorf("dns quantiles = %+v", dns)
+	}
+	if got.ByType[1].Errors != 20 {
+		t.Errorf("connect = %+v", got.ByType[1])
+	}
+	if len(got.TopTargets) != 4 || got.TopTargets[0].Count < 333 {
+		t.Errorf("targets = %+v", got.TopTargets)
+	}
+	if len(got.RecentErrors) != sessionRecentErrors || got.RecentErrors[0].String() != "NET 10.0.0.9:443 error 111" {
+		t.Errorf("recent errors = %+v", got.RecentErrors)
+	}
+}
+
+func TestSessionStreamGroup(t *testing.T) {
+	s := NewSessionStream()
+	for i := 1; i <= 100; i++ {
+		s.Add(&events.Event{Type: events.EventTCPSend, LatencyNS: uint64(i) * 1_000_000})
+		s.Add(&events.Event{Type: events.EventTCPRecv, LatencyNS: uint64(i) * 2_000_000})
+	}
+	s.AddSuppressed(&events.Event{Type: events.EventTCPSend, LatencyNS: 900_000_000, Error: -11})
+
+	g := s.Group(events.EventTCPSend, events.EventTCPRecv, events.EventDNS)
+	if g.Events != 200 || g.Errors != 0 || g.MaxMS != 200 || g.AvgMS != 75.75 {
+		t.Errorf("group = %+v", g)
+	}
+	if g.P50MS < 60 || g.P50MS > 70 {
+		t.Errorf("group p50 = %.2f", g.P50MS)
+	}
+	if tot := s.Totals(); tot.Events != 201 || tot.ByType[0].Events != 101 || tot.ByType[0].MaxMS != 900 {
+		t.Errorf("totals leave out the suppressed event: %+v", tot.ByType)
+	}
+	if empty := s.Group(events.EventDNS); empty.Events != 0 || empty.P99MS != 0 {
+		t.Errorf("group of unseen type = %+v", empty)
+	}
+}
@@ -0,0 +1,68 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+// CgroupSwap summarizes the EventSwap samples of one cgroup.
+type CgroupSwap struct {
+	Cgroup  string `json:"cgroup"`
+	Samples int    `json:"samples"`
+	// ActiveIntervals counts the samples in which pages moved to or from
+	// swap or zswap.
+	ActiveIntervals int    `json:"active_intervals"`
+	SwapInBytes     uint64 `json:"swap_in_bytes"`
+	SwapOutBytes    uint64 `json:"swap_out_bytes"`
+	ZswapInBytes    uint64 `json:"zswap_in_bytes"`
+	ZswapOutBytes   uint64 `json:"zswap_out_bytes"`
+	PeakSwapBytes   uint64 `json:"peak_swap_bytes"`
+	PeakZswapBytes  uint64 `json:"peak_zswap_bytes"`
+	// MemoryLimitBytes is the cgroup's memory limit, 0 when unlimited.
+	MemoryLimitBytes uint64 `json:"memory_limit_bytes"`
+}
+
+// AnalyzeSwap summarizes the EventSwap samples in evts per cgroup, most
+// swapped-out first. It returns nil when there are none.
+func AnalyzeSwap(evts []*events.Event) []CgroupSwap {
+	byCgroup := make(map[string]*CgroupSwap)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventSwap {
+			continue
+		}
+		s := resource.ParseSwapDetails(e.Details)
+		c := byCgroup[e.Target]
+		if c == nil {
+			c = &CgroupSwap{Cgroup: e.Target}
+			byCgroup[e.Target] = c
+		}
+		c.Samples++
+		if s.Active() {
+			c.ActiveIntervals++
+		}
+		c.SwapInBytes += s.SwapInBytes
+		c.SwapOutBytes += s.SwapOutBytes
+		c.ZswapInBytes += s.ZswapInBytes
+		c.ZswapOutBytes += s.ZswapOutBytes
+		c.PeakSwapBytes = max(c.PeakSwapBytes, s.SwapBytes)
+		c.PeakZswapBytes = max(c.PeakZswapBytes, s.ZswapBytes)
+		c.MemoryLimitBytes = s.MemoryLimitBytes
+	}
+	if len(byCgroup) == 0 {
+		return nil
+	}
+	out := make([]CgroupSwap, 0, len(byCgroup))
+	for _, c := range byCgroup {
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.SwapOutBytes+a.ZswapOutBytes != b.SwapOutBytes+b.ZswapOutBytes {
+			return a.SwapOutBytes+a.ZswapOutBytes > b.SwapOutBytes+b.ZswapOutBytes
+		}
+		return a.Cgroup < b.Cgroup
+	})
+	return out
+}
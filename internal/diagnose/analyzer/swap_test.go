@@ -0,0 +1,42 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+func swapEvent(cgroup string, s resource.SwapSample) *events.Event {
+	return &events.Event{Type: events.EventSwap, Target: cgroup, Bytes: s.SwapBytes, Details: resource.FormatSwapDetails(s)}
+}
+
+func TestAnalyzeSwap(t *testing.T) {
+	evts := []*events.Event{
+		swapEvent("/a", resource.SwapSample{SwapOutBytes: 4096, SwapBytes: 4096, MemoryLimitBytes: 1 << 30}),
+		swapEvent("/a", resource.SwapSample{SwapInBytes: 8192, ZswapOutBytes: 4096, SwapBytes: 12288, ZswapBytes: 2048, MemoryLimitBytes: 1 << 30}),
+		swapEvent("/a", resource.SwapSample{SwapBytes: 12288, MemoryLimitBytes: 1 << 30}),
+		swapEvent("/b", resource.SwapSample{SwapBytes: 4096}),
+		{Type: events.EventResourceLimit, Target: "/a"},
+	}
+	got := AnalyzeSwap(evts)
+	if len(got) != 2 {
+		t.Fatalf("swap = %+v", got)
+	}
+	a := got[0]
+	if a.Cgroup != "/a" || a.Samples != 3 || a.ActiveIntervals != 2 {
+		t.Errorf("cgroup = %+v", a)
+	}
+	if a.SwapOutBytes != 4096 || a.SwapInBytes != 8192 || a.ZswapOutBytes != 4096 || a.PeakSwapBytes != 12288 || a.PeakZswapBytes != 2048 {
+		t.Errorf("traffic = %+v", a)
+	}
+	if a.MemoryLimitBytes != 1<<30 {
+		t.Errorf("limit = %d", a.MemoryLimitBytes)
+	}
+	if got[1].ActiveIntervals != 0 {
+		t.Errorf("idle cgroup = %+v", got[1])
+	}
+	if AnalyzeSwap(nil) != nil {
+		t.Error("expected nil without swap samples")
+	}
+}
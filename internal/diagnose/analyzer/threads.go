@@ -0,0 +1,77 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ThreadCPU is one thread's on-CPU time, from its EventThreadCPU samples,
+// and its off-CPU blocks, from its EventSchedSwitch events.
+type ThreadCPU struct {
+	TID     uint32  `json:"tid"`
+	PID     uint32  `json:"pid"`
+	Name    string  `json:"name,omitempty"`
+	Process string  `json:"process,omitempty"`
+	OnCPUMS float64 `json:"on_cpu_ms"`
+	// Blocks counts the switches after which the thread stayed off the
+	// CPU for over 1ms; BlockedMS is their total.
+	Blocks     int     `json:"blocks"`
+	BlockedMS  float64 `json:"blocked_ms"`
+	MaxBlockMS float64 `json:"max_block_ms"`
+}
+
+// AnalyzeThreads aggregates the EventThreadCPU and EventSchedSwitch events
+// in evts per thread, most on-CPU time first. Events without a thread ID,
+// from older kernels' programs, are skipped. It returns nil when there are
+// none.
+func AnalyzeThreads(evts []*events.Event) []ThreadCPU {
+	type key struct{ pid, tid uint32 }
+	threads := make(map[key]*ThreadCPU)
+	for _, e := range evts {
+		if e == nil || e.ThreadID() == 0 {
+			continue
+		}
+		k := key{e.PID, e.ThreadID()}
+		th := threads[k]
+		if th == nil {
+			th = &ThreadCPU{TID: k.tid, PID: k.pid}
+			threads[k] = th
+		}
+		// Threads rename themselves; the latest name wins.
+		if e.Target != "" {
+			th.Name = e.Target
+		}
+		if e.ProcessName != "" {
+			th.Process = e.ProcessName
+		}
+		ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+		switch e.Type {
+		case events.EventThreadCPU:
+			th.OnCPUMS += ms
+		case events.EventSchedSwitch:
+			th.Blocks++
+			th.BlockedMS += ms
+			th.MaxBlockMS = max(th.MaxBlockMS, ms)
+		}
+	}
+	if len(threads) == 0 {
+		return nil
+	}
+	out := make([]ThreadCPU, 0, len(threads))
+	for _, th := range threads {
+		out = append(out, *th)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.OnCPUMS != b.OnCPUMS {
+			return a.OnCPUMS > b.OnCPUMS
+		}
+		if a.BlockedMS != b.BlockedMS {
+			return a.BlockedMS > b.BlockedMS
+		}
+		return a.TID < b.TID
+	})
+	return out
+}
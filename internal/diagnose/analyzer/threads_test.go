@@ -0,0 +1,35 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeThreads(t *testing.T) {
+	evts := []*events.Event{
+		{Type: events.EventThreadCPU, PID: 10, TCPState: 11, LatencyNS: 400_000_000, Target: "worker-1", ProcessName: "app"},
+		{Type: events.EventThreadCPU, PID: 10, TCPState: 11, LatencyNS: 100_000_000, Target: "worker-1"},
+		{Type: events.EventThreadCPU, PID: 10, TCPState: 12, LatencyNS: 20_000_000, Target: "gc"},
+		{Type: events.EventSchedSwitch, PID: 10, TCPState: 12, LatencyNS: 30_000_000, Target: "gc"},
+		{Type: events.EventSchedSwitch, PID: 10, TCPState: 12, LatencyNS: 10_000_000, Target: "gc"},
+		// Without a thread ID the event only counts towards the process.
+		{Type: events.EventSchedSwitch, PID: 10, LatencyNS: 50_000_000},
+		{Type: events.EventRead, PID: 10, TCPState: 3},
+	}
+	got := AnalyzeThreads(evts)
+	if len(got) != 2 {
+		t.Fatalf("threads = %+v", got)
+	}
+	w := got[0]
+	if w.TID != 11 || w.PID != 10 || w.Name != "worker-1" || w.Process != "app" || w.OnCPUMS != 500 || w.Blocks != 0 {
+		t.Errorf("worker = %+v", w)
+	}
+	gc := got[1]
+	if gc.TID != 12 || gc.OnCPUMS != 20 || gc.Blocks != 2 || gc.BlockedMS != 40 || gc.MaxBlockMS != 30 {
+		t.Errorf("gc = %+v", gc)
+	}
+	if AnalyzeThreads(nil) != nil {
+		t.Error("expected nil without thread events")
+	}
+}
@@ -0,0 +1,225 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ThroughputClass is the traffic of one direction to or from one class of
+// remote address (cluster, node or external).
+type ThroughputClass struct {
+	Class   string `json:"class"`
+	Bytes   uint64 `json:"bytes"`
+	Packets uint64 `json:"packets"`
+}
+
+// ThroughputDirection is what the traced pods' veths carried in one
+// direction, seen from the pod.
+type ThroughputDirection struct {
+	Direction   string  `json:"direction"`
+	Bytes       uint64  `json:"bytes"`
+	Packets     uint64  `json:"packets"`
+	AvgBitsPerS float64 `json:"avg_bits_per_sec"`
+	// PeakBitsPerS is the busiest counter interval of a single pod.
+	PeakBitsPerS float64           `json:"peak_bits_per_sec"`
+	ByClass      []ThroughputClass `json:"by_class"`
+}
+
+// PodThroughput summarizes the veth counters of EventPodThroughput events.
+type PodThroughput struct {
+	Directions []ThroughputDirection `json:"directions"`
+}
+
+// AnalyzePodThroughput totals the veth counter events over the window
+// duration. It returns nil when there are none.
+func AnalyzePodThroughput(evts []*events.Event, duration time.Duration) *PodThroughput {
+	type sampleKey struct {
+		cgroup uint64
+		ts     uint64
+		dir    string
+	}
+	type sample struct {
+		bytes    uint64
+		interval uint64
+	}
+	dirs := make(map[string]*ThroughputDirection)
+	classes := make(map[string]map[string]*ThroughputClass)
+	samples := make(map[sampleKey]*sample)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventPodThroughput {
+			continue
+		}
+		d := dirs[e.Details]
+		if d == nil {
+			d = &ThroughputDirection{Direction: e.Details}
+			dirs[e.Details] = d
+			classes[e.Details] = make(map[string]*ThroughputClass)
+		}
+		d.Bytes += e.Bytes
+		d.Packets += e.ThroughputPackets()
+		c := classes[e.Details][e.Target]
+		if c == nil {
+			c = &ThroughputClass{Class: e.Target}
+			classes[e.Details][e.Target] = c
+		}
+		c.Bytes += e.Bytes
+		c.Packets += e.ThroughputPackets()
+
+		// One poll emits an event per class with the same timestamp; their
+		// sum is the pod's rate over that interval.
+		k := sampleKey{cgroup: e.CgroupID, ts: e.Timestamp, dir: e.Details}
+		s := samples[k]
+		if s == nil {
+			s = &sample{interval: e.LatencyNS}
+			samples[k] = s
+		}
+		s.bytes += e.Bytes
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+	for k, s := range samples {
+		if s.interval == 0 {
+			continue
+		}
+		bps := float64(s.bytes) * 8 / time.Duration(s.interval).Seconds()
+		if d := dirs[k.dir]; bps > d.PeakBitsPerS {
+			d.PeakBitsPerS = bps
+		}
+	}
+	out := &PodThroughput{}
+	for name, d := range dirs {
+		if duration > 0 {
+			d.AvgBitsPerS = float64(d.Bytes) * 8 / duration.Seconds()
+		}
+		for _, c := range classes[name] {
+			d.ByClass = append(d.ByClass, *c)
+		}
+		sort.Slice(d.ByClass, func(i, j int) bool {
+			if d.ByClass[i].Bytes != d.ByClass[j].Bytes {
+				return d.ByClass[i].Bytes > d.ByClass[j].Bytes
+			}
+			return d.ByClass[i].Class < d.ByClass[j].Class
+		})
+		out.Directions = append(out.Directions, *d)
+	}
+	sort.Slice(out.Directions, func(i, j int) bool {
+		return out.Directions[i].Direction < out.Directions[j].Direction
+	})
+	return out
+}
+
+// BandwidthLimit is the shaping a pod's bandwidth annotations request, in
+// bits per second; zero means unshaped.
+type BandwidthLimit struct {
+	IngressBitsPerS uint64
+	EgressBitsPerS  uint64
+}
+
+// Sources of the capacity a BandwidthSaturation was measured against.
+const (
+	BandwidthLimitAnnotation = "annotation"
+	BandwidthLimitNIC        = "nic"
+)
+
+// BandwidthSaturation is one direction of one pod whose throughput reached
+// its capacity: the pod's bandwidth annotation or, for unshaped traffic, the
+// node's link speed.
+type BandwidthSaturation struct {
+	Pod                string  `json:"pod,omitempty"`
+	Direction          string  `json:"direction"`
+	LimitBitsPerS      float64 `json:"limit_bits_per_sec"`
+	LimitSource        string  `json:"limit_source"`
+	PeakBitsPerS       float64 `json:"peak_bits_per_sec"`
+	Intervals          int     `json:"intervals"`
+	SaturatedIntervals int     `json:"saturated_intervals"`
+}
+
+// AnalyzeBandwidthSaturation compares every counter interval of
+// EventPodThroughput events with the capacity of the pod's direction and
+// returns the directions with at least one interval at or above threshold
+// (a share of the capacity), busiest first. limits is keyed by
+// "namespace/pod"; nicBitsPerS is the node's link speed.
+func AnalyzeBandwidthSaturation(evts []*events.Event, limits map[string]BandwidthLimit, nicBitsPerS uint64, threshold float64) []BandwidthSaturation {
+	type sampleKey struct {
+		pod    string
+		cgroup uint64
+		ts     uint64
+		dir    string
+	}
+	type sample struct {
+		bytes    uint64
+		interval uint64
+	}
+	samples := make(map[sampleKey]*sample)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventPodThroughput {
+			continue
+		}
+		var pod string
+		if e.K8s != nil && e.K8s.PodName != "" {
+			pod = e.K8s.Namespace + "/" + e.K8s.PodName
+		}
+		k := sampleKey{pod: pod, cgroup: e.CgroupID, ts: e.Timestamp, dir: e.Details}
+		s := samples[k]
+		if s == nil {
+			s = &sample{interval: e.LatencyNS}
+			samples[k] = s
+		}
+		s.bytes += e.Bytes
+	}
+
+	type satKey struct{ pod, dir string }
+	sats := make(map[satKey]*BandwidthSaturation)
+	for k, s := range samples {
+		if s.interval == 0 {
+			continue
+		}
+		limit, source := float64(nicBitsPerS), BandwidthLimitNIC
+		l := limits[k.pod]
+		annotated := l.IngressBitsPerS
+		if k.dir == events.ThroughputEgress {
+			annotated = l.EgressBitsPerS
+		}
+		if annotated > 0 && (limit == 0 || float64(annotated) < limit) {
+			limit, source = float64(annotated), BandwidthLimitAnnotation
+		}
+		if limit == 0 {
+			continue
+		}
+		sk := satKey{pod: k.pod, dir: k.dir}
+		sat := sats[sk]
+		if sat == nil {
+			sat = &BandwidthSaturation{Pod: k.pod, Direction: k.dir, LimitBitsPerS: limit, LimitSource: source}
+			sats[sk] = sat
+		}
+		bps := float64(s.bytes) * 8 / time.Duration(s.interval).Seconds()
+		sat.Intervals++
+		if bps >= limit*threshold {
+			sat.SaturatedIntervals++
+		}
+		if bps > sat.PeakBitsPerS {
+			sat.PeakBitsPerS = bps
+		}
+	}
+
+	var out []BandwidthSaturation
+	for _, sat := range sats {
+		if sat.SaturatedIntervals > 0 {
+			out = append(out, *sat)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ui, uj := out[i].PeakBitsPerS/out[i].LimitBitsPerS, out[j].PeakBitsPerS/out[j].LimitBitsPerS
+		if ui != uj {
+			return ui > uj
+		}
+		if out[i].Pod != out[j].Pod {
+			return out[i].Pod < out[j].Pod
+		}
+		return out[i].Direction < out[j].Direction
+	})
+	return out
+}
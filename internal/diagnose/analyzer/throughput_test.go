@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzePodThroughput(t *testing.T) {
+	interval := uint64(5 * time.Second)
+	ev := func(ts uint64, dir, class string, bytes, packets uint64) *events.Event {
+		e := &events.Event{Type: events.EventPodThroughput, Timestamp: ts, CgroupID: 7,
+			LatencyNS: interval, Details: dir, Target: class, Bytes: bytes}
+		e.SetThroughputPackets(packets)
+		return e
+	}
+	evts := []*events.Event{
+		ev(1, events.ThroughputEgress, events.ThroughputClassExternal, 5_000_000, 4000),
+		ev(1, events.ThroughputEgress, events.ThroughputClassCluster, 1_250_000, 1000),
+		ev(2, events.ThroughputEgress, events.ThroughputClassCluster, 1_250_000, 1000),
+		ev(2, events.ThroughputIngress, events.ThroughputClassCluster, 625_000, 500),
+		{Type: events.EventTCPSend, Bytes: 99},
+		nil,
+	}
+	got := AnalyzePodThroughput(evts, 10*time.Second)
+	if got == nil || len(got.Directions) != 2 {
+		t.Fatalf("directions = %+v", got)
+	}
+	egress := got.Directions[0]
+	if egress.Direction != events.ThroughputEgress || egress.Bytes != 7_500_000 || egress.Packets != 6000 {
+		t.Errorf("egress = %+v", egress)
+	}
+	// 6.25 MB in the first 5s interval = 10 Mbit/s; 7.5 MB over 10s = 6 Mbit/s.
+	if egress.PeakBitsPerS != 10_000_000 || egress.AvgBitsPerS != 6_000_000 {
+		t.Errorf("egress rates = avg %v, peak %v", egress.AvgBitsPerS, egress.PeakBitsPerS)
+	}
+	if len(egress.ByClass) != 2 || egress.ByClass[0].Class != events.ThroughputClassExternal || egress.ByClass[0].Bytes != 5_000_000 {
+		t.Errorf("egress classes = %+v", egress.ByClass)
+	}
+	if ingress := got.Directions[1]; ingress.Direction != events.ThroughputIngress || ingress.PeakBitsPerS != 1_000_000 {
+		t.Errorf("ingress = %+v", ingress)
+	}
+	if AnalyzePodThroughput(evts[4:], time.Second) != nil {
+		t.Error("expected nil without throughput events")
+	}
+}
+
+func TestAnalyzeBandwidthSaturation(t *testing.T) {
+	interval := uint64(time.Second)
+	ev := func(ts uint64, pod, dir string, bytes uint64) *events.Event {
+		return &events.Event{Type: events.EventPodThroughput, Timestamp: ts, CgroupID: 7,
+			LatencyNS: interval, Details: dir, Target: events.ThroughputClassCluster, Bytes: bytes,
+			K8s: &events.K8sMetadata{Namespace: "ns", PodName: pod}}
+	}
+	evts := []*events.Event{
+		// web is shaped to 10 Mbit/s ingress: 1.2 MB/s = 9.6 Mbit/s saturates at 90%.
+		ev(1, "web", events.ThroughputIngress, 1_200_000),
+		ev(2, "web", events.ThroughputIngress, 500_000),
+		// web egress is unshaped and far below the 1 Gbit/s NIC.
+		ev(1, "web", events.ThroughputEgress, 1_200_000),
+		// batch is unshaped and fills the NIC.
+		ev(1, "batch", events.ThroughputEgress, 125_000_000),
+	}
+	limits := map[string]BandwidthLimit{"ns/web": {IngressBitsPerS: 10_000_000}}
+	got := AnalyzeBandwidthSaturation(evts, limits, 1_000_000_000, 0.9)
+	if len(got) != 2 {
+		t.Fatalf("saturations = %+v", got)
+	}
+	if b := got[0]; b.Pod != "ns/batch" || b.Direction != events.ThroughputEgress ||
+		b.LimitSource != BandwidthLimitNIC || b.PeakBitsPerS != 1_000_000_000 || b.SaturatedIntervals != 1 {
+		t.Errorf("batch = %+v", b)
+	}
+	if w := got[1]; w.Pod != "ns/web" || w.Direction != events.ThroughputIngress ||
+		w.LimitSource != BandwidthLimitAnnotation || w.LimitBitsPerS != 10_000_000 ||
+		w.Intervals != 2 || w.SaturatedIntervals != 1 {
+		t.Errorf("web = %+v", w)
+	}
+	if got := AnalyzeBandwidthSaturation(evts, nil, 0, 0.9); got != nil {
+		t.Errorf("without any capacity: %+v", got)
+	}
+}
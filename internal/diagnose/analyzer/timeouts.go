@@ -0,0 +1,178 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// minClientTimeout is the shortest abandon time taken for a timeout; faster
+// failures are refusals and resets, which cluster just as tightly.
+const minClientTimeout = 50 * time.Millisecond
+
+// tcpFinWait1 is the state a socket enters when its own end closes it.
+const tcpFinWait1 = 4
+
+// OpRequest is the operation of a request the traced pod sent and closed
+// the connection on before any reply came.
+const OpRequest = "request"
+
+// timeoutOperations are the event types whose failures carry how long the
+// operation ran before it gave up, by the operation they are reported as.
+var timeoutOperations = map[events.EventType]string{
+	events.EventConnect:      "connect",
+	events.EventDNS:          "DNS lookup",
+	events.EventDBQuery:      "query",
+	events.EventRedisCmd:     "cache command",
+	events.EventMemcachedCmd: "cache command",
+	events.EventGRPCMethod:   "gRPC call",
+}
+
+// ClientTimeout is a timeout inferred for the traced pod's requests to one
+// target: many of them were abandoned after nearly the same time.
+type ClientTimeout struct {
+	Target    string `json:"target"`
+	Operation string `json:"operation"`
+	// TimeoutMS is the median abandon time of the cluster, and MinMS and
+	// MaxMS its spread.
+	TimeoutMS float64 `json:"timeout_ms"`
+	MinMS     float64 `json:"min_ms"`
+	MaxMS     float64 `json:"max_ms"`
+	// Hits are the requests abandoned at the timeout, out of Requests.
+	Hits     int `json:"hits"`
+	Requests int `json:"requests"`
+	// SuccessP99MS is the p99 latency of the requests that completed: near
+	// the timeout the server is slow for it, far below it the hits are
+	// stalls.
+	SuccessP99MS float64 `json:"success_p99_ms"`
+}
+
+// HitRate is the share of the requests that hit the timeout, in percent.
+func (c ClientTimeout) HitRate() float64 {
+	if c.Requests == 0 {
+		return 0
+	}
+	return float64(c.Hits) / float64(c.Requests) * 100
+}
+
+// AnalyzeClientTimeouts infers client timeouts from how long abandoned
+// requests ran. A request is abandoned when a connect, lookup, query or
+// call failed after running for a while, or when the traced pod closed a
+// connection it had sent on without reading a reply. Per target and
+// operation, the largest group of abandon times within
+// PODTRACE_CLIENT_TIMEOUT_TOLERANCE of each other is taken for a timeout
+// when it holds PODTRACE_CLIENT_TIMEOUT_MIN requests. Targets are returned
+// with the most hits first.
+func AnalyzeClientTimeouts(evts []*events.Event) []ClientTimeout {
+	type key struct{ target, op string }
+	type group struct {
+		requests  int
+		abandoned []float64
+		completed []float64
+	}
+	groups := make(map[key]*group)
+	groupOf := func(k key) *group {
+		g := groups[k]
+		if g == nil {
+			g = &group{}
+			groups[k] = g
+		}
+		return g
+	}
+
+	sorted := make([]*events.Event, 0, len(evts))
+	for _, e := range evts {
+		if e != nil && e.Target != "" {
+			sorted = append(sorted, e)
+		}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	// A request on a connection starts with the first send after a reply
+	// and ends with the next receive, or with the close of the connection.
+	type conn struct {
+		pid    uint32
+		target string
+	}
+	pending := make(map[conn]uint64)
+	for _, e := range sorted {
+		if op, ok := timeoutOperations[e.Type]; ok {
+			g := groupOf(key{e.Target, op})
+			g.requests++
+			ms := float64(e.LatencyNS) / 1e6
+			if e.IsError() {
+				g.abandoned = append(g.abandoned, ms)
+			} else {
+				g.completed = append(g.completed, ms)
+			}
+			continue
+		}
+		c := conn{e.PID, e.Target}
+		sentAt, waiting := pending[c]
+		switch e.Type {
+		case events.EventTCPSend:
+			if !waiting {
+				pending[c] = e.Timestamp
+				groupOf(key{e.Target, OpRequest}).requests++
+			}
+		case events.EventTCPRecv:
+			if waiting {
+				delete(pending, c)
+				g := groupOf(key{e.Target, OpRequest})
+				g.completed = append(g.completed, float64(e.Timestamp-sentAt)/1e6)
+			}
+		case events.EventTCPState:
+			if waiting && e.TCPState == tcpFinWait1 {
+				delete(pending, c)
+				g := groupOf(key{e.Target, OpRequest})
+				g.abandoned = append(g.abandoned, float64(e.Timestamp-sentAt)/1e6)
+			}
+		}
+	}
+
+	floor := float64(minClientTimeout) / 1e6
+	var out []ClientTimeout
+	for k, g := range groups {
+		sort.Float64s(g.abandoned)
+		lo, best, bestLo := 0, 0, 0
+		for hi, ms := range g.abandoned {
+			if ms < floor {
+				lo = hi + 1
+				continue
+			}
+			for ms > g.abandoned[lo]*(1+config.ClientTimeoutTolerance) {
+				lo++
+			}
+			if hi-lo+1 > best {
+				best, bestLo = hi-lo+1, lo
+			}
+		}
+		if best == 0 || best < config.ClientTimeoutMin {
+			continue
+		}
+		cluster := g.abandoned[bestLo : bestLo+best]
+		sort.Float64s(g.completed)
+		out = append(out, ClientTimeout{
+			Target:       k.target,
+			Operation:    k.op,
+			TimeoutMS:    Percentile(cluster, 50),
+			MinMS:        cluster[0],
+			MaxMS:        cluster[len(cluster)-1],
+			Hits:         len(cluster),
+			Requests:     g.requests,
+			SuccessP99MS: Percentile(g.completed, 99),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Hits != out[j].Hits {
+			return out[i].Hits > out[j].Hits
+		}
+		if out[i].Target != out[j].Target {
+			return out[i].Target < out[j].Target
+		}
+		return out[i].Operation < out[j].Operation
+	})
+	return out
+}
@@ -0,0 +1,66 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeClientTimeouts(t *testing.T) {
+	var evts []*events.Event
+	at := uint64(time.Second)
+	next := func(d time.Duration) uint64 {
+		at += uint64(d)
+		return at
+	}
+	// 20 requests to the API: 14 abandoned ~2s after they were sent, the
+	// rest answered 151ms after their first send.
+	for i := range 20 {
+		pid := uint32(100 + i%3)
+		evts = append(evts, &events.Event{Type: events.EventTCPSend, PID: pid, Target: "10.0.0.5:8080", Timestamp: next(time.Millisecond)})
+		evts = append(evts, &events.Event{Type: events.EventTCPSend, PID: pid, Target: "10.0.0.5:8080", Timestamp: next(time.Millisecond)})
+		if i < 14 {
+			evts = append(evts, &events.Event{Type: events.EventTCPState, TCPState: tcpFinWait1, PID: pid, Target: "10.0.0.5:8080",
+				Timestamp: next(2*time.Second + time.Duration(i)*time.Millisecond)})
+			continue
+		}
+		evts = append(evts, &events.Event{Type: events.EventTCPRecv, PID: pid, Target: "10.0.0.5:8080", Timestamp: next(150 * time.Millisecond)})
+	}
+	// Connects to the database fail at 5s, refusals fail at once.
+	for i := range 6 {
+		evts = append(evts, &events.Event{Type: events.EventConnect, Target: "10.0.0.9:5432", Error: 110,
+			LatencyNS: uint64(5*time.Second + time.Duration(i)*10*time.Millisecond), Timestamp: next(time.Second)})
+	}
+	for range 10 {
+		evts = append(evts, &events.Event{Type: events.EventConnect, Target: "10.0.0.7:6379", Error: 111,
+			LatencyNS: uint64(200 * time.Microsecond), Timestamp: next(time.Second)})
+	}
+	// Failures spread out do not make a timeout.
+	for i := range 6 {
+		evts = append(evts, &events.Event{Type: events.EventDBQuery, Target: "10.0.0.9:5432", Error: 1,
+			LatencyNS: uint64(time.Duration(i+1) * 300 * time.Millisecond), Timestamp: next(time.Second)})
+	}
+
+	got := AnalyzeClientTimeouts(evts)
+	if len(got) != 2 {
+		t.Fatalf("timeouts = %+v", got)
+	}
+	api := got[0]
+	if api.Target != "10.0.0.5:8080" || api.Operation != OpRequest || api.Hits != 14 || api.Requests != 20 {
+		t.Errorf("api = %+v", api)
+	}
+	if api.TimeoutMS < 2000 || api.TimeoutMS > 2020 || api.HitRate() != 70 {
+		t.Errorf("api timeout = %.1fms, hit rate %.0f%%", api.TimeoutMS, api.HitRate())
+	}
+	if api.SuccessP99MS != 151 {
+		t.Errorf("api completed p99 = %.1fms, want 151", api.SuccessP99MS)
+	}
+	db := got[1]
+	if db.Target != "10.0.0.9:5432" || db.Operation != "connect" || db.Hits != 6 || db.Requests != 6 || db.TimeoutMS != 5025 {
+		t.Errorf("db = %+v", db)
+	}
+	if AnalyzeClientTimeouts(nil) != nil {
+		t.Error("expected nil without events")
+	}
+}
@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// TimeSeries splits a session into fixed intervals, so the shape of an
+// incident can be plotted from the export alone.
+type TimeSeries struct {
+	Start           time.Time `json:"start"`
+	IntervalSeconds float64   `json:"interval_seconds"`
+	Buckets         int       `json:"buckets"`
+	// Series holds one entry per event type seen, in event type order.
+	Series []TypeSeries `json:"series"`
+}
+
+// TypeSeries is one event type's activity per interval; every slice has
+// one value per bucket.
+type TypeSeries struct {
+	// Type is the event category, as in capture files, and EventType the
+	// value of the event type enum.
+	Type      string `json:"type"`
+	EventType int    `json:"event_type"`
+	Counts    []int  `json:"counts"`
+	Errors    []int  `json:"errors"`
+	// The latency percentiles are only given for event types that carry a
+	// latency; an interval without events has 0.
+	P50MS []float64 `json:"p50_ms,omitempty"`
+	P95MS []float64 `json:"p95_ms,omitempty"`
+	P99MS []float64 `json:"p99_ms,omitempty"`
+}
+
+// AnalyzeTimeSeries buckets evts into intervals of the given width from
+// start to end, widened so there are at most config.MaxExportBuckets. It
+// returns nil when the interval is zero or no event falls in the window.
+func AnalyzeTimeSeries(evts []*events.Event, start, end time.Time, interval time.Duration) *TimeSeries {
+	if interval <= 0 || !end.After(start) {
+		return nil
+	}
+	span := end.Sub(start)
+	if minInterval := (span + config.MaxExportBuckets - 1) / config.MaxExportBuckets; interval < minInterval {
+		interval = (minInterval + time.Second - 1).Truncate(time.Second)
+	}
+	n := int((span + interval - 1) / interval)
+
+	type typeBuckets struct {
+		series    TypeSeries
+		latencies [][]float64
+		timed     bool
+	}
+	byType := make(map[events.EventType]*typeBuckets)
+	for _, e := range evts {
+		if e == nil {
+			continue
+		}
+		at := e.TimestampTime()
+		if at.Before(start) || at.After(end) {
+			continue
+		}
+		i := min(int(at.Sub(start)/interval), n-1)
+		tb := byType[e.Type]
+		if tb == nil {
+			tb = &typeBuckets{
+				series:    TypeSeries{Type: e.TypeString(), EventType: int(e.Type), Counts: make([]int, n), Errors: make([]int, n)},
+				latencies: make([][]float64, n),
+			}
+			byType[e.Type] = tb
+		}
+		tb.series.Counts[i]++
+		if e.IsError() {
+			tb.series.Errors[i]++
+		}
+		if e.LatencyNS > 0 {
+			tb.timed = true
+			tb.latencies[i] = append(tb.latencies[i], float64(e.LatencyNS)/float64(config.NSPerMS))
+		}
+	}
+	if len(byType) == 0 {
+		return nil
+	}
+
+	out := &TimeSeries{Start: start, IntervalSeconds: interval.Seconds(), Buckets: n}
+	for _, tb := range byType {
+		s := tb.series
+		if tb.timed {
+			s.P50MS, s.P95MS, s.P99MS = make([]float64, n), make([]float64, n), make([]float64, n)
+			for i, lat := range tb.latencies {
+				sort.Float64s(lat)
+				s.P50MS[i], s.P95MS[i], s.P99MS[i] = Percentile(lat, 50), Percentile(lat, 95), Percentile(lat, 99)
+			}
+		}
+		out.Series = append(out.Series, s)
+	}
+	sort.Slice(out.Series, func(i, j int) bool { return out.Series[i].EventType < out.Series[j].EventType })
+	return out
+}
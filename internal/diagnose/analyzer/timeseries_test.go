@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeTimeSeries(t *testing.T) {
+	// Anchored at now: a fixed date before boot would map every event to
+	// BPF time 0.
+	start := time.Now()
+	at := func(d time.Duration) uint64 { return clock.WallToBPFTimestamp(start.Add(d)) }
+	evts := []*events.Event{
+		{Type: events.EventConnect, Timestamp: at(2 * time.Second), LatencyNS: uint64(10 * time.Millisecond)},
+		{Type: events.EventConnect, Timestamp: at(4 * time.Second), LatencyNS: uint64(30 * time.Millisecond), Error: 111},
+		{Type: events.EventConnect, Timestamp: at(25 * time.Second), LatencyNS: uint64(500 * time.Millisecond)},
+		{Type: events.EventDNS, Timestamp: at(29 * time.Second), LatencyNS: uint64(time.Millisecond)},
+		{Type: events.EventExec, Timestamp: at(11 * time.Second)},
+		{Type: events.EventExec, Timestamp: at(time.Minute)},
+	}
+
+	got := AnalyzeTimeSeries(evts, start, start.Add(30*time.Second), 10*time.Second)
+	if got == nil {
+		t.Fatal("AnalyzeTimeSeries returned nil")
+	}
+	if got.Buckets != 3 || got.IntervalSeconds != 10 || !got.Start.Equal(start) || len(got.Series) != 3 {
+		t.Fatalf("time series = %+v", got)
+	}
+	dns, connect, exec := got.Series[0], got.Series[1], got.Series[2]
+	if dns.EventType != int(events.EventDNS) || connect.Type != "NET" || exec.EventType != int(events.EventExec) {
+		t.Fatalf("series order = %+v", got.Series)
+	}
+	if !reflect.DeepEqual(connect.Counts, []int{2, 0, 1}) || !reflect.DeepEqual(connect.Errors, []int{1, 0, 0}) {
+		t.Errorf("connect counts = %v, errors = %v", connect.Counts, connect.Errors)
+	}
+	if !reflect.DeepEqual(connect.P50MS, []float64{20, 0, 500}) {
+		t.Errorf("connect p50 = %v", connect.P50MS)
+	}
+	if !reflect.DeepEqual(exec.Counts, []int{0, 1, 0}) || exec.P50MS != nil {
+		t.Errorf("exec series = %+v", exec)
+	}
+
+	long := AnalyzeTimeSeries(evts, start, start.Add(3*time.Hour), 10*time.Second)
+	if long.IntervalSeconds != 15 || long.Buckets != 720 {
+		t.Errorf("3h session: %d buckets of %.0fs, want 720 of 15s", long.Buckets, long.IntervalSeconds)
+	}
+	if AnalyzeTimeSeries(evts, start, start.Add(time.Minute), 0) != nil {
+		t.Error("expected nil with the buckets turned off")
+	}
+}
@@ -0,0 +1,105 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+// CgroupTmpfs summarizes the EventTmpfs samples of one cgroup: the memory
+// its tmpfs files held, which counts against its memory limit.
+type CgroupTmpfs struct {
+	Cgroup  string `json:"cgroup"`
+	Samples int    `json:"samples"`
+	// FirstBytes and LastBytes are the tmpfs usage of the first and last
+	// samples; GrowthBytes is how far the peak rose above the first.
+	FirstBytes  uint64 `json:"first_bytes"`
+	LastBytes   uint64 `json:"last_bytes"`
+	PeakBytes   uint64 `json:"peak_bytes"`
+	GrowthBytes uint64 `json:"growth_bytes"`
+	// PeakMemoryBytes is the cgroup's highest memory usage, tmpfs included.
+	PeakMemoryBytes uint64 `json:"peak_memory_bytes"`
+	// MemoryLimitBytes is the cgroup's memory limit, 0 when unlimited.
+	MemoryLimitBytes uint64 `json:"memory_limit_bytes"`
+	// PeakLimitShare is PeakBytes over the memory limit, 0 when unlimited.
+	PeakLimitShare float64 `json:"peak_limit_share"`
+	// OverIntervals counts the samples whose tmpfs usage was at least the
+	// share of the limit AnalyzeTmpfs was given.
+	OverIntervals int `json:"over_intervals"`
+}
+
+// AnalyzeTmpfs summarizes the EventTmpfs samples in evts per cgroup, the
+// largest share of the memory limit first. warnShare is the share of the
+// limit a sample counts in OverIntervals from. It returns nil when there
+// are none.
+func AnalyzeTmpfs(evts []*events.Event, warnShare float64) []CgroupTmpfs {
+	byCgroup := make(map[string]*CgroupTmpfs)
+	for _, e := range evts {
+		if e == nil || e.Type != events.EventTmpfs {
+			continue
+		}
+		s := resource.ParseTmpfsDetails(e.Details)
+		c := byCgroup[e.Target]
+		if c == nil {
+			c = &CgroupTmpfs{Cgroup: e.Target, FirstBytes: s.ShmemBytes}
+			byCgroup[e.Target] = c
+		}
+		c.Samples++
+		c.LastBytes = s.ShmemBytes
+		c.PeakBytes = max(c.PeakBytes, s.ShmemBytes)
+		c.PeakMemoryBytes = max(c.PeakMemoryBytes, s.MemoryBytes)
+		c.MemoryLimitBytes = s.MemoryLimitBytes
+		if s.MemoryLimitBytes > 0 && float64(s.ShmemBytes) >= warnShare*float64(s.MemoryLimitBytes) {
+			c.OverIntervals++
+		}
+	}
+	if len(byCgroup) == 0 {
+		return nil
+	}
+	out := make([]CgroupTmpfs, 0, len(byCgroup))
+	for _, c := range byCgroup {
+		c.GrowthBytes = c.PeakBytes - c.FirstBytes
+		if c.MemoryLimitBytes > 0 {
+			c.PeakLimitShare = float64(c.PeakBytes) / float64(c.MemoryLimitBytes)
+		}
+		out = append(out, *c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.PeakLimitShare != b.PeakLimitShare {
+			return a.PeakLimitShare > b.PeakLimitShare
+		}
+		if a.PeakBytes != b.PeakBytes {
+			return a.PeakBytes > b.PeakBytes
+		}
+		return a.Cgroup < b.Cgroup
+	})
+	return out
+}
+
+// MemoryVolumes returns the emptyDir volumes with medium Memory among
+// mounts, once each, by pod, container and volume. Their files are the
+// tmpfs usage AnalyzeTmpfs reports, with /dev/shm.
+func MemoryVolumes(mounts map[uint32]VolumeMount) []VolumeMount {
+	seen := make(map[VolumeMount]bool)
+	var out []VolumeMount
+	for _, m := range mounts {
+		if m.Kind != "emptyDir" || m.Source != "memory" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i], out[j]
+		if a.Pod != b.Pod {
+			return a.Pod < b.Pod
+		}
+		if a.Container != b.Container {
+			return a.Container < b.Container
+		}
+		return a.Volume < b.Volume
+	})
+	return out
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+func TestAnalyzeTmpfs(t *testing.T) {
+	sample := func(cgroup string, s resource.TmpfsSample) *events.Event {
+		return &events.Event{Type: events.EventTmpfs, Target: cgroup, Details: resource.FormatTmpfsDetails(s)}
+	}
+	const limit = 1 << 30
+	evts := []*events.Event{
+		sample("/pod-a", resource.TmpfsSample{ShmemBytes: 100 << 20, MemoryBytes: 500 << 20, MemoryLimitBytes: limit}),
+		sample("/pod-a", resource.TmpfsSample{ShmemBytes: 300 << 20, MemoryBytes: 900 << 20, MemoryLimitBytes: limit}),
+		sample("/pod-a", resource.TmpfsSample{ShmemBytes: 250 << 20, MemoryBytes: 800 << 20, MemoryLimitBytes: limit}),
+		sample("/pod-b", resource.TmpfsSample{ShmemBytes: 1 << 20, MemoryBytes: 10 << 20}),
+		{Type: events.EventSwap, Target: "/pod-a"},
+	}
+	got := AnalyzeTmpfs(evts, 0.2)
+	if len(got) != 2 {
+		t.Fatalf("cgroups = %+v", got)
+	}
+	a := got[0]
+	if a.Cgroup != "/pod-a" || a.Samples != 3 || a.FirstBytes != 100<<20 || a.LastBytes != 250<<20 ||
+		a.PeakBytes != 300<<20 || a.GrowthBytes != 200<<20 || a.PeakMemoryBytes != 900<<20 {
+		t.Errorf("pod-a = %+v", a)
+	}
+	if a.MemoryLimitBytes != limit || a.OverIntervals != 2 || a.PeakLimitShare < 0.29 || a.PeakLimitShare > 0.3 {
+		t.Errorf("pod-a limit = %+v", a)
+	}
+	if b := got[1]; b.Cgroup != "/pod-b" || b.PeakLimitShare != 0 || b.OverIntervals != 0 {
+		t.Errorf("pod-b = %+v", b)
+	}
+	if AnalyzeTmpfs(evts[4:], 0.2) != nil {
+		t.Error("no tmpfs sample: want nil")
+	}
+}
+
+func TestMemoryVolumes(t *testing.T) {
+	shm := VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "shm", Kind: "emptyDir", Source: "memory", MountPath: "/dev/shm"}
+	cache := VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "cache", Kind: "emptyDir", Source: "memory", MountPath: "/cache"}
+	disk := VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "scratch", Kind: "emptyDir", MountPath: "/tmp"}
+	got := MemoryVolumes(map[uint32]VolumeMount{1: shm, 2: shm, 3: cache, 4: disk})
+	if len(got) != 2 || got[0].Volume != "cache" || got[1].Volume != "shm" {
+		t.Errorf("memory volumes = %+v", got)
+	}
+}
@@ -0,0 +1,143 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/safeconv"
+)
+
+// VolumeMount is the pod volume behind one mount ID of a traced container.
+type VolumeMount struct {
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	Volume    string `json:"volume"`
+	// Kind is the volume source (emptyDir, persistentVolumeClaim, configMap,
+	// hostPath, ...) or "rootfs" for the container's writable layer.
+	Kind         string `json:"kind"`
+	Source       string `json:"source,omitempty"`
+	StorageClass string `json:"storage_class,omitempty"`
+	MountPath    string `json:"mount_path"`
+	// ReadOnly is set for read-only mounts and a read-only root filesystem.
+	ReadOnly bool `json:"read_only,omitempty"`
+	// SizeLimit is an emptyDir's sizeLimit.
+	SizeLimit string `json:"size_limit,omitempty"`
+}
+
+// VolumeLatency is the file-system latency of the traced FS events on one
+// volume.
+type VolumeLatency struct {
+	VolumeMount
+	Ops    int `json:"ops"`
+	Reads  int `json:"reads"`
+	Writes int `json:"writes"`
+	Fsyncs int `json:"fsyncs"`
+	// LatencyMS is the time spent in all the operations.
+	LatencyMS float64 `json:"latency_ms"`
+	AvgMS     float64 `json:"avg_ms"`
+	P99MS     float64 `json:"p99_ms"`
+	MaxMS     float64 `json:"max_ms"`
+	SlowOps   int     `json:"slow_ops"`
+	Bytes     uint64  `json:"bytes"`
+	// Storage is the claim's storage as the API described it, read when
+	// the volume was found slow.
+	Storage *StorageEvidence `json:"storage,omitempty"`
+}
+
+// StorageEvidence is the claim, volume and storage class behind a slow PVC
+// volume, and their recent Events.
+type StorageEvidence struct {
+	Phase            string         `json:"phase,omitempty"`
+	Capacity         string         `json:"capacity,omitempty"`
+	AccessModes      []string       `json:"access_modes,omitempty"`
+	PersistentVolume string         `json:"persistent_volume,omitempty"`
+	Provisioner      string         `json:"provisioner,omitempty"`
+	CSIDriver        string         `json:"csi_driver,omitempty"`
+	VolumeHandle     string         `json:"volume_handle,omitempty"`
+	Events           []StorageEvent `json:"events,omitempty"`
+}
+
+// StorageEvent is one Event on the claim or its PersistentVolume.
+type StorageEvent struct {
+	Object   string    `json:"object"`
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Message  string    `json:"message"`
+	Count    int32     `json:"count,omitempty"`
+	LastSeen time.Time `json:"last_seen"`
+}
+
+// AnalyzeVolumes puts the EventRead, EventWrite and EventFsync events on the
+// volume their file's mount ID belongs to and returns the volumes with at
+// least one operation, most time spent first. Events on mounts that are not
+// a known volume are left out. slowMS is the FS slow-operation threshold.
+func AnalyzeVolumes(evts []*events.Event, mounts map[uint32]VolumeMount, slowMS float64) []VolumeLatency {
+	if len(mounts) == 0 {
+		return nil
+	}
+	type acc struct {
+		v         VolumeLatency
+		latencies []float64
+	}
+	byVolume := make(map[VolumeMount]*acc)
+	for _, e := range evts {
+		if e == nil || e.MntID == 0 {
+			continue
+		}
+		m, ok := mounts[e.MntID]
+		if !ok {
+			continue
+		}
+		a := byVolume[m]
+		if a == nil {
+			a = &acc{v: VolumeLatency{VolumeMount: m}}
+			byVolume[m] = a
+		}
+		switch e.Type {
+		case events.EventRead:
+			a.v.Reads++
+		case events.EventWrite:
+			a.v.Writes++
+		case events.EventFsync:
+			a.v.Fsyncs++
+		default:
+			continue
+		}
+		ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+		a.v.Ops++
+		a.v.LatencyMS += ms
+		if ms > a.v.MaxMS {
+			a.v.MaxMS = ms
+		}
+		if ms > slowMS {
+			a.v.SlowOps++
+		}
+		if e.Bytes > 0 && e.Bytes < safeconv.Int64ToUint64(config.MaxBytesForBandwidth) {
+			a.v.Bytes += e.Bytes
+		}
+		a.latencies = append(a.latencies, ms)
+	}
+
+	out := make([]VolumeLatency, 0, len(byVolume))
+	for _, a := range byVolume {
+		if a.v.Ops == 0 {
+			continue
+		}
+		sort.Float64s(a.latencies)
+		a.v.AvgMS = a.v.LatencyMS / float64(a.v.Ops)
+		a.v.P99MS = Percentile(a.latencies, 99)
+		out = append(out, a.v)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].LatencyMS != out[j].LatencyMS {
+			return out[i].LatencyMS > out[j].LatencyMS
+		}
+		return out[i].Volume < out[j].Volume
+	})
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,50 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func fsEvent(typ events.EventType, mnt uint32, d time.Duration, bytes uint64) *events.Event {
+	return &events.Event{Type: typ, MntID: mnt, LatencyNS: uint64(d), Bytes: bytes}
+}
+
+func TestAnalyzeVolumes(t *testing.T) {
+	data := VolumeMount{Pod: "shop/db-0", Container: "db", Volume: "data", Kind: "persistentVolumeClaim",
+		Source: "data-db-0", StorageClass: "gp3", MountPath: "/var/lib/db"}
+	scratch := VolumeMount{Pod: "shop/db-0", Container: "db", Volume: "scratch", Kind: "emptyDir", MountPath: "/tmp"}
+	mounts := map[uint32]VolumeMount{11: data, 12: data, 13: scratch}
+
+	evts := []*events.Event{
+		fsEvent(events.EventWrite, 11, 40*time.Millisecond, 4096),
+		fsEvent(events.EventFsync, 12, 60*time.Millisecond, 0),
+		fsEvent(events.EventRead, 11, 2*time.Millisecond, 512),
+		fsEvent(events.EventWrite, 13, 1*time.Millisecond, 100),
+		fsEvent(events.EventWrite, 99, 500*time.Millisecond, 0), // unknown mount
+		fsEvent(events.EventWrite, 0, 500*time.Millisecond, 0),  // no mount ID
+		fsEvent(events.EventTCPSend, 11, 500*time.Millisecond, 0),
+	}
+	got := AnalyzeVolumes(evts, mounts, 10)
+	if len(got) != 2 {
+		t.Fatalf("volumes = %+v; want data and scratch", got)
+	}
+	v := got[0]
+	if v.Volume != "data" || v.StorageClass != "gp3" || v.Ops != 3 || v.Writes != 1 || v.Fsyncs != 1 || v.Reads != 1 {
+		t.Errorf("data = %+v", v)
+	}
+	if v.LatencyMS != 102 || v.AvgMS != 34 || v.MaxMS != 60 || v.SlowOps != 2 || v.Bytes != 4608 {
+		t.Errorf("data latency = %+v", v)
+	}
+	if got[1].Volume != "scratch" || got[1].Ops != 1 || got[1].SlowOps != 0 {
+		t.Errorf("scratch = %+v", got[1])
+	}
+
+	if AnalyzeVolumes(evts, nil, 10) != nil {
+		t.Error("no mounts: want nil")
+	}
+	if AnalyzeVolumes(evts[4:6], mounts, 10) != nil {
+		t.Error("no event on a known volume: want nil")
+	}
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"sort"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// Why the traffic to a target stalled.
+const (
+	StallLocalReader = "local_reader" // the traced pod read too slowly
+	StallPeerReader  = "peer_reader"  // the remote end read too slowly
+	StallLoss        = "loss"         // segments were lost and retransmitted
+)
+
+// TargetWindowStalls are the zero-window stalls and retransmits of the
+// connections to one remote endpoint.
+type TargetWindowStalls struct {
+	Target string `json:"target"`
+	// LocalStalls are windows the traced pod closed, PeerStalls windows the
+	// remote end closed.
+	LocalStalls  int     `json:"local_stalls"`
+	LocalStallMS float64 `json:"local_stall_ms"`
+	PeerStalls   int     `json:"peer_stalls"`
+	PeerStallMS  float64 `json:"peer_stall_ms"`
+	MaxStallMS   float64 `json:"max_stall_ms"`
+	Retransmits  int     `json:"retransmits"`
+	Cause        string  `json:"cause"`
+}
+
+// WindowStalls summarizes the TCP zero-window stalls of a trace next to its
+// retransmits, so that a receiver that reads too slowly can be told apart
+// from a lossy network.
+type WindowStalls struct {
+	LocalStalls  int     `json:"local_stalls"`
+	LocalStallMS float64 `json:"local_stall_ms"`
+	PeerStalls   int     `json:"peer_stalls"`
+	PeerStallMS  float64 `json:"peer_stall_ms"`
+	Retransmits  int     `json:"retransmits"`
+	// Targets lists the endpoints with the most stall time first; those
+	// with retransmits only follow.
+	Targets []TargetWindowStalls `json:"targets"`
+}
+
+// AnalyzeWindowStalls sums the EventTCPZeroWindow events and the data
+// retransmits in evts per remote endpoint. It returns nil when no window closed: without
+// a stall the retransmits are already covered by the TCP section.
+func AnalyzeWindowStalls(evts []*events.Event) *WindowStalls {
+	out := &WindowStalls{}
+	byTarget := make(map[string]*TargetWindowStalls)
+	target := func(name string) *TargetWindowStalls {
+		t := byTarget[name]
+		if t == nil {
+			t = &TargetWindowStalls{Target: name}
+			byTarget[name] = t
+		}
+		return t
+	}
+	for _, e := range evts {
+		if e == nil || e.Target == "" {
+			continue
+		}
+		switch e.Type {
+		case events.EventTCPZeroWindow:
+			ms := float64(e.LatencyNS) / 1e6
+			t := target(e.Target)
+			switch e.TCPState {
+			case events.ZeroWindowLocal:
+				out.LocalStalls++
+				out.LocalStallMS += ms
+				t.LocalStalls++
+				t.LocalStallMS += ms
+			case events.ZeroWindowPeer:
+				out.PeerStalls++
+				out.PeerStallMS += ms
+				t.PeerStalls++
+				t.PeerStallMS += ms
+			default:
+				continue
+			}
+			if ms > t.MaxStallMS {
+				t.MaxStallMS = ms
+			}
+		case events.EventTCPRetrans:
+			// A resent SYN is a connection not being set up, not loss
+			// that could have slowed one down.
+			if e.IsSYNRetrans() {
+				continue
+			}
+			out.Retransmits++
+			target(e.Target).Retransmits++
+		}
+	}
+	if out.LocalStalls+out.PeerStalls == 0 {
+		return nil
+	}
+	for _, t := range byTarget {
+		switch {
+		case t.LocalStalls > 0 && t.LocalStallMS >= t.PeerStallMS:
+			t.Cause = StallLocalReader
+		case t.PeerStalls > 0:
+			t.Cause = StallPeerReader
+		default:
+			t.Cause = StallLoss
+		}
+		out.Targets = append(out.Targets, *t)
+	}
+	sort.Slice(out.Targets, func(i, j int) bool {
+		a, b := out.Targets[i], out.Targets[j]
+		if sa, sb := a.LocalStallMS+a.PeerStallMS, b.LocalStallMS+b.PeerStallMS; sa != sb {
+			return sa > sb
+		}
+		if a.Retransmits != b.Retransmits {
+			return a.Retransmits > b.Retransmits
+		}
+		return a.Target < b.Target
+	})
+	if len(out.Targets) > config.TopTargetsLimit {
+		out.Targets = out.Targets[:config.TopTargetsLimit]
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeWindowStalls(t *testing.T) {
+	stall := func(target string, side uint32, d time.Duration) *events.Event {
+		return &events.Event{Type: events.EventTCPZeroWindow, Target: target, TCPState: side, LatencyNS: uint64(d)}
+	}
+	retrans := func(target string) *events.Event {
+		return &events.Event{Type: events.EventTCPRetrans, Target: target}
+	}
+	evts := []*events.Event{
+		stall("10.0.0.5:5432", events.ZeroWindowLocal, 300*time.Millisecond),
+		stall("10.0.0.5:5432", events.ZeroWindowLocal, 100*time.Millisecond),
+		stall("10.0.0.9:9092", events.ZeroWindowPeer, 50*time.Millisecond),
+		retrans("10.0.0.9:9092"),
+		retrans("10.0.0.7:443"),
+		retrans("10.0.0.7:443"),
+		{Type: events.EventTCPRetrans, Target: "10.0.0.5:5432", TCPState: 2},
+		{Type: events.EventTCPZeroWindow, LatencyNS: uint64(time.Second), TCPState: events.ZeroWindowPeer},
+	}
+	got := AnalyzeWindowStalls(evts)
+	if got == nil {
+		t.Fatal("AnalyzeWindowStalls returned nil")
+	}
+	if got.LocalStalls != 2 || got.LocalStallMS != 400 || got.PeerStalls != 1 || got.PeerStallMS != 50 || got.Retransmits != 3 {
+		t.Errorf("totals = %+v", got)
+	}
+	want := []struct {
+		target, cause string
+	}{
+		{"10.0.0.5:5432", StallLocalReader},
+		{"10.0.0.9:9092", StallPeerReader},
+		{"10.0.0.7:443", StallLoss},
+	}
+	if len(got.Targets) != len(want) {
+		t.Fatalf("targets = %+v", got.Targets)
+	}
+	for i, w := range want {
+		if got.Targets[i].Target != w.target || got.Targets[i].Cause != w.cause {
+			t.Errorf("targets[%d] = %+v, want %s (%s)", i, got.Targets[i], w.target, w.cause)
+		}
+	}
+	if got.Targets[0].MaxStallMS != 300 {
+		t.Errorf("max stall = %.1fms, want 300", got.Targets[0].MaxStallMS)
+	}
+
+	if s := AnalyzeWindowStalls([]*events.Event{retrans("10.0.0.7:443")}); s != nil {
+		t.Errorf("AnalyzeWindowStalls without a stall = %+v, want nil", s)
+	}
+}
@@ -0,0 +1,170 @@
+package analyzer
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// Error numbers of writes a filesystem refused.
+const (
+	errnoENOSPC = 28
+	errnoEROFS  = 30
+	errnoEDQUOT = 122
+)
+
+// Why a WriteFailure's writes were refused.
+const (
+	// WriteRefusedReadOnlyRoot: the container sets readOnlyRootFilesystem.
+	WriteRefusedReadOnlyRoot = "read_only_rootfs"
+	// WriteRefusedReadOnlyMount: the volume is mounted read-only, or is a
+	// kind Kubernetes always mounts read-only (configMap, secret, ...).
+	WriteRefusedReadOnlyMount = "read_only_mount"
+	// WriteRefusedReadOnlyFS: the filesystem itself went read-only, as
+	// filesystems do after I/O errors.
+	WriteRefusedReadOnlyFS = "read_only_filesystem"
+	// WriteRefusedSizeLimit: an emptyDir reached its sizeLimit.
+	WriteRefusedSizeLimit = "emptydir_size_limit"
+	// WriteRefusedNoSpace: the volume or its quota is full.
+	WriteRefusedNoSpace = "no_space"
+)
+
+// maxWriteFailureFiles is how many of the refused files are kept per
+// volume.
+const maxWriteFailureFiles = 5
+
+// WriteFailure is a run of writes one volume refused with one error.
+type WriteFailure struct {
+	// VolumeMount is zero when the volume could not be told.
+	VolumeMount
+	Errno string `json:"errno"`
+	Cause string `json:"cause"`
+	// Failures counts the refused opens, writes and fsyncs; Attempts every
+	// traced one on the volume.
+	Failures int      `json:"failures"`
+	Attempts int      `json:"attempts"`
+	Files    []string `json:"files,omitempty"`
+}
+
+// AnalyzeWriteFailures finds the opens, writes and fsyncs that failed with
+// EROFS, ENOSPC or EDQUOT and puts them on their volume: writes and fsyncs
+// by their mount ID, opens by the longest mount path their absolute path is
+// under. It returns one WriteFailure per volume and error, most failures
+// first.
+func AnalyzeWriteFailures(evts []*events.Event, mounts map[uint32]VolumeMount) []WriteFailure {
+	type key struct {
+		volume VolumeMount
+		errno  int32
+	}
+	failures := make(map[key]*WriteFailure)
+	attempts := make(map[VolumeMount]int)
+	var order []key
+	for _, e := range evts {
+		if e == nil {
+			continue
+		}
+		var m VolumeMount
+		switch e.Type {
+		case events.EventWrite, events.EventFsync:
+			m = mounts[e.MntID]
+		case events.EventOpen:
+			m = mountForPath(e, mounts)
+		default:
+			continue
+		}
+		attempts[m]++
+		errno := e.Error
+		if errno < 0 {
+			errno = -errno
+		}
+		if errno != errnoEROFS && errno != errnoENOSPC && errno != errnoEDQUOT {
+			continue
+		}
+		k := key{m, errno}
+		f := failures[k]
+		if f == nil {
+			f = &WriteFailure{VolumeMount: m, Errno: errnoName(errno), Cause: writeRefusedCause(m, errno)}
+			failures[k] = f
+			order = append(order, k)
+		}
+		f.Failures++
+		if e.Target != "" && len(f.Files) < maxWriteFailureFiles && !slices.Contains(f.Files, e.Target) {
+			f.Files = append(f.Files, e.Target)
+		}
+	}
+	if len(order) == 0 {
+		return nil
+	}
+	out := make([]WriteFailure, 0, len(order))
+	for _, k := range order {
+		f := failures[k]
+		f.Attempts = attempts[k.volume]
+		out = append(out, *f)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Failures > out[j].Failures })
+	return out
+}
+
+// mountForPath returns the volume an open's absolute path is on, among the
+// volumes of the event's pod when both sides know it.
+func mountForPath(e *events.Event, mounts map[uint32]VolumeMount) VolumeMount {
+	var best VolumeMount
+	if !strings.HasPrefix(e.Target, "/") {
+		return best
+	}
+	pod := ""
+	if e.K8s != nil && e.K8s.PodName != "" {
+		pod = e.K8s.Namespace + "/" + e.K8s.PodName
+	}
+	for _, m := range mounts {
+		if pod != "" && m.Pod != "" && m.Pod != pod {
+			continue
+		}
+		if !pathUnder(e.Target, m.MountPath) || len(m.MountPath) < len(best.MountPath) {
+			continue
+		}
+		if len(m.MountPath) == len(best.MountPath) && m.Container+"/"+m.Volume >= best.Container+"/"+best.Volume {
+			continue
+		}
+		best = m
+	}
+	return best
+}
+
+func pathUnder(path, dir string) bool {
+	if dir == "/" {
+		return true
+	}
+	dir = strings.TrimSuffix(dir, "/")
+	return path == dir || strings.HasPrefix(path, dir+"/")
+}
+
+func writeRefusedCause(m VolumeMount, errno int32) string {
+	if errno == errnoEROFS {
+		switch {
+		case m.Kind == "rootfs" && m.ReadOnly:
+			return WriteRefusedReadOnlyRoot
+		case m.ReadOnly, m.Kind == "configMap", m.Kind == "secret", m.Kind == "downwardAPI", m.Kind == "projected":
+			return WriteRefusedReadOnlyMount
+		}
+		return WriteRefusedReadOnlyFS
+	}
+	if m.Kind == "emptyDir" && m.SizeLimit != "" {
+		return WriteRefusedSizeLimit
+	}
+	return WriteRefusedNoSpace
+}
+
+func errnoName(errno int32) string {
+	switch errno {
+	case errnoEROFS:
+		return "EROFS"
+	case errnoENOSPC:
+		return "ENOSPC"
+	case errnoEDQUOT:
+		return "EDQUOT"
+	}
+	return ""
+}
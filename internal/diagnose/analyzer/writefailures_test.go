@@ -0,0 +1,54 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeWriteFailures(t *testing.T) {
+	rootfs := VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "rootfs", Kind: "rootfs", MountPath: "/", ReadOnly: true}
+	cache := VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "cache", Kind: "emptyDir", MountPath: "/cache", SizeLimit: "1Gi"}
+	config := VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "config", Kind: "configMap", MountPath: "/etc/api"}
+	mounts := map[uint32]VolumeMount{1: rootfs, 2: cache, 3: config}
+	k8s := &events.K8sMetadata{Namespace: "shop", PodName: "api-0"}
+
+	evts := []*events.Event{
+		{Type: events.EventOpen, Target: "/var/log/api.log", Error: 30, K8s: k8s},
+		{Type: events.EventOpen, Target: "/var/log/api.log", Error: 30, K8s: k8s},
+		{Type: events.EventOpen, Target: "/tmp/x", Error: 30, K8s: k8s},
+		{Type: events.EventOpen, Target: "/etc/api/app.yaml", Error: 30, K8s: k8s},
+		{Type: events.EventWrite, Target: "blob", MntID: 2, Error: 28},
+		{Type: events.EventWrite, Target: "blob", MntID: 2},
+		{Type: events.EventFsync, Target: "blob", MntID: 2, Error: -28},
+		{Type: events.EventWrite, Target: "other", MntID: 99, Error: 28},        // unknown mount
+		{Type: events.EventOpen, Target: "/cache/missing", Error: 2, K8s: k8s},  // ENOENT
+		{Type: events.EventTCPSend, Target: "10.0.0.1:80", MntID: 2, Error: 28}, // not a write
+		{Type: events.EventOpen, Target: "/cache/a", Error: 30, K8s: &events.K8sMetadata{Namespace: "shop", PodName: "other"}},
+	}
+	got := AnalyzeWriteFailures(evts, mounts)
+	if len(got) != 5 {
+		t.Fatalf("failures = %+v; want rootfs, cache, config, unknown and other pod", got)
+	}
+	if f := got[0]; f.Volume != "rootfs" || f.Cause != WriteRefusedReadOnlyRoot || f.Errno != "EROFS" ||
+		f.Failures != 3 || f.Attempts != 3 || len(f.Files) != 2 {
+		t.Errorf("rootfs = %+v", f)
+	}
+	if f := got[1]; f.Volume != "cache" || f.Cause != WriteRefusedSizeLimit || f.Errno != "ENOSPC" ||
+		f.Failures != 2 || f.Attempts != 4 || len(f.Files) != 1 {
+		t.Errorf("cache = %+v", f)
+	}
+	if f := got[2]; f.Volume != "config" || f.Cause != WriteRefusedReadOnlyMount {
+		t.Errorf("config = %+v", f)
+	}
+	if f := got[3]; f.Volume != "" || f.Cause != WriteRefusedNoSpace || f.Failures != 1 {
+		t.Errorf("unknown mount = %+v", f)
+	}
+	if f := got[4]; f.Volume != "" || f.Cause != WriteRefusedReadOnlyFS {
+		t.Errorf("other pod = %+v", f)
+	}
+
+	if AnalyzeWriteFailures(evts[5:6], mounts) != nil {
+		t.Error("no refused write: want nil")
+	}
+}
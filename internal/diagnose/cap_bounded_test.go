@@ -1,6 +1,7 @@
 package diagnose
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/podtrace/podtrace/internal/events"
@@ -59,3 +60,113 @@ func TestAddEvent_NoDropCounterUnderCap(t *testing.T) {
 		t.Errorf("no events should be dropped under cap, got droppedEvents=%d", d.droppedEvents)
 	}
 }
+
+func TestAddEvent_EventBudgetSwitchesToAggregation(t *testing.T) {
+	d := NewDiagnostician()
+	d.SetEventBudget(3)
+	for i := 0; i < 3; i++ {
+		d.AddEvent(&events.Event{Type: events.EventDNS, Bytes: uint64(i)})
+	}
+	if d.BudgetOverflow() != nil {
+		t.Fatal("budget reported spent before it was exceeded")
+	}
+	d.AddEvent(&events.Event{Type: events.EventHTTPResp, Error: 503, LatencyNS: 4_000_000})
+	d.AddEvent(&events.Event{Type: events.EventHTTPResp, LatencyNS: 2_000_000})
+	d.AddEvent(&events.Event{Type: events.EventDNS})
+
+	if got := len(d.GetEvents()); got != 3 {
+		t.Errorf("kept %d events, want the budget of 3", got)
+	}
+	o := d.BudgetOverflow()
+	if o == nil || o.Limit != 3 || o.Events != 3 || len(o.ByType) != 2 {
+		t.Fatalf("overflow = %+v", o)
+	}
+	http := o.ByType[0]
+	if http.Type != "HTTP" || http.Events != 2 || http.Errors != 1 || http.AvgLatencyMS != 3 || http.MaxLatencyMS != 4 {
+		t.Errorf("http totals = %+v", http)
+	}
+	if report := d.GenerateReport(); !strings.Contains(report, "switched to aggregation-only mode") {
+		t.Errorf("report does not note the switchover:\n%s", report)
+	}
+	if d.ExportJSON().BudgetOverflow == nil {
+		t.Error("export lacks the budget overflow")
+	}
+}
+
+func TestSessionTotalsCoverEvictedEvents(t *testing.T) {
+	d := NewDiagnostician()
+	d.maxEvents = 4
+	if d.SessionTotals() != nil {
+		t.Fatal("totals reported before the first event")
+	}
+	d.AddEvent(&events.Event{Type: events.EventConnect, Target: "db:5432", LatencyNS: 1_000_000})
+	if s := d.SessionTotals(); s == nil || s.Events != 1 {
+		t.Fatalf("totals while every event is kept = %+v", s)
+	}
+	for i := 0; i < 200; i++ {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Target: "db:5432", LatencyNS: uint64(i+1) * 1_000_000, Error: int32(i % 50 / 49)})
+	}
+
+	s := d.SessionTotals()
+	if s == nil || s.Events != 201 {
+		t.Fatalf("totals = %+v", s)
+	}
+	if len(s.ByType) != 1 || s.ByType[0].Events != 201 || s.ByType[0].Errors != 4 || s.ByType[0].MaxMS != 200 {
+		t.Errorf("by type = %+v", s.ByType)
+	}
+	if len(s.TopTargets) != 1 || s.TopTargets[0].Key != "db:5432" || s.TopTargets[0].Count != 201 {
+		t.Errorf("top targets = %+v", s.TopTargets)
+	}
+	if len(s.RecentErrors) != 4 {
+		t.Errorf("recent errors = %+v", s.RecentErrors)
+	}
+	if report := d.GenerateReport(); !strings.Contains(report, "201 events seen, 4 kept") {
+		t.Errorf("report lacks session totals:\n%s", report)
+	}
+}
+
+func TestSectionsUseSessionTotalsOnceEventsAreEvicted(t *testing.T) {
+	d := NewDiagnostician()
+	d.maxEvents = 4
+	for i := 0; i < 100; i++ {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Target: "db:5432", LatencyNS: uint64(i+1) * 1_000_000, Error: int32(i % 25 / 24)})
+	}
+	report := d.GenerateReport()
+	for _, want := range []string{
+		"Total connections: 100",
+		"Max latency: 100.00ms",
+		"Failed connections: 4 (4.0%)",
+		"the lines below cover the 4 kept",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("connection section lacks %q:\n%s", want, report)
+		}
+	}
+
+	d = NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventConnect, LatencyNS: 1_000_000})
+	if report := d.GenerateReport(); strings.Contains(report, "lines below cover") {
+		t.Errorf("kept events cover the session, but the report says otherwise:\n%s", report)
+	}
+}
+
+func TestTelemetryCountsUnkeptEvents(t *testing.T) {
+	d := NewDiagnostician()
+	d.SetEventBudget(4)
+	for i := 0; i < 4; i++ {
+		d.AddEvent(&events.Event{Type: events.EventDNS})
+	}
+	for i := 0; i < 6; i++ {
+		d.AddEvent(&events.Event{Type: events.EventDNS})
+	}
+	tel := d.Telemetry()
+	if tel.Received[events.EventDNS] != 10 || tel.Unkept[events.EventDNS] != 6 {
+		t.Fatalf("received %v, unkept %v", tel.Received, tel.Unkept)
+	}
+	if report := d.GenerateReport(); !strings.Contains(report, "Data: low confidence; 6 of 10 events (60%) sampled out or past the event budget") {
+		t.Errorf("report does not grade the DNS data:\n%s", report)
+	}
+	if c := d.ExportJSON().Coverage; len(c) == 0 {
+		t.Error("export lacks the data coverage")
+	}
+}
@@ -0,0 +1,270 @@
+// Package coverage grades how complete the data behind each report section
+// is, from the session's own telemetry: the probes that did not attach, the
+// events lost between the kernel and the diagnostician, and those it
+// sampled out or only counted past its event budget. A quiet section backed
+// by a probe that never attached is not evidence that nothing happened.
+package coverage
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// Grades of a section's data, best first.
+const (
+	GradeHigh   = "high"
+	GradeMedium = "medium"
+	GradeLow    = "low"
+)
+
+// lowLostShare is the share of a section's events lost from which its data
+// is graded low.
+const lowLostShare = 0.5
+
+// Telemetry is what a session knows about its own data loss.
+type Telemetry struct {
+	// LowPrivilege is set when the trace ran without BPF.
+	LowPrivilege bool `json:"low_privilege,omitempty"`
+	// FailedProbes are the BPF programs that did not attach.
+	FailedProbes []string `json:"failed_probes,omitempty"`
+	// RingBufferErrors counts failed ring buffer reads and FilteredDrops
+	// the events dropped on a full channel in userspace; neither tells
+	// which events were lost.
+	RingBufferErrors uint64 `json:"ring_buffer_errors,omitempty"`
+	FilteredDrops    uint64 `json:"filtered_drops,omitempty"`
+	// DNSDrops counts the DNS records the probes could not keep.
+	DNSDrops uint64 `json:"dns_drops,omitempty"`
+	// Received counts the events of each type the diagnostician got, and
+	// Unkept those it sampled out, evicted or only counted past its event
+	// budget.
+	Received map[events.EventType]int `json:"-"`
+	Unkept   map[events.EventType]int `json:"-"`
+}
+
+// Section is the grade of one report section's data.
+type Section struct {
+	ID    string `json:"section"`
+	Grade string `json:"grade"`
+	// Notes say what is missing from the section's data.
+	Notes []string `json:"notes,omitempty"`
+}
+
+// sources lists the event types each graded report section is built from,
+// by section ID; nil means every event type. Sections that report on the
+// session itself (pod, budget, session, suppressed, ...) are not graded.
+var sources = map[string][]events.EventType{
+	"disruptions":    {events.EventDisruption},
+	"chaos":          {events.EventChaos},
+	"pauses":         {events.EventPause},
+	"security":       {events.EventAFALG},
+	"cgroup":         nil,
+	"dns":            {events.EventDNS, events.EventDNSQuery},
+	"tcp":            {events.EventTCPSend, events.EventTCPRecv},
+	"retransmits":    {events.EventTCPRetrans},
+	"connection":     {events.EventConnect, events.EventTCPSend, events.EventTCPRecv},
+	"churn":          {events.EventConnect, events.EventTCPSend},
+	"members":        {events.EventConnect, events.EventDNS},
+	"windowstalls":   {events.EventTCPRetrans, events.EventTCPZeroWindow},
+	"timeouts":       {events.EventConnect, events.EventDNS, events.EventDBQuery, events.EventRedisCmd, events.EventMemcachedCmd, events.EventGRPCMethod, events.EventTCPSend, events.EventTCPRecv, events.EventTCPState},
+	"external":       {events.EventConnect, events.EventTCPSend, events.EventTCPRecv},
+	"throughput":     {events.EventPodThroughput},
+	"placement":      {events.EventCPUPlacement},
+	"neighbors":      {events.EventNeighbor, events.EventCPUPlacement, events.EventRead, events.EventWrite, events.EventFsync},
+	"fs":             {events.EventRead, events.EventWrite, events.EventFsync},
+	"volumes":        {events.EventRead, events.EventWrite, events.EventFsync},
+	"write_failures": {events.EventOpen, events.EventWrite, events.EventFsync},
+	"pagecache":      {events.EventPageCache, events.EventRead},
+	"fsnotify":       {events.EventFsNotify},
+	"udp":            {events.EventUDPSend, events.EventUDPRecv},
+	"http":           {events.EventHTTPReq, events.EventHTTPResp},
+	"objectstorage":  {events.EventHTTPReq, events.EventHTTPResp},
+	"http3":          {events.EventHTTP3},
+	"cpu":            {events.EventSchedSwitch, events.EventThreadCPU},
+	"tcpstate":       {events.EventTCPState},
+	"memory":         {events.EventOOMKill, events.EventPageFault},
+	"compaction":     {events.EventCompaction, events.EventTHPCollapse},
+	"swap":           {events.EventSwap},
+	"tmpfs":          {events.EventTmpfs},
+	"crash":          {events.EventCrash},
+	"runtime":        {events.EventCRIOp},
+	"imagepulls":     {events.EventImagePull},
+	"python":         {events.EventPyCall, events.EventPyGC},
+	"eventloop":      {events.EventLoopLag},
+	"resource":       {events.EventResourceLimit},
+	"pool":           {events.EventPoolAcquire, events.EventPoolRelease, events.EventPoolExhausted},
+	"amqp":           {events.EventAMQP},
+	"cpuusage":       nil,
+	"stacks":         nil,
+	"syscalls":       {events.EventExec, events.EventFork, events.EventOpen, events.EventClose},
+	"tracing":        nil,
+	"correlation":    nil,
+	"podcomm":        nil,
+	"errors":         nil,
+}
+
+// withoutBPF are the event types collected from cgroupfs, /proc or the
+// Kubernetes API rather than by a BPF program.
+var withoutBPF = []events.EventType{
+	events.EventResourceLimit, events.EventThreadCPU, events.EventSwap, events.EventTmpfs,
+	events.EventCRIOp, events.EventImagePull, events.EventDisruption, events.EventNeighbor,
+	events.EventChaos, events.EventPause,
+}
+
+// probeEvents maps the BPF programs allowed to fail to attach to the event
+// type each one produces. A mandatory probe that fails stops the trace.
+var probeEvents = map[string]events.EventType{
+	"kprobe_udp_sendmsg":                            events.EventUDPSend,
+	"kretprobe_udp_sendmsg":                         events.EventUDPSend,
+	"kprobe_udp_recvmsg":                            events.EventUDPRecv,
+	"kretprobe_udp_recvmsg":                         events.EventUDPRecv,
+	"kprobe_vfs_fsync":                              events.EventFsync,
+	"kretprobe_vfs_fsync":                           events.EventFsync,
+	"kprobe_do_futex":                               events.EventLockContention,
+	"kretprobe_do_futex":                            events.EventLockContention,
+	"kprobe_do_sys_openat2":                         events.EventOpen,
+	"kretprobe_do_sys_openat2":                      events.EventOpen,
+	"kprobe_close_fd":                               events.EventClose,
+	"kprobe_vfs_unlink":                             events.EventUnlink,
+	"kretprobe_vfs_unlink":                          events.EventUnlink,
+	"kprobe_vfs_rename":                             events.EventRename,
+	"kretprobe_vfs_rename":                          events.EventRename,
+	"kprobe_do_coredump":                            events.EventCrash,
+	"kprobe_filemap_add_folio":                      events.EventPageCache,
+	"kprobe_fsnotify_insert_event":                  events.EventFsNotify,
+	"tracepoint_sched_switch":                       events.EventSchedSwitch,
+	"tracepoint_inet_sock_set_state":                events.EventTCPState,
+	"tracepoint_tcp_retransmit_skb":                 events.EventTCPRetrans,
+	"tracepoint_tcp_retransmit_synack":              events.EventTCPRetrans,
+	"tracepoint_tcp_probe":                          events.EventTCPZeroWindow,
+	"tracepoint_net_dev_xmit":                       events.EventNetDevError,
+	"tracepoint_page_fault_user":                    events.EventPageFault,
+	"tracepoint_oom_mark_victim":                    events.EventOOMKill,
+	"tracepoint_mm_compaction_try_to_compact_pages": events.EventCompaction,
+	"tracepoint_mm_compaction_begin":                events.EventCompaction,
+	"tracepoint_mm_compaction_end":                  events.EventCompaction,
+	"tracepoint_mm_collapse_huge_page":              events.EventTHPCollapse,
+	"tracepoint_sched_process_fork":                 events.EventFork,
+	"tracepoint_sched_process_exec":                 events.EventExec,
+	"tracepoint_sys_enter_bind":                     events.EventAFALG,
+	"tracepoint_sys_exit_inotify_add_watch":         events.EventFsNotify,
+	"tracepoint_sys_exit_fanotify_mark":             events.EventFsNotify,
+}
+
+// Grade grades the data of the section id from t. It returns false for a
+// section that is not built from traced events.
+func Grade(id string, t Telemetry) (Section, bool) {
+	types, ok := sources[id]
+	if !ok {
+		return Section{}, false
+	}
+	s := Section{ID: id, Grade: GradeHigh}
+	uses := func(et events.EventType) bool { return types == nil || slices.Contains(types, et) }
+	bpf := types == nil
+	for _, et := range types {
+		if !slices.Contains(withoutBPF, et) {
+			bpf = true
+		}
+	}
+
+	if t.LowPrivilege && bpf {
+		s.lower(GradeLow, "no BPF probes in low-privilege mode")
+	}
+
+	var failed []string
+	blind := make(map[events.EventType]bool)
+	for _, p := range t.FailedProbes {
+		if et, ok := probeEvents[p]; ok && uses(et) {
+			failed = append(failed, p)
+			blind[et] = true
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		grade := GradeMedium
+		if types != nil && len(blind) == len(types) {
+			grade = GradeLow
+		}
+		s.lower(grade, fmt.Sprintf("not attached: %s", strings.Join(failed, ", ")))
+	}
+
+	received, unkept := 0, 0
+	for et, n := range t.Received {
+		if uses(et) {
+			received += n
+		}
+	}
+	for et, n := range t.Unkept {
+		if uses(et) {
+			unkept += n
+		}
+	}
+	if unkept > 0 && received > 0 {
+		share := float64(unkept) / float64(received)
+		grade := GradeMedium
+		if share >= lowLostShare {
+			grade = GradeLow
+		}
+		s.lower(grade, fmt.Sprintf("%d of %d events (%.0f%%) sampled out or past the event budget", unkept, received, share*100))
+	}
+
+	if bpf && t.RingBufferErrors > 0 {
+		s.lower(GradeMedium, fmt.Sprintf("%d ring buffer read errors", t.RingBufferErrors))
+	}
+	if bpf && t.FilteredDrops > 0 {
+		s.lower(GradeMedium, fmt.Sprintf("%d events dropped in userspace", t.FilteredDrops))
+	}
+	if uses(events.EventDNS) && t.DNSDrops > 0 {
+		s.lower(GradeMedium, fmt.Sprintf("%d DNS records dropped by the probes", t.DNSDrops))
+	}
+	return s, true
+}
+
+// Grades grades every section whose event types were received, or lost a
+// probe, by section ID.
+func Grades(t Telemetry) []Section {
+	ids := make([]string, 0, len(sources))
+	for id := range sources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	var out []Section
+	for _, id := range ids {
+		s, _ := Grade(id, t)
+		if s.Grade == GradeHigh && !received(sources[id], t) {
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func received(types []events.EventType, t Telemetry) bool {
+	for et, n := range t.Received {
+		if n > 0 && (types == nil || slices.Contains(types, et)) {
+			return true
+		}
+	}
+	return false
+}
+
+// lower records note and lowers s to grade if that is worse.
+func (s *Section) lower(grade, note string) {
+	s.Notes = append(s.Notes, note)
+	if rank(grade) > rank(s.Grade) {
+		s.Grade = grade
+	}
+}
+
+func rank(grade string) int {
+	switch grade {
+	case GradeMedium:
+		return 1
+	case GradeLow:
+		return 2
+	}
+	return 0
+}
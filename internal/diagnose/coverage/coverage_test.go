@@ -0,0 +1,75 @@
+package coverage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestGrade(t *testing.T) {
+	if _, ok := Grade("session", Telemetry{}); ok {
+		t.Error("session section graded")
+	}
+
+	s, ok := Grade("dns", Telemetry{Received: map[events.EventType]int{events.EventDNS: 10}})
+	if !ok || s.Grade != GradeHigh || len(s.Notes) != 0 {
+		t.Errorf("clean dns = %+v, %v", s, ok)
+	}
+
+	s, _ = Grade("udp", Telemetry{FailedProbes: []string{"kprobe_udp_sendmsg", "kprobe_vfs_fsync"}})
+	if s.Grade != GradeMedium || !reflect.DeepEqual(s.Notes, []string{"not attached: kprobe_udp_sendmsg"}) {
+		t.Errorf("udp with one side blind = %+v", s)
+	}
+	s, _ = Grade("udp", Telemetry{FailedProbes: []string{"kprobe_udp_sendmsg", "kprobe_udp_recvmsg"}})
+	if s.Grade != GradeLow {
+		t.Errorf("udp with both sides blind = %+v", s)
+	}
+
+	s, _ = Grade("fs", Telemetry{
+		Received: map[events.EventType]int{events.EventRead: 60, events.EventWrite: 40, events.EventDNS: 1000},
+		Unkept:   map[events.EventType]int{events.EventRead: 20, events.EventDNS: 900},
+	})
+	if s.Grade != GradeMedium || !reflect.DeepEqual(s.Notes, []string{"20 of 100 events (20%) sampled out or past the event budget"}) {
+		t.Errorf("sampled fs = %+v", s)
+	}
+	s, _ = Grade("dns", Telemetry{
+		Received: map[events.EventType]int{events.EventDNS: 1000},
+		Unkept:   map[events.EventType]int{events.EventDNS: 900},
+		DNSDrops: 3,
+	})
+	if s.Grade != GradeLow || len(s.Notes) != 2 {
+		t.Errorf("mostly lost dns = %+v", s)
+	}
+
+	s, _ = Grade("swap", Telemetry{LowPrivilege: true, RingBufferErrors: 5})
+	if s.Grade != GradeHigh {
+		t.Errorf("cgroupfs section downgraded by BPF telemetry: %+v", s)
+	}
+	s, _ = Grade("tcp", Telemetry{LowPrivilege: true})
+	if s.Grade != GradeLow || s.Notes[0] != "no BPF probes in low-privilege mode" {
+		t.Errorf("low-privilege tcp = %+v", s)
+	}
+	s, _ = Grade("stacks", Telemetry{FilteredDrops: 2})
+	if s.Grade != GradeMedium || s.Notes[0] != "2 events dropped in userspace" {
+		t.Errorf("stacks with userspace drops = %+v", s)
+	}
+}
+
+func TestGrades(t *testing.T) {
+	got := Grades(Telemetry{
+		FailedProbes: []string{"tracepoint_tcp_probe"},
+		Received:     map[events.EventType]int{events.EventSwap: 4},
+	})
+	var ids []string
+	for _, s := range got {
+		ids = append(ids, s.ID)
+	}
+	want := []string{"cgroup", "correlation", "cpuusage", "errors", "podcomm", "stacks", "swap", "tracing", "windowstalls"}
+	if !reflect.DeepEqual(ids, want) {
+		t.Errorf("graded sections = %v, want %v", ids, want)
+	}
+	if got[len(got)-1].Grade != GradeMedium {
+		t.Errorf("windowstalls = %+v", got[len(got)-1])
+	}
+}
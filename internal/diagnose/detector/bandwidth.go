@@ -0,0 +1,50 @@
+package detector
+
+import (
+	"fmt"
+
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+)
+
+// ScoreBandwidthSaturation adds a "bandwidth_saturation" issue for every pod
+// direction that ran at or above threshold of its capacity to issues, and
+// returns them re-ranked. Saturation is reported on its own rather than
+// folded into latency or errors: a pod held at its shaping limit queues and
+// drops packets in the CNI long before those show up as slow calls.
+func ScoreBandwidthSaturation(issues []Issue, sats []analyzer.BandwidthSaturation, threshold float64) []Issue {
+	if len(sats) == 0 {
+		return issues
+	}
+	for _, s := range sats {
+		limit := "node link speed"
+		if s.LimitSource == analyzer.BandwidthLimitAnnotation {
+			limit = "bandwidth annotation"
+		}
+		who := s.Direction
+		if s.Pod != "" {
+			who = s.Pod + " " + s.Direction
+		}
+		utilization := s.PeakBitsPerS / s.LimitBitsPerS
+		issue := Issue{
+			Message: fmt.Sprintf("Bandwidth saturation: %s peaked at %.1f Mbit/s, %.0f%% of its %.1f Mbit/s %s, in %d of %d intervals (threshold: %.0f%%)",
+				who, s.PeakBitsPerS/1e6, utilization*100, s.LimitBitsPerS/1e6, limit,
+				s.SaturatedIntervals, s.Intervals, threshold*100),
+			Rule:    "bandwidth_saturation",
+			Subject: who,
+			Targets: len(sats),
+			Samples: s.Intervals,
+		}
+		if s.Intervals > 0 {
+			issue.Frequency = float64(s.SaturatedIntervals) / float64(s.Intervals)
+		}
+		// Throughput cannot go far past its capacity, so the magnitude is how
+		// much of the headroom between the threshold and the limit was used.
+		if threshold < 1 {
+			issue.Magnitude = clamp01((utilization - threshold) / (1 - threshold))
+		} else if utilization >= threshold {
+			issue.Magnitude = 1
+		}
+		issues = append(issues, issue)
+	}
+	return rankIssues(issues)
+}
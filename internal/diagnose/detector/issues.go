@@ -2,13 +2,34 @@ package detector
 
 import (
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/diagnose/tracker"
 	"github.com/podtrace/podtrace/internal/events"
 )
 
+// DetectIssues returns the messages of ScoreIssues, most probable root
+// cause first.
 func DetectIssues(allEvents []*events.Event, errorRateThreshold, rttSpikeThreshold float64) []string {
-	var issues []string
+	scored := ScoreIssues(allEvents, errorRateThreshold, rttSpikeThreshold)
+	if len(scored) == 0 {
+		return nil
+	}
+	issues := make([]string, len(scored))
+	for i, issue := range scored {
+		issues[i] = issue.Message
+	}
+	return issues
+}
+
+// ScoreIssues runs every detection rule over allEvents and returns the
+// issues raised, ranked by score.
+func ScoreIssues(allEvents []*events.Event, errorRateThreshold, rttSpikeThreshold float64) []Issue {
+	var issues []Issue
 
 	var connectEvents []*events.Event
 	for _, e := range allEvents {
@@ -22,14 +43,30 @@ func DetectIssues(allEvents []*events.Event, errorRateThreshold, rttSpikeThresho
 
 	if len(connectEvents) > 0 {
 		errors := 0
+		failedTargets := make(map[string]struct{})
 		for _, e := range connectEvents {
 			if e.Error != 0 {
 				errors++
+				failedTargets[e.Target] = struct{}{}
 			}
 		}
-		errorRate := float64(errors) / float64(len(connectEvents)) * 100
-		if errorRate > errorRateThreshold {
-			issues = append(issues, fmt.Sprintf("High connection failure rate: %.1f%% (%d/%d) (threshold: %.1f%%)", errorRate, errors, len(connectEvents), errorRateThreshold))
+		// Failed legs of a race another leg won never reached the
+		// application, and each race is one connect, not one per leg.
+		connects := len(connectEvents)
+		if races := analyzer.AnalyzeConnectRaces(allEvents); races != nil {
+			errors -= races.LosingLegErrors
+			connects -= races.Wasted
+		}
+		errorRate := float64(errors) / float64(connects) * 100
+		if errors > 0 && errorRate > errorRateThreshold {
+			issues = append(issues, Issue{
+				Message:   fmt.Sprintf("High connection failure rate: %.1f%% (%d/%d) (threshold: %.1f%%)", errorRate, errors, connects, errorRateThreshold),
+				Rule:      "connect_failures",
+				Frequency: float64(errors) / float64(connects),
+				Magnitude: excess(errorRate, errorRateThreshold),
+				Targets:   len(failedTargets),
+				Samples:   connects,
+			})
 		}
 	}
 
@@ -45,18 +82,36 @@ func DetectIssues(allEvents []*events.Event, errorRateThreshold, rttSpikeThresho
 
 	if len(tcpEvents) > 0 {
 		spikes := 0
+		var spikeMS float64
+		spikeTargets := make(map[string]struct{})
 		for _, e := range tcpEvents {
-			if float64(e.LatencyNS)/float64(config.NSPerMS) > rttSpikeThreshold {
+			if ms := float64(e.LatencyNS) / float64(config.NSPerMS); ms > rttSpikeThreshold {
 				spikes++
+				spikeMS += ms
+				spikeTargets[e.Target] = struct{}{}
 			}
 		}
 		spikeRate := float64(spikes) / float64(len(tcpEvents)) * 100
 		if spikeRate > config.SpikeRateThreshold {
-			issues = append(issues, fmt.Sprintf("High TCP RTT spike rate: %.1f%% (%d/%d) (threshold: %.1fms)", spikeRate, spikes, len(tcpEvents), rttSpikeThreshold))
+			issues = append(issues, Issue{
+				Message:   fmt.Sprintf("High TCP RTT spike rate: %.1f%% (%d/%d) (threshold: %.1fms)", spikeRate, spikes, len(tcpEvents), rttSpikeThreshold),
+				Rule:      "tcp_rtt_spikes",
+				Frequency: float64(spikes) / float64(len(tcpEvents)),
+				Magnitude: excess(spikeMS/float64(spikes), rttSpikeThreshold),
+				Targets:   len(spikeTargets),
+				Samples:   len(tcpEvents),
+			})
 		}
 	}
 
+	// Per resource: the peak utilization, how many samples were taken, how
+	// many of them were over the warning threshold and in which cgroups.
+	type resourceUsage struct {
+		samples, over int
+		cgroups       map[uint64]struct{}
+	}
 	var resourceAlerts = make(map[string]int)
+	var resourceUsages = make(map[string]*resourceUsage)
 	for _, e := range allEvents {
 		if e == nil {
 			continue
@@ -86,6 +141,16 @@ func DetectIssues(allEvents []*events.Event, errorRateThreshold, rttSpikeThresho
 			if current, ok := resourceAlerts[key]; !ok || utilization > current {
 				resourceAlerts[key] = utilization
 			}
+			usage, ok := resourceUsages[key]
+			if !ok {
+				usage = &resourceUsage{cgroups: make(map[uint64]struct{})}
+				resourceUsages[key] = usage
+			}
+			usage.samples++
+			if utilization >= config.AlertWarnPct {
+				usage.over++
+				usage.cgroups[e.CgroupID] = struct{}{}
+			}
 		}
 	}
 
@@ -104,11 +169,475 @@ func DetectIssues(allEvents []*events.Event, errorRateThreshold, rttSpikeThresho
 		}
 
 		if severity != "" {
-			issues = append(issues, fmt.Sprintf("Resource limit %s: %s - %d%% utilization (threshold: %d%% warning, %d%% critical, %d%% emergency)",
-				severity, resourceName, maxUtil,
-				config.AlertWarnPct, config.AlertCritPct, config.AlertEmergPct))
+			usage := resourceUsages[resourceName]
+			magnitude := 1.0
+			if config.AlertWarnPct < 100 {
+				magnitude = float64(maxUtil-config.AlertWarnPct) / float64(100-config.AlertWarnPct)
+			}
+			issues = append(issues, Issue{
+				Message: fmt.Sprintf("Resource limit %s: %s - %d%% utilization (threshold: %d%% warning, %d%% critical, %d%% emergency)",
+					severity, resourceName, maxUtil,
+					config.AlertWarnPct, config.AlertCritPct, config.AlertEmergPct),
+				Rule:      "resource_limit",
+				Subject:   resourceName,
+				Frequency: float64(usage.over) / float64(usage.samples),
+				Magnitude: magnitude,
+				Targets:   len(usage.cgroups),
+				Samples:   usage.samples,
+			})
+		}
+	}
+
+	issues = append(issues, detectAMQPBacklog(allEvents)...)
+	issues = append(issues, detectCPUPlacement(allEvents)...)
+	issues = append(issues, detectSwap(allEvents)...)
+	issues = append(issues, detectTmpfsMemory(allEvents)...)
+	issues = append(issues, detectFsNotifyStorm(allEvents)...)
+	issues = append(issues, detectConnectionChurn(allEvents)...)
+	issues = append(issues, detectImagePulls(allEvents)...)
+	issues = append(issues, detectDisruptions(allEvents)...)
+	issues = append(issues, detectPauses(allEvents)...)
+	issues = append(issues, detectHTTPStatus(allEvents)...)
+
+	return rankIssues(issues)
+}
+
+// detectAMQPBacklog flags queues whose consumers ended the window holding at
+// least PODTRACE_AMQP_UNACKED_WARN unacknowledged deliveries: the broker has
+// handed the messages over and the application is not keeping up.
+func detectAMQPBacklog(allEvents []*events.Event) []Issue {
+	var amqpEvents []*events.Event
+	for _, e := range allEvents {
+		if e != nil && e.Type == events.EventAMQP {
+			amqpEvents = append(amqpEvents, e)
+		}
+	}
+	if len(amqpEvents) == 0 || config.AMQPUnackedWarn <= 0 {
+		return nil
+	}
+	var issues []Issue
+	for _, q := range analyzer.AnalyzeAMQP(amqpEvents).Queues {
+		if q.Unacked >= config.AMQPUnackedWarn {
+			issue := Issue{
+				Message: fmt.Sprintf("AMQP unacked backlog: queue %q holds %d unacknowledged deliveries (peak %d, ack p95 %.2fms) (threshold: %d)",
+					q.Queue, q.Unacked, q.PeakUnacked, q.P95AckMS, config.AMQPUnackedWarn),
+				Rule:      "amqp_backlog",
+				Subject:   q.Queue,
+				Magnitude: excess(float64(q.Unacked), float64(config.AMQPUnackedWarn)),
+				Samples:   q.Deliveries,
+			}
+			if q.Deliveries > 0 {
+				issue.Frequency = float64(q.Unacked) / float64(q.Deliveries)
+			}
+			issues = append(issues, issue)
+		}
+	}
+	// Every backlogged queue counts towards each one's blast radius: several
+	// stalled queues point at the consumer rather than at one queue.
+	for i := range issues {
+		issues[i].Targets = len(issues)
+	}
+	return issues
+}
+
+// detectCPUPlacement flags processes whose memory sits on NUMA nodes they
+// cannot run on, and processes left waiting for their CPUs: on dedicated,
+// pinned nodes both show up as latency the application cannot explain.
+func detectCPUPlacement(allEvents []*events.Event) []Issue {
+	placement := analyzer.AnalyzeCPUPlacement(allEvents)
+	if placement == nil {
+		return nil
+	}
+	var numaIssues, runqIssues []Issue
+	for _, p := range placement.Processes {
+		who := fmt.Sprintf("pid %d", p.PID)
+		if p.Process != "" {
+			who += " (" + p.Process + ")"
+		}
+		if p.RemoteMemoryIntervals > 0 {
+			numaIssues = append(numaIssues, Issue{
+				Message: fmt.Sprintf("NUMA misplacement: %s runs on node %s (CPUs %s) but %.0f%% of its memory (%s) is on other nodes in %d of %d samples; every cache miss pays the cross-node hop (threshold: %.0f%%)",
+					who, p.CPUNodes, p.CPUs, p.RemoteMemoryShare*100, analyzer.FormatBytes(p.RemoteMemoryBytes),
+					p.RemoteMemoryIntervals, p.Samples, config.NUMARemoteMemoryWarn*100),
+				Rule:      "numa_remote_memory",
+				Subject:   who,
+				Frequency: float64(p.RemoteMemoryIntervals) / float64(p.Samples),
+				Magnitude: excess(p.RemoteMemoryShare, config.NUMARemoteMemoryWarn),
+				Samples:   p.Samples,
+			})
 		}
+		if p.RunQueueIntervals > 0 {
+			where := "on CPUs " + p.CPUs
+			if p.Pinned && p.SharedCores != "" {
+				where = fmt.Sprintf("pinned to CPUs %s whose cores (%s) are shared with other workloads", p.CPUs, p.SharedCores)
+			} else if p.Pinned {
+				where = "pinned to CPUs " + p.CPUs
+			}
+			runqIssues = append(runqIssues, Issue{
+				Message: fmt.Sprintf("CPU contention: %s waited for a CPU %.0f%% of the time (peak %.0f%%), %s (threshold: %.0f%%)",
+					who, p.RunQueueShare*100, p.PeakRunQueueShare*100, where, config.RunQueueWaitWarn*100),
+				Rule:      "runqueue_wait",
+				Subject:   who,
+				Frequency: float64(p.RunQueueIntervals) / float64(p.Samples),
+				Magnitude: excess(p.PeakRunQueueShare, config.RunQueueWaitWarn),
+				Samples:   p.Samples,
+			})
+		}
+	}
+	for i := range numaIssues {
+		numaIssues[i].Targets = len(numaIssues)
+	}
+	for i := range runqIssues {
+		runqIssues[i].Targets = len(runqIssues)
 	}
+	return append(numaIssues, runqIssues...)
+}
 
+// detectSwap flags memory-limited cgroups that moved pages to or from swap:
+// with swap enabled on the node, a pod at its limit is paged out instead of
+// OOM-killed, and its latency degrades without any error.
+func detectSwap(allEvents []*events.Event) []Issue {
+	var issues []Issue
+	for _, c := range analyzer.AnalyzeSwap(allEvents) {
+		if c.ActiveIntervals == 0 || c.MemoryLimitBytes == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("Swapping: %s paged %s out and %s in during %d of %d intervals despite its %s memory limit, %s in swap at peak",
+			c.Cgroup, analyzer.FormatBytes(c.SwapOutBytes), analyzer.FormatBytes(c.SwapInBytes),
+			c.ActiveIntervals, c.Samples, analyzer.FormatBytes(c.MemoryLimitBytes), analyzer.FormatBytes(c.PeakSwapBytes))
+		if c.ZswapOutBytes+c.ZswapInBytes > 0 {
+			msg += fmt.Sprintf(" (zswap: %s out, %s in)", analyzer.FormatBytes(c.ZswapOutBytes), analyzer.FormatBytes(c.ZswapInBytes))
+		}
+		limit := float64(c.MemoryLimitBytes)
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "swap_activity",
+			Subject:   c.Cgroup,
+			Frequency: float64(c.ActiveIntervals) / float64(c.Samples),
+			Magnitude: clamp01(max(float64(c.SwapOutBytes+c.ZswapOutBytes), float64(c.PeakSwapBytes+c.PeakZswapBytes)) / limit),
+			Samples:   c.Samples,
+		})
+	}
+	for i := range issues {
+		issues[i].Targets = len(issues)
+	}
+	return issues
+}
+
+// detectTmpfsMemory flags memory-limited cgroups whose tmpfs files held at
+// least PODTRACE_TMPFS_MEMORY_WARN of the limit: writes to an emptyDir with
+// medium Memory or to /dev/shm are charged to the container's memory, so a
+// growing scratch directory ends in an OOM kill nothing else explains.
+func detectTmpfsMemory(allEvents []*events.Event) []Issue {
+	var issues []Issue
+	for _, c := range analyzer.AnalyzeTmpfs(allEvents, config.TmpfsMemoryWarn) {
+		if c.OverIntervals == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("tmpfs counts against the memory limit: %s held %s in tmpfs at peak, %.0f%% of its %s memory limit",
+			c.Cgroup, analyzer.FormatBytes(c.PeakBytes), c.PeakLimitShare*100, analyzer.FormatBytes(c.MemoryLimitBytes))
+		if c.GrowthBytes > 0 {
+			msg += fmt.Sprintf(", growing %s during the trace", analyzer.FormatBytes(c.GrowthBytes))
+		}
+		if c.PeakMemoryBytes > 0 {
+			msg += fmt.Sprintf(", with %s of memory in use at peak", analyzer.FormatBytes(c.PeakMemoryBytes))
+		}
+		msg += fmt.Sprintf("; files in emptyDirs with medium Memory and /dev/shm are charged to the container (threshold: %.0f%%)",
+			config.TmpfsMemoryWarn*100)
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "tmpfs_memory",
+			Subject:   c.Cgroup,
+			Frequency: float64(c.OverIntervals) / float64(c.Samples),
+			Magnitude: excess(c.PeakLimitShare, config.TmpfsMemoryWarn),
+			Samples:   c.Samples,
+		})
+	}
+	for i := range issues {
+		issues[i].Targets = len(issues)
+	}
 	return issues
 }
+
+// detectConnectionChurn raises one issue for the targets that get a fresh
+// connection for nearly every send: keep-alive is off, and each request
+// pays for a new handshake.
+func detectConnectionChurn(allEvents []*events.Event) []Issue {
+	var churned []tracker.ConnectionChurn
+	connects, sends := 0, 0
+	var cost time.Duration
+	for _, c := range tracker.AnalyzeConnectionChurn(allEvents) {
+		if !c.Excessive {
+			continue
+		}
+		churned = append(churned, c)
+		connects += c.Connects
+		sends += c.Sends
+		cost += c.Cost
+	}
+	if len(churned) == 0 {
+		return nil
+	}
+	worst := churned[0]
+	msg := fmt.Sprintf("Connection churn (keep-alive likely disabled): %s got %d connects for %d sends (%.0f%% fresh), about %s spent on connection setup",
+		worst.Target, worst.Connects, worst.Sends, worst.FreshRatio*100, worst.Cost.Round(time.Millisecond))
+	if len(churned) > 1 {
+		msg += fmt.Sprintf("; %d targets in all, about %s", len(churned), cost.Round(time.Millisecond))
+	}
+	msg += fmt.Sprintf(" (threshold: %.0f%% fresh)", config.ConnectionChurnWarn*100)
+	freshRatio := 1.0
+	if sends > connects {
+		freshRatio = float64(connects) / float64(sends)
+	}
+	return []Issue{{
+		Message:   msg,
+		Rule:      "connection_churn",
+		Frequency: freshRatio,
+		Magnitude: excess(worst.FreshRatio, config.ConnectionChurnWarn),
+		Targets:   len(churned),
+		Samples:   connects,
+	}}
+}
+
+// detectFsNotifyStorm flags cgroups that register inotify watches or
+// fanotify marks faster than PODTRACE_FSNOTIFY_WATCH_RATE_WARN, hit the
+// per-user limits, or let their queues overflow: config reloaders watching
+// every file of a volume cost the node kernel memory and CPU, and once the
+// limits are reached every pod of the same user misses file changes.
+func detectFsNotifyStorm(allEvents []*events.Event) []Issue {
+	var issues []Issue
+	for _, c := range analyzer.AnalyzeFsNotify(allEvents) {
+		if c.StormIntervals == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("inotify/fanotify storm: %s registered %d watches and %d marks (peak %.0f/s) with %d of %d intervals flagged",
+			c.Cgroup, c.Watches, c.Marks, c.PeakRegistrationRate, c.StormIntervals, c.Samples)
+		if c.LimitHits > 0 {
+			msg += fmt.Sprintf(", %d refused by the per-user limit", c.LimitHits)
+		}
+		if c.Overflows > 0 {
+			msg += fmt.Sprintf(", %d events dropped on full queues", c.Overflows)
+		}
+		msg += fmt.Sprintf(" (threshold: %.0f/s)", config.FsNotifyWatchRateWarn)
+		// Refused watches and dropped events mean file changes are missed,
+		// whatever the rate.
+		magnitude := excess(c.PeakRegistrationRate, config.FsNotifyWatchRateWarn)
+		if c.LimitHits+c.Overflows > 0 {
+			magnitude = 1
+		}
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "fsnotify_storm",
+			Subject:   c.Cgroup,
+			Frequency: float64(c.StormIntervals) / float64(c.Samples),
+			Magnitude: magnitude,
+			Samples:   c.Samples,
+		})
+	}
+	for i := range issues {
+		issues[i].Targets = len(issues)
+	}
+	return issues
+}
+
+// detectImagePulls flags registries the traced pods pulled slowly from, or
+// failed to pull from: a slow or rate-limiting registry delays every start
+// of the pod, and a failed pull keeps it from starting at all.
+func detectImagePulls(allEvents []*events.Event) []Issue {
+	pulls := analyzer.AnalyzeImagePulls(allEvents)
+	if pulls == nil {
+		return nil
+	}
+	slowMS := float64(config.ImagePullSlowWarn) / float64(time.Millisecond)
+	var issues []Issue
+	for _, r := range pulls.Registries {
+		if r.Slow+r.Failed == 0 {
+			continue
+		}
+		msg := fmt.Sprintf("image pulls from %s: %d of %d", r.Registry, r.Slow, r.Pulls)
+		msg += fmt.Sprintf(" took over %s (slowest %.1fs", config.ImagePullSlowWarn, r.MaxPullMS/1000)
+		if r.ThroughputBps > 0 {
+			msg += fmt.Sprintf(", %s/s on average", analyzer.FormatBytes(uint64(r.ThroughputBps)))
+		}
+		msg += ")"
+		if r.Failed > 0 {
+			reasons := make([]string, 0, len(r.Failures))
+			for reason, n := range r.Failures {
+				reasons = append(reasons, fmt.Sprintf("%s: %d", reason, n))
+			}
+			sort.Strings(reasons)
+			msg += fmt.Sprintf(", %d failed (%s)", r.Failed, strings.Join(reasons, ", "))
+		}
+		if r.ConnectMS > 0 {
+			msg += fmt.Sprintf(", registry connect %.1fms", r.ConnectMS)
+		}
+		// A failed pull keeps the pod from starting, however fast the rest.
+		magnitude := excess(r.MaxPullMS, slowMS)
+		if r.Failed > 0 {
+			magnitude = 1
+		}
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "image_pull",
+			Subject:   r.Registry,
+			Frequency: float64(r.Slow+r.Failed) / float64(r.Pulls),
+			Magnitude: magnitude,
+			Samples:   r.Pulls,
+		})
+	}
+	for i := range issues {
+		issues[i].Targets = len(issues)
+	}
+	return issues
+}
+
+// disruptionLatencyRise is how many times the p95 latency has to rise across
+// a disruption for it to be flagged.
+const disruptionLatencyRise = 2.0
+
+// detectDisruptions flags the evictions, preemptions and node pressure on the
+// traced node that the traced pods' latency or errors rose across, so that a
+// spike is put down to the node rather than to the application.
+func detectDisruptions(allEvents []*events.Event) []Issue {
+	var first, last uint64
+	for _, e := range allEvents {
+		if e == nil {
+			continue
+		}
+		if first == 0 || e.Timestamp < first {
+			first = e.Timestamp
+		}
+		last = max(last, e.Timestamp)
+	}
+	start := (&events.Event{Timestamp: first}).TimestampTime()
+	end := (&events.Event{Timestamp: last}).TimestampTime().Add(time.Nanosecond)
+	stats := analyzer.AnalyzeDisruptions(allEvents, start, end)
+	var issues []Issue
+	for _, s := range stats {
+		if s.Kind == events.DisruptionPressureCleared {
+			continue
+		}
+		rise := s.LatencyRise()
+		errorsRose := s.After.Errors > 0 && s.After.ErrorRate() >= 2*s.Before.ErrorRate()
+		if rise < disruptionLatencyRise && !errorsRose {
+			continue
+		}
+		msg := fmt.Sprintf("%s of %s", strings.ReplaceAll(s.Kind, "_", " "), s.Subject)
+		if s.Resource != "" {
+			msg += " (" + s.Resource + ")"
+		}
+		if s.Node != "" && s.Node != s.Subject {
+			msg += " on node " + s.Node
+		}
+		msg += fmt.Sprintf(" at %s coincided with", s.At.Format("15:04:05"))
+		var what []string
+		if rise >= disruptionLatencyRise {
+			what = append(what, fmt.Sprintf(" p95 latency rising %.2fms -> %.2fms", s.Before.P95LatencyMS, s.After.P95LatencyMS))
+		}
+		if errorsRose {
+			what = append(what, fmt.Sprintf(" errors rising %.2f%% -> %.2f%%", s.Before.ErrorRate(), s.After.ErrorRate()))
+		}
+		msg += strings.Join(what, " and")
+		magnitude := excess(rise, 1)
+		if errorsRose {
+			magnitude = max(magnitude, excess(s.After.ErrorRate(), s.Before.ErrorRate()))
+		}
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "pod_disruption",
+			Subject:   s.Kind + " " + s.Subject + " " + s.At.Format(time.RFC3339),
+			Frequency: s.After.ErrorRate() / 100,
+			Magnitude: magnitude,
+			Samples:   s.Before.Events + s.After.Events,
+		})
+	}
+	return issues
+}
+
+// detectPauses flags every interval in which a traced container was frozen
+// or its main process stopped: the gap it leaves in the events is otherwise
+// read as a network or disk stall.
+func detectPauses(allEvents []*events.Event) []Issue {
+	var first, last uint64
+	samples := 0
+	for _, e := range allEvents {
+		if e == nil {
+			continue
+		}
+		samples++
+		if first == 0 || e.Timestamp < first {
+			first = e.Timestamp
+		}
+		last = max(last, e.Timestamp)
+	}
+	start := (&events.Event{Timestamp: first}).TimestampTime()
+	end := (&events.Event{Timestamp: last}).TimestampTime().Add(time.Nanosecond)
+	var issues []Issue
+	for _, p := range analyzer.AnalyzePauses(allEvents, start, end) {
+		var how string
+		switch p.Kind {
+		case events.PauseFreeze:
+			how = "was frozen through its cgroup"
+		case events.PauseStop:
+			how = fmt.Sprintf("had its main process %d stopped by a signal", p.PID)
+		case events.PauseTraceStop:
+			how = fmt.Sprintf("had its main process %d held by a tracer", p.PID)
+		default:
+			how = "was paused (" + p.Kind + ")"
+		}
+		msg := fmt.Sprintf("%s %s for %.1fs from %s", p.Container, how, p.Duration().Seconds(), p.Start.Format("15:04:05"))
+		if p.Ongoing {
+			msg += " until the end of the trace"
+		}
+		msg += "; the gap in its events is the pause, not the network or the disk"
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "container_paused",
+			Subject:   p.Container + " " + p.Start.Format(time.RFC3339),
+			Frequency: p.Duration().Seconds() / end.Sub(start).Seconds(),
+			Magnitude: excess(p.Duration().Seconds(), config.PauseInterval.Seconds()),
+			Targets:   1,
+			Samples:   samples,
+		})
+	}
+	return issues
+}
+
+// detectHTTPStatus flags the endpoints that answered a burst of 5xx within
+// PODTRACE_HTTP_5XX_BURST_WINDOW, and those that throttled the traced pods
+// with 429s: the two patterns app teams ask about first.
+func detectHTTPStatus(allEvents []*events.Event) []Issue {
+	var bursts, throttles []Issue
+	for _, s := range analyzer.AnalyzeHTTPStatus(allEvents, time.Time{}, time.Time{}) {
+		if b := s.Burst; b != nil && b.Status5xx >= config.HTTP5xxBurstMin {
+			bursts = append(bursts, Issue{
+				Message: fmt.Sprintf("HTTP 5xx burst: %s answered %d of %d responses with 5xx within %s from %s (%d of %d over the run, threshold: %d)",
+					s.Endpoint, b.Status5xx, b.Responses, config.HTTP5xxBurstWindow, b.Start.Format("15:04:05"),
+					s.Status5xx, s.Responses, config.HTTP5xxBurstMin),
+				Rule:      "http_5xx_burst",
+				Subject:   s.Endpoint,
+				Frequency: float64(b.Status5xx) / float64(b.Responses),
+				Magnitude: excess(float64(b.Status5xx), float64(config.HTTP5xxBurstMin)),
+				Samples:   s.Responses,
+			})
+		}
+		if t := s.Throttled; t != nil && t.Responses >= config.HTTPThrottleMin {
+			throttles = append(throttles, Issue{
+				Message: fmt.Sprintf("HTTP throttling: %s answered %d of %d responses with 429 Too Many Requests between %s and %s (threshold: %d)",
+					s.Endpoint, t.Responses, s.Responses, t.First.Format("15:04:05"), t.Last.Format("15:04:05"), config.HTTPThrottleMin),
+				Rule:      "http_throttling",
+				Subject:   s.Endpoint,
+				Frequency: float64(t.Responses) / float64(s.Responses),
+				Magnitude: excess(float64(t.Responses), float64(config.HTTPThrottleMin)),
+				Samples:   s.Responses,
+			})
+		}
+	}
+	for i := range bursts {
+		bursts[i].Targets = len(bursts)
+	}
+	for i := range throttles {
+		throttles[i].Targets = len(throttles)
+	}
+	return append(bursts, throttles...)
+}
@@ -3,8 +3,12 @@ package detector
 import (
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/numa"
+	"github.com/podtrace/podtrace/internal/resource"
 )
 
 func TestDetectIssues_HighConnectionFailureRate(t *testing.T) {
@@ -294,3 +298,250 @@ func BenchmarkDetectIssues(b *testing.B) {
 func contains(s, substr string) bool {
 	return strings.Contains(s, substr)
 }
+
+func TestDetectIssues_AMQPUnackedBacklog(t *testing.T) {
+	evts := []*events.Event{
+		{Type: events.EventAMQP, Timestamp: 1, CorrelationID: 1, TCPState: events.AMQPBasicConsume, Target: "jobs", Details: "c1"},
+	}
+	for tag := uint64(1); tag <= 150; tag++ {
+		evts = append(evts, &events.Event{Type: events.EventAMQP, Timestamp: 1 + tag, CorrelationID: 1, TCPState: events.AMQPBasicDeliver, Target: "c1", Bytes: tag})
+	}
+	issues := DetectIssues(evts, 10.0, 100.0)
+	found := false
+	for _, issue := range issues {
+		if strings.Contains(issue, "AMQP unacked backlog") && strings.Contains(issue, `"jobs" holds 150`) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected AMQP backlog issue, got %v", issues)
+	}
+
+	acked := append(evts, &events.Event{Type: events.EventAMQP, Timestamp: 500, CorrelationID: 1, TCPState: events.AMQPBasicAck, Details: "out multiple", Bytes: 150})
+	for _, issue := range DetectIssues(acked, 10.0, 100.0) {
+		if strings.Contains(issue, "AMQP") {
+			t.Errorf("backlog cleared by a multiple ack, got %q", issue)
+		}
+	}
+}
+
+func TestDetectIssues_CPUPlacement(t *testing.T) {
+	sample := func(ts uint64, remote uint64, runq time.Duration) *events.Event {
+		s := numa.Sample{
+			Placement: numa.Placement{
+				CPUs: numa.CPUSet{2, 3}, CPUNodes: numa.CPUSet{1}, Pinned: true, SharedCores: numa.CPUSet{2, 3},
+				MemoryByNode: map[int]uint64{0: remote, 1: 1 << 30}, LastCPU: 2,
+			},
+			IntervalNS: uint64(10 * time.Second),
+			RunQueueNS: uint64(runq),
+		}
+		return &events.Event{Type: events.EventCPUPlacement, Timestamp: ts, PID: 42, ProcessName: "app", Details: numa.FormatDetails(s)}
+	}
+	issues := ScoreIssues([]*events.Event{
+		sample(1, 3<<30, 2*time.Second),
+		sample(2, 3<<30, 4*time.Second),
+	}, 10.0, 100.0)
+	var rules []string
+	for _, issue := range issues {
+		rules = append(rules, issue.Rule)
+	}
+	if len(issues) != 2 {
+		t.Fatalf("issues = %v", rules)
+	}
+	for _, issue := range issues {
+		switch issue.Rule {
+		case "numa_remote_memory":
+			if !strings.Contains(issue.Message, "pid 42 (app) runs on node 1 (CPUs 2-3) but 75% of its memory (3.00 GB) is on other nodes in 2 of 2 samples") {
+				t.Errorf("numa message = %q", issue.Message)
+			}
+		case "runqueue_wait":
+			if !strings.Contains(issue.Message, "waited for a CPU 30% of the time (peak 40%), pinned to CPUs 2-3 whose cores (2-3) are shared") {
+				t.Errorf("run queue message = %q", issue.Message)
+			}
+		default:
+			t.Errorf("unexpected rule %q", issue.Rule)
+		}
+	}
+
+	if issues := ScoreIssues([]*events.Event{sample(1, 0, 0)}, 10.0, 100.0); len(issues) != 0 {
+		t.Errorf("well-placed process flagged: %+v", issues)
+	}
+}
+
+func TestDetectIssues_SwapActivity(t *testing.T) {
+	sample := func(cgroup string, s resource.SwapSample) *events.Event {
+		return &events.Event{Type: events.EventSwap, Target: cgroup, Details: resource.FormatSwapDetails(s)}
+	}
+	issues := ScoreIssues([]*events.Event{
+		sample("/pod-a", resource.SwapSample{SwapOutBytes: 64 << 20, SwapBytes: 64 << 20, MemoryLimitBytes: 256 << 20}),
+		sample("/pod-a", resource.SwapSample{SwapInBytes: 16 << 20, SwapBytes: 64 << 20, MemoryLimitBytes: 256 << 20}),
+		sample("/pod-a", resource.SwapSample{SwapBytes: 64 << 20, MemoryLimitBytes: 256 << 20}),
+		// Unlimited cgroups may use swap as the node allows.
+		sample("/pod-b", resource.SwapSample{SwapOutBytes: 1 << 20, SwapBytes: 1 << 20}),
+	}, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Rule != "swap_activity" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	want := "Swapping: /pod-a paged 64.00 MB out and 16.00 MB in during 2 of 3 intervals despite its 256.00 MB memory limit, 64.00 MB in swap at peak"
+	if issues[0].Message != want {
+		t.Errorf("message = %q, want %q", issues[0].Message, want)
+	}
+	if issues[0].Magnitude != 0.25 {
+		t.Errorf("magnitude = %v, want 0.25", issues[0].Magnitude)
+	}
+}
+
+func TestDetectIssues_TmpfsMemory(t *testing.T) {
+	sample := func(cgroup string, s resource.TmpfsSample) *events.Event {
+		return &events.Event{Type: events.EventTmpfs, Target: cgroup, Details: resource.FormatTmpfsDetails(s)}
+	}
+	issues := ScoreIssues([]*events.Event{
+		sample("/pod-a", resource.TmpfsSample{ShmemBytes: 32 << 20, MemoryBytes: 128 << 20, MemoryLimitBytes: 256 << 20}),
+		sample("/pod-a", resource.TmpfsSample{ShmemBytes: 96 << 20, MemoryBytes: 224 << 20, MemoryLimitBytes: 256 << 20}),
+		// A small /dev/shm is not worth a finding.
+		sample("/pod-b", resource.TmpfsSample{ShmemBytes: 4 << 20, MemoryBytes: 64 << 20, MemoryLimitBytes: 256 << 20}),
+		// Without a limit there is nothing to run into.
+		sample("/pod-c", resource.TmpfsSample{ShmemBytes: 1 << 30, MemoryBytes: 2 << 30}),
+	}, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Rule != "tmpfs_memory" || issues[0].Code != "PODTRACE-MEM-002" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	want := "tmpfs counts against the memory limit: /pod-a held 96.00 MB in tmpfs at peak, 38% of its 256.00 MB memory limit, " +
+		"growing 64.00 MB during the trace, with 224.00 MB of memory in use at peak; " +
+		"files in emptyDirs with medium Memory and /dev/shm are charged to the container (threshold: 20%)"
+	if issues[0].Message != want {
+		t.Errorf("message = %q, want %q", issues[0].Message, want)
+	}
+	if issues[0].Frequency != 0.5 {
+		t.Errorf("frequency = %v, want 0.5", issues[0].Frequency)
+	}
+}
+
+func TestDetectIssues_FsNotifyStorm(t *testing.T) {
+	sample := func(cgroup string, c events.FsNotifyCounts) *events.Event {
+		return &events.Event{Type: events.EventFsNotify, Target: cgroup, LatencyNS: 10e9, Details: c.String()}
+	}
+	issues := ScoreIssues([]*events.Event{
+		sample("/reloader", events.FsNotifyCounts{Watches: 20000}),
+		sample("/reloader", events.FsNotifyCounts{Watches: 40, Overflows: 12}),
+		sample("/app", events.FsNotifyCounts{Watches: 5}),
+	}, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Rule != "fsnotify_storm" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	want := "inotify/fanotify storm: /reloader registered 20040 watches and 0 marks (peak 2000/s) with 2 of 2 intervals flagged, 12 events dropped on full queues (threshold: 100/s)"
+	if issues[0].Message != want {
+		t.Errorf("message = %q, want %q", issues[0].Message, want)
+	}
+	if issues[0].Magnitude != 1 {
+		t.Errorf("magnitude = %v, want 1", issues[0].Magnitude)
+	}
+}
+
+func TestDetectIssues_ImagePull(t *testing.T) {
+	pull := func(image string, pull time.Duration, size uint64, failed bool, d events.ImagePullDetails) *events.Event {
+		e := &events.Event{Type: events.EventImagePull, Target: image, LatencyNS: uint64(pull), Bytes: size, Details: d.String()}
+		if failed {
+			e.Error = 1
+		}
+		return e
+	}
+	issues := ScoreIssues([]*events.Event{
+		pull("ghcr.io/acme/app:1", 60*time.Second, 60<<20, false, events.ImagePullDetails{Registry: "ghcr.io", RegistryRTTNS: uint64(90 * time.Millisecond)}),
+		pull("ghcr.io/acme/init:1", 0, 0, true, events.ImagePullDetails{Registry: "ghcr.io", Reason: "RateLimited"}),
+		pull("quay.io/x:1", 2*time.Second, 10<<20, false, events.ImagePullDetails{Registry: "quay.io"}),
+	}, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Rule != "image_pull" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	want := "image pulls from ghcr.io: 1 of 2 took over 30s (slowest 60.0s, 1.00 MB/s on average), 1 failed (RateLimited: 1), registry connect 90.0ms"
+	if issues[0].Message != want {
+		t.Errorf("message = %q, want %q", issues[0].Message, want)
+	}
+	if issues[0].Magnitude != 1 || issues[0].Frequency != 1 {
+		t.Errorf("magnitude %v, frequency %v", issues[0].Magnitude, issues[0].Frequency)
+	}
+}
+
+func TestDetectIssues_HTTPStatus(t *testing.T) {
+	resp := func(target string, at time.Duration, status string) *events.Event {
+		return &events.Event{Type: events.EventHTTPResp, Timestamp: uint64(time.Hour + at), Target: target, Details: status}
+	}
+	var evts []*events.Event
+	for i := 0; i < 6; i++ {
+		evts = append(evts, resp("GET /orders", time.Duration(i)*time.Second, "502"))
+	}
+	evts = append(evts, resp("GET /orders", time.Minute, "200"))
+	for i := 0; i < 3; i++ {
+		evts = append(evts, resp("POST /pay", time.Duration(i)*time.Second, "429"))
+	}
+	evts = append(evts, resp("GET /health", 0, "500"))
+
+	issues := ScoreIssues(evts, 10.0, 100.0)
+	if len(issues) != 2 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	rules := map[string]Issue{issues[0].Rule: issues[0], issues[1].Rule: issues[1]}
+	burst, ok := rules["http_5xx_burst"]
+	if !ok || burst.Code != "PODTRACE-HTTP-001" || burst.Subject != "GET /orders" || burst.Frequency != 1 || burst.Samples != 7 {
+		t.Errorf("burst issue = %+v", burst)
+	}
+	throttled, ok := rules["http_throttling"]
+	if !ok || throttled.Code != "PODTRACE-HTTP-002" || throttled.Frequency != 1 {
+		t.Errorf("throttling issue = %+v", throttled)
+	}
+}
+
+func TestDetectIssues_PodDisruption(t *testing.T) {
+	const base = uint64(1_000_000_000_000)
+	send := func(at, latency time.Duration) *events.Event {
+		return &events.Event{Type: events.EventTCPSend, Timestamp: base + uint64(at), LatencyNS: uint64(latency)}
+	}
+	disruption := func(at time.Duration, subject string, d events.DisruptionDetails) *events.Event {
+		return &events.Event{Type: events.EventDisruption, Timestamp: base + uint64(at), Target: subject, Details: d.String()}
+	}
+	evts := []*events.Event{
+		send(0, time.Millisecond),
+		send(10*time.Second, time.Millisecond),
+		disruption(20*time.Second, "batch/job-1", events.DisruptionDetails{Kind: events.DisruptionEviction, Resource: "memory", Node: "node-1"}),
+		send(25*time.Second, 8*time.Millisecond),
+		send(30*time.Second, 8*time.Millisecond),
+		disruption(40*time.Second, "node-1", events.DisruptionDetails{Kind: events.DisruptionPressureCleared, Resource: "memory", Node: "node-1"}),
+	}
+	issues := ScoreIssues(evts, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Rule != "pod_disruption" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if !strings.HasPrefix(issues[0].Message, "eviction of batch/job-1 (memory) on node node-1 at ") ||
+		!strings.HasSuffix(issues[0].Message, "coincided with p95 latency rising 1.00ms -> 8.00ms") {
+		t.Errorf("message = %q", issues[0].Message)
+	}
+	if issues[0].Magnitude != excess(8, 1) || issues[0].Samples != 4 {
+		t.Errorf("magnitude %v, samples %d", issues[0].Magnitude, issues[0].Samples)
+	}
+}
+
+func TestDetectIssues_ContainerPaused(t *testing.T) {
+	const base = uint64(1_000_000_000_000)
+	mark := func(at time.Duration, kind, state string) *events.Event {
+		return &events.Event{Type: events.EventPause, Timestamp: base + uint64(at), PID: 7, Target: "shop/cart-0/app",
+			Details: events.PauseDetails{Kind: kind, State: state}.String()}
+	}
+	evts := []*events.Event{
+		{Type: events.EventTCPSend, Timestamp: base, LatencyNS: uint64(time.Millisecond)},
+		mark(10*time.Second, events.PauseStop, events.PauseStart),
+		mark(15*time.Second, events.PauseStop, events.PauseEnd),
+		{Type: events.EventTCPSend, Timestamp: base + uint64(20*time.Second), LatencyNS: uint64(time.Millisecond)},
+	}
+	issues := ScoreIssues(evts, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Rule != "container_paused" || issues[0].Code != "PODTRACE-PROC-001" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if !strings.HasPrefix(issues[0].Message, "shop/cart-0/app had its main process 7 stopped by a signal for 5.0s from ") ||
+		!strings.HasSuffix(issues[0].Message, "; the gap in its events is the pause, not the network or the disk") {
+		t.Errorf("message = %q", issues[0].Message)
+	}
+	if issues[0].Frequency < 0.24 || issues[0].Frequency > 0.26 || issues[0].Magnitude != excess(5, config.PauseInterval.Seconds()) {
+		t.Errorf("frequency %v, magnitude %v", issues[0].Frequency, issues[0].Magnitude)
+	}
+}
@@ -0,0 +1,166 @@
+package detector
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Issue is one detected problem with the evidence it was scored on. Rules
+// fire on thresholds; the score then ranks what fired, so the top of the list
+// is the most probable root cause rather than whichever rule ran first.
+type Issue struct {
+	// Code is the stable identifier of the rule, e.g. "PODTRACE-NET-001",
+	// for runbooks and alert routing to key off; see RuleCodes.
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// Rule identifies the rule that raised the issue ("connect_failures",
+	// "tcp_rtt_spikes", "resource_limit", "amqp_backlog",
+	// "bandwidth_saturation", "numa_remote_memory", "runqueue_wait",
+	// "swap_activity", "tmpfs_memory", "fsnotify_storm", "slow_volume",
+	// "write_refused", "image_pull", "pod_disruption", "connection_churn",
+	// "http_5xx_burst", "http_throttling", "container_paused").
+	Rule string `json:"rule"`
+	// Subject names what a rule that fires once per subject fired for: the
+	// endpoint, queue, process, cgroup, volume or resource. Empty for rules
+	// that fire once per run.
+	Subject string `json:"subject,omitempty"`
+	// Frequency is the share of the rule's events that were bad, 0..1.
+	Frequency float64 `json:"frequency"`
+	// Magnitude is how far past its threshold the rule's metric went, 0..1.
+	Magnitude float64 `json:"magnitude"`
+	// Targets is the blast radius: how many distinct targets (peers,
+	// cgroups, queues) were affected.
+	Targets int `json:"targets"`
+	// Samples is how many events the rule evaluated.
+	Samples int `json:"samples"`
+	// Score is 0..100; higher is more likely to be the root cause.
+	Score      float64 `json:"score"`
+	Confidence string  `json:"confidence"`
+}
+
+// RuleCodes maps each rule to its issue code. Codes are PODTRACE-<area>-<n>
+// and never change meaning once released; a new rule gets a new number.
+var RuleCodes = map[string]string{
+	"connect_failures":     "PODTRACE-NET-001",
+	"tcp_rtt_spikes":       "PODTRACE-NET-002",
+	"bandwidth_saturation": "PODTRACE-NET-003",
+	"connection_churn":     "PODTRACE-NET-004",
+	"resource_limit":       "PODTRACE-RES-001",
+	"swap_activity":        "PODTRACE-MEM-001",
+	"tmpfs_memory":         "PODTRACE-MEM-002",
+	"numa_remote_memory":   "PODTRACE-CPU-001",
+	"runqueue_wait":        "PODTRACE-CPU-002",
+	"fsnotify_storm":       "PODTRACE-FS-001",
+	"slow_volume":          "PODTRACE-FS-002",
+	"write_refused":        "PODTRACE-FS-003",
+	"amqp_backlog":         "PODTRACE-MQ-001",
+	"image_pull":           "PODTRACE-K8S-001",
+	"pod_disruption":       "PODTRACE-K8S-002",
+	"http_5xx_burst":       "PODTRACE-HTTP-001",
+	"http_throttling":      "PODTRACE-HTTP-002",
+	"container_paused":     "PODTRACE-PROC-001",
+}
+
+// Confidence levels, from the number of samples behind an issue.
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Score weights; they sum to 1.
+const (
+	frequencyWeight   = 0.4
+	magnitudeWeight   = 0.4
+	blastRadiusWeight = 0.2
+)
+
+// Sample counts needed for medium and high confidence. An issue that only
+// barely crossed its threshold is one level less certain.
+const (
+	mediumConfidenceSamples = 10
+	highConfidenceSamples   = 50
+	marginalMagnitude       = 0.1
+)
+
+// String renders the issue with its code and score, as shown in the report.
+func (i Issue) String() string {
+	if i.Code == "" {
+		return fmt.Sprintf("%s [score %.0f, %s confidence]", i.Message, i.Score, i.Confidence)
+	}
+	return fmt.Sprintf("[%s] %s [score %.0f, %s confidence]", i.Code, i.Message, i.Score, i.Confidence)
+}
+
+// Key identifies the issue across scorings of a growing event set: its rule
+// and subject, but not its message, which embeds live counts.
+func (i Issue) Key() string {
+	rule := i.Code
+	if rule == "" {
+		rule = i.Rule
+	}
+	if i.Subject == "" {
+		return rule
+	}
+	return rule + "\x00" + i.Subject
+}
+
+// score fills in Code, Score and Confidence from the issue's rule and
+// evidence.
+func (i *Issue) score() {
+	i.Code = RuleCodes[i.Rule]
+	i.Score = 100 * (frequencyWeight*clamp01(i.Frequency) +
+		magnitudeWeight*clamp01(i.Magnitude) +
+		blastRadiusWeight*blastRadius(i.Targets))
+
+	level := 0
+	if i.Samples >= highConfidenceSamples {
+		level = 2
+	} else if i.Samples >= mediumConfidenceSamples {
+		level = 1
+	}
+	if i.Magnitude < marginalMagnitude && level > 0 {
+		level--
+	}
+	i.Confidence = [...]string{ConfidenceLow, ConfidenceMedium, ConfidenceHigh}[level]
+}
+
+// rankIssues scores issues and orders them by descending score. Ties keep
+// rule order.
+func rankIssues(issues []Issue) []Issue {
+	for i := range issues {
+		issues[i].score()
+	}
+	sort.SliceStable(issues, func(a, b int) bool { return issues[a].Score > issues[b].Score })
+	return issues
+}
+
+// excess is how far observed is past threshold, relative to observed: 0 at
+// the threshold, approaching 1 as observed grows without bound.
+func excess(observed, threshold float64) float64 {
+	if observed <= 0 || observed <= threshold {
+		return 0
+	}
+	if threshold <= 0 {
+		return 1
+	}
+	return (observed - threshold) / observed
+}
+
+// blastRadius maps a count of affected targets to 0..1: a single target
+// adds nothing, each further one adds less.
+func blastRadius(targets int) float64 {
+	if targets <= 1 {
+		return 0
+	}
+	return 1 - 1/float64(targets)
+}
+
+func clamp01(v float64) float64 {
+	switch {
+	case v < 0:
+		return 0
+	case v > 1:
+		return 1
+	}
+	return v
+}
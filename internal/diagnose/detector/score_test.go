@@ -0,0 +1,257 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestScoreIssues_RanksByScoreNotRuleOrder(t *testing.T) {
+	var evs []*events.Event
+	// Marginal connection failures: 1 of 8 (12.5%) against a 10% threshold,
+	// to one peer.
+	for i := 0; i < 8; i++ {
+		e := &events.Event{Type: events.EventConnect, Target: "10.0.0.1:5432"}
+		if i < 1 {
+			e.Error = 111
+		}
+		evs = append(evs, e)
+	}
+	// Widespread RTT spikes: 60 of 100 sends at 800ms against 100ms, across
+	// four peers.
+	for i := 0; i < 100; i++ {
+		e := &events.Event{Type: events.EventTCPSend, LatencyNS: 10_000_000, Target: fmt.Sprintf("10.0.1.%d:443", i%4)}
+		if i < 60 {
+			e.LatencyNS = 800_000_000
+		}
+		evs = append(evs, e)
+	}
+
+	issues := ScoreIssues(evs, 10.0, 100.0)
+	if len(issues) != 2 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	top, second := issues[0], issues[1]
+	if top.Rule != "tcp_rtt_spikes" || second.Rule != "connect_failures" {
+		t.Fatalf("rank = %s, %s; want RTT spikes first", top.Rule, second.Rule)
+	}
+	if top.Targets != 4 || top.Samples != 100 || top.Confidence != ConfidenceHigh {
+		t.Errorf("top = %+v", top)
+	}
+	if second.Targets != 1 || second.Confidence != ConfidenceLow {
+		t.Errorf("second = %+v", second)
+	}
+	if top.Score <= second.Score || top.Score > 100 || second.Score <= 0 {
+		t.Errorf("scores = %.1f, %.1f", top.Score, second.Score)
+	}
+
+	got := DetectIssues(evs, 10.0, 100.0)
+	if len(got) != 2 || !strings.HasPrefix(got[0], "High TCP RTT spike rate") {
+		t.Errorf("DetectIssues = %v", got)
+	}
+}
+
+func TestScoreIssues_ResourceLimitBlastRadius(t *testing.T) {
+	evs := []*events.Event{
+		{Type: events.EventResourceLimit, TCPState: 1, Error: 97, CgroupID: 1},
+		{Type: events.EventResourceLimit, TCPState: 1, Error: 90, CgroupID: 2},
+		{Type: events.EventResourceLimit, TCPState: 1, Error: 40, CgroupID: 3},
+		{Type: events.EventResourceLimit, TCPState: 1, Error: 30, CgroupID: 3},
+	}
+	issues := ScoreIssues(evs, 10.0, 100.0)
+	if len(issues) != 1 {
+		t.Fatalf("issues = %+v", issues)
+	}
+	issue := issues[0]
+	if issue.Rule != "resource_limit" || issue.Targets != 2 || issue.Samples != 4 || issue.Frequency != 0.5 {
+		t.Errorf("issue = %+v", issue)
+	}
+}
+
+func TestIssueScoreAndConfidence(t *testing.T) {
+	cases := []struct {
+		issue      Issue
+		score      float64
+		confidence string
+	}{
+		{Issue{Frequency: 1, Magnitude: 1, Targets: 1, Samples: 50}, 80, ConfidenceHigh},
+		{Issue{Frequency: 0.5, Magnitude: 0.5, Targets: 2, Samples: 10}, 50, ConfidenceMedium},
+		{Issue{Frequency: 0.5, Magnitude: 0.05, Targets: 1, Samples: 50}, 22, ConfidenceMedium},
+		{Issue{Frequency: 2, Magnitude: 1, Targets: 1, Samples: 3}, 80, ConfidenceLow},
+	}
+	for _, c := range cases {
+		issue := c.issue
+		issue.score()
+		if fmt.Sprintf("%.0f", issue.Score) != fmt.Sprintf("%.0f", c.score) || issue.Confidence != c.confidence {
+			t.Errorf("%+v: score %.1f %s, want %.0f %s", c.issue, issue.Score, issue.Confidence, c.score, c.confidence)
+		}
+	}
+
+	issue := Issue{Message: "High connection failure rate: 50.0%", Score: 61.4, Confidence: ConfidenceHigh}
+	if got := issue.String(); got != "High connection failure rate: 50.0% [score 61, high confidence]" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+func TestExcessAndBlastRadius(t *testing.T) {
+	if excess(10, 10) != 0 || excess(0, 0) != 0 || excess(5, 0) != 1 || excess(20, 10) != 0.5 {
+		t.Error("excess")
+	}
+	if blastRadius(0) != 0 || blastRadius(1) != 0 || blastRadius(4) != 0.75 {
+		t.Error("blastRadius")
+	}
+}
+
+func TestScoreBandwidthSaturation(t *testing.T) {
+	rtt := Issue{Message: "High TCP RTT", Rule: "tcp_rtt_spikes", Frequency: 0.1, Magnitude: 0.1, Targets: 1, Samples: 20}
+	sats := []analyzer.BandwidthSaturation{{
+		Pod: "ns/web", Direction: "ingress", LimitBitsPerS: 10_000_000,
+		LimitSource: analyzer.BandwidthLimitAnnotation, PeakBitsPerS: 10_000_000,
+		Intervals: 12, SaturatedIntervals: 9,
+	}}
+	got := ScoreBandwidthSaturation([]Issue{rtt}, sats, 0.9)
+	if len(got) != 2 || got[0].Rule != "bandwidth_saturation" {
+		t.Fatalf("issues = %+v", got)
+	}
+	b := got[0]
+	if b.Magnitude != 1 || b.Frequency != 0.75 || b.Targets != 1 {
+		t.Errorf("saturation evidence = %+v", b)
+	}
+	want := "Bandwidth saturation: ns/web ingress peaked at 10.0 Mbit/s, 100% of its 10.0 Mbit/s bandwidth annotation, in 9 of 12 intervals (threshold: 90%)"
+	if b.Message != want {
+		t.Errorf("message = %q, want %q", b.Message, want)
+	}
+	if got := ScoreBandwidthSaturation(nil, nil, 0.9); got != nil {
+		t.Errorf("without saturation: %+v", got)
+	}
+}
+
+func TestScoreSlowVolumes(t *testing.T) {
+	data := analyzer.VolumeLatency{
+		VolumeMount: analyzer.VolumeMount{Pod: "shop/db-0", Volume: "data", Kind: "persistentVolumeClaim",
+			Source: "data-db-0", StorageClass: "gp3"},
+		Ops: 300, SlowOps: 30, P99MS: 40,
+		Storage: &analyzer.StorageEvidence{
+			Provisioner: "ebs.csi.aws.com", AccessModes: []string{"ReadWriteOnce"},
+			PersistentVolume: "pvc-123", VolumeHandle: "vol-0abc",
+			Events: []analyzer.StorageEvent{
+				{Object: "PersistentVolume/pvc-123", Type: "Normal", Reason: "Resized"},
+				{Object: "PersistentVolume/pvc-123", Type: "Warning", Reason: "VolumeDegraded", Message: "io errors"},
+			},
+		},
+	}
+	fast := analyzer.VolumeLatency{VolumeMount: analyzer.VolumeMount{Volume: "conf", Kind: "configMap"}, Ops: 50, SlowOps: 1, P99MS: 12}
+
+	got := ScoreSlowVolumes(nil, []analyzer.VolumeLatency{data, fast}, 10)
+	if len(got) != 1 || got[0].Rule != "slow_volume" || got[0].Code != "PODTRACE-FS-002" {
+		t.Fatalf("issues = %+v", got)
+	}
+	if got[0].Frequency != 0.1 || got[0].Magnitude != 0.75 || got[0].Samples != 300 {
+		t.Errorf("evidence = %+v", got[0])
+	}
+	want := "Slow volume: data (persistentVolumeClaim data-db-0) in shop/db-0: p99 40.00ms over the 10.0ms FS threshold, 30 of 300 operations slow; " +
+		"storage class gp3, provisioner ebs.csi.aws.com, ReadWriteOnce, PV pvc-123 (vol-0abc), last warning VolumeDegraded on PersistentVolume/pvc-123: io errors"
+	if got[0].Message != want {
+		t.Errorf("message = %q, want %q", got[0].Message, want)
+	}
+	if got := ScoreSlowVolumes(nil, []analyzer.VolumeLatency{fast}, 10); got != nil {
+		t.Errorf("without a slow volume: %+v", got)
+	}
+}
+
+func TestScoreWriteFailures(t *testing.T) {
+	root := analyzer.WriteFailure{
+		VolumeMount: analyzer.VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "rootfs", Kind: "rootfs", MountPath: "/", ReadOnly: true},
+		Errno:       "EROFS", Cause: analyzer.WriteRefusedReadOnlyRoot, Failures: 12, Attempts: 12, Files: []string{"/var/log/api.log"},
+	}
+	cache := analyzer.WriteFailure{
+		VolumeMount: analyzer.VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "cache", Kind: "emptyDir", MountPath: "/cache", SizeLimit: "1Gi"},
+		Errno:       "ENOSPC", Cause: analyzer.WriteRefusedSizeLimit, Failures: 4, Attempts: 40,
+	}
+	probe := analyzer.WriteFailure{Errno: "EROFS", Cause: analyzer.WriteRefusedReadOnlyFS, Failures: 1, Attempts: 1}
+
+	got := ScoreWriteFailures(nil, []analyzer.WriteFailure{root, cache, probe})
+	if len(got) != 2 || got[0].Rule != "write_refused" || got[0].Code != "PODTRACE-FS-003" {
+		t.Fatalf("issues = %+v", got)
+	}
+	// message -> frequency
+	want := map[string]float64{
+		"Writes failing because the root filesystem of container api is read-only: 12 EROFS of 12 opens, writes and fsyncs in shop/api-0 (/var/log/api.log)": 1,
+		"Writes failing because emptyDir cache sizeLimit 1Gi is reached: 4 ENOSPC of 40 opens, writes and fsyncs in shop/api-0":                              0.1,
+	}
+	for _, issue := range got {
+		freq, ok := want[issue.Message]
+		if !ok {
+			t.Errorf("unexpected message %q", issue.Message)
+		} else if issue.Frequency != freq {
+			t.Errorf("%q: frequency = %v, want %v", issue.Message, issue.Frequency, freq)
+		}
+	}
+	if got := ScoreWriteFailures(nil, []analyzer.WriteFailure{probe}); got != nil {
+		t.Errorf("below the minimum: %+v", got)
+	}
+}
+
+func TestScoreIssues_Codes(t *testing.T) {
+	evs := []*events.Event{
+		{Type: events.EventConnect, Error: 111, Target: "10.0.0.1:5432"},
+		{Type: events.EventConnect, Target: "10.0.0.1:5432"},
+	}
+	issues := ScoreIssues(evs, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Code != "PODTRACE-NET-001" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if got := issues[0].String(); !strings.HasPrefix(got, "[PODTRACE-NET-001] High connection failure rate") {
+		t.Errorf("String() = %q", got)
+	}
+
+	seen := make(map[string]string)
+	for rule, code := range RuleCodes {
+		if !strings.HasPrefix(code, "PODTRACE-") {
+			t.Errorf("rule %s has code %q", rule, code)
+		}
+		if other, dup := seen[code]; dup {
+			t.Errorf("rules %s and %s share code %s", rule, other, code)
+		}
+		seen[code] = rule
+	}
+}
+
+func TestScoreIssues_LosingRaceLegsAreNotFailures(t *testing.T) {
+	const ms = uint64(1_000_000)
+	var evs []*events.Event
+	// Every connect races IPv6, which is unreachable, against IPv4.
+	for i := uint64(0); i < 5; i++ {
+		at := i * 10_000 * ms
+		evs = append(evs,
+			&events.Event{Type: events.EventConnect, PID: 1, Timestamp: at, Target: "2001:db8:0:0:0:0:0:1:443", Details: "api.example.com", Error: -101},
+			&events.Event{Type: events.EventConnect, PID: 1, Timestamp: at + 250*ms, Target: "93.184.216.34:443", Details: "api.example.com"},
+			&events.Event{Type: events.EventTCPSend, PID: 1, Timestamp: at + 300*ms, Target: "93.184.216.34:443"},
+		)
+	}
+	for _, issue := range ScoreIssues(evs, 10.0, 100.0) {
+		if issue.Rule == "connect_failures" {
+			t.Errorf("losing race legs raised %+v", issue)
+		}
+	}
+}
+
+func TestScoreIssues_ConnectionChurn(t *testing.T) {
+	var evs []*events.Event
+	for i := uint64(0); i < 25; i++ {
+		evs = append(evs,
+			&events.Event{Type: events.EventConnect, Timestamp: i * 1_000_000, LatencyNS: 2_000_000, Target: "10.0.0.5:80"},
+			&events.Event{Type: events.EventTCPSend, Timestamp: i*1_000_000 + 1, Target: "10.0.0.5:80"},
+		)
+	}
+	issues := ScoreIssues(evs, 10.0, 100.0)
+	if len(issues) != 1 || issues[0].Rule != "connection_churn" || issues[0].Code != "PODTRACE-NET-004" {
+		t.Fatalf("issues = %+v", issues)
+	}
+	if !strings.Contains(issues[0].Message, "10.0.0.5:80 got 25 connects for 25 sends (100% fresh), about 48ms") {
+		t.Errorf("message = %q", issues[0].Message)
+	}
+}
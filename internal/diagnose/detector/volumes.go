@@ -0,0 +1,92 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+)
+
+// slowVolumeMinOps is how many slow operations a volume needs before its p99
+// is trusted; a single stalled fsync does not make a slow volume.
+const slowVolumeMinOps = 3
+
+// SlowVolumes returns the volumes whose p99 latency is above slowMS, the FS
+// slow-operation threshold, with at least slowVolumeMinOps slow operations.
+func SlowVolumes(vols []analyzer.VolumeLatency, slowMS float64) []analyzer.VolumeLatency {
+	var slow []analyzer.VolumeLatency
+	for _, v := range vols {
+		if v.P99MS > slowMS && v.SlowOps >= slowVolumeMinOps {
+			slow = append(slow, v)
+		}
+	}
+	return slow
+}
+
+// ScoreSlowVolumes adds a "slow_volume" issue for every slow volume to
+// issues, with the storage evidence read for it, and returns them re-ranked.
+func ScoreSlowVolumes(issues []Issue, vols []analyzer.VolumeLatency, slowMS float64) []Issue {
+	slow := SlowVolumes(vols, slowMS)
+	if len(slow) == 0 {
+		return issues
+	}
+	for _, v := range slow {
+		volume := v.Volume + " (" + v.Kind
+		if v.Source != "" {
+			volume += " " + v.Source
+		}
+		volume += ")"
+		if v.Pod != "" {
+			volume += " in " + v.Pod
+		}
+		msg := fmt.Sprintf("Slow volume: %s: p99 %.2fms over the %.1fms FS threshold, %d of %d operations slow",
+			volume, v.P99MS, slowMS, v.SlowOps, v.Ops)
+		if ev := storageEvidence(v); ev != "" {
+			msg += "; " + ev
+		}
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "slow_volume",
+			Subject:   volume,
+			Frequency: float64(v.SlowOps) / float64(v.Ops),
+			Magnitude: excess(v.P99MS, slowMS),
+			Targets:   len(slow),
+			Samples:   v.Ops,
+		})
+	}
+	return rankIssues(issues)
+}
+
+// storageEvidence renders what the storage team needs to pick the issue up:
+// the storage class and provisioner, access modes, the volume and the most
+// recent warning.
+func storageEvidence(v analyzer.VolumeLatency) string {
+	var parts []string
+	if v.StorageClass != "" {
+		parts = append(parts, "storage class "+v.StorageClass)
+	}
+	s := v.Storage
+	if s == nil {
+		return strings.Join(parts, ", ")
+	}
+	if s.Provisioner != "" {
+		parts = append(parts, "provisioner "+s.Provisioner)
+	}
+	if len(s.AccessModes) > 0 {
+		parts = append(parts, strings.Join(s.AccessModes, "/"))
+	}
+	if s.PersistentVolume != "" {
+		pv := "PV " + s.PersistentVolume
+		if s.VolumeHandle != "" {
+			pv += " (" + s.VolumeHandle + ")"
+		}
+		parts = append(parts, pv)
+	}
+	for _, e := range s.Events {
+		if e.Type == "Warning" {
+			parts = append(parts, fmt.Sprintf("last warning %s on %s: %s", e.Reason, e.Object, e.Message))
+			break
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,76 @@
+package detector
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+)
+
+// writeRefusedMinFailures is how many refused writes a volume needs before
+// it is reported; one probe of a read-only path is not a burst.
+const writeRefusedMinFailures = 3
+
+// ScoreWriteFailures adds a "write_refused" issue for every volume that
+// refused at least writeRefusedMinFailures writes to issues, saying why the
+// writes were refused, and returns them re-ranked.
+func ScoreWriteFailures(issues []Issue, failures []analyzer.WriteFailure) []Issue {
+	var refused []analyzer.WriteFailure
+	for _, f := range failures {
+		if f.Failures >= writeRefusedMinFailures {
+			refused = append(refused, f)
+		}
+	}
+	if len(refused) == 0 {
+		return issues
+	}
+	for _, f := range refused {
+		msg := fmt.Sprintf("Writes failing because %s: %d %s", writeRefusedReason(f), f.Failures, f.Errno)
+		if f.Attempts > 0 {
+			msg += fmt.Sprintf(" of %d opens, writes and fsyncs", f.Attempts)
+		}
+		if f.Pod != "" {
+			msg += " in " + f.Pod
+		}
+		if len(f.Files) > 0 {
+			msg += " (" + strings.Join(f.Files, ", ") + ")"
+		}
+		frequency := 1.0
+		if f.Attempts > 0 {
+			frequency = float64(f.Failures) / float64(f.Attempts)
+		}
+		issues = append(issues, Issue{
+			Message:   msg,
+			Rule:      "write_refused",
+			Subject:   f.Pod + " " + f.Volume,
+			Frequency: frequency,
+			Magnitude: excess(float64(f.Failures), writeRefusedMinFailures),
+			Targets:   len(refused),
+			Samples:   f.Attempts,
+		})
+	}
+	return rankIssues(issues)
+}
+
+func writeRefusedReason(f analyzer.WriteFailure) string {
+	volume := "volume " + f.Volume
+	if f.Volume == "" {
+		volume = "an untraced volume"
+	} else if f.MountPath != "" {
+		volume += " at " + f.MountPath
+	}
+	switch f.Cause {
+	case analyzer.WriteRefusedReadOnlyRoot:
+		if f.Container != "" {
+			return "the root filesystem of container " + f.Container + " is read-only"
+		}
+		return "the root filesystem is read-only"
+	case analyzer.WriteRefusedReadOnlyMount:
+		return volume + " (" + f.Kind + ") is mounted read-only"
+	case analyzer.WriteRefusedReadOnlyFS:
+		return "the filesystem of " + volume + " went read-only"
+	case analyzer.WriteRefusedSizeLimit:
+		return "emptyDir " + f.Volume + " sizeLimit " + f.SizeLimit + " is reached"
+	}
+	return volume + " is out of space"
+}
@@ -4,30 +4,83 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"maps"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
 	"github.com/podtrace/podtrace/internal/diagnose/correlator"
+	"github.com/podtrace/podtrace/internal/diagnose/coverage"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
 	"github.com/podtrace/podtrace/internal/diagnose/export"
+	"github.com/podtrace/podtrace/internal/diagnose/hooks"
 	"github.com/podtrace/podtrace/internal/diagnose/profiling"
 	"github.com/podtrace/podtrace/internal/diagnose/report"
+	"github.com/podtrace/podtrace/internal/diagnose/reporttmpl"
+	"github.com/podtrace/podtrace/internal/diagnose/slo"
 	"github.com/podtrace/podtrace/internal/diagnose/stacktrace"
+	"github.com/podtrace/podtrace/internal/diagnose/suppress"
 	"github.com/podtrace/podtrace/internal/diagnose/tracker"
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/geoip"
 	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/metricsexporter"
+	"github.com/podtrace/podtrace/internal/targetnames"
 )
 
 func (d *Diagnostician) ExportJSON() ExportData {
-	return export.ExportJSON(d)
+	data := export.ExportJSON(d)
+	data.SLOs = d.EvaluateSLOs()
+	data.BudgetOverflow = d.BudgetOverflow()
+	data.LowPrivilegeDisabled = d.LowPrivilegeDisabled()
+	data.TerminatedPods = d.PodTerminations()
+	data.Partial = len(data.TerminatedPods) > 0
+	data.Suppressed = d.Suppressed()
+	data.Session = d.SessionTotals()
+	data.StatefulSetMembers = d.StatefulSetMembers()
+	data.Retransmits = d.Retransmits()
+	data.WindowStalls = d.WindowStalls()
+	data.ExternalNetworks = d.ExternalNetworks()
+	data.PodThroughput = d.PodThroughput()
+	data.BandwidthSaturation = d.BandwidthSaturation()
+	data.CPUPlacement = d.CPUPlacement()
+	data.NoisyNeighbors = d.NoisyNeighbors()
+	data.MemoryCompaction = d.MemoryCompaction()
+	data.Swap = d.Swap()
+	data.Tmpfs = d.Tmpfs()
+	data.PageCache = d.PageCache()
+	data.Volumes = d.Volumes()
+	data.WriteFailures = d.WriteFailures()
+	data.Coverage = d.Coverage()
+	data.FsNotify = d.FsNotify()
+	data.Runtime = d.RuntimeOperations()
+	data.ImagePulls = d.ImagePulls()
+	data.Disruptions = d.Disruptions()
+	data.Chaos = d.Chaos()
+	data.Pauses = d.Pauses()
+	data.HTTPStatus = d.HTTPStatus()
+	data.ClientTimeouts = d.ClientTimeouts()
+	data.TimeSeries = d.TimeSeries()
+	return data
 }
 
 func (d *Diagnostician) ExportCSV(w io.Writer) error {
 	return export.ExportCSV(d, w)
 }
 
+func (d *Diagnostician) ExportProto(w io.Writer) error {
+	return export.ExportProto(d, w)
+}
+
+func (d *Diagnostician) ExportSQLite(w io.Writer) error {
+	return export.ExportSQLite(d, report.DetectIssues(d), w)
+}
+
 type ExportData = export.ExportData
 
 type Diagnostician struct {
@@ -48,6 +101,23 @@ type Diagnostician struct {
 	errorCorrelator    *correlator.ErrorCorrelator
 	sourcePod          string
 	sourceNamespace    string
+	slos               []slo.SLO
+	reportTemplate     *reporttmpl.Template
+	eventBudget        int
+	overflow           *analyzer.BudgetOverflow
+	session            *analyzer.SessionStream
+	bandwidthLimits    map[string]analyzer.BandwidthLimit
+	nicBitsPerS        uint64
+	lowPrivDisabled    []string
+	suppressRules      []suppress.Rule
+	suppressed         map[string]int
+	podStatuses        map[string]report.PodStatus
+	terminations       []analyzer.PodTermination
+	volumeMounts       map[uint32]analyzer.VolumeMount
+	storageEvidence    map[string]analyzer.StorageEvidence
+	telemetry          coverage.Telemetry
+	received           map[events.EventType]int
+	unkept             map[events.EventType]int
 }
 
 func NewDiagnostician() *Diagnostician {
@@ -59,7 +129,9 @@ func NewDiagnostician() *Diagnostician {
 		rttSpikeThreshold:  config.DefaultRTTThreshold,
 		fsSlowThreshold:    config.DefaultFSSlowThreshold,
 		maxEvents:          config.MaxEvents,
+		session:            analyzer.NewSessionStream(),
 		errorCorrelator:    correlator.NewErrorCorrelator(30 * time.Second),
+		suppressRules:      suppress.Builtin(),
 	}
 }
 
@@ -72,7 +144,9 @@ func NewDiagnosticianWithThresholds(errorRate, rttSpike, fsSlow float64) *Diagno
 		rttSpikeThreshold:  rttSpike,
 		fsSlowThreshold:    fsSlow,
 		maxEvents:          config.MaxEvents,
+		session:            analyzer.NewSessionStream(),
 		errorCorrelator:    correlator.NewErrorCorrelator(30 * time.Second),
+		suppressRules:      suppress.Builtin(),
 	}
 }
 
@@ -100,11 +174,26 @@ func (d *Diagnostician) AddEventWithContext(event *events.Event, k8sContext map[
 	if event == nil {
 		return
 	}
+	if names := targetnames.Global(); names != nil {
+		names.Observe(event)
+	}
 
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
+	if rule, ok := suppress.Match(d.suppressRules, event); ok {
+		// Benign: counted, but kept out of every analysis.
+		if d.suppressed == nil {
+			d.suppressed = make(map[string]int)
+		}
+		d.suppressed[rule]++
+		d.session.AddSuppressed(event)
+		return
+	}
+
 	d.eventCount++
+	d.session.Add(event)
+	d.received = countType(d.received, event)
 
 	if d.podCommTracker != nil && k8sContext != nil {
 		d.podCommTracker.ProcessEvent(event, k8sContext)
@@ -113,6 +202,17 @@ func (d *Diagnostician) AddEventWithContext(event *events.Event, k8sContext map[
 		d.errorCorrelator.AddEvent(event, k8sContext)
 	}
 
+	if d.eventBudget > 0 && d.eventCount > d.eventBudget {
+		if d.overflow == nil {
+			d.overflow = analyzer.NewBudgetOverflow(d.eventBudget, time.Now())
+			logger.Warn("Event budget reached; switching to aggregation-only mode",
+				zap.Int("max_events", d.eventBudget))
+		}
+		d.overflow.Add(event)
+		d.unkept = countType(d.unkept, event)
+		return
+	}
+
 	if len(d.events) < d.maxEvents {
 		d.events = append(d.events, event)
 		d.enrichedEvents = append(d.enrichedEvents, k8sContext)
@@ -121,6 +221,7 @@ func (d *Diagnostician) AddEventWithContext(event *events.Event, k8sContext map[
 
 	if !shouldSampleEvent(event, d.eventCount) {
 		d.droppedEvents++
+		d.unkept = countType(d.unkept, event)
 		if d.droppedEvents == 1 || d.droppedEvents%config.DroppedEventsLogRate == 0 {
 			logger.Warn("Event buffer at capacity; sampling and evicting oldest events",
 				zap.Int("max_events", d.maxEvents),
@@ -128,12 +229,25 @@ func (d *Diagnostician) AddEventWithContext(event *events.Event, k8sContext map[
 		}
 		return
 	}
+	d.unkept = countType(d.unkept, d.events[d.evHead])
 	d.events[d.evHead] = event
 	d.enrichedEvents[d.evHead] = k8sContext
 	d.evHead = (d.evHead + 1) % d.maxEvents
 	d.wrapped = true
 }
 
+// countType counts e by type in counts, which it makes on first use.
+func countType(counts map[events.EventType]int, e *events.Event) map[events.EventType]int {
+	if e == nil {
+		return counts
+	}
+	if counts == nil {
+		counts = make(map[events.EventType]int)
+	}
+	counts[e.Type]++
+	return counts
+}
+
 func (d *Diagnostician) GetEvents() []*events.Event {
 	d.mu.RLock()
 	defer d.mu.RUnlock()
@@ -168,6 +282,49 @@ func (d *Diagnostician) EventContexts() []map[string]interface{} {
 	return result
 }
 
+// SetEventBudget caps the events kept for the session at n (0 means no
+// cap). Later events only feed per-type totals, reported by BudgetOverflow.
+func (d *Diagnostician) SetEventBudget(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.eventBudget = n
+}
+
+// BudgetOverflow summarizes the events received after the budget set with
+// SetEventBudget was spent, or returns nil while it has not been.
+func (d *Diagnostician) BudgetOverflow() *analyzer.BudgetOverflow {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.overflow == nil {
+		return nil
+	}
+	return d.overflow.Snapshot()
+}
+
+// SessionTotals summarizes every event of the session from fixed-size
+// streaming summaries, kept or not. It returns nil before the first event.
+func (d *Diagnostician) SessionTotals() *analyzer.SessionTotals {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.session.Events() == 0 {
+		return nil
+	}
+	return d.session.Totals()
+}
+
+// SessionGroup returns the session-wide totals of the given event types and
+// whether any event of them was not kept, i.e. whether the totals cover more
+// than the kept events do. Suppressed events are left out.
+func (d *Diagnostician) SessionGroup(types ...events.EventType) (analyzer.TypeTotals, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	partial := false
+	for _, t := range types {
+		partial = partial || d.unkept[t] > 0
+	}
+	return d.session.Group(types...), partial
+}
+
 // SetTimeWindow overrides the observation window.
 func (d *Diagnostician) SetTimeWindow(start, end time.Time) {
 	d.mu.Lock()
@@ -176,6 +333,54 @@ func (d *Diagnostician) SetTimeWindow(start, end time.Time) {
 	d.endTime = end
 }
 
+// Focus narrows the session to the events between start and end and makes
+// the rates cover that window, so every section and issue is computed over
+// it alone. A zero bound keeps the session's own. The totals of events that
+// were not kept cannot be placed in time and are dropped with the rest.
+func (d *Diagnostician) Focus(start, end time.Time) {
+	all, contexts := d.GetEvents(), d.EventContexts()
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if start.IsZero() || start.Before(d.startTime) {
+		start = d.startTime
+	}
+	if end.IsZero() || (!d.endTime.IsZero() && end.After(d.endTime)) {
+		end = d.endTime
+	}
+	d.events, d.enrichedEvents = make([]*events.Event, 0), make([]map[string]interface{}, 0)
+	d.evHead, d.wrapped = 0, false
+	d.droppedEvents, d.overflow, d.unkept, d.suppressed, d.received = 0, nil, nil, nil, nil
+	d.session = analyzer.NewSessionStream()
+	if d.podCommTracker != nil {
+		d.podCommTracker = tracker.NewPodCommunicationTracker(d.sourcePod, d.sourceNamespace)
+	}
+	if d.errorCorrelator != nil {
+		d.errorCorrelator = correlator.NewErrorCorrelator(30 * time.Second)
+	}
+	for i, e := range all {
+		if e == nil {
+			continue
+		}
+		ts := e.TimestampTime()
+		if ts.Before(start) || (!end.IsZero() && ts.After(end)) {
+			continue
+		}
+		d.events = append(d.events, e)
+		d.enrichedEvents = append(d.enrichedEvents, contexts[i])
+		d.session.Add(e)
+		d.received = countType(d.received, e)
+		if d.podCommTracker != nil && contexts[i] != nil {
+			d.podCommTracker.ProcessEvent(e, contexts[i])
+		}
+		if d.errorCorrelator != nil {
+			d.errorCorrelator.AddEvent(e, contexts[i])
+		}
+	}
+	d.eventCount = len(d.events)
+	d.startTime, d.endTime = start, end
+}
+
 func (d *Diagnostician) CalculateRate(count int, duration time.Duration) float64 {
 	if duration.Seconds() > 0 {
 		return float64(count) / duration.Seconds()
@@ -203,17 +408,57 @@ func (d *Diagnostician) EndTime() time.Time {
 }
 
 func (d *Diagnostician) ErrorRateThreshold() float64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.errorRateThreshold
 }
 
 func (d *Diagnostician) RTTSpikeThreshold() float64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.rttSpikeThreshold
 }
 
 func (d *Diagnostician) FSSlowThreshold() float64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 	return d.fsSlowThreshold
 }
 
+// SetThresholds changes the issue thresholds of a running trace; the next
+// report applies them to every event seen so far.
+func (d *Diagnostician) SetThresholds(errorRate, rttSpike, fsSlow float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.errorRateThreshold = errorRate
+	d.rttSpikeThreshold = rttSpike
+	d.fsSlowThreshold = fsSlow
+}
+
+// SetSLOs installs the objectives the report evaluates over the window.
+func (d *Diagnostician) SetSLOs(slos []slo.SLO) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.slos = slos
+}
+
+// SLOs returns the objectives installed with SetSLOs.
+func (d *Diagnostician) SLOs() []slo.SLO {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.slos
+}
+
+// EvaluateSLOs evaluates the installed objectives over the collected events,
+// or returns nil when none are installed.
+func (d *Diagnostician) EvaluateSLOs() []slo.Result {
+	slos := d.SLOs()
+	if len(slos) == 0 {
+		return nil
+	}
+	return slo.Evaluate(slos, d.GetEvents())
+}
+
 func (d *Diagnostician) GenerateReport() string {
 	return d.GenerateReportWithContext(context.Background())
 }
@@ -221,7 +466,7 @@ func (d *Diagnostician) GenerateReport() string {
 func (d *Diagnostician) GenerateReportWithContext(ctx context.Context) string {
 	allEvents := d.GetEvents()
 	if len(allEvents) == 0 {
-		return "No events collected during the diagnostic period.\n"
+		return report.GeneratePartialSection(d.PodTerminations(), d.StartTime()) + "No events collected during the diagnostic period.\n"
 	}
 
 	select {
@@ -231,39 +476,512 @@ func (d *Diagnostician) GenerateReportWithContext(ctx context.Context) string {
 	}
 
 	duration := d.endTime.Sub(d.startTime)
-	var result string
-
-	result += report.GenerateSummarySection(d, duration)
-	result += report.GenerateSecuritySection(d)
-	result += report.GenerateCgroupScopeSection(d)
-	result += report.GenerateDNSSection(d, duration)
-	result += report.GenerateTCPSection(d, duration)
-	result += report.GenerateConnectionSection(d, duration)
-	result += report.GenerateFileSystemSection(d, duration)
-	result += report.GenerateUDPSection(d, duration)
-	result += report.GenerateHTTPSection(d, duration)
-	result += report.GenerateHTTP3Section(d, duration)
-	result += report.GenerateCPUSection(d, duration)
-	result += report.GenerateTCPStateSection(d, duration)
-	result += report.GenerateMemorySection(d, duration)
-	result += report.GenerateResourceSection(d)
-	result += report.GeneratePoolSection(d, duration)
-	result += profiling.GenerateCPUUsageReport(allEvents, duration)
-	result += stacktrace.GenerateStackTraceSectionWithContext(d, ctx)
-	result += report.GenerateSyscallSection(d, duration)
-	result += report.GenerateApplicationTracing(d, duration)
-	result += tracker.GenerateConnectionCorrelation(allEvents)
+	section := reporttmpl.NewSection
+	sections := []reporttmpl.Section{
+		section("partial", report.GeneratePartialSection(d.PodTerminations(), d.StartTime())),
+		section("pod", report.GeneratePodStatusSection(d.PodStatuses())),
+		section("lowprivilege", report.GenerateLowPrivilegeSection(d.LowPrivilegeDisabled())),
+		section("budget", report.GenerateBudgetSection(d.BudgetOverflow(), d.StartTime())),
+		section("session", report.GenerateSessionSection(d.SessionTotals(), len(allEvents))),
+		section("suppressed", report.GenerateSuppressedSection(d.Suppressed())),
+		section("annotations", report.GenerateAnnotationsSection(d)),
+		section("disruptions", report.GenerateDisruptionSection(d.Disruptions(), d.StartTime())),
+		section("chaos", report.GenerateChaosSection(d.Chaos(), d.StartTime())),
+		section("pauses", report.GeneratePauseSection(d.Pauses(), d.StartTime())),
+		section("security", report.GenerateSecuritySection(d)),
+		section("cgroup", report.GenerateCgroupScopeSection(d)),
+		section("dns", report.GenerateDNSSection(d, duration)),
+		section("tcp", report.GenerateTCPSection(d, duration)),
+		section("retransmits", report.GenerateRetransmitSection(d.Retransmits())),
+		section("connection", report.GenerateConnectionSection(d, duration)),
+		section("churn", report.GenerateConnectionChurnSection(d)),
+		section("members", report.GenerateStatefulSetMemberSection(d.StatefulSetMembers())),
+		section("windowstalls", report.GenerateWindowStallSection(d.WindowStalls())),
+		section("timeouts", report.GenerateClientTimeoutSection(d.ClientTimeouts())),
+		section("external", report.GenerateExternalNetworkSection(d.ExternalNetworks())),
+		section("throughput", report.GeneratePodThroughputSection(d.PodThroughput())),
+		section("placement", report.GenerateCPUPlacementSection(d.CPUPlacement())),
+		section("neighbors", report.GenerateNoisyNeighborSection(d.NoisyNeighbors())),
+		section("fs", report.GenerateFileSystemSection(d, duration)),
+		section("volumes", report.GenerateVolumeSection(d.Volumes(), d.FSSlowThreshold())),
+		section("write_failures", report.GenerateWriteFailureSection(d.WriteFailures())),
+		section("pagecache", report.GeneratePageCacheSection(d.PageCache())),
+		section("fsnotify", report.GenerateFsNotifySection(d.FsNotify())),
+		section("udp", report.GenerateUDPSection(d, duration)),
+		section("http", report.GenerateHTTPSection(d, duration)),
+		section("objectstorage", report.GenerateObjectStorageSection(d, duration)),
+		section("http3", report.GenerateHTTP3Section(d, duration)),
+		section("cpu", report.GenerateCPUSection(d, duration)),
+		section("tcpstate", report.GenerateTCPStateSection(d, duration)),
+		section("memory", report.GenerateMemorySection(d, duration)),
+		section("compaction", report.GenerateCompactionSection(d.MemoryCompaction(), duration)),
+		section("swap", report.GenerateSwapSection(d.Swap())),
+		section("tmpfs", report.GenerateTmpfsSection(d.Tmpfs(), analyzer.MemoryVolumes(d.VolumeMounts()))),
+		section("crash", report.GenerateCrashSection(d)),
+		section("runtime", report.GenerateRuntimeSection(d.RuntimeOperations())),
+		section("imagepulls", report.GenerateImagePullSection(d.ImagePulls())),
+		section("python", report.GeneratePythonSection(d, duration)),
+		section("eventloop", report.GenerateEventLoopSection(d, duration)),
+		section("resource", report.GenerateResourceSection(d)),
+		section("pool", report.GeneratePoolSection(d, duration)),
+		section("amqp", report.GenerateAMQPSection(d, duration)),
+		section("cpuusage", profiling.GenerateCPUUsageReport(allEvents, duration)),
+		section("stacks", stacktrace.GenerateStackTraceSectionWithContext(d, ctx)),
+		section("syscalls", report.GenerateSyscallSection(d, duration)),
+		section("tracing", report.GenerateApplicationTracing(d, duration)),
+		section("correlation", tracker.GenerateConnectionCorrelation(allEvents)),
+	}
 
 	if d.podCommTracker != nil {
 		summaries := d.podCommTracker.GetSummary()
-		result += tracker.GeneratePodCommunicationReport(summaries)
+		sections = append(sections, section("podcomm", tracker.GeneratePodCommunicationReport(summaries)))
 	}
 
 	if d.errorCorrelator != nil {
-		result += d.errorCorrelator.GetErrorSummary()
+		sections = append(sections, section("errors", d.errorCorrelator.GetErrorSummary()))
 	}
 
-	result += report.GenerateIssuesSection(d)
+	sections = append(sections, section("slo", report.GenerateSLOSection(d.EvaluateSLOs())))
 
+	data := reporttmpl.Data{
+		Duration:        duration,
+		Start:           d.StartTime(),
+		End:             d.EndTime(),
+		TotalEvents:     len(allEvents),
+		EventsPerSecond: d.CalculateRate(len(allEvents), duration),
+	}
+	telemetry := d.Telemetry()
+	for _, s := range sections {
+		if s.Header != "" || s.Body != "" {
+			if grade, ok := coverage.Grade(s.ID, telemetry); ok {
+				s.DataConfidence, s.DataGaps = grade.Grade, grade.Notes
+			}
+			data.Sections = append(data.Sections, s)
+		}
+	}
+	scoredIssues := report.DetectIssues(d)
+	for _, scored := range scoredIssues {
+		issue := reporttmpl.NewIssue(scored.Message)
+		issue.Code, issue.Score, issue.Confidence = scored.Code, scored.Score, scored.Confidence
+		data.Issues = append(data.Issues, issue)
+	}
+	d.NotifyIssues(scoredIssues)
+
+	tmpl := d.ReportTemplate()
+	result, err := tmpl.Render(data)
+	if err != nil && tmpl != reporttmpl.Default() {
+		logger.Warn("Custom report template failed, using the built-in report", zap.Error(err))
+		result, err = reporttmpl.Default().Render(data)
+	}
+	if err != nil {
+		return fmt.Sprintf("Report generation failed: %v\n", err)
+	}
 	return result
 }
+
+// NotifyIssues records detected issues in the metrics and hands them,
+// tagged with the traced pod, to the callbacks of the global hooks
+// dispatcher.
+func (d *Diagnostician) NotifyIssues(issues []detector.Issue) {
+	for _, issue := range issues {
+		metricsexporter.RecordIssue(issue.Code, issue.Rule, d.sourceNamespace, issue.Score)
+	}
+	dispatcher := hooks.Global()
+	if dispatcher == nil {
+		return
+	}
+	for _, issue := range issues {
+		dispatcher.Dispatch(hooks.Finding{Issue: issue, Pod: d.sourcePod, Namespace: d.sourceNamespace})
+	}
+}
+
+// TargetLabel renders a connection target with the name the global target
+// name resolver knows for its address, e.g. "54.1.2.3:443 (api.stripe.com)".
+func (d *Diagnostician) TargetLabel(target string) string {
+	if names := targetnames.Global(); names != nil {
+		return names.Label(target)
+	}
+	return target
+}
+
+// ExternalNetworks breaks internet-bound traffic down by the network that
+// owns each remote address, from the --geoip-db databases. It returns nil
+// when none are loaded or no event left the cluster.
+func (d *Diagnostician) ExternalNetworks() *analyzer.ExternalNetworks {
+	db := geoip.Global()
+	if db == nil {
+		return nil
+	}
+	return analyzer.AnalyzeExternalNetworks(d.GetEvents(), db.Lookup)
+}
+
+// PodThroughput totals the veth counters of --pod-throughput over the
+// observation window, or returns nil when they were off.
+func (d *Diagnostician) PodThroughput() *analyzer.PodThroughput {
+	return analyzer.AnalyzePodThroughput(d.FilterEvents(events.EventPodThroughput), d.endTime.Sub(d.startTime))
+}
+
+// NoisyNeighbors names the pods on the node that were busiest while the
+// traced pods waited for a CPU or on file I/O, or returns nil when they did
+// not.
+func (d *Diagnostician) NoisyNeighbors() *analyzer.NoisyNeighbors {
+	var evs []*events.Event
+	for _, t := range []events.EventType{events.EventCPUPlacement, events.EventRead, events.EventWrite, events.EventFsync, events.EventNeighbor} {
+		evs = append(evs, d.FilterEvents(t)...)
+	}
+	return analyzer.AnalyzeNoisyNeighbors(evs, d.FSSlowThreshold())
+}
+
+// CPUPlacement summarizes the PODTRACE_CPU_PLACEMENT samples, or returns
+// nil when they were off.
+func (d *Diagnostician) CPUPlacement() *analyzer.CPUPlacement {
+	return analyzer.AnalyzeCPUPlacement(d.FilterEvents(events.EventCPUPlacement))
+}
+
+// StatefulSetMembers sums the lookups of and connects to each headless
+// Service pod the traced pods addressed by name.
+func (d *Diagnostician) StatefulSetMembers() []analyzer.HeadlessService {
+	return analyzer.AnalyzeStatefulSetMembers(append(d.FilterEvents(events.EventDNS), d.FilterEvents(events.EventConnect)...))
+}
+
+// Retransmits splits the TCP retransmits of the trace into SYN and data
+// retransmits per target, or returns nil when there were none.
+func (d *Diagnostician) Retransmits() *analyzer.Retransmits {
+	return analyzer.AnalyzeRetransmits(d.FilterEvents(events.EventTCPRetrans))
+}
+
+// WindowStalls sets the TCP zero-window stalls of the trace against its
+// retransmits, or returns nil when no receive window closed.
+func (d *Diagnostician) WindowStalls() *analyzer.WindowStalls {
+	return analyzer.AnalyzeWindowStalls(append(d.FilterEvents(events.EventTCPZeroWindow), d.FilterEvents(events.EventTCPRetrans)...))
+}
+
+// TimeSeries buckets the events of the session into
+// PODTRACE_EXPORT_BUCKET_INTERVAL intervals per event type, or returns nil
+// when the buckets are turned off.
+func (d *Diagnostician) TimeSeries() *analyzer.TimeSeries {
+	return analyzer.AnalyzeTimeSeries(d.GetEvents(), d.StartTime(), d.EndTime(), config.ExportBucketInterval)
+}
+
+// ClientTimeouts infers the timeouts the traced pod's requests were
+// abandoned at, per target, or returns nil when none stood out.
+func (d *Diagnostician) ClientTimeouts() []analyzer.ClientTimeout {
+	return analyzer.AnalyzeClientTimeouts(d.GetEvents())
+}
+
+// MemoryCompaction summarizes the compaction stalls and THP collapses of the
+// traced processes, or returns nil when there were none.
+func (d *Diagnostician) MemoryCompaction() *analyzer.MemoryCompaction {
+	return analyzer.AnalyzeCompaction(append(d.FilterEvents(events.EventCompaction), d.FilterEvents(events.EventTHPCollapse)...))
+}
+
+// PageCache estimates the page-cache hit ratio of the traced reads, or
+// returns nil when no file was read.
+func (d *Diagnostician) PageCache() *analyzer.PageCache {
+	return analyzer.AnalyzePageCache(append(d.FilterEvents(events.EventPageCache), d.FilterEvents(events.EventRead)...))
+}
+
+// SetVolumeMounts records which pod volume each mount ID of the traced
+// containers is, for putting FS events on their volume.
+func (d *Diagnostician) SetVolumeMounts(mounts map[uint32]analyzer.VolumeMount) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.volumeMounts == nil {
+		d.volumeMounts = make(map[uint32]analyzer.VolumeMount, len(mounts))
+	}
+	for id, m := range mounts {
+		d.volumeMounts[id] = m
+	}
+}
+
+// VolumeMounts returns a copy of the mounts SetVolumeMounts recorded.
+func (d *Diagnostician) VolumeMounts() map[uint32]analyzer.VolumeMount {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	mounts := make(map[uint32]analyzer.VolumeMount, len(d.volumeMounts))
+	for id, m := range d.volumeMounts {
+		mounts[id] = m
+	}
+	return mounts
+}
+
+// Volumes aggregates the FS latency of the traced events per pod volume, or
+// returns nil when no volume mount is known. PVC volumes carry the storage
+// evidence SetStorageEvidence recorded for their claim.
+func (d *Diagnostician) Volumes() []analyzer.VolumeLatency {
+	mounts := d.VolumeMounts()
+	if len(mounts) == 0 {
+		return nil
+	}
+	fs := append(append(d.FilterEvents(events.EventWrite), d.FilterEvents(events.EventRead)...), d.FilterEvents(events.EventFsync)...)
+	vols := analyzer.AnalyzeVolumes(fs, mounts, d.FSSlowThreshold())
+	evidence := d.StorageEvidence()
+	for i := range vols {
+		if vols[i].Kind != "persistentVolumeClaim" {
+			continue
+		}
+		namespace, _, _ := strings.Cut(vols[i].Pod, "/")
+		if ev, ok := evidence[namespace+"/"+vols[i].Source]; ok {
+			vols[i].Storage = &ev
+		}
+	}
+	return vols
+}
+
+// WriteFailures groups the opens, writes and fsyncs refused with EROFS,
+// ENOSPC or EDQUOT by the volume they were on, or returns nil when none was.
+// Without volume mounts the failures are still reported, on no volume.
+func (d *Diagnostician) WriteFailures() []analyzer.WriteFailure {
+	fs := append(append(d.FilterEvents(events.EventOpen), d.FilterEvents(events.EventWrite)...), d.FilterEvents(events.EventFsync)...)
+	return analyzer.AnalyzeWriteFailures(fs, d.VolumeMounts())
+}
+
+// SetStorageEvidence records what the API says about the claim
+// "namespace/claim", for the slow-volume findings.
+func (d *Diagnostician) SetStorageEvidence(claim string, ev analyzer.StorageEvidence) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.storageEvidence == nil {
+		d.storageEvidence = make(map[string]analyzer.StorageEvidence)
+	}
+	d.storageEvidence[claim] = ev
+}
+
+// StorageEvidence returns a copy of the evidence SetStorageEvidence
+// recorded, by claim.
+func (d *Diagnostician) StorageEvidence() map[string]analyzer.StorageEvidence {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	out := make(map[string]analyzer.StorageEvidence, len(d.storageEvidence))
+	for claim, ev := range d.storageEvidence {
+		out[claim] = ev
+	}
+	return out
+}
+
+// RuntimeOperations summarizes the container runtime operations on the
+// traced pods, or returns nil when the runtime was not queried.
+func (d *Diagnostician) RuntimeOperations() *analyzer.RuntimeOperations {
+	return analyzer.AnalyzeRuntimeOperations(d.FilterEvents(events.EventCRIOp))
+}
+
+// ImagePulls summarizes the image pulls of the traced pods, or returns nil
+// when the kubelet reported none.
+func (d *Diagnostician) ImagePulls() *analyzer.ImagePulls {
+	return analyzer.AnalyzeImagePulls(d.FilterEvents(events.EventImagePull))
+}
+
+// Disruptions marks the evictions, preemptions and pressure changes on the
+// traced node with the traffic around each, or returns nil when there were
+// none.
+func (d *Diagnostician) Disruptions() []analyzer.DisruptionStats {
+	return analyzer.AnalyzeDisruptions(d.GetEvents(), d.StartTime(), d.EndTime())
+}
+
+// Chaos marks the intervals in which a fault was injected into a traced
+// pod's network, with the traffic inside and outside each, or returns nil
+// when none was found.
+func (d *Diagnostician) Chaos() []analyzer.ChaosInterval {
+	return analyzer.AnalyzeChaos(d.GetEvents(), d.StartTime(), d.EndTime())
+}
+
+// Pauses lists the intervals in which a traced container was frozen or its
+// main process stopped, or returns nil when none was seen.
+func (d *Diagnostician) Pauses() []analyzer.Pause {
+	return analyzer.AnalyzePauses(d.GetEvents(), d.StartTime(), d.EndTime())
+}
+
+// HTTPStatus tallies the status codes of each endpoint's responses, or
+// returns nil when there were none.
+func (d *Diagnostician) HTTPStatus() []analyzer.HTTPEndpointStatus {
+	return analyzer.AnalyzeHTTPStatus(d.FilterEvents(events.EventHTTPResp), d.StartTime(), d.EndTime())
+}
+
+// FsNotify summarizes the inotify and fanotify activity of the traced
+// cgroups, or returns nil when there was none.
+func (d *Diagnostician) FsNotify() []analyzer.CgroupFsNotify {
+	return analyzer.AnalyzeFsNotify(d.FilterEvents(events.EventFsNotify))
+}
+
+// Swap summarizes the swap activity of the traced cgroups, or returns nil
+// when none of them used swap.
+func (d *Diagnostician) Swap() []analyzer.CgroupSwap {
+	return analyzer.AnalyzeSwap(d.FilterEvents(events.EventSwap))
+}
+
+// Tmpfs summarizes the tmpfs usage of the traced cgroups against their
+// memory limits, or returns nil when none of them held memory in tmpfs.
+func (d *Diagnostician) Tmpfs() []analyzer.CgroupTmpfs {
+	return analyzer.AnalyzeTmpfs(d.FilterEvents(events.EventTmpfs), config.TmpfsMemoryWarn)
+}
+
+// SetBandwidthLimit records the bandwidth annotations of the pod
+// "namespace/pod" for saturation detection.
+func (d *Diagnostician) SetBandwidthLimit(pod string, limit analyzer.BandwidthLimit) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.bandwidthLimits == nil {
+		d.bandwidthLimits = make(map[string]analyzer.BandwidthLimit)
+	}
+	d.bandwidthLimits[pod] = limit
+}
+
+// SetNodeLinkSpeed records the node's link speed, the capacity of pods
+// without bandwidth annotations.
+func (d *Diagnostician) SetNodeLinkSpeed(bitsPerS uint64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.nicBitsPerS = bitsPerS
+}
+
+// SetLowPrivilege records that the trace runs without BPF and what it
+// therefore cannot observe, so the report does not pass silence off as
+// health.
+func (d *Diagnostician) SetLowPrivilege(disabled []string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.lowPrivDisabled = append([]string(nil), disabled...)
+}
+
+// SetPodStatus records the Kubernetes status of a traced pod for the top of
+// the report.
+func (d *Diagnostician) SetPodStatus(s report.PodStatus) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.podStatuses == nil {
+		d.podStatuses = make(map[string]report.PodStatus)
+	}
+	d.podStatuses[s.Namespace+"/"+s.Pod] = s
+}
+
+// PodStatus returns the status SetPodStatus recorded for one pod.
+func (d *Diagnostician) PodStatus(namespace, pod string) (report.PodStatus, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	s, ok := d.podStatuses[namespace+"/"+pod]
+	return s, ok
+}
+
+// PodStatuses returns every status SetPodStatus recorded, by pod.
+func (d *Diagnostician) PodStatuses() []report.PodStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	keys := make([]string, 0, len(d.podStatuses))
+	for k := range d.podStatuses {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	out := make([]report.PodStatus, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, d.podStatuses[k])
+	}
+	return out
+}
+
+// SetPodTerminations records the traced pods that went away mid-trace,
+// which makes the report partial.
+func (d *Diagnostician) SetPodTerminations(terms []analyzer.PodTermination) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.terminations = append([]analyzer.PodTermination(nil), terms...)
+}
+
+// PodTerminations returns what SetPodTerminations recorded, in order.
+func (d *Diagnostician) PodTerminations() []analyzer.PodTermination {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]analyzer.PodTermination(nil), d.terminations...)
+}
+
+// SetSuppression replaces the rules that keep benign events out of the
+// analysis; the built-in rules apply until it is called. Nil suppresses
+// nothing.
+func (d *Diagnostician) SetSuppression(rules []suppress.Rule) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.suppressRules = append([]suppress.Rule(nil), rules...)
+}
+
+// Suppressed returns how many events each suppression rule kept out of the
+// analysis, most first.
+func (d *Diagnostician) Suppressed() []suppress.Count {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return suppress.Counts(d.suppressed)
+}
+
+// LowPrivilegeDisabled returns what SetLowPrivilege recorded, or nil for a
+// full trace.
+func (d *Diagnostician) LowPrivilegeDisabled() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return append([]string(nil), d.lowPrivDisabled...)
+}
+
+// SetTelemetry records what the session knows about its own data loss, for
+// grading each report section's data.
+func (d *Diagnostician) SetTelemetry(t coverage.Telemetry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.telemetry = t
+	d.telemetry.FailedProbes = append([]string(nil), t.FailedProbes...)
+}
+
+// Telemetry returns what SetTelemetry recorded, with the events this
+// diagnostician received and did not keep unless it was handed counts of
+// its own.
+func (d *Diagnostician) Telemetry() coverage.Telemetry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	t := d.telemetry
+	t.FailedProbes = append([]string(nil), t.FailedProbes...)
+	if t.Received == nil {
+		t.Received, t.Unkept = maps.Clone(d.received), maps.Clone(d.unkept)
+	}
+	return t
+}
+
+// Coverage grades the data behind each report section that had events or
+// lost a probe.
+func (d *Diagnostician) Coverage() []coverage.Section {
+	return coverage.Grades(d.Telemetry())
+}
+
+// BandwidthLimits returns the limits recorded with SetBandwidthLimit and
+// SetNodeLinkSpeed, for handing to another diagnostician.
+func (d *Diagnostician) BandwidthLimits() (map[string]analyzer.BandwidthLimit, uint64) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	limits := make(map[string]analyzer.BandwidthLimit, len(d.bandwidthLimits))
+	for pod, l := range d.bandwidthLimits {
+		limits[pod] = l
+	}
+	return limits, d.nicBitsPerS
+}
+
+// BandwidthSaturation returns the pod directions whose veth throughput
+// reached PODTRACE_BANDWIDTH_SATURATION of their bandwidth annotation or of
+// the node's link speed.
+func (d *Diagnostician) BandwidthSaturation() []analyzer.BandwidthSaturation {
+	limits, nic := d.BandwidthLimits()
+	return analyzer.AnalyzeBandwidthSaturation(d.FilterEvents(events.EventPodThroughput), limits, nic, config.BandwidthSaturation)
+}
+
+// SetReportTemplate installs the template the report is rendered with; nil
+// restores the built-in one.
+func (d *Diagnostician) SetReportTemplate(t *reporttmpl.Template) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reportTemplate = t
+}
+
+// ReportTemplate returns the template installed with SetReportTemplate, or
+// the built-in one.
+func (d *Diagnostician) ReportTemplate() *reporttmpl.Template {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.reportTemplate == nil {
+		return reporttmpl.Default()
+	}
+	return d.reportTemplate
+}
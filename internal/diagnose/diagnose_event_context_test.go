@@ -5,7 +5,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/podtrace/podtrace/internal/clock"
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/targetnames"
 )
 
 func TestSetTimeWindow_OverridesStartAndEnd(t *testing.T) {
@@ -21,6 +23,44 @@ func TestSetTimeWindow_OverridesStartAndEnd(t *testing.T) {
 	}
 }
 
+func TestFocus_KeepsOnlyTheWindow(t *testing.T) {
+	d := NewDiagnostician()
+	base := time.Now().Truncate(time.Second)
+	d.SetTimeWindow(base, base.Add(time.Hour))
+	d.SetEventBudget(3)
+	for i, offset := range []time.Duration{time.Minute, 30 * time.Minute, 31 * time.Minute, 50 * time.Minute} {
+		d.AddEventWithContext(&events.Event{
+			Type:      events.EventDNS,
+			Timestamp: clock.WallToBPFTimestamp(base.Add(offset)),
+		}, map[string]interface{}{"seq": i})
+	}
+
+	if d.BudgetOverflow() == nil {
+		t.Fatal("the budget was not spent")
+	}
+
+	d.Focus(base.Add(29*time.Minute), base.Add(32*time.Minute))
+
+	evts := d.GetEvents()
+	if len(evts) != 2 {
+		t.Fatalf("kept %d events, want the two in the window", len(evts))
+	}
+	if ctx := d.EventContexts(); ctx[0]["seq"] != 1 || ctx[1]["seq"] != 2 {
+		t.Errorf("kept contexts %v, want the events' own", ctx)
+	}
+	if got := d.EndTime().Sub(d.StartTime()); got != 3*time.Minute {
+		t.Errorf("window = %v, want 3m", got)
+	}
+	if s := d.SessionTotals(); s == nil || s.Events != 2 {
+		t.Errorf("SessionTotals = %+v, want the two focused events, not the whole session", s)
+	}
+
+	d.Focus(time.Time{}, base.Add(2*time.Hour))
+	if !d.EndTime().Equal(base.Add(32 * time.Minute)) {
+		t.Errorf("EndTime = %v, want the focus kept, not widened", d.EndTime())
+	}
+}
+
 func TestEventContexts_UnwrappedBuffer(t *testing.T) {
 	d := NewDiagnostician()
 	d.AddEventWithContext(&events.Event{Type: events.EventDNS}, map[string]interface{}{"seq": "only"})
@@ -96,3 +136,17 @@ func TestGenerateReportWithContext_IncludesPodCommunication(t *testing.T) {
 		t.Errorf("report should mention the target pod, got:\n%s", report)
 	}
 }
+
+func TestGenerateReport_NamesConnectionTargets(t *testing.T) {
+	targetnames.SetGlobal(targetnames.New(targetnames.Options{}))
+	t.Cleanup(func() { targetnames.SetGlobal(nil) })
+
+	d := NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventDNS, Target: "api.stripe.com", Details: "54.187.174.169"})
+	d.AddEvent(&events.Event{Type: events.EventConnect, Target: "54.187.174.169:443", LatencyNS: 1e6})
+	d.Finish()
+
+	if got := d.GenerateReport(); !strings.Contains(got, "54.187.174.169:443 (api.stripe.com)") {
+		t.Errorf("report should name the connection target:\n%s", got)
+	}
+}
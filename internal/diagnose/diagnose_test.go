@@ -11,6 +11,7 @@ import (
 	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
 	"github.com/podtrace/podtrace/internal/diagnose/formatter"
 	"github.com/podtrace/podtrace/internal/diagnose/report"
+	"github.com/podtrace/podtrace/internal/diagnose/reporttmpl"
 	"github.com/podtrace/podtrace/internal/diagnose/stacktrace"
 	"github.com/podtrace/podtrace/internal/events"
 )
@@ -1444,3 +1445,210 @@ func TestNewDiagnosticianWithK8sAndThresholds(t *testing.T) {
 		t.Errorf("unexpected sourcePod/ns: %q/%q", d.sourcePod, d.sourceNamespace)
 	}
 }
+
+func TestGenerateReport_CustomTemplate(t *testing.T) {
+	d := NewDiagnostician()
+	for i := 0; i < 4; i++ {
+		d.AddEvent(&events.Event{Type: events.EventConnect, Target: "db:5432", Error: -111})
+	}
+	d.Finish()
+	builtin := d.GenerateReport()
+
+	tmpl, err := reporttmpl.New(map[string]string{
+		reporttmpl.MessagesFile: "\"Summary:\": \"Resumen:\"\n",
+		reporttmpl.RunbooksFile: "\"High connection failure rate\": https://runbooks.example.com/connect\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetReportTemplate(tmpl)
+	custom := d.GenerateReport()
+	if !strings.Contains(custom, "Resumen:\n") || strings.Contains(custom, "Summary:\n") {
+		t.Errorf("summary header not translated:\n%s", custom)
+	}
+	if !strings.Contains(custom, "(runbook: https://runbooks.example.com/connect)") {
+		t.Errorf("runbook link missing:\n%s", custom)
+	}
+
+	d.SetReportTemplate(nil)
+	if got := d.GenerateReport(); got != builtin {
+		t.Errorf("resetting the template should restore the built-in report")
+	}
+}
+
+func TestGenerateReport_BandwidthSaturation(t *testing.T) {
+	d := NewDiagnostician()
+	d.SetBandwidthLimit("ns/web", analyzer.BandwidthLimit{EgressBitsPerS: 8_000_000})
+	for ts := uint64(1); ts <= 3; ts++ {
+		d.AddEvent(&events.Event{Type: events.EventPodThroughput, Timestamp: ts,
+			LatencyNS: uint64(time.Second), Bytes: 1_000_000, Details: events.ThroughputEgress,
+			Target: events.ThroughputClassExternal, K8s: &events.K8sMetadata{Namespace: "ns", PodName: "web"}})
+	}
+	d.Finish()
+
+	out := d.GenerateReport()
+	if !strings.Contains(out, "Bandwidth saturation: ns/web egress peaked at 8.0 Mbit/s, 100% of its 8.0 Mbit/s bandwidth annotation, in 3 of 3 intervals") {
+		t.Errorf("report misses the saturation issue:\n%s", out)
+	}
+	if sats := d.ExportJSON().BandwidthSaturation; len(sats) != 1 || sats[0].LimitSource != analyzer.BandwidthLimitAnnotation {
+		t.Errorf("exported saturation = %+v", sats)
+	}
+}
+
+func TestGenerateReport_StatefulSetMembers(t *testing.T) {
+	d := NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventConnect, Target: "10.0.3.1:9092",
+		Details: "kafka-0.kafka.streaming.svc.cluster.local", LatencyNS: uint64(time.Millisecond)})
+	d.AddEvent(&events.Event{Type: events.EventConnect, Target: "10.0.3.2:9092",
+		Details: "kafka-1.kafka.streaming.svc.cluster.local", LatencyNS: uint64(time.Millisecond), Error: -111})
+	d.Finish()
+
+	out := d.GenerateReport()
+	for _, want := range []string{
+		"streaming/kafka: 2 members",
+		"kafka-0: 0 lookups, 1 connects (0 failed, avg 1.00ms)",
+		"kafka-1: 0 lookups, 1 connects (1 failed, avg 1.00ms) <- differs from the other members",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report misses %q:\n%s", want, out)
+		}
+	}
+	if s := d.ExportJSON().StatefulSetMembers; len(s) != 1 || len(s[0].Members) != 2 {
+		t.Errorf("exported members = %+v", s)
+	}
+}
+
+func TestGenerateReport_Retransmits(t *testing.T) {
+	d := NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventTCPRetrans, Target: "10.0.0.5:5432", TCPState: 2})
+	d.AddEvent(&events.Event{Type: events.EventTCPRetrans, Target: "10.0.0.5:5432", TCPState: 2})
+	d.AddEvent(&events.Event{Type: events.EventTCPRetrans, Target: "10.0.0.9:443", TCPState: 1})
+	d.Finish()
+
+	out := d.GenerateReport()
+	for _, want := range []string{
+		"SYN retransmits: 2 (connection setup failing)",
+		"Data retransmits: 1 (loss on established connections)",
+		"10.0.0.5:5432: 2 SYN, 0 data",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report misses %q:\n%s", want, out)
+		}
+	}
+	if r := d.ExportJSON().Retransmits; r == nil || r.SYN != 2 || r.Data != 1 {
+		t.Errorf("exported retransmits = %+v", r)
+	}
+}
+
+func TestGenerateReport_LowPrivilege(t *testing.T) {
+	d := NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventThreadCPU, PID: 7, TCPState: 8, LatencyNS: uint64(time.Second)})
+	d.Finish()
+	if out := d.GenerateReport(); strings.Contains(out, "Low-Privilege Mode") {
+		t.Errorf("full trace reported as low-privilege:\n%s", out)
+	}
+
+	d.SetLowPrivilege([]string{"file system and syscall latency"})
+	out := d.GenerateReport()
+	for _, want := range []string{"Low-Privilege Mode", "- file system and syscall latency"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report misses %q:\n%s", want, out)
+		}
+	}
+	if got := d.ExportJSON().LowPrivilegeDisabled; len(got) != 1 {
+		t.Errorf("exported low_privilege_disabled = %v", got)
+	}
+}
+
+func TestGenerateReport_PodStatus(t *testing.T) {
+	d := NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventDNS, Target: "example.com", LatencyNS: uint64(time.Millisecond)})
+	d.Finish()
+	d.SetPodStatus(report.PodStatus{Namespace: "shop", Pod: "api-0", QOSClass: "Guaranteed",
+		Containers: []report.ContainerStatus{{Name: "api", RestartCount: 2}}})
+
+	out := d.GenerateReport()
+	status := strings.Index(out, "Pod Status Statistics:")
+	if status < 0 || !strings.Contains(out, "api: 2 restarts") {
+		t.Fatalf("report misses the pod status:\n%s", out)
+	}
+	if dns := strings.Index(out, "DNS Statistics:"); dns >= 0 && dns < status {
+		t.Errorf("pod status should lead the sections:\n%s", out)
+	}
+	if _, ok := d.PodStatus("shop", "api-0"); !ok {
+		t.Error("PodStatus did not return the recorded status")
+	}
+}
+
+func TestSuppressedEventsCountedButNotAnalyzed(t *testing.T) {
+	d := NewDiagnostician()
+	for i := 0; i < 50; i++ {
+		d.AddEvent(&events.Event{Type: events.EventTCPRecv, Error: -11, Target: "10.0.0.5:6379"})
+	}
+	d.AddEvent(&events.Event{Type: events.EventConnect, ProcessName: "kubelet", Error: -111, Target: "10.0.0.9:8080"})
+	d.AddEvent(&events.Event{Type: events.EventConnect, Target: "10.0.0.7:5432", LatencyNS: uint64(time.Millisecond)})
+	d.Finish()
+
+	if got := len(d.GetEvents()); got != 1 {
+		t.Errorf("analyzed %d events, want only the unsuppressed connect", got)
+	}
+	counts := d.Suppressed()
+	if len(counts) != 2 || counts[0].Rule != "eagain-nonblocking" || counts[0].Events != 50 || counts[1].Events != 1 {
+		t.Errorf("Suppressed = %+v", counts)
+	}
+	out := d.GenerateReport()
+	if !strings.Contains(out, "Suppressed Benign Events") || !strings.Contains(out, "51 known-benign events") {
+		t.Errorf("report misses the suppressed section:\n%s", out)
+	}
+	if strings.Contains(out, "connection failure rate") {
+		t.Errorf("suppressed kubelet connect failure raised an issue:\n%s", out)
+	}
+	if got := d.ExportJSON().Suppressed; len(got) != 2 {
+		t.Errorf("exported suppressed = %+v", got)
+	}
+
+	d = NewDiagnostician()
+	d.SetSuppression(nil)
+	d.AddEvent(&events.Event{Type: events.EventTCPRecv, Error: -11})
+	if len(d.GetEvents()) != 1 || d.Suppressed() != nil {
+		t.Error("SetSuppression(nil) still suppressed an event")
+	}
+}
+
+func TestGenerateReport_WindowStalls(t *testing.T) {
+	d := NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventTCPZeroWindow, Target: "10.0.0.5:5432",
+		TCPState: events.ZeroWindowLocal, LatencyNS: uint64(250 * time.Millisecond)})
+	d.AddEvent(&events.Event{Type: events.EventTCPRetrans, Target: "10.0.0.7:443"})
+	d.Finish()
+
+	out := d.GenerateReport()
+	for _, want := range []string{
+		"Traced pod closed its receive window: 1 times, 250.0 ms",
+		"10.0.0.5:5432: 1 stalls, 250.0 ms (max 250.0 ms), 0 retransmits: the traced pod reads too slowly",
+		"10.0.0.7:443: 0 stalls, 0.0 ms (max 0.0 ms), 1 retransmits: packet loss",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report misses %q:\n%s", want, out)
+		}
+	}
+	if s := d.ExportJSON().WindowStalls; s == nil || s.LocalStalls != 1 || len(s.Targets) != 2 {
+		t.Errorf("exported window stalls = %+v", s)
+	}
+}
+
+func TestGenerateReport_MemoryCompaction(t *testing.T) {
+	d := NewDiagnostician()
+	d.AddEvent(&events.Event{Type: events.EventCompaction, PID: 42, ProcessName: "app",
+		LatencyNS: uint64(25 * time.Millisecond), Bytes: 2 << 20, TCPState: 8})
+	d.AddEvent(&events.Event{Type: events.EventTHPCollapse, PID: 42, Bytes: 2 << 20})
+	d.Finish()
+
+	out := d.GenerateReport()
+	if !strings.Contains(out, "25.0 ms spent in memory compaction during the window") {
+		t.Errorf("report misses the compaction stall time:\n%s", out)
+	}
+	if c := d.ExportJSON().MemoryCompaction; c == nil || c.Stalls != 1 || c.Collapses != 1 {
+		t.Errorf("exported compaction = %+v", c)
+	}
+}
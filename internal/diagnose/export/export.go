@@ -8,13 +8,19 @@ import (
 
 	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/diagnose/coverage"
 	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/diagnose/slo"
+	"github.com/podtrace/podtrace/internal/diagnose/suppress"
 	"github.com/podtrace/podtrace/internal/diagnose/tracker"
 	"github.com/podtrace/podtrace/internal/events"
 	"github.com/podtrace/podtrace/internal/validation"
+	"github.com/podtrace/podtrace/pkg/schema"
 )
 
 type ExportData struct {
+	// SchemaVersion is the pkg/schema version of the report.
+	SchemaVersion   string                   `json:"schema_version"`
 	Summary         map[string]interface{}   `json:"summary"`
 	DNS             map[string]interface{}   `json:"dns,omitempty"`
 	TCP             map[string]interface{}   `json:"tcp,omitempty"`
@@ -23,6 +29,49 @@ type ExportData struct {
 	CPU             map[string]interface{}   `json:"cpu,omitempty"`
 	ProcessActivity []map[string]interface{} `json:"process_activity,omitempty"`
 	PotentialIssues []string                 `json:"potential_issues,omitempty"`
+	IssueScores     []detector.Issue         `json:"issue_scores,omitempty"`
+	Annotations     []map[string]interface{} `json:"annotations,omitempty"`
+	SLOs            []slo.Result             `json:"slos,omitempty"`
+	BudgetOverflow  *analyzer.BudgetOverflow `json:"budget_overflow,omitempty"`
+	// LowPrivilegeDisabled lists what the trace could not observe because
+	// it ran without BPF.
+	LowPrivilegeDisabled []string `json:"low_privilege_disabled,omitempty"`
+	// Partial is set when traced pods went away mid-trace, as listed in
+	// TerminatedPods.
+	Partial        bool                      `json:"partial,omitempty"`
+	TerminatedPods []analyzer.PodTermination `json:"terminated_pods,omitempty"`
+	// Suppressed counts the known-benign events each suppression rule kept
+	// out of the analysis.
+	Suppressed       []suppress.Count           `json:"suppressed,omitempty"`
+	Session          *analyzer.SessionTotals    `json:"session,omitempty"`
+	ExternalNetworks *analyzer.ExternalNetworks `json:"external_networks,omitempty"`
+	// StatefulSetMembers is the traffic to each headless Service pod.
+	StatefulSetMembers []analyzer.HeadlessService `json:"statefulset_members,omitempty"`
+	// Retransmits splits TCP retransmits into SYN and data retransmits.
+	Retransmits *analyzer.Retransmits `json:"retransmits,omitempty"`
+	// WindowStalls sets zero-window stalls against retransmits per target.
+	WindowStalls  *analyzer.WindowStalls  `json:"window_stalls,omitempty"`
+	PodThroughput *analyzer.PodThroughput `json:"pod_throughput,omitempty"`
+	// BandwidthSaturation lists the pod directions that ran at their capacity.
+	BandwidthSaturation []analyzer.BandwidthSaturation `json:"bandwidth_saturation,omitempty"`
+	CPUPlacement        *analyzer.CPUPlacement         `json:"cpu_placement,omitempty"`
+	NoisyNeighbors      *analyzer.NoisyNeighbors       `json:"noisy_neighbors,omitempty"`
+	MemoryCompaction    *analyzer.MemoryCompaction     `json:"memory_compaction,omitempty"`
+	Swap                []analyzer.CgroupSwap          `json:"swap,omitempty"`
+	Tmpfs               []analyzer.CgroupTmpfs         `json:"tmpfs,omitempty"`
+	PageCache           *analyzer.PageCache            `json:"page_cache,omitempty"`
+	Volumes             []analyzer.VolumeLatency       `json:"volumes,omitempty"`
+	WriteFailures       []analyzer.WriteFailure        `json:"write_failures,omitempty"`
+	FsNotify            []analyzer.CgroupFsNotify      `json:"fsnotify,omitempty"`
+	Runtime             *analyzer.RuntimeOperations    `json:"runtime,omitempty"`
+	ImagePulls          *analyzer.ImagePulls           `json:"image_pulls,omitempty"`
+	Disruptions         []analyzer.DisruptionStats     `json:"disruptions,omitempty"`
+	Chaos               []analyzer.ChaosInterval       `json:"chaos,omitempty"`
+	Pauses              []analyzer.Pause               `json:"pauses,omitempty"`
+	HTTPStatus          []analyzer.HTTPEndpointStatus  `json:"http_status,omitempty"`
+	ClientTimeouts      []analyzer.ClientTimeout       `json:"client_timeouts,omitempty"`
+	TimeSeries          *analyzer.TimeSeries           `json:"timeseries,omitempty"`
+	Coverage            []coverage.Section             `json:"coverage,omitempty"`
 }
 
 type Diagnostician interface {
@@ -49,6 +98,7 @@ func ExportJSON(d Diagnostician) ExportData {
 	eventsPerSec := calculateRate(len(allEvents), duration)
 
 	data := ExportData{
+		SchemaVersion: schema.CurrentVersion,
 		Summary: map[string]interface{}{
 			"total_events":      len(allEvents),
 			"events_per_second": eventsPerSec,
@@ -81,6 +131,13 @@ func ExportJSON(d Diagnostician) ExportData {
 	if len(connectEvents) > 0 {
 		avgLatency, maxLatency, errors, p50, p95, p99, topTargets, errorBreakdown := analyzer.AnalyzeConnections(connectEvents)
 		data.Connections = buildConnectionExportData(connectEvents, duration, avgLatency, maxLatency, errors, p50, p95, p99, topTargets, errorBreakdown)
+		raceEvents := append(append(append([]*events.Event(nil), connectEvents...), tcpSendEvents...), tcpRecvEvents...)
+		if races := analyzer.AnalyzeConnectRaces(raceEvents); races != nil {
+			data.Connections["races"] = races
+		}
+		if churn := tracker.AnalyzeConnectionChurn(allEvents); len(churn) > 0 {
+			data.Connections["churn"] = churn
+		}
 	}
 
 	writeEvents := d.FilterEvents(events.EventWrite)
@@ -93,9 +150,13 @@ func ExportJSON(d Diagnostician) ExportData {
 	}
 
 	schedEvents := d.FilterEvents(events.EventSchedSwitch)
-	if len(schedEvents) > 0 {
+	threads := analyzer.AnalyzeThreads(append(d.FilterEvents(events.EventThreadCPU), schedEvents...))
+	if len(schedEvents) > 0 || len(threads) > 0 {
 		avgBlock, maxBlock, p50, p95, p99 := analyzer.AnalyzeCPU(schedEvents)
 		data.CPU = buildCPUExportData(schedEvents, avgBlock, maxBlock, p50, p95, p99)
+		if len(threads) > 0 {
+			data.CPU["threads"] = threads
+		}
 	}
 
 	pidActivity := tracker.AnalyzeProcessActivity(allEvents)
@@ -108,8 +169,18 @@ func ExportJSON(d Diagnostician) ExportData {
 		})
 	}
 
-	issues := detector.DetectIssues(allEvents, d.ErrorRateThreshold(), d.RTTSpikeThreshold())
-	data.PotentialIssues = issues
+	issues := detector.ScoreIssues(allEvents, d.ErrorRateThreshold(), d.RTTSpikeThreshold())
+	for _, issue := range issues {
+		data.PotentialIssues = append(data.PotentialIssues, issue.Message)
+	}
+	data.IssueScores = issues
+
+	for _, e := range d.FilterEvents(events.EventAnnotation) {
+		data.Annotations = append(data.Annotations, map[string]interface{}{
+			"time": e.TimestampTime().Format(time.RFC3339Nano),
+			"text": e.Target,
+		})
+	}
 
 	return data
 }
@@ -203,7 +274,7 @@ func ExportCSV(d Diagnostician, w io.Writer) error {
 	writer := csv.NewWriter(w)
 	defer writer.Flush()
 
-	header := []string{"timestamp", "pid", "process_name", "type", "latency_ms", "error", "target"}
+	header := []string{"timestamp", "pid", "process_name", "type", "latency_ms", "error", "target", "schema_version"}
 	if err := writer.Write(header); err != nil {
 		return err
 	}
@@ -221,6 +292,7 @@ func ExportCSV(d Diagnostician, w io.Writer) error {
 			fmt.Sprintf("%.2f", float64(event.LatencyNS)/float64(config.NSPerMS)),
 			fmt.Sprintf("%d", event.Error),
 			validation.SanitizeCSVField(event.Target),
+			schema.CurrentVersion,
 		}
 		if err := writer.Write(record); err != nil {
 			return err
@@ -229,3 +301,56 @@ func ExportCSV(d Diagnostician, w io.Writer) error {
 
 	return nil
 }
+
+// SchemaEvent is event in the public pkg/schema layout, as written to
+// capture files and the binary export.
+func SchemaEvent(event *events.Event) schema.Event {
+	s := schema.Event{
+		SchemaVersion: schema.CurrentVersion,
+		Time:          event.TimestampTime().Format(time.RFC3339Nano),
+		Type:          event.TypeString(),
+		PID:           event.PID,
+		Process:       event.ProcessName,
+		Target:        event.Target,
+		LatencyNS:     event.LatencyNS,
+		LatencyMS:     float64(event.LatencyNS) / float64(config.NSPerMS),
+		Error:         event.Error,
+		Bytes:         event.Bytes,
+		Details:       event.Details,
+	}
+	if k := event.K8s; k != nil {
+		s.Namespace, s.Pod, s.PodUID, s.Node = k.Namespace, k.PodName, k.PodUID, k.NodeName
+		s.Container, s.ContainerRuntime, s.ContainerRuntimeVersion = k.ContainerName, k.ContainerRuntime, k.RuntimeVersion
+	}
+	return s
+}
+
+// ExportProto writes the report summary and then every event in the
+// delimited binary format of pkg/schema.
+func ExportProto(d Diagnostician, w io.Writer) error {
+	allEvents := d.GetEvents()
+	summary := schema.Summary{
+		SchemaVersion:   schema.CurrentVersion,
+		TotalEvents:     uint64(len(allEvents)),
+		EventsPerSecond: calculateRate(len(allEvents), d.EndTime().Sub(d.StartTime())),
+		Start:           d.StartTime(),
+		End:             d.EndTime(),
+	}
+	for _, issue := range detector.ScoreIssues(allEvents, d.ErrorRateThreshold(), d.RTTSpikeThreshold()) {
+		summary.PotentialIssues = append(summary.PotentialIssues, issue.Message)
+	}
+
+	pw := schema.NewProtoWriter(w)
+	if err := pw.WriteSummary(summary); err != nil {
+		return err
+	}
+	for _, event := range allEvents {
+		if event == nil {
+			continue
+		}
+		if err := pw.WriteEvent(SchemaEvent(event)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -2,12 +2,15 @@ package export
 
 import (
 	"bytes"
+	"encoding/csv"
+	"encoding/json"
 	"io"
 	"testing"
 	"time"
 
 	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/pkg/schema"
 )
 
 type mockDiagnostician struct {
@@ -175,6 +178,46 @@ func TestExportJSON_WithCPUEvents(t *testing.T) {
 	}
 }
 
+func TestExportJSON_WithAnnotations(t *testing.T) {
+	d := &mockDiagnostician{
+		events: []*events.Event{
+			{Type: events.EventAnnotation, Target: "deployed v2"},
+		},
+		startTime: time.Now(),
+		endTime:   time.Now().Add(1 * time.Second),
+	}
+
+	data := ExportJSON(d)
+	if len(data.Annotations) != 1 || data.Annotations[0]["text"] != "deployed v2" {
+		t.Errorf("Expected one annotation, got %v", data.Annotations)
+	}
+	if _, ok := data.Annotations[0]["time"].(string); !ok {
+		t.Errorf("Expected annotation time, got %v", data.Annotations[0])
+	}
+}
+
+func TestExportJSON_WithIssueScores(t *testing.T) {
+	d := &mockDiagnostician{
+		events: []*events.Event{
+			{Type: events.EventConnect, Error: 111, Target: "10.0.0.1:80"},
+			{Type: events.EventConnect, Error: 0, Target: "10.0.0.1:80"},
+		},
+		startTime:          time.Now(),
+		endTime:            time.Now().Add(1 * time.Second),
+		errorRateThreshold: 10.0,
+		rttSpikeThreshold:  100.0,
+	}
+
+	data := ExportJSON(d)
+	if len(data.PotentialIssues) != 1 || len(data.IssueScores) != 1 {
+		t.Fatalf("Expected one scored issue, got %v / %+v", data.PotentialIssues, data.IssueScores)
+	}
+	scored := data.IssueScores[0]
+	if scored.Message != data.PotentialIssues[0] || scored.Rule != "connect_failures" || scored.Score <= 0 || scored.Confidence == "" {
+		t.Errorf("Unexpected issue score %+v", scored)
+	}
+}
+
 func TestExportCSV_EmptyEvents(t *testing.T) {
 	d := &mockDiagnostician{
 		events:             []*events.Event{},
@@ -217,6 +260,77 @@ func TestExportCSV_WithEvents(t *testing.T) {
 	}
 }
 
+func TestExportsCarrySchemaVersion(t *testing.T) {
+	d := &mockDiagnostician{
+		events:    []*events.Event{{Type: events.EventDNS, Target: "example.com", PID: 1}},
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Second),
+	}
+	report, err := json.Marshal(ExportJSON(d))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := schema.ReportVersion(report); err != nil || v != schema.CurrentVersion {
+		t.Errorf("report schema version = %q, %v; want %q", v, err, schema.CurrentVersion)
+	}
+
+	var buf bytes.Buffer
+	if err := ExportCSV(d, &buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := len(rows[0]) - 1
+	if rows[0][last] != "schema_version" || rows[1][last] != schema.CurrentVersion {
+		t.Errorf("CSV last column = %q/%q, want schema_version/%s", rows[0][last], rows[1][last], schema.CurrentVersion)
+	}
+}
+
+func TestExportProto(t *testing.T) {
+	start := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	d := &mockDiagnostician{
+		events: []*events.Event{
+			nil,
+			{Type: events.EventDNS, LatencyNS: 2000000, Target: "example.com", PID: 1234, ProcessName: "test", Timestamp: 1000},
+			{Type: events.EventConnect, Target: "10.0.0.1:80", PID: 1234, Error: -111},
+		},
+		startTime:          start,
+		endTime:            start.Add(2 * time.Second),
+		errorRateThreshold: 10.0,
+		rttSpikeThreshold:  100.0,
+		fsSlowThreshold:    10.0,
+	}
+	var buf bytes.Buffer
+	if err := ExportProto(d, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	r := schema.NewProtoReader(&buf)
+	rec, err := r.Next()
+	if err != nil || rec.Summary == nil {
+		t.Fatalf("first record = %+v, %v; want the summary", rec, err)
+	}
+	if rec.Summary.TotalEvents != 3 || rec.Summary.EventsPerSecond != 1.5 || !rec.Summary.Start.Equal(start) {
+		t.Errorf("summary = %+v", *rec.Summary)
+	}
+	var targets []string
+	for {
+		rec, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil || rec.Event == nil {
+			t.Fatalf("Next = %+v, %v; want an event", rec, err)
+		}
+		targets = append(targets, rec.Event.Target)
+	}
+	if len(targets) != 2 || targets[0] != "example.com" || targets[1] != "10.0.0.1:80" {
+		t.Errorf("event targets = %v, want the two non-nil events in order", targets)
+	}
+}
+
 func TestExportCSV_NilEvent(t *testing.T) {
 	d := &mockDiagnostician{
 		events: []*events.Event{
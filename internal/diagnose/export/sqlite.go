@@ -0,0 +1,156 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/sqlitefile"
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+// ExportSQLite writes a SQLite database with four tables: metadata (the
+// report summary as key/value rows), events (every kept event), connections
+// (connects and TCP bytes per target) and issues (the scored issues).
+func ExportSQLite(d Diagnostician, issues []detector.Issue, w io.Writer) error {
+	allEvents := d.GetEvents()
+	duration := d.EndTime().Sub(d.StartTime())
+	metadata := sqlitefile.Table{
+		Name:    "metadata",
+		Columns: []sqlitefile.Column{{Name: "key", Type: "TEXT"}, {Name: "value", Type: "TEXT"}},
+		Rows: [][]any{
+			{"schema_version", schema.CurrentVersion},
+			{"start_time", d.StartTime().Format(time.RFC3339)},
+			{"end_time", d.EndTime().Format(time.RFC3339)},
+			{"duration_seconds", fmt.Sprintf("%g", duration.Seconds())},
+			{"total_events", fmt.Sprintf("%d", len(allEvents))},
+			{"events_per_second", fmt.Sprintf("%g", calculateRate(len(allEvents), duration))},
+		},
+	}
+
+	eventTable := sqlitefile.Table{
+		Name: "events",
+		Columns: []sqlitefile.Column{
+			{Name: "time", Type: "TEXT"}, {Name: "type", Type: "TEXT"}, {Name: "pid", Type: "INTEGER"},
+			{Name: "process", Type: "TEXT"}, {Name: "namespace", Type: "TEXT"}, {Name: "pod", Type: "TEXT"},
+			{Name: "container", Type: "TEXT"}, {Name: "target", Type: "TEXT"}, {Name: "latency_ns", Type: "INTEGER"},
+			{Name: "latency_ms", Type: "REAL"}, {Name: "error", Type: "INTEGER"}, {Name: "bytes", Type: "INTEGER"},
+			{Name: "details", Type: "TEXT"}, {Name: "pod_uid", Type: "TEXT"}, {Name: "node", Type: "TEXT"},
+			{Name: "container_runtime", Type: "TEXT"}, {Name: "container_runtime_version", Type: "TEXT"},
+		},
+	}
+	for _, e := range allEvents {
+		if e == nil {
+			continue
+		}
+		s := SchemaEvent(e)
+		eventTable.Rows = append(eventTable.Rows, []any{
+			s.Time, s.Type, s.PID, nullable(s.Process), nullable(s.Namespace), nullable(s.Pod), nullable(s.Container), nullable(s.Target),
+			s.LatencyNS, s.LatencyMS, int64(s.Error), s.Bytes, nullable(s.Details), nullable(s.PodUID), nullable(s.Node),
+			nullable(s.ContainerRuntime), nullable(s.ContainerRuntimeVersion),
+		})
+	}
+
+	issueTable := sqlitefile.Table{
+		Name: "issues",
+		Columns: []sqlitefile.Column{
+			{Name: "code", Type: "TEXT"}, {Name: "rule", Type: "TEXT"}, {Name: "message", Type: "TEXT"},
+			{Name: "score", Type: "REAL"}, {Name: "confidence", Type: "TEXT"}, {Name: "frequency", Type: "REAL"},
+			{Name: "magnitude", Type: "REAL"}, {Name: "targets", Type: "INTEGER"}, {Name: "samples", Type: "INTEGER"},
+		},
+	}
+	for _, issue := range issues {
+		issueTable.Rows = append(issueTable.Rows, []any{
+			nullable(issue.Code), nullable(issue.Rule), issue.Message, issue.Score, nullable(issue.Confidence),
+			issue.Frequency, issue.Magnitude, issue.Targets, issue.Samples,
+		})
+	}
+
+	return sqlitefile.Write(w, []sqlitefile.Table{metadata, eventTable, connectionTable(allEvents), issueTable})
+}
+
+// connectionTable sums the connects and TCP traffic of each target.
+func connectionTable(allEvents []*events.Event) sqlitefile.Table {
+	t := sqlitefile.Table{
+		Name: "connections",
+		Columns: []sqlitefile.Column{
+			{Name: "target", Type: "TEXT"}, {Name: "connects", Type: "INTEGER"}, {Name: "failures", Type: "INTEGER"},
+			{Name: "avg_connect_ms", Type: "REAL"}, {Name: "p99_connect_ms", Type: "REAL"}, {Name: "max_connect_ms", Type: "REAL"},
+			{Name: "bytes_sent", Type: "INTEGER"}, {Name: "bytes_received", Type: "INTEGER"},
+			{Name: "first_seen", Type: "TEXT"}, {Name: "last_seen", Type: "TEXT"},
+		},
+	}
+	type conn struct {
+		failures       int
+		latencies      []float64
+		sent, received uint64
+		first, last    time.Time
+	}
+	byTarget := make(map[string]*conn)
+	for _, e := range allEvents {
+		if e == nil || e.Target == "" {
+			continue
+		}
+		if e.Type != events.EventConnect && e.Type != events.EventTCPSend && e.Type != events.EventTCPRecv {
+			continue
+		}
+		c := byTarget[e.Target]
+		if c == nil {
+			c = &conn{}
+			byTarget[e.Target] = c
+		}
+		switch e.Type {
+		case events.EventConnect:
+			c.latencies = append(c.latencies, float64(e.LatencyNS)/float64(config.NSPerMS))
+			if e.Error != 0 {
+				c.failures++
+			}
+		case events.EventTCPSend:
+			c.sent += e.Bytes
+		case events.EventTCPRecv:
+			c.received += e.Bytes
+		}
+		ts := e.TimestampTime()
+		if c.first.IsZero() || ts.Before(c.first) {
+			c.first = ts
+		}
+		if ts.After(c.last) {
+			c.last = ts
+		}
+	}
+	targets := make([]string, 0, len(byTarget))
+	for target := range byTarget {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		c := byTarget[target]
+		var avg, p99, maxMS any
+		if len(c.latencies) > 0 {
+			sort.Float64s(c.latencies)
+			sum := 0.0
+			for _, l := range c.latencies {
+				sum += l
+			}
+			avg, p99, maxMS = sum/float64(len(c.latencies)), analyzer.Percentile(c.latencies, 99), c.latencies[len(c.latencies)-1]
+		}
+		t.Rows = append(t.Rows, []any{
+			target, len(c.latencies), c.failures, avg, p99, maxMS, c.sent, c.received,
+			c.first.Format(time.RFC3339Nano), c.last.Format(time.RFC3339Nano),
+		})
+	}
+	return t
+}
+
+// nullable stores an empty string as NULL.
+func nullable(s string) any {
+	if s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestExportSQLite(t *testing.T) {
+	start := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	d := &mockDiagnostician{
+		events: []*events.Event{
+			nil,
+			{Type: events.EventDNS, LatencyNS: 2000000, Target: "example.com", PID: 1234, ProcessName: "test", Timestamp: 1000},
+			{Type: events.EventConnect, Target: "10.0.0.1:80", PID: 1234, Error: -111, K8s: &events.K8sMetadata{Namespace: "shop", PodName: "api-0"}},
+		},
+		startTime: start,
+		endTime:   start.Add(2 * time.Second),
+	}
+	var buf bytes.Buffer
+	issues := []detector.Issue{{Code: "PT-NET-001", Message: "connection failures", Score: 80, Confidence: "high"}}
+	if err := ExportSQLite(d, issues, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("SQLite format 3\x00")) {
+		t.Errorf("output is not a SQLite database: %q", buf.Bytes()[:16])
+	}
+}
+
+func TestConnectionTable(t *testing.T) {
+	evts := []*events.Event{
+		{Type: events.EventConnect, Target: "db:5432", LatencyNS: 4000000, Timestamp: 2000},
+		{Type: events.EventConnect, Target: "db:5432", LatencyNS: 2000000, Error: -111, Timestamp: 1000},
+		{Type: events.EventTCPSend, Target: "db:5432", Bytes: 300, Timestamp: 3000},
+		{Type: events.EventTCPRecv, Target: "db:5432", Bytes: 1200, Timestamp: 4000},
+		{Type: events.EventTCPSend, Target: "cache:6379", Bytes: 10, Timestamp: 5000},
+		{Type: events.EventDNS, Target: "db.shop.svc"},
+	}
+	table := connectionTable(evts)
+	if len(table.Rows) != 2 {
+		t.Fatalf("rows = %v, want cache:6379 and db:5432", table.Rows)
+	}
+	cache, db := table.Rows[0], table.Rows[1]
+	if cache[0] != "cache:6379" || cache[1] != 0 || cache[3] != nil || cache[6] != uint64(10) {
+		t.Errorf("cache row = %v", cache)
+	}
+	if want := []any{"db:5432", 2, 1, 3.0, 3.98, 4.0, uint64(300), uint64(1200)}; !reflect.DeepEqual(db[:8], want) {
+		t.Errorf("db row = %v, want %v", db[:8], want)
+	}
+}
@@ -0,0 +1,77 @@
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// maxExecOutput bounds the script output kept for the log.
+const maxExecOutput = 4 << 10
+
+// ExecCallback runs a user script for each finding, with the finding as JSON
+// on stdin and its key fields in PODTRACE_ISSUE_* environment variables.
+type ExecCallback struct {
+	Path string
+}
+
+// Name implements IssueCallback.
+func (c *ExecCallback) Name() string {
+	return "exec:" + c.Path
+}
+
+// OnIssue implements IssueCallback. The script is killed when ctx ends; a
+// non-zero exit is returned as an error with the tail of its output.
+func (c *ExecCallback) OnIssue(ctx context.Context, f Finding) error {
+	payload, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, c.Path) // #nosec G204 -- operator-supplied --on-issue script.
+	cmd.Stdin = bytes.NewReader(append(payload, '\n'))
+	cmd.Env = append(os.Environ(),
+		"PODTRACE_ISSUE_CODE="+f.Code,
+		"PODTRACE_ISSUE_RULE="+f.Rule,
+		"PODTRACE_ISSUE_SCORE="+strconv.FormatFloat(f.Score, 'f', 0, 64),
+		"PODTRACE_ISSUE_CONFIDENCE="+f.Confidence,
+		"PODTRACE_ISSUE_POD="+f.Pod,
+		"PODTRACE_ISSUE_NAMESPACE="+f.Namespace,
+	)
+	var out tailWriter
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(out.String()))
+	}
+	logger.Debug("Issue callback ran",
+		zap.String("script", c.Path),
+		zap.String("rule", f.Rule),
+		zap.String("output", strings.TrimSpace(out.String())))
+	return nil
+}
+
+// tailWriter keeps the last maxExecOutput bytes written to it.
+type tailWriter struct {
+	buf []byte
+}
+
+func (w *tailWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	if len(w.buf) > maxExecOutput {
+		w.buf = w.buf[len(w.buf)-maxExecOutput:]
+	}
+	return len(p), nil
+}
+
+func (w *tailWriter) String() string {
+	return string(w.buf)
+}
@@ -0,0 +1,128 @@
+// Package hooks runs automation when the diagnostician detects an issue:
+// registered IssueCallbacks, such as an ExecCallback that hands the finding
+// to a user script, get every new finding so they can scale up, restart a
+// pod or capture a heap dump.
+package hooks
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// Finding is one detected issue, as handed to callbacks.
+type Finding struct {
+	detector.Issue
+	Pod        string    `json:"pod,omitempty"`
+	Namespace  string    `json:"namespace,omitempty"`
+	DetectedAt time.Time `json:"detectedAt"`
+}
+
+// IssueCallback is invoked for each new finding. Callbacks run on their own
+// goroutine with a deadline on ctx; an error is logged and otherwise ignored.
+type IssueCallback interface {
+	Name() string
+	OnIssue(ctx context.Context, f Finding) error
+}
+
+// Dispatcher fans findings out to the registered callbacks. Reports are
+// regenerated periodically, so a rule raised again for the same pod within
+// the cooldown is not dispatched twice.
+type Dispatcher struct {
+	mu        sync.Mutex
+	callbacks []IssueCallback
+	cooldown  time.Duration
+	timeout   time.Duration
+	last      map[string]time.Time
+	wg        sync.WaitGroup
+	now       func() time.Time
+}
+
+// NewDispatcher returns a dispatcher that suppresses repeats within cooldown
+// and gives each callback timeout to finish.
+func NewDispatcher(cooldown, timeout time.Duration) *Dispatcher {
+	return &Dispatcher{
+		cooldown: cooldown,
+		timeout:  timeout,
+		last:     make(map[string]time.Time),
+		now:      time.Now,
+	}
+}
+
+// Register adds a callback.
+func (d *Dispatcher) Register(cb IssueCallback) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.callbacks = append(d.callbacks, cb)
+}
+
+// Dispatch hands f to every callback unless its rule already fired for the
+// same pod within the cooldown. It does not wait for the callbacks.
+func (d *Dispatcher) Dispatch(f Finding) {
+	d.mu.Lock()
+	if len(d.callbacks) == 0 {
+		d.mu.Unlock()
+		return
+	}
+	now := d.now()
+	key := f.Namespace + "/" + f.Pod + "/" + f.Rule
+	if at, ok := d.last[key]; ok && now.Sub(at) < d.cooldown {
+		d.mu.Unlock()
+		return
+	}
+	d.last[key] = now
+	if f.DetectedAt.IsZero() {
+		f.DetectedAt = now
+	}
+	callbacks := append([]IssueCallback(nil), d.callbacks...)
+	d.mu.Unlock()
+
+	for _, cb := range callbacks {
+		d.wg.Add(1)
+		go func(cb IssueCallback) {
+			defer d.wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), d.timeout)
+			defer cancel()
+			if err := cb.OnIssue(ctx, f); err != nil {
+				logger.Warn("Issue callback failed",
+					zap.String("callback", cb.Name()),
+					zap.String("rule", f.Rule),
+					zap.Error(err))
+			}
+		}(cb)
+	}
+}
+
+// Wait blocks until the dispatched callbacks finish or ctx ends.
+func (d *Dispatcher) Wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+var global atomic.Pointer[Dispatcher]
+
+// SetGlobal installs the dispatcher the diagnostician reports findings to;
+// nil disables dispatching.
+func SetGlobal(d *Dispatcher) {
+	global.Store(d)
+}
+
+// Global returns the dispatcher installed with SetGlobal, or nil.
+func Global() *Dispatcher {
+	return global.Load()
+}
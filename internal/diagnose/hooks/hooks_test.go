@@ -0,0 +1,98 @@
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+)
+
+type recorder struct {
+	mu       sync.Mutex
+	findings []Finding
+	err      error
+}
+
+func (r *recorder) Name() string { return "recorder" }
+
+func (r *recorder) OnIssue(_ context.Context, f Finding) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.findings = append(r.findings, f)
+	return r.err
+}
+
+func TestDispatcherCooldown(t *testing.T) {
+	now := time.Unix(1000, 0)
+	d := NewDispatcher(time.Minute, time.Second)
+	d.now = func() time.Time { return now }
+	rec := &recorder{}
+	failing := &recorder{err: errors.New("boom")}
+	d.Register(rec)
+	d.Register(failing)
+
+	issue := detector.Issue{Message: "High connection failure rate", Rule: "connect_failures", Score: 74}
+	d.Dispatch(Finding{Issue: issue, Pod: "api", Namespace: "prod"})
+	d.Dispatch(Finding{Issue: issue, Pod: "api", Namespace: "prod"})
+	d.Dispatch(Finding{Issue: issue, Pod: "worker", Namespace: "prod"})
+	now = now.Add(2 * time.Minute)
+	d.Dispatch(Finding{Issue: issue, Pod: "api", Namespace: "prod"})
+	if err := d.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rec.findings) != 3 || len(failing.findings) != 3 {
+		t.Fatalf("dispatched %d/%d findings, want 3 each", len(rec.findings), len(failing.findings))
+	}
+	for _, f := range rec.findings {
+		if f.DetectedAt.IsZero() || f.Rule != "connect_failures" {
+			t.Errorf("finding = %+v", f)
+		}
+	}
+}
+
+func TestExecCallback(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "stdin.json")
+	script := filepath.Join(dir, "hook.sh")
+	body := "#!/bin/sh\ncat > " + out + "\necho \"$PODTRACE_ISSUE_CODE $PODTRACE_ISSUE_RULE $PODTRACE_ISSUE_SCORE $PODTRACE_ISSUE_POD\" >> " + out + "\n"
+	if err := os.WriteFile(script, []byte(body), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	cb := &ExecCallback{Path: script}
+	f := Finding{Issue: detector.Issue{Code: "PODTRACE-NET-002", Message: "m", Rule: "tcp_rtt_spikes", Score: 61.6}, Pod: "api", Namespace: "prod"}
+	if err := cb.OnIssue(context.Background(), f); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitN(string(data), "\n", 2)
+	var got Finding
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("stdin is not a finding: %v\n%s", err, data)
+	}
+	if got.Rule != "tcp_rtt_spikes" || got.Pod != "api" || got.Namespace != "prod" {
+		t.Errorf("finding = %+v", got)
+	}
+	if strings.TrimSpace(lines[1]) != "PODTRACE-NET-002 tcp_rtt_spikes 62 api" {
+		t.Errorf("env = %q", lines[1])
+	}
+
+	failing := filepath.Join(dir, "fail.sh")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho no capacity >&2\nexit 3\n"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := (&ExecCallback{Path: failing}).OnIssue(context.Background(), f); err == nil || !strings.Contains(err.Error(), "no capacity") {
+		t.Errorf("err = %v", err)
+	}
+}
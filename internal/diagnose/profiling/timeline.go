@@ -12,6 +12,8 @@ import (
 
 type TimelineBucket struct {
 	Period     string
+	Start      time.Time
+	End        time.Time
 	Count      int
 	Percentage float64
 }
@@ -72,6 +74,8 @@ func AnalyzeTimeline(events []*events.Event, startTime time.Time, duration time.
 		percentage := float64(count) / float64(totalEvents) * 100
 		timeline = append(timeline, TimelineBucket{
 			Period:     period,
+			Start:      bucketStart,
+			End:        bucketEnd,
 			Count:      count,
 			Percentage: percentage,
 		})
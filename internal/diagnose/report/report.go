@@ -13,6 +13,8 @@ import (
 	"github.com/podtrace/podtrace/internal/diagnose/detector"
 	"github.com/podtrace/podtrace/internal/diagnose/formatter"
 	"github.com/podtrace/podtrace/internal/diagnose/profiling"
+	"github.com/podtrace/podtrace/internal/diagnose/slo"
+	"github.com/podtrace/podtrace/internal/diagnose/suppress"
 	"github.com/podtrace/podtrace/internal/diagnose/tracker"
 	"github.com/podtrace/podtrace/internal/events"
 	"github.com/podtrace/podtrace/internal/safeconv"
@@ -30,6 +32,61 @@ type Diagnostician interface {
 	ErrorRateThreshold() float64
 }
 
+// targetLabeler is implemented by diagnosticians that can name the remote
+// addresses of network targets.
+type targetLabeler interface {
+	TargetLabel(target string) string
+}
+
+// bandwidthSaturator is implemented by diagnosticians that know the
+// bandwidth capacity of the traced pods.
+type bandwidthSaturator interface {
+	BandwidthSaturation() []analyzer.BandwidthSaturation
+}
+
+// volumeReporter is implemented by diagnosticians that know which pod
+// volume the traced FS events were on.
+type volumeReporter interface {
+	Volumes() []analyzer.VolumeLatency
+	WriteFailures() []analyzer.WriteFailure
+}
+
+// sessionGrouper is implemented by diagnosticians that keep streaming totals
+// of every event, including those no longer kept.
+type sessionGrouper interface {
+	SessionGroup(types ...events.EventType) (analyzer.TypeTotals, bool)
+}
+
+// sessionGroup returns the session-wide totals of types when d keeps them
+// and they cover events the kept ones do not.
+func sessionGroup(d Diagnostician, types ...events.EventType) (analyzer.TypeTotals, bool) {
+	g, ok := d.(sessionGrouper)
+	if !ok {
+		return analyzer.TypeTotals{}, false
+	}
+	return g.SessionGroup(types...)
+}
+
+// keptNote tells that the lines after the session-wide figures only cover
+// the kept events.
+func keptNote(kept int) string {
+	return fmt.Sprintf("  Figures above cover every event; the lines below cover the %d kept\n", kept)
+}
+
+// labelTargets appends the name d knows for each raw "ip:port" target.
+func labelTargets(d Diagnostician, targets []analyzer.TargetCount) []analyzer.TargetCount {
+	l, ok := d.(targetLabeler)
+	if !ok {
+		return targets
+	}
+	out := make([]analyzer.TargetCount, len(targets))
+	for i, t := range targets {
+		t.Target = l.TargetLabel(t.Target)
+		out[i] = t
+	}
+	return out
+}
+
 func GenerateSummarySection(d Diagnostician, duration time.Duration) string {
 	events := d.GetEvents()
 	eventsPerSec := d.CalculateRate(len(events), duration)
@@ -42,6 +99,183 @@ func GenerateSummarySection(d Diagnostician, duration time.Duration) string {
 	return report
 }
 
+// GenerateAnnotationsSection lists the markers injected with 'podtrace
+// annotate' and compares the traffic before and after each one.
+func GenerateAnnotationsSection(d Diagnostician) string {
+	stats := analyzer.AnalyzeAnnotations(d.GetEvents(), d.StartTime(), d.EndTime())
+	if len(stats) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Annotations")
+	for _, a := range stats {
+		report += fmt.Sprintf("  +%.1fs %s\n", a.At.Sub(d.StartTime()).Seconds(), sanitize.Terminal(a.Text))
+		report += phaseLine("before:", a.Before)
+		report += phaseLine("after:", a.After)
+	}
+	report += "\n"
+	return report
+}
+
+// phaseLine renders the traffic on one side of an annotation or disruption.
+func phaseLine(label string, p analyzer.AnnotationPhase) string {
+	line := fmt.Sprintf("      %-7s %d events (%.1f/sec), %.2f%% errors", label, p.Events, p.Rate(), p.ErrorRate())
+	if p.AvgLatencyMS > 0 {
+		line += fmt.Sprintf(", avg latency %.2fms, p95 %.2fms", p.AvgLatencyMS, p.P95LatencyMS)
+	}
+	return line + "\n"
+}
+
+// disruptionLabels names the disruption kinds in the report.
+var disruptionLabels = map[string]string{
+	events.DisruptionEviction:          "eviction of",
+	events.DisruptionPreemption:        "preemption of",
+	events.DisruptionEvictionThreshold: "eviction threshold met on",
+	events.DisruptionNodePressure:      "pressure on",
+	events.DisruptionPressureCleared:   "pressure cleared on",
+}
+
+// GenerateDisruptionSection marks the evictions, preemptions and node
+// pressure changes on the traced node, each with the traffic around it.
+func GenerateDisruptionSection(stats []analyzer.DisruptionStats, start time.Time) string {
+	if len(stats) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Pod Disruption")
+	for _, s := range stats {
+		label, ok := disruptionLabels[s.Kind]
+		if !ok {
+			label = s.Kind + " of"
+		}
+		line := fmt.Sprintf("  +%.1fs %s %s", s.At.Sub(start).Seconds(), label, sanitize.Terminal(s.Subject))
+		if s.Resource != "" {
+			line += " (" + sanitize.Terminal(s.Resource) + ")"
+		}
+		if s.Node != "" && s.Node != s.Subject {
+			line += " on " + sanitize.Terminal(s.Node)
+		}
+		if rise := s.LatencyRise(); rise >= 2 {
+			line += fmt.Sprintf(" [p95 latency x%.1f after]", rise)
+		}
+		report += line + "\n"
+		report += phaseLine("before:", s.Before)
+		report += phaseLine("after:", s.After)
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateChaosSection marks the intervals in which a fault was injected
+// into a traced pod's network, so their latency and errors are read as the
+// experiment's.
+func GenerateChaosSection(intervals []analyzer.ChaosInterval, start time.Time) string {
+	if len(intervals) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Chaos Injection")
+	report += "  Faults were injected into the traced pods' network; latency and errors in these intervals are the experiment's:\n"
+	for _, c := range intervals {
+		to := fmt.Sprintf("+%.1fs", c.End.Sub(start).Seconds())
+		if c.Ongoing {
+			to = "end"
+		}
+		line := fmt.Sprintf("    +%.1fs to %s ", c.Start.Sub(start).Seconds(), to)
+		switch c.Kind {
+		case events.ChaosNetem:
+			line += "netem on " + sanitize.Terminal(c.Target)
+		case events.ChaosIPTablesDrop:
+			line += "rule " + sanitize.Terminal(c.Target)
+		default:
+			line += sanitize.Terminal(c.Kind) + " " + sanitize.Terminal(c.Target)
+		}
+		if c.Pod != "" {
+			line += " of " + sanitize.Terminal(c.Pod)
+		}
+		if c.Spec != "" {
+			line += ": " + sanitize.Terminal(c.Spec)
+		}
+		if c.Preexisting {
+			line += " (in place before the trace)"
+		}
+		if rise := c.LatencyRise(); rise >= 2 {
+			line += fmt.Sprintf(" [p95 latency x%.1f during]", rise)
+		}
+		report += line + "\n"
+		report += phaseLine("during:", c.During)
+		report += phaseLine("outside:", c.Outside)
+	}
+	report += "\n"
+	return report
+}
+
+// pauseVerb says in the report what happened to a container in a pause.
+func pauseVerb(kind string) string {
+	switch kind {
+	case events.PauseFreeze:
+		return "frozen"
+	case events.PauseStop:
+		return "stopped"
+	case events.PauseTraceStop:
+		return "held by a tracer"
+	}
+	return kind
+}
+
+// GeneratePauseSection lists the intervals in which a traced container was
+// frozen or its main process stopped, so the gaps they left in its events
+// are not read as network or disk stalls.
+func GeneratePauseSection(pauses []analyzer.Pause, start time.Time) string {
+	if len(pauses) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Container Pause")
+	report += "  Traced containers ran nothing in these intervals; gaps in their events are the pause, not the network or the disk:\n"
+	for _, p := range pauses {
+		to := fmt.Sprintf("+%.1fs", p.End.Sub(start).Seconds())
+		if p.Ongoing {
+			to = "end"
+		}
+		line := fmt.Sprintf("    +%.1fs to %s %s ", p.Start.Sub(start).Seconds(), to, sanitize.Terminal(p.Container))
+		switch p.Kind {
+		case events.PauseFreeze:
+			line += "frozen through its cgroup"
+		case events.PauseStop:
+			line += fmt.Sprintf("main process %d stopped by a signal", p.PID)
+		case events.PauseTraceStop:
+			line += fmt.Sprintf("main process %d held by a tracer", p.PID)
+		default:
+			line += sanitize.Terminal(p.Kind)
+		}
+		report += line + fmt.Sprintf(" for %.1fs\n", p.Duration().Seconds())
+	}
+	report += "\n"
+	return report
+}
+
+// GeneratePartialSection says which traced pods went away mid-trace, and
+// why, so that the sections below are read as covering only the time
+// before.
+func GeneratePartialSection(terms []analyzer.PodTermination, start time.Time) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Partial Report")
+	report += "  Traced pods went away before the trace ended; their events stop there:\n"
+	for _, t := range terms {
+		line := fmt.Sprintf("    %s/%s: %s at +%.1fs", sanitize.Terminal(t.Namespace), sanitize.Terminal(t.Pod), sanitize.Terminal(t.Reason), t.At.Sub(start).Seconds())
+		if t.Message != "" {
+			line += " (" + sanitize.Terminal(t.Message) + ")"
+		}
+		report += line + "\n"
+	}
+	report += "\n"
+	return report
+}
+
 func GenerateCgroupScopeSection(d Diagnostician) string {
 	evs := d.GetEvents()
 	if len(evs) == 0 {
@@ -109,6 +343,16 @@ func GenerateDNSSection(d Diagnostician, duration time.Duration) string {
 	}
 
 	avgLatency, maxLatency, errors, p50, p95, p99, topTargets := analyzer.AnalyzeDNS(queries, responses)
+	kept := lookupCount
+	q, qPartial := sessionGroup(d, events.EventDNSQuery)
+	r, rPartial := sessionGroup(d, events.EventDNS)
+	if qPartial || rPartial {
+		lookupCount = max(q.Events, r.Events)
+		if r.Events > 0 {
+			avgLatency, maxLatency, errors = r.AvgMS, r.MaxMS, r.Errors
+			p50, p95, p99 = r.P50MS, r.P95MS, r.P99MS
+		}
+	}
 	var report string
 	report += formatter.SectionHeader("DNS")
 	dnsRate := d.CalculateRate(lookupCount, duration)
@@ -116,6 +360,9 @@ func GenerateDNSSection(d Diagnostician, duration time.Duration) string {
 	report += formatter.LatencyMetrics(avgLatency, maxLatency)
 	report += formatter.Percentiles(p50, p95, p99)
 	report += formatter.ErrorRate(errors, lookupCount)
+	if qPartial || rPartial {
+		report += keptNote(kept)
+	}
 	if rcodes := analyzer.DNSRCodeBreakdown(responses); len(rcodes) > 0 {
 		report += "  Response code breakdown:\n"
 		for _, rc := range rcodes {
@@ -141,19 +388,34 @@ func GenerateTCPSection(d Diagnostician, duration time.Duration) string {
 		return ""
 	}
 
+	sends, recvs := len(tcpSendEvents), len(tcpRecvEvents)
+	all, partial := sessionGroup(d, events.EventTCPSend, events.EventTCPRecv)
+	if partial {
+		send, _ := sessionGroup(d, events.EventTCPSend)
+		recv, _ := sessionGroup(d, events.EventTCPRecv)
+		sends, recvs = send.Events, recv.Events
+	}
+
 	var report string
 	report += formatter.SectionHeader("TCP")
-	sendRate := d.CalculateRate(len(tcpSendEvents), duration)
-	recvRate := d.CalculateRate(len(tcpRecvEvents), duration)
-	report += fmt.Sprintf("  Send operations: %d (%.1f/sec)\n", len(tcpSendEvents), sendRate)
-	report += fmt.Sprintf("  Receive operations: %d (%.1f/sec)\n", len(tcpRecvEvents), recvRate)
+	sendRate := d.CalculateRate(sends, duration)
+	recvRate := d.CalculateRate(recvs, duration)
+	report += fmt.Sprintf("  Send operations: %d (%.1f/sec)\n", sends, sendRate)
+	report += fmt.Sprintf("  Receive operations: %d (%.1f/sec)\n", recvs, recvRate)
 
 	allTCP := append(tcpSendEvents, tcpRecvEvents...)
 	if len(allTCP) > 0 {
 		avgRTT, maxRTT, spikes, p50, p95, p99, errors, totalBytes, avgBytes, peakBytes := analyzer.AnalyzeTCP(allTCP, d.RTTSpikeThreshold())
+		if partial {
+			avgRTT, maxRTT = all.AvgMS, all.MaxMS
+			p50, p95, p99 = all.P50MS, all.P95MS, all.P99MS
+		}
 		report += fmt.Sprintf("  Average RTT: %.2fms\n", avgRTT)
 		report += fmt.Sprintf("  Max RTT: %.2fms\n", maxRTT)
 		report += formatter.Percentiles(p50, p95, p99)
+		if partial {
+			report += keptNote(len(allTCP))
+		}
 		report += fmt.Sprintf("  RTT spikes (>%dms): %d\n", config.RTTSpikeThresholdMS, spikes)
 		report += formatter.ErrorRate(errors, len(allTCP))
 		if totalBytes > 0 {
@@ -179,24 +441,118 @@ func GenerateConnectionSection(d Diagnostician, duration time.Duration) string {
 	}
 
 	avgLatency, maxLatency, errors, p50, p95, p99, topTargets, errorBreakdown := analyzer.AnalyzeConnections(connectEvents)
+	connects := len(connectEvents)
+	s, partial := sessionGroup(d, events.EventConnect)
+	if partial {
+		connects, errors = s.Events, s.Errors
+		avgLatency, maxLatency = s.AvgMS, s.MaxMS
+		p50, p95, p99 = s.P50MS, s.P95MS, s.P99MS
+	}
 	var report string
 	report += formatter.SectionHeader("Connection")
-	connRate := d.CalculateRate(len(connectEvents), duration)
-	report += formatter.TotalWithRate("connections", len(connectEvents), connRate)
+	connRate := d.CalculateRate(connects, duration)
+	report += formatter.TotalWithRate("connections", connects, connRate)
 	report += formatter.LatencyMetrics(avgLatency, maxLatency)
 	report += formatter.Percentiles(p50, p95, p99)
-	report += fmt.Sprintf("  Failed connections: %d (%.1f%%)\n", errors, float64(errors)*float64(config.Percent100)/float64(len(connectEvents)))
+	report += fmt.Sprintf("  Failed connections: %d (%.1f%%)\n", errors, float64(errors)*float64(config.Percent100)/float64(connects))
+	if partial {
+		report += keptNote(len(connectEvents))
+	}
 	if len(errorBreakdown) > 0 {
 		report += "  Error breakdown:\n"
 		for errCode, count := range errorBreakdown {
 			report += fmt.Sprintf("    - Error %d: %d occurrences\n", errCode, count)
 		}
 	}
-	report += formatter.TopTargets(topTargets, config.TopTargetsLimit, "connection targets", "connections")
+	raceEvents := append(append(append([]*events.Event(nil), connectEvents...), d.FilterEvents(events.EventTCPSend)...), d.FilterEvents(events.EventTCPRecv)...)
+	report += connectRaceLines(analyzer.AnalyzeConnectRaces(raceEvents))
+	report += formatter.TopTargets(labelTargets(d, topTargets), config.TopTargetsLimit, "connection targets", "connections")
+	report += "\n"
+	return report
+}
+
+// GenerateConnectionChurnSection reports, per target, how many sends each
+// fresh connection served, and flags the targets whose clients reconnect
+// for nearly every send.
+func GenerateConnectionChurnSection(d Diagnostician) string {
+	churn := tracker.AnalyzeConnectionChurn(d.GetEvents())
+	if len(churn) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Connection Reuse")
+	connects, sends := 0, 0
+	for _, c := range churn {
+		connects += c.Connects
+		sends += c.Sends
+	}
+	report += fmt.Sprintf("  Fresh connects: %d for %d sends", connects, sends)
+	if sends > 0 {
+		reused := 1 - float64(connects)/float64(sends)
+		if reused < 0 {
+			reused = 0
+		}
+		report += fmt.Sprintf(" (%.0f%% of sends on a reused connection)", reused*100)
+	}
+	report += "\n"
+	report += "  Top targets by connects:\n"
+	for i, c := range churn {
+		if i >= config.TopTargetsLimit {
+			break
+		}
+		report += fmt.Sprintf("    - %s: %d connects, %d sends, %.0f%% fresh, setup %.2fms each",
+			sanitize.Terminal(c.Target), c.Connects, c.Sends, c.FreshRatio*100, float64(c.SetupCost.Nanoseconds())/float64(config.NSPerMS))
+		if c.Excessive {
+			report += fmt.Sprintf(" [CHURN: ~%.0fms lost to reconnects; keep-alive likely off]", float64(c.Cost.Nanoseconds())/float64(config.NSPerMS))
+		}
+		report += "\n"
+	}
 	report += "\n"
 	return report
 }
 
+// connectRaceLines renders the connection-attempt fan-out, so failed legs
+// of a race the client won are not read as failures.
+func connectRaceLines(r *analyzer.ConnectRaces) string {
+	if r == nil {
+		return ""
+	}
+	out := fmt.Sprintf("  Raced connects: %d over %d attempts (%d wasted)\n", r.Races, r.Attempts, r.Wasted)
+	if r.LosingLegErrors > 0 {
+		out += fmt.Sprintf("  Failed connections that were losing race legs: %d\n", r.LosingLegErrors)
+	}
+	if len(r.WinsByFamily) > 0 {
+		families := make([]string, 0, len(r.WinsByFamily))
+		for f := range r.WinsByFamily {
+			families = append(families, f)
+		}
+		sort.Strings(families)
+		parts := make([]string, len(families))
+		for i, f := range families {
+			parts[i] = fmt.Sprintf("%s %d", f, r.WinsByFamily[f])
+		}
+		out += "  Winning family: " + strings.Join(parts, ", ")
+		if r.Undecided > 0 {
+			out += fmt.Sprintf(", undecided %d", r.Undecided)
+		}
+		out += "\n"
+	}
+	for _, race := range r.Top {
+		name := race.Host
+		if name == "" {
+			name = "port " + race.Port
+		} else {
+			name += ":" + race.Port
+		}
+		winner := "no winner seen"
+		if race.Winner != "" {
+			winner = "won by " + race.Winner + " (" + race.WinnerFamily + ")"
+		}
+		out += fmt.Sprintf("    - %s (pid %d): %d attempts, %d failed, %s\n", name, race.PID, race.Attempts, race.Failed, winner)
+	}
+	return out
+}
+
 func GenerateFileSystemSection(d Diagnostician, duration time.Duration) string {
 	writeEvents := d.FilterEvents(events.EventWrite)
 	readEvents := d.FilterEvents(events.EventRead)
@@ -205,20 +561,36 @@ func GenerateFileSystemSection(d Diagnostician, duration time.Duration) string {
 		return ""
 	}
 
+	writes, reads, fsyncs := len(writeEvents), len(readEvents), len(fsyncEvents)
+	all, partial := sessionGroup(d, events.EventWrite, events.EventRead, events.EventFsync)
+	if partial {
+		w, _ := sessionGroup(d, events.EventWrite)
+		r, _ := sessionGroup(d, events.EventRead)
+		f, _ := sessionGroup(d, events.EventFsync)
+		writes, reads, fsyncs = w.Events, r.Events, f.Events
+	}
+
 	var report string
 	report += formatter.SectionHeader("File System")
-	writeRate := d.CalculateRate(len(writeEvents), duration)
-	readRate := d.CalculateRate(len(readEvents), duration)
-	fsyncRate := d.CalculateRate(len(fsyncEvents), duration)
-	report += fmt.Sprintf("  Write operations: %d (%.1f/sec)\n", len(writeEvents), writeRate)
-	report += fmt.Sprintf("  Read operations: %d (%.1f/sec)\n", len(readEvents), readRate)
-	report += fmt.Sprintf("  Fsync operations: %d (%.1f/sec)\n", len(fsyncEvents), fsyncRate)
+	writeRate := d.CalculateRate(writes, duration)
+	readRate := d.CalculateRate(reads, duration)
+	fsyncRate := d.CalculateRate(fsyncs, duration)
+	report += fmt.Sprintf("  Write operations: %d (%.1f/sec)\n", writes, writeRate)
+	report += fmt.Sprintf("  Read operations: %d (%.1f/sec)\n", reads, readRate)
+	report += fmt.Sprintf("  Fsync operations: %d (%.1f/sec)\n", fsyncs, fsyncRate)
 
 	allFS := append(append(writeEvents, readEvents...), fsyncEvents...)
 	if len(allFS) > 0 {
 		avgLatency, maxLatency, slowOps, p50, p95, p99, totalBytes, avgBytes := analyzer.AnalyzeFS(allFS, d.FSSlowThreshold())
+		if partial {
+			avgLatency, maxLatency = all.AvgMS, all.MaxMS
+			p50, p95, p99 = all.P50MS, all.P95MS, all.P99MS
+		}
 		report += formatter.LatencyMetrics(avgLatency, maxLatency)
 		report += formatter.Percentiles(p50, p95, p99)
+		if partial {
+			report += keptNote(len(allFS))
+		}
 		report += fmt.Sprintf("  Slow operations (>%.1fms): %d\n", d.FSSlowThreshold(), slowOps)
 		if totalBytes > 0 {
 			report += formatter.BytesSection(totalBytes, avgBytes, calculateThroughput(totalBytes, duration))
@@ -233,6 +605,128 @@ func GenerateFileSystemSection(d Diagnostician, duration time.Duration) string {
 	return report
 }
 
+// GeneratePageCacheSection reports the page-cache hit ratio of the traced
+// reads and which files' slow reads went to the device, so slow reads can be
+// told apart into a cold cache and a slow device.
+func GeneratePageCacheSection(pc *analyzer.PageCache) string {
+	if pc == nil || (!pc.HasCounters && pc.Method == "") {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Page Cache")
+	if pc.HasCounters && pc.Reads > 0 {
+		report += fmt.Sprintf("  Hit ratio: %.1f%% of %d reads (%d missed, %d folios read from the device)\n",
+			pc.HitRatio*100, pc.Reads, pc.MissReads, pc.MissFolios)
+	}
+	if pc.SlowReads > 0 {
+		switch pc.Method {
+		case analyzer.ColdReadsByMisses:
+			report += fmt.Sprintf("  Slow reads: %d, %d on cold cache (by page-cache insertions)\n", pc.SlowReads, pc.ColdSlowReads)
+		case analyzer.ColdReadsByLatency:
+			report += fmt.Sprintf("  Slow reads: %d, %d on cold cache (over %.2fms, from the read latency modes)\n",
+				pc.SlowReads, pc.ColdSlowReads, pc.ColdThresholdMS)
+		default:
+			report += fmt.Sprintf("  Slow reads: %d, no cold-cache mode in their latencies\n", pc.SlowReads)
+		}
+	}
+	if len(pc.Files) > 0 {
+		report += "  Top files by cold-read latency:\n"
+		for _, f := range pc.Files {
+			report += fmt.Sprintf("    - %s: %d of %d slow reads cold (%.0f%% hit), %.1f ms cold of %.1f ms\n",
+				sanitize.Terminal(f.File), f.ColdReads, f.Reads, f.HitRatio*100, f.ColdLatencyMS, f.LatencyMS)
+		}
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateVolumeSection reports the FS latency per pod volume, so slow
+// writes point at the claim and storage class behind them rather than at a
+// file name.
+func GenerateVolumeSection(vols []analyzer.VolumeLatency, slowMS float64) string {
+	if len(vols) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Volume I/O")
+	for _, v := range vols {
+		backing := v.Kind
+		if v.Source != "" {
+			backing += " " + v.Source
+		}
+		if v.StorageClass != "" {
+			backing += ", storage class " + v.StorageClass
+		}
+		owner := v.Container
+		if v.Pod != "" {
+			owner = v.Pod + "/" + v.Container
+		}
+		report += fmt.Sprintf("  %s (%s) at %s in %s:\n",
+			sanitize.Terminal(v.Volume), sanitize.Terminal(backing), sanitize.Terminal(v.MountPath), sanitize.Terminal(owner))
+		report += fmt.Sprintf("    %d ops (%d writes, %d reads, %d fsyncs), avg %.2fms, p99 %.2fms, max %.2fms, %d slow (>%.1fms)",
+			v.Ops, v.Writes, v.Reads, v.Fsyncs, v.AvgMS, v.P99MS, v.MaxMS, v.SlowOps, slowMS)
+		if v.Bytes > 0 {
+			report += ", " + analyzer.FormatBytes(v.Bytes)
+		}
+		report += "\n"
+		if st := v.Storage; st != nil {
+			report += fmt.Sprintf("    Claim: %s %s %s, PV %s, provisioner %s",
+				orNone(st.Phase), orNone(st.Capacity), orNone(strings.Join(st.AccessModes, "/")),
+				orNone(st.PersistentVolume), orNone(st.Provisioner))
+			if st.CSIDriver != "" {
+				report += fmt.Sprintf(", CSI %s volume %s", st.CSIDriver, st.VolumeHandle)
+			}
+			report += "\n"
+			for _, e := range st.Events {
+				report += fmt.Sprintf("    Event: %s %s on %s (%dx, last %s): %s\n",
+					e.Type, e.Reason, e.Object, e.Count, e.LastSeen.Format(time.RFC3339), sanitize.Terminal(e.Message))
+			}
+		}
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateWriteFailureSection reports the writes refused by a read-only or
+// full volume, with why the volume refused them.
+func GenerateWriteFailureSection(failures []analyzer.WriteFailure) string {
+	if len(failures) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Refused Writes")
+	for _, f := range failures {
+		volume := "unknown volume"
+		if f.Volume != "" {
+			volume = fmt.Sprintf("%s (%s) at %s", f.Volume, f.Kind, f.MountPath)
+			if f.Pod != "" {
+				volume += " in " + f.Pod + "/" + f.Container
+			}
+		}
+		report += fmt.Sprintf("  %s: %d %s of %d attempts, %s\n",
+			sanitize.Terminal(volume), f.Failures, f.Errno, f.Attempts, writeRefusedCause(f))
+		for _, file := range f.Files {
+			report += fmt.Sprintf("    %s\n", sanitize.Terminal(file))
+		}
+	}
+	report += "\n"
+	return report
+}
+
+func writeRefusedCause(f analyzer.WriteFailure) string {
+	switch f.Cause {
+	case analyzer.WriteRefusedReadOnlyRoot:
+		return "readOnlyRootFilesystem"
+	case analyzer.WriteRefusedReadOnlyMount:
+		return "read-only mount"
+	case analyzer.WriteRefusedReadOnlyFS:
+		return "filesystem went read-only"
+	case analyzer.WriteRefusedSizeLimit:
+		return "emptyDir sizeLimit " + f.SizeLimit + " reached"
+	}
+	return "out of space"
+}
+
 func buildFileMap(allFS []*events.Event) map[string]int {
 	fileMap := make(map[string]int)
 	for _, e := range allFS {
@@ -306,12 +800,19 @@ func GenerateHTTPSection(d Diagnostician, duration time.Duration) string {
 		return ""
 	}
 
+	reqs, resps := len(httpReqEvents), len(httpRespEvents)
+	req, reqPartial := sessionGroup(d, events.EventHTTPReq)
+	resp, respPartial := sessionGroup(d, events.EventHTTPResp)
+	if reqPartial || respPartial {
+		reqs, resps = req.Events, resp.Events
+	}
+
 	var report string
 	report += formatter.SectionHeader("HTTP")
-	reqRate := d.CalculateRate(len(httpReqEvents), duration)
-	respRate := d.CalculateRate(len(httpRespEvents), duration)
-	report += fmt.Sprintf("  Requests: %d (%.1f/sec)\n", len(httpReqEvents), reqRate)
-	report += fmt.Sprintf("  Responses: %d (%.1f/sec)\n", len(httpRespEvents), respRate)
+	reqRate := d.CalculateRate(reqs, duration)
+	respRate := d.CalculateRate(resps, duration)
+	report += fmt.Sprintf("  Requests: %d (%.1f/sec)\n", reqs, reqRate)
+	report += fmt.Sprintf("  Responses: %d (%.1f/sec)\n", resps, respRate)
 	if line := httpTransportBreakdown(httpReqEvents, httpRespEvents); line != "" {
 		report += "  Transport: " + line + "\n"
 	}
@@ -324,8 +825,15 @@ func GenerateHTTPSection(d Diagnostician, duration time.Duration) string {
 		p50 := analyzer.Percentile(latencies, 50)
 		p95 := analyzer.Percentile(latencies, 95)
 		p99 := analyzer.Percentile(latencies, 99)
+		if respPartial {
+			avgLatency = resp.AvgMS
+			p50, p95, p99 = resp.P50MS, resp.P95MS, resp.P99MS
+		}
 		report += fmt.Sprintf("  Average latency: %.2fms\n", avgLatency)
 		report += formatter.Percentiles(p50, p95, p99)
+		if reqPartial || respPartial {
+			report += keptNote(len(httpReqEvents) + len(httpRespEvents))
+		}
 		if totalBytes > 0 {
 			bytesSection := formatter.BytesSection(totalBytes, avgBytes, calculateThroughput(totalBytes, duration))
 			bytesSection = strings.Replace(bytesSection, "Average bytes per operation", "Average bytes per response", 1)
@@ -347,6 +855,7 @@ func GenerateHTTPSection(d Diagnostician, duration time.Duration) string {
 		if len(statusMap) > 0 {
 			report += formatter.TopItemsWithRate(statusMap, config.TopURLsLimit, "response status codes", "responses", duration)
 		}
+		report += httpStatusByEndpoint(analyzer.AnalyzeHTTPStatus(httpRespEvents, d.StartTime(), d.EndTime()))
 	}
 	if tp := traceContextCount(httpReqEvents); tp > 0 {
 		report += fmt.Sprintf("  Trace context: %d/%d requests carried a W3C traceparent\n",
@@ -363,6 +872,43 @@ func GenerateHTTPSection(d Diagnostician, duration time.Duration) string {
 	return report
 }
 
+// GenerateObjectStorageSection breaks S3-style HTTP calls down by bucket and
+// operation, so a LIST storm against one bucket stands out from the rest of
+// the HTTP traffic.
+func GenerateObjectStorageSection(d Diagnostician, duration time.Duration) string {
+	httpRespEvents := d.FilterEvents(events.EventHTTPResp)
+	if len(httpRespEvents) == 0 {
+		return ""
+	}
+	stats := analyzer.AnalyzeObjectStorage(d.FilterEvents(events.EventHTTPReq), httpRespEvents)
+	if len(stats) == 0 {
+		return ""
+	}
+
+	total := 0
+	for _, s := range stats {
+		total += s.Count
+	}
+	var report string
+	report += formatter.SectionHeader("Object Storage")
+	report += fmt.Sprintf("  S3-style calls: %d (%.1f/sec)\n", total, d.CalculateRate(total, duration))
+	report += "  By bucket and operation:\n"
+	for i, s := range stats {
+		if i >= config.TopURLsLimit {
+			break
+		}
+		report += fmt.Sprintf("    - %s %s: %d calls (%.1f/sec), p50 %.2fms, p95 %.2fms, p99 %.2fms, max %.2fms",
+			sanitize.Terminal(s.Bucket), s.Operation, s.Count, d.CalculateRate(s.Count, duration),
+			s.P50MS, s.P95MS, s.P99MS, s.MaxMS)
+		if s.Errors > 0 {
+			report += fmt.Sprintf(", %d errors", s.Errors)
+		}
+		report += "\n"
+	}
+	report += "\n"
+	return report
+}
+
 func traceContextCount(httpReqEvents []*events.Event) int {
 	n := 0
 	for _, e := range httpReqEvents {
@@ -472,203 +1018,1167 @@ func buildResponseEndpointMap(httpRespEvents []*events.Event) map[string]int {
 	return endpointMap
 }
 
-// buildStatusMap counts HTTP response events by status code so the report can
-// surface the response side.
-func buildStatusMap(httpRespEvents []*events.Event) map[string]int {
-	statusMap := make(map[string]int)
-	for _, e := range httpRespEvents {
-		if code := responseStatus(e); code != "" {
-			statusMap[code]++
+// buildStatusMap counts HTTP response events by status code so the report can
+// surface the response side.
+func buildStatusMap(httpRespEvents []*events.Event) map[string]int {
+	statusMap := make(map[string]int)
+	for _, e := range httpRespEvents {
+		if code := responseStatus(e); code != "" {
+			statusMap[code]++
+		}
+	}
+	return statusMap
+}
+
+// responseStatus extracts the 3-digit status code from a response event. The
+// code is carried on the first line of Details.
+func responseStatus(e *events.Event) string {
+	if code := analyzer.HTTPStatusCode(e); code != 0 {
+		return strconv.Itoa(code)
+	}
+	return ""
+}
+
+// httpStatusByEndpoint renders the status classes of the busiest endpoints,
+// with the 5xx rate over each slice of the run so a climbing rate shows.
+func httpStatusByEndpoint(statuses []analyzer.HTTPEndpointStatus) string {
+	if len(statuses) == 0 {
+		return ""
+	}
+	out := "  Status by endpoint:\n"
+	for i, s := range statuses {
+		if i == config.TopURLsLimit {
+			break
+		}
+		out += fmt.Sprintf("    %s: %d responses, 2xx %d, 3xx %d, 4xx %d, 5xx %d (%.1f%%)",
+			sanitize.Terminal(s.Endpoint), s.Responses, s.Status2xx, s.Status3xx, s.Status4xx, s.Status5xx, s.ErrorRate())
+		if s.Throttled != nil {
+			out += fmt.Sprintf(", 429 %d", s.Throttled.Responses)
+		}
+		if s.Status5xx > 0 {
+			rates := make([]string, len(s.Trend))
+			for j, slice := range s.Trend {
+				rates[j] = "-"
+				if slice.Responses > 0 {
+					rates[j] = fmt.Sprintf("%.0f%%", slice.ErrorRate())
+				}
+			}
+			out += "; 5xx rate over the run: " + strings.Join(rates, " ")
+		}
+		out += "\n"
+	}
+	return out
+}
+
+// httpTransportBreakdown tallies HTTP events by protocol label (HTTP, HTTPS,
+// HTTP/2, HTTP/3) and renders a one-line summary. Returns "" when every event is
+// plain cleartext HTTP/1.x, so the line only appears when there is something to
+// distinguish (TLS, h2c, or h3 traffic present).
+func httpTransportBreakdown(eventGroups ...[]*events.Event) string {
+	counts := make(map[string]int)
+	total := 0
+	for _, group := range eventGroups {
+		for _, e := range group {
+			counts[e.HTTPProtoLabel()]++
+			total++
+		}
+	}
+	if total == 0 || counts["HTTP"] == total {
+		return ""
+	}
+	parts := make([]string, 0, len(counts))
+	for _, label := range []string{"HTTP", "HTTPS", "HTTP/2", "HTTP/3"} {
+		if n := counts[label]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, label))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+func GenerateCPUSection(d Diagnostician, duration time.Duration) string {
+	schedEvents := d.FilterEvents(events.EventSchedSwitch)
+	threads := analyzer.AnalyzeThreads(append(d.FilterEvents(events.EventThreadCPU), schedEvents...))
+	if len(schedEvents) == 0 && len(threads) == 0 {
+		return ""
+	}
+
+	var report string
+	report += formatter.SectionHeader("CPU")
+	if len(schedEvents) > 0 {
+		avgBlock, maxBlock, p50, p95, p99 := analyzer.AnalyzeCPU(schedEvents)
+		schedRate := d.CalculateRate(len(schedEvents), duration)
+		report += fmt.Sprintf("  Thread switches: %d (%.1f/sec)\n", len(schedEvents), schedRate)
+		report += fmt.Sprintf("  Average block time: %.2fms\n", avgBlock)
+		report += fmt.Sprintf("  Max block time: %.2fms\n", maxBlock)
+		report += formatter.Percentiles(p50, p95, p99)
+	}
+	report += formatThreads(threads, duration)
+	report += "\n"
+	return report
+}
+
+// formatThreads lists the threads that ran the longest and those that spent
+// the longest blocked, up to PODTRACE_TOP_THREADS_LIMIT each.
+func formatThreads(threads []analyzer.ThreadCPU, duration time.Duration) string {
+	label := func(th analyzer.ThreadCPU) string {
+		name := th.Name
+		if name == "" {
+			name = "?"
+		}
+		proc := fmt.Sprintf("pid %d", th.PID)
+		if th.Process != "" {
+			proc += " " + th.Process
+		}
+		return fmt.Sprintf("%s (tid %d, %s)", sanitize.Terminal(name), th.TID, sanitize.Terminal(proc))
+	}
+
+	var result string
+	var shown int
+	for _, th := range threads {
+		if th.OnCPUMS <= 0 || shown >= config.TopThreadsLimit {
+			break
+		}
+		if shown == 0 {
+			result += "  Top threads by CPU time:\n"
+		}
+		result += fmt.Sprintf("    - %s: %.2fms on CPU", label(th), th.OnCPUMS)
+		if windowMS := duration.Seconds() * 1000; windowMS > 0 {
+			result += fmt.Sprintf(" (%.1f%% of a CPU)", th.OnCPUMS/windowMS*100)
+		}
+		result += "\n"
+		shown++
+	}
+
+	blocked := append([]analyzer.ThreadCPU(nil), threads...)
+	sort.SliceStable(blocked, func(i, j int) bool { return blocked[i].BlockedMS > blocked[j].BlockedMS })
+	shown = 0
+	for _, th := range blocked {
+		if th.Blocks == 0 || shown >= config.TopThreadsLimit {
+			break
+		}
+		if shown == 0 {
+			result += "  Top threads by block time:\n"
+		}
+		result += fmt.Sprintf("    - %s: blocked %d times for %.2fms (max %.2fms)\n", label(th), th.Blocks, th.BlockedMS, th.MaxBlockMS)
+		shown++
+	}
+	return result
+}
+
+func GenerateTCPStateSection(d Diagnostician, duration time.Duration) string {
+	tcpStateEvents := d.FilterEvents(events.EventTCPState)
+	if len(tcpStateEvents) == 0 {
+		return ""
+	}
+
+	var report string
+	report += "TCP Connection State Tracking:\n"
+	stateRate := d.CalculateRate(len(tcpStateEvents), duration)
+	report += fmt.Sprintf("  State changes: %d (%.1f/sec)\n", len(tcpStateEvents), stateRate)
+	stateCounts := buildStateCounts(tcpStateEvents)
+	if len(stateCounts) > 0 {
+		report += formatStateDistribution(stateCounts)
+	}
+	report += "\n"
+	return report
+}
+
+func buildStateCounts(tcpStateEvents []*events.Event) map[string]int {
+	stateCounts := make(map[string]int)
+	for _, e := range tcpStateEvents {
+		stateStr := events.TCPStateString(e.TCPState)
+		stateCounts[stateStr]++
+	}
+	return stateCounts
+}
+
+func formatStateDistribution(stateCounts map[string]int) string {
+	type stateInfo struct {
+		state string
+		count int
+	}
+	var states []stateInfo
+	for state, count := range stateCounts {
+		states = append(states, stateInfo{state: state, count: count})
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].count > states[j].count
+	})
+	var result string
+	result += "  State distribution:\n"
+	for i, s := range states {
+		if i >= config.TopStatesLimit {
+			break
+		}
+		result += fmt.Sprintf("    - %s: %d\n", s.state, s.count)
+	}
+	return result
+}
+
+func GenerateMemorySection(d Diagnostician, duration time.Duration) string {
+	pageFaultEvents := d.FilterEvents(events.EventPageFault)
+	oomKillEvents := d.FilterEvents(events.EventOOMKill)
+	if len(pageFaultEvents) == 0 && len(oomKillEvents) == 0 {
+		return ""
+	}
+
+	var report string
+	report += formatter.SectionHeader("Memory")
+	if len(pageFaultEvents) > 0 {
+		report += formatPageFaults(pageFaultEvents, duration, d)
+	}
+	if len(oomKillEvents) > 0 {
+		report += formatOOMKills(oomKillEvents)
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateCrashSection reports processes that dumped core during the trace:
+// the signal, faulting address, where the core went, the top frames and what
+// the process was doing in the seconds before it died.
+func GenerateCrashSection(d Diagnostician) string {
+	crashes := analyzer.AnalyzeCrashes(d.GetEvents())
+	if len(crashes) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Crashes")
+	for _, c := range crashes {
+		report += fmt.Sprintf("  %s (PID %d) %s at +%.1fs", sanitize.Terminal(c.Process), c.PID, c.Signal, c.At.Sub(d.StartTime()).Seconds())
+		if c.HasFaultAddr {
+			report += fmt.Sprintf(", fault address 0x%x", c.FaultAddr)
+		}
+		report += "\n"
+		if c.Core != "" {
+			report += fmt.Sprintf("    Core: %s\n", sanitize.Terminal(c.Core))
+		}
+		if len(c.Frames) > 0 {
+			report += "    Top frames:\n"
+			for i, f := range c.Frames {
+				report += fmt.Sprintf("      #%d %s\n", i, sanitize.Terminal(f))
+			}
+		}
+		if len(c.Before) > 0 {
+			report += fmt.Sprintf("    Activity in the %s before the crash:\n", analyzer.CrashLookback)
+			for _, a := range c.Before {
+				line := fmt.Sprintf("      - %s: %d events", a.Type, a.Count)
+				if a.Errors > 0 {
+					line += fmt.Sprintf(", %d errors", a.Errors)
+				}
+				if a.P95MS > 0 {
+					line += fmt.Sprintf(", p95 %.2fms (whole trace %.2fms)", a.P95MS, a.TraceP95MS)
+				}
+				report += line + "\n"
+			}
+		}
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateEventLoopSection reports Node.js event-loop blocks (iterations
+// longer than the in-kernel 10ms floor) and how many slow operations of the
+// same process overlapped one, which points latency spikes at the loop
+// rather than the network or the peer.
+func GenerateEventLoopSection(d Diagnostician, duration time.Duration) string {
+	lags := d.FilterEvents(events.EventLoopLag)
+	if len(lags) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Event Loop")
+	report += fmt.Sprintf("  Blocked iterations (>10ms): %d (%.1f/sec)\n", len(lags), d.CalculateRate(len(lags), duration))
+
+	latencies := make([]float64, 0, len(lags))
+	blocks := make(map[uint32][]loopBlock)
+	perPID := make(map[string]int)
+	var total float64
+	for _, e := range lags {
+		ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+		latencies = append(latencies, ms)
+		total += ms
+		start := uint64(0)
+		if e.Timestamp > e.LatencyNS {
+			start = e.Timestamp - e.LatencyNS
+		}
+		blocks[e.PID] = append(blocks[e.PID], loopBlock{start: start, end: e.Timestamp})
+		name := e.Target
+		if name == "" {
+			name = "node"
+		}
+		perPID[fmt.Sprintf("%s (PID %d)", name, e.PID)]++
+	}
+	sort.Float64s(latencies)
+	report += fmt.Sprintf("  Total time blocked: %.2fms, longest block: %.2fms\n", total, latencies[len(latencies)-1])
+	report += formatter.Percentiles(analyzer.Percentile(latencies, 50), analyzer.Percentile(latencies, 95), analyzer.Percentile(latencies, 99))
+	report += formatter.TopItems(perPID, config.TopProcessesLimit, "processes with blocked loops", "blocks")
+
+	for pid := range blocks {
+		sort.Slice(blocks[pid], func(i, j int) bool { return blocks[pid][i].start < blocks[pid][j].start })
+	}
+	slow, overlapped := 0, 0
+	for _, e := range d.GetEvents() {
+		if e == nil || e.LatencyNS < uint64(config.NSPerMS) || !loopAttributable(e.Type) {
+			continue
+		}
+		pb, ok := blocks[e.PID]
+		if !ok {
+			continue
+		}
+		slow++
+		if overlapsLoopBlock(pb, e.Timestamp-min(e.Timestamp, e.LatencyNS), e.Timestamp) {
+			overlapped++
+		}
+	}
+	if slow > 0 {
+		report += fmt.Sprintf("  Slow operations overlapping a blocked loop: %d of %d (%.1f%%)\n",
+			overlapped, slow, float64(overlapped)*float64(config.Percent100)/float64(slow))
+	}
+	report += "\n"
+	return report
+}
+
+// maxLoopThreadsScanned bounds the backwards walk in overlapsLoopBlock.
+const maxLoopThreadsScanned = 8
+
+type loopBlock struct {
+	start, end uint64
+}
+
+// loopAttributable reports whether a slow event of this type can be caused
+// by a blocked event loop; CPU and runtime events describe the block itself.
+func loopAttributable(t events.EventType) bool {
+	switch t {
+	case events.EventLoopLag, events.EventSchedSwitch, events.EventLockContention,
+		events.EventPyCall, events.EventPyGC, events.EventUprobe:
+		return false
+	}
+	return true
+}
+
+// overlapsLoopBlock reports whether [start, end] intersects any block in
+// blocks, which must be sorted by start.
+func overlapsLoopBlock(blocks []loopBlock, start, end uint64) bool {
+	i := sort.Search(len(blocks), func(i int) bool { return blocks[i].start > end })
+	for j := i - 1; j >= 0; j-- {
+		if blocks[j].end >= start {
+			return true
+		}
+		// Blocks of one loop never overlap, so only a few recent ones (one
+		// per loop thread) can still reach start.
+		if i-j >= maxLoopThreadsScanned {
+			break
+		}
+	}
+	return false
+}
+
+// GeneratePythonSection aggregates CPython calls that ran longer than the
+// in-kernel 1ms floor, by function, and garbage collections by generation.
+func GeneratePythonSection(d Diagnostician, duration time.Duration) string {
+	calls := d.FilterEvents(events.EventPyCall)
+	gcs := d.FilterEvents(events.EventPyGC)
+	if len(calls) == 0 && len(gcs) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Python")
+	if len(calls) > 0 {
+		report += formatPythonCalls(calls, duration, d)
+	}
+	if len(gcs) > 0 {
+		report += formatPythonGC(gcs, duration, d)
+	}
+	report += "\n"
+	return report
+}
+
+type pythonFuncStats struct {
+	name  string
+	count int
+	total float64
+	max   float64
+}
+
+func pythonCallName(e *events.Event) string {
+	if e.Target == "" {
+		return "(frame evaluation, interpreter without USDT)"
+	}
+	name := e.Target
+	if e.Details != "" {
+		name += fmt.Sprintf(" (%s:%d)", e.Details, e.TCPState)
+	}
+	return name
+}
+
+func formatPythonCalls(calls []*events.Event, duration time.Duration, d Diagnostician) string {
+	var result string
+	result += fmt.Sprintf("  Slow calls (>1ms): %d (%.1f/sec)\n", len(calls), d.CalculateRate(len(calls), duration))
+	latencies := make([]float64, 0, len(calls))
+	byFunc := make(map[string]*pythonFuncStats)
+	for _, e := range calls {
+		ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+		latencies = append(latencies, ms)
+		name := pythonCallName(e)
+		st := byFunc[name]
+		if st == nil {
+			st = &pythonFuncStats{name: name}
+			byFunc[name] = st
+		}
+		st.count++
+		st.total += ms
+		if ms > st.max {
+			st.max = ms
+		}
+	}
+	sort.Float64s(latencies)
+	result += formatter.Percentiles(analyzer.Percentile(latencies, 50), analyzer.Percentile(latencies, 95), analyzer.Percentile(latencies, 99))
+
+	funcs := make([]*pythonFuncStats, 0, len(byFunc))
+	for _, st := range byFunc {
+		funcs = append(funcs, st)
+	}
+	sort.Slice(funcs, func(i, j int) bool {
+		if funcs[i].total != funcs[j].total {
+			return funcs[i].total > funcs[j].total
+		}
+		return funcs[i].name < funcs[j].name
+	})
+	result += "  Top functions by time:\n"
+	for i, st := range funcs {
+		if i >= config.TopURLsLimit {
+			break
+		}
+		result += fmt.Sprintf("    - %s: %d calls, avg %.2fms, max %.2fms\n",
+			sanitize.Terminal(st.name), st.count, st.total/float64(st.count), st.max)
+	}
+	return result
+}
+
+func formatPythonGC(gcs []*events.Event, duration time.Duration, d Diagnostician) string {
+	type genStats struct {
+		count     int
+		pause     float64
+		max       float64
+		collected uint64
+	}
+	gens := make(map[uint32]*genStats)
+	var total float64
+	for _, e := range gcs {
+		ms := float64(e.LatencyNS) / float64(config.NSPerMS)
+		total += ms
+		g := gens[e.TCPState]
+		if g == nil {
+			g = &genStats{}
+			gens[e.TCPState] = g
+		}
+		g.count++
+		g.pause += ms
+		g.collected += e.Bytes
+		if ms > g.max {
+			g.max = ms
+		}
+	}
+	var result string
+	result += fmt.Sprintf("  GC collections: %d (%.1f/sec), total pause %.2fms\n",
+		len(gcs), d.CalculateRate(len(gcs), duration), total)
+	keys := make([]uint32, 0, len(gens))
+	for k := range gens {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	for _, k := range keys {
+		g := gens[k]
+		result += fmt.Sprintf("    - generation %d: %d collections, avg pause %.2fms, max %.2fms, %d objects collected\n",
+			k, g.count, g.pause/float64(g.count), g.max, g.collected)
+	}
+	return result
+}
+
+func formatPageFaults(pageFaultEvents []*events.Event, duration time.Duration, d Diagnostician) string {
+	var result string
+	faultRate := d.CalculateRate(len(pageFaultEvents), duration)
+	result += fmt.Sprintf("  Page faults: %d (%.1f/sec)\n", len(pageFaultEvents), faultRate)
+	errorCounts := buildErrorCounts(pageFaultEvents)
+	if len(errorCounts) > 0 {
+		result += "  Page fault error codes:\n"
+		for errCode, count := range errorCounts {
+			result += fmt.Sprintf("    - Error %d: %d occurrences\n", errCode, count)
+		}
+	}
+	return result
+}
+
+func buildErrorCounts(pageFaultEvents []*events.Event) map[int32]int {
+	errorCounts := make(map[int32]int)
+	for _, e := range pageFaultEvents {
+		errorCounts[e.Error]++
+	}
+	return errorCounts
+}
+
+func formatOOMKills(oomKillEvents []*events.Event) string {
+	var result string
+	result += fmt.Sprintf("  OOM kills: %d\n", len(oomKillEvents))
+	var totalMem uint64
+	for _, e := range oomKillEvents {
+		totalMem += e.Bytes
+	}
+	if len(oomKillEvents) > 0 {
+		result += fmt.Sprintf("  Total memory killed: %s\n", analyzer.FormatBytes(totalMem))
+		result += fmt.Sprintf("  Average memory per kill: %s\n", analyzer.FormatBytes(totalMem/uint64(len(oomKillEvents))))
+		result += "  Killed processes:\n"
+		for i, e := range oomKillEvents {
+			if i >= config.MaxOOMKillsDisplay {
+				break
+			}
+			procName := e.Target
+			if procName == "" {
+				procName = fmt.Sprintf("PID %d", e.PID)
+			}
+			result += fmt.Sprintf("    - %s (%s)\n", sanitize.Terminal(procName), analyzer.FormatBytes(e.Bytes))
+		}
+	}
+	return result
+}
+
+// GenerateSLOSection reports pass/fail for each objective declared with
+// --slo.
+func GenerateSLOSection(results []slo.Result) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("SLO")
+	for _, r := range results {
+		status := strings.ToUpper(string(r.Status))
+		if r.Status == slo.StatusNoData {
+			report += fmt.Sprintf("  [%s] %s: no matching events\n", status, sanitize.Terminal(r.SLO.Name))
+			continue
+		}
+		report += fmt.Sprintf("  [%s] %s: %d events, p99 %.2fms, error rate %.2f%%\n",
+			status, sanitize.Terminal(r.SLO.Name), r.Events, r.P99MS, r.ErrorRate)
+		for _, v := range r.Violations {
+			report += fmt.Sprintf("    - %s\n", v)
+		}
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateBudgetSection notes the switch to aggregation-only mode once the
+// event budget was spent, with the per-type totals of the events that are
+// missing from every other section.
+func GenerateBudgetSection(o *analyzer.BudgetOverflow, start time.Time) string {
+	if o == nil {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Event Budget")
+	report += fmt.Sprintf("  Budget of %d events reached at +%.1fs; switched to aggregation-only mode\n",
+		o.Limit, o.ReachedAt.Sub(start).Seconds())
+	report += fmt.Sprintf("  %d later events were only counted and are not in the sections below:\n", o.Events)
+	for _, t := range o.ByType {
+		line := fmt.Sprintf("    %-12s %d events, %d errors", t.Type, t.Events, t.Errors)
+		if t.MaxLatencyMS > 0 {
+			line += fmt.Sprintf(", avg latency %.2fms, max %.2fms", t.AvgLatencyMS, t.MaxLatencyMS)
+		}
+		report += line + "\n"
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateLowPrivilegeSection tells the reader that the trace ran without
+// BPF and lists what it could not see, so that empty sections are not read
+// as a clean bill of health.
+func GenerateLowPrivilegeSection(disabled []string) string {
+	if len(disabled) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Low-Privilege Mode")
+	report += "  Traced without BPF: only cgroup resource usage and per-thread CPU time were collected.\n"
+	report += "  Not observed, so absent from the sections below:\n"
+	for _, what := range disabled {
+		report += fmt.Sprintf("    - %s\n", sanitize.Terminal(what))
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateSuppressedSection notes the known-benign events left out of the
+// sections below and of issue detection.
+func GenerateSuppressedSection(counts []suppress.Count) string {
+	if len(counts) == 0 {
+		return ""
+	}
+	total := 0
+	for _, c := range counts {
+		total += c.Events
+	}
+	var report string
+	report += formatter.SectionHeader("Suppressed Benign Events")
+	report += fmt.Sprintf("  %d known-benign events left out of the tables and issue detection below:\n", total)
+	for _, c := range counts {
+		report += fmt.Sprintf("    %-24s %d\n", sanitize.Terminal(c.Rule), c.Events)
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateSessionSection prints the streaming totals of the whole session
+// when the kept events (kept) no longer cover all of it.
+func GenerateSessionSection(s *analyzer.SessionTotals, kept int) string {
+	if s == nil {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Session Totals")
+	if kept >= s.Events {
+		report += fmt.Sprintf("  %d events seen, all kept\n", s.Events)
+	} else {
+		report += fmt.Sprintf("  %d events seen, %d kept for the sections below; totals here cover all of them\n", s.Events, kept)
+	}
+	for _, t := range s.ByType {
+		line := fmt.Sprintf("    %-12s %d events, %d errors", t.Type, t.Events, t.Errors)
+		if t.MaxMS > 0 {
+			line += fmt.Sprintf(", p50 %.2fms, p95 %.2fms, p99 %.2fms, max %.2fms", t.P50MS, t.P95MS, t.P99MS, t.MaxMS)
+		}
+		report += line + "\n"
+	}
+	if len(s.TopTargets) > 0 {
+		report += "  Busiest targets (estimated):\n"
+		for i, c := range s.TopTargets {
+			if i >= config.TopTargetsLimit {
+				break
+			}
+			report += fmt.Sprintf("    %-40s ~%d events\n", sanitize.Terminal(c.Key), c.Count)
+		}
+	}
+	if len(s.RecentErrors) > 0 {
+		report += "  Last errors:\n"
+		for _, e := range s.RecentErrors {
+			report += fmt.Sprintf("    %s\n", sanitize.Terminal(e.String()))
+		}
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateExternalNetworkSection shows which networks internet-bound traffic
+// went to, so retransmits and failed connects can be blamed on the right
+// third-party provider.
+func GenerateExternalNetworkSection(n *analyzer.ExternalNetworks) string {
+	if n == nil {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("External Network")
+	report += fmt.Sprintf("  %d internet-bound events across %d networks", n.Events, len(n.Networks))
+	if n.Untagged > 0 {
+		report += fmt.Sprintf(" (%d to addresses not in the GeoIP database)", n.Untagged)
+	}
+	report += "\n"
+	for i, s := range n.Networks {
+		if i >= config.TopTargetsLimit {
+			break
+		}
+		name := s.Network
+		if s.Country != "" {
+			name += " [" + s.Country + "]"
+		}
+		line := fmt.Sprintf("    %-40s %d events", sanitize.Terminal(name), s.Events)
+		if s.Connections > 0 {
+			line += fmt.Sprintf(", %d connects", s.Connections)
+			if s.FailedConnections > 0 {
+				line += fmt.Sprintf(" (%d failed)", s.FailedConnections)
+			}
+		}
+		if s.Bytes > 0 {
+			line += ", " + analyzer.FormatBytes(s.Bytes)
+		}
+		report += line + "\n"
+	}
+	var blame []string
+	for _, s := range n.Networks {
+		if s.Retransmits > 0 && n.Retransmits > 0 {
+			blame = append(blame, fmt.Sprintf("%.0f%% of retransmits are to %s",
+				float64(s.Retransmits)*100/float64(n.Retransmits), sanitize.Terminal(s.Network)))
+		}
+		if s.FailedConnections > 0 && n.FailedConnections > 0 {
+			blame = append(blame, fmt.Sprintf("%.0f%% of failed external connects are to %s",
+				float64(s.FailedConnections)*100/float64(n.FailedConnections), sanitize.Terminal(s.Network)))
+		}
+	}
+	for i, b := range blame {
+		if i >= config.TopTargetsLimit {
+			break
+		}
+		report += "  " + b + "\n"
+	}
+	report += "\n"
+	return report
+}
+
+// GeneratePodThroughputSection shows what the traced pods' veths carried, as
+// counted in the kernel for every packet rather than estimated from the
+// sampled socket calls.
+func GeneratePodThroughputSection(t *analyzer.PodThroughput) string {
+	if t == nil {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Pod Throughput")
+	for _, d := range t.Directions {
+		report += fmt.Sprintf("  %-8s %s in %d packets, avg %s, peak %s\n", d.Direction+":",
+			analyzer.FormatBytes(d.Bytes), d.Packets, formatBitRate(d.AvgBitsPerS), formatBitRate(d.PeakBitsPerS))
+		var parts []string
+		for _, c := range d.ByClass {
+			share := 0.0
+			if d.Bytes > 0 {
+				share = float64(c.Bytes) * 100 / float64(d.Bytes)
+			}
+			parts = append(parts, fmt.Sprintf("%s %s (%.0f%%)", c.Class, analyzer.FormatBytes(c.Bytes), share))
+		}
+		if len(parts) > 0 {
+			report += "           " + strings.Join(parts, ", ") + "\n"
+		}
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateCPUPlacementSection renders where each traced process may run,
+// where its memory is and how long it waited for a CPU.
+func GenerateCPUPlacementSection(p *analyzer.CPUPlacement) string {
+	if p == nil {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("CPU Placement")
+	for _, proc := range p.Processes {
+		name := fmt.Sprintf("pid %d", proc.PID)
+		if proc.Process != "" {
+			name += " (" + proc.Process + ")"
+		}
+		pinning := "unpinned"
+		if proc.Pinned {
+			pinning = "pinned"
+		}
+		report += fmt.Sprintf("  %s: CPUs %s on node %s (%s), memory nodes %s\n", name, proc.CPUs, proc.CPUNodes, pinning, proc.Mems)
+		if proc.MemoryBytes > 0 {
+			report += fmt.Sprintf("    Remote memory: %.0f%% (%s of %s)\n",
+				proc.RemoteMemoryShare*100, analyzer.FormatBytes(proc.RemoteMemoryBytes), analyzer.FormatBytes(proc.MemoryBytes))
+		}
+		report += fmt.Sprintf("    Run queue wait: %.1f%% (peak %.1f%%)\n", proc.RunQueueShare*100, proc.PeakRunQueueShare*100)
+		if proc.SharedCores != "" {
+			report += fmt.Sprintf("    Cores shared with other workloads: CPUs %s\n", proc.SharedCores)
+		}
+	}
+	if p.HasNodeAllocations {
+		report += fmt.Sprintf("  Node-wide remote page allocations: %.1f%%\n", p.NodeRemoteAllocShare*100)
+	}
+	report += "\n"
+	return report
+}
+
+// GenerateStatefulSetMemberSection reports the lookups and connects to each
+// member of a headless Service the traced pods addressed by pod DNS name,
+// marking the member that fails or is slow while its siblings are not.
+func GenerateStatefulSetMemberSection(services []analyzer.HeadlessService) string {
+	if len(services) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("StatefulSet Members")
+	for _, s := range services {
+		report += fmt.Sprintf("  %s/%s: %d members\n", sanitize.Terminal(s.Namespace), sanitize.Terminal(s.Service), len(s.Members))
+		for _, m := range s.Members {
+			line := fmt.Sprintf("    - %s: %d lookups", sanitize.Terminal(m.Name), m.Lookups)
+			if m.FailedLookups > 0 {
+				line += fmt.Sprintf(" (%d failed)", m.FailedLookups)
+			}
+			line += fmt.Sprintf(", %d connects", m.Connects)
+			if m.Connects > 0 {
+				line += fmt.Sprintf(" (%d failed, avg %.2fms)", m.FailedConnects, m.AvgConnectMS)
+			}
+			if m.Outlier {
+				line += " <- differs from the other members"
+			}
+			report += line + "\n"
 		}
 	}
-	return statusMap
+	report += "\n"
+	return report
 }
 
-// responseStatus extracts the 3-digit status code from a response event. The
-// code is carried on the first line of Details.
-func responseStatus(e *events.Event) string {
-	first := e.Details
-	if i := strings.IndexByte(first, '\n'); i >= 0 {
-		first = first[:i]
-	}
-	if n, err := strconv.Atoi(strings.TrimSpace(first)); err == nil && n >= 100 && n <= 599 {
-		return strconv.Itoa(n)
+// GenerateRetransmitSection reports SYN and data retransmits apart, overall
+// and per target: SYNs that are resent mean connections are not being set
+// up, data that is resent means loss on connections that are.
+func GenerateRetransmitSection(r *analyzer.Retransmits) string {
+	if r == nil {
+		return ""
 	}
-	if e.Error >= 100 && e.Error <= 599 {
-		return strconv.Itoa(int(e.Error))
+	var report string
+	report += formatter.SectionHeader("TCP Retransmits")
+	report += fmt.Sprintf("  SYN retransmits: %d (connection setup failing)\n", r.SYN)
+	report += fmt.Sprintf("  Data retransmits: %d (loss on established connections)\n", r.Data)
+	for _, t := range r.Targets {
+		report += fmt.Sprintf("    - %s: %d SYN, %d data\n", sanitize.Terminal(t.Target), t.SYN, t.Data)
 	}
-	return ""
+	report += "\n"
+	return report
 }
 
-// httpTransportBreakdown tallies HTTP events by protocol label (HTTP, HTTPS,
-// HTTP/2, HTTP/3) and renders a one-line summary. Returns "" when every event is
-// plain cleartext HTTP/1.x, so the line only appears when there is something to
-// distinguish (TLS, h2c, or h3 traffic present).
-func httpTransportBreakdown(eventGroups ...[]*events.Event) string {
-	counts := make(map[string]int)
-	total := 0
-	for _, group := range eventGroups {
-		for _, e := range group {
-			counts[e.HTTPProtoLabel()]++
-			total++
-		}
-	}
-	if total == 0 || counts["HTTP"] == total {
+// GenerateWindowStallSection reports how long TCP receive windows stayed
+// closed and which end closed them, next to the retransmits of the same
+// targets: a stalled connection with a closed window has a receiver that
+// reads too slowly, one without it a lossy path.
+func GenerateWindowStallSection(s *analyzer.WindowStalls) string {
+	if s == nil {
 		return ""
 	}
-	parts := make([]string, 0, len(counts))
-	for _, label := range []string{"HTTP", "HTTPS", "HTTP/2", "HTTP/3"} {
-		if n := counts[label]; n > 0 {
-			parts = append(parts, fmt.Sprintf("%d %s", n, label))
-		}
+	var report string
+	report += formatter.SectionHeader("TCP Window Stalls")
+	if s.LocalStalls > 0 {
+		report += fmt.Sprintf("  Traced pod closed its receive window: %d times, %.1f ms\n", s.LocalStalls, s.LocalStallMS)
+	}
+	if s.PeerStalls > 0 {
+		report += fmt.Sprintf("  Peers closed their receive window: %d times, %.1f ms\n", s.PeerStalls, s.PeerStallMS)
+	}
+	report += fmt.Sprintf("  Retransmits: %d\n", s.Retransmits)
+	for _, t := range s.Targets {
+		var cause string
+		switch t.Cause {
+		case analyzer.StallLocalReader:
+			cause = "the traced pod reads too slowly"
+		case analyzer.StallPeerReader:
+			cause = "the peer reads too slowly"
+		default:
+			cause = "packet loss"
+		}
+		report += fmt.Sprintf("    - %s: %d stalls, %.1f ms (max %.1f ms), %d retransmits: %s\n",
+			sanitize.Terminal(t.Target), t.LocalStalls+t.PeerStalls, t.LocalStallMS+t.PeerStallMS,
+			t.MaxStallMS, t.Retransmits, cause)
 	}
-	return strings.Join(parts, ", ")
+	report += "\n"
+	return report
 }
 
-func GenerateCPUSection(d Diagnostician, duration time.Duration) string {
-	schedEvents := d.FilterEvents(events.EventSchedSwitch)
-	if len(schedEvents) == 0 {
+// GenerateClientTimeoutSection reports the client timeouts inferred from
+// requests abandoned after nearly the same time, next to the latency of the
+// requests that completed.
+func GenerateClientTimeoutSection(timeouts []analyzer.ClientTimeout) string {
+	if len(timeouts) == 0 {
 		return ""
 	}
-
-	avgBlock, maxBlock, p50, p95, p99 := analyzer.AnalyzeCPU(schedEvents)
 	var report string
-	report += formatter.SectionHeader("CPU")
-	schedRate := d.CalculateRate(len(schedEvents), duration)
-	report += fmt.Sprintf("  Thread switches: %d (%.1f/sec)\n", len(schedEvents), schedRate)
-	report += fmt.Sprintf("  Average block time: %.2fms\n", avgBlock)
-	report += fmt.Sprintf("  Max block time: %.2fms\n", maxBlock)
-	report += formatter.Percentiles(p50, p95, p99)
+	report += formatter.SectionHeader("Client Timeouts")
+	for _, t := range timeouts {
+		report += fmt.Sprintf("  %s to %s: client timeout appears to be ~%s; %.0f%% of requests hit it (%d of %d, abandoned after %.0f-%.0fms",
+			t.Operation, sanitize.Terminal(t.Target), formatTimeout(t.TimeoutMS), t.HitRate(), t.Hits, t.Requests,
+			t.MinMS, t.MaxMS)
+		if t.SuccessP99MS > 0 {
+			report += fmt.Sprintf("; completed p99 %s", formatTimeout(t.SuccessP99MS))
+		}
+		report += ")\n"
+	}
 	report += "\n"
 	return report
 }
 
-func GenerateTCPStateSection(d Diagnostician, duration time.Duration) string {
-	tcpStateEvents := d.FilterEvents(events.EventTCPState)
-	if len(tcpStateEvents) == 0 {
-		return ""
+// formatTimeout renders a duration in milliseconds the way timeouts are
+// configured: 2.0s, 500ms.
+func formatTimeout(ms float64) string {
+	if ms >= 1000 {
+		return fmt.Sprintf("%.1fs", ms/1000)
 	}
+	return fmt.Sprintf("%.0fms", ms)
+}
 
+// GenerateCompactionSection reports the time the traced processes spent
+// stalled in direct memory compaction and the khugepaged collapses of their
+// memory: both are latency that shows up nowhere in the application.
+func GenerateCompactionSection(c *analyzer.MemoryCompaction, duration time.Duration) string {
+	if c == nil {
+		return ""
+	}
 	var report string
-	report += "TCP Connection State Tracking:\n"
-	stateRate := d.CalculateRate(len(tcpStateEvents), duration)
-	report += fmt.Sprintf("  State changes: %d (%.1f/sec)\n", len(tcpStateEvents), stateRate)
-	stateCounts := buildStateCounts(tcpStateEvents)
-	if len(stateCounts) > 0 {
-		report += formatStateDistribution(stateCounts)
+	report += formatter.SectionHeader("Memory Compaction")
+	if c.Stalls > 0 {
+		line := fmt.Sprintf("  %.1f ms spent in memory compaction during the window", c.StallMS)
+		if duration > 0 {
+			line += fmt.Sprintf(" (%.2f%% of %s)", c.StallMS/(duration.Seconds()*1000)*100, duration.Round(time.Second))
+		}
+		report += line + "\n"
+		report += fmt.Sprintf("  Stalls: %d (p95 %.2fms, max %.2fms)\n", c.Stalls, c.P95StallMS, c.MaxStallMS)
+		if c.HugePageStalls > 0 {
+			report += fmt.Sprintf("  Huge page allocations: %d stalls, %.1f ms\n", c.HugePageStalls, c.HugePageStallMS)
+		}
+		results := make([]string, 0, len(c.Results))
+		for r := range c.Results {
+			results = append(results, r)
+		}
+		sort.Strings(results)
+		for i, r := range results {
+			results[i] = fmt.Sprintf("%s %d", r, c.Results[r])
+		}
+		report += fmt.Sprintf("  Results: %s\n", strings.Join(results, ", "))
+		for _, p := range c.Processes {
+			name := fmt.Sprintf("pid %d", p.PID)
+			if p.Process != "" {
+				name += " (" + sanitize.Terminal(p.Process) + ")"
+			}
+			report += fmt.Sprintf("    - %s: %d stalls, %.1f ms\n", name, p.Stalls, p.StallMS)
+		}
+	}
+	if c.Collapses > 0 {
+		report += fmt.Sprintf("  khugepaged collapses: %d (%d failed), %s collapsed into huge pages\n",
+			c.Collapses, c.FailedCollapses, analyzer.FormatBytes(c.CollapsedBytes))
 	}
 	report += "\n"
 	return report
 }
 
-func buildStateCounts(tcpStateEvents []*events.Event) map[string]int {
-	stateCounts := make(map[string]int)
-	for _, e := range tcpStateEvents {
-		stateStr := events.TCPStateString(e.TCPState)
-		stateCounts[stateStr]++
+// GenerateSwapSection reports, per cgroup, the pages moved to and from swap
+// and zswap and how much memory sat in swap.
+func GenerateSwapSection(swaps []analyzer.CgroupSwap) string {
+	if len(swaps) == 0 {
+		return ""
 	}
-	return stateCounts
+	var report string
+	report += formatter.SectionHeader("Swap")
+	for _, c := range swaps {
+		limit := "no memory limit"
+		if c.MemoryLimitBytes > 0 {
+			limit = analyzer.FormatBytes(c.MemoryLimitBytes) + " memory limit"
+		}
+		report += fmt.Sprintf("  %s (%s): active in %d of %d intervals\n", sanitize.Terminal(c.Cgroup), limit, c.ActiveIntervals, c.Samples)
+		report += fmt.Sprintf("    Swap: %s out, %s in, %s at peak\n",
+			analyzer.FormatBytes(c.SwapOutBytes), analyzer.FormatBytes(c.SwapInBytes), analyzer.FormatBytes(c.PeakSwapBytes))
+		if c.ZswapOutBytes+c.ZswapInBytes+c.PeakZswapBytes > 0 {
+			report += fmt.Sprintf("    Zswap: %s out, %s in, %s compressed at peak\n",
+				analyzer.FormatBytes(c.ZswapOutBytes), analyzer.FormatBytes(c.ZswapInBytes), analyzer.FormatBytes(c.PeakZswapBytes))
+		}
+	}
+	report += "\n"
+	return report
 }
 
-func formatStateDistribution(stateCounts map[string]int) string {
-	type stateInfo struct {
-		state string
-		count int
+// GenerateTmpfsSection reports, per cgroup, the memory its tmpfs files held
+// against its memory limit, and the memory-backed emptyDirs of the traced
+// pods those files may be in.
+func GenerateTmpfsSection(cgroups []analyzer.CgroupTmpfs, volumes []analyzer.VolumeMount) string {
+	if len(cgroups) == 0 {
+		return ""
 	}
-	var states []stateInfo
-	for state, count := range stateCounts {
-		states = append(states, stateInfo{state: state, count: count})
+	var report string
+	report += formatter.SectionHeader("Tmpfs Memory")
+	for _, c := range cgroups {
+		limit := "no memory limit"
+		if c.MemoryLimitBytes > 0 {
+			limit = analyzer.FormatBytes(c.MemoryLimitBytes) + " memory limit"
+		}
+		report += fmt.Sprintf("  %s (%s): %d samples\n", sanitize.Terminal(c.Cgroup), limit, c.Samples)
+		report += fmt.Sprintf("    Tmpfs: %s at start, %s at peak, %s at end",
+			analyzer.FormatBytes(c.FirstBytes), analyzer.FormatBytes(c.PeakBytes), analyzer.FormatBytes(c.LastBytes))
+		if c.MemoryLimitBytes > 0 {
+			report += fmt.Sprintf(", %.1f%% of the limit at peak", c.PeakLimitShare*100)
+		}
+		report += "\n"
+		if c.PeakMemoryBytes > 0 {
+			report += fmt.Sprintf("    Memory: %s in use at peak, tmpfs included\n", analyzer.FormatBytes(c.PeakMemoryBytes))
+		}
 	}
-	sort.Slice(states, func(i, j int) bool {
-		return states[i].count > states[j].count
-	})
-	var result string
-	result += "  State distribution:\n"
-	for i, s := range states {
-		if i >= config.TopStatesLimit {
-			break
+	for _, v := range volumes {
+		line := fmt.Sprintf("  emptyDir %s (medium Memory) at %s in %s/%s", v.Volume, v.MountPath, v.Pod, v.Container)
+		if v.SizeLimit != "" {
+			line += ", sizeLimit " + v.SizeLimit
 		}
-		result += fmt.Sprintf("    - %s: %d\n", s.state, s.count)
+		report += sanitize.Terminal(line) + "\n"
 	}
-	return result
+	report += "\n"
+	return report
 }
 
-func GenerateMemorySection(d Diagnostician, duration time.Duration) string {
-	pageFaultEvents := d.FilterEvents(events.EventPageFault)
-	oomKillEvents := d.FilterEvents(events.EventOOMKill)
-	if len(pageFaultEvents) == 0 && len(oomKillEvents) == 0 {
+// GenerateFsNotifySection reports, per cgroup, the inotify watches and
+// fanotify marks registered and what the kernel refused or dropped.
+func GenerateFsNotifySection(cgroups []analyzer.CgroupFsNotify) string {
+	if len(cgroups) == 0 {
 		return ""
 	}
-
 	var report string
-	report += formatter.SectionHeader("Memory")
-	if len(pageFaultEvents) > 0 {
-		report += formatPageFaults(pageFaultEvents, duration, d)
-	}
-	if len(oomKillEvents) > 0 {
-		report += formatOOMKills(oomKillEvents)
+	report += formatter.SectionHeader("File Notification")
+	for _, c := range cgroups {
+		report += fmt.Sprintf("  %s: %d watches, %d marks registered (peak %.1f/s) over %d intervals\n",
+			sanitize.Terminal(c.Cgroup), c.Watches, c.Marks, c.PeakRegistrationRate, c.Samples)
+		if c.LimitHits > 0 {
+			report += fmt.Sprintf("    Refused by the per-user limit: %d\n", c.LimitHits)
+		}
+		if c.Overflows > 0 {
+			report += fmt.Sprintf("    Events dropped on full queues: %d\n", c.Overflows)
+		}
 	}
 	report += "\n"
 	return report
 }
 
-func formatPageFaults(pageFaultEvents []*events.Event, duration time.Duration, d Diagnostician) string {
-	var result string
-	faultRate := d.CalculateRate(len(pageFaultEvents), duration)
-	result += fmt.Sprintf("  Page faults: %d (%.1f/sec)\n", len(pageFaultEvents), faultRate)
-	errorCounts := buildErrorCounts(pageFaultEvents)
-	if len(errorCounts) > 0 {
-		result += "  Page fault error codes:\n"
-		for errCode, count := range errorCounts {
-			result += fmt.Sprintf("    - Error %d: %d occurrences\n", errCode, count)
+// GenerateRuntimeSection reports how long the container runtime took to
+// start the traced pods' containers and to answer podtrace's own calls.
+func GenerateRuntimeSection(ops *analyzer.RuntimeOperations) string {
+	if ops == nil {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Container Runtime")
+	if len(ops.Starts) > 0 {
+		report += "  Container starts (created to started):\n"
+		for _, s := range ops.Starts {
+			name := sanitize.Terminal(s.Container)
+			if s.Image != "" {
+				name += " (" + sanitize.Terminal(s.Image) + ")"
+			}
+			if s.Failed {
+				report += fmt.Sprintf("    %s %s: failed after %.2fms (%s)\n", s.At.Format("15:04:05"), name, s.StartMS, sanitize.Terminal(s.Reason))
+				continue
+			}
+			report += fmt.Sprintf("    %s %s: %.2fms\n", s.At.Format("15:04:05"), name, s.StartMS)
 		}
 	}
-	return result
+	if len(ops.Calls) > 0 {
+		report += "  Runtime responsiveness:\n"
+		for _, c := range ops.Calls {
+			report += fmt.Sprintf("    %s: %d calls, avg %.2fms, max %.2fms", c.Name, c.Calls, c.AvgMS, c.MaxMS)
+			if c.Failures > 0 {
+				report += fmt.Sprintf(", %d failed", c.Failures)
+			}
+			report += "\n"
+		}
+	}
+	report += "\n"
+	return report
 }
 
-func buildErrorCounts(pageFaultEvents []*events.Event) map[int32]int {
-	errorCounts := make(map[int32]int)
-	for _, e := range pageFaultEvents {
-		errorCounts[e.Error]++
+// GenerateNoisyNeighborSection lists the pods on the node that were busiest
+// while the traced pods waited for a CPU or on file I/O.
+func GenerateNoisyNeighborSection(n *analyzer.NoisyNeighbors) string {
+	if n == nil {
+		return ""
 	}
-	return errorCounts
+	var report string
+	report += formatter.SectionHeader("Noisy Neighbor")
+	if len(n.CPU) > 0 {
+		report += fmt.Sprintf("  Waited for a CPU past the threshold in %d intervals (peak %.1f%% of the time); suspected by CPU:\n",
+			n.RunQueueIntervals, n.PeakRunQueueShare*100)
+		for _, p := range n.CPU {
+			report += fmt.Sprintf("    %s: %.2f cores over %d intervals\n", sanitize.Terminal(p.Pod), p.CPUCores, p.Intervals)
+		}
+	}
+	if len(n.IO) > 0 {
+		report += fmt.Sprintf("  %d slow file operations; suspected by block I/O:\n", n.SlowIOOps)
+		for _, p := range n.IO {
+			report += fmt.Sprintf("    %s: %s/s over %d intervals\n", sanitize.Terminal(p.Pod), analyzer.FormatBytes(uint64(p.IOBps)), p.Intervals)
+		}
+	}
+	report += "\n"
+	return report
 }
 
-func formatOOMKills(oomKillEvents []*events.Event) string {
-	var result string
-	result += fmt.Sprintf("  OOM kills: %d\n", len(oomKillEvents))
-	var totalMem uint64
-	for _, e := range oomKillEvents {
-		totalMem += e.Bytes
+// GenerateImagePullSection reports the image pulls of the traced pods and,
+// per registry, how fast they went.
+func GenerateImagePullSection(pulls *analyzer.ImagePulls) string {
+	if pulls == nil {
+		return ""
 	}
-	if len(oomKillEvents) > 0 {
-		result += fmt.Sprintf("  Total memory killed: %s\n", analyzer.FormatBytes(totalMem))
-		result += fmt.Sprintf("  Average memory per kill: %s\n", analyzer.FormatBytes(totalMem/uint64(len(oomKillEvents))))
-		result += "  Killed processes:\n"
-		for i, e := range oomKillEvents {
-			if i >= config.MaxOOMKillsDisplay {
-				break
+	var report string
+	report += formatter.SectionHeader("Image Pull")
+	for _, r := range pulls.Registries {
+		report += fmt.Sprintf("  %s: %d pulls (%d cached, %d failed), slowest %.2fs",
+			sanitize.Terminal(r.Registry), r.Pulls, r.Cached, r.Failed, r.MaxPullMS/1000)
+		if r.ThroughputBps > 0 {
+			report += fmt.Sprintf(", %s/s", analyzer.FormatBytes(uint64(r.ThroughputBps)))
+		}
+		if r.ConnectMS > 0 {
+			report += fmt.Sprintf(", connect %.1fms", r.ConnectMS)
+		}
+		report += "\n"
+	}
+	report += "  Pulls:\n"
+	for _, p := range pulls.Pulls {
+		line := fmt.Sprintf("    %s %s: ", p.At.Format("15:04:05"), sanitize.Terminal(p.Image))
+		switch {
+		case p.Cached:
+			line += "already on the node"
+		case p.Failed:
+			line += "failed (" + sanitize.Terminal(p.Reason) + ")"
+		default:
+			line += fmt.Sprintf("%.2fs", p.DurationMS/1000)
+			if p.SizeBytes > 0 {
+				line += fmt.Sprintf(", %s at %s/s", analyzer.FormatBytes(p.SizeBytes), analyzer.FormatBytes(uint64(p.ThroughputBps)))
 			}
-			procName := e.Target
-			if procName == "" {
-				procName = fmt.Sprintf("PID %d", e.PID)
+			if p.WaitingMS > 0 {
+				line += fmt.Sprintf(", queued %.2fs behind other pulls", p.WaitingMS/1000)
+			}
+			if p.Slow {
+				line += " [SLOW]"
 			}
-			result += fmt.Sprintf("    - %s (%s)\n", sanitize.Terminal(procName), analyzer.FormatBytes(e.Bytes))
 		}
+		report += line + "\n"
 	}
-	return result
+	report += "\n"
+	return report
+}
+
+// formatBitRate renders bits per second the way link speeds are quoted.
+func formatBitRate(bps float64) string {
+	switch {
+	case bps >= 1e9:
+		return fmt.Sprintf("%.2f Gbit/s", bps/1e9)
+	case bps >= 1e6:
+		return fmt.Sprintf("%.2f Mbit/s", bps/1e6)
+	case bps >= 1e3:
+		return fmt.Sprintf("%.2f kbit/s", bps/1e3)
+	}
+	return fmt.Sprintf("%.0f bit/s", bps)
 }
 
 func GenerateIssuesSection(d Diagnostician) string {
-	events := d.GetEvents()
-	issues := detector.DetectIssues(events, d.ErrorRateThreshold(), d.RTTSpikeThreshold())
+	issues := DetectIssues(d)
 	if len(issues) == 0 {
 		return ""
 	}
+	var report string
+	report += formatter.SectionHeader("Potential Issues Detected")
+	for _, issue := range issues {
+		report += fmt.Sprintf("  %s\n", issue)
+	}
+	report += "\n"
+	return report
+}
+
+// DetectIssues runs issue detection over the window, ranked by score, and
+// forwards every issue found to the global alert manager, if one is
+// configured.
+func DetectIssues(d Diagnostician) []detector.Issue {
+	events := d.GetEvents()
+	issues := detector.ScoreIssues(events, d.ErrorRateThreshold(), d.RTTSpikeThreshold())
+	if b, ok := d.(bandwidthSaturator); ok {
+		issues = detector.ScoreBandwidthSaturation(issues, b.BandwidthSaturation(), config.BandwidthSaturation)
+	}
+	if v, ok := d.(volumeReporter); ok {
+		issues = detector.ScoreSlowVolumes(issues, v.Volumes(), d.FSSlowThreshold())
+		issues = detector.ScoreWriteFailures(issues, v.WriteFailures())
+	}
+	if len(issues) == 0 {
+		return nil
+	}
 
 	manager := alerting.GetGlobalManager()
 	if manager != nil {
-		for _, issue := range issues {
+		for _, scored := range issues {
+			issue := scored.Message
 			var severity alerting.AlertSeverity
 			if contains(issue, "CRITICAL") || contains(issue, "EMERGENCY") {
 				severity = alerting.SeverityCritical
@@ -689,7 +2199,11 @@ func GenerateIssuesSection(d Diagnostician) string {
 				Source:    "error_detector",
 				PodName:   "",
 				Namespace: "",
-				Context:   make(map[string]interface{}),
+				ErrorCode: scored.Code,
+				Context: map[string]interface{}{
+					"score":      scored.Score,
+					"confidence": scored.Confidence,
+				},
 				Recommendations: []string{
 					"Review diagnostic report for details",
 					"Check application logs",
@@ -699,14 +2213,7 @@ func GenerateIssuesSection(d Diagnostician) string {
 			manager.SendAlert(alert)
 		}
 	}
-
-	var report string
-	report += formatter.SectionHeader("Potential Issues Detected")
-	for _, issue := range issues {
-		report += fmt.Sprintf("  %s\n", issue)
-	}
-	report += "\n"
-	return report
+	return issues
 }
 
 func contains(s, substr string) bool {
@@ -780,6 +2287,57 @@ func GeneratePoolSection(d Diagnostician, duration time.Duration) string {
 	return report
 }
 
+// GenerateAMQPSection reports RabbitMQ traffic per queue (deliveries, how
+// long consumers took to ack them, unacked backlog) and per publish target
+// (broker confirm latency on channels in confirm mode).
+func GenerateAMQPSection(d Diagnostician, duration time.Duration) string {
+	amqpEvents := d.FilterEvents(events.EventAMQP)
+	if len(amqpEvents) == 0 {
+		return ""
+	}
+	stats := analyzer.AnalyzeAMQP(amqpEvents)
+	if len(stats.Queues) == 0 && len(stats.Publishes) == 0 {
+		return ""
+	}
+
+	var report string
+	report += formatter.SectionHeader("AMQP")
+	if len(stats.Queues) > 0 {
+		report += "  Consumed queues:\n"
+		for i, q := range stats.Queues {
+			if i >= config.MaxConnectionTargets {
+				break
+			}
+			report += fmt.Sprintf("    - %s: %d delivered (%.1f/sec), %d acked, %d rejected\n",
+				sanitize.Terminal(q.Queue), q.Deliveries, d.CalculateRate(q.Deliveries, duration), q.Acks, q.Rejects)
+			if q.Acks+q.Rejects > 0 {
+				report += fmt.Sprintf("        Ack latency: p50 %.2fms, p95 %.2fms, max %.2fms\n", q.P50AckMS, q.P95AckMS, q.MaxAckMS)
+			}
+			report += fmt.Sprintf("        Unacked: %d (peak %d)\n", q.Unacked, q.PeakUnacked)
+		}
+	}
+	if len(stats.Publishes) > 0 {
+		report += "  Publishes:\n"
+		for i, p := range stats.Publishes {
+			if i >= config.MaxConnectionTargets {
+				break
+			}
+			target := p.Target
+			if target == "" {
+				target = "(default exchange, empty routing key)"
+			}
+			report += fmt.Sprintf("    - %s: %d published (%.1f/sec)", sanitize.Terminal(target), p.Publishes, d.CalculateRate(p.Publishes, duration))
+			if p.Confirms+p.Nacks > 0 {
+				report += fmt.Sprintf(", %d confirmed, %d nacked, confirm p50 %.2fms p95 %.2fms max %.2fms",
+					p.Confirms, p.Nacks, p.P50ConfirmMS, p.P95ConfirmMS, p.MaxConfirmMS)
+			}
+			report += "\n"
+		}
+	}
+	report += "\n"
+	return report
+}
+
 func determinePoolHealth(stats analyzer.PoolStats) string {
 	if stats.ExhaustedCount > 0 {
 		if stats.TotalAcquires == 0 {
@@ -1315,12 +2873,23 @@ func formatTimeline(allEvents []*events.Event, startTime time.Time, duration tim
 	if len(timeline) == 0 {
 		return ""
 	}
+	pauses := analyzer.AnalyzePauses(allEvents, startTime, startTime.Add(duration))
 	var result string
 	result += "Activity Timeline:\n"
 	result += "  Activity distribution:\n"
 	for _, bucket := range timeline {
-		result += fmt.Sprintf("    - %s: %d events (%.1f%%)\n",
+		line := fmt.Sprintf("    - %s: %d events (%.1f%%)",
 			bucket.Period, bucket.Count, bucket.Percentage)
+		var marks []string
+		for _, p := range pauses {
+			if p.Overlaps(bucket.Start, bucket.End) {
+				marks = append(marks, fmt.Sprintf("%s %s", sanitize.Terminal(p.Container), pauseVerb(p.Kind)))
+			}
+		}
+		if len(marks) > 0 {
+			line += " [paused: " + strings.Join(marks, ", ") + "]"
+		}
+		result += line + "\n"
 	}
 	result += "\n"
 	return result
@@ -1468,3 +3037,70 @@ func buildFileCounts(openEvents []*events.Event) map[string]int {
 	}
 	return fileCounts
 }
+
+// PodStatus is a traced pod's QoS class and, per traced container, its
+// restarts, last termination and resources, shown at the top of its report.
+type PodStatus struct {
+	Namespace  string
+	Pod        string
+	QOSClass   string
+	Containers []ContainerStatus
+}
+
+// ContainerStatus is one traced container of a PodStatus.
+type ContainerStatus struct {
+	Name         string
+	RestartCount int32
+	// LastReason, LastExitCode and LastFinished describe how the previous
+	// instance ended; LastReason is empty when it has not terminated.
+	LastReason   string
+	LastExitCode int32
+	LastFinished time.Time
+	// Requests and Limits are rendered as "cpu=250m, memory=256Mi".
+	Requests string
+	Limits   string
+}
+
+// GeneratePodStatusSection gives the context a reader would otherwise run
+// kubectl describe for: restarts, why the last instance ended, QoS class
+// and resources.
+func GeneratePodStatusSection(pods []PodStatus) string {
+	if len(pods) == 0 {
+		return ""
+	}
+	var report string
+	report += formatter.SectionHeader("Pod Status")
+	for _, p := range pods {
+		line := fmt.Sprintf("  %s/%s", sanitize.Terminal(p.Namespace), sanitize.Terminal(p.Pod))
+		if p.QOSClass != "" {
+			line += fmt.Sprintf(" (QoS %s)", sanitize.Terminal(p.QOSClass))
+		}
+		report += line + "\n"
+		for _, c := range p.Containers {
+			line := fmt.Sprintf("    %s: %d restarts", sanitize.Terminal(c.Name), c.RestartCount)
+			if c.LastReason != "" || c.LastExitCode != 0 {
+				reason := c.LastReason
+				if reason == "" {
+					reason = "terminated"
+				}
+				line += fmt.Sprintf(", last terminated %s (exit code %d)", sanitize.Terminal(reason), c.LastExitCode)
+				if !c.LastFinished.IsZero() {
+					line += " at " + c.LastFinished.Local().Format("2006-01-02 15:04:05")
+				}
+			}
+			report += line + "\n"
+			if c.Requests != "" || c.Limits != "" {
+				report += fmt.Sprintf("      requests: %s; limits: %s\n", orNone(c.Requests), orNone(c.Limits))
+			}
+		}
+	}
+	report += "\n"
+	return report
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "none"
+	}
+	return sanitize.Terminal(s)
+}
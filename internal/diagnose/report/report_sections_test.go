@@ -6,7 +6,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/podtrace/podtrace/internal/clock"
 	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/diagnose/slo"
+	"github.com/podtrace/podtrace/internal/diagnose/stream"
 	"github.com/podtrace/podtrace/internal/events"
 )
 
@@ -176,6 +180,8 @@ func TestGenerateHTTPSection_ResponseStatusAndPeers(t *testing.T) {
 		"response status codes",
 		"L7 peers",
 		"10.1.2.3:8080",
+		"Status by endpoint:",
+		"/broken: 1 responses, 2xx 0, 3xx 0, 4xx 0, 5xx 1 (100.0%); 5xx rate over the run:",
 	} {
 		if !strings.Contains(out, want) {
 			t.Errorf("expected HTTP section to contain %q\n--- output ---\n%s", want, out)
@@ -219,3 +225,805 @@ func TestGeneratePoolSection_ExhaustedNoAcquires(t *testing.T) {
 		t.Errorf("expected CRITICAL pool-health status for exhaustion with no acquires, got:\n%s", out)
 	}
 }
+
+func TestGeneratePythonSection(t *testing.T) {
+	d := &filterDiagnostician{
+		byType: map[events.EventType][]*events.Event{
+			events.EventPyCall: {
+				{Type: events.EventPyCall, Target: "handle", Details: "app.py", TCPState: 42, LatencyNS: 30_000_000},
+				{Type: events.EventPyCall, Target: "handle", Details: "app.py", TCPState: 42, LatencyNS: 10_000_000},
+				{Type: events.EventPyCall, Target: "render", Details: "views.py", TCPState: 7, LatencyNS: 5_000_000},
+				{Type: events.EventPyCall, LatencyNS: 2_000_000},
+			},
+			events.EventPyGC: {
+				{Type: events.EventPyGC, TCPState: 2, LatencyNS: 40_000_000, Bytes: 900},
+				{Type: events.EventPyGC, TCPState: 0, LatencyNS: 1_000_000, Bytes: 10},
+			},
+		},
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Second),
+	}
+	out := GeneratePythonSection(d, time.Second)
+	for _, want := range []string{
+		"Python Statistics:",
+		"Slow calls (>1ms): 4",
+		"handle (app.py:42): 2 calls, avg 20.00ms, max 30.00ms",
+		"frame evaluation",
+		"GC collections: 2",
+		"generation 2: 1 collections, avg pause 40.00ms, max 40.00ms, 900 objects collected",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("python section missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "handle") > strings.Index(out, "render") {
+		t.Errorf("functions should be ordered by total time:\n%s", out)
+	}
+	if GeneratePythonSection(&filterDiagnostician{}, time.Second) != "" {
+		t.Error("expected empty section without python events")
+	}
+}
+
+func TestGenerateEventLoopSection(t *testing.T) {
+	const ms = uint64(1_000_000)
+	d := &filterDiagnostician{
+		byType: map[events.EventType][]*events.Event{
+			events.EventLoopLag: {
+				{Type: events.EventLoopLag, PID: 7, Target: "node", Timestamp: 1000 * ms, LatencyNS: 200 * ms},
+				{Type: events.EventLoopLag, PID: 7, Target: "node", Timestamp: 2000 * ms, LatencyNS: 20 * ms},
+			},
+			events.EventHTTPResp: {
+				// Waited through the 800-1000ms block.
+				{Type: events.EventHTTPResp, PID: 7, Timestamp: 1050 * ms, LatencyNS: 300 * ms},
+				// Slow, but between blocks.
+				{Type: events.EventHTTPResp, PID: 7, Timestamp: 1500 * ms, LatencyNS: 100 * ms},
+				// Another process; not attributable.
+				{Type: events.EventHTTPResp, PID: 9, Timestamp: 1050 * ms, LatencyNS: 300 * ms},
+			},
+		},
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Second),
+	}
+	out := GenerateEventLoopSection(d, time.Second)
+	for _, want := range []string{
+		"Event Loop Statistics:",
+		"Blocked iterations (>10ms): 2",
+		"longest block: 200.00ms",
+		"node (PID 7) (2 blocks)",
+		"Slow operations overlapping a blocked loop: 1 of 2 (50.0%)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("event loop section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateEventLoopSection(&filterDiagnostician{}, time.Second) != "" {
+		t.Error("expected empty section without loop events")
+	}
+}
+
+func TestGenerateObjectStorageSection(t *testing.T) {
+	const ms = uint64(1_000_000)
+	d := &filterDiagnostician{
+		byType: map[events.EventType][]*events.Event{
+			events.EventHTTPReq: {
+				{Type: events.EventHTTPReq, PID: 1, CorrelationID: 1, Target: "GET /?list-type=2", Details: "sigv4: logs.s3.amazonaws.com"},
+				{Type: events.EventHTTPReq, PID: 1, CorrelationID: 2, Target: "GET /healthz"},
+			},
+			events.EventHTTPResp: {
+				{Type: events.EventHTTPResp, PID: 1, CorrelationID: 1, Target: "GET /?list-type=2", LatencyNS: 40 * ms, Error: 503},
+				{Type: events.EventHTTPResp, PID: 1, CorrelationID: 2, Target: "GET /healthz", LatencyNS: 1 * ms},
+			},
+		},
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Second),
+	}
+	out := GenerateObjectStorageSection(d, time.Second)
+	for _, want := range []string{
+		"Object Storage Statistics:",
+		"S3-style calls: 1",
+		"logs LIST: 1 calls",
+		"p99 40.00ms",
+		"1 errors",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("object storage section missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "healthz") {
+		t.Errorf("plain HTTP call reported as object storage:\n%s", out)
+	}
+	if got := GenerateObjectStorageSection(&filterDiagnostician{}, time.Second); got != "" {
+		t.Errorf("expected empty section without HTTP events, got %q", got)
+	}
+}
+
+func TestGenerateSLOSection(t *testing.T) {
+	out := GenerateSLOSection([]slo.Result{
+		{SLO: slo.SLO{Name: "orders"}, Status: slo.StatusPass, Events: 10, P99MS: 12.5},
+		{SLO: slo.SLO{Name: "checkout"}, Status: slo.StatusFail, Events: 4, ErrorRate: 50, Violations: []string{"error rate 50.00% > 1.00%"}},
+		{SLO: slo.SLO{Name: "idle"}, Status: slo.StatusNoData},
+	})
+	for _, want := range []string{
+		"SLO Statistics:",
+		"[PASS] orders: 10 events, p99 12.50ms, error rate 0.00%",
+		"[FAIL] checkout: 4 events",
+		"    - error rate 50.00% > 1.00%",
+		"[NO DATA] idle: no matching events",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("SLO section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateSLOSection(nil) != "" {
+		t.Error("expected empty section without SLOs")
+	}
+}
+
+func TestGenerateAMQPSection(t *testing.T) {
+	const ms = uint64(1_000_000)
+	d := &filterDiagnostician{
+		byType: map[events.EventType][]*events.Event{
+			events.EventAMQP: {
+				{Type: events.EventAMQP, Timestamp: 1 * ms, CorrelationID: 1, TCPState: events.AMQPBasicConsume, Target: "orders", Details: "c1"},
+				{Type: events.EventAMQP, Timestamp: 2 * ms, CorrelationID: 1, TCPState: events.AMQPBasicDeliver, Target: "c1", Bytes: 1},
+				{Type: events.EventAMQP, Timestamp: 7 * ms, CorrelationID: 1, TCPState: events.AMQPBasicAck, Details: "out", Bytes: 1},
+				{Type: events.EventAMQP, Timestamp: 8 * ms, CorrelationID: 1, TCPState: events.AMQPBasicDeliver, Target: "c1", Bytes: 2},
+				{Type: events.EventAMQP, Timestamp: 1 * ms, CorrelationID: 2, TCPState: events.AMQPBasicPublish, Target: "orders"},
+			},
+		},
+		startTime: time.Now(),
+		endTime:   time.Now().Add(time.Second),
+	}
+	out := GenerateAMQPSection(d, time.Second)
+	for _, want := range []string{
+		"AMQP Statistics:",
+		"orders: 2 delivered",
+		"Ack latency: p50 5.00ms",
+		"Unacked: 1 (peak 1)",
+		"orders: 1 published",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("AMQP section missing %q:\n%s", want, out)
+		}
+	}
+	if got := GenerateAMQPSection(&filterDiagnostician{}, time.Second); got != "" {
+		t.Errorf("expected empty section without AMQP events, got %q", got)
+	}
+}
+
+func TestGenerateAnnotationsSection(t *testing.T) {
+	start := time.Now().Add(-10 * time.Second)
+	at := func(d time.Duration) uint64 { return clock.WallToBPFTimestamp(start.Add(d)) }
+	d := &filterDiagnostician{
+		byType: map[events.EventType][]*events.Event{
+			events.EventAnnotation: {
+				{Type: events.EventAnnotation, Timestamp: at(5 * time.Second), Target: "enabled flag"},
+			},
+			events.EventHTTPResp: {
+				{Type: events.EventHTTPResp, Timestamp: at(time.Second), LatencyNS: 2_000_000},
+				{Type: events.EventHTTPResp, Timestamp: at(6 * time.Second), LatencyNS: 8_000_000, Error: 500},
+				{Type: events.EventHTTPResp, Timestamp: at(7 * time.Second), LatencyNS: 8_000_000},
+			},
+		},
+		startTime: start,
+		endTime:   start.Add(10 * time.Second),
+	}
+	out := GenerateAnnotationsSection(d)
+	for _, want := range []string{
+		"Annotations Statistics:",
+		"+5.0s enabled flag",
+		"before: 1 events (0.2/sec), 0.00% errors, avg latency 2.00ms",
+		"after:  2 events (0.4/sec), 50.00% errors, avg latency 8.00ms",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("annotations section missing %q:\n%s", want, out)
+		}
+	}
+	if got := GenerateAnnotationsSection(&filterDiagnostician{}); got != "" {
+		t.Errorf("expected empty section without annotations, got %q", got)
+	}
+}
+
+func TestGenerateCrashSection(t *testing.T) {
+	start := time.Now().Add(-time.Minute)
+	at := func(d time.Duration) uint64 { return clock.WallToBPFTimestamp(start.Add(d)) }
+	d := &filterDiagnostician{
+		byType: map[events.EventType][]*events.Event{
+			events.EventWrite: {
+				{Type: events.EventWrite, PID: 7, Timestamp: at(38 * time.Second), LatencyNS: 84_000_000},
+			},
+			events.EventCrash: {
+				{Type: events.EventCrash, PID: 7, ProcessName: "api", Timestamp: at(40 * time.Second), Error: 11, TCPState: 1,
+					Details: "core: systemd-coredump (coredumpctl info 7)\nframe: api:main.c:42"},
+			},
+		},
+		startTime: start,
+		endTime:   start.Add(time.Minute),
+	}
+	out := GenerateCrashSection(d)
+	for _, want := range []string{
+		"Crashes Statistics:",
+		"api (PID 7) SIGSEGV at +40.0s, fault address 0x0",
+		"Core: systemd-coredump (coredumpctl info 7)",
+		"#0 api:main.c:42",
+		"Activity in the 10s before the crash:",
+		"- FS: 1 events, p95 84.00ms",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("crash section missing %q:\n%s", want, out)
+		}
+	}
+	if got := GenerateCrashSection(&filterDiagnostician{}); got != "" {
+		t.Errorf("expected empty section without crashes, got %q", got)
+	}
+}
+
+func TestGenerateBudgetSection(t *testing.T) {
+	start := time.Now()
+	o := analyzer.NewBudgetOverflow(1000, start.Add(12*time.Second))
+	o.Add(&events.Event{Type: events.EventDNS, Error: 3, LatencyNS: 5_000_000})
+	o.Add(&events.Event{Type: events.EventTCPRecv})
+	o.Add(&events.Event{Type: events.EventTCPRecv})
+	out := GenerateBudgetSection(o.Snapshot(), start)
+	for _, want := range []string{
+		"Event Budget Statistics:",
+		"Budget of 1000 events reached at +12.0s; switched to aggregation-only mode",
+		"3 later events were only counted",
+		"NET          2 events, 0 errors\n",
+		"DNS          1 events, 1 errors, avg latency 5.00ms, max 5.00ms",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("budget section missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "NET") > strings.Index(out, "DNS") {
+		t.Errorf("types not ordered by count:\n%s", out)
+	}
+	if GenerateBudgetSection(nil, start) != "" {
+		t.Error("expected empty section while the budget is not spent")
+	}
+}
+
+func TestGenerateSessionSection(t *testing.T) {
+	s := &analyzer.SessionTotals{
+		Events: 5000,
+		ByType: []analyzer.TypeTotals{
+			{Type: "NET", Events: 4000, Errors: 2, P50MS: 1, P95MS: 4, P99MS: 9.5, MaxMS: 30},
+			{Type: "PROC", Events: 1000},
+		},
+		TopTargets:   []stream.Counted{{Key: "db:5432", Count: 3100}},
+		RecentErrors: []analyzer.RecentError{{Type: "NET", Target: "db:5432", Error: 111}},
+	}
+	out := GenerateSessionSection(s, 1000)
+	for _, want := range []string{
+		"Session Totals Statistics:",
+		"5000 events seen, 1000 kept",
+		"NET          4000 events, 2 errors, p50 1.00ms, p95 4.00ms, p99 9.50ms, max 30.00ms",
+		"PROC         1000 events, 0 errors\n",
+		"db:5432",
+		"~3100 events",
+		"NET db:5432 error 111",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("session section missing %q:\n%s", want, out)
+		}
+	}
+	if out := GenerateSessionSection(&analyzer.SessionTotals{Events: 10}, 10); !strings.Contains(out, "10 events seen, all kept") {
+		t.Errorf("session section with every event kept:\n%s", out)
+	}
+	if GenerateSessionSection(nil, 0) != "" {
+		t.Error("expected no section before the first event")
+	}
+}
+
+func TestGenerateExternalNetworkSection(t *testing.T) {
+	n := &analyzer.ExternalNetworks{
+		Events: 120, Connections: 10, FailedConnections: 2, Retransmits: 50, Untagged: 4,
+		Networks: []analyzer.NetworkStats{
+			{Network: "AS16509/Amazon.com, Inc.", Country: "US", Events: 100, Connections: 8, FailedConnections: 2, Retransmits: 17, Bytes: 2048},
+			{Network: "AS15169/Google LLC", Events: 16, Connections: 2},
+		},
+	}
+	out := GenerateExternalNetworkSection(n)
+	for _, want := range []string{
+		"External Network Statistics:",
+		"120 internet-bound events across 2 networks (4 to addresses not in the GeoIP database)",
+		"AS16509/Amazon.com, Inc. [US]",
+		"100 events, 8 connects (2 failed), 2.00 KB",
+		"34% of retransmits are to AS16509/Amazon.com, Inc.",
+		"100% of failed external connects are to AS16509/Amazon.com, Inc.",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("external network section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateExternalNetworkSection(nil) != "" {
+		t.Error("expected empty section without GeoIP data")
+	}
+}
+
+func TestGeneratePodThroughputSection(t *testing.T) {
+	tp := &analyzer.PodThroughput{Directions: []analyzer.ThroughputDirection{{
+		Direction: "egress", Bytes: 3 * 1024 * 1024, Packets: 2500,
+		AvgBitsPerS: 2_500_000, PeakBitsPerS: 1_200_000_000,
+		ByClass: []analyzer.ThroughputClass{
+			{Class: "external", Bytes: 2 * 1024 * 1024},
+			{Class: "cluster", Bytes: 1024 * 1024},
+		},
+	}}}
+	out := GeneratePodThroughputSection(tp)
+	for _, want := range []string{
+		"Pod Throughput Statistics:",
+		"egress:  3.00 MB in 2500 packets, avg 2.50 Mbit/s, peak 1.20 Gbit/s",
+		"external 2.00 MB (67%), cluster 1.00 MB (33%)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("pod throughput section missing %q:\n%s", want, out)
+		}
+	}
+	if GeneratePodThroughputSection(nil) != "" {
+		t.Error("expected empty section without veth counters")
+	}
+}
+
+func TestGenerateCPUPlacementSection(t *testing.T) {
+	p := &analyzer.CPUPlacement{
+		Processes: []analyzer.ProcessPlacement{{
+			PID: 42, Process: "app", CPUs: "2-3", CPUNodes: "1", Mems: "0-1", Pinned: true, SharedCores: "2-3",
+			MemoryBytes: 4 * 1024 * 1024, RemoteMemoryBytes: 3 * 1024 * 1024, RemoteMemoryShare: 0.75,
+			RunQueueShare: 0.125, PeakRunQueueShare: 0.4,
+		}},
+		NodeRemoteAllocShare: 0.2, HasNodeAllocations: true,
+	}
+	out := GenerateCPUPlacementSection(p)
+	for _, want := range []string{
+		"CPU Placement Statistics:",
+		"pid 42 (app): CPUs 2-3 on node 1 (pinned), memory nodes 0-1",
+		"Remote memory: 75% (3.00 MB of 4.00 MB)",
+		"Run queue wait: 12.5% (peak 40.0%)",
+		"Cores shared with other workloads: CPUs 2-3",
+		"Node-wide remote page allocations: 20.0%",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("CPU placement section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateCPUPlacementSection(nil) != "" {
+		t.Error("expected empty section without placement samples")
+	}
+}
+
+func TestGenerateCompactionSection(t *testing.T) {
+	c := &analyzer.MemoryCompaction{
+		Stalls: 3, StallMS: 400, P95StallMS: 180, MaxStallMS: 200,
+		HugePageStalls: 2, HugePageStallMS: 350,
+		Results:   map[string]int{"success": 2, "deferred": 1},
+		Processes: []analyzer.CompactionProcess{{PID: 42, Process: "app", Stalls: 3, StallMS: 400}},
+		Collapses: 4, FailedCollapses: 1, CollapsedBytes: 6 << 20,
+	}
+	out := GenerateCompactionSection(c, 40*time.Second)
+	for _, want := range []string{
+		"Memory Compaction Statistics:",
+		"400.0 ms spent in memory compaction during the window (1.00% of 40s)",
+		"Stalls: 3 (p95 180.00ms, max 200.00ms)",
+		"Huge page allocations: 2 stalls, 350.0 ms",
+		"Results: deferred 1, success 2",
+		"pid 42 (app): 3 stalls, 400.0 ms",
+		"khugepaged collapses: 4 (1 failed), 6.00 MB collapsed into huge pages",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("compaction section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateCompactionSection(nil, time.Minute) != "" {
+		t.Error("expected empty section without compaction events")
+	}
+}
+
+func TestGenerateSwapSection(t *testing.T) {
+	out := GenerateSwapSection([]analyzer.CgroupSwap{{
+		Cgroup: "/kubepods/pod-a", Samples: 3, ActiveIntervals: 2,
+		SwapOutBytes: 64 << 20, SwapInBytes: 16 << 20, PeakSwapBytes: 64 << 20,
+		ZswapOutBytes: 8 << 20, PeakZswapBytes: 2 << 20, MemoryLimitBytes: 256 << 20,
+	}})
+	for _, want := range []string{
+		"Swap Statistics:",
+		"/kubepods/pod-a (256.00 MB memory limit): active in 2 of 3 intervals",
+		"Swap: 64.00 MB out, 16.00 MB in, 64.00 MB at peak",
+		"Zswap: 8.00 MB out, 0 B in, 2.00 MB compressed at peak",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("swap section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateSwapSection(nil) != "" {
+		t.Error("expected empty section without swap samples")
+	}
+}
+
+func TestGenerateClientTimeoutSection(t *testing.T) {
+	out := GenerateClientTimeoutSection([]analyzer.ClientTimeout{{
+		Target: "10.0.0.5:8080", Operation: analyzer.OpRequest, TimeoutMS: 2003, MinMS: 2001, MaxMS: 2012,
+		Hits: 14, Requests: 100, SuccessP99MS: 180,
+	}})
+	for _, want := range []string{
+		"Client Timeouts Statistics:",
+		"request to 10.0.0.5:8080: client timeout appears to be ~2.0s; 14% of requests hit it (14 of 100, abandoned after 2001-2012ms; completed p99 180ms)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("client timeout section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateClientTimeoutSection(nil) != "" {
+		t.Error("expected empty section without inferred timeouts")
+	}
+}
+
+func TestGenerateTmpfsSection(t *testing.T) {
+	out := GenerateTmpfsSection([]analyzer.CgroupTmpfs{{
+		Cgroup: "/kubepods/pod-a", Samples: 2, FirstBytes: 32 << 20, PeakBytes: 96 << 20, LastBytes: 96 << 20,
+		GrowthBytes: 64 << 20, PeakMemoryBytes: 224 << 20, MemoryLimitBytes: 256 << 20, PeakLimitShare: 0.375,
+	}}, []analyzer.VolumeMount{{Pod: "shop/api-0", Container: "api", Volume: "cache", Kind: "emptyDir",
+		Source: "memory", MountPath: "/cache", SizeLimit: "512Mi"}})
+	for _, want := range []string{
+		"Tmpfs Memory Statistics:",
+		"/kubepods/pod-a (256.00 MB memory limit): 2 samples",
+		"Tmpfs: 32.00 MB at start, 96.00 MB at peak, 96.00 MB at end, 37.5% of the limit at peak",
+		"Memory: 224.00 MB in use at peak, tmpfs included",
+		"emptyDir cache (medium Memory) at /cache in shop/api-0/api, sizeLimit 512Mi",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("tmpfs section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateTmpfsSection(nil, nil) != "" {
+		t.Error("expected empty section without tmpfs samples")
+	}
+}
+
+func TestGeneratePageCacheSection(t *testing.T) {
+	pc := &analyzer.PageCache{
+		Reads: 1000, MissReads: 50, MissFolios: 200, HitRatio: 0.95, HasCounters: true,
+		Method: analyzer.ColdReadsByMisses, SlowReads: 5, ColdSlowReads: 3,
+		Files: []analyzer.FileCache{{File: "data.db", Reads: 3, ColdReads: 2, HitRatio: 1.0 / 3, ColdLatencyMS: 20, LatencyMS: 22}},
+	}
+	out := GeneratePageCacheSection(pc)
+	for _, want := range []string{
+		"Page Cache Statistics:",
+		"Hit ratio: 95.0% of 1000 reads (50 missed, 200 folios read from the device)",
+		"Slow reads: 5, 3 on cold cache (by page-cache insertions)",
+		"data.db: 2 of 3 slow reads cold (33% hit), 20.0 ms cold of 22.0 ms",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("page cache section missing %q:\n%s", want, out)
+		}
+	}
+
+	pc = &analyzer.PageCache{Method: analyzer.ColdReadsByLatency, ColdThresholdMS: 4.5, SlowReads: 20, ColdSlowReads: 4}
+	if out := GeneratePageCacheSection(pc); !strings.Contains(out, "Slow reads: 20, 4 on cold cache (over 4.50ms, from the read latency modes)") || strings.Contains(out, "Hit ratio") {
+		t.Errorf("bimodality section:\n%s", out)
+	}
+	if GeneratePageCacheSection(&analyzer.PageCache{SlowReads: 3}) != "" {
+		t.Error("expected empty section without counters or cold reads")
+	}
+}
+
+func TestGenerateVolumeSection(t *testing.T) {
+	vols := []analyzer.VolumeLatency{{
+		VolumeMount: analyzer.VolumeMount{Pod: "shop/db-0", Container: "db", Volume: "data", Kind: "persistentVolumeClaim",
+			Source: "data-db-0", StorageClass: "gp3", MountPath: "/var/lib/db"},
+		Ops: 3, Reads: 1, Writes: 1, Fsyncs: 1, LatencyMS: 102, AvgMS: 34, P99MS: 60, MaxMS: 60, SlowOps: 2, Bytes: 4608,
+	}}
+	out := GenerateVolumeSection(vols, 10)
+	for _, want := range []string{
+		"Volume I/O Statistics:",
+		"data (persistentVolumeClaim data-db-0, storage class gp3) at /var/lib/db in shop/db-0/db:",
+		"3 ops (1 writes, 1 reads, 1 fsyncs), avg 34.00ms, p99 60.00ms, max 60.00ms, 2 slow (>10.0ms)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("volume section missing %q:\n%s", want, out)
+		}
+	}
+	vols[0].Storage = &analyzer.StorageEvidence{
+		Phase: "Bound", Capacity: "100Gi", AccessModes: []string{"ReadWriteOnce"}, PersistentVolume: "pvc-123",
+		Provisioner: "ebs.csi.aws.com", CSIDriver: "ebs.csi.aws.com", VolumeHandle: "vol-0abc",
+		Events: []analyzer.StorageEvent{{Object: "PersistentVolume/pvc-123", Type: "Warning", Reason: "VolumeDegraded",
+			Message: "io errors", Count: 2, LastSeen: time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)}},
+	}
+	out = GenerateVolumeSection(vols, 10)
+	for _, want := range []string{
+		"Claim: Bound 100Gi ReadWriteOnce, PV pvc-123, provisioner ebs.csi.aws.com, CSI ebs.csi.aws.com volume vol-0abc",
+		"Event: Warning VolumeDegraded on PersistentVolume/pvc-123 (2x, last 2026-10-16T14:00:00Z): io errors",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("volume section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateVolumeSection(nil, 10) != "" {
+		t.Error("expected empty section without volumes")
+	}
+}
+
+func TestGenerateWriteFailureSection(t *testing.T) {
+	failures := []analyzer.WriteFailure{
+		{
+			VolumeMount: analyzer.VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "rootfs", Kind: "rootfs", MountPath: "/", ReadOnly: true},
+			Errno:       "EROFS", Cause: analyzer.WriteRefusedReadOnlyRoot, Failures: 12, Attempts: 12, Files: []string{"/var/log/api.log"},
+		},
+		{
+			VolumeMount: analyzer.VolumeMount{Pod: "shop/api-0", Container: "api", Volume: "cache", Kind: "emptyDir", MountPath: "/cache", SizeLimit: "1Gi"},
+			Errno:       "ENOSPC", Cause: analyzer.WriteRefusedSizeLimit, Failures: 4, Attempts: 40,
+		},
+		{Errno: "ENOSPC", Cause: analyzer.WriteRefusedNoSpace, Failures: 1, Attempts: 1},
+	}
+	out := GenerateWriteFailureSection(failures)
+	for _, want := range []string{
+		"Refused Writes Statistics:",
+		"rootfs (rootfs) at / in shop/api-0/api: 12 EROFS of 12 attempts, readOnlyRootFilesystem",
+		"    /var/log/api.log",
+		"cache (emptyDir) at /cache in shop/api-0/api: 4 ENOSPC of 40 attempts, emptyDir sizeLimit 1Gi reached",
+		"unknown volume: 1 ENOSPC of 1 attempts, out of space",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("write failure section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateWriteFailureSection(nil) != "" {
+		t.Error("expected empty section without refused writes")
+	}
+}
+
+func TestGenerateFsNotifySection(t *testing.T) {
+	out := GenerateFsNotifySection([]analyzer.CgroupFsNotify{{
+		Cgroup: "/kubepods/reloader", Samples: 3, Watches: 20060, Marks: 2,
+		LimitHits: 4, Overflows: 12, PeakRegistrationRate: 2000, StormIntervals: 2,
+	}})
+	for _, want := range []string{
+		"File Notification Statistics:",
+		"/kubepods/reloader: 20060 watches, 2 marks registered (peak 2000.0/s) over 3 intervals",
+		"Refused by the per-user limit: 4",
+		"Events dropped on full queues: 12",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("fsnotify section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateFsNotifySection(nil) != "" {
+		t.Error("expected empty section without fsnotify samples")
+	}
+}
+
+func TestGenerateRuntimeSection(t *testing.T) {
+	at := time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local)
+	out := GenerateRuntimeSection(&analyzer.RuntimeOperations{
+		Starts: []analyzer.ContainerStart{
+			{Container: "init", At: at, StartMS: 1000, Failed: true, Reason: "StartError"},
+			{Container: "app", Image: "app:1.0", At: at.Add(time.Second), StartMS: 3200},
+		},
+		Calls: []analyzer.RuntimeCall{{Name: "ContainerStatus", Calls: 12, Failures: 1, AvgMS: 2.5, MaxMS: 40}},
+	})
+	for _, want := range []string{
+		"Container Runtime Statistics:",
+		"10:30:00 init: failed after 1000.00ms (StartError)",
+		"10:30:01 app (app:1.0): 3200.00ms",
+		"ContainerStatus: 12 calls, avg 2.50ms, max 40.00ms, 1 failed",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("runtime section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateRuntimeSection(nil) != "" {
+		t.Error("expected empty section without runtime operations")
+	}
+}
+
+func TestGenerateImagePullSection(t *testing.T) {
+	at := time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local)
+	out := GenerateImagePullSection(&analyzer.ImagePulls{
+		Pulls: []analyzer.ImagePull{
+			{Image: "nginx", Registry: "docker.io", At: at, Cached: true},
+			{Image: "ghcr.io/acme/app:1", Registry: "ghcr.io", At: at.Add(time.Second), DurationMS: 40000, WaitingMS: 5000,
+				SizeBytes: 200 << 20, ThroughputBps: 5 << 20, Slow: true},
+			{Image: "ghcr.io/acme/init:1", Registry: "ghcr.io", At: at.Add(2 * time.Second), Failed: true, Reason: "Unauthorized"},
+		},
+		Registries: []analyzer.RegistryPulls{
+			{Registry: "ghcr.io", Pulls: 2, Failed: 1, Slow: 1, MaxPullMS: 40000, ThroughputBps: 5 << 20, ConnectMS: 80},
+		},
+	})
+	for _, want := range []string{
+		"Image Pull Statistics:",
+		"ghcr.io: 2 pulls (0 cached, 1 failed), slowest 40.00s, 5.00 MB/s, connect 80.0ms",
+		"10:30:00 nginx: already on the node",
+		"10:30:01 ghcr.io/acme/app:1: 40.00s, 200.00 MB at 5.00 MB/s, queued 5.00s behind other pulls [SLOW]",
+		"10:30:02 ghcr.io/acme/init:1: failed (Unauthorized)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("image pull section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateImagePullSection(nil) != "" {
+		t.Error("expected empty section without image pulls")
+	}
+}
+
+func TestGenerateDisruptionSection(t *testing.T) {
+	start := time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local)
+	out := GenerateDisruptionSection([]analyzer.DisruptionStats{
+		{Kind: events.DisruptionNodePressure, Subject: "node-1", Resource: "memory", Node: "node-1", At: start.Add(10 * time.Second)},
+		{Kind: events.DisruptionEviction, Subject: "batch/job-1", Resource: "memory", Node: "node-1", At: start.Add(12 * time.Second),
+			Before: analyzer.AnnotationPhase{Events: 30, Seconds: 10, AvgLatencyMS: 2, P95LatencyMS: 4},
+			After:  analyzer.AnnotationPhase{Events: 20, Errors: 2, Seconds: 10, AvgLatencyMS: 15, P95LatencyMS: 40}},
+	}, start)
+	for _, want := range []string{
+		"Pod Disruption Statistics:",
+		"+10.0s pressure on node-1 (memory)\n",
+		"+12.0s eviction of batch/job-1 (memory) on node-1 [p95 latency x10.0 after]",
+		"before: 30 events (3.0/sec), 0.00% errors, avg latency 2.00ms, p95 4.00ms",
+		"after:  20 events (2.0/sec), 10.00% errors, avg latency 15.00ms, p95 40.00ms",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("disruption section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateDisruptionSection(nil, start) != "" {
+		t.Error("expected empty section without disruptions")
+	}
+}
+
+func TestGenerateChaosSection(t *testing.T) {
+	start := time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local)
+	out := GenerateChaosSection([]analyzer.ChaosInterval{
+		{Kind: events.ChaosNetem, Pod: "shop/cart-0", Target: "eth0", Spec: "delay=100ms,loss=5%", Start: start, End: start.Add(20 * time.Second), Preexisting: true,
+			During:  analyzer.AnnotationPhase{Events: 40, Seconds: 20, AvgLatencyMS: 105, P95LatencyMS: 120},
+			Outside: analyzer.AnnotationPhase{Events: 80, Seconds: 40, AvgLatencyMS: 5, P95LatencyMS: 10}},
+		{Kind: events.ChaosIPTablesDrop, Pod: "shop/cart-0", Target: "iptables -t filter -A OUTPUT -d 10.0.0.9/32 -j DROP",
+			Start: start.Add(30 * time.Second), End: start.Add(60 * time.Second), Ongoing: true},
+	}, start)
+	for _, want := range []string{
+		"Chaos Injection Statistics:",
+		"+0.0s to +20.0s netem on eth0 of shop/cart-0: delay=100ms,loss=5% (in place before the trace) [p95 latency x12.0 during]",
+		"during: 40 events (2.0/sec), 0.00% errors, avg latency 105.00ms, p95 120.00ms",
+		"outside: 80 events (2.0/sec)",
+		"+30.0s to end rule iptables -t filter -A OUTPUT -d 10.0.0.9/32 -j DROP of shop/cart-0\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("chaos section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateChaosSection(nil, start) != "" {
+		t.Error("expected empty section without injections")
+	}
+}
+
+func TestGeneratePauseSection(t *testing.T) {
+	start := time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local)
+	out := GeneratePauseSection([]analyzer.Pause{
+		{Container: "shop/cart-0/app", Kind: events.PauseFreeze, PID: 7, Start: start.Add(10 * time.Second), End: start.Add(15 * time.Second)},
+		{Container: "shop/cart-0/app", Kind: events.PauseStop, PID: 7, Start: start.Add(30 * time.Second), End: start.Add(40 * time.Second), Ongoing: true},
+	}, start)
+	for _, want := range []string{
+		"Container Pause Statistics:",
+		"+10.0s to +15.0s shop/cart-0/app frozen through its cgroup for 5.0s",
+		"+30.0s to end shop/cart-0/app main process 7 stopped by a signal for 10.0s",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("pause section missing %q:\n%s", want, out)
+		}
+	}
+	if GeneratePauseSection(nil, start) != "" {
+		t.Error("expected empty section without pauses")
+	}
+}
+
+func TestGeneratePartialSection(t *testing.T) {
+	start := time.Date(2026, 1, 2, 10, 30, 0, 0, time.Local)
+	out := GeneratePartialSection([]analyzer.PodTermination{
+		{Namespace: "shop", Pod: "api-0", Reason: "Evicted", Message: "The node was low on resource: memory.", At: start.Add(72 * time.Second)},
+		{Namespace: "shop", Pod: "api-1", Reason: "Deleted", At: start.Add(80 * time.Second)},
+	}, start)
+	for _, want := range []string{
+		"Partial Report",
+		"    shop/api-0: Evicted at +72.0s (The node was low on resource: memory.)\n",
+		"    shop/api-1: Deleted at +80.0s\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("partial section missing %q:\n%s", want, out)
+		}
+	}
+	if GeneratePartialSection(nil, start) != "" {
+		t.Error("expected empty section when every pod stayed")
+	}
+}
+
+func TestGenerateNoisyNeighborSection(t *testing.T) {
+	out := GenerateNoisyNeighborSection(&analyzer.NoisyNeighbors{
+		RunQueueIntervals: 3, PeakRunQueueShare: 0.4, SlowIOOps: 12,
+		CPU: []analyzer.Neighbor{{Pod: "batch/cruncher", CPUCores: 2.5, Intervals: 2}},
+		IO:  []analyzer.Neighbor{{Pod: "logs/shipper", IOBps: 100 << 20, Intervals: 1}},
+	})
+	for _, want := range []string{
+		"Noisy Neighbor Statistics:",
+		"Waited for a CPU past the threshold in 3 intervals (peak 40.0% of the time); suspected by CPU:",
+		"batch/cruncher: 2.50 cores over 2 intervals",
+		"12 slow file operations; suspected by block I/O:",
+		"logs/shipper: 100.00 MB/s over 1 intervals",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("noisy neighbor section missing %q:\n%s", want, out)
+		}
+	}
+	if GenerateNoisyNeighborSection(nil) != "" {
+		t.Error("expected empty section without noisy neighbors")
+	}
+}
+
+func TestGenerateConnectionSection_Races(t *testing.T) {
+	const ms = uint64(time.Millisecond)
+	d := &filterDiagnostician{byType: map[events.EventType][]*events.Event{
+		events.EventConnect: {
+			{Type: events.EventConnect, PID: 7, Timestamp: 1000 * ms, Target: "2001:db8:0:0:0:0:0:1:443", Details: "api.example.com", Error: -101},
+			{Type: events.EventConnect, PID: 7, Timestamp: 1250 * ms, Target: "93.184.216.34:443", Details: "api.example.com"},
+		},
+		events.EventTCPSend: {{Type: events.EventTCPSend, PID: 7, Timestamp: 1300 * ms, Target: "93.184.216.34:443"}},
+	}}
+	out := GenerateConnectionSection(d, time.Second)
+	for _, want := range []string{
+		"Raced connects: 1 over 2 attempts (1 wasted)",
+		"Failed connections that were losing race legs: 1",
+		"Winning family: IPv4 1",
+		"api.example.com:443 (pid 7): 2 attempts, 1 failed, won by 93.184.216.34:443 (IPv4)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("section lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateConnectionChurnSection(t *testing.T) {
+	var connects, sends []*events.Event
+	for i := uint64(0); i < 20; i++ {
+		connects = append(connects, &events.Event{Type: events.EventConnect, Timestamp: i * 1000, LatencyNS: 1_000_000, Target: "10.0.0.5:80"})
+		sends = append(sends, &events.Event{Type: events.EventTCPSend, Timestamp: i*1000 + 1, Target: "10.0.0.5:80"})
+	}
+	d := &filterDiagnostician{byType: map[events.EventType][]*events.Event{
+		events.EventConnect: connects,
+		events.EventTCPSend: sends,
+	}}
+	out := GenerateConnectionChurnSection(d)
+	for _, want := range []string{
+		"Fresh connects: 20 for 20 sends (0% of sends on a reused connection)",
+		"10.0.0.5:80: 20 connects, 20 sends, 100% fresh, setup 1.00ms each [CHURN: ~19ms lost to reconnects",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("section lacks %q:\n%s", want, out)
+		}
+	}
+	if GenerateConnectionChurnSection(&filterDiagnostician{}) != "" {
+		t.Error("expected no section without connects")
+	}
+}
+
+func TestGeneratePodStatusSection(t *testing.T) {
+	out := GeneratePodStatusSection([]PodStatus{{
+		Namespace: "shop", Pod: "api-0", QOSClass: "Burstable",
+		Containers: []ContainerStatus{
+			{Name: "api", RestartCount: 3, LastReason: "OOMKilled", LastExitCode: 137,
+				LastFinished: time.Date(2026, 10, 16, 14, 2, 11, 0, time.UTC), Limits: "memory=512Mi"},
+			{Name: "proxy"},
+		},
+	}})
+	for _, want := range []string{
+		"Pod Status Statistics:",
+		"  shop/api-0 (QoS Burstable)",
+		"    api: 3 restarts, last terminated OOMKilled (exit code 137) at 2026-10-",
+		"      requests: none; limits: memory=512Mi",
+		"    proxy: 0 restarts\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("section lacks %q:\n%s", want, out)
+		}
+	}
+	if GeneratePodStatusSection(nil) != "" {
+		t.Error("expected no section without a pod status")
+	}
+}
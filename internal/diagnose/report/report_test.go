@@ -371,6 +371,30 @@ func TestGenerateCPUSection_WithEvents(t *testing.T) {
 	}
 }
 
+func TestGenerateCPUSection_Threads(t *testing.T) {
+	d := &mockDiagnostician{
+		events: []*events.Event{
+			{Type: events.EventThreadCPU, PID: 10, TCPState: 11, LatencyNS: 500_000_000, Target: "worker-1", ProcessName: "app"},
+			{Type: events.EventThreadCPU, PID: 10, TCPState: 12, LatencyNS: 20_000_000, Target: "gc"},
+			{Type: events.EventSchedSwitch, PID: 10, TCPState: 12, LatencyNS: 30_000_000, Target: "gc"},
+		},
+		startTime: time.Now(),
+		endTime:   time.Now().Add(10 * time.Second),
+	}
+	result := GenerateCPUSection(d, d.endTime.Sub(d.startTime))
+	for _, want := range []string{
+		"Top threads by CPU time:",
+		"- worker-1 (tid 11, pid 10 app): 500.00ms on CPU (5.0% of a CPU)",
+		"- gc (tid 12, pid 10): 20.00ms on CPU",
+		"Top threads by block time:",
+		"- gc (tid 12, pid 10): blocked 1 times for 30.00ms (max 30.00ms)",
+	} {
+		if !strings.Contains(result, want) {
+			t.Errorf("CPU section missing %q:\n%s", want, result)
+		}
+	}
+}
+
 func TestGenerateTCPStateSection_Empty(t *testing.T) {
 	d := &mockDiagnostician{
 		events:    []*events.Event{},
@@ -0,0 +1,336 @@
+// Package reporttmpl renders the diagnose report through text/template, so
+// wording, section headers, layout, per-issue runbook links and suggested
+// next steps can be customized or localized from a directory (--report-template) without
+// forking. Section bodies are still produced by the report package; the
+// templates control everything around them.
+package reporttmpl
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+//go:embed templates/*.tmpl
+var defaultFS embed.FS
+
+// builtinNextSteps is the knowledge base of likely causes and commands per
+// issue code that nextsteps.yaml extends.
+//
+//go:embed nextsteps.yaml
+var builtinNextSteps string
+
+// Files of a template directory besides the *.tmpl templates.
+const (
+	// MessagesFile maps report strings (the keys passed to 't') to their
+	// translation.
+	MessagesFile = "messages.yaml"
+	// RunbooksFile maps an issue category (the text before its first ':')
+	// to a runbook URL.
+	RunbooksFile = "runbooks.yaml"
+	// NextStepsFile maps an issue code or category to its likely causes
+	// and the commands to run next, replacing the built-in entry.
+	NextStepsFile = "nextsteps.yaml"
+)
+
+// A template directory is forwarded verbatim to spawned pods as a flag, so
+// it is kept small.
+const (
+	maxFiles = 32
+	maxBytes = 256 << 10
+)
+
+// Section is one rendered report section, split so that its header can be
+// translated and the section can be picked out by ID.
+type Section struct {
+	// ID is a stable identifier such as "dns", "http" or "slo".
+	ID string
+	// Header is the section's first line, without the newline ("DNS
+	// Statistics:").
+	Header string
+	// Body is the rest of the section, starting with the header's newline.
+	Body string
+	// DataConfidence ("high", "medium" or "low") grades how complete the
+	// section's data is, and DataGaps says what is missing from it; empty
+	// for a section not built from traced events.
+	DataConfidence string
+	DataGaps       []string
+}
+
+// NewSection splits a section as produced by the report package.
+func NewSection(id, text string) Section {
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		return Section{ID: id, Header: text[:i], Body: text[i:]}
+	}
+	return Section{ID: id, Header: text}
+}
+
+// Issue is one detected issue.
+type Issue struct {
+	// Code is the stable issue code, e.g. "PODTRACE-NET-001"; empty for an
+	// issue not raised by a detector rule.
+	Code string
+	Text string
+	// Category is the text before the first ':', e.g. "High connection
+	// failure rate".
+	Category string
+	// Severity is "critical" or "warning".
+	Severity string
+	// Runbook is the URL configured for Code, or else Category, in
+	// runbooks.yaml.
+	Runbook string
+	// NextSteps is the entry for Code, or else Category, in nextsteps.yaml,
+	// or else the built-in one for Code.
+	NextSteps NextSteps
+	// Score (0..100) and Confidence ("high", "medium" or "low") rank the
+	// issue as a root cause; Confidence is empty for an unscored issue.
+	Score      float64
+	Confidence string
+}
+
+// NextSteps is what to suspect and run after an issue, for readers who do
+// not know where to look next.
+type NextSteps struct {
+	Causes   []string `yaml:"causes"`
+	Commands []string `yaml:"commands"`
+}
+
+// NewIssue classifies a detector issue line.
+func NewIssue(text string) Issue {
+	issue := Issue{Text: text, Category: text, Severity: "warning"}
+	if i := strings.IndexByte(text, ':'); i > 0 {
+		issue.Category = text[:i]
+	}
+	if strings.Contains(text, "CRITICAL") || strings.Contains(text, "EMERGENCY") {
+		issue.Severity = "critical"
+	}
+	return issue
+}
+
+// Data is what the "report" template is executed with.
+type Data struct {
+	Duration        time.Duration
+	Start           time.Time
+	End             time.Time
+	TotalEvents     int
+	EventsPerSecond float64
+	// Sections holds the non-empty sections in report order.
+	Sections []Section
+	Issues   []Issue
+}
+
+// Section returns the section with the given ID, or a zero Section when it
+// is absent from this report.
+func (d Data) Section(id string) Section {
+	for _, s := range d.Sections {
+		if s.ID == id {
+			return s
+		}
+	}
+	return Section{}
+}
+
+// Template is a parsed report template set.
+type Template struct {
+	tmpl      *template.Template
+	messages  map[string]string
+	runbooks  map[string]string
+	nextSteps map[string]NextSteps
+	files     map[string]string
+}
+
+var defaultTemplate = sync.OnceValue(func() *Template {
+	t, err := New(nil)
+	if err != nil {
+		panic(fmt.Sprintf("reporttmpl: default template: %v", err))
+	}
+	return t
+})
+
+var defaultNextSteps = sync.OnceValue(func() map[string]NextSteps {
+	var steps map[string]NextSteps
+	if err := parseMap(builtinNextSteps, &steps); err != nil {
+		panic(fmt.Sprintf("reporttmpl: built-in %s: %v", NextStepsFile, err))
+	}
+	return lowerKeys(steps)
+})
+
+// Default returns the built-in template, which renders the standard report.
+func Default() *Template {
+	return defaultTemplate()
+}
+
+// ReadDir reads a template directory: every *.tmpl file plus the optional
+// messages.yaml, runbooks.yaml and nextsteps.yaml.
+func ReadDir(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("read report template directory: %w", err)
+	}
+	files := make(map[string]string)
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || (filepath.Ext(name) != ".tmpl" && name != MessagesFile && name != RunbooksFile && name != NextStepsFile) {
+			continue
+		}
+		data, err := hostfs.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("read report template %s: %w", name, err)
+		}
+		files[name] = string(data)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("report template directory %s has no *.tmpl, %s, %s or %s files", dir, MessagesFile, RunbooksFile, NextStepsFile)
+	}
+	return files, nil
+}
+
+// New parses files (as returned by ReadDir) on top of the built-in
+// templates, so a file only needs to redefine the templates it changes. The
+// result is test-rendered, so template errors surface at startup rather
+// than at the end of a trace.
+func New(files map[string]string) (*Template, error) {
+	total := 0
+	for _, content := range files {
+		total += len(content)
+	}
+	if len(files) > maxFiles || total > maxBytes {
+		return nil, fmt.Errorf("report template: at most %d files and %d KiB", maxFiles, maxBytes>>10)
+	}
+
+	t := &Template{files: files}
+	if err := parseMap(files[MessagesFile], &t.messages); err != nil {
+		return nil, fmt.Errorf("report template %s: %w", MessagesFile, err)
+	}
+	var runbooks map[string]string
+	if err := parseMap(files[RunbooksFile], &runbooks); err != nil {
+		return nil, fmt.Errorf("report template %s: %w", RunbooksFile, err)
+	}
+	t.runbooks = lowerKeys(runbooks)
+	var nextSteps map[string]NextSteps
+	if err := parseMap(files[NextStepsFile], &nextSteps); err != nil {
+		return nil, fmt.Errorf("report template %s: %w", NextStepsFile, err)
+	}
+	t.nextSteps = lowerKeys(nextSteps)
+
+	tmpl, err := template.New("").Option("missingkey=error").Funcs(template.FuncMap{"t": t.translate}).ParseFS(defaultFS, "templates/*.tmpl")
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		if filepath.Ext(name) == ".tmpl" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if tmpl, err = tmpl.New(name).Parse(files[name]); err != nil {
+			return nil, fmt.Errorf("report template: %w", err)
+		}
+	}
+	if tmpl.Lookup("report") == nil {
+		return nil, errors.New(`report template: no "report" template defined`)
+	}
+	t.tmpl = tmpl
+
+	if _, err := t.Render(sampleData()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+func parseMap[V any](content string, out *map[string]V) error {
+	if strings.TrimSpace(content) == "" {
+		return nil
+	}
+	return yaml.Unmarshal([]byte(content), out)
+}
+
+// lowerKeys keys m by its trimmed, lower-cased keys, for matching that
+// ignores case.
+func lowerKeys[V any](m map[string]V) map[string]V {
+	out := make(map[string]V, len(m))
+	for k, v := range m {
+		out[strings.ToLower(strings.TrimSpace(k))] = v
+	}
+	return out
+}
+
+// Files returns the files the template was built from, for forwarding.
+func (t *Template) Files() map[string]string {
+	return t.files
+}
+
+// Render executes the "report" template. Issues get their runbook URL
+// filled in from runbooks.yaml and their next steps from nextsteps.yaml, by
+// code first and category second; next steps fall back to the built-in
+// entry for the code.
+func (t *Template) Render(d Data) (string, error) {
+	issues := make([]Issue, len(d.Issues))
+	for i, issue := range d.Issues {
+		if issue.Runbook == "" && issue.Code != "" {
+			issue.Runbook = t.runbooks[strings.ToLower(issue.Code)]
+		}
+		if issue.Runbook == "" {
+			issue.Runbook = t.runbooks[strings.ToLower(issue.Category)]
+		}
+		if len(issue.NextSteps.Causes) == 0 && len(issue.NextSteps.Commands) == 0 {
+			issue.NextSteps = t.lookupNextSteps(issue)
+		}
+		issues[i] = issue
+	}
+	d.Issues = issues
+	var b strings.Builder
+	if err := t.tmpl.ExecuteTemplate(&b, "report", d); err != nil {
+		return "", fmt.Errorf("render report template: %w", err)
+	}
+	return b.String(), nil
+}
+
+// lookupNextSteps returns the issue's entry in nextsteps.yaml, by code then
+// category, or else the built-in one for its code.
+func (t *Template) lookupNextSteps(issue Issue) NextSteps {
+	code := strings.ToLower(issue.Code)
+	if s, ok := t.nextSteps[code]; ok && code != "" {
+		return s
+	}
+	if s, ok := t.nextSteps[strings.ToLower(issue.Category)]; ok {
+		return s
+	}
+	return defaultNextSteps()[code]
+}
+
+// translate looks key up in messages.yaml and formats it with args, if any;
+// an untranslated key is used as is.
+func (t *Template) translate(key string, args ...interface{}) string {
+	if msg, ok := t.messages[key]; ok {
+		key = msg
+	}
+	if len(args) == 0 {
+		return key
+	}
+	return fmt.Sprintf(key, args...)
+}
+
+func sampleData() Data {
+	now := time.Now()
+	return Data{
+		Duration: time.Minute,
+		Start:    now.Add(-time.Minute),
+		End:      now,
+		Sections: []Section{NewSection("dns", "DNS Statistics:\n  Total lookups: 1\n\n")},
+		Issues:   []Issue{{Text: "High connection failure rate: 50.0%", Category: "High connection failure rate", Severity: "warning", Score: 50, Confidence: "medium"}},
+	}
+}
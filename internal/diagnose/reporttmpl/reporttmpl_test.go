@@ -0,0 +1,243 @@
+package reporttmpl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+)
+
+func testData() Data {
+	start := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	return Data{
+		Duration:        30 * time.Second,
+		Start:           start,
+		End:             start.Add(30 * time.Second),
+		TotalEvents:     300,
+		EventsPerSecond: 10,
+		Sections: []Section{
+			NewSection("dns", "DNS Statistics:\n  Total lookups: 3\n\n"),
+			NewSection("correlation", "\nConnection Correlation:\n  none\n"),
+		},
+		Issues: []Issue{NewIssue("High connection failure rate: 50.0% (1/2) (threshold: 10.0%)")},
+	}
+}
+
+func TestDefaultRendersStandardReport(t *testing.T) {
+	got, err := Default().Render(testData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "=== Diagnostic Report (collected over 30s) ===\n\n" +
+		"Summary:\n" +
+		"  Total events: 300\n" +
+		"  Events per second: 10.0\n" +
+		"  Collection period: 10:00:00 to 10:00:30\n\n" +
+		"DNS Statistics:\n  Total lookups: 3\n\n" +
+		"\nConnection Correlation:\n  none\n" +
+		"Potential Issues Detected Statistics:\n" +
+		"  High connection failure rate: 50.0% (1/2) (threshold: 10.0%)\n\n"
+	if got != want {
+		t.Errorf("default report mismatch:\ngot:\n%q\nwant:\n%q", got, want)
+	}
+
+	d := testData()
+	d.Issues = nil
+	got, err = Default().Render(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(got, "Potential Issues") {
+		t.Errorf("issues header rendered without issues:\n%s", got)
+	}
+}
+
+func TestCustomTemplateMessagesAndRunbooks(t *testing.T) {
+	tmpl, err := New(map[string]string{
+		"issue.tmpl": `{{define "issue"}}- [{{.Severity}}] {{.Text}}{{with .Runbook}} -> {{.}}{{end}}
+{{end}}`,
+		MessagesFile: "\"Summary:\": \"Zusammenfassung:\"\n\"DNS Statistics:\": \"DNS-Statistik:\"\n\"Total events: %d\": \"Ereignisse gesamt: %d\"\n",
+		RunbooksFile: "\"high connection failure rate\": https://runbooks.example.com/connect\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tmpl.Render(testData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"Zusammenfassung:\n",
+		"  Ereignisse gesamt: 300\n",
+		"DNS-Statistik:\n  Total lookups: 3\n",
+		"- [warning] High connection failure rate: 50.0% (1/2) (threshold: 10.0%) -> https://runbooks.example.com/connect\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("custom report missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestRunbookByCode(t *testing.T) {
+	tmpl, err := New(map[string]string{
+		RunbooksFile: "PODTRACE-NET-001: https://runbooks.example.com/net-001\n\"high connection failure rate\": https://runbooks.example.com/connect\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := testData()
+	d.Issues[0].Code = "PODTRACE-NET-001"
+	got, err := tmpl.Render(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "  [PODTRACE-NET-001] High connection failure rate: 50.0% (1/2) (threshold: 10.0%) (runbook: https://runbooks.example.com/net-001)\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("report missing %q:\n%s", want, got)
+	}
+}
+
+func TestNextSteps(t *testing.T) {
+	d := testData()
+	d.Issues[0].Code = "PODTRACE-NET-002"
+	got, err := Default().Render(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"(threshold: 10.0%)\n    Suggested next steps:\n",
+		"      Likely causes: packet drops on the node's NIC causing retransmits; an MTU mismatch",
+		"      $ ethtool -S <iface> | grep -iE 'drop|discard|err'\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("report missing %q:\n%s", want, got)
+		}
+	}
+
+	// An entry by category replaces the built-in one for the code.
+	tmpl, err := New(map[string]string{
+		NextStepsFile: "\"high connection failure rate\":\n  commands:\n    - kubectl get pods -n shop\n",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = tmpl.Render(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "    Suggested next steps:\n      $ kubectl get pods -n shop\n\n"
+	if !strings.HasSuffix(got, want) || strings.Contains(got, "Likely causes") {
+		t.Errorf("report should end in %q:\n%s", want, got)
+	}
+}
+
+func TestBuiltinNextStepsCoverEveryCode(t *testing.T) {
+	for rule, code := range detector.RuleCodes {
+		s := defaultNextSteps()[strings.ToLower(code)]
+		if len(s.Causes) == 0 || len(s.Commands) == 0 {
+			t.Errorf("%s (%s) has no built-in next steps", code, rule)
+		}
+	}
+}
+
+func TestSectionSelection(t *testing.T) {
+	tmpl, err := New(map[string]string{
+		"report.tmpl": `{{define "report"}}{{with .Section "dns"}}{{.Header}}{{end}}|{{.Section "missing"}}{{end}}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := tmpl.Render(testData())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(got, "DNS Statistics:|") {
+		t.Errorf("got %q", got)
+	}
+}
+
+func TestNewRejectsBrokenTemplates(t *testing.T) {
+	cases := map[string]map[string]string{
+		"syntax":        {"x.tmpl": `{{define "issue"}}{{.Text}`},
+		"unknown field": {"x.tmpl": `{{define "issue"}}{{.Nope}}{{end}}`},
+		"messages":      {MessagesFile: "- not\n- a map\n"},
+		"next steps":    {NextStepsFile: "PODTRACE-NET-001: not a map\n"},
+		"too many":      manyFiles(maxFiles + 1),
+	}
+	for name, files := range cases {
+		if _, err := New(files); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func manyFiles(n int) map[string]string {
+	files := make(map[string]string, n)
+	for i := 0; i < n; i++ {
+		files[strings.Repeat("a", i+1)+".tmpl"] = ""
+	}
+	return files
+}
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"issue.tmpl": `{{define "issue"}}{{.Text}}{{end}}`,
+		RunbooksFile: "a: b\n",
+		"README.md":  "ignored",
+		"notes.txt":  "ignored",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 || files["issue.tmpl"] == "" || files[RunbooksFile] == "" {
+		t.Errorf("files = %v", files)
+	}
+	if _, err := ReadDir(t.TempDir()); err == nil {
+		t.Error("expected error for a directory without templates")
+	}
+}
+
+func TestNewIssue(t *testing.T) {
+	issue := NewIssue("CRITICAL: Memory at 97%")
+	if issue.Category != "CRITICAL" || issue.Severity != "critical" {
+		t.Errorf("issue = %+v", issue)
+	}
+	if issue := NewIssue("no colon"); issue.Category != "no colon" || issue.Severity != "warning" {
+		t.Errorf("issue = %+v", issue)
+	}
+}
+
+func TestDefaultRendersIssueScore(t *testing.T) {
+	d := testData()
+	d.Issues[0].Score, d.Issues[0].Confidence = 72.4, "high"
+	got, err := Default().Render(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "  High connection failure rate: 50.0% (1/2) (threshold: 10.0%) [score 72, high confidence]\n"; !strings.Contains(got, want) {
+		t.Errorf("report missing %q:\n%s", want, got)
+	}
+}
+
+func TestDefaultRendersDataConfidence(t *testing.T) {
+	d := testData()
+	d.Sections[0].DataConfidence = "medium"
+	d.Sections[0].DataGaps = []string{"not attached: kprobe_udp_sendmsg", "3 ring buffer read errors"}
+	got, err := Default().Render(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "DNS Statistics:\n  Data: medium confidence; not attached: kprobe_udp_sendmsg; 3 ring buffer read errors\n  Total lookups: 3\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("report missing %q:\n%s", want, got)
+	}
+}
@@ -7,9 +7,26 @@ import (
 
 var eventTypeSamplingRates = map[events.EventType]int{
 	events.EventOOMKill:        1,
+	events.EventCrash:          1,
+	events.EventPodThroughput:  1,
+	events.EventCPUPlacement:   1,
+	events.EventCompaction:     1,
+	events.EventTHPCollapse:    1,
+	events.EventSwap:           1,
+	events.EventTmpfs:          1,
+	events.EventPageCache:      1,
+	events.EventFsNotify:       1,
+	events.EventThreadCPU:      1,
+	events.EventCRIOp:          1,
+	events.EventImagePull:      1,
+	events.EventDisruption:     1,
+	events.EventNeighbor:       1,
+	events.EventChaos:          1,
+	events.EventPause:          1,
 	events.EventPageFault:      1,
 	events.EventNetDevError:    1,
 	events.EventTCPRetrans:     5,
+	events.EventTCPZeroWindow:  5,
 	events.EventDNS:            10,
 	events.EventConnect:        20,
 	events.EventHTTPReq:        30,
@@ -41,9 +58,9 @@ func getEventPriority(event *events.Event) int {
 	}
 
 	switch event.Type {
-	case events.EventOOMKill, events.EventPageFault, events.EventNetDevError:
+	case events.EventOOMKill, events.EventCrash, events.EventPageFault, events.EventNetDevError:
 		return config.PriorityCritical
-	case events.EventTCPRetrans, events.EventLockContention:
+	case events.EventTCPRetrans, events.EventTCPZeroWindow, events.EventLockContention:
 		return config.PriorityHigh
 	case events.EventDNS, events.EventConnect, events.EventHTTPReq, events.EventHTTPResp:
 		return config.PriorityNormal
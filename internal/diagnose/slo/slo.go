@@ -0,0 +1,186 @@
+// Package slo evaluates user-declared service level objectives (p99 latency
+// and error rate per target pattern) over a diagnose window.
+package slo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+// SLO is one objective from the --slo file.
+type SLO struct {
+	Name string `yaml:"name" json:"name"`
+	// Target is a pattern matched against the whole event target; '*'
+	// matches any run of characters, including '/'.
+	Target string `yaml:"target" json:"target"`
+	// Type optionally restricts the objective to one event type, by its
+	// report name ("HTTP", "DB", "REDIS", ...).
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// P99 is the latency the 99th percentile must stay at or under.
+	P99 time.Duration `yaml:"p99,omitempty" json:"p99,omitempty"`
+	// MaxErrorRate is the highest acceptable error rate, in percent.
+	MaxErrorRate *float64 `yaml:"max_error_rate,omitempty" json:"maxErrorRate,omitempty"`
+
+	re *regexp.Regexp
+}
+
+const maxSLOs = 64
+
+// Load reads and validates an SLO file:
+//
+//	slos:
+//	  - name: checkout
+//	    target: "POST /api/checkout*"
+//	    p99: 250ms
+//	    max_error_rate: 1
+func Load(file string) ([]SLO, error) {
+	data, err := hostfs.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read SLO definitions: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse validates SLO definitions in the Load file format. JSON, being YAML,
+// is accepted too.
+func Parse(data []byte) ([]SLO, error) {
+	var doc struct {
+		SLOs []SLO `yaml:"slos"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse SLO definitions: %w", err)
+	}
+	if len(doc.SLOs) == 0 {
+		return nil, errors.New("SLO definitions: no entries under 'slos'")
+	}
+	if len(doc.SLOs) > maxSLOs {
+		return nil, fmt.Errorf("SLO definitions: %d entries exceeds the limit of %d", len(doc.SLOs), maxSLOs)
+	}
+	for i := range doc.SLOs {
+		s := &doc.SLOs[i]
+		if s.Target == "" {
+			return nil, fmt.Errorf("SLO definition %d: target is required", i+1)
+		}
+		if s.P99 <= 0 && s.MaxErrorRate == nil {
+			return nil, fmt.Errorf("SLO definition %d: at least one of p99 and max_error_rate is required", i+1)
+		}
+		if s.MaxErrorRate != nil && (*s.MaxErrorRate < 0 || *s.MaxErrorRate > 100) {
+			return nil, fmt.Errorf("SLO definition %d: max_error_rate must be a percentage between 0 and 100", i+1)
+		}
+		if s.Name == "" {
+			s.Name = s.Target
+		}
+		s.re = compilePattern(s.Target)
+	}
+	return doc.SLOs, nil
+}
+
+func compilePattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// Matches reports whether e counts toward the objective. Only events that
+// carry an outcome (a latency or an error) count, so request-side events
+// that merely announce a call are not mistaken for instant successes.
+func (s *SLO) Matches(e *events.Event) bool {
+	if e == nil || (e.LatencyNS == 0 && e.Error == 0) {
+		return false
+	}
+	if s.Type != "" && !strings.EqualFold(s.Type, e.TypeString()) {
+		return false
+	}
+	if s.re == nil {
+		s.re = compilePattern(s.Target)
+	}
+	return s.re.MatchString(e.Target)
+}
+
+// Status is the outcome of one objective over the window.
+type Status string
+
+const (
+	StatusPass   Status = "pass"
+	StatusFail   Status = "fail"
+	StatusNoData Status = "no data"
+)
+
+// Result is one evaluated objective.
+type Result struct {
+	SLO        SLO      `json:"slo"`
+	Status     Status   `json:"status"`
+	Events     int      `json:"events"`
+	Errors     int      `json:"errors"`
+	P99MS      float64  `json:"p99Ms"`
+	ErrorRate  float64  `json:"errorRate"`
+	Violations []string `json:"violations,omitempty"`
+}
+
+// Evaluate checks every objective against the window's events. An objective
+// no event matched is reported as StatusNoData rather than failed.
+func Evaluate(slos []SLO, evs []*events.Event) []Result {
+	results := make([]Result, 0, len(slos))
+	for i := range slos {
+		s := &slos[i]
+		r := Result{SLO: *s}
+		var lat []float64
+		for _, e := range evs {
+			if !s.Matches(e) {
+				continue
+			}
+			r.Events++
+			if e.Error != 0 {
+				r.Errors++
+			}
+			lat = append(lat, float64(e.LatencyNS)/float64(config.NSPerMS))
+		}
+		if r.Events == 0 {
+			r.Status = StatusNoData
+			results = append(results, r)
+			continue
+		}
+		sort.Float64s(lat)
+		r.P99MS = analyzer.Percentile(lat, 99)
+		r.ErrorRate = float64(r.Errors) / float64(r.Events) * 100
+		r.Status = StatusPass
+		if s.P99 > 0 {
+			limitMS := float64(s.P99) / float64(time.Millisecond)
+			if r.P99MS > limitMS {
+				r.Violations = append(r.Violations, fmt.Sprintf("p99 %.2fms > %s", r.P99MS, s.P99))
+			}
+		}
+		if s.MaxErrorRate != nil && r.ErrorRate > *s.MaxErrorRate {
+			r.Violations = append(r.Violations, fmt.Sprintf("error rate %.2f%% > %.2f%%", r.ErrorRate, *s.MaxErrorRate))
+		}
+		if len(r.Violations) > 0 {
+			r.Status = StatusFail
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Failed counts the results that violated their objective.
+func Failed(results []Result) int {
+	n := 0
+	for _, r := range results {
+		if r.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
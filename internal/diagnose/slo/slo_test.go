@@ -0,0 +1,101 @@
+package slo
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestParse(t *testing.T) {
+	slos, err := Parse([]byte(`
+slos:
+  - name: checkout
+    target: "POST /api/checkout*"
+    p99: 250ms
+    max_error_rate: 1
+  - target: "SELECT *"
+    type: db
+    max_error_rate: 0
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(slos) != 2 {
+		t.Fatalf("got %d SLOs", len(slos))
+	}
+	if slos[0].P99 != 250*time.Millisecond || *slos[0].MaxErrorRate != 1 {
+		t.Errorf("checkout SLO = %+v", slos[0])
+	}
+	if slos[1].Name != "SELECT *" || slos[1].P99 != 0 || *slos[1].MaxErrorRate != 0 {
+		t.Errorf("unnamed SLO = %+v", slos[1])
+	}
+
+	for _, bad := range []string{
+		"slos: []",
+		"slos:\n  - p99: 1s",
+		"slos:\n  - target: x",
+		"slos:\n  - target: x\n    max_error_rate: 101",
+		"slos: [",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	slos, err := Parse([]byte(`
+slos:
+  - name: orders
+    target: "GET /api/orders/*"
+    p99: 100ms
+  - name: checkout
+    target: "POST /checkout"
+    max_error_rate: 10
+  - name: idle
+    target: "GET /never"
+    p99: 1s
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evs []*events.Event
+	for i := 0; i < 100; i++ {
+		evs = append(evs, &events.Event{Type: events.EventHTTPResp, Target: "GET /api/orders/42", LatencyNS: 20 * uint64(time.Millisecond)})
+	}
+	evs = append(evs,
+		&events.Event{Type: events.EventHTTPResp, Target: "GET /api/orders/7", LatencyNS: 900 * uint64(time.Millisecond)},
+		&events.Event{Type: events.EventHTTPResp, Target: "GET /api/orders/8", LatencyNS: 950 * uint64(time.Millisecond)},
+		// Request-side events carry no outcome and are ignored.
+		&events.Event{Type: events.EventHTTPReq, Target: "GET /api/orders/9"},
+		&events.Event{Type: events.EventHTTPResp, Target: "POST /checkout", LatencyNS: 5, Error: 503},
+		&events.Event{Type: events.EventHTTPResp, Target: "POST /checkout", LatencyNS: 5},
+		nil,
+	)
+
+	results := Evaluate(slos, evs)
+	if len(results) != 3 {
+		t.Fatalf("got %d results", len(results))
+	}
+	orders, checkout, idle := results[0], results[1], results[2]
+	if orders.Status != StatusFail || orders.Events != 102 || len(orders.Violations) != 1 || !strings.HasPrefix(orders.Violations[0], "p99 ") {
+		t.Errorf("orders = %+v", orders)
+	}
+	if checkout.Status != StatusFail || checkout.ErrorRate != 50 {
+		t.Errorf("checkout = %+v", checkout)
+	}
+	if idle.Status != StatusNoData {
+		t.Errorf("idle = %+v", idle)
+	}
+	if Failed(results) != 2 {
+		t.Errorf("Failed = %d, want 2", Failed(results))
+	}
+
+	slos[1].MaxErrorRate = new(float64)
+	*slos[1].MaxErrorRate = 50
+	if r := Evaluate(slos[1:2], evs); r[0].Status != StatusPass {
+		t.Errorf("checkout at 50%% allowance = %+v", r[0])
+	}
+}
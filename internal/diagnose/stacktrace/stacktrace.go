@@ -199,6 +199,23 @@ func (r *stackResolver) loadSegments(exePath string) []loadSegment {
 	return out
 }
 
+// ResolveFrames symbolizes up to limit frames of stack against the running
+// process pid. Callers whose process is about to exit (crashes) must resolve
+// while the event is fresh, since /proc/<pid> disappears with it.
+func ResolveFrames(ctx context.Context, pid uint32, stack []uint64, limit int) []string {
+	resolver := &stackResolver{cache: make(map[string]string)}
+	var frames []string
+	for _, addr := range stack {
+		if len(frames) >= limit {
+			break
+		}
+		if frame := resolver.resolve(ctx, pid, addr); frame != "" {
+			frames = append(frames, frame)
+		}
+	}
+	return frames
+}
+
 func GenerateStackTraceSectionWithContext(d Diagnostician, ctx context.Context) string {
 	allEvents := d.GetEvents()
 	if len(allEvents) == 0 {
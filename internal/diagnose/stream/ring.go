@@ -0,0 +1,39 @@
+package stream
+
+// Ring keeps the last n values pushed to it.
+type Ring[T any] struct {
+	buf  []T
+	head int
+	full bool
+}
+
+// NewRing returns a ring holding up to n values.
+func NewRing[T any](n int) *Ring[T] {
+	if n < 1 {
+		n = 1
+	}
+	return &Ring[T]{buf: make([]T, 0, n)}
+}
+
+// Push adds v, evicting the oldest value when the ring is full.
+func (r *Ring[T]) Push(v T) {
+	if !r.full {
+		r.buf = append(r.buf, v)
+		r.full = len(r.buf) == cap(r.buf)
+		return
+	}
+	r.buf[r.head] = v
+	r.head = (r.head + 1) % len(r.buf)
+}
+
+// Len is the number of values held.
+func (r *Ring[T]) Len() int {
+	return len(r.buf)
+}
+
+// Items returns the held values, oldest first.
+func (r *Ring[T]) Items() []T {
+	out := make([]T, 0, len(r.buf))
+	out = append(out, r.buf[r.head:]...)
+	return append(out, r.buf[:r.head]...)
+}
@@ -0,0 +1,130 @@
+// Package stream provides fixed-size summaries of unbounded event streams.
+// Every structure allocates its full footprint up front (or grows to a hard
+// cap), so the memory a long diagnose session spends on them does not depend
+// on how many events it sees.
+package stream
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// CountMinSketch estimates per-key counts in width*depth counters. An
+// estimate never undercounts; it overcounts by at most 2N/width with
+// probability 1-2^-depth, N being the total added.
+type CountMinSketch struct {
+	width  int
+	depth  int
+	counts []uint32
+	total  uint64
+}
+
+// NewCountMinSketch allocates a sketch of width*depth counters.
+func NewCountMinSketch(width, depth int) *CountMinSketch {
+	if width < 1 {
+		width = 1
+	}
+	if depth < 1 {
+		depth = 1
+	}
+	return &CountMinSketch{width: width, depth: depth, counts: make([]uint32, width*depth)}
+}
+
+// Add counts n more occurrences of key and returns its new estimate.
+func (s *CountMinSketch) Add(key string, n uint32) uint32 {
+	h1, h2 := hashKey(key)
+	s.total += uint64(n)
+	est := ^uint32(0)
+	for row := 0; row < s.depth; row++ {
+		i := row*s.width + int((h1+uint64(row)*h2)%uint64(s.width))
+		if s.counts[i] <= ^uint32(0)-n {
+			s.counts[i] += n
+		} else {
+			s.counts[i] = ^uint32(0)
+		}
+		est = min(est, s.counts[i])
+	}
+	return est
+}
+
+// Estimate returns the estimated count of key.
+func (s *CountMinSketch) Estimate(key string) uint32 {
+	h1, h2 := hashKey(key)
+	est := ^uint32(0)
+	for row := 0; row < s.depth; row++ {
+		est = min(est, s.counts[row*s.width+int((h1+uint64(row)*h2)%uint64(s.width))])
+	}
+	return est
+}
+
+// Total is the sum of every count added.
+func (s *CountMinSketch) Total() uint64 {
+	return s.total
+}
+
+// Bytes is the memory held by the counters.
+func (s *CountMinSketch) Bytes() int {
+	return len(s.counts) * 4
+}
+
+// hashKey derives the two hashes the rows are indexed with (Kirsch and
+// Mitzenmacher double hashing).
+func hashKey(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(key))
+	sum := h.Sum64()
+	return sum, (sum >> 32) | 1
+}
+
+// Counted is a key with its estimated count.
+type Counted struct {
+	Key   string `json:"key"`
+	Count uint32 `json:"count"`
+}
+
+// TopK tracks the k most frequent keys of a stream: counts come from a
+// count-min sketch and only the k current leaders are stored by name.
+type TopK struct {
+	k      int
+	sketch *CountMinSketch
+	top    map[string]uint32
+}
+
+// NewTopK tracks the k heaviest keys over a width*depth sketch.
+func NewTopK(k, width, depth int) *TopK {
+	return &TopK{k: k, sketch: NewCountMinSketch(width, depth), top: make(map[string]uint32, k+1)}
+}
+
+// Add counts one occurrence of key.
+func (t *TopK) Add(key string) {
+	est := t.sketch.Add(key, 1)
+	if _, ok := t.top[key]; ok || len(t.top) < t.k {
+		t.top[key] = est
+		return
+	}
+	minKey, minCount := "", ^uint32(0)
+	for k, c := range t.top {
+		if c < minCount || (c == minCount && k < minKey) {
+			minKey, minCount = k, c
+		}
+	}
+	if est > minCount {
+		delete(t.top, minKey)
+		t.top[key] = est
+	}
+}
+
+// Top returns the tracked keys, most frequent first.
+func (t *TopK) Top() []Counted {
+	out := make([]Counted, 0, len(t.top))
+	for k := range t.top {
+		out = append(out, Counted{Key: k, Count: t.sketch.Estimate(k)})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out
+}
@@ -0,0 +1,119 @@
+package stream
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func TestCountMinSketchNeverUndercounts(t *testing.T) {
+	s := NewCountMinSketch(64, 4)
+	want := map[string]uint32{}
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("k%d", i%300)
+		s.Add(key, 1)
+		want[key]++
+	}
+	for k, n := range want {
+		if got := s.Estimate(k); got < n {
+			t.Errorf("Estimate(%s) = %d, below true count %d", k, got, n)
+		}
+	}
+	if s.Total() != 2000 || s.Bytes() != 64*4*4 {
+		t.Errorf("total = %d, bytes = %d", s.Total(), s.Bytes())
+	}
+}
+
+func TestTopKFindsHeavyHitters(t *testing.T) {
+	tk := NewTopK(3, 256, 4)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 5000; i++ {
+		tk.Add(fmt.Sprintf("noise-%d", rng.Intn(1000)))
+		if i%5 == 0 {
+			tk.Add("db:5432")
+		}
+		if i%10 == 0 {
+			tk.Add("cache:6379")
+		}
+	}
+	top := tk.Top()
+	if len(top) != 3 || top[0].Key != "db:5432" || top[1].Key != "cache:6379" {
+		t.Errorf("top = %+v", top)
+	}
+	if top[0].Count < 1000 {
+		t.Errorf("db count %d undercounted", top[0].Count)
+	}
+}
+
+func TestTDigestQuantiles(t *testing.T) {
+	d := NewTDigest(100)
+	rng := rand.New(rand.NewSource(2))
+	values := make([]float64, 0, 50000)
+	for i := 0; i < 50000; i++ {
+		v := rng.ExpFloat64() * 10
+		values = append(values, v)
+		d.Add(v)
+	}
+	sort.Float64s(values)
+	for q, tolerance := range map[float64]float64{0.5: 0.02, 0.95: 0.02, 0.99: 0.02, 0.999: 0.05} {
+		exact := values[int(q*float64(len(values)))]
+		if got := d.Quantile(q); math.Abs(got-exact)/exact > tolerance {
+			t.Errorf("q%.3f = %.3f, exact %.3f", q, got, exact)
+		}
+	}
+	if d.Count() != 50000 || d.Max() != values[len(values)-1] {
+		t.Errorf("count = %d, max = %f", d.Count(), d.Max())
+	}
+	if len(d.centroids) > 200 {
+		t.Errorf("%d centroids; the digest is not bounded", len(d.centroids))
+	}
+	if NewTDigest(100).Quantile(0.5) != 0 {
+		t.Error("empty digest must report 0")
+	}
+}
+
+func TestTDigestMerge(t *testing.T) {
+	a, b, whole := NewTDigest(100), NewTDigest(100), NewTDigest(100)
+	rng := rand.New(rand.NewSource(3))
+	for i := 0; i < 20000; i++ {
+		v := rng.ExpFloat64() * 10
+		if i%3 == 0 {
+			a.Add(v)
+		} else {
+			b.Add(v + 5)
+			v += 5
+		}
+		whole.Add(v)
+	}
+	a.Merge(b)
+	a.Merge(nil)
+	a.Merge(NewTDigest(100))
+	if a.Count() != whole.Count() || a.Max() != whole.Max() {
+		t.Fatalf("count = %d, max = %f; want %d, %f", a.Count(), a.Max(), whole.Count(), whole.Max())
+	}
+	for _, q := range []float64{0.5, 0.95, 0.99} {
+		if got, want := a.Quantile(q), whole.Quantile(q); math.Abs(got-want)/want > 0.02 {
+			t.Errorf("merged q%.2f = %.3f, single digest %.3f", q, got, want)
+		}
+	}
+	if len(a.centroids) > 200 {
+		t.Errorf("%d centroids after merge; the digest is not bounded", len(a.centroids))
+	}
+}
+
+func TestRing(t *testing.T) {
+	r := NewRing[int](3)
+	for i := 1; i <= 5; i++ {
+		r.Push(i)
+	}
+	if got := fmt.Sprint(r.Items()); got != "[3 4 5]" || r.Len() != 3 {
+		t.Errorf("items = %s", got)
+	}
+	r = NewRing[int](3)
+	r.Push(1)
+	if got := fmt.Sprint(r.Items()); got != "[1]" {
+		t.Errorf("items = %s", got)
+	}
+}
@@ -0,0 +1,164 @@
+package stream
+
+import (
+	"math"
+	"sort"
+)
+
+type centroid struct {
+	mean   float64
+	weight float64
+}
+
+// TDigest estimates quantiles of a stream in at most about compression
+// centroids (Dunning's merging t-digest). Accuracy is best at the tails,
+// which is where latency percentiles are read.
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []float64
+	count       float64
+	min, max    float64
+}
+
+// NewTDigest returns a digest keeping about compression centroids; 100 gives
+// tail quantiles within a fraction of a percent.
+func NewTDigest(compression float64) *TDigest {
+	if compression < 10 {
+		compression = 10
+	}
+	return &TDigest{
+		compression: compression,
+		buffer:      make([]float64, 0, int(5*compression)),
+		min:         math.Inf(1),
+		max:         math.Inf(-1),
+	}
+}
+
+// Add records one value.
+func (t *TDigest) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	t.buffer = append(t.buffer, x)
+	t.count++
+	t.min = math.Min(t.min, x)
+	t.max = math.Max(t.max, x)
+	if len(t.buffer) == cap(t.buffer) {
+		t.flush()
+	}
+}
+
+// Count is the number of values added.
+func (t *TDigest) Count() int {
+	return int(t.count)
+}
+
+// Max is the largest value added, or 0 for an empty digest.
+func (t *TDigest) Max() float64 {
+	if t.count == 0 {
+		return 0
+	}
+	return t.max
+}
+
+// Merge adds every value summarized by o, as if each had been added to t.
+func (t *TDigest) Merge(o *TDigest) {
+	if o == nil || o.count == 0 {
+		return
+	}
+	o.flush()
+	t.flush()
+	all := make([]centroid, 0, len(t.centroids)+len(o.centroids))
+	all = append(all, t.centroids...)
+	all = append(all, o.centroids...)
+	t.count += o.count
+	t.min = math.Min(t.min, o.min)
+	t.max = math.Max(t.max, o.max)
+	t.compress(all)
+}
+
+// Quantile estimates the q-quantile (0 <= q <= 1), or returns 0 for an empty
+// digest.
+func (t *TDigest) Quantile(q float64) float64 {
+	if t.count == 0 {
+		return 0
+	}
+	t.flush()
+	if q <= 0 {
+		return t.min
+	}
+	if q >= 1 {
+		return t.max
+	}
+	cs := t.centroids
+	if len(cs) == 1 {
+		return cs[0].mean
+	}
+	target := q * t.count
+	if target < cs[0].weight/2 {
+		return t.min + (cs[0].mean-t.min)*target/(cs[0].weight/2)
+	}
+	cum := 0.0
+	for i := 0; i < len(cs)-1; i++ {
+		lo := cum + cs[i].weight/2
+		hi := cum + cs[i].weight + cs[i+1].weight/2
+		if target < hi {
+			return cs[i].mean + (cs[i+1].mean-cs[i].mean)*(target-lo)/(hi-lo)
+		}
+		cum += cs[i].weight
+	}
+	last := cs[len(cs)-1]
+	lo := t.count - last.weight/2
+	return last.mean + (t.max-last.mean)*(target-lo)/(t.count-lo)
+}
+
+// flush merges the buffered values into the centroids, compressing them so
+// no centroid spans more than the scale function allows.
+func (t *TDigest) flush() {
+	if len(t.buffer) == 0 {
+		return
+	}
+	all := make([]centroid, 0, len(t.centroids)+len(t.buffer))
+	all = append(all, t.centroids...)
+	for _, x := range t.buffer {
+		all = append(all, centroid{mean: x, weight: 1})
+	}
+	t.buffer = t.buffer[:0]
+	t.compress(all)
+}
+
+// compress replaces the centroids with all, merged so no centroid spans
+// more than the scale function allows. t.count must already include them.
+func (t *TDigest) compress(all []centroid) {
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged := make([]centroid, 0, len(t.centroids))
+	cur := all[0]
+	soFar := 0.0
+	limit := t.count * t.kInverse(t.k(0)+1)
+	for _, c := range all[1:] {
+		if soFar+cur.weight+c.weight <= limit {
+			cur.mean += (c.mean - cur.mean) * c.weight / (cur.weight + c.weight)
+			cur.weight += c.weight
+			continue
+		}
+		soFar += cur.weight
+		merged = append(merged, cur)
+		limit = t.count * t.kInverse(t.k(soFar/t.count)+1)
+		cur = c
+	}
+	t.centroids = append(merged, cur)
+}
+
+// k is the k1 scale function: centroids are small near q=0 and q=1.
+func (t *TDigest) k(q float64) float64 {
+	return t.compression / (2 * math.Pi) * math.Asin(2*q-1)
+}
+
+func (t *TDigest) kInverse(k float64) float64 {
+	if k >= t.compression/4 {
+		return 1
+	}
+	return (math.Sin(k*2*math.Pi/t.compression) + 1) / 2
+}
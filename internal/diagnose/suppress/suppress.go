@@ -0,0 +1,172 @@
+// Package suppress keeps well-known benign events (EAGAIN on non-blocking
+// sockets, kubelet health-check connects, pause-container activity) out of
+// issue detection and the report's tables. Suppressed events are still
+// counted, per rule, so the report says how much was set aside.
+package suppress
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+// Rule matches one benign pattern. Every field that is set must match; a
+// rule needs at least one of them.
+type Rule struct {
+	Name string `yaml:"name" json:"name"`
+	// Type restricts the rule to one event type, by its report name ("NET",
+	// "FS", "HTTP", ...).
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// Process and Target are patterns matched against the whole process
+	// name and event target; '*' matches any run of characters.
+	Process string `yaml:"process,omitempty" json:"process,omitempty"`
+	Target  string `yaml:"target,omitempty" json:"target,omitempty"`
+	// Errno matches events that failed with this error number, whichever
+	// sign the probe reported it with.
+	Errno int32 `yaml:"errno,omitempty" json:"errno,omitempty"`
+
+	processRe, targetRe *regexp.Regexp
+}
+
+const maxRules = 64
+
+// Builtin returns the rules applied unless --no-suppress is given.
+func Builtin() []Rule {
+	rules := []Rule{
+		// Non-blocking sockets report EAGAIN whenever a read or write
+		// would block; event loops hit it constantly and retry.
+		{Name: "eagain-nonblocking", Type: "NET", Errno: config.EAGAIN},
+		// Liveness and readiness probes, when the node's kubelet is traced.
+		{Name: "kubelet-health-check", Type: "NET", Process: "kubelet"},
+		// The pod sandbox's pause process only holds the namespaces.
+		{Name: "pause-container", Process: "pause"},
+	}
+	for i := range rules {
+		rules[i].compile()
+	}
+	return rules
+}
+
+// Load reads and validates a suppression file:
+//
+//	suppress:
+//	  - name: readiness-probe
+//	    type: HTTP
+//	    target: "GET /healthz*"
+func Load(file string) ([]Rule, error) {
+	data, err := hostfs.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read suppression rules: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse validates rules in the Load file format. JSON, being YAML, is
+// accepted too.
+func Parse(data []byte) ([]Rule, error) {
+	var doc struct {
+		Rules []Rule `yaml:"suppress"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse suppression rules: %w", err)
+	}
+	if len(doc.Rules) == 0 {
+		return nil, errors.New("suppression rules: no entries under 'suppress'")
+	}
+	if len(doc.Rules) > maxRules {
+		return nil, fmt.Errorf("suppression rules: %d entries exceeds the limit of %d", len(doc.Rules), maxRules)
+	}
+	for i := range doc.Rules {
+		r := &doc.Rules[i]
+		if r.Type == "" && r.Process == "" && r.Target == "" && r.Errno == 0 {
+			return nil, fmt.Errorf("suppression rule %d: at least one of type, process, target and errno is required", i+1)
+		}
+		if r.Errno < 0 {
+			return nil, fmt.Errorf("suppression rule %d: errno must be positive", i+1)
+		}
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		r.compile()
+	}
+	return doc.Rules, nil
+}
+
+func (r *Rule) compile() {
+	if r.Process != "" {
+		r.processRe = compilePattern(r.Process)
+	}
+	if r.Target != "" {
+		r.targetRe = compilePattern(r.Target)
+	}
+}
+
+func compilePattern(pattern string) *regexp.Regexp {
+	parts := strings.Split(pattern, "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+}
+
+// Matches reports whether e is an instance of the benign pattern.
+func (r *Rule) Matches(e *events.Event) bool {
+	if e == nil {
+		return false
+	}
+	if r.Type != "" && !strings.EqualFold(r.Type, e.TypeString()) {
+		return false
+	}
+	if r.Errno != 0 && e.Error != r.Errno && e.Error != -r.Errno {
+		return false
+	}
+	if (r.Process != "" && r.processRe == nil) || (r.Target != "" && r.targetRe == nil) {
+		r.compile()
+	}
+	if r.processRe != nil && !r.processRe.MatchString(e.ProcessName) {
+		return false
+	}
+	return r.targetRe == nil || r.targetRe.MatchString(e.Target)
+}
+
+// Match returns the name of the first rule e matches.
+func Match(rules []Rule, e *events.Event) (string, bool) {
+	for i := range rules {
+		if rules[i].Matches(e) {
+			return rules[i].Name, true
+		}
+	}
+	return "", false
+}
+
+// Count is how many events one rule suppressed.
+type Count struct {
+	Rule   string `json:"rule"`
+	Events int    `json:"events"`
+}
+
+// Counts sorts per-rule totals, most suppressed first.
+func Counts(byRule map[string]int) []Count {
+	if len(byRule) == 0 {
+		return nil
+	}
+	counts := make([]Count, 0, len(byRule))
+	for rule, n := range byRule {
+		counts = append(counts, Count{Rule: rule, Events: n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Events != counts[j].Events {
+			return counts[i].Events > counts[j].Events
+		}
+		return counts[i].Rule < counts[j].Rule
+	})
+	return counts
+}
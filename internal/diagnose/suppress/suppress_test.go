@@ -0,0 +1,82 @@
+package suppress
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestParse(t *testing.T) {
+	rules, err := Parse([]byte(`
+suppress:
+  - name: readiness
+    type: http
+    target: "GET /healthz*"
+  - process: "node-exporter*"
+    errno: 104
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rules) != 2 || rules[0].Name != "readiness" || rules[1].Name != "rule-2" {
+		t.Fatalf("rules = %+v", rules)
+	}
+
+	for _, bad := range []string{
+		"suppress: []",
+		"suppress:\n  - name: empty",
+		"suppress:\n  - errno: -11",
+		"suppress: [",
+	} {
+		if _, err := Parse([]byte(bad)); err == nil {
+			t.Errorf("Parse(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestBuiltinRules(t *testing.T) {
+	rules := Builtin()
+	tests := []struct {
+		name  string
+		event *events.Event
+		want  string
+	}{
+		{"EAGAIN recv", &events.Event{Type: events.EventTCPRecv, Error: -11, ProcessName: "nginx"}, "eagain-nonblocking"},
+		{"kubelet probe", &events.Event{Type: events.EventConnect, ProcessName: "kubelet", Target: "10.0.0.5:8080"}, "kubelet-health-check"},
+		{"pause", &events.Event{Type: events.EventSchedSwitch, ProcessName: "pause"}, "pause-container"},
+		{"EAGAIN on a file", &events.Event{Type: events.EventRead, Error: -11}, ""},
+		{"real failure", &events.Event{Type: events.EventConnect, Error: -111, ProcessName: "api"}, ""},
+		{"resource at 11%", &events.Event{Type: events.EventResourceLimit, Error: 11}, ""},
+	}
+	for _, tt := range tests {
+		got, ok := Match(rules, tt.event)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("%s: Match = %q, %v; want %q", tt.name, got, ok, tt.want)
+		}
+	}
+}
+
+func TestRuleMatchesErrnoEitherSign(t *testing.T) {
+	r := Rule{Errno: 104, Target: "10.0.0.*:9100"}
+	for _, errno := range []int32{104, -104} {
+		if !r.Matches(&events.Event{Type: events.EventTCPSend, Error: errno, Target: "10.0.0.7:9100"}) {
+			t.Errorf("error %d: expected a match", errno)
+		}
+	}
+	if r.Matches(&events.Event{Type: events.EventTCPSend, Error: 104, Target: "10.0.1.7:9100"}) {
+		t.Error("matched a target outside the pattern")
+	}
+	if r.Matches(nil) {
+		t.Error("matched a nil event")
+	}
+}
+
+func TestCounts(t *testing.T) {
+	got := Counts(map[string]int{"b": 2, "a": 2, "c": 5})
+	if len(got) != 3 || got[0].Rule != "c" || got[1].Rule != "a" || got[2].Rule != "b" {
+		t.Errorf("Counts = %+v", got)
+	}
+	if Counts(nil) != nil {
+		t.Error("Counts(nil) should be nil")
+	}
+}
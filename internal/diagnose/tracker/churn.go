@@ -0,0 +1,59 @@
+package tracker
+
+import (
+	"sort"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// ConnectionChurn is how often a target got a fresh connection rather than
+// reusing an open one.
+type ConnectionChurn struct {
+	Target   string `json:"target"`
+	Connects int    `json:"connects"`
+	Sends    int    `json:"sends"`
+	// FreshRatio is connects per send: near 0 when connections are kept
+	// alive, near 1 when every request opens its own.
+	FreshRatio float64       `json:"fresh_ratio"`
+	SetupCost  time.Duration `json:"setup_cost_ns"`
+	// Cost estimates the time spent setting up the connections that
+	// keep-alive would have reused.
+	Cost      time.Duration `json:"estimated_cost_ns"`
+	Excessive bool          `json:"excessive"`
+}
+
+// AnalyzeConnectionChurn reads the connection table of evs and returns
+// the targets connected to at least once, most connects first. A target
+// with at least PODTRACE_CONNECTION_CHURN_MIN connects whose fresh ratio
+// reaches PODTRACE_CONNECTION_CHURN_WARN is excessive: its client most
+// likely has keep-alive disabled.
+func AnalyzeConnectionChurn(evs []*events.Event) []ConnectionChurn {
+	ct := NewConnectionTracker()
+	for _, e := range evs {
+		ct.ProcessEvent(e)
+	}
+	var out []ConnectionChurn
+	for _, s := range ct.GetConnectionSummary() {
+		if s.Connects == 0 {
+			continue
+		}
+		c := ConnectionChurn{Target: s.Target, Connects: s.Connects, Sends: s.SendCount, SetupCost: s.SetupCost}
+		c.FreshRatio = 1
+		if s.SendCount > s.Connects {
+			c.FreshRatio = float64(s.Connects) / float64(s.SendCount)
+		}
+		// One connection would have served them all.
+		c.Cost = time.Duration(s.Connects-1) * s.SetupCost
+		c.Excessive = s.Connects >= config.ConnectionChurnMin && c.FreshRatio >= config.ConnectionChurnWarn
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Connects != out[j].Connects {
+			return out[i].Connects > out[j].Connects
+		}
+		return out[i].Target < out[j].Target
+	})
+	return out
+}
@@ -0,0 +1,48 @@
+package tracker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestAnalyzeConnectionChurn(t *testing.T) {
+	const ms = uint64(time.Millisecond)
+	var evs []*events.Event
+	// 30 requests to the API, each on a fresh connection with a 4ms
+	// handshake.
+	for i := uint64(0); i < 30; i++ {
+		at := (i + 1) * 100 * ms
+		evs = append(evs,
+			&events.Event{Type: events.EventTCPState, TCPState: tcpSynSent, Timestamp: at, Target: "10.0.0.5:80"},
+			&events.Event{Type: events.EventConnect, Timestamp: at, LatencyNS: ms / 20, Target: "10.0.0.5:80"},
+			&events.Event{Type: events.EventTCPState, TCPState: tcpEstablished, Timestamp: at + 4*ms, Target: "10.0.0.5:80"},
+			&events.Event{Type: events.EventTCPSend, Timestamp: at + 5*ms, Target: "10.0.0.5:80"},
+		)
+	}
+	// The database keeps one connection open.
+	evs = append(evs, &events.Event{Type: events.EventConnect, Timestamp: ms, LatencyNS: ms, Target: "10.0.0.9:5432"})
+	for i := uint64(0); i < 50; i++ {
+		evs = append(evs, &events.Event{Type: events.EventTCPSend, Timestamp: (i + 2) * ms, Target: "10.0.0.9:5432"})
+	}
+
+	got := AnalyzeConnectionChurn(evs)
+	if len(got) != 2 {
+		t.Fatalf("churn = %+v", got)
+	}
+	api, db := got[0], got[1]
+	if api.Target != "10.0.0.5:80" || api.Connects != 30 || api.FreshRatio != 1 || !api.Excessive {
+		t.Errorf("api = %+v", api)
+	}
+	if api.SetupCost != 4*time.Millisecond || api.Cost != 29*4*time.Millisecond {
+		t.Errorf("api setup = %v, cost = %v", api.SetupCost, api.Cost)
+	}
+	if db.Connects != 1 || db.FreshRatio != 0.02 || db.Excessive || db.Cost != 0 {
+		t.Errorf("db = %+v", db)
+	}
+	// Without a handshake seen the connect call is the setup cost.
+	if db.SetupCost != time.Millisecond {
+		t.Errorf("db setup = %v", db.SetupCost)
+	}
+}
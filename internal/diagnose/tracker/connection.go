@@ -17,15 +17,23 @@ type ConnectionInfo struct {
 	RecvCount    int
 	TotalLatency time.Duration
 	LastActivity time.Time
+	// Connects counts the successful connects to Target, and Handshakes
+	// the SYN_SENT to ESTABLISHED transitions HandshakeTime adds up.
+	Connects      int
+	Handshakes    int
+	HandshakeTime time.Duration
+	ConnectTotal  time.Duration
 }
 
 type ConnectionTracker struct {
 	connections map[string]*ConnectionInfo
+	synSent     map[string]time.Time
 }
 
 func NewConnectionTracker() *ConnectionTracker {
 	return &ConnectionTracker{
 		connections: make(map[string]*ConnectionInfo),
+		synSent:     make(map[string]time.Time),
 	}
 }
 
@@ -37,15 +45,38 @@ func (ct *ConnectionTracker) ProcessEvent(event *events.Event) {
 	switch event.Type {
 	case events.EventConnect:
 		if event.Error == 0 && event.Target != "" {
-			if conn, exists := ct.connections[event.Target]; exists {
+			conn, exists := ct.connections[event.Target]
+			if exists {
 				conn.ConnectTime = event.TimestampTime()
 				conn.LastActivity = event.TimestampTime()
 			} else {
-				ct.connections[event.Target] = &ConnectionInfo{
+				conn = &ConnectionInfo{
 					Target:       event.Target,
 					ConnectTime:  event.TimestampTime(),
 					LastActivity: event.TimestampTime(),
 				}
+				ct.connections[event.Target] = conn
+			}
+			conn.Connects++
+			conn.ConnectTotal += event.Latency()
+		}
+
+	case events.EventTCPState:
+		if event.Target == "" {
+			return
+		}
+		switch event.TCPState {
+		case tcpSynSent:
+			ct.synSent[event.Target] = event.TimestampTime()
+		case tcpEstablished:
+			start, ok := ct.synSent[event.Target]
+			if !ok {
+				return
+			}
+			delete(ct.synSent, event.Target)
+			if conn, exists := ct.connections[event.Target]; exists {
+				conn.Handshakes++
+				conn.HandshakeTime += event.TimestampTime().Sub(start)
 			}
 		}
 
@@ -93,6 +124,8 @@ func (ct *ConnectionTracker) GetConnectionSummary() []ConnectionSummary {
 			TotalOps:     totalOps,
 			AvgLatency:   avgLatency,
 			LastActivity: conn.LastActivity,
+			Connects:     conn.Connects,
+			SetupCost:    conn.setupCost(),
 		})
 	}
 	sort.Slice(summaries, func(i, j int) bool {
@@ -101,6 +134,22 @@ func (ct *ConnectionTracker) GetConnectionSummary() []ConnectionSummary {
 	return summaries
 }
 
+// TCP states of the sock/inet_sock_set_state tracepoint.
+const (
+	tcpEstablished = 1
+	tcpSynSent     = 2
+)
+
+func (c *ConnectionInfo) setupCost() time.Duration {
+	if c.Handshakes > 0 {
+		return c.HandshakeTime / time.Duration(c.Handshakes)
+	}
+	if c.Connects > 0 {
+		return c.ConnectTotal / time.Duration(c.Connects)
+	}
+	return 0
+}
+
 type ConnectionSummary struct {
 	Target       string
 	ConnectTime  time.Time
@@ -109,6 +158,10 @@ type ConnectionSummary struct {
 	TotalOps     int
 	AvgLatency   time.Duration
 	LastActivity time.Time
+	Connects     int
+	// SetupCost is the average time one fresh connection took to set up:
+	// the handshake when it was seen, else the connect call itself.
+	SetupCost time.Duration
 }
 
 func GenerateConnectionCorrelation(events []*events.Event) string {
@@ -36,6 +36,10 @@ type Span struct {
 	Events       []*events.Event
 	Attributes   map[string]string
 	Error        bool
+
+	// operation marks a span StartOperation added: it has no events of its
+	// own and stretches over the spans under it.
+	operation bool
 }
 
 type ServiceInfo struct {
@@ -99,6 +103,86 @@ func (tt *TraceTracker) ProcessEvent(event *events.Event, k8sContext interface{}
 	if k8sContext != nil {
 		tt.updateServiceInfo(trace, span, k8sContext)
 	}
+	widenOperation(trace, span)
+}
+
+// StartOperation adds a span without events of its own that groups the
+// spans of one logical operation, such as the DNS lookup, connect, TLS
+// handshake and request of one outbound call. Spans whose parent it is
+// stretch it over their events.
+func (tt *TraceTracker) StartOperation(traceID, spanID, parentSpanID, name string, attrs map[string]string) {
+	if traceID == "" || spanID == "" {
+		return
+	}
+	tt.mu.Lock()
+	trace, exists := tt.traces[traceID]
+	if !exists {
+		now := time.Now()
+		trace = &Trace{
+			TraceID:   traceID,
+			Spans:     make([]*Span, 0),
+			StartTime: now,
+			EndTime:   now,
+			Services:  make(map[string]*ServiceInfo),
+		}
+		tt.traces[traceID] = trace
+	}
+	tt.mu.Unlock()
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	for _, span := range trace.Spans {
+		if span.SpanID == spanID {
+			return
+		}
+	}
+	span := &Span{
+		TraceID:      traceID,
+		SpanID:       spanID,
+		ParentSpanID: parentSpanID,
+		Operation:    name,
+		Events:       make([]*events.Event, 0),
+		Attributes:   make(map[string]string, len(attrs)),
+		operation:    true,
+	}
+	for k, v := range attrs {
+		span.Attributes[k] = v
+	}
+	trace.lastUpdate = time.Now()
+	trace.Spans = append(trace.Spans, span)
+}
+
+// widenOperation stretches the operation span child belongs to, if any,
+// over child, and marks it failed when child failed.
+func widenOperation(trace *Trace, child *Span) {
+	if child.ParentSpanID == "" {
+		return
+	}
+	for _, op := range trace.Spans {
+		if op.SpanID != child.ParentSpanID || !op.operation {
+			continue
+		}
+		end := child.StartTime.Add(child.Duration)
+		if op.StartTime.IsZero() {
+			op.StartTime, op.Duration = child.StartTime, child.Duration
+		} else {
+			opEnd := op.StartTime.Add(op.Duration)
+			if child.StartTime.Before(op.StartTime) {
+				op.StartTime = child.StartTime
+			}
+			if end.After(opEnd) {
+				opEnd = end
+			}
+			op.Duration = opEnd.Sub(op.StartTime)
+		}
+		if op.StartTime.Before(trace.StartTime) {
+			trace.StartTime = op.StartTime
+		}
+		if child.Error {
+			op.Error = true
+		}
+		return
+	}
 }
 
 func (tt *TraceTracker) findOrCreateSpan(trace *Trace, event *events.Event) *Span {
@@ -278,6 +362,14 @@ func (tt *TraceTracker) SnapshotAll() []*Trace {
 	return out
 }
 
+// Clone returns a deep copy of the trace, for consumers such as the
+// exporters that each modify spans of their own copy.
+func (t *Trace) Clone() *Trace {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return cloneTraceLocked(t, 0)
+}
+
 // cloneTraceLocked deep-copies a trace, including only spans from index
 // fromSpan on.
 func cloneTraceLocked(trace *Trace, fromSpan int) *Trace {
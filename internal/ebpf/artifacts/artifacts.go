@@ -0,0 +1,259 @@
+// Package artifacts fetches kernel-specific BTF and BPF objects from an HTTP
+// mirror at startup, so agent images need not bake in every kernel variant.
+// Every artifact is pinned by SHA256 in a checksum file deployed alongside
+// podtrace; nothing unpinned is downloaded, and nothing that fails
+// verification is used. Verified artifacts are cached on disk, so a restart
+// works while the mirror is unreachable.
+package artifacts
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"go.uber.org/zap"
+	"golang.org/x/sys/unix"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/hostfs"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// maxArtifactBytes bounds a single download; a vmlinux BTF is a few MiB.
+const maxArtifactBytes = 64 << 20
+
+// kernelBTFPath is where a kernel built with CONFIG_DEBUG_INFO_BTF exposes
+// its own BTF; when present nothing needs fetching.
+var kernelBTFPath = "/sys/kernel/btf/vmlinux"
+
+// BTFPath is the mirror-relative path of the BTF for a kernel release.
+func BTFPath(arch, kernelRelease string) string {
+	return path.Join("btf", arch, kernelRelease+".btf")
+}
+
+// BPFObjectPath is the mirror-relative path of the BPF object built for a
+// podtrace version. Objects are keyed by version because their event layout
+// must match the binary decoding it.
+func BPFObjectPath(arch, version string) string {
+	return path.Join("bpf", version, "podtrace."+arch+".bpf.o")
+}
+
+// ParseChecksums reads a SHA256SUMS-style file: "<hex digest>  <path>" per
+// line, with an optional '*' before the path and '#' comments.
+func ParseChecksums(data []byte) (map[string]string, error) {
+	sums := make(map[string]string)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("checksums line %d: want \"<sha256> <path>\"", n)
+		}
+		sum := strings.ToLower(fields[0])
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			return nil, fmt.Errorf("checksums line %d: %q is not a SHA256 digest", n, fields[0])
+		}
+		name := path.Clean(strings.TrimPrefix(fields[1], "*"))
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return nil, fmt.Errorf("checksums line %d: path %q escapes the mirror", n, fields[1])
+		}
+		sums[name] = sum
+	}
+	return sums, sc.Err()
+}
+
+// Fetcher resolves pinned artifacts through the cache and the mirror.
+type Fetcher struct {
+	// Mirror is the base URL; empty means the cache is the only source.
+	Mirror    string
+	CacheDir  string
+	Checksums map[string]string
+	Client    *http.Client
+}
+
+// ErrNotPinned is returned for an artifact the checksum file does not list.
+var ErrNotPinned = errors.New("no pinned checksum")
+
+// Fetch returns the local path of the artifact at the mirror-relative name:
+// the cached copy when it still matches its checksum, otherwise a fresh
+// download that is verified before it is cached.
+func (f *Fetcher) Fetch(ctx context.Context, name string) (string, error) {
+	want, ok := f.Checksums[name]
+	if !ok {
+		return "", fmt.Errorf("%s: %w", name, ErrNotPinned)
+	}
+	local := filepath.Join(f.CacheDir, filepath.FromSlash(name))
+	if data, err := hostfs.ReadFile(local); err == nil {
+		if digest(data) == want {
+			return local, nil
+		}
+		logger.Warn("Cached artifact does not match its pinned checksum; refetching", zap.String("path", local))
+	}
+	if f.Mirror == "" {
+		return "", fmt.Errorf("%s: not cached and no mirror configured", name)
+	}
+
+	data, err := f.download(ctx, strings.TrimSuffix(f.Mirror, "/")+"/"+name)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	if got := digest(data); got != want {
+		return "", fmt.Errorf("%s: checksum mismatch: got sha256 %s, pinned %s", name, got, want)
+	}
+	if err := writeAtomic(local, data); err != nil {
+		return "", fmt.Errorf("%s: cache: %w", name, err)
+	}
+	return local, nil
+}
+
+func (f *Fetcher) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", config.GetUserAgent())
+	client := f.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxArtifactBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxArtifactBytes {
+		return nil, fmt.Errorf("GET %s: larger than %d MiB", url, maxArtifactBytes>>20)
+	}
+	return data, nil
+}
+
+func digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func writeAtomic(file string, data []byte) error {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, ".fetch-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0o644); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// Prepare fetches what this host needs according to config.Artifact* and
+// points config.BTFFilePath and config.BPFObjectPath at the results:
+//
+//   - the BTF for the running kernel, unless the kernel exposes its own or
+//     PODTRACE_BTF_FILE is set;
+//   - a BPF object for this podtrace version, if the checksum file pins one
+//     and PODTRACE_BPF_OBJECT is not set.
+//
+// It does nothing without a checksum file. A failed fetch leaves the
+// built-in defaults in place and is returned for the caller to log.
+func Prepare(ctx context.Context) error {
+	if config.ArtifactChecksums == "" {
+		if config.ArtifactMirror != "" {
+			return errors.New("PODTRACE_ARTIFACT_MIRROR is set without PODTRACE_ARTIFACT_CHECKSUMS; refusing to fetch unpinned artifacts")
+		}
+		return nil
+	}
+	checksums, err := filepath.Abs(config.ArtifactChecksums)
+	if err != nil {
+		return fmt.Errorf("artifact checksums path: %w", err)
+	}
+	data, err := hostfs.ReadFile(checksums)
+	if err != nil {
+		return fmt.Errorf("read artifact checksums: %w", err)
+	}
+	sums, err := ParseChecksums(data)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", config.ArtifactChecksums, err)
+	}
+	cacheDir, err := filepath.Abs(config.ArtifactCacheDir)
+	if err != nil {
+		return fmt.Errorf("artifact cache path: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, config.ArtifactFetchTimeout)
+	defer cancel()
+	f := &Fetcher{
+		Mirror:    config.ArtifactMirror,
+		CacheDir:  cacheDir,
+		Checksums: sums,
+		Client:    &http.Client{Timeout: config.ArtifactFetchTimeout},
+	}
+
+	var errs []error
+	if config.BTFFilePath == "" && !fileExists(kernelBTFPath) {
+		release := kernelRelease()
+		if release == "" {
+			errs = append(errs, errors.New("kernel release unknown; cannot select BTF"))
+		} else if local, err := f.Fetch(ctx, BTFPath(runtime.GOARCH, release)); err != nil {
+			errs = append(errs, fmt.Errorf("BTF: %w", err))
+		} else {
+			config.BTFFilePath = local
+			logger.Info("Using fetched kernel BTF", zap.String("kernel", release), zap.String("path", local))
+		}
+	}
+	if config.BPFObjectPath == config.DefaultBPFObjectPath() {
+		name := BPFObjectPath(runtime.GOARCH, config.GetVersion())
+		if _, pinned := sums[name]; pinned {
+			if local, err := f.Fetch(ctx, name); err != nil {
+				errs = append(errs, fmt.Errorf("BPF object: %w", err))
+			} else {
+				config.BPFObjectPath = local
+				logger.Info("Using fetched BPF object", zap.String("path", local))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func fileExists(p string) bool {
+	_, err := os.Stat(p)
+	return err == nil
+}
+
+func kernelRelease() string {
+	var u unix.Utsname
+	if err := unix.Uname(&u); err != nil {
+		return ""
+	}
+	return unix.ByteSliceToString(u.Release[:])
+}
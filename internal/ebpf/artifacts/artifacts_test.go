@@ -0,0 +1,141 @@
+package artifacts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+func mirror(t *testing.T, files map[string]string) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		data, ok := files[strings.TrimPrefix(r.URL.Path, "/")]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(data))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+func TestParseChecksums(t *testing.T) {
+	sum := digest([]byte("btf"))
+	sums, err := ParseChecksums([]byte("# pinned\n" + strings.ToUpper(sum) + "  btf/amd64/6.1.0.btf\n" + sum + " *bpf/v1/podtrace.amd64.bpf.o\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sums["btf/amd64/6.1.0.btf"] != sum || sums["bpf/v1/podtrace.amd64.bpf.o"] != sum {
+		t.Errorf("sums = %v", sums)
+	}
+
+	for _, bad := range []string{
+		"abc  btf/x.btf\n",
+		sum + "\n",
+		sum + "  ../etc/passwd\n",
+		sum + "  /etc/passwd\n",
+	} {
+		if _, err := ParseChecksums([]byte(bad)); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestFetchVerifiesAndCaches(t *testing.T) {
+	name := BTFPath("amd64", "6.1.0")
+	srv, hits := mirror(t, map[string]string{name: "btf-data"})
+	f := &Fetcher{Mirror: srv.URL + "/", CacheDir: t.TempDir(), Checksums: map[string]string{name: digest([]byte("btf-data"))}}
+
+	local, err := f.Fetch(context.Background(), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(local); string(data) != "btf-data" {
+		t.Errorf("cached %q", data)
+	}
+
+	// Offline: the verified copy is served from the cache.
+	f.Mirror = ""
+	if again, err := f.Fetch(context.Background(), name); err != nil || again != local {
+		t.Errorf("offline fetch = %q, %v", again, err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("mirror hits = %d, want 1", hits.Load())
+	}
+}
+
+func TestFetchRejectsUnverified(t *testing.T) {
+	name := BTFPath("amd64", "6.1.0")
+	srv, _ := mirror(t, map[string]string{name: "tampered"})
+	dir := t.TempDir()
+	f := &Fetcher{Mirror: srv.URL, CacheDir: dir, Checksums: map[string]string{name: digest([]byte("btf-data"))}}
+
+	if _, err := f.Fetch(context.Background(), name); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("err = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(name))); !os.IsNotExist(err) {
+		t.Error("unverified artifact was cached")
+	}
+	if _, err := f.Fetch(context.Background(), "btf/amd64/other.btf"); !errors.Is(err, ErrNotPinned) {
+		t.Errorf("err = %v, want ErrNotPinned", err)
+	}
+	f.Checksums["btf/amd64/missing.btf"] = digest(nil)
+	if _, err := f.Fetch(context.Background(), "btf/amd64/missing.btf"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("err = %v", err)
+	}
+}
+
+func TestPrepare(t *testing.T) {
+	release := kernelRelease()
+	if release == "" {
+		t.Skip("uname unavailable")
+	}
+	btfName := BTFPath(runtime.GOARCH, release)
+	objName := BPFObjectPath(runtime.GOARCH, config.GetVersion())
+	srv, _ := mirror(t, map[string]string{btfName: "btf", objName: "obj"})
+
+	dir := t.TempDir()
+	sumsFile := filepath.Join(dir, "SHA256SUMS")
+	content := fmt.Sprintf("%s  %s\n%s  %s\n", digest([]byte("btf")), btfName, digest([]byte("obj")), objName)
+	if err := os.WriteFile(sumsFile, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	origMirror, origSums, origCache := config.ArtifactMirror, config.ArtifactChecksums, config.ArtifactCacheDir
+	origBTF, origObj, origKernelBTF := config.BTFFilePath, config.BPFObjectPath, kernelBTFPath
+	t.Cleanup(func() {
+		config.ArtifactMirror, config.ArtifactChecksums, config.ArtifactCacheDir = origMirror, origSums, origCache
+		config.BTFFilePath, config.BPFObjectPath, kernelBTFPath = origBTF, origObj, origKernelBTF
+	})
+	config.ArtifactMirror, config.ArtifactChecksums, config.ArtifactCacheDir = srv.URL, sumsFile, filepath.Join(dir, "cache")
+	config.BTFFilePath, config.BPFObjectPath = "", config.DefaultBPFObjectPath()
+	kernelBTFPath = filepath.Join(dir, "no-vmlinux")
+
+	if err := Prepare(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if config.BTFFilePath != filepath.Join(dir, "cache", filepath.FromSlash(btfName)) {
+		t.Errorf("BTFFilePath = %q", config.BTFFilePath)
+	}
+	if config.BPFObjectPath != filepath.Join(dir, "cache", filepath.FromSlash(objName)) {
+		t.Errorf("BPFObjectPath = %q", config.BPFObjectPath)
+	}
+
+	config.ArtifactChecksums = ""
+	if err := Prepare(context.Background()); err == nil {
+		t.Error("a mirror without checksums must be refused")
+	}
+}
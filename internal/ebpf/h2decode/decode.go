@@ -278,14 +278,20 @@ func (d *Decoder) decodeBlockLocked(st *dirState, rec *RawRecord, block []byte)
 		d.partialBlocks++
 	}
 
-	var method, path, status, traceparent, grpcStatus string
+	var method, path, authority, status, traceparent, grpcStatus string
 	var extra []string
+	sigv4 := false
 	for _, f := range fields {
+		if strings.HasPrefix(strings.ToLower(f.Name), "x-amz-") {
+			sigv4 = true
+		}
 		switch {
 		case f.Name == ":method":
 			method = f.Value
 		case f.Name == ":path":
 			path = f.Value
+		case f.Name == ":authority":
+			authority = f.Value
 		case f.Name == ":status":
 			status = f.Value
 		case f.Name == "grpc-status":
@@ -305,6 +311,11 @@ func (d *Decoder) decodeBlockLocked(st *dirState, rec *RawRecord, block []byte)
 	switch {
 	case path != "":
 		st.role = roleRequest
+		if sigv4 {
+			// Same marker the HTTP/1.x probe emits for SigV4-signed
+			// (S3-style) requests.
+			extra = append(extra, "sigv4: "+authority)
+		}
 		return d.buildRequestLocked(rec, method, path, traceparent, extra)
 	case status != "":
 		st.role = roleResponse
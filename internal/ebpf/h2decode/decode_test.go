@@ -249,6 +249,18 @@ func TestTraceparentCaptured(t *testing.T) {
 	}
 }
 
+func TestSigV4RequestMarked(t *testing.T) {
+	d := New()
+	enc := newBlockEncoder()
+	block := enc.encode(reqFields("GET", "/?list-type=2",
+		hf("x-amz-date", "20260101T000000Z"),
+		hf("x-amz-content-sha256", "UNSIGNED-PAYLOAD"))...)
+	ev := singleEvent(t, d.Ingest(rec(43, DirEgress, 0, 1, block)))
+	if ev.Details != "sigv4: demo.svc" {
+		t.Fatalf("details = %q, want sigv4 line", ev.Details)
+	}
+}
+
 func TestServerSideInboundRequest(t *testing.T) {
 	d := New()
 	enc := newBlockEncoder()
@@ -193,7 +193,39 @@ func ParseEvent(data []byte) *events.Event {
 		Target        [128]byte
 		Details       [128]byte
 		NetNsID       uint32
+		MntID         uint32
+		DNSServerIP   uint32
+		DNSTransport  uint8
+		_             [3]uint8
+		DNSServerIP6  [16]byte
+		PeerSaddr     uint32
+		PeerDaddr     uint32
+		PeerSport     uint16
+		PeerDport     uint16
+		PeerFamily    uint8
+		_             [3]uint8
+		PeerSaddr6    [16]byte
+		PeerDaddr6    [16]byte
+		CorrelationID uint64
+	}
+
+	type rawEventV9 struct {
+		Timestamp     uint64
+		PID           uint32
+		Type          uint32
+		LatencyNS     uint64
+		Error         int32
+		_             uint32
+		Bytes         uint64
+		TCPState      uint32
 		_             uint32
+		StackKey      uint64
+		CgroupID      uint64
+		Comm          [16]byte
+		Target        [128]byte
+		Details       [128]byte
+		NetNsID       uint32
+		MntID         uint32
 		DNSServerIP   uint32
 		DNSTransport  uint8
 		_             [3]uint8
@@ -207,8 +239,10 @@ func ParseEvent(data []byte) *events.Event {
 		PeerSaddr6    [16]byte
 		PeerDaddr6    [16]byte
 		CorrelationID uint64
+		SockID        uint64
 	}
 
+	expectedV9 := int(unsafe.Sizeof(rawEventV9{}))
 	expectedV8 := int(unsafe.Sizeof(rawEventV8{}))
 	expectedV7 := int(unsafe.Sizeof(rawEventV7{}))
 	expectedV6 := int(unsafe.Sizeof(rawEventV6{}))
@@ -226,6 +260,7 @@ func ParseEvent(data []byte) *events.Event {
 	event.Stack = nil
 	event.CgroupID = 0
 	event.NetNsID = 0
+	event.MntID = 0
 	event.DNSServerIP = 0
 	event.DNSTransport = 0
 	event.DNSServerIP6 = [16]byte{}
@@ -234,6 +269,38 @@ func ParseEvent(data []byte) *events.Event {
 	event.PeerSrcPort = 0
 	event.PeerDstPort = 0
 	event.CorrelationID = 0
+	event.SockID = 0
+
+	if len(data) >= expectedV9 {
+		var e rawEventV9
+		if err := binaryRead(bytes.NewReader(data[:expectedV9]), binary.LittleEndian, &e); err != nil {
+			return nil
+		}
+		event.Timestamp = e.Timestamp
+		event.PID = e.PID
+		event.Type = events.EventType(e.Type)
+		event.LatencyNS = e.LatencyNS
+		event.Error = e.Error
+		event.Bytes = e.Bytes
+		event.TCPState = e.TCPState
+		event.StackKey = e.StackKey
+		event.CgroupID = e.CgroupID
+		event.ProcessName = string(bytes.TrimRight(e.Comm[:], "\x00"))
+		event.Target = decodeTarget(e.Type, e.Target[:])
+		event.Details = string(bytes.TrimRight(e.Details[:], "\x00"))
+		event.NetNsID = e.NetNsID
+		event.MntID = e.MntID
+		event.DNSServerIP = e.DNSServerIP
+		event.DNSTransport = e.DNSTransport
+		event.DNSServerIP6 = e.DNSServerIP6
+		event.PeerSrcIP = events.PeerIP(e.PeerFamily, e.PeerSaddr, e.PeerSaddr6)
+		event.PeerDstIP = events.PeerIP(e.PeerFamily, e.PeerDaddr, e.PeerDaddr6)
+		event.PeerSrcPort = e.PeerSport
+		event.PeerDstPort = e.PeerDport
+		event.CorrelationID = e.CorrelationID
+		event.SockID = e.SockID
+		return event
+	}
 
 	if len(data) >= expectedV8 {
 		var e rawEventV8
@@ -253,6 +320,7 @@ func ParseEvent(data []byte) *events.Event {
 		event.Target = decodeTarget(e.Type, e.Target[:])
 		event.Details = string(bytes.TrimRight(e.Details[:], "\x00"))
 		event.NetNsID = e.NetNsID
+		event.MntID = e.MntID
 		event.DNSServerIP = e.DNSServerIP
 		event.DNSTransport = e.DNSTransport
 		event.DNSServerIP6 = e.DNSServerIP6
@@ -433,5 +501,6 @@ func PutEvent(event *events.Event) {
 	event.Target = ""
 	event.Details = ""
 	event.NetNsID = 0
+	event.MntID = 0
 	eventPool.Put(event)
 }
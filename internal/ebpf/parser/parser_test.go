@@ -11,7 +11,7 @@ import (
 	"github.com/podtrace/podtrace/internal/events"
 )
 
-// testRawV8 mirrors the on-wire struct event layout including the V8
+// testRawV8 mirrors the V8 on-wire struct event layout including the
 // correlation_id field.
 type testRawV8 struct {
 	Timestamp     uint64
@@ -29,7 +29,7 @@ type testRawV8 struct {
 	Target        [128]byte
 	Details       [128]byte
 	NetNsID       uint32
-	_             uint32
+	MntID         uint32
 	DNSServerIP   uint32
 	DNSTransport  uint8
 	_             [3]uint8
@@ -49,7 +49,7 @@ type testRawV8 struct {
 // and still fills the V7 peer 4-tuple.
 func TestParseEvent_V8_CorrelationID(t *testing.T) {
 	if got := int(unsafe.Sizeof(testRawV8{})); got != 424 {
-		t.Fatalf("testRawV8 size = %d, want 424 (must match C sizeof(struct event))", got)
+		t.Fatalf("testRawV8 size = %d, want 424 (the V8 sizeof(struct event))", got)
 	}
 	var raw testRawV8
 	raw.Timestamp = 111
@@ -80,6 +80,60 @@ func TestParseEvent_V8_CorrelationID(t *testing.T) {
 	}
 }
 
+// TestParseEvent_V8_MntID asserts FS events carry the mount ID from the
+// slot that was padding after net_ns_id.
+func TestParseEvent_V8_MntID(t *testing.T) {
+	var raw testRawV8
+	raw.Type = uint32(events.EventWrite)
+	raw.NetNsID = 4026531840
+	raw.MntID = 1234
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, raw); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	event := ParseEvent(buf.Bytes())
+	if event == nil {
+		t.Fatal("ParseEvent returned nil for a V8 record")
+	}
+	if event.MntID != 1234 || event.NetNsID != raw.NetNsID {
+		t.Errorf("MntID, NetNsID = %d, %d; want 1234, %d", event.MntID, event.NetNsID, raw.NetNsID)
+	}
+	PutEvent(event)
+}
+
+// testRawV9 is testRawV8 with the V9 sock_id field, the current C struct
+// event.
+type testRawV9 struct {
+	testRawV8
+	SockID uint64
+}
+
+// TestParseEvent_V9_SockID asserts the V9 record decodes sock_id next to
+// the V8 fields.
+func TestParseEvent_V9_SockID(t *testing.T) {
+	if got := int(unsafe.Sizeof(testRawV9{})); got != 432 {
+		t.Fatalf("testRawV9 size = %d, want 432 (must match C sizeof(struct event))", got)
+	}
+	var raw testRawV9
+	raw.Type = uint32(events.EventConnect)
+	raw.CorrelationID = 7
+	raw.SockID = 0xffff8881_2345_6780
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, binary.LittleEndian, raw); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	event := ParseEvent(buf.Bytes())
+	if event == nil {
+		t.Fatal("ParseEvent returned nil for a V9 record")
+	}
+	if event.SockID != raw.SockID || event.CorrelationID != 7 {
+		t.Errorf("SockID, CorrelationID = %#x, %d; want %#x, 7", event.SockID, event.CorrelationID, raw.SockID)
+	}
+	PutEvent(event)
+}
+
 func TestParseEvent_ValidEvent(t *testing.T) {
 	var raw rawEvent
 	raw.Timestamp = 1234567890
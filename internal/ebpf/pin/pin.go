@@ -0,0 +1,233 @@
+// Package pin hands the maps of a running tracer from a privileged loader
+// to an unprivileged reader. The loader pins them under a per-session
+// directory on bpffs and writes a manifest next to it in a regular state
+// directory, since bpffs holds nothing but BPF objects; the reader opens
+// the pinned maps by path, which needs no capability once the pins are
+// readable by its group.
+package pin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// manifestVersion is bumped whenever the set or layout of the pinned maps
+// changes, so a reader never consumes maps it would misparse.
+const manifestVersion = 1
+
+// ReaderMaps are the maps a reader consumes: the main event ring buffer and
+// the user stacks its events point into.
+var ReaderMaps = []string{"events", "stack_traces"}
+
+var sessionName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]{0,62}$`)
+
+// Manifest describes one pinned session.
+type Manifest struct {
+	Version   int       `json:"version"`
+	Session   string    `json:"session"`
+	PinDir    string    `json:"pin_dir"`
+	LoaderPID int       `json:"loader_pid"`
+	CreatedAt time.Time `json:"created_at"`
+	// Cgroups are the cgroups the loader filters on; the reader cannot
+	// change them.
+	Cgroups []string `json:"cgroups"`
+	Maps    []string `json:"maps"`
+}
+
+// MapPath returns where the map name is pinned.
+func (m *Manifest) MapPath(name string) string {
+	return filepath.Join(m.PinDir, name)
+}
+
+// Pinnable is what Publish needs of a map; *ebpf.Map implements it.
+type Pinnable interface {
+	Pin(fileName string) error
+	Unpin() error
+}
+
+// Session is where one session is pinned and described.
+type Session struct {
+	Name     string
+	PinDir   string
+	StateDir string
+}
+
+// NewSession validates name, which becomes a path element in both
+// directories.
+func NewSession(pinBase, stateDir, name string) (Session, error) {
+	if !sessionName.MatchString(name) {
+		return Session{}, fmt.Errorf("invalid session name %q: use letters, digits, '.', '_' and '-'", name)
+	}
+	return Session{Name: name, PinDir: filepath.Join(pinBase, name), StateDir: stateDir}, nil
+}
+
+func (s Session) manifestPath() string {
+	return filepath.Join(s.StateDir, s.Name+".json")
+}
+
+// Publish pins maps under the session directory and then writes its
+// manifest, so a reader that finds the manifest finds every map. With gid
+// >= 0 the pins are handed to that group, read-write, for the reader to
+// open them; otherwise only root can. A session left by a loader that is
+// still running is refused.
+func Publish(s Session, maps map[string]Pinnable, cgroups []string, gid int) (*Manifest, error) {
+	if old, err := Open(s); err == nil {
+		if processAlive(old.LoaderPID) {
+			return nil, fmt.Errorf("session %q is still served by loader pid %d", s.Name, old.LoaderPID)
+		}
+		if err := Remove(s); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.MkdirAll(s.PinDir, 0o750); err != nil {
+		return nil, fmt.Errorf("create pin directory: %w", err)
+	}
+	if err := os.MkdirAll(s.StateDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create state directory: %w", err)
+	}
+	m := &Manifest{
+		Version:   manifestVersion,
+		Session:   s.Name,
+		PinDir:    s.PinDir,
+		LoaderPID: os.Getpid(),
+		CreatedAt: time.Now().UTC(),
+		Cgroups:   append([]string(nil), cgroups...),
+	}
+	var pinned []Pinnable
+	fail := func(err error) (*Manifest, error) {
+		for _, p := range pinned {
+			_ = p.Unpin()
+		}
+		_ = os.Remove(s.PinDir)
+		return nil, err
+	}
+	for _, name := range ReaderMaps {
+		mp, ok := maps[name]
+		if !ok || mp == nil {
+			continue
+		}
+		path := m.MapPath(name)
+		if err := mp.Pin(path); err != nil {
+			return fail(fmt.Errorf("pin map %s: %w", name, err))
+		}
+		pinned = append(pinned, mp)
+		if err := grant(path, gid, 0o660); err != nil {
+			return fail(err)
+		}
+		m.Maps = append(m.Maps, name)
+	}
+	if len(m.Maps) == 0 || m.Maps[0] != "events" {
+		return fail(errors.New("the events ring buffer is not available to pin"))
+	}
+	if err := grant(s.PinDir, gid, 0o750); err != nil {
+		return fail(err)
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fail(err)
+	}
+	tmp := s.manifestPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fail(fmt.Errorf("write manifest: %w", err))
+	}
+	if err := os.Rename(tmp, s.manifestPath()); err != nil {
+		_ = os.Remove(tmp)
+		return fail(fmt.Errorf("write manifest: %w", err))
+	}
+	return m, nil
+}
+
+func grant(path string, gid, mode int) error {
+	if gid < 0 {
+		return nil
+	}
+	if err := os.Chown(path, -1, gid); err != nil {
+		return fmt.Errorf("hand %s to group %d: %w", path, gid, err)
+	}
+	if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+		return fmt.Errorf("chmod %s: %w", path, err)
+	}
+	return nil
+}
+
+// Open reads the manifest of a session.
+func Open(s Session) (*Manifest, error) {
+	data, err := os.ReadFile(s.manifestPath())
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no pinned session %q in %s: start `podtrace loader --session %s` first: %w", s.Name, s.StateDir, s.Name, err)
+		}
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("manifest of session %q: %w", s.Name, err)
+	}
+	if m.Version != manifestVersion {
+		return nil, fmt.Errorf("session %q was pinned by an incompatible loader (manifest version %d, want %d)", s.Name, m.Version, manifestVersion)
+	}
+	return &m, nil
+}
+
+// Exists reports whether the session's manifest is still in place; the
+// loader removes it when it stops.
+func Exists(s Session) bool {
+	_, err := os.Stat(s.manifestPath())
+	return err == nil
+}
+
+// Remove unpins the maps of a session and deletes its manifest. Readers
+// that have the maps open keep them until they close them.
+func Remove(s Session) error {
+	if err := os.Remove(s.manifestPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.RemoveAll(s.PinDir); err != nil {
+		return fmt.Errorf("unpin %s: %w", s.PinDir, err)
+	}
+	return nil
+}
+
+// Reap removes the sessions in stateDir whose loader is gone, after a
+// crash or a SIGKILL left their pins behind, and returns their names.
+func Reap(pinBase, stateDir string) []string {
+	entries, err := os.ReadDir(stateDir)
+	if err != nil {
+		return nil
+	}
+	var reaped []string
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		s, err := NewSession(pinBase, stateDir, name)
+		if err != nil {
+			continue
+		}
+		m, err := Open(s)
+		if err == nil && processAlive(m.LoaderPID) {
+			continue
+		}
+		if Remove(s) == nil {
+			reaped = append(reaped, name)
+		}
+	}
+	return reaped
+}
+
+// processAlive is overridden in tests.
+var processAlive = func(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,153 @@
+package pin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type fakeMap struct {
+	path  string
+	err   error
+	unpin int
+}
+
+func (f *fakeMap) Pin(fileName string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.path = fileName
+	return os.WriteFile(fileName, nil, 0o600)
+}
+
+func (f *fakeMap) Unpin() error {
+	f.unpin++
+	return os.Remove(f.path)
+}
+
+func testSession(t *testing.T, name string) Session {
+	t.Helper()
+	base := t.TempDir()
+	s, err := NewSession(filepath.Join(base, "bpf"), filepath.Join(base, "run"), name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func stubAlive(t *testing.T, alive bool) {
+	t.Helper()
+	orig := processAlive
+	processAlive = func(int) bool { return alive }
+	t.Cleanup(func() { processAlive = orig })
+}
+
+func TestNewSession_RejectsPathElements(t *testing.T) {
+	for _, name := range []string{"", "../x", "a/b", ".hidden"} {
+		if _, err := NewSession("/sys/fs/bpf/podtrace", "/run/podtrace", name); err == nil {
+			t.Errorf("NewSession(%q) accepted", name)
+		}
+	}
+}
+
+func TestPublishOpenRemove(t *testing.T) {
+	s := testSession(t, "prod-api")
+	events, stacks := &fakeMap{}, &fakeMap{}
+	m, err := Publish(s, map[string]Pinnable{"events": events, "stack_traces": stacks, "other": &fakeMap{}}, []string{"/sys/fs/cgroup/a"}, -1)
+	if err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if events.path != filepath.Join(s.PinDir, "events") || stacks.path != filepath.Join(s.PinDir, "stack_traces") {
+		t.Errorf("pinned at %q and %q", events.path, stacks.path)
+	}
+	if len(m.Maps) != 2 || m.LoaderPID != os.Getpid() {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	got, err := Open(s)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if got.MapPath("events") != events.path || len(got.Cgroups) != 1 {
+		t.Errorf("reopened manifest = %+v", got)
+	}
+
+	if err := Remove(s); err != nil {
+		t.Fatal(err)
+	}
+	if Exists(s) {
+		t.Error("manifest survived Remove")
+	}
+	if _, err := os.Stat(s.PinDir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("pin directory survived Remove: %v", err)
+	}
+}
+
+func TestPublish_RequiresEventsMap(t *testing.T) {
+	s := testSession(t, "s")
+	stacks := &fakeMap{}
+	if _, err := Publish(s, map[string]Pinnable{"stack_traces": stacks}, nil, -1); err == nil {
+		t.Fatal("expected an error without the events map")
+	}
+	if stacks.unpin != 1 || Exists(s) {
+		t.Errorf("failed publish left pins (unpin=%d) or a manifest", stacks.unpin)
+	}
+}
+
+func TestPublish_UnpinsOnFailure(t *testing.T) {
+	s := testSession(t, "s")
+	events := &fakeMap{}
+	if _, err := Publish(s, map[string]Pinnable{"events": events, "stack_traces": &fakeMap{err: errors.New("EPERM")}}, nil, -1); err == nil {
+		t.Fatal("expected the pin error")
+	}
+	if events.unpin != 1 {
+		t.Errorf("events unpinned %d times", events.unpin)
+	}
+}
+
+func TestPublish_RefusesLiveSession(t *testing.T) {
+	s := testSession(t, "s")
+	if _, err := Publish(s, map[string]Pinnable{"events": &fakeMap{}}, nil, -1); err != nil {
+		t.Fatal(err)
+	}
+	stubAlive(t, true)
+	if _, err := Publish(s, map[string]Pinnable{"events": &fakeMap{}}, nil, -1); err == nil {
+		t.Fatal("a session of a running loader was taken over")
+	}
+	stubAlive(t, false)
+	if _, err := Publish(s, map[string]Pinnable{"events": &fakeMap{}}, nil, -1); err != nil {
+		t.Fatalf("stale session not replaced: %v", err)
+	}
+}
+
+func TestOpen_VersionMismatch(t *testing.T) {
+	s := testSession(t, "s")
+	if err := os.MkdirAll(s.StateDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(s.manifestPath(), []byte(`{"version":99}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(s); err == nil {
+		t.Fatal("expected a version error")
+	}
+}
+
+func TestReap(t *testing.T) {
+	s := testSession(t, "old")
+	if _, err := Publish(s, map[string]Pinnable{"events": &fakeMap{}}, nil, -1); err != nil {
+		t.Fatal(err)
+	}
+	stubAlive(t, true)
+	if got := Reap(filepath.Dir(s.PinDir), s.StateDir); len(got) != 0 {
+		t.Errorf("reaped live session: %v", got)
+	}
+	stubAlive(t, false)
+	if got := Reap(filepath.Dir(s.PinDir), s.StateDir); len(got) != 1 || got[0] != "old" {
+		t.Errorf("Reap = %v", got)
+	}
+	if Exists(s) {
+		t.Error("stale manifest survived Reap")
+	}
+}
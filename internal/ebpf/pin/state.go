@@ -0,0 +1,141 @@
+package pin
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+)
+
+// The state of a pod's trace is pinned under <base>/<pod-uid>: its maps by
+// name, so that the collection of a restarted podtrace reuses them, and
+// its probe links under links/, so that the programs keep filling the maps
+// while no podtrace runs. A podtrace that starts takes the links over and
+// detaches them once its own are attached; one that stops cleanly unpins
+// its links and leaves the maps for the next run.
+
+const linksDir = "links"
+
+var podUID = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+
+// StateDir returns where the state of the pod uid is pinned.
+func StateDir(base, uid string) (string, error) {
+	if !podUID.MatchString(uid) {
+		return "", fmt.Errorf("invalid pod UID %q", uid)
+	}
+	return filepath.Join(base, uid), nil
+}
+
+// PinState marks the maps of spec that carry state across a restart to be
+// pinned by name: the hash and array maps holding in-flight timestamps,
+// counters and histograms. Ring buffers are drained by whoever reads them,
+// program arrays hold the programs of the old collection and the data
+// sections are rewritten on load, so those are loaded afresh.
+func PinState(spec *ebpf.CollectionSpec) {
+	for name, m := range spec.Maps {
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		switch m.Type {
+		case ebpf.RingBuf, ebpf.PerfEventArray, ebpf.ProgramArray:
+			continue
+		}
+		m.Pinning = ebpf.PinByName
+	}
+}
+
+// ClearState removes the pinned state under dir, detaching the programs of
+// a podtrace that crashed.
+func ClearState(dir string) error {
+	return os.RemoveAll(dir)
+}
+
+// ReleaseLinks detaches the links a previous podtrace pinned under dir and
+// returns how many there were.
+func ReleaseLinks(dir string) int {
+	entries, err := os.ReadDir(filepath.Join(dir, linksDir))
+	if err != nil {
+		return 0
+	}
+	n := 0
+	for _, e := range entries {
+		p := filepath.Join(dir, linksDir, e.Name())
+		l, err := link.LoadPinnedLink(p, nil)
+		if err != nil {
+			_ = os.Remove(p)
+			continue
+		}
+		_ = l.Unpin()
+		_ = l.Close()
+		n++
+	}
+	return n
+}
+
+// PinLinks pins links under dir so that they outlive this process, and
+// returns how many could be: kprobes and tracepoints attached through perf
+// events cannot be pinned before Linux 5.15 and are skipped.
+func PinLinks(dir string, links []link.Link) (int, error) {
+	ld := filepath.Join(dir, linksDir)
+	if err := os.MkdirAll(ld, 0o700); err != nil {
+		return 0, err
+	}
+	n := 0
+	for i, l := range links {
+		err := l.Pin(filepath.Join(ld, strconv.Itoa(i)))
+		if err == nil {
+			n++
+			continue
+		}
+		if !errors.Is(err, ebpf.ErrNotSupported) {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+// UnpinLinks removes the link pins under dir, so that closing the links
+// detaches them.
+func UnpinLinks(dir string, links []link.Link) {
+	for _, l := range links {
+		_ = l.Unpin()
+	}
+	_ = os.RemoveAll(filepath.Join(dir, linksDir))
+}
+
+// ReapState removes the pinned state of the pods under base that are not
+// in live, the pods that still have a cgroup on the node, and returns their
+// UIDs. Session directories, whose names are not pod UIDs, are left alone.
+func ReapState(base string, live map[string]struct{}) []string {
+	// No pod cgroup at all means they could not be listed, not that every
+	// pod is gone.
+	if len(live) == 0 {
+		return nil
+	}
+	entries, err := os.ReadDir(base)
+	if err != nil {
+		return nil
+	}
+	var reaped []string
+	for _, e := range entries {
+		uid := e.Name()
+		if !e.IsDir() || !podUID.MatchString(uid) {
+			continue
+		}
+		if _, ok := live[uid]; ok {
+			continue
+		}
+		if ClearState(filepath.Join(base, uid)) == nil {
+			reaped = append(reaped, uid)
+		}
+	}
+	sort.Strings(reaped)
+	return reaped
+}
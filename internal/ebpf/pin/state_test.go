@@ -0,0 +1,88 @@
+package pin
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cilium/ebpf"
+)
+
+const testUID = "11111111-2222-3333-4444-555555555555"
+
+func TestStateDir(t *testing.T) {
+	dir, err := StateDir("/sys/fs/bpf/podtrace", testUID)
+	if err != nil || dir != "/sys/fs/bpf/podtrace/"+testUID {
+		t.Errorf("StateDir = %q, %v", dir, err)
+	}
+	for _, uid := range []string{"", "../x", "default"} {
+		if _, err := StateDir("/sys/fs/bpf/podtrace", uid); err == nil {
+			t.Errorf("StateDir accepted %q", uid)
+		}
+	}
+}
+
+func TestPinState(t *testing.T) {
+	spec := &ebpf.CollectionSpec{Maps: map[string]*ebpf.MapSpec{
+		"start_times":  {Type: ebpf.Hash},
+		"latency_hist": {Type: ebpf.PerCPUArray},
+		"events":       {Type: ebpf.RingBuf},
+		"tail_calls":   {Type: ebpf.ProgramArray},
+		".rodata":      {Type: ebpf.Array},
+	}}
+	PinState(spec)
+	want := map[string]ebpf.PinType{
+		"start_times":  ebpf.PinByName,
+		"latency_hist": ebpf.PinByName,
+		"events":       ebpf.PinNone,
+		"tail_calls":   ebpf.PinNone,
+		".rodata":      ebpf.PinNone,
+	}
+	for name, pinning := range want {
+		if got := spec.Maps[name].Pinning; got != pinning {
+			t.Errorf("%s pinning = %v, want %v", name, got, pinning)
+		}
+	}
+}
+
+func TestReapState(t *testing.T) {
+	base := t.TempDir()
+	gone := "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee"
+	for _, name := range []string{testUID, gone, "session-a"} {
+		if err := os.MkdirAll(filepath.Join(base, name), 0o700); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := ReapState(base, nil); len(got) != 0 {
+		t.Errorf("reaped %v without a list of live pods", got)
+	}
+	got := ReapState(base, map[string]struct{}{testUID: {}})
+	if len(got) != 1 || got[0] != gone {
+		t.Errorf("ReapState = %v, want [%s]", got, gone)
+	}
+	for _, name := range []string{testUID, "session-a"} {
+		if _, err := os.Stat(filepath.Join(base, name)); err != nil {
+			t.Errorf("%s was removed: %v", name, err)
+		}
+	}
+}
+
+func TestReleaseLinks_DropsStalePins(t *testing.T) {
+	dir := t.TempDir()
+	if n := ReleaseLinks(dir); n != 0 {
+		t.Errorf("ReleaseLinks on an empty dir = %d", n)
+	}
+	stale := filepath.Join(dir, linksDir, "0")
+	if err := os.MkdirAll(filepath.Dir(stale), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(stale, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if n := ReleaseLinks(dir); n != 0 {
+		t.Errorf("ReleaseLinks = %d, want 0 for a file that is no link", n)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale link pin was not removed")
+	}
+}
@@ -2,12 +2,14 @@ package probes
 
 import (
 	"os"
+	"sync"
 	"syscall"
 )
 
 // AttachedFiles tracks library files already claimed for uprobe attachment
-// within one container attach pass.
+// within one container attach pass. It is safe for concurrent use.
 type AttachedFiles struct {
+	mu   sync.Mutex
 	seen map[attachedFileKey]struct{}
 }
 
@@ -36,6 +38,8 @@ func (a *AttachedFiles) Claim(family, path string) bool {
 		return true
 	}
 	k := attachedFileKey{family: family, dev: uint64(sys.Dev), ino: sys.Ino}
+	a.mu.Lock()
+	defer a.mu.Unlock()
 	if _, dup := a.seen[k]; dup {
 		return false
 	}
@@ -0,0 +1,47 @@
+package probes
+
+import (
+	"os"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+
+	"github.com/podtrace/podtrace/internal/system"
+)
+
+// queryEffective is BPF_F_QUERY_EFFECTIVE: include programs inherited from
+// ancestor cgroups, which is where Cilium attaches its cgroup programs.
+const queryEffective = 1
+
+// cgroupHookHolders names the foreign programs in effect on a cgroup hook,
+// tagged with their likely owner, e.g. "cil_sock4_conne (cilium)". It is
+// used to explain a failed cgroup attach; any query error yields nil.
+func cgroupHookHolders(path string, attach ebpf.AttachType) []string {
+	f, err := os.Open(path) // #nosec G304 -- path is a resolved pod cgroup directory.
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	res, err := link.QueryPrograms(link.QueryOptions{
+		Target:     int(f.Fd()),
+		Attach:     attach,
+		QueryFlags: queryEffective,
+	})
+	if err != nil {
+		return nil
+	}
+	var holders []string
+	for _, ap := range res.Programs {
+		prog, err := ebpf.NewProgramFromID(ap.ID)
+		if err != nil {
+			continue
+		}
+		info, err := prog.Info()
+		_ = prog.Close()
+		if err != nil {
+			continue
+		}
+		holders = append(holders, info.Name+" ("+system.BPFProgramOwner(info.Name)+")")
+	}
+	return holders
+}
@@ -0,0 +1,251 @@
+package probes
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v3"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/hostfs"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+// CustomUprobe is one user-defined uprobe from the --uprobes file.
+type CustomUprobe struct {
+	// Label is reported as the event target.
+	Label string `yaml:"label"`
+	// Binary is a glob matched against the full path of every executable
+	// mapping of the target process, or against its base name when the
+	// pattern has no '/' (e.g. "libpq.so*").
+	Binary string `yaml:"binary"`
+	Symbol string `yaml:"symbol"`
+	// Latency pairs entry with return and reports the call duration.
+	Latency bool `yaml:"latency"`
+}
+
+// customUprobeValue mirrors `struct custom_uprobe` in bpf/maps.h. Field sizes
+// MUST match CUSTOM_UPROBE_LABEL_LEN / CUSTOM_UPROBE_SYMBOL_LEN (136 bytes).
+type customUprobeValue struct {
+	Label  [64]byte
+	Symbol [64]byte
+	Paired uint8
+	_      [7]byte
+}
+
+const maxCustomUprobes = 64
+
+// LoadCustomUprobes reads and validates a custom uprobe file:
+//
+//	uprobes:
+//	  - label: pq-exec
+//	    binary: "libpq.so*"
+//	    symbol: PQexec
+//	    latency: true
+func LoadCustomUprobes(file string) ([]CustomUprobe, error) {
+	data, err := hostfs.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("read uprobe definitions: %w", err)
+	}
+	return parseCustomUprobes(data)
+}
+
+func parseCustomUprobes(data []byte) ([]CustomUprobe, error) {
+	var doc struct {
+		Uprobes []CustomUprobe `yaml:"uprobes"`
+	}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("parse uprobe definitions: %w", err)
+	}
+	if len(doc.Uprobes) == 0 {
+		return nil, errors.New("uprobe definitions: no entries under 'uprobes'")
+	}
+	if len(doc.Uprobes) > maxCustomUprobes {
+		return nil, fmt.Errorf("uprobe definitions: %d entries exceeds the limit of %d", len(doc.Uprobes), maxCustomUprobes)
+	}
+	for i := range doc.Uprobes {
+		u := &doc.Uprobes[i]
+		if u.Binary == "" || u.Symbol == "" {
+			return nil, fmt.Errorf("uprobe definition %d: binary and symbol are required", i+1)
+		}
+		if _, err := path.Match(u.Binary, ""); err != nil {
+			return nil, fmt.Errorf("uprobe definition %d: invalid binary pattern %q: %w", i+1, u.Binary, err)
+		}
+		if u.Label == "" {
+			u.Label = u.Symbol
+		}
+		if len(u.Label) >= 64 || len(u.Symbol) >= 64 {
+			return nil, fmt.Errorf("uprobe definition %d: label and symbol must be shorter than 64 bytes", i+1)
+		}
+	}
+	return doc.Uprobes, nil
+}
+
+// matchesBinary reports whether the mapped file containerPath matches the
+// definition's binary pattern.
+func (u CustomUprobe) matchesBinary(containerPath string) bool {
+	target := containerPath
+	if !strings.Contains(u.Binary, "/") {
+		target = path.Base(containerPath)
+	}
+	ok, _ := path.Match(u.Binary, target)
+	return ok
+}
+
+var (
+	customUprobesOnce sync.Once
+	customUprobes     []CustomUprobe
+)
+
+// configuredCustomUprobes loads config.CustomUprobesFile once. The file is
+// validated at startup, so a failure here only logs.
+func configuredCustomUprobes() []CustomUprobe {
+	customUprobesOnce.Do(func() {
+		if config.CustomUprobesFile == "" {
+			return
+		}
+		defs, err := LoadCustomUprobes(config.CustomUprobesFile)
+		if err != nil {
+			logger.Warn("Custom uprobes disabled", zap.Error(err))
+			return
+		}
+		customUprobes = defs
+	})
+	return customUprobes
+}
+
+// execMapping is one file mapped executable into the target process.
+type execMapping struct {
+	containerPath string
+	addrRange     string
+}
+
+// execMappings lists the distinct files mapped executable into pid, in
+// /proc/<pid>/maps order.
+func execMappings(pid uint32) []execMapping {
+	data, err := procfs.ReadFile(fmt.Sprintf("%d/maps", pid))
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var out []execMapping
+	for _, line := range strings.Split(string(data), "\n") {
+		parts := strings.Fields(line)
+		if len(parts) < 6 || !strings.Contains(parts[1], "x") {
+			continue
+		}
+		p := parts[5]
+		if strings.HasPrefix(p, "[") || seen[p] {
+			continue
+		}
+		seen[p] = true
+		out = append(out, execMapping{containerPath: p, addrRange: parts[0]})
+	}
+	return out
+}
+
+// hostPath resolves the mapping through pid's root, falling back to
+// /proc/<pid>/map_files for files no longer reachable by name.
+func (m execMapping) hostPath(pid uint32) string {
+	if p := fileInProcRoot(pid, m.containerPath); p != "" {
+		return p
+	}
+	return fileInProcMapFiles(pid, m.addrRange)
+}
+
+// AttachCustomUprobes attaches every user-defined uprobe whose binary pattern
+// matches a file mapped executable in pid. When the loaded BPF object predates
+// uprobe_custom, entry-only events are emitted through uprobe_usdt instead.
+func AttachCustomUprobes(coll *ebpf.Collection, pid uint32, af *AttachedFiles) []link.Link {
+	var links []link.Link
+	defs := configuredCustomUprobes()
+	if pid == 0 || len(defs) == 0 {
+		return links
+	}
+	entry := coll.Programs["uprobe_custom"]
+	ret := coll.Programs["uretprobe_custom"]
+	pmap := coll.Maps["custom_uprobes"]
+	legacy := entry == nil || pmap == nil
+	if legacy {
+		entry, ret, pmap = coll.Programs["uprobe_usdt"], nil, coll.Maps["usdt_probes"]
+	}
+	if entry == nil || pmap == nil {
+		return links
+	}
+
+	for _, c := range execMappings(pid) {
+		var matched []CustomUprobe
+		for _, d := range defs {
+			if d.matchesBinary(c.containerPath) {
+				matched = append(matched, d)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+		hostPath := c.hostPath(pid)
+		if hostPath == "" {
+			continue
+		}
+		exe, err := link.OpenExecutable(hostPath)
+		if err != nil {
+			continue
+		}
+		for _, d := range matched {
+			if !af.Claim("custom:"+d.Label, hostPath) {
+				continue
+			}
+			cookie := usdtCookieSeq.Add(1)
+			var err error
+			if legacy {
+				val := usdtProbeValue{}
+				copyCString(val.Provider[:], d.Label)
+				copyCString(val.Name[:], d.Symbol)
+				err = pmap.Update(cookie, &val, ebpf.UpdateAny)
+			} else {
+				val := customUprobeValue{}
+				copyCString(val.Label[:], d.Label)
+				copyCString(val.Symbol[:], d.Symbol)
+				if d.Latency && ret != nil {
+					val.Paired = 1
+				}
+				err = pmap.Update(cookie, &val, ebpf.UpdateAny)
+			}
+			if err != nil {
+				logger.Debug("custom uprobe map update failed", zap.String("label", d.Label), zap.Error(err))
+				continue
+			}
+			opts := &link.UprobeOptions{Cookie: cookie}
+			l, err := exe.Uprobe(d.Symbol, entry, opts)
+			if err != nil {
+				_ = pmap.Delete(cookie)
+				logger.Debug("custom uprobe not attached",
+					zap.Uint32("pid", pid), zap.String("label", d.Label), zap.String("symbol", d.Symbol),
+					zap.String("binary", c.containerPath), zap.Error(err))
+				continue
+			}
+			links = append(links, l)
+			if d.Latency && ret != nil {
+				if rl, err := exe.Uretprobe(d.Symbol, ret, opts); err == nil {
+					links = append(links, rl)
+				} else {
+					logger.Debug("custom uretprobe not attached", zap.String("label", d.Label), zap.Error(err))
+				}
+			} else if d.Latency && legacy {
+				logger.Info("Custom uprobe attached without latency pairing; the loaded BPF object predates uretprobe_custom",
+					zap.String("label", d.Label))
+			}
+			logger.Debug("custom uprobe attached",
+				zap.Uint32("pid", pid), zap.String("label", d.Label), zap.String("symbol", d.Symbol),
+				zap.String("binary", c.containerPath), zap.Bool("latency", d.Latency))
+		}
+	}
+	return links
+}
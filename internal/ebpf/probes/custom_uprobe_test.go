@@ -0,0 +1,72 @@
+package probes
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestCustomUprobeValueLayout(t *testing.T) {
+	if got := unsafe.Sizeof(customUprobeValue{}); got != 136 {
+		t.Fatalf("customUprobeValue size = %d, want 136 (must match struct custom_uprobe in bpf/maps.h)", got)
+	}
+}
+
+func TestParseCustomUprobes(t *testing.T) {
+	defs, err := parseCustomUprobes([]byte(`
+uprobes:
+  - label: pq-exec
+    binary: "libpq.so*"
+    symbol: PQexec
+    latency: true
+  - binary: /app/server
+    symbol: main.handle
+`))
+	if err != nil {
+		t.Fatalf("parseCustomUprobes: %v", err)
+	}
+	if len(defs) != 2 {
+		t.Fatalf("got %d definitions, want 2", len(defs))
+	}
+	if defs[0].Label != "pq-exec" || !defs[0].Latency {
+		t.Errorf("first definition = %+v", defs[0])
+	}
+	if defs[1].Label != "main.handle" {
+		t.Errorf("label should default to the symbol, got %q", defs[1].Label)
+	}
+}
+
+func TestParseCustomUprobes_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"empty":          "uprobes: []",
+		"missing symbol": "uprobes:\n  - binary: libc.so.6\n",
+		"missing binary": "uprobes:\n  - symbol: malloc\n",
+		"bad pattern":    "uprobes:\n  - binary: \"lib[\"\n    symbol: f\n",
+		"long label":     "uprobes:\n  - binary: a\n    symbol: f\n    label: " + strings.Repeat("x", 64) + "\n",
+		"not yaml":       "uprobes: [",
+	}
+	for name, doc := range cases {
+		if _, err := parseCustomUprobes([]byte(doc)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestCustomUprobeMatchesBinary(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"libpq.so*", "/usr/lib/x86_64-linux-gnu/libpq.so.5.15", true},
+		{"libpq.so*", "/usr/lib/libssl.so.3", false},
+		{"/app/*", "/app/server", true},
+		{"/app/*", "/opt/app/server", false},
+		{"server", "/app/server", true},
+	}
+	for _, c := range cases {
+		u := CustomUprobe{Binary: c.pattern}
+		if got := u.matchesBinary(c.path); got != c.want {
+			t.Errorf("%q vs %q = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
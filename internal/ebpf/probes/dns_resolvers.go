@@ -0,0 +1,174 @@
+package probes
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// DNS resolver probes selectable through PODTRACE_DNS_PROBES.
+const (
+	DNSProbeLibc   = "libc"   // getaddrinfo in libc
+	DNSProbeCares  = "cares"  // c-ares ares_query/ares_search/ares_getaddrinfo
+	DNSProbeGo     = "go"     // Go's net.(*Resolver).lookupIPAddr
+	DNSProbeSocket = "socket" // UDP port 53 at the socket layer
+)
+
+// goLookupIPSym is where both of Go's resolvers, pure Go and cgo, start.
+const goLookupIPSym = "net.(*Resolver).lookupIPAddr"
+
+// DNSProbeEnabled reports whether PODTRACE_DNS_PROBES selects the named
+// resolver probe.
+func DNSProbeEnabled(name string) bool {
+	for _, p := range strings.Split(config.DNSProbes, ",") {
+		if strings.EqualFold(strings.TrimSpace(p), name) {
+			return true
+		}
+	}
+	return false
+}
+
+var caresLibRe = regexp.MustCompile(`^libcares\.so(\.[0-9.]+)?$`)
+
+func isCaresLibrary(containerPath string) bool {
+	return caresLibRe.MatchString(path.Base(containerPath))
+}
+
+// AttachResolverProbes attaches the DNS probes for the resolvers pid
+// actually uses besides getaddrinfo: c-ares when libcares is mapped, and
+// Go's resolver when the executable is a Go binary.
+func AttachResolverProbes(coll *ebpf.Collection, pid uint32, af *AttachedFiles) []link.Link {
+	var links []link.Link
+	if pid == 0 {
+		return links
+	}
+	if DNSProbeEnabled(DNSProbeCares) {
+		links = append(links, attachCaresProbes(coll, pid, af)...)
+	}
+	if DNSProbeEnabled(DNSProbeGo) {
+		links = append(links, attachGoResolverProbes(coll, pid, af)...)
+	}
+	return links
+}
+
+func attachCaresProbes(coll *ebpf.Collection, pid uint32, af *AttachedFiles) []link.Link {
+	var links []link.Link
+	queryProg := coll.Programs["uprobe_ares_query"]
+	gaiProg := coll.Programs["uprobe_ares_getaddrinfo"]
+	if queryProg == nil {
+		return links
+	}
+	targets := []struct {
+		sym  string
+		prog *ebpf.Program
+	}{
+		{"ares_query", queryProg},
+		{"ares_search", queryProg},
+		{"ares_getaddrinfo", gaiProg},
+	}
+	for _, m := range execMappings(pid) {
+		if !isCaresLibrary(m.containerPath) {
+			continue
+		}
+		hostPath := m.hostPath(pid)
+		if hostPath == "" || !af.Claim("cares", hostPath) {
+			continue
+		}
+		exe, err := link.OpenExecutable(hostPath)
+		if err != nil {
+			continue
+		}
+		for _, t := range targets {
+			if t.prog == nil {
+				continue
+			}
+			// ares_getaddrinfo only exists since c-ares 1.16.
+			if l, err := exe.Uprobe(t.sym, t.prog, nil); err == nil {
+				links = append(links, l)
+			}
+		}
+		logger.Debug("c-ares DNS probes attached", zap.Uint32("pid", pid), zap.String("library", m.containerPath))
+	}
+	return links
+}
+
+// attachGoResolverProbes times lookupIPAddr with an entry uprobe and one
+// uprobe per return site, the same way as crypto/tls.(*Conn).Read.
+func attachGoResolverProbes(coll *ebpf.Collection, pid uint32, af *AttachedFiles) []link.Link {
+	var links []link.Link
+	entryProg := coll.Programs["uprobe_go_lookup_ip"]
+	retProg := coll.Programs["uprobe_go_lookup_ip_ret"]
+	if entryProg == nil || retProg == nil {
+		return links
+	}
+	exePath := findGoBinaryInProcess(pid)
+	if exePath == "" || !af.Claim("go-dns", exePath) {
+		return links
+	}
+	entryOff, retOffs, ok := goFuncReturnOffsets(exePath, goLookupIPSym)
+	if !ok {
+		// Not a Go binary, or one that never resolves names.
+		return links
+	}
+	exe, err := link.OpenExecutable(exePath)
+	if err != nil {
+		return links
+	}
+	el, err := exe.Uprobe("", entryProg, &link.UprobeOptions{Address: entryOff})
+	if err != nil {
+		logger.Debug("Go resolver entry uprobe not attached", zap.Uint32("pid", pid), zap.Error(err))
+		return links
+	}
+	links = append(links, el)
+	for _, ro := range retOffs {
+		if rl, err := exe.Uprobe("", retProg, &link.UprobeOptions{Address: ro}); err == nil {
+			links = append(links, rl)
+		}
+	}
+	logger.Debug("Go resolver DNS probes attached", zap.Uint32("pid", pid), zap.Int("ret_sites", len(links)-1))
+	return links
+}
+
+// dnsSocketProbes are the kprobes of the socket-level port-53 fallback.
+var dnsSocketProbes = []struct{ prog, symbol string }{
+	{"kprobe_dns_udp_sendmsg", "udp_sendmsg"},
+	{"kprobe_dns_udp_sendmsg", "udpv6_sendmsg"},
+	{"kprobe_dns_udp_recvmsg", "udp_recvmsg"},
+	{"kretprobe_dns_udp_recvmsg", "udp_recvmsg"},
+	{"kprobe_dns_udp_recvmsg", "udpv6_recvmsg"},
+	{"kretprobe_dns_udp_recvmsg", "udpv6_recvmsg"},
+}
+
+// AttachDNSSocketProbes attaches the socket-level port-53 fallback. It sees
+// every resolver, but not the names queried, so it is only attached for
+// pods the packet-based path could not be attached to.
+func AttachDNSSocketProbes(coll *ebpf.Collection) []link.Link {
+	var links []link.Link
+	if !DNSProbeEnabled(DNSProbeSocket) {
+		return links
+	}
+	for _, p := range dnsSocketProbes {
+		prog := coll.Programs[p.prog]
+		if prog == nil {
+			continue
+		}
+		l, err := attachKprobe(p.prog, p.symbol, prog)
+		if err != nil {
+			logger.Debug("DNS socket probe not attached",
+				zap.String("prog", p.prog), zap.String("symbol", p.symbol), zap.Error(err))
+			continue
+		}
+		links = append(links, l)
+	}
+	if len(links) > 0 {
+		logger.Info("DNS is traced at the socket layer; query names are not decoded")
+	}
+	return links
+}
@@ -0,0 +1,38 @@
+package probes
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+func TestIsCaresLibrary(t *testing.T) {
+	cases := map[string]bool{
+		"/usr/lib/x86_64-linux-gnu/libcares.so.2": true,
+		"/usr/lib/libcares.so.2.19.1":             true,
+		"/usr/lib/libcares.so":                    true,
+		"/usr/lib/libcares-static.a":              false,
+		"/usr/lib/x86_64-linux-gnu/libc.so.6":     false,
+	}
+	for p, want := range cases {
+		if got := isCaresLibrary(p); got != want {
+			t.Errorf("isCaresLibrary(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestDNSProbeEnabled(t *testing.T) {
+	old := config.DNSProbes
+	defer func() { config.DNSProbes = old }()
+
+	config.DNSProbes = "libc, Go"
+	for name, want := range map[string]bool{DNSProbeLibc: true, DNSProbeGo: true, DNSProbeCares: false, DNSProbeSocket: false} {
+		if got := DNSProbeEnabled(name); got != want {
+			t.Errorf("DNSProbeEnabled(%q) with %q = %v, want %v", name, config.DNSProbes, got, want)
+		}
+	}
+	config.DNSProbes = ""
+	if DNSProbeEnabled(DNSProbeLibc) {
+		t.Error("an empty PODTRACE_DNS_PROBES must disable every resolver probe")
+	}
+}
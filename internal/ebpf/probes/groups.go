@@ -13,10 +13,13 @@ const (
 	GroupCPU        ProbeGroup = "cpu"
 	GroupPool       ProbeGroup = "pool"
 	GroupCache      ProbeGroup = "cache"     // Redis, Memcached
-	GroupMessaging  ProbeGroup = "messaging" // Kafka
+	GroupMessaging  ProbeGroup = "messaging" // Kafka, AMQP
 	GroupFastCGI    ProbeGroup = "fastcgi"   // PHP-FPM / FastCGI unix socket probes
 	GroupCrypto     ProbeGroup = "crypto"    // AF_ALG crypto-socket detection
 	GroupUSDT       ProbeGroup = "usdt"      // USDT (.note.stapsdt) userspace probes
+	GroupCustom     ProbeGroup = "custom"    // user-defined uprobes (--uprobes)
+	GroupPython     ProbeGroup = "python"    // CPython USDT / frame-evaluation probes
+	GroupNode       ProbeGroup = "nodejs"    // libuv event-loop lag probes
 )
 
 // probeGroupMap maps each BPF program name to its ProbeGroup.
@@ -25,37 +28,66 @@ var probeGroupMap = map[string]ProbeGroup{
 	// USDT
 	"uprobe_usdt": GroupUSDT,
 
+	// Custom
+	"uprobe_custom":    GroupCustom,
+	"uretprobe_custom": GroupCustom,
+
+	// Python
+	"uprobe_py_function_entry":  GroupPython,
+	"uprobe_py_function_return": GroupPython,
+	"uprobe_py_gc_start":        GroupPython,
+	"uprobe_py_gc_done":         GroupPython,
+	"uprobe_py_eval_frame":      GroupPython,
+	"uretprobe_py_eval_frame":   GroupPython,
+
+	// Node.js
+	"uprobe_uv_io_poll":    GroupNode,
+	"uretprobe_uv_io_poll": GroupNode,
+	"uretprobe_uv_run":     GroupNode,
+
+	// Resolvers other than getaddrinfo, attached with it per container.
+	"uprobe_ares_query":       GroupTLS,
+	"uprobe_ares_getaddrinfo": GroupTLS,
+	"uprobe_go_lookup_ip":     GroupTLS,
+	"uprobe_go_lookup_ip_ret": GroupTLS,
+
 	// Network
-	"kprobe_tcp_connect":             GroupNetwork,
-	"kretprobe_tcp_connect":          GroupNetwork,
-	"kprobe_tcp_v6_connect":          GroupNetwork,
-	"kretprobe_tcp_v6_connect":       GroupNetwork,
-	"kprobe_tcp_sendmsg":             GroupNetwork,
-	"kretprobe_tcp_sendmsg":          GroupNetwork,
-	"kprobe_tcp_recvmsg":             GroupNetwork,
-	"kretprobe_tcp_recvmsg":          GroupNetwork,
-	"kprobe_udp_sendmsg":             GroupNetwork,
-	"kretprobe_udp_sendmsg":          GroupNetwork,
-	"kprobe_udp_recvmsg":             GroupNetwork,
-	"kretprobe_udp_recvmsg":          GroupNetwork,
-	"tracepoint_inet_sock_set_state": GroupNetwork,
-	"tracepoint_tcp_retransmit_skb":  GroupNetwork,
-	"tracepoint_net_dev_xmit":        GroupNetwork,
+	"kprobe_tcp_connect":               GroupNetwork,
+	"kretprobe_tcp_connect":            GroupNetwork,
+	"kprobe_tcp_v6_connect":            GroupNetwork,
+	"kretprobe_tcp_v6_connect":         GroupNetwork,
+	"kprobe_tcp_sendmsg":               GroupNetwork,
+	"kretprobe_tcp_sendmsg":            GroupNetwork,
+	"kprobe_tcp_recvmsg":               GroupNetwork,
+	"kretprobe_tcp_recvmsg":            GroupNetwork,
+	"kprobe_udp_sendmsg":               GroupNetwork,
+	"kretprobe_udp_sendmsg":            GroupNetwork,
+	"kprobe_udp_recvmsg":               GroupNetwork,
+	"kretprobe_udp_recvmsg":            GroupNetwork,
+	"tracepoint_inet_sock_set_state":   GroupNetwork,
+	"tracepoint_tcp_retransmit_skb":    GroupNetwork,
+	"tracepoint_tcp_retransmit_synack": GroupNetwork,
+	"tracepoint_tcp_probe":             GroupNetwork,
+	"tracepoint_net_dev_xmit":          GroupNetwork,
 
 	// FileSystem
-	"kprobe_vfs_write":         GroupFileSystem,
-	"kretprobe_vfs_write":      GroupFileSystem,
-	"kprobe_vfs_read":          GroupFileSystem,
-	"kretprobe_vfs_read":       GroupFileSystem,
-	"kprobe_vfs_fsync":         GroupFileSystem,
-	"kretprobe_vfs_fsync":      GroupFileSystem,
-	"kprobe_do_sys_openat2":    GroupFileSystem,
-	"kretprobe_do_sys_openat2": GroupFileSystem,
-	"kprobe_vfs_unlink":        GroupFileSystem,
-	"kretprobe_vfs_unlink":     GroupFileSystem,
-	"kprobe_vfs_rename":        GroupFileSystem,
-	"kretprobe_vfs_rename":     GroupFileSystem,
-	"kprobe_close_fd":          GroupFileSystem,
+	"kprobe_vfs_write":                      GroupFileSystem,
+	"kretprobe_vfs_write":                   GroupFileSystem,
+	"kprobe_vfs_read":                       GroupFileSystem,
+	"kretprobe_vfs_read":                    GroupFileSystem,
+	"kprobe_vfs_fsync":                      GroupFileSystem,
+	"kretprobe_vfs_fsync":                   GroupFileSystem,
+	"kprobe_do_sys_openat2":                 GroupFileSystem,
+	"kretprobe_do_sys_openat2":              GroupFileSystem,
+	"kprobe_vfs_unlink":                     GroupFileSystem,
+	"kretprobe_vfs_unlink":                  GroupFileSystem,
+	"kprobe_vfs_rename":                     GroupFileSystem,
+	"kretprobe_vfs_rename":                  GroupFileSystem,
+	"kprobe_close_fd":                       GroupFileSystem,
+	"kprobe_filemap_add_folio":              GroupFileSystem,
+	"kprobe_fsnotify_insert_event":          GroupFileSystem,
+	"tracepoint_sys_exit_inotify_add_watch": GroupFileSystem,
+	"tracepoint_sys_exit_fanotify_mark":     GroupFileSystem,
 
 	// CPU
 	"tracepoint_sched_switch": GroupCPU,
@@ -63,12 +95,17 @@ var probeGroupMap = map[string]ProbeGroup{
 	"kretprobe_do_futex":      GroupCPU,
 
 	// Memory
-	"tracepoint_page_fault_user": GroupMemory,
-	"tracepoint_oom_mark_victim": GroupMemory,
+	"tracepoint_page_fault_user":                    GroupMemory,
+	"tracepoint_oom_mark_victim":                    GroupMemory,
+	"tracepoint_mm_compaction_try_to_compact_pages": GroupMemory,
+	"tracepoint_mm_compaction_begin":                GroupMemory,
+	"tracepoint_mm_compaction_end":                  GroupMemory,
+	"tracepoint_mm_collapse_huge_page":              GroupMemory,
 
 	// Process (grouped under CPU for simplicity)
 	"tracepoint_sched_process_fork": GroupCPU,
 	"tracepoint_sched_process_exec": GroupCPU,
+	"kprobe_do_coredump":            GroupCPU,
 
 	// TLS (uprobes attached separately via SetContainerID)
 	"uprobe_getaddrinfo":           GroupTLS,
@@ -117,6 +154,16 @@ var probeGroupMap = map[string]ProbeGroup{
 	"uprobe_rd_kafka_consumer_poll":    GroupMessaging,
 	"uretprobe_rd_kafka_consumer_poll": GroupMessaging,
 
+	// Kafka wire protocol (socket-level, clients without librdkafka)
+	"kprobe_kafka_tcp_sendmsg":    GroupMessaging,
+	"kprobe_kafka_tcp_recvmsg":    GroupMessaging,
+	"kretprobe_kafka_tcp_recvmsg": GroupMessaging,
+
+	// AMQP 0-9-1 (RabbitMQ, socket-level)
+	"kprobe_amqp_tcp_sendmsg":    GroupMessaging,
+	"kprobe_amqp_tcp_recvmsg":    GroupMessaging,
+	"kretprobe_amqp_tcp_recvmsg": GroupMessaging,
+
 	// FastCGI (unix socket PHP-FPM tracing)
 	"kprobe_unix_stream_recvmsg":    GroupFastCGI,
 	"kretprobe_unix_stream_recvmsg": GroupFastCGI,
@@ -136,6 +183,16 @@ var probeGroupMap = map[string]ProbeGroup{
 	"kretprobe_h2_tcp_recvmsg": GroupNetwork,
 	"kprobe_h2_tcp_close":      GroupNetwork,
 
+	// PostgreSQL wire protocol (socket-level, clients without libpq)
+	"kprobe_pg_tcp_sendmsg":    GroupDatabase,
+	"kprobe_pg_tcp_recvmsg":    GroupDatabase,
+	"kretprobe_pg_tcp_recvmsg": GroupDatabase,
+
+	// MySQL/MariaDB wire protocol (socket-level, clients without libmysqlclient)
+	"kprobe_mysql_tcp_sendmsg":    GroupDatabase,
+	"kprobe_mysql_tcp_recvmsg":    GroupDatabase,
+	"kretprobe_mysql_tcp_recvmsg": GroupDatabase,
+
 	// Crypto (AF_ALG bind detection, "Copy-Fail" vulnerability interface)
 	"tracepoint_sys_enter_bind": GroupCrypto,
 }
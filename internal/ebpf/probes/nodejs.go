@@ -0,0 +1,69 @@
+package probes
+
+import (
+	"path"
+	"regexp"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// nodeBinaryRe matches the Node.js executable and the shared libnode that
+// embedders (Electron, distro packages) link against.
+var nodeBinaryRe = regexp.MustCompile(`^(node|nodejs|libnode\.so(\.[0-9.]+)?)$`)
+
+func isNodeBinary(containerPath string) bool {
+	return nodeBinaryRe.MatchString(path.Base(containerPath))
+}
+
+// AttachNodeProbes attaches the libuv event-loop lag probes to every Node.js
+// runtime mapped into pid. uv__io_poll is an internal symbol, so binaries
+// stripped of their symbol table are skipped.
+func AttachNodeProbes(coll *ebpf.Collection, pid uint32, af *AttachedFiles) []link.Link {
+	var links []link.Link
+	if pid == 0 || !config.NodeEnabled {
+		return links
+	}
+	pollEntry := coll.Programs["uprobe_uv_io_poll"]
+	pollRet := coll.Programs["uretprobe_uv_io_poll"]
+	runRet := coll.Programs["uretprobe_uv_run"]
+	if pollEntry == nil || pollRet == nil {
+		return links
+	}
+	for _, m := range execMappings(pid) {
+		if !isNodeBinary(m.containerPath) {
+			continue
+		}
+		hostPath := m.hostPath(pid)
+		if hostPath == "" || !af.Claim("nodejs", hostPath) {
+			continue
+		}
+		exe, err := link.OpenExecutable(hostPath)
+		if err != nil {
+			continue
+		}
+		entry, err := exe.Uprobe("uv__io_poll", pollEntry, nil)
+		if err != nil {
+			logger.Debug("node event-loop probes not attached; uv__io_poll not found",
+				zap.Uint32("pid", pid), zap.String("binary", m.containerPath), zap.Error(err))
+			continue
+		}
+		ret, err := exe.Uretprobe("uv__io_poll", pollRet, nil)
+		if err != nil {
+			_ = entry.Close()
+			continue
+		}
+		links = append(links, entry, ret)
+		if runRet != nil {
+			if l, err := exe.Uretprobe("uv_run", runRet, nil); err == nil {
+				links = append(links, l)
+			}
+		}
+		logger.Debug("node event-loop probes attached", zap.Uint32("pid", pid), zap.String("binary", m.containerPath))
+	}
+	return links
+}
@@ -0,0 +1,18 @@
+package probes
+
+import "testing"
+
+func TestIsNodeBinary(t *testing.T) {
+	cases := map[string]bool{
+		"/usr/local/bin/node":             true,
+		"/usr/bin/nodejs":                 true,
+		"/usr/lib/libnode.so.108":         true,
+		"/app/node_modules/.bin/next":     false,
+		"/usr/lib/node_modules/npm/bin/x": false,
+	}
+	for p, want := range cases {
+		if got := isNodeBinary(p); got != want {
+			t.Errorf("isNodeBinary(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
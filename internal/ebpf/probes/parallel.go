@@ -0,0 +1,91 @@
+package probes
+
+import (
+	"sync"
+
+	"github.com/cilium/ebpf/link"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+// Attaching a probe is mostly waiting on the kernel (symbol lookup, perf
+// event setup, uprobe registration on the file), so independent probes are
+// attached concurrently, at most config.ProbeAttachConcurrency at a time.
+
+// attachJob attaches one kernel probe.
+type attachJob struct {
+	prog      string
+	symbol    string
+	mandatory bool
+	attach    func() (link.Link, error)
+}
+
+// attachResult is the outcome of the attachJob at the same index.
+type attachResult struct {
+	link link.Link
+	err  error
+}
+
+// attachWorkers is how many attaches run at once; 1 attaches serially.
+func attachWorkers() int {
+	if config.ProbeAttachConcurrency < 1 {
+		return 1
+	}
+	return config.ProbeAttachConcurrency
+}
+
+// runBounded calls fn(0) .. fn(n-1) on at most workers goroutines and
+// returns once all have returned.
+func runBounded(n, workers int, fn func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+	next := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				fn(i)
+			}
+		}()
+	}
+	for i := 0; i < n; i++ {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+}
+
+// runAttachJobs runs jobs concurrently and returns their results in the
+// order of jobs.
+func runAttachJobs(jobs []attachJob) []attachResult {
+	results := make([]attachResult, len(jobs))
+	runBounded(len(jobs), attachWorkers(), func(i int) {
+		l, err := jobs[i].attach()
+		results[i] = attachResult{link: l, err: err}
+	})
+	return results
+}
+
+// AttachConcurrently runs the attach functions concurrently and returns
+// their links in the order of fns. Functions that share an AttachedFiles
+// may run together; it is safe for that.
+func AttachConcurrently(fns []func() []link.Link) []link.Link {
+	out := make([][]link.Link, len(fns))
+	runBounded(len(fns), attachWorkers(), func(i int) {
+		out[i] = fns[i]()
+	})
+	var links []link.Link
+	for _, ls := range out {
+		links = append(links, ls...)
+	}
+	return links
+}
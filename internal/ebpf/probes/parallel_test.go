@@ -0,0 +1,163 @@
+package probes
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cilium/ebpf/link"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+type closeCountingLink struct {
+	link.Link
+	name   string
+	closes atomic.Int32
+}
+
+func (l *closeCountingLink) Close() error {
+	l.closes.Add(1)
+	return nil
+}
+
+func withAttachConcurrency(t *testing.T, n int) {
+	t.Helper()
+	orig := config.ProbeAttachConcurrency
+	config.ProbeAttachConcurrency = n
+	t.Cleanup(func() { config.ProbeAttachConcurrency = orig })
+}
+
+func TestRunBounded_RunsEveryIndexWithinTheBound(t *testing.T) {
+	const n, workers = 40, 4
+	var running, peak atomic.Int32
+	seen := make([]atomic.Int32, n)
+	runBounded(n, workers, func(i int) {
+		cur := running.Add(1)
+		for {
+			p := peak.Load()
+			if cur <= p || peak.CompareAndSwap(p, cur) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		seen[i].Add(1)
+		running.Add(-1)
+	})
+	for i := range seen {
+		if seen[i].Load() != 1 {
+			t.Fatalf("index %d ran %d times", i, seen[i].Load())
+		}
+	}
+	if p := peak.Load(); p > workers || p < 2 {
+		t.Errorf("peak concurrency %d, want 2..%d", p, workers)
+	}
+}
+
+func TestAttachWorkers_AtLeastOne(t *testing.T) {
+	withAttachConcurrency(t, 0)
+	if got := attachWorkers(); got != 1 {
+		t.Errorf("attachWorkers() = %d, want 1", got)
+	}
+}
+
+func TestCollectAttachResults_AggregatesMandatoryFailures(t *testing.T) {
+	withAttachConcurrency(t, 3)
+	ok := &closeCountingLink{name: "ok"}
+	jobs := []attachJob{
+		{prog: "kprobe_a", symbol: "sym_a", mandatory: true, attach: func() (link.Link, error) { return nil, errors.New("a gone") }},
+		{prog: "kprobe_b", symbol: "sym_b", mandatory: true, attach: func() (link.Link, error) { return ok, nil }},
+		{prog: "kprobe_c", symbol: "sym_c", mandatory: true, attach: func() (link.Link, error) { return nil, errors.New("c gone") }},
+		{prog: "kprobe_d", symbol: "sym_d", attach: func() (link.Link, error) { return nil, errors.New("optional") }},
+	}
+	links, err := collectAttachResults(jobs, runAttachJobs(jobs))
+	if err == nil || links != nil {
+		t.Fatalf("expected a failure, got %v, %v", links, err)
+	}
+	for _, want := range []string{"a gone", "c gone", "sym_a, sym_c"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	var pe *ProbeError
+	if !errors.As(err, &pe) {
+		t.Errorf("error does not wrap a ProbeError: %v", err)
+	}
+	if ok.closes.Load() != 1 {
+		t.Errorf("attached link closed %d times, want 1", ok.closes.Load())
+	}
+}
+
+func TestCollectAttachResults_SkipsOptionalFailures(t *testing.T) {
+	withAttachConcurrency(t, 8)
+	var jobs []attachJob
+	for i := 0; i < 10; i++ {
+		l := &closeCountingLink{name: fmt.Sprint(i)}
+		fail := i%3 == 0
+		jobs = append(jobs, attachJob{prog: fmt.Sprintf("kprobe_%d", i), attach: func() (link.Link, error) {
+			if fail {
+				return nil, errors.New("unavailable")
+			}
+			return l, nil
+		}})
+	}
+	links, err := collectAttachResults(jobs, runAttachJobs(jobs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, l := range links {
+		if i%3 == 0 {
+			if l != nil {
+				t.Errorf("job %d failed but has a link", i)
+			}
+			continue
+		}
+		if l == nil || l.(*closeCountingLink).name != fmt.Sprint(i) {
+			t.Errorf("job %d: link %v out of order", i, l)
+		}
+	}
+}
+
+func TestAttachConcurrently_KeepsOrder(t *testing.T) {
+	withAttachConcurrency(t, 4)
+	var fns []func() []link.Link
+	for i := 0; i < 12; i++ {
+		fns = append(fns, func() []link.Link {
+			time.Sleep(time.Duration(12-i) * 100 * time.Microsecond)
+			return []link.Link{&closeCountingLink{name: fmt.Sprint(i)}}
+		})
+	}
+	links := AttachConcurrently(fns)
+	if len(links) != 12 {
+		t.Fatalf("got %d links", len(links))
+	}
+	for i, l := range links {
+		if l.(*closeCountingLink).name != fmt.Sprint(i) {
+			t.Fatalf("link %d is %q", i, l.(*closeCountingLink).name)
+		}
+	}
+}
+
+func TestAttachedFiles_ConcurrentClaim(t *testing.T) {
+	af := NewAttachedFiles()
+	path := t.TempDir()
+	var claimed atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if af.Claim("tls", path) {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if claimed.Load() != 1 {
+		t.Errorf("file claimed %d times, want 1", claimed.Load())
+	}
+}
@@ -1,12 +1,15 @@
 package probes
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/link"
@@ -39,21 +42,24 @@ var mandatoryProbes = map[string]string{
 // Kernel symbol names can change across versions (e.g. do_futex renamed in 5.16+),
 // so these degrade gracefully.
 var optionalProbes = map[string]string{
-	"kprobe_udp_sendmsg":       "udp_sendmsg",
-	"kretprobe_udp_sendmsg":    "udp_sendmsg",
-	"kprobe_udp_recvmsg":       "udp_recvmsg",
-	"kretprobe_udp_recvmsg":    "udp_recvmsg",
-	"kprobe_vfs_fsync":         "vfs_fsync",
-	"kretprobe_vfs_fsync":      "vfs_fsync",
-	"kprobe_do_futex":          "do_futex",
-	"kretprobe_do_futex":       "do_futex",
-	"kprobe_do_sys_openat2":    "do_sys_openat2",
-	"kretprobe_do_sys_openat2": "do_sys_openat2",
-	"kprobe_vfs_unlink":        "vfs_unlink",
-	"kprobe_close_fd":          "close_fd",
-	"kretprobe_vfs_unlink":     "vfs_unlink",
-	"kprobe_vfs_rename":        "vfs_rename",
-	"kretprobe_vfs_rename":     "vfs_rename",
+	"kprobe_udp_sendmsg":           "udp_sendmsg",
+	"kretprobe_udp_sendmsg":        "udp_sendmsg",
+	"kprobe_udp_recvmsg":           "udp_recvmsg",
+	"kretprobe_udp_recvmsg":        "udp_recvmsg",
+	"kprobe_vfs_fsync":             "vfs_fsync",
+	"kretprobe_vfs_fsync":          "vfs_fsync",
+	"kprobe_do_futex":              "do_futex",
+	"kretprobe_do_futex":           "do_futex",
+	"kprobe_do_sys_openat2":        "do_sys_openat2",
+	"kretprobe_do_sys_openat2":     "do_sys_openat2",
+	"kprobe_vfs_unlink":            "vfs_unlink",
+	"kprobe_close_fd":              "close_fd",
+	"kretprobe_vfs_unlink":         "vfs_unlink",
+	"kprobe_vfs_rename":            "vfs_rename",
+	"kretprobe_vfs_rename":         "vfs_rename",
+	"kprobe_do_coredump":           "do_coredump",
+	"kprobe_filemap_add_folio":     "filemap_add_folio",
+	"kprobe_fsnotify_insert_event": "fsnotify_insert_event",
 }
 
 func attachKprobe(progName, symbol string, prog *ebpf.Program) (link.Link, error) {
@@ -95,81 +101,125 @@ func allProbeGroups() []ProbeGroup {
 
 // AttachProbesByGroup performs the same attach work as AttachProbes but
 // returns the resulting links bucketed by the ProbeGroup each program
-// belongs to.
+// belongs to. The probes are attached concurrently; if mandatory ones fail,
+// every failure is reported and nothing stays attached.
 func AttachProbesByGroup(coll *ebpf.Collection) (map[ProbeGroup][]link.Link, error) {
+	started := time.Now()
+	jobs := kernelAttachJobs(coll, func(ProbeGroup) bool { return true })
+	links, err := collectAttachResults(jobs, runAttachJobs(jobs))
+	if err != nil {
+		return nil, err
+	}
 	groups := map[ProbeGroup][]link.Link{}
-	appendLink := func(progName string, l link.Link) {
-		g := GroupForProbe(progName)
-		groups[g] = append(groups[g], l)
+	for i, l := range links {
+		if l != nil {
+			g := GroupForProbe(jobs[i].prog)
+			groups[g] = append(groups[g], l)
+		}
 	}
-	closeAll := func() {
-		for _, ls := range groups {
-			for _, l := range ls {
-				_ = l.Close()
+	attached := 0
+	for _, ls := range groups {
+		attached += len(ls)
+	}
+	logger.Info("Kernel probes attached",
+		zap.Int("probes", attached),
+		zap.Int("workers", attachWorkers()),
+		zap.Duration("took", time.Since(started)))
+	return groups, nil
+}
+
+// kernelAttachJobs lists the kprobes and tracepoints of the groups include
+// admits whose program is in coll: mandatory kprobes first, then optional
+// ones, then tracepoints, each in a fixed order.
+func kernelAttachJobs(coll *ebpf.Collection, include func(ProbeGroup) bool) []attachJob {
+	var jobs []attachJob
+	addKprobes := func(probes map[string]string, mandatory bool) {
+		names := make([]string, 0, len(probes))
+		for name := range probes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, progName := range names {
+			if !include(GroupForProbe(progName)) {
+				continue
 			}
+			prog := coll.Programs[progName]
+			if prog == nil {
+				if mandatory {
+					logger.Debug("Mandatory probe program not found in collection, skipping",
+						zap.String("prog", progName))
+				}
+				continue
+			}
+			progName, symbol := progName, probes[progName]
+			jobs = append(jobs, attachJob{prog: progName, symbol: symbol, mandatory: mandatory,
+				attach: func() (link.Link, error) { return attachKprobe(progName, symbol, prog) }})
 		}
 	}
-
-	// --- Mandatory kprobes: all must attach or we return an error. ---
-	for progName, symbol := range mandatoryProbes {
-		prog := coll.Programs[progName]
-		if prog == nil {
-			logger.Debug("Mandatory probe program not found in collection, skipping",
-				zap.String("prog", progName))
+	addKprobes(mandatoryProbes, true)
+	addKprobes(optionalProbes, false)
+	for _, tp := range tracepointProbes {
+		if !include(GroupForProbe(tp.prog)) || coll.Programs[tp.prog] == nil {
 			continue
 		}
-
-		l, err := attachKprobe(progName, symbol, prog)
-		if err != nil {
-			reportAttachFailure(progName, symbol, true, err)
-			closeAll()
-			return nil, fmt.Errorf(
-				"%w\n\n"+
-					"Hint: mandatory kprobe %q could not attach to kernel symbol %q.\n"+
-					"  • Verify symbol exists: grep -w %q /proc/kallsyms\n"+
-					"  • Check for BPF denials: dmesg | grep -i bpf\n"+
-					"  • Kernel 5.8+ required (current: %s)\n"+
-					"  • On GKE Autopilot / AWS Fargate kprobes are not allowed; use a standard node pool.\n"+
-					"  • On OpenShift ensure the pod SCC allows CAP_BPF and CAP_SYS_ADMIN",
-				NewProbeAttachError(progName, err), progName, symbol, symbol, kernelVersionString())
-		}
-		appendLink(progName, l)
-		logger.Debug("Mandatory probe attached", zap.String("prog", progName), zap.String("symbol", symbol))
+		jobs = append(jobs, attachJob{prog: tp.prog, symbol: tp.category + ":" + tp.event,
+			attach: func() (link.Link, error) {
+				// attachTracepointSpec reports and logs its own failure.
+				l, _ := attachTracepointSpec(coll, tp)
+				return l, nil
+			}})
 	}
+	return jobs
+}
 
+// collectAttachResults returns the link of each job, nil where an optional
+// probe did not attach. When mandatory probes failed it closes every link
+// and returns all their failures together.
+func collectAttachResults(jobs []attachJob, results []attachResult) ([]link.Link, error) {
+	links := make([]link.Link, len(jobs))
+	var failed []error
+	var symbols []string
 	var skippedOptional []string
-
-	// --- Optional kprobes: log failures but continue. ---
-	for progName, symbol := range optionalProbes {
-		prog := coll.Programs[progName]
-		if prog == nil {
-			continue
-		}
-
-		l, err := attachKprobe(progName, symbol, prog)
-		if err != nil {
-			reportAttachFailure(progName, symbol, false, err)
-			skippedOptional = append(skippedOptional, fmt.Sprintf("%s->%s", progName, symbol))
+	for i, r := range results {
+		job := jobs[i]
+		switch {
+		case r.err == nil:
+			links[i] = r.link
+			if r.link != nil {
+				logger.Debug("Probe attached", zap.String("prog", job.prog), zap.String("symbol", job.symbol))
+			}
+		case job.mandatory:
+			reportAttachFailure(job.prog, job.symbol, true, r.err)
+			failed = append(failed, NewProbeAttachError(job.prog, r.err))
+			symbols = append(symbols, job.symbol)
+		default:
+			reportAttachFailure(job.prog, job.symbol, false, r.err)
+			skippedOptional = append(skippedOptional, fmt.Sprintf("%s->%s", job.prog, job.symbol))
 			logger.Debug("Optional probe unavailable (skipping)",
-				zap.String("prog", progName), zap.String("symbol", symbol), zap.Error(err))
-			continue
+				zap.String("prog", job.prog), zap.String("symbol", job.symbol), zap.Error(r.err))
 		}
-		appendLink(progName, l)
-		logger.Debug("Optional probe attached", zap.String("prog", progName), zap.String("symbol", symbol))
 	}
-
+	if len(failed) > 0 {
+		for _, l := range links {
+			if l != nil {
+				_ = l.Close()
+			}
+		}
+		return nil, fmt.Errorf(
+			"%w\n\n"+
+				"Hint: mandatory kprobes could not attach to kernel symbols %s.\n"+
+				"  • Verify the symbols exist: grep -w %q /proc/kallsyms\n"+
+				"  • Check for BPF denials: dmesg | grep -i bpf\n"+
+				"  • Kernel 5.8+ required (current: %s)\n"+
+				"  • On GKE Autopilot / AWS Fargate kprobes are not allowed; use a standard node pool.\n"+
+				"  • On OpenShift ensure the pod SCC allows CAP_BPF and CAP_SYS_ADMIN",
+			errors.Join(failed...), strings.Join(symbols, ", "), symbols[0], kernelVersionString())
+	}
 	if len(skippedOptional) > 0 {
 		logger.Info("Some optional probes unavailable (non-critical features degraded)",
 			zap.Strings("skipped", skippedOptional))
 	}
-
-	for _, tp := range tracepointProbes {
-		if l, ok := attachTracepointSpec(coll, tp); ok {
-			appendLink(tp.prog, l)
-		}
-	}
-
-	return groups, nil
+	return links, nil
 }
 
 // tracepointSpec describes a tracepoint-backed BPF program and how to
@@ -188,12 +238,20 @@ var tracepointProbes = []tracepointSpec{
 	{"tracepoint_sched_switch", "sched", "sched_switch", "CPU/scheduling tracking unavailable"},
 	{"tracepoint_inet_sock_set_state", "sock", "inet_sock_set_state", "TCP state-change tracking unavailable"},
 	{"tracepoint_tcp_retransmit_skb", "tcp", "tcp_retransmit_skb", "TCP retransmission tracking unavailable"},
+	{"tracepoint_tcp_retransmit_synack", "tcp", "tcp_retransmit_synack", "SYN-ACK retransmission tracking unavailable"},
+	{"tracepoint_tcp_probe", "tcp", "tcp_probe", "TCP zero-window stall tracking unavailable"},
 	{"tracepoint_net_dev_xmit", "net", "net_dev_xmit", "Network device error tracking unavailable"},
 	{"tracepoint_page_fault_user", "exceptions", "page_fault_user", "Page fault tracking unavailable"},
 	{"tracepoint_oom_mark_victim", "oom", "mark_victim", "OOM kill tracking unavailable"},
+	{"tracepoint_mm_compaction_try_to_compact_pages", "compaction", "mm_compaction_try_to_compact_pages", "Compaction allocation order tracking unavailable"},
+	{"tracepoint_mm_compaction_begin", "compaction", "mm_compaction_begin", "Memory compaction stall tracking unavailable"},
+	{"tracepoint_mm_compaction_end", "compaction", "mm_compaction_end", "Memory compaction stall tracking unavailable"},
+	{"tracepoint_mm_collapse_huge_page", "huge_memory", "mm_collapse_huge_page", "THP collapse tracking unavailable"},
 	{"tracepoint_sched_process_fork", "sched", "sched_process_fork", "Process fork tracking unavailable"},
 	{"tracepoint_sched_process_exec", "sched", "sched_process_exec", "Process exec tracking unavailable"},
 	{"tracepoint_sys_enter_bind", "syscalls", "sys_enter_bind", "AF_ALG crypto-socket detection unavailable"},
+	{"tracepoint_sys_exit_inotify_add_watch", "syscalls", "sys_exit_inotify_add_watch", "inotify watch registration tracking unavailable"},
+	{"tracepoint_sys_exit_fanotify_mark", "syscalls", "sys_exit_fanotify_mark", "fanotify mark registration tracking unavailable"},
 }
 
 // attachTracepointSpec attaches one tracepoint, returning (link, true) on
@@ -225,56 +283,31 @@ func attachTracepointSpec(coll *ebpf.Collection, tp tracepointSpec) (link.Link,
 // calls it when a CR newly needs a category whose group was previously
 // detached.
 func AttachProbeGroup(coll *ebpf.Collection, target ProbeGroup) ([]link.Link, error) {
+	jobs := kernelAttachJobs(coll, func(g ProbeGroup) bool { return g == target })
+	results := runAttachJobs(jobs)
 	var links []link.Link
-	rollback := func() {
-		for _, l := range links {
-			_ = l.Close()
-		}
-	}
-
-	for progName, symbol := range mandatoryProbes {
-		if GroupForProbe(progName) != target {
-			continue
-		}
-		prog := coll.Programs[progName]
-		if prog == nil {
-			continue
-		}
-		l, err := attachKprobe(progName, symbol, prog)
-		if err != nil {
-			reportAttachFailure(progName, symbol, true, err)
-			rollback()
-			return nil, fmt.Errorf("re-attach mandatory probe %q (%s): %w", progName, symbol, NewProbeAttachError(progName, err))
-		}
-		links = append(links, l)
-	}
-
-	for progName, symbol := range optionalProbes {
-		if GroupForProbe(progName) != target {
-			continue
-		}
-		prog := coll.Programs[progName]
-		if prog == nil {
-			continue
-		}
-		l, err := attachKprobe(progName, symbol, prog)
-		if err != nil {
+	var failed error
+	for i, r := range results {
+		job := jobs[i]
+		switch {
+		case r.err == nil:
+			if r.link != nil {
+				links = append(links, r.link)
+			}
+		case job.mandatory:
+			reportAttachFailure(job.prog, job.symbol, true, r.err)
+			failed = errors.Join(failed, fmt.Errorf("re-attach mandatory probe %q (%s): %w", job.prog, job.symbol, NewProbeAttachError(job.prog, r.err)))
+		default:
 			logger.Debug("optional probe unavailable on re-attach",
-				zap.String("prog", progName), zap.String("symbol", symbol), zap.Error(err))
-			continue
+				zap.String("prog", job.prog), zap.String("symbol", job.symbol), zap.Error(r.err))
 		}
-		links = append(links, l)
 	}
-
-	for _, tp := range tracepointProbes {
-		if GroupForProbe(tp.prog) != target {
-			continue
-		}
-		if l, ok := attachTracepointSpec(coll, tp); ok {
-			links = append(links, l)
+	if failed != nil {
+		for _, l := range links {
+			_ = l.Close()
 		}
+		return nil, failed
 	}
-
 	return links, nil
 }
 
@@ -330,7 +363,8 @@ func AttachDNSPacketProbes(coll *ebpf.Collection, cgroupPaths []string) []link.L
 			})
 			if err != nil {
 				logger.Info("Packet-based DNS capture unavailable for cgroup; falling back to libc uprobe only",
-					zap.String("cgroup", path), zap.String("direction", a.name), zap.Error(err))
+					zap.String("cgroup", path), zap.String("direction", a.name),
+					zap.Strings("hook_holders", cgroupHookHolders(path, a.typ)), zap.Error(err))
 				continue
 			}
 			links = append(links, l)
@@ -382,7 +416,8 @@ func AttachHTTP3Probes(coll *ebpf.Collection, cgroupPaths []string) []link.Link
 			})
 			if err != nil {
 				logger.Info("HTTP/3 detection unavailable for cgroup",
-					zap.String("cgroup", path), zap.String("direction", a.name), zap.Error(err))
+					zap.String("cgroup", path), zap.String("direction", a.name),
+					zap.Strings("hook_holders", cgroupHookHolders(path, a.typ)), zap.Error(err))
 				continue
 			}
 			links = append(links, l)
@@ -393,6 +428,9 @@ func AttachHTTP3Probes(coll *ebpf.Collection, cgroupPaths []string) []link.Link
 
 func AttachDNSProbesWithPID(coll *ebpf.Collection, containerID string, pid uint32, af *AttachedFiles) []link.Link {
 	var links []link.Link
+	if !DNSProbeEnabled(DNSProbeLibc) {
+		return links
+	}
 	libcPath := FindLibcPathWithPID(containerID, pid)
 	if libcPath != "" && !af.Claim("dns", libcPath) {
 		return links
@@ -819,10 +857,9 @@ func findLibcViaProcessMapsProcRoot(pid uint32) string {
 }
 
 func findLibcInProcess(pid uint32) string {
-	procRootPaths := getArchitecturePaths()
-	for _, basePath := range procRootPaths {
-		path := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root", basePath)
-		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+	procRoot := procRootDir(pid)
+	for _, basePath := range getArchitecturePaths() {
+		if path, ok := procRootFile(procRoot, basePath); ok {
 			return path
 		}
 	}
@@ -833,12 +870,10 @@ func fileInProcRoot(pid uint32, containerPath string) string {
 	if containerPath == "" || strings.HasPrefix(containerPath, "[") {
 		return ""
 	}
-	procRoot := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root")
-	hostPath := filepath.Join(procRoot, strings.TrimPrefix(containerPath, "/"))
-	if hostfs.IsRegularFile(hostPath) {
+	if hostPath, ok := procRootFile(procRootDir(pid), containerPath); ok {
 		return hostPath
 	}
-	if hostfs.IsRegularFile(containerPath) {
+	if !config.ProcRootOnly && hostfs.IsRegularFile(containerPath) {
 		return containerPath
 	}
 	return ""
@@ -908,20 +943,20 @@ func findGoBinaryInProcess(pid uint32) string {
 		target = filepath.Join(cwd, target)
 	}
 
-	procRootPath := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root")
-	hostPath := filepath.Join(procRootPath, strings.TrimPrefix(target, "/"))
-
-	if info, err := os.Stat(hostPath); err == nil && !info.IsDir() {
+	hostPath, ok := procRootFile(procRootDir(pid), target)
+	if ok {
 		logger.Debug("Found binary via container root", zap.Uint32("pid", pid), zap.String("container_path", target), zap.String("host_path", hostPath))
 		return hostPath
 	}
 
-	if info, err := os.Stat(target); err == nil && !info.IsDir() {
-		logger.Debug("Found binary via direct path", zap.Uint32("pid", pid), zap.String("path", target))
-		return target
+	if !config.ProcRootOnly {
+		if info, err := os.Stat(target); err == nil && !info.IsDir() {
+			logger.Debug("Found binary via direct path", zap.Uint32("pid", pid), zap.String("path", target))
+			return target
+		}
 	}
 
-	logger.Debug("Binary not found or not accessible", zap.Uint32("pid", pid), zap.String("target", target), zap.String("host_path", hostPath), zap.Error(err))
+	logger.Debug("Binary not found or not accessible", zap.Uint32("pid", pid), zap.String("target", target), zap.String("host_path", hostPath))
 	return ""
 }
 
@@ -932,7 +967,7 @@ func findGoBinaryViaProcessMaps(pid uint32) string {
 		return ""
 	}
 
-	procRootPath := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root")
+	procRootPath := procRootDir(pid)
 
 	for _, line := range strings.Split(string(data), "\n") {
 		if strings.Contains(line, "r-xp") {
@@ -944,13 +979,12 @@ func findGoBinaryViaProcessMaps(pid uint32) string {
 						continue
 					}
 
-					hostPath := filepath.Join(procRootPath, strings.TrimPrefix(binaryPath, "/"))
-					if hostfs.IsRegularFile(hostPath) {
+					if hostPath, ok := procRootFile(procRootPath, binaryPath); ok {
 						logger.Debug("Found binary via process maps", zap.Uint32("pid", pid), zap.String("container_path", binaryPath), zap.String("host_path", hostPath))
 						return hostPath
 					}
 
-					if hostfs.IsRegularFile(binaryPath) {
+					if !config.ProcRootOnly && hostfs.IsRegularFile(binaryPath) {
 						logger.Debug("Found binary via process maps (direct)", zap.Uint32("pid", pid), zap.String("path", binaryPath))
 						return binaryPath
 					}
@@ -962,7 +996,7 @@ func findGoBinaryViaProcessMaps(pid uint32) string {
 }
 
 func findGoBinaryInContainer(containerID string, pid uint32) string {
-	procRootPath := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root")
+	procRootPath := procRootDir(pid)
 
 	if cmdlineData, err := procfs.ReadFile(fmt.Sprintf("%d/cmdline", pid)); err == nil {
 		cmdline := string(cmdlineData)
@@ -971,8 +1005,7 @@ func findGoBinaryInContainer(containerID string, pid uint32) string {
 			if len(parts) > 0 && parts[0] != "" {
 				binaryPath := parts[0]
 				if filepath.IsAbs(binaryPath) {
-					hostPath := filepath.Join(procRootPath, strings.TrimPrefix(binaryPath, "/"))
-					if hostfs.IsRegularFile(hostPath) {
+					if hostPath, ok := procRootFile(procRootPath, binaryPath); ok {
 						logger.Debug("Found binary via cmdline", zap.Uint32("pid", pid), zap.String("cmdline_path", binaryPath), zap.String("host_path", hostPath))
 						return hostPath
 					}
@@ -993,8 +1026,7 @@ func findGoBinaryInContainer(containerID string, pid uint32) string {
 				filepath.Join("/app", "app"),
 			}
 			for _, relPath := range commonPaths {
-				hostPath := filepath.Join(procRootPath, strings.TrimPrefix(relPath, "/"))
-				if hostfs.IsRegularFile(hostPath) {
+				if hostPath, ok := procRootFile(procRootPath, relPath); ok {
 					logger.Debug("Found binary via comm name", zap.Uint32("pid", pid), zap.String("comm", commName), zap.String("path", hostPath))
 					return hostPath
 				}
@@ -1005,33 +1037,13 @@ func findGoBinaryInContainer(containerID string, pid uint32) string {
 	commonPaths := config.GetCommonBinarySearchPaths()
 
 	for _, relPath := range commonPaths {
-		hostPath := filepath.Join(procRootPath, strings.TrimPrefix(relPath, "/"))
-		if info, err := os.Stat(hostPath); err == nil && !info.IsDir() {
+		if hostPath, ok := procRootFile(procRootPath, relPath); ok {
 			logger.Debug("Found binary via container root common paths", zap.Uint32("pid", pid), zap.String("path", hostPath))
 			return hostPath
 		}
 	}
 
-	rootfsPaths := []string{}
-	if dockerRootfs, err := config.GetDockerContainerRootfs(containerID); err == nil {
-		rootfsPaths = append(rootfsPaths, dockerRootfs)
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdOverlayPattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdNativePattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
+	rootfsPaths := containerRootfsPaths(containerID)
 
 	commonPathsForRootfs := config.GetCommonBinarySearchPaths()
 	for _, rootfs := range rootfsPaths {
@@ -1055,35 +1067,15 @@ func findLibcInContainer(containerID string) string {
 			return path
 		}
 
-		procRootPaths := getArchitecturePaths()
-		for _, basePath := range procRootPaths {
-			path := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root", basePath)
-			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		procRoot := procRootDir(pid)
+		for _, basePath := range getArchitecturePaths() {
+			if path, ok := procRootFile(procRoot, basePath); ok {
 				return path
 			}
 		}
 	}
 
-	rootfsPaths := []string{}
-	if dockerRootfs, err := config.GetDockerContainerRootfs(containerID); err == nil {
-		rootfsPaths = append(rootfsPaths, dockerRootfs)
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdOverlayPattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdNativePattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
+	rootfsPaths := containerRootfsPaths(containerID)
 
 	for _, rootfs := range rootfsPaths {
 		if _, err := os.Stat(rootfs); err == nil {
@@ -1290,35 +1282,15 @@ func findDBLibsInContainer(containerID string, libNames []string) []string {
 			paths = append(paths, foundPaths...)
 		}
 
-		archPaths := getArchitectureDBPaths(libNames)
-		for _, basePath := range archPaths {
-			path := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root", strings.TrimPrefix(basePath, "/"))
-			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+		procRoot := procRootDir(pid)
+		for _, basePath := range getArchitectureDBPaths(libNames) {
+			if path, ok := procRootFile(procRoot, basePath); ok {
 				paths = append(paths, path)
 			}
 		}
 	}
 
-	rootfsPaths := []string{}
-	if dockerRootfs, err := config.GetDockerContainerRootfs(containerID); err == nil {
-		rootfsPaths = append(rootfsPaths, dockerRootfs)
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdOverlayPattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdNativePattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
+	rootfsPaths := containerRootfsPaths(containerID)
 
 	for _, rootfs := range rootfsPaths {
 		if _, err := os.Stat(rootfs); err == nil {
@@ -1355,10 +1327,10 @@ func findDBLibsWithPID(containerID string, pid uint32, libNames []string) []stri
 			}
 		}
 
-		archPaths := getArchitectureDBPaths(libNames)
-		for _, basePath := range archPaths {
-			p := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root", strings.TrimPrefix(basePath, "/"))
-			if info, err := os.Stat(p); err == nil && !info.IsDir() && !seen[p] {
+		procRoot := procRootDir(pid)
+		for _, basePath := range getArchitectureDBPaths(libNames) {
+			p, ok := procRootFile(procRoot, basePath)
+			if ok && !seen[p] {
 				out = append(out, p)
 				seen[p] = true
 			}
@@ -1416,6 +1388,19 @@ func findDBLibs(containerID string, libNames []string) []string {
 
 func FindLibcInContainer(containerID string) []string {
 	paths := []string{}
+	if config.ProcRootOnly {
+		pid := findContainerProcess(containerID)
+		if pid == 0 {
+			return paths
+		}
+		procRoot := procRootDir(pid)
+		for _, basePath := range getArchitecturePaths() {
+			if path, ok := procRootFile(procRoot, basePath); ok {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
 	containerRoot, err := config.GetDockerContainerRootfs(containerID)
 	if err != nil {
 		return paths
@@ -1510,26 +1495,7 @@ func findTLSLibsInContainer(containerID string, libPatterns []string) []string {
 		}
 	}
 
-	rootfsPaths := []string{}
-	if dockerRootfs, err := config.GetDockerContainerRootfs(containerID); err == nil {
-		rootfsPaths = append(rootfsPaths, dockerRootfs)
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdOverlayPattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
-
-	if matches, err := filepath.Glob(config.GetContainerdNativePattern()); err == nil {
-		for _, match := range matches {
-			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
-				rootfsPaths = append(rootfsPaths, match)
-			}
-		}
-	}
+	rootfsPaths := containerRootfsPaths(containerID)
 
 	libPatternsLower := make([]string, len(libPatterns))
 	for i, p := range libPatterns {
@@ -1582,7 +1548,7 @@ func findTLSLibsInContainerWithPID(containerID string, pid uint32, libPatterns [
 // looking for shared-library files whose name matches one of libPatterns,
 // independent of whether the resolved process has them mapped.
 func findTLSLibsViaProcRootScan(pid uint32, libPatterns []string) []string {
-	root := filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root")
+	root := procRootDir(pid)
 	dirs := append(config.GetDefaultLibSearchPaths(),
 		"/usr/lib/x86_64-linux-gnu", "/lib/x86_64-linux-gnu",
 		"/usr/lib/aarch64-linux-gnu", "/lib/aarch64-linux-gnu",
@@ -1595,6 +1561,13 @@ func findTLSLibsViaProcRootScan(pid uint32, libPatterns []string) []string {
 	seen := make(map[string]bool)
 	for _, d := range dirs {
 		dirPath := filepath.Join(root, strings.TrimPrefix(d, "/"))
+		if config.ProcRootOnly {
+			resolved, err := hostfs.ResolveInRoot(root, d)
+			if err != nil {
+				continue
+			}
+			dirPath = resolved
+		}
 		entries, err := os.ReadDir(dirPath)
 		if err != nil {
 			if !os.IsNotExist(err) {
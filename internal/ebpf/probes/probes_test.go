@@ -1108,6 +1108,40 @@ func TestFindLibcInContainer_ViaRootfs(t *testing.T) {
 	}
 }
 
+func TestFindLibcInContainer_ProcRootOnlyUsesContainerProcess(t *testing.T) {
+	tmpDir := t.TempDir()
+	origProcBase, origProcRootOnly := config.ProcBasePath, config.ProcRootOnly
+	config.SetProcBasePath(tmpDir)
+	config.ProcRootOnly = true
+	defer func() {
+		config.SetProcBasePath(origProcBase)
+		config.ProcRootOnly = origProcRootOnly
+	}()
+
+	containerID := "test-container-procroot"
+	libc := getArchitecturePaths()[0]
+	for pid, cgroup := range map[string]string{"1": "0::/init.scope\n", "4242": "0::/kubepods/pod_" + containerID + "\n"} {
+		procDir := filepath.Join(tmpDir, pid)
+		if err := os.MkdirAll(filepath.Join(procDir, "root", filepath.Dir(libc)), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(procDir, "root", libc), []byte("fake libc"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(procDir, "cgroup"), []byte(cgroup), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := FindLibcInContainer(containerID)
+	if len(result) != 1 || result[0] != filepath.Join(tmpDir, "4242", "root", libc) {
+		t.Errorf("FindLibcInContainer = %v, want the libc under the container process's root", result)
+	}
+	if got := FindLibcInContainer("not-running"); len(got) != 0 {
+		t.Errorf("FindLibcInContainer for a container without a process = %v, want none", got)
+	}
+}
+
 func TestGetArchitecturePaths(t *testing.T) {
 	paths := getArchitecturePaths()
 	if len(paths) == 0 {
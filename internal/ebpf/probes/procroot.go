@@ -0,0 +1,59 @@
+package probes
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+// procRootDir is the kernel's view of pid's root filesystem.
+func procRootDir(pid uint32) string {
+	return filepath.Join(config.ProcBasePath, fmt.Sprintf("%d", pid), "root")
+}
+
+// procRootFile maps a container path onto procRoot and reports whether it
+// names a regular file. In proc-root-only mode the path is resolved with
+// RESOLVE_IN_ROOT semantics so container symlinks cannot point the lookup
+// (or the uprobe attached to the result) at a host file.
+func procRootFile(procRoot, containerPath string) (string, bool) {
+	if config.ProcRootOnly {
+		path, info, err := hostfs.StatInRoot(procRoot, containerPath)
+		if err != nil {
+			return "", false
+		}
+		return path, info.Mode().IsRegular()
+	}
+	path := filepath.Join(procRoot, strings.TrimPrefix(containerPath, "/"))
+	info, err := os.Stat(path)
+	return path, err == nil && !info.IsDir()
+}
+
+// containerRootfsPaths lists the Docker and containerd snapshot directories
+// that may hold containerID's rootfs. Proc-root-only mode returns none: those
+// state directories are commonly masked by hardened AppArmor/SELinux
+// profiles, and /proc/<pid>/root already reaches the same files.
+func containerRootfsPaths(containerID string) []string {
+	if config.ProcRootOnly {
+		return nil
+	}
+	rootfsPaths := []string{}
+	if dockerRootfs, err := config.GetDockerContainerRootfs(containerID); err == nil {
+		rootfsPaths = append(rootfsPaths, dockerRootfs)
+	}
+	for _, pattern := range []string{config.GetContainerdOverlayPattern(), config.GetContainerdNativePattern()} {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		for _, match := range matches {
+			if strings.Contains(match, containerID) || filepath.Base(filepath.Dir(match)) == containerID {
+				rootfsPaths = append(rootfsPaths, match)
+			}
+		}
+	}
+	return rootfsPaths
+}
@@ -242,6 +242,126 @@ func AttachHTTPProbes(coll *ebpf.Collection) []link.Link {
 	return links
 }
 
+// AttachPGWireProbes attaches the socket-level PostgreSQL protocol kprobes:
+// a kprobe on tcp_sendmsg (Query/Parse/Bind) plus a kprobe+kretprobe pair on
+// tcp_recvmsg (ReadyForQuery). They cover clients that bypass libpq.
+func AttachPGWireProbes(coll *ebpf.Collection) []link.Link {
+	var links []link.Link
+	attach := func(progName, sym string, ret bool) {
+		prog := coll.Programs[progName]
+		if prog == nil {
+			return
+		}
+		var l link.Link
+		var err error
+		if ret {
+			l, err = link.Kretprobe(sym, prog, nil)
+		} else {
+			l, err = link.Kprobe(sym, prog, nil)
+		}
+		if err == nil {
+			links = append(links, l)
+			logger.Debug("PostgreSQL wire probe attached", zap.String("prog", progName))
+		} else {
+			logger.Debug("PostgreSQL wire probe unavailable", zap.String("prog", progName), zap.Error(err))
+		}
+	}
+	attach("kprobe_pg_tcp_sendmsg", "tcp_sendmsg", false)
+	attach("kprobe_pg_tcp_recvmsg", "tcp_recvmsg", false)
+	attach("kretprobe_pg_tcp_recvmsg", "tcp_recvmsg", true)
+	return links
+}
+
+// AttachMySQLWireProbes attaches the socket-level MySQL protocol kprobes on
+// tcp_sendmsg (commands) and tcp_recvmsg (greeting, OK/ERR/EOF). They cover
+// clients that bypass libmysqlclient and only inspect port-3306 sockets.
+func AttachMySQLWireProbes(coll *ebpf.Collection) []link.Link {
+	var links []link.Link
+	attach := func(progName, sym string, ret bool) {
+		prog := coll.Programs[progName]
+		if prog == nil {
+			return
+		}
+		var l link.Link
+		var err error
+		if ret {
+			l, err = link.Kretprobe(sym, prog, nil)
+		} else {
+			l, err = link.Kprobe(sym, prog, nil)
+		}
+		if err == nil {
+			links = append(links, l)
+			logger.Debug("MySQL wire probe attached", zap.String("prog", progName))
+		} else {
+			logger.Debug("MySQL wire probe unavailable", zap.String("prog", progName), zap.Error(err))
+		}
+	}
+	attach("kprobe_mysql_tcp_sendmsg", "tcp_sendmsg", false)
+	attach("kprobe_mysql_tcp_recvmsg", "tcp_recvmsg", false)
+	attach("kretprobe_mysql_tcp_recvmsg", "tcp_recvmsg", true)
+	return links
+}
+
+// AttachKafkaWireProbes attaches the socket-level Kafka protocol kprobes on
+// tcp_sendmsg (requests) and tcp_recvmsg (responses, matched by correlation
+// id). They cover clients without librdkafka on port-9092 broker sockets.
+func AttachKafkaWireProbes(coll *ebpf.Collection) []link.Link {
+	var links []link.Link
+	attach := func(progName, sym string, ret bool) {
+		prog := coll.Programs[progName]
+		if prog == nil {
+			return
+		}
+		var l link.Link
+		var err error
+		if ret {
+			l, err = link.Kretprobe(sym, prog, nil)
+		} else {
+			l, err = link.Kprobe(sym, prog, nil)
+		}
+		if err == nil {
+			links = append(links, l)
+			logger.Debug("Kafka wire probe attached", zap.String("prog", progName))
+		} else {
+			logger.Debug("Kafka wire probe unavailable", zap.String("prog", progName), zap.Error(err))
+		}
+	}
+	attach("kprobe_kafka_tcp_sendmsg", "tcp_sendmsg", false)
+	attach("kprobe_kafka_tcp_recvmsg", "tcp_recvmsg", false)
+	attach("kretprobe_kafka_tcp_recvmsg", "tcp_recvmsg", true)
+	return links
+}
+
+// AttachAMQPProbes attaches the socket-level AMQP 0-9-1 kprobes on
+// tcp_sendmsg and tcp_recvmsg for port-5672 connections. AMQPS is parsed
+// from the OpenSSL hooks that AttachHTTPProbes already covers.
+func AttachAMQPProbes(coll *ebpf.Collection) []link.Link {
+	var links []link.Link
+	attach := func(progName, sym string, ret bool) {
+		prog := coll.Programs[progName]
+		if prog == nil {
+			return
+		}
+		var l link.Link
+		var err error
+		if ret {
+			l, err = link.Kretprobe(sym, prog, nil)
+		} else {
+			l, err = link.Kprobe(sym, prog, nil)
+		}
+		if err == nil {
+			links = append(links, l)
+			logger.Debug("AMQP probe attached", zap.String("prog", progName))
+		} else {
+			logger.Debug("AMQP probe unavailable", zap.String("prog", progName), zap.Error(err))
+		}
+	}
+	attach("kprobe_amqp_tcp_sendmsg", "tcp_sendmsg", false)
+	attach("kprobe_amqp_tcp_recvmsg", "tcp_recvmsg", false)
+	attach("kretprobe_amqp_tcp_recvmsg", "tcp_recvmsg", true)
+	return links
+}
+
 // AttachH2Probes attaches the HTTP/2 (h2c) HPACK endpoint-capture kprobes: a
 // kprobe on tcp_sendmsg (request HEADERS) plus a kprobe+kretprobe pair on
 // tcp_recvmsg (response HEADERS).
@@ -0,0 +1,172 @@
+package probes
+
+import (
+	"path"
+	"regexp"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/safeelf"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/usdt"
+)
+
+// pythonBinaryRe matches CPython interpreters and their shared runtime
+// (python3, python3.12, libpython3.12.so.1.0) but not extension modules.
+var pythonBinaryRe = regexp.MustCompile(`^(lib)?python[23](\.[0-9]+)?[dmu]*(\.so(\.[0-9.]+)?)?$`)
+
+// isPythonBinary reports whether a mapped file is a CPython interpreter.
+func isPythonBinary(containerPath string) bool {
+	return pythonBinaryRe.MatchString(path.Base(containerPath))
+}
+
+// pythonUSDTPrograms maps CPython's USDT probe names to the BPF programs
+// attached at them.
+var pythonUSDTPrograms = map[string]string{
+	"function__entry":  "uprobe_py_function_entry",
+	"function__return": "uprobe_py_function_return",
+	"gc__start":        "uprobe_py_gc_start",
+	"gc__done":         "uprobe_py_gc_done",
+}
+
+// pythonEvalFrameSymbols are tried in order for the timing-only fallback;
+// CPython 3.11 renamed the frame evaluator.
+var pythonEvalFrameSymbols = []string{"_PyEval_EvalFrameDefault", "PyEval_EvalFrameDefault"}
+
+// AttachPythonProbes attaches to every CPython interpreter mapped into pid.
+// Interpreters built with --with-dtrace get per-function and GC events from
+// their USDT probes; others fall back to timing PyEval_EvalFrameDefault.
+func AttachPythonProbes(coll *ebpf.Collection, pid uint32, af *AttachedFiles) []link.Link {
+	defer safeelf.RecoverParse("probes.AttachPythonProbes")
+
+	var links []link.Link
+	if pid == 0 || !config.PythonEnabled || coll.Maps["usdt_probes"] == nil {
+		return links
+	}
+	for _, m := range execMappings(pid) {
+		if !isPythonBinary(m.containerPath) {
+			continue
+		}
+		hostPath := m.hostPath(pid)
+		if hostPath == "" || !af.Claim("python", hostPath) {
+			continue
+		}
+		ls, viaUSDT := attachPythonUSDT(coll, hostPath)
+		if !viaUSDT {
+			ls = attachPythonEvalFrame(coll, hostPath)
+		}
+		if len(ls) > 0 {
+			logger.Debug("python probes attached",
+				zap.Uint32("pid", pid), zap.String("binary", m.containerPath),
+				zap.Bool("usdt", viaUSDT), zap.Int("links", len(ls)))
+		}
+		links = append(links, ls...)
+	}
+	return links
+}
+
+// attachPythonUSDT attaches the python provider's USDT probes in binPath. It
+// reports whether function entry/return pairing is in place, which decides
+// if the frame-timing fallback is still needed.
+func attachPythonUSDT(coll *ebpf.Collection, binPath string) ([]link.Link, bool) {
+	var links []link.Link
+	f, err := safeelf.Open(binPath)
+	if err != nil {
+		return links, false
+	}
+	defer func() { _ = f.Close() }()
+
+	found, err := usdt.ScanFile(f)
+	if err != nil || len(found) == 0 {
+		return links, false
+	}
+	exe, err := link.OpenExecutable(binPath)
+	if err != nil {
+		return links, false
+	}
+	pmap := coll.Maps["usdt_probes"]
+	semSupported := uprobeRefCtrOffsetSupported()
+	attached := map[string]bool{}
+	for i := range found {
+		p := found[i]
+		if p.Provider != "python" {
+			continue
+		}
+		prog := coll.Programs[pythonUSDTPrograms[p.Name]]
+		if prog == nil {
+			continue
+		}
+		addr, ok := vaddrToFileOffset(f, p.PC)
+		if !ok {
+			continue
+		}
+		cookie := usdtCookieSeq.Add(1)
+		val := usdtProbeValue{}
+		copyCString(val.Provider[:], p.Provider)
+		copyCString(val.Name[:], p.Name)
+		nargs := len(p.Args)
+		if nargs > usdt.MaxArgs {
+			nargs = usdt.MaxArgs
+		}
+		val.NArgs = uint8(nargs)
+		for j := 0; j < nargs; j++ {
+			val.Args[j] = usdtArgValue{
+				Size:   p.Args[j].Size,
+				Kind:   uint8(p.Args[j].Kind),
+				RegOff: p.Args[j].RegOff,
+				Disp:   p.Args[j].Disp,
+			}
+		}
+		if err := pmap.Update(cookie, &val, ebpf.UpdateAny); err != nil {
+			continue
+		}
+		// CPython only fires its probes while the semaphore is raised.
+		opts := &link.UprobeOptions{Address: addr, Cookie: cookie}
+		if p.SemAddr != 0 && semSupported {
+			if semOff, ok := vaddrToFileOffset(f, p.SemAddr); ok {
+				opts.RefCtrOffset = semOff
+			}
+		}
+		l, err := exe.Uprobe("", prog, opts)
+		if err != nil {
+			_ = pmap.Delete(cookie)
+			logger.Debug("python usdt probe not attached",
+				zap.String("probe", p.Name), zap.String("binary", binPath), zap.Error(err))
+			continue
+		}
+		links = append(links, l)
+		attached[p.Name] = true
+	}
+	return links, attached["function__entry"] && attached["function__return"]
+}
+
+// attachPythonEvalFrame times frame evaluation for interpreters without
+// USDT probes. Events carry no function name.
+func attachPythonEvalFrame(coll *ebpf.Collection, binPath string) []link.Link {
+	var links []link.Link
+	entry := coll.Programs["uprobe_py_eval_frame"]
+	ret := coll.Programs["uretprobe_py_eval_frame"]
+	if entry == nil || ret == nil {
+		return links
+	}
+	exe, err := link.OpenExecutable(binPath)
+	if err != nil {
+		return links
+	}
+	for _, sym := range pythonEvalFrameSymbols {
+		l, err := exe.Uprobe(sym, entry, nil)
+		if err != nil {
+			continue
+		}
+		rl, err := exe.Uretprobe(sym, ret, nil)
+		if err != nil {
+			_ = l.Close()
+			continue
+		}
+		return append(links, l, rl)
+	}
+	return links
+}
@@ -0,0 +1,29 @@
+package probes
+
+import "testing"
+
+func TestIsPythonBinary(t *testing.T) {
+	cases := map[string]bool{
+		"/usr/bin/python3":                                    true,
+		"/usr/local/bin/python3.12":                           true,
+		"/usr/lib/x86_64-linux-gnu/libpython3.11.so.1.0":      true,
+		"/usr/local/lib/libpython3.9d.so":                     true,
+		"/usr/lib/python3/dist-packages/_cffi.cpython-311.so": false,
+		"/usr/lib/python3.11/lib-dynload/_ssl.so":             false,
+		"/usr/bin/pythonista":                                 false,
+		"/app/server":                                         false,
+	}
+	for p, want := range cases {
+		if got := isPythonBinary(p); got != want {
+			t.Errorf("isPythonBinary(%q) = %v, want %v", p, got, want)
+		}
+	}
+}
+
+func TestPythonProgramsInGroup(t *testing.T) {
+	for _, prog := range pythonUSDTPrograms {
+		if got := GroupForProbe(prog); got != GroupPython {
+			t.Errorf("%s group = %q, want %q", prog, got, GroupPython)
+		}
+	}
+}
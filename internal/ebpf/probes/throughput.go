@@ -0,0 +1,124 @@
+package probes
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/link"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// Directions and remote address classes of the pod_throughput map; keep in
+// sync with the TP_DIR_* and TP_CLASS_* constants in bpf/maps.h.
+const (
+	ThroughputDirIngress uint8 = 0
+	ThroughputDirEgress  uint8 = 1
+
+	ThroughputClassExternal uint8 = 0
+	ThroughputClassCluster  uint8 = 1
+	ThroughputClassNode     uint8 = 2
+)
+
+// podInterface is the pod-side end of the veth pair CNIs create.
+const podInterface = "eth0"
+
+// VethIfindex returns the host-side ifindex of the veth serving the network
+// namespace pid lives in. The pod's sysfs, seen through /proc/<pid>/root,
+// reports the peer's index in the iflink of its eth0; a pod on the host
+// network has no veth and yields an error.
+func VethIfindex(pid uint32) (int, error) {
+	dir := filepath.Join(procRootDir(pid), "sys", "class", "net", podInterface)
+	ifindex, err := readSysfsInt(filepath.Join(dir, "ifindex"))
+	if err != nil {
+		return 0, err
+	}
+	iflink, err := readSysfsInt(filepath.Join(dir, "iflink"))
+	if err != nil {
+		return 0, err
+	}
+	if iflink == ifindex || iflink <= 0 {
+		return 0, fmt.Errorf("%s of pid %d is not a veth (host network pod?)", podInterface, pid)
+	}
+	return iflink, nil
+}
+
+func readSysfsInt(path string) (int, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- sysfs file under the target's /proc root.
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// AttachThroughputProbes attaches the veth byte/packet counters to both
+// directions of the host-side veth ifindex. They use tcx links (Linux 6.6+)
+// placed first in the chain, so CNI programs that redirect packets cannot
+// hide them; on older kernels throughput counting is unavailable.
+func AttachThroughputProbes(coll *ebpf.Collection, ifindex int) []link.Link {
+	attach := []struct {
+		prog *ebpf.Program
+		typ  ebpf.AttachType
+		name string
+	}{
+		{coll.Programs["pod_veth_ingress"], ebpf.AttachTCXIngress, "ingress"},
+		{coll.Programs["pod_veth_egress"], ebpf.AttachTCXEgress, "egress"},
+	}
+	var links []link.Link
+	for _, a := range attach {
+		if a.prog == nil {
+			continue
+		}
+		l, err := link.AttachTCX(link.TCXOptions{
+			Interface: ifindex,
+			Program:   a.prog,
+			Attach:    a.typ,
+			Anchor:    link.Head(),
+		})
+		if err != nil {
+			logger.Info("Pod throughput counters unavailable for veth (tcx needs Linux 6.6+)",
+				zap.Int("ifindex", ifindex), zap.String("direction", a.name), zap.Error(err))
+			continue
+		}
+		links = append(links, l)
+	}
+	return links
+}
+
+// cidrClassKey mirrors struct cidr_class_key in bpf/maps.h.
+type cidrClassKey struct {
+	PrefixLen uint32
+	Addr      [16]byte
+}
+
+// SetCIDRClasses fills the cidr_classes trie the throughput counters
+// classify remote addresses with. Node prefixes are written last, so a node
+// address inside a cluster range counts as node traffic.
+func SetCIDRClasses(m *ebpf.Map, cluster, node []netip.Prefix) error {
+	put := func(p netip.Prefix, class uint8) error {
+		p = p.Masked()
+		bits := p.Bits()
+		if p.Addr().Is4() {
+			bits += 96
+		}
+		key := cidrClassKey{PrefixLen: uint32(bits), Addr: p.Addr().As16()}
+		return m.Put(&key, &class)
+	}
+	for _, p := range cluster {
+		if err := put(p, ThroughputClassCluster); err != nil {
+			return fmt.Errorf("cidr class %s: %w", p, err)
+		}
+	}
+	for _, p := range node {
+		if err := put(p, ThroughputClassNode); err != nil {
+			return fmt.Errorf("cidr class %s: %w", p, err)
+		}
+	}
+	return nil
+}
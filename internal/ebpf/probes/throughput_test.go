@@ -0,0 +1,37 @@
+package probes
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+func TestVethIfindex(t *testing.T) {
+	tmpDir := t.TempDir()
+	origProcBase := config.ProcBasePath
+	config.SetProcBasePath(tmpDir)
+	defer func() { config.SetProcBasePath(origProcBase) }()
+
+	writeIf := func(pid, ifindex, iflink string) {
+		dir := filepath.Join(tmpDir, pid, "root", "sys", "class", "net", "eth0")
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		_ = os.WriteFile(filepath.Join(dir, "ifindex"), []byte(ifindex+"\n"), 0o644)
+		_ = os.WriteFile(filepath.Join(dir, "iflink"), []byte(iflink+"\n"), 0o644)
+	}
+	writeIf("100", "3", "27")
+	writeIf("200", "2", "2")
+
+	if got, err := VethIfindex(100); err != nil || got != 27 {
+		t.Errorf("VethIfindex(100) = %d, %v; want 27", got, err)
+	}
+	if _, err := VethIfindex(200); err == nil {
+		t.Error("expected an error for a host-network interface")
+	}
+	if _, err := VethIfindex(300); err == nil {
+		t.Error("expected an error for a missing process")
+	}
+}
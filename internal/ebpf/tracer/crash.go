@@ -0,0 +1,26 @@
+package tracer
+
+import (
+	"context"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/coredump"
+	"github.com/podtrace/podtrace/internal/diagnose/stacktrace"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// corePattern is read on every crash: it is cheap and an operator may
+// change it mid-session.
+var corePattern = coredump.Pattern
+
+// enrichCrash records where the core of a crashed process went and
+// symbolizes its top frames. The kprobe fires while the process is still
+// dumping, so /proc/<pid> exists now but not by the time a report is built.
+func enrichCrash(ctx context.Context, e *events.Event) {
+	if e == nil || e.Type != events.EventCrash {
+		return
+	}
+	dest := coredump.Destination(corePattern(), e.PID, e.Target, e.Error, e.TimestampTime())
+	frames := stacktrace.ResolveFrames(ctx, e.PID, e.Stack, config.MaxStackFramesLimit)
+	e.Details = coredump.FormatDetails(dest, frames)
+}
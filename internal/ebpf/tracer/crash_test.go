@@ -0,0 +1,31 @@
+package tracer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/coredump"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestEnrichCrash(t *testing.T) {
+	orig := corePattern
+	corePattern = func() string { return "/var/cores/core.%e.%P" }
+	t.Cleanup(func() { corePattern = orig })
+
+	e := &events.Event{Type: events.EventCrash, PID: 77, Target: "api", Error: 11, Stack: []uint64{0x401000}}
+	enrichCrash(context.Background(), e)
+	dest, frames := coredump.ParseDetails(e.Details)
+	if dest != "/var/cores/core.api.77" {
+		t.Errorf("core destination = %q", dest)
+	}
+	if len(frames) != 1 || frames[0] == "" {
+		t.Errorf("frames = %v", frames)
+	}
+
+	other := &events.Event{Type: events.EventExec, Details: "keep"}
+	enrichCrash(context.Background(), other)
+	if other.Details != "keep" {
+		t.Errorf("non-crash event modified: %q", other.Details)
+	}
+}
@@ -0,0 +1,115 @@
+package tracer
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// fsnotifyValue mirrors struct fsnotify_value in bpf/maps.h.
+type fsnotifyValue struct {
+	Watches   uint64
+	Marks     uint64
+	LimitHits uint64
+	Overflows uint64
+}
+
+// runFsNotifyPoller turns the fsnotify_stats counters into one EventFsNotify
+// per target cgroup and interval with inotify or fanotify activity.
+func (t *Tracer) runFsNotifyPoller(ctx context.Context, eventChan chan<- *events.Event) {
+	if t.collection == nil || t.collection.Maps["fsnotify_stats"] == nil {
+		return
+	}
+	interval := config.FsNotifyInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := make(map[uint64]fsnotifyValue)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, err := t.readFsNotifyCounters()
+			if err != nil {
+				logger.Debug("fsnotify_stats iterate error", zap.Error(err))
+				continue
+			}
+			for _, ev := range t.fsnotifyEvents(last, current, interval) {
+				select {
+				case <-ctx.Done():
+					return
+				case eventChan <- ev:
+				default:
+				}
+			}
+			last = current
+		}
+	}
+}
+
+func (t *Tracer) readFsNotifyCounters() (map[uint64]fsnotifyValue, error) {
+	current := make(map[uint64]fsnotifyValue)
+	var cgid uint64
+	var perCPU []fsnotifyValue
+	iter := t.collection.Maps["fsnotify_stats"].Iterate()
+	for iter.Next(&cgid, &perCPU) {
+		var sum fsnotifyValue
+		for _, v := range perCPU {
+			sum.Watches += v.Watches
+			sum.Marks += v.Marks
+			sum.LimitHits += v.LimitHits
+			sum.Overflows += v.Overflows
+		}
+		current[cgid] = sum
+	}
+	return current, iter.Err()
+}
+
+// fsnotifyEvents turns the counter deltas since last into events, for the
+// cgroups still traced, like pageCacheEvents.
+func (t *Tracer) fsnotifyEvents(last, current map[uint64]fsnotifyValue, interval time.Duration) []*events.Event {
+	t.cgroupWriteMu.Lock()
+	paths := append([]string(nil), t.cgroupPaths...)
+	t.cgroupWriteMu.Unlock()
+
+	now := monotonicNowNS()
+	var out []*events.Event
+	for _, p := range paths {
+		cgid, err := getCgroupIDFromPath(p)
+		if err != nil {
+			continue
+		}
+		cur, ok := current[cgid]
+		if !ok {
+			continue
+		}
+		prev := last[cgid]
+		if cur.Watches < prev.Watches || cur.Marks < prev.Marks || cur.LimitHits < prev.LimitHits || cur.Overflows < prev.Overflows {
+			prev = fsnotifyValue{}
+		}
+		if cur == prev {
+			continue
+		}
+		counts := events.FsNotifyCounts{
+			Watches:   cur.Watches - prev.Watches,
+			Marks:     cur.Marks - prev.Marks,
+			LimitHits: cur.LimitHits - prev.LimitHits,
+			Overflows: cur.Overflows - prev.Overflows,
+		}
+		out = append(out, &events.Event{
+			Timestamp: now,
+			PID:       firstPIDUnder(p),
+			CgroupID:  cgid,
+			Type:      events.EventFsNotify,
+			LatencyNS: uint64(interval),
+			Target:    p,
+			Details:   counts.String(),
+		})
+	}
+	return out
+}
@@ -0,0 +1,52 @@
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestFsNotifyEventsEmitIntervalDeltas(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	dir := filepath.Join(base, "pod1")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cgid, err := getCgroupIDFromPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &Tracer{cgroupPaths: []string{dir}}
+
+	last := map[uint64]fsnotifyValue{cgid: {Watches: 100}}
+	current := map[uint64]fsnotifyValue{
+		cgid:     {Watches: 2100, Marks: 1, LimitHits: 3, Overflows: 9},
+		cgid + 1: {Watches: 5},
+	}
+	got := tr.fsnotifyEvents(last, current, 10*time.Second)
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want 1", len(got))
+	}
+	e := got[0]
+	if e.Type != events.EventFsNotify || e.CgroupID != cgid || e.Target != dir || e.LatencyNS != uint64(10*time.Second) {
+		t.Errorf("event = %+v", e)
+	}
+	want := events.FsNotifyCounts{Watches: 2000, Marks: 1, LimitHits: 3, Overflows: 9}
+	if c := events.ParseFsNotifyCounts(e.Details); c != want {
+		t.Errorf("counts = %+v, want %+v", c, want)
+	}
+
+	if got := tr.fsnotifyEvents(current, current, time.Second); len(got) != 0 {
+		t.Errorf("idle interval produced %d events", len(got))
+	}
+	// An overflow alone, without registrations, still produces an event.
+	next := map[uint64]fsnotifyValue{cgid: {Watches: 2100, Marks: 1, LimitHits: 3, Overflows: 10}}
+	got = tr.fsnotifyEvents(current, next, time.Second)
+	if len(got) != 1 || events.ParseFsNotifyCounts(got[0].Details).Overflows != 1 {
+		t.Errorf("overflow-only events = %+v", got)
+	}
+}
@@ -0,0 +1,253 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/cache"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/procfs"
+	"github.com/podtrace/podtrace/internal/resource"
+)
+
+// LowPrivilegeDisabled lists what low-privilege mode cannot observe, for
+// the startup log and the report.
+var LowPrivilegeDisabled = []string{
+	"network: connects, TCP/UDP latency, retransmits, DNS and TLS",
+	"application protocols: HTTP, gRPC, databases, Kafka and other uprobes",
+	"file system and syscall latency",
+	"scheduler run queue, off-CPU and lock waits, stack traces",
+	"OOM kills, process lifecycle and signals",
+	"CPU throttling from the BPF quota sampler (cgroup cpu.stat is still read)",
+}
+
+// LowPrivilegeTracer collects what can be observed without loading a single
+// BPF program, for clusters that forbid CAP_BPF and CAP_SYS_ADMIN: the
+// resource limits and usage of the target cgroups, read from cgroupfs, and
+// the CPU time of their threads, sampled from /proc.
+type LowPrivilegeTracer struct {
+	mu      sync.Mutex
+	cgroups []string
+	ids     map[string]uint64
+
+	resourceMgr *resourceMonitorManager
+	// names resolves process and thread names with the same caches as the
+	// BPF tracer.
+	names  *Tracer
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ TracerInterface = (*LowPrivilegeTracer)(nil)
+
+// NewLowPrivilegeTracer returns a tracer that needs no capabilities beyond
+// reading /proc and the cgroup filesystem.
+func NewLowPrivilegeTracer() *LowPrivilegeTracer {
+	ttl := time.Duration(config.CacheTTLSeconds) * time.Second
+	return &LowPrivilegeTracer{
+		ids:         make(map[string]uint64),
+		resourceMgr: newResourceMonitorManager(),
+		names: &Tracer{
+			processNameCache: cache.NewLRUCache(config.CacheMaxSize, ttl),
+			threadNameCache:  cache.NewLRUCache(config.CacheMaxSize, ttl),
+		},
+	}
+}
+
+// SetCgroups replaces the monitored cgroups.
+func (t *LowPrivilegeTracer) SetCgroups(cgroupPaths []string) error {
+	t.mu.Lock()
+	t.cgroups = nil
+	t.ids = make(map[string]uint64, len(cgroupPaths))
+	for _, p := range cgroupPaths {
+		if p == "" {
+			continue
+		}
+		id, err := getCgroupIDFromPath(p)
+		if err != nil {
+			t.mu.Unlock()
+			return fmt.Errorf("cgroup %s: %w", p, err)
+		}
+		t.cgroups = append(t.cgroups, p)
+		t.ids[p] = id
+	}
+	paths := append([]string(nil), t.cgroups...)
+	t.mu.Unlock()
+	t.resourceMgr.reconcile(paths)
+	return nil
+}
+
+// AttachToCgroup adds one cgroup to the monitored set.
+func (t *LowPrivilegeTracer) AttachToCgroup(cgroupPath string) error {
+	t.mu.Lock()
+	paths := append(append([]string(nil), t.cgroups...), cgroupPath)
+	t.mu.Unlock()
+	return t.SetCgroups(paths)
+}
+
+// SetContainerID is a no-op: without uprobes there is nothing to attach
+// inside the container.
+func (t *LowPrivilegeTracer) SetContainerID(string) error { return nil }
+
+// SetContainerTargets is a no-op, see SetContainerID.
+func (t *LowPrivilegeTracer) SetContainerTargets([]ContainerProbeTarget) error { return nil }
+
+// Start begins monitoring the cgroups set so far and sampling their threads.
+func (t *LowPrivilegeTracer) Start(ctx context.Context, eventChan chan<- *events.Event) error {
+	ctx, cancel := context.WithCancel(ctx)
+	t.mu.Lock()
+	t.cancel = cancel
+	t.mu.Unlock()
+
+	t.resourceMgr.activateUnprivileged(ctx, eventChan)
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.runThreadCPUSampler(ctx, eventChan)
+	}()
+	logger.Warn("Low-privilege mode: no BPF program is loaded; only cgroup resource usage and per-thread CPU time from /proc are collected",
+		zap.Strings("disabled", LowPrivilegeDisabled))
+	return nil
+}
+
+// Stop ends the sampling and the resource monitors.
+func (t *LowPrivilegeTracer) Stop() error {
+	t.mu.Lock()
+	cancel := t.cancel
+	t.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	t.wg.Wait()
+	t.resourceMgr.stopAll()
+	t.names.processNameCache.Close()
+	t.names.threadNameCache.Close()
+	return nil
+}
+
+// runThreadCPUSampler emits the same EventThreadCPU events as the BPF
+// thread_cpu_time poller, from the utime and stime of every thread of the
+// target cgroups. Their resolution is a clock tick, not a context switch.
+func (t *LowPrivilegeTracer) runThreadCPUSampler(ctx context.Context, eventChan chan<- *events.Event) {
+	ticker := time.NewTicker(config.ThreadCPUInterval)
+	defer ticker.Stop()
+	last := t.sampleThreadCPU()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := t.sampleThreadCPU()
+			for _, ev := range t.names.threadCPUEvents(last, current) {
+				select {
+				case <-ctx.Done():
+					return
+				case eventChan <- ev:
+				default:
+				}
+			}
+			last = current
+		}
+	}
+}
+
+func (t *LowPrivilegeTracer) sampleThreadCPU() map[uint32]threadCPUValue {
+	t.mu.Lock()
+	ids := make(map[string]uint64, len(t.ids))
+	for p, id := range t.ids {
+		ids[p] = id
+	}
+	t.mu.Unlock()
+
+	tickNS := uint64(time.Second) / userHZ
+	out := make(map[uint32]threadCPUValue)
+	for path, id := range ids {
+		for _, pid := range readPIDsFromCgroupProcs(path) {
+			for tid, ticks := range readThreadTicks(pid) {
+				out[tid] = threadCPUValue{CgroupID: id, PID: pid, OnCPUNS: ticks * tickNS}
+			}
+		}
+	}
+	return out
+}
+
+// readThreadTicks returns utime+stime, in clock ticks, of every thread of
+// pid from /proc/<pid>/task/<tid>/stat.
+func readThreadTicks(pid uint32) map[uint32]uint64 {
+	dir, err := procfs.Open(fmt.Sprintf("%d/task", pid))
+	if err != nil {
+		return nil
+	}
+	names, err := dir.Readdirnames(-1)
+	_ = dir.Close()
+	if err != nil {
+		return nil
+	}
+	out := make(map[uint32]uint64, len(names))
+	for _, name := range names {
+		tid, err := strconv.ParseUint(name, 10, 32)
+		if err != nil {
+			continue
+		}
+		data, err := procfs.ReadFile(fmt.Sprintf("%d/task/%d/stat", pid, tid))
+		if err != nil {
+			continue
+		}
+		if ticks, ok := parseStatCPUTicks(string(data)); ok {
+			out[uint32(tid)] = ticks
+		}
+	}
+	return out
+}
+
+// parseStatCPUTicks returns utime+stime, fields 14 and 15 of a stat line.
+// The command name in field 2 may contain spaces, so fields are counted
+// from its closing parenthesis.
+func parseStatCPUTicks(stat string) (uint64, bool) {
+	rp := strings.LastIndexByte(stat, ')')
+	if rp < 0 {
+		return 0, false
+	}
+	fields := strings.Fields(stat[rp+1:])
+	if len(fields) < 13 {
+		return 0, false
+	}
+	utime, err1 := strconv.ParseUint(fields[11], 10, 64)
+	stime, err2 := strconv.ParseUint(fields[12], 10, 64)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return utime + stime, true
+}
+
+// userHZ is USER_HZ, the unit of the stat CPU times, which the Linux ABI
+// fixes at 100 on every architecture podtrace runs on.
+const userHZ = 100
+
+// activateUnprivileged starts the monitors without the BPF limit and alert
+// maps: usage is read from cgroupfs and alerts are raised from userspace.
+func (m *resourceMonitorManager) activateUnprivileged(ctx context.Context, eventChan chan<- *events.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.startMonitor == nil {
+		m.startMonitor = func(path string) (stoppable, error) {
+			rm, err := resource.NewResourceMonitor(path, nil, nil, eventChan, "")
+			if err != nil {
+				return nil, err
+			}
+			rm.EnableUserspaceAlerts()
+			rm.Start(ctx)
+			return rm, nil
+		}
+	}
+	m.active = true
+	m.reconcileLocked()
+}
@@ -0,0 +1,63 @@
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+func TestParseStatCPUTicks(t *testing.T) {
+	stat := "43 (worker (1)) S 1 43 43 0 -1 4194560 100 0 0 0 250 75 0 0 20 0 4 0 100 0 0"
+	ticks, ok := parseStatCPUTicks(stat)
+	if !ok || ticks != 325 {
+		t.Errorf("parseStatCPUTicks = %d, %v; want 325, true", ticks, ok)
+	}
+	if _, ok := parseStatCPUTicks("43 (short) S 1"); ok {
+		t.Error("expected a truncated stat line to be rejected")
+	}
+}
+
+func TestReadThreadTicks(t *testing.T) {
+	procBase := t.TempDir()
+	for tid, stat := range map[string]string{
+		"42": "42 (app) S 1 42 42 0 -1 0 0 0 0 0 10 5 0 0 20 0 2 0 1 0 0",
+		"43": "43 (worker) R 1 42 42 0 -1 0 0 0 0 0 200 40 0 0 20 0 2 0 1 0 0",
+	} {
+		dir := filepath.Join(procBase, "42", "task", tid)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	oldProc := config.ProcBasePath
+	config.SetProcBasePath(procBase)
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.SetProcBasePath(oldProc)
+		procfs.ResetForTesting()
+	})
+
+	got := readThreadTicks(42)
+	if len(got) != 2 || got[42] != 15 || got[43] != 240 {
+		t.Errorf("readThreadTicks = %v", got)
+	}
+	if got := readThreadTicks(99); got != nil {
+		t.Errorf("missing process: got %v", got)
+	}
+}
+
+func TestLowPrivilegeTracerNoContainerProbes(t *testing.T) {
+	tr := NewLowPrivilegeTracer()
+	defer func() { _ = tr.Stop() }()
+	if err := tr.SetContainerID("abc"); err != nil {
+		t.Errorf("SetContainerID: %v", err)
+	}
+	if err := tr.SetCgroups([]string{filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected an error for a cgroup that does not exist")
+	}
+}
@@ -0,0 +1,44 @@
+package tracer
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/sqldigest"
+)
+
+// enrichMySQLWire rewrites a DB query event produced by the socket-level
+// MySQL parser (Details "mysql:<kind>[ sqlstate=X][ server=V]") the same way
+// enrichPGWire does for Postgres. Prepared statements are resolved in the
+// kernel; an EXECUTE whose prepare was not seen carries its statement id in
+// Bytes.
+func enrichMySQLWire(e *events.Event) {
+	if e == nil || e.Type != events.EventDBQuery || !strings.HasPrefix(e.Details, "mysql:") || len(e.Details) < 7 {
+		return
+	}
+	kind := e.Details[6]
+	var sqlstate, server string
+	for _, f := range strings.Fields(e.Details[7:]) {
+		if v, ok := strings.CutPrefix(f, "sqlstate="); ok {
+			sqlstate = v
+		} else if v, ok := strings.CutPrefix(f, "server="); ok {
+			server = v
+		}
+	}
+
+	if kind == 'E' && e.Target == "" {
+		e.Target = "EXECUTE " + strconv.FormatUint(e.Bytes, 10)
+	} else {
+		e.Target = sqldigest.Normalize(e.Target)
+	}
+	e.Bytes = 0
+
+	e.Details = "mysql digest=" + sqldigest.Digest(e.Target)
+	if sqlstate != "" {
+		e.Details += " sqlstate=" + sqlstate
+	}
+	if server != "" {
+		e.Details += " server=" + server
+	}
+}
@@ -0,0 +1,40 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestEnrichMySQLWire(t *testing.T) {
+	q := &events.Event{Type: events.EventDBQuery, Target: "SELECT * FROM users WHERE email = 'a@b.c'", Details: "mysql:Q server=8.0.36"}
+	enrichMySQLWire(q)
+	if q.Target != "SELECT * FROM users WHERE email = ?" {
+		t.Errorf("query not normalized: %q", q.Target)
+	}
+	if !strings.HasPrefix(q.Details, "mysql digest=") || !strings.HasSuffix(q.Details, " server=8.0.36") {
+		t.Errorf("details = %q", q.Details)
+	}
+
+	failed := &events.Event{Type: events.EventDBQuery, Target: "INSERT INTO t (id) VALUES (1, 2)", Error: 1062, Details: "mysql:E sqlstate=23000", Bytes: 3}
+	enrichMySQLWire(failed)
+	if failed.Target != "INSERT INTO t (id) VALUES (?)" || failed.Bytes != 0 {
+		t.Errorf("resolved execute: %+v", failed)
+	}
+	if !strings.HasSuffix(failed.Details, " sqlstate=23000") {
+		t.Errorf("sqlstate dropped: %q", failed.Details)
+	}
+
+	unknown := &events.Event{Type: events.EventDBQuery, Details: "mysql:E", Bytes: 42}
+	enrichMySQLWire(unknown)
+	if unknown.Target != "EXECUTE 42" {
+		t.Errorf("unresolved execute target = %q", unknown.Target)
+	}
+
+	libmysql := &events.Event{Type: events.EventDBQuery, Target: "SELECT 1"}
+	enrichMySQLWire(libmysql)
+	if libmysql.Target != "SELECT 1" || libmysql.Details != "" {
+		t.Errorf("libmysqlclient events must be left alone: %+v", libmysql)
+	}
+}
@@ -0,0 +1,113 @@
+package tracer
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// readCacheValue mirrors struct read_cache_value in bpf/maps.h.
+type readCacheValue struct {
+	Reads      uint64
+	MissReads  uint64
+	MissFolios uint64
+}
+
+// runPageCachePoller turns the read_cache_stats counters into one
+// EventPageCache per target cgroup and interval that read regular files.
+func (t *Tracer) runPageCachePoller(ctx context.Context, eventChan chan<- *events.Event) {
+	if t.collection == nil || t.collection.Maps["read_cache_stats"] == nil {
+		return
+	}
+	interval := config.PageCacheInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	last := make(map[uint64]readCacheValue)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, err := t.readPageCacheCounters()
+			if err != nil {
+				logger.Debug("read_cache_stats iterate error", zap.Error(err))
+				continue
+			}
+			for _, ev := range t.pageCacheEvents(last, current, interval) {
+				select {
+				case <-ctx.Done():
+					return
+				case eventChan <- ev:
+				default:
+				}
+			}
+			last = current
+		}
+	}
+}
+
+func (t *Tracer) readPageCacheCounters() (map[uint64]readCacheValue, error) {
+	current := make(map[uint64]readCacheValue)
+	var cgid uint64
+	var perCPU []readCacheValue
+	iter := t.collection.Maps["read_cache_stats"].Iterate()
+	for iter.Next(&cgid, &perCPU) {
+		var sum readCacheValue
+		for _, v := range perCPU {
+			sum.Reads += v.Reads
+			sum.MissReads += v.MissReads
+			sum.MissFolios += v.MissFolios
+		}
+		current[cgid] = sum
+	}
+	return current, iter.Err()
+}
+
+// pageCacheEvents turns the counter deltas since last into events, for the
+// cgroups still traced. A counter that went backwards (the LRU evicted and
+// re-created the entry) starts over from zero.
+func (t *Tracer) pageCacheEvents(last, current map[uint64]readCacheValue, interval time.Duration) []*events.Event {
+	t.cgroupWriteMu.Lock()
+	paths := append([]string(nil), t.cgroupPaths...)
+	t.cgroupWriteMu.Unlock()
+
+	now := monotonicNowNS()
+	var out []*events.Event
+	for _, p := range paths {
+		cgid, err := getCgroupIDFromPath(p)
+		if err != nil {
+			continue
+		}
+		cur, ok := current[cgid]
+		if !ok {
+			continue
+		}
+		prev := last[cgid]
+		if cur.Reads < prev.Reads || cur.MissReads < prev.MissReads || cur.MissFolios < prev.MissFolios {
+			prev = readCacheValue{}
+		}
+		if cur.Reads == prev.Reads {
+			continue
+		}
+		counts := events.PageCacheCounts{
+			Reads:      cur.Reads - prev.Reads,
+			MissReads:  cur.MissReads - prev.MissReads,
+			MissFolios: cur.MissFolios - prev.MissFolios,
+		}
+		out = append(out, &events.Event{
+			Timestamp: now,
+			PID:       firstPIDUnder(p),
+			CgroupID:  cgid,
+			Type:      events.EventPageCache,
+			LatencyNS: uint64(interval),
+			Target:    p,
+			Details:   counts.String(),
+		})
+	}
+	return out
+}
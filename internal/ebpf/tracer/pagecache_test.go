@@ -0,0 +1,55 @@
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestPageCacheEventsEmitIntervalDeltas(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	dir := filepath.Join(base, "pod1")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte("42\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cgid, err := getCgroupIDFromPath(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := &Tracer{cgroupPaths: []string{dir}}
+
+	last := map[uint64]readCacheValue{cgid: {Reads: 100, MissReads: 10, MissFolios: 40}}
+	current := map[uint64]readCacheValue{
+		cgid: {Reads: 300, MissReads: 15, MissFolios: 60},
+		// Counters of cgroups no longer traced are ignored.
+		cgid + 1: {Reads: 5},
+	}
+	got := tr.pageCacheEvents(last, current, 10*time.Second)
+	if len(got) != 1 {
+		t.Fatalf("got %d events, want 1", len(got))
+	}
+	e := got[0]
+	if e.Type != events.EventPageCache || e.CgroupID != cgid || e.PID != 42 || e.Target != dir || e.LatencyNS != uint64(10*time.Second) {
+		t.Errorf("event = %+v", e)
+	}
+	if c := events.ParsePageCacheCounts(e.Details); c != (events.PageCacheCounts{Reads: 200, MissReads: 5, MissFolios: 20}) {
+		t.Errorf("counts = %+v", c)
+	}
+
+	// No new reads, no event; a reset counter counts from zero.
+	if got := tr.pageCacheEvents(current, current, time.Second); len(got) != 0 {
+		t.Errorf("idle interval produced %d events", len(got))
+	}
+	reset := map[uint64]readCacheValue{cgid: {Reads: 7, MissReads: 1, MissFolios: 2}}
+	got = tr.pageCacheEvents(current, reset, time.Second)
+	if len(got) != 1 || events.ParsePageCacheCounts(got[0].Details).Reads != 7 {
+		t.Errorf("reset counter events = %+v", got)
+	}
+}
@@ -0,0 +1,86 @@
+package tracer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/sqldigest"
+)
+
+// pgStatementCacheMax bounds the prepared-statement name cache; it is reset
+// when full rather than evicted entry by entry.
+const pgStatementCacheMax = 4096
+
+type pgStatementKey struct {
+	pid  uint32
+	name string
+}
+
+// pgStatementCache remembers the normalized text of named prepared
+// statements seen in Parse messages, so later Bind-only executions (the
+// common case for pgx and JDBC statement caches) report the statement rather
+// than its name. The zero value is ready to use.
+type pgStatementCache struct {
+	mu    sync.Mutex
+	stmts map[pgStatementKey]string
+}
+
+func (c *pgStatementCache) store(pid uint32, name, normalized string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stmts == nil || len(c.stmts) >= pgStatementCacheMax {
+		c.stmts = make(map[pgStatementKey]string)
+	}
+	c.stmts[pgStatementKey{pid, name}] = normalized
+}
+
+func (c *pgStatementCache) lookup(pid uint32, name string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.stmts[pgStatementKey{pid, name}]
+	return s, ok
+}
+
+// enrichPGWire rewrites a DB query event produced by the socket-level
+// Postgres parser (Details "pg:<kind>[ sqlstate=X][ stmt=N]") so that Target
+// holds the literal-free statement and Details its digest. Events from the
+// libpq/MySQL uprobes are left alone.
+func (c *pgStatementCache) enrichPGWire(e *events.Event) {
+	if e == nil || e.Type != events.EventDBQuery || !strings.HasPrefix(e.Details, "pg:") || len(e.Details) < 4 {
+		return
+	}
+	kind := e.Details[3]
+	var sqlstate, stmt string
+	for _, f := range strings.Fields(e.Details[4:]) {
+		if v, ok := strings.CutPrefix(f, "sqlstate="); ok {
+			sqlstate = v
+		} else if v, ok := strings.CutPrefix(f, "stmt="); ok {
+			stmt = v
+		}
+	}
+
+	switch kind {
+	case 'B':
+		if s, ok := c.lookup(e.PID, e.Target); ok {
+			e.Target = s
+		} else if e.Target == "" {
+			e.Target = "EXECUTE <unnamed prepared statement>"
+		} else {
+			e.Target = "EXECUTE " + e.Target
+		}
+	case 'P':
+		e.Target = sqldigest.Normalize(e.Target)
+		if stmt != "" {
+			c.store(e.PID, stmt, e.Target)
+		}
+	default:
+		e.Target = sqldigest.Normalize(e.Target)
+	}
+
+	e.Details = fmt.Sprintf("postgres digest=%s", sqldigest.Digest(e.Target))
+	if sqlstate != "" {
+		e.Details += " sqlstate=" + sqlstate
+	}
+}
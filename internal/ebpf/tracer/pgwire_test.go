@@ -0,0 +1,41 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestEnrichPGWire(t *testing.T) {
+	var c pgStatementCache
+
+	q := &events.Event{Type: events.EventDBQuery, PID: 1, Target: "SELECT * FROM users WHERE id = 7", Details: "pg:Q"}
+	c.enrichPGWire(q)
+	if q.Target != "SELECT * FROM users WHERE id = ?" || !strings.HasPrefix(q.Details, "postgres digest=") {
+		t.Fatalf("simple query not normalized: %+v", q)
+	}
+
+	parse := &events.Event{Type: events.EventDBQuery, PID: 1, Target: "UPDATE t SET v = $1 WHERE id = $2", Details: "pg:P stmt=stmtcache_1"}
+	c.enrichPGWire(parse)
+	bind := &events.Event{Type: events.EventDBQuery, PID: 1, Target: "stmtcache_1", Details: "pg:B sqlstate=23505 stmt=stmtcache_1"}
+	c.enrichPGWire(bind)
+	if bind.Target != parse.Target {
+		t.Errorf("bind should resolve the prepared statement, got %q", bind.Target)
+	}
+	if bind.Details != parse.Details+" sqlstate=23505" {
+		t.Errorf("bind details = %q, want parse digest plus sqlstate (%q)", bind.Details, parse.Details)
+	}
+
+	other := &events.Event{Type: events.EventDBQuery, PID: 2, Target: "stmtcache_1", Details: "pg:B stmt=stmtcache_1"}
+	c.enrichPGWire(other)
+	if other.Target != "EXECUTE stmtcache_1" {
+		t.Errorf("statement names are per process, got %q", other.Target)
+	}
+
+	libpq := &events.Event{Type: events.EventDBQuery, Target: "SELECT"}
+	c.enrichPGWire(libpq)
+	if libpq.Target != "SELECT" || libpq.Details != "" {
+		t.Errorf("libpq events must be left alone: %+v", libpq)
+	}
+}
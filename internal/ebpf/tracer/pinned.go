@@ -0,0 +1,245 @@
+package tracer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/ringbuf"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/cache"
+	"github.com/podtrace/podtrace/internal/ebpf/parser"
+	"github.com/podtrace/podtrace/internal/ebpf/pin"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/metricsexporter"
+	"github.com/podtrace/podtrace/internal/validation"
+)
+
+// pinnedSessionCheckInterval is how often a reader checks that its loader
+// still serves the session.
+const pinnedSessionCheckInterval = 5 * time.Second
+
+// Publish pins the maps a reader needs for session s, handing them to
+// group gid when it is >= 0. The programs stay attached for as long as t
+// is not stopped; t must not be started, the reader consumes its events.
+func (t *Tracer) Publish(s pin.Session, gid int) (*pin.Manifest, error) {
+	if t.collection == nil {
+		return nil, errors.New("tracer has no loaded collection")
+	}
+	maps := make(map[string]pin.Pinnable, len(pin.ReaderMaps))
+	for _, name := range pin.ReaderMaps {
+		if m := t.collection.Maps[name]; m != nil {
+			maps[name] = m
+		}
+	}
+	t.cgroupWriteMu.Lock()
+	cgroups := append([]string(nil), t.cgroupPaths...)
+	t.cgroupWriteMu.Unlock()
+	return pin.Publish(s, maps, cgroups, gid)
+}
+
+// PinnedReader consumes the events of a session pinned by a privileged
+// `podtrace loader`, so the long-running process needs no capability. The
+// loader owns the programs, probes and cgroup filter; the reader adds what
+// userspace can: process names from /proc and resource usage from cgroupfs.
+type PinnedReader struct {
+	session  pin.Session
+	manifest *pin.Manifest
+	events   *ebpf.Map
+	stacks   *ebpf.Map
+	reader   *ringbuf.Reader
+
+	mu          sync.Mutex
+	cgroups     []string
+	resourceMgr *resourceMonitorManager
+	names       *Tracer
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+var _ TracerInterface = (*PinnedReader)(nil)
+
+// NewPinnedReader opens the maps of session name under config.PinDir.
+func NewPinnedReader(name string) (*PinnedReader, error) {
+	s, err := pin.NewSession(config.PinDir, config.PinStateDir, name)
+	if err != nil {
+		return nil, err
+	}
+	m, err := pin.Open(s)
+	if err != nil {
+		return nil, err
+	}
+	eventsMap, err := ebpf.LoadPinnedMap(m.MapPath("events"), nil)
+	if err != nil {
+		return nil, fmt.Errorf("open pinned events map (is this user in the loader's --reader-group?): %w", err)
+	}
+	var stacksMap *ebpf.Map
+	if slices.Contains(m.Maps, "stack_traces") {
+		if stacksMap, err = ebpf.LoadPinnedMap(m.MapPath("stack_traces"), nil); err != nil {
+			logger.Warn("Pinned stack map not readable, events will carry no stack", zap.Error(err))
+			stacksMap = nil
+		}
+	}
+	rd, err := ringbuf.NewReader(eventsMap)
+	if err != nil {
+		_ = eventsMap.Close()
+		if stacksMap != nil {
+			_ = stacksMap.Close()
+		}
+		return nil, fmt.Errorf("map pinned events ring buffer: %w", err)
+	}
+	ttl := time.Duration(config.CacheTTLSeconds) * time.Second
+	return &PinnedReader{
+		session:     s,
+		manifest:    m,
+		events:      eventsMap,
+		stacks:      stacksMap,
+		reader:      rd,
+		resourceMgr: newResourceMonitorManager(),
+		names: &Tracer{
+			processNameCache:              cache.NewLRUCache(config.CacheMaxSize, ttl),
+			threadNameCache:               cache.NewLRUCache(config.CacheMaxSize, ttl),
+			attributionCorrelatorDisabled: true,
+		},
+	}, nil
+}
+
+// SetCgroups sets the cgroups whose resource usage is monitored. Which
+// cgroups are traced was fixed by the loader; others are only warned about.
+func (r *PinnedReader) SetCgroups(cgroupPaths []string) error {
+	var paths []string
+	for _, p := range cgroupPaths {
+		if p == "" {
+			continue
+		}
+		if !slices.Contains(r.manifest.Cgroups, p) {
+			logger.Warn("Cgroup is not traced by the loader of this session; only its resource usage is monitored",
+				zap.String("session", r.session.Name), zap.String("cgroup_path", p))
+		}
+		paths = append(paths, p)
+	}
+	r.mu.Lock()
+	r.cgroups = paths
+	r.mu.Unlock()
+	r.resourceMgr.reconcile(paths)
+	return nil
+}
+
+// AttachToCgroup adds one cgroup, see SetCgroups.
+func (r *PinnedReader) AttachToCgroup(cgroupPath string) error {
+	r.mu.Lock()
+	paths := append(append([]string(nil), r.cgroups...), cgroupPath)
+	r.mu.Unlock()
+	return r.SetCgroups(paths)
+}
+
+// SetContainerID is a no-op: the loader attaches the container's uprobes.
+func (r *PinnedReader) SetContainerID(string) error { return nil }
+
+// SetContainerTargets is a no-op, see SetContainerID.
+func (r *PinnedReader) SetContainerTargets([]ContainerProbeTarget) error { return nil }
+
+// Start reads the pinned ring buffer until ctx is done, the reader is
+// stopped or the loader removes the session.
+func (r *PinnedReader) Start(ctx context.Context, eventChan chan<- *events.Event) error {
+	ctx, cancel := context.WithCancel(ctx)
+	r.mu.Lock()
+	r.cancel = cancel
+	r.mu.Unlock()
+
+	r.resourceMgr.activateUnprivileged(ctx, eventChan)
+	logger.Info("Reading pinned session",
+		zap.String("session", r.session.Name),
+		zap.Int("loader_pid", r.manifest.LoaderPID),
+		zap.Strings("cgroups", r.manifest.Cgroups))
+
+	r.wg.Add(2)
+	go func() {
+		defer r.wg.Done()
+		r.watchSession(ctx)
+	}()
+	go func() {
+		defer r.wg.Done()
+		r.readEvents(ctx, eventChan)
+	}()
+	return nil
+}
+
+func (r *PinnedReader) readEvents(ctx context.Context, eventChan chan<- *events.Event) {
+	for {
+		record, err := r.reader.Read()
+		if err != nil {
+			if errors.Is(err, ringbuf.ErrClosed) || errors.Is(err, os.ErrClosed) {
+				return
+			}
+			metricsexporter.RecordRingBufferDrop()
+			logger.Debug("Error reading pinned ring buffer", zap.Error(err))
+			continue
+		}
+		event := parser.ParseEvent(record.RawSample)
+		if event == nil {
+			continue
+		}
+		resolveAndConsumeStack(r.stacks, event)
+		r.names.attributeProcessName(event)
+		event.ProcessName = validation.SanitizeProcessName(event.ProcessName)
+		select {
+		case <-ctx.Done():
+			return
+		case eventChan <- event:
+		default:
+			metricsexporter.RecordRingBufferDrop()
+		}
+	}
+}
+
+// watchSession closes the ring buffer, ending readEvents, once ctx is done
+// or the loader has removed the session: its programs are detached and no
+// event will come.
+func (r *PinnedReader) watchSession(ctx context.Context) {
+	ticker := time.NewTicker(pinnedSessionCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			_ = r.reader.Close()
+			return
+		case <-ticker.C:
+			if !pin.Exists(r.session) {
+				logger.Warn("The loader removed the pinned session; no more events will arrive",
+					zap.String("session", r.session.Name))
+				_ = r.reader.Close()
+				return
+			}
+		}
+	}
+}
+
+// Stop ends the reading and the resource monitors and releases the maps.
+// The session stays pinned for the loader to remove.
+func (r *PinnedReader) Stop() error {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	_ = r.reader.Close()
+	r.wg.Wait()
+	r.resourceMgr.stopAll()
+	if r.stacks != nil {
+		_ = r.stacks.Close()
+	}
+	_ = r.events.Close()
+	r.names.processNameCache.Close()
+	r.names.threadNameCache.Close()
+	return nil
+}
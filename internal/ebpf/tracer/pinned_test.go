@@ -0,0 +1,36 @@
+package tracer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/pin"
+)
+
+func TestPublish_WithoutCollection(t *testing.T) {
+	s, err := pin.NewSession(t.TempDir(), t.TempDir(), "s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := (&Tracer{}).Publish(s, -1); err == nil {
+		t.Fatal("expected an error without a loaded collection")
+	}
+	if pin.Exists(s) {
+		t.Error("manifest written for an unpinned session")
+	}
+}
+
+func TestNewPinnedReader_NoSession(t *testing.T) {
+	origPin, origState := config.PinDir, config.PinStateDir
+	t.Cleanup(func() { config.PinDir, config.PinStateDir = origPin, origState })
+	config.PinDir, config.PinStateDir = t.TempDir(), t.TempDir()
+
+	_, err := NewPinnedReader("missing")
+	if err == nil || !strings.Contains(err.Error(), "podtrace loader") {
+		t.Fatalf("err = %v, want a hint to start the loader", err)
+	}
+	if _, err := NewPinnedReader("a/b"); err == nil {
+		t.Fatal("expected an invalid session name to be rejected")
+	}
+}
@@ -0,0 +1,116 @@
+package tracer
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/numa"
+)
+
+// placementSampler remembers the cumulative counters of the previous sample
+// so each event covers one interval.
+type placementSampler struct {
+	topo      *numa.Topology
+	runDelay  map[uint32]uint64
+	allocs    numa.NodeAllocations
+	hasAllocs bool
+}
+
+// runPlacementSampler emits one EventCPUPlacement per target process and
+// interval when PODTRACE_CPU_PLACEMENT is on. It needs no BPF program: the
+// placement comes from /proc and the node topology from /sys.
+func (t *Tracer) runPlacementSampler(ctx context.Context, eventChan chan<- *events.Event) {
+	if !config.CPUPlacement {
+		return
+	}
+	topo, err := numa.ReadTopology()
+	if err != nil {
+		logger.Warn("CPU placement sampling disabled: cannot read the CPU topology", zap.Error(err))
+		return
+	}
+	s := &placementSampler{topo: topo, runDelay: make(map[uint32]uint64)}
+	s.allocs, s.hasAllocs = numa.ReadNodeAllocations()
+
+	interval := config.CPUPlacementInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.cgroupWriteMu.Lock()
+			paths := append([]string(nil), t.cgroupPaths...)
+			t.cgroupWriteMu.Unlock()
+			for _, ev := range s.sample(paths, interval) {
+				ev.ProcessName = t.getProcessNameQuick(ev.PID)
+				select {
+				case <-ctx.Done():
+					return
+				case eventChan <- ev:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// sample reads the main process of every cgroup in paths. Processes seen for
+// the first time only seed the run queue counter.
+func (s *placementSampler) sample(paths []string, interval time.Duration) []*events.Event {
+	allocs, hasAllocs := numa.ReadNodeAllocations()
+	var delta numa.NodeAllocations
+	if hasAllocs && s.hasAllocs && allocs.Local >= s.allocs.Local && allocs.Remote >= s.allocs.Remote {
+		delta = numa.NodeAllocations{Local: allocs.Local - s.allocs.Local, Remote: allocs.Remote - s.allocs.Remote}
+	}
+	s.allocs, s.hasAllocs = allocs, hasAllocs
+
+	now := monotonicNowNS()
+	seen := make(map[uint32]bool)
+	var out []*events.Event
+	for _, p := range paths {
+		pid := firstPIDUnder(p)
+		if pid == 0 || seen[pid] {
+			continue
+		}
+		seen[pid] = true
+		placement, err := numa.ReadPlacement(pid, s.topo)
+		if err != nil {
+			logger.Debug("Cannot read CPU placement", zap.Uint32("pid", pid), zap.Error(err))
+			continue
+		}
+		prev, known := s.runDelay[pid]
+		s.runDelay[pid] = placement.RunDelayNS
+		if !known || placement.RunDelayNS < prev {
+			continue
+		}
+		sample := numa.Sample{
+			Placement:      *placement,
+			IntervalNS:     uint64(interval),
+			RunQueueNS:     placement.RunDelayNS - prev,
+			Allocations:    delta,
+			HasAllocations: hasAllocs,
+		}
+		cgid, _ := getCgroupIDFromPath(p)
+		out = append(out, &events.Event{
+			Timestamp: now,
+			PID:       pid,
+			CgroupID:  cgid,
+			Type:      events.EventCPUPlacement,
+			LatencyNS: sample.RunQueueNS,
+			Target:    placement.CPUs.String(),
+			Details:   numa.FormatDetails(sample),
+		})
+	}
+	for pid := range s.runDelay {
+		if !seen[pid] {
+			delete(s.runDelay, pid)
+		}
+	}
+	return out
+}
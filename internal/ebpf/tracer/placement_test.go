@@ -0,0 +1,68 @@
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/numa"
+	"github.com/podtrace/podtrace/internal/procfs"
+	"github.com/podtrace/podtrace/internal/sysfs"
+)
+
+func TestPlacementSamplerEmitsIntervalDeltas(t *testing.T) {
+	cgroupBase, procBase := t.TempDir(), t.TempDir()
+	cgroupDir := filepath.Join(cgroupBase, "kubepods", "pod1", "ctr")
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(cgroupDir, "cgroup.procs"), "42\n")
+	write(filepath.Join(procBase, "42", "status"), "Cpus_allowed_list:\t2-3\nMems_allowed_list:\t0-1\n")
+	write(filepath.Join(procBase, "42", "numa_maps"), "7f00 default anon=4 N0=3 N1=1 kernelpagesize_kB=4\n")
+	write(filepath.Join(procBase, "42", "schedstat"), "1000 500000000 10\n")
+
+	oldCgroup, oldProc := config.CgroupBasePath, config.ProcBasePath
+	config.SetCgroupBasePath(cgroupBase)
+	config.SetProcBasePath(procBase)
+	sysfs.ResetForTesting()
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.SetCgroupBasePath(oldCgroup)
+		config.SetProcBasePath(oldProc)
+		sysfs.ResetForTesting()
+		procfs.ResetForTesting()
+	})
+
+	s := &placementSampler{
+		topo: &numa.Topology{
+			Nodes:  map[int]numa.CPUSet{0: {0, 1}, 1: {2, 3}},
+			Online: numa.CPUSet{0, 1, 2, 3},
+		},
+		runDelay: make(map[uint32]uint64),
+	}
+	if evs := s.sample([]string{cgroupDir}, time.Second); len(evs) != 0 {
+		t.Fatalf("first sample should only seed counters, got %d events", len(evs))
+	}
+	write(filepath.Join(procBase, "42", "schedstat"), "2000 800000000 20\n")
+	evs := s.sample([]string{cgroupDir, cgroupDir}, time.Second)
+	if len(evs) != 1 {
+		t.Fatalf("got %d events, want 1", len(evs))
+	}
+	ev := evs[0]
+	if ev.Type != events.EventCPUPlacement || ev.PID != 42 || ev.LatencyNS != 300_000_000 || ev.Target != "2-3" {
+		t.Errorf("event = %+v", ev)
+	}
+	got := numa.ParseDetails(ev.Details)
+	if !got.Pinned || got.CPUNodes.String() != "1" || got.RemoteMemory() != 3*4096 || got.IntervalNS != uint64(time.Second) {
+		t.Errorf("sample = %+v", got)
+	}
+}
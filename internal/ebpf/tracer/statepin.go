@@ -0,0 +1,32 @@
+package tracer
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/pin"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// pinState takes over the probe links a previous podtrace left pinned under
+// dir, now that t's own are attached to the same maps, and pins t's links
+// in their place so that a crash leaves them attached.
+func (t *Tracer) pinState(dir string) {
+	t.statePinDir = dir
+	// The flag map is pinned too and may still hold the previous run's
+	// setting.
+	if !config.DNSPayloadEnabled || t.dnsPayloadReader == nil {
+		setDNSPayloadFlag(t.collection, false)
+	}
+	if n := pin.ReleaseLinks(dir); n > 0 {
+		logger.Info("Took over the BPF state of a previous podtrace",
+			zap.String("dir", dir), zap.Int("links", n))
+	}
+	n, err := pin.PinLinks(dir, t.links)
+	if err != nil {
+		logger.Warn("Failed to pin probe links; a crash will detach them", zap.String("dir", dir), zap.Error(err))
+		return
+	}
+	logger.Info("Pinned BPF state", zap.String("dir", dir),
+		zap.Int("links", n), zap.Int("unpinnable_links", len(t.links)-n))
+}
@@ -0,0 +1,118 @@
+package tracer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/procfs"
+	"github.com/podtrace/podtrace/internal/validation"
+)
+
+// threadCPUValue mirrors struct thread_cpu_value in bpf/maps.h.
+type threadCPUValue struct {
+	CgroupID uint64
+	PID      uint32
+	_        uint32
+	OnCPUNS  uint64
+}
+
+// runThreadCPUPoller turns the thread_cpu_time counters into one
+// EventThreadCPU per thread and interval that ran on a CPU.
+func (t *Tracer) runThreadCPUPoller(ctx context.Context, eventChan chan<- *events.Event) {
+	if t.collection == nil || t.collection.Maps["thread_cpu_time"] == nil {
+		return
+	}
+	ticker := time.NewTicker(config.ThreadCPUInterval)
+	defer ticker.Stop()
+	last := make(map[uint32]threadCPUValue)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current, err := t.readThreadCPUCounters()
+			if err != nil {
+				logger.Debug("thread_cpu_time iterate error", zap.Error(err))
+				continue
+			}
+			for _, ev := range t.threadCPUEvents(last, current) {
+				select {
+				case <-ctx.Done():
+					return
+				case eventChan <- ev:
+				default:
+				}
+			}
+			last = current
+		}
+	}
+}
+
+func (t *Tracer) readThreadCPUCounters() (map[uint32]threadCPUValue, error) {
+	current := make(map[uint32]threadCPUValue)
+	var tid uint32
+	var v threadCPUValue
+	iter := t.collection.Maps["thread_cpu_time"].Iterate()
+	for iter.Next(&tid, &v) {
+		current[tid] = v
+	}
+	return current, iter.Err()
+}
+
+// threadCPUEvents turns the on-CPU time each thread accumulated since last
+// into events. A counter that went backwards (the LRU evicted the thread and
+// a new one reused its ID) starts over from zero.
+func (t *Tracer) threadCPUEvents(last, current map[uint32]threadCPUValue) []*events.Event {
+	now := monotonicNowNS()
+	var out []*events.Event
+	for tid, cur := range current {
+		prev, ok := last[tid]
+		if !ok || cur.PID != prev.PID || cur.OnCPUNS < prev.OnCPUNS {
+			prev = threadCPUValue{}
+		}
+		if cur.OnCPUNS == prev.OnCPUNS {
+			continue
+		}
+		out = append(out, &events.Event{
+			Timestamp:   now,
+			PID:         cur.PID,
+			CgroupID:    cur.CgroupID,
+			ProcessName: t.getProcessNameQuick(cur.PID),
+			Type:        events.EventThreadCPU,
+			LatencyNS:   cur.OnCPUNS - prev.OnCPUNS,
+			TCPState:    tid,
+			Target:      t.threadName(cur.PID, tid),
+		})
+	}
+	return out
+}
+
+// threadName reads a thread's name from /proc/<pid>/task/<tid>/comm, which
+// threads set with pthread_setname_np or prctl(PR_SET_NAME); it is empty
+// once the thread has exited.
+func (t *Tracer) threadName(pid, tid uint32) string {
+	if !validation.ValidatePID(pid) || !validation.ValidatePID(tid) {
+		return ""
+	}
+	if t.threadNameCache != nil {
+		if name, ok := t.threadNameCache.Get(tid); ok {
+			return name
+		}
+	}
+	data, err := procfs.ReadFile(fmt.Sprintf("%d/task/%d/comm", pid, tid))
+	if err != nil {
+		return ""
+	}
+	name := validation.SanitizeProcessName(strings.TrimSpace(string(data)))
+	if t.threadNameCache != nil {
+		t.threadNameCache.Set(tid, name)
+	}
+	return name
+}
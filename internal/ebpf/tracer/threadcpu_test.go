@@ -0,0 +1,67 @@
+package tracer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/cache"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+func TestThreadCPUEventsEmitIntervalDeltas(t *testing.T) {
+	procBase := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(procBase, "42", "task", "43"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(procBase, "42", "task", "43", "comm"), []byte("worker-1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	oldProc := config.ProcBasePath
+	config.SetProcBasePath(procBase)
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.SetProcBasePath(oldProc)
+		procfs.ResetForTesting()
+	})
+
+	ttl := time.Duration(config.CacheTTLSeconds) * time.Second
+	tr := &Tracer{
+		processNameCache: cache.NewLRUCache(config.CacheMaxSize, ttl),
+		threadNameCache:  cache.NewLRUCache(config.CacheMaxSize, ttl),
+	}
+	defer tr.processNameCache.Close()
+	defer tr.threadNameCache.Close()
+
+	last := map[uint32]threadCPUValue{
+		43: {CgroupID: 7, PID: 42, OnCPUNS: 1_000_000},
+		44: {CgroupID: 7, PID: 42, OnCPUNS: 5_000_000},
+	}
+	current := map[uint32]threadCPUValue{
+		43: {CgroupID: 7, PID: 42, OnCPUNS: 251_000_000},
+		// Idle since the last interval.
+		44: {CgroupID: 7, PID: 42, OnCPUNS: 5_000_000},
+		// The thread ID was reused by another process.
+		45: {CgroupID: 7, PID: 50, OnCPUNS: 2_000_000},
+	}
+	last[45] = threadCPUValue{CgroupID: 7, PID: 42, OnCPUNS: 9_000_000}
+
+	got := tr.threadCPUEvents(last, current)
+	if len(got) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(got), got)
+	}
+	byTID := make(map[uint32]*events.Event)
+	for _, e := range got {
+		byTID[e.ThreadID()] = e
+	}
+	e := byTID[43]
+	if e == nil || e.Type != events.EventThreadCPU || e.PID != 42 || e.CgroupID != 7 || e.LatencyNS != 250_000_000 || e.Target != "worker-1" {
+		t.Errorf("thread 43 event = %+v", e)
+	}
+	if e := byTID[45]; e == nil || e.LatencyNS != 2_000_000 || e.PID != 50 {
+		t.Errorf("reused thread event = %+v", e)
+	}
+}
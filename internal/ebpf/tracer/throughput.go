@@ -0,0 +1,283 @@
+package tracer
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cilium/ebpf/link"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/ebpf/probes"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// throughputKey mirrors struct throughput_key in bpf/maps.h.
+type throughputKey struct {
+	Ifindex uint32
+	Dir     uint8
+	Class   uint8
+	Pad     uint16
+}
+
+// throughputValue mirrors struct throughput_value in bpf/maps.h.
+type throughputValue struct {
+	Bytes   uint64
+	Packets uint64
+}
+
+// vethTarget is one pod veth carrying throughput counters.
+type vethTarget struct {
+	links    []link.Link
+	cgroupID uint64
+	pid      uint32
+}
+
+// throughputState tracks the attached veths and the counter values last
+// turned into events.
+type throughputState struct {
+	veths map[int]*vethTarget
+	last  map[throughputKey]throughputValue
+}
+
+// syncThroughputProbes reconciles the veth counters with the current target
+// cgroups: every target pod's veth is counted once, however many of its
+// containers are traced.
+func (t *Tracer) syncThroughputProbes(paths []string) {
+	if t.collection == nil || !config.PodThroughput {
+		return
+	}
+	want := make(map[int]*vethTarget)
+	for _, p := range paths {
+		if p == "" {
+			continue
+		}
+		pid := firstPIDUnder(p)
+		if pid == 0 {
+			continue
+		}
+		ifindex, err := probes.VethIfindex(pid)
+		if err != nil {
+			logger.Debug("No veth to count pod throughput on", zap.String("cgroup", p), zap.Error(err))
+			continue
+		}
+		if _, ok := want[ifindex]; ok {
+			continue
+		}
+		cgid, _ := getCgroupIDFromPath(p)
+		want[ifindex] = &vethTarget{cgroupID: cgid, pid: pid}
+	}
+
+	t.probeGroupsMu.Lock()
+	if t.throughput.veths == nil {
+		t.throughput.veths = map[int]*vethTarget{}
+	}
+	for ifindex, v := range t.throughput.veths {
+		if _, ok := want[ifindex]; ok {
+			continue
+		}
+		for _, l := range v.links {
+			_ = l.Close()
+		}
+		delete(t.throughput.veths, ifindex)
+	}
+	var missing []int
+	for ifindex, v := range want {
+		if cur, ok := t.throughput.veths[ifindex]; ok {
+			cur.cgroupID, cur.pid = v.cgroupID, v.pid
+			continue
+		}
+		missing = append(missing, ifindex)
+	}
+	t.probeGroupsMu.Unlock()
+
+	for _, ifindex := range missing {
+		v := want[ifindex]
+		v.links = probes.AttachThroughputProbes(t.collection, ifindex)
+		t.probeGroupsMu.Lock()
+		t.throughput.veths[ifindex] = v
+		t.probeGroupsMu.Unlock()
+	}
+}
+
+// firstPIDUnder returns the main PID of the cgroup at path or, for a pod
+// cgroup whose processes all live in container sub-cgroups, of its first
+// populated child.
+func firstPIDUnder(path string) uint32 {
+	if pid := readMainPIDFromCgroupProcs(path); pid != 0 {
+		return pid
+	}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return 0
+	}
+	for _, e := range entries {
+		if !e.IsDir() {
+			continue
+		}
+		if pid := readMainPIDFromCgroupProcs(filepath.Join(path, e.Name())); pid != 0 {
+			return pid
+		}
+	}
+	return 0
+}
+
+// loadCIDRClasses fills the cidr_classes trie from PODTRACE_CLUSTER_CIDRS,
+// PODTRACE_NODE_CIDRS and the node's own interface addresses.
+func (t *Tracer) loadCIDRClasses() {
+	m := t.collection.Maps["cidr_classes"]
+	if m == nil {
+		return
+	}
+	cluster := parsePrefixes(config.ClusterCIDRs)
+	node := parsePrefixes(config.NodeCIDRs)
+	if addrs, err := net.InterfaceAddrs(); err == nil {
+		for _, a := range addrs {
+			ipn, ok := a.(*net.IPNet)
+			if !ok {
+				continue
+			}
+			if addr, ok := netip.AddrFromSlice(ipn.IP); ok && !addr.IsLoopback() && !addr.IsLinkLocalUnicast() {
+				addr = addr.Unmap()
+				node = append(node, netip.PrefixFrom(addr, addr.BitLen()))
+			}
+		}
+	}
+	if err := probes.SetCIDRClasses(m, cluster, node); err != nil {
+		logger.Warn("Failed to load pod throughput address classes", zap.Error(err))
+	}
+}
+
+func parsePrefixes(csv string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, s := range strings.Split(csv, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			logger.Warn("Ignoring invalid CIDR", zap.String("cidr", s), zap.Error(err))
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
+
+// runThroughputPoller turns the veth counters into one EventPodThroughput
+// per (pod, direction, class) and interval that saw traffic.
+func (t *Tracer) runThroughputPoller(ctx context.Context, eventChan chan<- *events.Event) {
+	if !config.PodThroughput || t.collection == nil || t.collection.Maps["pod_throughput"] == nil {
+		return
+	}
+	t.loadCIDRClasses()
+	interval := config.PodThroughputInterval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			t.pollThroughput(ctx, eventChan, interval)
+		}
+	}
+}
+
+func (t *Tracer) pollThroughput(ctx context.Context, eventChan chan<- *events.Event, interval time.Duration) {
+	m := t.collection.Maps["pod_throughput"]
+	current := make(map[throughputKey]throughputValue)
+	var key throughputKey
+	var perCPU []throughputValue
+	iter := m.Iterate()
+	for iter.Next(&key, &perCPU) {
+		var sum throughputValue
+		for _, v := range perCPU {
+			sum.Bytes += v.Bytes
+			sum.Packets += v.Packets
+		}
+		current[key] = sum
+	}
+	if err := iter.Err(); err != nil {
+		logger.Debug("pod_throughput iterate error", zap.Error(err))
+		return
+	}
+
+	t.probeGroupsMu.Lock()
+	last := t.throughput.last
+	t.throughput.last = current
+	veths := make(map[uint32]vethTarget, len(t.throughput.veths))
+	for ifindex, v := range t.throughput.veths {
+		veths[uint32(ifindex)] = *v
+	}
+	t.probeGroupsMu.Unlock()
+
+	now := monotonicNowNS()
+	for k, cur := range current {
+		v, ok := veths[k.Ifindex]
+		if !ok {
+			// The veth was detached (or reused by another pod): forget it.
+			_ = m.Delete(&k)
+			continue
+		}
+		prev := last[k]
+		if cur.Bytes < prev.Bytes || cur.Packets < prev.Packets {
+			prev = throughputValue{}
+		}
+		if cur.Bytes == prev.Bytes {
+			continue
+		}
+		ev := &events.Event{
+			Timestamp: now,
+			PID:       v.pid,
+			CgroupID:  v.cgroupID,
+			Type:      events.EventPodThroughput,
+			LatencyNS: uint64(interval),
+			Bytes:     cur.Bytes - prev.Bytes,
+			Target:    throughputClassName(k.Class),
+			Details:   throughputDirName(k.Dir),
+		}
+		ev.SetThroughputPackets(cur.Packets - prev.Packets)
+		select {
+		case <-ctx.Done():
+			return
+		case eventChan <- ev:
+		default:
+		}
+	}
+}
+
+func throughputClassName(class uint8) string {
+	switch class {
+	case probes.ThroughputClassCluster:
+		return events.ThroughputClassCluster
+	case probes.ThroughputClassNode:
+		return events.ThroughputClassNode
+	default:
+		return events.ThroughputClassExternal
+	}
+}
+
+func throughputDirName(dir uint8) string {
+	if dir == probes.ThroughputDirEgress {
+		return events.ThroughputEgress
+	}
+	return events.ThroughputIngress
+}
+
+// takeLinks forgets every veth and returns their links for the caller to
+// close; the caller holds probeGroupsMu.
+func (s *throughputState) takeLinks() []link.Link {
+	var out []link.Link
+	for _, v := range s.veths {
+		out = append(out, v.links...)
+	}
+	s.veths = nil
+	return out
+}
@@ -43,6 +43,7 @@ import (
 	"github.com/podtrace/podtrace/internal/ebpf/h3stream"
 	"github.com/podtrace/podtrace/internal/ebpf/loader"
 	"github.com/podtrace/podtrace/internal/ebpf/parser"
+	"github.com/podtrace/podtrace/internal/ebpf/pin"
 	"github.com/podtrace/podtrace/internal/ebpf/probes"
 	"github.com/podtrace/podtrace/internal/ebpf/quicinitial"
 	"github.com/podtrace/podtrace/internal/events"
@@ -76,19 +77,39 @@ type ProfilingControllerSetter interface {
 	SetProfilingController(ctrl ProfilingController)
 }
 
+// ProbeCategoryGate is satisfied by *Tracer.
+type ProbeCategoryGate interface {
+	SetEnabledCategories(categories []string) error
+}
+
+// AlertThresholdSetter is satisfied by *Tracer.
+type AlertThresholdSetter interface {
+	SetAlertThresholds(warn, crit, emerg int) error
+}
+
+// EmissionGate is satisfied by *Tracer.
+type EmissionGate interface {
+	SetEmission(enabled bool) error
+}
+
 type Tracer struct {
 	collection     *ebpf.Collection
 	links          []link.Link
+	// statePinDir is where the maps and links are pinned with
+	// PODTRACE_PIN_STATE, or "".
+	statePinDir string
 	probeGroupsMu  sync.Mutex
 	probeGroups    map[probes.ProbeGroup][]link.Link
 	dnsPacketLinks map[string][]link.Link
 	http3Links     map[string][]link.Link
+	throughput     throughputState
 
 	intentionallyDisabled map[probes.ProbeGroup]struct{}
 	detachWarned          map[probes.ProbeGroup]struct{}
 
 	containerUprobes              map[string]*containerUprobeSet
 	globalProtocolAttached        bool
+	dnsSocketAttached             bool
 	attachContainerGroupFn        func(g probes.ProbeGroup, id string, pids []uint32) []link.Link
 	reader                        *ringbuf.Reader
 	h2Reader                      *ringbuf.Reader
@@ -108,6 +129,7 @@ type Tracer struct {
 	containerID                   string
 	containerPID                  uint32
 	processNameCache              *cache.LRUCache
+	threadNameCache               *cache.LRUCache
 	attributionTable              *attribution.Table
 	attributionCorrelatorDisabled bool
 	pathCache                     *cache.PathCache
@@ -122,6 +144,7 @@ type Tracer struct {
 	cgroupWriteMu                 sync.Mutex
 	cpAnalyzer                    *criticalpath.Analyzer
 	piiRedactor                   *redactor.Redactor
+	pgStatements                  pgStatementCache
 	profilingCtrl                 ProfilingController
 }
 
@@ -196,6 +219,9 @@ var containerUprobeGroups = []probes.ProbeGroup{
 	probes.GroupCache,
 	probes.GroupMessaging,
 	probes.GroupUSDT,
+	probes.GroupCustom,
+	probes.GroupPython,
+	probes.GroupNode,
 }
 
 // attachGlobalProtocolProbesOnce attaches the protocol kprobes that are NOT
@@ -213,48 +239,64 @@ func (t *Tracer) attachGlobalProtocolProbesOnce() {
 	t.registerGroupLinks(probes.GroupNetwork, probes.AttachGRPCProbes(t.collection))
 	t.registerGroupLinks(probes.GroupNetwork, probes.AttachHTTPProbes(t.collection))
 	t.registerGroupLinks(probes.GroupNetwork, probes.AttachH2Probes(t.collection))
+	t.registerGroupLinks(probes.GroupDatabase, probes.AttachPGWireProbes(t.collection))
+	t.registerGroupLinks(probes.GroupDatabase, probes.AttachMySQLWireProbes(t.collection))
+	t.registerGroupLinks(probes.GroupMessaging, probes.AttachKafkaWireProbes(t.collection))
+	t.registerGroupLinks(probes.GroupMessaging, probes.AttachAMQPProbes(t.collection))
 }
 
 // attachContainerGroupUprobes attaches one probe group's container-scoped
 // uprobes for one container and returns the links, without registering them
 // in the shared probeGroups registry, the per-container lifecycle in
-// SetContainerTargets owns them.
+// SetContainerTargets owns them. The binaries of the PIDs are attached
+// concurrently.
 func (t *Tracer) attachContainerGroupUprobes(g probes.ProbeGroup, id string, pids []uint32) []link.Link {
 	coll := t.collection
 	if coll == nil || id == "" || len(pids) == 0 {
 		return nil
 	}
 	af := probes.NewAttachedFiles()
-	var ls []link.Link
+	var fns []func() []link.Link
 	for _, pid := range pids {
 		switch g {
 		case probes.GroupTLS:
-			ls = append(ls, probes.AttachDNSProbesWithPID(coll, id, pid, af)...)
-			ls = append(ls, probes.AttachSyncProbesWithPID(coll, id, pid, af)...)
-			ls = append(ls, probes.AttachTLSProbesWithPID(coll, id, pid, af)...)
-			ls = append(ls, probes.AttachGoTLSProbes(coll, pid)...)
-			ls = append(ls, probes.AttachGoGRPCProbes(coll, pid)...)
-			ls = append(ls, probes.AttachRustlsProbes(coll, pid)...)
-			ls = append(ls, probes.AttachGoHTTP3Probes(coll, pid)...)
-			ls = append(ls, probes.AttachNghttp3Probes(coll, pid, af)...)
-			ls = append(ls, probes.AttachQuicheProbes(coll, pid, af)...)
-			ls = append(ls, probes.AttachQuicheRustProbes(coll, pid)...)
+			fns = append(fns,
+				func() []link.Link { return probes.AttachDNSProbesWithPID(coll, id, pid, af) },
+				func() []link.Link { return probes.AttachResolverProbes(coll, pid, af) },
+				func() []link.Link { return probes.AttachSyncProbesWithPID(coll, id, pid, af) },
+				func() []link.Link { return probes.AttachTLSProbesWithPID(coll, id, pid, af) },
+				func() []link.Link { return probes.AttachGoTLSProbes(coll, pid) },
+				func() []link.Link { return probes.AttachGoGRPCProbes(coll, pid) },
+				func() []link.Link { return probes.AttachRustlsProbes(coll, pid) },
+				func() []link.Link { return probes.AttachGoHTTP3Probes(coll, pid) },
+				func() []link.Link { return probes.AttachNghttp3Probes(coll, pid, af) },
+				func() []link.Link { return probes.AttachQuicheProbes(coll, pid, af) },
+				func() []link.Link { return probes.AttachQuicheRustProbes(coll, pid) },
+			)
 		case probes.GroupDatabase:
-			ls = append(ls, probes.AttachDBProbesWithPID(coll, id, pid, af)...)
+			fns = append(fns, func() []link.Link { return probes.AttachDBProbesWithPID(coll, id, pid, af) })
 		case probes.GroupPool:
-			ls = append(ls, probes.AttachPoolProbesWithPID(coll, id, pid, af)...)
+			fns = append(fns, func() []link.Link { return probes.AttachPoolProbesWithPID(coll, id, pid, af) })
 		case probes.GroupCache:
-			ls = append(ls, probes.AttachRedisProbesWithPID(coll, id, pid, af)...)
-			ls = append(ls, probes.AttachMemcachedProbesWithPID(coll, id, pid, af)...)
+			fns = append(fns,
+				func() []link.Link { return probes.AttachRedisProbesWithPID(coll, id, pid, af) },
+				func() []link.Link { return probes.AttachMemcachedProbesWithPID(coll, id, pid, af) },
+			)
 		case probes.GroupMessaging:
-			ls = append(ls, probes.AttachKafkaProbesWithPID(coll, id, pid, af)...)
+			fns = append(fns, func() []link.Link { return probes.AttachKafkaProbesWithPID(coll, id, pid, af) })
 		case probes.GroupUSDT:
-			ls = append(ls, probes.AttachUSDTProbes(coll, pid)...)
+			fns = append(fns, func() []link.Link { return probes.AttachUSDTProbes(coll, pid) })
+		case probes.GroupCustom:
+			fns = append(fns, func() []link.Link { return probes.AttachCustomUprobes(coll, pid, af) })
+		case probes.GroupPython:
+			fns = append(fns, func() []link.Link { return probes.AttachPythonProbes(coll, pid, af) })
+		case probes.GroupNode:
+			fns = append(fns, func() []link.Link { return probes.AttachNodeProbes(coll, pid, af) })
 		default:
 			return nil
 		}
 	}
-	return ls
+	return probes.AttachConcurrently(fns)
 }
 
 // attachContainerGroup dispatches to the test seam when set.
@@ -322,12 +364,23 @@ func (t *Tracer) SetContainerTargets(targets []ContainerProbeTarget) error {
 		_ = l.Close()
 	}
 
+	started := time.Now()
+	attached := 0
 	for _, ct := range toAttach {
 		links := t.attachContainerUprobes(ct.ID, ct.PIDs)
+		for _, ls := range links {
+			attached += len(ls)
+		}
 		t.probeGroupsMu.Lock()
 		t.containerUprobes[ct.ID] = &containerUprobeSet{pids: ct.PIDs, links: links}
 		t.probeGroupsMu.Unlock()
 	}
+	if len(toAttach) > 0 {
+		logger.Info("Container uprobes attached",
+			zap.Int("containers", len(toAttach)),
+			zap.Int("probes", attached),
+			zap.Duration("took", time.Since(started)))
+	}
 	return nil
 }
 
@@ -426,11 +479,25 @@ func (t *Tracer) syncDNSPacketProbes(paths []string) {
 	}
 	t.probeGroupsMu.Unlock()
 
+	uncovered := false
 	for _, p := range missing {
 		ls := probes.AttachDNSPacketProbes(t.collection, []string{p})
 		t.probeGroupsMu.Lock()
 		t.dnsPacketLinks[p] = ls
 		t.probeGroupsMu.Unlock()
+		uncovered = uncovered || len(ls) == 0
+	}
+	// A pod the packet path could not attach to (disabled, or another
+	// program owns the cgroup hook) falls back to the socket layer, which
+	// covers every pod once attached.
+	if uncovered {
+		t.probeGroupsMu.Lock()
+		already := t.dnsSocketAttached
+		t.dnsSocketAttached = true
+		t.probeGroupsMu.Unlock()
+		if !already {
+			t.registerGroupLinks(probes.GroupNetwork, probes.AttachDNSSocketProbes(t.collection))
+		}
 	}
 }
 
@@ -620,25 +687,43 @@ func NewTracer() (*Tracer, error) {
 
 	HaveSkStorageCrossContext()
 
+	statePinDir := config.StatePinDir
+	if statePinDir != "" {
+		if err := os.MkdirAll(statePinDir, 0o700); err != nil {
+			logger.Warn("BPF state pinning disabled", zap.String("dir", statePinDir), zap.Error(err))
+			statePinDir = ""
+		} else {
+			pin.PinState(spec)
+			opts.Maps.PinPath = statePinDir
+		}
+	}
+
 	coll, err := ebpf.NewCollectionWithOptions(spec, opts)
+	if err != nil && statePinDir != "" && errors.Is(err, ebpf.ErrMapIncompatible) {
+		logger.Warn("Pinned BPF state was left by an incompatible podtrace version, starting from empty maps",
+			zap.String("dir", statePinDir), zap.Error(err))
+		if cerr := pin.ClearState(statePinDir); cerr == nil {
+			if err = os.MkdirAll(statePinDir, 0o700); err == nil {
+				coll, err = ebpf.NewCollectionWithOptions(spec, opts)
+			}
+		}
+	}
 	if err != nil {
 		logVerifierFailure(err)
 		return nil, NewCollectionError(err)
 	}
 
-	if threshMap, ok := coll.Maps["alert_thresholds"]; ok && threshMap != nil {
-		thresholds := []uint32{
-			config.ClampUint32(config.AlertWarnPct),
-			config.ClampUint32(config.AlertCritPct),
-			config.ClampUint32(config.AlertEmergPct),
-		}
-		for i, v := range thresholds {
-			k := uint32(i)
-			val := v
-			if err := threshMap.Update(&k, &val, ebpf.UpdateAny); err != nil {
-				logger.Warn("Failed to set alert threshold", zap.Int("index", i), zap.Uint32("value", val), zap.Error(err))
-			}
+	if err := writeAlertThresholds(coll, config.AlertWarnPct, config.AlertCritPct, config.AlertEmergPct); err != nil {
+		logger.Warn("Failed to set alert thresholds", zap.Error(err))
+	}
+	// Written either way: a pinned emit_paused may still hold the flag of
+	// an armed run.
+	if err := writeEmissionPaused(coll, config.StartArmed); err != nil {
+		if config.StartArmed {
+			coll.Close()
+			return nil, fmt.Errorf("--armed: %w", err)
 		}
+		logger.Warn("Failed to enable event emission", zap.Error(err))
 	}
 
 	probeGroups, err := probes.AttachProbesByGroup(coll)
@@ -753,6 +838,7 @@ func NewTracer() (*Tracer, error) {
 		dnsResolved6Map:               dnsResolved6Map,
 		filter:                        filter.NewCgroupFilter(),
 		processNameCache:              processCache,
+		threadNameCache:               cache.NewLRUCache(config.CacheMaxSize, ttl),
 		attributionTable:              attribution.New(0, 0),
 		attributionCorrelatorDisabled: os.Getenv("PODTRACE_DISABLE_ATTRIBUTION_CORRELATOR") == "1",
 		pathCache:                     cache.NewPathCache(),
@@ -760,6 +846,9 @@ func NewTracer() (*Tracer, error) {
 	}
 	t.useUserspaceCgroupFilter.Store(true)
 	t.storeCgroupIDs(map[uint64]struct{}{})
+	if statePinDir != "" {
+		t.pinState(statePinDir)
+	}
 
 	if config.CriticalPathEnabled {
 		window := time.Duration(config.CriticalPathWindowMS) * time.Millisecond
@@ -812,6 +901,7 @@ func (t *Tracer) SetCgroups(cgroupPaths []string) error {
 		t.cgroupWriteMu.Unlock()
 		t.syncDNSPacketProbes(nil)
 		t.syncHTTP3Probes(nil)
+		t.syncThroughputProbes(nil)
 		logger.Debug("Detached all cgroups")
 		return nil
 	}
@@ -936,6 +1026,7 @@ func (t *Tracer) attachCgroups(cgroupPaths []string, replace bool) error {
 	currentPaths := append([]string(nil), t.cgroupPaths...)
 	t.syncDNSPacketProbes(currentPaths)
 	t.syncHTTP3Probes(currentPaths)
+	t.syncThroughputProbes(currentPaths)
 
 	if t.resourceMgr != nil {
 		t.resourceMgr.reconcile(currentPaths)
@@ -1262,6 +1353,7 @@ func (t *Tracer) Start(ctx context.Context, eventChan chan<- *events.Event) erro
 	t.cgroupWriteMu.Unlock()
 	t.syncDNSPacketProbes(dnsCgroups)
 	t.syncHTTP3Probes(dnsCgroups)
+	t.syncThroughputProbes(dnsCgroups)
 
 	t.resourceMgr.activate(ctx, eventChan,
 		t.collection.Maps["cgroup_limits"],
@@ -1288,6 +1380,11 @@ func (t *Tracer) Start(ctx context.Context, eventChan chan<- *events.Event) erro
 	}()
 
 	go t.runDNSTimeoutSweeper(ctx, eventChan)
+	go t.runThroughputPoller(ctx, eventChan)
+	go t.runPlacementSampler(ctx, eventChan)
+	go t.runPageCachePoller(ctx, eventChan)
+	go t.runFsNotifyPoller(ctx, eventChan)
+	go t.runThreadCPUPoller(ctx, eventChan)
 
 	if config.ManagementPort > 0 {
 		go t.serveManagementAPI(ctx, config.ManagementPort)
@@ -1530,6 +1627,12 @@ func (t *Tracer) processAndDispatch(ctx context.Context, event *events.Event,
 
 	cache.SnapshotCPUTime(event.PID)
 
+	t.pgStatements.enrichPGWire(event)
+	enrichMySQLWire(event)
+	enrichCrash(ctx, event)
+	if event.Type == events.EventSchedSwitch {
+		event.Target = t.threadName(event.PID, event.ThreadID())
+	}
 	if t.piiRedactor != nil {
 		t.piiRedactor.Redact(event)
 	}
@@ -2202,11 +2305,15 @@ func (t *Tracer) Stop() error {
 		closing = append(closing, ls...)
 	}
 	t.http3Links = nil
+	closing = append(closing, t.throughput.takeLinks()...)
 	for _, set := range t.containerUprobes {
 		closing = append(closing, set.allLinks()...)
 	}
 	t.containerUprobes = nil
 	t.probeGroupsMu.Unlock()
+	if t.statePinDir != "" {
+		pin.UnpinLinks(t.statePinDir, closing)
+	}
 	for _, l := range closing {
 		_ = l.Close()
 	}
@@ -2219,6 +2326,10 @@ func (t *Tracer) Stop() error {
 		t.processNameCache.Close()
 	}
 
+	if t.threadNameCache != nil {
+		t.threadNameCache.Close()
+	}
+
 	if t.pathCache != nil {
 		t.pathCache.Clear()
 	}
@@ -2286,10 +2397,19 @@ func (t *Tracer) getProcessNameQuick(pid uint32) string {
 }
 
 func (t *Tracer) pollBPFMapUtilization() {
+	for name, ratio := range t.MapFill() {
+		metricsexporter.RecordBPFMapUtilization(name, ratio)
+	}
+}
+
+// MapFill returns the share of max_entries in use of each map that grows
+// with the traced workload.
+func (t *Tracer) MapFill() map[string]float64 {
 	if t.collection == nil {
-		return
+		return nil
 	}
 	tracked := []string{"stack_traces", "start_times", "socket_conns", "db_queries", "pool_states"}
+	fill := make(map[string]float64, len(tracked))
 	for _, name := range tracked {
 		m, ok := t.collection.Maps[name]
 		if !ok || m == nil {
@@ -2308,9 +2428,9 @@ func (t *Tracer) pollBPFMapUtilization() {
 				count++
 			}
 		}
-		ratio := float64(count) / float64(info.MaxEntries)
-		metricsexporter.RecordBPFMapUtilization(name, ratio)
+		fill[name] = float64(count) / float64(info.MaxEntries)
 	}
+	return fill
 }
 
 // batchCountMapEntries counts a map's live entries using BPF_MAP_LOOKUP_BATCH,
@@ -2593,3 +2713,52 @@ func WaitForInterrupt() {
 	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
 	<-sig
 }
+
+// SetAlertThresholds changes the resource alert levels the BPF programs
+// read from alert_thresholds.
+func (t *Tracer) SetAlertThresholds(warn, crit, emerg int) error {
+	return writeAlertThresholds(t.collection, warn, crit, emerg)
+}
+
+// SetEmission turns event emission by the BPF programs on or off; the
+// probes stay attached either way.
+func (t *Tracer) SetEmission(enabled bool) error {
+	return writeEmissionPaused(t.collection, !enabled)
+}
+
+func writeEmissionPaused(coll *ebpf.Collection, paused bool) error {
+	if coll == nil {
+		return nil
+	}
+	pausedMap, ok := coll.Maps["emit_paused"]
+	if !ok || pausedMap == nil {
+		if paused {
+			return errors.New("the loaded BPF object has no emit_paused map; rebuild it")
+		}
+		return nil
+	}
+	var zero uint32
+	val := uint32(0)
+	if paused {
+		val = 1
+	}
+	return pausedMap.Update(&zero, &val, ebpf.UpdateAny)
+}
+
+func writeAlertThresholds(coll *ebpf.Collection, warn, crit, emerg int) error {
+	if coll == nil {
+		return nil
+	}
+	threshMap, ok := coll.Maps["alert_thresholds"]
+	if !ok || threshMap == nil {
+		return nil
+	}
+	for i, v := range []int{warn, crit, emerg} {
+		k := uint32(i)
+		val := config.ClampUint32(v)
+		if err := threshMap.Update(&k, &val, ebpf.UpdateAny); err != nil {
+			return fmt.Errorf("alert threshold %d: %w", i, err)
+		}
+	}
+	return nil
+}
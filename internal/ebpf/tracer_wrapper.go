@@ -1,6 +1,9 @@
 package ebpf
 
 import (
+	"fmt"
+
+	"github.com/podtrace/podtrace/internal/ebpf/pin"
 	"github.com/podtrace/podtrace/internal/ebpf/tracer"
 )
 
@@ -12,6 +15,31 @@ func NewTracer() (TracerInterface, error) {
 	return tracer.NewTracer()
 }
 
+// NewLowPrivilegeTracer returns the tracer of low-privilege mode, which
+// loads no BPF program.
+func NewLowPrivilegeTracer() TracerInterface {
+	return tracer.NewLowPrivilegeTracer()
+}
+
+// NewPinnedReader returns a tracer that reads the session a privileged
+// `podtrace loader` pinned, and needs no capability.
+func NewPinnedReader(session string) (TracerInterface, error) {
+	r, err := tracer.NewPinnedReader(session)
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Publish pins the maps of t for an unprivileged reader of session s.
+func Publish(t TracerInterface, s pin.Session, gid int) (*pin.Manifest, error) {
+	bt, ok := t.(*tracer.Tracer)
+	if !ok {
+		return nil, fmt.Errorf("tracer %T has no BPF maps to pin", t)
+	}
+	return bt.Publish(s, gid)
+}
+
 func WaitForInterrupt() {
 	tracer.WaitForInterrupt()
 }
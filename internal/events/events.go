@@ -3,7 +3,10 @@ package events
 import (
 	"encoding/binary"
 	"fmt"
+	"math"
 	"net"
+	"strconv"
+	"strings"
 	"time"
 	"unicode/utf8"
 
@@ -105,6 +108,72 @@ const (
 	EventAFALG
 	EventHTTP3
 	EventUSDT
+	EventUprobe
+	EventPyCall
+	EventPyGC
+	EventLoopLag
+	EventKafkaOp
+	EventAMQP
+	EventAnnotation
+	EventCrash
+	EventPodThroughput
+	// EventCPUPlacement samples where a process may run and where its memory
+	// lives over one interval: LatencyNS is the time it waited for a CPU,
+	// Details the numa.FormatDetails encoding of the sample.
+	EventCPUPlacement
+	// EventCompaction is one direct-compaction run in an allocating task:
+	// LatencyNS is the stall, Bytes the size of the allocation it was for,
+	// TCPState the compaction result.
+	EventCompaction
+	// EventTHPCollapse is khugepaged collapsing a range of a process into a
+	// huge page; Error is the scan result when it failed.
+	EventTHPCollapse
+	// EventSwap is one interval of a cgroup's swap and zswap activity: Bytes
+	// is its memory in swap, Details the resource.FormatSwapDetails encoding.
+	EventSwap
+	// EventPageCache is one interval (LatencyNS) of a cgroup's regular-file
+	// reads and page-cache misses; Details is a PageCacheCounts.
+	EventPageCache
+	// EventFsNotify is one interval (LatencyNS) of a cgroup's inotify and
+	// fanotify activity; Details is a FsNotifyCounts.
+	EventFsNotify
+	// EventThreadCPU is one interval of a thread's on-CPU time (LatencyNS);
+	// TCPState is the thread ID and Target its name.
+	EventThreadCPU
+	// EventCRIOp is a container runtime operation on a traced pod, read
+	// from the CRI: Target is the call, LatencyNS how long it took, Error 1
+	// when it failed and Details a CRIOpDetails.
+	EventCRIOp
+	// EventImagePull is an image pull the kubelet reported for a traced
+	// pod: Target is the image, LatencyNS the pull, Bytes the image size,
+	// Error 1 when it failed and Details an ImagePullDetails.
+	EventImagePull
+	// EventDisruption is an eviction or preemption of a pod on the traced
+	// node, or a change of the node's pressure conditions: Target is the
+	// pod (namespace/name) or the node, Details a DisruptionDetails.
+	EventDisruption
+	// EventNeighbor is one interval (LatencyNS) of another pod on the traced
+	// node among its busiest: Target is the pod (namespace/name), Bytes its
+	// block I/O and Details a NeighborUsage. Nothing of its traffic is read.
+	EventNeighbor
+	// EventTCPZeroWindow is a receive window that closed and reopened on an
+	// established connection: LatencyNS is how long it stayed closed,
+	// Target the remote end and TCPState which end advertised it (see
+	// ZeroWindowSide).
+	EventTCPZeroWindow
+	// EventTmpfs is one interval of a cgroup's tmpfs and shared memory
+	// usage, which counts against its memory limit: Bytes is the memory in
+	// tmpfs, Details the resource.FormatTmpfsDetails encoding.
+	EventTmpfs
+	// EventChaos is a fault injection on a traced pod's network appearing
+	// or going away, as chaos tools leave it: Target is the interface or
+	// the rule, Details a ChaosDetails.
+	EventChaos
+	// EventPause is a traced container being frozen through its cgroup, or
+	// its main process being stopped, and resuming: Target is the
+	// container (namespace/pod/container), PID the main process and
+	// Details a PauseDetails.
+	EventPause
 )
 
 type Event struct {
@@ -112,6 +181,7 @@ type Event struct {
 	PID          uint32
 	CgroupID     uint64
 	NetNsID      uint32   // V4: network namespace inum (0 if kernel BTF unavailable)
+	MntID        uint32   // V8: mount ID of the file for FS events (0 otherwise)
 	DNSServerIP  uint32   // V5: upstream resolver IPv4 for DNS events (0 otherwise)
 	DNSTransport uint8    // V5: 0=UDP, 1=TCP for DNS events
 	DNSServerIP6 [16]byte // V6: upstream resolver IPv6 for DNS events
@@ -136,6 +206,7 @@ type Event struct {
 	TraceState   string
 
 	CorrelationID uint64
+	SockID        uint64 // V9: kernel address of the socket (0 if unknown)
 
 	K8s *K8sMetadata
 }
@@ -221,7 +292,7 @@ func (e *Event) TypeString() string {
 		return e.HTTPProtoLabel()
 	case EventLockContention:
 		return "LOCK"
-	case EventTCPRetrans, EventNetDevError:
+	case EventTCPRetrans, EventNetDevError, EventTCPZeroWindow:
 		return "NET"
 	case EventDBQuery:
 		return "DB"
@@ -241,7 +312,7 @@ func (e *Event) TypeString() string {
 		return "FASTCGI"
 	case EventGRPCMethod:
 		return "gRPC"
-	case EventKafkaProduce, EventKafkaFetch:
+	case EventKafkaProduce, EventKafkaFetch, EventKafkaOp:
 		return "KAFKA"
 	case EventAFALG:
 		return "CRYPTO"
@@ -249,6 +320,50 @@ func (e *Event) TypeString() string {
 		return "HTTP/3"
 	case EventUSDT:
 		return "USDT"
+	case EventUprobe:
+		return "UPROBE"
+	case EventPyCall:
+		return "PY_CALL"
+	case EventPyGC:
+		return "PY_GC"
+	case EventLoopLag:
+		return "LOOP_LAG"
+	case EventAMQP:
+		return "AMQP"
+	case EventAnnotation:
+		return "ANNOTATION"
+	case EventCrash:
+		return "CRASH"
+	case EventPodThroughput:
+		return "THROUGHPUT"
+	case EventCPUPlacement:
+		return "CPU_PLACEMENT"
+	case EventCompaction:
+		return "COMPACTION"
+	case EventTHPCollapse:
+		return "THP_COLLAPSE"
+	case EventSwap:
+		return "SWAP"
+	case EventPageCache:
+		return "PAGE_CACHE"
+	case EventFsNotify:
+		return "FSNOTIFY"
+	case EventThreadCPU:
+		return "THREAD_CPU"
+	case EventCRIOp:
+		return "CRI_OP"
+	case EventImagePull:
+		return "IMAGE_PULL"
+	case EventDisruption:
+		return "DISRUPTION"
+	case EventNeighbor:
+		return "NEIGHBOR"
+	case EventTmpfs:
+		return "TMPFS"
+	case EventChaos:
+		return "CHAOS"
+	case EventPause:
+		return "PAUSE"
 	default:
 		return "UNKNOWN"
 	}
@@ -346,12 +461,566 @@ func dnsRCodeName(rcode int32) string {
 	}
 }
 
+// AMQP methods carried in TCPState (class << 16 | method) for EventAMQP.
+const (
+	AMQPBasicConsume   uint32 = 60<<16 | 20
+	AMQPBasicConsumeOK uint32 = 60<<16 | 21
+	AMQPBasicPublish   uint32 = 60<<16 | 40
+	AMQPBasicDeliver   uint32 = 60<<16 | 60
+	AMQPBasicAck       uint32 = 60<<16 | 80
+	AMQPBasicReject    uint32 = 60<<16 | 90
+	AMQPBasicNack      uint32 = 60<<16 | 120
+	AMQPConfirmSelect  uint32 = 85<<16 | 10
+)
+
+// AMQPMethod returns the AMQP method name (basic.publish, …) for an
+// EVENT_AMQP event.
+func (e *Event) AMQPMethod() string {
+	switch e.TCPState {
+	case AMQPBasicConsume:
+		return "basic.consume"
+	case AMQPBasicConsumeOK:
+		return "basic.consume-ok"
+	case AMQPBasicPublish:
+		return "basic.publish"
+	case AMQPBasicDeliver:
+		return "basic.deliver"
+	case AMQPBasicAck:
+		return "basic.ack"
+	case AMQPBasicReject:
+		return "basic.reject"
+	case AMQPBasicNack:
+		return "basic.nack"
+	case AMQPConfirmSelect:
+		return "confirm.select"
+	default:
+		return fmt.Sprintf("method %d.%d", e.TCPState>>16, e.TCPState&0xffff)
+	}
+}
+
+// CrashSignal returns the name of the signal (carried in Error) that made an
+// EventCrash process dump core. Only core-dumping signals reach do_coredump.
+func (e *Event) CrashSignal() string {
+	switch e.Error {
+	case 3:
+		return "SIGQUIT"
+	case 4:
+		return "SIGILL"
+	case 5:
+		return "SIGTRAP"
+	case 6:
+		return "SIGABRT"
+	case 7:
+		return "SIGBUS"
+	case 8:
+		return "SIGFPE"
+	case 11:
+		return "SIGSEGV"
+	case 24:
+		return "SIGXCPU"
+	case 25:
+		return "SIGXFSZ"
+	case 31:
+		return "SIGSYS"
+	default:
+		return fmt.Sprintf("signal %d", e.Error)
+	}
+}
+
+// EventPodThroughput reports the bytes a pod's veth carried over one interval
+// (LatencyNS) in one direction (Details) to or from one class of remote
+// address (Target).
+const (
+	ThroughputIngress = "ingress"
+	ThroughputEgress  = "egress"
+
+	ThroughputClassCluster  = "cluster"
+	ThroughputClassNode     = "node"
+	ThroughputClassExternal = "external"
+)
+
+// ThroughputPackets is the packet count of an EventPodThroughput, carried in
+// TCPState (unused for these events otherwise).
+func (e *Event) ThroughputPackets() uint64 {
+	return uint64(e.TCPState)
+}
+
+// SetThroughputPackets stores the packet count of an EventPodThroughput,
+// saturating at the width of TCPState.
+func (e *Event) SetThroughputPackets(n uint64) {
+	if n > math.MaxUint32 {
+		n = math.MaxUint32
+	}
+	e.TCPState = uint32(n)
+}
+
+// CompactionResult names the compact_result (carried in TCPState) an
+// EventCompaction ended with.
+func (e *Event) CompactionResult() string {
+	switch e.TCPState {
+	case 0:
+		return "not_suitable_zone"
+	case 1:
+		return "skipped"
+	case 2:
+		return "deferred"
+	case 3:
+		return "no_suitable_page"
+	case 4:
+		return "continue"
+	case 5:
+		return "complete"
+	case 6:
+		return "partial_skipped"
+	case 7:
+		return "contended"
+	case 8:
+		return "success"
+	default:
+		return fmt.Sprintf("result %d", e.TCPState)
+	}
+}
+
+// Ends of a connection that can advertise the zero window of an
+// EventTCPZeroWindow, carried in TCPState.
+const (
+	ZeroWindowPeer  = 1 // the remote receiver stopped reading
+	ZeroWindowLocal = 2 // the traced pod stopped reading
+)
+
+// ZeroWindowSide names the end that advertised the zero window of an
+// EventTCPZeroWindow: "peer", "local", or "" for other events.
+func (e *Event) ZeroWindowSide() string {
+	if e.Type != EventTCPZeroWindow {
+		return ""
+	}
+	switch e.TCPState {
+	case ZeroWindowPeer:
+		return "peer"
+	case ZeroWindowLocal:
+		return "local"
+	}
+	return ""
+}
+
+// ThreadID is the thread an EventSchedSwitch or EventThreadCPU is about,
+// carried in TCPState; 0 for other events.
+func (e *Event) ThreadID() uint32 {
+	if e.Type != EventSchedSwitch && e.Type != EventThreadCPU {
+		return 0
+	}
+	return e.TCPState
+}
+
+// ReadCacheMisses is the number of folios an EventRead brought into the page
+// cache, carried in TCPState; 0 is a cache hit, or a kernel without the
+// filemap_add_folio probe.
+func (e *Event) ReadCacheMisses() uint64 {
+	return uint64(e.TCPState)
+}
+
+// PageCacheCounts are the regular-file reads of an EventPageCache, the reads
+// among them that missed the page cache and the folios those misses added.
+type PageCacheCounts struct {
+	Reads      uint64
+	MissReads  uint64
+	MissFolios uint64
+}
+
+// String encodes c as EventPageCache Details.
+func (c PageCacheCounts) String() string {
+	return fmt.Sprintf("reads=%d miss_reads=%d miss_folios=%d", c.Reads, c.MissReads, c.MissFolios)
+}
+
+// ParsePageCacheCounts is the inverse of PageCacheCounts.String; unknown or
+// malformed keys are skipped.
+func ParsePageCacheCounts(details string) PageCacheCounts {
+	var c PageCacheCounts
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch k {
+		case "reads":
+			c.Reads = n
+		case "miss_reads":
+			c.MissReads = n
+		case "miss_folios":
+			c.MissFolios = n
+		}
+	}
+	return c
+}
+
+// FsNotifyCounts are the inotify watches and fanotify marks an
+// EventFsNotify's cgroup registered, the registrations the per-user limits
+// refused, and the events its groups' full queues dropped.
+type FsNotifyCounts struct {
+	Watches   uint64
+	Marks     uint64
+	LimitHits uint64
+	Overflows uint64
+}
+
+// String encodes c as EventFsNotify Details.
+func (c FsNotifyCounts) String() string {
+	return fmt.Sprintf("watches=%d marks=%d limit_hits=%d overflows=%d", c.Watches, c.Marks, c.LimitHits, c.Overflows)
+}
+
+// ParseFsNotifyCounts is the inverse of FsNotifyCounts.String; unknown or
+// malformed keys are skipped.
+func ParseFsNotifyCounts(details string) FsNotifyCounts {
+	var c FsNotifyCounts
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch k {
+		case "watches":
+			c.Watches = n
+		case "marks":
+			c.Marks = n
+		case "limit_hits":
+			c.LimitHits = n
+		case "overflows":
+			c.Overflows = n
+		}
+	}
+	return c
+}
+
+// CRI calls an EventCRIOp can stand for. CRIOpStartContainer spans a
+// container's creation to its start as the runtime recorded them, so it
+// covers CreateContainer returning and StartContainer; the others are
+// podtrace's own calls, timed as they were made.
+const (
+	CRIOpStartContainer  = "StartContainer"
+	CRIOpListContainers  = "ListContainers"
+	CRIOpContainerStatus = "ContainerStatus"
+)
+
+// CRIOpDetails name the container, image and failure reason of an
+// EventCRIOp; the runtime reports none of them with spaces.
+type CRIOpDetails struct {
+	Container string
+	Image     string
+	Reason    string
+}
+
+// String encodes d as EventCRIOp Details, leaving out empty fields.
+func (d CRIOpDetails) String() string {
+	var parts []string
+	for _, kv := range [][2]string{{"container", d.Container}, {"image", d.Image}, {"reason", d.Reason}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+strings.Join(strings.Fields(kv[1]), "_"))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseCRIOpDetails is the inverse of CRIOpDetails.String; unknown keys are
+// skipped.
+func ParseCRIOpDetails(details string) CRIOpDetails {
+	var d CRIOpDetails
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "container":
+			d.Container = v
+		case "image":
+			d.Image = v
+		case "reason":
+			d.Reason = v
+		}
+	}
+	return d
+}
+
+// ImagePullDetails describe an EventImagePull: the registry it pulled from,
+// the time it queued behind other pulls, the registry's connect latency when
+// probed, whether the image was already on the node, and why it failed.
+type ImagePullDetails struct {
+	Registry      string
+	WaitNS        uint64
+	RegistryRTTNS uint64
+	Cached        bool
+	Reason        string
+}
+
+// String encodes d as EventImagePull Details.
+func (d ImagePullDetails) String() string {
+	s := fmt.Sprintf("registry=%s wait_ns=%d registry_rtt_ns=%d cached=%t", d.Registry, d.WaitNS, d.RegistryRTTNS, d.Cached)
+	if d.Reason != "" {
+		s += " reason=" + strings.Join(strings.Fields(d.Reason), "_")
+	}
+	return s
+}
+
+// ParseImagePullDetails is the inverse of ImagePullDetails.String; unknown
+// or malformed keys are skipped.
+func ParseImagePullDetails(details string) ImagePullDetails {
+	var d ImagePullDetails
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "registry":
+			d.Registry = v
+		case "wait_ns":
+			d.WaitNS, _ = strconv.ParseUint(v, 10, 64)
+		case "registry_rtt_ns":
+			d.RegistryRTTNS, _ = strconv.ParseUint(v, 10, 64)
+		case "cached":
+			d.Cached, _ = strconv.ParseBool(v)
+		case "reason":
+			d.Reason = v
+		}
+	}
+	return d
+}
+
+// Kinds of EventDisruption.
+const (
+	// DisruptionEviction is the kubelet evicting a pod under node pressure.
+	DisruptionEviction = "eviction"
+	// DisruptionPreemption is the scheduler preempting a pod for a higher
+	// priority one.
+	DisruptionPreemption = "preemption"
+	// DisruptionEvictionThreshold is the kubelet starting to reclaim a
+	// resource, before it evicts.
+	DisruptionEvictionThreshold = "eviction_threshold"
+	// DisruptionNodePressure and DisruptionPressureCleared are a node
+	// pressure condition turning true and false again.
+	DisruptionNodePressure    = "node_pressure"
+	DisruptionPressureCleared = "pressure_cleared"
+)
+
+// DisruptionDetails describe an EventDisruption: its kind, the resource
+// under pressure (memory, disk, pid, ...) when known, and the node.
+type DisruptionDetails struct {
+	Kind     string
+	Resource string
+	Node     string
+}
+
+// String encodes d as EventDisruption Details, leaving out empty fields.
+func (d DisruptionDetails) String() string {
+	var parts []string
+	for _, kv := range [][2]string{{"kind", d.Kind}, {"resource", d.Resource}, {"node", d.Node}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+strings.Join(strings.Fields(kv[1]), "_"))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseDisruptionDetails is the inverse of DisruptionDetails.String;
+// unknown keys are skipped.
+func ParseDisruptionDetails(details string) DisruptionDetails {
+	var d DisruptionDetails
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "kind":
+			d.Kind = v
+		case "resource":
+			d.Resource = v
+		case "node":
+			d.Node = v
+		}
+	}
+	return d
+}
+
+// Kinds of EventChaos.
+const (
+	// ChaosNetem is a netem qdisc delaying, dropping, duplicating or
+	// corrupting packets, on the pod's own interface or its host veth.
+	ChaosNetem = "netem"
+	// ChaosIPTablesDrop is an iptables DROP or REJECT rule added to the
+	// pod's network namespace while it was traced.
+	ChaosIPTablesDrop = "iptables_drop"
+)
+
+// States of EventChaos: the injection appeared or went away.
+const (
+	ChaosStart = "start"
+	ChaosEnd   = "end"
+)
+
+// ChaosDetails describe an EventChaos: its kind and state, the pod
+// (namespace/name) whose network it affects, and the injection itself,
+// such as "delay=100ms,loss=5%" for netem. Preexisting marks an injection
+// already in place when the trace started.
+type ChaosDetails struct {
+	Kind        string
+	State       string
+	Pod         string
+	Spec        string
+	Preexisting bool
+}
+
+// String encodes d as EventChaos Details, leaving out empty fields.
+func (d ChaosDetails) String() string {
+	var parts []string
+	for _, kv := range [][2]string{{"kind", d.Kind}, {"state", d.State}, {"pod", d.Pod}, {"spec", d.Spec}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+strings.Join(strings.Fields(kv[1]), "_"))
+		}
+	}
+	if d.Preexisting {
+		parts = append(parts, "preexisting=true")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseChaosDetails is the inverse of ChaosDetails.String; unknown keys are
+// skipped.
+func ParseChaosDetails(details string) ChaosDetails {
+	var d ChaosDetails
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "kind":
+			d.Kind = v
+		case "state":
+			d.State = v
+		case "pod":
+			d.Pod = v
+		case "spec":
+			d.Spec = v
+		case "preexisting":
+			d.Preexisting, _ = strconv.ParseBool(v)
+		}
+	}
+	return d
+}
+
+// Kinds of EventPause.
+const (
+	// PauseFreeze is the container's cgroup frozen, with cgroup.freeze
+	// under cgroup v2 or the freezer controller under v1.
+	PauseFreeze = "freeze"
+	// PauseStop is the main process stopped by SIGSTOP, SIGTSTP, SIGTTIN
+	// or SIGTTOU.
+	PauseStop = "sigstop"
+	// PauseTraceStop is the main process held by a tracer such as a
+	// debugger.
+	PauseTraceStop = "trace_stop"
+)
+
+// States of EventPause: the container paused or resumed.
+const (
+	PauseStart = "start"
+	PauseEnd   = "end"
+)
+
+// PauseDetails describe an EventPause: its kind and state.
+type PauseDetails struct {
+	Kind  string
+	State string
+}
+
+// String encodes d as EventPause Details, leaving out empty fields.
+func (d PauseDetails) String() string {
+	var parts []string
+	for _, kv := range [][2]string{{"kind", d.Kind}, {"state", d.State}} {
+		if kv[1] != "" {
+			parts = append(parts, kv[0]+"="+kv[1])
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParsePauseDetails is the inverse of PauseDetails.String; unknown keys
+// are skipped.
+func ParsePauseDetails(details string) PauseDetails {
+	var d PauseDetails
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "kind":
+			d.Kind = v
+		case "state":
+			d.State = v
+		}
+	}
+	return d
+}
+
+// NeighborUsage is the CPU time and block I/O an EventNeighbor's pod used in
+// its interval.
+type NeighborUsage struct {
+	CPUNS   uint64
+	IOBytes uint64
+}
+
+// String encodes u as EventNeighbor Details.
+func (u NeighborUsage) String() string {
+	return fmt.Sprintf("cpu_ns=%d io_bytes=%d", u.CPUNS, u.IOBytes)
+}
+
+// ParseNeighborUsage is the inverse of NeighborUsage.String; unknown or
+// malformed keys are skipped.
+func ParseNeighborUsage(details string) NeighborUsage {
+	var u NeighborUsage
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			continue
+		}
+		switch k {
+		case "cpu_ns":
+			u.CPUNS = n
+		case "io_bytes":
+			u.IOBytes = n
+		}
+	}
+	return u
+}
+
 // IsCopyFailSignal reports whether this event is an AF_ALG bind of an "aead"
 // transform by an unprivileged (uid != 0) caller.
 func (e *Event) IsCopyFailSignal() bool {
 	return e.Type == EventAFALG && e.Target == "aead" && e.Bytes != 0
 }
 
+// IsSYNRetrans reports whether an EventTCPRetrans resent a SYN or SYN-ACK,
+// a connection that failed to set up, rather than data of an established
+// one. TCPState carries the socket's state; 0 on kernels that do not report
+// it counts as data.
+func (e *Event) IsSYNRetrans() bool {
+	return e.Type == EventTCPRetrans && (e.TCPState == 2 || e.TCPState == 3)
+}
+
 func TCPStateString(state uint32) string {
 	states := map[uint32]string{
 		1:  "ESTABLISHED",
@@ -77,6 +77,29 @@ func TestTypeString_RemainingTypes(t *testing.T) {
 		{EventDNSQuery, "DNS"},
 		{EventHTTP3, "HTTP/3"},
 		{EventUSDT, "USDT"},
+		{EventUprobe, "UPROBE"},
+		{EventPyCall, "PY_CALL"},
+		{EventPyGC, "PY_GC"},
+		{EventLoopLag, "LOOP_LAG"},
+		{EventAMQP, "AMQP"},
+		{EventAnnotation, "ANNOTATION"},
+		{EventCrash, "CRASH"},
+		{EventPodThroughput, "THROUGHPUT"},
+		{EventCPUPlacement, "CPU_PLACEMENT"},
+		{EventCompaction, "COMPACTION"},
+		{EventTHPCollapse, "THP_COLLAPSE"},
+		{EventSwap, "SWAP"},
+		{EventPageCache, "PAGE_CACHE"},
+		{EventFsNotify, "FSNOTIFY"},
+		{EventThreadCPU, "THREAD_CPU"},
+		{EventCRIOp, "CRI_OP"},
+		{EventImagePull, "IMAGE_PULL"},
+		{EventDisruption, "DISRUPTION"},
+		{EventNeighbor, "NEIGHBOR"},
+		{EventTCPZeroWindow, "NET"},
+		{EventTmpfs, "TMPFS"},
+		{EventChaos, "CHAOS"},
+		{EventPause, "PAUSE"},
 	}
 	for _, c := range cases {
 		e := &Event{Type: c.et}
@@ -134,3 +157,195 @@ func TestEvent_DNSResponseCode(t *testing.T) {
 		}
 	}
 }
+
+func TestEvent_AMQPMethod(t *testing.T) {
+	cases := map[uint32]string{
+		AMQPBasicPublish:  "basic.publish",
+		AMQPBasicDeliver:  "basic.deliver",
+		AMQPBasicAck:      "basic.ack",
+		AMQPConfirmSelect: "confirm.select",
+		60<<16 | 70:       "method 60.70",
+	}
+	for state, want := range cases {
+		e := &Event{Type: EventAMQP, TCPState: state}
+		if got := e.AMQPMethod(); got != want {
+			t.Errorf("AMQPMethod() for TCPState=%#x = %q, want %q", state, got, want)
+		}
+	}
+}
+
+func TestEvent_CrashSignal(t *testing.T) {
+	cases := map[int32]string{
+		11: "SIGSEGV",
+		6:  "SIGABRT",
+		7:  "SIGBUS",
+		99: "signal 99",
+	}
+	for sig, want := range cases {
+		e := &Event{Type: EventCrash, Error: sig}
+		if got := e.CrashSignal(); got != want {
+			t.Errorf("CrashSignal() for Error=%d = %q, want %q", sig, got, want)
+		}
+	}
+}
+
+func TestEvent_ThroughputPackets(t *testing.T) {
+	e := &Event{Type: EventPodThroughput}
+	e.SetThroughputPackets(1500)
+	if got := e.ThroughputPackets(); got != 1500 {
+		t.Errorf("ThroughputPackets() = %d, want 1500", got)
+	}
+	e.SetThroughputPackets(1 << 40)
+	if got := e.ThroughputPackets(); got != 1<<32-1 {
+		t.Errorf("ThroughputPackets() = %d, want saturation at %d", got, uint64(1<<32-1))
+	}
+}
+
+func TestEvent_CompactionResult(t *testing.T) {
+	cases := map[uint32]string{
+		2:  "deferred",
+		7:  "contended",
+		8:  "success",
+		42: "result 42",
+	}
+	for result, want := range cases {
+		e := &Event{Type: EventCompaction, TCPState: result}
+		if got := e.CompactionResult(); got != want {
+			t.Errorf("CompactionResult() for TCPState=%d = %q, want %q", result, got, want)
+		}
+	}
+}
+
+func TestEvent_ZeroWindowSide(t *testing.T) {
+	cases := []struct {
+		e    Event
+		want string
+	}{
+		{Event{Type: EventTCPZeroWindow, TCPState: ZeroWindowPeer}, "peer"},
+		{Event{Type: EventTCPZeroWindow, TCPState: ZeroWindowLocal}, "local"},
+		{Event{Type: EventTCPZeroWindow}, ""},
+		{Event{Type: EventTCPState, TCPState: ZeroWindowLocal}, ""},
+	}
+	for _, c := range cases {
+		if got := c.e.ZeroWindowSide(); got != c.want {
+			t.Errorf("ZeroWindowSide() for %+v = %q, want %q", c.e, got, c.want)
+		}
+	}
+}
+
+func TestEvent_IsSYNRetrans(t *testing.T) {
+	cases := []struct {
+		e    Event
+		want bool
+	}{
+		{Event{Type: EventTCPRetrans, TCPState: 2}, true},
+		{Event{Type: EventTCPRetrans, TCPState: 3}, true},
+		{Event{Type: EventTCPRetrans, TCPState: 1}, false},
+		{Event{Type: EventTCPRetrans}, false},
+		{Event{Type: EventTCPState, TCPState: 2}, false},
+	}
+	for _, c := range cases {
+		if got := c.e.IsSYNRetrans(); got != c.want {
+			t.Errorf("IsSYNRetrans() for %+v = %v, want %v", c.e, got, c.want)
+		}
+	}
+}
+
+func TestPageCacheCounts_RoundTrip(t *testing.T) {
+	c := PageCacheCounts{Reads: 1200, MissReads: 30, MissFolios: 480}
+	if got := ParsePageCacheCounts(c.String()); got != c {
+		t.Errorf("ParsePageCacheCounts(%q) = %+v, want %+v", c.String(), got, c)
+	}
+	if got := ParsePageCacheCounts("reads=x miss_reads=2 junk"); got != (PageCacheCounts{MissReads: 2}) {
+		t.Errorf("malformed details = %+v", got)
+	}
+}
+
+func TestFsNotifyCounts_RoundTrip(t *testing.T) {
+	c := FsNotifyCounts{Watches: 5000, Marks: 3, LimitHits: 12, Overflows: 40}
+	if got := ParseFsNotifyCounts(c.String()); got != c {
+		t.Errorf("ParseFsNotifyCounts(%q) = %+v, want %+v", c.String(), got, c)
+	}
+	if got := ParseFsNotifyCounts("watches=-1 overflows=7 junk"); got != (FsNotifyCounts{Overflows: 7}) {
+		t.Errorf("malformed details = %+v", got)
+	}
+}
+
+func TestCRIOpDetails_RoundTrip(t *testing.T) {
+	d := CRIOpDetails{Container: "app", Image: "registry.local/app:1.2", Reason: "StartError"}
+	if got := ParseCRIOpDetails(d.String()); got != d {
+		t.Errorf("ParseCRIOpDetails(%q) = %+v, want %+v", d.String(), got, d)
+	}
+	if s := (CRIOpDetails{Container: "app"}).String(); s != "container=app" {
+		t.Errorf("String() = %q, want only the container", s)
+	}
+	if got := ParseCRIOpDetails("reason=a b junk"); got != (CRIOpDetails{Reason: "a"}) {
+		t.Errorf("malformed details = %+v", got)
+	}
+}
+
+func TestImagePullDetails_RoundTrip(t *testing.T) {
+	d := ImagePullDetails{Registry: "ghcr.io", WaitNS: 2_000_000_000, RegistryRTTNS: 35_000_000, Reason: "RateLimited"}
+	if got := ParseImagePullDetails(d.String()); got != d {
+		t.Errorf("ParseImagePullDetails(%q) = %+v, want %+v", d.String(), got, d)
+	}
+	if got := ParseImagePullDetails("cached=true wait_ns=x junk"); got != (ImagePullDetails{Cached: true}) {
+		t.Errorf("malformed details = %+v", got)
+	}
+}
+
+func TestDisruptionDetails_RoundTrip(t *testing.T) {
+	d := DisruptionDetails{Kind: DisruptionEviction, Resource: "memory", Node: "node-1"}
+	if got := ParseDisruptionDetails(d.String()); got != d {
+		t.Errorf("ParseDisruptionDetails(%q) = %+v, want %+v", d.String(), got, d)
+	}
+	if s := (DisruptionDetails{Kind: DisruptionPreemption}).String(); s != "kind=preemption" {
+		t.Errorf("String() = %q, want only the kind", s)
+	}
+}
+
+func TestNeighborUsage_RoundTrip(t *testing.T) {
+	u := NeighborUsage{CPUNS: 3_500_000_000, IOBytes: 64 << 20}
+	if got := ParseNeighborUsage(u.String()); got != u {
+		t.Errorf("ParseNeighborUsage(%q) = %+v, want %+v", u.String(), got, u)
+	}
+	if got := ParseNeighborUsage("cpu_ns=x io_bytes=7 other=1"); got != (NeighborUsage{IOBytes: 7}) {
+		t.Errorf("malformed keys not skipped: %+v", got)
+	}
+}
+
+func TestChaosDetails_RoundTrip(t *testing.T) {
+	d := ChaosDetails{Kind: ChaosNetem, State: ChaosStart, Pod: "shop/cart-0", Spec: "delay=100ms,loss=5%", Preexisting: true}
+	if got := ParseChaosDetails(d.String()); got != d {
+		t.Errorf("ParseChaosDetails(%q) = %+v, want %+v", d.String(), got, d)
+	}
+	if s := (ChaosDetails{Kind: ChaosIPTablesDrop, State: ChaosEnd}).String(); s != "kind=iptables_drop state=end" {
+		t.Errorf("String() = %q, want only the kind and state", s)
+	}
+}
+
+func TestPauseDetails_RoundTrip(t *testing.T) {
+	d := PauseDetails{Kind: PauseFreeze, State: PauseStart}
+	if got := ParsePauseDetails(d.String()); got != d {
+		t.Errorf("ParsePauseDetails(%q) = %+v, want %+v", d.String(), got, d)
+	}
+	if got := ParsePauseDetails("kind=sigstop junk other=1"); got != (PauseDetails{Kind: PauseStop}) {
+		t.Errorf("malformed details = %+v", got)
+	}
+}
+
+func TestThreadID(t *testing.T) {
+	for _, tt := range []struct {
+		typ  EventType
+		want uint32
+	}{
+		{EventSchedSwitch, 4242},
+		{EventThreadCPU, 4242},
+		{EventRead, 0},
+	} {
+		e := &Event{Type: tt.typ, TCPState: 4242}
+		if got := e.ThreadID(); got != tt.want {
+			t.Errorf("%v ThreadID() = %d, want %d", tt.typ, got, tt.want)
+		}
+	}
+}
@@ -264,6 +264,7 @@ func TestTypeString_FastCGIGRPCKafka(t *testing.T) {
 		{EventGRPCMethod, "gRPC"},
 		{EventKafkaProduce, "KAFKA"},
 		{EventKafkaFetch, "KAFKA"},
+		{EventKafkaOp, "KAFKA"},
 	}
 	for _, c := range cases {
 		e := &Event{Type: c.et}
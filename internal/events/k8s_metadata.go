@@ -16,6 +16,12 @@ type K8sMetadata struct {
 	WorkloadKind string
 
 	WorkloadName string
+
+	// ContainerRuntime and RuntimeVersion are what the node's CRI reports,
+	// e.g. "containerd" and "v1.7.13".
+	ContainerRuntime string
+
+	RuntimeVersion string
 }
 
 // IsZero reports whether the metadata bundle contains no useful
@@ -27,5 +33,7 @@ func (m K8sMetadata) IsZero() bool {
 		m.NodeName == "" &&
 		m.ContainerName == "" &&
 		m.WorkloadKind == "" &&
-		m.WorkloadName == ""
+		m.WorkloadName == "" &&
+		m.ContainerRuntime == "" &&
+		m.RuntimeVersion == ""
 }
\ No newline at end of file
@@ -0,0 +1,156 @@
+// Package federation lets podtrace in one cluster request a bounded trace
+// from the agents of another, through the federation gateway the remote
+// operator serves. A request becomes a PodTraceSession in the remote cluster;
+// its events go to the remote ExporterConfig, so pointing both clusters at
+// the same tracing backend puts both sides of a cross-cluster call in one
+// place.
+package federation
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/fips"
+)
+
+// MaxDuration bounds the trace a request may ask for.
+const MaxDuration = time.Hour
+
+// SessionsPath is where the gateway accepts trace requests; the status of
+// one is at SessionsPath/<namespace>/<name>.
+const SessionsPath = "/v1/sessions"
+
+// TraceRequest asks the remote cluster to trace the pods matching Selector
+// in Namespace for Duration.
+type TraceRequest struct {
+	Namespace string `json:"namespace"`
+	// Selector is a label selector, e.g. "app=api,tier=web".
+	Selector string `json:"selector"`
+	// Duration is a Go duration string, e.g. "90s".
+	Duration string `json:"duration"`
+	// Exporter is the remote ExporterConfig the events are sent to.
+	Exporter string `json:"exporter"`
+	// Filters are event categories (dns, net, fs, ...); empty traces all.
+	Filters []string `json:"filters,omitempty"`
+	// RequestedBy names the requesting cluster, for the remote audit trail.
+	RequestedBy string `json:"requestedBy,omitempty"`
+}
+
+// ParsedDuration returns the validated Duration.
+func (r TraceRequest) ParsedDuration() (time.Duration, error) {
+	d, err := time.ParseDuration(r.Duration)
+	if err != nil || d <= 0 || d > MaxDuration {
+		return 0, fmt.Errorf("federation: duration %q must be in (0, %s]", r.Duration, MaxDuration)
+	}
+	return d, nil
+}
+
+// Validate reports the first problem with r.
+func (r TraceRequest) Validate() error {
+	switch {
+	case r.Namespace == "":
+		return errors.New("federation: namespace is required")
+	case r.Selector == "":
+		return errors.New("federation: selector is required")
+	case r.Exporter == "":
+		return errors.New("federation: exporter is required")
+	}
+	_, err := r.ParsedDuration()
+	return err
+}
+
+// Session is a requested trace as the remote cluster reports it.
+type Session struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// State is the PodTraceSession state: Pending, Running, Completed or
+	// Failed; empty until the operator picked it up.
+	State          string `json:"state,omitempty"`
+	ReportLocation string `json:"reportLocation,omitempty"`
+	Message        string `json:"message,omitempty"`
+}
+
+// Done reports whether the session finished, either way.
+func (s *Session) Done() bool {
+	return s.State == "Completed" || s.State == "Failed"
+}
+
+// Client requests traces through a federation gateway.
+type Client struct {
+	gateway *url.URL
+	token   string
+	http    *http.Client
+}
+
+// NewClient returns a client for the gateway at gatewayURL, authenticating
+// with token as a bearer token.
+func NewClient(gatewayURL, token string) (*Client, error) {
+	u, err := url.Parse(gatewayURL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("federation: gateway %q is not an http(s) URL", gatewayURL)
+	}
+	if token == "" {
+		return nil, errors.New("federation: a gateway token is required")
+	}
+	if u.Scheme == "http" {
+		if err := fips.CheckCleartext("federation gateway", u.Host); err != nil {
+			return nil, err
+		}
+	}
+	return &Client{gateway: u, token: token, http: &http.Client{Timeout: 30 * time.Second}}, nil
+}
+
+// RequestTrace asks the remote cluster to start the trace r describes.
+func (c *Client) RequestTrace(ctx context.Context, r TraceRequest) (*Session, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(ctx, http.MethodPost, SessionsPath, body)
+}
+
+// Status returns the current state of a requested trace.
+func (c *Client) Status(ctx context.Context, namespace, name string) (*Session, error) {
+	return c.do(ctx, http.MethodGet, SessionsPath+"/"+namespace+"/"+name, nil)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body []byte) (*Session, error) {
+	u := c.gateway.JoinPath(path)
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("federation: %s %s: %w", method, u.Redacted(), err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("federation: read response: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("federation: gateway returned %s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("federation: decode response: %w", err)
+	}
+	return &s, nil
+}
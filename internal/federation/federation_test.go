@@ -0,0 +1,73 @@
+package federation
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTraceRequestValidate(t *testing.T) {
+	ok := TraceRequest{Namespace: "ns", Selector: "app=x", Duration: "30s", Exporter: "otlp"}
+	if err := ok.Validate(); err != nil {
+		t.Fatalf("Validate(%+v) = %v", ok, err)
+	}
+	for _, mutate := range []func(*TraceRequest){
+		func(r *TraceRequest) { r.Namespace = "" },
+		func(r *TraceRequest) { r.Selector = "" },
+		func(r *TraceRequest) { r.Exporter = "" },
+		func(r *TraceRequest) { r.Duration = "" },
+		func(r *TraceRequest) { r.Duration = "-1s" },
+		func(r *TraceRequest) { r.Duration = "2h" },
+	} {
+		r := ok
+		mutate(&r)
+		if err := r.Validate(); err == nil {
+			t.Errorf("Validate(%+v) = nil, want an error", r)
+		}
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	for _, u := range []string{"", "gateway:8443", "ftp://gateway", "https://"} {
+		if _, err := NewClient(u, "t"); err == nil {
+			t.Errorf("NewClient(%q) = nil error", u)
+		}
+	}
+	if _, err := NewClient("https://gateway:8443", ""); err == nil {
+		t.Error("NewClient without a token = nil error")
+	}
+}
+
+func TestClientStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/base/v1/sessions/ns/federated-abc" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(`{"cluster":"west","namespace":"ns","name":"federated-abc","state":"Failed","message":"no pods matched"}`))
+	}))
+	defer srv.Close()
+
+	c, err := NewClient(srv.URL+"/base", "tok")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	s, err := c.Status(context.Background(), "ns", "federated-abc")
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if !s.Done() || s.Cluster != "west" || s.Message != "no pods matched" {
+		t.Errorf("Status = %+v", s)
+	}
+
+	bad, _ := NewClient(srv.URL+"/base", "wrong")
+	if _, err := bad.Status(context.Background(), "ns", "federated-abc"); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("Status with a wrong token = %v, want a 401 error", err)
+	}
+}
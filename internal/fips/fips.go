@@ -0,0 +1,100 @@
+// Package fips enforces --fips for regulated environments: podtrace must run
+// with Go's FIPS 140-3 cryptographic module enabled, and its network
+// integrations may only connect over TLS that the module allows.
+package fips
+
+import (
+	"crypto/fips140"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+// approvedSuites are the TLS 1.2 cipher suites allowed in FIPS mode; TLS 1.3
+// suites are not configurable and the module restricts them itself.
+var approvedSuites = map[uint16]bool{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256: true,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384: true,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256:   true,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384:   true,
+}
+
+// cleartextOverrides are the settings that let an integration reach a
+// non-loopback host without TLS.
+var cleartextOverrides = []struct {
+	env     string
+	enabled func() bool
+}{
+	{"PODTRACE_OTLP_INSECURE", config.OTLPAllowInsecureNonLoopback},
+	{"PODTRACE_EXPORTER_INSECURE", config.ExporterAllowInsecureNonLoopback},
+	{"PODTRACE_ALERT_SPLUNK_ALLOW_HTTP", config.SplunkAlertAllowHTTP},
+	{"PODTRACE_ALERT_WEBHOOK_ALLOW_HTTP", config.WebhookAllowHTTP},
+}
+
+// Enabled reports whether the binary runs with the FIPS 140-3 module: built
+// with GOFIPS140, or started with GODEBUG=fips140=on.
+func Enabled() bool {
+	return fips140.Enabled()
+}
+
+// Check returns an error when --fips is set but the module is not enabled,
+// or when a setting would let an integration send cleartext off the node.
+// It returns nil without --fips.
+func Check() error {
+	if !config.FIPS {
+		return nil
+	}
+	if !Enabled() {
+		return errors.New("--fips: this binary is not running in FIPS 140-3 mode; use a FIPS build (make build-fips) or set GODEBUG=fips140=on")
+	}
+	for _, o := range cleartextOverrides {
+		if o.enabled() {
+			return fmt.Errorf("--fips: %s allows cleartext connections to non-loopback hosts; unset it", o.env)
+		}
+	}
+	return nil
+}
+
+// CheckTLS refuses, under --fips, a TLS config that skips certificate
+// verification or allows versions or cipher suites outside FIPS mode.
+func CheckTLS(what string, cfg *tls.Config) error {
+	if !config.FIPS || cfg == nil {
+		return nil
+	}
+	if cfg.InsecureSkipVerify {
+		return fmt.Errorf("--fips: %s skips TLS certificate verification", what)
+	}
+	if cfg.MinVersion != 0 && cfg.MinVersion < tls.VersionTLS12 {
+		return fmt.Errorf("--fips: %s allows TLS versions below 1.2", what)
+	}
+	for _, id := range cfg.CipherSuites {
+		if !approvedSuites[id] {
+			return fmt.Errorf("--fips: %s allows cipher suite %s, which FIPS mode does not approve", what, tls.CipherSuiteName(id))
+		}
+	}
+	return nil
+}
+
+// CheckCleartext refuses, under --fips, a connection without TLS to host,
+// which may carry a port. Loopback hosts are allowed: the traffic does not
+// leave the node.
+func CheckCleartext(what, host string) error {
+	if !config.FIPS {
+		return nil
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(strings.ToLower(host), "[]")
+	if host == "localhost" {
+		return nil
+	}
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return fmt.Errorf("--fips: %s to %s would not use TLS", what, host)
+}
@@ -0,0 +1,116 @@
+package fips
+
+import (
+	"crypto/tls"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+func withFIPS(t *testing.T) {
+	t.Helper()
+	prev := config.FIPS
+	config.FIPS = true
+	t.Cleanup(func() { config.FIPS = prev })
+}
+
+func TestCheckOff(t *testing.T) {
+	prev := config.FIPS
+	config.FIPS = false
+	defer func() { config.FIPS = prev }()
+	if err := Check(); err != nil {
+		t.Errorf("Check without --fips: %v", err)
+	}
+	if err := CheckCleartext("syslog", "siem.example:514"); err != nil {
+		t.Errorf("CheckCleartext without --fips: %v", err)
+	}
+	if err := CheckTLS("syslog", &tls.Config{InsecureSkipVerify: true}); err != nil {
+		t.Errorf("CheckTLS without --fips: %v", err)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	withFIPS(t)
+	err := Check()
+	if !Enabled() {
+		if err == nil || !strings.Contains(err.Error(), "not running in FIPS 140-3 mode") {
+			t.Errorf("Check outside FIPS mode = %v", err)
+		}
+		return
+	}
+	if err != nil {
+		t.Fatalf("Check in FIPS mode: %v", err)
+	}
+	t.Setenv("PODTRACE_ALERT_WEBHOOK_ALLOW_HTTP", "true")
+	if err := Check(); err == nil || !strings.Contains(err.Error(), "PODTRACE_ALERT_WEBHOOK_ALLOW_HTTP") {
+		t.Errorf("Check with a cleartext override = %v", err)
+	}
+}
+
+func TestCheckCleartext(t *testing.T) {
+	withFIPS(t)
+	for _, host := range []string{"localhost:4318", "127.0.0.1", "[::1]:514", "127.0.0.53:53"} {
+		if err := CheckCleartext("syslog", host); err != nil {
+			t.Errorf("CheckCleartext(%q): %v", host, err)
+		}
+	}
+	for _, host := range []string{"siem.example:514", "10.0.0.5", "[fd00::1]:601"} {
+		if err := CheckCleartext("syslog", host); err == nil {
+			t.Errorf("CheckCleartext(%q): expected an error", host)
+		}
+	}
+}
+
+func TestCheckTLS(t *testing.T) {
+	withFIPS(t)
+	for name, tc := range map[string]struct {
+		cfg *tls.Config
+		ok  bool
+	}{
+		"default":       {&tls.Config{MinVersion: tls.VersionTLS12}, true},
+		"approved":      {&tls.Config{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}}, true},
+		"skip verify":   {&tls.Config{InsecureSkipVerify: true}, false},
+		"tls 1.0":       {&tls.Config{MinVersion: tls.VersionTLS10}, false},
+		"chacha suites": {&tls.Config{CipherSuites: []uint16{tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256}}, false},
+	} {
+		if err := CheckTLS("syslog", tc.cfg); (err == nil) != tc.ok {
+			t.Errorf("%s: CheckTLS = %v, want ok %v", name, err, tc.ok)
+		}
+	}
+}
+
+// TestNoUnapprovedCrypto keeps algorithms FIPS mode does not approve, and
+// crypto outside the standard library's module, out of podtrace's code.
+func TestNoUnapprovedCrypto(t *testing.T) {
+	banned := []string{"crypto/md5", "crypto/sha1", "crypto/des", "crypto/rc4", "golang.org/x/crypto"}
+	fset := token.NewFileSet()
+	for _, root := range []string{"../../cmd", "../../internal", "../../pkg"} {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+				return err
+			}
+			f, err := parser.ParseFile(fset, path, nil, parser.ImportsOnly)
+			if err != nil {
+				return err
+			}
+			for _, imp := range f.Imports {
+				p, _ := strconv.Unquote(imp.Path.Value)
+				for _, b := range banned {
+					if p == b || strings.HasPrefix(p, b+"/") {
+						t.Errorf("%s imports %s", path, p)
+					}
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Package geoip tags internet-bound traffic with the network that owns the
+// remote address (its autonomous system number and organization, and its
+// country when known) from local MaxMind DB files such as GeoLite2-ASN, so
+// the report can say which third-party provider retransmits or failures
+// concentrate on. No lookups leave the node.
+package geoip
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// maxCachedInfos bounds the per-address lookup cache.
+const maxCachedInfos = 8192
+
+// Info is what the databases know about one address.
+type Info struct {
+	ASN     uint32 `json:"asn,omitempty"`
+	Org     string `json:"org,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+// Network names the owning network as "AS16509/Amazon.com, Inc.", or
+// whatever part of that is known.
+func (i Info) Network() string {
+	switch {
+	case i.ASN != 0 && i.Org != "":
+		return fmt.Sprintf("AS%d/%s", i.ASN, i.Org)
+	case i.ASN != 0:
+		return fmt.Sprintf("AS%d", i.ASN)
+	case i.Org != "":
+		return i.Org
+	}
+	return ""
+}
+
+func (i Info) empty() bool {
+	return i.ASN == 0 && i.Org == "" && i.Country == ""
+}
+
+// DB looks addresses up in one or more MMDB files, merging what each knows,
+// so an ASN database and a country database can be used together.
+type DB struct {
+	readers []*Reader
+
+	mu    sync.Mutex
+	cache map[netip.Addr]Info
+}
+
+// Open reads the given MMDB files into memory.
+func Open(paths []string) (*DB, error) {
+	db := &DB{cache: make(map[netip.Addr]Info)}
+	for _, path := range paths {
+		buf, err := os.ReadFile(path) // #nosec G304 -- operator-supplied --geoip-db file.
+		if err != nil {
+			return nil, fmt.Errorf("geoip: %w", err)
+		}
+		r, err := newReader(buf)
+		if err != nil {
+			return nil, fmt.Errorf("geoip %s: %w", path, err)
+		}
+		db.readers = append(db.readers, r)
+	}
+	if len(db.readers) == 0 {
+		return nil, fmt.Errorf("geoip: no database files given")
+	}
+	return db, nil
+}
+
+// DatabaseTypes lists the database_type of each loaded file.
+func (db *DB) DatabaseTypes() []string {
+	out := make([]string, len(db.readers))
+	for i, r := range db.readers {
+		out[i] = r.DatabaseType
+	}
+	return out
+}
+
+// Lookup returns what the databases know about addr. Only public addresses
+// are looked up; cluster and node addresses are never in these databases.
+func (db *DB) Lookup(addr netip.Addr) (Info, bool) {
+	addr = addr.Unmap()
+	if !IsExternal(addr) {
+		return Info{}, false
+	}
+	db.mu.Lock()
+	info, ok := db.cache[addr]
+	db.mu.Unlock()
+	if ok {
+		return info, !info.empty()
+	}
+	for _, r := range db.readers {
+		rec, err := r.lookup(addr)
+		if err != nil || rec == nil {
+			continue
+		}
+		merge(&info, rec)
+	}
+	db.mu.Lock()
+	if len(db.cache) >= maxCachedInfos {
+		for k := range db.cache {
+			delete(db.cache, k)
+			break
+		}
+	}
+	db.cache[addr] = info
+	db.mu.Unlock()
+	return info, !info.empty()
+}
+
+// merge fills the fields of info still unset from an MMDB record, reading
+// the MaxMind/DB-IP layout (autonomous_system_*, country.iso_code) and the
+// IPinfo one (asn "AS123", name or as_name, country).
+func merge(info *Info, rec any) {
+	m, ok := rec.(map[string]any)
+	if !ok {
+		return
+	}
+	if info.ASN == 0 {
+		if n := asUint(m["autonomous_system_number"]); n > 0 && n <= 1<<32-1 {
+			info.ASN = uint32(n)
+		} else if s, ok := m["asn"].(string); ok {
+			if n, err := strconv.ParseUint(strings.TrimPrefix(strings.ToUpper(s), "AS"), 10, 32); err == nil {
+				info.ASN = uint32(n)
+			}
+		}
+	}
+	if info.Org == "" {
+		for _, k := range []string{"autonomous_system_organization", "as_name", "name"} {
+			if s, ok := m[k].(string); ok && s != "" {
+				info.Org = s
+				break
+			}
+		}
+	}
+	if info.Country == "" {
+		for _, k := range []string{"country", "registered_country"} {
+			switch c := m[k].(type) {
+			case map[string]any:
+				if s, ok := c["iso_code"].(string); ok && s != "" {
+					info.Country = s
+				}
+			case string:
+				info.Country = c
+			}
+			if info.Country != "" {
+				break
+			}
+		}
+	}
+}
+
+// IsExternal reports whether addr is an internet address rather than a
+// cluster, node, loopback or carrier-grade NAT one.
+func IsExternal(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnat.Contains(addr)
+}
+
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+var global atomic.Pointer[DB]
+
+// SetGlobal installs the database the report tags external targets with;
+// nil disables tagging.
+func SetGlobal(db *DB) {
+	global.Store(db)
+}
+
+// Global returns the database installed with SetGlobal, or nil.
+func Global() *DB {
+	return global.Load()
+}
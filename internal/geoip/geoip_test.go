@@ -0,0 +1,205 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+// buildMMDB writes a minimal IPv6 MaxMind DB mapping each prefix to its
+// record, enough to exercise the reader end to end.
+func buildMMDB(t *testing.T, recordSize int, records map[string]map[string]any) []byte {
+	t.Helper()
+	const empty, child, leaf = 0, 1, 2
+	type rec struct{ kind, v int }
+	nodes := [][2]rec{{}}
+	var data bytes.Buffer
+	var offsets []int
+
+	prefixes := make([]string, 0, len(records))
+	for p := range records {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+	for _, ps := range prefixes {
+		p := netip.MustParsePrefix(ps)
+		bits, ip := p.Bits(), p.Addr().As16()
+		if p.Addr().Is4() {
+			bits += 96
+			a := p.Addr().As4()
+			ip = [16]byte{}
+			copy(ip[12:], a[:])
+		}
+		offsets = append(offsets, data.Len())
+		encodeValue(&data, records[ps])
+		node := 0
+		for i := 0; i < bits; i++ {
+			bit := ip[i>>3] >> (7 - uint(i&7)) & 1
+			if i == bits-1 {
+				nodes[node][bit] = rec{leaf, len(offsets) - 1}
+				break
+			}
+			if nodes[node][bit].kind != child {
+				nodes = append(nodes, [2]rec{})
+				nodes[node][bit] = rec{child, len(nodes) - 1}
+			}
+			node = nodes[node][bit].v
+		}
+	}
+
+	var out bytes.Buffer
+	n := len(nodes)
+	value := func(r rec) uint32 {
+		switch r.kind {
+		case child:
+			return uint32(r.v)
+		case leaf:
+			return uint32(n + 16 + offsets[r.v])
+		}
+		return uint32(n)
+	}
+	for _, nd := range nodes {
+		l, r := value(nd[0]), value(nd[1])
+		switch recordSize {
+		case 24:
+			out.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 28:
+			out.Write([]byte{byte(l >> 16), byte(l >> 8), byte(l), byte(l>>24)<<4 | byte(r>>24), byte(r >> 16), byte(r >> 8), byte(r)})
+		case 32:
+			_ = binary.Write(&out, binary.BigEndian, [2]uint32{l, r})
+		}
+	}
+	out.Write(make([]byte, 16))
+	out.Write(data.Bytes())
+	out.Write(metadataMarker)
+	encodeValue(&out, map[string]any{
+		"node_count":    uint32(n),
+		"record_size":   uint32(recordSize),
+		"ip_version":    uint32(6),
+		"database_type": "Test-ASN",
+	})
+	return out.Bytes()
+}
+
+func encodeValue(b *bytes.Buffer, v any) {
+	ctrl := func(typ byte, size int) {
+		sizeBits, ext := byte(size), []byte(nil)
+		if size >= 29 {
+			sizeBits, ext = 29, []byte{byte(size - 29)}
+		}
+		if typ > 7 {
+			b.Write([]byte{sizeBits, typ - 7})
+		} else {
+			b.WriteByte(typ<<5 | sizeBits)
+		}
+		b.Write(ext)
+	}
+	switch x := v.(type) {
+	case string:
+		ctrl(typeString, len(x))
+		b.WriteString(x)
+	case uint32:
+		var p []byte
+		for n := x; n > 0; n >>= 8 {
+			p = append([]byte{byte(n)}, p...)
+		}
+		ctrl(typeUint32, len(p))
+		b.Write(p)
+	case map[string]any:
+		ctrl(typeMap, len(x))
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encodeValue(b, k)
+			encodeValue(b, x[k])
+		}
+	}
+}
+
+func writeDB(t *testing.T, buf []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	if err := os.WriteFile(path, buf, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+var testRecords = map[string]map[string]any{
+	"52.94.0.0/16": {
+		"autonomous_system_number":       uint32(16509),
+		"autonomous_system_organization": "Amazon.com, Inc.",
+	},
+	"8.8.8.0/24": {
+		"asn":     "AS15169",
+		"name":    "Google LLC",
+		"country": "US",
+	},
+	"2a03:2880::/32": {
+		"autonomous_system_number": uint32(32934),
+		"registered_country":       map[string]any{"iso_code": "IE"},
+	},
+}
+
+func TestLookup(t *testing.T) {
+	for _, size := range []int{24, 28, 32} {
+		db, err := Open([]string{writeDB(t, buildMMDB(t, size, testRecords))})
+		if err != nil {
+			t.Fatalf("record size %d: %v", size, err)
+		}
+		tests := []struct {
+			addr string
+			want Info
+			ok   bool
+		}{
+			{"52.94.12.1", Info{ASN: 16509, Org: "Amazon.com, Inc."}, true},
+			{"::ffff:52.94.200.7", Info{ASN: 16509, Org: "Amazon.com, Inc."}, true},
+			{"8.8.8.8", Info{ASN: 15169, Org: "Google LLC", Country: "US"}, true},
+			{"2a03:2880:f10c::1", Info{ASN: 32934, Country: "IE"}, true},
+			{"1.1.1.1", Info{}, false},
+			{"10.0.0.1", Info{}, false},
+		}
+		for _, tt := range tests {
+			got, ok := db.Lookup(netip.MustParseAddr(tt.addr))
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("record size %d: Lookup(%s) = %+v, %v; want %+v, %v", size, tt.addr, got, ok, tt.want, tt.ok)
+			}
+		}
+		if types := db.DatabaseTypes(); len(types) != 1 || types[0] != "Test-ASN" {
+			t.Errorf("DatabaseTypes() = %v", types)
+		}
+	}
+}
+
+func TestOpenRejectsNonMMDB(t *testing.T) {
+	if _, err := Open([]string{writeDB(t, []byte("not a database"))}); err == nil {
+		t.Fatal("expected an error for a file without MMDB metadata")
+	}
+	if _, err := Open(nil); err == nil {
+		t.Fatal("expected an error without files")
+	}
+}
+
+func TestInfoNetwork(t *testing.T) {
+	tests := []struct {
+		info Info
+		want string
+	}{
+		{Info{ASN: 16509, Org: "Amazon.com, Inc."}, "AS16509/Amazon.com, Inc."},
+		{Info{ASN: 16509}, "AS16509"},
+		{Info{Org: "Example"}, "Example"},
+		{Info{Country: "US"}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.info.Network(); got != tt.want {
+			t.Errorf("%+v.Network() = %q, want %q", tt.info, got, tt.want)
+		}
+	}
+}
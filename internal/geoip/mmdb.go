@@ -0,0 +1,307 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/netip"
+)
+
+// metadataMarker starts the metadata section at the end of an MMDB file.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// maxDecodeDepth bounds nested maps, arrays and pointers in a record.
+const maxDecodeDepth = 32
+
+// Reader looks up addresses in a MaxMind DB (MMDB) file: a binary search
+// tree over address bits whose leaves point into a data section of
+// self-describing values. Only what podtrace needs is implemented: lookups
+// returning records as maps, slices, strings and numbers.
+type Reader struct {
+	buf          []byte
+	nodeCount    uint
+	recordSize   uint
+	ipVersion    uint
+	treeSize     uint
+	ipv4Start    uint
+	DatabaseType string
+}
+
+func newReader(buf []byte) (*Reader, error) {
+	i := bytes.LastIndex(buf, metadataMarker)
+	if i < 0 {
+		return nil, fmt.Errorf("not a MaxMind DB file: metadata not found")
+	}
+	metaStart := uint(i + len(metadataMarker))
+	d := decoder{buf: buf, base: metaStart}
+	v, _, err := d.decode(metaStart, 0)
+	if err != nil {
+		return nil, fmt.Errorf("metadata: %w", err)
+	}
+	meta, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("metadata is not a map")
+	}
+	r := &Reader{
+		buf:        buf,
+		nodeCount:  uint(asUint(meta["node_count"])),
+		recordSize: uint(asUint(meta["record_size"])),
+		ipVersion:  uint(asUint(meta["ip_version"])),
+	}
+	r.DatabaseType, _ = meta["database_type"].(string)
+	switch r.recordSize {
+	case 24, 28, 32:
+	default:
+		return nil, fmt.Errorf("unsupported record size %d", r.recordSize)
+	}
+	if r.ipVersion != 4 && r.ipVersion != 6 {
+		return nil, fmt.Errorf("unsupported ip version %d", r.ipVersion)
+	}
+	r.treeSize = r.nodeCount * r.recordSize / 4
+	if r.treeSize+16 > uint(i) {
+		return nil, fmt.Errorf("search tree of %d nodes exceeds the file", r.nodeCount)
+	}
+	// IPv4 addresses live under ::/96 of an IPv6 tree.
+	if r.ipVersion == 6 {
+		node := uint(0)
+		for b := 0; b < 96 && node < r.nodeCount; b++ {
+			node = r.readNode(node, 0)
+		}
+		r.ipv4Start = node
+	}
+	return r, nil
+}
+
+// lookup returns the record for addr, or nil when the database has none.
+func (r *Reader) lookup(addr netip.Addr) (any, error) {
+	addr = addr.Unmap()
+	var ip []byte
+	node := uint(0)
+	switch {
+	case addr.Is4() && r.ipVersion == 6:
+		a := addr.As4()
+		ip, node = a[:], r.ipv4Start
+	case addr.Is4():
+		a := addr.As4()
+		ip = a[:]
+	case r.ipVersion == 6:
+		a := addr.As16()
+		ip = a[:]
+	default:
+		return nil, nil
+	}
+	for i := 0; i < len(ip)*8 && node < r.nodeCount; i++ {
+		node = r.readNode(node, uint(ip[i>>3]>>(7-uint(i&7)))&1)
+	}
+	if node <= r.nodeCount {
+		return nil, nil
+	}
+	// Records past node_count point into the data section, which starts
+	// after the tree and a 16-byte separator.
+	off := r.treeSize + node - r.nodeCount
+	if off >= uint(len(r.buf)) {
+		return nil, fmt.Errorf("record pointer %d out of range", node)
+	}
+	d := decoder{buf: r.buf, base: r.treeSize + 16}
+	v, _, err := d.decode(off, 0)
+	return v, err
+}
+
+func (r *Reader) readNode(node, bit uint) uint {
+	b := r.buf
+	switch r.recordSize {
+	case 24:
+		off := node*6 + bit*3
+		return uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+	case 28:
+		off := node * 7
+		if bit == 0 {
+			return uint(b[off+3]&0xf0)<<20 | uint(b[off])<<16 | uint(b[off+1])<<8 | uint(b[off+2])
+		}
+		return uint(b[off+3]&0x0f)<<24 | uint(b[off+4])<<16 | uint(b[off+5])<<8 | uint(b[off+6])
+	default:
+		off := node*8 + bit*4
+		return uint(binary.BigEndian.Uint32(b[off:]))
+	}
+}
+
+// decoder reads values of the MMDB data section; pointers are relative to
+// base.
+type decoder struct {
+	buf  []byte
+	base uint
+}
+
+// MMDB data types.
+const (
+	typeExtended = 0
+	typePointer  = 1
+	typeString   = 2
+	typeDouble   = 3
+	typeBytes    = 4
+	typeUint16   = 5
+	typeUint32   = 6
+	typeMap      = 7
+	typeInt32    = 8
+	typeUint64   = 9
+	typeUint128  = 10
+	typeArray    = 11
+	typeBool     = 14
+	typeFloat    = 15
+)
+
+func (d *decoder) take(off, n uint) ([]byte, error) {
+	if off+n > uint(len(d.buf)) || off+n < off {
+		return nil, fmt.Errorf("value at %d overruns the data section", off)
+	}
+	return d.buf[off : off+n], nil
+}
+
+func (d *decoder) decode(off uint, depth int) (any, uint, error) {
+	if depth > maxDecodeDepth {
+		return nil, 0, fmt.Errorf("values nested too deeply")
+	}
+	b, err := d.take(off, 1)
+	if err != nil {
+		return nil, 0, err
+	}
+	ctrl := b[0]
+	off++
+	typ := ctrl >> 5
+	if typ == typePointer {
+		n := uint(ctrl>>3&3) + 1
+		p, err := d.take(off, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		off += n
+		var ptr uint
+		v := uint(ctrl & 7)
+		switch n {
+		case 1:
+			ptr = v<<8 | uint(p[0])
+		case 2:
+			ptr = (v<<16 | uint(p[0])<<8 | uint(p[1])) + 2048
+		case 3:
+			ptr = (v<<24 | uint(p[0])<<16 | uint(p[1])<<8 | uint(p[2])) + 526336
+		default:
+			ptr = uint(binary.BigEndian.Uint32(p))
+		}
+		val, _, err := d.decode(d.base+ptr, depth+1)
+		return val, off, err
+	}
+	if typ == typeExtended {
+		e, err := d.take(off, 1)
+		if err != nil {
+			return nil, 0, err
+		}
+		typ = 7 + e[0]
+		off++
+	}
+	size := uint(ctrl & 0x1f)
+	if size >= 29 {
+		n := size - 28
+		s, err := d.take(off, n)
+		if err != nil {
+			return nil, 0, err
+		}
+		off += n
+		switch n {
+		case 1:
+			size = 29 + uint(s[0])
+		case 2:
+			size = 285 + (uint(s[0])<<8 | uint(s[1]))
+		default:
+			size = 65821 + (uint(s[0])<<16 | uint(s[1])<<8 | uint(s[2]))
+		}
+	}
+
+	switch typ {
+	case typeMap:
+		m := make(map[string]any, size)
+		for i := uint(0); i < size; i++ {
+			k, next, err := d.decode(off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			key, ok := k.(string)
+			if !ok {
+				return nil, 0, fmt.Errorf("map key at %d is not a string", off)
+			}
+			v, next2, err := d.decode(next, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			m[key] = v
+			off = next2
+		}
+		return m, off, nil
+	case typeArray:
+		a := make([]any, 0, min(size, 1024))
+		for i := uint(0); i < size; i++ {
+			v, next, err := d.decode(off, depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			a = append(a, v)
+			off = next
+		}
+		return a, off, nil
+	case typeBool:
+		return size != 0, off, nil
+	}
+
+	p, err := d.take(off, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	off += size
+	switch typ {
+	case typeString:
+		return string(p), off, nil
+	case typeBytes, typeUint128:
+		return append([]byte(nil), p...), off, nil
+	case typeDouble:
+		if size != 8 {
+			return nil, 0, fmt.Errorf("double of size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(p)), off, nil
+	case typeFloat:
+		if size != 4 {
+			return nil, 0, fmt.Errorf("float of size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(p))), off, nil
+	case typeUint16, typeUint32, typeUint64:
+		if size > 8 {
+			return nil, 0, fmt.Errorf("unsigned integer of size %d", size)
+		}
+		var n uint64
+		for _, c := range p {
+			n = n<<8 | uint64(c)
+		}
+		return n, off, nil
+	case typeInt32:
+		if size > 4 {
+			return nil, 0, fmt.Errorf("int32 of size %d", size)
+		}
+		var n uint32
+		for _, c := range p {
+			n = n<<8 | uint32(c)
+		}
+		return int64(int32(n)), off, nil
+	}
+	return nil, 0, fmt.Errorf("unknown data type %d at %d", typ, off)
+}
+
+func asUint(v any) uint64 {
+	switch n := v.(type) {
+	case uint64:
+		return n
+	case int64:
+		if n > 0 {
+			return uint64(n)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,308 @@
+// Package history indexes the diagnose runs made from a workstation, with
+// their reports, so that `podtrace history` and `podtrace show` can find and
+// re-open a past run instead of searching the filesystem for its output.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/hostfs"
+)
+
+const (
+	// IndexFile holds one JSON Run per line, oldest first.
+	IndexFile  = "history.db"
+	reportsDir = "reports"
+)
+
+// ErrNotFound is returned by Find when no run matches.
+var ErrNotFound = errors.New("no such run in the history")
+
+// Run is the record of one diagnose run.
+type Run struct {
+	// ID is the start time and a random suffix, e.g. 20261016-142233-9f1c;
+	// any unique prefix finds the run.
+	ID      string    `json:"id"`
+	Target  string    `json:"target"`
+	Started time.Time `json:"started"`
+	// DurationMS is how long the trace ran.
+	DurationMS int64 `json:"durationMs"`
+	// Issues are the codes of the issues found. Remote runs, whose report
+	// was streamed back from a spawned pod, do not count them.
+	Issues []string `json:"issues,omitempty"`
+	Remote bool     `json:"remote,omitempty"`
+	// Exports are where else the run's results went: bundle, summary file,
+	// report sink.
+	Exports []string `json:"exports,omitempty"`
+	// Metrics are the headline figures of a local run, e.g. dns_p99_ms,
+	// that the next run of the same target is compared with.
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+	// Format is that of the stored report: text, json or csv.
+	Format string `json:"format"`
+	Report string `json:"report"`
+}
+
+// Duration is how long the trace ran.
+func (r Run) Duration() time.Duration {
+	return time.Duration(r.DurationMS) * time.Millisecond
+}
+
+// Store is a history directory.
+type Store struct {
+	dir string
+	// max is how many runs are kept; the oldest are removed beyond it. 0
+	// keeps them all.
+	max int
+}
+
+// Open returns the store in dir, created on the first Record.
+func Open(dir string, max int) *Store {
+	return &Store{dir: dir, max: max}
+}
+
+// DefaultDir is ~/.podtrace.
+func DefaultDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("locate the history directory: %w", err)
+	}
+	return filepath.Join(home, ".podtrace"), nil
+}
+
+// Dir is the directory of the store.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Record stores report and adds run to the index, assigning its ID and
+// report file, and returns it.
+func (s *Store) Record(run Run, report []byte) (Run, error) {
+	if err := os.MkdirAll(filepath.Join(s.dir, reportsDir), 0o700); err != nil {
+		return Run{}, fmt.Errorf("create history directory: %w", err)
+	}
+	id, err := newID(run.Started)
+	if err != nil {
+		return Run{}, err
+	}
+	run.ID = id
+	if run.Format == "" {
+		run.Format = "text"
+	}
+	run.Report = filepath.Join(reportsDir, id+"."+reportExt(run.Format))
+	if err := hostfs.WriteFileWithin(s.dir, filepath.Join(s.dir, run.Report), report, 0o600); err != nil {
+		return Run{}, fmt.Errorf("store report: %w", err)
+	}
+	line, err := json.Marshal(run)
+	if err != nil {
+		return Run{}, err
+	}
+
+	f, err := os.OpenFile(filepath.Join(s.dir, IndexFile), os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600) // #nosec G304 -- fixed name under the history directory.
+	if err != nil {
+		return Run{}, fmt.Errorf("open history index: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	// Runs that finish together take turns; the lock goes with the file.
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		return Run{}, fmt.Errorf("lock history index: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return Run{}, fmt.Errorf("write history index: %w", err)
+	}
+	return run, s.prune()
+}
+
+// prune drops the oldest runs beyond max. The caller holds the index lock.
+func (s *Store) prune() error {
+	if s.max <= 0 {
+		return nil
+	}
+	runs, err := s.read()
+	if err != nil || len(runs) <= s.max {
+		return err
+	}
+	drop := runs[:len(runs)-s.max]
+	var buf bytes.Buffer
+	for _, r := range runs[len(drop):] {
+		line, err := json.Marshal(r)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	// Rewrite in place: a rename would swap the file out from under the
+	// lock other runs wait on.
+	if err := os.WriteFile(filepath.Join(s.dir, IndexFile), buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("prune history index: %w", err)
+	}
+	for _, r := range drop {
+		_ = os.Remove(s.reportPath(r))
+	}
+	return nil
+}
+
+// List returns the runs, newest first.
+func (s *Store) List() ([]Run, error) {
+	runs, err := s.read()
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(runs, func(i, j int) bool { return runs[i].Started.After(runs[j].Started) })
+	return runs, nil
+}
+
+// read returns the runs in index order, skipping lines it cannot parse.
+func (s *Store) read() ([]Run, error) {
+	data, err := os.ReadFile(filepath.Join(s.dir, IndexFile)) // #nosec G304 -- fixed name under the history directory.
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read history index: %w", err)
+	}
+	var runs []Run
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var r Run
+		if json.Unmarshal(sc.Bytes(), &r) == nil && r.ID != "" {
+			runs = append(runs, r)
+		}
+	}
+	return runs, sc.Err()
+}
+
+// Find returns the run whose ID is ref or starts with it; an empty ref
+// finds the latest run.
+func (s *Store) Find(ref string) (Run, error) {
+	runs, err := s.List()
+	if err != nil {
+		return Run{}, err
+	}
+	if ref == "" {
+		if len(runs) == 0 {
+			return Run{}, ErrNotFound
+		}
+		return runs[0], nil
+	}
+	var match []Run
+	for _, r := range runs {
+		if r.ID == ref {
+			return r, nil
+		}
+		if strings.HasPrefix(r.ID, ref) {
+			match = append(match, r)
+		}
+	}
+	switch len(match) {
+	case 0:
+		return Run{}, fmt.Errorf("%w: %q", ErrNotFound, ref)
+	case 1:
+		return match[0], nil
+	default:
+		return Run{}, fmt.Errorf("%q matches %d runs; give more of the ID", ref, len(match))
+	}
+}
+
+// Previous returns the latest run of target that started before t and
+// recorded metrics.
+func (s *Store) Previous(target string, t time.Time) (Run, error) {
+	runs, err := s.List()
+	if err != nil {
+		return Run{}, err
+	}
+	for _, r := range runs {
+		if r.Target == target && r.Started.Before(t) && len(r.Metrics) > 0 {
+			return r, nil
+		}
+	}
+	return Run{}, fmt.Errorf("%w: no earlier run of %s", ErrNotFound, target)
+}
+
+// Delta is the change of one metric since a previous run.
+type Delta struct {
+	Metric   string
+	Previous float64
+	Current  float64
+}
+
+// Change is the relative change, e.g. 3.4 for +340%. A metric that was
+// zero before changes by +Inf when it is not now.
+func (d Delta) Change() float64 {
+	if d.Previous == 0 {
+		if d.Current == 0 {
+			return 0
+		}
+		return math.Inf(1)
+	}
+	return (d.Current - d.Previous) / d.Previous
+}
+
+// Compare returns the metrics both runs recorded that changed by at least
+// minChange (0.2 is 20%) either way, the largest change first.
+func Compare(previous, current map[string]float64, minChange float64) []Delta {
+	var out []Delta
+	for name, cur := range current {
+		prev, ok := previous[name]
+		if !ok {
+			continue
+		}
+		d := Delta{Metric: name, Previous: prev, Current: cur}
+		if math.Abs(d.Change()) >= minChange && d.Change() != 0 {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		ci, cj := math.Abs(out[i].Change()), math.Abs(out[j].Change())
+		if ci != cj {
+			return ci > cj
+		}
+		return out[i].Metric < out[j].Metric
+	})
+	return out
+}
+
+// Report returns the stored report of r.
+func (s *Store) Report(r Run) ([]byte, error) {
+	data, err := os.ReadFile(s.reportPath(r))
+	if err != nil {
+		return nil, fmt.Errorf("read report of run %s: %w", r.ID, err)
+	}
+	return data, nil
+}
+
+func (s *Store) reportPath(r Run) string {
+	// The index is user-writable; never follow it out of the directory.
+	return filepath.Join(s.dir, reportsDir, filepath.Base(r.Report))
+}
+
+func reportExt(format string) string {
+	switch format {
+	case "json", "csv":
+		return format
+	case "proto":
+		return "pb"
+	}
+	return "txt"
+}
+
+func newID(started time.Time) (string, error) {
+	var b [2]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return started.UTC().Format("20060102-150405") + "-" + hex.EncodeToString(b[:]), nil
+}
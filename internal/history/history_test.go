@@ -0,0 +1,158 @@
+package history
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordListFind(t *testing.T) {
+	s := Open(t.TempDir(), 0)
+	t0 := time.Date(2026, 10, 13, 9, 30, 0, 0, time.UTC)
+
+	first, err := s.Record(Run{Target: "prod/api-0", Started: t0, DurationMS: 60000, Issues: []string{"PODTRACE-NET-001"}}, []byte("report one\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(first.ID, "20261013-093000-") || first.Format != "text" {
+		t.Errorf("unexpected record %+v", first)
+	}
+	second, err := s.Record(Run{Target: "prod/api-1", Started: t0.Add(time.Hour), Format: "json", Remote: true}, []byte("{}"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Ext(second.Report) != ".json" {
+		t.Errorf("json report stored as %q", second.Report)
+	}
+
+	runs, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[0].ID != second.ID || runs[1].ID != first.ID {
+		t.Fatalf("expected newest first, got %+v", runs)
+	}
+	if runs[1].Duration() != time.Minute {
+		t.Errorf("duration = %s", runs[1].Duration())
+	}
+
+	latest, err := s.Find("")
+	if err != nil || latest.ID != second.ID {
+		t.Errorf("Find(\"\") = %+v, %v", latest, err)
+	}
+	got, err := s.Find(first.ID[:len("20261013-09")])
+	if err != nil || got.ID != first.ID {
+		t.Errorf("prefix lookup = %+v, %v", got, err)
+	}
+	if _, err := s.Find("2026"); err == nil || !strings.Contains(err.Error(), "matches 2 runs") {
+		t.Errorf("expected an ambiguous prefix error, got %v", err)
+	}
+	if _, err := s.Find("2025"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+
+	report, err := s.Report(first)
+	if err != nil || string(report) != "report one\n" {
+		t.Errorf("Report = %q, %v", report, err)
+	}
+}
+
+func TestFind_EmptyStore(t *testing.T) {
+	s := Open(filepath.Join(t.TempDir(), "missing"), 0)
+	if runs, err := s.List(); err != nil || len(runs) != 0 {
+		t.Errorf("List on a missing store = %v, %v", runs, err)
+	}
+	if _, err := s.Find(""); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestRecord_PrunesOldestRuns(t *testing.T) {
+	dir := t.TempDir()
+	s := Open(dir, 2)
+	t0 := time.Now()
+	var recorded []Run
+	for i := 0; i < 3; i++ {
+		r, err := s.Record(Run{Started: t0.Add(time.Duration(i) * time.Minute)}, []byte("r"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		recorded = append(recorded, r)
+	}
+	runs, err := s.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 2 || runs[1].ID != recorded[1].ID {
+		t.Fatalf("expected the two latest runs, got %+v", runs)
+	}
+	if _, err := os.Stat(filepath.Join(dir, recorded[0].Report)); !os.IsNotExist(err) {
+		t.Errorf("report of the pruned run still present: %v", err)
+	}
+}
+
+func TestRead_SkipsCorruptLines(t *testing.T) {
+	dir := t.TempDir()
+	s := Open(dir, 0)
+	r, err := s.Record(Run{Started: time.Now()}, []byte("r"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, IndexFile), os.O_APPEND|os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString("not json\n{\"id\":\"\"}\n")
+	_ = f.Close()
+	runs, err := s.List()
+	if err != nil || len(runs) != 1 || runs[0].ID != r.ID {
+		t.Errorf("List = %+v, %v", runs, err)
+	}
+}
+
+func TestReport_StaysInStore(t *testing.T) {
+	dir := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(outside, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s := Open(dir, 0)
+	if _, err := s.Report(Run{ID: "x", Report: "../../" + outside}); err == nil {
+		t.Error("read a report outside the store")
+	}
+}
+
+func TestPreviousAndCompare(t *testing.T) {
+	s := Open(t.TempDir(), 0)
+	t0 := time.Date(2026, 10, 13, 9, 30, 0, 0, time.UTC)
+	record := func(target string, at time.Time, metrics map[string]float64) Run {
+		t.Helper()
+		r, err := s.Record(Run{Target: target, Started: at, Metrics: metrics}, []byte("report\n"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return r
+	}
+	want := record("prod/api-0", t0, map[string]float64{"dns_p99_ms": 10, "fs_p99_ms": 4, "connect_errors_pct": 0})
+	record("prod/api-1", t0.Add(time.Hour), map[string]float64{"dns_p99_ms": 1})
+	record("prod/api-0", t0.Add(2*time.Hour), nil)
+
+	prev, err := s.Previous("prod/api-0", t0.Add(3*time.Hour))
+	if err != nil || prev.ID != want.ID {
+		t.Fatalf("Previous = %+v, %v; want %s", prev, err, want.ID)
+	}
+	if _, err := s.Previous("prod/api-0", t0); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Previous before the first run: %v", err)
+	}
+
+	deltas := Compare(prev.Metrics, map[string]float64{"dns_p99_ms": 44, "fs_p99_ms": 4.4, "connect_errors_pct": 5, "http_p99_ms": 9}, 0.2)
+	if len(deltas) != 2 || deltas[0].Metric != "connect_errors_pct" || deltas[1].Metric != "dns_p99_ms" {
+		t.Fatalf("deltas = %+v", deltas)
+	}
+	if c := deltas[1].Change(); c != 3.4 {
+		t.Errorf("dns change = %v, want 3.4", c)
+	}
+}
@@ -0,0 +1,36 @@
+//go:build linux
+
+package hostfs
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+// verifyInRoot confirms rel opens beneath root with openat2 while refusing to
+// follow any symlink. Kernels without openat2 (< 5.6) fall back to the
+// userspace resolution alone.
+func verifyInRoot(root, rel string) error {
+	if rel == "" {
+		return nil
+	}
+	rootFd, err := unix.Open(root, unix.O_PATH|unix.O_DIRECTORY|unix.O_CLOEXEC, 0)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = unix.Close(rootFd) }()
+
+	how := &unix.OpenHow{
+		Flags:   uint64(unix.O_PATH | unix.O_CLOEXEC),
+		Resolve: unix.RESOLVE_IN_ROOT | unix.RESOLVE_NO_SYMLINKS | unix.RESOLVE_NO_MAGICLINKS,
+	}
+	fd, err := unix.Openat2(rootFd, rel, how)
+	if errors.Is(err, unix.ENOSYS) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return unix.Close(fd)
+}
@@ -0,0 +1,7 @@
+//go:build !linux
+
+package hostfs
+
+func verifyInRoot(_, _ string) error {
+	return nil
+}
@@ -0,0 +1,79 @@
+package hostfs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveInRoot_AbsoluteSymlinkStaysInRoot(t *testing.T) {
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "usr/lib"))
+	mustMkdir(t, filepath.Join(root, "etc"))
+	mustWrite(t, filepath.Join(root, "etc/shadow"), "container")
+	if err := os.Symlink("/etc/shadow", filepath.Join(root, "usr/lib/libc.so.6")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveInRoot(root, "/usr/lib/libc.so.6")
+	if err != nil {
+		t.Fatalf("ResolveInRoot: %v", err)
+	}
+	if want := filepath.Join(root, "etc/shadow"); got != want {
+		t.Errorf("got %q, want %q (absolute symlink must re-anchor at root)", got, want)
+	}
+}
+
+func TestResolveInRoot_DotDotClampedAtRoot(t *testing.T) {
+	root := t.TempDir()
+	mustMkdir(t, filepath.Join(root, "lib"))
+	mustWrite(t, filepath.Join(root, "lib/libssl.so"), "x")
+	if err := os.Symlink("../../../../lib/libssl.so", filepath.Join(root, "lib/escape.so")); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveInRoot(root, "lib/escape.so")
+	if err != nil {
+		t.Fatalf("ResolveInRoot: %v", err)
+	}
+	if want := filepath.Join(root, "lib/libssl.so"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestResolveInRoot_SymlinkLoop(t *testing.T) {
+	root := t.TempDir()
+	if err := os.Symlink("b", filepath.Join(root, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("a", filepath.Join(root, "b")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ResolveInRoot(root, "a"); !errors.Is(err, ErrSymlinkLoop) {
+		t.Fatalf("expected ErrSymlinkLoop, got %v", err)
+	}
+}
+
+func TestStatInRoot_MissingAndRelativeRoot(t *testing.T) {
+	if _, _, err := StatInRoot(t.TempDir(), "nope"); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist, got %v", err)
+	}
+	if _, err := ResolveInRoot("relative/root", "x"); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("expected ErrInvalidPath, got %v", err)
+	}
+}
+
+func mustMkdir(t *testing.T, dir string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustWrite(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
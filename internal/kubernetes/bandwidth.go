@@ -0,0 +1,48 @@
+package kubernetes
+
+import (
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// Annotations the CNI bandwidth plugin shapes pod traffic with.
+const (
+	IngressBandwidthAnnotation = "kubernetes.io/ingress-bandwidth"
+	EgressBandwidthAnnotation  = "kubernetes.io/egress-bandwidth"
+)
+
+// PodBandwidth is the traffic shaping requested by a pod's bandwidth
+// annotations, in bits per second; zero means unshaped.
+type PodBandwidth struct {
+	IngressBitsPerS uint64
+	EgressBitsPerS  uint64
+}
+
+// podBandwidth reads the bandwidth annotations of pod. Values are resource
+// quantities ("10M", "1G") in bits per second, as the bandwidth plugin
+// reads them; unparseable values are ignored.
+func podBandwidth(pod *corev1.Pod) PodBandwidth {
+	return PodBandwidth{
+		IngressBitsPerS: parseBandwidth(pod, IngressBandwidthAnnotation),
+		EgressBitsPerS:  parseBandwidth(pod, EgressBandwidthAnnotation),
+	}
+}
+
+func parseBandwidth(pod *corev1.Pod, annotation string) uint64 {
+	v, ok := pod.Annotations[annotation]
+	if !ok {
+		return 0
+	}
+	q, err := resource.ParseQuantity(v)
+	if err != nil || q.Sign() <= 0 {
+		logger.Debug("Ignoring invalid bandwidth annotation",
+			zap.String("pod", pod.Namespace+"/"+pod.Name),
+			zap.String("annotation", annotation),
+			zap.String("value", v))
+		return 0
+	}
+	return uint64(q.Value())
+}
@@ -0,0 +1,27 @@
+package kubernetes
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestPodBandwidth(t *testing.T) {
+	tests := []struct {
+		annotations map[string]string
+		want        PodBandwidth
+	}{
+		{nil, PodBandwidth{}},
+		{map[string]string{IngressBandwidthAnnotation: "10M"}, PodBandwidth{IngressBitsPerS: 10_000_000}},
+		{map[string]string{IngressBandwidthAnnotation: "1G", EgressBandwidthAnnotation: "500k"}, PodBandwidth{IngressBitsPerS: 1_000_000_000, EgressBitsPerS: 500_000}},
+		{map[string]string{EgressBandwidthAnnotation: "fast"}, PodBandwidth{}},
+		{map[string]string{EgressBandwidthAnnotation: "-1M"}, PodBandwidth{}},
+	}
+	for _, tt := range tests {
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Namespace: "ns", Annotations: tt.annotations}}
+		if got := podBandwidth(pod); got != tt.want {
+			t.Errorf("podBandwidth(%v) = %+v, want %+v", tt.annotations, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContainerKind tells regular, init and ephemeral containers apart.
+type ContainerKind string
+
+const (
+	ContainerKindRegular   ContainerKind = "container"
+	ContainerKindInit      ContainerKind = "init"
+	ContainerKindEphemeral ContainerKind = "ephemeral"
+)
+
+// ContainerSummary is one container declared by a pod.
+type ContainerSummary struct {
+	Name    string
+	Kind    ContainerKind
+	Running bool
+	// TargetContainer is the container whose namespaces an ephemeral
+	// container joined (kubectl debug --target), if any.
+	TargetContainer string
+}
+
+// PodContainers lists every container the pod declares, regular first, then
+// init, then ephemeral (debug) containers added with kubectl debug.
+func PodContainers(pod *corev1.Pod) []ContainerSummary {
+	running := make(map[string]bool)
+	for _, list := range [][]corev1.ContainerStatus{
+		pod.Status.ContainerStatuses,
+		pod.Status.InitContainerStatuses,
+		pod.Status.EphemeralContainerStatuses,
+	} {
+		for _, cs := range list {
+			running[cs.Name] = cs.State.Running != nil && cs.ContainerID != ""
+		}
+	}
+	out := make([]ContainerSummary, 0,
+		len(pod.Spec.Containers)+len(pod.Spec.InitContainers)+len(pod.Spec.EphemeralContainers))
+	for _, c := range pod.Spec.Containers {
+		out = append(out, ContainerSummary{Name: c.Name, Kind: ContainerKindRegular, Running: running[c.Name]})
+	}
+	for _, c := range pod.Spec.InitContainers {
+		out = append(out, ContainerSummary{Name: c.Name, Kind: ContainerKindInit, Running: running[c.Name]})
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		out = append(out, ContainerSummary{
+			Name:            c.Name,
+			Kind:            ContainerKindEphemeral,
+			Running:         running[c.Name],
+			TargetContainer: c.TargetContainerName,
+		})
+	}
+	return out
+}
+
+// ListContainers returns the containers of a pod, including ephemeral ones,
+// so callers can offer them for --container.
+func (r *PodResolver) ListContainers(ctx context.Context, podName, namespace string) ([]ContainerSummary, error) {
+	pod, err := r.clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+	if err != nil {
+		return nil, NewPodNotFoundError(podName, namespace, err)
+	}
+	return PodContainers(pod), nil
+}
+
+// containerKind returns the kind of the named container of pod.
+func containerKind(pod *corev1.Pod, name string) ContainerKind {
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == name {
+			return ContainerKindInit
+		}
+	}
+	for _, c := range pod.Spec.EphemeralContainers {
+		if c.Name == name {
+			return ContainerKindEphemeral
+		}
+	}
+	return ContainerKindRegular
+}
+
+// DefaultContainerAnnotation names a pod's main container, as honoured by
+// kubectl logs and exec.
+const DefaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// sidecarPatterns match the names and images of well-known sidecars, which
+// are never the pod's main app when something else runs next to them.
+var sidecarPatterns = []string{
+	"istio-proxy", "istio/proxyv2", "linkerd-proxy", "linkerd/proxy", "envoy",
+	"cloud-sql-proxy", "cloudsql-proxy", "vault-agent", "hashicorp/vault",
+	"daprd", "consul-dataplane", "oauth2-proxy", "kube-rbac-proxy",
+	"fluent-bit", "fluentd", "filebeat", "promtail",
+	"opentelemetry-collector", "otel-collector", "jaeger-agent", "datadog/agent",
+	"config-reloader", "configmap-reload",
+}
+
+// ContainerChoice records which container of a multi-container pod a PodInfo
+// reports as its main one, and why.
+type ContainerChoice struct {
+	Name       string
+	Reason     string
+	Candidates []string
+	// Guessed is set when the pick came from heuristics rather than from the
+	// pod itself (a single candidate or the default-container annotation).
+	Guessed bool
+}
+
+// ChooseMainContainer picks the pod's main app among the named candidate
+// containers: the one named by the default-container annotation, else the
+// non-sidecar regular container with the largest CPU request, else the first
+// candidate that is not an ephemeral debug container.
+func ChooseMainContainer(pod *corev1.Pod, candidates []string) ContainerChoice {
+	choice := ContainerChoice{Candidates: candidates}
+	var stable []string
+	for _, name := range candidates {
+		if containerKind(pod, name) != ContainerKindEphemeral {
+			stable = append(stable, name)
+		}
+	}
+	switch len(stable) {
+	case 0:
+		if len(candidates) > 0 {
+			choice.Name, choice.Reason = candidates[0], "only ephemeral containers are running"
+		}
+		return choice
+	case 1:
+		choice.Name, choice.Reason = stable[0], "only container"
+		return choice
+	}
+
+	if want := pod.Annotations[DefaultContainerAnnotation]; want != "" {
+		for _, name := range stable {
+			if name == want {
+				choice.Name, choice.Reason = name, DefaultContainerAnnotation+" annotation"
+				return choice
+			}
+		}
+	}
+
+	choice.Guessed = true
+	var best *corev1.Container
+	var bestCPU int64
+	apps := 0
+	for i := range pod.Spec.Containers {
+		c := &pod.Spec.Containers[i]
+		if !slices.Contains(stable, c.Name) || isSidecar(c) {
+			continue
+		}
+		apps++
+		cpu := c.Resources.Requests.Cpu().MilliValue()
+		if best == nil || cpu > bestCPU {
+			best, bestCPU = c, cpu
+		}
+	}
+	switch {
+	case best == nil:
+		choice.Name, choice.Reason = stable[0], "first container; every container looks like a sidecar"
+	case apps == 1:
+		choice.Name, choice.Reason = best.Name, "only container that is not a known sidecar"
+	case bestCPU > 0:
+		choice.Name, choice.Reason = best.Name, fmt.Sprintf("largest CPU request (%dm)", bestCPU)
+	default:
+		choice.Name, choice.Reason = best.Name, "first container that is not a known sidecar"
+	}
+	return choice
+}
+
+func isSidecar(c *corev1.Container) bool {
+	name, image := strings.ToLower(c.Name), strings.ToLower(c.Image)
+	for _, p := range sidecarPatterns {
+		if strings.Contains(name, p) || strings.Contains(image, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// primaryTarget picks the target a PodInfo reports as its ContainerID, using
+// ChooseMainContainer over the resolved targets.
+func primaryTarget(pod *corev1.Pod, targets []ContainerTarget) (ContainerTarget, ContainerChoice) {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Name
+	}
+	choice := ChooseMainContainer(pod, names)
+	for _, t := range targets {
+		if t.Name == choice.Name {
+			return t, choice
+		}
+	}
+	return targets[0], choice
+}
+
+// newContainerNotFoundInPodError names the containers the pod does have, so
+// a typo or a finished debug session is obvious from the message.
+func newContainerNotFoundInPodError(name string, pod *corev1.Pod) *KubernetesError {
+	err := NewContainerNotFoundError(name)
+	var names []string
+	for _, c := range PodContainers(pod) {
+		label := c.Name
+		if c.Kind != ContainerKindRegular {
+			label += " (" + string(c.Kind) + ")"
+		}
+		if !c.Running {
+			label += " [not running]"
+		}
+		names = append(names, label)
+	}
+	if len(names) > 0 {
+		err.Message = fmt.Sprintf("%s (containers: %s)", err.Message, strings.Join(names, ", "))
+	}
+	return err
+}
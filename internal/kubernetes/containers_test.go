@@ -0,0 +1,194 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// withDebugContainer adds a running ephemeral container, as kubectl debug
+// --target=app would.
+func withDebugContainer(pod *corev1.Pod, name, id string) *corev1.Pod {
+	pod.Spec.EphemeralContainers = append(pod.Spec.EphemeralContainers, corev1.EphemeralContainer{
+		EphemeralContainerCommon: corev1.EphemeralContainerCommon{Name: name},
+		TargetContainerName:      "app",
+	})
+	pod.Status.EphemeralContainerStatuses = append(pod.Status.EphemeralContainerStatuses, corev1.ContainerStatus{
+		Name: name, ContainerID: "containerd://" + id,
+		State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+	})
+	return pod
+}
+
+func TestPodContainers_ListsEphemeral(t *testing.T) {
+	pod := multiContainerRunningPod("default", "web", map[string]string{"app": strings.Repeat("a", 64)})
+	pod.Spec.InitContainers = []corev1.Container{{Name: "migrate"}}
+	withDebugContainer(pod, "debugger-x7k2", strings.Repeat("b", 64))
+
+	got := PodContainers(pod)
+	want := []ContainerSummary{
+		{Name: "app", Kind: ContainerKindRegular, Running: true},
+		{Name: "migrate", Kind: ContainerKindInit},
+		{Name: "debugger-x7k2", Kind: ContainerKindEphemeral, Running: true, TargetContainer: "app"},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("PodContainers = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("container %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	resolver := NewPodResolverForTesting(fake.NewSimpleClientset(pod))
+	listed, err := resolver.ListContainers(context.Background(), "web", "default")
+	if err != nil || len(listed) != 3 {
+		t.Fatalf("ListContainers = %+v, %v", listed, err)
+	}
+}
+
+func TestResolvePod_EphemeralContainer(t *testing.T) {
+	idApp := strings.Repeat("c", 64)
+	idDebug := strings.Repeat("d", 64)
+	fakeCgroupBase(t, idApp, idDebug)
+	pod := withDebugContainer(
+		multiContainerRunningPod("default", "web", map[string]string{"app": idApp}),
+		"debugger", idDebug)
+	resolver := NewPodResolverForTesting(fake.NewSimpleClientset(pod))
+
+	info, err := resolver.ResolvePod(context.Background(), "web", "default", "")
+	if err != nil {
+		t.Fatalf("ResolvePod: %v", err)
+	}
+	if len(info.Containers) != 2 {
+		t.Fatalf("Containers = %+v, want app and debugger", info.Containers)
+	}
+	if info.ContainerName != "app" || info.ContainerID != idApp {
+		t.Errorf("primary container = %s/%s, want the app container", info.ContainerName, info.ContainerID)
+	}
+	if info.Containers[1].Kind != ContainerKindEphemeral {
+		t.Errorf("debugger kind = %q, want %q", info.Containers[1].Kind, ContainerKindEphemeral)
+	}
+
+	info, err = resolver.ResolvePod(context.Background(), "web", "default", "debugger")
+	if err != nil {
+		t.Fatalf("ResolvePod(debugger): %v", err)
+	}
+	if len(info.Containers) != 1 || info.ContainerID != idDebug {
+		t.Fatalf("explicit ephemeral target = %+v, want only the debugger", info.Containers)
+	}
+}
+
+func TestResolvePod_ContainerNotFoundListsContainers(t *testing.T) {
+	pod := withDebugContainer(
+		multiContainerRunningPod("default", "web", map[string]string{"app": strings.Repeat("e", 64)}),
+		"debugger", strings.Repeat("f", 64))
+	resolver := NewPodResolverForTesting(fake.NewSimpleClientset(pod))
+
+	_, err := resolver.ResolvePod(context.Background(), "web", "default", "debuger")
+	if err == nil {
+		t.Fatal("expected container-not-found error")
+	}
+	if !strings.Contains(err.Error(), "app, debugger (ephemeral)") {
+		t.Errorf("error should list the pod's containers, got %q", err)
+	}
+}
+
+func TestPrimaryTarget_SkipsEphemeral(t *testing.T) {
+	pod := withDebugContainer(
+		multiContainerRunningPod("default", "web", map[string]string{"app": strings.Repeat("1", 64)}),
+		"debugger", strings.Repeat("2", 64))
+	targets := []ContainerTarget{
+		{Name: "debugger", Kind: ContainerKindEphemeral},
+		{Name: "app", Kind: ContainerKindRegular},
+	}
+	if got, _ := primaryTarget(pod, targets); got.Name != "app" {
+		t.Errorf("primaryTarget = %q, want app", got.Name)
+	}
+	if got, _ := primaryTarget(pod, targets[:1]); got.Name != "debugger" {
+		t.Errorf("primaryTarget with only an ephemeral container = %q, want debugger", got.Name)
+	}
+}
+
+func mainContainerPod(containers ...corev1.Container) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec:       corev1.PodSpec{Containers: containers},
+	}
+}
+
+func withCPU(c corev1.Container, cpu string) corev1.Container {
+	c.Resources.Requests = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse(cpu)}
+	return c
+}
+
+func TestChooseMainContainer(t *testing.T) {
+	proxy := corev1.Container{Name: "istio-proxy", Image: "docker.io/istio/proxyv2:1.22"}
+	logs := corev1.Container{Name: "shipper", Image: "fluent/fluent-bit:3.0"}
+	app := corev1.Container{Name: "api", Image: "registry.example.com/api:v4"}
+	worker := corev1.Container{Name: "worker", Image: "registry.example.com/worker:v4"}
+
+	tests := []struct {
+		name        string
+		pod         *corev1.Pod
+		candidates  []string
+		want        string
+		wantGuessed bool
+		wantReason  string
+	}{
+		{"single", mainContainerPod(app), []string{"api"}, "api", false, "only container"},
+		{"sidecar first", mainContainerPod(proxy, app), []string{"istio-proxy", "api"}, "api", true, "not a known sidecar"},
+		{"image pattern", mainContainerPod(logs, app), []string{"shipper", "api"}, "api", true, "not a known sidecar"},
+		{"largest cpu", mainContainerPod(withCPU(app, "200m"), withCPU(worker, "1"), proxy),
+			[]string{"api", "worker", "istio-proxy"}, "worker", true, "largest CPU request (1000m)"},
+		{"no requests", mainContainerPod(worker, app), []string{"worker", "api"}, "worker", true, "first container"},
+		{"all sidecars", mainContainerPod(proxy, logs), []string{"istio-proxy", "shipper"}, "istio-proxy", true, "looks like a sidecar"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChooseMainContainer(tt.pod, tt.candidates)
+			if got.Name != tt.want || got.Guessed != tt.wantGuessed || !strings.Contains(got.Reason, tt.wantReason) {
+				t.Errorf("ChooseMainContainer = %+v, want %s (guessed=%v, reason ~ %q)", got, tt.want, tt.wantGuessed, tt.wantReason)
+			}
+		})
+	}
+
+	annotated := mainContainerPod(withCPU(app, "100m"), withCPU(worker, "2"))
+	annotated.Annotations = map[string]string{DefaultContainerAnnotation: "api"}
+	if got := ChooseMainContainer(annotated, []string{"api", "worker"}); got.Name != "api" || got.Guessed {
+		t.Errorf("annotation should win without guessing, got %+v", got)
+	}
+}
+
+func TestResolvePod_PicksMainContainer(t *testing.T) {
+	idProxy := strings.Repeat("3", 64)
+	idApp := strings.Repeat("4", 64)
+	fakeCgroupBase(t, idProxy, idApp)
+	pod := multiContainerRunningPod("default", "web", map[string]string{"sidecar": idProxy, "app": idApp})
+	pod.Spec.Containers[0].Image = "docker.io/istio/proxyv2:1.22"
+	resolver := NewPodResolverForTesting(fake.NewSimpleClientset(pod))
+
+	info, err := resolver.ResolvePod(context.Background(), "web", "default", "")
+	if err != nil {
+		t.Fatalf("ResolvePod: %v", err)
+	}
+	if info.ContainerName != "app" || info.ContainerID != idApp {
+		t.Errorf("main container = %s, want app", info.ContainerName)
+	}
+	if !info.MainContainer.Guessed || len(info.Containers) != 2 {
+		t.Errorf("MainContainer = %+v, Containers = %d", info.MainContainer, len(info.Containers))
+	}
+}
+
+func TestPodHasContainerID_Ephemeral(t *testing.T) {
+	id := strings.Repeat("9", 64)
+	pod := withDebugContainer(multiContainerRunningPod("default", "web", nil), "debugger", id)
+	if !podHasContainerID(pod, id) {
+		t.Error("ephemeral container ID should be found")
+	}
+}
@@ -0,0 +1,166 @@
+package kubernetes
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// Disruption is an eviction or preemption of a pod on a node, or a change of
+// the node's pressure conditions.
+type Disruption struct {
+	// Kind is one of the events.Disruption kinds.
+	Kind string
+	// Subject is the pod as namespace/name, or the node for node kinds.
+	Subject  string
+	Resource string
+	Node     string
+	At       time.Time
+}
+
+// Event returns d as an EventDisruption.
+func (d Disruption) Event() *events.Event {
+	return &events.Event{
+		Type:      events.EventDisruption,
+		Timestamp: clock.WallToBPFTimestamp(d.At),
+		Target:    d.Subject,
+		Details:   events.DisruptionDetails{Kind: d.Kind, Resource: d.Resource, Node: d.Node}.String(),
+	}
+}
+
+var (
+	// "The node was low on resource: memory. Threshold quantity: ..."
+	evictedResourceRe = regexp.MustCompile(`low on resource: ([A-Za-z0-9.\-]+)`)
+	// "Attempting to reclaim memory"
+	reclaimResourceRe = regexp.MustCompile(`reclaim ([A-Za-z0-9.\-]+)`)
+	// "Preempted by pod 1c3f... on node node-1", or "by a pod on node ...".
+	preemptedNodeRe = regexp.MustCompile(`on node (\S+)`)
+)
+
+// ParseDisruption reads the disruption of node an event reports, and returns
+// false for any other event, or one about another node.
+func ParseDisruption(ev *corev1.Event, node string) (Disruption, bool) {
+	if ev == nil {
+		return Disruption{}, false
+	}
+	d := Disruption{Node: node, At: eventTime(ev)}
+	switch ev.Reason {
+	case "Evicted":
+		if ev.InvolvedObject.Kind != "Pod" || ev.Source.Host != node {
+			return Disruption{}, false
+		}
+		d.Kind, d.Subject = events.DisruptionEviction, ev.InvolvedObject.Namespace+"/"+ev.InvolvedObject.Name
+		if m := evictedResourceRe.FindStringSubmatch(ev.Message); m != nil {
+			d.Resource = strings.TrimSuffix(m[1], ".")
+		}
+	case "Preempted":
+		m := preemptedNodeRe.FindStringSubmatch(ev.Message)
+		if ev.InvolvedObject.Kind != "Pod" || m == nil || m[1] != node {
+			return Disruption{}, false
+		}
+		d.Kind, d.Subject = events.DisruptionPreemption, ev.InvolvedObject.Namespace+"/"+ev.InvolvedObject.Name
+	case "EvictionThresholdMet":
+		if ev.InvolvedObject.Kind != "Node" || ev.InvolvedObject.Name != node {
+			return Disruption{}, false
+		}
+		d.Kind, d.Subject = events.DisruptionEvictionThreshold, node
+		if m := reclaimResourceRe.FindStringSubmatch(ev.Message); m != nil {
+			d.Resource = m[1]
+		}
+	default:
+		return Disruption{}, false
+	}
+	return d, true
+}
+
+// pressureResources maps the node pressure conditions to their resource.
+var pressureResources = map[corev1.NodeConditionType]string{
+	corev1.NodeMemoryPressure: "memory",
+	corev1.NodeDiskPressure:   "disk",
+	corev1.NodePIDPressure:    "pid",
+}
+
+// pressureChanges returns the pressure conditions of n that changed from
+// last, and records them in last. A condition seen for the first time only
+// counts when it is true.
+func pressureChanges(n *corev1.Node, last map[corev1.NodeConditionType]corev1.ConditionStatus) []Disruption {
+	var out []Disruption
+	for _, c := range n.Status.Conditions {
+		resource, ok := pressureResources[c.Type]
+		if !ok {
+			continue
+		}
+		prev, known := last[c.Type]
+		last[c.Type] = c.Status
+		if prev == c.Status || (!known && c.Status != corev1.ConditionTrue) {
+			continue
+		}
+		d := Disruption{Kind: events.DisruptionNodePressure, Subject: n.Name, Resource: resource, Node: n.Name, At: c.LastTransitionTime.Time}
+		if c.Status != corev1.ConditionTrue {
+			d.Kind = events.DisruptionPressureCleared
+		}
+		if d.At.IsZero() {
+			d.At = time.Now()
+		}
+		out = append(out, d)
+	}
+	return out
+}
+
+// WatchDisruptions calls emit for every eviction and preemption of a pod on
+// node, every eviction threshold the node's kubelet reports and every change
+// of its pressure conditions, from since on, until ctx is done. Events are
+// watched in every namespace; it returns the first error, such as a missing
+// permission to watch events or nodes, after starting what it could.
+func WatchDisruptions(ctx context.Context, clientset kubernetes.Interface, node string, since time.Time, emit func(Disruption)) error {
+	handle := func(ev *corev1.Event) {
+		if d, ok := ParseDisruption(ev, node); ok && !d.At.Before(since) {
+			emit(d)
+		}
+	}
+	var firstErr error
+	for _, reason := range []string{"Evicted", "Preempted", "EvictionThresholdMet"} {
+		if err := watchCoreEvents(ctx, clientset, metav1.NamespaceAll, "reason="+reason, handle); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	if err := watchNodePressure(ctx, clientset, node, since, emit); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// watchNodePressure calls emit for every change of node's pressure
+// conditions after since, and at since for a pressure already on, until ctx
+// is done.
+func watchNodePressure(ctx context.Context, clientset kubernetes.Interface, node string, since time.Time, emit func(Disruption)) error {
+	last := make(map[corev1.NodeConditionType]corev1.ConditionStatus)
+	return watchLoop(ctx, func() (watch.Interface, error) {
+		return clientset.CoreV1().Nodes().Watch(ctx, metav1.ListOptions{FieldSelector: "metadata.name=" + node})
+	}, func(ev watch.Event) {
+		n, ok := ev.Object.(*corev1.Node)
+		if !ok || n.Name != node {
+			return
+		}
+		for _, d := range pressureChanges(n, last) {
+			if d.At.Before(since) {
+				// Pressure that began earlier and still holds is marked
+				// where the trace can see it.
+				if d.Kind != events.DisruptionNodePressure {
+					continue
+				}
+				d.At = since
+			}
+			emit(d)
+		}
+	})
+}
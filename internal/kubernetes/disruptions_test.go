@@ -0,0 +1,147 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func disruptionEvent(reason, kind, ns, name, host, msg string, at time.Time) *corev1.Event {
+	ev := kubeletEvent(reason+name, reason, msg, at, 1)
+	ev.InvolvedObject = corev1.ObjectReference{Kind: kind, Namespace: ns, Name: name}
+	ev.Source.Host = host
+	return ev
+}
+
+func TestParseDisruption(t *testing.T) {
+	at := time.Now()
+	tests := []struct {
+		name string
+		ev   *corev1.Event
+		want Disruption
+		ok   bool
+	}{
+		{
+			name: "eviction on the node",
+			ev:   disruptionEvent("Evicted", "Pod", "batch", "job-1", "node-1", "The node was low on resource: memory. Threshold quantity: 100Mi, available: 50Mi.", at),
+			want: Disruption{Kind: events.DisruptionEviction, Subject: "batch/job-1", Resource: "memory", Node: "node-1", At: at},
+			ok:   true,
+		},
+		{
+			name: "eviction elsewhere",
+			ev:   disruptionEvent("Evicted", "Pod", "batch", "job-2", "node-2", "The node was low on resource: memory.", at),
+		},
+		{
+			name: "preemption",
+			ev:   disruptionEvent("Preempted", "Pod", "web", "low-0", "", "Preempted by pod 1c3f on node node-1", at),
+			want: Disruption{Kind: events.DisruptionPreemption, Subject: "web/low-0", Node: "node-1", At: at},
+			ok:   true,
+		},
+		{
+			name: "eviction threshold",
+			ev:   disruptionEvent("EvictionThresholdMet", "Node", "", "node-1", "node-1", "Attempting to reclaim ephemeral-storage", at),
+			want: Disruption{Kind: events.DisruptionEvictionThreshold, Subject: "node-1", Resource: "ephemeral-storage", Node: "node-1", At: at},
+			ok:   true,
+		},
+		{
+			name: "other reason",
+			ev:   disruptionEvent("Killing", "Pod", "web", "a", "node-1", "Stopping container app", at),
+		},
+	}
+	for _, tt := range tests {
+		got, ok := ParseDisruption(tt.ev, "node-1")
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v", tt.name, ok)
+			continue
+		}
+		if ok && (got.Kind != tt.want.Kind || got.Subject != tt.want.Subject || got.Resource != tt.want.Resource ||
+			got.Node != tt.want.Node || !got.At.Equal(tt.want.At)) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func pressureNode(memory, disk corev1.ConditionStatus, at time.Time) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-1"},
+		Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: corev1.NodeMemoryPressure, Status: memory, LastTransitionTime: metav1.NewTime(at)},
+			{Type: corev1.NodeDiskPressure, Status: disk, LastTransitionTime: metav1.NewTime(at)},
+		}},
+	}
+}
+
+func TestPressureChanges(t *testing.T) {
+	at := time.Now()
+	last := make(map[corev1.NodeConditionType]corev1.ConditionStatus)
+	if got := pressureChanges(pressureNode(corev1.ConditionFalse, corev1.ConditionFalse, at), last); len(got) != 0 {
+		t.Errorf("healthy node reported %+v", got)
+	}
+	got := pressureChanges(pressureNode(corev1.ConditionTrue, corev1.ConditionFalse, at), last)
+	if len(got) != 1 || got[0].Kind != events.DisruptionNodePressure || got[0].Resource != "memory" {
+		t.Errorf("memory pressure = %+v", got)
+	}
+	if got := pressureChanges(pressureNode(corev1.ConditionTrue, corev1.ConditionFalse, at), last); len(got) != 0 {
+		t.Errorf("unchanged node reported %+v", got)
+	}
+	got = pressureChanges(pressureNode(corev1.ConditionFalse, corev1.ConditionFalse, at), last)
+	if len(got) != 1 || got[0].Kind != events.DisruptionPressureCleared {
+		t.Errorf("cleared pressure = %+v", got)
+	}
+}
+
+func TestWatchDisruptions(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	watchers := map[string]*watch.RaceFreeFakeWatcher{}
+	for _, sel := range []string{"reason=Evicted", "reason=Preempted", "reason=EvictionThresholdMet", "metadata.name=node-1"} {
+		watchers[sel] = watch.NewRaceFreeFake()
+	}
+	reactor := func(action k8stesting.Action) (bool, watch.Interface, error) {
+		sel := action.(k8stesting.WatchAction).GetWatchRestrictions().Fields.String()
+		return true, watchers[sel], nil
+	}
+	clientset.PrependWatchReactor("events", reactor)
+	clientset.PrependWatchReactor("nodes", reactor)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	got := make(chan Disruption, 8)
+	if err := WatchDisruptions(ctx, clientset, "node-1", now.Add(-time.Minute), func(d Disruption) { got <- d }); err != nil {
+		t.Fatal(err)
+	}
+	// Pressure on since long before the trace is marked at its start.
+	watchers["metadata.name=node-1"].Add(pressureNode(corev1.ConditionTrue, corev1.ConditionFalse, now.Add(-time.Hour)))
+	watchers["reason=Evicted"].Add(disruptionEvent("Evicted", "Pod", "batch", "old", "node-1", "low on resource: memory", now.Add(-time.Hour)))
+	watchers["reason=Evicted"].Add(disruptionEvent("Evicted", "Pod", "batch", "job-1", "node-1", "low on resource: memory", now))
+
+	var kinds []string
+	for len(kinds) < 2 {
+		select {
+		case d := <-got:
+			kinds = append(kinds, d.Kind+" "+d.Subject)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("disruptions = %v", kinds)
+		}
+	}
+	want := map[string]bool{"node_pressure node-1": true, "eviction batch/job-1": true}
+	for _, k := range kinds {
+		if !want[k] {
+			t.Errorf("unexpected disruption %q", k)
+		}
+	}
+	select {
+	case d := <-got:
+		t.Errorf("unexpected disruption %+v", d)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
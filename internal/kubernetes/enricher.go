@@ -15,6 +15,7 @@ import (
 
 	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/targetnames"
 )
 
 type KubernetesContext struct {
@@ -62,6 +63,7 @@ type ContextEnricher struct {
 	serviceResolver *ServiceResolver
 	cacheTTL        time.Duration
 	informerCache   *InformerCache
+	translator      *ServiceTranslator
 }
 
 func NewContextEnricher(clientset kubernetes.Interface, podInfo *PodInfo) *ContextEnricher {
@@ -75,6 +77,7 @@ func NewContextEnricher(clientset kubernetes.Interface, podInfo *PodInfo) *Conte
 		serviceResolver: NewServiceResolverWithCache(clientset, ic),
 		cacheTTL:        ttl,
 		informerCache:   ic,
+		translator:      NewServiceTranslator(),
 	}
 }
 
@@ -115,15 +118,46 @@ func (ce *ContextEnricher) EnrichEvent(ctx context.Context, event *events.Event)
 			ce.enrichNetworkTarget(ctx, enriched, ip, port)
 		}
 	}
+	enrichPodDNSName(enriched)
 
 	return enriched
 }
 
+// enrichPodDNSName attributes a lookup of, or a connect to, the pod DNS name
+// of a headless Service to that pod: for a StatefulSet the name says which
+// member was dialed, where its IP may only resolve to the Service.
+func enrichPodDNSName(enriched *EnrichedEvent) {
+	var name string
+	switch enriched.Type {
+	case events.EventDNS, events.EventDNSQuery:
+		name = enriched.Target
+	case events.EventConnect:
+		name = enriched.Details
+	}
+	p, ok := targetnames.ParsePodDNSName(name)
+	if !ok {
+		return
+	}
+	kc := enriched.KubernetesContext
+	if kc.TargetPodName == "" {
+		kc.TargetPodName = p.Host
+		kc.TargetNamespace = p.Namespace
+	}
+	if kc.ServiceName == "" {
+		kc.ServiceName = p.Service
+		kc.ServiceNamespace = p.Namespace
+	}
+	kc.IsExternal = false
+}
+
 func (ce *ContextEnricher) enrichNetworkTarget(ctx context.Context, enriched *EnrichedEvent, ip string, port int) {
 	enrichCtx, cancel := context.WithTimeout(ctx, config.K8sAPITimeout)
 	defer cancel()
 
-	serviceInfo := ce.serviceResolver.ResolveService(enrichCtx, ip, port)
+	serviceInfo := ce.dialedService(ip, port)
+	if serviceInfo == nil {
+		serviceInfo = ce.serviceResolver.ResolveService(enrichCtx, ip, port)
+	}
 	if serviceInfo != nil {
 		enriched.KubernetesContext.ServiceName = serviceInfo.Name
 		enriched.KubernetesContext.ServiceNamespace = serviceInfo.Namespace
@@ -143,6 +177,28 @@ func (ce *ContextEnricher) enrichNetworkTarget(ctx context.Context, enriched *En
 	}
 }
 
+// dialedService names the Service a connection to ip:port was addressed
+// to: ip itself when it is a ClusterIP, or else the one Service whose
+// ClusterIP the node's load balancer (IPVS, Cilium) translated to this
+// backend. A backend of several Services is left to ResolveService.
+func (ce *ContextEnricher) dialedService(ip string, port int) *ServiceInfo {
+	if svc := ce.informerCache.GetServiceByClusterIP(ip, port); svc != nil {
+		return svc
+	}
+	var found *ServiceInfo
+	for _, f := range ce.translator.Frontends(ip, port) {
+		svc := ce.informerCache.GetServiceByClusterIP(f.Addr().String(), int(f.Port()))
+		if svc == nil {
+			continue
+		}
+		if found != nil && (found.Name != svc.Name || found.Namespace != svc.Namespace) {
+			return nil
+		}
+		found = svc
+	}
+	return found
+}
+
 func (ce *ContextEnricher) resolvePodByIP(ctx context.Context, ip string) *PodMetadata {
 	if ip == "" {
 		return nil
@@ -247,7 +303,8 @@ func isNetworkEvent(eventType events.EventType) bool {
 		eventType == events.EventUDPSend ||
 		eventType == events.EventUDPRecv ||
 		eventType == events.EventTCPState ||
-		eventType == events.EventTCPRetrans
+		eventType == events.EventTCPRetrans ||
+		eventType == events.EventTCPZeroWindow
 }
 
 func isPrivateIP(ipStr string) bool {
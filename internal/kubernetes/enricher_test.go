@@ -45,6 +45,23 @@ func TestContextEnricher_EnrichEvent(t *testing.T) {
 	}
 }
 
+func TestContextEnricher_EnrichEvent_PodDNSName(t *testing.T) {
+	enricher := NewContextEnricher(fake.NewSimpleClientset(), &PodInfo{PodName: "src", Namespace: "default"})
+	for _, event := range []*events.Event{
+		{Type: events.EventDNS, Target: "web-1.web.db.svc.cluster.local"},
+		{Type: events.EventConnect, Target: "198.51.100.7:5432", Details: "web-1.web.db.svc.cluster.local"},
+	} {
+		kc := enricher.EnrichEvent(context.Background(), event).KubernetesContext
+		if kc.TargetPodName != "web-1" || kc.TargetNamespace != "db" || kc.ServiceName != "web" || kc.ServiceNamespace != "db" || kc.IsExternal {
+			t.Errorf("%s event context = %+v, want member web-1 of db/web", event.TypeString(), kc)
+		}
+	}
+	kc := enricher.EnrichEvent(context.Background(), &events.Event{Type: events.EventDNS, Target: "web.db.svc.cluster.local"}).KubernetesContext
+	if kc.TargetPodName != "" || kc.ServiceName != "" {
+		t.Errorf("Service name lookup context = %+v, want no member", kc)
+	}
+}
+
 func TestContextEnricher_EnrichEvent_NonNetwork(t *testing.T) {
 	clientset := fake.NewSimpleClientset()
 	podInfo := &PodInfo{
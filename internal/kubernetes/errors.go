@@ -1,6 +1,9 @@
 package kubernetes
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 type ErrorCode int
 
@@ -12,6 +15,8 @@ const (
 	ErrCodeContainerNotFound
 	ErrCodeInvalidContainerID
 	ErrCodeCgroupNotFound
+	ErrCodeInitContainerFinished
+	ErrCodeAmbiguousContainer
 )
 
 type KubernetesError struct {
@@ -83,3 +88,17 @@ func NewCgroupNotFoundError(containerID string) *KubernetesError {
 	}
 }
 
+func NewInitContainerFinishedError(containerName string, exitCode int32) *KubernetesError {
+	return &KubernetesError{
+		Code:    ErrCodeInitContainerFinished,
+		Message: fmt.Sprintf("init container %s already finished with exit code %d", containerName, exitCode),
+	}
+}
+
+func NewAmbiguousContainerError(namespace, podName string, choice ContainerChoice) *KubernetesError {
+	return &KubernetesError{
+		Code: ErrCodeAmbiguousContainer,
+		Message: fmt.Sprintf("pod %s/%s has several containers (%s) and would fall back to guessing %q (%s); pass --container",
+			namespace, podName, strings.Join(choice.Candidates, ", "), choice.Name, choice.Reason),
+	}
+}
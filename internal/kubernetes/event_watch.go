@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// watchLoop starts a watch and calls handle for each of its events until ctx
+// is done, re-establishing the watch whenever the server closes it. It
+// returns the error of the first watch.
+func watchLoop(ctx context.Context, start func() (watch.Interface, error), handle func(watch.Event)) error {
+	w, err := start()
+	if err != nil {
+		return err
+	}
+	go func() {
+		defer func() { w.Stop() }()
+		for {
+			var ev watch.Event
+			var open bool
+			select {
+			case <-ctx.Done():
+				return
+			case ev, open = <-w.ResultChan():
+			}
+			if open {
+				handle(ev)
+				continue
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(rewatchBackoff):
+			}
+			if next, err := start(); err == nil {
+				w = next
+			}
+		}
+	}()
+	return nil
+}
+
+// watchCoreEvents calls handle once for every event in namespace ("" for
+// all) matching fieldSelector, including the ones already recorded, and
+// again each time the reporter repeats it, until ctx is done.
+func watchCoreEvents(ctx context.Context, clientset kubernetes.Interface, namespace, fieldSelector string, handle func(*corev1.Event)) error {
+	// A watch restarted without a resource version replays the events
+	// already seen; reporters bump Count when they repeat one.
+	seen := make(map[string]int32)
+	return watchLoop(ctx, func() (watch.Interface, error) {
+		return clientset.CoreV1().Events(namespace).Watch(ctx, metav1.ListOptions{FieldSelector: fieldSelector})
+	}, func(ev watch.Event) {
+		k8sEvent, ok := ev.Object.(*corev1.Event)
+		if !ok || ev.Type == watch.Deleted {
+			return
+		}
+		key := string(k8sEvent.UID)
+		if count, dup := seen[key]; dup && count >= k8sEvent.Count {
+			return
+		}
+		seen[key] = k8sEvent.Count
+		handle(k8sEvent)
+	})
+}
@@ -154,7 +154,9 @@ func (ec *EventsCorrelator) rewatch(ctx context.Context) bool {
 }
 
 func (ec *EventsCorrelator) addEvent(event *corev1.Event) {
-	if event.InvolvedObject.Name != ec.podName {
+	// The issues podtrace itself recorded on the pod are already in the
+	// report.
+	if event.InvolvedObject.Name != ec.podName || event.Reason == IssueEventReason {
 		return
 	}
 
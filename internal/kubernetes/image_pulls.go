@@ -0,0 +1,181 @@
+package kubernetes
+
+import (
+	"context"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/safeconv"
+)
+
+// ImagePull is one image pull the kubelet reported for a pod.
+type ImagePull struct {
+	Image    string
+	Registry string
+	// At is when the kubelet reported the pull done or failed.
+	At time.Time
+	// Duration is the pull itself; Waiting the time it queued behind other
+	// pulls before, when the kubelet serializes them.
+	Duration time.Duration
+	Waiting  time.Duration
+	Size     uint64
+	// Cached is set when the image was already on the node.
+	Cached bool
+	Failed bool
+	// Reason classifies a failure: NotFound, Unauthorized, RateLimited,
+	// Timeout or ErrImagePull.
+	Reason string
+	// RegistryRTT is the TCP connect latency to the registry, when probed.
+	RegistryRTT time.Duration
+}
+
+// Event returns p as an EventImagePull.
+func (p ImagePull) Event() *events.Event {
+	e := &events.Event{
+		Type:      events.EventImagePull,
+		Timestamp: clock.WallToBPFTimestamp(p.At),
+		LatencyNS: safeconv.Int64ToUint64(p.Duration.Nanoseconds()),
+		Bytes:     p.Size,
+		Target:    p.Image,
+		Details: events.ImagePullDetails{
+			Registry:      p.Registry,
+			WaitNS:        safeconv.Int64ToUint64(p.Waiting.Nanoseconds()),
+			RegistryRTTNS: safeconv.Int64ToUint64(p.RegistryRTT.Nanoseconds()),
+			Cached:        p.Cached,
+			Reason:        p.Reason,
+		}.String(),
+	}
+	if p.Failed {
+		e.Error = 1
+	}
+	return e
+}
+
+// Kubelet image event messages, as of Kubernetes 1.30; older kubelets leave
+// out the waiting time and the image size.
+var (
+	pulledImageRe  = regexp.MustCompile(`^Successfully pulled image "([^"]+)" in ([0-9.]+[a-zµ]+)(?: \(([0-9.]+[a-zµ]+) including waiting\))?(?:\. Image size: ([0-9]+) bytes)?`)
+	presentImageRe = regexp.MustCompile(`^Container image "([^"]+)" already present on machine`)
+	failedImageRe  = regexp.MustCompile(`^Failed to pull image "([^"]+)": (.*)`)
+)
+
+// ParseImagePull reads the image pull a kubelet event reports, and returns
+// false for any other event.
+func ParseImagePull(ev *corev1.Event) (ImagePull, bool) {
+	if ev == nil {
+		return ImagePull{}, false
+	}
+	var p ImagePull
+	switch ev.Reason {
+	case "Pulled":
+		if m := pulledImageRe.FindStringSubmatch(ev.Message); m != nil {
+			p.Image = m[1]
+			p.Duration, _ = time.ParseDuration(m[2])
+			if total, err := time.ParseDuration(m[3]); err == nil && total > p.Duration {
+				p.Waiting = total - p.Duration
+			}
+			p.Size, _ = strconv.ParseUint(m[4], 10, 64)
+		} else if m := presentImageRe.FindStringSubmatch(ev.Message); m != nil {
+			p.Image, p.Cached = m[1], true
+		} else {
+			return ImagePull{}, false
+		}
+	case "Failed":
+		m := failedImageRe.FindStringSubmatch(ev.Message)
+		if m == nil {
+			return ImagePull{}, false
+		}
+		p.Image, p.Failed, p.Reason = m[1], true, pullFailureReason(m[2])
+	default:
+		return ImagePull{}, false
+	}
+	p.Registry = ImageRegistry(p.Image)
+	p.At = eventTime(ev)
+	return p, true
+}
+
+// pullFailureReason classifies the runtime's error for a failed pull.
+func pullFailureReason(msg string) string {
+	msg = strings.ToLower(msg)
+	switch {
+	case strings.Contains(msg, "not found") || strings.Contains(msg, "manifest unknown"):
+		return "NotFound"
+	case strings.Contains(msg, "unauthorized") || strings.Contains(msg, "403 forbidden") || strings.Contains(msg, "authorization failed"):
+		return "Unauthorized"
+	case strings.Contains(msg, "too many requests") || strings.Contains(msg, "toomanyrequests") || strings.Contains(msg, "rate limit"):
+		return "RateLimited"
+	case strings.Contains(msg, "timeout") || strings.Contains(msg, "deadline exceeded"):
+		return "Timeout"
+	default:
+		return "ErrImagePull"
+	}
+}
+
+// eventTime is when ev last happened, for kubelets that set either the
+// legacy or the events.k8s.io timestamps.
+func eventTime(ev *corev1.Event) time.Time {
+	switch {
+	case !ev.LastTimestamp.IsZero():
+		return ev.LastTimestamp.Time
+	case !ev.EventTime.IsZero():
+		return ev.EventTime.Time
+	default:
+		return ev.CreationTimestamp.Time
+	}
+}
+
+// ImageRegistry returns the registry host, with its port if any, an image
+// reference pulls from; references without one pull from Docker Hub.
+func ImageRegistry(image string) string {
+	first, _, ok := strings.Cut(image, "/")
+	if !ok || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		return "docker.io"
+	}
+	return first
+}
+
+// registryAddr is the address to connect to for registry, with Docker Hub's
+// API host standing in for docker.io.
+func registryAddr(registry string) string {
+	if registry == "docker.io" {
+		registry = "registry-1.docker.io"
+	}
+	if _, _, err := net.SplitHostPort(registry); err == nil {
+		return registry
+	}
+	return net.JoinHostPort(registry, "443")
+}
+
+// ProbeRegistry returns how long a TCP connect to registry takes, from where
+// podtrace runs: on the node, that is the path the runtime pulls over.
+func ProbeRegistry(ctx context.Context, registry string, timeout time.Duration) (time.Duration, error) {
+	d := net.Dialer{Timeout: timeout}
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", registryAddr(registry))
+	if err != nil {
+		return 0, err
+	}
+	rtt := time.Since(start)
+	_ = conn.Close()
+	return rtt, nil
+}
+
+// WatchImagePulls calls emit for every image pull the kubelet reports for
+// namespace/pod from since on, including the ones already recorded, until
+// ctx is done. It returns the error of the first watch, such as a missing
+// permission to watch events; later closed watches are re-established.
+func WatchImagePulls(ctx context.Context, clientset kubernetes.Interface, namespace, pod string, since time.Time, emit func(ImagePull)) error {
+	return watchCoreEvents(ctx, clientset, namespace, "involvedObject.name="+pod, func(ev *corev1.Event) {
+		if p, ok := ParseImagePull(ev); ok && !p.At.Before(since) {
+			emit(p)
+		}
+	})
+}
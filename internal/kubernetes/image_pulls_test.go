@@ -0,0 +1,153 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func kubeletEvent(uid, reason, msg string, at time.Time, count int32) *corev1.Event {
+	return &corev1.Event{
+		ObjectMeta:    metav1.ObjectMeta{UID: types.UID("uid-" + uid), Name: uid, Namespace: "shop"},
+		Reason:        reason,
+		Message:       msg,
+		LastTimestamp: metav1.NewTime(at),
+		Count:         count,
+	}
+}
+
+func TestParseImagePull(t *testing.T) {
+	at := time.Now()
+	tests := []struct {
+		name string
+		ev   *corev1.Event
+		want ImagePull
+		ok   bool
+	}{
+		{
+			name: "pulled with size",
+			ev:   kubeletEvent("a", "Pulled", `Successfully pulled image "ghcr.io/acme/app:1.2" in 12.5s (14.5s including waiting). Image size: 52428800 bytes.`, at, 1),
+			want: ImagePull{Image: "ghcr.io/acme/app:1.2", Registry: "ghcr.io", Duration: 12500 * time.Millisecond, Waiting: 2 * time.Second, Size: 52428800},
+			ok:   true,
+		},
+		{
+			name: "pulled by an older kubelet",
+			ev:   kubeletEvent("b", "Pulled", `Successfully pulled image "nginx:1.25" in 1.5s`, at, 1),
+			want: ImagePull{Image: "nginx:1.25", Registry: "docker.io", Duration: 1500 * time.Millisecond},
+			ok:   true,
+		},
+		{
+			name: "already present",
+			ev:   kubeletEvent("c", "Pulled", `Container image "localhost:5000/app" already present on machine`, at, 1),
+			want: ImagePull{Image: "localhost:5000/app", Registry: "localhost:5000", Cached: true},
+			ok:   true,
+		},
+		{
+			name: "rate limited",
+			ev:   kubeletEvent("d", "Failed", `Failed to pull image "nginx": rpc error: code = Unknown desc = toomanyrequests: You have reached your pull rate limit`, at, 1),
+			want: ImagePull{Image: "nginx", Registry: "docker.io", Failed: true, Reason: "RateLimited"},
+			ok:   true,
+		},
+		{
+			name: "other failure event",
+			ev:   kubeletEvent("e", "Failed", "Error: ErrImagePull", at, 1),
+		},
+		{
+			name: "pulling",
+			ev:   kubeletEvent("f", "Pulling", `Pulling image "nginx"`, at, 1),
+		},
+	}
+	for _, tt := range tests {
+		got, ok := ParseImagePull(tt.ev)
+		if ok != tt.ok {
+			t.Errorf("%s: ok = %v", tt.name, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		tt.want.At = got.At
+		if got != tt.want || !got.At.Equal(at) {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestImageRegistry(t *testing.T) {
+	for image, want := range map[string]string{
+		"nginx":                       "docker.io",
+		"library/nginx:1.25":          "docker.io",
+		"quay.io/prometheus/node":     "quay.io",
+		"registry.local:5000/app@sha": "registry.local:5000",
+		"localhost/app":               "localhost",
+	} {
+		if got := ImageRegistry(image); got != want {
+			t.Errorf("ImageRegistry(%q) = %q, want %q", image, got, want)
+		}
+	}
+	if got := registryAddr("docker.io"); got != "registry-1.docker.io:443" {
+		t.Errorf("registryAddr(docker.io) = %q", got)
+	}
+	if got := registryAddr("registry.local:5000"); got != "registry.local:5000" {
+		t.Errorf("registryAddr with port = %q", got)
+	}
+}
+
+func TestImagePullEvent(t *testing.T) {
+	e := ImagePull{Image: "nginx", Registry: "docker.io", Duration: time.Second, Size: 1024, Failed: true, Reason: "Timeout", RegistryRTT: 30 * time.Millisecond}.Event()
+	if e.Type != events.EventImagePull || e.Target != "nginx" || e.LatencyNS != uint64(time.Second) || e.Bytes != 1024 || e.Error != 1 {
+		t.Errorf("event = %+v", e)
+	}
+	if d := events.ParseImagePullDetails(e.Details); d.Registry != "docker.io" || d.RegistryRTTNS != uint64(30*time.Millisecond) || d.Reason != "Timeout" {
+		t.Errorf("details = %+v", d)
+	}
+}
+
+func TestWatchImagePulls(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	fw := watch.NewRaceFreeFake()
+	clientset.PrependWatchReactor("events", func(k8stesting.Action) (bool, watch.Interface, error) {
+		return true, fw, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	now := time.Now()
+	got := make(chan ImagePull, 4)
+	if err := WatchImagePulls(ctx, clientset, "shop", "web-0", now.Add(-time.Hour), func(p ImagePull) { got <- p }); err != nil {
+		t.Fatal(err)
+	}
+	pulled := kubeletEvent("a", "Pulled", `Successfully pulled image "nginx" in 2s`, now, 1)
+	fw.Add(kubeletEvent("old", "Pulled", `Successfully pulled image "old" in 2s`, now.Add(-2*time.Hour), 1))
+	fw.Add(kubeletEvent("b", "Pulling", `Pulling image "nginx"`, now, 1))
+	fw.Add(pulled)
+	fw.Modify(pulled)
+	fw.Add(kubeletEvent("c", "Failed", `Failed to pull image "ghcr.io/x": not found`, now, 1))
+
+	var images []string
+	for len(images) < 2 {
+		select {
+		case p := <-got:
+			images = append(images, p.Image)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("pulls = %v", images)
+		}
+	}
+	if images[0] != "nginx" || images[1] != "ghcr.io/x" {
+		t.Errorf("pulls = %v", images)
+	}
+	select {
+	case p := <-got:
+		t.Errorf("unexpected pull %+v", p)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -24,6 +24,7 @@ const (
 	podIPIndex     = "podIP"
 	ipPortIndex    = "ipPort"
 	ipOnlyIndex    = "ipOnly"
+	clusterIPIndex = "clusterIP"
 	serviceNameKey = "kubernetes.io/service-name"
 )
 
@@ -36,6 +37,7 @@ type InformerCache struct {
 
 	podInf cache.SharedIndexInformer
 	esInf  cache.SharedIndexInformer
+	svcInf cache.SharedIndexInformer
 }
 
 func NewInformerCache(clientset kubernetes.Interface) *InformerCache {
@@ -125,9 +127,27 @@ func (ic *InformerCache) Start(ctx context.Context) {
 		},
 	})
 
+	svcInf := factory.Core().V1().Services().Informer()
+	_ = svcInf.AddIndexers(cache.Indexers{
+		clusterIPIndex: func(obj interface{}) ([]string, error) {
+			svc, ok := obj.(*corev1.Service)
+			if !ok || svc == nil {
+				return nil, nil
+			}
+			var keys []string
+			for _, ip := range svc.Spec.ClusterIPs {
+				if ip != "" && ip != corev1.ClusterIPNone {
+					keys = append(keys, ip)
+				}
+			}
+			return keys, nil
+		},
+	})
+
 	ic.mu.Lock()
 	ic.podInf = podInf
 	ic.esInf = esInf
+	ic.svcInf = svcInf
 	ic.mu.Unlock()
 
 	factory.Start(stopCh)
@@ -142,7 +162,7 @@ func (ic *InformerCache) Start(ctx context.Context) {
 	syncCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutSec)*time.Second)
 	defer cancel()
 
-	if !cache.WaitForCacheSync(syncCtx.Done(), podInf.HasSynced, esInf.HasSynced) {
+	if !cache.WaitForCacheSync(syncCtx.Done(), podInf.HasSynced, esInf.HasSynced, svcInf.HasSynced) {
 		logger.Warn("Kubernetes informer cache did not sync within timeout; enrichment lookups may be incomplete until sync finishes",
 			zap.Int("timeout_seconds", timeoutSec))
 	}
@@ -237,3 +257,36 @@ func (ic *InformerCache) GetServiceByEndpoint(ip string, port int) *ServiceInfo
 	}
 	return &ServiceInfo{Name: svcName, Namespace: es.Namespace, Port: port}
 }
+
+// GetServiceByClusterIP returns the Service whose ClusterIP is ip and that
+// exposes port, or any port when port is 0.
+func (ic *InformerCache) GetServiceByClusterIP(ip string, port int) *ServiceInfo {
+	if ic == nil || ip == "" {
+		return nil
+	}
+	ic.mu.RLock()
+	inf := ic.svcInf
+	ic.mu.RUnlock()
+	if inf == nil {
+		return nil
+	}
+	objs, err := inf.GetIndexer().ByIndex(clusterIPIndex, ip)
+	if err != nil {
+		return nil
+	}
+	for _, obj := range objs {
+		svc, ok := obj.(*corev1.Service)
+		if !ok || svc == nil {
+			continue
+		}
+		if port == 0 {
+			return &ServiceInfo{Name: svc.Name, Namespace: svc.Namespace}
+		}
+		for _, p := range svc.Spec.Ports {
+			if int(p.Port) == port {
+				return &ServiceInfo{Name: svc.Name, Namespace: svc.Namespace, Port: port}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// WaitForInitContainer polls the pod until its init container name is
+// running with a container ID, so it can be resolved like any other
+// container. Init containers run one after another, so the named one may
+// still be waiting behind an earlier step or an image pull. It fails fast
+// when the pod has no such init container or the container has already
+// finished, and returns ctx's error when ctx ends first.
+func WaitForInitContainer(ctx context.Context, clientset kubernetes.Interface, namespace, podName, name string, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	logged := false
+	for {
+		pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, podName, metav1.GetOptions{})
+		if err != nil {
+			return NewPodNotFoundError(podName, namespace, err)
+		}
+		ready, err := initContainerRunning(pod, name)
+		if err != nil || ready {
+			return err
+		}
+		if !logged {
+			logger.Info("Waiting for init container to start",
+				zap.String("pod", namespace+"/"+podName),
+				zap.String("init_container", name))
+			logged = true
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("waiting for init container %s: %w", name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// initContainerRunning reports whether the named init container is running
+// with a container ID, or an error when it can never be traced.
+func initContainerRunning(pod *corev1.Pod, name string) (bool, error) {
+	declared := false
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == name {
+			declared = true
+			break
+		}
+	}
+	if !declared {
+		return false, newContainerNotFoundInPodError(name, pod)
+	}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if cs.Name != name {
+			continue
+		}
+		if cs.State.Running != nil && cs.ContainerID != "" {
+			return true, nil
+		}
+		// A restartable (sidecar) init container that exited is restarted by
+		// the kubelet, so only a run-once init container is done for good.
+		if t := cs.State.Terminated; t != nil && !isRestartableInit(pod, name) {
+			return false, NewInitContainerFinishedError(name, t.ExitCode)
+		}
+	}
+	return false, nil
+}
+
+func isRestartableInit(pod *corev1.Pod, name string) bool {
+	for _, c := range pod.Spec.InitContainers {
+		if c.Name == name {
+			return c.RestartPolicy != nil && *c.RestartPolicy == corev1.ContainerRestartPolicyAlways
+		}
+	}
+	return false
+}
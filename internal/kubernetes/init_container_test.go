@@ -0,0 +1,93 @@
+package kubernetes
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func initPod(state corev1.ContainerState, containerID string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "prod", Name: "api-0"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "migrate"}},
+			Containers:     []corev1.Container{{Name: "app"}},
+		},
+		Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{
+				{Name: "migrate", ContainerID: containerID, State: state},
+			},
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "app", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}},
+			},
+		},
+	}
+}
+
+func TestInitContainerRunning(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	waiting := corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}
+	done := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}
+
+	if ok, err := initContainerRunning(initPod(running, "containerd://"+hex64()), "migrate"); !ok || err != nil {
+		t.Errorf("running: got %v, %v", ok, err)
+	}
+	if ok, err := initContainerRunning(initPod(waiting, ""), "migrate"); ok || err != nil {
+		t.Errorf("waiting: got %v, %v", ok, err)
+	}
+	if ok, err := initContainerRunning(initPod(running, ""), "migrate"); ok || err != nil {
+		t.Errorf("running without ID: got %v, %v", ok, err)
+	}
+
+	_, err := initContainerRunning(initPod(done, "containerd://"+hex64()), "migrate")
+	var kerr *KubernetesError
+	if !errors.As(err, &kerr) || kerr.Code != ErrCodeInitContainerFinished {
+		t.Errorf("terminated: expected ErrCodeInitContainerFinished, got %v", err)
+	}
+
+	_, err = initContainerRunning(initPod(waiting, ""), "app")
+	if !errors.As(err, &kerr) || kerr.Code != ErrCodeContainerNotFound {
+		t.Errorf("regular container: expected ErrCodeContainerNotFound, got %v", err)
+	}
+
+	sidecar := initPod(done, "containerd://"+hex64())
+	always := corev1.ContainerRestartPolicyAlways
+	sidecar.Spec.InitContainers[0].RestartPolicy = &always
+	if ok, err := initContainerRunning(sidecar, "migrate"); ok || err != nil {
+		t.Errorf("exited sidecar: got %v, %v, want to keep waiting", ok, err)
+	}
+}
+
+func TestWaitForInitContainer_WaitsUntilRunning(t *testing.T) {
+	pod := initPod(corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}, "")
+	clientset := fake.NewSimpleClientset(pod)
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		started := initPod(corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}, "containerd://"+hex64())
+		_, _ = clientset.CoreV1().Pods("prod").UpdateStatus(context.Background(), started, metav1.UpdateOptions{})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := WaitForInitContainer(ctx, clientset, "prod", "api-0", "migrate", 5*time.Millisecond); err != nil {
+		t.Fatalf("WaitForInitContainer: %v", err)
+	}
+}
+
+func TestWaitForInitContainer_Timeout(t *testing.T) {
+	pod := initPod(corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "PodInitializing"}}, "")
+	clientset := fake.NewSimpleClientset(pod)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	err := WaitForInitContainer(ctx, clientset, "prod", "api-0", "migrate", 5*time.Millisecond)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+}
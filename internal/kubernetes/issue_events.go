@@ -0,0 +1,146 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/diagnose/hooks"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// IssueEventReason is the reason of the Events podtrace records for the
+// issues it detects.
+const IssueEventReason = "PodtraceIssueDetected"
+
+// maxEventMessage is the longest message the API server accepts on an Event.
+const maxEventMessage = 1024
+
+// IssueEventRecorder is an IssueCallback that records each finding as a
+// Warning Event on the traced pod, so it shows in `kubectl describe pod`
+// and reaches whatever already alerts on Events.
+type IssueEventRecorder struct {
+	clientset kubernetes.Interface
+	host      string
+
+	mu   sync.Mutex
+	uids map[string]types.UID
+	// forbidden is set once the API server refused an Event, so a missing
+	// RBAC grant is reported once instead of for every finding.
+	forbidden atomic.Bool
+}
+
+var _ hooks.IssueCallback = (*IssueEventRecorder)(nil)
+
+// NewIssueEventRecorder returns a recorder that creates Events through
+// clientset.
+func NewIssueEventRecorder(clientset kubernetes.Interface) *IssueEventRecorder {
+	host := os.Getenv("NODE_NAME")
+	if host == "" {
+		host, _ = os.Hostname()
+	}
+	return &IssueEventRecorder{clientset: clientset, host: host, uids: make(map[string]types.UID)}
+}
+
+// Name implements hooks.IssueCallback.
+func (r *IssueEventRecorder) Name() string {
+	return "k8s-events"
+}
+
+// OnIssue implements hooks.IssueCallback. Findings without a pod are not
+// recorded.
+func (r *IssueEventRecorder) OnIssue(ctx context.Context, f hooks.Finding) error {
+	if f.Pod == "" || f.Namespace == "" || r.forbidden.Load() {
+		return nil
+	}
+	uid, err := r.podUID(ctx, f.Namespace, f.Pod)
+	if err != nil {
+		return r.refused(err, "get pod")
+	}
+	at := metav1.NewTime(f.DetectedAt)
+	if f.DetectedAt.IsZero() {
+		at = metav1.NewTime(time.Now())
+	}
+	event := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: f.Pod + ".podtrace-",
+			Namespace:    f.Namespace,
+		},
+		InvolvedObject: corev1.ObjectReference{
+			APIVersion: "v1",
+			Kind:       "Pod",
+			Namespace:  f.Namespace,
+			Name:       f.Pod,
+			UID:        uid,
+		},
+		Reason:         IssueEventReason,
+		Message:        issueEventMessage(f),
+		Type:           corev1.EventTypeWarning,
+		Source:         corev1.EventSource{Component: "podtrace", Host: r.host},
+		FirstTimestamp: at,
+		LastTimestamp:  at,
+		Count:          1,
+	}
+	if _, err := r.clientset.CoreV1().Events(f.Namespace).Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		return r.refused(err, "create event")
+	}
+	return nil
+}
+
+func (r *IssueEventRecorder) podUID(ctx context.Context, namespace, name string) (types.UID, error) {
+	key := namespace + "/" + name
+	r.mu.Lock()
+	uid, ok := r.uids[key]
+	r.mu.Unlock()
+	if ok {
+		return uid, nil
+	}
+	pod, err := r.clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	r.uids[key] = pod.UID
+	r.mu.Unlock()
+	return pod.UID, nil
+}
+
+// refused wraps err; a Forbidden error disables the recorder after
+// explaining the missing grant.
+func (r *IssueEventRecorder) refused(err error, op string) error {
+	if apierrors.IsForbidden(err) {
+		if !r.forbidden.Swap(true) {
+			logger.Warn("Not allowed to record issues as Kubernetes Events; grant get on pods and create on events (see deploy/cli-rbac/role.yaml)",
+				zap.Error(err))
+		}
+		return nil
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+func issueEventMessage(f hooks.Finding) string {
+	msg := f.Message
+	if f.Code != "" {
+		msg = f.Code + ": " + msg
+	}
+	msg = fmt.Sprintf("%s (rule %s, score %.0f, %s confidence)", msg, f.Rule, f.Score, f.Confidence)
+	if len(msg) > maxEventMessage {
+		msg = msg[:maxEventMessage-3]
+		for !utf8.ValidString(msg) {
+			msg = msg[:len(msg)-1]
+		}
+		msg += "..."
+	}
+	return msg
+}
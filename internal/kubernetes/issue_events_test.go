@@ -0,0 +1,105 @@
+package kubernetes
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/diagnose/hooks"
+)
+
+func testFinding() hooks.Finding {
+	return hooks.Finding{
+		Issue: detector.Issue{
+			Code: "PODTRACE-NET-001", Message: "High connection failure rate: 12.0%",
+			Rule: "connect_failures", Score: 74, Confidence: detector.ConfidenceHigh,
+		},
+		Pod: "api", Namespace: "prod", DetectedAt: time.Unix(1000, 0),
+	}
+}
+
+func TestIssueEventRecorder_RecordsWarningOnPod(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod", UID: "uid-1"},
+	})
+	r := NewIssueEventRecorder(clientset)
+	if err := r.OnIssue(context.Background(), testFinding()); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.OnIssue(context.Background(), hooks.Finding{Issue: testFinding().Issue}); err != nil {
+		t.Fatalf("finding without a pod: %v", err)
+	}
+
+	list, err := clientset.CoreV1().Events("prod").List(context.Background(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 1 {
+		t.Fatalf("recorded %d events, want 1", len(list.Items))
+	}
+	ev := list.Items[0]
+	if ev.Type != corev1.EventTypeWarning || ev.Reason != IssueEventReason {
+		t.Errorf("type/reason = %s/%s", ev.Type, ev.Reason)
+	}
+	if ev.InvolvedObject.Kind != "Pod" || ev.InvolvedObject.Name != "api" || ev.InvolvedObject.UID != "uid-1" {
+		t.Errorf("involvedObject = %+v", ev.InvolvedObject)
+	}
+	want := "PODTRACE-NET-001: High connection failure rate: 12.0% (rule connect_failures, score 74, high confidence)"
+	if ev.Message != want {
+		t.Errorf("message = %q, want %q", ev.Message, want)
+	}
+	if !ev.FirstTimestamp.Time.Equal(time.Unix(1000, 0)) {
+		t.Errorf("firstTimestamp = %v", ev.FirstTimestamp)
+	}
+}
+
+func TestIssueEventRecorder_ForbiddenDisables(t *testing.T) {
+	clientset := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "prod", UID: "uid-1"},
+	})
+	creates := 0
+	clientset.PrependReactor("create", "events", func(k8stesting.Action) (bool, runtime.Object, error) {
+		creates++
+		return true, nil, apierrors.NewForbidden(schema.GroupResource{Resource: "events"}, "", nil)
+	})
+	r := NewIssueEventRecorder(clientset)
+	for range 2 {
+		if err := r.OnIssue(context.Background(), testFinding()); err != nil {
+			t.Fatalf("forbidden must not be reported as a failure: %v", err)
+		}
+	}
+	if creates != 1 {
+		t.Errorf("create attempts = %d, want 1 before the recorder disables itself", creates)
+	}
+}
+
+func TestIssueEventMessage_Truncates(t *testing.T) {
+	f := testFinding()
+	f.Message = strings.Repeat("é", maxEventMessage)
+	msg := issueEventMessage(f)
+	if len(msg) > maxEventMessage || !strings.HasSuffix(msg, "...") {
+		t.Errorf("len = %d, suffix %q", len(msg), msg[len(msg)-3:])
+	}
+}
+
+func TestEventsCorrelator_SkipsOwnIssueEvents(t *testing.T) {
+	ec := NewEventsCorrelator(fake.NewSimpleClientset(), "api", "prod")
+	ec.addEvent(&corev1.Event{
+		InvolvedObject: corev1.ObjectReference{Name: "api"},
+		Reason:         IssueEventReason,
+		FirstTimestamp: metav1.Now(),
+	})
+	if n := len(ec.GetEvents()); n != 0 {
+		t.Errorf("correlator kept %d podtrace issue events", n)
+	}
+}
@@ -53,6 +53,16 @@ type PodSpecOptions struct {
 	ServiceAccountName    string
 	OwnerHost             string
 	OwnerPID              int
+
+	// LowPrivilege builds an unprivileged pod with no added capabilities
+	// and without the BPF, debugfs and tracefs mounts, for
+	// --low-privilege runs.
+	LowPrivilege bool
+}
+
+// lowPrivilegeDropped are the volumes only BPF tracing needs.
+var lowPrivilegeDropped = map[string]bool{
+	"bpf": true, "btf": true, "debug": true, "tracing": true, "securityfs": true,
 }
 
 // BuildPodSpec returns a pod with privileged + hostPID set, mounting the host
@@ -123,6 +133,10 @@ func BuildPodSpec(opts PodSpecOptions) (*corev1.Pod, error) {
 		"PODTRACE_ALERT_WARN_PCT",
 		"PODTRACE_ALERT_CRIT_PCT",
 		"PODTRACE_ALERT_EMERG_PCT",
+		"PODTRACE_LOW_PRIVILEGE",
+		"PODTRACE_PIN_STATE",
+		"PODTRACE_PIN_DIR",
+		"PODTRACE_ISSUE_EVENTS",
 	}
 	for _, name := range passthrough {
 		if v := os.Getenv(name); v != "" {
@@ -143,6 +157,13 @@ func BuildPodSpec(opts PodSpecOptions) (*corev1.Pod, error) {
 		})
 	}
 
+	caps := []corev1.Capability{"BPF", "SYS_ADMIN", "PERFMON", "SYS_RESOURCE", "NET_ADMIN"}
+	if opts.LowPrivilege {
+		priv = false
+		caps = nil
+		volumes, mounts = lowPrivilegeVolumes(volumes, mounts)
+	}
+
 	container := corev1.Container{
 		Name:                     "podtrace",
 		Image:                    opts.Image,
@@ -157,7 +178,7 @@ func BuildPodSpec(opts PodSpecOptions) (*corev1.Pod, error) {
 			Privileged: &priv,
 			RunAsUser:  &runAsRoot,
 			Capabilities: &corev1.Capabilities{
-				Add: []corev1.Capability{"BPF", "SYS_ADMIN", "PERFMON", "SYS_RESOURCE", "NET_ADMIN"},
+				Add: caps,
 			},
 		},
 		VolumeMounts: mounts,
@@ -269,3 +290,25 @@ func Hostname() string {
 	}
 	return "unknown"
 }
+
+// lowPrivilegeVolumes drops the volumes of lowPrivilegeDropped and mounts the
+// cgroup filesystem read-only: low-privilege mode only reads it.
+func lowPrivilegeVolumes(volumes []corev1.Volume, mounts []corev1.VolumeMount) ([]corev1.Volume, []corev1.VolumeMount) {
+	keptVolumes := volumes[:0]
+	for _, v := range volumes {
+		if !lowPrivilegeDropped[v.Name] {
+			keptVolumes = append(keptVolumes, v)
+		}
+	}
+	keptMounts := mounts[:0]
+	for _, m := range mounts {
+		if lowPrivilegeDropped[m.Name] {
+			continue
+		}
+		if m.Name == "cgroup" {
+			m.ReadOnly = true
+		}
+		keptMounts = append(keptMounts, m)
+	}
+	return keptVolumes, keptMounts
+}
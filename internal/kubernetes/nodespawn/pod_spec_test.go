@@ -89,6 +89,35 @@ func TestBuildPodSpec_SecurityContext(t *testing.T) {
 	}
 }
 
+func TestBuildPodSpec_LowPrivilege(t *testing.T) {
+	o := baseOpts()
+	o.LowPrivilege = true
+	got, err := BuildPodSpec(o)
+	if err != nil {
+		t.Fatalf("unexpected: %v", err)
+	}
+	sc := got.Spec.Containers[0].SecurityContext
+	if sc.Privileged == nil || *sc.Privileged {
+		t.Errorf("expected an unprivileged container")
+	}
+	if len(sc.Capabilities.Add) != 0 {
+		t.Errorf("expected no added capabilities, got %v", sc.Capabilities.Add)
+	}
+	for _, v := range got.Spec.Volumes {
+		if lowPrivilegeDropped[v.Name] {
+			t.Errorf("volume %q should be dropped", v.Name)
+		}
+	}
+	for _, m := range got.Spec.Containers[0].VolumeMounts {
+		if lowPrivilegeDropped[m.Name] {
+			t.Errorf("mount %q should be dropped", m.Name)
+		}
+		if m.Name == "cgroup" && !m.ReadOnly {
+			t.Errorf("cgroup mount %q should be read-only", m.MountPath)
+		}
+	}
+}
+
 func TestBuildPodSpec_TerminationMessagePolicyFallsBackToLogs(t *testing.T) {
 	got, err := BuildPodSpec(baseOpts())
 	if err != nil {
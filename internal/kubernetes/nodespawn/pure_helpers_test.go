@@ -13,6 +13,11 @@ func TestPodRef_PreResolved(t *testing.T) {
 		t.Errorf("PreResolved() = %v, want [ns1/cart-abc/deadbeef/app] (legacy singular fields)", got)
 	}
 
+	r.UID = "0f3c6a52"
+	if got := r.PreResolved(); len(got) != 1 || got[0] != "ns1/cart-abc/deadbeef/app/0f3c6a52" {
+		t.Errorf("PreResolved() with a UID = %v", got)
+	}
+
 	r2 := PodRef{Namespace: "ns", Name: "p"}
 	if got := r2.PreResolved(); len(got) != 0 {
 		t.Errorf("PreResolved() with no containers = %v, want empty", got)
@@ -59,6 +59,9 @@ type RunOptions struct {
 	ExtraEnv []corev1.EnvVar
 
 	SplunkToken string
+
+	// LowPrivilege spawns unprivileged pods, see PodSpecOptions.
+	LowPrivilege bool
 }
 
 // Run orchestrates the spawn + stream lifecycle. It returns when every per-node
@@ -120,6 +123,7 @@ func Run(ctx context.Context, opts RunOptions) error {
 			OwnerPID:              opts.OwnerPID,
 			ExtraEnv:              opts.ExtraEnv,
 			SplunkToken:           opts.SplunkToken,
+			LowPrivilege:          opts.LowPrivilege,
 		})
 		if err != nil {
 			cmu.Lock()
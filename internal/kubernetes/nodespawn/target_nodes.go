@@ -2,6 +2,7 @@ package nodespawn
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
@@ -29,6 +30,7 @@ type PodRef struct {
 	ContainerID   string
 	ContainerName string
 	Containers    []ContainerRef
+	UID           string
 }
 
 // String returns the "namespace/name" form ResolvePod accepts.
@@ -36,8 +38,8 @@ func (r PodRef) String() string {
 	return r.Namespace + "/" + r.Name
 }
 
-// PreResolved returns one "ns/name/containerID/containerName" string per
-// traced container, each accepted by --preresolved-pod.
+// PreResolved returns one "ns/name/containerID/containerName/podUID" string
+// per traced container, each accepted by --preresolved-pod.
 func (r PodRef) PreResolved() []string {
 	containers := r.Containers
 	if len(containers) == 0 && r.ContainerID != "" {
@@ -48,7 +50,11 @@ func (r PodRef) PreResolved() []string {
 		if c.ID == "" {
 			continue
 		}
-		out = append(out, r.Namespace+"/"+r.Name+"/"+c.ID+"/"+c.Name)
+		ref := r.Namespace + "/" + r.Name + "/" + c.ID + "/" + c.Name
+		if r.UID != "" {
+			ref += "/" + r.UID
+		}
+		out = append(out, ref)
 	}
 	return out
 }
@@ -77,10 +83,11 @@ func ResolveTargetNodes(ctx context.Context, clientset kubernetes.Interface, sel
 	unscheduled := []PodRef{}
 	missingContainer := []PodRef{}
 	routedWithoutID := []PodRef{}
+	var ambiguous []error
 	seen := map[string]struct{}{}
 
 	add := func(pod *corev1.Pod) {
-		ref := PodRef{Namespace: pod.Namespace, Name: pod.Name}
+		ref := PodRef{Namespace: pod.Namespace, Name: pod.Name, UID: string(pod.UID)}
 		if _, dup := seen[ref.String()]; dup {
 			return
 		}
@@ -94,6 +101,16 @@ func ResolveTargetNodes(ctx context.Context, clientset kubernetes.Interface, sel
 			missingContainer = append(missingContainer, ref)
 			return
 		}
+		if sel.StrictContainer && sel.ContainerName == "" {
+			names := make([]string, len(statuses))
+			for i, cs := range statuses {
+				names[i] = cs.Name
+			}
+			if choice := pkgkube.ChooseMainContainer(pod, names); choice.Guessed {
+				ambiguous = append(ambiguous, pkgkube.NewAmbiguousContainerError(pod.Namespace, pod.Name, choice))
+				return
+			}
+		}
 		if len(statuses) > 0 {
 			for _, cs := range statuses {
 				if idx := indexAfterScheme(cs.ContainerID); idx >= 0 && cs.ContainerID[idx:] != "" {
@@ -149,6 +166,9 @@ func ResolveTargetNodes(ctx context.Context, clientset kubernetes.Interface, sel
 		}
 	}
 
+	if len(ambiguous) > 0 {
+		return NodeTargets{}, fmt.Errorf("nodespawn: --strict: %w", errors.Join(ambiguous...))
+	}
 	if len(missingContainer) > 0 && len(byNode) == 0 {
 		return NodeTargets{}, fmt.Errorf("nodespawn: no matched pod has a running container named %q: %s",
 			sel.ContainerName, joinRefs(missingContainer))
@@ -301,3 +301,32 @@ func TestResolveTargetNodes_DedupesNameAndSelectorMatch(t *testing.T) {
 		t.Fatalf("pod matched by name AND selector must be added once, got %d refs", n)
 	}
 }
+
+func TestResolveTargetNodes_StrictRejectsAmbiguousPod(t *testing.T) {
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	p := podWithContainer("ns1", "a", "node-1", "api", "containerd://aaa", running)
+	p.Spec.Containers = []corev1.Container{{Name: "api"}, {Name: "worker"}}
+	p.Status.ContainerStatuses = append(p.Status.ContainerStatuses,
+		corev1.ContainerStatus{Name: "worker", ContainerID: "containerd://bbb", State: running})
+	cs := fake.NewClientset(p)
+	sel := pkgkube.TargetSelection{DefaultNamespace: "ns1", Pods: []string{"a"}, StrictContainer: true}
+
+	_, err := ResolveTargetNodes(context.Background(), cs, sel)
+	if err == nil || !strings.Contains(err.Error(), "pass --container") {
+		t.Fatalf("expected an ambiguous-container error, got %v", err)
+	}
+
+	p.Annotations = map[string]string{pkgkube.DefaultContainerAnnotation: "worker"}
+	cs = fake.NewClientset(p)
+	if _, err := ResolveTargetNodes(context.Background(), cs, sel); err != nil {
+		t.Fatalf("default-container annotation should satisfy --strict, got %v", err)
+	}
+
+	sel.ContainerName = "api"
+	sel.StrictContainer = true
+	p.Annotations = nil
+	cs = fake.NewClientset(p)
+	if _, err := ResolveTargetNodes(context.Background(), cs, sel); err != nil {
+		t.Fatalf("--container should satisfy --strict, got %v", err)
+	}
+}
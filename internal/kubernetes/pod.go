@@ -48,6 +48,21 @@ func (r *PodResolver) GetRestConfig() *rest.Config {
 	return r.restConfig
 }
 
+// kubeContext is the kubeconfig context the clients use; empty means the
+// current one.
+var kubeContext string
+
+// SetKubeContext makes the kubeconfig-based clients use the named context
+// instead of the current one, e.g. to reach another cluster. A named context
+// also takes precedence over the in-cluster config.
+func SetKubeContext(name string) { kubeContext = name }
+
+// ConfigOverrides returns the kubeconfig overrides selecting the context set
+// with SetKubeContext.
+func ConfigOverrides() *clientcmd.ConfigOverrides {
+	return &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+}
+
 func kubeconfigLoadingRules() *clientcmd.ClientConfigLoadingRules {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 
@@ -79,7 +94,7 @@ func NamespaceFromContext() (string, bool) {
 	return NamespaceFromKubeconfig("")
 }
 
-// NamespaceFromKubeconfig resolves the current context's namespace from an
+// NamespaceFromKubeconfig resolves the selected context's namespace from an
 // explicit kubeconfig path, falling back to the default loading rules when
 // the path is empty. Callers that build their client from a --kubeconfig
 // flag must resolve the namespace from the SAME file, or the namespace and
@@ -90,7 +105,7 @@ func NamespaceFromKubeconfig(path string) (string, bool) {
 		rules = &clientcmd.ClientConfigLoadingRules{ExplicitPath: path}
 	}
 	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		rules, &clientcmd.ConfigOverrides{})
+		rules, ConfigOverrides())
 	ns, _, err := clientConfig.Namespace()
 	if err != nil || ns == "" {
 		return "", false
@@ -102,10 +117,12 @@ func NewPodResolver() (*PodResolver, error) {
 	var config *rest.Config
 	var err error
 
-	config, err = rest.InClusterConfig()
-	if err != nil {
+	if kubeContext == "" {
+		config, err = rest.InClusterConfig()
+	}
+	if config == nil {
 		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-			kubeconfigLoadingRules(), &clientcmd.ConfigOverrides{})
+			kubeconfigLoadingRules(), ConfigOverrides())
 
 		config, err = kubeConfig.ClientConfig()
 		if err != nil {
@@ -127,7 +144,7 @@ func (r *PodResolver) ResolvePod(ctx context.Context, podName, namespace, contai
 		return nil, NewPodNotFoundError(podName, namespace, err)
 	}
 
-	if len(pod.Status.ContainerStatuses) == 0 {
+	if len(pod.Status.ContainerStatuses) == 0 && len(pod.Status.InitContainerStatuses) == 0 {
 		return nil, NewNoContainersError()
 	}
 
@@ -137,7 +154,7 @@ func (r *PodResolver) ResolvePod(ctx context.Context, podName, namespace, contai
 		if name == "" && len(pod.Spec.Containers) > 0 {
 			name = pod.Spec.Containers[0].Name
 		}
-		return nil, NewContainerNotFoundError(name)
+		return nil, newContainerNotFoundInPodError(name, pod)
 	}
 
 	targets := resolveContainerTargets(ctx, pod, statuses)
@@ -162,17 +179,24 @@ func (r *PodResolver) ResolvePod(ctx context.Context, podName, namespace, contai
 		ownerName = pod.OwnerReferences[0].Name
 	}
 
+	primary, choice := primaryTarget(pod, targets)
 	return &PodInfo{
 		PodName:       podName,
 		Namespace:     namespace,
 		Containers:    targets,
-		ContainerID:   targets[0].ID,
-		CgroupPath:    targets[0].CgroupPath,
-		ContainerName: targets[0].Name,
+		ContainerID:   primary.ID,
+		CgroupPath:    primary.CgroupPath,
+		ContainerName: primary.Name,
+		MainContainer: choice,
 		Labels:        labels,
 		PodIP:         pod.Status.PodIP,
 		OwnerKind:     ownerKind,
 		OwnerName:     ownerName,
+		Bandwidth:     podBandwidth(pod),
+		Status:        podStatus(pod, targets),
+		PodUID:        string(pod.UID),
+		NodeName:      pod.Spec.NodeName,
+		Runtime:       cri.PodRuntime(pod),
 	}, nil
 }
 
@@ -200,7 +224,7 @@ func resolveContainerTargets(ctx context.Context, pod *corev1.Pod, statuses []co
 				zap.Error(err))
 			continue
 		}
-		targets = append(targets, ContainerTarget{Name: cs.Name, ID: shortID, CgroupPath: cgroupPath})
+		targets = append(targets, ContainerTarget{Name: cs.Name, ID: shortID, CgroupPath: cgroupPath, Kind: containerKind(pod, cs.Name)})
 	}
 	return targets
 }
@@ -468,6 +492,7 @@ type ContainerTarget struct {
 	Name       string
 	ID         string
 	CgroupPath string
+	Kind       ContainerKind
 }
 
 type PodInfo struct {
@@ -477,10 +502,23 @@ type PodInfo struct {
 	ContainerID   string
 	CgroupPath    string
 	ContainerName string
+	// MainContainer explains why ContainerName was picked when the pod has
+	// several traced containers; zero for pre-resolved targets.
+	MainContainer ContainerChoice
 	Labels        map[string]string
 	PodIP         string
 	OwnerKind     string
 	OwnerName     string
+	// Bandwidth is the shaping requested by the pod's bandwidth annotations.
+	Bandwidth PodBandwidth
+	// Status is the pod's restarts and resources when it was resolved;
+	// empty for pre-resolved targets.
+	Status PodStatus
+	// PodUID tells the pod apart from an earlier one of the same name, and
+	// NodeName and Runtime say where and under what runtime it runs.
+	PodUID   string
+	NodeName string
+	Runtime  cri.Runtime
 }
 
 func findCgroupPath(containerID string) (string, error) {
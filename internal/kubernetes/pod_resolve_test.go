@@ -124,3 +124,44 @@ users:
 		t.Errorf("NamespaceFromKubeconfig(bad) = (%q, %v), want (\"\", false)", ns, ok)
 	}
 }
+
+func TestNamespaceFromKubeconfigContext(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	content := `apiVersion: v1
+kind: Config
+clusters:
+- cluster:
+    server: https://east:6443
+  name: east
+- cluster:
+    server: https://west:6443
+  name: west
+contexts:
+- context:
+    cluster: east
+    namespace: east-ns
+    user: u
+  name: east
+- context:
+    cluster: west
+    namespace: west-ns
+    user: u
+  name: west
+current-context: east
+users:
+- name: u
+  user: {}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("write kubeconfig: %v", err)
+	}
+	SetKubeContext("west")
+	t.Cleanup(func() { SetKubeContext("") })
+	if ns, ok := NamespaceFromKubeconfig(path); !ok || ns != "west-ns" {
+		t.Errorf("NamespaceFromKubeconfig with context west = (%q, %v), want (west-ns, true)", ns, ok)
+	}
+	SetKubeContext("")
+	if ns, ok := NamespaceFromKubeconfig(path); !ok || ns != "east-ns" {
+		t.Errorf("NamespaceFromKubeconfig with current context = (%q, %v), want (east-ns, true)", ns, ok)
+	}
+}
@@ -0,0 +1,111 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodStatus is the part of kubectl describe a report reader needs: the
+// pod's QoS class and, per traced container, restarts, how the previous
+// instance ended and the requested resources.
+type PodStatus struct {
+	QOSClass   string
+	Containers []ContainerStatus
+}
+
+// ContainerStatus is one traced container's restarts and resources.
+type ContainerStatus struct {
+	Name         string
+	RestartCount int32
+	// LastTermination is how the previous instance ended; nil when the
+	// container has not terminated before.
+	LastTermination *Termination
+	// Requests and Limits render the container's resources as
+	// "cpu=250m, memory=256Mi"; empty when none are set.
+	Requests string
+	Limits   string
+}
+
+// Termination is a container's lastState.terminated.
+type Termination struct {
+	Reason     string
+	ExitCode   int32
+	FinishedAt time.Time
+}
+
+// Empty reports whether nothing is known about the pod's status.
+func (s PodStatus) Empty() bool {
+	return s.QOSClass == "" && len(s.Containers) == 0
+}
+
+// podStatus reads the status of pod's traced containers.
+func podStatus(pod *corev1.Pod, targets []ContainerTarget) PodStatus {
+	specs := make(map[string]corev1.ResourceRequirements)
+	for _, c := range pod.Spec.InitContainers {
+		specs[c.Name] = c.Resources
+	}
+	for _, c := range pod.Spec.Containers {
+		specs[c.Name] = c.Resources
+	}
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, group := range [][]corev1.ContainerStatus{pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses, pod.Status.EphemeralContainerStatuses} {
+		for _, cs := range group {
+			statuses[cs.Name] = cs
+		}
+	}
+
+	s := PodStatus{QOSClass: string(pod.Status.QOSClass)}
+	for _, t := range targets {
+		c := ContainerStatus{Name: t.Name}
+		if res, ok := specs[t.Name]; ok {
+			c.Requests = formatResources(res.Requests)
+			c.Limits = formatResources(res.Limits)
+		}
+		if cs, ok := statuses[t.Name]; ok {
+			c.RestartCount = cs.RestartCount
+			if term := cs.LastTerminationState.Terminated; term != nil {
+				c.LastTermination = &Termination{
+					Reason:     term.Reason,
+					ExitCode:   term.ExitCode,
+					FinishedAt: term.FinishedAt.Time,
+				}
+			}
+		}
+		s.Containers = append(s.Containers, c)
+	}
+	return s
+}
+
+// FetchPodStatus gets the pod and reads the status of the given traced
+// containers, for targets resolved without the pod object.
+func FetchPodStatus(ctx context.Context, clientset kubernetes.Interface, namespace, name string, targets []ContainerTarget) (PodStatus, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return PodStatus{}, fmt.Errorf("get pod %s/%s: %w", namespace, name, err)
+	}
+	return podStatus(pod, targets), nil
+}
+
+func formatResources(list corev1.ResourceList) string {
+	if len(list) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(list))
+	for name := range list {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	parts := make([]string, len(names))
+	for i, name := range names {
+		q := list[corev1.ResourceName(name)]
+		parts[i] = name + "=" + q.String()
+	}
+	return strings.Join(parts, ", ")
+}
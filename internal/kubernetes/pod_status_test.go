@@ -0,0 +1,69 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func statusTestPod() *corev1.Pod {
+	finished := time.Date(2026, 10, 16, 14, 2, 11, 0, time.UTC)
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api-0", Namespace: "shop"},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{
+			{Name: "api", Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi"), corev1.ResourceCPU: resource.MustParse("250m")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("512Mi")},
+			}},
+			{Name: "proxy"},
+		}},
+		Status: corev1.PodStatus{
+			QOSClass: corev1.PodQOSBurstable,
+			ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "api", RestartCount: 3, LastTerminationState: corev1.ContainerState{
+					Terminated: &corev1.ContainerStateTerminated{Reason: "OOMKilled", ExitCode: 137, FinishedAt: metav1.NewTime(finished)},
+				}},
+				{Name: "proxy"},
+			},
+		},
+	}
+}
+
+func TestPodStatus(t *testing.T) {
+	s := podStatus(statusTestPod(), []ContainerTarget{{Name: "api"}})
+	if s.QOSClass != "Burstable" || len(s.Containers) != 1 {
+		t.Fatalf("status = %+v; want Burstable with only the traced container", s)
+	}
+	c := s.Containers[0]
+	if c.RestartCount != 3 || c.Requests != "cpu=250m, memory=256Mi" || c.Limits != "memory=512Mi" {
+		t.Errorf("container = %+v", c)
+	}
+	if c.LastTermination == nil || c.LastTermination.Reason != "OOMKilled" || c.LastTermination.ExitCode != 137 ||
+		c.LastTermination.FinishedAt.Hour() != 14 {
+		t.Errorf("last termination = %+v", c.LastTermination)
+	}
+
+	proxy := podStatus(statusTestPod(), []ContainerTarget{{Name: "proxy"}}).Containers[0]
+	if proxy.LastTermination != nil || proxy.Requests != "" || proxy.Limits != "" {
+		t.Errorf("never-restarted container without resources = %+v", proxy)
+	}
+	if !(PodStatus{}).Empty() || s.Empty() {
+		t.Error("Empty misreports")
+	}
+}
+
+func TestFetchPodStatus(t *testing.T) {
+	clientset := fake.NewSimpleClientset(statusTestPod())
+	s, err := FetchPodStatus(context.Background(), clientset, "shop", "api-0", []ContainerTarget{{Name: "api"}})
+	if err != nil || len(s.Containers) != 1 || s.Containers[0].RestartCount != 3 {
+		t.Errorf("FetchPodStatus = %+v, %v", s, err)
+	}
+	if _, err := FetchPodStatus(context.Background(), clientset, "shop", "missing", nil); err == nil {
+		t.Error("expected an error for a missing pod")
+	}
+}
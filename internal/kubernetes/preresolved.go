@@ -2,7 +2,10 @@ package kubernetes
 
 import (
 	"fmt"
+	"os"
 	"strings"
+
+	"github.com/podtrace/podtrace/internal/cri"
 )
 
 // PreResolvedRef is the workstation's hand-off to the spawn pod: enough fields
@@ -12,24 +15,30 @@ type PreResolvedRef struct {
 	PodName       string
 	ContainerID   string
 	ContainerName string
+	PodUID        string
 }
 
-// ParsePreResolvedRef parses the "namespace/podName/containerID/containerName"
-// form. Each ref names exactly one container; a multi-container pod arrives
-// as one ref per container. Empty containerName is allowed.
+// ParsePreResolvedRef parses the
+// "namespace/podName/containerID/containerName/podUID" form. Each ref names
+// exactly one container; a multi-container pod arrives as one ref per
+// container. Empty containerName is allowed, and refs from older
+// workstations end before the pod UID.
 func ParsePreResolvedRef(s string) (PreResolvedRef, error) {
-	parts := strings.SplitN(s, "/", 4)
+	parts := strings.SplitN(s, "/", 5)
 	if len(parts) < 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
-		return PreResolvedRef{}, fmt.Errorf("preresolved ref %q must be ns/name/containerID[/containerName]", s)
+		return PreResolvedRef{}, fmt.Errorf("preresolved ref %q must be ns/name/containerID[/containerName[/podUID]]", s)
 	}
 	ref := PreResolvedRef{
 		Namespace:   parts[0],
 		PodName:     parts[1],
 		ContainerID: parts[2],
 	}
-	if len(parts) == 4 {
+	if len(parts) >= 4 {
 		ref.ContainerName = parts[3]
 	}
+	if len(parts) == 5 {
+		ref.PodUID = parts[4]
+	}
 	return ref, nil
 }
 
@@ -39,6 +48,10 @@ func BuildPodInfoFromPreResolved(ref PreResolvedRef) (*PodInfo, error) {
 	if ref.ContainerID == "" {
 		return nil, fmt.Errorf("preresolved ref for %s/%s has empty containerID", ref.Namespace, ref.PodName)
 	}
+	runtime := cri.LocalRuntime()
+	if runtime.Name == "" {
+		runtime.Name = cri.RuntimeFromContainerID(ref.ContainerID)
+	}
 	normalizedID, err := normalizeContainerID(ref.ContainerID)
 	if err != nil {
 		return nil, fmt.Errorf("preresolved ref for %s/%s: %w", ref.Namespace, ref.PodName, err)
@@ -62,6 +75,9 @@ func BuildPodInfoFromPreResolved(ref PreResolvedRef) (*PodInfo, error) {
 		ContainerName: ref.ContainerName,
 		CgroupPath:    cgroupPath,
 		Labels:        map[string]string{},
+		PodUID:        ref.PodUID,
+		NodeName:      os.Getenv("NODE_NAME"),
+		Runtime:       runtime,
 	}, nil
 }
 
@@ -93,7 +109,7 @@ type PreResolvedSkip struct {
 	Cause error
 }
 
-// BuildPodInfosFromPreResolved processes a list of "ns/name/containerID[/cName]"
+// BuildPodInfosFromPreResolved processes a list of "ns/name/containerID[/cName[/uid]]"
 // strings and returns:
 //   - infos:    the successfully-resolved targets
 //   - skipped:  per-ref failures with their reason (parse error, empty container,
@@ -39,6 +39,13 @@ func TestParsePreResolvedRef_AcceptsThreeOrFourParts(t *testing.T) {
 	if r4.ContainerName != "cname" {
 		t.Errorf("4-part container name lost: %+v", r4)
 	}
+	r5, err := ParsePreResolvedRef("ns/pod/cid//0f3c6a52-1b7e-4c1d-9a8e-5d2f4b6c7e81")
+	if err != nil {
+		t.Fatalf("5-part form must parse: %v", err)
+	}
+	if r5.ContainerName != "" || r5.PodUID != "0f3c6a52-1b7e-4c1d-9a8e-5d2f4b6c7e81" {
+		t.Errorf("5-part parse mismatch: %+v", r5)
+	}
 }
 
 func TestBuildPodInfoFromPreResolved_EmptyContainerID(t *testing.T) {
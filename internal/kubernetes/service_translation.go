@@ -0,0 +1,229 @@
+package kubernetes
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cilium/ebpf"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// ServiceTranslator maps a Service backend a connection reached back to the
+// Service address the client dialed. IPVS and Cilium's socket load balancer
+// rewrite the ClusterIP to a backend pod IP, Cilium before connect() even
+// returns, so the address podtrace sees names a pod rather than the Service.
+// Both keep the translation in node-local tables: IPVS in its connection
+// table, Cilium in pinned BPF maps.
+type ServiceTranslator struct {
+	ipvsConnPath string
+	ciliumDir    string
+	refresh      time.Duration
+
+	mu       sync.Mutex
+	loadedAt time.Time
+	table    lbTable
+}
+
+// lbTable maps a backend to the frontends (Service address and port) that
+// were seen balancing to it.
+type lbTable map[netip.AddrPort]map[netip.AddrPort]struct{}
+
+func (t lbTable) add(backend, frontend netip.AddrPort) {
+	if !backend.IsValid() || !frontend.IsValid() || backend == frontend {
+		return
+	}
+	fronts := t[backend]
+	if fronts == nil {
+		fronts = make(map[netip.AddrPort]struct{}, 1)
+		t[backend] = fronts
+	}
+	fronts[frontend] = struct{}{}
+}
+
+// NewServiceTranslator returns a translator reading the host's tables, or
+// nil when PODTRACE_SERVICE_TRANSLATION is off.
+func NewServiceTranslator() *ServiceTranslator {
+	if !config.ServiceTranslation {
+		return nil
+	}
+	return &ServiceTranslator{
+		// /proc/1/net is the host's network namespace, whichever one
+		// podtrace itself runs in.
+		ipvsConnPath: filepath.Join(config.ProcBasePath, "1", "net", "ip_vs_conn"),
+		ciliumDir:    config.CiliumBPFDir,
+		refresh:      config.ServiceTranslationRefresh,
+	}
+}
+
+// Frontends returns the addresses the load balancer translated to backend
+// ip:port: the ClusterIP of every Service the backend serves, and for
+// Cilium also the node ports and external IPs in front of them.
+func (st *ServiceTranslator) Frontends(ip string, port int) []netip.AddrPort {
+	if st == nil || ip == "" || port <= 0 || port > 0xffff {
+		return nil
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	backend := netip.AddrPortFrom(addr.Unmap(), uint16(port))
+
+	st.mu.Lock()
+	if st.table == nil || time.Since(st.loadedAt) >= st.refresh {
+		st.table = st.load()
+		st.loadedAt = time.Now()
+	}
+	fronts := st.table[backend]
+	st.mu.Unlock()
+
+	if len(fronts) == 0 {
+		return nil
+	}
+	out := make([]netip.AddrPort, 0, len(fronts))
+	for f := range fronts {
+		out = append(out, f)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Compare(out[j]) < 0 })
+	return out
+}
+
+func (st *ServiceTranslator) load() lbTable {
+	t := make(lbTable)
+	if f, err := os.Open(st.ipvsConnPath); err == nil {
+		parseIPVSConns(f, t)
+		_ = f.Close()
+	}
+	for _, family := range []struct {
+		name    string
+		addrLen int
+	}{{"lb4", 4}, {"lb6", 16}} {
+		services := filepath.Join(st.ciliumDir, "cilium_"+family.name+"_services_v2")
+		// Cilium 1.13 moved backends to v3; older releases still use v2.
+		for _, version := range []string{"v3", "v2"} {
+			backends := filepath.Join(st.ciliumDir, "cilium_"+family.name+"_backends_"+version)
+			err := readCiliumLB(services, backends, family.addrLen, t)
+			if err == nil {
+				break
+			}
+			if !errors.Is(err, os.ErrNotExist) {
+				logger.Debug("Cilium service maps not read", zap.String("map", backends), zap.Error(err))
+				break
+			}
+		}
+	}
+	return t
+}
+
+// parseIPVSConns reads /proc/net/ip_vs_conn: each connection names the
+// client, the virtual service it dialed and the real server it went to.
+//
+//	Pro FromIP   FPrt ToIP     TPrt DestIP   DPrt State       Expires ...
+//	TCP 0A000105 C350 0A600001 01BB 0A000207 1F90 ESTABLISHED     899
+func parseIPVSConns(r io.Reader, t lbTable) {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) < 7 || f[0] == "Pro" {
+			continue
+		}
+		virtual, ok1 := ipvsAddrPort(f[3], f[4])
+		dest, ok2 := ipvsAddrPort(f[5], f[6])
+		if ok1 && ok2 {
+			t.add(dest, virtual)
+		}
+	}
+}
+
+// ipvsAddrPort parses an ip_vs_conn address, %08X for IPv4 and the full
+// colon form for IPv6, and its %04X port.
+func ipvsAddrPort(addr, port string) (netip.AddrPort, bool) {
+	p, err := strconv.ParseUint(port, 16, 16)
+	if err != nil {
+		return netip.AddrPort{}, false
+	}
+	var a netip.Addr
+	if strings.Contains(addr, ":") {
+		if a, err = netip.ParseAddr(addr); err != nil {
+			return netip.AddrPort{}, false
+		}
+	} else {
+		v, err := strconv.ParseUint(addr, 16, 32)
+		if err != nil {
+			return netip.AddrPort{}, false
+		}
+		var b [4]byte
+		binary.BigEndian.PutUint32(b[:], uint32(v))
+		a = netip.AddrFrom4(b)
+	}
+	return netip.AddrPortFrom(a, uint16(p)), true
+}
+
+func readCiliumLB(servicesPath, backendsPath string, addrLen int, t lbTable) error {
+	opts := &ebpf.LoadPinOptions{ReadOnly: true}
+	backendsMap, err := ebpf.LoadPinnedMap(backendsPath, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = backendsMap.Close() }()
+	servicesMap, err := ebpf.LoadPinnedMap(servicesPath, opts)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = servicesMap.Close() }()
+
+	backends := make(map[uint32][]byte)
+	var bk uint32
+	var bv []byte
+	it := backendsMap.Iterate()
+	for it.Next(&bk, &bv) {
+		backends[bk] = bv
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	var sk, sv []byte
+	it = servicesMap.Iterate()
+	for it.Next(&sk, &sv) {
+		addCiliumService(sk, sv, backends, addrLen, t)
+	}
+	return it.Err()
+}
+
+// addCiliumService adds one entry of a cilium_lb{4,6}_services_v2 map. The
+// key is the frontend address, its port in network order and a backend
+// slot; slot 0 describes the Service itself and slots 1..count hold the
+// backend IDs of cilium_lb{4,6}_backends, whose values start with the
+// backend's address and port.
+func addCiliumService(key, value []byte, backends map[uint32][]byte, addrLen int, t lbTable) {
+	if len(key) < addrLen+4 || len(value) < 4 {
+		return
+	}
+	slot := binary.NativeEndian.Uint16(key[addrLen+2:])
+	if slot == 0 {
+		return
+	}
+	backend, ok := backends[binary.NativeEndian.Uint32(value)]
+	if !ok || len(backend) < addrLen+2 {
+		return
+	}
+	frontAddr, ok1 := netip.AddrFromSlice(key[:addrLen])
+	backAddr, ok2 := netip.AddrFromSlice(backend[:addrLen])
+	if !ok1 || !ok2 {
+		return
+	}
+	t.add(netip.AddrPortFrom(backAddr.Unmap(), binary.BigEndian.Uint16(backend[addrLen:])),
+		netip.AddrPortFrom(frontAddr.Unmap(), binary.BigEndian.Uint16(key[addrLen:])))
+}
@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"encoding/binary"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+const ipvsConnSample = `Pro FromIP   FPrt ToIP     TPrt DestIP   DPrt State       Expires PEName PEData
+TCP 0A000105 C350 0A600001 01BB 0A000207 1F90 ESTABLISHED     899
+TCP 0A000105 C351 0A600001 01BB 0A000208 1F90 ESTABLISHED     899
+UDP fd00:0000:0000:0000:0000:0000:0000:0005 C352 fd00:0000:0000:0000:0000:0000:0060:0001 0035 fd00:0000:0000:0000:0000:0000:0002:0009 0035 UDP             12
+TCP garbage
+`
+
+func TestParseIPVSConns(t *testing.T) {
+	tbl := make(lbTable)
+	parseIPVSConns(strings.NewReader(ipvsConnSample), tbl)
+	vip := netip.MustParseAddrPort("10.96.0.1:443")
+	for _, backend := range []string{"10.0.2.7:8080", "10.0.2.8:8080"} {
+		fronts := tbl[netip.MustParseAddrPort(backend)]
+		if _, ok := fronts[vip]; !ok || len(fronts) != 1 {
+			t.Errorf("frontends of %s = %v, want %s", backend, fronts, vip)
+		}
+	}
+	v6 := tbl[netip.MustParseAddrPort("[fd00::2:9]:53")]
+	if _, ok := v6[netip.MustParseAddrPort("[fd00::60:1]:53")]; !ok {
+		t.Errorf("IPv6 frontends = %v", v6)
+	}
+}
+
+func TestAddCiliumService(t *testing.T) {
+	key := func(addr string, port, slot uint16) []byte {
+		b := make([]byte, 12)
+		a := netip.MustParseAddr(addr).As4()
+		copy(b, a[:])
+		binary.BigEndian.PutUint16(b[4:], port)
+		binary.NativeEndian.PutUint16(b[6:], slot)
+		return b
+	}
+	value := func(id uint32) []byte {
+		b := make([]byte, 12)
+		binary.NativeEndian.PutUint32(b, id)
+		return b
+	}
+	backend := make([]byte, 12)
+	copy(backend, []byte{10, 0, 2, 7})
+	binary.BigEndian.PutUint16(backend[4:], 8080)
+	backends := map[uint32][]byte{7: backend}
+
+	tbl := make(lbTable)
+	addCiliumService(key("10.96.0.10", 80, 0), value(0), backends, 4, tbl)
+	addCiliumService(key("10.96.0.10", 80, 1), value(7), backends, 4, tbl)
+	addCiliumService(key("10.96.0.10", 80, 2), value(99), backends, 4, tbl)
+	addCiliumService([]byte{1, 2}, value(7), backends, 4, tbl)
+
+	if len(tbl) != 1 {
+		t.Fatalf("table = %v, want one backend", tbl)
+	}
+	fronts := tbl[netip.MustParseAddrPort("10.0.2.7:8080")]
+	if _, ok := fronts[netip.MustParseAddrPort("10.96.0.10:80")]; !ok || len(fronts) != 1 {
+		t.Errorf("frontends = %v", fronts)
+	}
+}
+
+func TestServiceTranslator_Frontends(t *testing.T) {
+	dir := t.TempDir()
+	connPath := filepath.Join(dir, "ip_vs_conn")
+	if err := os.WriteFile(connPath, []byte(ipvsConnSample), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	st := &ServiceTranslator{ipvsConnPath: connPath, ciliumDir: filepath.Join(dir, "no-cilium"), refresh: time.Minute}
+	got := st.Frontends("10.0.2.7", 8080)
+	if len(got) != 1 || got[0] != netip.MustParseAddrPort("10.96.0.1:443") {
+		t.Errorf("Frontends(10.0.2.7:8080) = %v", got)
+	}
+	if got := st.Frontends("10.0.2.7", 9090); got != nil {
+		t.Errorf("Frontends of an unknown backend = %v", got)
+	}
+	var nilST *ServiceTranslator
+	if got := nilST.Frontends("10.0.2.7", 8080); got != nil {
+		t.Errorf("nil translator Frontends = %v", got)
+	}
+}
+
+func TestContextEnricher_DialedService(t *testing.T) {
+	dir := t.TempDir()
+	connPath := filepath.Join(dir, "ip_vs_conn")
+	if err := os.WriteFile(connPath, []byte(ipvsConnSample), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	svcInf := cache.NewSharedIndexInformer(nil, &corev1.Service{}, 0, cache.Indexers{
+		clusterIPIndex: func(obj interface{}) ([]string, error) {
+			return obj.(*corev1.Service).Spec.ClusterIPs, nil
+		},
+	})
+	if err := svcInf.GetIndexer().Add(&corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "api"},
+		Spec: corev1.ServiceSpec{
+			ClusterIPs: []string{"10.96.0.1"},
+			Ports:      []corev1.ServicePort{{Port: 443}},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	ce := &ContextEnricher{
+		informerCache: &InformerCache{svcInf: svcInf},
+		translator:    &ServiceTranslator{ipvsConnPath: connPath, ciliumDir: dir, refresh: time.Minute},
+	}
+
+	for _, target := range []struct {
+		ip   string
+		port int
+	}{{"10.96.0.1", 443}, {"10.0.2.8", 8080}} {
+		svc := ce.dialedService(target.ip, target.port)
+		if svc == nil || svc.Name != "api" || svc.Namespace != "default" || svc.Port != 443 {
+			t.Errorf("dialedService(%s:%d) = %+v, want default/api:443", target.ip, target.port, svc)
+		}
+	}
+	if svc := ce.dialedService("10.0.2.9", 8080); svc != nil {
+		t.Errorf("dialedService of an untranslated backend = %+v", svc)
+	}
+}
@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// maxStorageEvents is how many of a claim's and volume's Events are kept,
+// most recent first.
+const maxStorageEvents = 5
+
+// StorageHealth is what the API says about the storage behind a claim, for
+// handing a slow volume to whoever runs the storage.
+type StorageHealth struct {
+	Claim       string
+	Phase       string
+	Capacity    string
+	AccessModes []string
+	// PersistentVolume is the bound volume; empty while the claim is pending.
+	PersistentVolume string
+	StorageClass     string
+	Provisioner      string
+	// CSIDriver and VolumeHandle identify the volume to its CSI driver.
+	CSIDriver    string
+	VolumeHandle string
+	Events       []StorageEvent
+}
+
+// StorageEvent is one Event on the claim or its volume.
+type StorageEvent struct {
+	Object   string
+	Type     string
+	Reason   string
+	Message  string
+	Count    int32
+	LastSeen time.Time
+}
+
+// FetchStorageHealth reads the claim, its PersistentVolume and StorageClass,
+// and their recent Events. Only the claim is required; whatever else cannot
+// be read is left empty.
+func FetchStorageHealth(ctx context.Context, clientset kubernetes.Interface, namespace, claim string) (StorageHealth, error) {
+	pvc, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, claim, metav1.GetOptions{})
+	if err != nil {
+		return StorageHealth{}, fmt.Errorf("get claim %s/%s: %w", namespace, claim, err)
+	}
+	h := StorageHealth{
+		Claim:            claim,
+		Phase:            string(pvc.Status.Phase),
+		PersistentVolume: pvc.Spec.VolumeName,
+	}
+	for _, m := range pvc.Status.AccessModes {
+		h.AccessModes = append(h.AccessModes, string(m))
+	}
+	if len(h.AccessModes) == 0 {
+		for _, m := range pvc.Spec.AccessModes {
+			h.AccessModes = append(h.AccessModes, string(m))
+		}
+	}
+	if q, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+		h.Capacity = q.String()
+	}
+	if pvc.Spec.StorageClassName != nil {
+		h.StorageClass = *pvc.Spec.StorageClassName
+	}
+
+	if h.PersistentVolume != "" {
+		if pv, err := clientset.CoreV1().PersistentVolumes().Get(ctx, h.PersistentVolume, metav1.GetOptions{}); err == nil {
+			if h.StorageClass == "" {
+				h.StorageClass = pv.Spec.StorageClassName
+			}
+			if csi := pv.Spec.CSI; csi != nil {
+				h.CSIDriver, h.VolumeHandle = csi.Driver, csi.VolumeHandle
+			}
+		}
+	}
+	if h.StorageClass != "" {
+		if sc, err := clientset.StorageV1().StorageClasses().Get(ctx, h.StorageClass, metav1.GetOptions{}); err == nil {
+			h.Provisioner = sc.Provisioner
+		}
+	}
+
+	h.Events = append(h.Events, objectEvents(ctx, clientset, namespace, "PersistentVolumeClaim", claim)...)
+	if h.PersistentVolume != "" {
+		// Events on cluster-scoped objects are recorded in "default".
+		h.Events = append(h.Events, objectEvents(ctx, clientset, metav1.NamespaceDefault, "PersistentVolume", h.PersistentVolume)...)
+	}
+	sort.SliceStable(h.Events, func(i, j int) bool { return h.Events[i].LastSeen.After(h.Events[j].LastSeen) })
+	if len(h.Events) > maxStorageEvents {
+		h.Events = h.Events[:maxStorageEvents]
+	}
+	return h, nil
+}
+
+func objectEvents(ctx context.Context, clientset kubernetes.Interface, namespace, kind, name string) []StorageEvent {
+	list, err := clientset.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fields.AndSelectors(
+			fields.OneTermEqualSelector("involvedObject.name", name),
+			fields.OneTermEqualSelector("involvedObject.kind", kind),
+		).String(),
+	})
+	if err != nil {
+		return nil
+	}
+	var out []StorageEvent
+	for _, e := range list.Items {
+		if e.InvolvedObject.Kind != kind || e.InvolvedObject.Name != name {
+			continue
+		}
+		last := e.LastTimestamp.Time
+		if last.IsZero() {
+			last = e.EventTime.Time
+		}
+		out = append(out, StorageEvent{
+			Object:   kind + "/" + name,
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Message:  e.Message,
+			Count:    e.Count,
+			LastSeen: last,
+		})
+	}
+	return out
+}
@@ -0,0 +1,67 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFetchStorageHealth(t *testing.T) {
+	class := "gp3"
+	now := time.Date(2026, 10, 16, 14, 0, 0, 0, time.UTC)
+	event := func(name, ns, kind, obj, reason string, at time.Time) *corev1.Event {
+		return &corev1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: name, Namespace: ns},
+			InvolvedObject: corev1.ObjectReference{Kind: kind, Name: obj},
+			Type:           corev1.EventTypeWarning, Reason: reason, Message: reason + " message",
+			Count: 2, LastTimestamp: metav1.NewTime(at),
+		}
+	}
+	cs := fake.NewSimpleClientset(
+		&corev1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data-db-0", Namespace: "shop"},
+			Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &class, VolumeName: "pvc-123"},
+			Status: corev1.PersistentVolumeClaimStatus{
+				Phase:       corev1.ClaimBound,
+				AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+				Capacity:    corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("100Gi")},
+			},
+		},
+		&corev1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pvc-123"},
+			Spec: corev1.PersistentVolumeSpec{PersistentVolumeSource: corev1.PersistentVolumeSource{
+				CSI: &corev1.CSIPersistentVolumeSource{Driver: "ebs.csi.aws.com", VolumeHandle: "vol-0abc"},
+			}},
+		},
+		&storagev1.StorageClass{ObjectMeta: metav1.ObjectMeta{Name: "gp3"}, Provisioner: "ebs.csi.aws.com"},
+		event("e1", "shop", "PersistentVolumeClaim", "data-db-0", "VolumeResizeFailed", now.Add(-time.Hour)),
+		event("e2", "default", "PersistentVolume", "pvc-123", "VolumeDegraded", now),
+		event("e3", "shop", "Pod", "db-0", "Unrelated", now),
+	)
+
+	h, err := FetchStorageHealth(context.Background(), cs, "shop", "data-db-0")
+	if err != nil {
+		t.Fatalf("FetchStorageHealth: %v", err)
+	}
+	if h.Phase != "Bound" || h.Capacity != "100Gi" || len(h.AccessModes) != 1 || h.AccessModes[0] != "ReadWriteOnce" {
+		t.Errorf("claim = %+v", h)
+	}
+	if h.PersistentVolume != "pvc-123" || h.StorageClass != "gp3" || h.Provisioner != "ebs.csi.aws.com" ||
+		h.CSIDriver != "ebs.csi.aws.com" || h.VolumeHandle != "vol-0abc" {
+		t.Errorf("volume = %+v", h)
+	}
+	if len(h.Events) != 2 || h.Events[0].Reason != "VolumeDegraded" || h.Events[0].Object != "PersistentVolume/pvc-123" ||
+		h.Events[1].Reason != "VolumeResizeFailed" {
+		t.Errorf("events = %+v", h.Events)
+	}
+
+	if _, err := FetchStorageHealth(context.Background(), cs, "shop", "missing"); err == nil {
+		t.Error("missing claim: want an error")
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/cache"
 
+	"github.com/podtrace/podtrace/internal/cri"
 	"github.com/podtrace/podtrace/internal/logger"
 	"go.uber.org/zap"
 )
@@ -27,6 +28,9 @@ type TargetSelection struct {
 	AllInNamespace   bool
 	Pods             []string // supports "pod" or "namespace/pod"
 	ContainerName    string
+	// StrictContainer refuses pods whose main container would have to be
+	// guessed when ContainerName is empty.
+	StrictContainer bool
 }
 
 func (s TargetSelection) EffectiveNamespaces() []string {
@@ -241,6 +245,11 @@ func (tr *TargetRegistry) handlePodUpsert(ctx context.Context, pod *corev1.Pod)
 		}
 		return
 	}
+	if tr.selection.StrictContainer && tr.selection.ContainerName == "" && info.MainContainer.Guessed {
+		logger.Warn("Skipping target pod whose main container is ambiguous",
+			zap.Error(NewAmbiguousContainerError(pod.Namespace, pod.Name, info.MainContainer)))
+		return
+	}
 
 	tr.mu.Lock()
 	if len(tr.targets) >= tr.maxTargets {
@@ -256,18 +265,25 @@ func (tr *TargetRegistry) handlePodUpsert(ctx context.Context, pod *corev1.Pod)
 }
 
 // podHasContainerID reports whether any of the pod's current container
-// statuses carries the given (runtime-prefix-stripped) container ID.
+// statuses, including init and ephemeral ones, carries the given
+// (runtime-prefix-stripped) container ID.
 func podHasContainerID(pod *corev1.Pod, shortID string) bool {
 	if shortID == "" {
 		return false
 	}
-	for _, cs := range pod.Status.ContainerStatuses {
-		id := cs.ContainerID
-		if i := strings.Index(id, "://"); i >= 0 {
-			id = id[i+3:]
-		}
-		if id == shortID {
-			return true
+	for _, list := range [][]corev1.ContainerStatus{
+		pod.Status.ContainerStatuses,
+		pod.Status.InitContainerStatuses,
+		pod.Status.EphemeralContainerStatuses,
+	} {
+		for _, cs := range list {
+			id := cs.ContainerID
+			if i := strings.Index(id, "://"); i >= 0 {
+				id = id[i+3:]
+			}
+			if id == shortID {
+				return true
+			}
 		}
 	}
 	return false
@@ -358,7 +374,7 @@ func resolvePodInfoFromObject(ctx context.Context, pod *corev1.Pod, containerNam
 			if err != nil {
 				continue
 			}
-			targets = append(targets, ContainerTarget{Name: cs.Name, ID: shortID})
+			targets = append(targets, ContainerTarget{Name: cs.Name, ID: shortID, Kind: containerKind(pod, cs.Name)})
 		}
 		if len(targets) == 0 {
 			return nil, fmt.Errorf("no usable container in pod %s/%s", pod.Namespace, pod.Name)
@@ -377,17 +393,24 @@ func resolvePodInfoFromObject(ctx context.Context, pod *corev1.Pod, containerNam
 		ownerName = pod.OwnerReferences[0].Name
 	}
 
+	primary, choice := primaryTarget(pod, targets)
 	return &PodInfo{
 		PodName:       pod.Name,
 		Namespace:     pod.Namespace,
 		Containers:    targets,
-		ContainerID:   targets[0].ID,
-		CgroupPath:    targets[0].CgroupPath,
-		ContainerName: targets[0].Name,
+		ContainerID:   primary.ID,
+		CgroupPath:    primary.CgroupPath,
+		ContainerName: primary.Name,
+		MainContainer: choice,
 		Labels:        labels,
 		PodIP:         pod.Status.PodIP,
 		OwnerKind:     ownerKind,
 		OwnerName:     ownerName,
+		Bandwidth:     podBandwidth(pod),
+		Status:        podStatus(pod, targets),
+		PodUID:        string(pod.UID),
+		NodeName:      pod.Spec.NodeName,
+		Runtime:       cri.PodRuntime(pod),
 	}, nil
 }
 
@@ -0,0 +1,71 @@
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// PodTermination is how a traced pod went away before the trace ended.
+type PodTermination struct {
+	Namespace string
+	Pod       string
+	// Reason is the API's: a DisruptionTarget reason such as
+	// EvictionByEvictionAPI or PreemptionByScheduler, the pod's status
+	// reason such as Evicted, or else Deleted, Failed or Completed.
+	Reason  string
+	Message string
+	At      time.Time
+}
+
+// PodTerminationOf reports how pod ended, once it is deleted or has reached
+// a phase it never leaves. A pod that is still shutting down has not ended:
+// its last moments are worth tracing.
+func PodTerminationOf(pod *corev1.Pod, deleted bool, at time.Time) (PodTermination, bool) {
+	if pod == nil {
+		return PodTermination{}, false
+	}
+	t := PodTermination{Namespace: pod.Namespace, Pod: pod.Name, At: at}
+	switch {
+	case deleted:
+		t.Reason = "Deleted"
+	case pod.Status.Phase == corev1.PodFailed:
+		t.Reason = "Failed"
+	case pod.Status.Phase == corev1.PodSucceeded:
+		t.Reason = "Completed"
+	default:
+		return PodTermination{}, false
+	}
+	if pod.Status.Reason != "" {
+		t.Reason, t.Message = pod.Status.Reason, pod.Status.Message
+	}
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.DisruptionTarget && c.Status == corev1.ConditionTrue && c.Reason != "" {
+			t.Reason, t.Message = c.Reason, c.Message
+		}
+	}
+	return t, true
+}
+
+// WatchPodTermination calls gone once, when the pod is deleted, evicted,
+// preempted or finishes, until ctx is done. It returns once the watch has
+// started.
+func WatchPodTermination(ctx context.Context, clientset kubernetes.Interface, namespace, name string, gone func(PodTermination)) error {
+	var once sync.Once
+	return watchLoop(ctx, func() (watch.Interface, error) {
+		return clientset.CoreV1().Pods(namespace).Watch(ctx, metav1.ListOptions{FieldSelector: "metadata.name=" + name})
+	}, func(ev watch.Event) {
+		pod, ok := ev.Object.(*corev1.Pod)
+		if !ok || pod.Name != name {
+			return
+		}
+		if t, ok := PodTerminationOf(pod, ev.Type == watch.Deleted, time.Now()); ok {
+			once.Do(func() { gone(t) })
+		}
+	})
+}
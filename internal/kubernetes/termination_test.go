@@ -0,0 +1,86 @@
+package kubernetes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func terminationPod(phase corev1.PodPhase, reason, message string, conds ...corev1.PodCondition) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "shop", Name: "api-0"},
+		Status:     corev1.PodStatus{Phase: phase, Reason: reason, Message: message, Conditions: conds},
+	}
+}
+
+func TestPodTerminationOf(t *testing.T) {
+	at := time.Now()
+	drained := corev1.PodCondition{Type: corev1.DisruptionTarget, Status: corev1.ConditionTrue, Reason: "EvictionByEvictionAPI", Message: "Eviction API: evicting"}
+	tests := []struct {
+		name    string
+		pod     *corev1.Pod
+		deleted bool
+		reason  string
+		ok      bool
+	}{
+		{"running", terminationPod(corev1.PodRunning, "", ""), false, "", false},
+		{"shutting down after a drain", terminationPod(corev1.PodRunning, "", "", drained), false, "", false},
+		{"deleted", terminationPod(corev1.PodRunning, "", ""), true, "Deleted", true},
+		{"drained", terminationPod(corev1.PodRunning, "", "", drained), true, "EvictionByEvictionAPI", true},
+		{"evicted by the kubelet", terminationPod(corev1.PodFailed, "Evicted", "The node was low on resource: memory."), false, "Evicted", true},
+		{"failed", terminationPod(corev1.PodFailed, "", ""), false, "Failed", true},
+		{"completed", terminationPod(corev1.PodSucceeded, "", ""), false, "Completed", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := PodTerminationOf(tt.pod, tt.deleted, at)
+			if ok != tt.ok || got.Reason != tt.reason {
+				t.Fatalf("PodTerminationOf = %+v, %v; want reason %q, %v", got, ok, tt.reason, tt.ok)
+			}
+			if ok && (got.Namespace != "shop" || got.Pod != "api-0" || !got.At.Equal(at)) {
+				t.Errorf("termination = %+v", got)
+			}
+		})
+	}
+}
+
+func TestWatchPodTermination(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	w := watch.NewRaceFreeFake()
+	clientset.PrependWatchReactor("pods", func(action k8stesting.Action) (bool, watch.Interface, error) {
+		if sel := action.(k8stesting.WatchAction).GetWatchRestrictions().Fields.String(); sel != "metadata.name=api-0" {
+			t.Errorf("watch selector = %q", sel)
+		}
+		return true, w, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	got := make(chan PodTermination, 4)
+	if err := WatchPodTermination(ctx, clientset, "shop", "api-0", func(t PodTermination) { got <- t }); err != nil {
+		t.Fatal(err)
+	}
+	w.Add(terminationPod(corev1.PodRunning, "", ""))
+	w.Modify(terminationPod(corev1.PodFailed, "Evicted", "The node was low on resource: memory."))
+	w.Delete(terminationPod(corev1.PodFailed, "Evicted", ""))
+
+	select {
+	case term := <-got:
+		if term.Reason != "Evicted" || term.Message != "The node was low on resource: memory." {
+			t.Errorf("termination = %+v", term)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no termination reported")
+	}
+	select {
+	case term := <-got:
+		t.Errorf("termination reported twice: %+v", term)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,235 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/podtrace/podtrace/internal/procfs"
+	"github.com/podtrace/podtrace/internal/sysfs"
+)
+
+// Volume kinds besides the pod spec's volume source names.
+const (
+	// VolumeRootfs is the container's own writable root filesystem.
+	VolumeRootfs = "rootfs"
+	// VolumeOther is a volume source podtrace does not name.
+	VolumeOther = "other"
+)
+
+// Volume is one pod volume where a traced container mounts it.
+type Volume struct {
+	Container string
+	Name      string
+	// Kind is the volume source as the pod spec names it (emptyDir,
+	// persistentVolumeClaim, configMap, secret, hostPath, ...), or
+	// VolumeRootfs.
+	Kind string
+	// Source is what backs the volume: the claim, ConfigMap or Secret name,
+	// or the host path.
+	Source string
+	// StorageClass is the claim's storage class, for PVC volumes.
+	StorageClass string
+	MountPath    string
+	// ReadOnly is set for read-only mounts and, on the VolumeRootfs volume,
+	// for readOnlyRootFilesystem.
+	ReadOnly bool
+	// SizeLimit is an emptyDir's sizeLimit, empty when unlimited.
+	SizeLimit string
+}
+
+// podVolumes lists the volume mounts of pod's traced containers, each
+// container's root filesystem last.
+func podVolumes(pod *corev1.Pod, targets []ContainerTarget) []Volume {
+	sources := make(map[string]corev1.VolumeSource, len(pod.Spec.Volumes))
+	for _, v := range pod.Spec.Volumes {
+		sources[v.Name] = v.VolumeSource
+	}
+	containers := make(map[string]corev1.Container)
+	for _, c := range pod.Spec.InitContainers {
+		containers[c.Name] = c
+	}
+	for _, c := range pod.Spec.Containers {
+		containers[c.Name] = c
+	}
+
+	var vols []Volume
+	for _, t := range targets {
+		c, ok := containers[t.Name]
+		if !ok {
+			continue
+		}
+		for _, m := range c.VolumeMounts {
+			src := sources[m.Name]
+			kind, source := volumeSource(src)
+			v := Volume{
+				Container: t.Name,
+				Name:      m.Name,
+				Kind:      kind,
+				Source:    source,
+				MountPath: m.MountPath,
+				ReadOnly:  m.ReadOnly,
+			}
+			if src.EmptyDir != nil && src.EmptyDir.SizeLimit != nil {
+				v.SizeLimit = src.EmptyDir.SizeLimit.String()
+			}
+			vols = append(vols, v)
+		}
+		rootfs := Volume{Container: t.Name, Name: VolumeRootfs, Kind: VolumeRootfs, MountPath: "/"}
+		if sc := c.SecurityContext; sc != nil && sc.ReadOnlyRootFilesystem != nil {
+			rootfs.ReadOnly = *sc.ReadOnlyRootFilesystem
+		}
+		vols = append(vols, rootfs)
+	}
+	return vols
+}
+
+func volumeSource(s corev1.VolumeSource) (kind, source string) {
+	switch {
+	case s.PersistentVolumeClaim != nil:
+		return "persistentVolumeClaim", s.PersistentVolumeClaim.ClaimName
+	case s.EmptyDir != nil:
+		if s.EmptyDir.Medium == corev1.StorageMediumMemory {
+			return "emptyDir", "memory"
+		}
+		return "emptyDir", ""
+	case s.ConfigMap != nil:
+		return "configMap", s.ConfigMap.Name
+	case s.Secret != nil:
+		return "secret", s.Secret.SecretName
+	case s.HostPath != nil:
+		return "hostPath", s.HostPath.Path
+	case s.Projected != nil:
+		return "projected", ""
+	case s.DownwardAPI != nil:
+		return "downwardAPI", ""
+	case s.CSI != nil:
+		return "csi", s.CSI.Driver
+	case s.Ephemeral != nil:
+		return "ephemeral", ""
+	case s.NFS != nil:
+		return "nfs", s.NFS.Server + ":" + s.NFS.Path
+	}
+	return VolumeOther, ""
+}
+
+// FetchPodVolumes gets the pod and lists the volume mounts of the given
+// traced containers, with the storage class of each claim that can be read.
+func FetchPodVolumes(ctx context.Context, clientset kubernetes.Interface, namespace, name string, targets []ContainerTarget) ([]Volume, error) {
+	pod, err := clientset.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("get pod %s/%s: %w", namespace, name, err)
+	}
+	vols := podVolumes(pod, targets)
+	classes := make(map[string]string)
+	for i := range vols {
+		if vols[i].Kind != "persistentVolumeClaim" {
+			continue
+		}
+		class, ok := classes[vols[i].Source]
+		if !ok {
+			if pvc, err := clientset.CoreV1().PersistentVolumeClaims(namespace).Get(ctx, vols[i].Source, metav1.GetOptions{}); err == nil && pvc.Spec.StorageClassName != nil {
+				class = *pvc.Spec.StorageClassName
+			}
+			classes[vols[i].Source] = class
+		}
+		vols[i].StorageClass = class
+	}
+	return vols, nil
+}
+
+// MountedVolumes maps the mount IDs of a traced container's mount table to
+// the volumes mounted there, so FS events can be put on the volume their
+// file lives on. The container's root mount is its VolumeRootfs volume.
+// cgroupPath is the container's cgroup; its oldest process's
+// /proc/<pid>/mountinfo is read.
+func MountedVolumes(container, cgroupPath string, vols []Volume) (map[uint32]Volume, error) {
+	pid := oldestCgroupPID(cgroupPath)
+	if pid == 0 {
+		return nil, fmt.Errorf("no process in cgroup %s", cgroupPath)
+	}
+	data, err := procfs.ReadFile(strconv.FormatUint(uint64(pid), 10) + "/mountinfo")
+	if err != nil {
+		return nil, fmt.Errorf("read mount table: %w", err)
+	}
+	byPath := make(map[string]Volume)
+	for _, v := range vols {
+		if v.Container == container {
+			byPath[filepath.Clean(v.MountPath)] = v
+		}
+	}
+	out := make(map[uint32]Volume)
+	for id, mountPoint := range parseMountInfo(data) {
+		if v, ok := byPath[mountPoint]; ok {
+			out[id] = v
+		}
+	}
+	return out, nil
+}
+
+// parseMountInfo maps each mount ID of a /proc/<pid>/mountinfo to its mount
+// point.
+func parseMountInfo(data []byte) map[uint32]string {
+	mounts := make(map[uint32]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		id, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil {
+			continue
+		}
+		mounts[uint32(id)] = filepath.Clean(unescapeMountPath(fields[4]))
+	}
+	return mounts
+}
+
+// unescapeMountPath undoes the octal escapes (\040 for a space) the kernel
+// writes in mountinfo paths.
+func unescapeMountPath(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+3 < len(s) {
+			if n, err := strconv.ParseUint(s[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(n))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// oldestCgroupPID returns the lowest PID in the cgroup's cgroup.procs, or 0.
+func oldestCgroupPID(cgroupPath string) uint32 {
+	rel, ok := sysfs.CgroupRelative(cgroupPath)
+	if !ok {
+		return 0
+	}
+	data, err := sysfs.CgroupReadFile(filepath.Join(rel, "cgroup.procs"))
+	if err != nil {
+		return 0
+	}
+	var oldest uint32
+	for _, f := range strings.Fields(string(data)) {
+		pid, err := strconv.ParseUint(f, 10, 32)
+		if err != nil || pid == 0 {
+			continue
+		}
+		if oldest == 0 || uint32(pid) < oldest {
+			oldest = uint32(pid)
+		}
+	}
+	return oldest
+}
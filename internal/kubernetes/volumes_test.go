@@ -0,0 +1,131 @@
+package kubernetes
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/procfs"
+	"github.com/podtrace/podtrace/internal/sysfs"
+)
+
+func volumeTestPod() *corev1.Pod {
+	sizeLimit := resource.MustParse("1Gi")
+	readOnly := true
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "shop"},
+		Spec: corev1.PodSpec{
+			Volumes: []corev1.Volume{
+				{Name: "data", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "data-db-0"}}},
+				{Name: "scratch", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{SizeLimit: &sizeLimit}}},
+				{Name: "conf", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "db-conf"}}}},
+			},
+			Containers: []corev1.Container{
+				{Name: "db", SecurityContext: &corev1.SecurityContext{ReadOnlyRootFilesystem: &readOnly}, VolumeMounts: []corev1.VolumeMount{
+					{Name: "data", MountPath: "/var/lib/db"},
+					{Name: "scratch", MountPath: "/tmp/"},
+					{Name: "conf", MountPath: "/etc/db", ReadOnly: true},
+				}},
+				{Name: "exporter", VolumeMounts: []corev1.VolumeMount{{Name: "conf", MountPath: "/etc/db"}}},
+			},
+		},
+	}
+}
+
+func TestPodVolumes(t *testing.T) {
+	vols := podVolumes(volumeTestPod(), []ContainerTarget{{Name: "db"}})
+	if len(vols) != 4 {
+		t.Fatalf("volumes = %+v; want the traced container's 3 mounts and its root filesystem", vols)
+	}
+	want := []Volume{
+		{Container: "db", Name: "data", Kind: "persistentVolumeClaim", Source: "data-db-0", MountPath: "/var/lib/db"},
+		{Container: "db", Name: "scratch", Kind: "emptyDir", MountPath: "/tmp/", SizeLimit: "1Gi"},
+		{Container: "db", Name: "conf", Kind: "configMap", Source: "db-conf", MountPath: "/etc/db", ReadOnly: true},
+		{Container: "db", Name: VolumeRootfs, Kind: VolumeRootfs, MountPath: "/", ReadOnly: true},
+	}
+	for i, w := range want {
+		if vols[i] != w {
+			t.Errorf("volume %d = %+v, want %+v", i, vols[i], w)
+		}
+	}
+}
+
+func TestFetchPodVolumes_StorageClass(t *testing.T) {
+	class := "gp3"
+	cs := fake.NewSimpleClientset(volumeTestPod(), &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data-db-0", Namespace: "shop"},
+		Spec:       corev1.PersistentVolumeClaimSpec{StorageClassName: &class},
+	})
+	vols, err := FetchPodVolumes(context.Background(), cs, "shop", "db-0", []ContainerTarget{{Name: "db"}})
+	if err != nil {
+		t.Fatalf("FetchPodVolumes: %v", err)
+	}
+	if vols[0].StorageClass != "gp3" || vols[1].StorageClass != "" {
+		t.Errorf("storage classes = %q, %q; want gp3 on the claim only", vols[0].StorageClass, vols[1].StorageClass)
+	}
+	if _, err := FetchPodVolumes(context.Background(), cs, "shop", "missing", nil); err == nil {
+		t.Error("missing pod: want an error")
+	}
+}
+
+func TestParseMountInfo(t *testing.T) {
+	data := []byte(`2360 2100 0:412 / / rw,relatime master:804 - overlay overlay rw,lowerdir=/l
+2371 2360 8:16 /pods/x/volumes/kubernetes.io~csi/pvc-1/mount /var/lib/db rw,relatime - ext4 /dev/sdb rw
+2375 2360 0:30 /my\040dir /mnt/my\040dir rw - tmpfs tmpfs rw
+garbage
+`)
+	got := parseMountInfo(data)
+	if got[2360] != "/" || got[2371] != "/var/lib/db" || got[2375] != "/mnt/my dir" || len(got) != 3 {
+		t.Errorf("mounts = %v", got)
+	}
+}
+
+func TestMountedVolumes(t *testing.T) {
+	cgroupBase, procBase := t.TempDir(), t.TempDir()
+	origCgroup, origProc := config.CgroupBasePath, config.ProcBasePath
+	config.SetCgroupBasePath(cgroupBase)
+	config.SetProcBasePath(procBase)
+	sysfs.ResetForTesting()
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.SetCgroupBasePath(origCgroup)
+		config.SetProcBasePath(origProc)
+		sysfs.ResetForTesting()
+		procfs.ResetForTesting()
+	})
+
+	cgroup := filepath.Join(cgroupBase, "pod", "db")
+	if err := os.MkdirAll(cgroup, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cgroup, "cgroup.procs"), []byte("4312\n4100\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(procBase, "4100"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mountinfo := "10 1 0:40 / / rw - overlay overlay rw\n11 10 8:16 / /var/lib/db rw - ext4 /dev/sdb rw\n12 10 0:41 / /proc rw - proc proc rw\n13 10 0:42 / /tmp rw - tmpfs tmpfs rw\n"
+	if err := os.WriteFile(filepath.Join(procBase, "4100", "mountinfo"), []byte(mountinfo), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	vols := podVolumes(volumeTestPod(), []ContainerTarget{{Name: "db"}})
+	got, err := MountedVolumes("db", cgroup, vols)
+	if err != nil {
+		t.Fatalf("MountedVolumes: %v", err)
+	}
+	if got[10].Kind != VolumeRootfs || got[11].Name != "data" || got[13].Name != "scratch" || len(got) != 3 {
+		t.Errorf("mounted volumes = %+v", got)
+	}
+
+	if _, err := MountedVolumes("db", filepath.Join(cgroupBase, "gone"), vols); err == nil {
+		t.Error("empty cgroup: want an error")
+	}
+}
@@ -2,6 +2,8 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"os"
 	"time"
 
@@ -15,22 +17,81 @@ import (
 var (
 	log         *zap.Logger
 	atomicLevel zap.AtomicLevel
+	logFile     *rotatingFile
+	// extraSinks receive a JSON copy of every entry on top of the configured
+	// destination; see AddSink.
+	extraSinks []zapcore.WriteSyncer
 )
 
 func init() {
 	level := getLogLevel()
 	atomicLevel = zap.NewAtomicLevelAt(level)
+	log = newLogger(zapcore.NewJSONEncoder(newEncoderConfig()), zapcore.AddSync(os.Stderr))
+}
+
+func newEncoderConfig() zapcore.EncoderConfig {
 	encoderConfig := zap.NewProductionEncoderConfig()
 	encoderConfig.EncodeTime = zapcore.ISO8601TimeEncoder
 	encoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+	return encoderConfig
+}
 
-	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderConfig),
-		zapcore.AddSync(os.Stderr),
-		atomicLevel,
-	)
+func newLogger(encoder zapcore.Encoder, sink zapcore.WriteSyncer) *zap.Logger {
+	core := zapcore.NewCore(encoder, sink, atomicLevel)
+	for _, extra := range extraSinks {
+		core = zapcore.NewTee(core, extraCore(extra))
+	}
+	return zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+}
+
+func extraCore(sink zapcore.WriteSyncer) zapcore.Core {
+	return zapcore.NewCore(zapcore.NewJSONEncoder(newEncoderConfig()), sink, atomicLevel)
+}
+
+// AddSink additionally writes every log entry, JSON-encoded, to w, whatever
+// the format and destination chosen by Configure. It is how the run's logs
+// end up in an incident bundle.
+func AddSink(w io.Writer) {
+	sink := zapcore.AddSync(w)
+	extraSinks = append(extraSinks, sink)
+	log = log.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return zapcore.NewTee(core, extraCore(sink))
+	}))
+}
 
-	log = zap.New(core, zap.AddCaller(), zap.AddStacktrace(zapcore.ErrorLevel))
+// Configure swaps the log encoder and destination. format is "json" (the
+// default) or "console"; a non-empty path routes logs to a size-rotated file
+// instead of stderr so the terminal (and redirected stdout exports) carry
+// only the report.
+func Configure(format, path string) error {
+	var encoder zapcore.Encoder
+	switch format {
+	case "", "json":
+		encoder = zapcore.NewJSONEncoder(newEncoderConfig())
+	case "console":
+		encoder = zapcore.NewConsoleEncoder(newEncoderConfig())
+	default:
+		return fmt.Errorf("invalid log format %q (valid: json, console)", format)
+	}
+
+	sink := zapcore.AddSync(os.Stderr)
+	var next *rotatingFile
+	if path != "" {
+		f, err := newRotatingFile(path, int64(config.LogMaxSizeMB)*config.MB, config.LogMaxBackups)
+		if err != nil {
+			return err
+		}
+		next = f
+		sink = f
+	}
+
+	_ = log.Sync()
+	log = newLogger(encoder, sink)
+	if logFile != nil {
+		_ = logFile.Close()
+	}
+	logFile = next
+	return nil
 }
 
 func getLogLevel() zapcore.Level {
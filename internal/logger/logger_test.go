@@ -1,9 +1,11 @@
 package logger
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"go.uber.org/zap"
@@ -136,3 +138,24 @@ func TestParseLogLevel(t *testing.T) {
 }
 
 
+
+func TestAddSink(t *testing.T) {
+	origLog, origSinks := log, extraSinks
+	t.Cleanup(func() { log, extraSinks = origLog, origSinks })
+
+	var buf bytes.Buffer
+	AddSink(&buf)
+	Info("bundled entry")
+	if !strings.Contains(buf.String(), `"msg":"bundled entry"`) {
+		t.Errorf("sink got %q", buf.String())
+	}
+
+	if err := Configure("console", ""); err != nil {
+		t.Fatal(err)
+	}
+	buf.Reset()
+	Info("after configure")
+	if !strings.Contains(buf.String(), `"msg":"after configure"`) {
+		t.Errorf("sink lost by Configure, got %q", buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestRotatingFile_RotatesAndCapsBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "podtrace.log")
+	r, err := newRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("newRotatingFile: %v", err)
+	}
+	defer func() { _ = r.Close() }()
+
+	for _, line := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := r.Write([]byte(line)); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+	}
+
+	cur, _ := os.ReadFile(path)
+	if string(cur) != "dddddddd\n" {
+		t.Errorf("current file = %q, want the newest line", cur)
+	}
+	b1, _ := os.ReadFile(path + ".1")
+	if string(b1) != "cccccccc\n" {
+		t.Errorf("backup .1 = %q", b1)
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 2 backups, found %s.3", path)
+	}
+}
+
+func TestConfigure_RoutesLogsToFile(t *testing.T) {
+	orig := log
+	t.Cleanup(func() {
+		log = orig
+		if logFile != nil {
+			_ = logFile.Close()
+			logFile = nil
+		}
+	})
+
+	path := filepath.Join(t.TempDir(), "out.log")
+	if err := Configure("console", path); err != nil {
+		t.Fatalf("Configure: %v", err)
+	}
+	Info("routed to file", zap.String("k", "v"))
+	Sync()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log file: %v", err)
+	}
+	if !strings.Contains(string(data), "routed to file") {
+		t.Errorf("log file missing message: %q", data)
+	}
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		t.Errorf("console format should not emit JSON: %q", data)
+	}
+}
+
+func TestConfigure_RejectsUnknownFormat(t *testing.T) {
+	if err := Configure("xml", ""); err == nil {
+		t.Fatal("expected error for unknown log format")
+	}
+}
@@ -0,0 +1,251 @@
+package metricsexporter
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+// ErrUnauthorized is returned by an Authenticator that refuses a scrape.
+var ErrUnauthorized = errors.New("unauthorized")
+
+// Authenticator decides who may scrape the metrics endpoint. It returns the
+// namespaces the caller may see, nil for every namespace, or an error to
+// refuse the request.
+type Authenticator interface {
+	Authenticate(r *http.Request) (namespaces []string, err error)
+}
+
+// ServerOptions configure the metrics server. OptionsFromConfig reads them
+// from the PODTRACE_METRICS_* settings.
+type ServerOptions struct {
+	Addr string
+	// Auth, when set, must accept every request.
+	Auth Authenticator
+	// TLS, when set, serves HTTPS; with client certificates required it is
+	// mTLS.
+	TLS *tls.Config
+	// Namespaces limits the series served to these namespaces; empty
+	// serves all of them. Series without a namespace label are always
+	// served.
+	Namespaces []string
+}
+
+// authenticated reports whether o keeps anonymous clients out, which is
+// what lets the server bind beyond the loopback interface.
+func (o ServerOptions) authenticated() bool {
+	return o.Auth != nil || (o.TLS != nil && o.TLS.ClientAuth == tls.RequireAndVerifyClientCert)
+}
+
+// OptionsFromConfig builds the server options from the bind address,
+// bearer token file, TLS and namespace settings.
+func OptionsFromConfig() (ServerOptions, error) {
+	opts := ServerOptions{Addr: config.GetMetricsAddress()}
+	for _, ns := range strings.Split(config.MetricsNamespaces, ",") {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			opts.Namespaces = append(opts.Namespaces, ns)
+		}
+	}
+	if config.MetricsBearerTokenFile != "" {
+		auth, err := LoadBearerTokens(config.MetricsBearerTokenFile)
+		if err != nil {
+			return ServerOptions{}, err
+		}
+		opts.Auth = auth
+	}
+	if config.MetricsTLSCert != "" || config.MetricsTLSKey != "" {
+		cert, err := tls.LoadX509KeyPair(config.MetricsTLSCert, config.MetricsTLSKey)
+		if err != nil {
+			return ServerOptions{}, fmt.Errorf("load metrics TLS certificate: %w", err)
+		}
+		opts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+	if config.MetricsClientCA != "" {
+		if opts.TLS == nil {
+			return ServerOptions{}, errors.New("metrics client CA needs a TLS certificate and key")
+		}
+		pem, err := os.ReadFile(config.MetricsClientCA)
+		if err != nil {
+			return ServerOptions{}, fmt.Errorf("read metrics client CA: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return ServerOptions{}, fmt.Errorf("metrics client CA %s holds no PEM certificate", config.MetricsClientCA)
+		}
+		opts.TLS.ClientCAs = pool
+		opts.TLS.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return opts, nil
+}
+
+// BearerTokens accepts requests carrying one of its tokens in an
+// Authorization: Bearer header.
+type BearerTokens struct {
+	tokens []bearerToken
+}
+
+type bearerToken struct {
+	token      []byte
+	namespaces []string
+}
+
+// LoadBearerTokens reads a token file: one token per line, optionally
+// followed by the comma-separated namespaces it may see. Blank lines and
+// lines starting with # are skipped.
+func LoadBearerTokens(path string) (*BearerTokens, error) {
+	data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied token file
+	if err != nil {
+		return nil, fmt.Errorf("read metrics bearer token file: %w", err)
+	}
+	b := &BearerTokens{}
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		t := bearerToken{token: []byte(fields[0])}
+		if len(fields) > 1 {
+			for _, ns := range strings.Split(fields[1], ",") {
+				if ns != "" {
+					t.namespaces = append(t.namespaces, ns)
+				}
+			}
+		}
+		b.tokens = append(b.tokens, t)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read metrics bearer token file: %w", err)
+	}
+	if len(b.tokens) == 0 {
+		return nil, fmt.Errorf("metrics bearer token file %s holds no token", path)
+	}
+	return b, nil
+}
+
+// Authenticate implements Authenticator. Every token is compared, so the
+// time taken does not tell which one came close.
+func (b *BearerTokens) Authenticate(r *http.Request) ([]string, error) {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || got == "" {
+		return nil, ErrUnauthorized
+	}
+	var match *bearerToken
+	for i := range b.tokens {
+		if subtle.ConstantTimeCompare([]byte(got), b.tokens[i].token) == 1 && match == nil {
+			match = &b.tokens[i]
+		}
+	}
+	if match == nil {
+		return nil, ErrUnauthorized
+	}
+	return match.namespaces, nil
+}
+
+// exposition serves OpenMetrics to scrapers that ask for it, with the
+// _created series of counters and histograms and exemplars, and the
+// Prometheus text format to the rest.
+var exposition = promhttp.HandlerOpts{
+	EnableOpenMetrics:                   true,
+	EnableOpenMetricsTextCreatedSamples: true,
+}
+
+// metricsHandler serves the default registry to callers opts.Auth accepts,
+// limited to the namespaces both the options and the caller allow.
+func metricsHandler(opts ServerOptions) http.Handler {
+	all := promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer, promhttp.HandlerFor(prometheus.DefaultGatherer, exposition))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var scope []string
+		if opts.Auth != nil {
+			ns, err := opts.Auth.Authenticate(r)
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="podtrace"`)
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			scope = ns
+		}
+		allowed, limited := intersectNamespaces(opts.Namespaces, scope)
+		if !limited {
+			all.ServeHTTP(w, r)
+			return
+		}
+		promhttp.HandlerFor(namespaceGatherer{g: prometheus.DefaultGatherer, allowed: allowed}, exposition).ServeHTTP(w, r)
+	})
+}
+
+// intersectNamespaces combines two namespace lists, each empty for all
+// namespaces. limited is false when the result is every namespace.
+func intersectNamespaces(a, b []string) (allowed map[string]struct{}, limited bool) {
+	if len(a) == 0 && len(b) == 0 {
+		return nil, false
+	}
+	if len(a) == 0 {
+		a, b = b, nil
+	}
+	allowed = make(map[string]struct{}, len(a))
+	for _, ns := range a {
+		allowed[ns] = struct{}{}
+	}
+	if len(b) > 0 {
+		inB := make(map[string]struct{}, len(b))
+		for _, ns := range b {
+			inB[ns] = struct{}{}
+		}
+		for ns := range allowed {
+			if _, ok := inB[ns]; !ok {
+				delete(allowed, ns)
+			}
+		}
+	}
+	return allowed, true
+}
+
+// namespaceGatherer drops the series whose namespace label is not
+// allowed. Series without one, such as the process and drop counters,
+// pass.
+type namespaceGatherer struct {
+	g       prometheus.Gatherer
+	allowed map[string]struct{}
+}
+
+func (n namespaceGatherer) Gather() ([]*dto.MetricFamily, error) {
+	mfs, err := n.g.Gather()
+	out := mfs[:0]
+	for _, mf := range mfs {
+		kept := mf.Metric[:0]
+		for _, m := range mf.Metric {
+			if n.keep(m) {
+				kept = append(kept, m)
+			}
+		}
+		if len(kept) > 0 {
+			mf.Metric = kept
+			out = append(out, mf)
+		}
+	}
+	return out, err
+}
+
+func (n namespaceGatherer) keep(m *dto.Metric) bool {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == "namespace" {
+			_, ok := n.allowed[l.GetValue()]
+			return ok
+		}
+	}
+	return true
+}
@@ -0,0 +1,153 @@
+package metricsexporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+func writeTokenFile(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "tokens")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBearerTokens(t *testing.T) {
+	auth, err := LoadBearerTokens(writeTokenFile(t, "# scrapers\nadmin-token\n\nteam-a-token team-a,shared\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		header string
+		ns     []string
+		ok     bool
+	}{
+		{"Bearer admin-token", nil, true},
+		{"Bearer team-a-token", []string{"team-a", "shared"}, true},
+		{"Bearer wrong", nil, false},
+		{"Basic admin-token", nil, false},
+		{"", nil, false},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if tt.header != "" {
+			r.Header.Set("Authorization", tt.header)
+		}
+		ns, err := auth.Authenticate(r)
+		if (err == nil) != tt.ok || strings.Join(ns, ",") != strings.Join(tt.ns, ",") {
+			t.Errorf("%q: namespaces %v, err %v", tt.header, ns, err)
+		}
+	}
+
+	if _, err := LoadBearerTokens(writeTokenFile(t, "# none\n")); err == nil {
+		t.Error("expected an error for a file without tokens")
+	}
+}
+
+func TestMetricsHandler_AuthAndNamespaces(t *testing.T) {
+	probe := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "podtrace_auth_test_probe", Help: "test"}, []string{"namespace"})
+	prometheus.MustRegister(probe)
+	t.Cleanup(func() { prometheus.Unregister(probe) })
+	for _, ns := range []string{"team-a", "team-b", "shared"} {
+		probe.WithLabelValues(ns).Set(1)
+	}
+	auth, err := LoadBearerTokens(writeTokenFile(t, "admin\nteam-a team-a,shared\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scrape := func(h http.Handler, token string) (int, string) {
+		r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		if token != "" {
+			r.Header.Set("Authorization", "Bearer "+token)
+		}
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		return rr.Code, rr.Body.String()
+	}
+	has := func(body, ns string) bool {
+		return strings.Contains(body, `podtrace_auth_test_probe{namespace="`+ns+`"}`)
+	}
+
+	h := metricsHandler(ServerOptions{Auth: auth})
+	if code, _ := scrape(h, ""); code != http.StatusUnauthorized {
+		t.Errorf("anonymous scrape: %d", code)
+	}
+	if _, body := scrape(h, "admin"); !has(body, "team-a") || !has(body, "team-b") || !has(body, "shared") {
+		t.Errorf("admin scrape misses namespaces:\n%s", body)
+	}
+	if _, body := scrape(h, "team-a"); !has(body, "team-a") || has(body, "team-b") || !has(body, "shared") {
+		t.Errorf("team-a scrape not limited to team-a,shared:\n%s", body)
+	}
+
+	// The server-wide filter narrows every caller.
+	h = metricsHandler(ServerOptions{Auth: auth, Namespaces: []string{"team-a", "team-b"}})
+	if _, body := scrape(h, "team-a"); !has(body, "team-a") || has(body, "team-b") || has(body, "shared") {
+		t.Errorf("team-a scrape under the server filter:\n%s", body)
+	}
+	if _, body := scrape(metricsHandler(ServerOptions{Namespaces: []string{"team-b"}}), ""); has(body, "team-a") || !has(body, "team-b") {
+		t.Errorf("unauthenticated namespace filter:\n%s", body)
+	}
+}
+
+func TestAuthOnly_RefusesNamespacedTokens(t *testing.T) {
+	auth, err := LoadBearerTokens(writeTokenFile(t, "admin\nteam-a team-a\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := authOnly(auth, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for token, want := range map[string]int{"admin": http.StatusOK, "team-a": http.StatusUnauthorized, "": http.StatusUnauthorized} {
+		r := httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, r)
+		if rr.Code != want {
+			t.Errorf("token %q: %d, want %d", token, rr.Code, want)
+		}
+	}
+}
+
+func TestOptionsFromConfig(t *testing.T) {
+	orig := [...]string{config.MetricsBearerTokenFile, config.MetricsTLSCert, config.MetricsTLSKey, config.MetricsClientCA, config.MetricsNamespaces}
+	t.Cleanup(func() {
+		config.MetricsBearerTokenFile, config.MetricsTLSCert, config.MetricsTLSKey, config.MetricsClientCA, config.MetricsNamespaces =
+			orig[0], orig[1], orig[2], orig[3], orig[4]
+	})
+	t.Setenv("PODTRACE_METRICS_ADDR", "0.0.0.0:9102")
+	config.MetricsBearerTokenFile = writeTokenFile(t, "admin\n")
+	config.MetricsNamespaces = " team-a, ,team-b"
+
+	opts, err := OptionsFromConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.Addr != "0.0.0.0:9102" || strings.Join(opts.Namespaces, ",") != "team-a,team-b" || !opts.authenticated() {
+		t.Errorf("options = %+v", opts)
+	}
+
+	config.MetricsClientCA = filepath.Join(t.TempDir(), "ca.pem")
+	if _, err := OptionsFromConfig(); err == nil || !strings.Contains(err.Error(), "needs a TLS certificate") {
+		t.Errorf("client CA without a certificate: %v", err)
+	}
+}
+
+func TestNewServer_AllowsNonLoopbackWithAuth(t *testing.T) {
+	auth, err := LoadBearerTokens(writeTokenFile(t, "admin\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(ServerOptions{Addr: "0.0.0.0:0", Auth: auth})
+	defer srv.Shutdown()
+	if srv.server.Addr != "0.0.0.0:0" {
+		t.Errorf("authenticated server moved to %q", srv.server.Addr)
+	}
+}
@@ -8,12 +8,16 @@ import (
 
 func TestRecordFilteredEventDrop_IncrementsCounter(t *testing.T) {
 	before := testutil.ToFloat64(filteredEventDropsCounter)
+	total := FilteredEventDrops()
 	RecordFilteredEventDrop()
 	RecordFilteredEventDrop()
 	after := testutil.ToFloat64(filteredEventDropsCounter)
 	if after-before != 2 {
 		t.Errorf("filteredEventDropsCounter delta = %v, want 2", after-before)
 	}
+	if got := FilteredEventDrops() - total; got != 2 {
+		t.Errorf("FilteredEventDrops delta = %d, want 2", got)
+	}
 }
 
 func TestRecordFilteredEventDrop_DoesNotTouchRingBufferCounter(t *testing.T) {
@@ -0,0 +1,95 @@
+package metricsexporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func scrapeOpenMetrics(t *testing.T) string {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	r.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	rr := httptest.NewRecorder()
+	metricsHandler(ServerOptions{}).ServeHTTP(rr, r)
+	if ct := rr.Header().Get("Content-Type"); !strings.HasPrefix(ct, "application/openmetrics-text") {
+		t.Fatalf("Content-Type = %q, want OpenMetrics", ct)
+	}
+	return rr.Body.String()
+}
+
+func TestMetricsHandler_OpenMetrics(t *testing.T) {
+	SetTargetPods([]PodMetadata{{Namespace: "om-test", Pod: "api-0", UID: "uid-1", Node: "node-a", Container: "api", OwnerKind: "StatefulSet", OwnerName: "api", Runtime: "containerd"}})
+	t.Cleanup(func() { SetTargetPods(nil) })
+	HandleEventWithContext(&events.Event{Type: events.EventConnect, ProcessName: "om-proc", LatencyNS: 2e6}, map[string]interface{}{"namespace": "om-test"})
+	RecordAttribution("event_comm", "other")
+	HandleEventWithContext(&events.Event{Type: events.EventDNS, ProcessName: "om-proc", LatencyNS: 1e6}, map[string]interface{}{"namespace": "om-test"})
+	HandleEventWithContext(&events.Event{
+		Type: events.EventGRPCMethod, ProcessName: "om-proc", Target: "/om.Svc/Get", LatencyNS: 3e6,
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7",
+	}, map[string]interface{}{"namespace": "om-test"})
+
+	body := scrapeOpenMetrics(t)
+	for _, want := range []string{
+		"# UNIT podtrace_latency_seconds seconds",
+		"# TYPE podtrace_dns_latency_seconds histogram",
+		"# TYPE podtrace_dns_latency_latest_seconds gauge",
+		"# UNIT podtrace_dns_latency_latest_seconds seconds",
+		`podtrace_attribution_created{event="other",source="event_comm"}`,
+		`podtrace_latency_seconds_created{namespace="om-test",process_name="om-proc"`,
+		`podtrace_pod_info{container="api",namespace="om-test",node="node-a",owner_kind="StatefulSet",owner_name="api",pod="api-0",runtime="containerd",uid="uid-1"} 1`,
+		`trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`,
+		"# EOF",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("OpenMetrics exposition misses %s", want)
+		}
+	}
+	for _, old := range []string{"podtrace_dns_latency_seconds_gauge", "podtrace_dns_latency_seconds_histogram"} {
+		if strings.Contains(body, "# UNIT "+old) {
+			t.Errorf("deprecated %s declares a unit its name does not end in", old)
+		}
+	}
+}
+
+func TestSetTargetPods_ReplacesSeries(t *testing.T) {
+	t.Cleanup(func() { SetTargetPods(nil) })
+	SetTargetPods([]PodMetadata{{Namespace: "a", Pod: "p1"}, {Namespace: "a", Pod: "p2"}})
+	SetTargetPods([]PodMetadata{{Namespace: "a", Pod: "p2"}})
+	if got := testutil.CollectAndCount(podInfoGauge); got != 1 {
+		t.Errorf("podtrace_pod_info has %d series, want the one pod still traced", got)
+	}
+}
+
+func TestExportResourceMetrics_Ratio(t *testing.T) {
+	ExportResourceMetrics("memory", "ratio-test", 1000, 920, 92, 2)
+	if got := testutil.ToFloat64(resourceUtilizationGauge.WithLabelValues("memory", "ratio-test")); got != 0.92 {
+		t.Errorf("utilization = %v, want 0.92", got)
+	}
+}
+
+func TestLegacyNames_MirrorRenamedSeries(t *testing.T) {
+	HandleEventWithContext(&events.Event{Type: events.EventDNS, ProcessName: "legacy-proc", LatencyNS: 5e6}, map[string]interface{}{"namespace": "legacy-test"})
+	ExportResourceMetrics("memory", "legacy-test", 1000, 920, 92, 2)
+
+	if got := testutil.ToFloat64(legacyDNSGauge.WithLabelValues("DNS", "legacy-proc", "legacy-test")); got != 0.005 {
+		t.Errorf("podtrace_dns_latency_seconds_gauge = %v, want 0.005", got)
+	}
+	if got := testutil.ToFloat64(legacyResourceUtilizationGauge.WithLabelValues("memory", "legacy-test")); got != 92 {
+		t.Errorf("podtrace_resource_utilization_percent = %v, want the old 0-100 scale", got)
+	}
+	body := scrapeOpenMetrics(t)
+	for _, want := range []string{
+		`podtrace_dns_latency_seconds_histogram_count{namespace="legacy-test",process_name="legacy-proc",type="DNS"} 1`,
+		`podtrace_dns_latency_seconds_count{namespace="legacy-test",process_name="legacy-proc",type="DNS"} 1`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape lacks %q", want)
+		}
+	}
+}
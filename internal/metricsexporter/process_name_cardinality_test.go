@@ -37,6 +37,7 @@ func TestProcessNameCardinalityBounded(t *testing.T) {
 		events.EventGRPCMethod,
 		events.EventKafkaProduce,
 		events.EventKafkaFetch,
+		events.EventKafkaOp,
 	}
 
 	for i := 0; i < distinctNames; i++ {
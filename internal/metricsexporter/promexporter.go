@@ -9,6 +9,7 @@ import (
 	"runtime/debug"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
@@ -18,7 +19,6 @@ import (
 	"github.com/podtrace/podtrace/internal/events"
 	"github.com/podtrace/podtrace/internal/logger"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type EventType uint32
@@ -27,6 +27,7 @@ var (
 	rttHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_rtt_seconds",
+			Unit:    "seconds",
 			Help:    "RTT observed by Podtrace.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
 		},
@@ -36,6 +37,7 @@ var (
 	latencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Latency observed by Podtrace.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
 		},
@@ -44,14 +46,16 @@ var (
 
 	dnsGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "podtrace_dns_latency_seconds_gauge",
+			Name: "podtrace_dns_latency_latest_seconds",
+			Unit: "seconds",
 			Help: "Latest DNS query latency per process.",
 		},
 		[]string{"type", "process_name", "namespace"},
 	)
 	dnsHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "podtrace_dns_latency_seconds_histogram",
+			Name:    "podtrace_dns_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of DNS query latencies per process.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
 		},
@@ -60,14 +64,16 @@ var (
 
 	fsGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "podtrace_fs_latency_seconds_gauge",
+			Name: "podtrace_fs_latency_latest_seconds",
+			Unit: "seconds",
 			Help: "Latest file system operation latency per process.",
 		},
 		[]string{"type", "process_name", "namespace"},
 	)
 	fsHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "podtrace_fs_latency_seconds_histogram",
+			Name:    "podtrace_fs_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of file system latencies per process and type.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
 		},
@@ -76,14 +82,16 @@ var (
 
 	cpuGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "podtrace_cpu_block_seconds_gauge",
+			Name: "podtrace_cpu_block_latest_seconds",
+			Unit: "seconds",
 			Help: "Latest CPU block time per process.",
 		},
 		[]string{"type", "process_name", "namespace"},
 	)
 	cpuHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
-			Name:    "podtrace_cpu_block_seconds_histogram",
+			Name:    "podtrace_cpu_block_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of CPU block times per process.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
 		},
@@ -92,6 +100,7 @@ var (
 	rttGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "podtrace_rtt_latest_seconds",
+			Unit: "seconds",
 			Help: "Most recent RTT observed by Podtrace.",
 		},
 		[]string{"type", "process_name", "namespace", "target_pod", "target_service"},
@@ -100,6 +109,7 @@ var (
 	latencyGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "podtrace_latency_latest_seconds",
+			Unit: "seconds",
 			Help: "Most recent latency observed by Podtrace.",
 		},
 		[]string{"type", "process_name", "namespace", "target_pod", "target_service"},
@@ -108,14 +118,32 @@ var (
 	networkBytesCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "podtrace_network_bytes_total",
+			Unit: "bytes",
 			Help: "Total bytes transferred over network (TCP/UDP send/receive). Use rate() to get bytes/second.",
 		},
 		[]string{"type", "process_name", "direction", "namespace", "target_pod", "target_service"},
 	)
 
+	connectsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "podtrace_connects_total",
+			Help: "Successful TCP connects. Divided by podtrace_tcp_sends_total, the share of sends that needed a fresh connection.",
+		},
+		[]string{"process_name", "namespace", "target_pod", "target_service"},
+	)
+
+	tcpSendsCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "podtrace_tcp_sends_total",
+			Help: "TCP send operations.",
+		},
+		[]string{"process_name", "namespace", "target_pod", "target_service"},
+	)
+
 	filesystemBytesCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "podtrace_filesystem_bytes_total",
+			Unit: "bytes",
 			Help: "Total bytes transferred via filesystem operations (read/write). Use rate() to get bytes/second.",
 		},
 		[]string{"type", "process_name", "operation", "namespace"},
@@ -142,6 +170,14 @@ var (
 		},
 	)
 
+	tracingSpansDroppedCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "podtrace_tracing_spans_dropped_total",
+			Help: "Total spans a tracing exporter dropped, by exporter and reason (queue_full, export_failed, shutdown).",
+		},
+		[]string{"exporter", "reason"},
+	)
+
 	processCacheHitsCounter = prometheus.NewCounter(
 		prometheus.CounterOpts{
 			Name: "podtrace_process_cache_hits_total",
@@ -173,6 +209,7 @@ var (
 	eventProcessingLatencyHistogram = prometheus.NewHistogram(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_event_processing_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Time taken to process events from ring buffer to event channel.",
 			Buckets: prometheus.ExponentialBuckets(0.000001, 2, 20),
 		},
@@ -211,6 +248,7 @@ var (
 	tlsGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "podtrace_tls_handshake_latency_latest_seconds",
+			Unit: "seconds",
 			Help: "Latest TLS handshake latency per process.",
 		},
 		[]string{"type", "process_name", "namespace"},
@@ -219,6 +257,7 @@ var (
 	tlsHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_tls_handshake_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of TLS handshake latencies per process.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
 		},
@@ -233,6 +272,7 @@ var (
 		[]string{"type", "process_name", "namespace"},
 	)
 
+	// The resource gauges carry no unit: for cpu they hold microseconds.
 	resourceLimitBytesGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "podtrace_resource_limit_bytes",
@@ -249,10 +289,11 @@ var (
 		[]string{"resource_type", "namespace"},
 	)
 
-	resourceUtilizationPercentGauge = prometheus.NewGaugeVec(
+	resourceUtilizationGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "podtrace_resource_utilization_percent",
-			Help: "Resource utilization percentage (usage/limit * 100).",
+			Name: "podtrace_resource_utilization_ratio",
+			Unit: "ratio",
+			Help: "Resource utilization as usage/limit; above 1 the limit is exceeded.",
 		},
 		[]string{"resource_type", "namespace"},
 	)
@@ -292,6 +333,7 @@ var (
 	poolWaitTimeHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_pool_wait_seconds",
+			Unit:    "seconds",
 			Help:    "Time waiting for connection during pool exhaustion.",
 			Buckets: prometheus.ExponentialBuckets(0.001, 2, 15),
 		},
@@ -308,8 +350,9 @@ var (
 
 	poolUtilizationGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
-			Name: "podtrace_pool_utilization_percent",
-			Help: "Pool utilization percentage (current/max * 100).",
+			Name: "podtrace_pool_utilization_ratio",
+			Unit: "ratio",
+			Help: "Pool utilization as current/max connections.",
 		},
 		[]string{"pool_id", "process_name", "namespace"},
 	)
@@ -325,6 +368,7 @@ var (
 	bpfMapUtilizationGauge = prometheus.NewGaugeVec(
 		prometheus.GaugeOpts{
 			Name: "podtrace_bpf_map_utilization_ratio",
+			Unit: "ratio",
 			Help: "Fill ratio of BPF hash maps (0.0–1.0). Values near 1.0 indicate map pressure.",
 		},
 		[]string{"map"},
@@ -333,6 +377,7 @@ var (
 	redisLatencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_redis_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of Redis command latencies.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 15),
 		},
@@ -342,6 +387,7 @@ var (
 	memcachedLatencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_memcached_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of Memcached operation latencies.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 15),
 		},
@@ -351,6 +397,7 @@ var (
 	fastcgiLatencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_fastcgi_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of FastCGI request latencies.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 15),
 		},
@@ -360,6 +407,7 @@ var (
 	grpcLatencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_grpc_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of gRPC method call latencies.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 15),
 		},
@@ -369,6 +417,7 @@ var (
 	kafkaLatencyHistogram = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "podtrace_kafka_latency_seconds",
+			Unit:    "seconds",
 			Help:    "Distribution of Kafka produce/consume latencies.",
 			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 15),
 		},
@@ -378,6 +427,7 @@ var (
 	kafkaBytesCounter = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "podtrace_kafka_bytes_total",
+			Unit: "bytes",
 			Help: "Total bytes in Kafka produce/consume operations.",
 		},
 		[]string{"operation", "topic", "process_name", "namespace"},
@@ -407,6 +457,80 @@ var (
 		},
 		[]string{"pod_ip", "profile_type"},
 	)
+
+	issueScoreGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "podtrace_issue_score",
+			Help: "Score (0-100) of the last detection of each issue, by issue code.",
+		},
+		[]string{"code", "rule", "namespace"},
+	)
+
+	podInfoGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "podtrace_pod_info",
+			Help: "Metadata of each traced pod; always 1. Join on namespace and pod to label other series.",
+		},
+		[]string{"namespace", "pod", "uid", "node", "container", "owner_kind", "owner_name", "runtime"},
+	)
+)
+
+// The series below carry the names used before the OpenMetrics renames and
+// are exported next to the new ones while config.MetricsLegacyNames is set.
+// They go away in the next release.
+var (
+	legacyDNSGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "podtrace_dns_latency_seconds_gauge",
+			Help: "Deprecated: use podtrace_dns_latency_latest_seconds.",
+		},
+		[]string{"type", "process_name", "namespace"},
+	)
+	legacyDNSHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "podtrace_dns_latency_seconds_histogram",
+			Help:    "Deprecated: use podtrace_dns_latency_seconds.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
+		},
+		[]string{"type", "process_name", "namespace"},
+	)
+	legacyFSGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "podtrace_fs_latency_seconds_gauge",
+			Help: "Deprecated: use podtrace_fs_latency_latest_seconds.",
+		},
+		[]string{"type", "process_name", "namespace"},
+	)
+	legacyFSHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "podtrace_fs_latency_seconds_histogram",
+			Help:    "Deprecated: use podtrace_fs_latency_seconds.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
+		},
+		[]string{"type", "process_name", "namespace"},
+	)
+	legacyCPUGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "podtrace_cpu_block_seconds_gauge",
+			Help: "Deprecated: use podtrace_cpu_block_latest_seconds.",
+		},
+		[]string{"type", "process_name", "namespace"},
+	)
+	legacyCPUHistogram = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "podtrace_cpu_block_seconds_histogram",
+			Help:    "Deprecated: use podtrace_cpu_block_seconds.",
+			Buckets: prometheus.ExponentialBuckets(0.0001, 2, 20),
+		},
+		[]string{"type", "process_name", "namespace"},
+	)
+	legacyResourceUtilizationGauge = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "podtrace_resource_utilization_percent",
+			Help: "Deprecated: use podtrace_resource_utilization_ratio, which is 0-1 instead of 0-100.",
+		},
+		[]string{"resource_type", "namespace"},
+	)
 )
 
 func init() {
@@ -422,10 +546,13 @@ func init() {
 	prometheus.MustRegister(fsGauge)
 	prometheus.MustRegister(cpuGauge)
 	prometheus.MustRegister(networkBytesCounter)
+	prometheus.MustRegister(connectsCounter)
+	prometheus.MustRegister(tcpSendsCounter)
 	prometheus.MustRegister(filesystemBytesCounter)
 	prometheus.MustRegister(ringBufferDropsCounter)
 	prometheus.MustRegister(dnsDropsCounter)
 	prometheus.MustRegister(filteredEventDropsCounter)
+	prometheus.MustRegister(tracingSpansDroppedCounter)
 	prometheus.MustRegister(processCacheHitsCounter)
 	prometheus.MustRegister(processCacheMissesCounter)
 	prometheus.MustRegister(pidCacheHitsCounter)
@@ -439,7 +566,7 @@ func init() {
 	prometheus.MustRegister(tlsHandshakesCounter)
 	prometheus.MustRegister(resourceLimitBytesGauge)
 	prometheus.MustRegister(resourceUsageBytesGauge)
-	prometheus.MustRegister(resourceUtilizationPercentGauge)
+	prometheus.MustRegister(resourceUtilizationGauge)
 	prometheus.MustRegister(resourceAlertLevelGauge)
 	prometheus.MustRegister(poolAcquiresCounter)
 	prometheus.MustRegister(poolReleasesCounter)
@@ -458,6 +585,39 @@ func init() {
 	prometheus.MustRegister(profilingGoroutinesGauge)
 	prometheus.MustRegister(profilingAutoTriggersTotal)
 	prometheus.MustRegister(profilingFetchErrorsTotal)
+	prometheus.MustRegister(issueScoreGauge)
+	prometheus.MustRegister(podInfoGauge)
+	if config.MetricsLegacyNames {
+		prometheus.MustRegister(legacyDNSGauge, legacyDNSHistogram, legacyFSGauge, legacyFSHistogram,
+			legacyCPUGauge, legacyCPUHistogram, legacyResourceUtilizationGauge)
+	}
+}
+
+// RecordIssue sets the score of a detected issue. Codes and rules come from
+// the fixed detector rule set, so the labels stay bounded.
+func RecordIssue(code, rule, namespace string, score float64) {
+	issueScoreGauge.WithLabelValues(code, rule, namespace).Set(score)
+}
+
+// PodMetadata is what podtrace_pod_info says about one traced pod.
+type PodMetadata struct {
+	Namespace string
+	Pod       string
+	UID       string
+	Node      string
+	Container string
+	OwnerKind string
+	OwnerName string
+	Runtime   string
+}
+
+// SetTargetPods replaces the podtrace_pod_info series with one per traced
+// pod, so pods that left the target set stop being reported.
+func SetTargetPods(pods []PodMetadata) {
+	podInfoGauge.Reset()
+	for _, p := range pods {
+		podInfoGauge.WithLabelValues(p.Namespace, p.Pod, p.UID, p.Node, p.Container, p.OwnerKind, p.OwnerName, p.Runtime).Set(1)
+	}
 }
 
 // RecordProfilingGoroutines records goroutine counts from the last pprof fetch.
@@ -562,9 +722,13 @@ func HandleEventWithContext(e *events.Event, k8sContext map[string]interface{})
 	switch e.Type {
 	case events.EventConnect:
 		ExportTCPMetricWithContext(e, namespace, targetPod, targetService)
+		if e.Error == 0 {
+			connectsCounter.WithLabelValues(boundProcessName(e), namespace, targetPod, targetService).Inc()
+		}
 
 	case events.EventTCPSend:
 		ExportRTTMetricWithContext(e, namespace, targetPod, targetService)
+		tcpSendsCounter.WithLabelValues(boundProcessName(e), namespace, targetPod, targetService).Inc()
 		ExportNetworkBandwidthMetricWithContext(e, "send", namespace, targetPod, targetService)
 
 	case events.EventTCPRecv:
@@ -615,7 +779,7 @@ func HandleEventWithContext(e *events.Event, k8sContext map[string]interface{})
 		if cmd == "" {
 			cmd = "unknown"
 		}
-		redisLatencyHistogram.WithLabelValues(cmd, boundProcessName(e), namespace).Observe(latSec)
+		observe(redisLatencyHistogram.WithLabelValues(cmd, boundProcessName(e), namespace), latSec, e)
 
 	case events.EventMemcachedCmd:
 		latSec := float64(e.LatencyNS) / 1e9
@@ -623,7 +787,7 @@ func HandleEventWithContext(e *events.Event, k8sContext map[string]interface{})
 		if op == "" {
 			op = "unknown"
 		}
-		memcachedLatencyHistogram.WithLabelValues(op, boundProcessName(e), namespace).Observe(latSec)
+		observe(memcachedLatencyHistogram.WithLabelValues(op, boundProcessName(e), namespace), latSec, e)
 
 	case events.EventFastCGIResp:
 		latSec := float64(e.LatencyNS) / 1e9
@@ -631,7 +795,7 @@ func HandleEventWithContext(e *events.Event, k8sContext map[string]interface{})
 		if method == "" {
 			method = "unknown"
 		}
-		fastcgiLatencyHistogram.WithLabelValues(method, boundProcessName(e), namespace).Observe(latSec)
+		observe(fastcgiLatencyHistogram.WithLabelValues(method, boundProcessName(e), namespace), latSec, e)
 
 	case events.EventGRPCMethod:
 		latSec := float64(e.LatencyNS) / 1e9
@@ -639,7 +803,7 @@ func HandleEventWithContext(e *events.Event, k8sContext map[string]interface{})
 		if method == "" {
 			method = "unknown"
 		}
-		grpcLatencyHistogram.WithLabelValues(method, boundProcessName(e), namespace).Observe(latSec)
+		observe(grpcLatencyHistogram.WithLabelValues(method, boundProcessName(e), namespace), latSec, e)
 
 	case events.EventKafkaProduce:
 		latSec := float64(e.LatencyNS) / 1e9
@@ -648,7 +812,7 @@ func HandleEventWithContext(e *events.Event, k8sContext map[string]interface{})
 			topic = "unknown"
 		}
 		procName := boundProcessName(e)
-		kafkaLatencyHistogram.WithLabelValues("produce", topic, procName, namespace).Observe(latSec)
+		observe(kafkaLatencyHistogram.WithLabelValues("produce", topic, procName, namespace), latSec, e)
 		if e.Bytes > 0 {
 			kafkaBytesCounter.WithLabelValues("produce", topic, procName, namespace).Add(float64(e.Bytes))
 		}
@@ -660,10 +824,20 @@ func HandleEventWithContext(e *events.Event, k8sContext map[string]interface{})
 			topic = "unknown"
 		}
 		procName := boundProcessName(e)
-		kafkaLatencyHistogram.WithLabelValues("fetch", topic, procName, namespace).Observe(latSec)
+		observe(kafkaLatencyHistogram.WithLabelValues("fetch", topic, procName, namespace), latSec, e)
 		if e.Bytes > 0 {
 			kafkaBytesCounter.WithLabelValues("fetch", topic, procName, namespace).Add(float64(e.Bytes))
 		}
+
+	case events.EventKafkaOp:
+		// Wire-level request/response pair; Target is the API name and Bytes
+		// the request size, so only the latency is recorded.
+		latSec := float64(e.LatencyNS) / 1e9
+		topic := topicCardinality.bound(e.Details)
+		if topic == "" {
+			topic = "unknown"
+		}
+		observe(kafkaLatencyHistogram.WithLabelValues(kafkaOpLabel(e.Target), topic, boundProcessName(e), namespace), latSec, e)
 	}
 }
 
@@ -684,7 +858,7 @@ func ExportRTTMetric(e *events.Event) {
 func ExportRTTMetricWithContext(e *events.Event, namespace, targetPod, targetService string) {
 	rttSec := float64(e.LatencyNS) / 1e9
 	procName := boundProcessName(e)
-	rttHistogram.WithLabelValues(e.TypeString(), procName, namespace, targetPod, targetService).Observe(rttSec)
+	observe(rttHistogram.WithLabelValues(e.TypeString(), procName, namespace, targetPod, targetService), rttSec, e)
 	rttGauge.WithLabelValues(e.TypeString(), procName, namespace, targetPod, targetService).Set(rttSec)
 }
 
@@ -695,7 +869,7 @@ func ExportTCPMetric(e *events.Event) {
 func ExportTCPMetricWithContext(e *events.Event, namespace, targetPod, targetService string) {
 	latencySec := float64(e.LatencyNS) / 1e9
 	procName := boundProcessName(e)
-	latencyHistogram.WithLabelValues(e.TypeString(), procName, namespace, targetPod, targetService).Observe(latencySec)
+	observe(latencyHistogram.WithLabelValues(e.TypeString(), procName, namespace, targetPod, targetService), latencySec, e)
 	latencyGauge.WithLabelValues(e.TypeString(), procName, namespace, targetPod, targetService).Set(latencySec)
 }
 
@@ -707,7 +881,11 @@ func ExportDNSMetricWithContext(e *events.Event, namespace string) {
 	latencySec := float64(e.LatencyNS) / 1e9
 	procName := boundProcessName(e)
 	dnsGauge.WithLabelValues(e.TypeString(), procName, namespace).Set(latencySec)
-	dnsHistogram.WithLabelValues(e.TypeString(), procName, namespace).Observe(latencySec)
+	observe(dnsHistogram.WithLabelValues(e.TypeString(), procName, namespace), latencySec, e)
+	if config.MetricsLegacyNames {
+		legacyDNSGauge.WithLabelValues(e.TypeString(), procName, namespace).Set(latencySec)
+		legacyDNSHistogram.WithLabelValues(e.TypeString(), procName, namespace).Observe(latencySec)
+	}
 }
 
 func ExportFileSystemMetric(e *events.Event) {
@@ -718,7 +896,11 @@ func ExportFileSystemMetricWithContext(e *events.Event, namespace string) {
 	latencySec := float64(e.LatencyNS) / 1e9
 	procName := boundProcessName(e)
 	fsGauge.WithLabelValues(e.TypeString(), procName, namespace).Set(latencySec)
-	fsHistogram.WithLabelValues(e.TypeString(), procName, namespace).Observe(latencySec)
+	observe(fsHistogram.WithLabelValues(e.TypeString(), procName, namespace), latencySec, e)
+	if config.MetricsLegacyNames {
+		legacyFSGauge.WithLabelValues(e.TypeString(), procName, namespace).Set(latencySec)
+		legacyFSHistogram.WithLabelValues(e.TypeString(), procName, namespace).Observe(latencySec)
+	}
 }
 
 func ExportSchedSwitchMetric(e *events.Event) {
@@ -729,7 +911,11 @@ func ExportSchedSwitchMetricWithContext(e *events.Event, namespace string) {
 	blockSec := float64(e.LatencyNS) / 1e9
 	procName := boundProcessName(e)
 	cpuGauge.WithLabelValues(e.TypeString(), procName, namespace).Set(blockSec)
-	cpuHistogram.WithLabelValues(e.TypeString(), procName, namespace).Observe(blockSec)
+	observe(cpuHistogram.WithLabelValues(e.TypeString(), procName, namespace), blockSec, e)
+	if config.MetricsLegacyNames {
+		legacyCPUGauge.WithLabelValues(e.TypeString(), procName, namespace).Set(blockSec)
+		legacyCPUHistogram.WithLabelValues(e.TypeString(), procName, namespace).Observe(blockSec)
+	}
 }
 
 func ExportNetworkBandwidthMetric(e *events.Event, direction string) {
@@ -752,8 +938,13 @@ func ExportFilesystemBandwidthMetricWithContext(e *events.Event, operation, name
 	}
 }
 
+// Running totals of the drop counters, for the report's data-confidence
+// grades; Prometheus counters cannot be read back.
+var ringBufferDrops, filteredEventDrops, dnsDrops atomic.Uint64
+
 func RecordRingBufferDrop() {
 	ringBufferDropsCounter.Inc()
+	ringBufferDrops.Add(1)
 }
 
 // RecordFilteredEventDrop counts an event dropped because the userspace
@@ -761,14 +952,35 @@ func RecordRingBufferDrop() {
 // a kernel ring-buffer drop and must not inflate that metric.
 func RecordFilteredEventDrop() {
 	filteredEventDropsCounter.Inc()
+	filteredEventDrops.Add(1)
 }
 
 func AddDNSDrops(delta uint64) {
 	if delta > 0 {
 		dnsDropsCounter.Add(float64(delta))
+		dnsDrops.Add(delta)
 	}
 }
 
+// AddTracingSpansDropped counts spans a tracing exporter gave up on.
+// Exporter names and reasons come from fixed sets, so the labels stay
+// bounded.
+func AddTracingSpansDropped(exporter, reason string, spans int) {
+	if spans > 0 {
+		tracingSpansDroppedCounter.WithLabelValues(exporter, reason).Add(float64(spans))
+	}
+}
+
+// RingBufferDrops returns how many ring buffer reads failed since start.
+func RingBufferDrops() uint64 { return ringBufferDrops.Load() }
+
+// FilteredEventDrops returns how many events were dropped on the full
+// filtered-event channel since start.
+func FilteredEventDrops() uint64 { return filteredEventDrops.Load() }
+
+// DNSDrops returns how many DNS records the probes dropped since start.
+func DNSDrops() uint64 { return dnsDrops.Load() }
+
 func RecordProcessCacheHit() {
 	processCacheHitsCounter.Inc()
 }
@@ -866,15 +1078,34 @@ type Server struct {
 	server *http.Server
 }
 
+// StartServer starts the metrics server with the options from the
+// configuration. A configuration it cannot load is logged and nothing is
+// served, rather than serving without the protection asked for.
 func StartServer() *Server {
+	opts, err := OptionsFromConfig()
+	if err != nil {
+		logger.Error("Metrics server not started", zap.Error(err))
+		return &Server{}
+	}
+	return NewServer(opts)
+}
+
+// NewServer starts serving /metrics, and /debug/pprof when enabled, with
+// opts. A listen failure is logged, as the metrics are not worth stopping
+// the trace for.
+func NewServer(opts ServerOptions) *Server {
+	protect := func(h http.Handler) http.Handler {
+		return securityHeadersMiddleware(rateLimitMiddleware(h))
+	}
 	mux := http.NewServeMux()
-	mux.Handle("/metrics", securityHeadersMiddleware(rateLimitMiddleware(promhttp.Handler())))
+	mux.Handle("/metrics", protect(metricsHandler(opts)))
 	if config.MetricsEnablePprof() {
 		// pprof goes through the same security/rate-limit middleware as
 		// /metrics: a 30-second CPU profile request is a cheap DoS against
-		// a privileged pod when the handlers are exposed raw.
+		// a privileged pod when the handlers are exposed raw. It is not
+		// namespaced, so a token limited to some namespaces cannot use it.
 		wrap := func(h http.HandlerFunc) http.Handler {
-			return securityHeadersMiddleware(rateLimitMiddleware(h))
+			return protect(authOnly(opts.Auth, h))
 		}
 		mux.Handle("/debug/pprof/", wrap(pprofhttp.Index))
 		mux.Handle("/debug/pprof/cmdline", wrap(pprofhttp.Cmdline))
@@ -883,10 +1114,12 @@ func StartServer() *Server {
 		mux.Handle("/debug/pprof/trace", wrap(pprofhttp.Trace))
 	}
 
-	addr := config.GetMetricsAddress()
-
-	if !addrIsLoopback(addr) && !config.AllowNonLoopbackMetrics() {
-		logger.Warn("Rejecting non-loopback metrics address, falling back to default",
+	addr := opts.Addr
+	if addr == "" {
+		addr = config.GetMetricsAddress()
+	}
+	if !addrIsLoopback(addr) && !opts.authenticated() && !config.AllowNonLoopbackMetrics() {
+		logger.Warn("Rejecting non-loopback metrics address without authentication, falling back to default",
 			zap.String("requested_addr", addr),
 			zap.String("fallback", fmt.Sprintf("%s:%d", config.DefaultMetricsHost, config.DefaultMetricsPort)))
 		addr = config.DefaultMetricsHost + ":" + fmt.Sprintf("%d", config.DefaultMetricsPort)
@@ -895,10 +1128,10 @@ func StartServer() *Server {
 	server := &http.Server{
 		Addr:         addr,
 		Handler:      mux,
+		TLSConfig:    opts.TLS,
 		ReadTimeout:  config.DefaultMetricsReadTimeout,
 		WriteTimeout: config.DefaultMetricsWriteTimeout,
 	}
-
 	srv := &Server{server: server}
 
 	go func() {
@@ -913,7 +1146,13 @@ func StartServer() *Server {
 			logger.Info("Prometheus metrics server includes /debug/pprof (PODTRACE_METRICS_ENABLE_PPROF=1)",
 				zap.String("addr", addr))
 		}
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if opts.TLS != nil {
+			err = server.ListenAndServeTLS("", "")
+		} else {
+			err = server.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			logger.Error("Metrics server error", zap.Error(err))
 		}
 	}()
@@ -921,6 +1160,21 @@ func StartServer() *Server {
 	return srv
 }
 
+// authOnly refuses requests auth does not accept, or that it limits to
+// some namespaces.
+func authOnly(auth Authenticator, next http.Handler) http.Handler {
+	if auth == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ns, err := auth.Authenticate(r); err != nil || ns != nil {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 func (s *Server) Shutdown() {
 	if s.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), config.DefaultMetricsShutdownTimeout)
@@ -937,7 +1191,7 @@ func ExportTLSMetricWithContext(e *events.Event, namespace string) {
 	latencySec := float64(e.LatencyNS) / 1e9
 	procName := boundProcessName(e)
 	tlsGauge.WithLabelValues(e.TypeString(), procName, namespace).Set(latencySec)
-	tlsHistogram.WithLabelValues(e.TypeString(), procName, namespace).Observe(latencySec)
+	observe(tlsHistogram.WithLabelValues(e.TypeString(), procName, namespace), latencySec, e)
 	tlsHandshakesCounter.WithLabelValues(e.TypeString(), procName, namespace).Inc()
 }
 
@@ -980,7 +1234,10 @@ func ExportResourceLimitMetricWithContext(e *events.Event, namespace string) {
 	// Set metrics
 	resourceLimitBytesGauge.WithLabelValues(resourceTypeLabel, namespace).Set(limitBytes)
 	resourceUsageBytesGauge.WithLabelValues(resourceTypeLabel, namespace).Set(usageBytes)
-	resourceUtilizationPercentGauge.WithLabelValues(resourceTypeLabel, namespace).Set(utilization)
+	resourceUtilizationGauge.WithLabelValues(resourceTypeLabel, namespace).Set(utilization / 100)
+	if config.MetricsLegacyNames {
+		legacyResourceUtilizationGauge.WithLabelValues(resourceTypeLabel, namespace).Set(utilization)
+	}
 
 	// Determine alert level
 	var alertLevel float64
@@ -1001,15 +1258,52 @@ func ExportResourceLimitMetricWithContext(e *events.Event, namespace string) {
 func ExportResourceMetrics(resourceType string, namespace string, limitBytes, usageBytes uint64, utilizationPercent float64, alertLevel uint32) {
 	resourceLimitBytesGauge.WithLabelValues(resourceType, namespace).Set(float64(limitBytes))
 	resourceUsageBytesGauge.WithLabelValues(resourceType, namespace).Set(float64(usageBytes))
-	resourceUtilizationPercentGauge.WithLabelValues(resourceType, namespace).Set(utilizationPercent)
+	resourceUtilizationGauge.WithLabelValues(resourceType, namespace).Set(utilizationPercent / 100)
+	if config.MetricsLegacyNames {
+		legacyResourceUtilizationGauge.WithLabelValues(resourceType, namespace).Set(utilizationPercent)
+	}
 	resourceAlertLevelGauge.WithLabelValues(resourceType, namespace).Set(float64(alertLevel))
 }
 
+// maxExemplarIDRunes keeps an exemplar's trace and span ids inside the 128
+// runes OpenMetrics allows for its labels; ObserveWithExemplar panics past
+// that.
+const maxExemplarIDRunes = 96
+
+// observe records v for the event. Once the tracing manager has joined the
+// event to a trace, the trace is the observation's exemplar, which an
+// OpenMetrics scrape carries so a slow bucket links to a trace behind it.
+func observe(o prometheus.Observer, v float64, e *events.Event) {
+	if e.TraceID != "" && len(e.TraceID)+len(e.SpanID) <= maxExemplarIDRunes {
+		if eo, ok := o.(prometheus.ExemplarObserver); ok {
+			labels := prometheus.Labels{"trace_id": e.TraceID}
+			if e.SpanID != "" {
+				labels["span_id"] = e.SpanID
+			}
+			eo.ObserveWithExemplar(v, labels)
+			return
+		}
+	}
+	o.Observe(v)
+}
+
 // boundProcessName caps process_name label cardinality.
 func boundProcessName(e *events.Event) string {
 	return processCardinality.bound(e.ProcessName)
 }
 
+// kafkaOpLabel maps the API name of a wire-level Kafka event to the
+// operation label, kept apart from the librdkafka "produce"/"fetch" calls
+// because a request is not the same thing as an enqueue or a poll.
+func kafkaOpLabel(api string) string {
+	switch api {
+	case "Produce", "Fetch", "Metadata":
+		return strings.ToLower(api) + "_request"
+	default:
+		return "unknown"
+	}
+}
+
 // poolLabels resolves the (bounded) pool_id and process_name label values so
 // arbitrary pool identifiers and process names cannot grow series without
 // bound, matching how every other traffic-derived label is capped.
@@ -1035,5 +1329,5 @@ func ExportPoolExhaustedMetricWithContext(e *events.Event, namespace string) {
 	poolID, processName := poolLabels(e)
 	waitTimeSec := float64(e.LatencyNS) / 1e9
 	poolExhaustedCounter.WithLabelValues(poolID, processName, namespace).Inc()
-	poolWaitTimeHistogram.WithLabelValues(poolID, processName, namespace).Observe(waitTimeSec)
+	observe(poolWaitTimeHistogram.WithLabelValues(poolID, processName, namespace), waitTimeSec, e)
 }
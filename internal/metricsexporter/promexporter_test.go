@@ -449,6 +449,8 @@ func TestHandleEventWithContext_AllEventTypes(t *testing.T) {
 		{Type: events.EventKafkaProduce, LatencyNS: 5_000_000, Details: "", Bytes: 0, ProcessName: "p"},
 		{Type: events.EventKafkaFetch, LatencyNS: 3_000_000, Details: "my-topic", Bytes: 512, ProcessName: "p"},
 		{Type: events.EventKafkaFetch, LatencyNS: 3_000_000, Details: "", Bytes: 0, ProcessName: "p"},
+		{Type: events.EventKafkaOp, LatencyNS: 4_000_000, Target: "Produce", Details: "my-topic", Bytes: 1024, ProcessName: "p"},
+		{Type: events.EventKafkaOp, LatencyNS: 4_000_000, Target: "Metadata", Details: "", ProcessName: "p"},
 	}
 
 	for _, e := range testEvents {
@@ -457,6 +459,19 @@ func TestHandleEventWithContext_AllEventTypes(t *testing.T) {
 	}
 }
 
+func TestKafkaOpLabel(t *testing.T) {
+	for api, want := range map[string]string{
+		"Produce":  "produce_request",
+		"Fetch":    "fetch_request",
+		"Metadata": "metadata_request",
+		"tgt":      "unknown",
+	} {
+		if got := kafkaOpLabel(api); got != want {
+			t.Errorf("kafkaOpLabel(%q) = %q, want %q", api, got, want)
+		}
+	}
+}
+
 func TestHandleEventWithContext_Nil(t *testing.T) {
 	HandleEventWithContext(nil, nil) // must not panic
 }
@@ -561,3 +576,22 @@ func TestAddDNSDrops(t *testing.T) {
 		t.Errorf("dnsDropsCounter delta = %v, want 3", after-before)
 	}
 }
+
+func TestHandleEventWithContext_ConnectsAndSends(t *testing.T) {
+	ctx := map[string]interface{}{"namespace": "churn-ns", "target_service": "api"}
+	connects := connectsCounter.WithLabelValues("c", "churn-ns", "", "api")
+	sends := tcpSendsCounter.WithLabelValues("c", "churn-ns", "", "api")
+	c0, s0 := testutil.ToFloat64(connects), testutil.ToFloat64(sends)
+
+	HandleEventWithContext(&events.Event{Type: events.EventConnect, ProcessName: "c"}, ctx)
+	HandleEventWithContext(&events.Event{Type: events.EventConnect, ProcessName: "c", Error: -111}, ctx)
+	HandleEventWithContext(&events.Event{Type: events.EventTCPSend, ProcessName: "c"}, ctx)
+	HandleEventWithContext(&events.Event{Type: events.EventTCPSend, ProcessName: "c"}, ctx)
+
+	if got := testutil.ToFloat64(connects) - c0; got != 1 {
+		t.Errorf("connects delta = %v, want 1 (failed connects excluded)", got)
+	}
+	if got := testutil.ToFloat64(sends) - s0; got != 2 {
+		t.Errorf("sends delta = %v, want 2", got)
+	}
+}
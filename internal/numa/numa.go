@@ -0,0 +1,168 @@
+// Package numa reads where traced processes are allowed to run, where their
+// memory lives and how long they wait for a CPU, so the report can tell when
+// CPU pinning or NUMA placement, rather than the application, explains
+// latency.
+package numa
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// sysDevicesSystem is where the CPU and NUMA topology is read from; tests
+// point it at a fixture. The topology is not namespaced, so the container's
+// /sys shows the node's.
+var sysDevicesSystem = "/sys/devices/system"
+
+// CPUSet is a sorted set of CPU (or NUMA node) numbers.
+type CPUSet []int
+
+// ParseList parses a kernel list such as "0-3,8,10-11".
+func ParseList(s string) (CPUSet, error) {
+	var out CPUSet
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return out, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(lo)
+		if err != nil {
+			return nil, fmt.Errorf("invalid list %q: %w", s, err)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(hi); err != nil || last < first {
+				return nil, fmt.Errorf("invalid range %q in list %q", part, s)
+			}
+		}
+		for n := first; n <= last; n++ {
+			out = append(out, n)
+		}
+	}
+	sort.Ints(out)
+	return out, nil
+}
+
+// String renders the set in the kernel's list format.
+func (c CPUSet) String() string {
+	var parts []string
+	for i := 0; i < len(c); {
+		j := i
+		for j+1 < len(c) && c[j+1] == c[j]+1 {
+			j++
+		}
+		if j == i {
+			parts = append(parts, strconv.Itoa(c[i]))
+		} else {
+			parts = append(parts, strconv.Itoa(c[i])+"-"+strconv.Itoa(c[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ",")
+}
+
+// Contains reports whether n is in the set.
+func (c CPUSet) Contains(n int) bool {
+	i := sort.SearchInts(c, n)
+	return i < len(c) && c[i] == n
+}
+
+// Topology is the node's NUMA layout.
+type Topology struct {
+	// Nodes maps each NUMA node to its CPUs.
+	Nodes map[int]CPUSet
+	// Siblings maps each CPU to the hardware threads of its core, itself
+	// included.
+	Siblings map[int]CPUSet
+	// Online is every online CPU.
+	Online CPUSet
+}
+
+// ReadTopology reads the NUMA nodes and SMT siblings of the node. A kernel
+// without NUMA support reports everything as node 0.
+func ReadTopology() (*Topology, error) {
+	onlineData, err := os.ReadFile(filepath.Join(sysDevicesSystem, "cpu", "online"))
+	if err != nil {
+		return nil, err
+	}
+	online, err := ParseList(string(onlineData))
+	if err != nil {
+		return nil, err
+	}
+	topo := &Topology{Nodes: make(map[int]CPUSet), Siblings: make(map[int]CPUSet), Online: online}
+
+	nodeDirs, _ := filepath.Glob(filepath.Join(sysDevicesSystem, "node", "node[0-9]*"))
+	for _, dir := range nodeDirs {
+		id, err := strconv.Atoi(strings.TrimPrefix(filepath.Base(dir), "node"))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "cpulist")) // #nosec G304 -- sysfs node directory.
+		if err != nil {
+			continue
+		}
+		if cpus, err := ParseList(string(data)); err == nil {
+			topo.Nodes[id] = cpus
+		}
+	}
+	if len(topo.Nodes) == 0 {
+		topo.Nodes[0] = online
+	}
+
+	for _, cpu := range online {
+		path := filepath.Join(sysDevicesSystem, "cpu", "cpu"+strconv.Itoa(cpu), "topology", "thread_siblings_list")
+		data, err := os.ReadFile(path) // #nosec G304 -- sysfs CPU topology attribute.
+		if err != nil {
+			continue
+		}
+		if sib, err := ParseList(string(data)); err == nil {
+			topo.Siblings[cpu] = sib
+		}
+	}
+	return topo, nil
+}
+
+// NodeOf returns the NUMA node of cpu, or -1.
+func (t *Topology) NodeOf(cpu int) int {
+	for node, cpus := range t.Nodes {
+		if cpus.Contains(cpu) {
+			return node
+		}
+	}
+	return -1
+}
+
+// NodesOf returns the NUMA nodes the CPUs of set belong to.
+func (t *Topology) NodesOf(set CPUSet) CPUSet {
+	seen := make(map[int]bool)
+	var out CPUSet
+	for _, cpu := range set {
+		if node := t.NodeOf(cpu); node >= 0 && !seen[node] {
+			seen[node] = true
+			out = append(out, node)
+		}
+	}
+	sort.Ints(out)
+	return out
+}
+
+// SharedCores returns the CPUs of set whose core has a hardware thread
+// outside set: whatever runs there competes for the core's execution units
+// and caches.
+func (t *Topology) SharedCores(set CPUSet) CPUSet {
+	var out CPUSet
+	for _, cpu := range set {
+		for _, sib := range t.Siblings[cpu] {
+			if !set.Contains(sib) {
+				out = append(out, cpu)
+				break
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+package numa
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+func writeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// fakeTopology lays out two nodes of two cores with two threads each:
+// node0 = cpus 0,1,4,5 and node1 = cpus 2,3,6,7, with siblings n and n+4.
+func fakeTopology(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	old := sysDevicesSystem
+	sysDevicesSystem = dir
+	t.Cleanup(func() { sysDevicesSystem = old })
+
+	writeFile(t, filepath.Join(dir, "cpu", "online"), "0-7\n")
+	writeFile(t, filepath.Join(dir, "node", "node0", "cpulist"), "0-1,4-5\n")
+	writeFile(t, filepath.Join(dir, "node", "node1", "cpulist"), "2-3,6-7\n")
+	writeFile(t, filepath.Join(dir, "node", "node0", "numastat"), "numa_hit 100\nlocal_node 90\nother_node 10\n")
+	writeFile(t, filepath.Join(dir, "node", "node1", "numastat"), "numa_hit 50\nlocal_node 20\nother_node 30\n")
+	for cpu := 0; cpu < 8; cpu++ {
+		sib := []string{"0,4", "1,5", "2,6", "3,7"}[cpu%4]
+		writeFile(t, filepath.Join(dir, "cpu", "cpu"+strconv.Itoa(cpu), "topology", "thread_siblings_list"), sib+"\n")
+	}
+}
+
+func TestParseList(t *testing.T) {
+	got, err := ParseList("0-3,8,10-11\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (CPUSet{0, 1, 2, 3, 8, 10, 11}); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseList = %v, want %v", got, want)
+	}
+	if s := got.String(); s != "0-3,8,10-11" {
+		t.Errorf("String() = %q", s)
+	}
+	for _, bad := range []string{"a", "3-1", "1-x"} {
+		if _, err := ParseList(bad); err == nil {
+			t.Errorf("ParseList(%q) accepted", bad)
+		}
+	}
+}
+
+func TestReadPlacement(t *testing.T) {
+	fakeTopology(t)
+	topo, err := ReadTopology()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	proc := t.TempDir()
+	old := config.ProcBasePath
+	config.SetProcBasePath(proc)
+	procfs.ResetForTesting()
+	t.Cleanup(func() {
+		config.SetProcBasePath(old)
+		procfs.ResetForTesting()
+	})
+	writeFile(t, filepath.Join(proc, "42", "status"),
+		"Name:\tapp\nCpus_allowed_list:\t2-3\nMems_allowed_list:\t0-1\n")
+	writeFile(t, filepath.Join(proc, "42", "numa_maps"),
+		"7f00 default anon=10 dirty=10 N0=6 N1=4 kernelpagesize_kB=4\n"+
+			"7f10 default file=/lib/libc.so mapped=2 N1=2 kernelpagesize_kB=2048\n")
+	writeFile(t, filepath.Join(proc, "42", "stat"),
+		"42 (my app) S 1 42 42 0 -1 4194560 0 0 0 0 0 0 0 0 20 0 1 0 100 0 0 0 0 0 0 0 0 0 0 0 0 0 0 0 17 3 0 0\n")
+	writeFile(t, filepath.Join(proc, "42", "schedstat"), "5000 1200 30\n")
+
+	p, err := ReadPlacement(42, topo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.Pinned || p.CPUs.String() != "2-3" || p.CPUNodes.String() != "1" || p.Mems.String() != "0-1" {
+		t.Errorf("placement = %+v", p)
+	}
+	// CPUs 2 and 3 share their cores with 6 and 7.
+	if p.SharedCores.String() != "2-3" {
+		t.Errorf("shared cores = %v", p.SharedCores)
+	}
+	if p.MemoryByNode[0] != 6*4096 || p.MemoryByNode[1] != 4*4096+2*2048*1024 {
+		t.Errorf("memory = %v", p.MemoryByNode)
+	}
+	if p.RemoteMemory() != 6*4096 {
+		t.Errorf("remote memory = %d", p.RemoteMemory())
+	}
+	if p.LastCPU != 3 || p.RunDelayNS != 1200 {
+		t.Errorf("last cpu %d, run delay %d", p.LastCPU, p.RunDelayNS)
+	}
+
+	allocs, ok := ReadNodeAllocations()
+	if !ok || allocs.Local != 110 || allocs.Remote != 40 {
+		t.Errorf("allocations = %+v, %v", allocs, ok)
+	}
+}
+
+func TestDetailsRoundTrip(t *testing.T) {
+	s := Sample{
+		Placement: Placement{
+			CPUs: CPUSet{2, 3}, CPUNodes: CPUSet{1}, Mems: CPUSet{0, 1}, Pinned: true,
+			SharedCores: CPUSet{2, 3}, MemoryByNode: map[int]uint64{0: 4096, 1: 8192}, LastCPU: 3,
+		},
+		IntervalNS:     10_000_000_000,
+		RunQueueNS:     1_500_000_000,
+		Allocations:    NodeAllocations{Local: 90, Remote: 10},
+		HasAllocations: true,
+	}
+	got := ParseDetails(FormatDetails(s))
+	if !reflect.DeepEqual(got, s) {
+		t.Fatalf("round trip = %+v, want %+v", got, s)
+	}
+	if got := ParseDetails(""); got.LastCPU != -1 || got.Pinned {
+		t.Errorf("empty details = %+v", got)
+	}
+}
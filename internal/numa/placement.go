@@ -0,0 +1,315 @@
+package numa
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/procfs"
+)
+
+// Placement is where a process may run and where its memory is.
+type Placement struct {
+	// CPUs is the process's Cpus_allowed_list; CPUNodes their NUMA nodes.
+	CPUs     CPUSet
+	CPUNodes CPUSet
+	// Mems is the Mems_allowed_list.
+	Mems CPUSet
+	// Pinned is true when CPUs excludes some online CPU (a cpuset or
+	// sched_setaffinity restriction, such as the static CPU manager's).
+	Pinned bool
+	// SharedCores are the CPUs of a pinned process whose core it shares
+	// with CPUs outside its set.
+	SharedCores CPUSet
+	// MemoryByNode is the process's resident memory per NUMA node, in
+	// bytes, from numa_maps.
+	MemoryByNode map[int]uint64
+	// LastCPU is the CPU the process last ran on.
+	LastCPU int
+	// RunDelayNS is the process's total time spent runnable but waiting
+	// for a CPU, from schedstat.
+	RunDelayNS uint64
+}
+
+// RemoteMemory returns the bytes resident on nodes none of the process's
+// CPUs belong to.
+func (p *Placement) RemoteMemory() uint64 {
+	var remote uint64
+	for node, b := range p.MemoryByNode {
+		if !p.CPUNodes.Contains(node) {
+			remote += b
+		}
+	}
+	return remote
+}
+
+// TotalMemory returns the bytes resident on any node.
+func (p *Placement) TotalMemory() uint64 {
+	var total uint64
+	for _, b := range p.MemoryByNode {
+		total += b
+	}
+	return total
+}
+
+// ReadPlacement reads the placement of pid from /proc against topo.
+func ReadPlacement(pid uint32, topo *Topology) (*Placement, error) {
+	status, err := procfs.ReadFile(fmt.Sprintf("%d/status", pid))
+	if err != nil {
+		return nil, err
+	}
+	p := &Placement{LastCPU: -1}
+	for _, line := range strings.Split(string(status), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Cpus_allowed_list":
+			p.CPUs, err = ParseList(value)
+		case "Mems_allowed_list":
+			p.Mems, err = ParseList(value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(p.CPUs) == 0 {
+		return nil, fmt.Errorf("pid %d: no Cpus_allowed_list", pid)
+	}
+	p.CPUNodes = topo.NodesOf(p.CPUs)
+	for _, cpu := range topo.Online {
+		if !p.CPUs.Contains(cpu) {
+			p.Pinned = true
+			break
+		}
+	}
+	if p.Pinned {
+		p.SharedCores = topo.SharedCores(p.CPUs)
+	}
+
+	// numa_maps is missing on kernels without NUMA; the rest still helps.
+	if data, err := procfs.ReadFile(fmt.Sprintf("%d/numa_maps", pid)); err == nil {
+		p.MemoryByNode = parseNumaMaps(string(data))
+	}
+	if data, err := procfs.ReadFile(fmt.Sprintf("%d/stat", pid)); err == nil {
+		p.LastCPU = parseStatProcessor(string(data))
+	}
+	if data, err := procfs.ReadFile(fmt.Sprintf("%d/schedstat", pid)); err == nil {
+		if f := strings.Fields(string(data)); len(f) >= 2 {
+			p.RunDelayNS, _ = strconv.ParseUint(f[1], 10, 64)
+		}
+	}
+	return p, nil
+}
+
+// parseNumaMaps sums the N<node>=<pages> counts of every mapping, scaled by
+// the mapping's page size.
+func parseNumaMaps(data string) map[int]uint64 {
+	out := make(map[int]uint64)
+	for _, line := range strings.Split(data, "\n") {
+		pageSize := uint64(4096)
+		counts := make(map[int]uint64)
+		for _, f := range strings.Fields(line) {
+			k, v, ok := strings.Cut(f, "=")
+			if !ok {
+				continue
+			}
+			if k == "kernelpagesize_kB" {
+				if kb, err := strconv.ParseUint(v, 10, 64); err == nil {
+					pageSize = kb * 1024
+				}
+				continue
+			}
+			if len(k) < 2 || k[0] != 'N' {
+				continue
+			}
+			node, err := strconv.Atoi(k[1:])
+			if err != nil {
+				continue
+			}
+			if pages, err := strconv.ParseUint(v, 10, 64); err == nil {
+				counts[node] += pages
+			}
+		}
+		for node, pages := range counts {
+			out[node] += pages * pageSize
+		}
+	}
+	return out
+}
+
+// parseStatProcessor returns field 39 (processor) of /proc/<pid>/stat, or -1.
+func parseStatProcessor(stat string) int {
+	// comm may contain spaces and parentheses; fields resume after the last ')'.
+	i := strings.LastIndexByte(stat, ')')
+	if i < 0 {
+		return -1
+	}
+	f := strings.Fields(stat[i+1:])
+	const processorField = 39 - 3
+	if len(f) <= processorField {
+		return -1
+	}
+	cpu, err := strconv.Atoi(f[processorField])
+	if err != nil {
+		return -1
+	}
+	return cpu
+}
+
+// NodeAllocations are the node-wide page allocation counters of numastat,
+// summed over every node.
+type NodeAllocations struct {
+	// Local pages were allocated on the node the allocating task ran on.
+	Local uint64
+	// Remote pages were allocated on another node than the task's.
+	Remote uint64
+}
+
+// ReadNodeAllocations sums local_node and other_node over every NUMA node.
+// It returns false on kernels without NUMA statistics.
+func ReadNodeAllocations() (NodeAllocations, bool) {
+	var out NodeAllocations
+	files, _ := filepath.Glob(filepath.Join(sysDevicesSystem, "node", "node[0-9]*", "numastat"))
+	if len(files) == 0 {
+		return out, false
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file) // #nosec G304 -- sysfs node statistics.
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			f := strings.Fields(line)
+			if len(f) != 2 {
+				continue
+			}
+			n, err := strconv.ParseUint(f[1], 10, 64)
+			if err != nil {
+				continue
+			}
+			switch f[0] {
+			case "local_node":
+				out.Local += n
+			case "other_node":
+				out.Remote += n
+			}
+		}
+	}
+	return out, true
+}
+
+// Sample is one interval of a process's placement, as carried by an
+// EventCPUPlacement.
+type Sample struct {
+	Placement
+	// IntervalNS is the length of the interval; RunQueueNS is how much of
+	// it the process spent waiting for a CPU.
+	IntervalNS uint64
+	RunQueueNS uint64
+	// Allocations are the node-wide allocation counters over the interval;
+	// HasAllocations is false without NUMA statistics.
+	Allocations    NodeAllocations
+	HasAllocations bool
+}
+
+// Details keys of an EventCPUPlacement.
+const (
+	keyCPUs       = "cpus"
+	keyCPUNodes   = "cpu_nodes"
+	keyMems       = "mems"
+	keyPinned     = "pinned"
+	keyShared     = "shared_cores"
+	keyMemory     = "mem"
+	keyLastCPU    = "last_cpu"
+	keyInterval   = "interval_ns"
+	keyRunQueue   = "runq_ns"
+	keyLocalAlloc = "node_local"
+	keyOtherAlloc = "node_remote"
+)
+
+// FormatDetails encodes s as EventCPUPlacement Details: space-separated
+// key=value pairs, memory as node:bytes pairs.
+func FormatDetails(s Sample) string {
+	parts := []string{
+		keyCPUs + "=" + s.CPUs.String(),
+		keyCPUNodes + "=" + s.CPUNodes.String(),
+		keyMems + "=" + s.Mems.String(),
+		keyPinned + "=" + strconv.FormatBool(s.Pinned),
+		keyShared + "=" + s.SharedCores.String(),
+		keyLastCPU + "=" + strconv.Itoa(s.LastCPU),
+		keyInterval + "=" + strconv.FormatUint(s.IntervalNS, 10),
+		keyRunQueue + "=" + strconv.FormatUint(s.RunQueueNS, 10),
+	}
+	if len(s.MemoryByNode) > 0 {
+		nodes := make([]int, 0, len(s.MemoryByNode))
+		for n := range s.MemoryByNode {
+			nodes = append(nodes, n)
+		}
+		sort.Ints(nodes)
+		mem := make([]string, len(nodes))
+		for i, n := range nodes {
+			mem[i] = strconv.Itoa(n) + ":" + strconv.FormatUint(s.MemoryByNode[n], 10)
+		}
+		parts = append(parts, keyMemory+"="+strings.Join(mem, ","))
+	}
+	if s.HasAllocations {
+		parts = append(parts,
+			keyLocalAlloc+"="+strconv.FormatUint(s.Allocations.Local, 10),
+			keyOtherAlloc+"="+strconv.FormatUint(s.Allocations.Remote, 10))
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseDetails is the inverse of FormatDetails; unknown or malformed keys
+// are skipped.
+func ParseDetails(details string) Sample {
+	s := Sample{Placement: Placement{LastCPU: -1}}
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if !ok {
+			continue
+		}
+		switch k {
+		case keyCPUs:
+			s.CPUs, _ = ParseList(v)
+		case keyCPUNodes:
+			s.CPUNodes, _ = ParseList(v)
+		case keyMems:
+			s.Mems, _ = ParseList(v)
+		case keyPinned:
+			s.Pinned, _ = strconv.ParseBool(v)
+		case keyShared:
+			s.SharedCores, _ = ParseList(v)
+		case keyLastCPU:
+			if cpu, err := strconv.Atoi(v); err == nil {
+				s.LastCPU = cpu
+			}
+		case keyInterval:
+			s.IntervalNS, _ = strconv.ParseUint(v, 10, 64)
+		case keyRunQueue:
+			s.RunQueueNS, _ = strconv.ParseUint(v, 10, 64)
+		case keyMemory:
+			s.MemoryByNode = make(map[int]uint64)
+			for _, pair := range strings.Split(v, ",") {
+				n, b, ok := strings.Cut(pair, ":")
+				node, err1 := strconv.Atoi(n)
+				bytes, err2 := strconv.ParseUint(b, 10, 64)
+				if ok && err1 == nil && err2 == nil {
+					s.MemoryByNode[node] = bytes
+				}
+			}
+		case keyLocalAlloc:
+			s.Allocations.Local, _ = strconv.ParseUint(v, 10, 64)
+			s.HasAllocations = true
+		case keyOtherAlloc:
+			s.Allocations.Remote, _ = strconv.ParseUint(v, 10, 64)
+			s.HasAllocations = true
+		}
+	}
+	return s
+}
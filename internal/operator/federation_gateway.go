@@ -0,0 +1,206 @@
+package operator
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	podtracev1alpha1 "github.com/podtrace/podtrace/api/v1alpha1"
+	"github.com/podtrace/podtrace/internal/federation"
+	"github.com/podtrace/podtrace/internal/validation"
+)
+
+// Labels and annotations of the sessions the federation gateway creates.
+const (
+	ComponentFederated      = "federated-session"
+	AnnotationRequestedBy   = "podtrace.io/requested-by"
+	federatedSessionPrefix  = "federated-"
+	federatedSessionTTL     = int32(3600)
+	federationMaxBodyBytes  = 64 << 10
+	federationShutdownGrace = 5 * time.Second
+)
+
+// FederationGateway serves trace requests from podtrace in other clusters:
+// each accepted request becomes a PodTraceSession here, whose state the
+// requester can then poll. Every request must carry Token as a bearer
+// token.
+type FederationGateway struct {
+	Client client.Client
+	// Cluster names this cluster in the responses.
+	Cluster string
+	Token   string
+	Addr    string
+}
+
+// NeedLeaderElection is false: every replica can serve requests.
+func (g *FederationGateway) NeedLeaderElection() bool { return false }
+
+// Start serves until ctx is done.
+func (g *FederationGateway) Start(ctx context.Context) error {
+	if g.Token == "" {
+		return errors.New("federation gateway: a token is required")
+	}
+	srv := &http.Server{Handler: g, ReadHeaderTimeout: 10 * time.Second}
+	ln, err := net.Listen("tcp", g.Addr)
+	if err != nil {
+		return fmt.Errorf("federation gateway: listen %s: %w", g.Addr, err)
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), federationShutdownGrace)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+	ctrl.Log.WithName("federation").Info("serving federation gateway", "addr", g.Addr)
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (g *FederationGateway) authorized(r *http.Request) bool {
+	got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && g.Token != "" && subtle.ConstantTimeCompare([]byte(got), []byte(g.Token)) == 1
+}
+
+func (g *FederationGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !g.authorized(r) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch rest, _ := strings.CutPrefix(r.URL.Path, federation.SessionsPath); {
+	case rest == "" && r.Method == http.MethodPost:
+		g.create(w, r)
+	case strings.HasPrefix(rest, "/") && r.Method == http.MethodGet:
+		ns, name, ok := strings.Cut(strings.TrimPrefix(rest, "/"), "/")
+		if !ok || ns == "" || name == "" || strings.Contains(name, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		g.status(w, r, ns, name)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (g *FederationGateway) create(w http.ResponseWriter, r *http.Request) {
+	var req federation.TraceRequest
+	body, err := io.ReadAll(io.LimitReader(r.Body, federationMaxBodyBytes))
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	session, err := federatedSession(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := g.Client.Create(r.Context(), session); err != nil {
+		code := http.StatusInternalServerError
+		if apierrors.IsInvalid(err) || apierrors.IsForbidden(err) {
+			code = http.StatusUnprocessableEntity
+		}
+		http.Error(w, err.Error(), code)
+		return
+	}
+	g.respond(w, http.StatusCreated, session)
+}
+
+func (g *FederationGateway) status(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	var session podtracev1alpha1.PodTraceSession
+	if err := g.Client.Get(r.Context(), types.NamespacedName{Namespace: namespace, Name: name}, &session); err != nil {
+		if apierrors.IsNotFound(err) {
+			http.NotFound(w, r)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Only the sessions the gateway created are visible through it.
+	if session.Labels[LabelComponent] != ComponentFederated {
+		http.NotFound(w, r)
+		return
+	}
+	g.respond(w, http.StatusOK, &session)
+}
+
+func (g *FederationGateway) respond(w http.ResponseWriter, code int, session *podtracev1alpha1.PodTraceSession) {
+	out := federation.Session{
+		Cluster:        g.Cluster,
+		Namespace:      session.Namespace,
+		Name:           session.Name,
+		State:          string(session.Status.State),
+		ReportLocation: session.Status.ReportLocation,
+	}
+	for _, c := range session.Status.Conditions {
+		if c.Status == metav1.ConditionFalse && c.Message != "" {
+			out.Message = c.Message
+			break
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(out)
+}
+
+// federatedSession renders the PodTraceSession for req.
+func federatedSession(req federation.TraceRequest) (*podtracev1alpha1.PodTraceSession, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := validation.ValidateNamespace(req.Namespace); err != nil {
+		return nil, fmt.Errorf("invalid namespace: %w", err)
+	}
+	selector, err := metav1.ParseToLabelSelector(req.Selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", req.Selector, err)
+	}
+	var filters []podtracev1alpha1.EventFilter
+	if len(req.Filters) > 0 {
+		if err := validation.ValidateEventFilter(strings.Join(req.Filters, ",")); err != nil {
+			return nil, err
+		}
+		for _, f := range req.Filters {
+			filters = append(filters, podtracev1alpha1.EventFilter(strings.ToLower(f)))
+		}
+	}
+	duration, _ := req.ParsedDuration()
+	ttl := federatedSessionTTL
+	session := &podtracev1alpha1.PodTraceSession{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: federatedSessionPrefix,
+			Namespace:    req.Namespace,
+			Labels: map[string]string{
+				LabelManagedBy: ManagedByValue,
+				LabelComponent: ComponentFederated,
+			},
+		},
+		Spec: podtracev1alpha1.PodTraceSessionSpec{
+			Selector:                selector,
+			Duration:                metav1.Duration{Duration: duration},
+			Filters:                 filters,
+			ExporterRef:             podtracev1alpha1.LocalObjectReference{Name: req.Exporter},
+			TTLSecondsAfterFinished: &ttl,
+		},
+	}
+	if req.RequestedBy != "" {
+		session.Annotations = map[string]string{AnnotationRequestedBy: req.RequestedBy}
+	}
+	return session, nil
+}
@@ -0,0 +1,115 @@
+package operator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	podtracev1alpha1 "github.com/podtrace/podtrace/api/v1alpha1"
+	"github.com/podtrace/podtrace/internal/federation"
+)
+
+func TestFederationGateway_RequestAndStatus(t *testing.T) {
+	scheme := newOperatorScheme(t)
+	c := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&podtracev1alpha1.PodTraceSession{}).Build()
+	srv := httptest.NewServer(&FederationGateway{Client: c, Cluster: "west", Token: "s3cret"})
+	defer srv.Close()
+
+	fc, err := federation.NewClient(srv.URL, "s3cret")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+	got, err := fc.RequestTrace(ctx, federation.TraceRequest{
+		Namespace:   "payments",
+		Selector:    "app=ledger,tier=backend",
+		Duration:    "2m",
+		Exporter:    "otlp-default",
+		Filters:     []string{"net", "DNS"},
+		RequestedBy: "east",
+	})
+	if err != nil {
+		t.Fatalf("RequestTrace: %v", err)
+	}
+	if got.Cluster != "west" || got.Namespace != "payments" || !strings.HasPrefix(got.Name, "federated-") {
+		t.Fatalf("session = %+v", got)
+	}
+
+	var s podtracev1alpha1.PodTraceSession
+	if err := c.Get(ctx, types.NamespacedName{Namespace: "payments", Name: got.Name}, &s); err != nil {
+		t.Fatalf("get created session: %v", err)
+	}
+	if s.Spec.Duration.Duration != 2*time.Minute || s.Spec.ExporterRef.Name != "otlp-default" {
+		t.Errorf("spec = %+v", s.Spec)
+	}
+	if s.Spec.Selector == nil || s.Spec.Selector.MatchLabels["app"] != "ledger" || s.Spec.Selector.MatchLabels["tier"] != "backend" {
+		t.Errorf("selector = %+v", s.Spec.Selector)
+	}
+	if len(s.Spec.Filters) != 2 || s.Spec.Filters[1] != "dns" {
+		t.Errorf("filters = %v", s.Spec.Filters)
+	}
+	if s.Labels[LabelComponent] != ComponentFederated || s.Annotations[AnnotationRequestedBy] != "east" {
+		t.Errorf("labels = %v, annotations = %v", s.Labels, s.Annotations)
+	}
+	if s.Spec.TTLSecondsAfterFinished == nil {
+		t.Error("federated sessions must be garbage-collected")
+	}
+
+	s.Status.State = podtracev1alpha1.SessionStateCompleted
+	s.Status.ReportLocation = "configmap/payments/report"
+	if err := c.Status().Update(ctx, &s); err != nil {
+		t.Fatalf("update status: %v", err)
+	}
+	st, err := fc.Status(ctx, "payments", got.Name)
+	if err != nil {
+		t.Fatalf("Status: %v", err)
+	}
+	if !st.Done() || st.ReportLocation != "configmap/payments/report" {
+		t.Errorf("status = %+v", st)
+	}
+}
+
+func TestFederationGateway_Rejects(t *testing.T) {
+	scheme := newOperatorScheme(t)
+	other := &podtracev1alpha1.PodTraceSession{ObjectMeta: metav1.ObjectMeta{Name: "manual", Namespace: "payments"}}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(other).Build()
+	g := &FederationGateway{Client: c, Token: "s3cret"}
+
+	cases := []struct {
+		name, method, path, token, body string
+		want                            int
+	}{
+		{"no token", http.MethodPost, federation.SessionsPath, "", `{}`, http.StatusUnauthorized},
+		{"wrong token", http.MethodPost, federation.SessionsPath, "nope", `{}`, http.StatusUnauthorized},
+		{"bad json", http.MethodPost, federation.SessionsPath, "s3cret", `{`, http.StatusBadRequest},
+		{"too long", http.MethodPost, federation.SessionsPath, "s3cret",
+			`{"namespace":"payments","selector":"app=x","duration":"2h","exporter":"e"}`, http.StatusBadRequest},
+		{"bad filter", http.MethodPost, federation.SessionsPath, "s3cret",
+			`{"namespace":"payments","selector":"app=x","duration":"1m","exporter":"e","filters":["bogus"]}`, http.StatusBadRequest},
+		{"bad selector", http.MethodPost, federation.SessionsPath, "s3cret",
+			`{"namespace":"payments","selector":"app in (","duration":"1m","exporter":"e"}`, http.StatusBadRequest},
+		{"not federated", http.MethodGet, federation.SessionsPath + "/payments/manual", "s3cret", "", http.StatusNotFound},
+		{"missing", http.MethodGet, federation.SessionsPath + "/payments/gone", "s3cret", "", http.StatusNotFound},
+		{"unknown path", http.MethodGet, "/v1/other", "s3cret", "", http.StatusNotFound},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			g.ServeHTTP(rec, req)
+			if rec.Code != tc.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
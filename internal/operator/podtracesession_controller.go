@@ -228,6 +228,9 @@ func (r *PodTraceSessionReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		applyReportUploadStatus(&session, obs)
 		observeReportUploadMetrics(&session, obs)
 	}
+	if session.Status.State == podtracev1alpha1.SessionStateCompleted && session.Status.ReportLocation == "" {
+		session.Status.ReportLocation = inClusterReportLocation(&session)
+	}
 
 	if err := r.Status().Update(ctx, &session); err != nil {
 		if apierrors.IsConflict(err) {
@@ -578,7 +581,7 @@ func (r *PodTraceSessionReconciler) ensureJobs(ctx context.Context, s *podtracev
 
 // reconcileTerminalSession handles TTL-driven cleanup only.
 func (r *PodTraceSessionReconciler) reconcileTerminalSession(ctx context.Context, s *podtracev1alpha1.PodTraceSession) (ctrl.Result, error) {
-	if s.Status.CompletionTime == nil {
+	if s.Status.CompletionTime == nil || s.Spec.RetainAfterFinished {
 		return ctrl.Result{}, nil
 	}
 	ttl := sessionTTL(s)
@@ -599,6 +602,22 @@ func sessionTTL(s *podtracev1alpha1.PodTraceSession) int32 {
 	return 300
 }
 
+// inClusterReportLocation names the ConfigMap or Secret the Jobs of a
+// completed session wrote their reports to, so status links the results
+// as it does for an object-store upload. It is empty for other sinks.
+func inClusterReportLocation(s *podtracev1alpha1.PodTraceSession) string {
+	ref := s.Spec.ReportRef
+	switch {
+	case ref == nil:
+		return ""
+	case ref.ConfigMap != nil:
+		return "configmap/" + s.Namespace + "/" + ref.ConfigMap.Name
+	case ref.Secret != nil:
+		return "secret/" + s.Namespace + "/" + ref.Secret.Name
+	}
+	return ""
+}
+
 // setCondition mirrors TracerConfigReconciler.setCondition.
 func (r *PodTraceSessionReconciler) setCondition(s *podtracev1alpha1.PodTraceSession, condType string, status metav1.ConditionStatus, reason, message string) {
 	s.Status.Conditions = upsertCondition(s.Status.Conditions, metav1.Condition{
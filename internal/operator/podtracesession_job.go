@@ -286,6 +286,14 @@ func effectiveSessionDuration(s *podtracev1alpha1.PodTraceSession, tc *podtracev
 	return d
 }
 
+func joinEventFilters(filters []podtracev1alpha1.EventFilter) string {
+	vals := make([]string, 0, len(filters))
+	for _, f := range filters {
+		vals = append(vals, string(f))
+	}
+	return strings.Join(vals, ",")
+}
+
 // buildDiagnoseArgs renders the in-Job CLI arguments from the session's
 // grant-authorized targets.
 func buildDiagnoseArgs(s *podtracev1alpha1.PodTraceSession, targets sessionTargets, duration time.Duration) []string {
@@ -296,11 +304,10 @@ func buildDiagnoseArgs(s *podtracev1alpha1.PodTraceSession, targets sessionTarge
 		args = append(args, "--container", s.Spec.ContainerName)
 	}
 	if len(s.Spec.Filters) > 0 {
-		vals := make([]string, 0, len(s.Spec.Filters))
-		for _, f := range s.Spec.Filters {
-			vals = append(vals, string(f))
-		}
-		args = append(args, "--filter", strings.Join(vals, ","))
+		args = append(args, "--filter", joinEventFilters(s.Spec.Filters))
+	}
+	if len(s.Spec.ProbeGroups) > 0 {
+		args = append(args, "--probe-groups", joinEventFilters(s.Spec.ProbeGroups))
 	}
 	if s.Spec.SamplePercent != nil {
 		args = append(args, "--tracing-sample-rate", strconv.FormatFloat(float64(*s.Spec.SamplePercent)/100.0, 'f', 2, 64))
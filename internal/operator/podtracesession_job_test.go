@@ -91,6 +91,23 @@ func TestBuildDiagnoseArgs_FiltersAndSample(t *testing.T) {
 	}
 }
 
+func TestBuildDiagnoseArgs_ProbeGroups(t *testing.T) {
+	s := newSession(func(s *podtracev1alpha1.PodTraceSession) {
+		s.Spec.ProbeGroups = []podtracev1alpha1.EventFilter{
+			podtracev1alpha1.FilterNet,
+			podtracev1alpha1.FilterFS,
+		}
+	})
+	args := buildDiagnoseArgs(s, sessionTargets{PodRefs: s.Spec.PodRefs}, s.Spec.Duration.Duration)
+	joined := strings.Join(args, " ")
+	if !strings.Contains(joined, "--probe-groups net,fs") {
+		t.Errorf("probe-groups flag wrong: %v", args)
+	}
+	if strings.Contains(joined, "--filter") {
+		t.Errorf("probe groups must not filter the report: %v", args)
+	}
+}
+
 func TestBuildSessionJobSpec_CoreInvariants(t *testing.T) {
 	ttl := int32(600)
 	backoff := int32(0)
@@ -843,3 +843,45 @@ func TestReconcileTerminalSession_TTLSemantics(t *testing.T) {
 		t.Errorf("session must NOT be deleted before TTL elapses: %v", err)
 	}
 }
+
+// A GitOps controller re-creates a session the operator deleted, which would
+// run the trace again; retainAfterFinished keeps it past its TTL.
+func TestReconcileTerminalSession_RetainAfterFinished(t *testing.T) {
+	scheme := newOperatorScheme(t)
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	zero := int32(0)
+	s := &podtracev1alpha1.PodTraceSession{
+		ObjectMeta: metav1.ObjectMeta{Name: "g", Namespace: "default", UID: "uid-g"},
+		Spec:       podtracev1alpha1.PodTraceSessionSpec{TTLSecondsAfterFinished: &zero, RetainAfterFinished: true},
+		Status:     podtracev1alpha1.PodTraceSessionStatus{CompletionTime: &past},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(s).Build()
+	r := &PodTraceSessionReconciler{Client: c, Scheme: scheme, SystemNamespace: "ns-sys"}
+	res, err := r.reconcileTerminalSession(context.Background(), s)
+	if err != nil || res.RequeueAfter != 0 {
+		t.Fatalf("reconcileTerminalSession = %+v, %v", res, err)
+	}
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "g", Namespace: "default"}, &podtracev1alpha1.PodTraceSession{}); err != nil {
+		t.Errorf("retained session was deleted: %v", err)
+	}
+}
+
+func TestInClusterReportLocation(t *testing.T) {
+	for _, tc := range []struct {
+		ref  *podtracev1alpha1.ReportReference
+		want string
+	}{
+		{nil, ""},
+		{&podtracev1alpha1.ReportReference{ConfigMap: &corev1.LocalObjectReference{Name: "r"}}, "configmap/team/r"},
+		{&podtracev1alpha1.ReportReference{Secret: &corev1.LocalObjectReference{Name: "r"}}, "secret/team/r"},
+		{&podtracev1alpha1.ReportReference{ObjectStore: &podtracev1alpha1.ObjectStoreReference{URI: "s3://b/k"}}, ""},
+	} {
+		s := &podtracev1alpha1.PodTraceSession{
+			ObjectMeta: metav1.ObjectMeta{Name: "s", Namespace: "team"},
+			Spec:       podtracev1alpha1.PodTraceSessionSpec{ReportRef: tc.ref},
+		}
+		if got := inClusterReportLocation(s); got != tc.want {
+			t.Errorf("inClusterReportLocation(%+v) = %q, want %q", tc.ref, got, tc.want)
+		}
+	}
+}
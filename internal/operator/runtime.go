@@ -41,6 +41,14 @@ type Options struct {
 	BootstrapFallbackImage string
 
 	BootstrapTracerConfigName string
+
+	// FederationBindAddress (host:port) for the federation gateway, through
+	// which podtrace in other clusters requests traces here. Empty, or an
+	// empty FederationToken, disables it.
+	FederationBindAddress string
+	FederationToken       string
+	// ClusterName names this cluster in federation responses.
+	ClusterName string
 }
 
 func DefaultOptions() Options {
@@ -139,6 +147,17 @@ func Run(ctx context.Context, opts Options) error {
 		return fmt.Errorf("register session-child reaper: %w", err)
 	}
 
+	if opts.FederationBindAddress != "" && opts.FederationToken != "" {
+		if err := mgr.Add(&FederationGateway{
+			Client:  mgr.GetClient(),
+			Cluster: opts.ClusterName,
+			Token:   opts.FederationToken,
+			Addr:    opts.FederationBindAddress,
+		}); err != nil {
+			return fmt.Errorf("register federation gateway: %w", err)
+		}
+	}
+
 	return mgr.Start(ctx)
 }
 
@@ -104,6 +104,20 @@ func Open(rel string) (*os.File, error) {
 	return r.Open(rel)
 }
 
+// ReadDir lists a directory within procfs, such as "1234/fd".
+func ReadDir(rel string) ([]os.DirEntry, error) {
+	r, err := rootForBase(config.ProcBasePath)
+	if err != nil {
+		return nil, fmt.Errorf("procfs: open %s: %w", config.ProcBasePath, err)
+	}
+	f, err := r.Open(rel)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return f.ReadDir(-1)
+}
+
 // Readlink returns the target of a symbolic link inside procfs. The
 // returned target is whatever the kernel reported (typically an
 // absolute path); callers that need to verify the target stays inside
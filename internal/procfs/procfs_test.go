@@ -174,3 +174,25 @@ func TestReadFile_WrapsOpenError(t *testing.T) {
 		t.Logf("unwrapped err = %v", err)
 	}
 }
+
+func TestReadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "42", "fd"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("anon_inode:bpf-prog", filepath.Join(dir, "42", "fd", "7")); err != nil {
+		t.Fatal(err)
+	}
+	withProcBase(t, dir)
+
+	entries, err := ReadDir("42/fd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "7" {
+		t.Errorf("entries = %v", entries)
+	}
+	if _, err := ReadDir("../.."); err == nil {
+		t.Error("ReadDir escaped the procfs root")
+	}
+}
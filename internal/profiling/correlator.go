@@ -215,7 +215,7 @@ func isSlowEventType(t events.EventType) bool {
 		events.EventDBQuery,
 		events.EventRedisCmd, events.EventMemcachedCmd,
 		events.EventGRPCMethod,
-		events.EventKafkaProduce, events.EventKafkaFetch,
+		events.EventKafkaProduce, events.EventKafkaFetch, events.EventKafkaOp,
 		events.EventTLSHandshake,
 		events.EventLockContention,
 		events.EventHTTPResp,
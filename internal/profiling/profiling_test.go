@@ -458,7 +458,7 @@ func TestIsSlowEventType_Coverage(t *testing.T) {
 		events.EventRead, events.EventWrite, events.EventFsync,
 		events.EventDNS, events.EventDBQuery, events.EventRedisCmd,
 		events.EventMemcachedCmd, events.EventGRPCMethod,
-		events.EventKafkaProduce, events.EventKafkaFetch,
+		events.EventKafkaProduce, events.EventKafkaFetch, events.EventKafkaOp,
 		events.EventTLSHandshake, events.EventLockContention,
 		events.EventHTTPResp, events.EventFastCGIResp,
 	}
@@ -0,0 +1,497 @@
+// Package query filters and aggregates the events of a capture file with a
+// small pipeline language, for `podtrace query`:
+//
+//	type=dns target~"payments" | p99 latency by target
+//
+// The first stage is a filter: comparisons separated by spaces that must all
+// hold. Each later stage is an aggregation or a row limit.
+package query
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/diagnose/analyzer"
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+// DefaultLimit is how many events a query without an aggregation or a
+// limit lists.
+const DefaultLimit = 20
+
+// Fields a query can filter and group on. Latency is in milliseconds.
+var (
+	stringFields  = []string{"type", "process", "target", "details"}
+	numericFields = []string{"latency", "bytes", "error", "pid"}
+)
+
+// aggregations maps each aggregation function to the percentile it
+// takes, or -1 for count, sum and avg. count takes no field.
+var aggregations = map[string]float64{
+	"count": -1, "sum": -1, "avg": -1, "min": 0, "max": 100,
+	"p50": 50, "p90": 90, "p95": 95, "p99": 99,
+}
+
+var termRe = regexp.MustCompile(`^([a-z]+)(!=|!~|>=|<=|=|~|>|<)(.*)$`)
+
+// Filter is one field comparison.
+type Filter struct {
+	Field string
+	Op    string
+	Value string
+	re    *regexp.Regexp
+	num   float64
+}
+
+// Aggregation reduces the matching events to one value per group.
+type Aggregation struct {
+	// Func is count, sum, avg, min, max, p50, p90, p95 or p99.
+	Func string
+	// Field is the numeric field aggregated; empty for count.
+	Field string
+	// By is the field the events are grouped on; empty for one group.
+	By string
+}
+
+// Query is a parsed query.
+type Query struct {
+	Filters []Filter
+	Agg     *Aggregation
+	// Limit caps the rows shown; 0 shows every aggregated row and
+	// DefaultLimit events.
+	Limit int
+}
+
+// Parse parses "[filter ...] [| aggregation] [| limit N]".
+func Parse(s string) (*Query, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	var stages [][]string
+	stage := []string{}
+	for _, tok := range tokens {
+		if tok == "|" {
+			stages = append(stages, stage)
+			stage = []string{}
+			continue
+		}
+		stage = append(stage, tok)
+	}
+	stages = append(stages, stage)
+
+	q := &Query{}
+	for _, term := range stages[0] {
+		f, err := parseFilter(term)
+		if err != nil {
+			return nil, err
+		}
+		q.Filters = append(q.Filters, f)
+	}
+	for _, st := range stages[1:] {
+		if len(st) == 0 {
+			return nil, errors.New("empty stage after |")
+		}
+		if st[0] == "limit" {
+			if len(st) != 2 {
+				return nil, errors.New("limit takes a positive number of rows")
+			}
+			n, err := strconv.Atoi(st[1])
+			if err != nil || n <= 0 {
+				return nil, errors.New("limit takes a positive number of rows")
+			}
+			q.Limit = n
+			continue
+		}
+		if q.Agg != nil {
+			return nil, errors.New("only one aggregation per query")
+		}
+		agg, err := parseAggregation(st)
+		if err != nil {
+			return nil, err
+		}
+		q.Agg = agg
+	}
+	return q, nil
+}
+
+// tokenize splits s on spaces and '|', keeping quoted text together.
+func tokenize(s string) ([]string, error) {
+	var tokens []string
+	var cur strings.Builder
+	quoted := false
+	flush := func() {
+		if cur.Len() > 0 {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quoted && c == '\\' && i+1 < len(s):
+			cur.WriteByte(c)
+			i++
+			cur.WriteByte(s[i])
+		case c == '"':
+			quoted = !quoted
+			cur.WriteByte(c)
+		case quoted:
+			cur.WriteByte(c)
+		case c == ' ' || c == '\t':
+			flush()
+		case c == '|':
+			flush()
+			tokens = append(tokens, "|")
+		default:
+			cur.WriteByte(c)
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	flush()
+	return tokens, nil
+}
+
+func parseFilter(term string) (Filter, error) {
+	m := termRe.FindStringSubmatch(term)
+	if m == nil {
+		return Filter{}, fmt.Errorf("invalid filter %q: expected <field><op><value>, e.g. type=dns", term)
+	}
+	f := Filter{Field: m[1], Op: m[2], Value: m[3]}
+	if strings.HasPrefix(f.Value, `"`) {
+		v, err := strconv.Unquote(f.Value)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid filter %q: %w", term, err)
+		}
+		f.Value = v
+	}
+	switch {
+	case isString(f.Field):
+		switch f.Op {
+		case "~", "!~":
+			re, err := regexp.Compile(f.Value)
+			if err != nil {
+				return Filter{}, fmt.Errorf("invalid filter %q: %w", term, err)
+			}
+			f.re = re
+		case "=", "!=":
+		default:
+			return Filter{}, fmt.Errorf("invalid filter %q: %s is text; use =, !=, ~ or !~", term, f.Field)
+		}
+	case isNumeric(f.Field):
+		if f.Op == "~" || f.Op == "!~" {
+			return Filter{}, fmt.Errorf("invalid filter %q: %s is a number; use =, !=, <, <=, > or >=", term, f.Field)
+		}
+		v, err := parseNumber(f.Field, f.Value)
+		if err != nil {
+			return Filter{}, fmt.Errorf("invalid filter %q: %w", term, err)
+		}
+		f.num = v
+	default:
+		return Filter{}, unknownField(f.Field)
+	}
+	return f, nil
+}
+
+// parseNumber parses a filter value; a latency may carry a duration unit
+// ("250ms", "1.5s") and is otherwise in milliseconds.
+func parseNumber(field, v string) (float64, error) {
+	if field == "latency" {
+		if d, err := time.ParseDuration(v); err == nil {
+			return float64(d) / float64(time.Millisecond), nil
+		}
+	}
+	n, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s needs a number, not %q", field, v)
+	}
+	return n, nil
+}
+
+func parseAggregation(st []string) (*Aggregation, error) {
+	agg := &Aggregation{Func: st[0]}
+	if _, ok := aggregations[agg.Func]; !ok {
+		return nil, fmt.Errorf("unknown aggregation %q (use count, sum, avg, min, max, p50, p90, p95, p99 or limit)", agg.Func)
+	}
+	rest := st[1:]
+	if agg.Func != "count" {
+		if len(rest) == 0 || !isNumeric(rest[0]) {
+			return nil, fmt.Errorf("%s takes a numeric field: %s", agg.Func, strings.Join(numericFields, ", "))
+		}
+		agg.Field, rest = rest[0], rest[1:]
+	}
+	switch {
+	case len(rest) == 0:
+	case len(rest) == 2 && rest[0] == "by":
+		if !isString(rest[1]) && !isNumeric(rest[1]) {
+			return nil, unknownField(rest[1])
+		}
+		agg.By = rest[1]
+	default:
+		return nil, fmt.Errorf("invalid aggregation %q: expected %s [<field>] [by <field>]", strings.Join(st, " "), agg.Func)
+	}
+	return agg, nil
+}
+
+func isString(field string) bool { return slices.Contains(stringFields, field) }
+
+func isNumeric(field string) bool { return slices.Contains(numericFields, field) }
+
+func unknownField(field string) error {
+	return fmt.Errorf("unknown field %q (use %s, %s)", field, strings.Join(stringFields, ", "), strings.Join(numericFields, ", "))
+}
+
+// Match reports whether e passes every filter of q.
+func (q *Query) Match(e schema.Event) bool {
+	for _, f := range q.Filters {
+		if !f.match(e) {
+			return false
+		}
+	}
+	return true
+}
+
+func (f Filter) match(e schema.Event) bool {
+	if isString(f.Field) {
+		v := text(e, f.Field)
+		switch f.Op {
+		case "=":
+			return f.equal(v)
+		case "!=":
+			return !f.equal(v)
+		case "~":
+			return f.re.MatchString(v)
+		default:
+			return !f.re.MatchString(v)
+		}
+	}
+	v := number(e, f.Field)
+	switch f.Op {
+	case "=":
+		return v == f.num
+	case "!=":
+		return v != f.num
+	case ">":
+		return v > f.num
+	case ">=":
+		return v >= f.num
+	case "<":
+		return v < f.num
+	default:
+		return v <= f.num
+	}
+}
+
+// equal compares text exactly, except event types, which are upper case
+// in captures but natural to type in lower case.
+func (f Filter) equal(v string) bool {
+	if f.Field == "type" {
+		return strings.EqualFold(v, f.Value)
+	}
+	return v == f.Value
+}
+
+func text(e schema.Event, field string) string {
+	switch field {
+	case "type":
+		return e.Type
+	case "process":
+		return e.Process
+	case "target":
+		return e.Target
+	case "details":
+		return e.Details
+	}
+	return strconv.FormatFloat(number(e, field), 'f', -1, 64)
+}
+
+func number(e schema.Event, field string) float64 {
+	switch field {
+	case "latency":
+		return float64(e.LatencyNS) / float64(time.Millisecond)
+	case "bytes":
+		return float64(e.Bytes)
+	case "error":
+		return float64(e.Error)
+	case "pid":
+		return float64(e.PID)
+	}
+	return 0
+}
+
+// Result is the table a query produced.
+type Result struct {
+	Columns []string
+	Rows    [][]string
+	// Matched counts the events that passed the filter, of Total.
+	Matched int
+	Total   int
+	// Hidden counts the rows left out by the limit.
+	Hidden int
+}
+
+// Run runs q over evts.
+func (q *Query) Run(evts []schema.Event) Result {
+	var matched []schema.Event
+	for _, e := range evts {
+		if q.Match(e) {
+			matched = append(matched, e)
+		}
+	}
+	res := Result{Matched: len(matched), Total: len(evts)}
+	limit := q.Limit
+	if q.Agg == nil {
+		if limit == 0 {
+			limit = DefaultLimit
+		}
+		res.Columns = []string{"TIME", "TYPE", "PID", "PROCESS", "TARGET", "LATENCY", "ERROR", "BYTES"}
+		for _, e := range matched {
+			res.Rows = append(res.Rows, []string{
+				eventTime(e), e.Type, strconv.FormatUint(uint64(e.PID), 10), e.Process, e.Target,
+				formatValue("latency", number(e, "latency")), strconv.FormatInt(int64(e.Error), 10), strconv.FormatUint(e.Bytes, 10),
+			})
+		}
+	} else {
+		res.Columns, res.Rows = q.Agg.run(matched)
+	}
+	if limit > 0 && len(res.Rows) > limit {
+		res.Hidden = len(res.Rows) - limit
+		res.Rows = res.Rows[:limit]
+	}
+	return res
+}
+
+// run groups evts and returns one row per group, largest value first.
+func (a *Aggregation) run(evts []schema.Event) ([]string, [][]string) {
+	type group struct {
+		key    string
+		values []float64
+	}
+	groups := make(map[string]*group)
+	var order []*group
+	for _, e := range evts {
+		key := ""
+		if a.By != "" {
+			key = text(e, a.By)
+		}
+		g := groups[key]
+		if g == nil {
+			g = &group{key: key}
+			groups[key] = g
+			order = append(order, g)
+		}
+		g.values = append(g.values, number(e, a.Field))
+	}
+
+	type row struct {
+		key   string
+		value float64
+		n     int
+	}
+	rows := make([]row, 0, len(order))
+	for _, g := range order {
+		rows = append(rows, row{key: g.key, value: a.reduce(g.values), n: len(g.values)})
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].value != rows[j].value {
+			return rows[i].value > rows[j].value
+		}
+		return rows[i].key < rows[j].key
+	})
+
+	name := a.Func
+	if a.Field != "" {
+		name += " " + a.Field
+	}
+	var cols []string
+	if a.By != "" {
+		cols = append(cols, strings.ToUpper(a.By))
+	}
+	cols = append(cols, strings.ToUpper(name))
+	if a.Func != "count" {
+		cols = append(cols, "EVENTS")
+	}
+	out := make([][]string, 0, len(rows))
+	for _, r := range rows {
+		var cells []string
+		if a.By != "" {
+			key := r.key
+			if key == "" {
+				key = "-"
+			}
+			cells = append(cells, key)
+		}
+		if a.Func == "count" {
+			cells = append(cells, strconv.Itoa(r.n))
+		} else {
+			cells = append(cells, formatValue(a.Field, r.value), strconv.Itoa(r.n))
+		}
+		out = append(out, cells)
+	}
+	return cols, out
+}
+
+func (a *Aggregation) reduce(values []float64) float64 {
+	switch a.Func {
+	case "count":
+		return float64(len(values))
+	case "sum", "avg":
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		if a.Func == "avg" {
+			return sum / float64(len(values))
+		}
+		return sum
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return analyzer.Percentile(sorted, aggregations[a.Func])
+}
+
+func formatValue(field string, v float64) string {
+	if field == "latency" {
+		return fmt.Sprintf("%.2fms", v)
+	}
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+func eventTime(e schema.Event) string {
+	t, err := e.Timestamp()
+	if err != nil {
+		return e.Time
+	}
+	return t.Format("15:04:05.000")
+}
+
+// Write renders r as an aligned table followed by how many events matched.
+func (r Result) Write(w io.Writer) error {
+	if len(r.Rows) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(r.Columns, "\t"))
+		for _, row := range r.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if r.Hidden > 0 {
+		if _, err := fmt.Fprintf(w, "... %d more rows (| limit N to show more)\n", r.Hidden); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%d of %d events matched\n", r.Matched, r.Total)
+	return err
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/podtrace/podtrace/pkg/schema"
+)
+
+func testEvents() []schema.Event {
+	ev := func(typ, target string, latencyMS float64, errno int32) schema.Event {
+		return schema.Event{
+			SchemaVersion: schema.CurrentVersion,
+			Time:          "2026-01-02T10:00:00.25Z",
+			Type:          typ,
+			PID:           42,
+			Process:       "api",
+			Target:        target,
+			LatencyNS:     uint64(latencyMS * 1e6),
+			Error:         errno,
+		}
+	}
+	return []schema.Event{
+		ev("DNS", "payments.shop.svc", 2, 0),
+		ev("DNS", "payments.shop.svc", 40, 0),
+		ev("DNS", "payments-db.shop.svc", 8, 0),
+		ev("DNS", "inventory.shop.svc", 1, 3),
+		ev("CONNECT", "10.0.0.7:443", 5, 111),
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, bad := range []string{
+		"type",
+		"color=red",
+		"latency~5",
+		"target>3",
+		"latency>fast",
+		`target~"pay`,
+		"target~(",
+		"type=dns |",
+		"| p99",
+		"| p99 target",
+		"| median latency",
+		"| count by color",
+		"| count | count",
+		"| limit 0",
+		"| count target",
+	} {
+		if _, err := Parse(bad); err == nil {
+			t.Errorf("Parse(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestRunAggregation(t *testing.T) {
+	q, err := Parse(`type=dns target~"payments" | p99 latency by target`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := q.Run(testEvents())
+	if res.Matched != 3 || res.Total != 5 {
+		t.Errorf("matched %d of %d", res.Matched, res.Total)
+	}
+	wantCols := []string{"TARGET", "P99 LATENCY", "EVENTS"}
+	wantRows := [][]string{
+		{"payments.shop.svc", "39.62ms", "2"},
+		{"payments-db.shop.svc", "8.00ms", "1"},
+	}
+	if !reflect.DeepEqual(res.Columns, wantCols) || !reflect.DeepEqual(res.Rows, wantRows) {
+		t.Errorf("result = %v %v", res.Columns, res.Rows)
+	}
+
+	q, _ = Parse("error!=0 | count by type")
+	res = q.Run(testEvents())
+	if want := [][]string{{"CONNECT", "1"}, {"DNS", "1"}}; !reflect.DeepEqual(res.Rows, want) {
+		t.Errorf("count by type = %v", res.Rows)
+	}
+
+	q, _ = Parse("latency>=5ms | count")
+	if res = q.Run(testEvents()); !reflect.DeepEqual(res.Rows, [][]string{{"3"}}) {
+		t.Errorf("count = %v", res.Rows)
+	}
+}
+
+func TestRunListsEvents(t *testing.T) {
+	q, err := Parse("type=DNS | limit 2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := q.Run(testEvents())
+	if len(res.Rows) != 2 || res.Hidden != 2 {
+		t.Fatalf("rows %d, hidden %d", len(res.Rows), res.Hidden)
+	}
+	if want := []string{"10:00:00.250", "DNS", "42", "api", "payments.shop.svc", "2.00ms", "0", "0"}; !reflect.DeepEqual(res.Rows[0], want) {
+		t.Errorf("row = %v", res.Rows[0])
+	}
+
+	var b strings.Builder
+	if err := res.Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{"TIME          TYPE", "... 2 more rows (| limit N to show more)\n", "4 of 5 events matched\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"strings"
 
@@ -12,6 +13,8 @@ import (
 	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"github.com/podtrace/podtrace/internal/fips"
 )
 
 // S3 credential keys read from the user-supplied Secret. All optional —
@@ -67,6 +70,11 @@ func newS3Sink(ctx context.Context, cfg Config, d destination) (Sink, error) {
 
 	clientOpts := []func(*s3.Options){}
 	if endpoint := stringFromCreds(creds, s3SecretKeyEndpoint); endpoint != "" {
+		if u, err := url.Parse(endpoint); err == nil && u.Scheme == "http" {
+			if err := fips.CheckCleartext("s3 endpoint", u.Host); err != nil {
+				return nil, err
+			}
+		}
 		clientOpts = append(clientOpts, func(o *s3.Options) {
 			o.BaseEndpoint = aws.String(endpoint)
 		})
@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
@@ -39,6 +40,23 @@ const (
 	AlertEmergency = 3
 )
 
+// alertThresholds overrides the PODTRACE_ALERT_*_PCT levels once they are
+// changed while the monitors run.
+var alertThresholds atomic.Pointer[[3]int]
+
+// SetAlertThresholds changes the utilization percentages at which the
+// running monitors raise a warning, critical and emergency alert.
+func SetAlertThresholds(warn, crit, emerg int) {
+	alertThresholds.Store(&[3]int{warn, crit, emerg})
+}
+
+func currentAlertThresholds() (warn, crit, emerg int) {
+	if t := alertThresholds.Load(); t != nil {
+		return t[0], t[1], t[2]
+	}
+	return config.AlertWarnPct, config.AlertCritPct, config.AlertEmergPct
+}
+
 type ResourceLimit struct {
 	LimitBytes   uint64
 	UsageBytes   uint64
@@ -97,6 +115,16 @@ type ResourceMonitor struct {
 	cpuQuotaMap      bpfLimitMap
 	cpuAlertsReadMap bpfAlertReadMap
 	cpuSamplerOn     bool
+
+	// userspaceAlerts raises alerts without the BPF alert map, for
+	// low-privilege mode.
+	userspaceAlerts bool
+
+	// swap holds the previous swap counters, once swapKnown.
+	swap      swapCounters
+	swapKnown bool
+	// tmpfsHeld is set while the last tmpfs sample was not empty.
+	tmpfsHeld bool
 }
 
 type bpfAlertReadMap interface {
@@ -138,6 +166,14 @@ func NewResourceMonitor(cgroupPath string, limitsMap, alertsMap *ebpf.Map, event
 	return rm, nil
 }
 
+// EnableUserspaceAlerts makes the monitor raise resource limit alerts and
+// events although it has no BPF alert map to publish them to.
+func (rm *ResourceMonitor) EnableUserspaceAlerts() {
+	rm.mu.Lock()
+	rm.userspaceAlerts = true
+	rm.mu.Unlock()
+}
+
 func (rm *ResourceMonitor) Start(ctx context.Context) {
 	rm.wg.Add(1)
 	go rm.monitorLoop(ctx)
@@ -166,6 +202,8 @@ func (rm *ResourceMonitor) monitorLoop(ctx context.Context) {
 			}
 			rm.checkAlerts()
 			rm.checkBPFCPUAlerts()
+			rm.checkSwap()
+			rm.checkTmpfs()
 		}
 	}
 }
@@ -491,7 +529,7 @@ func (rm *ResourceMonitor) checkAlerts() {
 	rm.mu.RLock()
 	defer rm.mu.RUnlock()
 
-	if rm.alertsMap == nil {
+	if rm.alertsMap == nil && !rm.userspaceAlerts {
 		return
 	}
 
@@ -516,26 +554,31 @@ func (rm *ResourceMonitor) checkAlerts() {
 		}
 		utilizationUint32 := safeconv.Uint64ToUint32(utilization)
 
+		warnPct, critPct, emergPct := currentAlertThresholds()
 		var alertLevel uint32
 		switch {
-		case utilizationUint32 >= safeconv.IntToUint32(config.AlertEmergPct):
+		case utilizationUint32 >= safeconv.IntToUint32(emergPct):
 			alertLevel = AlertEmergency
-		case utilizationUint32 >= safeconv.IntToUint32(config.AlertCritPct):
+		case utilizationUint32 >= safeconv.IntToUint32(critPct):
 			alertLevel = AlertCritical
-		case utilizationUint32 >= safeconv.IntToUint32(config.AlertWarnPct):
+		case utilizationUint32 >= safeconv.IntToUint32(warnPct):
 			alertLevel = AlertWarning
 		default:
 			alertLevel = AlertNone
 		}
 
-		key := resourceMapKey{CgroupID: rm.cgroupInode, ResourceType: resourceType}
-		if alertLevel > 0 {
-			if err := rm.alertsMap.Put(key, alertLevel); err != nil {
-				logger.Warn("Failed to update alert map", zap.Error(err))
-			}
-		} else {
-			if err := rm.alertsMap.Delete(key); err != nil && !isBenignMapDeleteError(err) {
-				logger.Warn("Failed to delete alert from map", zap.Error(err))
+		// Without the map (low-privilege mode) nothing in the kernel reads
+		// the level.
+		if rm.alertsMap != nil {
+			key := resourceMapKey{CgroupID: rm.cgroupInode, ResourceType: resourceType}
+			if alertLevel > 0 {
+				if err := rm.alertsMap.Put(key, alertLevel); err != nil {
+					logger.Warn("Failed to update alert map", zap.Error(err))
+				}
+			} else {
+				if err := rm.alertsMap.Delete(key); err != nil && !isBenignMapDeleteError(err) {
+					logger.Warn("Failed to delete alert from map", zap.Error(err))
+				}
 			}
 		}
 
@@ -369,6 +369,28 @@ func TestCheckAlerts_NilMapIsNoOp(t *testing.T) {
 	rm.checkAlerts()
 }
 
+func TestCheckAlerts_UserspaceAlertsWithoutMap(t *testing.T) {
+	eventChan := make(chan *events.Event, 1)
+	rm := newMonitorWithFakeMaps(t, nil, nil, eventChan)
+	rm.EnableUserspaceAlerts()
+	rm.mu.Lock()
+	rm.limits = map[uint32]*ResourceLimit{
+		ResourceMemory: {LimitBytes: 100, UsageBytes: 96, ResourceType: ResourceMemory},
+	}
+	rm.mu.Unlock()
+
+	rm.checkAlerts()
+
+	select {
+	case e := <-eventChan:
+		if e.Type != events.EventResourceLimit || e.TCPState != ResourceMemory || e.Error != 96 {
+			t.Errorf("unexpected event: %+v", e)
+		}
+	default:
+		t.Fatal("expected a resource limit event without the BPF alert map")
+	}
+}
+
 func TestCheckAlerts_ZeroAndUnlimitedSkipped(t *testing.T) {
 	alertsMap := newFakeBPFMap()
 	eventChan := make(chan *events.Event, 4)
@@ -460,3 +482,14 @@ func TestCheckAlerts_ChannelFullDoesNotBlock(t *testing.T) {
 		t.Errorf("level = %d, want %d", level, AlertEmergency)
 	}
 }
+
+func TestSetAlertThresholds_OverridesConfig(t *testing.T) {
+	t.Cleanup(func() { alertThresholds.Store(nil) })
+	if w, c, e := currentAlertThresholds(); w != config.AlertWarnPct || c != config.AlertCritPct || e != config.AlertEmergPct {
+		t.Fatalf("default thresholds = %d/%d/%d, want the config values", w, c, e)
+	}
+	SetAlertThresholds(50, 60, 70)
+	if w, c, e := currentAlertThresholds(); w != 50 || c != 60 || e != 70 {
+		t.Errorf("thresholds = %d/%d/%d, want 50/60/70", w, c, e)
+	}
+}
@@ -0,0 +1,222 @@
+package resource
+
+import (
+	"context"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/sysfs"
+)
+
+// kubepodsRoots are the kubelet's pod hierarchies, relative to the cgroup
+// root, for the systemd and cgroupfs drivers.
+var kubepodsRoots = []string{
+	"kubepods.slice",
+	"kubepods",
+	"kubelet.slice/kubelet-kubepods.slice",
+	"system.slice/kubelet.service/kubepods",
+}
+
+// podCgroupRe matches a pod cgroup directory, "pod<uid>" under cgroupfs or
+// "kubepods-<qos>-pod<uid>.slice" with underscores under systemd.
+var podCgroupRe = regexp.MustCompile(`pod([0-9a-f]{8}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{4}[-_][0-9a-f]{12})(\.slice)?$`)
+
+// PodUIDFromCgroup returns the UID of the pod whose cgroup holds the cgroup
+// path p, or "" when p is not under a pod cgroup.
+func PodUIDFromCgroup(p string) string {
+	for dir := path.Clean(p); dir != "/" && dir != "."; dir = path.Dir(dir) {
+		if m := podCgroupRe.FindStringSubmatch(path.Base(dir)); m != nil {
+			return strings.ReplaceAll(m[1], "_", "-")
+		}
+	}
+	return ""
+}
+
+// NodePodUIDs returns the UIDs of the pods that have a cgroup on the node.
+func NodePodUIDs() map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, uid := range podCgroups() {
+		out[uid] = struct{}{}
+	}
+	return out
+}
+
+// neighborCounters are the cumulative counters of a pod cgroup.
+type neighborCounters struct {
+	cpuUsec, ioBytes uint64
+}
+
+// NeighborMonitor samples the CPU time and block I/O of the pods on the node
+// other than the traced ones, and emits an EventNeighbor per interval for the
+// busiest of them. It reads the pods' cgroup counters only, never their
+// traffic, and needs cgroup v2.
+type NeighborMonitor struct {
+	eventChan chan<- *events.Event
+	interval  time.Duration
+	top       int
+	// traced are the cgroup paths of the traced containers; the pod cgroups
+	// holding them are skipped.
+	traced []string
+	// name returns the namespace/name of a pod UID, or "" when unknown.
+	name func(uid string) string
+
+	last   map[string]neighborCounters
+	lastAt time.Time
+}
+
+// NewNeighborMonitor returns a monitor that skips the pods holding the traced
+// cgroup paths and names the others with name, which may be nil.
+func NewNeighborMonitor(traced []string, name func(uid string) string, eventChan chan<- *events.Event) *NeighborMonitor {
+	return &NeighborMonitor{
+		eventChan: eventChan,
+		interval:  config.NeighborInterval,
+		top:       config.NeighborTop,
+		traced:    traced,
+		name:      name,
+	}
+}
+
+// Start samples every interval until ctx is done.
+func (m *NeighborMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		m.sample(time.Now())
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, e := range m.sample(now) {
+					select {
+					case m.eventChan <- e:
+					default:
+						logger.Warn("Failed to send neighbor event, channel full", zap.String("pod", e.Target))
+					}
+				}
+			}
+		}
+	}()
+}
+
+// podCgroups returns the pod cgroups on the node, relative to the cgroup
+// root, with their pod UIDs.
+func podCgroups() map[string]string {
+	out := make(map[string]string)
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		entries, err := sysfs.CgroupReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			rel := path.Join(dir, e.Name())
+			if m := podCgroupRe.FindStringSubmatch(e.Name()); m != nil {
+				out[rel] = strings.ReplaceAll(m[1], "_", "-")
+			} else if depth == 0 {
+				// The burstable and besteffort QoS levels.
+				walk(rel, depth+1)
+			}
+		}
+	}
+	for _, root := range kubepodsRoots {
+		walk(root, 0)
+	}
+	return out
+}
+
+// isTraced reports whether the pod cgroup rel holds a traced container.
+func (m *NeighborMonitor) isTraced(rel string) bool {
+	abs := path.Join(config.CgroupBasePath, rel)
+	for _, t := range m.traced {
+		if t == abs || strings.HasPrefix(t, abs+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sample reads the counters of every untraced pod and returns an
+// EventNeighbor for each of the m.top pods that used the most CPU, and the
+// m.top that did the most block I/O, since the previous sample.
+func (m *NeighborMonitor) sample(now time.Time) []*events.Event {
+	type usage struct {
+		uid string
+		events.NeighborUsage
+	}
+	next := make(map[string]neighborCounters)
+	var used []usage
+	for rel, uid := range podCgroups() {
+		if m.isTraced(rel) {
+			continue
+		}
+		stat, err := sysfs.CgroupReadFile(path.Join(rel, "cpu.stat"))
+		if err != nil {
+			continue
+		}
+		c := neighborCounters{cpuUsec: parseCPUStat(string(stat))}
+		if io, err := sysfs.CgroupReadFile(path.Join(rel, "io.stat")); err == nil {
+			c.ioBytes = parseIOStat(string(io))
+		}
+		next[rel] = c
+		prev, ok := m.last[rel]
+		if !ok || c.cpuUsec < prev.cpuUsec || c.ioBytes < prev.ioBytes {
+			continue
+		}
+		u := usage{uid: uid, NeighborUsage: events.NeighborUsage{CPUNS: (c.cpuUsec - prev.cpuUsec) * 1000, IOBytes: c.ioBytes - prev.ioBytes}}
+		if u.CPUNS+u.IOBytes > 0 {
+			used = append(used, u)
+		}
+	}
+	interval := now.Sub(m.lastAt)
+	m.last, m.lastAt = next, now
+
+	picked := make(map[string]bool)
+	pick := func(metric func(usage) uint64) {
+		sort.Slice(used, func(i, j int) bool {
+			if a, b := metric(used[i]), metric(used[j]); a != b {
+				return a > b
+			}
+			return used[i].uid < used[j].uid
+		})
+		for i := 0; i < len(used) && i < m.top && metric(used[i]) > 0; i++ {
+			picked[used[i].uid] = true
+		}
+	}
+	pick(func(u usage) uint64 { return u.CPUNS })
+	pick(func(u usage) uint64 { return u.IOBytes })
+
+	var out []*events.Event
+	for _, u := range used {
+		if !picked[u.uid] {
+			continue
+		}
+		pod := u.uid
+		if m.name != nil {
+			if n := m.name(u.uid); n != "" {
+				pod = n
+			}
+		}
+		out = append(out, &events.Event{
+			Type:      events.EventNeighbor,
+			Timestamp: clock.WallToBPFTimestamp(now),
+			Target:    pod,
+			LatencyNS: uint64(interval),
+			Bytes:     u.IOBytes,
+			Details:   u.NeighborUsage.String(),
+		})
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestNeighborMonitorSample(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	pods := map[string]string{
+		"busy":   "kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod11111111_2222_3333_4444_555555555555.slice",
+		"disk":   "kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod66666666_7777_8888_9999_000000000000.slice",
+		"idle":   "kubepods.slice/kubepods-podaaaaaaaa_bbbb_cccc_dddd_eeeeeeeeeeee.slice",
+		"traced": "kubepods.slice/kubepods-podffffffff_ffff_ffff_ffff_ffffffffffff.slice",
+	}
+	write := func(pod string, cpuUsec, rbytes int) {
+		dir := filepath.Join(base, pods[pod])
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cpu.stat"), []byte("usage_usec "+strconv.Itoa(cpuUsec)+"\nuser_usec 0\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "io.stat"), []byte("8:0 rbytes="+strconv.Itoa(rbytes)+" wbytes=0 rios=1 wios=0\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for pod := range pods {
+		write(pod, 1000, 0)
+	}
+	names := map[string]string{"11111111-2222-3333-4444-555555555555": "batch/cruncher"}
+	m := NewNeighborMonitor([]string{filepath.Join(base, pods["traced"], "cri-containerd-abc.scope")},
+		func(uid string) string { return names[uid] }, nil)
+	m.top = 1
+
+	at := time.Now()
+	if got := m.sample(at); len(got) != 0 {
+		t.Fatalf("first sample emitted %v", got)
+	}
+	write("busy", 2_001_000, 0)
+	write("disk", 1500, 64<<20)
+	write("idle", 1100, 0)
+	write("traced", 9_000_000, 1<<30)
+	got := m.sample(at.Add(5 * time.Second))
+	if len(got) != 2 {
+		t.Fatalf("sample = %+v", got)
+	}
+	byPod := map[string]*events.Event{}
+	for _, e := range got {
+		byPod[e.Target] = e
+	}
+	busy, disk := byPod["batch/cruncher"], byPod["66666666-7777-8888-9999-000000000000"]
+	if busy == nil || disk == nil {
+		t.Fatalf("sample = %v", byPod)
+	}
+	if u := events.ParseNeighborUsage(busy.Details); u.CPUNS != 2_000_000_000 || busy.LatencyNS != uint64(5*time.Second) {
+		t.Errorf("busy = %+v", busy)
+	}
+	if disk.Bytes != 64<<20 {
+		t.Errorf("disk = %+v", disk)
+	}
+}
+
+func TestPodUIDFromCgroup(t *testing.T) {
+	tests := map[string]string{
+		"/sys/fs/cgroup/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod11111111_2222_3333_4444_555555555555.slice/cri-containerd-abc.scope": "11111111-2222-3333-4444-555555555555",
+		"/sys/fs/cgroup/kubepods/besteffort/podaaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee/0123456789ab":                                                          "aaaaaaaa-bbbb-cccc-dddd-eeeeeeeeeeee",
+		"/sys/fs/cgroup/system.slice/containerd.service":                                                                                                   "",
+		"": "",
+	}
+	for in, want := range tests {
+		if got := PodUIDFromCgroup(in); got != want {
+			t.Errorf("PodUIDFromCgroup(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/clock"
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// PauseTarget is a traced container watched for pauses.
+type PauseTarget struct {
+	// Name is the container as namespace/pod/container.
+	Name       string
+	CgroupPath string
+}
+
+// PauseMonitor looks at the traced containers every interval and emits an
+// EventPause when one is frozen through its cgroup, or its main process is
+// stopped, and again when it resumes. A paused container leaves a gap in
+// the trace that reads like a network or disk stall otherwise.
+type PauseMonitor struct {
+	eventChan chan<- *events.Event
+	interval  time.Duration
+	targets   []PauseTarget
+
+	// paused holds the kind of pause in effect per container, and pids the
+	// main process it was found on.
+	paused map[string]string
+	pids   map[string]uint32
+}
+
+// NewPauseMonitor returns a monitor of the targets.
+func NewPauseMonitor(targets []PauseTarget, eventChan chan<- *events.Event) *PauseMonitor {
+	return &PauseMonitor{
+		eventChan: eventChan,
+		interval:  config.PauseInterval,
+		targets:   targets,
+		paused:    make(map[string]string),
+		pids:      make(map[string]uint32),
+	}
+}
+
+// Start samples every interval until ctx is done.
+func (m *PauseMonitor) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(m.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				for _, e := range m.sample(now) {
+					select {
+					case m.eventChan <- e:
+					default:
+						logger.Warn("Failed to send pause event, channel full", zap.String("container", e.Target))
+					}
+				}
+			}
+		}
+	}()
+}
+
+// sample looks at every target once and returns the pauses that started
+// or ended since the previous look.
+func (m *PauseMonitor) sample(now time.Time) []*events.Event {
+	var out []*events.Event
+	for _, t := range m.targets {
+		pid := readMainPID(t.CgroupPath)
+		kind := ""
+		if frozen, err := cgroupFrozen(t.CgroupPath); err == nil && frozen {
+			kind = events.PauseFreeze
+		} else if pid != 0 {
+			kind = stopKind(readProcState(pid))
+		}
+		out = append(out, m.observe(t.Name, kind, pid, now)...)
+	}
+	return out
+}
+
+// observe records the pause kind in effect for a container, "" when it
+// runs, and returns the marks of any change.
+func (m *PauseMonitor) observe(name, kind string, pid uint32, now time.Time) []*events.Event {
+	prev := m.paused[name]
+	if kind == prev {
+		return nil
+	}
+	mark := func(kind, state string, pid uint32) *events.Event {
+		return &events.Event{
+			Type:      events.EventPause,
+			Timestamp: clock.WallToBPFTimestamp(now),
+			PID:       pid,
+			Target:    name,
+			Details:   events.PauseDetails{Kind: kind, State: state}.String(),
+		}
+	}
+	var out []*events.Event
+	if prev != "" {
+		out = append(out, mark(prev, events.PauseEnd, m.pids[name]))
+		delete(m.paused, name)
+	}
+	if kind != "" {
+		out = append(out, mark(kind, events.PauseStart, pid))
+		m.paused[name], m.pids[name] = kind, pid
+	}
+	return out
+}
+
+// cgroupFrozen reports whether the cgroup is frozen: "frozen 1" in its
+// cgroup.events under v2, or a FROZEN or FREEZING freezer.state under v1.
+// A cgroup whose parent is frozen reads as frozen too.
+func cgroupFrozen(cgroupPath string) (bool, error) {
+	if isCgroupV2(cgroupPath) {
+		data, err := readCgroupFile(filepath.Join(cgroupPath, "cgroup.events"))
+		if err != nil {
+			return false, err
+		}
+		for _, line := range strings.Split(data, "\n") {
+			if k, v, ok := strings.Cut(strings.TrimSpace(line), " "); ok && k == "frozen" {
+				return v == "1", nil
+			}
+		}
+		return false, nil
+	}
+	subpath, ok := cgroupV1Subpath(cgroupPath)
+	if !ok {
+		return false, fmt.Errorf("no cgroup v1 subpath in %s", cgroupPath)
+	}
+	state, err := readV1ControllerFile([]string{"freezer"}, subpath, "freezer.state")
+	if err != nil {
+		return false, err
+	}
+	state = strings.TrimSpace(state)
+	return state == "FROZEN" || state == "FREEZING", nil
+}
+
+// readMainPID returns the lowest PID in the cgroup's cgroup.procs, the
+// container's entrypoint, or 0.
+func readMainPID(cgroupPath string) uint32 {
+	data, err := readCgroupFile(filepath.Join(cgroupPath, "cgroup.procs"))
+	if err != nil {
+		return 0
+	}
+	var main uint32
+	for _, f := range strings.Fields(data) {
+		var pid uint32
+		if _, err := fmt.Sscanf(f, "%d", &pid); err == nil && pid > 0 && (main == 0 || pid < main) {
+			main = pid
+		}
+	}
+	return main
+}
+
+// readProcState returns the state letter of a process from its stat
+// file, or 0 when it cannot be read.
+func readProcState(pid uint32) byte {
+	data, err := os.ReadFile(filepath.Join(config.ProcBasePath, fmt.Sprint(pid), "stat")) // #nosec G304 -- /proc/<pid>/stat of a traced process
+	if err != nil {
+		return 0
+	}
+	return parseProcState(string(data))
+}
+
+// parseProcState reads the state letter that follows the parenthesized
+// command name in a /proc/<pid>/stat line, which may itself hold spaces
+// and parentheses.
+func parseProcState(stat string) byte {
+	rp := strings.LastIndexByte(stat, ')')
+	if rp < 0 {
+		return 0
+	}
+	rest := strings.TrimLeft(stat[rp+1:], " ")
+	if rest == "" {
+		return 0
+	}
+	return rest[0]
+}
+
+// stopKind maps a process state to the pause it is in, or "" when it is
+// not stopped.
+func stopKind(state byte) string {
+	switch state {
+	case 'T':
+		return events.PauseStop
+	case 't':
+		return events.PauseTraceStop
+	}
+	return ""
+}
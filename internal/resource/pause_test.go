@@ -0,0 +1,103 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestParseProcState(t *testing.T) {
+	for stat, want := range map[string]byte{
+		"7 (app) S 1 7 7 0":           'S',
+		"7 (my (odd) app) T 1 7 7 0":  'T',
+		"7 (gdb-held) t 1 7 7 0":      't',
+		"7 (truncated)":               0,
+		"garbage without parentheses": 0,
+	} {
+		if got := parseProcState(stat); got != want {
+			t.Errorf("parseProcState(%q) = %q, want %q", stat, got, want)
+		}
+	}
+}
+
+func TestPauseMonitor_Sample(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	procBase := t.TempDir()
+	origProc := config.ProcBasePath
+	config.ProcBasePath = procBase
+	t.Cleanup(func() { config.ProcBasePath = origProc })
+
+	cgroupPath := filepath.Join(base, "pod", "app")
+	if err := os.MkdirAll(cgroupPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(procBase, "7"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, data string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(base, "cgroup.controllers"), "cpu memory")
+	write(filepath.Join(cgroupPath, "cgroup.procs"), "42\n7\n")
+	write(filepath.Join(cgroupPath, "cgroup.events"), "populated 1\nfrozen 0\n")
+	write(filepath.Join(procBase, "7", "stat"), "7 (app) S 1 7 7 0")
+
+	m := NewPauseMonitor([]PauseTarget{{Name: "shop/cart-0/app", CgroupPath: cgroupPath}}, nil)
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	step := func(i int) []string {
+		t.Helper()
+		var out []string
+		for _, e := range m.sample(now.Add(time.Duration(i) * time.Second)) {
+			d := events.ParsePauseDetails(e.Details)
+			if e.Type != events.EventPause || e.Target != "shop/cart-0/app" || e.PID != 7 {
+				t.Errorf("event = %+v", e)
+			}
+			out = append(out, d.State+" "+d.Kind)
+		}
+		return out
+	}
+	expect := func(i int, want ...string) {
+		t.Helper()
+		got := step(i)
+		if len(got) != len(want) {
+			t.Fatalf("step %d: marks = %q, want %q", i, got, want)
+		}
+		for j := range got {
+			if got[j] != want[j] {
+				t.Fatalf("step %d: marks = %q, want %q", i, got, want)
+			}
+		}
+	}
+
+	expect(0)
+	write(filepath.Join(cgroupPath, "cgroup.events"), "populated 1\nfrozen 1\n")
+	expect(1, "start freeze")
+	expect(2)
+	// Thawed, but the main process was stopped meanwhile.
+	write(filepath.Join(cgroupPath, "cgroup.events"), "populated 1\nfrozen 0\n")
+	write(filepath.Join(procBase, "7", "stat"), "7 (app) T 1 7 7 0")
+	expect(3, "end freeze", "start sigstop")
+	write(filepath.Join(procBase, "7", "stat"), "7 (app) S 1 7 7 0")
+	expect(4, "end sigstop")
+}
+
+func TestCgroupFrozen_V1(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	path := writeCgroupV1Layout(t, base, "kubepods/podabc/app", map[string]map[string]string{
+		"freezer": {"freezer.state": "FROZEN\n"},
+		"memory":  {"memory.limit_in_bytes": "1048576"},
+	})
+	frozen, err := cgroupFrozen(path)
+	if err != nil || !frozen {
+		t.Errorf("cgroupFrozen = %v, %v; want frozen", frozen, err)
+	}
+}
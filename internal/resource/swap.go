@@ -0,0 +1,217 @@
+package resource
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// swapCounters are the cumulative swap counters of a cgroup. Cgroup v1 has
+// no per-cgroup swap-in/out counts, only the bytes in swap.
+type swapCounters struct {
+	swapInPages, swapOutPages   uint64
+	zswapInPages, zswapOutPages uint64
+	swapBytes, zswapBytes       uint64
+	// hasActivity is false when only the swap usage could be read.
+	hasActivity bool
+}
+
+// SwapSample is one interval of a cgroup's swap activity, as carried by an
+// EventSwap.
+type SwapSample struct {
+	IntervalNS uint64
+	// SwapInBytes and SwapOutBytes were paged in from and out to swap
+	// devices; ZswapInBytes and ZswapOutBytes to and from the compressed
+	// zswap pool, which costs CPU rather than disk I/O.
+	SwapInBytes, SwapOutBytes   uint64
+	ZswapInBytes, ZswapOutBytes uint64
+	// SwapBytes and ZswapBytes are resident in swap and in zswap at the end
+	// of the interval.
+	SwapBytes, ZswapBytes uint64
+	// MemoryLimitBytes is the cgroup's memory limit, 0 when unlimited.
+	MemoryLimitBytes uint64
+}
+
+// Active reports whether the cgroup moved pages to or from swap in the
+// interval.
+func (s SwapSample) Active() bool {
+	return s.SwapInBytes+s.SwapOutBytes+s.ZswapInBytes+s.ZswapOutBytes > 0
+}
+
+// Details keys of an EventSwap.
+const (
+	keySwapInterval = "interval_ns"
+	keySwapIn       = "swpin"
+	keySwapOut      = "swpout"
+	keyZswapIn      = "zswpin"
+	keyZswapOut     = "zswpout"
+	keySwap         = "swap"
+	keyZswap        = "zswap"
+	keyMemoryLimit  = "mem_limit"
+)
+
+// FormatSwapDetails encodes s as EventSwap Details: space-separated
+// key=value pairs, all in bytes but the interval.
+func FormatSwapDetails(s SwapSample) string {
+	pairs := []struct {
+		key   string
+		value uint64
+	}{
+		{keySwapInterval, s.IntervalNS},
+		{keySwapIn, s.SwapInBytes},
+		{keySwapOut, s.SwapOutBytes},
+		{keyZswapIn, s.ZswapInBytes},
+		{keyZswapOut, s.ZswapOutBytes},
+		{keySwap, s.SwapBytes},
+		{keyZswap, s.ZswapBytes},
+		{keyMemoryLimit, s.MemoryLimitBytes},
+	}
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p.key + "=" + strconv.FormatUint(p.value, 10)
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseSwapDetails is the inverse of FormatSwapDetails; unknown or malformed
+// keys are skipped.
+func ParseSwapDetails(details string) SwapSample {
+	var s SwapSample
+	fields := map[string]*uint64{
+		keySwapInterval: &s.IntervalNS,
+		keySwapIn:       &s.SwapInBytes,
+		keySwapOut:      &s.SwapOutBytes,
+		keyZswapIn:      &s.ZswapInBytes,
+		keyZswapOut:     &s.ZswapOutBytes,
+		keySwap:         &s.SwapBytes,
+		keyZswap:        &s.ZswapBytes,
+		keyMemoryLimit:  &s.MemoryLimitBytes,
+	}
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if dst := fields[k]; ok && dst != nil {
+			*dst, _ = strconv.ParseUint(v, 10, 64)
+		}
+	}
+	return s
+}
+
+// readSwapCounters reads the cgroup's swap counters: memory.stat's pswpin,
+// pswpout, zswpin, zswpout and zswap plus memory.swap.current on v2, the
+// memory.stat "swap" bytes on v1.
+func (rm *ResourceMonitor) readSwapCounters() (swapCounters, bool) {
+	var c swapCounters
+	if isCgroupV2(rm.cgroupPath) {
+		stat, err := readCgroupFile(filepath.Join(rm.cgroupPath, "memory.stat"))
+		if err != nil {
+			return c, false
+		}
+		fields := parseMemoryStat(stat)
+		c.swapInPages, c.swapOutPages = fields["pswpin"], fields["pswpout"]
+		c.zswapInPages, c.zswapOutPages = fields["zswpin"], fields["zswpout"]
+		c.zswapBytes = fields["zswap"]
+		_, c.hasActivity = fields["pswpout"]
+		// memory.swap.current is missing when swap accounting is off.
+		if current, err := readCgroupFile(filepath.Join(rm.cgroupPath, "memory.swap.current")); err == nil {
+			c.swapBytes, _ = strconv.ParseUint(strings.TrimSpace(current), 10, 64)
+		}
+		return c, true
+	}
+	subpath, ok := cgroupV1Subpath(rm.cgroupPath)
+	if !ok {
+		return c, false
+	}
+	stat, err := readV1ControllerFile(cgroupV1MemoryDirs, subpath, "memory.stat")
+	if err != nil {
+		return c, false
+	}
+	c.swapBytes = parseMemoryStat(stat)["swap"]
+	return c, true
+}
+
+// checkSwap emits one EventSwap per interval while the cgroup has pages in
+// swap or moves pages to or from it. Nodes without swap never produce one.
+// On v1, where only the swap usage is known, its growth stands in for the
+// swap-out traffic.
+func (rm *ResourceMonitor) checkSwap() {
+	counters, ok := rm.readSwapCounters()
+	if !ok {
+		return
+	}
+	rm.mu.Lock()
+	prev, known := rm.swap, rm.swapKnown
+	rm.swap, rm.swapKnown = counters, true
+	var limit uint64
+	if l, ok := rm.limits[ResourceMemory]; ok && l.LimitBytes != ^uint64(0) {
+		limit = l.LimitBytes
+	}
+	rm.mu.Unlock()
+	if !known || rm.eventChan == nil {
+		return
+	}
+
+	page := uint64(os.Getpagesize())
+	delta := func(cur, old uint64) uint64 {
+		if cur < old {
+			return 0
+		}
+		return cur - old
+	}
+	s := SwapSample{
+		IntervalNS:       uint64(rm.checkInterval),
+		SwapBytes:        counters.swapBytes,
+		ZswapBytes:       counters.zswapBytes,
+		MemoryLimitBytes: limit,
+	}
+	if counters.hasActivity {
+		s.SwapInBytes = delta(counters.swapInPages, prev.swapInPages) * page
+		s.SwapOutBytes = delta(counters.swapOutPages, prev.swapOutPages) * page
+		s.ZswapInBytes = delta(counters.zswapInPages, prev.zswapInPages) * page
+		s.ZswapOutBytes = delta(counters.zswapOutPages, prev.zswapOutPages) * page
+	} else {
+		s.SwapOutBytes = delta(counters.swapBytes, prev.swapBytes)
+	}
+	if !s.Active() && s.SwapBytes == 0 && s.ZswapBytes == 0 {
+		return
+	}
+
+	event := &events.Event{
+		Type:        events.EventSwap,
+		CgroupID:    rm.cgroupInode,
+		ProcessName: "cgroup",
+		LatencyNS:   s.IntervalNS,
+		Bytes:       s.SwapBytes,
+		Target:      rm.cgroupPath,
+		Details:     FormatSwapDetails(s),
+		Timestamp:   uint64(time.Now().UnixNano()),
+	}
+	select {
+	case rm.eventChan <- event:
+	default:
+		logger.Warn("Failed to send swap event, channel full", zap.String("cgroup_path", rm.cgroupPath))
+	}
+}
+
+// parseMemoryStat parses the "name value" lines of memory.stat.
+func parseMemoryStat(stat string) map[string]uint64 {
+	out := make(map[string]uint64)
+	scanner := bufio.NewScanner(strings.NewReader(stat))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseUint(parts[1], 10, 64); err == nil {
+			out[parts[0]] = v
+		}
+	}
+	return out
+}
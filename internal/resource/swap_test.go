@@ -0,0 +1,98 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestSwapDetailsRoundTrip(t *testing.T) {
+	s := SwapSample{
+		IntervalNS: 5e9, SwapInBytes: 1, SwapOutBytes: 2, ZswapInBytes: 3, ZswapOutBytes: 4,
+		SwapBytes: 5, ZswapBytes: 6, MemoryLimitBytes: 7,
+	}
+	if got := ParseSwapDetails(FormatSwapDetails(s)); !reflect.DeepEqual(got, s) {
+		t.Fatalf("round trip = %+v, want %+v", got, s)
+	}
+}
+
+func TestCheckSwap_V2(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	cgroupPath := filepath.Join(base, "pod")
+	if err := os.MkdirAll(cgroupPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(cgroupPath, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cgroup.controllers", "memory")
+	write("memory.max", "268435456")
+	write("memory.stat", "anon 100\npswpin 0\npswpout 0\nzswpin 0\nzswpout 0\nzswap 0\n")
+	write("memory.swap.current", "0")
+
+	eventChan := make(chan *events.Event, 4)
+	rm, err := NewResourceMonitor(cgroupPath, nil, nil, eventChan, "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first read only seeds the counters, and an idle cgroup without
+	// swap emits nothing.
+	rm.checkSwap()
+	rm.checkSwap()
+	if len(eventChan) != 0 {
+		t.Fatalf("unexpected swap event for a cgroup without swap: %+v", <-eventChan)
+	}
+
+	write("memory.stat", "anon 100\npswpin 2\npswpout 10\nzswpin 1\nzswpout 3\nzswap 4096\n")
+	write("memory.swap.current", "40960")
+	rm.checkSwap()
+	if len(eventChan) != 1 {
+		t.Fatalf("got %d swap events, want 1", len(eventChan))
+	}
+	e := <-eventChan
+	page := uint64(os.Getpagesize())
+	s := ParseSwapDetails(e.Details)
+	if e.Type != events.EventSwap || e.Bytes != 40960 || e.Target != cgroupPath {
+		t.Errorf("event = %+v", e)
+	}
+	if s.SwapOutBytes != 10*page || s.SwapInBytes != 2*page || s.ZswapOutBytes != 3*page || s.ZswapInBytes != page {
+		t.Errorf("traffic = %+v", s)
+	}
+	if s.ZswapBytes != 4096 || s.MemoryLimitBytes != 268435456 {
+		t.Errorf("usage = %+v", s)
+	}
+}
+
+func TestCheckSwap_V1UsesSwapGrowth(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	const subpath = "kubepods/pod-test"
+	cgroupPath := writeCgroupV1Layout(t, base, subpath, map[string]map[string]string{
+		"memory": {"memory.limit_in_bytes": "1073741824", "memory.stat": "cache 0\nswap 4096\n"},
+	})
+	eventChan := make(chan *events.Event, 4)
+	rm, err := NewResourceMonitor(cgroupPath, nil, nil, eventChan, "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm.checkSwap()
+	if err := os.WriteFile(filepath.Join(base, "memory", subpath, "memory.stat"), []byte("cache 0\nswap 16384\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rm.checkSwap()
+	if len(eventChan) != 1 {
+		t.Fatalf("got %d swap events, want 1", len(eventChan))
+	}
+	s := ParseSwapDetails((<-eventChan).Details)
+	if s.SwapOutBytes != 12288 || s.SwapBytes != 16384 || s.MemoryLimitBytes != 1073741824 {
+		t.Errorf("sample = %+v", s)
+	}
+}
@@ -0,0 +1,127 @@
+package resource
+
+import (
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// TmpfsSample is one interval of a cgroup's tmpfs and shared memory usage,
+// as carried by an EventTmpfs. Files in a tmpfs, such as an emptyDir with
+// medium Memory or /dev/shm, are memory charged to the cgroup that wrote
+// them.
+type TmpfsSample struct {
+	IntervalNS uint64
+	// ShmemBytes is the cgroup's tmpfs and shared memory at the end of the
+	// interval.
+	ShmemBytes uint64
+	// MemoryBytes is the cgroup's memory usage, tmpfs included.
+	MemoryBytes uint64
+	// MemoryLimitBytes is the cgroup's memory limit, 0 when unlimited.
+	MemoryLimitBytes uint64
+}
+
+// Details keys of an EventTmpfs.
+const (
+	keyTmpfsInterval = "interval_ns"
+	keyShmem         = "shmem"
+	keyMemory        = "mem"
+)
+
+// FormatTmpfsDetails encodes s as EventTmpfs Details: space-separated
+// key=value pairs, all in bytes but the interval.
+func FormatTmpfsDetails(s TmpfsSample) string {
+	return strings.Join([]string{
+		keyTmpfsInterval + "=" + strconv.FormatUint(s.IntervalNS, 10),
+		keyShmem + "=" + strconv.FormatUint(s.ShmemBytes, 10),
+		keyMemory + "=" + strconv.FormatUint(s.MemoryBytes, 10),
+		keyMemoryLimit + "=" + strconv.FormatUint(s.MemoryLimitBytes, 10),
+	}, " ")
+}
+
+// ParseTmpfsDetails is the inverse of FormatTmpfsDetails; unknown or
+// malformed keys are skipped.
+func ParseTmpfsDetails(details string) TmpfsSample {
+	var s TmpfsSample
+	fields := map[string]*uint64{
+		keyTmpfsInterval: &s.IntervalNS,
+		keyShmem:         &s.ShmemBytes,
+		keyMemory:        &s.MemoryBytes,
+		keyMemoryLimit:   &s.MemoryLimitBytes,
+	}
+	for _, f := range strings.Fields(details) {
+		k, v, ok := strings.Cut(f, "=")
+		if dst := fields[k]; ok && dst != nil {
+			*dst, _ = strconv.ParseUint(v, 10, 64)
+		}
+	}
+	return s
+}
+
+// readShmem reads the "shmem" field of the cgroup's memory.stat, on v2 and
+// v1 alike.
+func (rm *ResourceMonitor) readShmem() (uint64, bool) {
+	var stat string
+	var err error
+	if isCgroupV2(rm.cgroupPath) {
+		stat, err = readCgroupFile(filepath.Join(rm.cgroupPath, "memory.stat"))
+	} else {
+		subpath, ok := cgroupV1Subpath(rm.cgroupPath)
+		if !ok {
+			return 0, false
+		}
+		stat, err = readV1ControllerFile(cgroupV1MemoryDirs, subpath, "memory.stat")
+	}
+	if err != nil {
+		return 0, false
+	}
+	shmem, ok := parseMemoryStat(stat)["shmem"]
+	return shmem, ok
+}
+
+// checkTmpfs emits one EventTmpfs per interval while the cgroup holds
+// memory in tmpfs, so its growth against the memory limit can be followed.
+// Cgroups that never write to a tmpfs never produce one.
+func (rm *ResourceMonitor) checkTmpfs() {
+	shmem, ok := rm.readShmem()
+	if !ok || rm.eventChan == nil {
+		return
+	}
+	rm.mu.Lock()
+	held := rm.tmpfsHeld
+	rm.tmpfsHeld = shmem > 0
+	s := TmpfsSample{IntervalNS: uint64(rm.checkInterval), ShmemBytes: shmem}
+	if l, ok := rm.limits[ResourceMemory]; ok {
+		s.MemoryBytes = l.UsageBytes
+		if l.LimitBytes != ^uint64(0) {
+			s.MemoryLimitBytes = l.LimitBytes
+		}
+	}
+	rm.mu.Unlock()
+	// A tmpfs emptied during the trace reports the drop to zero once.
+	if shmem == 0 && !held {
+		return
+	}
+
+	event := &events.Event{
+		Type:        events.EventTmpfs,
+		CgroupID:    rm.cgroupInode,
+		ProcessName: "cgroup",
+		LatencyNS:   s.IntervalNS,
+		Bytes:       s.ShmemBytes,
+		Target:      rm.cgroupPath,
+		Details:     FormatTmpfsDetails(s),
+		Timestamp:   uint64(time.Now().UnixNano()),
+	}
+	select {
+	case rm.eventChan <- event:
+	default:
+		logger.Warn("Failed to send tmpfs event, channel full", zap.String("cgroup_path", rm.cgroupPath))
+	}
+}
@@ -0,0 +1,76 @@
+package resource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestTmpfsDetailsRoundTrip(t *testing.T) {
+	s := TmpfsSample{IntervalNS: 5e9, ShmemBytes: 1, MemoryBytes: 2, MemoryLimitBytes: 3}
+	if got := ParseTmpfsDetails(FormatTmpfsDetails(s)); !reflect.DeepEqual(got, s) {
+		t.Fatalf("round trip = %+v, want %+v", got, s)
+	}
+}
+
+func TestCheckTmpfs_V2(t *testing.T) {
+	base := t.TempDir()
+	useCgroupBase(t, base)
+	cgroupPath := filepath.Join(base, "pod")
+	if err := os.MkdirAll(cgroupPath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, data string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(cgroupPath, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("cgroup.controllers", "memory")
+	write("memory.max", "268435456")
+	write("memory.current", "104857600")
+	write("memory.stat", "anon 100\nshmem 0\n")
+
+	eventChan := make(chan *events.Event, 4)
+	rm, err := NewResourceMonitor(cgroupPath, nil, nil, eventChan, "ns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.updateResourceUsage(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A cgroup without tmpfs usage emits nothing.
+	rm.checkTmpfs()
+	if len(eventChan) != 0 {
+		t.Fatalf("unexpected tmpfs event for a cgroup without tmpfs: %+v", <-eventChan)
+	}
+
+	write("memory.stat", "anon 100\nshmem 67108864\n")
+	rm.checkTmpfs()
+	if len(eventChan) != 1 {
+		t.Fatalf("got %d tmpfs events, want 1", len(eventChan))
+	}
+	e := <-eventChan
+	s := ParseTmpfsDetails(e.Details)
+	if e.Type != events.EventTmpfs || e.Bytes != 67108864 || e.Target != cgroupPath {
+		t.Errorf("event = %+v", e)
+	}
+	if s.ShmemBytes != 67108864 || s.MemoryBytes != 104857600 || s.MemoryLimitBytes != 268435456 {
+		t.Errorf("sample = %+v", s)
+	}
+
+	// Emptying the tmpfs reports the drop once, then nothing.
+	write("memory.stat", "anon 100\nshmem 0\n")
+	rm.checkTmpfs()
+	rm.checkTmpfs()
+	if len(eventChan) != 1 {
+		t.Fatalf("got %d tmpfs events after emptying, want 1", len(eventChan))
+	}
+	if e := <-eventChan; e.Bytes != 0 {
+		t.Errorf("drop event = %+v", e)
+	}
+}
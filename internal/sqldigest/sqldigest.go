@@ -0,0 +1,132 @@
+// Package sqldigest reduces SQL statements to a literal-free shape so that
+// executions of the same statement with different values group together,
+// and so that the values themselves never leave the tracer.
+package sqldigest
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// Normalize replaces string and numeric literals with '?', drops comments,
+// puts single spaces between tokens and folds literal lists such as
+// "IN (?, ?, ?)" to "IN (?)". Bind placeholders ($1, ?) are kept.
+// Truncated input (an unterminated literal or comment) is handled.
+func Normalize(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	last := ""
+	emit := func(tok string) {
+		if b.Len() > 0 && last != "." && last != "(" && tok != "." && tok != ")" && tok != "," {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok)
+		last = tok
+	}
+	for i := 0; i < len(query); {
+		c := query[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '-' && i+1 < len(query) && query[i+1] == '-':
+			for i < len(query) && query[i] != '\n' {
+				i++
+			}
+		case c == '/' && i+1 < len(query) && query[i+1] == '*':
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 4
+			}
+		case c == '\'':
+			i = skipQuoted(query, i)
+			emit("?")
+		case c == '$' && i+1 < len(query) && isDigit(query[i+1]):
+			j := i + 1
+			for j < len(query) && isDigit(query[j]) {
+				j++
+			}
+			emit(query[i:j])
+			i = j
+		case isDigit(c):
+			j := i
+			for j < len(query) && (isDigit(query[j]) || query[j] == '.' || query[j] == 'e' || query[j] == 'E') {
+				j++
+			}
+			emit("?")
+			i = j
+		case c == '"':
+			j := i + 1
+			for j < len(query) && query[j] != '"' {
+				j++
+			}
+			if j < len(query) {
+				j++
+			}
+			emit(query[i:j])
+			i = j
+		case isIdent(c):
+			j := i + 1
+			for j < len(query) && isIdent(query[j]) {
+				j++
+			}
+			emit(query[i:j])
+			i = j
+		case strings.IndexByte(operatorChars, c) >= 0:
+			j := i + 1
+			for j < len(query) && strings.IndexByte(operatorChars, query[j]) >= 0 && !startsComment(query, j) {
+				j++
+			}
+			emit(query[i:j])
+			i = j
+		default:
+			emit(string(c))
+			i++
+		}
+	}
+	return foldLists(b.String())
+}
+
+const operatorChars = "<>=!|&:+-*/%~^"
+
+func startsComment(s string, i int) bool {
+	return i+1 < len(s) && (s[i] == '-' && s[i+1] == '-' || s[i] == '/' && s[i+1] == '*')
+}
+
+// Digest returns a short stable identifier for a normalized statement.
+func Digest(normalized string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(normalized))
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// skipQuoted returns the index just past the quoted literal starting at i,
+// honouring the doubled-quote escape.
+func skipQuoted(s string, i int) int {
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == '\'' {
+			if j+1 < len(s) && s[j+1] == '\'' {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// foldLists collapses "?, ?, ?" to "?".
+func foldLists(s string) string {
+	for strings.Contains(s, "?, ?") {
+		s = strings.ReplaceAll(s, "?, ?", "?")
+	}
+	return s
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isIdent(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || isDigit(c) || c >= 0x80
+}
@@ -0,0 +1,35 @@
+package sqldigest
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{
+		"SELECT * FROM users WHERE id = 42":                          "SELECT * FROM users WHERE id = ?",
+		"select name from t where email='a@b.c' and n > 1.5e3":       "select name from t where email = ? and n > ?",
+		"SELECT 1 FROM t WHERE id IN (1,2, 3)":                       "SELECT ? FROM t WHERE id IN (?)",
+		"UPDATE t SET v = $1 WHERE id = $2":                          "UPDATE t SET v = $1 WHERE id = $2",
+		"SELECT col2 FROM \"Tab1\" -- trailing comment\n  WHERE x=1": "SELECT col2 FROM \"Tab1\" WHERE x = ?",
+		"/* app:api */ INSERT INTO t VALUES ('it''s', 7)":            "INSERT INTO t VALUES (?)",
+		"SELECT * FROM t WHERE s = 'truncat":                         "SELECT * FROM t WHERE s = ?",
+	}
+	for in, want := range cases {
+		if got := Normalize(in); got != want {
+			t.Errorf("Normalize(%q)\n got %q\nwant %q", in, got, want)
+		}
+	}
+}
+
+func TestDigest_GroupsLiteralVariants(t *testing.T) {
+	a := Digest(Normalize("SELECT * FROM orders WHERE id = 1"))
+	b := Digest(Normalize("SELECT *  FROM orders WHERE id = 99"))
+	c := Digest(Normalize("SELECT * FROM orders WHERE user_id = 1"))
+	if a != b {
+		t.Errorf("literal variants should share a digest: %s vs %s", a, b)
+	}
+	if a == c {
+		t.Error("different statements should not share a digest")
+	}
+	if len(a) != 16 {
+		t.Errorf("digest %q should be 16 hex chars", a)
+	}
+}
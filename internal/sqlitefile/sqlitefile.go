@@ -0,0 +1,366 @@
+// Package sqlitefile writes a SQLite 3 database file without cgo or a SQL
+// engine: the tables are bulk-loaded as B-trees straight into the file
+// format (https://www.sqlite.org/fileformat.html). The result opens in
+// sqlite3, DuckDB's SQLite scanner and any BI tool with a SQLite driver.
+//
+// Only what an export needs is written: rowid tables without indexes, in
+// UTF-8, built once. Nothing here reads a database back.
+package sqlitefile
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+const (
+	pageSize = 4096
+	// headerSize is the database header at the start of page 1.
+	headerSize = 100
+
+	pageInteriorTable = 0x05
+	pageLeafTable     = 0x0d
+
+	leafHeaderSize     = 8
+	interiorHeaderSize = 12
+
+	// maxLocal and minLocal bound the part of a table leaf cell's payload
+	// kept on the page; the rest goes to overflow pages.
+	maxLocal = pageSize - 35
+	minLocal = (pageSize-12)*32/255 - 23
+)
+
+// Column is one column of a table. Type is its declared SQL type (INTEGER,
+// REAL, TEXT or BLOB).
+type Column struct {
+	Name string
+	Type string
+}
+
+// Table is a table and its rows. A row holds one value per column: nil,
+// int, int64, uint32, uint64, float64, bool, string or []byte.
+type Table struct {
+	Name    string
+	Columns []Column
+	Rows    [][]any
+}
+
+// Write writes a database holding tables to w.
+func Write(w io.Writer, tables []Table) error {
+	b := &builder{pages: [][]byte{make([]byte, pageSize)}}
+	schema := Table{Name: "sqlite_schema"}
+	for _, t := range tables {
+		if err := t.validate(); err != nil {
+			return err
+		}
+		root, err := b.buildTable(t.Rows, 0)
+		if err != nil {
+			return fmt.Errorf("sqlitefile: table %s: %w", t.Name, err)
+		}
+		schema.Rows = append(schema.Rows, []any{"table", t.Name, t.Name, int64(root), t.createSQL()})
+	}
+	// The schema table is rooted at page 1, after the database header.
+	if _, err := b.buildTable(schema.Rows, 1); err != nil {
+		return fmt.Errorf("sqlitefile: schema: %w", err)
+	}
+	b.writeHeader()
+	for _, p := range b.pages {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Table) validate() error {
+	if t.Name == "" || len(t.Columns) == 0 {
+		return errors.New("sqlitefile: a table needs a name and columns")
+	}
+	for i, row := range t.Rows {
+		if len(row) != len(t.Columns) {
+			return fmt.Errorf("sqlitefile: table %s: row %d has %d values for %d columns", t.Name, i+1, len(row), len(t.Columns))
+		}
+	}
+	return nil
+}
+
+func (t Table) createSQL() string {
+	cols := make([]string, len(t.Columns))
+	for i, c := range t.Columns {
+		cols[i] = quoteIdent(c.Name) + " " + c.Type
+	}
+	return "CREATE TABLE " + quoteIdent(t.Name) + " (" + strings.Join(cols, ", ") + ")"
+}
+
+func quoteIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+type builder struct {
+	// pages[i] is page i+1; page 1 is reserved for the schema.
+	pages [][]byte
+}
+
+func (b *builder) alloc() (uint32, []byte) {
+	p := make([]byte, pageSize)
+	b.pages = append(b.pages, p)
+	return uint32(len(b.pages)), p
+}
+
+// child is a built page and the largest rowid under it.
+type child struct {
+	page   uint32
+	maxKey int64
+}
+
+// buildTable writes rows as a table B-tree with rowids 1..n and returns its
+// root page. With root set, the tree must fit on that one page.
+func (b *builder) buildTable(rows [][]any, root uint32) (uint32, error) {
+	var leaves []child
+	var cells [][]byte
+	used := 0
+	flush := func(maxKey int64) {
+		var page uint32
+		var buf []byte
+		if root != 0 {
+			page, buf = root, b.pages[root-1]
+		} else {
+			page, buf = b.alloc()
+		}
+		writeBTreePage(buf, offsetOf(page), pageLeafTable, cells, 0)
+		leaves = append(leaves, child{page, maxKey})
+		cells, used = nil, 0
+	}
+	capacity := pageSize - leafHeaderSize
+	if root == 1 {
+		capacity -= headerSize
+	}
+	for i, row := range rows {
+		rowid := int64(i + 1)
+		cell := b.leafCell(rowid, encodeRecord(row))
+		if used+len(cell)+2 > capacity {
+			if root != 0 {
+				return 0, errors.New("too large for its page")
+			}
+			flush(rowid - 1)
+		}
+		cells = append(cells, cell)
+		used += len(cell) + 2
+	}
+	flush(int64(len(rows)))
+
+	level := leaves
+	for len(level) > 1 {
+		var next []child
+		for len(level) > 0 {
+			var cells [][]byte
+			used := 0
+			n := 0
+			// The last child of a page is its right-most pointer, not a cell.
+			for n < len(level)-1 {
+				cell := binary.BigEndian.AppendUint32(nil, level[n].page)
+				cell = appendVarint(cell, uint64(level[n].maxKey))
+				if used+len(cell)+2 > pageSize-interiorHeaderSize {
+					break
+				}
+				cells = append(cells, cell)
+				used += len(cell) + 2
+				n++
+			}
+			right := level[n]
+			page, buf := b.alloc()
+			writeBTreePage(buf, 0, pageInteriorTable, cells, right.page)
+			next = append(next, child{page, right.maxKey})
+			level = level[n+1:]
+		}
+		level = next
+	}
+	return level[0].page, nil
+}
+
+// leafCell builds a table leaf cell, spilling what does not fit on the page
+// to a chain of overflow pages.
+func (b *builder) leafCell(rowid int64, payload []byte) []byte {
+	cell := appendVarint(nil, uint64(len(payload)))
+	cell = appendVarint(cell, uint64(rowid))
+	local := len(payload)
+	if local > maxLocal {
+		local = minLocal + (len(payload)-minLocal)%(pageSize-4)
+		if local > maxLocal {
+			local = minLocal
+		}
+	}
+	cell = append(cell, payload[:local]...)
+	if local == len(payload) {
+		return cell
+	}
+	rest := payload[local:]
+	first, buf := b.alloc()
+	for {
+		n := copy(buf[4:], rest)
+		rest = rest[n:]
+		if len(rest) == 0 {
+			break
+		}
+		next, nextBuf := b.alloc()
+		binary.BigEndian.PutUint32(buf, next)
+		buf = nextBuf
+	}
+	return binary.BigEndian.AppendUint32(cell, first)
+}
+
+func offsetOf(page uint32) int {
+	if page == 1 {
+		return headerSize
+	}
+	return 0
+}
+
+// writeBTreePage lays a B-tree page out in buf: the header at off, the cell
+// pointers after it and the cells packed at the end of the page.
+func writeBTreePage(buf []byte, off int, kind byte, cells [][]byte, right uint32) {
+	hdr := leafHeaderSize
+	if kind == pageInteriorTable {
+		hdr = interiorHeaderSize
+	}
+	buf[off] = kind
+	binary.BigEndian.PutUint16(buf[off+3:], uint16(len(cells)))
+	if kind == pageInteriorTable {
+		binary.BigEndian.PutUint32(buf[off+8:], right)
+	}
+	end := pageSize
+	for i, c := range cells {
+		end -= len(c)
+		copy(buf[end:], c)
+		binary.BigEndian.PutUint16(buf[off+hdr+2*i:], uint16(end))
+	}
+	// A content area starting at 65536 is stored as 0; pages here are
+	// smaller, so the value always fits.
+	binary.BigEndian.PutUint16(buf[off+5:], uint16(end))
+}
+
+func (b *builder) writeHeader() {
+	h := b.pages[0]
+	copy(h, "SQLite format 3\x00")
+	binary.BigEndian.PutUint16(h[16:], pageSize)
+	h[18], h[19] = 1, 1 // legacy (rollback journal) read and write versions
+	h[21], h[22], h[23] = 64, 32, 32
+	binary.BigEndian.PutUint32(h[24:], 1) // file change counter
+	binary.BigEndian.PutUint32(h[28:], uint32(len(b.pages)))
+	binary.BigEndian.PutUint32(h[40:], 1) // schema cookie
+	binary.BigEndian.PutUint32(h[44:], 4) // schema format
+	binary.BigEndian.PutUint32(h[56:], 1) // UTF-8
+	binary.BigEndian.PutUint32(h[92:], 1) // version-valid-for, the change counter
+	binary.BigEndian.PutUint32(h[96:], 3046000)
+}
+
+// encodeRecord encodes values in the record format: a header of serial
+// types followed by the values.
+func encodeRecord(values []any) []byte {
+	var types, body []byte
+	for _, v := range values {
+		var t uint64
+		t, body = appendValue(body, v)
+		types = appendVarint(types, t)
+	}
+	hdrLen := len(types) + 1
+	for varintLen(uint64(hdrLen))+len(types) != hdrLen {
+		hdrLen = varintLen(uint64(hdrLen)) + len(types)
+	}
+	rec := appendVarint(make([]byte, 0, hdrLen+len(body)), uint64(hdrLen))
+	rec = append(rec, types...)
+	return append(rec, body...)
+}
+
+// appendValue appends v's body and returns its serial type.
+func appendValue(body []byte, v any) (uint64, []byte) {
+	switch v := v.(type) {
+	case nil:
+		return 0, body
+	case bool:
+		if v {
+			return 9, body
+		}
+		return 8, body
+	case int:
+		return appendInt(body, int64(v))
+	case int64:
+		return appendInt(body, v)
+	case uint32:
+		return appendInt(body, int64(v))
+	case uint64:
+		if v > math.MaxInt64 {
+			return appendValue(body, float64(v))
+		}
+		return appendInt(body, int64(v))
+	case float64:
+		return 7, binary.BigEndian.AppendUint64(body, math.Float64bits(v))
+	case string:
+		return uint64(13 + 2*len(v)), append(body, v...)
+	case []byte:
+		return uint64(12 + 2*len(v)), append(body, v...)
+	}
+	return appendValue(body, fmt.Sprint(v))
+}
+
+func appendInt(body []byte, v int64) (uint64, []byte) {
+	switch {
+	case v == 0:
+		return 8, body
+	case v == 1:
+		return 9, body
+	}
+	for _, s := range []struct {
+		typ   uint64
+		bytes int
+	}{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 6}} {
+		bits := uint(8 * s.bytes)
+		if v >= -1<<(bits-1) && v < 1<<(bits-1) {
+			for i := s.bytes - 1; i >= 0; i-- {
+				body = append(body, byte(v>>(8*uint(i))))
+			}
+			return s.typ, body
+		}
+	}
+	return 6, binary.BigEndian.AppendUint64(body, uint64(v))
+}
+
+// appendVarint appends v as a SQLite varint: big-endian groups of 7 bits,
+// with a ninth byte that carries 8.
+func appendVarint(b []byte, v uint64) []byte {
+	if v > 1<<56-1 {
+		var buf [9]byte
+		buf[8] = byte(v)
+		v >>= 8
+		for i := 7; i >= 0; i-- {
+			buf[i] = byte(v&0x7f) | 0x80
+			v >>= 7
+		}
+		return append(b, buf[:]...)
+	}
+	var groups [8]byte
+	n := 0
+	for {
+		groups[n] = byte(v & 0x7f)
+		n++
+		v >>= 7
+		if v == 0 {
+			break
+		}
+	}
+	for i := n - 1; i >= 0; i-- {
+		c := groups[i]
+		if i > 0 {
+			c |= 0x80
+		}
+		b = append(b, c)
+	}
+	return b
+}
+
+func varintLen(v uint64) int {
+	return len(appendVarint(nil, v))
+}
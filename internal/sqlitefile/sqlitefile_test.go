@@ -0,0 +1,190 @@
+package sqlitefile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// reader walks the table B-trees of a database file, as a check of the
+// layout Write produces.
+type reader struct {
+	t    *testing.T
+	data []byte
+}
+
+func (r reader) page(n uint32) []byte {
+	return r.data[(n-1)*pageSize : n*pageSize]
+}
+
+func (r reader) rows(root uint32) [][]any {
+	p := r.page(root)
+	off := offsetOf(root)
+	cells := int(binary.BigEndian.Uint16(p[off+3:]))
+	var out [][]any
+	switch p[off] {
+	case pageLeafTable:
+		for i := 0; i < cells; i++ {
+			c := p[binary.BigEndian.Uint16(p[off+leafHeaderSize+2*i:]):]
+			size, n := readVarint(c)
+			_, m := readVarint(c[n:])
+			c = c[n+m:]
+			local := int(size)
+			if local > maxLocal {
+				local = minLocal + (int(size)-minLocal)%(pageSize-4)
+				if local > maxLocal {
+					local = minLocal
+				}
+			}
+			payload := append([]byte(nil), c[:local]...)
+			if local < int(size) {
+				for next := binary.BigEndian.Uint32(c[local:]); next != 0; {
+					ov := r.page(next)
+					payload = append(payload, ov[4:]...)
+					next = binary.BigEndian.Uint32(ov)
+				}
+				payload = payload[:size]
+			}
+			out = append(out, decodeRecord(r.t, payload))
+		}
+	case pageInteriorTable:
+		for i := 0; i < cells; i++ {
+			c := p[binary.BigEndian.Uint16(p[off+interiorHeaderSize+2*i:]):]
+			out = append(out, r.rows(binary.BigEndian.Uint32(c))...)
+		}
+		out = append(out, r.rows(binary.BigEndian.Uint32(p[off+8:]))...)
+	default:
+		r.t.Fatalf("page %d has type %#x", root, p[off])
+	}
+	return out
+}
+
+func readVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return v<<8 | uint64(b[8]), 9
+}
+
+func decodeRecord(t *testing.T, rec []byte) []any {
+	hdrLen, n := readVarint(rec)
+	hdr, body := rec[n:hdrLen], rec[hdrLen:]
+	var out []any
+	for len(hdr) > 0 {
+		typ, m := readVarint(hdr)
+		hdr = hdr[m:]
+		switch {
+		case typ == 0:
+			out = append(out, nil)
+		case typ == 8, typ == 9:
+			out = append(out, int64(typ-8))
+		case typ <= 6:
+			size := []int{0, 1, 2, 3, 4, 6, 8}[typ]
+			v := int64(int8(body[0]))
+			for _, c := range body[1:size] {
+				v = v<<8 | int64(c)
+			}
+			out = append(out, v)
+			body = body[size:]
+		case typ == 7:
+			out = append(out, math.Float64frombits(binary.BigEndian.Uint64(body)))
+			body = body[8:]
+		case typ >= 12:
+			size := int(typ-12) / 2
+			if typ%2 == 1 {
+				out = append(out, string(body[:size]))
+			} else {
+				out = append(out, append([]byte(nil), body[:size]...))
+			}
+			body = body[size:]
+		default:
+			t.Fatalf("serial type %d", typ)
+		}
+	}
+	return out
+}
+
+func TestWrite(t *testing.T) {
+	big := strings.Repeat("x", 3*pageSize+17)
+	events := Table{
+		Name:    "events",
+		Columns: []Column{{"id", "INTEGER"}, {"target", "TEXT"}, {"latency_ms", "REAL"}, {"ok", "INTEGER"}},
+	}
+	const rows = 20000
+	for i := 0; i < rows; i++ {
+		events.Rows = append(events.Rows, []any{int64(i) * 1_000_003 * int64(1-2*(i%2)), "db:5432", float64(i) / 4, i%3 == 0})
+	}
+	events.Rows[7][1] = big
+	events.Rows[8][1] = nil
+	meta := Table{Name: `odd "name"`, Columns: []Column{{"key", "TEXT"}, {"value", "BLOB"}}, Rows: [][]any{{"k", []byte{0, 1, 2}}}}
+	empty := Table{Name: "issues", Columns: []Column{{"code", "TEXT"}}}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, []Table{events, meta, empty}); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	if !bytes.HasPrefix(data, []byte("SQLite format 3\x00")) || len(data)%pageSize != 0 {
+		t.Fatalf("not a database file: %d bytes", len(data))
+	}
+	if got := binary.BigEndian.Uint32(data[28:]); int(got) != len(data)/pageSize {
+		t.Errorf("header page count %d, file has %d", got, len(data)/pageSize)
+	}
+
+	r := reader{t: t, data: data}
+	schema := r.rows(1)
+	if len(schema) != 3 {
+		t.Fatalf("schema = %v", schema)
+	}
+	if want := `CREATE TABLE "odd ""name""" ("key" TEXT, "value" BLOB)`; schema[1][4] != want {
+		t.Errorf("sql = %v", schema[1][4])
+	}
+
+	got := r.rows(uint32(schema[0][3].(int64)))
+	if len(got) != rows {
+		t.Fatalf("read %d rows, want %d", len(got), rows)
+	}
+	for i, row := range got {
+		want := events.Rows[i]
+		ok := int64(0)
+		if want[3].(bool) {
+			ok = 1
+		}
+		if row[0] != want[0] || row[1] != want[1] && !(want[1] == nil && row[1] == nil) || row[2] != want[2] || row[3] != ok {
+			t.Fatalf("row %d = %.60v, want %.60v", i, row, want)
+		}
+	}
+	if got := r.rows(uint32(schema[1][3].(int64))); !reflect.DeepEqual(got, [][]any{{"k", []byte{0, 1, 2}}}) {
+		t.Errorf("meta rows = %v", got)
+	}
+	if got := r.rows(uint32(schema[2][3].(int64))); len(got) != 0 {
+		t.Errorf("empty table rows = %v", got)
+	}
+}
+
+func TestWriteRejectsBadTables(t *testing.T) {
+	for _, tables := range [][]Table{
+		{{Name: "t"}},
+		{{Name: "t", Columns: []Column{{"a", "TEXT"}}, Rows: [][]any{{"x", "y"}}}},
+	} {
+		if err := Write(&bytes.Buffer{}, tables); err == nil {
+			t.Errorf("Write(%v) succeeded", tables)
+		}
+	}
+}
+
+func TestAppendVarint(t *testing.T) {
+	for _, v := range []uint64{0, 0x7f, 0x80, 0x3fff, 0x4000, 1<<56 - 1, 1 << 56, math.MaxUint64} {
+		b := appendVarint(nil, v)
+		if got, n := readVarint(b); got != v || n != len(b) {
+			t.Errorf("varint %#x: read %#x in %d of %d bytes", v, got, n, len(b))
+		}
+	}
+}
@@ -22,6 +22,7 @@ package sysfs
 import (
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"sync"
 	"sync/atomic"
@@ -97,6 +98,15 @@ func CgroupStat(rel string) (os.FileInfo, error) {
 	return r.Stat(rel)
 }
 
+// CgroupReadDir lists a directory under the cgroup root.
+func CgroupReadDir(rel string) ([]fs.DirEntry, error) {
+	r, err := cgroupRoot()
+	if err != nil {
+		return nil, fmt.Errorf("sysfs: open cgroup root %s: %w", config.CgroupBasePath, err)
+	}
+	return fs.ReadDir(r.FS(), rel)
+}
+
 // CgroupRelative returns the relative path of a fully-qualified cgroup
 // path against config.CgroupBasePath. Returns ("", false) when the
 // argument is not actually under the configured base. Use when older
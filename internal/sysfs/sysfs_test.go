@@ -56,6 +56,24 @@ func TestCgroupOpen_RoundTrip(t *testing.T) {
 	defer func() { _ = f.Close() }()
 }
 
+func TestCgroupReadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "kubepods/pod-x"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	withCgroupBase(t, dir)
+	entries, err := CgroupReadDir("kubepods")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "pod-x" || !entries[0].IsDir() {
+		t.Errorf("entries = %v", entries)
+	}
+	if _, err := CgroupReadDir("../etc"); err == nil {
+		t.Fatal("traversal must be rejected")
+	}
+}
+
 func TestCgroupStat_Subdir(t *testing.T) {
 	dir := t.TempDir()
 	if err := os.MkdirAll(filepath.Join(dir, "kubepods/pod-x"), 0o755); err != nil {
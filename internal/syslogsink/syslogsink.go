@@ -0,0 +1,379 @@
+// Package syslogsink sends podtrace's events and detected issues to a remote
+// syslog receiver as RFC 5424 messages, over UDP, TCP or TLS (RFC 5425), for
+// SIEMs such as QRadar or ArcSight that only ingest syslog.
+package syslogsink
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/diagnose/hooks"
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/fips"
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// Severities of RFC 5424 used for podtrace's messages.
+const (
+	SeverityError   = 3
+	SeverityWarning = 4
+	SeverityNotice  = 5
+	SeverityInfo    = 6
+)
+
+// sdID is the structured-data ID of podtrace's parameters. 32473 is the
+// enterprise number RFC 5612 reserves for documentation and examples.
+const sdID = "podtrace@32473"
+
+const (
+	appName   = "podtrace"
+	queueSize = 4096
+	// redialBackoff is how long a stream transport waits after a failed
+	// dial before it tries again; messages meanwhile are dropped.
+	redialBackoff = 5 * time.Second
+	// writeTimeout bounds a dial and a write to the receiver.
+	writeTimeout = 5 * time.Second
+)
+
+var facilities = map[string]int{
+	"kern": 0, "user": 1, "daemon": 3, "auth": 4, "syslog": 5, "authpriv": 10,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// Sink writes RFC 5424 messages to one syslog receiver. Messages are queued
+// and written by a background goroutine, so a slow or unreachable receiver
+// drops messages instead of stalling the trace.
+type Sink struct {
+	network  string
+	addr     string
+	tls      *tls.Config
+	facility int
+	// octetCounting frames stream messages as "LEN SP MSG" (RFC 6587,
+	// RFC 5425) rather than ending them with a newline.
+	octetCounting bool
+	hostname      string
+	procID        string
+
+	// mu guards closed, so nothing is queued once Close closed the queue.
+	mu      sync.RWMutex
+	closed  bool
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	conn     net.Conn
+	nextDial time.Time
+}
+
+// Open parses a syslog URL and connects to the receiver:
+// udp://host:514, tcp://host:601 or tls://host:6514. The query may set
+// facility (default local0), framing=lf for newline-terminated TCP
+// messages, and ca, a PEM file to verify a TLS receiver with instead of
+// the system roots.
+func Open(rawURL string) (*Sink, error) {
+	s, err := parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.dial(); err != nil {
+		return nil, fmt.Errorf("syslog: connect to %s: %w", s.addr, err)
+	}
+	s.queue = make(chan []byte, queueSize)
+	s.done = make(chan struct{})
+	go s.run()
+	return s, nil
+}
+
+func parse(rawURL string) (*Sink, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("syslog: %w", err)
+	}
+	s := &Sink{facility: facilities["local0"], octetCounting: true, procID: strconv.Itoa(os.Getpid())}
+	port := ""
+	switch u.Scheme {
+	case "udp":
+		s.network, port = "udp", "514"
+	case "tcp":
+		s.network, port = "tcp", "601"
+	case "tls":
+		s.network, port = "tcp", "6514"
+		s.tls = &tls.Config{ServerName: u.Hostname(), MinVersion: tls.VersionTLS12}
+	default:
+		return nil, fmt.Errorf("syslog: %q: want udp://, tcp:// or tls://", rawURL)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("syslog: %q has no host", rawURL)
+	}
+	if u.Port() != "" {
+		port = u.Port()
+	}
+	s.addr = net.JoinHostPort(u.Hostname(), port)
+	if s.tls == nil {
+		if err := fips.CheckCleartext("syslog", s.addr); err != nil {
+			return nil, err
+		}
+	}
+
+	q := u.Query()
+	if f := q.Get("facility"); f != "" {
+		n, ok := facilities[strings.ToLower(f)]
+		if !ok {
+			return nil, fmt.Errorf("syslog: unknown facility %q", f)
+		}
+		s.facility = n
+	}
+	switch q.Get("framing") {
+	case "", "octet":
+	case "lf":
+		if s.tls != nil {
+			return nil, errors.New("syslog: framing=lf is not allowed over TLS; RFC 5425 requires octet counting")
+		}
+		s.octetCounting = false
+	default:
+		return nil, fmt.Errorf("syslog: unknown framing %q: want octet or lf", q.Get("framing"))
+	}
+	if ca := q.Get("ca"); ca != "" {
+		if s.tls == nil {
+			return nil, errors.New("syslog: ca is only used with tls://")
+		}
+		pem, err := os.ReadFile(ca)
+		if err != nil {
+			return nil, fmt.Errorf("syslog: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("syslog: %s holds no PEM certificate", ca)
+		}
+		s.tls.RootCAs = pool
+	}
+	if err := fips.CheckTLS("syslog", s.tls); err != nil {
+		return nil, err
+	}
+
+	s.hostname = os.Getenv("NODE_NAME")
+	if s.hostname == "" {
+		s.hostname, _ = os.Hostname()
+	}
+	return s, nil
+}
+
+func (s *Sink) dial() error {
+	d := net.Dialer{Timeout: writeTimeout}
+	var conn net.Conn
+	var err error
+	if s.tls != nil {
+		conn, err = tls.DialWithDialer(&d, s.network, s.addr, s.tls)
+	} else {
+		conn, err = d.Dial(s.network, s.addr)
+	}
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+	for msg := range s.queue {
+		s.write(msg)
+	}
+	if s.conn != nil {
+		_ = s.conn.Close()
+	}
+}
+
+// write sends one message, reconnecting a stream transport that broke. A
+// message that cannot be written is counted as dropped.
+func (s *Sink) write(msg []byte) {
+	if s.conn == nil {
+		if time.Now().Before(s.nextDial) {
+			s.drop()
+			return
+		}
+		if err := s.dial(); err != nil {
+			s.nextDial = time.Now().Add(redialBackoff)
+			logger.Warn("Syslog receiver unreachable", zap.String("addr", s.addr), zap.Error(err))
+			s.drop()
+			return
+		}
+	}
+	frame := msg
+	if s.network == "tcp" {
+		if s.octetCounting {
+			frame = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		} else {
+			frame = append(msg, '\n')
+		}
+	}
+	_ = s.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if _, err := s.conn.Write(frame); err != nil {
+		_ = s.conn.Close()
+		s.conn = nil
+		s.drop()
+	}
+}
+
+func (s *Sink) drop() {
+	if s.dropped.Add(1) == 1 {
+		logger.Warn("Dropping syslog messages: the receiver is not keeping up or not reachable", zap.String("addr", s.addr))
+	}
+}
+
+// Dropped returns how many messages could not be sent.
+func (s *Sink) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+func (s *Sink) enqueue(msg []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.closed {
+		return
+	}
+	select {
+	case s.queue <- msg:
+	default:
+		s.drop()
+	}
+}
+
+// Event sends one event; text is its formatted line. Failed events are
+// warnings, the rest informational.
+func (s *Sink) Event(e *events.Event, text string) {
+	if s == nil || e == nil {
+		return
+	}
+	severity := SeverityInfo
+	if e.IsError() {
+		severity = SeverityWarning
+	}
+	params := [][2]string{{"type", e.TypeString()}, {"pid", strconv.FormatUint(uint64(e.PID), 10)}}
+	if e.ProcessName != "" {
+		params = append(params, [2]string{"process", e.ProcessName})
+	}
+	if e.Target != "" {
+		params = append(params, [2]string{"target", e.Target})
+	}
+	if e.LatencyNS > 0 {
+		params = append(params, [2]string{"latency_ms", strconv.FormatFloat(float64(e.LatencyNS)/float64(config.NSPerMS), 'f', 3, 64)})
+	}
+	if e.IsError() {
+		params = append(params, [2]string{"error", strconv.Itoa(int(e.Error))})
+	}
+	if e.K8s != nil && e.K8s.PodName != "" {
+		params = append(params, [2]string{"namespace", e.K8s.Namespace}, [2]string{"pod", e.K8s.PodName})
+		if e.K8s.PodUID != "" {
+			params = append(params, [2]string{"pod_uid", e.K8s.PodUID})
+		}
+	}
+	s.enqueue(s.format(severity, e.TimestampTime(), "EVENT", params, text))
+}
+
+// Name implements hooks.IssueCallback.
+func (s *Sink) Name() string { return "syslog" }
+
+// OnIssue implements hooks.IssueCallback: it sends the finding with a
+// severity from its confidence.
+func (s *Sink) OnIssue(_ context.Context, f hooks.Finding) error {
+	severity := SeverityNotice
+	switch f.Confidence {
+	case detector.ConfidenceHigh:
+		severity = SeverityError
+	case detector.ConfidenceMedium:
+		severity = SeverityWarning
+	}
+	params := [][2]string{
+		{"code", f.Code}, {"rule", f.Rule},
+		{"score", strconv.FormatFloat(f.Score, 'f', 1, 64)}, {"confidence", f.Confidence},
+	}
+	if f.Pod != "" {
+		params = append(params, [2]string{"namespace", f.Namespace}, [2]string{"pod", f.Pod})
+	}
+	s.enqueue(s.format(severity, f.DetectedAt, "ISSUE", params, "["+f.Code+"] "+f.Message))
+	return nil
+}
+
+var _ hooks.IssueCallback = (*Sink)(nil)
+
+// Close sends what is queued, waiting at most until ctx ends, and closes
+// the connection.
+func (s *Sink) Close(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if n := s.Dropped(); n > 0 {
+		logger.Warn("Syslog messages dropped", zap.Uint64("dropped", n), zap.String("addr", s.addr))
+	}
+	return nil
+}
+
+// format renders an RFC 5424 message:
+// <PRI>1 TIMESTAMP HOSTNAME APP-NAME PROCID MSGID [SD-ID PARAM="VALUE"...] MSG
+// A zero ts is sent as the nil value "-".
+func (s *Sink) format(severity int, ts time.Time, msgID string, params [][2]string, msg string) []byte {
+	var b strings.Builder
+	stamp := "-"
+	if !ts.IsZero() {
+		stamp = ts.UTC().Format("2006-01-02T15:04:05.000000Z07:00")
+	}
+	fmt.Fprintf(&b, "<%d>1 %s %s %s %s %s [%s", s.facility*8+severity,
+		stamp, headerField(s.hostname, 255), appName, headerField(s.procID, 128), msgID, sdID)
+	for _, p := range params {
+		b.WriteString(" " + p[0] + `="` + escapeParam(p[1]) + `"`)
+	}
+	b.WriteString("] ")
+	b.WriteString(strings.NewReplacer("\r", " ", "\n", " ").Replace(msg))
+	return []byte(b.String())
+}
+
+// headerField returns v as an RFC 5424 header field: printable ASCII
+// without spaces, at most max long, and "-" when empty.
+func headerField(v string, max int) string {
+	out := strings.Map(func(r rune) rune {
+		if r < 33 || r > 126 {
+			return -1
+		}
+		return r
+	}, v)
+	if len(out) > max {
+		out = out[:max]
+	}
+	if out == "" {
+		return "-"
+	}
+	return out
+}
+
+// escapeParam escapes the characters RFC 5424 reserves in a PARAM-VALUE.
+func escapeParam(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`, "\n", " ", "\r", " ").Replace(v)
+}
@@ -0,0 +1,146 @@
+package syslogsink
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/diagnose/detector"
+	"github.com/podtrace/podtrace/internal/diagnose/hooks"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		url, addr string
+		facility  int
+		octet     bool
+	}{
+		{"udp://siem.example", "siem.example:514", 16, true},
+		{"tcp://10.0.0.5?facility=local3&framing=lf", "10.0.0.5:601", 19, false},
+		{"tls://siem.example:16514", "siem.example:16514", 16, true},
+	} {
+		s, err := parse(tc.url)
+		if err != nil {
+			t.Fatalf("parse(%q): %v", tc.url, err)
+		}
+		if s.addr != tc.addr || s.facility != tc.facility || s.octetCounting != tc.octet {
+			t.Errorf("parse(%q) = addr %s facility %d octet %v", tc.url, s.addr, s.facility, s.octetCounting)
+		}
+	}
+	for _, bad := range []string{"http://siem:514", "udp://", "udp://siem?facility=mail2", "tls://siem?framing=lf", "tcp://siem?ca=/tmp/ca.pem"} {
+		if _, err := parse(bad); err == nil {
+			t.Errorf("parse(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	s := &Sink{facility: 16, hostname: "node a", procID: "42"}
+	ts := time.Date(2026, 10, 16, 13, 44, 15, 123456000, time.UTC)
+	got := string(s.format(SeverityWarning, ts, "ISSUE", [][2]string{{"target", `db"1]\x`}}, "line one\nline two"))
+	want := `<132>1 2026-10-16T13:44:15.123456Z nodea podtrace 42 ISSUE [podtrace@32473 target="db\"1\]\\x"] line one line two`
+	if got != want {
+		t.Errorf("format =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestSinkUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = pc.Close() }()
+
+	s, err := Open("udp://" + pc.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Event(&events.Event{Type: events.EventConnect, PID: 7, Target: "10.0.0.9:5432", Error: -111, Timestamp: 1}, "connect failed")
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 2048)
+	_ = pc.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := string(buf[:n])
+	if !strings.HasPrefix(msg, "<132>1 ") || !strings.Contains(msg, ` EVENT [podtrace@32473 type="NET" pid="7" target="10.0.0.9:5432" error="-111"] connect failed`) {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestSinkTCPOctetCounting(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = ln.Close() }()
+	received := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		var sb strings.Builder
+		r := bufio.NewReader(conn)
+		for {
+			b, err := r.ReadByte()
+			if err != nil {
+				break
+			}
+			sb.WriteByte(b)
+		}
+		received <- sb.String()
+	}()
+
+	s, err := Open("tcp://" + ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []string{detector.ConfidenceHigh, detector.ConfidenceLow} {
+		f := hooks.Finding{Issue: detector.Issue{Code: "PODTRACE-NET-001", Message: "High connection failure rate", Rule: "connect_failures", Score: 74, Confidence: c}, Pod: "api", Namespace: "prod"}
+		if err := s.OnIssue(context.Background(), f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var stream string
+	select {
+	case stream = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("receiver got nothing")
+	}
+	var frames []string
+	for stream != "" {
+		size, rest, ok := strings.Cut(stream, " ")
+		n, err := strconv.Atoi(size)
+		if !ok || err != nil || n > len(rest) {
+			t.Fatalf("bad octet-counted frame at %q", stream)
+		}
+		frames, stream = append(frames, rest[:n]), rest[n:]
+	}
+	if len(frames) != 2 {
+		t.Fatalf("frames = %q", frames)
+	}
+	for i, c := range []string{"high", "low"} {
+		want := `ISSUE [podtrace@32473 code="PODTRACE-NET-001" rule="connect_failures" score="74.0" confidence="` + c + `" namespace="prod" pod="api"] [PODTRACE-NET-001] High connection failure rate`
+		if !strings.HasPrefix(frames[i], []string{"<131>1 - ", "<133>1 - "}[i]) || !strings.HasSuffix(frames[i], want) {
+			t.Errorf("frame %d = %q", i, frames[i])
+		}
+	}
+	if s.Dropped() != 0 {
+		t.Errorf("dropped = %d", s.Dropped())
+	}
+}
@@ -0,0 +1,212 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/cilium/ebpf"
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// BPFProgram is one program currently loaded in the kernel, as bpftool
+// prog show would list it.
+type BPFProgram struct {
+	ID   uint32
+	Name string
+	Type string
+}
+
+// BPFConflict describes foreign programs on a hook podtrace also uses and
+// what that means for podtrace's data.
+type BPFConflict struct {
+	Hook     string   `json:"hook"`
+	Owner    string   `json:"owner"`
+	Programs []string `json:"programs"`
+	Note     string   `json:"note"`
+}
+
+// BPFCoexistence summarises the other BPF datapaths sharing the node.
+// Unattributed lists programs of unknown origin on cgroup hooks podtrace
+// uses, as "name (hook)": they may or may not affect what podtrace sees,
+// so they are reported without a warning.
+type BPFCoexistence struct {
+	Cilium       bool          `json:"cilium"`
+	Programs     int           `json:"loadedPrograms"`
+	Conflicts    []BPFConflict `json:"conflicts,omitempty"`
+	Unattributed []string      `json:"unattributed,omitempty"`
+}
+
+// podtraceHooks are the program types that can interact with podtrace's
+// probes: the ones it attaches, plus cgroup_sock_addr, which rewrites the
+// addresses its connect probes report. XDP, tc and LSM programs cannot.
+var podtraceHooks = map[string]bool{
+	ebpf.Kprobe.String():         true,
+	ebpf.TracePoint.String():     true,
+	ebpf.CGroupSKB.String():      true,
+	ebpf.CGroupSockAddr.String(): true,
+}
+
+// benignOwners load programs on podtrace's hooks that do not interfere
+// with it: systemd's per-unit firewall, device and bind policies apply to
+// service cgroups, and podtrace's own programs belong to other podtrace
+// sessions.
+var benignOwners = map[string]bool{
+	"systemd":  true,
+	"podtrace": true,
+}
+
+// podtraceCgroupPrograms are the names of podtrace's own cgroup_skb
+// programs, as the kernel truncates them.
+var podtraceCgroupPrograms = map[string]bool{
+	"dns_egress":    true,
+	"dns_ingress":   true,
+	"http3_egress":  true,
+	"http3_ingress": true,
+}
+
+// LoadedBPFPrograms enumerates every program loaded in the kernel. It needs
+// CAP_SYS_ADMIN; without it the kernel hides the ID space and an error is
+// returned.
+func LoadedBPFPrograms() ([]BPFProgram, error) {
+	var progs []BPFProgram
+	id := ebpf.ProgramID(0)
+	for {
+		next, err := ebpf.ProgramGetNextID(id)
+		if errors.Is(err, os.ErrNotExist) {
+			return progs, nil
+		}
+		if err != nil {
+			return progs, fmt.Errorf("enumerate BPF programs: %w", err)
+		}
+		id = next
+		prog, err := ebpf.NewProgramFromID(id)
+		if err != nil {
+			// The program was unloaded between the two calls.
+			continue
+		}
+		info, err := prog.Info()
+		_ = prog.Close()
+		if err != nil {
+			continue
+		}
+		progs = append(progs, BPFProgram{ID: uint32(id), Name: info.Name, Type: info.Type.String()})
+	}
+}
+
+// DetectBPFCoexistence inspects the loaded programs for other BPF datapaths
+// (Cilium in particular) on the hooks podtrace attaches to.
+func DetectBPFCoexistence() (BPFCoexistence, error) {
+	progs, err := LoadedBPFPrograms()
+	if err != nil {
+		return BPFCoexistence{}, err
+	}
+	return analyzeBPFCoexistence(progs), nil
+}
+
+// CheckBPFCoexistence logs the conflicts found by DetectBPFCoexistence. It
+// never fails: introspection is advisory and unavailable without privilege.
+func CheckBPFCoexistence() {
+	co, err := DetectBPFCoexistence()
+	if err != nil {
+		logger.Debug("BPF coexistence check skipped", zap.Error(err))
+		return
+	}
+	for _, c := range co.Conflicts {
+		logger.Warn("BPF programs from another datapath share a podtrace hook",
+			zap.String("hook", c.Hook),
+			zap.String("owner", c.Owner),
+			zap.Strings("programs", c.Programs),
+			zap.String("impact", c.Note))
+	}
+}
+
+// BPFProgramOwner guesses which project loaded a program from its name.
+// Cilium prefixes its datapath programs with cil_ (cil_from_container,
+// cil_sock4_connect, ...) and its tail calls with tail_; systemd names its
+// cgroup programs sd_ (sd_fw_ingress, sd_devices, ...).
+func BPFProgramOwner(name string) string {
+	switch {
+	case strings.HasPrefix(name, "cil_"), strings.HasPrefix(name, "tail_"):
+		return "cilium"
+	case strings.HasPrefix(name, "calico_"):
+		return "calico"
+	case strings.HasPrefix(name, "sd_"):
+		return "systemd"
+	case podtraceCgroupPrograms[name]:
+		return "podtrace"
+	default:
+		return "other"
+	}
+}
+
+func analyzeBPFCoexistence(progs []BPFProgram) BPFCoexistence {
+	co := BPFCoexistence{Programs: len(progs)}
+	type key struct{ hook, owner string }
+	grouped := make(map[key][]string)
+	for _, p := range progs {
+		owner := BPFProgramOwner(p.Name)
+		if owner == "cilium" {
+			co.Cilium = true
+		}
+		if !podtraceHooks[p.Type] || benignOwners[owner] {
+			continue
+		}
+		if owner == "other" {
+			// Unnamed or unattributed tracing programs are common (other
+			// profilers) and coexist with kprobes without interference.
+			// Unknown cgroup programs are listed, but only the known
+			// datapaths are flagged as conflicts.
+			if p.Type == ebpf.CGroupSKB.String() || p.Type == ebpf.CGroupSockAddr.String() {
+				co.Unattributed = append(co.Unattributed, fmt.Sprintf("%s (%s)", p.Name, p.Type))
+			}
+			continue
+		}
+		k := key{p.Type, owner}
+		grouped[k] = append(grouped[k], p.Name)
+	}
+	for k, names := range grouped {
+		sort.Strings(names)
+		co.Conflicts = append(co.Conflicts, BPFConflict{
+			Hook:     k.hook,
+			Owner:    k.owner,
+			Programs: names,
+			Note:     conflictNote(k.hook, k.owner, names),
+		})
+	}
+	sort.Strings(co.Unattributed)
+	sort.Slice(co.Conflicts, func(i, j int) bool {
+		if co.Conflicts[i].Hook != co.Conflicts[j].Hook {
+			return co.Conflicts[i].Hook < co.Conflicts[j].Hook
+		}
+		return co.Conflicts[i].Owner < co.Conflicts[j].Owner
+	})
+	return co
+}
+
+func conflictNote(hook, owner string, names []string) string {
+	switch hook {
+	case ebpf.CGroupSockAddr.String():
+		if owner == "cilium" && hasSocketLB(names) {
+			return "Cilium socket-LB rewrites Service addresses at connect(); podtrace connect events report the selected backend IP, not the ClusterIP"
+		}
+		return "connect()/sendmsg() addresses may be rewritten before podtrace observes them"
+	case ebpf.CGroupSKB.String():
+		return "packets dropped by these cgroup_skb programs never reach podtrace's packet-based DNS/HTTP3 capture; if a cgroup is held exclusively, podtrace falls back to libc uprobes for DNS"
+	default:
+		return "kprobes/tracepoints on the same functions run back to back; expect higher per-call overhead and slightly inflated latency"
+	}
+}
+
+func hasSocketLB(names []string) bool {
+	for _, n := range names {
+		if strings.HasPrefix(n, "cil_sock") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package system
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeBPFCoexistence_Cilium(t *testing.T) {
+	progs := []BPFProgram{
+		{ID: 1, Name: "cil_from_contai", Type: "SchedCLS"},
+		{ID: 2, Name: "cil_sock4_conne", Type: "CGroupSockAddr"},
+		{ID: 3, Name: "cil_sock6_conne", Type: "CGroupSockAddr"},
+		{ID: 4, Name: "sd_fw_egress", Type: "CGroupSKB"},
+		{ID: 5, Name: "", Type: "Kprobe"},
+		{ID: 6, Name: "tail_handle_ipv", Type: "SchedCLS"},
+	}
+	co := analyzeBPFCoexistence(progs)
+	if !co.Cilium {
+		t.Fatal("expected Cilium to be detected from cil_ program names")
+	}
+	if co.Programs != len(progs) {
+		t.Errorf("Programs = %d, want %d", co.Programs, len(progs))
+	}
+	if len(co.Conflicts) != 1 {
+		t.Fatalf("expected the socket-LB conflict only, systemd's firewall is benign; got %+v", co.Conflicts)
+	}
+	sock := co.Conflicts[0]
+	if sock.Hook != "CGroupSockAddr" || sock.Owner != "cilium" || len(sock.Programs) != 2 {
+		t.Errorf("unexpected socket-LB conflict: %+v", sock)
+	}
+	if !strings.Contains(sock.Note, "socket-LB") {
+		t.Errorf("expected socket-LB note, got %q", sock.Note)
+	}
+}
+
+func TestAnalyzeBPFCoexistence_BenignAndUnattributed(t *testing.T) {
+	co := analyzeBPFCoexistence([]BPFProgram{
+		{ID: 1, Name: "sd_fw_ingress", Type: "CGroupSKB"},
+		{ID: 2, Name: "sd_fw_egress", Type: "CGroupSKB"},
+		{ID: 3, Name: "sd_bind4", Type: "CGroupSockAddr"},
+		{ID: 4, Name: "dns_egress", Type: "CGroupSKB"},
+		{ID: 5, Name: "egress_filter", Type: "CGroupSKB"},
+		{ID: 6, Name: "calico_connect_", Type: "CGroupSockAddr"},
+	})
+	if len(co.Conflicts) != 1 || co.Conflicts[0].Owner != "calico" {
+		t.Errorf("want only the Calico program flagged, got %+v", co.Conflicts)
+	}
+	if len(co.Unattributed) != 1 || co.Unattributed[0] != "egress_filter (CGroupSKB)" {
+		t.Errorf("Unattributed = %v, want the unknown cgroup_skb program", co.Unattributed)
+	}
+}
+
+func TestAnalyzeBPFCoexistence_NoForeignDatapath(t *testing.T) {
+	co := analyzeBPFCoexistence([]BPFProgram{{ID: 1, Name: "kprobe_tcp_conn", Type: "Kprobe"}})
+	if co.Cilium || len(co.Conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %+v", co)
+	}
+}
+
+func TestBPFProgramOwner(t *testing.T) {
+	cases := map[string]string{
+		"cil_to_netdev":   "cilium",
+		"tail_nodeport_n": "cilium",
+		"calico_tc_main":  "calico",
+		"sd_fw_ingress":   "systemd",
+		"dns_egress":      "podtrace",
+		"egress_filter":   "other",
+	}
+	for name, want := range cases {
+		if got := BPFProgramOwner(name); got != want {
+			t.Errorf("BPFProgramOwner(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
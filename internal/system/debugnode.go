@@ -0,0 +1,39 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+// DetectHostRoot returns the root the node's paths are read under: the
+// PODTRACE_HOST_ROOT value when set, or /host when podtrace runs in a
+// `kubectl debug node/...` container. Such a container shares the host's
+// PIDs but not its mounts, so its own /sys/fs/cgroup, /sys/fs/bpf and
+// container runtime sockets are not the node's; the node's root is mounted
+// at /host, and PID 1's root is that same directory.
+func DetectHostRoot() string {
+	if config.HostRoot != "" {
+		return config.HostRoot
+	}
+	if isHostRootMount(config.DefaultDebugHostRoot, filepath.Join(config.ProcBasePath, "1", "root"), "/") {
+		return config.DefaultDebugHostRoot
+	}
+	return ""
+}
+
+// isHostRootMount reports whether candidate is the root of PID 1, seen at
+// initRoot, while this process has another root.
+func isHostRootMount(candidate, initRoot, ownRoot string) bool {
+	c, err := os.Stat(candidate)
+	if err != nil || !c.IsDir() {
+		return false
+	}
+	init, err := os.Stat(initRoot)
+	if err != nil || !os.SameFile(c, init) {
+		return false
+	}
+	own, err := os.Stat(ownRoot)
+	return err == nil && !os.SameFile(own, init)
+}
@@ -0,0 +1,48 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+func TestIsHostRootMount(t *testing.T) {
+	dir := t.TempDir()
+	hostRoot := filepath.Join(dir, "node")
+	ownRoot := filepath.Join(dir, "container")
+	for _, d := range []string{hostRoot, ownRoot} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// PID 1's root is a link to the node's root, as /proc/1/root is.
+	initRoot := filepath.Join(dir, "init-root")
+	if err := os.Symlink(hostRoot, initRoot); err != nil {
+		t.Fatal(err)
+	}
+
+	if !isHostRootMount(hostRoot, initRoot, ownRoot) {
+		t.Error("the node's root mounted in a container was not detected")
+	}
+	if isHostRootMount(ownRoot, initRoot, ownRoot) {
+		t.Error("a directory that is not PID 1's root was detected")
+	}
+	if isHostRootMount(hostRoot, initRoot, hostRoot) {
+		t.Error("detected a host root while already running in it")
+	}
+	if isHostRootMount(filepath.Join(dir, "missing"), initRoot, ownRoot) {
+		t.Error("detected a missing directory")
+	}
+}
+
+func TestDetectHostRoot_PrefersSetting(t *testing.T) {
+	orig := config.HostRoot
+	defer func() { config.HostRoot = orig }()
+
+	config.HostRoot = "/"
+	if got := DetectHostRoot(); got != "/" {
+		t.Errorf("DetectHostRoot() = %q, want the PODTRACE_HOST_ROOT value", got)
+	}
+}
@@ -0,0 +1,233 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// auditLogTailBytes bounds how much of the audit log is scanned: denials
+// worth reporting were logged moments ago, and audit.log can be gigabytes.
+const auditLogTailBytes = 256 * 1024
+
+// maxReportedDenials caps how many denials are folded into one error.
+const maxReportedDenials = 3
+
+// recentDenialWindow is how far back a denial still explains a failure
+// that just happened. Older denials in the log concern earlier runs or
+// other workloads.
+const recentDenialWindow = 5 * time.Minute
+
+// auditLogPaths are the audit-log locations checked for LSM denials. The
+// /host variants cover the node-local agent, which mounts the host root
+// there. PODTRACE_AUDIT_LOG overrides the list.
+var auditLogPaths = []string{
+	"/var/log/audit/audit.log",
+	"/host/var/log/audit/audit.log",
+	"/var/log/kern.log",
+	"/host/var/log/kern.log",
+	"/var/log/syslog",
+	"/host/var/log/syslog",
+}
+
+var (
+	avcDeniedRe      = regexp.MustCompile(`avc:\s+denied\s+\{\s*([^}]*?)\s*\}`)
+	apparmorDeniedRe = regexp.MustCompile(`apparmor="DENIED"`)
+	auditFieldRe     = regexp.MustCompile(`(\w+)=("[^"]*"|\S+)`)
+	auditStampRe     = regexp.MustCompile(`audit\((\d+)\.(\d+):\d+\)`)
+)
+
+// LSMDenial is one SELinux AVC or AppArmor denial parsed from the audit log.
+type LSMDenial struct {
+	LSM        string // "selinux" or "apparmor"
+	Permission string // SELinux permission set or AppArmor operation/requested mask
+	Comm       string
+	Subject    string // SELinux scontext or AppArmor profile
+	Target     string // SELinux tcontext:tclass or AppArmor object name
+	Time       time.Time
+}
+
+// String renders the denial as a one-line explanation.
+func (d LSMDenial) String() string {
+	switch d.LSM {
+	case "selinux":
+		return fmt.Sprintf("blocked by SELinux policy: %s denied { %s } on %s (comm=%s)", d.Subject, d.Permission, d.Target, d.Comm)
+	case "apparmor":
+		return fmt.Sprintf("blocked by AppArmor profile %q: %s on %s (comm=%s)", d.Subject, d.Permission, d.Target, d.Comm)
+	default:
+		return fmt.Sprintf("blocked by %s: %s", d.LSM, d.Permission)
+	}
+}
+
+// RecentLSMDenials returns denials from the tail of the first readable audit
+// log that concern podtrace or BPF: denials whose comm is podtrace, whose
+// SELinux class is bpf/perf_event/capability2, or whose AppArmor operation
+// touches bpf. Only denials logged at or after since are returned; a zero
+// since means the last recentDenialWindow. Newest denials come first.
+func RecentLSMDenials(since time.Time) []LSMDenial {
+	if since.IsZero() {
+		since = time.Now().Add(-recentDenialWindow)
+	}
+	for _, path := range candidateAuditLogs() {
+		data, err := readTail(path, auditLogTailBytes)
+		if err != nil {
+			continue
+		}
+		return parseLSMDenials(string(data), since)
+	}
+	return nil
+}
+
+func candidateAuditLogs() []string {
+	if p := os.Getenv("PODTRACE_AUDIT_LOG"); p != "" {
+		return []string{p}
+	}
+	return auditLogPaths
+}
+
+func readTail(path string, n int64) ([]byte, error) {
+	f, err := os.Open(path) // #nosec G304 -- fixed audit-log locations or operator-supplied PODTRACE_AUDIT_LOG.
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	st, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if st.Size() > n {
+		if _, err := f.Seek(-n, io.SeekEnd); err != nil {
+			return nil, err
+		}
+	}
+	return io.ReadAll(io.LimitReader(f, n))
+}
+
+// parseLSMDenials extracts relevant denials logged at or after since from
+// audit-log text, newest first. Lines without an audit(<epoch>:<serial>)
+// stamp cannot be dated and are skipped.
+func parseLSMDenials(text string, since time.Time) []LSMDenial {
+	lines := strings.Split(text, "\n")
+	var out []LSMDenial
+	for i := len(lines) - 1; i >= 0; i-- {
+		line := lines[i]
+		logged, ok := auditTime(line)
+		if !ok || logged.Before(since) {
+			continue
+		}
+		var d LSMDenial
+		switch {
+		case avcDeniedRe.MatchString(line):
+			fields := auditFields(line)
+			d = LSMDenial{
+				LSM:        "selinux",
+				Permission: avcDeniedRe.FindStringSubmatch(line)[1],
+				Comm:       fields["comm"],
+				Subject:    fields["scontext"],
+				Target:     fields["tcontext"] + ":" + fields["tclass"],
+			}
+			if !relevantDenial(d, fields["tclass"]) {
+				continue
+			}
+		case apparmorDeniedRe.MatchString(line):
+			fields := auditFields(line)
+			perm := fields["operation"]
+			if mask := fields["requested_mask"]; mask != "" {
+				perm += " (" + mask + ")"
+			}
+			target := fields["name"]
+			if target == "" {
+				target = fields["class"]
+			}
+			d = LSMDenial{
+				LSM:        "apparmor",
+				Permission: perm,
+				Comm:       fields["comm"],
+				Subject:    fields["profile"],
+				Target:     target,
+			}
+			if !relevantDenial(d, fields["class"]) {
+				continue
+			}
+		default:
+			continue
+		}
+		d.Time = logged
+		out = append(out, d)
+	}
+	return out
+}
+
+// auditTime parses the msg=audit(<seconds>.<fraction>:<serial>) stamp the
+// kernel puts on every audit record, in audit.log and in syslog copies.
+func auditTime(line string) (time.Time, bool) {
+	m := auditStampRe.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}, false
+	}
+	sec, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	frac := (m[2] + "000000000")[:9]
+	nsec, _ := strconv.ParseInt(frac, 10, 64)
+	return time.Unix(sec, nsec), true
+}
+
+func auditFields(line string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range auditFieldRe.FindAllStringSubmatch(line, -1) {
+		fields[m[1]] = strings.Trim(m[2], `"`)
+	}
+	return fields
+}
+
+func relevantDenial(d LSMDenial, class string) bool {
+	if strings.Contains(strings.ToLower(d.Comm), "podtrace") {
+		return true
+	}
+	switch class {
+	case "bpf", "perf_event", "capability2", "lockdown":
+		return true
+	}
+	return strings.Contains(d.Permission, "bpf") || strings.Contains(d.Permission, "perfmon")
+}
+
+// ExplainLSMDenial annotates a permission failure with the related
+// SELinux/AppArmor denials of the last few minutes, so "operation not
+// permitted" on a hardened node names the policy that blocked it. Other
+// errors, and permission errors with no recent matching denial, are
+// returned unchanged.
+func ExplainLSMDenial(err error) error {
+	if err == nil || !isPermissionError(err) {
+		return err
+	}
+	denials := RecentLSMDenials(time.Time{})
+	if len(denials) == 0 {
+		return err
+	}
+	if len(denials) > maxReportedDenials {
+		denials = denials[:maxReportedDenials]
+	}
+	var b strings.Builder
+	b.WriteString("\n\nRecent LSM denials (from the audit log):")
+	for _, d := range denials {
+		b.WriteString("\n  • ")
+		b.WriteString(d.String())
+	}
+	return fmt.Errorf("%w%s", err, b.String())
+}
+
+func isPermissionError(err error) bool {
+	if errors.Is(err, syscall.EPERM) || errors.Is(err, syscall.EACCES) || errors.Is(err, os.ErrPermission) {
+		return true
+	}
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "operation not permitted") || strings.Contains(msg, "permission denied")
+}
@@ -0,0 +1,82 @@
+package system
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+const sampleAuditLogFormat = `type=AVC msg=audit(%[1]d.100:10): avc:  denied  { read } for  pid=9 comm="sshd" name="shadow" scontext=system_u:system_r:sshd_t:s0 tcontext=system_u:object_r:shadow_t:s0 tclass=file permissive=0
+type=AVC msg=audit(%[1]d.200:11): avc:  denied  { map_create } for  pid=42 comm="podtrace" scontext=system_u:system_r:container_t:s0:c1,c2 tcontext=system_u:system_r:container_t:s0:c1,c2 tclass=bpf permissive=0
+type=AVC msg=audit(%[1]d.300:12): apparmor="DENIED" operation="capable" profile="cri-containerd.apparmor.d" pid=42 comm="podtrace" capability=39 capname="bpf"
+`
+
+// sampleAuditLog returns the sample denials stamped at the given time.
+func sampleAuditLog(at time.Time) string {
+	return fmt.Sprintf(sampleAuditLogFormat, at.Unix())
+}
+
+func TestParseLSMDenials_SelectsRelevantNewestFirst(t *testing.T) {
+	at := time.Now().Truncate(time.Second)
+	got := parseLSMDenials(sampleAuditLog(at), at.Add(-time.Minute))
+	if len(got) != 2 {
+		t.Fatalf("expected 2 relevant denials (sshd filtered out), got %d: %+v", len(got), got)
+	}
+	if got[0].LSM != "apparmor" || got[0].Subject != "cri-containerd.apparmor.d" {
+		t.Errorf("newest denial should be the AppArmor one, got %+v", got[0])
+	}
+	sel := got[1]
+	if sel.LSM != "selinux" || sel.Permission != "map_create" || sel.Comm != "podtrace" {
+		t.Errorf("unexpected SELinux denial: %+v", sel)
+	}
+	if !strings.Contains(sel.String(), "blocked by SELinux policy") || !strings.Contains(sel.Target, ":bpf") {
+		t.Errorf("unexpected rendering: %q", sel.String())
+	}
+	if !sel.Time.Equal(at.Add(200 * time.Millisecond)) {
+		t.Errorf("denial time = %v, want %v", sel.Time, at.Add(200*time.Millisecond))
+	}
+}
+
+func TestParseLSMDenials_SkipsOldAndUndatedDenials(t *testing.T) {
+	now := time.Now()
+	text := sampleAuditLog(now.Add(-time.Hour)) +
+		`kernel: apparmor="DENIED" operation="capable" profile="x" comm="podtrace" capname="bpf"` + "\n"
+	if got := parseLSMDenials(text, now.Add(-recentDenialWindow)); len(got) != 0 {
+		t.Errorf("denials from an hour ago or without a stamp reported: %+v", got)
+	}
+}
+
+func TestExplainLSMDenial_AnnotatesPermissionErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	if err := os.WriteFile(path, []byte(sampleAuditLog(time.Now())), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PODTRACE_AUDIT_LOG", path)
+
+	base := fmt.Errorf("load program: %w", syscall.EPERM)
+	err := ExplainLSMDenial(base)
+	if !errors.Is(err, syscall.EPERM) {
+		t.Fatalf("wrapped error lost EPERM: %v", err)
+	}
+	if !strings.Contains(err.Error(), "blocked by SELinux policy") || !strings.Contains(err.Error(), "AppArmor") {
+		t.Errorf("expected denial explanation, got %q", err.Error())
+	}
+
+	other := errors.New("symbol not found")
+	if got := ExplainLSMDenial(other); got != other {
+		t.Errorf("non-permission errors must pass through unchanged, got %v", got)
+	}
+}
+
+func TestExplainLSMDenial_NoAuditLog(t *testing.T) {
+	t.Setenv("PODTRACE_AUDIT_LOG", filepath.Join(t.TempDir(), "missing.log"))
+	base := fmt.Errorf("attach: %w", syscall.EACCES)
+	if got := ExplainLSMDenial(base); got != base {
+		t.Errorf("expected the original error without an audit log, got %v", got)
+	}
+}
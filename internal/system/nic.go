@@ -0,0 +1,45 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+// NodeLinkSpeed returns the speed, in bits per second, of the node's fastest
+// physical network interface that reports one, and that interface's name.
+// It reads the host's sysfs through /proc/1/root, so a podtrace running in
+// its own network namespace still sees the node's NICs. Virtual interfaces
+// (veths, bridges, tunnels) have no backing device and are skipped; zero
+// means no speed is known.
+func NodeLinkSpeed() (uint64, string) {
+	dir := filepath.Join(config.ProcBasePath, "1", "root", "sys", "class", "net")
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, ""
+	}
+	var best uint64
+	var name string
+	for _, e := range entries {
+		iface := filepath.Join(dir, e.Name())
+		if _, err := os.Stat(filepath.Join(iface, "device")); err != nil {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(iface, "speed")) // #nosec G304 -- sysfs attribute of a host interface.
+		if err != nil {
+			continue
+		}
+		// speed is in Mbit/s; links that are down report -1.
+		mbps, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil || mbps <= 0 {
+			continue
+		}
+		if bps := uint64(mbps) * 1_000_000; bps > best {
+			best, name = bps, e.Name()
+		}
+	}
+	return best, name
+}
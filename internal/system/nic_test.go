@@ -0,0 +1,43 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/config"
+)
+
+func TestNodeLinkSpeed(t *testing.T) {
+	base := t.TempDir()
+	old := config.ProcBasePath
+	config.SetProcBasePath(base)
+	t.Cleanup(func() { config.SetProcBasePath(old) })
+
+	net := filepath.Join(base, "1", "root", "sys", "class", "net")
+	iface := func(name, speed string, physical bool) {
+		dir := filepath.Join(net, name)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if physical {
+			if err := os.Mkdir(filepath.Join(dir, "device"), 0o755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(dir, "speed"), []byte(speed+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if bps, name := NodeLinkSpeed(); bps != 0 || name != "" {
+		t.Fatalf("without sysfs: got %d %q", bps, name)
+	}
+	iface("eth0", "10000", true)
+	iface("eth1", "-1", true)
+	iface("eth2", "25000", true)
+	iface("veth1234", "100000", false)
+	if bps, name := NodeLinkSpeed(); bps != 25_000_000_000 || name != "eth2" {
+		t.Fatalf("NodeLinkSpeed() = %d, %q; want 25Gbit/s on eth2", bps, name)
+	}
+}
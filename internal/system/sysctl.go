@@ -0,0 +1,134 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/logger"
+)
+
+// procSysPath is where sysctls are read from; tests point it at a fixture.
+var procSysPath = "/proc/sys"
+
+// Capability bit numbers from linux/capability.h.
+const (
+	capSysAdmin = 21
+	capSyslog   = 34
+	capPerfmon  = 38
+	capBPF      = 39
+)
+
+// kernelParams are the sysctls that gate or degrade BPF tracing.
+var kernelParams = []string{
+	"kernel.perf_event_paranoid",
+	"kernel.kptr_restrict",
+	"net.core.bpf_jit_enable",
+	"kernel.unprivileged_bpf_disabled",
+}
+
+// KernelParamFinding ties an observed limitation to the sysctl causing it.
+type KernelParamFinding struct {
+	Param      string `json:"param"`
+	Value      string `json:"value"`
+	Blocking   bool   `json:"blocking"`
+	Limitation string `json:"limitation"`
+	Fix        string `json:"fix"`
+}
+
+// CheckKernelParams logs every kernel parameter that limits what podtrace can
+// trace with the capabilities it actually holds. It never fails: a blocking
+// setting surfaces again, with its real errno, when the programs load.
+func CheckKernelParams() {
+	for _, f := range KernelParamFindings() {
+		fields := []zap.Field{
+			zap.String("param", f.Param),
+			zap.String("value", f.Value),
+			zap.String("fix", f.Fix),
+		}
+		if f.Blocking {
+			logger.Warn("Kernel parameter blocks tracing: "+f.Limitation, fields...)
+		} else {
+			logger.Info("Kernel parameter degrades tracing: "+f.Limitation, fields...)
+		}
+	}
+}
+
+// KernelParamFindings reads the tracing-related sysctls and the effective
+// capability set of this process and reports the resulting limitations.
+func KernelParamFindings() []KernelParamFinding {
+	values := make(map[string]string, len(kernelParams))
+	for _, p := range kernelParams {
+		data, err := os.ReadFile(filepath.Join(procSysPath, strings.ReplaceAll(p, ".", "/"))) // #nosec G304 -- fixed sysctl names under /proc/sys.
+		if err != nil {
+			continue
+		}
+		values[p] = strings.TrimSpace(string(data))
+	}
+	return evaluateKernelParams(values, effectiveCaps())
+}
+
+// evaluateKernelParams is the pure-function half of KernelParamFindings.
+// Missing sysctls are skipped; caps is the CapEff bitmask.
+func evaluateKernelParams(values map[string]string, caps uint64) []KernelParamFinding {
+	has := func(bit uint) bool { return caps&(1<<bit) != 0 }
+	var out []KernelParamFinding
+	add := func(param string, blocking bool, limitation, fix string) {
+		out = append(out, KernelParamFinding{Param: param, Value: values[param], Blocking: blocking, Limitation: limitation, Fix: fix})
+	}
+
+	if v, ok := sysctlInt(values, "kernel.perf_event_paranoid"); ok && v > 2 && !has(capPerfmon) && !has(capSysAdmin) {
+		add("kernel.perf_event_paranoid", true,
+			"perf_event_open is denied without CAP_PERFMON, so kprobes, tracepoints and CPU profiling cannot attach",
+			"sysctl -w kernel.perf_event_paranoid=2  (or grant CAP_PERFMON)")
+	}
+	if v, ok := sysctlInt(values, "kernel.kptr_restrict"); ok && (v >= 2 || (v == 1 && !has(capSyslog))) {
+		add("kernel.kptr_restrict", false,
+			"/proc/kallsyms hides kernel addresses, so kernel stack frames display as raw hex",
+			"sysctl -w kernel.kptr_restrict=0")
+	}
+	if v, ok := sysctlInt(values, "net.core.bpf_jit_enable"); ok && v == 0 {
+		add("net.core.bpf_jit_enable", false,
+			"BPF programs run in the interpreter, multiplying per-event overhead",
+			"sysctl -w net.core.bpf_jit_enable=1")
+	}
+	if v, ok := sysctlInt(values, "kernel.unprivileged_bpf_disabled"); ok && v != 0 && !has(capBPF) && !has(capSysAdmin) {
+		fix := "grant CAP_BPF to the podtrace container"
+		if v == 2 {
+			fix = "sysctl -w kernel.unprivileged_bpf_disabled=0  (or grant CAP_BPF)"
+		}
+		add("kernel.unprivileged_bpf_disabled", true,
+			"the bpf() syscall is denied without CAP_BPF, so no probe can load",
+			fix)
+	}
+	return out
+}
+
+func sysctlInt(values map[string]string, param string) (int, bool) {
+	s, ok := values[param]
+	if !ok {
+		return 0, false
+	}
+	v, err := strconv.Atoi(s)
+	return v, err == nil
+}
+
+// effectiveCaps returns the CapEff mask from /proc/self/status, or all bits
+// set when it cannot be read so that no finding is blamed on missing caps.
+func effectiveCaps() uint64 {
+	data, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return ^uint64(0)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if rest, ok := strings.CutPrefix(line, "CapEff:"); ok {
+			if v, err := strconv.ParseUint(strings.TrimSpace(rest), 16, 64); err == nil {
+				return v
+			}
+		}
+	}
+	return ^uint64(0)
+}
@@ -0,0 +1,71 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEvaluateKernelParams_Unprivileged(t *testing.T) {
+	values := map[string]string{
+		"kernel.perf_event_paranoid":       "4",
+		"kernel.kptr_restrict":             "1",
+		"net.core.bpf_jit_enable":          "0",
+		"kernel.unprivileged_bpf_disabled": "2",
+	}
+	got := evaluateKernelParams(values, 0)
+	if len(got) != 4 {
+		t.Fatalf("expected a finding per sysctl, got %+v", got)
+	}
+	byParam := make(map[string]KernelParamFinding)
+	for _, f := range got {
+		byParam[f.Param] = f
+	}
+	if f := byParam["kernel.perf_event_paranoid"]; !f.Blocking || f.Value != "4" {
+		t.Errorf("perf_event_paranoid=4 without CAP_PERFMON should block: %+v", f)
+	}
+	if f := byParam["kernel.unprivileged_bpf_disabled"]; f.Fix != "sysctl -w kernel.unprivileged_bpf_disabled=0  (or grant CAP_BPF)" {
+		t.Errorf("unexpected fix for unprivileged_bpf_disabled=2: %q", f.Fix)
+	}
+	if f := byParam["net.core.bpf_jit_enable"]; f.Blocking {
+		t.Errorf("disabled JIT degrades, it does not block: %+v", f)
+	}
+}
+
+func TestEvaluateKernelParams_CapabilitiesSatisfyLimits(t *testing.T) {
+	values := map[string]string{
+		"kernel.perf_event_paranoid":       "3",
+		"kernel.kptr_restrict":             "1",
+		"net.core.bpf_jit_enable":          "1",
+		"kernel.unprivileged_bpf_disabled": "1",
+	}
+	caps := uint64(1<<capPerfmon | 1<<capBPF | 1<<capSyslog)
+	if got := evaluateKernelParams(values, caps); len(got) != 0 {
+		t.Errorf("expected no findings with CAP_PERFMON/CAP_BPF/CAP_SYSLOG, got %+v", got)
+	}
+
+	// kptr_restrict=2 hides addresses even from CAP_SYSLOG.
+	values["kernel.kptr_restrict"] = "2"
+	got := evaluateKernelParams(values, caps)
+	if len(got) != 1 || got[0].Param != "kernel.kptr_restrict" {
+		t.Errorf("expected only kptr_restrict finding, got %+v", got)
+	}
+}
+
+func TestKernelParamFindings_ReadsProcSys(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "net", "core"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "net", "core", "bpf_jit_enable"), []byte("0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	orig := procSysPath
+	procSysPath = dir
+	t.Cleanup(func() { procSysPath = orig })
+
+	got := KernelParamFindings()
+	if len(got) != 1 || got[0].Param != "net.core.bpf_jit_enable" || got[0].Value != "0" {
+		t.Errorf("expected only the JIT finding from the fixture, got %+v", got)
+	}
+}
@@ -0,0 +1,50 @@
+package targetnames
+
+import "strings"
+
+// PodDNSName is a pod's own DNS name under a headless Service,
+// <hostname>.<service>.<namespace>.svc.<cluster domain>: what clients of a
+// StatefulSet use to reach one member. The hostname of a StatefulSet pod is
+// its pod name.
+type PodDNSName struct {
+	Host      string
+	Service   string
+	Namespace string
+}
+
+// Member renders the name as "<hostname>.<service>.<namespace>".
+func (p PodDNSName) Member() string {
+	return p.Host + "." + p.Service + "." + p.Namespace
+}
+
+// ParsePodDNSName recognises a headless Service pod name. The name must be
+// qualified at least down to ".svc" so that an external name with four
+// labels is not mistaken for one; a Service name (<service>.<namespace>.svc)
+// has no pod label and is not a match either.
+func ParsePodDNSName(name string) (PodDNSName, bool) {
+	labels := strings.Split(strings.ToLower(strings.TrimSuffix(name, ".")), ".")
+	if len(labels) < 4 || labels[3] != "svc" {
+		return PodDNSName{}, false
+	}
+	for _, l := range labels[:3] {
+		if !isDNSLabel(l) {
+			return PodDNSName{}, false
+		}
+	}
+	return PodDNSName{Host: labels[0], Service: labels[1], Namespace: labels[2]}, true
+}
+
+// isDNSLabel reports whether l is an RFC 1123 label, the form Kubernetes
+// requires of hostnames, Service names and namespaces.
+func isDNSLabel(l string) bool {
+	if l == "" || len(l) > 63 || l[0] == '-' || l[len(l)-1] == '-' {
+		return false
+	}
+	for i := 0; i < len(l); i++ {
+		c := l[i]
+		if (c < 'a' || c > 'z') && (c < '0' || c > '9') && c != '-' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,152 @@
+package targetnames
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"strings"
+)
+
+// rangeEntry is one published cloud address range.
+type rangeEntry struct {
+	prefix netip.Prefix
+	label  string
+	// generic marks catch-all entries (AWS "AMAZON", Azure "AzureCloud"),
+	// which repeat the prefixes of the specific services.
+	generic bool
+}
+
+// Ranges maps addresses to the cloud provider service that publishes them,
+// from the range files AWS (ip-ranges.json), Google Cloud (cloud.json) and
+// Azure (ServiceTags_*.json) distribute. The files are read from disk; the
+// operator keeps them current.
+type Ranges struct {
+	entries []rangeEntry // most specific prefix first
+}
+
+// LoadRanges reads and merges the given range files.
+func LoadRanges(paths []string) (*Ranges, error) {
+	r := &Ranges{}
+	for _, path := range paths {
+		data, err := os.ReadFile(path) // #nosec G304 -- operator-supplied PODTRACE_IP_RANGES file.
+		if err != nil {
+			return nil, fmt.Errorf("ip ranges: %w", err)
+		}
+		entries, err := parseRanges(data)
+		if err != nil {
+			return nil, fmt.Errorf("ip ranges %s: %w", path, err)
+		}
+		r.entries = append(r.entries, entries...)
+	}
+	sort.SliceStable(r.entries, func(i, j int) bool {
+		a, b := r.entries[i], r.entries[j]
+		if a.prefix.Bits() != b.prefix.Bits() {
+			return a.prefix.Bits() > b.prefix.Bits()
+		}
+		return !a.generic && b.generic
+	})
+	return r, nil
+}
+
+// Lookup returns the label of the most specific range containing addr, or "".
+func (r *Ranges) Lookup(addr netip.Addr) string {
+	for _, e := range r.entries {
+		if e.prefix.Contains(addr) {
+			return e.label
+		}
+	}
+	return ""
+}
+
+// Len is the number of loaded ranges.
+func (r *Ranges) Len() int {
+	return len(r.entries)
+}
+
+type rangesFile struct {
+	// AWS ip-ranges.json
+	Prefixes []struct {
+		IPPrefix string `json:"ip_prefix"`
+		Region   string `json:"region"`
+		// Shared: the AWS service, or "Google Cloud".
+		Service string `json:"service"`
+		// Google Cloud cloud.json
+		IPv4Prefix string `json:"ipv4Prefix"`
+		IPv6Prefix string `json:"ipv6Prefix"`
+		Scope      string `json:"scope"`
+	} `json:"prefixes"`
+	IPv6Prefixes []struct {
+		IPv6Prefix string `json:"ipv6_prefix"`
+		Region     string `json:"region"`
+		Service    string `json:"service"`
+	} `json:"ipv6_prefixes"`
+	// Azure ServiceTags_*.json
+	Values []struct {
+		Name       string `json:"name"`
+		Properties struct {
+			Region          string   `json:"region"`
+			SystemService   string   `json:"systemService"`
+			AddressPrefixes []string `json:"addressPrefixes"`
+		} `json:"properties"`
+	} `json:"values"`
+}
+
+func parseRanges(data []byte) ([]rangeEntry, error) {
+	var f rangesFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	var out []rangeEntry
+	add := func(cidr, label string, generic bool) {
+		if p, err := netip.ParsePrefix(strings.TrimSpace(cidr)); err == nil {
+			out = append(out, rangeEntry{prefix: p.Masked(), label: label, generic: generic})
+		}
+	}
+	addAWS := func(cidr, service, region string) {
+		if service == "AMAZON" {
+			add(cidr, join("AWS", region), true)
+			return
+		}
+		add(cidr, join("AWS", service, region), false)
+	}
+	for _, p := range f.Prefixes {
+		switch {
+		case p.IPPrefix != "":
+			addAWS(p.IPPrefix, p.Service, p.Region)
+		case p.IPv4Prefix != "" || p.IPv6Prefix != "":
+			label := join(p.Service, p.Scope)
+			if p.Service == "" {
+				label = join("Google Cloud", p.Scope)
+			}
+			add(p.IPv4Prefix, label, false)
+			add(p.IPv6Prefix, label, false)
+		}
+	}
+	for _, p := range f.IPv6Prefixes {
+		addAWS(p.IPv6Prefix, p.Service, p.Region)
+	}
+	// Azure tags without a systemService (AzureCloud.<region>) are the
+	// catch-all for a region, like AWS's "AMAZON".
+	for _, v := range f.Values {
+		service := v.Properties.SystemService
+		for _, cidr := range v.Properties.AddressPrefixes {
+			add(cidr, join("Azure", service, v.Properties.Region), service == "")
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no AWS, Google Cloud or Azure ranges found")
+	}
+	return out, nil
+}
+
+func join(parts ...string) string {
+	var kept []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" && p != "global" {
+			kept = append(kept, p)
+		}
+	}
+	return strings.Join(kept, " ")
+}
@@ -0,0 +1,248 @@
+// Package targetnames turns the remote IPs of network events into readable
+// names for the report: the hostname the traced pod itself resolved to that
+// address, else a reverse-DNS (PTR) name, else the cloud provider service
+// whose published range contains it. Lookups run in the background so event
+// processing never waits on DNS.
+package targetnames
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// Sizing of the name cache and the lookup pipeline.
+const (
+	maxCachedNames = 4096
+	lookupQueue    = 256
+	lookupWorkers  = 4
+)
+
+// Options configure a Resolver.
+type Options struct {
+	// ReverseDNS enables PTR lookups for public addresses.
+	ReverseDNS bool
+	// Timeout bounds each PTR lookup.
+	Timeout time.Duration
+	// TTL is how long a looked-up name is kept.
+	TTL time.Duration
+	// Ranges maps addresses to cloud provider services; may be nil.
+	Ranges *Ranges
+}
+
+type entry struct {
+	name      string
+	expiresAt time.Time // zero for names observed from the pod's own DNS
+}
+
+// Resolver caches names for remote IPs.
+type Resolver struct {
+	opts       Options
+	lookupAddr func(ctx context.Context, addr string) ([]string, error)
+	now        func() time.Time
+
+	mu      sync.Mutex
+	names   map[netip.Addr]entry
+	pending map[netip.Addr]struct{}
+	queue   chan netip.Addr
+	wg      sync.WaitGroup
+}
+
+// New returns a resolver; Start runs its lookup workers.
+func New(opts Options) *Resolver {
+	return &Resolver{
+		opts:       opts,
+		lookupAddr: net.DefaultResolver.LookupAddr,
+		now:        time.Now,
+		names:      make(map[netip.Addr]entry),
+		pending:    make(map[netip.Addr]struct{}),
+		queue:      make(chan netip.Addr, lookupQueue),
+	}
+}
+
+// Start runs the reverse-DNS workers until ctx ends.
+func (r *Resolver) Start(ctx context.Context) {
+	if !r.opts.ReverseDNS {
+		return
+	}
+	for i := 0; i < lookupWorkers; i++ {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case addr := <-r.queue:
+					r.lookup(ctx, addr)
+				}
+			}
+		}()
+	}
+}
+
+// Wait blocks until the workers have exited after ctx ended.
+func (r *Resolver) Wait() {
+	r.wg.Wait()
+}
+
+// Observe learns names from an event: DNS answers of the traced pod map each
+// returned address to the queried name, a connect the kernel already tied to
+// a resolved hostname records that name, and any other connect to a public
+// address schedules a PTR lookup.
+func (r *Resolver) Observe(e *events.Event) {
+	if e == nil {
+		return
+	}
+	switch e.Type {
+	case events.EventDNS:
+		if e.Error != 0 || e.Target == "" || e.Details == "" {
+			return
+		}
+		name := strings.TrimSuffix(e.Target, ".")
+		for _, s := range strings.Split(e.Details, ",") {
+			if addr, err := netip.ParseAddr(strings.TrimSpace(s)); err == nil {
+				r.store(addr.Unmap(), entry{name: name})
+			}
+		}
+	case events.EventConnect:
+		addr, ok := targetAddr(e.Target)
+		if !ok {
+			return
+		}
+		if e.Details != "" {
+			r.store(addr, entry{name: strings.TrimSuffix(e.Details, ".")})
+			return
+		}
+		r.schedule(addr)
+	}
+}
+
+// Name returns the best known name for addr, or "".
+func (r *Resolver) Name(addr netip.Addr) string {
+	r.mu.Lock()
+	e, ok := r.names[addr]
+	r.mu.Unlock()
+	if ok && (e.expiresAt.IsZero() || r.now().Before(e.expiresAt)) && e.name != "" {
+		return e.name
+	}
+	if r.opts.Ranges != nil {
+		if svc := r.opts.Ranges.Lookup(addr); svc != "" {
+			return svc
+		}
+	}
+	return ""
+}
+
+// Label renders an "ip:port" or bare IP target as "target (name)", the form
+// connection targets the kernel tied to a DNS answer already take, when a
+// name is known, and returns target unchanged otherwise.
+func (r *Resolver) Label(target string) string {
+	addr, ok := targetAddr(target)
+	if !ok {
+		return target
+	}
+	if name := r.Name(addr); name != "" {
+		return target + " (" + name + ")"
+	}
+	return target
+}
+
+func (r *Resolver) schedule(addr netip.Addr) {
+	if !r.opts.ReverseDNS || !isPublic(addr) {
+		return
+	}
+	r.mu.Lock()
+	if e, ok := r.names[addr]; ok && (e.expiresAt.IsZero() || r.now().Before(e.expiresAt)) {
+		r.mu.Unlock()
+		return
+	}
+	if _, ok := r.pending[addr]; ok {
+		r.mu.Unlock()
+		return
+	}
+	r.pending[addr] = struct{}{}
+	r.mu.Unlock()
+
+	select {
+	case r.queue <- addr:
+	default:
+		// The queue is full: drop the lookup, a later event retries it.
+		r.mu.Lock()
+		delete(r.pending, addr)
+		r.mu.Unlock()
+	}
+}
+
+func (r *Resolver) lookup(ctx context.Context, addr netip.Addr) {
+	lookupCtx, cancel := context.WithTimeout(ctx, r.opts.Timeout)
+	defer cancel()
+	names, err := r.lookupAddr(lookupCtx, addr.String())
+	var name string
+	if err == nil && len(names) > 0 {
+		name = strings.TrimSuffix(names[0], ".")
+	}
+	r.mu.Lock()
+	delete(r.pending, addr)
+	r.mu.Unlock()
+	// Failed lookups are cached too, as "", so an unnamed address is not
+	// queried again on every connect.
+	r.store(addr, entry{name: name, expiresAt: r.now().Add(r.opts.TTL)})
+}
+
+func (r *Resolver) store(addr netip.Addr, e entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.names[addr]; ok && old.expiresAt.IsZero() && !e.expiresAt.IsZero() {
+		// A name the pod resolved itself beats a PTR record.
+		return
+	}
+	if _, ok := r.names[addr]; !ok && len(r.names) >= maxCachedNames {
+		for k := range r.names {
+			delete(r.names, k)
+			break
+		}
+	}
+	r.names[addr] = e
+}
+
+// targetAddr extracts the IP of an "ip:port", "[ipv6]:port" or bare IP
+// target.
+func targetAddr(target string) (netip.Addr, bool) {
+	host := target
+	if h, _, err := net.SplitHostPort(target); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// isPublic reports whether addr is worth a PTR lookup: cluster, node and
+// loopback addresses are named by the Kubernetes enrichment instead.
+func isPublic(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !cgnat.Contains(addr)
+}
+
+var cgnat = netip.MustParsePrefix("100.64.0.0/10")
+
+var global atomic.Pointer[Resolver]
+
+// SetGlobal installs the resolver the diagnostician feeds and labels report
+// targets with; nil disables naming.
+func SetGlobal(r *Resolver) {
+	global.Store(r)
+}
+
+// Global returns the resolver installed with SetGlobal, or nil.
+func Global() *Resolver {
+	return global.Load()
+}
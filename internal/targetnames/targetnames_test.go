@@ -0,0 +1,173 @@
+package targetnames
+
+import (
+	"context"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func waitForName(t *testing.T, r *Resolver, target, want string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := r.Label(target); got == want {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("Label(%q) = %q, want %q", target, r.Label(target), want)
+}
+
+func TestObserve_DNSAnswers(t *testing.T) {
+	r := New(Options{})
+	r.Observe(&events.Event{Type: events.EventDNS, Target: "api.stripe.com.", Details: "54.187.174.169, 2600:1f14::1"})
+
+	if got := r.Label("54.187.174.169:443"); got != "54.187.174.169:443 (api.stripe.com)" {
+		t.Errorf("IPv4 label = %q", got)
+	}
+	if got := r.Label("[2600:1f14::1]:443"); got != "[2600:1f14::1]:443 (api.stripe.com)" {
+		t.Errorf("IPv6 label = %q", got)
+	}
+	if got := r.Label("10.0.0.1:80"); got != "10.0.0.1:80" {
+		t.Errorf("unknown address should be unchanged, got %q", got)
+	}
+	if got := r.Label("/var/log/app.log"); got != "/var/log/app.log" {
+		t.Errorf("non-address target should be unchanged, got %q", got)
+	}
+}
+
+func TestObserve_ReverseDNS(t *testing.T) {
+	r := New(Options{ReverseDNS: true, Timeout: time.Second, TTL: time.Minute})
+	var lookups atomic.Int32
+	r.lookupAddr = func(_ context.Context, addr string) ([]string, error) {
+		lookups.Add(1)
+		return []string{"lb-" + addr + ".example.net."}, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Start(ctx)
+	t.Cleanup(func() { cancel(); r.Wait() })
+
+	connect := &events.Event{Type: events.EventConnect, Target: "93.184.216.34:443"}
+	r.Observe(connect)
+	waitForName(t, r, "93.184.216.34:443", "93.184.216.34:443 (lb-93.184.216.34.example.net)")
+
+	r.Observe(connect)
+	time.Sleep(20 * time.Millisecond)
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("cached address looked up %d times, want 1", n)
+	}
+
+	r.Observe(&events.Event{Type: events.EventConnect, Target: "10.1.2.3:5432"})
+	r.Observe(&events.Event{Type: events.EventConnect, Target: "127.0.0.1:8080"})
+	time.Sleep(20 * time.Millisecond)
+	if n := lookups.Load(); n != 1 {
+		t.Errorf("private addresses must not be looked up, got %d lookups", n)
+	}
+}
+
+func TestObserve_PodDNSBeatsPTR(t *testing.T) {
+	r := New(Options{TTL: time.Minute})
+	addr := netip.MustParseAddr("93.184.216.34")
+	r.Observe(&events.Event{Type: events.EventConnect, Target: "93.184.216.34:443", Details: "example.com"})
+	r.store(addr, entry{name: "ptr.example.net", expiresAt: time.Now().Add(time.Minute)})
+	if got := r.Name(addr); got != "example.com" {
+		t.Errorf("Name = %q, want the name the pod resolved", got)
+	}
+}
+
+func TestReverseDNSDisabled(t *testing.T) {
+	r := New(Options{})
+	r.lookupAddr = func(context.Context, string) ([]string, error) {
+		t.Fatal("lookup with reverse DNS disabled")
+		return nil, nil
+	}
+	r.Observe(&events.Event{Type: events.EventConnect, Target: "93.184.216.34:443"})
+	if len(r.pending) != 0 {
+		t.Errorf("pending = %v, want nothing queued", r.pending)
+	}
+}
+
+func TestLoadRanges(t *testing.T) {
+	dir := t.TempDir()
+	aws := filepath.Join(dir, "ip-ranges.json")
+	gcp := filepath.Join(dir, "cloud.json")
+	azure := filepath.Join(dir, "ServiceTags_Public.json")
+	files := map[string]string{
+		aws: `{"syncToken":"1","prefixes":[
+			{"ip_prefix":"52.216.0.0/15","region":"us-east-1","service":"AMAZON"},
+			{"ip_prefix":"52.216.0.0/15","region":"us-east-1","service":"S3"},
+			{"ip_prefix":"3.0.0.0/9","region":"us-east-1","service":"AMAZON"}],
+			"ipv6_prefixes":[{"ipv6_prefix":"2600:1f18::/33","region":"us-east-1","service":"EC2"}]}`,
+		gcp: `{"syncToken":"2","prefixes":[{"ipv4Prefix":"34.1.208.0/20","service":"Google Cloud","scope":"africa-south1"}]}`,
+		azure: `{"changeNumber":1,"values":[
+			{"name":"AzureCloud.westeurope","properties":{"region":"westeurope","addressPrefixes":["20.38.0.0/16"]}},
+			{"name":"Storage.WestEurope","properties":{"region":"westeurope","systemService":"AzureStorage","addressPrefixes":["20.38.108.0/23"]}}]}`,
+	}
+	for path, body := range files {
+		if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ranges, err := LoadRanges([]string{aws, gcp, azure})
+	if err != nil {
+		t.Fatalf("LoadRanges: %v", err)
+	}
+
+	for ip, want := range map[string]string{
+		"52.217.1.2":    "AWS S3 us-east-1",
+		"3.1.2.3":       "AWS us-east-1",
+		"2600:1f18::5":  "AWS EC2 us-east-1",
+		"34.1.210.1":    "Google Cloud africa-south1",
+		"20.38.108.10":  "Azure AzureStorage westeurope",
+		"20.38.1.1":     "Azure westeurope",
+		"198.51.100.20": "",
+	} {
+		if got := ranges.Lookup(netip.MustParseAddr(ip)); got != want {
+			t.Errorf("Lookup(%s) = %q, want %q", ip, got, want)
+		}
+	}
+
+	r := New(Options{Ranges: ranges})
+	if got := r.Label("52.217.1.2:443"); got != "52.217.1.2:443 (AWS S3 us-east-1)" {
+		t.Errorf("Label with ranges = %q", got)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte(`{"foo":1}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadRanges([]string{bad}); err == nil {
+		t.Error("expected an error for a file without ranges")
+	}
+}
+
+func TestParsePodDNSName(t *testing.T) {
+	cases := []struct {
+		name string
+		want PodDNSName
+		ok   bool
+	}{
+		{"web-0.web.db.svc.cluster.local", PodDNSName{"web-0", "web", "db"}, true},
+		{"Kafka-2.kafka-headless.streaming.svc.cluster.local.", PodDNSName{"kafka-2", "kafka-headless", "streaming"}, true},
+		{"web-0.web.db.svc", PodDNSName{"web-0", "web", "db"}, true},
+		{"web.db.svc.cluster.local", PodDNSName{}, false},
+		{"web-0.web.db", PodDNSName{}, false},
+		{"api.eu-west-1.amazonaws.com", PodDNSName{}, false},
+		{"web_0.web.db.svc.cluster.local", PodDNSName{}, false},
+	}
+	for _, c := range cases {
+		got, ok := ParsePodDNSName(c.name)
+		if ok != c.ok || got != c.want {
+			t.Errorf("ParsePodDNSName(%q) = %+v, %v; want %+v, %v", c.name, got, ok, c.want, c.ok)
+		}
+	}
+	if m := (PodDNSName{"web-0", "web", "db"}).Member(); m != "web-0.web.db" {
+		t.Errorf("Member() = %q", m)
+	}
+}
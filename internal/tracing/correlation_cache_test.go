@@ -1,6 +1,7 @@
 package tracing
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -145,6 +146,7 @@ func TestManager_ExportTraces_SplunkFailureAlertSuppressed(t *testing.T) {
 	origJaeger := config.JaegerEndpoint
 	origDataDog := config.DataDogEndpoint
 	origZipkin := config.ZipkinEndpoint
+	origAttempts, origBackoff, origTimeout := config.TracingRetryMaxAttempts, config.TracingRetryInitialBackoff, config.TracingBatchTimeout
 	defer func() {
 		config.TracingEnabled = origTracing
 		config.SplunkEndpoint = origSplunk
@@ -154,6 +156,7 @@ func TestManager_ExportTraces_SplunkFailureAlertSuppressed(t *testing.T) {
 		config.JaegerEndpoint = origJaeger
 		config.DataDogEndpoint = origDataDog
 		config.ZipkinEndpoint = origZipkin
+		config.TracingRetryMaxAttempts, config.TracingRetryInitialBackoff, config.TracingBatchTimeout = origAttempts, origBackoff, origTimeout
 	}()
 
 	config.TracingEnabled = true
@@ -164,6 +167,9 @@ func TestManager_ExportTraces_SplunkFailureAlertSuppressed(t *testing.T) {
 	config.JaegerEndpoint = ""
 	config.DataDogEndpoint = ""
 	config.ZipkinEndpoint = ""
+	config.TracingRetryMaxAttempts = 2
+	config.TracingRetryInitialBackoff = time.Millisecond
+	config.TracingBatchTimeout = time.Millisecond
 
 	m, err := NewManager()
 	if err != nil {
@@ -180,7 +186,12 @@ func TestManager_ExportTraces_SplunkFailureAlertSuppressed(t *testing.T) {
 	}, nil)
 
 	m.exportTraces(true)
-	if got := m.traceTracker.SnapshotForExport(m.exportInterval, true); len(got) == 0 {
-		t.Error("a failed export must not advance the watermark; the trace should remain exportable")
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := m.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown() error = %v", err)
+	}
+	if got := m.DroppedSpans()["splunk"]; got != 1 {
+		t.Errorf("a batch failing every retry must be dropped and counted; dropped = %d, want 1", got)
 	}
 }
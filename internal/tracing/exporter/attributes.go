@@ -0,0 +1,45 @@
+package exporter
+
+import (
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/podtrace/podtrace/internal/events"
+	"github.com/podtrace/podtrace/internal/safeconv"
+)
+
+// eventAttributes maps an event on a span to the attributes every exporter
+// attaches to it, so the backends show the same keys: OTLP as span event
+// attributes, Zipkin in the annotation value.
+func eventAttributes(event *events.Event) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		attribute.String("target", event.Target),
+		attribute.Int64("latency_ns", safeconv.Uint64ToInt64(event.LatencyNS)),
+	}
+	if event.Type == events.EventDNS {
+		attrs = append(attrs,
+			attribute.String("dns.question.name", event.Target),
+			attribute.Int("dns.question.type", int(event.TCPState)),
+			attribute.Int("dns.response.code", int(event.Error)),
+		)
+		if event.Details != "" {
+			attrs = append(attrs, attribute.String("dns.resolved", event.Details))
+			attrs = append(attrs, attribute.Int("dns.answer.count",
+				strings.Count(event.Details, ",")+1))
+		}
+		if s := event.DNSServerAddr(); s != "" {
+			attrs = append(attrs, attribute.String("dns.server", s))
+		}
+		if event.DNSTransport == 1 {
+			attrs = append(attrs, attribute.String("dns.transport", "tcp"))
+		}
+	}
+	if event.Type == events.EventHTTPReq || event.Type == events.EventHTTPResp {
+		attrs = append(attrs,
+			attribute.String("http.scheme", event.HTTPScheme()),
+			attribute.String("podtrace.http.transport", event.HTTPProtoLabel()),
+		)
+	}
+	return attrs
+}
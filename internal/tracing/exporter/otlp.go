@@ -19,8 +19,6 @@ import (
 
 	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/diagnose/tracker"
-	"github.com/podtrace/podtrace/internal/events"
-	"github.com/podtrace/podtrace/internal/safeconv"
 )
 
 type OTLPExporter struct {
@@ -198,38 +196,10 @@ func (e *OTLPExporter) spanSnapshot(span *tracker.Span) (sdktrace.ReadOnlySpan,
 	}
 
 	for _, event := range span.Events {
-		attrs := []attribute.KeyValue{
-			attribute.String("target", event.Target),
-			attribute.Int64("latency_ns", safeconv.Uint64ToInt64(event.LatencyNS)),
-		}
-		if event.Type == events.EventDNS {
-			attrs = append(attrs,
-				attribute.String("dns.question.name", event.Target),
-				attribute.Int("dns.question.type", int(event.TCPState)),
-				attribute.Int("dns.response.code", int(event.Error)),
-			)
-			if event.Details != "" {
-				attrs = append(attrs, attribute.String("dns.resolved", event.Details))
-				attrs = append(attrs, attribute.Int("dns.answer.count",
-					strings.Count(event.Details, ",")+1))
-			}
-			if s := event.DNSServerAddr(); s != "" {
-				attrs = append(attrs, attribute.String("dns.server", s))
-			}
-			if event.DNSTransport == 1 {
-				attrs = append(attrs, attribute.String("dns.transport", "tcp"))
-			}
-		}
-		if event.Type == events.EventHTTPReq || event.Type == events.EventHTTPResp {
-			attrs = append(attrs,
-				attribute.String("http.scheme", event.HTTPScheme()),
-				attribute.String("podtrace.http.transport", event.HTTPProtoLabel()),
-			)
-		}
 		stub.Events = append(stub.Events, sdktrace.Event{
 			Name:       event.TypeString(),
 			Time:       event.TimestampTime(),
-			Attributes: attrs,
+			Attributes: eventAttributes(event),
 		})
 	}
 
@@ -10,6 +10,7 @@ import (
 
 	"github.com/podtrace/podtrace/internal/config"
 	"github.com/podtrace/podtrace/internal/diagnose/tracker"
+	"github.com/podtrace/podtrace/internal/events"
 )
 
 type ZipkinExporter struct {
@@ -115,12 +116,13 @@ func (e *ZipkinExporter) exportTrace(t *tracker.Trace) error {
 
 		if span.Error {
 			zs.Tags["error"] = "true"
+			zs.Tags["otel.status_code"] = "ERROR"
 		}
 
 		for _, event := range span.Events {
 			zs.Annotations = append(zs.Annotations, zipkinAnnotation{
 				Timestamp: event.TimestampTime().UnixMicro(),
-				Value:     fmt.Sprintf("%s %s latency=%dns", event.TypeString(), event.Target, event.LatencyNS),
+				Value:     zipkinAnnotationValue(event),
 			})
 		}
 
@@ -169,6 +171,22 @@ func zipkinKind(span *tracker.Span) string {
 	return ""
 }
 
+// zipkinAnnotationValue carries the event attributes the OTLP exporter
+// sets the way OpenTelemetry encodes them for Zipkin: the event name, then
+// the attributes as a JSON object.
+func zipkinAnnotationValue(event *events.Event) string {
+	attrs := eventAttributes(event)
+	fields := make(map[string]interface{}, len(attrs))
+	for _, kv := range attrs {
+		fields[string(kv.Key)] = kv.Value.AsInterface()
+	}
+	b, err := json.Marshal(fields)
+	if err != nil {
+		return event.TypeString()
+	}
+	return event.TypeString() + ": " + string(b)
+}
+
 // max64 returns the larger of two int64 values.
 func max64(a, b int64) int64 {
 	if a > b {
@@ -5,10 +5,12 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/podtrace/podtrace/internal/diagnose/tracker"
+	"github.com/podtrace/podtrace/internal/events"
 )
 
 func newTestZipkinTrace() *tracker.Trace {
@@ -192,6 +194,54 @@ func TestZipkinExporter_exportTrace_ErrorTagInPayload(t *testing.T) {
 	}
 }
 
+func TestZipkinExporter_exportTrace_EventAttributes(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	exporter, err := NewZipkinExporter(srv.URL, 1.0)
+	if err != nil {
+		t.Fatalf("NewZipkinExporter() error: %v", err)
+	}
+
+	trace := newTestZipkinTrace()
+	trace.Spans[0].Events = []*events.Event{{
+		Type:      events.EventDNS,
+		Target:    "db.internal",
+		LatencyNS: 2500,
+		Details:   "10.0.0.1,10.0.0.2",
+	}}
+	if err := exporter.exportTrace(trace); err != nil {
+		t.Fatalf("exportTrace() unexpected error: %v", err)
+	}
+
+	var payload []zipkinSpan
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("failed to unmarshal payload: %v", err)
+	}
+	value := payload[0].Annotations[0].Value
+	name, attrs, ok := strings.Cut(value, ": ")
+	if !ok || name != "DNS" {
+		t.Fatalf("annotation %q: want the event name then its attributes", value)
+	}
+	var got map[string]interface{}
+	if err := json.Unmarshal([]byte(attrs), &got); err != nil {
+		t.Fatalf("annotation attributes %q: %v", attrs, err)
+	}
+	// The keys the OTLP exporter sets on the same event.
+	for _, kv := range eventAttributes(trace.Spans[0].Events[0]) {
+		if _, ok := got[string(kv.Key)]; !ok {
+			t.Errorf("annotation lacks %s: %s", kv.Key, attrs)
+		}
+	}
+	if got["dns.question.name"] != "db.internal" || got["dns.answer.count"] != float64(2) {
+		t.Errorf("annotation attributes = %v", got)
+	}
+}
+
 func TestZipkinExporter_exportTrace_ContentTypeHeader(t *testing.T) {
 	var contentType string
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -3,6 +3,7 @@ package tracing
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -33,6 +34,8 @@ type Manager struct {
 	cleanupInterval time.Duration
 	synthesize      bool
 	corr            *correlationCache
+	ops             *operationCache
+	queues          []*exportQueue
 	stopCh          chan struct{}
 	stopOnce        sync.Once
 	wg              sync.WaitGroup
@@ -89,7 +92,7 @@ func NewManager() (*Manager, error) {
 		}
 	}
 
-	return &Manager{
+	m := &Manager{
 		enabled:         true,
 		extractor:       extractor,
 		traceTracker:    traceTracker,
@@ -103,15 +106,19 @@ func NewManager() (*Manager, error) {
 		cleanupInterval: 1 * time.Minute,
 		synthesize:      config.SynthesizeSpans,
 		corr:            newCorrelationCache(config.MaxTraceContextCacheSize),
+		ops:             newOperationCache(config.MaxTraceContextCacheSize),
 		stopCh:          make(chan struct{}),
-	}, nil
+	}
+	for _, target := range m.exporterTargets() {
+		m.queues = append(m.queues, newExportQueue(target, alertExportFailure))
+	}
+	return m, nil
 }
 
 func (m *Manager) ProcessEvent(event *events.Event, k8sContext interface{}) {
 	if !m.enabled || event == nil {
 		return
 	}
-
 	haveContext := false
 	if event.Details != "" {
 		if tc := m.extractor.ExtractFromRawHeaders(event.Details); tc != nil && tc.HasRemoteParent() {
@@ -123,9 +130,55 @@ func (m *Manager) ProcessEvent(event *events.Event, k8sContext interface{}) {
 		}
 	}
 
-	if m.assignSpanIdentity(event, haveContext) {
-		m.traceTracker.ProcessEvent(event, k8sContext)
+	var op *pendingOperation
+	if event.Type == events.EventHTTPReq && m.ops != nil {
+		op = m.ops.take(event)
+	}
+	if !m.assignSpanIdentity(event, haveContext) {
+		// Connection setup without a trace of its own waits for the
+		// request it leads to.
+		if m.ops != nil {
+			m.ops.observe(event)
+		}
+		return
+	}
+	if op != nil {
+		m.linkOperation(event, op, k8sContext)
+	}
+	m.traceTracker.ProcessEvent(event, k8sContext)
+}
+
+// linkOperation makes the request and the setup of the connection it went
+// out on children of one operation span: DNS lookup, connect and TLS
+// handshake each get a span next to the request's, all under a span
+// covering the whole call, in the request's trace.
+func (m *Manager) linkOperation(req *events.Event, op *pendingOperation, k8sContext interface{}) {
+	key := strconv.FormatUint(uint64(op.connect.PID), 10) + "\x00" + op.connect.Target + "\x00" + strconv.FormatUint(op.connect.Timestamp, 10)
+	opSpanID := deriveSpanID("operation\x00" + key)
+
+	peer := op.peerName
+	if peer == "" {
+		peer = op.connect.Target
+	}
+	attrs := map[string]string{
+		"podtrace.operation": "outbound_request",
+		"net.peer.name":      peer,
+		"net.peer.address":   op.connect.Target,
+	}
+	if req.ProcessName != "" {
+		attrs["process.name"] = req.ProcessName
+	}
+	m.traceTracker.StartOperation(req.TraceID, opSpanID, req.ParentSpanID, req.TypeString()+" "+peer, attrs)
+
+	for _, ev := range op.setupEvents() {
+		ev.TraceID = req.TraceID
+		ev.SpanID = deriveSpanID(key + "\x00" + ev.TypeString())
+		ev.ParentSpanID = opSpanID
+		ev.TraceFlags = req.TraceFlags
+		ev.TraceState = req.TraceState
+		m.traceTracker.ProcessEvent(ev, k8sContext)
 	}
+	req.ParentSpanID = opSpanID
 }
 
 // assignSpanIdentity gives the event a stable per-request span id and, for the
@@ -148,6 +201,16 @@ func (m *Manager) assignSpanIdentity(event *events.Event, haveContext bool) bool
 		return true
 	}
 
+	if event.Type == events.EventAnnotation || event.Type == events.EventDisruption || event.Type == events.EventChaos ||
+		event.Type == events.EventPause {
+		// Each marker is its own single-span trace, so it shows up on the
+		// tracing backend's timeline next to the traffic it explains.
+		key := strings.ToLower(event.TypeString()) + "\x00" + strconv.FormatUint(event.Timestamp, 10) + "\x00" + event.Target
+		event.TraceID = deriveTraceID(key)
+		event.SpanID = deriveSpanID(key)
+		return true
+	}
+
 	if isCorrelatableL7(event) {
 		key := correlationKey(event)
 		if e, ok := m.corr.loadDelete(key); ok {
@@ -174,6 +237,7 @@ func (m *Manager) Start(ctx context.Context) error {
 		return nil
 	}
 
+	m.startQueues()
 	m.wg.Add(2)
 	go m.exportLoop(ctx)
 	go m.cleanupLoop(ctx)
@@ -214,6 +278,9 @@ func (m *Manager) cleanupLoop(ctx context.Context) {
 		case <-ticker.C:
 			m.traceTracker.CleanupOldTraces(10 * time.Minute)
 			m.corr.sweep(2 * time.Minute)
+			if m.ops != nil {
+				m.ops.sweep(2 * time.Minute)
+			}
 		}
 	}
 }
@@ -266,52 +333,71 @@ func (m *Manager) exporterTargets() []exporterTarget {
 	return out
 }
 
+// startQueues starts the worker of every exporter queue.
+func (m *Manager) startQueues() {
+	for _, q := range m.queues {
+		q.start()
+	}
+}
+
 // exportTraces hands each span to every exporter exactly once: the tracker
 // snapshot advances a per-trace watermark, so a tick no longer re-sends every
 // accumulated trace (which duplicated spans in all backends on every 5s
 // interval). force (shutdown) flushes spans of traces that are still
-// settling.
+// settling. The spans go to the exporter queues without waiting on any
+// collector; from there each queue delivers or drops and counts them.
 func (m *Manager) exportTraces(force bool) {
 	traces := m.traceTracker.SnapshotForExport(m.exportInterval, force)
 	if len(traces) == 0 {
 		return
 	}
-
-	allSucceeded := true
-	for _, target := range m.exporterTargets() {
-		err := target.export(traces)
-		if err == nil {
-			continue
-		}
-		allSucceeded = false
-		logger.Warn("Failed to export traces", zap.String("exporter", target.name), zap.Error(err))
-		if target.suppressAlert {
-			continue
+	m.traceTracker.CommitExport(traces)
+	// Exporters fill in span fields as they convert them, so every queue
+	// but the last gets its own copy.
+	for i, q := range m.queues {
+		batch := traces
+		if i < len(m.queues)-1 {
+			batch = make([]*tracker.Trace, len(traces))
+			for j, t := range traces {
+				batch[j] = t.Clone()
+			}
 		}
-		manager := alerting.GetGlobalManager()
-		if manager == nil {
-			continue
-		}
-		manager.SendAlert(&alerting.Alert{
-			Severity:  alerting.SeverityWarning,
-			Title:     fmt.Sprintf("%s Exporter Failure", strings.ToUpper(target.name[:1])+target.name[1:]),
-			Message:   fmt.Sprintf("Failed to export traces to %s: %v", target.name, err),
-			Timestamp: time.Now(),
-			Source:    "exporter",
-			Context: map[string]interface{}{
-				"exporter": target.name,
-				"endpoint": target.endpoint,
-				"error":    err.Error(),
-			},
-			Recommendations: target.recommendations,
-		})
+		q.enqueue(batch)
+	}
+}
+
+// DroppedSpans returns how many spans each exporter dropped so far.
+func (m *Manager) DroppedSpans() map[string]uint64 {
+	out := make(map[string]uint64, len(m.queues))
+	for _, q := range m.queues {
+		out[q.target.name] = q.dropped.Load()
 	}
+	return out
+}
 
-	// Advance the per-trace watermark only when every exporter accepted the
-	// spans.
-	if allSucceeded {
-		m.traceTracker.CommitExport(traces)
+// alertExportFailure raises an alert for a batch an exporter dropped after
+// its last retry.
+func alertExportFailure(target exporterTarget, err error) {
+	if target.suppressAlert {
+		return
 	}
+	manager := alerting.GetGlobalManager()
+	if manager == nil {
+		return
+	}
+	manager.SendAlert(&alerting.Alert{
+		Severity:  alerting.SeverityWarning,
+		Title:     fmt.Sprintf("%s Exporter Failure", strings.ToUpper(target.name[:1])+target.name[1:]),
+		Message:   fmt.Sprintf("Failed to export traces to %s: %v", target.name, err),
+		Timestamp: time.Now(),
+		Source:    "exporter",
+		Context: map[string]interface{}{
+			"exporter": target.name,
+			"endpoint": target.endpoint,
+			"error":    err.Error(),
+		},
+		Recommendations: target.recommendations,
+	})
 }
 
 func (m *Manager) GetRequestFlowGraph() *graph.RequestFlowGraph {
@@ -341,15 +427,26 @@ func (m *Manager) Shutdown(ctx context.Context) error {
 	go func() {
 		defer close(done)
 		m.wg.Wait()
-		// Final flush: force-export spans of traces still settling.
+		// Final flush: force-export spans of traces still settling, then
+		// let every queue drain.
 		m.exportTraces(true)
+		m.startQueues()
+		for _, q := range m.queues {
+			q.close()
+		}
+		for _, q := range m.queues {
+			<-q.done
+		}
 		m.shutdownExporters(ctx)
 	}()
 
 	select {
 	case <-done:
 	case <-ctx.Done():
-		logger.Warn("Tracing shutdown exceeded its deadline; abandoning in-flight export", zap.Error(ctx.Err()))
+		for _, q := range m.queues {
+			q.stop()
+		}
+		logger.Warn("Tracing shutdown exceeded its deadline; dropping queued spans", zap.Error(ctx.Err()))
 	}
 	return nil
 }
@@ -20,6 +20,7 @@ func newTestManager(synthesize bool) *Manager {
 		extractor:    extractor.NewHTTPExtractor(),
 		traceTracker: tracker.NewTraceTracker(),
 		corr:         newCorrelationCache(config.MaxTraceContextCacheSize),
+		ops:          newOperationCache(config.MaxTraceContextCacheSize),
 		synthesize:   synthesize,
 	}
 }
@@ -133,3 +134,25 @@ func TestProcessEvent_NoSynthesize_ContextlessDropped(t *testing.T) {
 		t.Errorf("expected 0 traces without context and synthesis off, got %d", n)
 	}
 }
+
+func TestAnnotationBecomesOwnSpan(t *testing.T) {
+	// Annotations are exported even when synthesis is off.
+	m := newTestManager(false)
+	m.ProcessEvent(&events.Event{Type: events.EventAnnotation, Timestamp: 1_000, Target: "deployed v2"}, nil)
+
+	span := onlySpan(t, m)
+	if span.Operation != "ANNOTATION" || span.Attributes["target"] != "deployed v2" {
+		t.Fatalf("annotation span = %+v", span)
+	}
+}
+
+func TestDisruptionBecomesOwnSpan(t *testing.T) {
+	m := newTestManager(false)
+	details := events.DisruptionDetails{Kind: events.DisruptionEviction, Resource: "memory", Node: "node-1"}.String()
+	m.ProcessEvent(&events.Event{Type: events.EventDisruption, Timestamp: 1_000, Target: "batch/job-1", Details: details}, nil)
+
+	span := onlySpan(t, m)
+	if span.Operation != "DISRUPTION" || span.Attributes["target"] != "batch/job-1" {
+		t.Fatalf("disruption span = %+v", span)
+	}
+}
@@ -0,0 +1,241 @@
+package tracing
+
+import (
+	"net"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// pendingOperation is the setup of one outbound connection: its DNS
+// lookup, connect and TLS handshake, held until the first request on it.
+type pendingOperation struct {
+	dns      *events.Event
+	connect  *events.Event
+	tls      *events.Event
+	peerName string
+	endpoint string
+	storedNS int64
+}
+
+// operationCache links the connection setup events of a process to the
+// first request sent on that connection. A connection is known by its
+// socket: BPF stamps connect, TLS handshake and L7 events with the kernel
+// address of the socket they went through (SockID), which stays the same
+// for the life of the connection. Events without one, from kernels
+// without BTF or older recordings, fall back to the process and remote
+// endpoint: the connect event's target and the peer address BPF fuses
+// into L7 and TLS handshake events.
+type operationCache struct {
+	mu sync.Mutex
+	// lookups holds DNS answers by process and resolved address.
+	lookups map[string]lookupEntry
+	// conns holds connections without a request yet, by socket, or by
+	// process and remote endpoint when the socket is unknown.
+	conns map[string]*pendingOperation
+	// endpoints maps the endpoint key of a connection held by socket to
+	// its socket key, for events that carry no socket.
+	endpoints map[string]string
+	// lastConn is the latest connect of a process, for TLS handshakes
+	// that carry no socket or peer address.
+	lastConn   map[uint32]string
+	maxEntries int
+}
+
+type lookupEntry struct {
+	event    *events.Event
+	storedNS int64
+}
+
+func newOperationCache(maxEntries int) *operationCache {
+	return &operationCache{
+		lookups:    make(map[string]lookupEntry),
+		conns:      make(map[string]*pendingOperation),
+		endpoints:  make(map[string]string),
+		lastConn:   make(map[uint32]string),
+		maxEntries: maxEntries,
+	}
+}
+
+// sockKey is the key of the connection on a socket.
+func sockKey(id uint64) string {
+	return "sock\x00" + strconv.FormatUint(id, 16)
+}
+
+// endpointKey is the key of a process's connection to host:port.
+func endpointKey(pid uint32, host string, port uint16) string {
+	return strconv.FormatUint(uint64(pid), 10) + "\x00" + normalizeHost(host) + "\x00" + strconv.FormatUint(uint64(port), 10)
+}
+
+func lookupKey(pid uint32, host string) string {
+	return strconv.FormatUint(uint64(pid), 10) + "\x00" + normalizeHost(host)
+}
+
+func normalizeHost(host string) string {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return addr.Unmap().String()
+	}
+	return host
+}
+
+// splitConnectTarget splits a connect event's target. IPv6 targets come
+// from BPF as eight groups and the port joined by colons, without
+// brackets.
+func splitConnectTarget(target string) (host string, port uint16, ok bool) {
+	if h, p, err := net.SplitHostPort(target); err == nil {
+		n, err := strconv.ParseUint(p, 10, 16)
+		return h, uint16(n), err == nil
+	}
+	i := strings.LastIndexByte(target, ':')
+	if i <= 0 {
+		return "", 0, false
+	}
+	n, err := strconv.ParseUint(target[i+1:], 10, 16)
+	if err != nil {
+		return "", 0, false
+	}
+	return target[:i], uint16(n), true
+}
+
+// connKey returns the key an event's connection is held under: its
+// socket when BPF saw one, else its process and remote endpoint.
+func (c *operationCache) connKey(e *events.Event) (string, bool) {
+	if e.SockID != 0 {
+		return sockKey(e.SockID), true
+	}
+	if e.PeerDstIP == "" {
+		return "", false
+	}
+	ek := endpointKey(e.PID, e.PeerDstIP, e.PeerDstPort)
+	if k, ok := c.endpoints[ek]; ok {
+		return k, true
+	}
+	return ek, true
+}
+
+// observe keeps a copy of a DNS, connect or TLS handshake event for
+// linking. Other events are ignored.
+func (c *operationCache) observe(e *events.Event) {
+	switch e.Type {
+	case events.EventDNS, events.EventConnect, events.EventTLSHandshake:
+	default:
+		return
+	}
+	ev := *e
+	now := time.Now().UnixNano()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.lookups)+len(c.conns) >= c.maxEntries {
+		c.lookups = make(map[string]lookupEntry)
+		c.conns = make(map[string]*pendingOperation)
+		c.endpoints = make(map[string]string)
+		c.lastConn = make(map[uint32]string)
+	}
+
+	switch e.Type {
+	case events.EventDNS:
+		if e.Error != 0 {
+			return
+		}
+		for _, addr := range strings.Split(e.Details, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				c.lookups[lookupKey(e.PID, addr)] = lookupEntry{event: &ev, storedNS: now}
+			}
+		}
+	case events.EventConnect:
+		host, port, ok := splitConnectTarget(e.Target)
+		if !ok {
+			return
+		}
+		op := &pendingOperation{connect: &ev, peerName: e.Details, storedNS: now}
+		lk := lookupKey(e.PID, host)
+		if l, ok := c.lookups[lk]; ok {
+			op.dns = l.event
+			op.peerName = l.event.Target
+			delete(c.lookups, lk)
+		}
+		key := endpointKey(e.PID, host, port)
+		if e.SockID != 0 {
+			op.endpoint = key
+			key = sockKey(e.SockID)
+			c.endpoints[op.endpoint] = key
+		}
+		c.conns[key] = op
+		c.lastConn[e.PID] = key
+	case events.EventTLSHandshake:
+		key, ok := c.connKey(e)
+		if !ok {
+			key, ok = c.lastConn[e.PID]
+		}
+		if op := c.conns[key]; ok && op != nil && op.tls == nil {
+			op.tls = &ev
+		}
+	}
+}
+
+// take returns and forgets the setup of the connection a request went
+// out on, or nil when it was set up before the trace or not seen.
+func (c *operationCache) take(req *events.Event) *pendingOperation {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key, ok := c.connKey(req)
+	if !ok {
+		return nil
+	}
+	op, ok := c.conns[key]
+	if !ok {
+		return nil
+	}
+	delete(c.conns, key)
+	if op.endpoint != "" && c.endpoints[op.endpoint] == key {
+		delete(c.endpoints, op.endpoint)
+	}
+	if c.lastConn[req.PID] == key {
+		delete(c.lastConn, req.PID)
+	}
+	return op
+}
+
+// sweep drops setups older than maxAge: lookups never connected to and
+// connections that never carried a request.
+func (c *operationCache) sweep(maxAge time.Duration) {
+	cutoff := time.Now().Add(-maxAge).UnixNano()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, l := range c.lookups {
+		if l.storedNS < cutoff {
+			delete(c.lookups, k)
+		}
+	}
+	for k, op := range c.conns {
+		if op.storedNS < cutoff {
+			delete(c.conns, k)
+		}
+	}
+	for ek, k := range c.endpoints {
+		if _, ok := c.conns[k]; !ok {
+			delete(c.endpoints, ek)
+		}
+	}
+	for pid, k := range c.lastConn {
+		if _, ok := c.conns[k]; !ok {
+			delete(c.lastConn, pid)
+		}
+	}
+}
+
+// setupEvents returns the setup events in the order they happened.
+func (op *pendingOperation) setupEvents() []*events.Event {
+	out := make([]*events.Event, 0, 3)
+	for _, e := range []*events.Event{op.dns, op.connect, op.tls} {
+		if e != nil {
+			out = append(out, e)
+		}
+	}
+	return out
+}
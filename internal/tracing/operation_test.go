@@ -0,0 +1,138 @@
+package tracing
+
+import (
+	"testing"
+
+	"github.com/podtrace/podtrace/internal/diagnose/tracker"
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func outboundCall(pid uint32, tp string) []*events.Event {
+	return []*events.Event{
+		{Type: events.EventDNS, PID: pid, Timestamp: 2000, LatencyNS: 1000, Target: "api.internal", Details: "10.0.0.2"},
+		{Type: events.EventConnect, PID: pid, Timestamp: 3000, LatencyNS: 500, Target: "10.0.0.2:80"},
+		{Type: events.EventTLSHandshake, PID: pid, Timestamp: 4000, LatencyNS: 800, PeerDstIP: "10.0.0.2", PeerDstPort: 80},
+		func() *events.Event { e := httpReq(1000, 5000, tp); e.PID = pid; return e }(),
+		func() *events.Event { e := httpResp(1000, 9000, 4000); e.PID = pid; return e }(),
+	}
+}
+
+func spansByOperation(spans []*tracker.Span) map[string]*tracker.Span {
+	out := make(map[string]*tracker.Span, len(spans))
+	for _, s := range spans {
+		out[s.Operation] = s
+	}
+	return out
+}
+
+func TestProcessEvent_LinksConnectionSetupUnderOneOperation(t *testing.T) {
+	m := newTestManager(false)
+	tp := "traceparent: 00-" + testAppTraceID + "-" + testAppSpanID + "-01"
+	for _, e := range outboundCall(42, tp) {
+		m.ProcessEvent(e, nil)
+	}
+
+	traces := m.traceTracker.SnapshotForExport(0, true)
+	if len(traces) != 1 || len(traces[0].Spans) != 5 {
+		t.Fatalf("want one trace of 5 spans (operation, DNS, connect, TLS, HTTP), got %d traces", len(traces))
+	}
+	spans := spansByOperation(traces[0].Spans)
+	op := spans["HTTP api.internal"]
+	if op == nil {
+		t.Fatalf("no operation span named after the resolved host: %v", spans)
+	}
+	if op.TraceID != testAppTraceID || op.ParentSpanID != testAppSpanID {
+		t.Errorf("operation span in trace %s under %s, want the caller's %s/%s", op.TraceID, op.ParentSpanID, testAppTraceID, testAppSpanID)
+	}
+	for name, s := range spans {
+		if s == op {
+			continue
+		}
+		if s.ParentSpanID != op.SpanID {
+			t.Errorf("%s span parent = %s, want the operation %s", name, s.ParentSpanID, op.SpanID)
+		}
+	}
+	// From the DNS lookup's start to the response.
+	dns := spans["DNS"]
+	if !op.StartTime.Equal(dns.StartTime) || op.Duration != 8000 {
+		t.Errorf("operation spans %v for %v, want from the DNS start for 8µs", op.StartTime, op.Duration)
+	}
+
+	// A second request on the kept-alive connection is not linked again.
+	m.ProcessEvent(func() *events.Event { e := httpReq(2000, 20000, tp); e.PID = 42; return e }(), nil)
+	traces = m.traceTracker.SnapshotForExport(0, true)
+	for _, s := range traces[0].Spans {
+		if s.Operation == "HTTP" && s.Attributes["podtrace.correlation_id"] == "2000" && s.ParentSpanID != testAppSpanID {
+			t.Errorf("keep-alive request parented to %s, want the caller's span", s.ParentSpanID)
+		}
+	}
+}
+
+func TestProcessEvent_SetupOfOtherProcessesIsNotLinked(t *testing.T) {
+	m := newTestManager(true)
+	evs := outboundCall(42, "")
+	for _, e := range evs[:3] {
+		m.ProcessEvent(e, nil)
+	}
+	for _, e := range evs[3:] {
+		e.PID = 7
+		m.ProcessEvent(e, nil)
+	}
+	traces := m.traceTracker.SnapshotForExport(0, true)
+	if len(traces) != 1 || len(traces[0].Spans) != 1 {
+		t.Fatalf("another process's request picked up the setup: %d traces", len(traces))
+	}
+}
+
+func TestProcessEvent_LinksSetupBySocket(t *testing.T) {
+	m := newTestManager(false)
+	// Two pooled connections of one process to the same endpoint; the
+	// handshake carries only its socket.
+	for _, e := range []*events.Event{
+		{Type: events.EventDNS, PID: 42, Timestamp: 2000, LatencyNS: 1000, Target: "api.internal", Details: "10.0.0.2"},
+		{Type: events.EventConnect, PID: 42, Timestamp: 3000, LatencyNS: 500, Target: "10.0.0.2:80", SockID: 0xa1},
+		{Type: events.EventConnect, PID: 42, Timestamp: 3500, LatencyNS: 500, Target: "10.0.0.2:80", SockID: 0xb2},
+		{Type: events.EventTLSHandshake, PID: 42, Timestamp: 4000, LatencyNS: 800, SockID: 0xa1},
+	} {
+		m.ProcessEvent(e, nil)
+	}
+	const otherTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	for _, r := range []struct {
+		sock    uint64
+		traceID string
+	}{{0xb2, otherTraceID}, {0xa1, testAppTraceID}} {
+		req := httpReq(r.sock, 5000, "traceparent: 00-"+r.traceID+"-"+testAppSpanID+"-01")
+		req.PID = 42
+		req.SockID = r.sock
+		m.ProcessEvent(req, nil)
+	}
+
+	spans := make(map[string]map[string]*tracker.Span)
+	for _, tr := range m.traceTracker.SnapshotForExport(0, true) {
+		spans[tr.TraceID] = spansByOperation(tr.Spans)
+	}
+	first, second := spans[testAppTraceID], spans[otherTraceID]
+	if len(first) != 5 || first["TLS"] == nil {
+		t.Errorf("request on the first socket got %d spans %v, want operation, DNS, connect, TLS and HTTP", len(first), first)
+	}
+	if len(second) != 3 || second["NET"] == nil || second["TLS"] != nil {
+		t.Errorf("request on the second socket got %d spans %v, want operation, connect and HTTP", len(second), second)
+	}
+}
+
+func TestSplitConnectTarget(t *testing.T) {
+	for target, want := range map[string]string{
+		"10.0.0.2:443":                          "10.0.0.2",
+		"[2001:db8::1]:443":                     "2001:db8::1",
+		"2001:db8:000:000:000:000:000:001:443":  "2001:db8::1",
+		"fd00:000:000:000:000:000:000:00a:8080": "fd00::a",
+	} {
+		host, _, ok := splitConnectTarget(target)
+		if !ok || normalizeHost(host) != want {
+			t.Errorf("%s: host %q, want %q", target, normalizeHost(host), want)
+		}
+	}
+	if _, _, ok := splitConnectTarget("no-port"); ok {
+		t.Error("target without a port accepted")
+	}
+}
@@ -0,0 +1,212 @@
+package tracing
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/podtrace/podtrace/internal/config"
+	"github.com/podtrace/podtrace/internal/diagnose/tracker"
+	"github.com/podtrace/podtrace/internal/logger"
+	"github.com/podtrace/podtrace/internal/metricsexporter"
+)
+
+// Reasons a queue drops spans, as the reason label of
+// podtrace_tracing_spans_dropped_total.
+const (
+	dropQueueFull    = "queue_full"
+	dropExportFailed = "export_failed"
+	dropShutdown     = "shutdown"
+)
+
+// Backoff is the retry policy every exporter queue shares. The wait before
+// retry n is Initial doubled n-1 times, capped at Max, with up to a fifth
+// taken off at random so exporters that failed together do not retry in
+// lockstep.
+type Backoff struct {
+	Initial     time.Duration
+	Max         time.Duration
+	MaxAttempts int
+}
+
+func backoffFromConfig() Backoff {
+	return Backoff{
+		Initial:     config.TracingRetryInitialBackoff,
+		Max:         config.TracingRetryMaxBackoff,
+		MaxAttempts: config.TracingRetryMaxAttempts,
+	}
+}
+
+// Delay returns the wait before the retry that follows failed attempt n,
+// counting from 1.
+func (b Backoff) Delay(attempt int) time.Duration {
+	d := b.Initial
+	for i := 1; i < attempt && d < b.Max; i++ {
+		d *= 2
+	}
+	if b.Max > 0 && d > b.Max {
+		d = b.Max
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d - time.Duration(rand.Int64N(int64(d)/5+1))
+}
+
+// exportQueue decouples one exporter from the export loop: traces wait in
+// a bounded channel and a worker sends them in batches, retrying with the
+// shared backoff. A full queue or a batch that runs out of retries drops
+// its spans and counts them, so a slow or dead collector never holds up
+// the other exporters or event processing.
+type exportQueue struct {
+	target       exporterTarget
+	ch           chan *tracker.Trace
+	batchSize    int
+	batchTimeout time.Duration
+	backoff      Backoff
+	// onFailure is told about a batch dropped after its last retry.
+	onFailure func(exporterTarget, error)
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+	// abort cuts retries and the drain short once shutdown runs out of
+	// time.
+	abort     chan struct{}
+	abortOnce sync.Once
+
+	dropped atomic.Uint64
+}
+
+func newExportQueue(target exporterTarget, onFailure func(exporterTarget, error)) *exportQueue {
+	return &exportQueue{
+		target:       target,
+		ch:           make(chan *tracker.Trace, max(config.TracingQueueSize, 1)),
+		batchSize:    max(config.TracingBatchSize, 1),
+		batchTimeout: config.TracingBatchTimeout,
+		backoff:      backoffFromConfig(),
+		onFailure:    onFailure,
+		done:         make(chan struct{}),
+		abort:        make(chan struct{}),
+	}
+}
+
+// start runs the worker once.
+func (q *exportQueue) start() {
+	q.startOnce.Do(func() { go q.run() })
+}
+
+// enqueue hands traces to the worker without blocking. It reports how
+// many spans did not fit.
+func (q *exportQueue) enqueue(traces []*tracker.Trace) int {
+	dropped := 0
+	for _, t := range traces {
+		select {
+		case q.ch <- t:
+		default:
+			dropped += len(t.Spans)
+		}
+	}
+	if dropped > 0 {
+		q.drop(dropQueueFull, dropped)
+		logger.Debug("Tracing exporter queue full; dropping spans",
+			zap.String("exporter", q.target.name), zap.Int("spans", dropped))
+	}
+	return dropped
+}
+
+// close stops taking traces; the worker sends what is queued and exits.
+func (q *exportQueue) close() {
+	q.closeOnce.Do(func() { close(q.ch) })
+}
+
+// stop gives up on the queued traces and any retry in flight.
+func (q *exportQueue) stop() {
+	q.abortOnce.Do(func() { close(q.abort) })
+}
+
+func (q *exportQueue) run() {
+	defer close(q.done)
+	for {
+		first, ok := <-q.ch
+		if !ok {
+			return
+		}
+		batch, open := q.collect(first)
+		q.send(batch)
+		if !open {
+			return
+		}
+	}
+}
+
+// collect gathers a batch starting with first until it is full, the batch
+// timeout passes or the queue closes. open is false once it has closed.
+func (q *exportQueue) collect(first *tracker.Trace) (batch []*tracker.Trace, open bool) {
+	batch = append(make([]*tracker.Trace, 0, q.batchSize), first)
+	timer := time.NewTimer(q.batchTimeout)
+	defer timer.Stop()
+	for len(batch) < q.batchSize {
+		select {
+		case t, ok := <-q.ch:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, t)
+		case <-timer.C:
+			return batch, true
+		}
+	}
+	return batch, true
+}
+
+func (q *exportQueue) send(batch []*tracker.Trace) {
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-q.abort:
+			q.drop(dropShutdown, spanCount(batch))
+			return
+		default:
+		}
+		err := q.target.export(batch)
+		if err == nil {
+			return
+		}
+		if attempt >= max(q.backoff.MaxAttempts, 1) {
+			q.drop(dropExportFailed, spanCount(batch))
+			logger.Warn("Failed to export traces; dropping batch",
+				zap.String("exporter", q.target.name), zap.Int("attempts", attempt),
+				zap.Int("spans", spanCount(batch)), zap.Error(err))
+			if q.onFailure != nil {
+				q.onFailure(q.target, err)
+			}
+			return
+		}
+		logger.Debug("Failed to export traces; retrying",
+			zap.String("exporter", q.target.name), zap.Int("attempt", attempt), zap.Error(err))
+		wait := time.NewTimer(q.backoff.Delay(attempt))
+		select {
+		case <-wait.C:
+		case <-q.abort:
+			wait.Stop()
+			q.drop(dropShutdown, spanCount(batch))
+			return
+		}
+	}
+}
+
+func (q *exportQueue) drop(reason string, spans int) {
+	q.dropped.Add(uint64(spans))
+	metricsexporter.AddTracingSpansDropped(q.target.name, reason, spans)
+}
+
+func spanCount(traces []*tracker.Trace) int {
+	n := 0
+	for _, t := range traces {
+		n += len(t.Spans)
+	}
+	return n
+}
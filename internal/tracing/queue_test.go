@@ -0,0 +1,121 @@
+package tracing
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/diagnose/tracker"
+)
+
+func queueTraces(n int) []*tracker.Trace {
+	out := make([]*tracker.Trace, n)
+	for i := range out {
+		out[i] = &tracker.Trace{Spans: []*tracker.Span{{}, {}}}
+	}
+	return out
+}
+
+func testQueue(size, batch int, export func([]*tracker.Trace) error) *exportQueue {
+	return &exportQueue{
+		target:       exporterTarget{name: "test", export: export},
+		ch:           make(chan *tracker.Trace, size),
+		batchSize:    batch,
+		batchTimeout: 10 * time.Millisecond,
+		backoff:      Backoff{Initial: time.Millisecond, Max: 4 * time.Millisecond, MaxAttempts: 3},
+		done:         make(chan struct{}),
+		abort:        make(chan struct{}),
+	}
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Initial: 100 * time.Millisecond, Max: time.Second}
+	for attempt, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 30: time.Second} {
+		if got := b.Delay(attempt); got > want || got < want*4/5 {
+			t.Errorf("Delay(%d) = %v, want %v less up to a fifth", attempt, got, want)
+		}
+	}
+}
+
+func TestExportQueue_BatchesAndDropsWhenFull(t *testing.T) {
+	var mu sync.Mutex
+	var batches []int
+	release := make(chan struct{})
+	q := testQueue(4, 3, func(traces []*tracker.Trace) error {
+		<-release
+		mu.Lock()
+		batches = append(batches, len(traces))
+		mu.Unlock()
+		return nil
+	})
+
+	// With the worker not started, a slow collector: the queue takes four
+	// traces and drops the rest without blocking.
+	if dropped := q.enqueue(queueTraces(6)); dropped != 4 {
+		t.Fatalf("dropped %d spans, want 4", dropped)
+	}
+	close(release)
+	q.start()
+	q.close()
+	<-q.done
+
+	if len(batches) != 2 || batches[0] != 3 || batches[1] != 1 {
+		t.Errorf("batches = %v, want [3 1]", batches)
+	}
+	if got := q.dropped.Load(); got != 4 {
+		t.Errorf("dropped counter = %d, want 4", got)
+	}
+}
+
+func TestExportQueue_RetriesThenDrops(t *testing.T) {
+	var calls atomic.Int32
+	var failed error
+	exported := make(chan struct{})
+	q := testQueue(4, 4, func([]*tracker.Trace) error {
+		if calls.Add(1) == 2 {
+			close(exported)
+			return nil
+		}
+		return errors.New("collector unavailable")
+	})
+	q.onFailure = func(_ exporterTarget, err error) { failed = err }
+
+	// The second attempt succeeds.
+	q.enqueue(queueTraces(1))
+	q.start()
+	select {
+	case <-exported:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the retry never reached the collector")
+	}
+	if calls.Load() != 2 || q.dropped.Load() != 0 {
+		t.Fatalf("after a retry: calls %d, dropped %d", calls.Load(), q.dropped.Load())
+	}
+
+	// The next batch fails all three attempts.
+	q.enqueue(queueTraces(1))
+	q.close()
+	<-q.done
+	if calls.Load() != 5 || q.dropped.Load() != 2 || failed == nil {
+		t.Errorf("after exhausting retries: calls %d, dropped %d, failure %v", calls.Load(), q.dropped.Load(), failed)
+	}
+}
+
+func TestExportQueue_StopAbandonsRetries(t *testing.T) {
+	q := testQueue(4, 4, func([]*tracker.Trace) error { return errors.New("down") })
+	q.backoff = Backoff{Initial: time.Hour, Max: time.Hour, MaxAttempts: 10}
+	q.enqueue(queueTraces(2))
+	q.start()
+	q.close()
+	q.stop()
+	select {
+	case <-q.done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stop did not cut the retry wait short")
+	}
+	if got := q.dropped.Load(); got != 4 {
+		t.Errorf("dropped = %d, want 4", got)
+	}
+}
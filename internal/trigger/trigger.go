@@ -0,0 +1,196 @@
+// Package trigger parses and evaluates capture-trigger conditions such as
+// "error_rate>5% for 30s". Until its condition fires, podtrace only feeds
+// events into a cheap rolling aggregate; full capture starts once it does.
+package trigger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+// SampleWindow is the trailing window each metric is computed over.
+const SampleWindow = 5 * time.Second
+
+// Metric is an aggregate a trigger condition compares against.
+type Metric string
+
+const (
+	// MetricErrorRate is the percentage of events that carry an error.
+	MetricErrorRate Metric = "error_rate"
+	// MetricErrors is errored events per second.
+	MetricErrors Metric = "errors"
+	// MetricEventRate is events per second.
+	MetricEventRate Metric = "event_rate"
+	// MetricLatencyAvg is the mean event latency in milliseconds.
+	MetricLatencyAvg Metric = "latency_avg"
+)
+
+var conditionRe = regexp.MustCompile(`^\s*([a-z_]+)\s*(>=|<=|>|<)\s*([0-9]*\.?[0-9]+)\s*(%|ms|/s)?\s*(?:for\s+(\S+))?\s*$`)
+
+// Condition is a parsed trigger expression.
+type Condition struct {
+	Metric    Metric
+	Op        string
+	Threshold float64
+	For       time.Duration
+}
+
+// Parse parses "<metric><op><value>[unit] [for <duration>]", for example
+// "error_rate>5% for 30s", "latency_avg>=200ms" or "event_rate>1000/s for 1m".
+// Without "for" the condition fires on the first sample that satisfies it.
+func Parse(expr string) (*Condition, error) {
+	m := conditionRe.FindStringSubmatch(strings.ToLower(expr))
+	if m == nil {
+		return nil, fmt.Errorf("invalid trigger %q: expected <metric><op><value> [for <duration>], e.g. \"error_rate>5%% for 30s\"", expr)
+	}
+	c := &Condition{Metric: Metric(m[1]), Op: m[2]}
+	want := ""
+	switch c.Metric {
+	case MetricErrorRate:
+		want = "%"
+	case MetricErrors, MetricEventRate:
+		want = "/s"
+	case MetricLatencyAvg:
+		want = "ms"
+	default:
+		return nil, fmt.Errorf("invalid trigger %q: unknown metric %q (use error_rate, errors, event_rate or latency_avg)", expr, m[1])
+	}
+	if m[4] != "" && m[4] != want {
+		return nil, fmt.Errorf("invalid trigger %q: %s is measured in %s, not %s", expr, c.Metric, want, m[4])
+	}
+	v, err := strconv.ParseFloat(m[3], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid trigger %q: %w", expr, err)
+	}
+	c.Threshold = v
+	if m[5] != "" {
+		d, err := time.ParseDuration(m[5])
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("invalid trigger %q: bad duration %q", expr, m[5])
+		}
+		c.For = d
+	}
+	return c, nil
+}
+
+// String renders the condition in the syntax Parse accepts.
+func (c *Condition) String() string {
+	unit := map[Metric]string{MetricErrorRate: "%", MetricErrors: "/s", MetricEventRate: "/s", MetricLatencyAvg: "ms"}[c.Metric]
+	s := fmt.Sprintf("%s%s%s%s", c.Metric, c.Op, strconv.FormatFloat(c.Threshold, 'f', -1, 64), unit)
+	if c.For > 0 {
+		s += " for " + c.For.String()
+	}
+	return s
+}
+
+func (c *Condition) holds(v float64) bool {
+	switch c.Op {
+	case ">":
+		return v > c.Threshold
+	case ">=":
+		return v >= c.Threshold
+	case "<":
+		return v < c.Threshold
+	default:
+		return v <= c.Threshold
+	}
+}
+
+type bucket struct {
+	sec        int64
+	events     uint64
+	errors     uint64
+	latencySum time.Duration
+}
+
+// Evaluator keeps one counter bucket per second of SampleWindow. Observe is
+// O(1) and allocation-free, so it is cheap enough to run on every event while
+// podtrace waits for the condition.
+type Evaluator struct {
+	cond    *Condition
+	buckets [int(SampleWindow / time.Second)]bucket
+	since   time.Time
+	fired   bool
+}
+
+// NewEvaluator returns an Evaluator for c.
+func NewEvaluator(c *Condition) *Evaluator {
+	return &Evaluator{cond: c}
+}
+
+// Condition returns the condition being evaluated.
+func (ev *Evaluator) Condition() *Condition { return ev.cond }
+
+// Observe counts e in the bucket for now.
+func (ev *Evaluator) Observe(e *events.Event, now time.Time) {
+	if e == nil {
+		return
+	}
+	sec := now.Unix()
+	b := &ev.buckets[sec%int64(len(ev.buckets))]
+	if b.sec != sec {
+		*b = bucket{sec: sec}
+	}
+	b.events++
+	if e.IsError() {
+		b.errors++
+	}
+	b.latencySum += e.Latency()
+}
+
+// Value computes the condition's metric over the trailing SampleWindow.
+func (ev *Evaluator) Value(now time.Time) float64 {
+	var n, errs uint64
+	var lat time.Duration
+	oldest := now.Unix() - int64(len(ev.buckets)) + 1
+	for _, b := range ev.buckets {
+		if b.sec < oldest || b.sec > now.Unix() {
+			continue
+		}
+		n += b.events
+		errs += b.errors
+		lat += b.latencySum
+	}
+	secs := SampleWindow.Seconds()
+	switch ev.cond.Metric {
+	case MetricErrorRate:
+		if n == 0 {
+			return 0
+		}
+		return float64(errs) / float64(n) * 100
+	case MetricErrors:
+		return float64(errs) / secs
+	case MetricEventRate:
+		return float64(n) / secs
+	default:
+		if n == 0 {
+			return 0
+		}
+		return float64(lat) / float64(n) / float64(time.Millisecond)
+	}
+}
+
+// Check samples the metric at now and reports whether the condition has held
+// for at least Condition.For. Once it fires it stays fired.
+func (ev *Evaluator) Check(now time.Time) (float64, bool) {
+	v := ev.Value(now)
+	if ev.fired {
+		return v, true
+	}
+	if !ev.cond.holds(v) {
+		ev.since = time.Time{}
+		return v, false
+	}
+	if ev.since.IsZero() {
+		ev.since = now
+	}
+	if now.Sub(ev.since) >= ev.cond.For {
+		ev.fired = true
+	}
+	return v, ev.fired
+}
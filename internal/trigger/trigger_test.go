@@ -0,0 +1,128 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/podtrace/podtrace/internal/events"
+)
+
+func TestParse(t *testing.T) {
+	cases := []struct {
+		expr string
+		want Condition
+	}{
+		{"error_rate>5% for 30s", Condition{MetricErrorRate, ">", 5, 30 * time.Second}},
+		{"latency_avg >= 250ms", Condition{MetricLatencyAvg, ">=", 250, 0}},
+		{"EVENT_RATE>1000/s for 1m", Condition{MetricEventRate, ">", 1000, time.Minute}},
+		{"errors<0.5", Condition{MetricErrors, "<", 0.5, 0}},
+	}
+	for _, tc := range cases {
+		got, err := Parse(tc.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.expr, err)
+			continue
+		}
+		if *got != tc.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tc.expr, *got, tc.want)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"error_rate",
+		"cpu>5%",
+		"error_rate>5ms",
+		"error_rate>5% for soon",
+		"error_rate>5% during 30s",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) should fail", expr)
+		}
+	}
+}
+
+func TestCondition_StringRoundTrips(t *testing.T) {
+	c, err := Parse("error_rate>=2.5% for 1m30s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String(); got != "error_rate>=2.5% for 1m30s" {
+		t.Errorf("String() = %q", got)
+	}
+	again, err := Parse(c.String())
+	if err != nil || *again != *c {
+		t.Errorf("String() output does not parse back: %v %+v", err, again)
+	}
+}
+
+func TestEvaluator_FiresOnlyAfterConditionHoldsForDuration(t *testing.T) {
+	c, _ := Parse("error_rate>50% for 3s")
+	ev := NewEvaluator(c)
+	start := time.Unix(1_700_000_000, 0)
+	ok := &events.Event{Type: events.EventDNS}
+	bad := &events.Event{Type: events.EventDNS, Error: 3}
+
+	ev.Observe(ok, start)
+	if _, fired := ev.Check(start); fired {
+		t.Fatal("fired on healthy traffic")
+	}
+
+	var fired bool
+	var at time.Time
+	for i := 1; i <= 10 && !fired; i++ {
+		now := start.Add(time.Duration(i) * time.Second)
+		for j := 0; j < 5; j++ {
+			ev.Observe(bad, now)
+		}
+		_, fired = ev.Check(now)
+		at = now
+	}
+	if !fired {
+		t.Fatal("trigger never fired")
+	}
+	// The condition first holds at +1s, so it must hold through +4s.
+	if got := at.Sub(start); got != 4*time.Second {
+		t.Errorf("fired after %v, want 4s", got)
+	}
+	if _, still := ev.Check(at.Add(time.Hour)); !still {
+		t.Error("a fired trigger must stay fired")
+	}
+}
+
+func TestEvaluator_ResetsWhenConditionBreaks(t *testing.T) {
+	c, _ := Parse("event_rate>1/s for 2s")
+	ev := NewEvaluator(c)
+	now := time.Unix(1_700_000_000, 0)
+	for i := 0; i < 10; i++ {
+		ev.Observe(&events.Event{}, now)
+	}
+	if _, fired := ev.Check(now); fired {
+		t.Fatal("fired before the for-duration elapsed")
+	}
+	// Six seconds of silence drain the window and break the streak.
+	now = now.Add(6 * time.Second)
+	if v, fired := ev.Check(now); fired || v != 0 {
+		t.Fatalf("expected drained window, got value=%v fired=%v", v, fired)
+	}
+	for i := 0; i < 10; i++ {
+		ev.Observe(&events.Event{}, now)
+	}
+	if _, fired := ev.Check(now.Add(time.Second)); fired {
+		t.Error("streak should restart after the condition broke")
+	}
+}
+
+func TestEvaluator_LatencyAvg(t *testing.T) {
+	c, _ := Parse("latency_avg>100ms")
+	ev := NewEvaluator(c)
+	now := time.Unix(1_700_000_000, 0)
+	ev.Observe(&events.Event{LatencyNS: uint64(50 * time.Millisecond)}, now)
+	ev.Observe(&events.Event{LatencyNS: uint64(250 * time.Millisecond)}, now)
+	v, fired := ev.Check(now)
+	if v != 150 || !fired {
+		t.Errorf("latency_avg = %v fired=%v, want 150 and fired", v, fired)
+	}
+}
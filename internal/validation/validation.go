@@ -68,8 +68,8 @@ func ValidateExportFormat(format string) error {
 		return fmt.Errorf("export format exceeds maximum length of %d characters", maxExportFormatLength)
 	}
 	format = strings.ToLower(format)
-	if format != "json" && format != "csv" {
-		return fmt.Errorf("export format must be 'json' or 'csv'")
+	if format != "json" && format != "csv" && format != "proto" && format != "sqlite" {
+		return fmt.Errorf("export format must be 'json', 'csv', 'proto' or 'sqlite'")
 	}
 	return nil
 }
@@ -100,6 +100,18 @@ func ValidateEventFilter(filter string) error {
 	return nil
 }
 
+// ValidateVerbosity accepts the --verbosity output tiers; empty keeps the
+// default report output.
+func ValidateVerbosity(verbosity string) error {
+	switch strings.ToLower(strings.TrimSpace(verbosity)) {
+	case "", config.VerbosityEvents, config.VerbosityAnomalies, config.VerbosityIssues:
+		return nil
+	default:
+		return fmt.Errorf("invalid verbosity: %s (valid: %s, %s, %s)", verbosity,
+			config.VerbosityEvents, config.VerbosityAnomalies, config.VerbosityIssues)
+	}
+}
+
 func ValidatePID(pid uint32) bool {
 	return pid > 0 && pid < 4194304
 }
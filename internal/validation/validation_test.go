@@ -98,6 +98,8 @@ func TestValidateExportFormat(t *testing.T) {
 		{"valid csv", "csv", false},
 		{"valid JSON uppercase", "JSON", false},
 		{"valid CSV uppercase", "CSV", false},
+		{"valid proto", "proto", false},
+		{"valid sqlite", "sqlite", false},
 		{"empty (allowed)", "", false},
 		{"invalid format", "xml", true},
 		{"invalid format", "yaml", true},
@@ -114,6 +116,30 @@ func TestValidateExportFormat(t *testing.T) {
 	}
 }
 
+func TestValidateVerbosity(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"empty (default report)", "", false},
+		{"events", "events", false},
+		{"anomalies", "anomalies", false},
+		{"issues", "issues", false},
+		{"case insensitive", "Issues", false},
+		{"invalid", "chatty", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateVerbosity(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateVerbosity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
 func TestValidateEventFilter(t *testing.T) {
 	tests := []struct {
 		name    string
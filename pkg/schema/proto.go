@@ -0,0 +1,389 @@
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// The binary format is defined by podtrace.proto and encoded here with
+// protowire, so that neither podtrace nor a Go consumer needs generated
+// code. Consumers in other languages generate theirs from the .proto.
+
+//go:embed podtrace.proto
+var protoDefinition []byte
+
+// ProtoDefinition returns podtrace.proto, the definition of the binary
+// export format.
+func ProtoDefinition() []byte {
+	return bytes.Clone(protoDefinition)
+}
+
+// maxRecordSize bounds one record read from a stream; an event is well
+// under a kilobyte.
+const maxRecordSize = 16 << 20
+
+// Field numbers, as in podtrace.proto.
+const (
+	recordEvent   protowire.Number = 1
+	recordSummary protowire.Number = 2
+
+	eventSchemaVersion protowire.Number = 1
+	eventTime          protowire.Number = 2
+	eventType          protowire.Number = 3
+	eventPID           protowire.Number = 4
+	eventProcess       protowire.Number = 5
+	eventTarget        protowire.Number = 6
+	eventLatencyNS     protowire.Number = 7
+	eventError         protowire.Number = 8
+	eventBytes         protowire.Number = 9
+	eventDetails       protowire.Number = 10
+	eventNamespace     protowire.Number = 11
+	eventPod           protowire.Number = 12
+	eventPodUID        protowire.Number = 13
+	eventNode          protowire.Number = 14
+	eventContainer     protowire.Number = 15
+	eventRuntime       protowire.Number = 16
+	eventRuntimeVer    protowire.Number = 17
+
+	summarySchemaVersion   protowire.Number = 1
+	summaryTotalEvents     protowire.Number = 2
+	summaryEventsPerSecond protowire.Number = 3
+	summaryStart           protowire.Number = 4
+	summaryEnd             protowire.Number = 5
+	summaryPotentialIssues protowire.Number = 6
+)
+
+// Summary is the summary section of a diagnose report.
+type Summary struct {
+	SchemaVersion   string
+	TotalEvents     uint64
+	EventsPerSecond float64
+	Start, End      time.Time
+	PotentialIssues []string
+}
+
+// Record is one record of a binary stream: either an Event or a Summary.
+type Record struct {
+	Event   *Event
+	Summary *Summary
+}
+
+// ProtoWriter writes Records in the delimited binary format.
+type ProtoWriter struct {
+	w        io.Writer
+	msg, buf []byte
+}
+
+// NewProtoWriter writes records to w, one Write call each.
+func NewProtoWriter(w io.Writer) *ProtoWriter {
+	return &ProtoWriter{w: w}
+}
+
+// WriteEvent writes e as an event record.
+func (pw *ProtoWriter) WriteEvent(e Event) error {
+	pw.msg = appendEvent(pw.msg[:0], e)
+	return pw.write(recordEvent)
+}
+
+// WriteSummary writes s as a summary record.
+func (pw *ProtoWriter) WriteSummary(s Summary) error {
+	pw.msg = appendSummary(pw.msg[:0], s)
+	return pw.write(recordSummary)
+}
+
+func (pw *ProtoWriter) write(field protowire.Number) error {
+	size := protowire.SizeTag(field) + protowire.SizeBytes(len(pw.msg))
+	pw.buf = protowire.AppendVarint(pw.buf[:0], uint64(size))
+	pw.buf = protowire.AppendTag(pw.buf, field, protowire.BytesType)
+	pw.buf = protowire.AppendBytes(pw.buf, pw.msg)
+	_, err := pw.w.Write(pw.buf)
+	return err
+}
+
+func appendEvent(b []byte, e Event) []byte {
+	b = appendString(b, eventSchemaVersion, e.SchemaVersion)
+	if ts, err := e.Timestamp(); err == nil {
+		b = appendVarint(b, eventTime, uint64(ts.UnixNano()))
+	}
+	b = appendString(b, eventType, e.Type)
+	b = appendVarint(b, eventPID, uint64(e.PID))
+	b = appendString(b, eventProcess, e.Process)
+	b = appendString(b, eventTarget, e.Target)
+	b = appendVarint(b, eventLatencyNS, e.LatencyNS)
+	b = appendVarint(b, eventError, protowire.EncodeZigZag(int64(e.Error)))
+	b = appendVarint(b, eventBytes, e.Bytes)
+	b = appendString(b, eventDetails, e.Details)
+	b = appendString(b, eventNamespace, e.Namespace)
+	b = appendString(b, eventPod, e.Pod)
+	b = appendString(b, eventPodUID, e.PodUID)
+	b = appendString(b, eventNode, e.Node)
+	b = appendString(b, eventContainer, e.Container)
+	b = appendString(b, eventRuntime, e.ContainerRuntime)
+	return appendString(b, eventRuntimeVer, e.ContainerRuntimeVersion)
+}
+
+func appendSummary(b []byte, s Summary) []byte {
+	b = appendString(b, summarySchemaVersion, s.SchemaVersion)
+	b = appendVarint(b, summaryTotalEvents, s.TotalEvents)
+	if s.EventsPerSecond != 0 {
+		b = protowire.AppendTag(b, summaryEventsPerSecond, protowire.Fixed64Type)
+		b = protowire.AppendFixed64(b, math.Float64bits(s.EventsPerSecond))
+	}
+	if !s.Start.IsZero() {
+		b = appendVarint(b, summaryStart, uint64(s.Start.UnixNano()))
+	}
+	if !s.End.IsZero() {
+		b = appendVarint(b, summaryEnd, uint64(s.End.UnixNano()))
+	}
+	for _, issue := range s.PotentialIssues {
+		b = protowire.AppendTag(b, summaryPotentialIssues, protowire.BytesType)
+		b = protowire.AppendString(b, issue)
+	}
+	return b
+}
+
+// appendString and appendVarint leave out zero values, as proto3 does.
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// ProtoReader reads Records in the delimited binary format.
+type ProtoReader struct {
+	r   *bufio.Reader
+	buf []byte
+}
+
+// NewProtoReader reads records from r.
+func NewProtoReader(r io.Reader) *ProtoReader {
+	return &ProtoReader{r: bufio.NewReader(r)}
+}
+
+// Next returns the next record, or io.EOF after the last one. Records of a
+// kind this build does not know are skipped.
+func (pr *ProtoReader) Next() (Record, error) {
+	for {
+		size, err := binary.ReadUvarint(pr.r)
+		if err == io.EOF {
+			return Record{}, io.EOF
+		}
+		if err != nil {
+			return Record{}, fmt.Errorf("schema: read record size: %w", err)
+		}
+		if size > maxRecordSize {
+			return Record{}, fmt.Errorf("schema: record of %d bytes exceeds %d", size, maxRecordSize)
+		}
+		if cap(pr.buf) < int(size) {
+			pr.buf = make([]byte, size)
+		}
+		pr.buf = pr.buf[:size]
+		if _, err := io.ReadFull(pr.r, pr.buf); err != nil {
+			return Record{}, fmt.Errorf("schema: truncated record: %w", err)
+		}
+		rec, err := decodeRecord(pr.buf)
+		if err != nil || rec.Event != nil || rec.Summary != nil {
+			return rec, err
+		}
+	}
+}
+
+func decodeRecord(msg []byte) (Record, error) {
+	var rec Record
+	err := fields(msg, func(num protowire.Number, typ protowire.Type, raw []byte) error {
+		switch num {
+		case recordEvent:
+			v, err := bytesField(num, typ, raw)
+			if err != nil {
+				return err
+			}
+			e, err := decodeEvent(v)
+			if err != nil {
+				return err
+			}
+			rec = Record{Event: &e}
+		case recordSummary:
+			v, err := bytesField(num, typ, raw)
+			if err != nil {
+				return err
+			}
+			s, err := decodeSummary(v)
+			if err != nil {
+				return err
+			}
+			rec = Record{Summary: &s}
+		}
+		return nil
+	})
+	return rec, err
+}
+
+func decodeEvent(msg []byte) (Event, error) {
+	var e Event
+	err := fields(msg, func(num protowire.Number, typ protowire.Type, raw []byte) error {
+		switch num {
+		case eventSchemaVersion, eventType, eventProcess, eventTarget, eventDetails,
+			eventNamespace, eventPod, eventPodUID, eventNode, eventContainer, eventRuntime, eventRuntimeVer:
+			v, err := bytesField(num, typ, raw)
+			if err != nil {
+				return err
+			}
+			switch num {
+			case eventSchemaVersion:
+				e.SchemaVersion = string(v)
+			case eventType:
+				e.Type = string(v)
+			case eventProcess:
+				e.Process = string(v)
+			case eventTarget:
+				e.Target = string(v)
+			case eventNamespace:
+				e.Namespace = string(v)
+			case eventPod:
+				e.Pod = string(v)
+			case eventPodUID:
+				e.PodUID = string(v)
+			case eventNode:
+				e.Node = string(v)
+			case eventContainer:
+				e.Container = string(v)
+			case eventRuntime:
+				e.ContainerRuntime = string(v)
+			case eventRuntimeVer:
+				e.ContainerRuntimeVersion = string(v)
+			default:
+				e.Details = string(v)
+			}
+		case eventTime, eventPID, eventLatencyNS, eventError, eventBytes:
+			v, err := varintField(num, typ, raw)
+			if err != nil {
+				return err
+			}
+			switch num {
+			case eventTime:
+				e.Time = time.Unix(0, int64(v)).UTC().Format(time.RFC3339Nano)
+			case eventPID:
+				e.PID = uint32(v)
+			case eventLatencyNS:
+				e.LatencyNS = v
+			case eventError:
+				e.Error = int32(protowire.DecodeZigZag(v))
+			default:
+				e.Bytes = v
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return Event{}, fmt.Errorf("schema: decode event: %w", err)
+	}
+	if e.SchemaVersion != CurrentVersion {
+		return Event{}, unsupported(e.SchemaVersion)
+	}
+	e.LatencyMS = float64(e.LatencyNS) / float64(time.Millisecond)
+	return e, nil
+}
+
+func decodeSummary(msg []byte) (Summary, error) {
+	var s Summary
+	err := fields(msg, func(num protowire.Number, typ protowire.Type, raw []byte) error {
+		switch num {
+		case summarySchemaVersion, summaryPotentialIssues:
+			v, err := bytesField(num, typ, raw)
+			if err != nil {
+				return err
+			}
+			if num == summarySchemaVersion {
+				s.SchemaVersion = string(v)
+			} else {
+				s.PotentialIssues = append(s.PotentialIssues, string(v))
+			}
+		case summaryTotalEvents, summaryStart, summaryEnd:
+			v, err := varintField(num, typ, raw)
+			if err != nil {
+				return err
+			}
+			switch num {
+			case summaryTotalEvents:
+				s.TotalEvents = v
+			case summaryStart:
+				s.Start = time.Unix(0, int64(v)).UTC()
+			default:
+				s.End = time.Unix(0, int64(v)).UTC()
+			}
+		case summaryEventsPerSecond:
+			if typ != protowire.Fixed64Type {
+				return wireTypeError(num, typ)
+			}
+			v, _ := protowire.ConsumeFixed64(raw)
+			s.EventsPerSecond = math.Float64frombits(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return Summary{}, fmt.Errorf("schema: decode summary: %w", err)
+	}
+	if s.SchemaVersion != CurrentVersion {
+		return Summary{}, unsupported(s.SchemaVersion)
+	}
+	return s, nil
+}
+
+// fields calls fn with the number, wire type and raw value of each field
+// of msg, unknown ones included.
+func fields(msg []byte, fn func(num protowire.Number, typ protowire.Type, raw []byte) error) error {
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		msg = msg[n:]
+		m := protowire.ConsumeFieldValue(num, typ, msg)
+		if m < 0 {
+			return protowire.ParseError(m)
+		}
+		if err := fn(num, typ, msg[:m]); err != nil {
+			return err
+		}
+		msg = msg[m:]
+	}
+	return nil
+}
+
+func bytesField(num protowire.Number, typ protowire.Type, raw []byte) ([]byte, error) {
+	if typ != protowire.BytesType {
+		return nil, wireTypeError(num, typ)
+	}
+	v, _ := protowire.ConsumeBytes(raw)
+	return v, nil
+}
+
+func varintField(num protowire.Number, typ protowire.Type, raw []byte) (uint64, error) {
+	if typ != protowire.VarintType {
+		return 0, wireTypeError(num, typ)
+	}
+	v, _ := protowire.ConsumeVarint(raw)
+	return v, nil
+}
+
+func wireTypeError(num protowire.Number, typ protowire.Type) error {
+	return fmt.Errorf("unexpected wire type %d for field %d", typ, num)
+}
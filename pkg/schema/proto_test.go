@@ -0,0 +1,236 @@
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+func sampleEvent() Event {
+	return Event{
+		SchemaVersion: CurrentVersion,
+		Time:          "2026-10-16T14:22:33.123456789Z",
+		Type:          "NET_CONNECT",
+		PID:           4242,
+		Process:       "api",
+		Target:        "10.0.0.7:5432",
+		LatencyNS:     1_234_567,
+		LatencyMS:     1.234567,
+		Error:         -111,
+		Bytes:         512,
+		Details:       "retry",
+
+		Namespace:               "production",
+		Pod:                     "api-7d9f",
+		PodUID:                  "0f3c6a52-1b7e-4c1d-9a8e-5d2f4b6c7e81",
+		Node:                    "node-3",
+		Container:               "api",
+		ContainerRuntime:        "containerd",
+		ContainerRuntimeVersion: "v1.7.13",
+	}
+}
+
+func TestProtoRoundTrip(t *testing.T) {
+	summary := Summary{
+		SchemaVersion:   CurrentVersion,
+		TotalEvents:     2,
+		EventsPerSecond: 0.5,
+		Start:           time.Date(2026, 10, 16, 14, 22, 0, 0, time.UTC),
+		End:             time.Date(2026, 10, 16, 14, 22, 4, 0, time.UTC),
+		PotentialIssues: []string{"high error rate", "slow DNS"},
+	}
+	var buf bytes.Buffer
+	w := NewProtoWriter(&buf)
+	if err := w.WriteSummary(summary); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := w.WriteEvent(sampleEvent()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := NewProtoReader(&buf)
+	rec, err := r.Next()
+	if err != nil || rec.Summary == nil {
+		t.Fatalf("first record = %+v, %v; want the summary", rec, err)
+	}
+	got := *rec.Summary
+	if got.TotalEvents != 2 || got.EventsPerSecond != 0.5 || !got.Start.Equal(summary.Start) ||
+		!got.End.Equal(summary.End) || strings.Join(got.PotentialIssues, "|") != "high error rate|slow DNS" {
+		t.Errorf("summary = %+v", got)
+	}
+	for i := 0; i < 2; i++ {
+		rec, err := r.Next()
+		if err != nil || rec.Event == nil {
+			t.Fatalf("record %d = %+v, %v; want an event", i+2, rec, err)
+		}
+		if *rec.Event != sampleEvent() {
+			t.Errorf("event = %+v, want %+v", *rec.Event, sampleEvent())
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("after the last record: %v, want io.EOF", err)
+	}
+}
+
+func TestProtoSmallerThanJSON(t *testing.T) {
+	var pb, js bytes.Buffer
+	w := NewProtoWriter(&pb)
+	enc := json.NewEncoder(&js)
+	for i := 0; i < 100; i++ {
+		e := sampleEvent()
+		e.Details, e.Process = "", ""
+		_ = w.WriteEvent(e)
+		_ = enc.Encode(e)
+	}
+	if pb.Len()*2 > js.Len() {
+		t.Errorf("proto stream is %d bytes, JSON lines %d; want at most half", pb.Len(), js.Len())
+	}
+}
+
+func TestProtoReaderErrors(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewProtoWriter(&buf)
+	e := sampleEvent()
+	e.SchemaVersion = "v9"
+	_ = w.WriteEvent(e)
+	if _, err := NewProtoReader(&buf).Next(); !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("newer version: %v, want ErrUnsupportedVersion", err)
+	}
+
+	buf.Reset()
+	_ = NewProtoWriter(&buf).WriteEvent(sampleEvent())
+	truncated := buf.Bytes()[:buf.Len()-3]
+	if _, err := NewProtoReader(bytes.NewReader(truncated)).Next(); err == nil || err == io.EOF {
+		t.Errorf("truncated record: %v, want an error", err)
+	}
+
+	huge := protowire.AppendVarint(nil, maxRecordSize+1)
+	if _, err := NewProtoReader(bytes.NewReader(huge)).Next(); err == nil || !strings.Contains(err.Error(), "exceeds") {
+		t.Errorf("oversized record: %v", err)
+	}
+}
+
+func TestProtoReaderSkipsUnknown(t *testing.T) {
+	var buf bytes.Buffer
+	// A record of a kind added later (field 9), then an event carrying an
+	// extra field (99).
+	future := protowire.AppendTag(nil, 9, protowire.BytesType)
+	future = protowire.AppendString(future, "later")
+	buf.Write(protowire.AppendVarint(nil, uint64(len(future))))
+	buf.Write(future)
+
+	msg := appendEvent(nil, sampleEvent())
+	msg = protowire.AppendTag(msg, 99, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, 7)
+	rec := protowire.AppendTag(nil, recordEvent, protowire.BytesType)
+	rec = protowire.AppendBytes(rec, msg)
+	buf.Write(protowire.AppendVarint(nil, uint64(len(rec))))
+	buf.Write(rec)
+
+	got, err := NewProtoReader(&buf).Next()
+	if err != nil || got.Event == nil || *got.Event != sampleEvent() {
+		t.Errorf("Next = %+v, %v; want the event, unknown parts skipped", got, err)
+	}
+}
+
+// eventDescriptor builds the descriptors of podtrace.proto's Record and
+// Event by hand, to check the encoding against the protobuf runtime.
+func eventDescriptor(t *testing.T) (record, event protoreflect.MessageDescriptor) {
+	t.Helper()
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto {
+		return &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(num),
+			Type:   typ.Enum(),
+			Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		}
+	}
+	str, i64, u32, u64, s32 := descriptorpb.FieldDescriptorProto_TYPE_STRING, descriptorpb.FieldDescriptorProto_TYPE_INT64,
+		descriptorpb.FieldDescriptorProto_TYPE_UINT32, descriptorpb.FieldDescriptorProto_TYPE_UINT64, descriptorpb.FieldDescriptorProto_TYPE_SINT32
+	eventField := field("event", 1, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE)
+	eventField.TypeName = proto.String(".podtrace.schema.v1.Event")
+	fd, err := protodesc.NewFile(&descriptorpb.FileDescriptorProto{
+		Name:    proto.String("podtrace.proto"),
+		Package: proto.String("podtrace.schema.v1"),
+		Syntax:  proto.String("proto3"),
+		MessageType: []*descriptorpb.DescriptorProto{
+			{Name: proto.String("Record"), Field: []*descriptorpb.FieldDescriptorProto{eventField}},
+			{Name: proto.String("Event"), Field: []*descriptorpb.FieldDescriptorProto{
+				field("schema_version", 1, str), field("time_unix_nano", 2, i64), field("type", 3, str),
+				field("pid", 4, u32), field("process", 5, str), field("target", 6, str),
+				field("latency_ns", 7, u64), field("error", 8, s32), field("bytes", 9, u64), field("details", 10, str),
+				field("namespace", 11, str), field("pod", 12, str), field("pod_uid", 13, str), field("node", 14, str),
+				field("container", 15, str), field("container_runtime", 16, str), field("container_runtime_version", 17, str),
+			}},
+		},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fd.Messages().ByName("Record"), fd.Messages().ByName("Event")
+}
+
+func TestProtoMatchesProtobufRuntime(t *testing.T) {
+	recordDesc, eventDesc := eventDescriptor(t)
+	want := sampleEvent()
+
+	// Written by the protobuf runtime, read by ProtoReader.
+	ev := dynamicpb.NewMessage(eventDesc)
+	set := func(name string, v protoreflect.Value) { ev.Set(eventDesc.Fields().ByName(protoreflect.Name(name)), v) }
+	ts, _ := want.Timestamp()
+	set("schema_version", protoreflect.ValueOfString(want.SchemaVersion))
+	set("time_unix_nano", protoreflect.ValueOfInt64(ts.UnixNano()))
+	set("type", protoreflect.ValueOfString(want.Type))
+	set("pid", protoreflect.ValueOfUint32(want.PID))
+	set("process", protoreflect.ValueOfString(want.Process))
+	set("target", protoreflect.ValueOfString(want.Target))
+	set("latency_ns", protoreflect.ValueOfUint64(want.LatencyNS))
+	set("error", protoreflect.ValueOfInt32(want.Error))
+	set("bytes", protoreflect.ValueOfUint64(want.Bytes))
+	set("details", protoreflect.ValueOfString(want.Details))
+	set("namespace", protoreflect.ValueOfString(want.Namespace))
+	set("pod", protoreflect.ValueOfString(want.Pod))
+	set("pod_uid", protoreflect.ValueOfString(want.PodUID))
+	set("node", protoreflect.ValueOfString(want.Node))
+	set("container", protoreflect.ValueOfString(want.Container))
+	set("container_runtime", protoreflect.ValueOfString(want.ContainerRuntime))
+	set("container_runtime_version", protoreflect.ValueOfString(want.ContainerRuntimeVersion))
+	rec := dynamicpb.NewMessage(recordDesc)
+	rec.Set(recordDesc.Fields().ByName("event"), protoreflect.ValueOfMessage(ev))
+	var buf bytes.Buffer
+	if _, err := protodelim.MarshalTo(&buf, rec); err != nil {
+		t.Fatal(err)
+	}
+	got, err := NewProtoReader(&buf).Next()
+	if err != nil || got.Event == nil || *got.Event != want {
+		t.Fatalf("ProtoReader read %+v, %v; want %+v", got.Event, err, want)
+	}
+
+	// Written by ProtoWriter, read by the protobuf runtime.
+	buf.Reset()
+	if err := NewProtoWriter(&buf).WriteEvent(want); err != nil {
+		t.Fatal(err)
+	}
+	back := dynamicpb.NewMessage(recordDesc)
+	if err := protodelim.UnmarshalFrom(bufio.NewReader(&buf), back); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(back, rec) {
+		t.Errorf("protobuf runtime read %v, want %v", back, rec)
+	}
+}
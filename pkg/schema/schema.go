@@ -0,0 +1,163 @@
+// Package schema is the versioned public format of the events and reports
+// podtrace exports: the JSON-lines capture files (--trigger-record, a
+// bundle's capture.jsonl), the --export json report, the --export csv
+// event table and the binary --export proto stream (proto.go). Every one of
+// them carries its schema version, and the
+// decoders here read the current version and every earlier one, so a
+// pipeline built on them keeps working across podtrace releases.
+//
+// Within a version, fields are only added. A field is renamed, removed or
+// changes meaning only in a new version, which this package then also
+// decodes into the current types.
+package schema
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+const (
+	// CurrentVersion is the version podtrace writes.
+	CurrentVersion = "v1"
+	// LegacyVersion is what exports written before versioning decode as;
+	// they carry no version field.
+	LegacyVersion = "v0"
+)
+
+// Kinds of document a JSON Schema is published for.
+const (
+	KindEvent  = "event"
+	KindReport = "report"
+)
+
+//go:embed event.v1.json report.v1.json
+var schemas embed.FS
+
+// Event is one captured event, a line of a capture file.
+type Event struct {
+	// SchemaVersion is the version the line was written in. Decoding
+	// upgrades older lines to this layout but keeps their version here.
+	SchemaVersion string `json:"schemaVersion"`
+	// Time is when the event happened, RFC 3339 with nanoseconds.
+	Time    string `json:"time"`
+	Type    string `json:"type"`
+	PID     uint32 `json:"pid"`
+	Process string `json:"process,omitempty"`
+	Target  string `json:"target,omitempty"`
+	// LatencyNS is the exact latency; LatencyMS the same in milliseconds.
+	// v0 lines only had LatencyMS, so LatencyNS is derived from it.
+	LatencyNS uint64  `json:"latencyNs"`
+	LatencyMS float64 `json:"latencyMs"`
+	Error     int32   `json:"error,omitempty"`
+	Bytes     uint64  `json:"bytes,omitempty"`
+	Details   string  `json:"details,omitempty"`
+	// The pod the event came from, when it is known. PodUID tells a pod
+	// apart from an earlier one of the same name; ContainerRuntime and
+	// ContainerRuntimeVersion are what the node's CRI reports.
+	Namespace               string `json:"namespace,omitempty"`
+	Pod                     string `json:"pod,omitempty"`
+	PodUID                  string `json:"podUid,omitempty"`
+	Node                    string `json:"node,omitempty"`
+	Container               string `json:"container,omitempty"`
+	ContainerRuntime        string `json:"containerRuntime,omitempty"`
+	ContainerRuntimeVersion string `json:"containerRuntimeVersion,omitempty"`
+}
+
+// Timestamp parses Time.
+func (e Event) Timestamp() (time.Time, error) {
+	return time.Parse(time.RFC3339Nano, e.Time)
+}
+
+// DecodeEvent decodes one capture line of any supported version.
+func DecodeEvent(line []byte) (Event, error) {
+	var e Event
+	if err := json.Unmarshal(line, &e); err != nil {
+		return Event{}, fmt.Errorf("schema: decode event: %w", err)
+	}
+	switch e.SchemaVersion {
+	case "":
+		e.SchemaVersion = LegacyVersion
+		e.LatencyNS = uint64(e.LatencyMS * float64(time.Millisecond))
+	case CurrentVersion:
+	default:
+		return Event{}, unsupported(e.SchemaVersion)
+	}
+	return e, nil
+}
+
+// EventReader reads the events of a capture file.
+type EventReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+// NewEventReader reads capture lines from r.
+func NewEventReader(r io.Reader) *EventReader {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &EventReader{sc: sc}
+}
+
+// Next returns the next event, skipping blank lines, or io.EOF after the
+// last one.
+func (r *EventReader) Next() (Event, error) {
+	for r.sc.Scan() {
+		r.line++
+		line := bytes.TrimSpace(r.sc.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		e, err := DecodeEvent(line)
+		if err != nil {
+			return Event{}, fmt.Errorf("line %d: %w", r.line, err)
+		}
+		return e, nil
+	}
+	if err := r.sc.Err(); err != nil {
+		return Event{}, err
+	}
+	return Event{}, io.EOF
+}
+
+// ReportVersion returns the schema version of an --export json report,
+// LegacyVersion for one written before versioning, or an error for a
+// version this build does not read.
+func ReportVersion(report []byte) (string, error) {
+	var head struct {
+		SchemaVersion string `json:"schema_version"`
+	}
+	if err := json.Unmarshal(report, &head); err != nil {
+		return "", fmt.Errorf("schema: decode report: %w", err)
+	}
+	switch head.SchemaVersion {
+	case "":
+		return LegacyVersion, nil
+	case CurrentVersion:
+		return CurrentVersion, nil
+	}
+	return "", unsupported(head.SchemaVersion)
+}
+
+// JSONSchema returns the JSON Schema (draft 2020-12) of the current version
+// of kind, KindEvent or KindReport.
+func JSONSchema(kind string) ([]byte, error) {
+	switch kind {
+	case KindEvent, KindReport:
+		return schemas.ReadFile(kind + "." + CurrentVersion + ".json")
+	}
+	return nil, fmt.Errorf("schema: unknown kind %q (use %s or %s)", kind, KindEvent, KindReport)
+}
+
+// ErrUnsupportedVersion is returned for a document written by a newer
+// podtrace.
+var ErrUnsupportedVersion = errors.New("schema: unsupported version")
+
+func unsupported(v string) error {
+	return fmt.Errorf("%w %q (this build understands %s and %s)", ErrUnsupportedVersion, v, LegacyVersion, CurrentVersion)
+}
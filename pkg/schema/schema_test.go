@@ -0,0 +1,147 @@
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeEventCurrentVersion(t *testing.T) {
+	line := `{"schemaVersion":"v1","time":"2026-10-16T14:22:33.123456789Z","type":"DNS","pid":42,"target":"db.svc","latencyNs":1500,"latencyMs":0.0015,"future":"ignored"}`
+	e, err := DecodeEvent([]byte(line))
+	if err != nil {
+		t.Fatalf("DecodeEvent: %v", err)
+	}
+	if e.SchemaVersion != CurrentVersion || e.Type != "DNS" || e.PID != 42 || e.LatencyNS != 1500 {
+		t.Errorf("unexpected event %+v", e)
+	}
+	ts, err := e.Timestamp()
+	if err != nil || ts.Nanosecond() != 123456789 {
+		t.Errorf("Timestamp = %v, %v", ts, err)
+	}
+}
+
+func TestDecodeEventLegacyVersion(t *testing.T) {
+	line := `{"time":"2026-10-16T14:22:33Z","type":"NET_CONNECT","pid":7,"latencyMs":12.5,"error":-111}`
+	e, err := DecodeEvent([]byte(line))
+	if err != nil {
+		t.Fatalf("DecodeEvent: %v", err)
+	}
+	if e.SchemaVersion != LegacyVersion {
+		t.Errorf("SchemaVersion = %q, want %q", e.SchemaVersion, LegacyVersion)
+	}
+	if want := uint64(12500 * time.Microsecond); e.LatencyNS != want {
+		t.Errorf("LatencyNS = %d, want %d derived from latencyMs", e.LatencyNS, want)
+	}
+	if e.Error != -111 {
+		t.Errorf("Error = %d", e.Error)
+	}
+}
+
+func TestDecodeEventRejectsNewerVersion(t *testing.T) {
+	_, err := DecodeEvent([]byte(`{"schemaVersion":"v9","type":"DNS"}`))
+	if !errors.Is(err, ErrUnsupportedVersion) {
+		t.Errorf("error = %v, want ErrUnsupportedVersion", err)
+	}
+	if _, err := DecodeEvent([]byte(`not json`)); err == nil {
+		t.Error("expected an error for a malformed line")
+	}
+}
+
+func TestEventReaderMixedVersions(t *testing.T) {
+	in := strings.Join([]string{
+		`{"time":"2026-10-16T14:22:33Z","type":"DNS","pid":1,"latencyMs":1}`,
+		``,
+		`{"schemaVersion":"v1","time":"2026-10-16T14:22:34Z","type":"DNS","pid":2,"latencyNs":2000000,"latencyMs":2}`,
+		`{"schemaVersion":"v2","type":"DNS"}`,
+	}, "\n")
+	r := NewEventReader(strings.NewReader(in))
+	var pids []uint32
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			t.Fatal("expected the v2 line to fail before EOF")
+		}
+		if err != nil {
+			if !errors.Is(err, ErrUnsupportedVersion) || !strings.Contains(err.Error(), "line 4") {
+				t.Errorf("error = %v, want unsupported version on line 4", err)
+			}
+			break
+		}
+		pids = append(pids, e.PID)
+	}
+	if len(pids) != 2 || pids[0] != 1 || pids[1] != 2 {
+		t.Errorf("decoded PIDs %v, want [1 2]", pids)
+	}
+}
+
+func TestReportVersion(t *testing.T) {
+	tests := []struct {
+		report  string
+		want    string
+		wantErr bool
+	}{
+		{report: `{"schema_version":"v1","summary":{}}`, want: CurrentVersion},
+		{report: `{"summary":{}}`, want: LegacyVersion},
+		{report: `{"schema_version":"v2"}`, wantErr: true},
+		{report: `[]`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ReportVersion([]byte(tt.report))
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ReportVersion(%s) = %q, %v", tt.report, got, err)
+		}
+	}
+}
+
+func TestJSONSchema(t *testing.T) {
+	for _, kind := range []string{KindEvent, KindReport} {
+		data, err := JSONSchema(kind)
+		if err != nil {
+			t.Fatalf("JSONSchema(%s): %v", kind, err)
+		}
+		var doc struct {
+			ID         string                     `json:"$id"`
+			Properties map[string]json.RawMessage `json:"properties"`
+		}
+		if err := json.Unmarshal(data, &doc); err != nil {
+			t.Fatalf("%s schema is not JSON: %v", kind, err)
+		}
+		if !strings.HasSuffix(doc.ID, kind+"."+CurrentVersion+".json") {
+			t.Errorf("%s schema $id = %q", kind, doc.ID)
+		}
+	}
+	if _, err := JSONSchema("bundle"); err == nil {
+		t.Error("expected an error for an unknown kind")
+	}
+}
+
+// TestEventSchemaCoversFields keeps event.v1.json in step with Event.
+func TestEventSchemaCoversFields(t *testing.T) {
+	data, err := JSONSchema(KindEvent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Properties map[string]json.RawMessage `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatal(err)
+	}
+	full, err := json.Marshal(Event{Process: "p", Target: "t", Error: 1, Bytes: 1, Details: "d"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(full, &fields); err != nil {
+		t.Fatal(err)
+	}
+	for name := range fields {
+		if _, ok := doc.Properties[name]; !ok {
+			t.Errorf("field %q is missing from event.v1.json", name)
+		}
+	}
+}
@@ -84,6 +84,13 @@ type CategoryGateable interface {
 	SetEnabledCategories(categories []string) error
 }
 
+// MapFillReporter is an optional capability a TracerBackend can implement
+// to report the share of max_entries in use of its growing BPF maps, by
+// map name.
+type MapFillReporter interface {
+	MapFill() map[string]float64
+}
+
 type ContainerUprobeTarget struct {
 	ContainerID string
 	PID         uint32